@@ -7,6 +7,7 @@ import (
 	"regexp"
 
 	"github.com/hashicorp/go-multierror"
+	"github.com/treeverse/lakefs/secrets"
 	"gopkg.in/yaml.v3"
 )
 
@@ -68,6 +69,28 @@ func (a *Action) Validate() error {
 	return nil
 }
 
+// ExpandSecrets returns a copy of the action with the references to secrets in the properties of
+// its hooks replaced by their values
+func (a *Action) ExpandSecrets(lookup secrets.LookupFunc) (*Action, error) {
+	expanded := *a
+	expanded.Hooks = make([]ActionHook, len(a.Hooks))
+	for i, hook := range a.Hooks {
+		expanded.Hooks[i] = hook
+		if hook.Properties == nil {
+			continue
+		}
+		expanded.Hooks[i].Properties = make(map[string]string, len(hook.Properties))
+		for k, v := range hook.Properties {
+			value, err := secrets.Expand(v, lookup)
+			if err != nil {
+				return nil, fmt.Errorf("hook %s property %s: %w", hook.ID, k, err)
+			}
+			expanded.Hooks[i].Properties[k] = value
+		}
+	}
+	return &expanded, nil
+}
+
 func (a *Action) Match(spec MatchSpec) (bool, error) {
 	// at least one matched event definition
 	var actionOn *ActionOn
@@ -0,0 +1,44 @@
+package actions
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+// MaxHookOutputSize limits the output kept for each hook run by FireAction
+const MaxHookOutputSize = 64 * 1024
+
+// HookResult is the outcome of running a single hook of an action
+type HookResult struct {
+	HookID string
+	Output string
+	Err    error
+}
+
+type hookOutputBuffer struct {
+	bytes.Buffer
+}
+
+func (b *hookOutputBuffer) OutputWrite(_ context.Context, _ string, reader io.Reader) error {
+	_, err := b.ReadFrom(io.LimitReader(reader, MaxHookOutputSize))
+	return err
+}
+
+// FireAction runs all hooks of action on event, regardless of whether the action matches it.
+// Used to test an action definition, a failing hook doesn't stop the following hooks.
+func FireAction(ctx context.Context, action *Action, runID string, event Event) []HookResult {
+	results := make([]HookResult, len(action.Hooks))
+	for i, ah := range action.Hooks {
+		results[i].HookID = ah.ID
+		hook, err := NewHook(action, ah)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		var output hookOutputBuffer
+		results[i].Err = hook.Run(ctx, runID, event, &output)
+		results[i].Output = output.String()
+	}
+	return results
+}
@@ -0,0 +1,75 @@
+package actions_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/treeverse/lakefs/actions"
+)
+
+func TestFireAction(t *testing.T) {
+	var (
+		received      actions.WebhookEventInfo
+		authorization string
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte("all good"))
+	})
+	mux.HandleFunc("/fail", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	action := &actions.Action{
+		Name: "test action",
+		On:   actions.OnEvents{PreCommit: &actions.ActionOn{}},
+		Hooks: []actions.ActionHook{
+			{ID: "hook_fail", Type: string(actions.HookTypeWebhook), Properties: map[string]string{"url": server.URL + "/fail"}},
+			{ID: "hook_ok", Type: string(actions.HookTypeWebhook), Properties: map[string]string{"url": server.URL + "/ok", "authorization": "Bearer ${secrets.TOKEN}"}},
+			{ID: "hook_no_url", Type: string(actions.HookTypeWebhook)},
+		},
+	}
+	event := actions.Event{
+		EventType:    actions.EventTypePreCommit,
+		EventTime:    time.Now(),
+		RepositoryID: "repo1",
+		BranchID:     "main",
+	}
+	action, err := action.ExpandSecrets(func(name string) (string, error) {
+		return "value-of-" + name, nil
+	})
+	if err != nil {
+		t.Fatalf("ExpandSecrets() error = %v", err)
+	}
+	results := actions.FireAction(context.Background(), action, "run1", event)
+	if len(results) != len(action.Hooks) {
+		t.Fatalf("FireAction() got %d results, expected %d", len(results), len(action.Hooks))
+	}
+	if !errors.Is(results[0].Err, actions.ErrWebhookRequestFailed) {
+		t.Errorf("hook %s err=%v, expected %s", results[0].HookID, results[0].Err, actions.ErrWebhookRequestFailed)
+	}
+	if results[1].Err != nil || results[1].Output != "all good" {
+		t.Errorf("hook %s err=%v, output=%s, expected success", results[1].HookID, results[1].Err, results[1].Output)
+	}
+	if received.RunID != "run1" || received.HookID != "hook_ok" || received.RepositoryID != "repo1" {
+		t.Errorf("webhook received unexpected event %+v", received)
+	}
+	if authorization != "Bearer value-of-TOKEN" {
+		t.Errorf("webhook received authorization '%s', expected the expanded secret", authorization)
+	}
+	if !errors.Is(results[2].Err, actions.ErrWebhookMissingURL) {
+		t.Errorf("hook %s err=%v, expected %s", results[2].HookID, results[2].Err, actions.ErrWebhookMissingURL)
+	}
+}
@@ -0,0 +1,204 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/treeverse/lakefs/db"
+)
+
+// Definition is a single version of an action stored for a repository, in addition to the
+// actions committed under the repository actions prefix.  Every change to a definition,
+// including enabling or disabling it, stores a new version.
+type Definition struct {
+	RepositoryID string    `db:"repository_id"`
+	Name         string    `db:"name"`
+	Version      int       `db:"version"`
+	Definition   string    `db:"definition"`
+	Enabled      bool      `db:"enabled"`
+	CreationDate time.Time `db:"creation_date"`
+}
+
+// Store manages the action definitions of repositories
+type Store interface {
+	// SetDefinition parses and stores data as a new version of the action it defines
+	SetDefinition(ctx context.Context, repositoryID string, data []byte) (*Definition, error)
+	// GetDefinition returns the latest version of the action name
+	GetDefinition(ctx context.Context, repositoryID, name string) (*Definition, error)
+	// ListDefinitions returns the latest version of each action in repositoryID, ordered by name
+	ListDefinitions(ctx context.Context, repositoryID string) ([]*Definition, error)
+	// ListDefinitionVersions returns all versions of the action name, newest first
+	ListDefinitionVersions(ctx context.Context, repositoryID, name string) ([]*Definition, error)
+	// SetDefinitionEnabled stores a new version of the action name enabled or disabled
+	SetDefinitionEnabled(ctx context.Context, repositoryID, name string, enabled bool) (*Definition, error)
+	// DeleteDefinition deletes all versions of the action name
+	DeleteDefinition(ctx context.Context, repositoryID, name string) error
+	// LoadActions returns the parsed enabled actions of repositoryID
+	LoadActions(ctx context.Context, repositoryID string) ([]*Action, error)
+}
+
+type store struct {
+	db db.Database
+}
+
+var ErrDefinitionNotFound = fmt.Errorf("action definition %w", db.ErrNotFound)
+
+func NewStore(adb db.Database) Store {
+	return &store{
+		db: adb,
+	}
+}
+
+const definitionFields = `repository_id, name, version, definition, enabled, creation_date`
+
+func getLatestDefinition(tx db.Tx, repositoryID, name string) (*Definition, error) {
+	var d Definition
+	err := tx.Get(&d, `SELECT `+definitionFields+`
+		FROM actions_definitions
+		WHERE repository_id = $1 AND name = $2
+		ORDER BY version DESC LIMIT 1`,
+		repositoryID, name)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, ErrDefinitionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func insertDefinitionVersion(tx db.Tx, repositoryID, name, definition string, enabled bool) (*Definition, error) {
+	var d Definition
+	err := tx.Get(&d, `INSERT INTO actions_definitions (`+definitionFields+`)
+		SELECT $1, $2, COALESCE(MAX(version), 0) + 1, $3, $4, $5
+		FROM actions_definitions WHERE repository_id = $1 AND name = $2
+		RETURNING `+definitionFields,
+		repositoryID, name, definition, enabled, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (s *store) SetDefinition(ctx context.Context, repositoryID string, data []byte) (*Definition, error) {
+	act, err := ParseAction(data)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		// new actions start enabled, updates keep the current state
+		enabled := true
+		latest, err := getLatestDefinition(tx, repositoryID, act.Name)
+		if err == nil {
+			enabled = latest.Enabled
+		} else if !errors.Is(err, ErrDefinitionNotFound) {
+			return nil, err
+		}
+		return insertDefinitionVersion(tx, repositoryID, act.Name, string(data), enabled)
+	}, db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.(*Definition), nil
+}
+
+func (s *store) GetDefinition(ctx context.Context, repositoryID, name string) (*Definition, error) {
+	res, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		return getLatestDefinition(tx, repositoryID, name)
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.(*Definition), nil
+}
+
+func (s *store) ListDefinitions(ctx context.Context, repositoryID string) ([]*Definition, error) {
+	res, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var definitions []*Definition
+		err := tx.Select(&definitions, `SELECT DISTINCT ON (name) `+definitionFields+`
+			FROM actions_definitions
+			WHERE repository_id = $1
+			ORDER BY name, version DESC`,
+			repositoryID)
+		return definitions, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.([]*Definition), nil
+}
+
+func (s *store) ListDefinitionVersions(ctx context.Context, repositoryID, name string) ([]*Definition, error) {
+	res, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var definitions []*Definition
+		err := tx.Select(&definitions, `SELECT `+definitionFields+`
+			FROM actions_definitions
+			WHERE repository_id = $1 AND name = $2
+			ORDER BY version DESC`,
+			repositoryID, name)
+		if err != nil {
+			return nil, err
+		}
+		if len(definitions) == 0 {
+			return nil, ErrDefinitionNotFound
+		}
+		return definitions, nil
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.([]*Definition), nil
+}
+
+func (s *store) SetDefinitionEnabled(ctx context.Context, repositoryID, name string, enabled bool) (*Definition, error) {
+	res, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		latest, err := getLatestDefinition(tx, repositoryID, name)
+		if err != nil {
+			return nil, err
+		}
+		if latest.Enabled == enabled {
+			return latest, nil
+		}
+		return insertDefinitionVersion(tx, repositoryID, name, latest.Definition, enabled)
+	}, db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.(*Definition), nil
+}
+
+func (s *store) DeleteDefinition(ctx context.Context, repositoryID, name string) error {
+	_, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		res, err := tx.Exec(`DELETE FROM actions_definitions WHERE repository_id = $1 AND name = $2`,
+			repositoryID, name)
+		if err != nil {
+			return nil, err
+		}
+		if res.RowsAffected() == 0 {
+			return nil, ErrDefinitionNotFound
+		}
+		return nil, nil
+	}, db.WithContext(ctx))
+	return err
+}
+
+func (s *store) LoadActions(ctx context.Context, repositoryID string) ([]*Action, error) {
+	definitions, err := s.ListDefinitions(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	var actions []*Action
+	for _, d := range definitions {
+		if !d.Enabled {
+			continue
+		}
+		act, err := ParseAction([]byte(d.Definition))
+		if err != nil {
+			return nil, fmt.Errorf("parsing action %s version %d: %w", d.Name, d.Version, err)
+		}
+		actions = append(actions, act)
+	}
+	return actions, nil
+}
@@ -14,7 +14,10 @@ type Webhook struct {
 	ID         string
 	ActionName string
 	URL        string
-	Timeout    time.Duration
+	// Authorization is sent as the Authorization header of requests, usually referencing a
+	// repository secret: "Bearer ${secrets.CI_TOKEN}"
+	Authorization string
+	Timeout       time.Duration
 }
 
 type WebhookEventInfo struct {
@@ -54,10 +57,11 @@ func NewWebhook(action *Action, h ActionHook) (Hook, error) {
 		requestTimeout = d
 	}
 	return &Webhook{
-		ID:         h.ID,
-		ActionName: action.Name,
-		Timeout:    requestTimeout,
-		URL:        webhookURL,
+		ID:            h.ID,
+		ActionName:    action.Name,
+		Timeout:       requestTimeout,
+		URL:           webhookURL,
+		Authorization: h.Properties["authorization"],
 	}, nil
 }
 
@@ -71,6 +75,9 @@ func (w *Webhook) Run(ctx context.Context, runID string, ed Event, writer Output
 	if err != nil {
 		return err
 	}
+	if w.Authorization != "" {
+		req.Header.Set("Authorization", w.Authorization)
+	}
 
 	client := &http.Client{
 		Timeout: w.Timeout,
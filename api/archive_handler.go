@@ -0,0 +1,179 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/swag"
+	"github.com/treeverse/lakefs/api/gen/models"
+	"github.com/treeverse/lakefs/api/gen/restapi/operations/objects"
+	"github.com/treeverse/lakefs/archive"
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/encryption"
+	"github.com/treeverse/lakefs/errcode"
+	"github.com/treeverse/lakefs/logging"
+	"github.com/treeverse/lakefs/permissions"
+)
+
+// MaxArchiveObjects is the maximum number of objects in an archive of objects under a prefix
+const MaxArchiveObjects = 10000
+
+var ErrTooManyArchiveObjects = fmt.Errorf("more than %d objects to archive", MaxArchiveObjects)
+
+// listArchiveEntries returns the entries archived under prefix of ref, read from one snapshot
+func listArchiveEntries(deps *Dependencies, repository, ref, prefix string) ([]*catalog.DBEntry, error) {
+	redacted := make(map[string]struct{})
+	redactions, err := deps.Cataloger.ListRedactions(deps.ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	for _, redaction := range redactions {
+		redacted[redaction.PhysicalAddress] = struct{}{}
+	}
+	var (
+		entries []*catalog.DBEntry
+		token   string
+	)
+	for {
+		page, nextToken, err := deps.Cataloger.ListEntriesWithToken(deps.ctx, repository, ref, prefix, token, "", MaxResultsPerPage)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range page {
+			if entry.DirectoryMarker || entry.LinkTarget != "" {
+				continue
+			}
+			if _, ok := redacted[entry.PhysicalAddress]; ok {
+				return nil, fmt.Errorf("%s redacted: %w", entry.Path, catalog.ErrInvalidValue)
+			}
+			entries = append(entries, entry)
+			if len(entries) > MaxArchiveObjects {
+				return nil, ErrTooManyArchiveObjects
+			}
+		}
+		if nextToken == "" {
+			return entries, nil
+		}
+		token = nextToken
+	}
+}
+
+// archiveFileName returns the name of path in an archive of the objects under prefix: its path
+// relative to the directory of prefix
+func archiveFileName(prefix, p string) string {
+	dir := prefix[:strings.LastIndex(prefix, "/")+1]
+	return strings.TrimPrefix(p, dir)
+}
+
+func writeArchive(deps *Dependencies, w archive.Writer, storageNamespace, prefix string, entries []*catalog.DBEntry) error {
+	for _, entry := range entries {
+		reader, err := deps.BlockAdapter.Get(block.ObjectPointer{StorageNamespace: storageNamespace, Identifier: entry.PhysicalAddress}, entry.Size)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", entry.Path, err)
+		}
+		if encryption.IsEncrypted(entry.Metadata) {
+			reader, err = deps.Encryptor.DecryptReadCloser(deps.ctx, entry.Metadata, reader, 0)
+			if err != nil {
+				return fmt.Errorf("decrypt %s: %w", entry.Path, err)
+			}
+		}
+		err = w.WriteFile(archive.File{
+			Name:    archiveFileName(prefix, entry.Path),
+			Size:    entry.Size,
+			ModTime: entry.CreationDate,
+		}, reader)
+		_ = reader.Close()
+		if err != nil {
+			return fmt.Errorf("archive %s: %w", entry.Path, err)
+		}
+	}
+	return w.Close()
+}
+
+func (c *Controller) ObjectsGetObjectsArchiveHandler() objects.GetObjectsArchiveHandler {
+	return objects.GetObjectsArchiveHandlerFunc(func(params objects.GetObjectsArchiveParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ListObjectsAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return objects.NewGetObjectsArchiveUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("get_objects_archive")
+		cataloger := deps.Cataloger
+		prefix := swag.StringValue(params.Prefix)
+		format := swag.StringValue(params.Format)
+
+		repo, err := cataloger.GetRepository(deps.ctx, params.Repository)
+		if errors.Is(err, db.ErrNotFound) {
+			return objects.NewGetObjectsArchiveNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository not found"))
+		}
+		if err != nil {
+			return objects.NewGetObjectsArchiveDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		entries, err := listArchiveEntries(deps, params.Repository, params.Ref, prefix)
+		switch {
+		case errors.Is(err, ErrTooManyArchiveObjects) || errors.Is(err, catalog.ErrInvalidValue):
+			return objects.NewGetObjectsArchiveBadRequest().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, db.ErrNotFound):
+			return objects.NewGetObjectsArchiveNotFound().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return objects.NewGetObjectsArchiveDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+
+		// every object archived must be readable, and decryptable if it is encrypted
+		var perms []permissions.Permission
+		for _, entry := range entries {
+			perms = append(perms, permissions.Permission{
+				Action:   permissions.ReadObjectAction,
+				Resource: permissions.ObjectArn(params.Repository, entry.Path),
+			})
+			if encryption.IsEncrypted(entry.Metadata) {
+				perms = append(perms, permissions.Permission{
+					Action:   permissions.DecryptObjectAction,
+					Resource: permissions.ObjectArn(params.Repository, entry.Path),
+				})
+			}
+		}
+		if len(perms) > 0 {
+			if err := authorize(deps.Auth, user, perms); err != nil {
+				return objects.NewGetObjectsArchiveUnauthorized().WithPayload(responseErrorFrom(err))
+			}
+		}
+
+		return middleware.ResponderFunc(func(w http.ResponseWriter, _ runtime.Producer) {
+			name := path.Base(strings.TrimSuffix(prefix, "/"))
+			if name == "." || name == "/" {
+				name = params.Repository
+			}
+			w.Header().Set("Content-Type", archive.ContentType(format))
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.%s\"", name, format))
+			aw, err := archive.NewWriter(w, format)
+			if err != nil {
+				// the format enum is validated by the API, this guards formats it lists that
+				// the archive package does not write
+				w.Header().Set("Content-Type", runtime.JSONMime)
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(responseErrorFrom(err))
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			if err := writeArchive(deps, aw, repo.StorageNamespace, prefix, entries); err != nil {
+				logging.FromContext(deps.ctx).WithError(err).Error("Objects archive failed")
+				// the status was sent, abort so that the client does not mistake the
+				// response for a complete archive
+				panic(http.ErrAbortHandler)
+			}
+		})
+	})
+}
@@ -4,11 +4,16 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	openapierrors "github.com/go-openapi/errors"
+	"github.com/go-openapi/runtime"
 	"github.com/treeverse/lakefs/api/gen/models"
 	"github.com/treeverse/lakefs/auth"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/httputil"
 	"github.com/treeverse/lakefs/logging"
+	"github.com/treeverse/lakefs/permissions"
 	"gopkg.in/dgrijalva/jwt-go.v3"
 )
 
@@ -27,10 +32,11 @@ func NewBasicAuthHandler(authService auth.Service) func(accessKey, secretKey str
 			logger.WithError(err).WithField("access_key", accessKey).Debug("could not get access key for login")
 			return nil, ErrAuthenticationFailed
 		}
-		if secretKey != credentials.AccessSecretKey {
+		if !credentials.SecretMatches(secretKey, time.Now()) {
 			logger.WithField("access_key", accessKey).Debug("access key secret does not match")
 			return nil, ErrAuthenticationFailed
 		}
+		markCredentialsUsed(authService, accessKey)
 		userData, err := authService.GetUserByID(credentials.UserID)
 		if err != nil {
 			logger.WithField("access_key", accessKey).Debug("could not find user for key pair")
@@ -48,19 +54,9 @@ func NewBasicAuthHandler(authService auth.Service) func(accessKey, secretKey str
 func NewJwtTokenAuthHandler(authService auth.Service) func(string) (*models.User, error) {
 	logger := logging.Default().WithField("auth", "jwt")
 	return func(tokenString string) (*models.User, error) {
-		claims := &jwt.StandardClaims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("%w: %s", ErrUnexpectedSigningMethod, token.Header["alg"])
-			}
-			return authService.SecretStore().SharedSecret(), nil
-		})
+		claims, err := parseJWT(authService, tokenString)
 		if err != nil {
-			return nil, ErrAuthenticationFailed
-		}
-		claims, ok := token.Claims.(*jwt.StandardClaims)
-		if !ok || !token.Valid {
-			return nil, ErrAuthenticationFailed
+			return nil, err
 		}
 		cred, err := authService.GetCredentials(claims.Subject)
 		if err != nil {
@@ -75,8 +71,105 @@ func NewJwtTokenAuthHandler(authService auth.Service) func(string) (*models.User
 			}).Debug("could not find user id by credentials")
 			return nil, ErrAuthenticationFailed
 		}
+		markCredentialsUsed(authService, claims.Subject)
 		return &models.User{
 			ID: userData.Username,
 		}, nil
 	}
 }
+
+// parseJWT returns the claims of a valid token signed by the server
+func parseJWT(authService auth.Service, tokenString string) (*jwt.StandardClaims, error) {
+	claims := &jwt.StandardClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnexpectedSigningMethod, token.Header["alg"])
+		}
+		return authService.SecretStore().SharedSecret(), nil
+	})
+	if err != nil {
+		return nil, ErrAuthenticationFailed
+	}
+	claims, ok := token.Claims.(*jwt.StandardClaims)
+	// tokens with an audience, such as browse tokens, do not log in
+	if !ok || !token.Valid || claims.Audience != "" {
+		return nil, ErrAuthenticationFailed
+	}
+	return claims, nil
+}
+
+// NewNetworkPolicyHandler rejects requests authenticated by credentials whose network policy
+// does not permit the source address of the request.  Swagger authenticators do not see the
+// request, so the policy is checked before them; requests with missing or invalid credentials
+// are left for them to reject.
+func NewNetworkPolicyHandler(authService auth.Service, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accessKeyID, _, ok := r.BasicAuth()
+		if !ok {
+			if tokenString := r.Header.Get(JWTAuthorizationHeaderName); tokenString != "" {
+				if claims, err := parseJWT(authService, tokenString); err == nil {
+					accessKeyID = claims.Subject
+				}
+			}
+		}
+		if accessKeyID != "" {
+			credentials, err := authService.GetCredentials(accessKeyID)
+			if err == nil && !credentials.NetworkPolicy.Permits(httputil.SourceIPFromRequest(r)) {
+				logging.FromContext(r.Context()).
+					WithField("access_key", accessKeyID).
+					WithField("source_ip", httputil.SourceIPFromRequest(r).String()).
+					Warn("source address not permitted for key")
+				openapierrors.ServeError(w, r, openapierrors.New(http.StatusForbidden, auth.ErrAddressNotPermitted.Error()))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RunAsHeaderName names the user on whose behalf a request runs
+const RunAsHeaderName = "X-Lakefs-Run-As"
+
+// NewImpersonationAuthorizer returns a Swagger authorizer running requests that set
+// RunAsHeaderName as the user it names.  The authenticated user must be allowed to impersonate
+// that user, and is kept as the ImpersonatedBy of the principal, which is replaced by the
+// impersonated user as loaded from authService.
+func NewImpersonationAuthorizer(authService auth.Service) runtime.Authorizer {
+	return runtime.AuthorizerFunc(func(r *http.Request, principal interface{}) error {
+		runAs := r.Header.Get(RunAsHeaderName)
+		user, ok := principal.(*models.User)
+		if runAs == "" || !ok || runAs == user.ID {
+			return nil
+		}
+		err := authorize(authService, user, []permissions.Permission{
+			{
+				Action:   permissions.ImpersonateUserAction,
+				Resource: permissions.UserArn(runAs),
+			},
+		})
+		if err != nil {
+			return openapierrors.New(http.StatusForbidden, "%s may not run as %s", user.ID, runAs)
+		}
+		target, err := authService.GetUser(runAs)
+		if errors.Is(err, db.ErrNotFound) {
+			return openapierrors.New(http.StatusForbidden, "run as %s: user not found", runAs)
+		}
+		if err != nil {
+			return openapierrors.New(http.StatusInternalServerError, "run as %s: %s", runAs, err)
+		}
+		*user = models.User{
+			ID:             target.Username,
+			CreationDate:   target.CreatedAt.Unix(),
+			ImpersonatedBy: user.ID,
+		}
+		return nil
+	})
+}
+
+// markCredentialsUsed records the use of accessKeyID, failing to record it does not fail the
+// request
+func markCredentialsUsed(authService auth.Service, accessKeyID string) {
+	if err := authService.MarkCredentialsUsed(accessKeyID); err != nil {
+		logging.Default().WithError(err).WithField("access_key", accessKeyID).Warn("Failed to record credentials use")
+	}
+}
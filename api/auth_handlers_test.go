@@ -2,13 +2,20 @@ package api_test
 
 import (
 	"errors"
+	"net/http"
 	"testing"
 	"time"
 
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/treeverse/lakefs/api"
+	authmodel "github.com/treeverse/lakefs/auth/model"
 	"github.com/treeverse/lakefs/testutil"
 
+	"github.com/go-openapi/runtime"
 	"github.com/go-openapi/runtime/client"
+	"github.com/go-openapi/strfmt"
+	"github.com/treeverse/lakefs/api/gen/client/auth"
 	"github.com/treeverse/lakefs/api/gen/client/repositories"
 )
 
@@ -62,3 +69,86 @@ func TestJwtTokenAuth(t *testing.T) {
 		}
 	})
 }
+
+// runAs authenticates with creds and runs the request as username
+func runAs(creds *authmodel.Credential, username string) runtime.ClientAuthInfoWriter {
+	return runtime.ClientAuthInfoWriterFunc(func(r runtime.ClientRequest, reg strfmt.Registry) error {
+		if err := r.SetHeaderParam(api.RunAsHeaderName, username); err != nil {
+			return err
+		}
+		return client.BasicAuth(creds.AccessKeyID, creds.AccessSecretKey).AuthenticateRequest(r, reg)
+	})
+}
+
+// responseCode returns the status code of the error response err
+func responseCode(err error) int {
+	var apiErr *runtime.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	var coded interface{ Code() int }
+	if errors.As(err, &coded) {
+		return coded.Code()
+	}
+	return 0
+}
+
+func TestImpersonation(t *testing.T) {
+	clt, deps := setupClient(t, "")
+	adminCreds := createDefaultAdminUser(t, clt)
+	for _, username := range []string{"bob", "carol"} {
+		testutil.MustDo(t, "create user "+username, deps.authService.CreateUser(&authmodel.User{
+			CreatedAt: time.Now(),
+			Username:  username,
+		}))
+	}
+	testutil.MustDo(t, "add bob to viewers", deps.authService.AddUserToGroup("bob", "Viewers"))
+	bob, err := deps.authService.GetUser("bob")
+	testutil.MustDo(t, "get bob", err)
+	carolCreds, err := deps.authService.CreateCredentials("carol")
+	testutil.MustDo(t, "create carol credentials", err)
+
+	t.Run("denied without permission", func(t *testing.T) {
+		_, err := clt.Auth.GetCurrentUser(auth.NewGetCurrentUserParamsWithTimeout(timeout), runAs(carolCreds, "bob"))
+		if code := responseCode(err); code != http.StatusForbidden {
+			t.Errorf("carol running as bob got %v (status %d), expected %d", err, code, http.StatusForbidden)
+		}
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		_, err := clt.Auth.GetCurrentUser(auth.NewGetCurrentUserParamsWithTimeout(timeout), runAs(adminCreds, "nobody"))
+		if code := responseCode(err); code != http.StatusForbidden {
+			t.Errorf("running as an unknown user got %v (status %d), expected %d", err, code, http.StatusForbidden)
+		}
+	})
+
+	t.Run("runs as the user", func(t *testing.T) {
+		res, err := clt.Auth.GetCurrentUser(auth.NewGetCurrentUserParamsWithTimeout(timeout), runAs(adminCreds, "bob"))
+		testutil.MustDo(t, "get current user as bob", err)
+		user := res.Payload.User
+		if user.ID != "bob" || user.ImpersonatedBy != "admin" || user.CreationDate != bob.CreatedAt.Unix() {
+			t.Errorf("current user %+v, expected bob created at %d impersonated by admin", user, bob.CreatedAt.Unix())
+		}
+	})
+
+	t.Run("audit log attribution", func(t *testing.T) {
+		level := logrus.GetLevel()
+		logrus.SetLevel(logrus.InfoLevel)
+		defer logrus.SetLevel(level)
+		hook := logtest.NewGlobal()
+		defer logrus.StandardLogger().ReplaceHooks(make(logrus.LevelHooks))
+
+		_, err := clt.Repositories.ListRepositories(repositories.NewListRepositoriesParamsWithTimeout(timeout), runAs(adminCreds, "bob"))
+		testutil.MustDo(t, "list repositories as bob", err)
+		for _, entry := range hook.AllEntries() {
+			if entry.Data["action"] == "list_repos" {
+				if entry.Data["user"] != "bob" || entry.Data["impersonated_by"] != "admin" {
+					t.Errorf("action logged with user %v impersonated by %v, expected bob impersonated by admin",
+						entry.Data["user"], entry.Data["impersonated_by"])
+				}
+				return
+			}
+		}
+		t.Error("action on behalf of bob was not logged")
+	})
+}
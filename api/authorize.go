@@ -6,15 +6,19 @@ import (
 
 	"github.com/treeverse/lakefs/api/gen/models"
 	"github.com/treeverse/lakefs/auth"
+	"github.com/treeverse/lakefs/auth/model"
+	"github.com/treeverse/lakefs/catalog"
 	"github.com/treeverse/lakefs/permissions"
 )
 
 var ErrAuthorization = errors.New("authorization error")
 
-func authorize(a auth.Service, user *models.User, permissions []permissions.Permission) error {
+// authorize verifies user is allowed permissions, and is not denied any of deniable
+func authorize(a auth.Service, user *models.User, permissions []permissions.Permission, deniable ...permissions.Permission) error {
 	authResp, err := a.Authorize(&auth.AuthorizationRequest{
 		Username:            user.ID,
 		RequiredPermissions: permissions,
+		DeniablePermissions: deniable,
 	})
 	if err != nil {
 		return ErrAuthorization
@@ -29,3 +33,66 @@ func authorize(a auth.Service, user *models.User, permissions []permissions.Perm
 	}
 	return nil
 }
+
+// authorizeLinkFunc returns a function authorizing user to read the objects links point to
+func authorizeLinkFunc(deps *Dependencies, user *models.User) func(repository, path string) error {
+	return func(repository, path string) error {
+		return authorize(deps.Auth, user, []permissions.Permission{
+			{
+				Action:   permissions.ReadObjectAction,
+				Resource: permissions.ObjectArn(repository, path),
+			},
+		})
+	}
+}
+
+// authorizeReadCommitsFunc returns a function authorizing user to read the commits of a
+// repository
+func authorizeReadCommitsFunc(deps *Dependencies, user *models.User) func(repository string) error {
+	return func(repository string) error {
+		return authorize(deps.Auth, user, []permissions.Permission{
+			{
+				Action:   permissions.ReadCommitAction,
+				Resource: permissions.RepoArn(repository),
+			},
+		})
+	}
+}
+
+// sandboxPermissions returns the deniable permissions of writing to branch, policies denying them
+// confine user to its sandbox branch
+func sandboxPermissions(user *models.User, repository, branch string) []permissions.Permission {
+	if branch == catalog.SandboxBranchID(user.ID) {
+		return nil
+	}
+	return []permissions.Permission{
+		{
+			Action:   permissions.WriteOutsideSandboxAction,
+			Resource: permissions.BranchArn(repository, branch),
+		},
+	}
+}
+
+// userGroupsFunc returns a function listing the names of the groups of a user
+func userGroupsFunc(deps *Dependencies) func(username string) ([]string, error) {
+	return func(username string) ([]string, error) {
+		var names []string
+		after := ""
+		for {
+			groups, paginator, err := deps.Auth.ListUserGroups(username, &model.PaginationParams{
+				After:  after,
+				Amount: MaxResultsPerPage,
+			})
+			if err != nil {
+				return nil, err
+			}
+			for _, group := range groups {
+				names = append(names, group.DisplayName)
+			}
+			if paginator.NextPageToken == "" {
+				return names, nil
+			}
+			after = paginator.NextPageToken
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/swag"
+	"github.com/treeverse/lakefs/api/gen/models"
+	"github.com/treeverse/lakefs/api/gen/restapi/operations/objects"
+	"github.com/treeverse/lakefs/auth"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/permissions"
+)
+
+const (
+	// BrowsePathPrefix starts the URLs serving objects to holders of browse tokens
+	BrowsePathPrefix = "/browse/"
+	// BrowseTokenAudience is the audience of browse tokens.  Login tokens have no audience,
+	// so neither kind of token is accepted in place of the other.
+	BrowseTokenAudience      = "lakefs-browse"
+	DefaultBrowseTokenExpiry = 15 * time.Minute
+)
+
+// BrowseTokenClaims scope a browse token to reading a path of a ref as its subject, the user
+// that created it
+type BrowseTokenClaims struct {
+	jwt.StandardClaims
+	Repository string `json:"repository"`
+	Ref        string `json:"ref"`
+	Path       string `json:"path"`
+}
+
+func GenerateBrowseToken(secret []byte, username, repository, ref, path string, issuedAt, expiresAt time.Time) (string, error) {
+	claims := &BrowseTokenClaims{
+		StandardClaims: jwt.StandardClaims{
+			Audience:  BrowseTokenAudience,
+			Subject:   username,
+			IssuedAt:  issuedAt.Unix(),
+			ExpiresAt: expiresAt.Unix(),
+		},
+		Repository: repository,
+		Ref:        ref,
+		Path:       path,
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(secret)
+}
+
+// ParseBrowseToken returns the claims of a valid browse token signed with secret
+func ParseBrowseToken(secret []byte, tokenString string) (*BrowseTokenClaims, error) {
+	claims := &BrowseTokenClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnexpectedSigningMethod, token.Header["alg"])
+		}
+		return secret, nil
+	})
+	if err != nil || !token.Valid || !claims.VerifyAudience(BrowseTokenAudience, true) {
+		return nil, ErrAuthenticationFailed
+	}
+	return claims, nil
+}
+
+func (c *Controller) ObjectsCreateBrowseTokenHandler() objects.CreateBrowseTokenHandler {
+	return objects.CreateBrowseTokenHandlerFunc(func(params objects.CreateBrowseTokenParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadObjectAction,
+				Resource: permissions.ObjectArn(params.Repository, params.Path),
+			},
+		})
+		if err != nil {
+			return objects.NewCreateBrowseTokenUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("create_browse_token")
+
+		_, err = deps.Cataloger.GetEntry(deps.ctx, params.Repository, params.Ref, params.Path, catalog.GetEntryParams{
+			AuthorizeLink: authorizeLinkFunc(deps, user),
+		})
+		if errors.Is(err, db.ErrNotFound) {
+			return objects.NewCreateBrowseTokenNotFound().WithPayload(responseError("resource not found"))
+		}
+		if errors.Is(err, ErrAuthorization) || errors.Is(err, catalog.ErrCrossRepositoryLink) {
+			return objects.NewCreateBrowseTokenUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		if err != nil {
+			return objects.NewCreateBrowseTokenDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+
+		expiry := DefaultBrowseTokenExpiry
+		if params.ExpirySeconds != nil {
+			expiry = time.Duration(*params.ExpirySeconds) * time.Second
+		}
+		now := time.Now()
+		expiresAt := now.Add(expiry)
+		token, err := GenerateBrowseToken(deps.Auth.SecretStore().SharedSecret(), user.ID, params.Repository, params.Ref, params.Path, now, expiresAt)
+		if err != nil {
+			return objects.NewCreateBrowseTokenDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return objects.NewCreateBrowseTokenCreated().WithPayload(&models.BrowseToken{
+			Token:     swag.String(token),
+			URL:       swag.String(BrowsePathPrefix + token),
+			ExpiresAt: swag.Int64(expiresAt.Unix()),
+		})
+	})
+}
+
+// NewBrowseHandler serves the object of the browse token following BrowsePathPrefix, as
+// getObject serves it to the user that created the token
+func NewBrowseHandler(authService auth.Service, getObject objects.GetObjectHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		claims, err := ParseBrowseToken(authService.SecretStore().SharedSecret(), strings.TrimPrefix(r.URL.Path, BrowsePathPrefix))
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		params := objects.GetObjectParams{
+			HTTPRequest: r,
+			Repository:  claims.Repository,
+			Ref:         claims.Ref,
+			Path:        claims.Path,
+		}
+		if rng := r.Header.Get("Range"); rng != "" {
+			params.Range = swag.String(rng)
+		}
+		getObject.Handle(params, &models.User{ID: claims.Subject}).WriteResponse(w, runtime.ByteStreamProducer())
+	})
+}
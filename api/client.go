@@ -2,9 +2,13 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"net/http"
 	"net/url"
 	"path"
+	"time"
 
 	"github.com/go-openapi/runtime"
 	httptransport "github.com/go-openapi/runtime/client"
@@ -23,6 +27,8 @@ import (
 	"github.com/treeverse/lakefs/catalog"
 )
 
+var ErrRangeNotSupported = errors.New("server does not support range requests")
+
 type AuthClient interface {
 	GetCurrentUser(ctx context.Context) (*models.User, error)
 	GetUser(ctx context.Context, userID string) (*models.User, error)
@@ -41,11 +47,14 @@ type AuthClient interface {
 	AddGroupMembership(ctx context.Context, groupID, userID string) error
 	DeleteGroupMembership(ctx context.Context, groupID, userID string) error
 	ListUserCredentials(ctx context.Context, userID string, after string, amount int) ([]*models.Credentials, *models.Pagination, error)
-	CreateCredentials(ctx context.Context, userID string) (*models.CredentialsWithSecret, error)
+	CreateCredentials(ctx context.Context, userID string, expiresAt *time.Time) (*models.CredentialsWithSecret, error)
+	RotateCredentials(ctx context.Context, userID, accessKeyID string, gracePeriod time.Duration, expiresAt *time.Time) (*models.CredentialsWithSecret, error)
+	SetCredentialsNetworkPolicy(ctx context.Context, userID, accessKeyID string, policy *models.NetworkPolicy) (*models.Credentials, error)
 	DeleteCredentials(ctx context.Context, userID, accessKeyID string) error
 	GetCredentials(ctx context.Context, userID, accessKeyID string) (*models.Credentials, error)
 	ListUserGroups(ctx context.Context, userID string, after string, amount int) ([]*models.Group, *models.Pagination, error)
 	ListUserPolicies(ctx context.Context, userID string, effective bool, after string, amount int) ([]*models.Policy, *models.Pagination, error)
+	SimulateAuthorization(ctx context.Context, userID, action, resource string) (*models.AuthorizationSimulation, error)
 	AttachPolicyToUser(ctx context.Context, userID, policyID string) error
 	DetachPolicyFromUser(ctx context.Context, userID, policyID string) error
 	ListGroupPolicies(ctx context.Context, groupID string, after string, amount int) ([]*models.Policy, *models.Pagination, error)
@@ -63,6 +72,12 @@ type RepositoryClient interface {
 	ListBranches(ctx context.Context, repository string, from string, amount int) ([]*models.Ref, *models.Pagination, error)
 	GetBranch(ctx context.Context, repository, branchID string) (string, error)
 	CreateBranch(ctx context.Context, repository string, branch *models.BranchCreation) (string, error)
+	GetOrCreateSandbox(ctx context.Context, repository string, ttl time.Duration) (*models.Sandbox, error)
+
+	AcquirePathLease(ctx context.Context, repository, branch, prefix string, ttl time.Duration) (*models.PathLease, error)
+	RenewPathLease(ctx context.Context, repository, branch, leaseID string, ttl time.Duration) (*models.PathLease, error)
+	ReleasePathLease(ctx context.Context, repository, branch, leaseID string) error
+	ListPathLeases(ctx context.Context, repository, branch string) ([]*models.PathLease, error)
 	DeleteBranch(ctx context.Context, repository, branchID string) error
 	ResetBranch(ctx context.Context, repository, branchID string, resetProps *models.ResetCreation) error
 	RevertBranch(ctx context.Context, repository, branchID string, commitRef string, parentNumber int) error
@@ -74,18 +89,26 @@ type RepositoryClient interface {
 
 	Commit(ctx context.Context, repository, branchID, message string, metadata map[string]string) (*models.Commit, error)
 	GetCommit(ctx context.Context, repository, commitID string) (*models.Commit, error)
-	GetCommitLog(ctx context.Context, repository, branchID, after string, amount int) ([]*models.Commit, *models.Pagination, error)
+	GetCommitLog(ctx context.Context, repository, branchID, after string, amount int, firstParent bool) ([]*models.Commit, *models.Pagination, error)
+	StreamCommitLog(ctx context.Context, repository, ref string, firstParent bool, w io.Writer) error
+	CommitsBetween(ctx context.Context, repository, leftRef, rightRef, after string, amount int) ([]*models.Commit, *models.Pagination, error)
+	IsAncestor(ctx context.Context, repository, ancestorRef, ref string) (bool, error)
 
 	StatObject(ctx context.Context, repository, ref, path string) (*models.ObjectStats, error)
 	ListObjects(ctx context.Context, repository, ref, prefix, from string, amount int) ([]*models.ObjectStats, *models.Pagination, error)
 	GetObject(ctx context.Context, repository, ref, path string, w io.Writer) (*objects.GetObjectOK, error)
+	GetObjectRange(ctx context.Context, repository, ref, path string, start, end int64, w io.Writer) (*objects.GetObjectPartialContent, error)
+	GetObjectDownloadManifest(ctx context.Context, repository, ref, path string, partSize int64, expiry time.Duration) (*models.DownloadManifest, error)
+	ListObjectLocations(ctx context.Context, repository, ref, prefix, after string, amount int, presign bool, expiry time.Duration) ([]*models.ObjectLocation, *models.Pagination, error)
 	UploadObject(ctx context.Context, repository, branchID, path string, r io.Reader) (*models.ObjectStats, error)
+	AppendObject(ctx context.Context, repository, branchID, path string, r io.Reader) (*models.ObjectStats, error)
 	DeleteObject(ctx context.Context, repository, branchID, path string) error
 
-	DiffRefs(ctx context.Context, repository, leftRef, rightRef string, after string, amount int) ([]*models.Diff, *models.Pagination, error)
-	Merge(ctx context.Context, repository, destinationBranch, sourceRef string) (*models.MergeResult, error)
+	DiffRefs(ctx context.Context, repository, leftRef, rightRef string, after string, amount int, changeTypes []string) ([]*models.Diff, *models.Pagination, error)
+	ExportDiff(ctx context.Context, repository, leftRef, rightRef, format string) (*models.DiffExport, error)
+	Merge(ctx context.Context, repository, destinationBranch, sourceRef string, merge *models.Merge) (*models.MergeResult, error)
 
-	DiffBranch(ctx context.Context, repository, branch string, after string, amount int) ([]*models.Diff, *models.Pagination, error)
+	DiffBranch(ctx context.Context, repository, branch string, after string, amount int, changeTypes []string) ([]*models.Diff, *models.Pagination, error)
 
 	Symlink(ctx context.Context, repoID, ref, path string) (string, error)
 
@@ -288,11 +311,12 @@ func (c *client) ListUserCredentials(ctx context.Context, userID string, after s
 	return resp.GetPayload().Results, resp.GetPayload().Pagination, nil
 }
 
-func (c *client) CreateCredentials(ctx context.Context, userID string) (*models.CredentialsWithSecret, error) {
+func (c *client) CreateCredentials(ctx context.Context, userID string, expiresAt *time.Time) (*models.CredentialsWithSecret, error) {
 	resp, err := c.remote.Auth.CreateCredentials(&auth.CreateCredentialsParams{
-		UserID:     userID,
-		Context:    ctx,
-		HTTPClient: nil,
+		UserID:      userID,
+		Credentials: &models.CredentialsCreation{ExpiresAt: unixTime(expiresAt)},
+		Context:     ctx,
+		HTTPClient:  nil,
 	}, c.auth)
 	if err != nil {
 		return nil, err
@@ -300,6 +324,35 @@ func (c *client) CreateCredentials(ctx context.Context, userID string) (*models.
 	return resp.GetPayload(), err
 }
 
+func (c *client) RotateCredentials(ctx context.Context, userID, accessKeyID string, gracePeriod time.Duration, expiresAt *time.Time) (*models.CredentialsWithSecret, error) {
+	resp, err := c.remote.Auth.RotateCredentials(&auth.RotateCredentialsParams{
+		UserID:      userID,
+		AccessKeyID: accessKeyID,
+		Rotation: &models.CredentialsRotation{
+			GracePeriodSeconds: swag.Int64(int64(gracePeriod / time.Second)),
+			ExpiresAt:          unixTime(expiresAt),
+		},
+		Context: ctx,
+	}, c.auth)
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetPayload(), nil
+}
+
+func (c *client) SetCredentialsNetworkPolicy(ctx context.Context, userID, accessKeyID string, policy *models.NetworkPolicy) (*models.Credentials, error) {
+	resp, err := c.remote.Auth.SetCredentialsNetworkPolicy(&auth.SetCredentialsNetworkPolicyParams{
+		UserID:      userID,
+		AccessKeyID: accessKeyID,
+		Policy:      policy,
+		Context:     ctx,
+	}, c.auth)
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetPayload(), nil
+}
+
 func (c *client) DeleteCredentials(ctx context.Context, userID, accessKeyID string) error {
 	_, err := c.remote.Auth.DeleteCredentials(&auth.DeleteCredentialsParams{
 		AccessKeyID: accessKeyID,
@@ -348,6 +401,21 @@ func (c *client) ListUserPolicies(ctx context.Context, userID string, effective
 	return resp.GetPayload().Results, resp.GetPayload().Pagination, nil
 }
 
+func (c *client) SimulateAuthorization(ctx context.Context, userID, action, resource string) (*models.AuthorizationSimulation, error) {
+	resp, err := c.remote.Auth.SimulateAuthorization(&auth.SimulateAuthorizationParams{
+		Simulation: &models.AuthorizationSimulationRequest{
+			User:     swag.String(userID),
+			Action:   swag.String(action),
+			Resource: swag.String(resource),
+		},
+		Context: ctx,
+	}, c.auth)
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetPayload(), nil
+}
+
 func (c *client) AttachPolicyToUser(ctx context.Context, userID, policyID string) error {
 	_, err := c.remote.Auth.AttachPolicyToUser(&auth.AttachPolicyToUserParams{
 		PolicyID: policyID,
@@ -482,6 +550,73 @@ func (c *client) CreateBranch(ctx context.Context, repository string, branch *mo
 	return resp.GetPayload(), nil
 }
 
+func (c *client) GetOrCreateSandbox(ctx context.Context, repository string, ttl time.Duration) (*models.Sandbox, error) {
+	resp, err := c.remote.Branches.GetOrCreateSandbox(&branches.GetOrCreateSandboxParams{
+		Repository: repository,
+		Sandbox:    &models.SandboxCreation{TTLSeconds: int64(ttl / time.Second)},
+		Context:    ctx,
+	}, c.auth)
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetPayload(), nil
+}
+
+func (c *client) AcquirePathLease(ctx context.Context, repository, branch, prefix string, ttl time.Duration) (*models.PathLease, error) {
+	resp, err := c.remote.Branches.AcquirePathLease(&branches.AcquirePathLeaseParams{
+		Repository: repository,
+		Branch:     branch,
+		Lease: &models.PathLeaseCreation{
+			Prefix:     swag.String(prefix),
+			TTLSeconds: int64(ttl / time.Second),
+		},
+		Context: ctx,
+	}, c.auth)
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetPayload(), nil
+}
+
+func (c *client) RenewPathLease(ctx context.Context, repository, branch, leaseID string, ttl time.Duration) (*models.PathLease, error) {
+	params := &branches.RenewPathLeaseParams{
+		Repository: repository,
+		Branch:     branch,
+		LeaseID:    leaseID,
+		Context:    ctx,
+	}
+	if ttl > 0 {
+		params.TTLSeconds = swag.Int64(int64(ttl / time.Second))
+	}
+	resp, err := c.remote.Branches.RenewPathLease(params, c.auth)
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetPayload(), nil
+}
+
+func (c *client) ReleasePathLease(ctx context.Context, repository, branch, leaseID string) error {
+	_, err := c.remote.Branches.ReleasePathLease(&branches.ReleasePathLeaseParams{
+		Repository: repository,
+		Branch:     branch,
+		LeaseID:    leaseID,
+		Context:    ctx,
+	}, c.auth)
+	return err
+}
+
+func (c *client) ListPathLeases(ctx context.Context, repository, branch string) ([]*models.PathLease, error) {
+	resp, err := c.remote.Branches.ListPathLeases(&branches.ListPathLeasesParams{
+		Repository: repository,
+		Branch:     branch,
+		Context:    ctx,
+	}, c.auth)
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetPayload().Results, nil
+}
+
 func (c *client) DeleteBranch(ctx context.Context, repository, branchID string) error {
 	_, err := c.remote.Branches.DeleteBranch(&branches.DeleteBranchParams{
 		Branch:     branchID,
@@ -537,11 +672,37 @@ func (c *client) GetCommit(ctx context.Context, repository, commitID string) (*m
 	return commit.GetPayload(), nil
 }
 
-func (c *client) GetCommitLog(ctx context.Context, repository, branchID, after string, amount int) ([]*models.Commit, *models.Pagination, error) {
+func (c *client) GetCommitLog(ctx context.Context, repository, branchID, after string, amount int, firstParent bool) ([]*models.Commit, *models.Pagination, error) {
 	resp, err := c.remote.Commits.GetBranchCommitLog(&commits.GetBranchCommitLogParams{
+		Amount:      swag.Int64(int64(amount)),
+		After:       swag.String(after),
+		FirstParent: swag.Bool(firstParent),
+		Branch:      branchID,
+		Repository:  repository,
+		Context:     ctx,
+	}, c.auth)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.GetPayload().Results, resp.GetPayload().Pagination, nil
+}
+
+func (c *client) StreamCommitLog(ctx context.Context, repository, ref string, firstParent bool, writer io.Writer) error {
+	_, err := c.remote.Commits.StreamCommitLog(&commits.StreamCommitLogParams{
+		FirstParent: swag.Bool(firstParent),
+		Ref:         ref,
+		Repository:  repository,
+		Context:     ctx,
+	}, c.auth, writer)
+	return err
+}
+
+func (c *client) CommitsBetween(ctx context.Context, repository, leftRef, rightRef, after string, amount int) ([]*models.Commit, *models.Pagination, error) {
+	resp, err := c.remote.Commits.CommitsBetween(&commits.CommitsBetweenParams{
 		Amount:     swag.Int64(int64(amount)),
 		After:      swag.String(after),
-		Branch:     branchID,
+		LeftRef:    leftRef,
+		RightRef:   rightRef,
 		Repository: repository,
 		Context:    ctx,
 	}, c.auth)
@@ -551,10 +712,38 @@ func (c *client) GetCommitLog(ctx context.Context, repository, branchID, after s
 	return resp.GetPayload().Results, resp.GetPayload().Pagination, nil
 }
 
-func (c *client) DiffRefs(ctx context.Context, repository, leftRef, rightRef, after string, amount int) ([]*models.Diff, *models.Pagination, error) {
+func (c *client) IsAncestor(ctx context.Context, repository, ancestorRef, ref string) (bool, error) {
+	resp, err := c.remote.Commits.IsAncestor(&commits.IsAncestorParams{
+		AncestorRef: ancestorRef,
+		Ref:         ref,
+		Repository:  repository,
+		Context:     ctx,
+	}, c.auth)
+	if err != nil {
+		return false, err
+	}
+	return swag.BoolValue(resp.GetPayload().IsAncestor), nil
+}
+
+func (c *client) ExportDiff(ctx context.Context, repository, leftRef, rightRef, format string) (*models.DiffExport, error) {
+	resp, err := c.remote.Refs.ExportDiff(&refs.ExportDiffParams{
+		Repository: repository,
+		LeftRef:    leftRef,
+		RightRef:   rightRef,
+		Export:     &models.DiffExportCreation{Format: swag.String(format)},
+		Context:    ctx,
+	}, c.auth)
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetPayload(), nil
+}
+
+func (c *client) DiffRefs(ctx context.Context, repository, leftRef, rightRef, after string, amount int, changeTypes []string) ([]*models.Diff, *models.Pagination, error) {
 	diff, err := c.remote.Refs.DiffRefs(&refs.DiffRefsParams{
 		After:      swag.String(after),
 		Amount:     swag.Int64(int64(amount)),
+		ChangeType: changeTypes,
 		LeftRef:    leftRef,
 		Repository: repository,
 		RightRef:   rightRef,
@@ -567,11 +756,12 @@ func (c *client) DiffRefs(ctx context.Context, repository, leftRef, rightRef, af
 	return payload.Results, payload.Pagination, nil
 }
 
-func (c *client) Merge(ctx context.Context, repository, destinationBranch, sourceRef string) (*models.MergeResult, error) {
+func (c *client) Merge(ctx context.Context, repository, destinationBranch, sourceRef string, merge *models.Merge) (*models.MergeResult, error) {
 	statusOK, err := c.remote.Refs.MergeIntoBranch(&refs.MergeIntoBranchParams{
 		DestinationBranch: destinationBranch,
 		SourceRef:         sourceRef,
 		Repository:        repository,
+		Merge:             merge,
 		Context:           ctx,
 	}, c.auth)
 
@@ -585,11 +775,12 @@ func (c *client) Merge(ctx context.Context, repository, destinationBranch, sourc
 	return nil, err
 }
 
-func (c *client) DiffBranch(ctx context.Context, repoID, branch string, after string, amount int) ([]*models.Diff, *models.Pagination, error) {
+func (c *client) DiffBranch(ctx context.Context, repoID, branch string, after string, amount int, changeTypes []string) ([]*models.Diff, *models.Pagination, error) {
 	diff, err := c.remote.Branches.DiffBranch(&branches.DiffBranchParams{
 		After:      swag.String(after),
 		Amount:     swag.Int64(int64(amount)),
 		Branch:     branch,
+		ChangeType: changeTypes,
 		Repository: repoID,
 		Context:    ctx,
 	}, c.auth)
@@ -697,13 +888,77 @@ func (c *client) GetObject(ctx context.Context, repoID, ref, path string, writer
 		Repository: repoID,
 		Context:    ctx,
 	}
-	resp, err := c.remote.Objects.GetObject(params, c.auth, writer)
+	resp, _, err := c.remote.Objects.GetObject(params, c.auth, writer)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetObjectRange writes the bytes of path between start and end, inclusive, to writer
+func (c *client) GetObjectRange(ctx context.Context, repoID, ref, path string, start, end int64, writer io.Writer) (*objects.GetObjectPartialContent, error) {
+	params := &objects.GetObjectParams{
+		Ref:        ref,
+		Path:       path,
+		Range:      swag.String(fmt.Sprintf("bytes=%d-%d", start, end)),
+		Repository: repoID,
+		Context:    ctx,
+	}
+	_, resp, err := c.remote.Objects.GetObject(params, c.auth, writer)
 	if err != nil {
 		return nil, err
 	}
+	if resp == nil {
+		// the server returned the whole object
+		return nil, ErrRangeNotSupported
+	}
 	return resp, nil
 }
 
+// GetObjectDownloadManifest returns presigned URLs for downloading parts of partSize bytes of
+// path directly from the object store.  Zero partSize or expiry use the server defaults.
+func (c *client) GetObjectDownloadManifest(ctx context.Context, repoID, ref, path string, partSize int64, expiry time.Duration) (*models.DownloadManifest, error) {
+	params := &objects.GetObjectDownloadManifestParams{
+		Ref:        ref,
+		Path:       path,
+		Repository: repoID,
+		Context:    ctx,
+	}
+	if partSize > 0 {
+		params.PartSize = swag.Int64(partSize)
+	}
+	if expiry > 0 {
+		params.ExpirySeconds = swag.Int64(int64(expiry / time.Second))
+	}
+	resp, err := c.remote.Objects.GetObjectDownloadManifest(params, c.auth)
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetPayload(), nil
+}
+
+// ListObjectLocations lists all objects under prefix with the addresses of their data, and
+// presigned URLs reading them if presign is set.  Zero expiry uses the server default.
+func (c *client) ListObjectLocations(ctx context.Context, repoID, ref, prefix, after string, amount int, presign bool, expiry time.Duration) ([]*models.ObjectLocation, *models.Pagination, error) {
+	params := &objects.ListObjectLocationsParams{
+		After:      swag.String(after),
+		Amount:     swag.Int64(int64(amount)),
+		Ref:        ref,
+		Repository: repoID,
+		Prefix:     swag.String(prefix),
+		Presign:    swag.Bool(presign),
+		Context:    ctx,
+	}
+	if expiry > 0 {
+		params.ExpirySeconds = swag.Int64(int64(expiry / time.Second))
+	}
+	resp, err := c.remote.Objects.ListObjectLocations(params, c.auth)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.GetPayload().Results, resp.GetPayload().Pagination, nil
+}
+
 func (c *client) UploadObject(ctx context.Context, repoID, branchID, path string, r io.Reader) (*models.ObjectStats, error) {
 	resp, err := c.remote.Objects.UploadObject(&objects.UploadObjectParams{
 		Branch:     branchID,
@@ -718,6 +973,20 @@ func (c *client) UploadObject(ctx context.Context, repoID, branchID, path string
 	return resp.GetPayload(), nil
 }
 
+func (c *client) AppendObject(ctx context.Context, repoID, branchID, path string, r io.Reader) (*models.ObjectStats, error) {
+	resp, err := c.remote.Objects.AppendObject(&objects.AppendObjectParams{
+		Branch:     branchID,
+		Content:    runtime.NamedReader("content", r),
+		Path:       path,
+		Repository: repoID,
+		Context:    ctx,
+	}, c.auth)
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetPayload(), nil
+}
+
 func (c *client) DeleteObject(ctx context.Context, repository, branchID, path string) error {
 	_, err := c.remote.Objects.DeleteObject(&objects.DeleteObjectParams{
 		Branch:     branchID,
@@ -748,7 +1017,25 @@ func (c *client) RefsRestore(ctx context.Context, repository string, manifest *m
 	return err
 }
 
-func NewClient(endpointURL, accessKeyID, secretAccessKey string) (Client, error) {
+type clientOptions struct {
+	httpClient *http.Client
+}
+
+type ClientOption func(*clientOptions)
+
+// WithHTTPClient sets the HTTP client used to call the API, e.g. to set timeouts or wrap its
+// transport.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(o *clientOptions) {
+		o.httpClient = httpClient
+	}
+}
+
+func NewClient(endpointURL, accessKeyID, secretAccessKey string, opts ...ClientOption) (Client, error) {
+	options := &clientOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
 	parsedURL, err := url.Parse(endpointURL)
 	if err != nil {
 		return nil, err
@@ -756,8 +1043,12 @@ func NewClient(endpointURL, accessKeyID, secretAccessKey string) (Client, error)
 	if len(parsedURL.Path) == 0 {
 		parsedURL.Path = path.Join(parsedURL.Path, genclient.DefaultBasePath)
 	}
+	transport := httptransport.New(parsedURL.Host, parsedURL.Path, []string{parsedURL.Scheme})
+	if options.httpClient != nil {
+		transport = httptransport.NewWithClient(parsedURL.Host, parsedURL.Path, []string{parsedURL.Scheme}, options.httpClient)
+	}
 	return &client{
-		remote: genclient.New(httptransport.New(parsedURL.Host, parsedURL.Path, []string{parsedURL.Scheme}), strfmt.Default),
+		remote: genclient.New(transport, strfmt.Default),
 		auth:   httptransport.BasicAuth(accessKeyID, secretAccessKey),
 	}, nil
 }
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/swag"
+	"github.com/treeverse/lakefs/api/gen/models"
+	"github.com/treeverse/lakefs/api/gen/restapi/operations/commits"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/errcode"
+	"github.com/treeverse/lakefs/permissions"
+)
+
+func commitAnnotationsModel(annotations *catalog.CommitAnnotations) *models.CommitAnnotations {
+	res := make([]*models.CommitAnnotation, len(annotations.Annotations))
+	for i, annotation := range annotations.Annotations {
+		res[i] = &models.CommitAnnotation{
+			Key:        swag.String(annotation.Key),
+			Value:      swag.String(annotation.Value),
+			Annotator:  swag.String(annotation.Annotator),
+			UpdateDate: swag.Int64(annotation.UpdateDate.Unix()),
+		}
+	}
+	return &models.CommitAnnotations{
+		CommitID:    swag.String(annotations.CommitID),
+		Annotations: res,
+	}
+}
+
+func (c *Controller) GetCommitAnnotationsHandler() commits.GetCommitAnnotationsHandler {
+	return commits.GetCommitAnnotationsHandlerFunc(func(params commits.GetCommitAnnotationsParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadCommitAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return commits.NewGetCommitAnnotationsUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("get_commit_annotations")
+		annotations, err := deps.Cataloger.GetCommitAnnotations(deps.ctx, params.Repository, params.CommitID)
+		if errors.Is(err, db.ErrNotFound) {
+			return commits.NewGetCommitAnnotationsNotFound().WithPayload(responseErrorCode(errcode.CommitNotFound, "commit not found"))
+		}
+		if err != nil {
+			return commits.NewGetCommitAnnotationsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return commits.NewGetCommitAnnotationsOK().WithPayload(commitAnnotationsModel(annotations))
+	})
+}
+
+func (c *Controller) AnnotateCommitsHandler() commits.AnnotateCommitsHandler {
+	return commits.AnnotateCommitsHandlerFunc(func(params commits.AnnotateCommitsParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.AnnotateCommitAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return commits.NewAnnotateCommitsUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("annotate_commits")
+		userModel, err := c.deps.Auth.GetUser(user.ID)
+		if err != nil {
+			return commits.NewAnnotateCommitsUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		updates := make([]catalog.CommitAnnotationUpdate, len(params.Updates.Updates))
+		for i, update := range params.Updates.Updates {
+			updates[i] = catalog.CommitAnnotationUpdate{
+				Reference: swag.StringValue(update.CommitID),
+				Set:       update.Set,
+				Unset:     update.Unset,
+			}
+		}
+		results, err := deps.Cataloger.AnnotateCommits(deps.ctx, params.Repository, updates, userModel.Username)
+		switch {
+		case errors.Is(err, catalog.ErrInvalidValue):
+			return commits.NewAnnotateCommitsBadRequest().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, db.ErrNotFound):
+			return commits.NewAnnotateCommitsNotFound().WithPayload(responseErrorCode(errcode.CommitNotFound, "%s", err))
+		case err != nil:
+			return commits.NewAnnotateCommitsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		payload := &models.CommitAnnotationsList{Results: make([]*models.CommitAnnotations, len(results))}
+		for i, annotations := range results {
+			payload.Results[i] = commitAnnotationsModel(annotations)
+		}
+		return commits.NewAnnotateCommitsOK().WithPayload(payload)
+	})
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/swag"
+	"github.com/treeverse/lakefs/api/gen/models"
+	"github.com/treeverse/lakefs/api/gen/restapi/operations/commits"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/logging"
+	"github.com/treeverse/lakefs/permissions"
+)
+
+// commitLogStream writes commits to the response as newline delimited JSON.  Writes block while
+// the client is not reading, which holds the walk over the log from reading further commits.
+type commitLogStream struct {
+	*ndjsonStream
+}
+
+func newCommitLogStream(w http.ResponseWriter) *commitLogStream {
+	return &commitLogStream{ndjsonStream: newNDJSONStream(w, runtime.DefaultMime)}
+}
+
+func (s *commitLogStream) Write(commit *catalog.CommitLog) error {
+	return s.ndjsonStream.Write(transformCommitLogToCommit(commit))
+}
+
+func (c *Controller) StreamCommitLogHandler() commits.StreamCommitLogHandler {
+	return commits.StreamCommitLogHandlerFunc(func(params commits.StreamCommitLogParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadCommitAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return commits.NewStreamCommitLogUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("stream_commit_log")
+		return middleware.ResponderFunc(func(w http.ResponseWriter, _ runtime.Producer) {
+			stream := newCommitLogStream(w)
+			err := deps.Cataloger.WalkCommitLog(deps.ctx, params.Repository, params.Ref, swag.BoolValue(params.FirstParent), stream.Write)
+			switch {
+			case errors.Is(err, db.ErrNotFound):
+				stream.Fail(err, http.StatusNotFound)
+			case err != nil:
+				logging.FromContext(deps.ctx).WithError(err).Error("Commit log stream failed")
+				stream.Fail(err, http.StatusInternalServerError)
+			default:
+				stream.start()
+			}
+		})
+	})
+}
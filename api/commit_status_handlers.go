@@ -0,0 +1,96 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/swag"
+	"github.com/treeverse/lakefs/api/gen/models"
+	"github.com/treeverse/lakefs/api/gen/restapi/operations/commits"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/errcode"
+	"github.com/treeverse/lakefs/permissions"
+)
+
+func commitVerificationModel(verification *catalog.CommitVerification) *models.CommitVerification {
+	statuses := make([]*models.CommitStatus, len(verification.Statuses))
+	for i, status := range verification.Statuses {
+		statuses[i] = &models.CommitStatus{
+			Context:     swag.String(status.Context),
+			State:       swag.String(status.State),
+			Description: status.Description,
+			TargetURL:   status.TargetURL,
+			Creator:     swag.String(status.Creator),
+			UpdateDate:  swag.Int64(status.UpdateDate.Unix()),
+		}
+	}
+	return &models.CommitVerification{
+		CommitID: swag.String(verification.CommitID),
+		State:    swag.String(verification.State),
+		Statuses: statuses,
+	}
+}
+
+func (c *Controller) GetCommitVerificationHandler() commits.GetCommitVerificationHandler {
+	return commits.GetCommitVerificationHandlerFunc(func(params commits.GetCommitVerificationParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadCommitAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return commits.NewGetCommitVerificationUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("get_commit_verification")
+		verification, err := deps.Cataloger.GetCommitVerification(deps.ctx, params.Repository, params.CommitID)
+		if errors.Is(err, db.ErrNotFound) {
+			return commits.NewGetCommitVerificationNotFound().WithPayload(responseErrorCode(errcode.CommitNotFound, "commit not found"))
+		}
+		if err != nil {
+			return commits.NewGetCommitVerificationDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return commits.NewGetCommitVerificationOK().WithPayload(commitVerificationModel(verification))
+	})
+}
+
+func (c *Controller) SetCommitStatusHandler() commits.SetCommitStatusHandler {
+	return commits.SetCommitStatusHandlerFunc(func(params commits.SetCommitStatusParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.SetCommitStatusAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return commits.NewSetCommitStatusUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("set_commit_status")
+		userModel, err := c.deps.Auth.GetUser(user.ID)
+		if err != nil {
+			return commits.NewSetCommitStatusUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		err = deps.Cataloger.SetCommitStatus(deps.ctx, params.Repository, params.CommitID, catalog.CommitStatus{
+			Context:     swag.StringValue(params.Status.Context),
+			State:       swag.StringValue(params.Status.State),
+			Description: params.Status.Description,
+			TargetURL:   params.Status.TargetURL,
+			Creator:     userModel.Username,
+		})
+		switch {
+		case errors.Is(err, catalog.ErrInvalidValue):
+			return commits.NewSetCommitStatusBadRequest().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, db.ErrNotFound):
+			return commits.NewSetCommitStatusNotFound().WithPayload(responseErrorCode(errcode.CommitNotFound, "commit not found"))
+		case err != nil:
+			return commits.NewSetCommitStatusDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		verification, err := deps.Cataloger.GetCommitVerification(deps.ctx, params.Repository, params.CommitID)
+		if err != nil {
+			return commits.NewSetCommitStatusDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return commits.NewSetCommitStatusCreated().WithPayload(commitVerificationModel(verification))
+	})
+}
@@ -6,8 +6,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -15,29 +17,42 @@ import (
 	"github.com/go-openapi/runtime"
 	"github.com/go-openapi/runtime/middleware"
 	"github.com/go-openapi/swag"
+	"github.com/treeverse/lakefs/actions"
 	"github.com/treeverse/lakefs/api/gen/models"
 	"github.com/treeverse/lakefs/api/gen/restapi/operations"
+	actionsop "github.com/treeverse/lakefs/api/gen/restapi/operations/actions"
 	authop "github.com/treeverse/lakefs/api/gen/restapi/operations/auth"
 	"github.com/treeverse/lakefs/api/gen/restapi/operations/branches"
 	"github.com/treeverse/lakefs/api/gen/restapi/operations/commits"
 	configop "github.com/treeverse/lakefs/api/gen/restapi/operations/config"
+	"github.com/treeverse/lakefs/api/gen/restapi/operations/datasets"
 	hcop "github.com/treeverse/lakefs/api/gen/restapi/operations/health_check"
 	"github.com/treeverse/lakefs/api/gen/restapi/operations/metadata"
 	"github.com/treeverse/lakefs/api/gen/restapi/operations/objects"
 	"github.com/treeverse/lakefs/api/gen/restapi/operations/refs"
 	"github.com/treeverse/lakefs/api/gen/restapi/operations/repositories"
+	"github.com/treeverse/lakefs/api/gen/restapi/operations/retention"
 	setupop "github.com/treeverse/lakefs/api/gen/restapi/operations/setup"
+	"github.com/treeverse/lakefs/api/gen/restapi/operations/snapshots"
 	"github.com/treeverse/lakefs/api/gen/restapi/operations/tags"
 	"github.com/treeverse/lakefs/auth"
 	"github.com/treeverse/lakefs/auth/model"
 	"github.com/treeverse/lakefs/block"
 	"github.com/treeverse/lakefs/catalog"
 	"github.com/treeverse/lakefs/cloud"
+	"github.com/treeverse/lakefs/config"
 	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/encryption"
+	"github.com/treeverse/lakefs/errcode"
 	"github.com/treeverse/lakefs/graveler"
 	"github.com/treeverse/lakefs/httputil"
+	"github.com/treeverse/lakefs/ingest"
+	"github.com/treeverse/lakefs/jobs"
 	"github.com/treeverse/lakefs/logging"
+	"github.com/treeverse/lakefs/notifications"
 	"github.com/treeverse/lakefs/permissions"
+	"github.com/treeverse/lakefs/secrets"
+	"github.com/treeverse/lakefs/settings"
 	"github.com/treeverse/lakefs/stats"
 	"github.com/treeverse/lakefs/upload"
 )
@@ -55,8 +70,18 @@ const (
 type Dependencies struct {
 	ctx                   context.Context
 	Cataloger             catalog.Cataloger
+	Actions               actions.Store
+	Notifications         notifications.Store
+	IngestStreams         ingest.Store
+	Secrets               secrets.Store
+	Jobs                  *jobs.Manager
+	Settings              *settings.Service
+	InstanceSettings      *settings.InstanceService
+	Config                *config.Config
 	Auth                  auth.Service
+	ExternalAuth          *auth.ExternalAuth
 	BlockAdapter          block.Adapter
+	Encryptor             *encryption.Encryptor
 	MetadataManager       auth.MetadataManager
 	Migrator              db.Migrator
 	Collector             stats.Collector
@@ -66,22 +91,37 @@ type Dependencies struct {
 
 func (d *Dependencies) WithContext(ctx context.Context) *Dependencies {
 	return &Dependencies{
-		ctx:             ctx,
-		Cataloger:       d.Cataloger,
-		Auth:            d.Auth,
-		BlockAdapter:    d.BlockAdapter.WithContext(ctx),
-		MetadataManager: d.MetadataManager,
-		Migrator:        d.Migrator,
-		Collector:       d.Collector,
-		Logger:          d.Logger.WithContext(ctx),
+		ctx:              ctx,
+		Cataloger:        d.Cataloger,
+		Actions:          d.Actions,
+		Notifications:    d.Notifications,
+		IngestStreams:    d.IngestStreams,
+		Secrets:          d.Secrets,
+		Jobs:             d.Jobs,
+		Settings:         d.Settings,
+		InstanceSettings: d.InstanceSettings,
+		Config:           d.Config,
+		Auth:             d.Auth,
+		ExternalAuth:     d.ExternalAuth,
+		BlockAdapter:     d.BlockAdapter.WithContext(ctx),
+		Encryptor:        d.Encryptor,
+		MetadataManager:  d.MetadataManager,
+		Migrator:         d.Migrator,
+		Collector:        d.Collector,
+		Logger:           d.Logger.WithContext(ctx),
 	}
 }
 
 func (d *Dependencies) LogAction(action string) {
-	logging.FromContext(d.ctx).
+	logger := logging.FromContext(d.ctx).
 		WithField("action", action).
-		WithField("message_type", "action").
-		Debug("performing API action")
+		WithField("message_type", "action")
+	// actions on behalf of other users are always recorded
+	if user, ok := d.ctx.Value(UserContextKey).(*models.User); ok && user.ImpersonatedBy != "" {
+		logger.Info("performing API action on behalf of user")
+	} else {
+		logger.Debug("performing API action")
+	}
 	d.Collector.CollectEvent("api_server", action)
 }
 
@@ -122,8 +162,11 @@ func (c *Controller) Configure(api *operations.LakefsAPI) {
 	api.AuthCreateCredentialsHandler = c.CreateCredentialsHandler()
 	api.AuthDeleteCredentialsHandler = c.DeleteCredentialsHandler()
 	api.AuthGetCredentialsHandler = c.GetCredentialsHandler()
+	api.AuthRotateCredentialsHandler = c.RotateCredentialsHandler()
+	api.AuthSetCredentialsNetworkPolicyHandler = c.SetCredentialsNetworkPolicyHandler()
 	api.AuthListUserGroupsHandler = c.ListUserGroupsHandler()
 	api.AuthListUserPoliciesHandler = c.ListUserPoliciesHandler()
+	api.AuthSimulateAuthorizationHandler = c.SimulateAuthorizationHandler()
 	api.AuthAttachPolicyToUserHandler = c.AttachPolicyToUserHandler()
 	api.AuthDetachPolicyFromUserHandler = c.DetachPolicyFromUserHandler()
 	api.AuthListGroupPoliciesHandler = c.ListGroupPoliciesHandler()
@@ -133,7 +176,10 @@ func (c *Controller) Configure(api *operations.LakefsAPI) {
 	api.RepositoriesListRepositoriesHandler = c.ListRepositoriesHandler()
 	api.RepositoriesGetRepositoryHandler = c.GetRepoHandler()
 	api.RepositoriesCreateRepositoryHandler = c.CreateRepositoryHandler()
+	api.RepositoriesForkRepositoryHandler = c.ForkRepositoryHandler()
 	api.RepositoriesDeleteRepositoryHandler = c.DeleteRepositoryHandler()
+	api.RepositoriesGetRangeSplitPolicyHandler = c.GetRangeSplitPolicyHandler()
+	api.RepositoriesSetRangeSplitPolicyHandler = c.SetRangeSplitPolicyHandler()
 
 	api.BranchesListBranchesHandler = c.ListBranchesHandler()
 	api.BranchesGetBranchHandler = c.GetBranchHandler()
@@ -141,41 +187,212 @@ func (c *Controller) Configure(api *operations.LakefsAPI) {
 	api.BranchesDeleteBranchHandler = c.DeleteBranchHandler()
 	api.BranchesResetBranchHandler = c.ResetBranchHandler()
 	api.BranchesRevertHandler = c.RevertHandler()
+	api.BranchesReplacePrefixHandler = c.ReplacePrefixHandler()
+	api.BranchesGenerateEntriesHandler = c.GenerateEntriesHandler()
+	api.BranchesGetStagingStatsHandler = c.GetStagingStatsHandler()
+	api.BranchesGetOrCreateSandboxHandler = c.GetOrCreateSandboxHandler()
 
 	api.TagsListTagsHandler = c.ListTagsHandler()
 	api.TagsGetTagHandler = c.GetTagHandler()
 	api.TagsCreateTagHandler = c.CreateTagHandler()
 	api.TagsDeleteTagHandler = c.DeleteTagHandler()
 
+	api.ActionsListActionsHandler = c.ListActionsHandler()
+	api.ActionsSetActionHandler = c.SetActionHandler()
+	api.ActionsGetActionHandler = c.GetActionHandler()
+	api.ActionsDeleteActionHandler = c.DeleteActionHandler()
+	api.ActionsListActionVersionsHandler = c.ListActionVersionsHandler()
+	api.ActionsSetActionEnabledHandler = c.SetActionEnabledHandler()
+	api.ActionsTestActionHandler = c.TestActionHandler()
+
+	api.NotificationsListNotificationSinksHandler = c.ListNotificationSinksHandler()
+	api.NotificationsGetNotificationSinkHandler = c.GetNotificationSinkHandler()
+	api.NotificationsSetNotificationSinkHandler = c.SetNotificationSinkHandler()
+	api.NotificationsDeleteNotificationSinkHandler = c.DeleteNotificationSinkHandler()
+	api.IngestListIngestStreamsHandler = c.ListIngestStreamsHandler()
+	api.IngestGetIngestStreamHandler = c.GetIngestStreamHandler()
+	api.IngestSetIngestStreamHandler = c.SetIngestStreamHandler()
+	api.IngestDeleteIngestStreamHandler = c.DeleteIngestStreamHandler()
+	api.IngestIngestRecordsHandler = c.IngestRecordsHandler()
+	api.SecretsListSecretsHandler = c.ListSecretsHandler()
+	api.SecretsSetSecretHandler = c.SetSecretHandler()
+	api.SecretsDeleteSecretHandler = c.DeleteSecretHandler()
+	api.JobsListJobsHandler = c.ListJobsHandler()
+	api.JobsGetJobHandler = c.GetJobHandler()
+	api.JobsCancelJobHandler = c.CancelJobHandler()
+	api.SettingsGetRepositorySettingsHandler = c.GetRepositorySettingsHandler()
+	api.SettingsSetRepositorySettingHandler = c.SetRepositorySettingHandler()
+	api.SettingsDeleteRepositorySettingHandler = c.DeleteRepositorySettingHandler()
+	api.InstanceSettingsGetInstanceSettingsHandler = c.GetInstanceSettingsHandler()
+	api.InstanceSettingsSetInstanceSettingHandler = c.SetInstanceSettingHandler()
+	api.InstanceSettingsListInstanceSettingsAuditHandler = c.ListInstanceSettingsAuditHandler()
+	api.InstanceSettingsListFeatureFlagsHandler = c.ListFeatureFlagsHandler()
+	api.InstanceSettingsSetFeatureFlagHandler = c.SetFeatureFlagHandler()
+	api.InstanceSettingsDeleteFeatureFlagHandler = c.DeleteFeatureFlagHandler()
+	api.SchemasListSchemasHandler = c.ListSchemasHandler()
+	api.SchemasGetSchemaHandler = c.GetSchemaHandler()
+	api.SchemasSetSchemaHandler = c.SetSchemaHandler()
+	api.SchemasDeleteSchemaHandler = c.DeleteSchemaHandler()
+
+	api.RetentionGetRetentionRulesHandler = c.GetRetentionRulesHandler()
+	api.RetentionSetRetentionRulesHandler = c.SetRetentionRulesHandler()
+	api.RetentionDeleteRetentionRulesHandler = c.DeleteRetentionRulesHandler()
+	api.RetentionRunGarbageCollectionHandler = c.RunGarbageCollectionHandler()
+	api.RetentionPurgeHistoryHandler = c.PurgeHistoryHandler()
+
+	api.SnapshotsListRepositorySnapshotsHandler = c.ListRepositorySnapshotsHandler()
+	api.SnapshotsCreateRepositorySnapshotHandler = c.CreateRepositorySnapshotHandler()
+	api.SnapshotsGetRepositorySnapshotHandler = c.GetRepositorySnapshotHandler()
+	api.SnapshotsDeleteRepositorySnapshotHandler = c.DeleteRepositorySnapshotHandler()
+	api.SnapshotsRestoreRepositorySnapshotHandler = c.RestoreRepositorySnapshotHandler()
+	api.DatasetsListDatasetsHandler = c.ListDatasetsHandler()
+	api.DatasetsCreateDatasetHandler = c.CreateDatasetHandler()
+	api.DatasetsGetDatasetHandler = c.GetDatasetHandler()
+	api.DatasetsDeleteDatasetHandler = c.DeleteDatasetHandler()
+	api.DatasetsDiffDatasetHandler = c.DiffDatasetHandler()
+
 	api.CommitsCommitHandler = c.CommitHandler()
 	api.CommitsGetCommitHandler = c.GetCommitHandler()
+	api.CommitsGetCommitVerificationHandler = c.GetCommitVerificationHandler()
+	api.CommitsSetCommitStatusHandler = c.SetCommitStatusHandler()
+	api.CommitsAnnotateCommitsHandler = c.AnnotateCommitsHandler()
+	api.CommitsGetCommitAnnotationsHandler = c.GetCommitAnnotationsHandler()
+	api.LabelsFindRefsByLabelsHandler = c.FindRefsByLabelsHandler()
+	api.LabelsGetRefLabelsHandler = c.GetRefLabelsHandler()
+	api.LabelsSetRefLabelsHandler = c.SetRefLabelsHandler()
 	api.CommitsGetBranchCommitLogHandler = c.CommitsGetBranchCommitLogHandler()
+	api.CommitsCommitsBetweenHandler = c.CommitsBetweenHandler()
+	api.CommitsIsAncestorHandler = c.IsAncestorHandler()
+	api.CommitsGetLineageHandler = c.GetLineageHandler()
+	api.CommitsStreamCommitLogHandler = c.StreamCommitLogHandler()
 
 	api.RefsDiffRefsHandler = c.RefsDiffRefsHandler()
+	api.RefsExportDiffHandler = c.ExportDiffHandler()
+	api.RefsDiffRefsDataHandler = c.RefsDiffRefsDataHandler()
 	api.BranchesDiffBranchHandler = c.BranchesDiffBranchHandler()
 	api.RefsMergeIntoBranchHandler = c.MergeMergeIntoBranchHandler()
+	api.BranchesGetMergeGuardrailsHandler = c.GetMergeGuardrailsHandler()
+	api.BranchesSetMergeGuardrailsHandler = c.SetMergeGuardrailsHandler()
+	api.BranchesDeleteMergeGuardrailsHandler = c.DeleteMergeGuardrailsHandler()
+	api.BranchesListMergeQueueHandler = c.ListMergeQueueHandler()
+	api.BranchesEnqueueMergeHandler = c.EnqueueMergeHandler()
+	api.BranchesGetMergeRequestHandler = c.GetMergeRequestHandler()
+	api.BranchesCancelMergeRequestHandler = c.CancelMergeRequestHandler()
+	api.PullsListPullRequestsHandler = c.ListPullRequestsHandler()
+	api.PullsOpenPullRequestHandler = c.OpenPullRequestHandler()
+	api.PullsGetPullRequestHandler = c.GetPullRequestHandler()
+	api.PullsClosePullRequestHandler = c.ClosePullRequestHandler()
+	api.PullsListPullRequestCommentsHandler = c.ListPullRequestCommentsHandler()
+	api.PullsCommentPullRequestHandler = c.CommentPullRequestHandler()
+	api.PullsApprovePullRequestHandler = c.ApprovePullRequestHandler()
+	api.PullsMergePullRequestHandler = c.MergePullRequestHandler()
+	api.BranchesGetAutoCommitPolicyHandler = c.GetAutoCommitPolicyHandler()
+	api.BranchesSetAutoCommitPolicyHandler = c.SetAutoCommitPolicyHandler()
+	api.BranchesDeleteAutoCommitPolicyHandler = c.DeleteAutoCommitPolicyHandler()
+	api.BranchesListPathLeasesHandler = c.ListPathLeasesHandler()
+	api.BranchesAcquirePathLeaseHandler = c.AcquirePathLeaseHandler()
+	api.BranchesRenewPathLeaseHandler = c.RenewPathLeaseHandler()
+	api.BranchesReleasePathLeaseHandler = c.ReleasePathLeaseHandler()
+	api.RefsMergePreviewHandler = c.MergePreviewHandler()
 
 	api.ObjectsStatObjectHandler = c.ObjectsStatObjectHandler()
+	api.ObjectsStatObjectsHandler = c.ObjectsStatObjectsHandler()
+	api.ObjectsGetObjectHistoryHandler = c.ObjectsGetObjectHistoryHandler()
+	api.ObjectsRestoreObjectHandler = c.ObjectsRestoreObjectHandler()
+	api.ObjectsLinkObjectByDigestHandler = c.ObjectsLinkObjectByDigestHandler()
+	api.ObjectsCreateObjectLinkHandler = c.ObjectsCreateObjectLinkHandler()
 	api.ObjectsGetUnderlyingPropertiesHandler = c.ObjectsGetUnderlyingPropertiesHandler()
 	api.ObjectsListObjectsHandler = c.ObjectsListObjectsHandler()
 	api.ObjectsGetObjectHandler = c.ObjectsGetObjectHandler()
+	api.ObjectsGetObjectDownloadManifestHandler = c.ObjectsGetObjectDownloadManifestHandler()
+	api.ObjectsListObjectLocationsHandler = c.ObjectsListObjectLocationsHandler()
+	api.ObjectsCreateBrowseTokenHandler = c.ObjectsCreateBrowseTokenHandler()
+	api.ObjectsRedactObjectHandler = c.ObjectsRedactObjectHandler()
+	api.ObjectsListRedactionsHandler = c.ObjectsListRedactionsHandler()
+	api.ObjectsGetObjectsArchiveHandler = c.ObjectsGetObjectsArchiveHandler()
 	api.ObjectsUploadObjectHandler = c.ObjectsUploadObjectHandler()
+	api.ObjectsAppendObjectHandler = c.ObjectsAppendObjectHandler()
 	api.ObjectsDeleteObjectHandler = c.ObjectsDeleteObjectHandler()
 
 	api.MetadataCreateSymlinkHandler = c.MetadataCreateSymlinkHandler()
 
 	api.ConfigGetConfigHandler = c.ConfigGetConfigHandler()
+	api.ConfigReloadConfigHandler = c.ConfigReloadConfigHandler()
 
 	api.RefsDumpHandler = c.RefsDumpHandler()
 	api.RefsRestoreHandler = c.RefsRestoreHandler()
 }
 
-func (c *Controller) setupRequest(user *models.User, r *http.Request, permissions []permissions.Permission) (*Dependencies, error) {
+func (c *Controller) setupRequest(user *models.User, r *http.Request, permissions []permissions.Permission, deniable ...permissions.Permission) (*Dependencies, error) {
 	// add user to context
-	ctx := logging.AddFields(r.Context(), logging.Fields{"user": user.ID})
+	fields := logging.Fields{"user": user.ID}
+	if user.ImpersonatedBy != "" {
+		fields["impersonated_by"] = user.ImpersonatedBy
+	}
+	ctx := logging.AddFields(r.Context(), fields)
 	ctx = context.WithValue(ctx, UserContextKey, user)
+	ctx = catalog.WithPathLeaseOwner(ctx, user.ID)
 	deps := c.deps.WithContext(ctx)
-	return deps, authorize(deps.Auth, user, permissions)
+	err := authorize(deps.Auth, user, permissions, deniable...)
+	if errors.Is(err, ErrAuthorization) && len(deniable) == 0 && publicRead(deps, permissions) {
+		return deps, nil
+	}
+	return deps, err
+}
+
+// userTenant returns the tenant of the requesting user, empty for users of the installation
+func userTenant(deps *Dependencies, user *models.User) (string, error) {
+	u, err := deps.Auth.GetUser(user.ID)
+	if err != nil {
+		return "", err
+	}
+	return u.Tenant, nil
+}
+
+// checkTenantStatements verifies user may grant statements: users of a tenant may only grant
+// actions on resources of their tenant
+func checkTenantStatements(deps *Dependencies, user *models.User, statements model.Statements) error {
+	tenant, err := userTenant(deps, user)
+	if err != nil {
+		return err
+	}
+	return auth.ValidateTenantStatements(tenant, statements)
+}
+
+// checkTenantName verifies user may create a repository, group or policy named name: users of a
+// tenant create names of their tenant, and other users may not create names of existing tenants
+func checkTenantName(deps *Dependencies, user *models.User, name string) error {
+	tenant, err := userTenant(deps, user)
+	if err != nil {
+		return err
+	}
+	return auth.CheckTenantName(deps.Auth, tenant, name)
+}
+
+// checkTenantPolicy verifies user may attach the policy policyID: users of a tenant may only
+// attach policies of their tenant granting actions on resources of their tenant
+func checkTenantPolicy(deps *Dependencies, user *models.User, policyID string) error {
+	tenant, err := userTenant(deps, user)
+	if err != nil || tenant == "" {
+		return err
+	}
+	if !auth.InTenant(tenant, policyID) {
+		return fmt.Errorf("policy %s: %w", policyID, auth.ErrNotInTenant)
+	}
+	policy, err := deps.Auth.GetPolicy(policyID)
+	if err != nil {
+		return err
+	}
+	return auth.ValidateTenantStatements(tenant, policy.Statement)
+}
+
+// tenantAfter returns the listing start of tenant resources, skipping the names before the
+// tenant prefix
+func tenantAfter(tenant, after string) string {
+	if tenant != "" && after < auth.TenantPrefix(tenant) {
+		return auth.TenantPrefix(tenant)
+	}
+	return after
 }
 
 func createPaginator(nextToken string, amountResults int) *models.Pagination {
@@ -279,12 +496,25 @@ func (c *Controller) ListRepositoriesHandler() repositories.ListRepositoriesHand
 		deps.LogAction("list_repos")
 
 		after, amount := getPaginationParams(params.After, params.Amount)
+		tenant, err := userTenant(deps, user)
+		if err != nil {
+			return repositories.NewListRepositoriesDefault(http.StatusInternalServerError).
+				WithPayload(responseErrorFrom(err))
+		}
 
-		repos, hasMore, err := deps.Cataloger.ListRepositories(deps.ctx, amount, after)
+		repos, hasMore, err := deps.Cataloger.ListRepositories(deps.ctx, amount, tenantAfter(tenant, after))
 		if err != nil {
 			return repositories.NewListRepositoriesDefault(http.StatusInternalServerError).
 				WithPayload(responseError("error listing repositories: %s", err))
 		}
+		// repositories are ordered by name, those of the tenant are consecutive
+		for i, repo := range repos {
+			if !auth.InTenant(tenant, repo.Name) {
+				repos = repos[:i]
+				hasMore = false
+				break
+			}
+		}
 
 		repoList := make([]*models.Repository, len(repos))
 		var lastID string
@@ -343,7 +573,7 @@ func (c *Controller) GetRepoHandler() repositories.GetRepositoryHandler {
 		repo, err := deps.Cataloger.GetRepository(deps.ctx, params.Repository)
 		if errors.Is(err, db.ErrNotFound) {
 			return repositories.NewGetRepositoryNotFound().
-				WithPayload(responseError("repository not found"))
+				WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository not found"))
 		}
 		if err != nil {
 			return repositories.NewGetRepositoryDefault(http.StatusInternalServerError).
@@ -374,31 +604,32 @@ func (c *Controller) GetCommitHandler() commits.GetCommitHandler {
 		deps.LogAction("get_commit")
 		commit, err := deps.Cataloger.GetCommit(deps.ctx, params.Repository, params.CommitID)
 		if errors.Is(err, db.ErrNotFound) {
-			return commits.NewGetCommitNotFound().WithPayload(responseError("commit not found"))
+			return commits.NewGetCommitNotFound().WithPayload(responseErrorCode(errcode.CommitNotFound, "commit not found"))
 		}
 		if err != nil {
 			return commits.NewGetCommitDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
 		}
-		return commits.NewGetCommitOK().WithPayload(&models.Commit{
-			Committer:    commit.Committer,
-			CreationDate: commit.CreationDate.Unix(),
-			ID:           params.CommitID,
-			Message:      commit.Message,
-			Metadata:     commit.Metadata,
-			Parents:      commit.Parents,
-			MetaRangeID:  commit.MetaRangeID,
-		})
+		return commits.NewGetCommitOK().WithPayload(transformCommitLogToCommit(commit))
 	})
 }
 
 func (c *Controller) CommitHandler() commits.CommitHandler {
 	return commits.CommitHandlerFunc(func(params commits.CommitParams, user *models.User) middleware.Responder {
-		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+		perms := []permissions.Permission{
 			{
 				Action:   permissions.CreateCommitAction,
 				Resource: permissions.BranchArn(params.Repository, params.Branch),
 			},
-		})
+		}
+		// committing on behalf of someone else is allowed by policy, the committer is always the user
+		setsAuthor := params.Commit.Author != "" || params.Commit.AuthorEmail != "" || params.Commit.AuthorDate != 0
+		if setsAuthor {
+			perms = append(perms, permissions.Permission{
+				Action:   permissions.SetCommitAuthorAction,
+				Resource: permissions.BranchArn(params.Repository, params.Branch),
+			})
+		}
+		deps, err := c.setupRequest(user, params.HTTPRequest, perms, sandboxPermissions(user, params.Repository, params.Branch)...)
 		if err != nil {
 			return commits.NewCommitUnauthorized().WithPayload(responseErrorFrom(err))
 		}
@@ -407,21 +638,33 @@ func (c *Controller) CommitHandler() commits.CommitHandler {
 		if err != nil {
 			return commits.NewCommitUnauthorized().WithPayload(responseErrorFrom(err))
 		}
-		committer := userModel.Username
-		commitMessage := swag.StringValue(params.Commit.Message)
-		commit, err := deps.Cataloger.Commit(deps.ctx, params.Repository,
-			params.Branch, commitMessage, committer, params.Commit.Metadata)
+		commitParams := catalog.CommitParams{
+			Committer:      userModel.Username,
+			CommitterEmail: params.Commit.CommitterEmail,
+			Message:        swag.StringValue(params.Commit.Message),
+			Metadata:       params.Commit.Metadata,
+			Author:         params.Commit.Author,
+			AuthorEmail:    params.Commit.AuthorEmail,
+			// lineage inputs pin commits of other repositories, their committer must read them
+			AuthorizeLineageInput: authorizeReadCommitsFunc(deps, user),
+		}
+		if params.Commit.AuthorDate != 0 {
+			commitParams.AuthorDate = time.Unix(params.Commit.AuthorDate, 0)
+		}
+		commit, err := deps.Cataloger.CommitWithParams(deps.ctx, params.Repository, params.Branch, commitParams)
+		if errors.Is(err, ErrAuthorization) {
+			return commits.NewCommitUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		if errors.Is(err, catalog.ErrInvalidValue) {
+			return commits.NewCommitBadRequest().WithPayload(responseErrorFrom(err))
+		}
+		if errors.Is(err, catalog.ErrSchemaIncompatible) {
+			return commits.NewCommitPreconditionFailed().WithPayload(responseErrorFrom(err))
+		}
 		if err != nil {
 			return commits.NewCommitDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
 		}
-		return commits.NewCommitCreated().WithPayload(&models.Commit{
-			Committer:    commit.Committer,
-			CreationDate: commit.CreationDate.Unix(),
-			ID:           commit.Reference,
-			Message:      commit.Message,
-			Metadata:     commit.Metadata,
-			Parents:      commit.Parents,
-		})
+		return commits.NewCommitCreated().WithPayload(transformCommitLogToCommit(commit))
 	})
 }
 
@@ -439,14 +682,33 @@ func (c *Controller) CommitsGetBranchCommitLogHandler() commits.GetBranchCommitL
 		deps.LogAction("get_branch_commit_log")
 		cataloger := deps.Cataloger
 
+		if acceptsNDJSON(params.HTTPRequest) {
+			return streamNDJSON(deps.ctx, swag.StringValue(params.After), func(after string) ([]interface{}, string, error) {
+				commitLog, hasMore, err := cataloger.ListCommits(deps.ctx, params.Repository, params.Branch, after, MaxResultsPerPage, swag.BoolValue(params.FirstParent))
+				if err != nil {
+					return nil, "", err
+				}
+				records := make([]interface{}, len(commitLog))
+				next := ""
+				for i, commit := range commitLog {
+					records[i] = transformCommitLogToCommit(commit)
+					next = commit.Reference
+				}
+				if !hasMore {
+					next = ""
+				}
+				return records, next, nil
+			})
+		}
+
 		after, amount := getPaginationParams(params.After, params.Amount)
 		// get commit log
-		commitLog, hasMore, err := cataloger.ListCommits(deps.ctx, params.Repository, params.Branch, after, amount)
+		commitLog, hasMore, err := cataloger.ListCommits(deps.ctx, params.Repository, params.Branch, after, amount, swag.BoolValue(params.FirstParent))
 		switch {
 		case errors.Is(err, catalog.ErrBranchNotFound) || errors.Is(err, graveler.ErrBranchNotFound):
-			return commits.NewGetBranchCommitLogNotFound().WithPayload(responseError("branch '%s' not found.", params.Branch))
+			return commits.NewGetBranchCommitLogNotFound().WithPayload(responseErrorCode(errcode.BranchNotFound, "branch '%s' not found.", params.Branch))
 		case errors.Is(err, catalog.ErrRepositoryNotFound) || errors.Is(err, graveler.ErrRepositoryNotFound):
-			return commits.NewGetBranchCommitLogNotFound().WithPayload(responseError("repository '%s' not found.", params.Repository))
+			return commits.NewGetBranchCommitLogNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository '%s' not found.", params.Repository))
 		case err != nil:
 			return commits.NewGetBranchCommitLogDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
 		}
@@ -454,15 +716,7 @@ func (c *Controller) CommitsGetBranchCommitLogHandler() commits.GetBranchCommitL
 		serializedCommits := make([]*models.Commit, len(commitLog))
 		lastID := ""
 		for i, commit := range commitLog {
-			serializedCommits[i] = &models.Commit{
-				Committer:    commit.Committer,
-				CreationDate: commit.CreationDate.Unix(),
-				ID:           commit.Reference,
-				Message:      commit.Message,
-				Metadata:     commit.Metadata,
-				MetaRangeID:  commit.MetaRangeID,
-				Parents:      commit.Parents,
-			}
+			serializedCommits[i] = transformCommitLogToCommit(commit)
 			lastID = commit.Reference
 		}
 
@@ -481,6 +735,112 @@ func (c *Controller) CommitsGetBranchCommitLogHandler() commits.GetBranchCommitL
 	})
 }
 
+func (c *Controller) CommitsBetweenHandler() commits.CommitsBetweenHandler {
+	return commits.CommitsBetweenHandlerFunc(func(params commits.CommitsBetweenParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadCommitAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return commits.NewCommitsBetweenUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("commits_between")
+		after, amount := getPaginationParams(params.After, params.Amount)
+		commitLog, hasMore, err := deps.Cataloger.CommitsBetween(deps.ctx, params.Repository, params.LeftRef, params.RightRef, after, amount)
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return commits.NewCommitsBetweenNotFound().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return commits.NewCommitsBetweenDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+
+		serializedCommits := make([]*models.Commit, len(commitLog))
+		lastID := ""
+		for i, commit := range commitLog {
+			serializedCommits[i] = transformCommitLogToCommit(commit)
+			lastID = commit.Reference
+		}
+
+		returnValue := commits.NewCommitsBetweenOK().WithPayload(&commits.CommitsBetweenOKBody{
+			Pagination: &models.Pagination{
+				HasMore:    swag.Bool(hasMore),
+				Results:    swag.Int64(int64(len(serializedCommits))),
+				MaxPerPage: swag.Int64(MaxResultsPerPage),
+			},
+			Results: serializedCommits,
+		})
+		if hasMore {
+			returnValue.Payload.Pagination.NextOffset = lastID
+		}
+		return returnValue
+	})
+}
+
+func (c *Controller) IsAncestorHandler() commits.IsAncestorHandler {
+	return commits.IsAncestorHandlerFunc(func(params commits.IsAncestorParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadCommitAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return commits.NewIsAncestorUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("is_ancestor")
+		isAncestor, err := deps.Cataloger.IsAncestor(deps.ctx, params.Repository, params.AncestorRef, params.Ref)
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return commits.NewIsAncestorNotFound().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return commits.NewIsAncestorDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return commits.NewIsAncestorOK().WithPayload(&models.Ancestry{
+			IsAncestor: swag.Bool(isAncestor),
+		})
+	})
+}
+
+func (c *Controller) GetLineageHandler() commits.GetLineageHandler {
+	return commits.GetLineageHandlerFunc(func(params commits.GetLineageParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadCommitAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return commits.NewGetLineageUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("get_lineage")
+		// the graph crosses repositories, commits of repositories user cannot read are pruned
+		edges, err := deps.Cataloger.GetLineage(deps.ctx, params.Repository, params.Ref,
+			swag.BoolValue(params.Downstream), int(swag.Int64Value(params.Depth)), authorizeReadCommitsFunc(deps, user))
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return commits.NewGetLineageNotFound().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return commits.NewGetLineageDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		results := make([]*models.LineageEdge, 0, len(edges))
+		for _, edge := range edges {
+			results = append(results, &models.LineageEdge{
+				Commit: &models.LineageRef{
+					Repository: swag.String(edge.Commit.Repository),
+					Reference:  swag.String(edge.Commit.Reference),
+				},
+				Input: &models.LineageRef{
+					Repository: swag.String(edge.Input.Repository),
+					Reference:  swag.String(edge.Input.Reference),
+				},
+			})
+		}
+		return commits.NewGetLineageOK().WithPayload(&models.LineageList{Results: results})
+	})
+}
+
 func ensureStorageNamespaceRW(adapter block.Adapter, storageNamespace string) error {
 	const (
 		dummyKey  = "dummy"
@@ -513,6 +873,16 @@ func (c *Controller) CreateRepositoryHandler() repositories.CreateRepositoryHand
 		}
 		deps.LogAction("create_repo")
 
+		if err := checkTenantName(deps, user, swag.StringValue(params.Repository.Name)); err != nil {
+			return repositories.NewCreateRepositoryUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		if err := checkTenantRepositoryQuota(deps, user); err != nil {
+			return repositories.NewCreateRepositoryBadRequest().WithPayload(responseErrorFrom(err))
+		}
+		if err := checkRepositoryLimit(deps); err != nil {
+			return repositories.NewCreateRepositoryBadRequest().WithPayload(responseErrorFrom(err))
+		}
+
 		if swag.BoolValue(params.Bare) {
 			// create a bare repository. This is useful in conjunction with refs-restore to create a copy
 			// of another repository by e.g. copying the _lakefs/ directory and restoring its refs
@@ -528,6 +898,7 @@ func (c *Controller) CreateRepositoryHandler() repositories.CreateRepositoryHand
 				return repositories.NewCreateRepositoryBadRequest().
 					WithPayload(responseError("error creating repository: could not access storage namespace"))
 			}
+			setDefaultRetentionRules(deps, repo.Name)
 			return repositories.NewCreateRepositoryCreated().WithPayload(&models.Repository{
 				StorageNamespace: repo.StorageNamespace,
 				CreationDate:     repo.CreationDate.Unix(),
@@ -553,6 +924,7 @@ func (c *Controller) CreateRepositoryHandler() repositories.CreateRepositoryHand
 			return repositories.NewGetRepositoryDefault(http.StatusInternalServerError).
 				WithPayload(responseError(fmt.Sprintf("error creating repository: %s", err)))
 		}
+		setDefaultRetentionRules(deps, repo.Name)
 
 		return repositories.NewCreateRepositoryCreated().WithPayload(&models.Repository{
 			StorageNamespace: repo.StorageNamespace,
@@ -563,6 +935,77 @@ func (c *Controller) CreateRepositoryHandler() repositories.CreateRepositoryHand
 	})
 }
 
+// checkTenantRepositoryQuota verifies the tenant of user may hold another repository
+func checkTenantRepositoryQuota(deps *Dependencies, user *models.User) error {
+	tenantName, err := userTenant(deps, user)
+	if err != nil || tenantName == "" {
+		return err
+	}
+	tenant, err := deps.Auth.GetTenant(tenantName)
+	if err != nil {
+		return err
+	}
+	if tenant.MaxRepositories == 0 {
+		return nil
+	}
+	repos, _, err := deps.Cataloger.ListRepositories(deps.ctx, tenant.MaxRepositories, auth.TenantPrefix(tenantName))
+	if err != nil {
+		return err
+	}
+	count := 0
+	for _, repo := range repos {
+		if auth.InTenant(tenantName, repo.Name) {
+			count++
+		}
+	}
+	if count >= tenant.MaxRepositories {
+		return fmt.Errorf("%d repositories: %w", tenant.MaxRepositories, auth.ErrTenantQuotaExceeded)
+	}
+	return nil
+}
+
+func (c *Controller) ForkRepositoryHandler() repositories.ForkRepositoryHandler {
+	return repositories.ForkRepositoryHandlerFunc(func(params repositories.ForkRepositoryParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadRepositoryAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+			{
+				Action:   permissions.CreateRepositoryAction,
+				Resource: permissions.RepoArn(swag.StringValue(params.Fork.Name)),
+			},
+		})
+		if err != nil {
+			return repositories.NewForkRepositoryUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("fork_repo")
+		if err := checkTenantName(deps, user, swag.StringValue(params.Fork.Name)); err != nil {
+			return repositories.NewForkRepositoryUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		if err := checkRepositoryLimit(deps); err != nil {
+			return repositories.NewForkRepositoryBadRequest().WithPayload(responseErrorFrom(err))
+		}
+		repo, err := deps.Cataloger.ForkRepository(deps.ctx, params.Repository, swag.StringValue(params.Fork.Name))
+		switch {
+		case errors.Is(err, catalog.ErrRepositoryNotFound) || errors.Is(err, graveler.ErrRepositoryNotFound):
+			return repositories.NewForkRepositoryNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository not found"))
+		case errors.Is(err, graveler.ErrNotUnique):
+			return repositories.NewForkRepositoryConflict().WithPayload(responseErrorCode(errcode.AlreadyExists, "repository already exists"))
+		case errors.Is(err, catalog.ErrInvalidValue):
+			return repositories.NewForkRepositoryBadRequest().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return repositories.NewForkRepositoryDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return repositories.NewForkRepositoryCreated().WithPayload(&models.Repository{
+			StorageNamespace: repo.StorageNamespace,
+			CreationDate:     repo.CreationDate.Unix(),
+			DefaultBranch:    repo.DefaultBranch,
+			ID:               repo.Name,
+		})
+	})
+}
+
 func (c *Controller) DeleteRepositoryHandler() repositories.DeleteRepositoryHandler {
 	return repositories.DeleteRepositoryHandlerFunc(func(params repositories.DeleteRepositoryParams, user *models.User) middleware.Responder {
 		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
@@ -577,7 +1020,7 @@ func (c *Controller) DeleteRepositoryHandler() repositories.DeleteRepositoryHand
 		deps.LogAction("delete_repo")
 		err = deps.Cataloger.DeleteRepository(deps.ctx, params.Repository)
 		if errors.Is(err, db.ErrNotFound) {
-			return repositories.NewDeleteRepositoryNotFound().WithPayload(responseError("repository not found"))
+			return repositories.NewDeleteRepositoryNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository not found"))
 		}
 		if err != nil {
 			return repositories.NewDeleteRepositoryDefault(http.StatusInternalServerError).
@@ -651,9 +1094,9 @@ func (c *Controller) GetBranchHandler() branches.GetBranchHandler {
 
 		switch {
 		case errors.Is(err, catalog.ErrBranchNotFound) || errors.Is(err, graveler.ErrBranchNotFound):
-			return branches.NewGetBranchNotFound().WithPayload(responseError("branch '%s' not found.", params.Branch))
+			return branches.NewGetBranchNotFound().WithPayload(responseErrorCode(errcode.BranchNotFound, "branch '%s' not found.", params.Branch))
 		case errors.Is(err, catalog.ErrRepositoryNotFound) || errors.Is(err, graveler.ErrRepositoryNotFound):
-			return branches.NewGetBranchNotFound().WithPayload(responseError("repository '%s' not found.", params.Repository))
+			return branches.NewGetBranchNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository '%s' not found.", params.Repository))
 		case err != nil:
 			return branches.NewGetBranchDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
 		}
@@ -665,6 +1108,35 @@ func (c *Controller) GetBranchHandler() branches.GetBranchHandler {
 	})
 }
 
+func (c *Controller) GetStagingStatsHandler() branches.GetStagingStatsHandler {
+	return branches.GetStagingStatsHandlerFunc(func(params branches.GetStagingStatsParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadBranchAction,
+				Resource: permissions.BranchArn(params.Repository, params.Branch),
+			},
+		})
+		if err != nil {
+			return branches.NewGetStagingStatsUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("get_staging_stats")
+		stats, err := deps.Cataloger.GetStagingStats(deps.ctx, params.Repository, params.Branch)
+		switch {
+		case errors.Is(err, catalog.ErrBranchNotFound) || errors.Is(err, graveler.ErrBranchNotFound):
+			return branches.NewGetStagingStatsNotFound().WithPayload(responseErrorCode(errcode.BranchNotFound, "branch '%s' not found.", params.Branch))
+		case errors.Is(err, catalog.ErrRepositoryNotFound) || errors.Is(err, graveler.ErrRepositoryNotFound):
+			return branches.NewGetStagingStatsNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository '%s' not found.", params.Repository))
+		case err != nil:
+			return branches.NewGetStagingStatsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return branches.NewGetStagingStatsOK().WithPayload(&models.StagingStats{
+			Writes:    swag.Int64(stats.Writes),
+			Deletes:   swag.Int64(stats.Deletes),
+			SizeBytes: swag.Int64(stats.SizeBytes),
+		})
+	})
+}
+
 func (c *Controller) CreateBranchHandler() branches.CreateBranchHandler {
 	return branches.CreateBranchHandlerFunc(func(params branches.CreateBranchParams, user *models.User) middleware.Responder {
 		repository := params.Repository
@@ -681,7 +1153,21 @@ func (c *Controller) CreateBranchHandler() branches.CreateBranchHandler {
 		deps.LogAction("create_branch")
 		cataloger := deps.Cataloger
 		sourceRef := swag.StringValue(params.Branch.Source)
-		commitLog, err := cataloger.CreateBranch(deps.ctx, repository, branch, sourceRef)
+		var commitLog *catalog.CommitLog
+		if params.Branch.TTLSeconds > 0 {
+			commitLog, err = cataloger.CreateEphemeralBranch(deps.ctx, repository, branch, sourceRef, catalog.EphemeralBranchParams{
+				TTL:              time.Duration(params.Branch.TTLSeconds) * time.Second,
+				DeleteAfterMerge: params.Branch.DeleteAfterMerge,
+			})
+		} else if params.Branch.DeleteAfterMerge {
+			return branches.NewCreateBranchBadRequest().
+				WithPayload(responseError("delete_after_merge requires ttl_seconds"))
+		} else {
+			commitLog, err = cataloger.CreateBranch(deps.ctx, repository, branch, sourceRef)
+		}
+		if errors.Is(err, catalog.ErrInvalidValue) || errors.Is(err, catalog.ErrRequiredValue) {
+			return branches.NewCreateBranchBadRequest().WithPayload(responseErrorFrom(err))
+		}
 		if err != nil {
 			return branches.NewCreateBranchDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
 		}
@@ -689,6 +1175,44 @@ func (c *Controller) CreateBranchHandler() branches.CreateBranchHandler {
 	})
 }
 
+func (c *Controller) GetOrCreateSandboxHandler() branches.GetOrCreateSandboxHandler {
+	return branches.GetOrCreateSandboxHandlerFunc(func(params branches.GetOrCreateSandboxParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.CreateSandboxAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return branches.NewGetOrCreateSandboxUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("get_or_create_sandbox")
+		ttl := catalog.DefaultSandboxTTL
+		if params.Sandbox != nil && params.Sandbox.TTLSeconds > 0 {
+			ttl = time.Duration(params.Sandbox.TTLSeconds) * time.Second
+		}
+		sandbox, err := deps.Cataloger.GetOrCreateSandbox(deps.ctx, params.Repository, user.ID, ttl)
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return branches.NewGetOrCreateSandboxNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository '%s' not found.", params.Repository))
+		case errors.Is(err, catalog.ErrInvalidValue) || errors.Is(err, catalog.ErrRequiredValue):
+			return branches.NewGetOrCreateSandboxBadRequest().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return branches.NewGetOrCreateSandboxDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		var expiresAt int64
+		if !sandbox.ExpiresAt.IsZero() {
+			expiresAt = sandbox.ExpiresAt.Unix()
+		}
+		return branches.NewGetOrCreateSandboxOK().WithPayload(&models.Sandbox{
+			Branch:    swag.String(sandbox.Branch),
+			CommitID:  swag.String(sandbox.Reference),
+			ExpiresAt: expiresAt,
+			Created:   swag.Bool(sandbox.Created),
+		})
+	})
+}
+
 func (c *Controller) DeleteBranchHandler() branches.DeleteBranchHandler {
 	return branches.DeleteBranchHandlerFunc(func(params branches.DeleteBranchParams, user *models.User) middleware.Responder {
 		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
@@ -696,7 +1220,7 @@ func (c *Controller) DeleteBranchHandler() branches.DeleteBranchHandler {
 				Action:   permissions.DeleteBranchAction,
 				Resource: permissions.BranchArn(params.Repository, params.Branch),
 			},
-		})
+		}, sandboxPermissions(user, params.Repository, params.Branch)...)
 		if err != nil {
 			return branches.NewDeleteBranchUnauthorized().WithPayload(responseErrorFrom(err))
 		}
@@ -705,9 +1229,9 @@ func (c *Controller) DeleteBranchHandler() branches.DeleteBranchHandler {
 		err = cataloger.DeleteBranch(deps.ctx, params.Repository, params.Branch)
 		switch {
 		case errors.Is(err, catalog.ErrBranchNotFound) || errors.Is(err, graveler.ErrBranchNotFound):
-			return branches.NewDeleteBranchNotFound().WithPayload(responseError("branch '%s' not found.", params.Branch))
+			return branches.NewDeleteBranchNotFound().WithPayload(responseErrorCode(errcode.BranchNotFound, "branch '%s' not found.", params.Branch))
 		case errors.Is(err, catalog.ErrRepositoryNotFound) || errors.Is(err, graveler.ErrRepositoryNotFound):
-			return branches.NewDeleteBranchNotFound().WithPayload(responseError("repository '%s' not found.", params.Repository))
+			return branches.NewDeleteBranchNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository '%s' not found.", params.Repository))
 		case err != nil:
 			return branches.NewDeleteBranchDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
 		}
@@ -778,9 +1302,9 @@ func (c *Controller) GetTagHandler() tags.GetTagHandler {
 
 		switch {
 		case errors.Is(err, graveler.ErrTagNotFound):
-			return tags.NewGetTagNotFound().WithPayload(responseError("tag '%s' not found.", params.Tag))
+			return tags.NewGetTagNotFound().WithPayload(responseErrorCode(errcode.TagNotFound, "tag '%s' not found.", params.Tag))
 		case errors.Is(err, graveler.ErrRepositoryNotFound):
-			return tags.NewGetTagNotFound().WithPayload(responseError("repository '%s' not found.", params.Repository))
+			return tags.NewGetTagNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository '%s' not found.", params.Repository))
 		case err != nil:
 			return tags.NewGetTagDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
 		}
@@ -835,9 +1359,9 @@ func (c *Controller) DeleteTagHandler() tags.DeleteTagHandler {
 		err = cataloger.DeleteTag(deps.ctx, params.Repository, params.Tag)
 		switch {
 		case errors.Is(err, graveler.ErrTagNotFound):
-			return tags.NewDeleteTagNotFound().WithPayload(responseError("tag '%s' not found.", params.Tag))
+			return tags.NewDeleteTagNotFound().WithPayload(responseErrorCode(errcode.TagNotFound, "tag '%s' not found.", params.Tag))
 		case errors.Is(err, graveler.ErrRepositoryNotFound):
-			return tags.NewDeleteTagNotFound().WithPayload(responseError("repository '%s' not found.", params.Repository))
+			return tags.NewDeleteTagNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository '%s' not found.", params.Repository))
 		case err != nil:
 			return tags.NewDeleteTagDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
 		}
@@ -845,58 +1369,799 @@ func (c *Controller) DeleteTagHandler() tags.DeleteTagHandler {
 	})
 }
 
-func (c *Controller) MergeMergeIntoBranchHandler() refs.MergeIntoBranchHandler {
-	return refs.MergeIntoBranchHandlerFunc(func(params refs.MergeIntoBranchParams, user *models.User) middleware.Responder {
+func actionDefinitionModel(d *actions.Definition) *models.ActionDefinition {
+	return &models.ActionDefinition{
+		Name:         swag.String(d.Name),
+		Version:      swag.Int64(int64(d.Version)),
+		Enabled:      swag.Bool(d.Enabled),
+		Definition:   swag.String(d.Definition),
+		CreationDate: swag.Int64(d.CreationDate.Unix()),
+	}
+}
+
+func actionDefinitionListModel(definitions []*actions.Definition) *models.ActionDefinitionList {
+	results := make([]*models.ActionDefinition, len(definitions))
+	for i, d := range definitions {
+		results[i] = actionDefinitionModel(d)
+	}
+	return &models.ActionDefinitionList{Results: results}
+}
+
+func (c *Controller) ListActionsHandler() actionsop.ListActionsHandler {
+	return actionsop.ListActionsHandlerFunc(func(params actionsop.ListActionsParams, user *models.User) middleware.Responder {
 		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
 			{
-				Action:   permissions.CreateCommitAction,
-				Resource: permissions.BranchArn(params.Repository, params.DestinationBranch),
+				Action:   permissions.ListActionsAction,
+				Resource: permissions.RepoArn(params.Repository),
 			},
 		})
 		if err != nil {
-			return refs.NewMergeIntoBranchUnauthorized().WithPayload(responseErrorFrom(err))
+			return actionsop.NewListActionsUnauthorized().WithPayload(responseErrorFrom(err))
 		}
-		deps.LogAction("merge_branches")
-		userModel, err := deps.Auth.GetUser(user.ID)
+		deps.LogAction("list_actions")
+		definitions, err := deps.Actions.ListDefinitions(deps.ctx, params.Repository)
 		if err != nil {
-			return refs.NewMergeIntoBranchUnauthorized().WithPayload(responseErrorFrom(err))
-		}
-		var message string
-		var metadata map[string]string
-		if params.Merge != nil {
-			message = params.Merge.Message
-			metadata = params.Merge.Metadata
+			return actionsop.NewListActionsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
 		}
-		res, err := deps.Cataloger.Merge(deps.ctx,
-			params.Repository, params.DestinationBranch, params.SourceRef,
-			userModel.Username,
-			message,
-			metadata)
+		return actionsop.NewListActionsOK().WithPayload(actionDefinitionListModel(definitions))
+	})
+}
 
-		switch {
-		case err == nil:
-			payload := newMergeResultFromCatalog(res)
-			return refs.NewMergeIntoBranchOK().WithPayload(payload)
-		case errors.Is(err, catalog.ErrUnsupportedRelation):
-			return refs.NewMergeIntoBranchDefault(http.StatusInternalServerError).WithPayload(responseError("branches have no common base"))
-		case errors.Is(err, catalog.ErrBranchNotFound) || errors.Is(err, graveler.ErrBranchNotFound):
-			return refs.NewMergeIntoBranchDefault(http.StatusInternalServerError).WithPayload(responseError("a branch does not exist "))
-		case errors.Is(err, catalog.ErrConflictFound) || errors.Is(err, graveler.ErrConflictFound):
-			payload := newMergeResultFromCatalog(res)
-			return refs.NewMergeIntoBranchConflict().WithPayload(payload)
-		case errors.Is(err, catalog.ErrNoDifferenceWasFound) || errors.Is(err, graveler.ErrNoChanges):
-			return refs.NewMergeIntoBranchDefault(http.StatusInternalServerError).WithPayload(responseError("no difference was found"))
-		case errors.Is(err, graveler.ErrLockNotAcquired):
-			return refs.NewMergeIntoBranchDefault(http.StatusInternalServerError).WithPayload(responseError("branch is currently locked, try again later"))
-		default:
-			return refs.NewMergeIntoBranchDefault(http.StatusInternalServerError).WithPayload(responseError("internal error"))
+func (c *Controller) SetActionHandler() actionsop.SetActionHandler {
+	return actionsop.SetActionHandlerFunc(func(params actionsop.SetActionParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.WriteActionAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return actionsop.NewSetActionUnauthorized().WithPayload(responseErrorFrom(err))
 		}
+		deps.LogAction("set_action")
+		_, err = deps.Cataloger.GetRepository(deps.ctx, params.Repository)
+		if errors.Is(err, db.ErrNotFound) {
+			return actionsop.NewSetActionNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository '%s' not found.", params.Repository))
+		}
+		if err != nil {
+			return actionsop.NewSetActionDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		// the action name is taken from the definition, check access to it before storing
+		act, err := actions.ParseAction([]byte(swag.StringValue(params.Action.Definition)))
+		if err != nil {
+			return actionsop.NewSetActionBadRequest().WithPayload(responseErrorFrom(err))
+		}
+		err = authorize(deps.Auth, user, []permissions.Permission{
+			{
+				Action:   permissions.WriteActionAction,
+				Resource: permissions.ActionArn(params.Repository, act.Name),
+			},
+		})
+		if err != nil {
+			return actionsop.NewSetActionUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		definition, err := deps.Actions.SetDefinition(deps.ctx, params.Repository, []byte(swag.StringValue(params.Action.Definition)))
+		if err != nil {
+			return actionsop.NewSetActionDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return actionsop.NewSetActionCreated().WithPayload(actionDefinitionModel(definition))
 	})
 }
 
-func newMergeResultFromCatalog(res *catalog.MergeResult) *models.MergeResult {
-	if res == nil {
-		return &models.MergeResult{}
+func (c *Controller) GetActionHandler() actionsop.GetActionHandler {
+	return actionsop.GetActionHandlerFunc(func(params actionsop.GetActionParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadActionAction,
+				Resource: permissions.ActionArn(params.Repository, params.Action),
+			},
+		})
+		if err != nil {
+			return actionsop.NewGetActionUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("get_action")
+		definition, err := deps.Actions.GetDefinition(deps.ctx, params.Repository, params.Action)
+		switch {
+		case errors.Is(err, actions.ErrDefinitionNotFound):
+			return actionsop.NewGetActionNotFound().WithPayload(responseError("action '%s' not found.", params.Action))
+		case err != nil:
+			return actionsop.NewGetActionDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return actionsop.NewGetActionOK().WithPayload(actionDefinitionModel(definition))
+	})
+}
+
+func (c *Controller) DeleteActionHandler() actionsop.DeleteActionHandler {
+	return actionsop.DeleteActionHandlerFunc(func(params actionsop.DeleteActionParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.DeleteActionAction,
+				Resource: permissions.ActionArn(params.Repository, params.Action),
+			},
+		})
+		if err != nil {
+			return actionsop.NewDeleteActionUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("delete_action")
+		err = deps.Actions.DeleteDefinition(deps.ctx, params.Repository, params.Action)
+		switch {
+		case errors.Is(err, actions.ErrDefinitionNotFound):
+			return actionsop.NewDeleteActionNotFound().WithPayload(responseError("action '%s' not found.", params.Action))
+		case err != nil:
+			return actionsop.NewDeleteActionDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return actionsop.NewDeleteActionNoContent()
+	})
+}
+
+func (c *Controller) ListActionVersionsHandler() actionsop.ListActionVersionsHandler {
+	return actionsop.ListActionVersionsHandlerFunc(func(params actionsop.ListActionVersionsParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadActionAction,
+				Resource: permissions.ActionArn(params.Repository, params.Action),
+			},
+		})
+		if err != nil {
+			return actionsop.NewListActionVersionsUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("list_action_versions")
+		definitions, err := deps.Actions.ListDefinitionVersions(deps.ctx, params.Repository, params.Action)
+		switch {
+		case errors.Is(err, actions.ErrDefinitionNotFound):
+			return actionsop.NewListActionVersionsNotFound().WithPayload(responseError("action '%s' not found.", params.Action))
+		case err != nil:
+			return actionsop.NewListActionVersionsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return actionsop.NewListActionVersionsOK().WithPayload(actionDefinitionListModel(definitions))
+	})
+}
+
+func (c *Controller) SetActionEnabledHandler() actionsop.SetActionEnabledHandler {
+	return actionsop.SetActionEnabledHandlerFunc(func(params actionsop.SetActionEnabledParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.WriteActionAction,
+				Resource: permissions.ActionArn(params.Repository, params.Action),
+			},
+		})
+		if err != nil {
+			return actionsop.NewSetActionEnabledUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("set_action_enabled")
+		enabled := swag.BoolValue(params.Enabled.Enabled)
+		definition, err := deps.Actions.SetDefinitionEnabled(deps.ctx, params.Repository, params.Action, enabled)
+		switch {
+		case errors.Is(err, actions.ErrDefinitionNotFound):
+			return actionsop.NewSetActionEnabledNotFound().WithPayload(responseError("action '%s' not found.", params.Action))
+		case err != nil:
+			return actionsop.NewSetActionEnabledDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return actionsop.NewSetActionEnabledOK().WithPayload(actionDefinitionModel(definition))
+	})
+}
+
+func (c *Controller) TestActionHandler() actionsop.TestActionHandler {
+	return actionsop.TestActionHandlerFunc(func(params actionsop.TestActionParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.RunActionAction,
+				Resource: permissions.ActionArn(params.Repository, params.Action),
+			},
+		})
+		if err != nil {
+			return actionsop.NewTestActionUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("test_action")
+		definition, err := deps.Actions.GetDefinition(deps.ctx, params.Repository, params.Action)
+		switch {
+		case errors.Is(err, actions.ErrDefinitionNotFound):
+			return actionsop.NewTestActionNotFound().WithPayload(responseError("action '%s' not found.", params.Action))
+		case err != nil:
+			return actionsop.NewTestActionDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		act, err := actions.ParseAction([]byte(definition.Definition))
+		if err != nil {
+			return actionsop.NewTestActionDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		act, err = act.ExpandSecrets(secrets.RepositoryLookup(deps.ctx, deps.Secrets, params.Repository))
+		if errors.Is(err, secrets.ErrSecretNotFound) {
+			return actionsop.NewTestActionBadRequest().WithPayload(responseErrorFrom(err))
+		}
+		if err != nil {
+			return actionsop.NewTestActionDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		now := time.Now()
+		event := actions.Event{
+			EventType:     actions.EventTypePreCommit,
+			EventTime:     now,
+			RepositoryID:  params.Repository,
+			CommitMessage: "test event",
+			Committer:     user.ID,
+		}
+		if params.Event != nil {
+			if params.Event.EventType != "" {
+				event.EventType = actions.EventType(params.Event.EventType)
+			}
+			event.BranchID = params.Event.BranchID
+			event.SourceRef = params.Event.SourceRef
+		}
+		if event.EventType != actions.EventTypePreCommit && event.EventType != actions.EventTypePreMerge {
+			return actionsop.NewTestActionBadRequest().WithPayload(responseError("unknown event type '%s'", event.EventType))
+		}
+		runID := actions.NewRunID(now)
+		hookResults := actions.FireAction(deps.ctx, act, runID, event)
+		results := make([]*models.ActionHookResult, len(hookResults))
+		for i, r := range hookResults {
+			results[i] = &models.ActionHookResult{
+				HookID: swag.String(r.HookID),
+				Output: r.Output,
+			}
+			if r.Err != nil {
+				results[i].Error = r.Err.Error()
+			}
+		}
+		return actionsop.NewTestActionOK().WithPayload(&models.ActionTestResult{
+			RunID:   runID,
+			Results: results,
+		})
+	})
+}
+
+func newRepositorySnapshotModel(snapshot *catalog.RepositorySnapshot) *models.RepositorySnapshot {
+	branches := make([]*models.Ref, 0, len(snapshot.Branches))
+	for branchID, commitID := range snapshot.Branches {
+		branches = append(branches, &models.Ref{
+			ID:       swag.String(branchID),
+			CommitID: swag.String(commitID),
+		})
+	}
+	sort.Slice(branches, func(i, j int) bool {
+		return swag.StringValue(branches[i].ID) < swag.StringValue(branches[j].ID)
+	})
+	return &models.RepositorySnapshot{
+		ID:           swag.String(snapshot.ID),
+		CreationDate: swag.Int64(snapshot.CreationDate.Unix()),
+		Branches:     branches,
+	}
+}
+
+func (c *Controller) ListRepositorySnapshotsHandler() snapshots.ListRepositorySnapshotsHandler {
+	return snapshots.ListRepositorySnapshotsHandlerFunc(func(params snapshots.ListRepositorySnapshotsParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ListSnapshotsAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return snapshots.NewListRepositorySnapshotsUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("list_repository_snapshots")
+		if _, err := deps.Cataloger.GetRepository(deps.ctx, params.Repository); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				return snapshots.NewListRepositorySnapshotsNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository not found"))
+			}
+			return snapshots.NewListRepositorySnapshotsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		res, err := deps.Cataloger.ListRepositorySnapshots(deps.ctx, params.Repository)
+		if err != nil {
+			return snapshots.NewListRepositorySnapshotsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		results := make([]*models.RepositorySnapshot, len(res))
+		for i, snapshot := range res {
+			results[i] = newRepositorySnapshotModel(snapshot)
+		}
+		return snapshots.NewListRepositorySnapshotsOK().WithPayload(&models.RepositorySnapshotList{Results: results})
+	})
+}
+
+func (c *Controller) CreateRepositorySnapshotHandler() snapshots.CreateRepositorySnapshotHandler {
+	return snapshots.CreateRepositorySnapshotHandlerFunc(func(params snapshots.CreateRepositorySnapshotParams, user *models.User) middleware.Responder {
+		snapshotID := swag.StringValue(params.Snapshot.ID)
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.CreateSnapshotAction,
+				Resource: permissions.SnapshotArn(params.Repository, snapshotID),
+			},
+		})
+		if err != nil {
+			return snapshots.NewCreateRepositorySnapshotUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("create_repository_snapshot")
+		snapshot, err := deps.Cataloger.CreateRepositorySnapshot(deps.ctx, params.Repository, snapshotID)
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return snapshots.NewCreateRepositorySnapshotNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository not found"))
+		case errors.Is(err, graveler.ErrRepositorySnapshotExists):
+			return snapshots.NewCreateRepositorySnapshotConflict().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, catalog.ErrInvalidValue):
+			return snapshots.NewCreateRepositorySnapshotBadRequest().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return snapshots.NewCreateRepositorySnapshotDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return snapshots.NewCreateRepositorySnapshotCreated().WithPayload(newRepositorySnapshotModel(snapshot))
+	})
+}
+
+func (c *Controller) GetRepositorySnapshotHandler() snapshots.GetRepositorySnapshotHandler {
+	return snapshots.GetRepositorySnapshotHandlerFunc(func(params snapshots.GetRepositorySnapshotParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadSnapshotAction,
+				Resource: permissions.SnapshotArn(params.Repository, params.Snapshot),
+			},
+		})
+		if err != nil {
+			return snapshots.NewGetRepositorySnapshotUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("get_repository_snapshot")
+		snapshot, err := deps.Cataloger.GetRepositorySnapshot(deps.ctx, params.Repository, params.Snapshot)
+		if errors.Is(err, db.ErrNotFound) {
+			return snapshots.NewGetRepositorySnapshotNotFound().WithPayload(responseError("snapshot not found"))
+		}
+		if err != nil {
+			return snapshots.NewGetRepositorySnapshotDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return snapshots.NewGetRepositorySnapshotOK().WithPayload(newRepositorySnapshotModel(snapshot))
+	})
+}
+
+func (c *Controller) DeleteRepositorySnapshotHandler() snapshots.DeleteRepositorySnapshotHandler {
+	return snapshots.DeleteRepositorySnapshotHandlerFunc(func(params snapshots.DeleteRepositorySnapshotParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.DeleteSnapshotAction,
+				Resource: permissions.SnapshotArn(params.Repository, params.Snapshot),
+			},
+		})
+		if err != nil {
+			return snapshots.NewDeleteRepositorySnapshotUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("delete_repository_snapshot")
+		err = deps.Cataloger.DeleteRepositorySnapshot(deps.ctx, params.Repository, params.Snapshot)
+		if errors.Is(err, db.ErrNotFound) {
+			return snapshots.NewDeleteRepositorySnapshotNotFound().WithPayload(responseError("snapshot not found"))
+		}
+		if err != nil {
+			return snapshots.NewDeleteRepositorySnapshotDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return snapshots.NewDeleteRepositorySnapshotNoContent()
+	})
+}
+
+func (c *Controller) RestoreRepositorySnapshotHandler() snapshots.RestoreRepositorySnapshotHandler {
+	return snapshots.RestoreRepositorySnapshotHandlerFunc(func(params snapshots.RestoreRepositorySnapshotParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.RestoreSnapshotAction,
+				Resource: permissions.SnapshotArn(params.Repository, params.Snapshot),
+			},
+		})
+		if err != nil {
+			return snapshots.NewRestoreRepositorySnapshotUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("restore_repository_snapshot")
+		err = deps.Cataloger.RestoreRepositorySnapshot(deps.ctx, params.Repository, params.Snapshot)
+		if errors.Is(err, db.ErrNotFound) {
+			return snapshots.NewRestoreRepositorySnapshotNotFound().WithPayload(responseError("snapshot not found"))
+		}
+		if err != nil {
+			return snapshots.NewRestoreRepositorySnapshotDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return snapshots.NewRestoreRepositorySnapshotNoContent()
+	})
+}
+
+func newDatasetModel(dataset *catalog.Dataset) *models.Dataset {
+	return &models.Dataset{
+		Name:         swag.String(dataset.Name),
+		Prefixes:     dataset.Prefixes,
+		SchemaHints:  dataset.SchemaHints,
+		CreationDate: dataset.CreationDate.Unix(),
+	}
+}
+
+func (c *Controller) ListDatasetsHandler() datasets.ListDatasetsHandler {
+	return datasets.ListDatasetsHandlerFunc(func(params datasets.ListDatasetsParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ListDatasetsAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return datasets.NewListDatasetsUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("list_datasets")
+		if _, err := deps.Cataloger.GetRepository(deps.ctx, params.Repository); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				return datasets.NewListDatasetsNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository not found"))
+			}
+			return datasets.NewListDatasetsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		res, err := deps.Cataloger.ListDatasets(deps.ctx, params.Repository)
+		if err != nil {
+			return datasets.NewListDatasetsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		results := make([]*models.Dataset, len(res))
+		for i, dataset := range res {
+			results[i] = newDatasetModel(dataset)
+		}
+		return datasets.NewListDatasetsOK().WithPayload(&models.DatasetList{Results: results})
+	})
+}
+
+func (c *Controller) CreateDatasetHandler() datasets.CreateDatasetHandler {
+	return datasets.CreateDatasetHandlerFunc(func(params datasets.CreateDatasetParams, user *models.User) middleware.Responder {
+		name := swag.StringValue(params.Dataset.Name)
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.CreateDatasetAction,
+				Resource: permissions.DatasetArn(params.Repository, name),
+			},
+		})
+		if err != nil {
+			return datasets.NewCreateDatasetUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("create_dataset")
+		err = deps.Cataloger.CreateDataset(deps.ctx, params.Repository, catalog.Dataset{
+			Name:        name,
+			Prefixes:    params.Dataset.Prefixes,
+			SchemaHints: params.Dataset.SchemaHints,
+		})
+		switch {
+		case errors.Is(err, catalog.ErrDatasetAlreadyExists):
+			return datasets.NewCreateDatasetConflict().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, db.ErrNotFound):
+			return datasets.NewCreateDatasetNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository not found"))
+		case errors.Is(err, catalog.ErrInvalidValue) || errors.Is(err, catalog.ErrRequiredValue):
+			return datasets.NewCreateDatasetBadRequest().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return datasets.NewCreateDatasetDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		dataset, err := deps.Cataloger.GetDataset(deps.ctx, params.Repository, name)
+		if err != nil {
+			return datasets.NewCreateDatasetDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return datasets.NewCreateDatasetCreated().WithPayload(newDatasetModel(dataset))
+	})
+}
+
+func (c *Controller) GetDatasetHandler() datasets.GetDatasetHandler {
+	return datasets.GetDatasetHandlerFunc(func(params datasets.GetDatasetParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadDatasetAction,
+				Resource: permissions.DatasetArn(params.Repository, params.Dataset),
+			},
+		})
+		if err != nil {
+			return datasets.NewGetDatasetUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("get_dataset")
+		dataset, err := deps.Cataloger.GetDataset(deps.ctx, params.Repository, params.Dataset)
+		if errors.Is(err, db.ErrNotFound) {
+			return datasets.NewGetDatasetNotFound().WithPayload(responseError("dataset not found"))
+		}
+		if err != nil {
+			return datasets.NewGetDatasetDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return datasets.NewGetDatasetOK().WithPayload(newDatasetModel(dataset))
+	})
+}
+
+func (c *Controller) DeleteDatasetHandler() datasets.DeleteDatasetHandler {
+	return datasets.DeleteDatasetHandlerFunc(func(params datasets.DeleteDatasetParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.DeleteDatasetAction,
+				Resource: permissions.DatasetArn(params.Repository, params.Dataset),
+			},
+		})
+		if err != nil {
+			return datasets.NewDeleteDatasetUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("delete_dataset")
+		err = deps.Cataloger.DeleteDataset(deps.ctx, params.Repository, params.Dataset)
+		if errors.Is(err, db.ErrNotFound) {
+			return datasets.NewDeleteDatasetNotFound().WithPayload(responseError("dataset not found"))
+		}
+		if err != nil {
+			return datasets.NewDeleteDatasetDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return datasets.NewDeleteDatasetNoContent()
+	})
+}
+
+func (c *Controller) DiffDatasetHandler() datasets.DiffDatasetHandler {
+	return datasets.DiffDatasetHandlerFunc(func(params datasets.DiffDatasetParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadDatasetAction,
+				Resource: permissions.DatasetArn(params.Repository, params.Dataset),
+			},
+			{
+				Action:   permissions.ListObjectsAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return datasets.NewDiffDatasetUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("diff_dataset")
+		diff, hasMore, err := deps.Cataloger.DiffDataset(deps.ctx, params.Repository, params.Dataset, params.LeftRef, params.RightRef, catalog.DiffParams{
+			Limit: int(swag.Int64Value(params.Amount)),
+			After: swag.StringValue(params.After),
+			Types: transformStringsToDifferenceTypes(params.ChangeType),
+		})
+		if errors.Is(err, db.ErrNotFound) {
+			return datasets.NewDiffDatasetNotFound().WithPayload(responseErrorFrom(err))
+		}
+		if err != nil {
+			return datasets.NewDiffDatasetDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+
+		results := make([]*models.Diff, len(diff))
+		for i, d := range diff {
+			results[i] = transformDifferenceToDiff(d)
+		}
+		var nextOffset string
+		if hasMore && len(diff) > 0 {
+			nextOffset = diff[len(diff)-1].Path
+		}
+		return datasets.NewDiffDatasetOK().WithPayload(&datasets.DiffDatasetOKBody{
+			Results: results,
+			Pagination: &models.Pagination{
+				NextOffset: nextOffset,
+				HasMore:    swag.Bool(hasMore),
+				Results:    swag.Int64(int64(len(diff))),
+				MaxPerPage: swag.Int64(MaxResultsPerPage),
+			},
+		})
+	})
+}
+
+func (c *Controller) GetRetentionRulesHandler() retention.GetRetentionRulesHandler {
+	return retention.GetRetentionRulesHandlerFunc(func(params retention.GetRetentionRulesParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.GetRetentionRulesAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return retention.NewGetRetentionRulesUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("get_retention_rules")
+		rules, err := deps.Cataloger.GetRetentionRules(deps.ctx, params.Repository)
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return retention.NewGetRetentionRulesNotFound().WithPayload(responseError("retention rules for repository '%s' not found.", params.Repository))
+		case err != nil:
+			return retention.NewGetRetentionRulesDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return retention.NewGetRetentionRulesOK().WithPayload(&models.RetentionRules{
+			KeepLastCommits:   int64(rules.KeepLastCommits),
+			KeepNewerThanDays: int64(rules.KeepNewerThanDays),
+			KeepTagged:        rules.KeepTagged,
+		})
+	})
+}
+
+func (c *Controller) SetRetentionRulesHandler() retention.SetRetentionRulesHandler {
+	return retention.SetRetentionRulesHandlerFunc(func(params retention.SetRetentionRulesParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.SetRetentionRulesAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return retention.NewSetRetentionRulesUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("set_retention_rules")
+		err = deps.Cataloger.SetRetentionRules(deps.ctx, params.Repository, catalog.RetentionRules{
+			KeepLastCommits:   int(params.Rules.KeepLastCommits),
+			KeepNewerThanDays: int(params.Rules.KeepNewerThanDays),
+			KeepTagged:        params.Rules.KeepTagged,
+		})
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return retention.NewSetRetentionRulesNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository '%s' not found.", params.Repository))
+		case errors.Is(err, catalog.ErrInvalidValue):
+			return retention.NewSetRetentionRulesBadRequest().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return retention.NewSetRetentionRulesDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return retention.NewSetRetentionRulesNoContent()
+	})
+}
+
+func (c *Controller) DeleteRetentionRulesHandler() retention.DeleteRetentionRulesHandler {
+	return retention.DeleteRetentionRulesHandlerFunc(func(params retention.DeleteRetentionRulesParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.SetRetentionRulesAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return retention.NewDeleteRetentionRulesUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("delete_retention_rules")
+		err = deps.Cataloger.DeleteRetentionRules(deps.ctx, params.Repository)
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return retention.NewDeleteRetentionRulesNotFound().WithPayload(responseError("retention rules for repository '%s' not found.", params.Repository))
+		case err != nil:
+			return retention.NewDeleteRetentionRulesDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return retention.NewDeleteRetentionRulesNoContent()
+	})
+}
+
+func (c *Controller) RunGarbageCollectionHandler() retention.RunGarbageCollectionHandler {
+	return retention.RunGarbageCollectionHandlerFunc(func(params retention.RunGarbageCollectionParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.RunGarbageCollectionAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return retention.NewRunGarbageCollectionUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("run_garbage_collection")
+		gcParams := catalog.GarbageCollectionParams{
+			DryRun: swag.BoolValue(params.DryRun),
+		}
+		if swag.BoolValue(params.Async) {
+			if _, err := deps.Cataloger.GetRetentionRules(deps.ctx, params.Repository); errors.Is(err, db.ErrNotFound) {
+				return retention.NewRunGarbageCollectionNotFound().WithPayload(responseError("retention rules for repository '%s' not found.", params.Repository))
+			} else if err != nil {
+				return retention.NewRunGarbageCollectionDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+			}
+			job, err := deps.Jobs.Submit(deps.ctx, jobs.TypeGarbageCollection, params.Repository, user.ID, func(ctx context.Context) (interface{}, error) {
+				res, err := deps.Cataloger.GarbageCollect(ctx, params.Repository, gcParams)
+				if err != nil {
+					return nil, err
+				}
+				return garbageCollectionResultModel(res), nil
+			})
+			if err != nil {
+				return retention.NewRunGarbageCollectionDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+			}
+			return retention.NewRunGarbageCollectionAccepted().WithPayload(jobModel(job))
+		}
+		res, err := deps.Cataloger.GarbageCollect(deps.ctx, params.Repository, gcParams)
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return retention.NewRunGarbageCollectionNotFound().WithPayload(responseError("retention rules for repository '%s' not found.", params.Repository))
+		case err != nil:
+			return retention.NewRunGarbageCollectionDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return retention.NewRunGarbageCollectionOK().WithPayload(garbageCollectionResultModel(res))
+	})
+}
+
+func garbageCollectionResultModel(res *catalog.GarbageCollectionResult) *models.GarbageCollectionResult {
+	return &models.GarbageCollectionResult{
+		ExpiredCommits: res.ExpiredCommits,
+		RemovedCommits: res.RemovedCommits,
+		RemovedObjects: res.RemovedObjects,
+	}
+}
+
+func (c *Controller) PurgeHistoryHandler() retention.PurgeHistoryHandler {
+	return retention.PurgeHistoryHandlerFunc(func(params retention.PurgeHistoryParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.PurgeHistoryAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return retention.NewPurgeHistoryUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("purge_history")
+		res, err := deps.Cataloger.PurgeHistory(deps.ctx, params.Repository, swag.StringValue(params.Purge.Path), params.Purge.Prefix)
+		switch {
+		case errors.Is(err, catalog.ErrInvalidValue) || errors.Is(err, catalog.ErrRequiredValue):
+			return retention.NewPurgeHistoryBadRequest().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, db.ErrNotFound):
+			return retention.NewPurgeHistoryNotFound().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, graveler.ErrHistoryChanged):
+			return retention.NewPurgeHistoryConflict().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return retention.NewPurgeHistoryDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return retention.NewPurgeHistoryOK().WithPayload(&models.PurgeHistoryResult{
+			Commits:        res.Commits,
+			RemovedObjects: res.RemovedObjects,
+		})
+	})
+}
+
+func (c *Controller) MergeMergeIntoBranchHandler() refs.MergeIntoBranchHandler {
+	return refs.MergeIntoBranchHandlerFunc(func(params refs.MergeIntoBranchParams, user *models.User) middleware.Responder {
+		perms := []permissions.Permission{
+			{
+				Action:   permissions.CreateCommitAction,
+				Resource: permissions.BranchArn(params.Repository, params.DestinationBranch),
+			},
+		}
+		override := params.Merge != nil && params.Merge.OverrideGuardrails
+		if override {
+			perms = append(perms, permissions.Permission{
+				Action:   permissions.OverrideGuardrailsAction,
+				Resource: permissions.BranchArn(params.Repository, params.DestinationBranch),
+			})
+		}
+		deps, err := c.setupRequest(user, params.HTTPRequest, perms,
+			sandboxPermissions(user, params.Repository, params.DestinationBranch)...)
+		if err != nil {
+			return refs.NewMergeIntoBranchUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("merge_branches")
+		userModel, err := deps.Auth.GetUser(user.ID)
+		if err != nil {
+			return refs.NewMergeIntoBranchUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		mergeParams := catalog.MergeParams{
+			Committer:          userModel.Username,
+			OverrideGuardrails: override,
+		}
+		if params.Merge != nil {
+			mergeParams.Message = params.Merge.Message
+			mergeParams.Metadata = params.Merge.Metadata
+		}
+		res, err := deps.Cataloger.Merge(deps.ctx, params.Repository, params.DestinationBranch, params.SourceRef, mergeParams)
+
+		switch {
+		case err == nil:
+			payload := newMergeResultFromCatalog(res)
+			return refs.NewMergeIntoBranchOK().WithPayload(payload)
+		case errors.Is(err, catalog.ErrUnsupportedRelation):
+			return refs.NewMergeIntoBranchDefault(http.StatusInternalServerError).WithPayload(responseErrorCode(errcode.NoMergeBase, "branches have no common base"))
+		case errors.Is(err, catalog.ErrBranchNotFound) || errors.Is(err, graveler.ErrBranchNotFound):
+			return refs.NewMergeIntoBranchDefault(http.StatusInternalServerError).WithPayload(responseErrorCode(errcode.BranchNotFound, "a branch does not exist "))
+		case errors.Is(err, catalog.ErrConflictFound) || errors.Is(err, graveler.ErrConflictFound):
+			payload := newMergeResultFromCatalog(res)
+			return refs.NewMergeIntoBranchConflict().WithPayload(payload)
+		case errors.Is(err, catalog.ErrNoDifferenceWasFound) || errors.Is(err, graveler.ErrNoChanges):
+			return refs.NewMergeIntoBranchDefault(http.StatusInternalServerError).WithPayload(responseErrorCode(errcode.NoChanges, "no difference was found"))
+		case errors.Is(err, graveler.ErrLockNotAcquired):
+			return refs.NewMergeIntoBranchDefault(http.StatusInternalServerError).WithPayload(responseErrorCode(errcode.Locked, "branch is currently locked, try again later"))
+		case errors.Is(err, catalog.ErrMergeGuardrailsExceeded):
+			return refs.NewMergeIntoBranchPreconditionFailed().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, catalog.ErrPathLeased):
+			return refs.NewMergeIntoBranchDefault(http.StatusConflict).WithPayload(responseErrorFrom(err))
+		default:
+			return refs.NewMergeIntoBranchDefault(http.StatusInternalServerError).WithPayload(responseError("internal error"))
+		}
+	})
+}
+
+// diffRecords returns a page of differences as records for streamNDJSON
+func diffRecords(diff catalog.Differences, hasMore bool, err error) ([]interface{}, string, error) {
+	if err != nil {
+		return nil, "", err
+	}
+	records := make([]interface{}, len(diff))
+	for i, d := range diff {
+		records[i] = transformDifferenceToDiff(d)
+	}
+	next := ""
+	if hasMore && len(diff) > 0 {
+		next = diff[len(diff)-1].Path
+	}
+	return records, next, nil
+}
+
+func newMergeResultFromCatalog(res *catalog.MergeResult) *models.MergeResult {
+	if res == nil {
+		return &models.MergeResult{}
 	}
 	var summary models.MergeResultSummary
 	for k, v := range res.Summary {
@@ -911,11 +2176,395 @@ func newMergeResultFromCatalog(res *catalog.MergeResult) *models.MergeResult {
 		case catalog.DifferenceTypeConflict:
 			summary.Conflict = val
 		}
-	}
-	return &models.MergeResult{
-		Reference: res.Reference,
-		Summary:   &summary,
-	}
+	}
+	return &models.MergeResult{
+		Reference: res.Reference,
+		Summary:   &summary,
+	}
+}
+
+func (c *Controller) GetRangeSplitPolicyHandler() repositories.GetRangeSplitPolicyHandler {
+	return repositories.GetRangeSplitPolicyHandlerFunc(func(params repositories.GetRangeSplitPolicyParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.GetRangeSplitPolicyAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return repositories.NewGetRangeSplitPolicyUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("get_range_split_policy")
+		policy, err := deps.Cataloger.GetRangeSplitPolicy(deps.ctx, params.Repository)
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return repositories.NewGetRangeSplitPolicyNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository '%s' not found.", params.Repository))
+		case err != nil:
+			return repositories.NewGetRangeSplitPolicyDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return repositories.NewGetRangeSplitPolicyOK().WithPayload(&models.RangeSplitPolicy{
+			MinRangeSizeBytes:   int64(policy.MinRangeSizeBytes),
+			MaxRangeSizeBytes:   int64(policy.MaxRangeSizeBytes),
+			MaxRangeEntries:     int64(policy.MaxRangeEntries),
+			PrefixBoundaryDepth: int64(policy.PrefixBoundaryDepth),
+		})
+	})
+}
+
+func (c *Controller) SetRangeSplitPolicyHandler() repositories.SetRangeSplitPolicyHandler {
+	return repositories.SetRangeSplitPolicyHandlerFunc(func(params repositories.SetRangeSplitPolicyParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.SetRangeSplitPolicyAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return repositories.NewSetRangeSplitPolicyUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("set_range_split_policy")
+		err = deps.Cataloger.SetRangeSplitPolicy(deps.ctx, params.Repository, catalog.RangeSplitPolicy{
+			MinRangeSizeBytes:   uint64(params.Policy.MinRangeSizeBytes),
+			MaxRangeSizeBytes:   uint64(params.Policy.MaxRangeSizeBytes),
+			MaxRangeEntries:     int(params.Policy.MaxRangeEntries),
+			PrefixBoundaryDepth: int(params.Policy.PrefixBoundaryDepth),
+		})
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return repositories.NewSetRangeSplitPolicyNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository '%s' not found.", params.Repository))
+		case errors.Is(err, catalog.ErrInvalidValue):
+			return repositories.NewSetRangeSplitPolicyBadRequest().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return repositories.NewSetRangeSplitPolicyDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return repositories.NewSetRangeSplitPolicyNoContent()
+	})
+}
+
+func (c *Controller) GetMergeGuardrailsHandler() branches.GetMergeGuardrailsHandler {
+	return branches.GetMergeGuardrailsHandlerFunc(func(params branches.GetMergeGuardrailsParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.GetMergeGuardrailsAction,
+				Resource: permissions.BranchArn(params.Repository, params.Branch),
+			},
+		})
+		if err != nil {
+			return branches.NewGetMergeGuardrailsUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("get_merge_guardrails")
+		guardrails, err := deps.Cataloger.GetMergeGuardrails(deps.ctx, params.Repository, params.Branch)
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return branches.NewGetMergeGuardrailsNotFound().WithPayload(responseError("merge guardrails for branch '%s' not found.", params.Branch))
+		case err != nil:
+			return branches.NewGetMergeGuardrailsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return branches.NewGetMergeGuardrailsOK().WithPayload(&models.MergeGuardrails{
+			MaxChangedEntries:    int64(guardrails.MaxChangedEntries),
+			MaxDeletedEntries:    int64(guardrails.MaxDeletedEntries),
+			MaxBytes:             guardrails.MaxBytes,
+			RequiredStatusChecks: guardrails.RequiredStatusChecks,
+			RequiredApprovals:    int64(guardrails.RequiredApprovals),
+		})
+	})
+}
+
+func (c *Controller) SetMergeGuardrailsHandler() branches.SetMergeGuardrailsHandler {
+	return branches.SetMergeGuardrailsHandlerFunc(func(params branches.SetMergeGuardrailsParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.SetMergeGuardrailsAction,
+				Resource: permissions.BranchArn(params.Repository, params.Branch),
+			},
+		})
+		if err != nil {
+			return branches.NewSetMergeGuardrailsUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("set_merge_guardrails")
+		err = deps.Cataloger.SetMergeGuardrails(deps.ctx, params.Repository, params.Branch, catalog.MergeGuardrails{
+			MaxChangedEntries:    int(params.Guardrails.MaxChangedEntries),
+			MaxDeletedEntries:    int(params.Guardrails.MaxDeletedEntries),
+			MaxBytes:             params.Guardrails.MaxBytes,
+			RequiredStatusChecks: params.Guardrails.RequiredStatusChecks,
+			RequiredApprovals:    int(params.Guardrails.RequiredApprovals),
+		})
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return branches.NewSetMergeGuardrailsNotFound().WithPayload(responseErrorCode(errcode.BranchNotFound, "branch '%s' not found.", params.Branch))
+		case errors.Is(err, catalog.ErrInvalidValue):
+			return branches.NewSetMergeGuardrailsBadRequest().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return branches.NewSetMergeGuardrailsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return branches.NewSetMergeGuardrailsNoContent()
+	})
+}
+
+func (c *Controller) DeleteMergeGuardrailsHandler() branches.DeleteMergeGuardrailsHandler {
+	return branches.DeleteMergeGuardrailsHandlerFunc(func(params branches.DeleteMergeGuardrailsParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.SetMergeGuardrailsAction,
+				Resource: permissions.BranchArn(params.Repository, params.Branch),
+			},
+		})
+		if err != nil {
+			return branches.NewDeleteMergeGuardrailsUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("delete_merge_guardrails")
+		err = deps.Cataloger.DeleteMergeGuardrails(deps.ctx, params.Repository, params.Branch)
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return branches.NewDeleteMergeGuardrailsNotFound().WithPayload(responseError("merge guardrails for branch '%s' not found.", params.Branch))
+		case err != nil:
+			return branches.NewDeleteMergeGuardrailsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return branches.NewDeleteMergeGuardrailsNoContent()
+	})
+}
+
+func (c *Controller) GetAutoCommitPolicyHandler() branches.GetAutoCommitPolicyHandler {
+	return branches.GetAutoCommitPolicyHandlerFunc(func(params branches.GetAutoCommitPolicyParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.GetAutoCommitPolicyAction,
+				Resource: permissions.BranchArn(params.Repository, params.Branch),
+			},
+		})
+		if err != nil {
+			return branches.NewGetAutoCommitPolicyUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("get_auto_commit_policy")
+		policy, err := deps.Cataloger.GetAutoCommitPolicy(deps.ctx, params.Repository, params.Branch)
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return branches.NewGetAutoCommitPolicyNotFound().WithPayload(responseError("auto-commit policy for branch '%s' not found.", params.Branch))
+		case err != nil:
+			return branches.NewGetAutoCommitPolicyDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return branches.NewGetAutoCommitPolicyOK().WithPayload(&models.AutoCommitPolicy{
+			MaxStagedEntries: int64(policy.MaxStagedEntries),
+			IntervalSeconds:  int64(policy.IntervalSeconds),
+			MessageTemplate:  policy.MessageTemplate,
+		})
+	})
+}
+
+func (c *Controller) SetAutoCommitPolicyHandler() branches.SetAutoCommitPolicyHandler {
+	return branches.SetAutoCommitPolicyHandlerFunc(func(params branches.SetAutoCommitPolicyParams, user *models.User) middleware.Responder {
+		// the policy commits on behalf of its setter
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.SetAutoCommitPolicyAction,
+				Resource: permissions.BranchArn(params.Repository, params.Branch),
+			},
+			{
+				Action:   permissions.CreateCommitAction,
+				Resource: permissions.BranchArn(params.Repository, params.Branch),
+			},
+		})
+		if err != nil {
+			return branches.NewSetAutoCommitPolicyUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("set_auto_commit_policy")
+		err = deps.Cataloger.SetAutoCommitPolicy(deps.ctx, params.Repository, params.Branch, catalog.AutoCommitPolicy{
+			MaxStagedEntries: int(params.Policy.MaxStagedEntries),
+			IntervalSeconds:  int(params.Policy.IntervalSeconds),
+			MessageTemplate:  params.Policy.MessageTemplate,
+		})
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return branches.NewSetAutoCommitPolicyNotFound().WithPayload(responseErrorCode(errcode.BranchNotFound, "branch '%s' not found.", params.Branch))
+		case errors.Is(err, catalog.ErrInvalidValue):
+			return branches.NewSetAutoCommitPolicyBadRequest().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return branches.NewSetAutoCommitPolicyDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return branches.NewSetAutoCommitPolicyNoContent()
+	})
+}
+
+func (c *Controller) DeleteAutoCommitPolicyHandler() branches.DeleteAutoCommitPolicyHandler {
+	return branches.DeleteAutoCommitPolicyHandlerFunc(func(params branches.DeleteAutoCommitPolicyParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.SetAutoCommitPolicyAction,
+				Resource: permissions.BranchArn(params.Repository, params.Branch),
+			},
+		})
+		if err != nil {
+			return branches.NewDeleteAutoCommitPolicyUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("delete_auto_commit_policy")
+		err = deps.Cataloger.DeleteAutoCommitPolicy(deps.ctx, params.Repository, params.Branch)
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return branches.NewDeleteAutoCommitPolicyNotFound().WithPayload(responseError("auto-commit policy for branch '%s' not found.", params.Branch))
+		case err != nil:
+			return branches.NewDeleteAutoCommitPolicyDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return branches.NewDeleteAutoCommitPolicyNoContent()
+	})
+}
+
+func newPathLeaseModel(lease *catalog.PathLease) *models.PathLease {
+	return &models.PathLease{
+		ID:           swag.String(lease.ID),
+		Prefix:       swag.String(lease.Prefix),
+		Owner:        swag.String(lease.Owner),
+		ExpiresAt:    swag.Int64(lease.ExpiresAt.Unix()),
+		CreationDate: swag.Int64(lease.CreationDate.Unix()),
+	}
+}
+
+func (c *Controller) ListPathLeasesHandler() branches.ListPathLeasesHandler {
+	return branches.ListPathLeasesHandlerFunc(func(params branches.ListPathLeasesParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadBranchAction,
+				Resource: permissions.BranchArn(params.Repository, params.Branch),
+			},
+		})
+		if err != nil {
+			return branches.NewListPathLeasesUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("list_path_leases")
+		leases, err := deps.Cataloger.ListPathLeases(deps.ctx, params.Repository, params.Branch)
+		if err != nil {
+			return branches.NewListPathLeasesDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		results := make([]*models.PathLease, len(leases))
+		for i, lease := range leases {
+			results[i] = newPathLeaseModel(lease)
+		}
+		return branches.NewListPathLeasesOK().WithPayload(&models.PathLeaseList{Results: results})
+	})
+}
+
+func (c *Controller) AcquirePathLeaseHandler() branches.AcquirePathLeaseHandler {
+	return branches.AcquirePathLeaseHandlerFunc(func(params branches.AcquirePathLeaseParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.CreatePathLeaseAction,
+				Resource: permissions.BranchArn(params.Repository, params.Branch),
+			},
+		})
+		if err != nil {
+			return branches.NewAcquirePathLeaseUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("acquire_path_lease")
+		ttl := catalog.DefaultPathLeaseTTL
+		if params.Lease.TTLSeconds > 0 {
+			ttl = time.Duration(params.Lease.TTLSeconds) * time.Second
+		}
+		lease, err := deps.Cataloger.AcquirePathLease(deps.ctx, params.Repository, params.Branch, swag.StringValue(params.Lease.Prefix), user.ID, ttl)
+		switch {
+		case errors.Is(err, catalog.ErrPathLeased):
+			return branches.NewAcquirePathLeaseConflict().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, db.ErrNotFound):
+			return branches.NewAcquirePathLeaseNotFound().WithPayload(responseErrorCode(errcode.BranchNotFound, "branch '%s' not found.", params.Branch))
+		case errors.Is(err, catalog.ErrInvalidValue) || errors.Is(err, catalog.ErrRequiredValue):
+			return branches.NewAcquirePathLeaseBadRequest().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return branches.NewAcquirePathLeaseDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return branches.NewAcquirePathLeaseCreated().WithPayload(newPathLeaseModel(lease))
+	})
+}
+
+func (c *Controller) RenewPathLeaseHandler() branches.RenewPathLeaseHandler {
+	return branches.RenewPathLeaseHandlerFunc(func(params branches.RenewPathLeaseParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.CreatePathLeaseAction,
+				Resource: permissions.BranchArn(params.Repository, params.Branch),
+			},
+		})
+		if err != nil {
+			return branches.NewRenewPathLeaseUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("renew_path_lease")
+		ttl := catalog.DefaultPathLeaseTTL
+		if params.TTLSeconds != nil {
+			ttl = time.Duration(*params.TTLSeconds) * time.Second
+		}
+		lease, err := deps.Cataloger.RenewPathLease(deps.ctx, params.Repository, params.Branch, params.LeaseID, user.ID, ttl)
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return branches.NewRenewPathLeaseNotFound().WithPayload(responseError("path lease '%s' not found.", params.LeaseID))
+		case errors.Is(err, catalog.ErrInvalidValue) || errors.Is(err, catalog.ErrRequiredValue):
+			return branches.NewRenewPathLeaseBadRequest().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return branches.NewRenewPathLeaseDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return branches.NewRenewPathLeaseOK().WithPayload(newPathLeaseModel(lease))
+	})
+}
+
+func (c *Controller) ReleasePathLeaseHandler() branches.ReleasePathLeaseHandler {
+	return branches.ReleasePathLeaseHandlerFunc(func(params branches.ReleasePathLeaseParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.CreatePathLeaseAction,
+				Resource: permissions.BranchArn(params.Repository, params.Branch),
+			},
+		})
+		if err != nil {
+			return branches.NewReleasePathLeaseUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("release_path_lease")
+		err = deps.Cataloger.ReleasePathLease(deps.ctx, params.Repository, params.Branch, params.LeaseID, user.ID)
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return branches.NewReleasePathLeaseNotFound().WithPayload(responseError("path lease '%s' not found.", params.LeaseID))
+		case err != nil:
+			return branches.NewReleasePathLeaseDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return branches.NewReleasePathLeaseNoContent()
+	})
+}
+
+func (c *Controller) MergePreviewHandler() refs.MergePreviewHandler {
+	return refs.MergePreviewHandlerFunc(func(params refs.MergePreviewParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ListObjectsAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return refs.NewMergePreviewUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("merge_preview")
+		res, err := deps.Cataloger.MergePreview(deps.ctx, params.Repository, params.DestinationBranch, params.SourceRef)
+		switch {
+		case err == nil:
+		case errors.Is(err, db.ErrNotFound):
+			return refs.NewMergePreviewNotFound().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, graveler.ErrNoMergeBase):
+			return refs.NewMergePreviewDefault(http.StatusInternalServerError).WithPayload(responseErrorCode(errcode.NoMergeBase, "branches have no common base"))
+		default:
+			return refs.NewMergePreviewDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		var summary models.MergePreviewSummary
+		for k, v := range res.Summary {
+			val := int64(v)
+			switch k {
+			case catalog.DifferenceTypeAdded:
+				summary.Added = val
+			case catalog.DifferenceTypeChanged:
+				summary.Changed = val
+			case catalog.DifferenceTypeRemoved:
+				summary.Removed = val
+			}
+		}
+		return refs.NewMergePreviewOK().WithPayload(&models.MergePreview{
+			Summary:          &summary,
+			Conflicts:        res.Conflicts,
+			DestinationDirty: swag.Bool(res.DestinationDirty),
+		})
+	})
 }
 
 func (c *Controller) BranchesDiffBranchHandler() branches.DiffBranchHandler {
@@ -929,133 +2578,559 @@ func (c *Controller) BranchesDiffBranchHandler() branches.DiffBranchHandler {
 		if err != nil {
 			return branches.NewDiffBranchUnauthorized().WithPayload(responseErrorFrom(err))
 		}
-		deps.LogAction("diff_workspace")
-		cataloger := deps.Cataloger
-		limit := int(swag.Int64Value(params.Amount))
-		after := swag.StringValue(params.After)
-		diff, hasMore, err := cataloger.DiffUncommitted(deps.ctx, params.Repository, params.Branch, limit, after)
+		deps.LogAction("diff_workspace")
+		cataloger := deps.Cataloger
+		if acceptsNDJSON(params.HTTPRequest) {
+			return streamNDJSON(deps.ctx, swag.StringValue(params.After), func(after string) ([]interface{}, string, error) {
+				diff, hasMore, err := cataloger.DiffUncommitted(deps.ctx, params.Repository, params.Branch, catalog.DiffParams{
+					Limit: MaxResultsPerPage,
+					After: after,
+					Types: transformStringsToDifferenceTypes(params.ChangeType),
+				})
+				return diffRecords(diff, hasMore, err)
+			})
+		}
+		limit := int(swag.Int64Value(params.Amount))
+		after := swag.StringValue(params.After)
+		diff, hasMore, err := cataloger.DiffUncommitted(deps.ctx, params.Repository, params.Branch, catalog.DiffParams{
+			Limit: limit,
+			After: after,
+			Types: transformStringsToDifferenceTypes(params.ChangeType),
+		})
+		if err != nil {
+			return branches.NewDiffBranchDefault(http.StatusInternalServerError).
+				WithPayload(responseError("could not diff branch: %s", err))
+		}
+
+		results := make([]*models.Diff, len(diff))
+		for i, d := range diff {
+			results[i] = transformDifferenceToDiff(d)
+		}
+		var nextOffset string
+		if hasMore && len(diff) > 0 {
+			nextOffset = diff[len(diff)-1].Path
+		}
+		return branches.NewDiffBranchOK().WithPayload(&branches.DiffBranchOKBody{
+			Results: results,
+			Pagination: &models.Pagination{
+				NextOffset: nextOffset,
+				HasMore:    swag.Bool(hasMore),
+				Results:    swag.Int64(int64(len(diff))),
+				MaxPerPage: swag.Int64(MaxResultsPerPage),
+			},
+		})
+	})
+}
+
+func (c *Controller) RefsDiffRefsHandler() refs.DiffRefsHandler {
+	return refs.DiffRefsHandlerFunc(func(params refs.DiffRefsParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ListObjectsAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return refs.NewDiffRefsUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("diff_refs")
+		cataloger := deps.Cataloger
+		limit := int(swag.Int64Value(params.Amount))
+		after := swag.StringValue(params.After)
+		diffFunc := cataloger.Compare // default diff type is three-dot
+		if swag.StringValue(params.Type) == string(models.DiffTypeTwoDot) {
+			diffFunc = cataloger.Diff
+		}
+		if acceptsNDJSON(params.HTTPRequest) {
+			return streamNDJSON(deps.ctx, after, func(after string) ([]interface{}, string, error) {
+				diff, hasMore, err := diffFunc(deps.ctx, params.Repository, params.LeftRef, params.RightRef, catalog.DiffParams{
+					Limit: MaxResultsPerPage,
+					After: after,
+					Types: transformStringsToDifferenceTypes(params.ChangeType),
+				})
+				return diffRecords(diff, hasMore, err)
+			})
+		}
+		diff, hasMore, err := diffFunc(deps.ctx, params.Repository, params.LeftRef, params.RightRef, catalog.DiffParams{
+			Limit: limit,
+			After: after,
+			Types: transformStringsToDifferenceTypes(params.ChangeType),
+		})
+		if errors.Is(err, catalog.ErrFeatureNotSupported) {
+			return refs.NewDiffRefsDefault(http.StatusNotImplemented).WithPayload(responseError(err.Error()))
+		}
+		if err != nil {
+			return refs.NewDiffRefsDefault(http.StatusInternalServerError).
+				WithPayload(responseError("could not diff references: %s", err))
+		}
+
+		results := make([]*models.Diff, len(diff))
+		for i, d := range diff {
+			results[i] = transformDifferenceToDiff(d)
+		}
+		var nextOffset string
+		if hasMore && len(diff) > 0 {
+			nextOffset = diff[len(diff)-1].Path
+		}
+		return refs.NewDiffRefsOK().WithPayload(&refs.DiffRefsOKBody{
+			Results: results,
+			Pagination: &models.Pagination{
+				NextOffset: nextOffset,
+				HasMore:    swag.Bool(hasMore),
+				Results:    swag.Int64(int64(len(diff))),
+				MaxPerPage: swag.Int64(MaxResultsPerPage),
+			},
+		})
+	})
+}
+
+func (c *Controller) ExportDiffHandler() refs.ExportDiffHandler {
+	return refs.ExportDiffHandlerFunc(func(params refs.ExportDiffParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ListObjectsAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return refs.NewExportDiffUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("export_diff")
+		format := swag.StringValue(params.Export.Format)
+		if swag.BoolValue(params.Async) {
+			job, err := deps.Jobs.Submit(deps.ctx, jobs.TypeExportDiff, params.Repository, user.ID, func(ctx context.Context) (interface{}, error) {
+				export, err := deps.Cataloger.ExportDiff(ctx, params.Repository, params.LeftRef, params.RightRef, format)
+				if err != nil {
+					return nil, err
+				}
+				return diffExportModel(export), nil
+			})
+			if err != nil {
+				return refs.NewExportDiffDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+			}
+			return refs.NewExportDiffAccepted().WithPayload(jobModel(job))
+		}
+		export, err := deps.Cataloger.ExportDiff(deps.ctx, params.Repository, params.LeftRef, params.RightRef, format)
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return refs.NewExportDiffNotFound().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, catalog.ErrInvalidValue) || errors.Is(err, catalog.ErrRequiredValue):
+			return refs.NewExportDiffBadRequest().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return refs.NewExportDiffDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return refs.NewExportDiffCreated().WithPayload(diffExportModel(export))
+	})
+}
+
+func diffExportModel(export *catalog.DiffExport) *models.DiffExport {
+	return &models.DiffExport{
+		ID:           swag.String(export.ID),
+		Format:       swag.String(export.Format),
+		LeftRef:      swag.String(export.LeftReference),
+		RightRef:     swag.String(export.RightReference),
+		Location:     swag.String(export.Location),
+		Count:        swag.Int64(int64(export.Count)),
+		CreationDate: swag.Int64(export.CreationDate.Unix()),
+	}
+}
+
+func (c *Controller) ObjectsStatObjectHandler() objects.StatObjectHandler {
+	return objects.StatObjectHandlerFunc(func(params objects.StatObjectParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadObjectAction,
+				Resource: permissions.ObjectArn(params.Repository, params.Path),
+			},
+		})
+		if err != nil {
+			return objects.NewStatObjectUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("stat_object")
+		cataloger := deps.Cataloger
+
+		entry, err := cataloger.GetEntry(deps.ctx, params.Repository, params.Ref, params.Path, catalog.GetEntryParams{
+			ReturnExpired: true,
+			AuthorizeLink: authorizeLinkFunc(deps, user),
+		})
+		if errors.Is(err, db.ErrNotFound) {
+			return objects.NewStatObjectNotFound().WithPayload(responseError("resource not found"))
+		}
+		if errors.Is(err, ErrAuthorization) || errors.Is(err, catalog.ErrCrossRepositoryLink) {
+			return objects.NewStatObjectUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		if err != nil {
+			return objects.NewStatObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+
+		repo, err := cataloger.GetRepository(deps.ctx, params.Repository)
+		if err != nil {
+			return objects.NewStatObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+
+		qk, err := block.ResolveNamespace(repo.StorageNamespace, entry.PhysicalAddress)
+		if err != nil {
+			return objects.NewStatObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+
+		// serialize entry
+		obj := &models.ObjectStats{
+			Checksum:        entry.Checksum,
+			Mtime:           entry.CreationDate.Unix(),
+			Path:            params.Path,
+			PhysicalAddress: qk.Format(),
+			PathType:        objectPathType(entry),
+			SizeBytes:       entry.Size,
+			Metadata:        httputil.ObjectMetadata(entry.Metadata),
+		}
+
+		if entry.Expired {
+			return objects.NewStatObjectGone().WithPayload(obj)
+		}
+		return objects.NewStatObjectOK().WithPayload(obj)
+	})
+}
+
+func (c *Controller) ObjectsStatObjectsHandler() objects.StatObjectsHandler {
+	return objects.StatObjectsHandlerFunc(func(params objects.StatObjectsParams, user *models.User) middleware.Responder {
+		perms := make([]permissions.Permission, len(params.Request.Paths))
+		for i, path := range params.Request.Paths {
+			perms[i] = permissions.Permission{
+				Action:   permissions.ReadObjectAction,
+				Resource: permissions.ObjectArn(params.Repository, path),
+			}
+		}
+		deps, err := c.setupRequest(user, params.HTTPRequest, perms)
+		if err != nil {
+			return objects.NewStatObjectsUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("stat_objects")
+		cataloger := deps.Cataloger
+
+		repo, err := cataloger.GetRepository(deps.ctx, params.Repository)
+		if errors.Is(err, db.ErrNotFound) {
+			return objects.NewStatObjectsNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository not found"))
+		}
+		if err != nil {
+			return objects.NewStatObjectsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		entries, err := cataloger.GetEntries(deps.ctx, params.Repository, params.Ref, params.Request.Paths, catalog.GetEntryParams{
+			AuthorizeLink: authorizeLinkFunc(deps, user),
+		})
+		switch {
+		case errors.Is(err, catalog.ErrInvalidValue) || errors.Is(err, catalog.ErrRequiredValue):
+			return objects.NewStatObjectsBadRequest().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, ErrAuthorization) || errors.Is(err, catalog.ErrCrossRepositoryLink):
+			return objects.NewStatObjectsUnauthorized().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, db.ErrNotFound) || errors.Is(err, graveler.ErrNotFound):
+			return objects.NewStatObjectsNotFound().WithPayload(responseError("ref '%s' not found", params.Ref))
+		case err != nil:
+			return objects.NewStatObjectsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+
+		results := make([]*models.ObjectStatsResult, len(entries))
+		for i, entry := range entries {
+			path := params.Request.Paths[i]
+			results[i] = &models.ObjectStatsResult{
+				Path:  swag.String(path),
+				Found: swag.Bool(entry != nil),
+			}
+			if entry == nil {
+				continue
+			}
+			qk, err := block.ResolveNamespace(repo.StorageNamespace, entry.PhysicalAddress)
+			if err != nil {
+				return objects.NewStatObjectsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+			}
+			results[i].Object = &models.ObjectStats{
+				Checksum:        entry.Checksum,
+				Mtime:           entry.CreationDate.Unix(),
+				Path:            path,
+				PhysicalAddress: qk.Format(),
+				PathType:        objectPathType(entry),
+				SizeBytes:       entry.Size,
+			}
+		}
+		return objects.NewStatObjectsOK().WithPayload(&objects.StatObjectsOKBody{
+			Results: results,
+		})
+	})
+}
+
+func (c *Controller) ObjectsGetObjectHistoryHandler() objects.GetObjectHistoryHandler {
+	return objects.GetObjectHistoryHandlerFunc(func(params objects.GetObjectHistoryParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadObjectAction,
+				Resource: permissions.ObjectArn(params.Repository, params.Path),
+			},
+		})
+		if err != nil {
+			return objects.NewGetObjectHistoryUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("get_object_history")
+		cataloger := deps.Cataloger
+
+		repo, err := cataloger.GetRepository(deps.ctx, params.Repository)
+		if errors.Is(err, db.ErrNotFound) {
+			return objects.NewGetObjectHistoryNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository '%s' not found.", params.Repository))
+		}
 		if err != nil {
-			return branches.NewDiffBranchDefault(http.StatusInternalServerError).
-				WithPayload(responseError("could not diff branch: %s", err))
+			return objects.NewGetObjectHistoryDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
 		}
 
-		results := make([]*models.Diff, len(diff))
-		for i, d := range diff {
-			results[i] = transformDifferenceToDiff(d)
+		after, amount := getPaginationParams(params.After, params.Amount)
+		versions, hasMore, err := cataloger.GetEntryHistory(deps.ctx, params.Repository, params.Branch, params.Path, after, amount)
+		switch {
+		case errors.Is(err, catalog.ErrBranchNotFound) || errors.Is(err, graveler.ErrBranchNotFound):
+			return objects.NewGetObjectHistoryNotFound().WithPayload(responseErrorCode(errcode.BranchNotFound, "branch '%s' not found.", params.Branch))
+		case errors.Is(err, catalog.ErrInvalidValue):
+			return objects.NewGetObjectHistoryDefault(http.StatusBadRequest).WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return objects.NewGetObjectHistoryDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
 		}
-		var nextOffset string
-		if hasMore && len(diff) > 0 {
-			nextOffset = diff[len(diff)-1].Path
+
+		results := make([]*models.ObjectVersion, len(versions))
+		lastID := ""
+		for i, version := range versions {
+			commit := version.Commit
+			result := &models.ObjectVersion{
+				Commit:  transformCommitLogToCommit(commit),
+				Deleted: swag.Bool(version.Entry == nil),
+			}
+			if entry := version.Entry; entry != nil {
+				qk, err := block.ResolveNamespace(repo.StorageNamespace, entry.PhysicalAddress)
+				if err != nil {
+					return objects.NewGetObjectHistoryDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+				}
+				result.Object = &models.ObjectStats{
+					Checksum:        entry.Checksum,
+					Mtime:           entry.CreationDate.Unix(),
+					Path:            params.Path,
+					PhysicalAddress: qk.Format(),
+					PathType:        objectPathType(entry),
+					SizeBytes:       entry.Size,
+				}
+			}
+			results[i] = result
+			lastID = commit.Reference
 		}
-		return branches.NewDiffBranchOK().WithPayload(&branches.DiffBranchOKBody{
-			Results: results,
+
+		returnValue := objects.NewGetObjectHistoryOK().WithPayload(&objects.GetObjectHistoryOKBody{
 			Pagination: &models.Pagination{
-				NextOffset: nextOffset,
 				HasMore:    swag.Bool(hasMore),
-				Results:    swag.Int64(int64(len(diff))),
+				Results:    swag.Int64(int64(len(results))),
 				MaxPerPage: swag.Int64(MaxResultsPerPage),
 			},
+			Results: results,
 		})
+		if hasMore {
+			returnValue.Payload.Pagination.NextOffset = lastID
+		}
+		return returnValue
 	})
 }
 
-func (c *Controller) RefsDiffRefsHandler() refs.DiffRefsHandler {
-	return refs.DiffRefsHandlerFunc(func(params refs.DiffRefsParams, user *models.User) middleware.Responder {
+func (c *Controller) ObjectsRestoreObjectHandler() objects.RestoreObjectHandler {
+	return objects.RestoreObjectHandlerFunc(func(params objects.RestoreObjectParams, user *models.User) middleware.Responder {
 		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
 			{
-				Action:   permissions.ListObjectsAction,
-				Resource: permissions.RepoArn(params.Repository),
+				Action:   permissions.ReadObjectAction,
+				Resource: permissions.ObjectArn(params.Repository, params.Path),
 			},
-		})
+			{
+				Action:   permissions.WriteObjectAction,
+				Resource: permissions.ObjectArn(params.Repository, params.Path),
+			},
+		}, sandboxPermissions(user, params.Repository, params.Branch)...)
 		if err != nil {
-			return refs.NewDiffRefsUnauthorized().WithPayload(responseErrorFrom(err))
+			return objects.NewRestoreObjectUnauthorized().WithPayload(responseErrorFrom(err))
 		}
-		deps.LogAction("diff_refs")
+		deps.LogAction("restore_object")
 		cataloger := deps.Cataloger
-		limit := int(swag.Int64Value(params.Amount))
-		after := swag.StringValue(params.After)
-		diffFunc := cataloger.Compare // default diff type is three-dot
-		if swag.StringValue(params.Type) == string(models.DiffTypeTwoDot) {
-			diffFunc = cataloger.Diff
+
+		repo, err := cataloger.GetRepository(deps.ctx, params.Repository)
+		if errors.Is(err, db.ErrNotFound) {
+			return objects.NewRestoreObjectNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository not found"))
 		}
-		diff, hasMore, err := diffFunc(deps.ctx, params.Repository, params.LeftRef, params.RightRef, catalog.DiffParams{
-			Limit: limit,
-			After: after,
-		})
-		if errors.Is(err, catalog.ErrFeatureNotSupported) {
-			return refs.NewDiffRefsDefault(http.StatusNotImplemented).WithPayload(responseError(err.Error()))
+		if err != nil {
+			return objects.NewRestoreObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		err = cataloger.CheckPathLease(deps.ctx, params.Repository, params.Branch, params.Path, user.ID)
+		if errors.Is(err, catalog.ErrPathLeased) {
+			return objects.NewRestoreObjectConflict().WithPayload(responseErrorFrom(err))
 		}
 		if err != nil {
-			return refs.NewDiffRefsDefault(http.StatusInternalServerError).
-				WithPayload(responseError("could not diff references: %s", err))
+			return objects.NewRestoreObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
 		}
 
-		results := make([]*models.Diff, len(diff))
-		for i, d := range diff {
-			results[i] = transformDifferenceToDiff(d)
+		entry, err := cataloger.RestoreEntry(deps.ctx, params.Repository, params.Branch, params.Path, params.Restore.Ref)
+		switch {
+		case errors.Is(err, catalog.ErrPathLeased):
+			return objects.NewRestoreObjectConflict().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, catalog.ErrInvalidValue):
+			return objects.NewRestoreObjectBadRequest().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, db.ErrNotFound) || errors.Is(err, graveler.ErrNotFound):
+			return objects.NewRestoreObjectNotFound().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return objects.NewRestoreObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
 		}
-		var nextOffset string
-		if hasMore && len(diff) > 0 {
-			nextOffset = diff[len(diff)-1].Path
+
+		qk, err := block.ResolveNamespace(repo.StorageNamespace, entry.PhysicalAddress)
+		if err != nil {
+			return objects.NewRestoreObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
 		}
-		return refs.NewDiffRefsOK().WithPayload(&refs.DiffRefsOKBody{
-			Results: results,
-			Pagination: &models.Pagination{
-				NextOffset: nextOffset,
-				HasMore:    swag.Bool(hasMore),
-				Results:    swag.Int64(int64(len(diff))),
-				MaxPerPage: swag.Int64(MaxResultsPerPage),
-			},
+		return objects.NewRestoreObjectOK().WithPayload(&models.ObjectStats{
+			Checksum:        entry.Checksum,
+			Mtime:           entry.CreationDate.Unix(),
+			Path:            params.Path,
+			PhysicalAddress: qk.Format(),
+			PathType:        objectPathType(entry),
+			SizeBytes:       entry.Size,
 		})
 	})
 }
 
-func (c *Controller) ObjectsStatObjectHandler() objects.StatObjectHandler {
-	return objects.StatObjectHandlerFunc(func(params objects.StatObjectParams, user *models.User) middleware.Responder {
+func (c *Controller) ObjectsLinkObjectByDigestHandler() objects.LinkObjectByDigestHandler {
+	return objects.LinkObjectByDigestHandlerFunc(func(params objects.LinkObjectByDigestParams, user *models.User) middleware.Responder {
+		sourcePath := params.Digest.SourcePath
+		if sourcePath == "" {
+			sourcePath = params.Path
+		}
 		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
 			{
-				Action:   permissions.ReadObjectAction,
+				Action:   permissions.WriteObjectAction,
 				Resource: permissions.ObjectArn(params.Repository, params.Path),
 			},
-		})
+			{
+				Action:   permissions.ReadObjectAction,
+				Resource: permissions.ObjectArn(params.Repository, sourcePath),
+			},
+		}, sandboxPermissions(user, params.Repository, params.Branch)...)
 		if err != nil {
-			return objects.NewStatObjectUnauthorized().WithPayload(responseErrorFrom(err))
+			return objects.NewLinkObjectByDigestUnauthorized().WithPayload(responseErrorFrom(err))
 		}
-		deps.LogAction("stat_object")
+		deps.LogAction("link_object_by_digest")
 		cataloger := deps.Cataloger
 
-		entry, err := cataloger.GetEntry(deps.ctx, params.Repository, params.Ref, params.Path, catalog.GetEntryParams{ReturnExpired: true})
+		repo, err := cataloger.GetRepository(deps.ctx, params.Repository)
 		if errors.Is(err, db.ErrNotFound) {
-			return objects.NewStatObjectNotFound().WithPayload(responseError("resource not found"))
+			return objects.NewLinkObjectByDigestNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository not found"))
 		}
 		if err != nil {
-			return objects.NewStatObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+			return objects.NewLinkObjectByDigestDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		err = cataloger.CheckPathLease(deps.ctx, params.Repository, params.Branch, params.Path, user.ID)
+		if errors.Is(err, catalog.ErrPathLeased) {
+			return objects.NewLinkObjectByDigestConflict().WithPayload(responseErrorFrom(err))
 		}
-
-		repo, err := cataloger.GetRepository(deps.ctx, params.Repository)
 		if err != nil {
-			return objects.NewStatObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+			return objects.NewLinkObjectByDigestDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+
+		keyID, _ := deps.Encryptor.KeyID(params.Repository, params.Path)
+		entry, err := cataloger.LinkEntryByDigest(deps.ctx, params.Repository, params.Branch, params.Path, catalog.LinkByDigestParams{
+			Checksum:        swag.StringValue(params.Digest.Checksum),
+			Size:            swag.Int64Value(params.Digest.SizeBytes),
+			SourceReference: params.Digest.SourceRef,
+			SourcePath:      params.Digest.SourcePath,
+			EncryptionKeyID: keyID,
+		})
+		switch {
+		case errors.Is(err, catalog.ErrDigestMismatch):
+			return objects.NewLinkObjectByDigestPreconditionFailed().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, catalog.ErrPathLeased):
+			return objects.NewLinkObjectByDigestConflict().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, catalog.ErrInvalidValue) || errors.Is(err, catalog.ErrRequiredValue):
+			return objects.NewLinkObjectByDigestBadRequest().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, db.ErrNotFound) || errors.Is(err, graveler.ErrNotFound):
+			return objects.NewLinkObjectByDigestNotFound().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return objects.NewLinkObjectByDigestDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
 		}
 
 		qk, err := block.ResolveNamespace(repo.StorageNamespace, entry.PhysicalAddress)
 		if err != nil {
-			return objects.NewStatObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+			return objects.NewLinkObjectByDigestDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
 		}
-
-		// serialize entry
-		obj := &models.ObjectStats{
+		return objects.NewLinkObjectByDigestOK().WithPayload(&models.ObjectStats{
 			Checksum:        entry.Checksum,
 			Mtime:           entry.CreationDate.Unix(),
 			Path:            params.Path,
 			PhysicalAddress: qk.Format(),
-			PathType:        models.ObjectStatsPathTypeObject,
+			PathType:        objectPathType(entry),
 			SizeBytes:       entry.Size,
+		})
+	})
+}
+
+func (c *Controller) ObjectsCreateObjectLinkHandler() objects.CreateObjectLinkHandler {
+	return objects.CreateObjectLinkHandlerFunc(func(params objects.CreateObjectLinkParams, user *models.User) middleware.Responder {
+		target := swag.StringValue(params.Link.Target)
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.WriteObjectAction,
+				Resource: permissions.ObjectArn(params.Repository, params.Path),
+			},
+		}, sandboxPermissions(user, params.Repository, params.Branch)...)
+		if err != nil {
+			return objects.NewCreateObjectLinkUnauthorized().WithPayload(responseErrorFrom(err))
 		}
+		// the link exposes its target to the readers of the link path
+		targetRepository := params.Link.Repository
+		if targetRepository == "" {
+			targetRepository = params.Repository
+		}
+		if err := authorizeLinkFunc(deps, user)(targetRepository, target); err != nil {
+			return objects.NewCreateObjectLinkUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("create_object_link")
+		cataloger := deps.Cataloger
 
-		if entry.Expired {
-			return objects.NewStatObjectGone().WithPayload(obj)
+		repo, err := cataloger.GetRepository(deps.ctx, params.Repository)
+		if errors.Is(err, db.ErrNotFound) {
+			return objects.NewCreateObjectLinkNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository not found"))
 		}
-		return objects.NewStatObjectOK().WithPayload(obj)
+		if err != nil {
+			return objects.NewCreateObjectLinkDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		entry := catalog.DBEntry{
+			Path:         params.Path,
+			CreationDate: time.Now(),
+			LinkTarget:   target,
+		}
+		if (params.Link.Repository == "") != (params.Link.Ref == "") {
+			return objects.NewCreateObjectLinkBadRequest().WithPayload(responseError("repository and ref are set together"))
+		}
+		if params.Link.Repository != "" {
+			// pin the link to the commit of ref
+			commit, err := cataloger.GetCommit(deps.ctx, params.Link.Repository, params.Link.Ref)
+			if errors.Is(err, db.ErrNotFound) {
+				return objects.NewCreateObjectLinkNotFound().WithPayload(responseErrorFrom(err))
+			}
+			if err != nil {
+				return objects.NewCreateObjectLinkDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+			}
+			entry.LinkRepository = params.Link.Repository
+			entry.LinkRef = commit.Reference
+		}
+		err = cataloger.CreateEntry(deps.ctx, repo.Name, params.Branch, entry)
+		switch {
+		case errors.Is(err, catalog.ErrInvalidLinkTarget) || errors.Is(err, catalog.ErrInvalidValue):
+			return objects.NewCreateObjectLinkBadRequest().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, catalog.ErrPathLeased):
+			return objects.NewCreateObjectLinkConflict().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, db.ErrNotFound) || errors.Is(err, graveler.ErrNotFound):
+			return objects.NewCreateObjectLinkNotFound().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return objects.NewCreateObjectLinkDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+
+		stats, err := transformEntryToObjectStats(repo, &entry)
+		if err != nil {
+			return objects.NewCreateObjectLinkDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return objects.NewCreateObjectLinkCreated().WithPayload(stats)
 	})
 }
 
@@ -1127,34 +3202,197 @@ func (c *Controller) ObjectsGetObjectHandler() objects.GetObjectHandler {
 		}
 
 		// read the FS entry
-		entry, err := cataloger.GetEntry(deps.ctx, params.Repository, params.Ref, params.Path, catalog.GetEntryParams{ReturnExpired: true})
+		entry, err := cataloger.GetEntry(deps.ctx, params.Repository, params.Ref, params.Path, catalog.GetEntryParams{
+			ReturnExpired: true,
+			AuthorizeLink: authorizeLinkFunc(deps, user),
+		})
 		if errors.Is(err, db.ErrNotFound) {
 			return objects.NewGetObjectNotFound().WithPayload(responseError("resource not found"))
 		}
+		if errors.Is(err, ErrAuthorization) || errors.Is(err, catalog.ErrCrossRepositoryLink) {
+			return objects.NewGetObjectUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		if err != nil {
+			return objects.NewGetObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
 		if entry.Expired {
 			return objects.NewGetObjectGone().WithPayload(responseError("resource expired"))
 		}
+		redaction, err := cataloger.GetRedaction(deps.ctx, repo.StorageNamespace, entry.PhysicalAddress)
+		if err == nil {
+			return objects.NewGetObjectGone().WithPayload(responseError("resource redacted: %s", redaction.Reason))
+		}
+		if !errors.Is(err, db.ErrNotFound) {
+			return objects.NewGetObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		encrypted := encryption.IsEncrypted(entry.Metadata)
+		if encrypted {
+			err := authorize(deps.Auth, user, []permissions.Permission{
+				{
+					Action:   permissions.DecryptObjectAction,
+					Resource: permissions.ObjectArn(params.Repository, params.Path),
+				},
+			})
+			if err != nil {
+				return objects.NewGetObjectUnauthorized().WithPayload(responseErrorFrom(err))
+			}
+		}
+
+		immutable, err := cataloger.IsImmutableRef(deps.ctx, params.Repository, params.Ref)
 		if err != nil {
 			return objects.NewGetObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
 		}
-		// setup response
-		res := objects.NewGetObjectOK()
-		res.ETag = httputil.ETag(entry.Checksum)
-		res.LastModified = httputil.HeaderTimestamp(entry.CreationDate)
-		res.ContentDisposition = fmt.Sprintf("filename=\"%s\"", filepath.Base(entry.Path))
+		etag := httputil.ETag(entry.Checksum)
+		lastModified := httputil.HeaderTimestamp(entry.CreationDate)
+		cacheControl := deps.Config.GetCacheParams().CacheControl(immutable)
+		if objectCacheControl := httputil.ObjectHeaderValue(entry.Metadata, "Cache-Control"); objectCacheControl != "" {
+			cacheControl = objectCacheControl
+		}
+		if httputil.NotModified(params.HTTPRequest, etag, entry.CreationDate) {
+			return objects.NewGetObjectNotModified().
+				WithETag(etag).
+				WithLastModified(lastModified).
+				WithCacheControl(cacheControl)
+		}
+
+		// a single byte range is served, other range specs are ignored and the whole object
+		// is returned
+		var rng *httputil.Range
+		if spec := swag.StringValue(params.Range); strings.HasPrefix(spec, "bytes=") && !strings.Contains(spec, ",") {
+			r, err := httputil.ParseRange(spec, entry.Size)
+			if err != nil {
+				return objects.NewGetObjectRequestedRangeNotSatisfiable().
+					WithContentRange(fmt.Sprintf("bytes */%d", entry.Size)).
+					WithPayload(responseErrorCode(errcode.InvalidValue, "range %s not satisfiable for object of size %d", spec, entry.Size))
+			}
+			rng = &r
+		}
 
-		// build a response as a multi-reader
-		res.ContentLength = entry.Size
-		reader, err := deps.BlockAdapter.Get(block.ObjectPointer{StorageNamespace: repo.StorageNamespace, Identifier: entry.PhysicalAddress}, entry.Size)
+		pointer := block.ObjectPointer{StorageNamespace: repo.StorageNamespace, Identifier: entry.PhysicalAddress}
+		var reader io.ReadCloser
+		var offset int64
+		if rng != nil {
+			offset = rng.StartOffset
+			reader, err = deps.BlockAdapter.GetRange(pointer, rng.StartOffset, rng.EndOffset)
+		} else {
+			reader, err = deps.BlockAdapter.Get(pointer, entry.Size)
+		}
 		if err != nil {
 			return objects.NewGetObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
 		}
+		if encrypted {
+			decrypted, err := deps.Encryptor.DecryptReadCloser(deps.ctx, entry.Metadata, reader, offset)
+			if err != nil {
+				_ = reader.Close()
+				return objects.NewGetObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+			}
+			reader = decrypted
+		}
+
+		contentDisposition := httputil.ObjectHeaderValue(entry.Metadata, "Content-Disposition")
+		if contentDisposition == "" {
+			contentDisposition = fmt.Sprintf("filename=\"%s\"", filepath.Base(entry.Path))
+		}
+		contentType := httputil.ObjectHeaderValue(entry.Metadata, "Content-Type")
+		contentEncoding := httputil.ObjectHeaderValue(entry.Metadata, "Content-Encoding")
+		if rng != nil {
+			return objects.NewGetObjectPartialContent().
+				WithETag(etag).
+				WithLastModified(lastModified).
+				WithCacheControl(cacheControl).
+				WithContentDisposition(contentDisposition).
+				WithContentType(contentType).
+				WithContentEncoding(contentEncoding).
+				WithAcceptRanges("bytes").
+				WithContentLength(rng.EndOffset - rng.StartOffset + 1). // both range ends are inclusive
+				WithContentRange(fmt.Sprintf("bytes %d-%d/%d", rng.StartOffset, rng.EndOffset, entry.Size)).
+				WithPayload(reader)
+		}
+		return objects.NewGetObjectOK().
+			WithETag(etag).
+			WithLastModified(lastModified).
+			WithCacheControl(cacheControl).
+			WithContentDisposition(contentDisposition).
+			WithContentType(contentType).
+			WithContentEncoding(contentEncoding).
+			WithAcceptRanges("bytes").
+			WithContentLength(entry.Size).
+			WithPayload(reader)
+	})
+}
+
+func newRedactionModel(redaction *catalog.Redaction) *models.Redaction {
+	return &models.Redaction{
+		Path:            swag.String(redaction.Path),
+		PhysicalAddress: swag.String(redaction.PhysicalAddress),
+		Reason:          swag.String(redaction.Reason),
+		RedactedBy:      swag.String(redaction.RedactedBy),
+		CreationDate:    swag.Int64(redaction.CreationDate.Unix()),
+	}
+}
+
+func (c *Controller) ObjectsRedactObjectHandler() objects.RedactObjectHandler {
+	return objects.RedactObjectHandlerFunc(func(params objects.RedactObjectParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.RedactObjectAction,
+				Resource: permissions.ObjectArn(params.Repository, params.Path),
+			},
+		})
+		if err != nil {
+			return objects.NewRedactObjectUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("redact_object")
+		userModel, err := deps.Auth.GetUser(user.ID)
+		if err != nil {
+			return objects.NewRedactObjectUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		redaction, err := deps.Cataloger.RedactEntry(deps.ctx, params.Repository, params.Ref, params.Path,
+			swag.StringValue(params.Redaction.Reason), userModel.Username)
+		switch {
+		case errors.Is(err, catalog.ErrInvalidValue) || errors.Is(err, catalog.ErrRequiredValue):
+			return objects.NewRedactObjectBadRequest().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, db.ErrAlreadyExists):
+			return objects.NewRedactObjectConflict().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, db.ErrNotFound):
+			return objects.NewRedactObjectNotFound().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return objects.NewRedactObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return objects.NewRedactObjectCreated().WithPayload(newRedactionModel(redaction))
+	})
+}
 
-		// done
-		res.Payload = reader
-		return res
+func (c *Controller) ObjectsListRedactionsHandler() objects.ListRedactionsHandler {
+	return objects.ListRedactionsHandlerFunc(func(params objects.ListRedactionsParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ListRedactionsAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return objects.NewListRedactionsUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("list_redactions")
+		if _, err := deps.Cataloger.GetRepository(deps.ctx, params.Repository); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				return objects.NewListRedactionsNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository not found"))
+			}
+			return objects.NewListRedactionsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		res, err := deps.Cataloger.ListRedactions(deps.ctx, params.Repository)
+		if err != nil {
+			return objects.NewListRedactionsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		results := make([]*models.Redaction, len(res))
+		for i, redaction := range res {
+			results[i] = newRedactionModel(redaction)
+		}
+		return objects.NewListRedactionsOK().WithPayload(&models.RedactionList{Results: results})
 	})
 }
+
 func (c *Controller) ConfigGetConfigHandler() configop.GetConfigHandler {
 	return configop.GetConfigHandlerFunc(func(params configop.GetConfigParams, user *models.User) middleware.Responder {
 		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
@@ -1174,6 +3412,25 @@ func (c *Controller) ConfigGetConfigHandler() configop.GetConfigHandler {
 	})
 }
 
+func (c *Controller) ConfigReloadConfigHandler() configop.ReloadConfigHandler {
+	return configop.ReloadConfigHandlerFunc(func(params configop.ReloadConfigParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReloadConfigAction,
+				Resource: permissions.All,
+			},
+		})
+		if err != nil {
+			return configop.NewReloadConfigUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("reload_config")
+		if err := deps.Config.Reload(); err != nil {
+			return configop.NewReloadConfigDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return configop.NewReloadConfigNoContent()
+	})
+}
+
 func (c *Controller) MetadataCreateSymlinkHandler() metadata.CreateSymlinkHandler {
 	return metadata.CreateSymlinkHandlerFunc(func(params metadata.CreateSymlinkParams, user *models.User) middleware.Responder {
 		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
@@ -1279,10 +3536,36 @@ func (c *Controller) ObjectsListObjectsHandler() objects.ListObjectsHandler {
 		deps.LogAction("list_objects")
 		cataloger := deps.Cataloger
 
+		if acceptsNDJSON(params.HTTPRequest) {
+			var repo *catalog.Repository
+			return streamNDJSON(deps.ctx, swag.StringValue(params.After), func(after string) ([]interface{}, string, error) {
+				var err error
+				if repo == nil {
+					repo, err = cataloger.GetRepository(deps.ctx, params.Repository)
+					if err != nil {
+						return nil, "", err
+					}
+				}
+				entries, nextToken, err := cataloger.ListEntriesWithToken(deps.ctx, params.Repository, params.Ref,
+					swag.StringValue(params.Prefix), after, catalog.DefaultPathDelimiter, MaxResultsPerPage)
+				if err != nil {
+					return nil, "", err
+				}
+				records := make([]interface{}, len(entries))
+				for i, entry := range entries {
+					records[i], err = transformEntryToObjectStats(repo, entry)
+					if err != nil {
+						return nil, "", err
+					}
+				}
+				return records, nextToken, nil
+			})
+		}
+
 		after, amount := getPaginationParams(params.After, params.Amount)
 
 		delimiter := catalog.DefaultPathDelimiter
-		res, hasMore, err := cataloger.ListEntries(
+		res, nextToken, err := cataloger.ListEntriesWithToken(
 			deps.ctx,
 			params.Repository,
 			params.Ref,
@@ -1293,6 +3576,9 @@ func (c *Controller) ObjectsListObjectsHandler() objects.ListObjectsHandler {
 		if errors.Is(err, db.ErrNotFound) {
 			return objects.NewListObjectsNotFound().WithPayload(responseError("could not find requested path"))
 		}
+		if errors.Is(err, graveler.ErrSnapshotExpired) || errors.Is(err, catalog.ErrInvalidContinuationToken) {
+			return objects.NewListObjectsDefault(http.StatusConflict).WithPayload(responseErrorFrom(err))
+		}
 		if err != nil {
 			return objects.NewListObjectsDefault(http.StatusInternalServerError).
 				WithPayload(responseError("error while listing objects: %s", err))
@@ -1304,47 +3590,16 @@ func (c *Controller) ObjectsListObjectsHandler() objects.ListObjectsHandler {
 		}
 
 		objList := make([]*models.ObjectStats, len(res))
-		var lastID string
 		for i, entry := range res {
-			qk, err := block.ResolveNamespace(repo.StorageNamespace, entry.PhysicalAddress)
-			if err != nil {
-				return objects.NewStatObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
-			}
-
-			if entry.CommonLevel {
-				objList[i] = &models.ObjectStats{
-					Path:     entry.Path,
-					PathType: models.ObjectStatsPathTypeCommonPrefix,
-				}
-			} else {
-				var mtime int64
-				if !entry.CreationDate.IsZero() {
-					mtime = entry.CreationDate.Unix()
-				}
-				objList[i] = &models.ObjectStats{
-					Checksum:        entry.Checksum,
-					Mtime:           mtime,
-					Path:            entry.Path,
-					PhysicalAddress: qk.Format(),
-					PathType:        models.ObjectStatsPathTypeObject,
-					SizeBytes:       entry.Size,
-				}
-			}
-			lastID = entry.Path
-		}
-		returnValue := objects.NewListObjectsOK().WithPayload(&objects.ListObjectsOKBody{
-			Pagination: &models.Pagination{
-				HasMore:    swag.Bool(hasMore),
-				Results:    swag.Int64(int64(len(objList))),
-				MaxPerPage: swag.Int64(MaxResultsPerPage),
-			},
-			Results: objList,
-		})
-
-		if hasMore {
-			returnValue.Payload.Pagination.NextOffset = lastID
+			objList[i], err = transformEntryToObjectStats(repo, entry)
+			if err != nil {
+				return objects.NewStatObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+			}
 		}
-		return returnValue
+		return objects.NewListObjectsOK().WithPayload(&objects.ListObjectsOKBody{
+			Pagination: createPaginator(nextToken, len(objList)),
+			Results:    objList,
+		})
 	})
 }
 
@@ -1355,7 +3610,7 @@ func (c *Controller) ObjectsUploadObjectHandler() objects.UploadObjectHandler {
 				Action:   permissions.WriteObjectAction,
 				Resource: permissions.ObjectArn(params.Repository, params.Path),
 			},
-		})
+		}, sandboxPermissions(user, params.Repository, params.Branch)...)
 		if err != nil {
 			return objects.NewUploadObjectUnauthorized().WithPayload(responseErrorFrom(err))
 		}
@@ -1364,7 +3619,7 @@ func (c *Controller) ObjectsUploadObjectHandler() objects.UploadObjectHandler {
 
 		repo, err := cataloger.GetRepository(deps.ctx, params.Repository)
 		if errors.Is(err, db.ErrNotFound) {
-			return objects.NewUploadObjectNotFound().WithPayload(responseError("repository not found"))
+			return objects.NewUploadObjectNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository not found"))
 		}
 		if err != nil {
 			return objects.NewUploadObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
@@ -1377,20 +3632,43 @@ func (c *Controller) ObjectsUploadObjectHandler() objects.UploadObjectHandler {
 		if !branchExists {
 			return objects.NewUploadObjectNotFound().WithPayload(responseError("branch '%s' not found", params.Branch))
 		}
+		err = cataloger.CheckPathLease(deps.ctx, params.Repository, params.Branch, params.Path, user.ID)
+		if errors.Is(err, catalog.ErrPathLeased) {
+			return objects.NewUploadObjectConflict().WithPayload(responseErrorFrom(err))
+		}
+		if err != nil {
+			return objects.NewUploadObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
 		// workaround in order to extract file content-length using swagger
 		file, ok := params.Content.(*runtime.File)
 		if !ok {
 			return objects.NewUploadObjectDefault(http.StatusInternalServerError).WithPayload(responseError("failed extracting size from file"))
 		}
 		byteSize := file.Header.Size
+		repoSettings, err := deps.Settings.Get(deps.ctx, params.Repository)
+		if err != nil {
+			return objects.NewUploadObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		if repoSettings.TooLarge(byteSize) {
+			return objects.NewUploadObjectRequestEntityTooLarge().WithPayload(responseError("object size %d exceeds the maximum object size %d of repository '%s'", byteSize, repoSettings.MaxObjectSize, params.Repository))
+		}
+
+		metadata, ok := uploadObjectMetadata(params, file)
+		if !ok {
+			return objects.NewUploadObjectBadRequest().WithPayload(responseError("user metadata larger than %d bytes", httputil.MaxUserMetadataSize))
+		}
 
 		// write the content
-		blob, err := upload.WriteBlob(deps.BlockAdapter, repo.StorageNamespace, params.Content, byteSize, block.PutOpts{StorageClass: params.StorageClass})
+		storageClass := repoSettings.StorageClassOr(params.StorageClass)
+		blob, err := upload.WriteEncryptedBlob(deps.ctx, deps.Encryptor, repo.Name, params.Path, deps.BlockAdapter, repo.StorageNamespace, params.Content, byteSize, block.PutOpts{StorageClass: storageClass})
 		if err != nil {
 			return objects.NewUploadObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
 		}
 
 		// write metadata
+		for k, v := range blob.Metadata {
+			metadata[k] = v
+		}
 		writeTime := time.Now()
 		entry := catalog.DBEntry{
 			Path:            params.Path,
@@ -1398,11 +3676,16 @@ func (c *Controller) ObjectsUploadObjectHandler() objects.UploadObjectHandler {
 			CreationDate:    writeTime,
 			Size:            blob.Size,
 			Checksum:        blob.Checksum,
+			Metadata:        metadata,
+			DirectoryMarker: blob.Size == 0 && catalog.IsDirectoryMarkerPath(params.Path),
 		}
 		err = cataloger.CreateEntry(deps.ctx, repo.Name, params.Branch, entry)
 		if errors.Is(err, db.ErrNotFound) {
 			return objects.NewUploadObjectNotFound().WithPayload(responseErrorFrom(err))
 		}
+		if errors.Is(err, catalog.ErrPathLeased) {
+			return objects.NewUploadObjectConflict().WithPayload(responseErrorFrom(err))
+		}
 		if err != nil {
 			return objects.NewUploadObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
 		}
@@ -1417,12 +3700,135 @@ func (c *Controller) ObjectsUploadObjectHandler() objects.UploadObjectHandler {
 			Mtime:           writeTime.Unix(),
 			Path:            params.Path,
 			PhysicalAddress: qk.Format(),
-			PathType:        models.ObjectStatsPathTypeObject,
+			PathType:        objectPathType(&entry),
 			SizeBytes:       blob.Size,
+			Metadata:        httputil.ObjectMetadata(entry.Metadata),
+		})
+	})
+}
+
+func (c *Controller) ObjectsAppendObjectHandler() objects.AppendObjectHandler {
+	return objects.AppendObjectHandlerFunc(func(params objects.AppendObjectParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.WriteObjectAction,
+				Resource: permissions.ObjectArn(params.Repository, params.Path),
+			},
+		}, sandboxPermissions(user, params.Repository, params.Branch)...)
+		if err != nil {
+			return objects.NewAppendObjectUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("append_object")
+		cataloger := deps.Cataloger
+
+		repo, err := cataloger.GetRepository(deps.ctx, params.Repository)
+		if errors.Is(err, db.ErrNotFound) {
+			return objects.NewAppendObjectNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository not found"))
+		}
+		if err != nil {
+			return objects.NewAppendObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		branchExists, err := cataloger.BranchExists(deps.ctx, params.Repository, params.Branch)
+		if err != nil {
+			return objects.NewAppendObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		if !branchExists {
+			return objects.NewAppendObjectNotFound().WithPayload(responseErrorCode(errcode.BranchNotFound, "branch '%s' not found", params.Branch))
+		}
+		err = cataloger.CheckPathLease(deps.ctx, params.Repository, params.Branch, params.Path, user.ID)
+		if errors.Is(err, catalog.ErrPathLeased) {
+			return objects.NewAppendObjectConflict().WithPayload(responseErrorFrom(err))
+		}
+		if err != nil {
+			return objects.NewAppendObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		// workaround in order to extract file content-length using swagger
+		file, ok := params.Content.(*runtime.File)
+		if !ok {
+			return objects.NewAppendObjectDefault(http.StatusInternalServerError).WithPayload(responseError("failed extracting size from file"))
+		}
+		byteSize := file.Header.Size
+		repoSettings, err := deps.Settings.Get(deps.ctx, params.Repository)
+		if err != nil {
+			return objects.NewAppendObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		if repoSettings.TooLarge(byteSize) {
+			return objects.NewAppendObjectRequestEntityTooLarge().WithPayload(responseError("appended size %d exceeds the maximum object size %d of repository '%s'", byteSize, repoSettings.MaxObjectSize, params.Repository))
+		}
+
+		entry, err := upload.AppendBlob(deps.ctx, cataloger, deps.BlockAdapter, deps.Encryptor, upload.AppendParams{
+			Repository:    repo,
+			Branch:        params.Branch,
+			Path:          params.Path,
+			Body:          params.Content,
+			ContentLength: byteSize,
+			MaxSize:       repoSettings.MaxObjectSize,
+		})
+		switch {
+		case errors.Is(err, graveler.ErrPreconditionFailed):
+			return objects.NewAppendObjectPreconditionFailed().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, upload.ErrAppendTooLarge):
+			return objects.NewAppendObjectRequestEntityTooLarge().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, catalog.ErrPathLeased):
+			return objects.NewAppendObjectConflict().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, catalog.ErrFeatureNotSupported):
+			return objects.NewAppendObjectNotImplemented().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, db.ErrNotFound):
+			return objects.NewAppendObjectNotFound().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return objects.NewAppendObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+
+		qk, err := block.ResolveNamespace(repo.StorageNamespace, entry.PhysicalAddress)
+		if err != nil {
+			return objects.NewAppendObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return objects.NewAppendObjectOK().WithPayload(&models.ObjectStats{
+			Checksum:        entry.Checksum,
+			Mtime:           entry.CreationDate.Unix(),
+			Path:            params.Path,
+			PhysicalAddress: qk.Format(),
+			PathType:        objectPathType(entry),
+			SizeBytes:       entry.Size,
 		})
 	})
 }
 
+// objectPathType returns the path type reported for an entry that is not a common prefix
+func objectPathType(entry *catalog.DBEntry) string {
+	if entry.DirectoryMarker {
+		return models.ObjectStatsPathTypeDirectoryMarker
+	}
+	return models.ObjectStatsPathTypeObject
+}
+
+// uploadObjectMetadata returns the metadata of an object uploaded by params: the x-amz-meta-*
+// headers of the request, the Content-Type of the uploaded file and the object headers set on
+// the form.  The object headers of the request itself describe the form, not the object.  It
+// returns false if the user metadata is larger than httputil.MaxUserMetadataSize.
+func uploadObjectMetadata(params objects.UploadObjectParams, file *runtime.File) (catalog.Metadata, bool) {
+	metadata := catalog.Metadata{}
+	size := 0
+	for name, values := range params.HTTPRequest.Header {
+		key := strings.ToLower(name)
+		if !strings.HasPrefix(key, httputil.UserMetadataHeaderPrefix) || len(values) == 0 {
+			continue
+		}
+		value := strings.Join(values, ",")
+		metadata[key] = value
+		size += len(key) - len(httputil.UserMetadataHeaderPrefix) + len(value)
+	}
+	header := http.Header{}
+	header.Set("Content-Type", file.Header.Header.Get("Content-Type"))
+	header.Set("Cache-Control", swag.StringValue(params.CacheControl))
+	header.Set("Content-Disposition", swag.StringValue(params.ContentDisposition))
+	header.Set("Content-Encoding", swag.StringValue(params.ContentEncoding))
+	for k, v := range httputil.ObjectHeadersMetadata(header) {
+		metadata[k] = v
+	}
+	return metadata, size <= httputil.MaxUserMetadataSize
+}
+
 func (c *Controller) ObjectsDeleteObjectHandler() objects.DeleteObjectHandler {
 	return objects.DeleteObjectHandlerFunc(func(params objects.DeleteObjectParams, user *models.User) middleware.Responder {
 		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
@@ -1430,17 +3836,27 @@ func (c *Controller) ObjectsDeleteObjectHandler() objects.DeleteObjectHandler {
 				Action:   permissions.DeleteObjectAction,
 				Resource: permissions.ObjectArn(params.Repository, params.Path),
 			},
-		})
+		}, sandboxPermissions(user, params.Repository, params.Branch)...)
 		if err != nil {
 			return objects.NewDeleteObjectUnauthorized().WithPayload(responseErrorFrom(err))
 		}
 		deps.LogAction("delete_object")
 		cataloger := deps.Cataloger
 
+		err = cataloger.CheckPathLease(deps.ctx, params.Repository, params.Branch, params.Path, user.ID)
+		if errors.Is(err, catalog.ErrPathLeased) {
+			return objects.NewDeleteObjectConflict().WithPayload(responseErrorFrom(err))
+		}
+		if err != nil {
+			return objects.NewDeleteObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
 		err = cataloger.DeleteEntry(deps.ctx, params.Repository, params.Branch, params.Path)
 		if errors.Is(err, db.ErrNotFound) {
 			return objects.NewDeleteObjectNotFound().WithPayload(responseError("resource not found"))
 		}
+		if errors.Is(err, catalog.ErrPathLeased) {
+			return objects.NewDeleteObjectConflict().WithPayload(responseErrorFrom(err))
+		}
 		if err != nil {
 			return objects.NewDeleteObjectDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
 		}
@@ -1456,7 +3872,7 @@ func (c *Controller) RevertHandler() branches.RevertHandler {
 				Action:   permissions.RevertBranchAction,
 				Resource: permissions.BranchArn(params.Repository, params.Branch),
 			},
-		})
+		}, sandboxPermissions(user, params.Repository, params.Branch)...)
 		if err != nil {
 			return branches.NewRevertUnauthorized().WithPayload(responseErrorFrom(err))
 		}
@@ -1474,6 +3890,9 @@ func (c *Controller) RevertHandler() branches.RevertHandler {
 		if errors.Is(err, graveler.ErrNotFound) {
 			return branches.NewRevertNotFound().WithPayload(responseErrorFrom(err))
 		}
+		if errors.Is(err, catalog.ErrPathLeased) {
+			return branches.NewRevertDefault(http.StatusConflict).WithPayload(responseErrorFrom(err))
+		}
 		if err != nil {
 			return branches.NewRevertDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
 		}
@@ -1481,6 +3900,98 @@ func (c *Controller) RevertHandler() branches.RevertHandler {
 	})
 }
 
+func (c *Controller) ReplacePrefixHandler() branches.ReplacePrefixHandler {
+	return branches.ReplacePrefixHandlerFunc(func(params branches.ReplacePrefixParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.CreateCommitAction,
+				Resource: permissions.BranchArn(params.Repository, params.Branch),
+			},
+		}, sandboxPermissions(user, params.Repository, params.Branch)...)
+		if err != nil {
+			return branches.NewReplacePrefixUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("replace_prefix")
+		userModel, err := deps.Auth.GetUser(user.ID)
+		if err != nil {
+			return branches.NewReplacePrefixUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		res, err := deps.Cataloger.ReplacePrefix(deps.ctx, params.Repository, params.Branch, catalog.ReplacePrefixParams{
+			Prefix:    swag.StringValue(params.Replace.Prefix),
+			SourceRef: params.Replace.SourceRef,
+			Committer: userModel.Username,
+			Message:   params.Replace.Message,
+			Metadata:  params.Replace.Metadata,
+		})
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return branches.NewReplacePrefixNotFound().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, graveler.ErrDirtyBranch), errors.Is(err, catalog.ErrPathLeased), errors.Is(err, catalog.ErrMergeGuardrailsExceeded):
+			return branches.NewReplacePrefixConflict().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, catalog.ErrInvalidValue), errors.Is(err, catalog.ErrRequiredValue):
+			return branches.NewReplacePrefixBadRequest().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, graveler.ErrNoChanges):
+			return branches.NewReplacePrefixDefault(http.StatusInternalServerError).WithPayload(responseErrorCode(errcode.NoChanges, "no difference was found"))
+		case err != nil:
+			return branches.NewReplacePrefixDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return branches.NewReplacePrefixOK().WithPayload(newMergeResultFromCatalog(res))
+	})
+}
+
+func (c *Controller) GenerateEntriesHandler() branches.GenerateEntriesHandler {
+	return branches.GenerateEntriesHandlerFunc(func(params branches.GenerateEntriesParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.GenerateEntriesAction,
+				Resource: permissions.BranchArn(params.Repository, params.Branch),
+			},
+		}, sandboxPermissions(user, params.Repository, params.Branch)...)
+		if err != nil {
+			return branches.NewGenerateEntriesUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("generate_entries")
+		// generated entries have no data, keep them on repositories made for load tests
+		enabled, err := deps.InstanceSettings.FeatureEnabled(deps.ctx, settings.FeatureGenerateEntries, params.Repository)
+		if err != nil {
+			return branches.NewGenerateEntriesDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		if !enabled {
+			return branches.NewGenerateEntriesBadRequest().
+				WithPayload(responseErrorCode(errcode.NotSupported, "generate entries is not enabled on the repository"))
+		}
+		userModel, err := deps.Auth.GetUser(user.ID)
+		if err != nil {
+			return branches.NewGenerateEntriesUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		fanOut := int(params.Generate.FanOut)
+		if fanOut == 0 {
+			fanOut = 1
+		}
+		res, err := deps.Cataloger.GenerateEntries(deps.ctx, params.Repository, params.Branch, catalog.GenerateEntriesParams{
+			Prefix:    params.Generate.Prefix,
+			Count:     int(swag.Int64Value(params.Generate.Count)),
+			Depth:     int(params.Generate.Depth),
+			FanOut:    fanOut,
+			Size:      params.Generate.Size,
+			Committer: userModel.Username,
+			Message:   params.Generate.Message,
+			Metadata:  params.Generate.Metadata,
+		})
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return branches.NewGenerateEntriesNotFound().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, graveler.ErrDirtyBranch), errors.Is(err, catalog.ErrPathLeased):
+			return branches.NewGenerateEntriesConflict().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, catalog.ErrInvalidValue), errors.Is(err, catalog.ErrRequiredValue):
+			return branches.NewGenerateEntriesBadRequest().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return branches.NewGenerateEntriesDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return branches.NewGenerateEntriesOK().WithPayload(newMergeResultFromCatalog(res))
+	})
+}
+
 func (c *Controller) ResetBranchHandler() branches.ResetBranchHandler {
 	return branches.ResetBranchHandlerFunc(func(params branches.ResetBranchParams, user *models.User) middleware.Responder {
 		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
@@ -1488,7 +3999,7 @@ func (c *Controller) ResetBranchHandler() branches.ResetBranchHandler {
 				Action:   permissions.RevertBranchAction,
 				Resource: permissions.BranchArn(params.Repository, params.Branch),
 			},
-		})
+		}, sandboxPermissions(user, params.Repository, params.Branch)...)
 		if err != nil {
 			return branches.NewResetBranchUnauthorized().WithPayload(responseErrorFrom(err))
 		}
@@ -1511,6 +4022,9 @@ func (c *Controller) ResetBranchHandler() branches.ResetBranchHandler {
 		if errors.Is(err, db.ErrNotFound) {
 			return branches.NewResetBranchNotFound().WithPayload(responseErrorFrom(err))
 		}
+		if errors.Is(err, catalog.ErrPathLeased) {
+			return branches.NewResetBranchConflict().WithPayload(responseErrorFrom(err))
+		}
 		if err != nil {
 			return branches.NewResetBranchDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
 		}
@@ -1531,9 +4045,16 @@ func (c *Controller) CreateUserHandler() authop.CreateUserHandler {
 			return authop.NewCreateUserUnauthorized().
 				WithPayload(responseErrorFrom(err))
 		}
+		tenant, err := userTenant(deps, user)
+		if err != nil {
+			return authop.NewCreateUserDefault(http.StatusInternalServerError).
+				WithPayload(responseErrorFrom(err))
+		}
+		// users created by tenant users belong to the same tenant
 		u := &model.User{
 			CreatedAt: time.Now(),
 			Username:  swag.StringValue(params.User.ID),
+			Tenant:    tenant,
 		}
 		err = deps.Auth.CreateUser(u)
 		deps.LogAction("create_user")
@@ -1564,8 +4085,13 @@ func (c *Controller) ListUsersHandler() authop.ListUsersHandler {
 		}
 
 		deps.LogAction("list_users")
+		tenant, err := userTenant(deps, user)
+		if err != nil {
+			return authop.NewListUsersDefault(http.StatusInternalServerError).
+				WithPayload(responseErrorFrom(err))
+		}
 		users, paginator, err := deps.Auth.ListUsers(&model.PaginationParams{
-			After:  swag.StringValue(params.After),
+			After:  tenantAfter(tenant, swag.StringValue(params.After)),
 			Amount: pageAmount(params.Amount),
 		})
 		if err != nil {
@@ -1573,12 +4099,15 @@ func (c *Controller) ListUsersHandler() authop.ListUsersHandler {
 				WithPayload(responseErrorFrom(err))
 		}
 
-		response := make([]*models.User, len(users))
-		for i, u := range users {
-			response[i] = &models.User{
+		response := make([]*models.User, 0, len(users))
+		for _, u := range users {
+			if !auth.InTenant(tenant, u.Username) {
+				continue
+			}
+			response = append(response, &models.User{
 				CreationDate: u.CreatedAt.Unix(),
 				ID:           u.Username,
-			}
+			})
 		}
 
 		return authop.NewListUsersOK().
@@ -1693,8 +4222,13 @@ func (c *Controller) ListGroupsHandler() authop.ListGroupsHandler {
 		}
 
 		deps.LogAction("list_groups")
+		tenant, err := userTenant(deps, user)
+		if err != nil {
+			return authop.NewListGroupsDefault(http.StatusInternalServerError).
+				WithPayload(responseErrorFrom(err))
+		}
 		groups, paginator, err := deps.Auth.ListGroups(&model.PaginationParams{
-			After:  swag.StringValue(params.After),
+			After:  tenantAfter(tenant, swag.StringValue(params.After)),
 			Amount: pageAmount(params.Amount),
 		})
 
@@ -1703,12 +4237,15 @@ func (c *Controller) ListGroupsHandler() authop.ListGroupsHandler {
 				WithPayload(responseErrorFrom(err))
 		}
 
-		response := make([]*models.Group, len(groups))
-		for i, g := range groups {
-			response[i] = &models.Group{
+		response := make([]*models.Group, 0, len(groups))
+		for _, g := range groups {
+			if !auth.InTenant(tenant, g.DisplayName) {
+				continue
+			}
+			response = append(response, &models.Group{
 				CreationDate: g.CreatedAt.Unix(),
 				ID:           g.DisplayName,
-			}
+			})
 		}
 
 		return authop.NewListGroupsOK().
@@ -1731,6 +4268,10 @@ func (c *Controller) CreateGroupHandler() authop.CreateGroupHandler {
 			return authop.NewCreateGroupUnauthorized().
 				WithPayload(responseErrorFrom(err))
 		}
+		if err := checkTenantName(deps, user, swag.StringValue(params.Group.ID)); err != nil {
+			return authop.NewCreateGroupUnauthorized().
+				WithPayload(responseErrorFrom(err))
+		}
 		g := &model.Group{
 			CreatedAt:   time.Now(),
 			DisplayName: swag.StringValue(params.Group.ID),
@@ -1808,8 +4349,13 @@ func (c *Controller) ListPoliciesHandler() authop.ListPoliciesHandler {
 		}
 
 		deps.LogAction("list_policies")
+		tenant, err := userTenant(deps, user)
+		if err != nil {
+			return authop.NewListPoliciesDefault(http.StatusInternalServerError).
+				WithPayload(responseErrorFrom(err))
+		}
 		policies, paginator, err := deps.Auth.ListPolicies(&model.PaginationParams{
-			After:  swag.StringValue(params.After),
+			After:  tenantAfter(tenant, swag.StringValue(params.After)),
 			Amount: pageAmount(params.Amount),
 		})
 		if err != nil {
@@ -1817,9 +4363,12 @@ func (c *Controller) ListPoliciesHandler() authop.ListPoliciesHandler {
 				WithPayload(responseErrorFrom(err))
 		}
 
-		response := make([]*models.Policy, len(policies))
-		for i, p := range policies {
-			response[i] = serializePolicy(p)
+		response := make([]*models.Policy, 0, len(policies))
+		for _, p := range policies {
+			if !auth.InTenant(tenant, p.DisplayName) {
+				continue
+			}
+			response = append(response, serializePolicy(p))
 		}
 
 		return authop.NewListPoliciesOK().
@@ -1852,6 +4401,15 @@ func (c *Controller) CreatePolicyHandler() authop.CreatePolicyHandler {
 			}
 		}
 
+		if err := checkTenantStatements(deps, user, stmts); err != nil {
+			return authop.NewCreatePolicyUnauthorized().
+				WithPayload(responseErrorFrom(err))
+		}
+		if err := checkTenantName(deps, user, swag.StringValue(params.Policy.ID)); err != nil {
+			return authop.NewCreatePolicyUnauthorized().
+				WithPayload(responseErrorFrom(err))
+		}
+
 		p := &model.Policy{
 			CreatedAt:   time.Now(),
 			DisplayName: swag.StringValue(params.Policy.ID),
@@ -1920,6 +4478,11 @@ func (c *Controller) UpdatePolicyHandler() authop.UpdatePolicyHandler {
 			}
 		}
 
+		if err := checkTenantStatements(deps, user, stmts); err != nil {
+			return authop.NewUpdatePolicyUnauthorized().
+				WithPayload(responseErrorFrom(err))
+		}
+
 		p := &model.Policy{
 			CreatedAt:   time.Now(),
 			DisplayName: swag.StringValue(params.Policy.ID),
@@ -2077,10 +4640,7 @@ func (c *Controller) ListUserCredentialsHandler() authop.ListUserCredentialsHand
 
 		response := make([]*models.Credentials, len(credentials))
 		for i, c := range credentials {
-			response[i] = &models.Credentials{
-				AccessKeyID:  c.AccessKeyID,
-				CreationDate: c.IssuedDate.Unix(),
-			}
+			response[i] = newCredentialsModel(c)
 		}
 
 		return authop.NewListUserCredentialsOK().
@@ -2091,6 +4651,46 @@ func (c *Controller) ListUserCredentialsHandler() authop.ListUserCredentialsHand
 	})
 }
 
+// unixTime returns the unix time of t, 0 for nil
+func unixTime(t *time.Time) int64 {
+	if t == nil {
+		return 0
+	}
+	return t.Unix()
+}
+
+// timeFromUnix returns the time of the unix time sec, nil for 0
+func timeFromUnix(sec int64) *time.Time {
+	if sec == 0 {
+		return nil
+	}
+	t := time.Unix(sec, 0)
+	return &t
+}
+
+func newCredentialsModel(c *model.Credential) *models.Credentials {
+	return &models.Credentials{
+		AccessKeyID:             c.AccessKeyID,
+		CreationDate:            c.IssuedDate.Unix(),
+		ExpiresAt:               unixTime(c.ExpiresAt),
+		LastUsedAt:              unixTime(c.LastUsedAt),
+		PreviousSecretExpiresAt: unixTime(c.PreviousSecretExpiresAt),
+		NetworkPolicy: &models.NetworkPolicy{
+			Allow: c.NetworkPolicy.Allow,
+			Deny:  c.NetworkPolicy.Deny,
+		},
+	}
+}
+
+func newCredentialsWithSecretModel(c *model.Credential) *models.CredentialsWithSecret {
+	return &models.CredentialsWithSecret{
+		AccessKeyID:     c.AccessKeyID,
+		AccessSecretKey: c.AccessSecretKey,
+		CreationDate:    c.IssuedDate.Unix(),
+		ExpiresAt:       unixTime(c.ExpiresAt),
+	}
+}
+
 func (c *Controller) CreateCredentialsHandler() authop.CreateCredentialsHandler {
 	return authop.CreateCredentialsHandlerFunc(func(params authop.CreateCredentialsParams, user *models.User) middleware.Responder {
 		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
@@ -2105,18 +4705,91 @@ func (c *Controller) CreateCredentialsHandler() authop.CreateCredentialsHandler
 		}
 
 		deps.LogAction("create_credentials")
-		credentials, err := deps.Auth.CreateCredentials(params.UserID)
+		var expiresAt *time.Time
+		if params.Credentials != nil {
+			expiresAt = timeFromUnix(params.Credentials.ExpiresAt)
+		}
+		credentials, err := deps.Auth.CreateCredentialsWithExpiry(params.UserID, expiresAt)
 		if err != nil {
 			return authop.NewCreateCredentialsDefault(http.StatusInternalServerError).
 				WithPayload(responseErrorFrom(err))
 		}
 
 		return authop.NewCreateCredentialsCreated().
-			WithPayload(&models.CredentialsWithSecret{
-				AccessKeyID:     credentials.AccessKeyID,
-				AccessSecretKey: credentials.AccessSecretKey,
-				CreationDate:    credentials.IssuedDate.Unix(),
-			})
+			WithPayload(newCredentialsWithSecretModel(credentials))
+	})
+}
+
+func (c *Controller) RotateCredentialsHandler() authop.RotateCredentialsHandler {
+	return authop.RotateCredentialsHandlerFunc(func(params authop.RotateCredentialsParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.RotateCredentialsAction,
+				Resource: permissions.UserArn(params.UserID),
+			},
+		})
+		if err != nil {
+			return authop.NewRotateCredentialsUnauthorized().
+				WithPayload(responseErrorFrom(err))
+		}
+
+		deps.LogAction("rotate_credentials")
+		const defaultGracePeriod = 24 * time.Hour
+		gracePeriod := defaultGracePeriod
+		var expiresAt *time.Time
+		if params.Rotation != nil {
+			if params.Rotation.GracePeriodSeconds != nil {
+				gracePeriod = time.Duration(swag.Int64Value(params.Rotation.GracePeriodSeconds)) * time.Second
+			}
+			expiresAt = timeFromUnix(params.Rotation.ExpiresAt)
+		}
+		credentials, err := deps.Auth.RotateCredentials(params.UserID, params.AccessKeyID, gracePeriod, expiresAt)
+		if errors.Is(err, db.ErrNotFound) {
+			return authop.NewRotateCredentialsNotFound().
+				WithPayload(responseError("credentials not found"))
+		}
+		if err != nil {
+			return authop.NewRotateCredentialsDefault(http.StatusInternalServerError).
+				WithPayload(responseErrorFrom(err))
+		}
+
+		return authop.NewRotateCredentialsOK().
+			WithPayload(newCredentialsWithSecretModel(credentials))
+	})
+}
+
+func (c *Controller) SetCredentialsNetworkPolicyHandler() authop.SetCredentialsNetworkPolicyHandler {
+	return authop.SetCredentialsNetworkPolicyHandlerFunc(func(params authop.SetCredentialsNetworkPolicyParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.SetNetworkPolicyAction,
+				Resource: permissions.UserArn(params.UserID),
+			},
+		})
+		if err != nil {
+			return authop.NewSetCredentialsNetworkPolicyUnauthorized().
+				WithPayload(responseErrorFrom(err))
+		}
+
+		deps.LogAction("set_credentials_network_policy")
+		credentials, err := deps.Auth.SetCredentialsNetworkPolicy(params.UserID, params.AccessKeyID, model.NetworkPolicy{
+			Allow: params.Policy.Allow,
+			Deny:  params.Policy.Deny,
+		})
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return authop.NewSetCredentialsNetworkPolicyNotFound().
+				WithPayload(responseError("credentials not found"))
+		case errors.Is(err, model.ErrValidationError):
+			return authop.NewSetCredentialsNetworkPolicyBadRequest().
+				WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return authop.NewSetCredentialsNetworkPolicyDefault(http.StatusInternalServerError).
+				WithPayload(responseErrorFrom(err))
+		}
+
+		return authop.NewSetCredentialsNetworkPolicyOK().
+			WithPayload(newCredentialsModel(credentials))
 	})
 }
 
@@ -2172,10 +4845,7 @@ func (c *Controller) GetCredentialsHandler() authop.GetCredentialsHandler {
 		}
 
 		return authop.NewGetCredentialsOK().
-			WithPayload(&models.Credentials{
-				AccessKeyID:  credentials.AccessKeyID,
-				CreationDate: credentials.IssuedDate.Unix(),
-			})
+			WithPayload(newCredentialsModel(credentials))
 	})
 }
 
@@ -2264,6 +4934,59 @@ func (c *Controller) ListUserPoliciesHandler() authop.ListUserPoliciesHandler {
 	})
 }
 
+func (c *Controller) SimulateAuthorizationHandler() authop.SimulateAuthorizationHandler {
+	return authop.SimulateAuthorizationHandlerFunc(func(params authop.SimulateAuthorizationParams, user *models.User) middleware.Responder {
+		userID := swag.StringValue(params.Simulation.User)
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadUserAction,
+				Resource: permissions.UserArn(userID),
+			},
+		})
+		if err != nil {
+			return authop.NewSimulateAuthorizationUnauthorized().
+				WithPayload(responseErrorFrom(err))
+		}
+
+		deps.LogAction("simulate_authorization")
+		sim, err := deps.Auth.Simulate(&auth.AuthorizationRequest{
+			Username: userID,
+			RequiredPermissions: []permissions.Permission{
+				{
+					Action:   swag.StringValue(params.Simulation.Action),
+					Resource: swag.StringValue(params.Simulation.Resource),
+				},
+			},
+		})
+		if errors.Is(err, db.ErrNotFound) {
+			return authop.NewSimulateAuthorizationNotFound().
+				WithPayload(responseError("user '%s' not found.", userID))
+		}
+		if err != nil {
+			return authop.NewSimulateAuthorizationDefault(http.StatusInternalServerError).
+				WithPayload(responseErrorFrom(err))
+		}
+
+		matches := make([]*models.PolicyMatch, len(sim.Matches))
+		for i, m := range sim.Matches {
+			matches[i] = &models.PolicyMatch{
+				Policy: swag.String(m.Policy),
+				Statement: &models.Statement{
+					Action:   m.Statement.Action,
+					Effect:   swag.String(m.Statement.Effect),
+					Resource: swag.String(m.Statement.Resource),
+				},
+			}
+		}
+		return authop.NewSimulateAuthorizationOK().
+			WithPayload(&models.AuthorizationSimulation{
+				Allowed: swag.Bool(sim.Allowed),
+				Reason:  swag.String(sim.Reason),
+				Matches: matches,
+			})
+	})
+}
+
 func (c *Controller) AttachPolicyToUserHandler() authop.AttachPolicyToUserHandler {
 	return authop.AttachPolicyToUserHandlerFunc(func(params authop.AttachPolicyToUserParams, user *models.User) middleware.Responder {
 		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
@@ -2277,6 +5000,11 @@ func (c *Controller) AttachPolicyToUserHandler() authop.AttachPolicyToUserHandle
 				WithPayload(responseErrorFrom(err))
 		}
 
+		if err := checkTenantPolicy(deps, user, params.PolicyID); err != nil {
+			return authop.NewAttachPolicyToUserUnauthorized().
+				WithPayload(responseErrorFrom(err))
+		}
+
 		deps.LogAction("attach_policy_to_user")
 		err = deps.Auth.AttachPolicyToUser(params.PolicyID, params.UserID)
 		if err != nil {
@@ -2361,6 +5089,11 @@ func (c *Controller) AttachPolicyToGroupHandler() authop.AttachPolicyToGroupHand
 				WithPayload(responseErrorFrom(err))
 		}
 
+		if err := checkTenantPolicy(deps, user, params.PolicyID); err != nil {
+			return authop.NewAttachPolicyToGroupUnauthorized().
+				WithPayload(responseErrorFrom(err))
+		}
+
 		deps.LogAction("attach_policy_to_group")
 		err = deps.Auth.AttachPolicyToGroup(params.PolicyID, params.GroupID)
 		if err != nil {
@@ -2428,7 +5161,7 @@ func (c *Controller) RefsRestoreHandler() refs.RestoreHandler {
 		}
 
 		// ensure no refs currently found
-		_, _, err = deps.Cataloger.ListCommits(deps.ctx, repo.Name, repo.DefaultBranch, "", 1)
+		_, _, err = deps.Cataloger.ListCommits(deps.ctx, repo.Name, repo.DefaultBranch, "", 1, false)
 		if !errors.Is(err, graveler.ErrNotFound) {
 			return refs.NewRestoreBadRequest().
 				WithPayload(responseError("can only restore into a bare repository"))
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"io/ioutil"
 	"math"
 	"net/http"
 	"strconv"
@@ -13,18 +14,21 @@ import (
 
 	"github.com/go-openapi/runtime"
 	httptransport "github.com/go-openapi/runtime/client"
+	"github.com/go-openapi/strfmt"
 	"github.com/go-openapi/swag"
 	"github.com/go-test/deep"
 	"github.com/treeverse/lakefs/api/gen/client/auth"
 	"github.com/treeverse/lakefs/api/gen/client/branches"
 	"github.com/treeverse/lakefs/api/gen/client/commits"
 	"github.com/treeverse/lakefs/api/gen/client/config"
+	"github.com/treeverse/lakefs/api/gen/client/datasets"
 	"github.com/treeverse/lakefs/api/gen/client/objects"
 	"github.com/treeverse/lakefs/api/gen/client/refs"
 	"github.com/treeverse/lakefs/api/gen/client/repositories"
 	"github.com/treeverse/lakefs/api/gen/client/setup"
 	"github.com/treeverse/lakefs/api/gen/client/tags"
 	"github.com/treeverse/lakefs/api/gen/models"
+	authmodel "github.com/treeverse/lakefs/auth/model"
 	"github.com/treeverse/lakefs/block"
 	"github.com/treeverse/lakefs/catalog"
 	"github.com/treeverse/lakefs/db"
@@ -1032,7 +1036,7 @@ func TestController_ObjectsGetObjectHandler(t *testing.T) {
 
 	t.Run("get object", func(t *testing.T) {
 		buf := new(bytes.Buffer)
-		resp, err := clt.Objects.GetObject(
+		resp, _, err := clt.Objects.GetObject(
 			objects.NewGetObjectParamsWithTimeout(timeout).
 				WithRef("master").
 				WithPath("foo/bar").
@@ -1054,7 +1058,7 @@ func TestController_ObjectsGetObjectHandler(t *testing.T) {
 			t.Fatalf("got unexpected body: '%s'", body)
 		}
 
-		_, err = clt.Objects.GetObject(
+		_, _, err = clt.Objects.GetObject(
 			objects.NewGetObjectParamsWithTimeout(timeout).
 				WithRef("master:HEAD").
 				WithPath("foo/bar").
@@ -1065,6 +1069,115 @@ func TestController_ObjectsGetObjectHandler(t *testing.T) {
 		}
 	})
 
+	t.Run("get range", func(t *testing.T) {
+		cases := []struct {
+			name          string
+			spec          string
+			expectedBody  string
+			expectedRange string
+		}{
+			{name: "closed", spec: "bytes=5-11", expectedBody: "is file", expectedRange: "bytes 5-11/37"},
+			{name: "past end", spec: "bytes=32-100", expectedBody: "bytes", expectedRange: "bytes 32-36/37"},
+			{name: "suffix", spec: "bytes=-5", expectedBody: "bytes", expectedRange: "bytes 32-36/37"},
+			{name: "open ended", spec: "bytes=21-", expectedBody: "made up of bytes", expectedRange: "bytes 21-36/37"},
+		}
+		for _, tt := range cases {
+			t.Run(tt.name, func(t *testing.T) {
+				buf := new(bytes.Buffer)
+				resp, partial, err := clt.Objects.GetObject(
+					objects.NewGetObjectParamsWithTimeout(timeout).
+						WithRef("master").
+						WithPath("foo/bar").
+						WithRepository("repo1").
+						WithRange(swag.String(tt.spec)),
+					bauth, buf)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if partial == nil {
+					t.Fatalf("GetObject() with range %s returned %+v, expected partial content", tt.spec, resp)
+				}
+				if partial.ContentRange != tt.expectedRange {
+					t.Errorf("GetObject() content range = %s, expected %s", partial.ContentRange, tt.expectedRange)
+				}
+				if partial.ContentLength != int64(len(tt.expectedBody)) {
+					t.Errorf("GetObject() content length = %d, expected %d", partial.ContentLength, len(tt.expectedBody))
+				}
+				if body := buf.String(); body != tt.expectedBody {
+					t.Errorf("GetObject() body = '%s', expected '%s'", body, tt.expectedBody)
+				}
+			})
+		}
+	})
+
+	t.Run("get unsatisfiable range", func(t *testing.T) {
+		for _, spec := range []string{"bytes=37-", "bytes=-40"} {
+			_, _, err := clt.Objects.GetObject(
+				objects.NewGetObjectParamsWithTimeout(timeout).
+					WithRef("master").
+					WithPath("foo/bar").
+					WithRepository("repo1").
+					WithRange(swag.String(spec)),
+				bauth, new(bytes.Buffer))
+			notSatisfiable, ok := err.(*objects.GetObjectRequestedRangeNotSatisfiable)
+			if !ok {
+				t.Fatalf("GetObject() with range %s error = %v, expected range not satisfiable", spec, err)
+			}
+			if notSatisfiable.ContentRange != "bytes */37" {
+				t.Errorf("GetObject() with range %s content range = %s, expected bytes */37", spec, notSatisfiable.ContentRange)
+			}
+		}
+	})
+
+	t.Run("get encrypted range", func(t *testing.T) {
+		const content = "this is encrypted content made up of bytes"
+		_, err := deps.cataloger.CreateRepository(ctx, encryptedRepository, "ns-encrypted", "master")
+		if err != nil {
+			t.Fatal(err)
+		}
+		stats, err := clt.Objects.UploadObject(
+			objects.NewUploadObjectParamsWithTimeout(timeout).
+				WithBranch("master").
+				WithContent(runtime.NamedReader("content", strings.NewReader(content))).
+				WithPath("secret").
+				WithRepository(encryptedRepository),
+			bauth)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the stored object must not hold the content in the clear
+		stored, err := deps.blocks.Get(block.ObjectPointer{
+			StorageNamespace: "ns-encrypted",
+			Identifier:       stats.Payload.PhysicalAddress,
+		}, int64(len(content)))
+		testutil.MustDo(t, "get stored object", err)
+		defer func() { _ = stored.Close() }()
+		storedContent, err := ioutil.ReadAll(stored)
+		testutil.MustDo(t, "read stored object", err)
+		if string(storedContent) == content {
+			t.Fatal("stored object is not encrypted")
+		}
+
+		buf := new(bytes.Buffer)
+		_, partial, err := clt.Objects.GetObject(
+			objects.NewGetObjectParamsWithTimeout(timeout).
+				WithRef("master").
+				WithPath("secret").
+				WithRepository(encryptedRepository).
+				WithRange(swag.String("bytes=8-24")),
+			bauth, buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if partial == nil {
+			t.Fatal("GetObject() of an encrypted object range returned the whole object")
+		}
+		if body, expected := buf.String(), content[8:25]; body != expected {
+			t.Errorf("GetObject() body = '%s', expected '%s'", body, expected)
+		}
+	})
+
 	t.Run("get properties", func(t *testing.T) {
 		properties, err := clt.Objects.GetUnderlyingProperties(
 			objects.NewGetUnderlyingPropertiesParamsWithTimeout(timeout).
@@ -1114,7 +1227,7 @@ func TestController_ObjectsUploadObjectHandler(t *testing.T) {
 
 		// download it
 		rbuf := new(bytes.Buffer)
-		rresp, err := clt.Objects.GetObject(
+		rresp, _, err := clt.Objects.GetObject(
 			objects.NewGetObjectParamsWithTimeout(timeout).
 				WithRef("master").
 				WithPath("foo/bar").
@@ -1146,6 +1259,71 @@ func TestController_ObjectsUploadObjectHandler(t *testing.T) {
 			t.Fatal("Missing branch should return not found")
 		}
 	})
+
+	// withHeaders authenticates requests with bauth and sets header on them
+	withHeaders := func(header map[string]string) runtime.ClientAuthInfoWriter {
+		return runtime.ClientAuthInfoWriterFunc(func(r runtime.ClientRequest, reg strfmt.Registry) error {
+			for name, value := range header {
+				if err := r.SetHeaderParam(name, value); err != nil {
+					return err
+				}
+			}
+			return bauth.AuthenticateRequest(r, reg)
+		})
+	}
+
+	t.Run("upload object metadata", func(t *testing.T) {
+		_, err := clt.Objects.UploadObject(
+			objects.NewUploadObjectParamsWithTimeout(timeout).
+				WithBranch("master").
+				WithContent(runtime.NamedReader("content", strings.NewReader("hello"))).
+				WithCacheControl(swag.String("max-age=60")).
+				WithContentDisposition(swag.String("attachment")).
+				WithPath("foo/meta").
+				WithRepository("repo1"),
+			withHeaders(map[string]string{
+				"X-Amz-Meta-Owner": "jane",
+				"Cache-Control":    "no-cache",
+				"Content-Encoding": "identity",
+			}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := clt.Objects.StatObject(
+			objects.NewStatObjectParamsWithTimeout(timeout).
+				WithRef("master").
+				WithPath("foo/meta").
+				WithRepository("repo1"),
+			bauth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		metadata := resp.Payload.Metadata
+		if metadata["x-amz-meta-owner"] != "jane" {
+			t.Errorf("user metadata = %v, expected x-amz-meta-owner jane", metadata)
+		}
+		if metadata["cache-control"] != "max-age=60" || metadata["content-disposition"] != "attachment" {
+			t.Errorf("object headers = %v, expected those of the form fields", metadata)
+		}
+		if _, ok := metadata["content-encoding"]; ok {
+			t.Errorf("object headers = %v, expected no content-encoding of the request", metadata)
+		}
+	})
+
+	t.Run("upload object metadata too large", func(t *testing.T) {
+		_, err := clt.Objects.UploadObject(
+			objects.NewUploadObjectParamsWithTimeout(timeout).
+				WithBranch("master").
+				WithContent(runtime.NamedReader("content", strings.NewReader("hello"))).
+				WithPath("foo/large").
+				WithRepository("repo1"),
+			withHeaders(map[string]string{
+				"X-Amz-Meta-Large": strings.Repeat("x", httputil.MaxUserMetadataSize),
+			}))
+		if _, ok := err.(*objects.UploadObjectBadRequest); !ok {
+			t.Fatalf("upload with large user metadata error = %v, expected bad request", err)
+		}
+	})
 }
 
 func TestController_ObjectsDeleteObjectHandler(t *testing.T) {
@@ -1181,7 +1359,7 @@ func TestController_ObjectsDeleteObjectHandler(t *testing.T) {
 
 		// download it
 		rbuf := new(bytes.Buffer)
-		rresp, err := clt.Objects.GetObject(
+		rresp, _, err := clt.Objects.GetObject(
 			objects.NewGetObjectParamsWithTimeout(timeout).
 				WithRef("master").
 				WithPath("foo/bar").
@@ -1222,6 +1400,413 @@ func TestController_ObjectsDeleteObjectHandler(t *testing.T) {
 	})
 }
 
+func TestController_ObjectsCreateObjectLinkHandler(t *testing.T) {
+	clt, deps := setupClient(t, "")
+
+	// create user
+	creds := createDefaultAdminUser(t, clt)
+	bauth := httptransport.BasicAuth(creds.AccessKeyID, creds.AccessSecretKey)
+
+	ctx := context.Background()
+	_, err := deps.cataloger.CreateRepository(ctx, "repo1", "gs://bucket/prefix", "master")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const content = "hello world this is my awesome content"
+	_, err = clt.Objects.UploadObject(
+		objects.NewUploadObjectParamsWithTimeout(timeout).
+			WithBranch("master").
+			WithContent(runtime.NamedReader("content", strings.NewReader(content))).
+			WithPath("v1/data").
+			WithRepository("repo1"),
+		bauth)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("link directory", func(t *testing.T) {
+		resp, err := clt.Objects.CreateObjectLink(
+			objects.NewCreateObjectLinkParamsWithTimeout(timeout).
+				WithBranch("master").
+				WithPath("latest/").
+				WithLink(&models.ObjectLink{Target: swag.String("v1/")}).
+				WithRepository("repo1"),
+			bauth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Payload.PathType != models.ObjectStatsPathTypeLink || resp.Payload.LinkTarget != "v1/" {
+			t.Fatalf("CreateObjectLink() stats = %+v, expected a link to v1/", resp.Payload)
+		}
+
+		rbuf := new(bytes.Buffer)
+		_, _, err = clt.Objects.GetObject(
+			objects.NewGetObjectParamsWithTimeout(timeout).
+				WithRef("master").
+				WithPath("latest/data").
+				WithRepository("repo1"),
+			bauth, rbuf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rbuf.String() != content {
+			t.Fatalf("read through link = %q, expected %q", rbuf.String(), content)
+		}
+	})
+
+	t.Run("link to itself", func(t *testing.T) {
+		_, err := clt.Objects.CreateObjectLink(
+			objects.NewCreateObjectLinkParamsWithTimeout(timeout).
+				WithBranch("master").
+				WithPath("self").
+				WithLink(&models.ObjectLink{Target: swag.String("self")}).
+				WithRepository("repo1"),
+			bauth)
+		if _, ok := err.(*objects.CreateObjectLinkBadRequest); !ok {
+			t.Fatalf("CreateObjectLink() to itself error = %v, expected bad request", err)
+		}
+	})
+
+	t.Run("link loop", func(t *testing.T) {
+		for _, link := range []struct{ path, target string }{{"loop-a", "loop-b"}, {"loop-b", "loop-a"}} {
+			_, err := clt.Objects.CreateObjectLink(
+				objects.NewCreateObjectLinkParamsWithTimeout(timeout).
+					WithBranch("master").
+					WithPath(link.path).
+					WithLink(&models.ObjectLink{Target: swag.String(link.target)}).
+					WithRepository("repo1"),
+				bauth)
+			testutil.MustDo(t, "create link "+link.path, err)
+		}
+		_, _, err := clt.Objects.GetObject(
+			objects.NewGetObjectParamsWithTimeout(timeout).
+				WithRef("master").
+				WithPath("loop-a").
+				WithRepository("repo1"),
+			bauth, new(bytes.Buffer))
+		if _, ok := err.(*objects.GetObjectDefault); !ok {
+			t.Fatalf("GetObject() of a link loop error = %v, expected default error", err)
+		}
+	})
+
+	t.Run("link unreadable target", func(t *testing.T) {
+		_, err := clt.Objects.UploadObject(
+			objects.NewUploadObjectParamsWithTimeout(timeout).
+				WithBranch("master").
+				WithContent(runtime.NamedReader("content", strings.NewReader("secret content"))).
+				WithPath("secret/y").
+				WithRepository("repo1"),
+			bauth)
+		testutil.MustDo(t, "upload secret", err)
+		_, err = clt.Objects.CreateObjectLink(
+			objects.NewCreateObjectLinkParamsWithTimeout(timeout).
+				WithBranch("master").
+				WithPath("incoming/to-secret").
+				WithLink(&models.ObjectLink{Target: swag.String("secret/y")}).
+				WithRepository("repo1"),
+			bauth)
+		testutil.MustDo(t, "link to secret", err)
+
+		writerCreds := createUserWithStatements(t, deps, "incoming-writer", authmodel.Statements{
+			{
+				Action:   []string{"fs:WriteObject", "fs:ReadObject"},
+				Resource: "arn:lakefs:fs:::repository/repo1/object/incoming/*",
+				Effect:   authmodel.StatementEffectAllow,
+			},
+		})
+		writerAuth := httptransport.BasicAuth(writerCreds.AccessKeyID, writerCreds.AccessSecretKey)
+		_, err = clt.Objects.CreateObjectLink(
+			objects.NewCreateObjectLinkParamsWithTimeout(timeout).
+				WithBranch("master").
+				WithPath("incoming/x").
+				WithLink(&models.ObjectLink{Target: swag.String("secret/y")}).
+				WithRepository("repo1"),
+			writerAuth)
+		if _, ok := err.(*objects.CreateObjectLinkUnauthorized); !ok {
+			t.Fatalf("CreateObjectLink() to unreadable target error = %v, expected unauthorized", err)
+		}
+		// links created by others resolve only to paths the reader may read
+		_, _, err = clt.Objects.GetObject(
+			objects.NewGetObjectParamsWithTimeout(timeout).
+				WithRef("master").
+				WithPath("incoming/to-secret").
+				WithRepository("repo1"),
+			writerAuth, new(bytes.Buffer))
+		if _, ok := err.(*objects.GetObjectUnauthorized); !ok {
+			t.Fatalf("GetObject() through link to unreadable target error = %v, expected unauthorized", err)
+		}
+	})
+
+	t.Run("missing branch", func(t *testing.T) {
+		_, err := clt.Objects.CreateObjectLink(
+			objects.NewCreateObjectLinkParamsWithTimeout(timeout).
+				WithBranch("masterX").
+				WithPath("latest/").
+				WithLink(&models.ObjectLink{Target: swag.String("v1/")}).
+				WithRepository("repo1"),
+			bauth)
+		if _, ok := err.(*objects.CreateObjectLinkNotFound); !ok {
+			t.Fatalf("CreateObjectLink() on a missing branch error = %v, expected not found", err)
+		}
+	})
+
+	t.Run("link another repository", func(t *testing.T) {
+		_, err := deps.cataloger.CreateRepository(ctx, "repo2", "gs://bucket/repo2", "master")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = clt.Objects.UploadObject(
+			objects.NewUploadObjectParamsWithTimeout(timeout).
+				WithBranch("master").
+				WithContent(runtime.NamedReader("content", strings.NewReader("shared content"))).
+				WithPath("data/file1").
+				WithRepository("repo2"),
+			bauth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		commit, err := deps.cataloger.Commit(ctx, "repo2", "master", "shared data", "test", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = clt.Objects.CreateObjectLink(
+			objects.NewCreateObjectLinkParamsWithTimeout(timeout).
+				WithBranch("master").
+				WithPath("shared").
+				WithLink(&models.ObjectLink{Target: swag.String("data/file1"), Repository: "repo2"}).
+				WithRepository("repo1"),
+			bauth)
+		if _, ok := err.(*objects.CreateObjectLinkBadRequest); !ok {
+			t.Fatalf("CreateObjectLink() with no ref error = %v, expected bad request", err)
+		}
+
+		resp, err := clt.Objects.CreateObjectLink(
+			objects.NewCreateObjectLinkParamsWithTimeout(timeout).
+				WithBranch("master").
+				WithPath("shared").
+				WithLink(&models.ObjectLink{Target: swag.String("data/file1"), Repository: "repo2", Ref: "master"}).
+				WithRepository("repo1"),
+			bauth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Payload.LinkRepository != "repo2" || resp.Payload.LinkRef != commit.Reference {
+			t.Fatalf("CreateObjectLink() stats = %+v, expected a link pinned to commit %s of repo2", resp.Payload, commit.Reference)
+		}
+
+		rbuf := new(bytes.Buffer)
+		_, _, err = clt.Objects.GetObject(
+			objects.NewGetObjectParamsWithTimeout(timeout).
+				WithRef("master").
+				WithPath("shared").
+				WithRepository("repo1"),
+			bauth, rbuf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rbuf.String() != "shared content" {
+			t.Fatalf("read through link = %q, expected %q", rbuf.String(), "shared content")
+		}
+	})
+}
+
+func TestController_Datasets(t *testing.T) {
+	clt, deps := setupClient(t, "")
+
+	// create user
+	creds := createDefaultAdminUser(t, clt)
+	bauth := httptransport.BasicAuth(creds.AccessKeyID, creds.AccessSecretKey)
+
+	ctx := context.Background()
+	_, err := deps.cataloger.CreateRepository(ctx, "repo1", "gs://bucket/prefix", "master")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("create", func(t *testing.T) {
+		resp, err := clt.Datasets.CreateDataset(
+			datasets.NewCreateDatasetParamsWithTimeout(timeout).
+				WithRepository("repo1").
+				WithDataset(&models.Dataset{
+					Name:        swag.String("events"),
+					Prefixes:    []string{"events/"},
+					SchemaHints: map[string]string{"format": "parquet"},
+				}),
+			bauth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if swag.StringValue(resp.Payload.Name) != "events" || resp.Payload.CreationDate == 0 {
+			t.Fatalf("CreateDataset() = %+v, expected dataset events", resp.Payload)
+		}
+
+		_, err = clt.Datasets.CreateDataset(
+			datasets.NewCreateDatasetParamsWithTimeout(timeout).
+				WithRepository("repo1").
+				WithDataset(&models.Dataset{Name: swag.String("events"), Prefixes: []string{"other/"}}),
+			bauth)
+		if _, ok := err.(*datasets.CreateDatasetConflict); !ok {
+			t.Fatalf("CreateDataset() of an existing dataset error = %v, expected conflict", err)
+		}
+
+		_, err = clt.Datasets.CreateDataset(
+			datasets.NewCreateDatasetParamsWithTimeout(timeout).
+				WithRepository("repo1").
+				WithDataset(&models.Dataset{Name: swag.String("bad name"), Prefixes: []string{"other/"}}),
+			bauth)
+		if _, ok := err.(*datasets.CreateDatasetBadRequest); !ok {
+			t.Fatalf("CreateDataset() with an invalid name error = %v, expected bad request", err)
+		}
+	})
+
+	t.Run("get and list", func(t *testing.T) {
+		resp, err := clt.Datasets.GetDataset(
+			datasets.NewGetDatasetParamsWithTimeout(timeout).WithRepository("repo1").WithDataset("events"),
+			bauth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := deep.Equal(resp.Payload.Prefixes, []string{"events/"}); diff != nil {
+			t.Error("GetDataset() prefixes", diff)
+		}
+		listResp, err := clt.Datasets.ListDatasets(
+			datasets.NewListDatasetsParamsWithTimeout(timeout).WithRepository("repo1"),
+			bauth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(listResp.Payload.Results) != 1 || swag.StringValue(listResp.Payload.Results[0].Name) != "events" {
+			t.Errorf("ListDatasets() = %+v, expected dataset events", listResp.Payload.Results)
+		}
+	})
+
+	t.Run("diff", func(t *testing.T) {
+		_, err := deps.cataloger.CreateBranch(ctx, "repo1", "base", "master")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, path := range []string{"events/day1", "other/day1"} {
+			_, err := clt.Objects.UploadObject(
+				objects.NewUploadObjectParamsWithTimeout(timeout).
+					WithBranch("master").
+					WithContent(runtime.NamedReader("content", strings.NewReader(path))).
+					WithPath(path).
+					WithRepository("repo1"),
+				bauth)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := deps.cataloger.Commit(ctx, "repo1", "master", "day1", "test", nil); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := clt.Datasets.DiffDataset(
+			datasets.NewDiffDatasetParamsWithTimeout(timeout).
+				WithRepository("repo1").
+				WithDataset("events").
+				WithLeftRef("master").
+				WithRightRef("base"),
+			bauth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Payload.Results) != 1 || resp.Payload.Results[0].Path != "events/day1" {
+			t.Errorf("DiffDataset() = %+v, expected a difference of events/day1", resp.Payload.Results)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		_, err := clt.Datasets.DeleteDataset(
+			datasets.NewDeleteDatasetParamsWithTimeout(timeout).WithRepository("repo1").WithDataset("events"),
+			bauth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = clt.Datasets.GetDataset(
+			datasets.NewGetDatasetParamsWithTimeout(timeout).WithRepository("repo1").WithDataset("events"),
+			bauth)
+		if _, ok := err.(*datasets.GetDatasetNotFound); !ok {
+			t.Fatalf("GetDataset() of a deleted dataset error = %v, expected not found", err)
+		}
+	})
+}
+
+func TestController_GetLineageHandler(t *testing.T) {
+	clt, deps := setupClient(t, "")
+
+	// create user
+	creds := createDefaultAdminUser(t, clt)
+	bauth := httptransport.BasicAuth(creds.AccessKeyID, creds.AccessSecretKey)
+
+	ctx := context.Background()
+	commitIDs := make(map[string]string)
+	for _, repo := range []string{"raw", "derived"} {
+		_, err := deps.cataloger.CreateRepository(ctx, repo, "gs://bucket/"+repo, "master")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = clt.Objects.UploadObject(
+			objects.NewUploadObjectParamsWithTimeout(timeout).
+				WithBranch("master").
+				WithContent(runtime.NamedReader("content", strings.NewReader(repo))).
+				WithPath("data").
+				WithRepository(repo),
+			bauth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var metadata catalog.Metadata
+		if repo == "derived" {
+			metadata = catalog.Metadata{catalog.LineageInputsMetadataKey: `[{"repository":"raw","reference":"master"}]`}
+		}
+		commitLog, err := deps.cataloger.Commit(ctx, repo, "master", "load "+repo, "test", metadata)
+		if err != nil {
+			t.Fatal(err)
+		}
+		commitIDs[repo] = commitLog.Reference
+	}
+	expected := []*models.LineageEdge{
+		{
+			Commit: &models.LineageRef{Repository: swag.String("derived"), Reference: swag.String(commitIDs["derived"])},
+			Input:  &models.LineageRef{Repository: swag.String("raw"), Reference: swag.String(commitIDs["raw"])},
+		},
+	}
+
+	t.Run("upstream", func(t *testing.T) {
+		resp, err := clt.Commits.GetLineage(
+			commits.NewGetLineageParamsWithTimeout(timeout).WithRepository("derived").WithRef("master"),
+			bauth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := deep.Equal(resp.Payload.Results, expected); diff != nil {
+			t.Error("GetLineage() upstream", diff)
+		}
+	})
+
+	t.Run("downstream", func(t *testing.T) {
+		resp, err := clt.Commits.GetLineage(
+			commits.NewGetLineageParamsWithTimeout(timeout).WithRepository("raw").WithRef("master").WithDownstream(swag.Bool(true)),
+			bauth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := deep.Equal(resp.Payload.Results, expected); diff != nil {
+			t.Error("GetLineage() downstream", diff)
+		}
+	})
+
+	t.Run("missing ref", func(t *testing.T) {
+		_, err := clt.Commits.GetLineage(
+			commits.NewGetLineageParamsWithTimeout(timeout).WithRepository("raw").WithRef("no-such-ref"),
+			bauth)
+		if _, ok := err.(*commits.GetLineageNotFound); !ok {
+			t.Fatalf("GetLineage() of a missing ref error = %v, expected not found", err)
+		}
+	})
+}
+
 func TestController_CreatePolicyHandler(t *testing.T) {
 	clt, _ := setupClient(t, "")
 
@@ -1311,6 +1896,70 @@ func TestController_CreatePolicyHandler(t *testing.T) {
 
 }
 
+func TestController_TenantPolicyEscalation(t *testing.T) {
+	clt, deps := setupClient(t, "")
+	createDefaultAdminUser(t, clt)
+	creds := createTenantAdminUser(t, deps, "acme")
+	bauth := httptransport.BasicAuth(creds.AccessKeyID, creds.AccessSecretKey)
+
+	allowAll := []*models.Statement{
+		{
+			Action:   []string{"*"},
+			Effect:   swag.String("allow"),
+			Resource: swag.String("*"),
+		},
+	}
+	_, err := clt.Auth.CreatePolicy(
+		auth.NewCreatePolicyParamsWithTimeout(timeout).
+			WithPolicy(&models.Policy{ID: swag.String("acme-Everything"), Statement: allowAll}),
+		bauth)
+	var createUnauthorized *auth.CreatePolicyUnauthorized
+	if !errors.As(err, &createUnauthorized) {
+		t.Fatalf("CreatePolicy allowing all actions on all resources: %v, expected unauthorized", err)
+	}
+
+	_, err = clt.Auth.CreatePolicy(
+		auth.NewCreatePolicyParamsWithTimeout(timeout).
+			WithPolicy(&models.Policy{ID: swag.String("acme-Repos"), Statement: []*models.Statement{
+				{
+					Action:   []string{"fs:*"},
+					Effect:   swag.String("allow"),
+					Resource: swag.String("arn:lakefs:fs:::repository/acme-*"),
+				},
+			}}),
+		bauth)
+	testutil.MustDo(t, "create policy on tenant repositories", err)
+	_, err = clt.Auth.UpdatePolicy(
+		auth.NewUpdatePolicyParamsWithTimeout(timeout).
+			WithPolicyID("acme-Repos").
+			WithPolicy(&models.Policy{ID: swag.String("acme-Repos"), Statement: allowAll}),
+		bauth)
+	var updateUnauthorized *auth.UpdatePolicyUnauthorized
+	if !errors.As(err, &updateUnauthorized) {
+		t.Fatalf("UpdatePolicy to allow all actions on all resources: %v, expected unauthorized", err)
+	}
+
+	// installation policies may not be attached by tenant admins
+	_, err = clt.Auth.AttachPolicyToUser(
+		auth.NewAttachPolicyToUserParamsWithTimeout(timeout).
+			WithUserID("acme-admin").
+			WithPolicyID("AuthFullAccess"),
+		bauth)
+	var attachUnauthorized *auth.AttachPolicyToUserUnauthorized
+	if !errors.As(err, &attachUnauthorized) {
+		t.Fatalf("AttachPolicyToUser of installation policy: %v, expected unauthorized", err)
+	}
+	_, err = clt.Auth.AttachPolicyToGroup(
+		auth.NewAttachPolicyToGroupParamsWithTimeout(timeout).
+			WithGroupID("acme-Admins").
+			WithPolicyID("FSFullAccess"),
+		bauth)
+	var attachGroupUnauthorized *auth.AttachPolicyToGroupUnauthorized
+	if !errors.As(err, &attachGroupUnauthorized) {
+		t.Fatalf("AttachPolicyToGroup of installation policy: %v, expected unauthorized", err)
+	}
+}
+
 func TestController_ConfigHandlers(t *testing.T) {
 	const BlockstoreType = "s3"
 	clt, _ := setupClient(t, BlockstoreType)
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/swag"
+	"github.com/treeverse/lakefs/api/gen/models"
+	"github.com/treeverse/lakefs/api/gen/restapi/operations/refs"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/permissions"
+)
+
+func transformTableStats(stats *catalog.TableStats) *models.TableStats {
+	if stats == nil {
+		return nil
+	}
+	columns := make([]*models.TableStatsColumnsItems0, len(stats.Columns))
+	for i, col := range stats.Columns {
+		columns[i] = &models.TableStatsColumnsItems0{
+			Name: swag.String(col.Name),
+			Type: swag.String(col.Type),
+		}
+	}
+	return &models.TableStats{
+		Format:            swag.String(stats.Format),
+		RowCount:          swag.Int64(stats.RowCount),
+		RowCountEstimated: stats.RowCountEstimated,
+		Columns:           columns,
+	}
+}
+
+func transformDataDifference(d *catalog.DataDifference) *models.DataDiff {
+	return &models.DataDiff{
+		Type:           transformDifferenceTypeToString(d.Type),
+		Path:           d.Path,
+		Left:           transformTableStats(d.Left),
+		Right:          transformTableStats(d.Right),
+		RowCountDelta:  d.RowCountDelta(),
+		AddedColumns:   d.AddedColumns,
+		RemovedColumns: d.RemovedColumns,
+		ChangedColumns: d.ChangedColumns,
+		Error:          d.Error,
+	}
+}
+
+func (c *Controller) RefsDiffRefsDataHandler() refs.DiffRefsDataHandler {
+	return refs.DiffRefsDataHandlerFunc(func(params refs.DiffRefsDataParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ListObjectsAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return refs.NewDiffRefsDataUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("diff_refs_data")
+		cataloger := deps.Cataloger
+		limit := int(swag.Int64Value(params.Amount))
+		if limit <= 0 || limit > catalog.DataDiffLimitMax {
+			limit = catalog.DataDiffLimitMax
+		}
+		diff, hasMore, err := cataloger.Diff(deps.ctx, params.Repository, params.LeftRef, params.RightRef, catalog.DiffParams{
+			Limit: limit,
+			After: swag.StringValue(params.After),
+			Types: transformStringsToDifferenceTypes(params.ChangeType),
+		})
+		if errors.Is(err, db.ErrNotFound) {
+			return refs.NewDiffRefsDataNotFound().WithPayload(responseErrorFrom(err))
+		}
+		if err != nil {
+			return refs.NewDiffRefsDataDefault(http.StatusInternalServerError).
+				WithPayload(responseError("could not diff references: %s", err))
+		}
+
+		// the data of every tabular object compared must be readable
+		var perms []permissions.Permission
+		for _, d := range diff {
+			if catalog.TableFormat(d.Path) != "" {
+				perms = append(perms, permissions.Permission{
+					Action:   permissions.ReadObjectAction,
+					Resource: permissions.ObjectArn(params.Repository, d.Path),
+				})
+			}
+		}
+		if len(perms) > 0 {
+			if err := authorize(deps.Auth, user, perms); err != nil {
+				return refs.NewDiffRefsDataUnauthorized().WithPayload(responseErrorFrom(err))
+			}
+		}
+
+		dataDiff, err := cataloger.DiffData(deps.ctx, params.Repository, params.LeftRef, params.RightRef, diff)
+		if errors.Is(err, db.ErrNotFound) {
+			return refs.NewDiffRefsDataNotFound().WithPayload(responseErrorFrom(err))
+		}
+		if err != nil {
+			return refs.NewDiffRefsDataDefault(http.StatusInternalServerError).
+				WithPayload(responseError("could not diff data of references: %s", err))
+		}
+		results := make([]*models.DataDiff, len(dataDiff))
+		for i, d := range dataDiff {
+			results[i] = transformDataDifference(d)
+		}
+		var nextOffset string
+		if hasMore && len(diff) > 0 {
+			nextOffset = diff[len(diff)-1].Path
+		}
+		return refs.NewDiffRefsDataOK().WithPayload(&refs.DiffRefsDataOKBody{
+			Results: results,
+			Pagination: &models.Pagination{
+				NextOffset: nextOffset,
+				HasMore:    swag.Bool(hasMore),
+				Results:    swag.Int64(int64(len(diff))),
+				MaxPerPage: swag.Int64(catalog.DataDiffLimitMax),
+			},
+		})
+	})
+}
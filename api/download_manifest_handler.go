@@ -0,0 +1,134 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/swag"
+	"github.com/treeverse/lakefs/api/gen/models"
+	"github.com/treeverse/lakefs/api/gen/restapi/operations/objects"
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/encryption"
+	"github.com/treeverse/lakefs/errcode"
+	"github.com/treeverse/lakefs/permissions"
+)
+
+const (
+	DefaultDownloadPartSize = 64 * 1024 * 1024
+	DefaultDownloadExpiry   = 15 * time.Minute
+	// MaxDownloadParts is the maximum number of parts in a download manifest
+	MaxDownloadParts = 10000
+)
+
+// downloadParts splits an object of size bytes into consecutive parts of partSize bytes, the
+// last part holding the remainder.  Part ends are inclusive.
+func downloadParts(size, partSize int64) []*models.DownloadPart {
+	parts := make([]*models.DownloadPart, 0, (size+partSize-1)/partSize)
+	for start := int64(0); start < size; start += partSize {
+		end := start + partSize - 1
+		if end >= size {
+			end = size - 1
+		}
+		parts = append(parts, &models.DownloadPart{
+			PartNumber: swag.Int64(int64(len(parts) + 1)),
+			Start:      swag.Int64(start),
+			End:        swag.Int64(end),
+			Range:      swag.String(fmt.Sprintf("bytes=%d-%d", start, end)),
+		})
+	}
+	return parts
+}
+
+func (c *Controller) ObjectsGetObjectDownloadManifestHandler() objects.GetObjectDownloadManifestHandler {
+	return objects.GetObjectDownloadManifestHandlerFunc(func(params objects.GetObjectDownloadManifestParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadObjectAction,
+				Resource: permissions.ObjectArn(params.Repository, params.Path),
+			},
+		})
+		if err != nil {
+			return objects.NewGetObjectDownloadManifestUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("get_object_download_manifest")
+		cataloger := deps.Cataloger
+
+		presigner, ok := deps.BlockAdapter.(block.Presigner)
+		if !ok {
+			return objects.NewGetObjectDownloadManifestNotImplemented().
+				WithPayload(responseErrorCode(errcode.NotSupported, "block adapter does not support presigned URLs"))
+		}
+		partSize := swag.Int64Value(params.PartSize)
+		if partSize == 0 {
+			partSize = DefaultDownloadPartSize
+		}
+		expiry := DefaultDownloadExpiry
+		if params.ExpirySeconds != nil {
+			expiry = time.Duration(*params.ExpirySeconds) * time.Second
+		}
+
+		repo, err := cataloger.GetRepository(deps.ctx, params.Repository)
+		if errors.Is(err, db.ErrNotFound) {
+			return objects.NewGetObjectDownloadManifestNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository not found"))
+		}
+		if err != nil {
+			return objects.NewGetObjectDownloadManifestDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		entry, err := cataloger.GetEntry(deps.ctx, params.Repository, params.Ref, params.Path, catalog.GetEntryParams{
+			ReturnExpired: true,
+			AuthorizeLink: authorizeLinkFunc(deps, user),
+		})
+		if errors.Is(err, db.ErrNotFound) {
+			return objects.NewGetObjectDownloadManifestNotFound().WithPayload(responseError("resource not found"))
+		}
+		if errors.Is(err, ErrAuthorization) || errors.Is(err, catalog.ErrCrossRepositoryLink) {
+			return objects.NewGetObjectDownloadManifestUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		if err != nil {
+			return objects.NewGetObjectDownloadManifestDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		if entry.Expired {
+			return objects.NewGetObjectDownloadManifestGone().WithPayload(responseErrorCode(errcode.ObjectExpired, "resource expired"))
+		}
+		redaction, err := cataloger.GetRedaction(deps.ctx, repo.StorageNamespace, entry.PhysicalAddress)
+		if err == nil {
+			return objects.NewGetObjectDownloadManifestGone().WithPayload(responseError("resource redacted: %s", redaction.Reason))
+		}
+		if !errors.Is(err, db.ErrNotFound) {
+			return objects.NewGetObjectDownloadManifestDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		// the object store holds the ciphertext, only lakeFS can decrypt it
+		if encryption.IsEncrypted(entry.Metadata) {
+			return objects.NewGetObjectDownloadManifestBadRequest().
+				WithPayload(responseErrorCode(errcode.NotSupported, "encrypted objects must be downloaded through lakeFS"))
+		}
+		if entry.Size > partSize*MaxDownloadParts {
+			return objects.NewGetObjectDownloadManifestBadRequest().
+				WithPayload(responseErrorCode(errcode.InvalidValue, "part size %d splits object of size %d into more than %d parts", partSize, entry.Size, MaxDownloadParts))
+		}
+
+		expiresAt := time.Now().Add(expiry)
+		pointer := block.ObjectPointer{StorageNamespace: repo.StorageNamespace, Identifier: entry.PhysicalAddress}
+		parts := downloadParts(entry.Size, partSize)
+		for _, part := range parts {
+			url, err := presigner.GetPresignedRangeURL(pointer, *part.Start, *part.End, expiry)
+			if err != nil {
+				return objects.NewGetObjectDownloadManifestDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+			}
+			part.URL = swag.String(url)
+		}
+		return objects.NewGetObjectDownloadManifestOK().WithPayload(&models.DownloadManifest{
+			Path:      swag.String(entry.Path),
+			SizeBytes: swag.Int64(entry.Size),
+			Checksum:  swag.String(entry.Checksum),
+			PartSize:  swag.Int64(partSize),
+			ExpiresAt: swag.Int64(expiresAt.Unix()),
+			Parts:     parts,
+		})
+	})
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/go-openapi/swag"
+)
+
+func TestDownloadParts(t *testing.T) {
+	cases := []struct {
+		name     string
+		size     int64
+		partSize int64
+		expected [][2]int64
+	}{
+		{name: "empty", size: 0, partSize: 10, expected: nil},
+		{name: "single", size: 7, partSize: 10, expected: [][2]int64{{0, 6}}},
+		{name: "exact", size: 20, partSize: 10, expected: [][2]int64{{0, 9}, {10, 19}}},
+		{name: "remainder", size: 25, partSize: 10, expected: [][2]int64{{0, 9}, {10, 19}, {20, 24}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			parts := downloadParts(tc.size, tc.partSize)
+			if len(parts) != len(tc.expected) {
+				t.Fatalf("got %d parts, expected %d", len(parts), len(tc.expected))
+			}
+			for i, part := range parts {
+				if n := swag.Int64Value(part.PartNumber); n != int64(i+1) {
+					t.Errorf("part %d: got part number %d", i, n)
+				}
+				start, end := swag.Int64Value(part.Start), swag.Int64Value(part.End)
+				if start != tc.expected[i][0] || end != tc.expected[i][1] {
+					t.Errorf("part %d: got range %d-%d, expected %d-%d", i, start, end, tc.expected[i][0], tc.expected[i][1])
+				}
+			}
+		})
+	}
+}
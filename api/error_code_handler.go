@@ -0,0 +1,72 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/treeverse/lakefs/errcode"
+)
+
+// errorCodeResponseWriter buffers JSON error responses so a code can be added to them
+type errorCodeResponseWriter struct {
+	http.ResponseWriter
+	status    int
+	buffering bool
+	body      bytes.Buffer
+}
+
+func (w *errorCodeResponseWriter) WriteHeader(status int) {
+	w.status = status
+	if status >= http.StatusBadRequest && strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		w.buffering = true
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *errorCodeResponseWriter) Write(b []byte) (int, error) {
+	if w.buffering {
+		return w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *errorCodeResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok && !w.buffering {
+		f.Flush()
+	}
+}
+
+func (w *errorCodeResponseWriter) finish() {
+	if !w.buffering {
+		return
+	}
+	body := w.body.Bytes()
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err == nil {
+		_, hasMessage := payload["message"]
+		code, _ := payload["code"].(string)
+		if hasMessage && code == "" {
+			payload["code"] = string(errcode.FromStatus(w.status))
+			if b, err := json.Marshal(payload); err == nil {
+				body = append(b, '\n')
+			}
+		}
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.ResponseWriter.WriteHeader(w.status)
+	_, _ = w.ResponseWriter.Write(body)
+}
+
+// NewErrorCodeHandler adds a code derived from the response status to JSON error responses
+// that were returned without one, so every API error carries a machine-readable code.
+func NewErrorCodeHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cw := &errorCodeResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(cw, r)
+		cw.finish()
+	})
+}
@@ -0,0 +1,59 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/treeverse/lakefs/api"
+)
+
+func TestErrorCodeHandler(t *testing.T) {
+	cases := []struct {
+		name     string
+		status   int
+		body     string
+		wantCode string
+	}{
+		{name: "missing code", status: http.StatusNotFound, body: `{"message":"not found"}`, wantCode: "not_found"},
+		{name: "numeric code", status: http.StatusUnauthorized, body: `{"code":401,"message":"unauthenticated"}`, wantCode: "unauthorized"},
+		{name: "existing code", status: http.StatusNotFound, body: `{"code":"branch_not_found","message":"branch not found"}`, wantCode: "branch_not_found"},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := api.NewErrorCodeHandler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/repositories", nil))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			var payload struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+				t.Fatalf("decode response: %s", err)
+			}
+			if payload.Code != tt.wantCode {
+				t.Errorf("code = %s, want %s", payload.Code, tt.wantCode)
+			}
+		})
+	}
+
+	t.Run("success untouched", func(t *testing.T) {
+		handler := api.NewErrorCodeHandler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"message":"ok"}`))
+		}))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/repositories", nil))
+		if rec.Body.String() != `{"message":"ok"}` {
+			t.Errorf("body = %s, want unchanged", rec.Body.String())
+		}
+	})
+}
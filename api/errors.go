@@ -1,15 +1,44 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/treeverse/lakefs/api/gen/models"
+	"github.com/treeverse/lakefs/errcode"
 )
 
 func responseError(msg string, args ...interface{}) *models.Error {
 	return &models.Error{Message: fmt.Sprintf(msg, args...)}
 }
 
+// responseErrorCode returns an error payload with an explicit code, for errors reported with
+// a message of their own
+func responseErrorCode(code errcode.Code, msg string, args ...interface{}) *models.Error {
+	return &models.Error{Message: fmt.Sprintf(msg, args...), Code: string(code)}
+}
+
+// responseErrorFrom returns the error payload for err, including the code it maps to
 func responseErrorFrom(err error) *models.Error {
-	return responseError(err.Error())
+	code, _ := errcode.Of(err)
+	return &models.Error{Message: err.Error(), Code: string(code)}
+}
+
+// errorPayload is implemented by the error responses of the generated client
+type errorPayload interface {
+	GetPayload() *models.Error
+}
+
+// ErrorCode returns the code of an error returned by the API client, or an empty code if
+// err was not returned by the server.
+func ErrorCode(err error) errcode.Code {
+	var payloadErr errorPayload
+	if !errors.As(err, &payloadErr) {
+		return ""
+	}
+	payload := payloadErr.GetPayload()
+	if payload == nil {
+		return ""
+	}
+	return errcode.Code(payload.Code)
 }
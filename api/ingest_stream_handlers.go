@@ -0,0 +1,241 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/swag"
+	"github.com/treeverse/lakefs/api/gen/models"
+	ingestop "github.com/treeverse/lakefs/api/gen/restapi/operations/ingest"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/errcode"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/ingest"
+	"github.com/treeverse/lakefs/permissions"
+)
+
+func ingestStreamModel(stream *ingest.Stream) *models.IngestStream {
+	return &models.IngestStream{
+		Name:                swag.String(stream.Name),
+		Branch:              swag.String(stream.Branch),
+		Prefix:              swag.String(stream.Prefix),
+		Format:              swag.String(stream.Format),
+		MaxFileSizeBytes:    swag.Int64(stream.MaxFileSizeBytes),
+		RollIntervalSeconds: swag.Int64(int64(stream.RollIntervalSeconds)),
+		CreationDate:        swag.Int64(stream.CreationDate.Unix()),
+		CurrentPath:         stream.CurrentPath,
+	}
+}
+
+func (c *Controller) ListIngestStreamsHandler() ingestop.ListIngestStreamsHandler {
+	return ingestop.ListIngestStreamsHandlerFunc(func(params ingestop.ListIngestStreamsParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.GetIngestStreamAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return ingestop.NewListIngestStreamsUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("list_ingest_streams")
+		streams, err := deps.IngestStreams.ListStreams(deps.ctx, params.Repository)
+		if err != nil {
+			return ingestop.NewListIngestStreamsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		results := make([]*models.IngestStream, len(streams))
+		for i, stream := range streams {
+			results[i] = ingestStreamModel(stream)
+		}
+		return ingestop.NewListIngestStreamsOK().WithPayload(&models.IngestStreamList{Results: results})
+	})
+}
+
+func (c *Controller) GetIngestStreamHandler() ingestop.GetIngestStreamHandler {
+	return ingestop.GetIngestStreamHandlerFunc(func(params ingestop.GetIngestStreamParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.GetIngestStreamAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return ingestop.NewGetIngestStreamUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("get_ingest_stream")
+		stream, err := deps.IngestStreams.GetStream(deps.ctx, params.Repository, params.Stream)
+		switch {
+		case errors.Is(err, ingest.ErrStreamNotFound):
+			return ingestop.NewGetIngestStreamNotFound().WithPayload(responseError("ingest stream '%s' not found.", params.Stream))
+		case err != nil:
+			return ingestop.NewGetIngestStreamDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return ingestop.NewGetIngestStreamOK().WithPayload(ingestStreamModel(stream))
+	})
+}
+
+func (c *Controller) SetIngestStreamHandler() ingestop.SetIngestStreamHandler {
+	return ingestop.SetIngestStreamHandlerFunc(func(params ingestop.SetIngestStreamParams, user *models.User) middleware.Responder {
+		creation := params.IngestStream
+		branch := swag.StringValue(creation.Branch)
+		// the stream writes records, and its auto-commit policy commits them, on behalf of its setter
+		perms := []permissions.Permission{
+			{
+				Action:   permissions.SetIngestStreamAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+			{
+				Action:   permissions.WriteObjectAction,
+				Resource: permissions.ObjectArn(params.Repository, creation.Prefix),
+			},
+		}
+		if creation.AutoCommit != nil {
+			perms = append(perms, permissions.Permission{
+				Action:   permissions.SetAutoCommitPolicyAction,
+				Resource: permissions.BranchArn(params.Repository, branch),
+			}, permissions.Permission{
+				Action:   permissions.CreateCommitAction,
+				Resource: permissions.BranchArn(params.Repository, branch),
+			})
+		}
+		deps, err := c.setupRequest(user, params.HTTPRequest, perms, sandboxPermissions(user, params.Repository, branch)...)
+		if err != nil {
+			return ingestop.NewSetIngestStreamUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("set_ingest_stream")
+		stream := &ingest.Stream{
+			RepositoryID:        params.Repository,
+			Name:                params.Stream,
+			Branch:              branch,
+			Prefix:              creation.Prefix,
+			Format:              swag.StringValue(creation.Format),
+			MaxFileSizeBytes:    creation.MaxFileSizeBytes,
+			RollIntervalSeconds: int(creation.RollIntervalSeconds),
+		}
+		if stream.MaxFileSizeBytes == 0 {
+			stream.MaxFileSizeBytes = ingest.DefaultMaxFileSizeBytes
+		}
+		if stream.RollIntervalSeconds == 0 {
+			stream.RollIntervalSeconds = ingest.DefaultRollIntervalSeconds
+		}
+		if err := stream.Validate(); err != nil {
+			return ingestop.NewSetIngestStreamBadRequest().WithPayload(responseErrorFrom(err))
+		}
+		_, err = deps.Cataloger.GetRepository(deps.ctx, params.Repository)
+		if errors.Is(err, db.ErrNotFound) {
+			return ingestop.NewSetIngestStreamNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository '%s' not found.", params.Repository))
+		}
+		if err != nil {
+			return ingestop.NewSetIngestStreamDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		branchExists, err := deps.Cataloger.BranchExists(deps.ctx, params.Repository, branch)
+		if err != nil {
+			return ingestop.NewSetIngestStreamDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		if !branchExists {
+			return ingestop.NewSetIngestStreamNotFound().WithPayload(responseErrorCode(errcode.BranchNotFound, "branch '%s' not found.", branch))
+		}
+		if policy := creation.AutoCommit; policy != nil {
+			err = deps.Cataloger.SetAutoCommitPolicy(deps.ctx, params.Repository, branch, catalog.AutoCommitPolicy{
+				MaxStagedEntries: int(policy.MaxStagedEntries),
+				IntervalSeconds:  int(policy.IntervalSeconds),
+				MessageTemplate:  policy.MessageTemplate,
+			})
+			if errors.Is(err, catalog.ErrInvalidValue) {
+				return ingestop.NewSetIngestStreamBadRequest().WithPayload(responseErrorFrom(err))
+			}
+			if err != nil {
+				return ingestop.NewSetIngestStreamDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+			}
+		}
+		err = deps.IngestStreams.SetStream(deps.ctx, stream)
+		if err == nil {
+			stream, err = deps.IngestStreams.GetStream(deps.ctx, params.Repository, params.Stream)
+		}
+		if err != nil {
+			return ingestop.NewSetIngestStreamDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return ingestop.NewSetIngestStreamCreated().WithPayload(ingestStreamModel(stream))
+	})
+}
+
+func (c *Controller) DeleteIngestStreamHandler() ingestop.DeleteIngestStreamHandler {
+	return ingestop.DeleteIngestStreamHandlerFunc(func(params ingestop.DeleteIngestStreamParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.SetIngestStreamAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return ingestop.NewDeleteIngestStreamUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("delete_ingest_stream")
+		err = deps.IngestStreams.DeleteStream(deps.ctx, params.Repository, params.Stream)
+		switch {
+		case errors.Is(err, ingest.ErrStreamNotFound):
+			return ingestop.NewDeleteIngestStreamNotFound().WithPayload(responseError("ingest stream '%s' not found.", params.Stream))
+		case err != nil:
+			return ingestop.NewDeleteIngestStreamDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return ingestop.NewDeleteIngestStreamNoContent()
+	})
+}
+
+func (c *Controller) IngestRecordsHandler() ingestop.IngestRecordsHandler {
+	return ingestop.IngestRecordsHandlerFunc(func(params ingestop.IngestRecordsParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.IngestRecordsAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return ingestop.NewIngestRecordsUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("ingest_records")
+		repo, err := deps.Cataloger.GetRepository(deps.ctx, params.Repository)
+		if errors.Is(err, db.ErrNotFound) {
+			return ingestop.NewIngestRecordsNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository '%s' not found.", params.Repository))
+		}
+		if err != nil {
+			return ingestop.NewIngestRecordsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		stream, err := deps.IngestStreams.GetStream(deps.ctx, params.Repository, params.Stream)
+		if errors.Is(err, ingest.ErrStreamNotFound) {
+			return ingestop.NewIngestRecordsNotFound().WithPayload(responseError("ingest stream '%s' not found.", params.Stream))
+		}
+		if err != nil {
+			return ingestop.NewIngestRecordsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		repoSettings, err := deps.Settings.Get(deps.ctx, params.Repository)
+		if err != nil {
+			return ingestop.NewIngestRecordsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+
+		ingester := ingest.NewIngester(deps.IngestStreams, deps.Cataloger, deps.BlockAdapter, deps.Encryptor)
+		result, err := ingester.Ingest(deps.ctx, repo, stream, params.Content, repoSettings.MaxObjectSize)
+		switch {
+		case errors.Is(err, ingest.ErrInvalidBatch):
+			return ingestop.NewIngestRecordsBadRequest().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, graveler.ErrPreconditionFailed):
+			return ingestop.NewIngestRecordsPreconditionFailed().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, catalog.ErrPathLeased):
+			return ingestop.NewIngestRecordsConflict().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, catalog.ErrFeatureNotSupported):
+			return ingestop.NewIngestRecordsNotImplemented().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, db.ErrNotFound):
+			return ingestop.NewIngestRecordsNotFound().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return ingestop.NewIngestRecordsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return ingestop.NewIngestRecordsOK().WithPayload(&models.IngestResult{
+			Path:      swag.String(result.Path),
+			Records:   swag.Int64(result.Records),
+			SizeBytes: swag.Int64(result.SizeBytes),
+			Rolled:    swag.Bool(result.Rolled),
+		})
+	})
+}
@@ -0,0 +1,268 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/swag"
+	"github.com/treeverse/lakefs/api/gen/models"
+	instanceop "github.com/treeverse/lakefs/api/gen/restapi/operations/instance_settings"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/permissions"
+	"github.com/treeverse/lakefs/settings"
+)
+
+// checkRepositoryLimit verifies the installation may hold another repository
+func checkRepositoryLimit(deps *Dependencies) error {
+	instance, err := deps.InstanceSettings.Get(deps.ctx)
+	if err != nil || instance.MaxRepositories == 0 {
+		return err
+	}
+	repos, _, err := deps.Cataloger.ListRepositories(deps.ctx, instance.MaxRepositories, "")
+	if err != nil {
+		return err
+	}
+	return instance.CheckRepositoryCount(len(repos))
+}
+
+// setDefaultRetentionRules gives a new repository the retention rules of the instance settings.
+// Failures are logged, the repository is created anyway.
+func setDefaultRetentionRules(deps *Dependencies, repository string) {
+	instance, err := deps.InstanceSettings.Get(deps.ctx)
+	if err == nil && instance.DefaultRetentionDays > 0 {
+		err = deps.Cataloger.SetRetentionRules(deps.ctx, repository, catalog.RetentionRules{
+			KeepNewerThanDays: instance.DefaultRetentionDays,
+			KeepTagged:        true,
+		})
+	}
+	if err != nil {
+		deps.Logger.WithError(err).WithField("repository", repository).Warn("Failed to set default retention rules")
+	}
+}
+
+// publicReadActions may be performed by every user on public repositories
+var publicReadActions = map[string]struct{}{
+	permissions.ReadRepositoryAction: {},
+	permissions.ReadObjectAction:     {},
+	permissions.ListObjectsAction:    {},
+	permissions.ReadCommitAction:     {},
+	permissions.ListCommitsAction:    {},
+	permissions.ReadBranchAction:     {},
+	permissions.ListBranchesAction:   {},
+	permissions.ReadTagAction:        {},
+	permissions.ListTagsAction:       {},
+}
+
+// publicRead returns true if perms only read a single repository that is public
+func publicRead(deps *Dependencies, perms []permissions.Permission) bool {
+	repository := ""
+	for _, p := range perms {
+		if _, ok := publicReadActions[p.Action]; !ok {
+			return false
+		}
+		repo, ok := permissions.ArnRepository(p.Resource)
+		if !ok || (repository != "" && repo != repository) {
+			return false
+		}
+		repository = repo
+	}
+	if repository == "" {
+		return false
+	}
+	instance, err := deps.InstanceSettings.Get(deps.ctx)
+	if err != nil || !instance.PublicRepositoriesAllowed {
+		return false
+	}
+	repoSettings, err := deps.Settings.Get(deps.ctx, repository)
+	return err == nil && repoSettings.Public
+}
+
+func (c *Controller) GetInstanceSettingsHandler() instanceop.GetInstanceSettingsHandler {
+	return instanceop.GetInstanceSettingsHandlerFunc(func(params instanceop.GetInstanceSettingsParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadSettingsAction,
+				Resource: permissions.All,
+			},
+		})
+		if err != nil {
+			return instanceop.NewGetInstanceSettingsUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("get_instance_settings")
+		// read without the cache, to include recent changes
+		stored, err := deps.InstanceSettings.ListInstanceSettings(deps.ctx)
+		if err != nil {
+			return instanceop.NewGetInstanceSettingsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		instance := settings.ApplyInstanceSettings(stored)
+		return instanceop.NewGetInstanceSettingsOK().WithPayload(&models.InstanceSettings{
+			PublicRepositoriesAllowed: swag.Bool(instance.PublicRepositoriesAllowed),
+			MaxRepositories:           swag.Int64(int64(instance.MaxRepositories)),
+			DefaultRetentionDays:      swag.Int64(int64(instance.DefaultRetentionDays)),
+		})
+	})
+}
+
+func (c *Controller) SetInstanceSettingHandler() instanceop.SetInstanceSettingHandler {
+	return instanceop.SetInstanceSettingHandlerFunc(func(params instanceop.SetInstanceSettingParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.WriteSettingsAction,
+				Resource: permissions.All,
+			},
+		})
+		if err != nil {
+			return instanceop.NewSetInstanceSettingUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("set_instance_setting")
+		err = deps.InstanceSettings.Set(deps.ctx, params.Key, swag.StringValue(params.Setting.Value), user.ID)
+		switch {
+		case errors.Is(err, settings.ErrUnknownKey), errors.Is(err, settings.ErrInvalidValue):
+			return instanceop.NewSetInstanceSettingBadRequest().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return instanceop.NewSetInstanceSettingDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return instanceop.NewSetInstanceSettingNoContent()
+	})
+}
+
+func (c *Controller) ListInstanceSettingsAuditHandler() instanceop.ListInstanceSettingsAuditHandler {
+	return instanceop.ListInstanceSettingsAuditHandlerFunc(func(params instanceop.ListInstanceSettingsAuditParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadSettingsAction,
+				Resource: permissions.All,
+			},
+		})
+		if err != nil {
+			return instanceop.NewListInstanceSettingsAuditUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("list_instance_settings_audit")
+		after, amount := getPaginationParams(params.After, params.Amount)
+		var afterID int64
+		if after != "" {
+			afterID, err = strconv.ParseInt(after, 10, 64)
+			if err != nil {
+				return instanceop.NewListInstanceSettingsAuditDefault(http.StatusBadRequest).WithPayload(responseError("invalid after: %s", after))
+			}
+		}
+		entries, hasMore, err := deps.InstanceSettings.ListAudit(deps.ctx, amount, afterID)
+		if err != nil {
+			return instanceop.NewListInstanceSettingsAuditDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		results := make([]*models.InstanceSettingsAuditEntry, len(entries))
+		for i, entry := range entries {
+			results[i] = &models.InstanceSettingsAuditEntry{
+				ID:         swag.Int64(entry.ID),
+				Key:        swag.String(entry.Key),
+				Value:      swag.String(entry.Value),
+				UpdatedBy:  swag.String(entry.UpdatedBy),
+				UpdateDate: swag.Int64(entry.UpdateDate.Unix()),
+			}
+		}
+		pagination := &models.Pagination{
+			HasMore:    swag.Bool(hasMore),
+			Results:    swag.Int64(int64(len(results))),
+			MaxPerPage: swag.Int64(MaxResultsPerPage),
+		}
+		if hasMore {
+			pagination.NextOffset = strconv.FormatInt(entries[len(entries)-1].ID, 10)
+		}
+		return instanceop.NewListInstanceSettingsAuditOK().WithPayload(&models.InstanceSettingsAuditList{
+			Pagination: pagination,
+			Results:    results,
+		})
+	})
+}
+
+func featureFlagModel(flag *settings.FeatureFlag) *models.FeatureFlag {
+	m := &models.FeatureFlag{
+		Name:         swag.String(flag.Name),
+		Enabled:      swag.Bool(flag.Enabled),
+		Repositories: flag.Repositories,
+		Percentage:   int64(flag.Percentage),
+		UpdatedBy:    flag.UpdatedBy,
+	}
+	if !flag.UpdateDate.IsZero() {
+		m.UpdateDate = flag.UpdateDate.Unix()
+	}
+	return m
+}
+
+func (c *Controller) ListFeatureFlagsHandler() instanceop.ListFeatureFlagsHandler {
+	return instanceop.ListFeatureFlagsHandlerFunc(func(params instanceop.ListFeatureFlagsParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadSettingsAction,
+				Resource: permissions.All,
+			},
+		})
+		if err != nil {
+			return instanceop.NewListFeatureFlagsUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("list_feature_flags")
+		flags, err := deps.InstanceSettings.FeatureFlags(deps.ctx)
+		if err != nil {
+			return instanceop.NewListFeatureFlagsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		results := make([]*models.FeatureFlag, len(flags))
+		for i, flag := range flags {
+			results[i] = featureFlagModel(flag)
+		}
+		return instanceop.NewListFeatureFlagsOK().WithPayload(&models.FeatureFlagList{Results: results})
+	})
+}
+
+func (c *Controller) SetFeatureFlagHandler() instanceop.SetFeatureFlagHandler {
+	return instanceop.SetFeatureFlagHandlerFunc(func(params instanceop.SetFeatureFlagParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.WriteSettingsAction,
+				Resource: permissions.All,
+			},
+		})
+		if err != nil {
+			return instanceop.NewSetFeatureFlagUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("set_feature_flag")
+		err = deps.InstanceSettings.SetFeatureFlag(deps.ctx, &settings.FeatureFlag{
+			Name:         params.Feature,
+			Enabled:      params.Flag.Enabled,
+			Repositories: params.Flag.Repositories,
+			Percentage:   int(params.Flag.Percentage),
+			UpdatedBy:    user.ID,
+		})
+		switch {
+		case errors.Is(err, settings.ErrUnknownFeature), errors.Is(err, settings.ErrInvalidFeatureFlag):
+			return instanceop.NewSetFeatureFlagBadRequest().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return instanceop.NewSetFeatureFlagDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return instanceop.NewSetFeatureFlagNoContent()
+	})
+}
+
+func (c *Controller) DeleteFeatureFlagHandler() instanceop.DeleteFeatureFlagHandler {
+	return instanceop.DeleteFeatureFlagHandlerFunc(func(params instanceop.DeleteFeatureFlagParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.WriteSettingsAction,
+				Resource: permissions.All,
+			},
+		})
+		if err != nil {
+			return instanceop.NewDeleteFeatureFlagUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("delete_feature_flag")
+		err = deps.InstanceSettings.DeleteFeatureFlag(deps.ctx, params.Feature, user.ID)
+		switch {
+		case errors.Is(err, settings.ErrFeatureFlagNotFound):
+			return instanceop.NewDeleteFeatureFlagNotFound().WithPayload(responseError("feature flag '%s' not set.", params.Feature))
+		case err != nil:
+			return instanceop.NewDeleteFeatureFlagDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return instanceop.NewDeleteFeatureFlagNoContent()
+	})
+}
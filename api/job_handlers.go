@@ -0,0 +1,127 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/swag"
+	"github.com/treeverse/lakefs/api/gen/models"
+	jobsop "github.com/treeverse/lakefs/api/gen/restapi/operations/jobs"
+	"github.com/treeverse/lakefs/jobs"
+	"github.com/treeverse/lakefs/permissions"
+)
+
+func jobModel(job *jobs.Job) *models.Job {
+	m := &models.Job{
+		ID:              swag.String(job.ID),
+		Type:            swag.String(job.Type),
+		Repository:      swag.String(job.Repository),
+		CreatedBy:       swag.String(job.CreatedBy),
+		Status:          swag.String(job.Status),
+		Progress:        swag.Float64(job.Progress),
+		Error:           job.Error,
+		Log:             job.Log,
+		CancelRequested: job.CancelRequested,
+		CreationDate:    swag.Int64(job.CreationDate.Unix()),
+		UpdateDate:      swag.Int64(job.UpdateDate.Unix()),
+	}
+	if len(job.Result) > 0 {
+		var result interface{}
+		if err := json.Unmarshal(job.Result, &result); err == nil {
+			m.Result = result
+		}
+	}
+	return m
+}
+
+func (c *Controller) ListJobsHandler() jobsop.ListJobsHandler {
+	return jobsop.ListJobsHandlerFunc(func(params jobsop.ListJobsParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadRepositoryAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return jobsop.NewListJobsUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("list_jobs")
+		after, amount := getPaginationParams(params.After, params.Amount)
+		list, hasMore, err := deps.Jobs.List(deps.ctx, params.Repository, amount, after)
+		if err != nil {
+			return jobsop.NewListJobsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		results := make([]*models.Job, len(list))
+		for i, job := range list {
+			results[i] = jobModel(job)
+		}
+		pagination := &models.Pagination{
+			HasMore:    swag.Bool(hasMore),
+			Results:    swag.Int64(int64(len(results))),
+			MaxPerPage: swag.Int64(MaxResultsPerPage),
+		}
+		if hasMore {
+			pagination.NextOffset = list[len(list)-1].ID
+		}
+		return jobsop.NewListJobsOK().WithPayload(&models.JobList{
+			Pagination: pagination,
+			Results:    results,
+		})
+	})
+}
+
+func (c *Controller) GetJobHandler() jobsop.GetJobHandler {
+	return jobsop.GetJobHandlerFunc(func(params jobsop.GetJobParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadRepositoryAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return jobsop.NewGetJobUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("get_job")
+		job, err := deps.Jobs.Get(deps.ctx, params.JobID)
+		switch {
+		case errors.Is(err, jobs.ErrJobNotFound) || (err == nil && job.Repository != params.Repository):
+			return jobsop.NewGetJobNotFound().WithPayload(responseError("job not found"))
+		case err != nil:
+			return jobsop.NewGetJobDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return jobsop.NewGetJobOK().WithPayload(jobModel(job))
+	})
+}
+
+func (c *Controller) CancelJobHandler() jobsop.CancelJobHandler {
+	return jobsop.CancelJobHandlerFunc(func(params jobsop.CancelJobParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.CancelJobAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return jobsop.NewCancelJobUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("cancel_job")
+		job, err := deps.Jobs.Get(deps.ctx, params.JobID)
+		if err == nil && job.Repository != params.Repository {
+			err = jobs.ErrJobNotFound
+		}
+		if err == nil {
+			err = deps.Jobs.Cancel(deps.ctx, params.JobID)
+		}
+		switch {
+		case errors.Is(err, jobs.ErrJobNotFound):
+			return jobsop.NewCancelJobNotFound().WithPayload(responseError("job not found"))
+		case errors.Is(err, jobs.ErrJobFinished):
+			return jobsop.NewCancelJobConflict().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return jobsop.NewCancelJobDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return jobsop.NewCancelJobAccepted()
+	})
+}
@@ -1,17 +1,26 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"net"
 	"net/http"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/treeverse/lakefs/auth"
+	"github.com/treeverse/lakefs/auth/model"
+	"github.com/treeverse/lakefs/httputil"
+	"github.com/treeverse/lakefs/logging"
 )
 
 type LoginRequestData struct {
 	AccessKeyID     string `json:"access_key_id"`
 	SecretAccessKey string `json:"secret_access_key"`
+	// Username and Password log in users of the external auth provider
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
 }
 
 type LoginResponseData struct {
@@ -33,7 +42,9 @@ func GenerateJWT(secret []byte, accessKeyID string, issuedAt, expiresAt time.Tim
 	return token.SignedString(secret)
 }
 
-func NewLoginHandler(authService auth.Service) http.Handler {
+// NewLoginHandler logs in users by their access key, or by username and password when
+// externalAuth is set
+func NewLoginHandler(authService auth.Service, externalAuth *auth.ExternalAuth) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			w.WriteHeader(http.StatusMethodNotAllowed)
@@ -47,12 +58,19 @@ func NewLoginHandler(authService auth.Service) http.Handler {
 			return
 		}
 
-		// load credentials by access key
-		credentials, err := authService.GetCredentials(login.AccessKeyID)
-		if err != nil || credentials.AccessSecretKey != login.SecretAccessKey {
+		credentials, err := loginCredentials(r.Context(), authService, externalAuth, login, httputil.SourceIPFromRequest(r))
+		if errors.Is(err, auth.ErrAddressNotPermitted) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if err != nil {
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
+		if !credentials.NetworkPolicy.Permits(httputil.SourceIPFromRequest(r)) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
 
 		// verify user associated with credentials exists
 		_, err = authService.GetUserByID(credentials.UserID)
@@ -64,7 +82,7 @@ func NewLoginHandler(authService auth.Service) http.Handler {
 		loginTime := time.Now()
 		expires := loginTime.Add(DefaultLoginExpiration)
 		secret := authService.SecretStore().SharedSecret()
-		tokenString, err := GenerateJWT(secret, login.AccessKeyID, loginTime, expires)
+		tokenString, err := GenerateJWT(secret, credentials.AccessKeyID, loginTime, expires)
 		if err != nil {
 			w.WriteHeader(http.StatusUnauthorized)
 			return
@@ -85,6 +103,30 @@ func NewLoginHandler(authService auth.Service) http.Handler {
 	})
 }
 
+// loginCredentials returns the credentials of the user logging in from sourceIP, verifying its
+// secret access key or its password with the external auth provider
+func loginCredentials(ctx context.Context, authService auth.Service, externalAuth *auth.ExternalAuth, login *LoginRequestData, sourceIP net.IP) (*model.Credential, error) {
+	if login.Username == "" {
+		credentials, err := authService.GetCredentials(login.AccessKeyID)
+		if err != nil {
+			return nil, err
+		}
+		if !credentials.SecretMatches(login.SecretAccessKey, time.Now()) {
+			return nil, auth.ErrInvalidCredentials
+		}
+		return credentials, nil
+	}
+	if externalAuth == nil {
+		return nil, auth.ErrInvalidCredentials
+	}
+	credentials, err := externalAuth.Login(ctx, authService, login.Username, login.Password, sourceIP)
+	if err != nil {
+		logging.FromContext(ctx).WithError(err).WithField("username", login.Username).Warn("External login failed")
+		return nil, err
+	}
+	return credentials, nil
+}
+
 func NewLogoutHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/swag"
+	"github.com/treeverse/lakefs/api/gen/models"
+	"github.com/treeverse/lakefs/api/gen/restapi/operations/branches"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/permissions"
+)
+
+func mergeRequestModel(request *catalog.MergeRequest) *models.MergeRequest {
+	return &models.MergeRequest{
+		ID:                 swag.Int64(request.ID),
+		DestinationBranch:  swag.String(request.DestinationBranch),
+		SourceRef:          swag.String(request.SourceRef),
+		Committer:          swag.String(request.Committer),
+		Message:            request.Message,
+		Metadata:           request.Metadata,
+		OverrideGuardrails: request.OverrideGuardrails,
+		State:              swag.String(request.State),
+		Attempts:           swag.Int64(int64(request.Attempts)),
+		MaxAttempts:        swag.Int64(int64(request.MaxAttempts)),
+		Error:              request.Error,
+		Reference:          request.Reference,
+		CreationDate:       swag.Int64(request.CreationDate.Unix()),
+		UpdateDate:         swag.Int64(request.UpdateDate.Unix()),
+	}
+}
+
+func (c *Controller) ListMergeQueueHandler() branches.ListMergeQueueHandler {
+	return branches.ListMergeQueueHandlerFunc(func(params branches.ListMergeQueueParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadBranchAction,
+				Resource: permissions.BranchArn(params.Repository, params.Branch),
+			},
+		})
+		if err != nil {
+			return branches.NewListMergeQueueUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("list_merge_queue")
+		queue, err := deps.Cataloger.ListMergeQueue(deps.ctx, params.Repository, params.Branch)
+		if err != nil {
+			return branches.NewListMergeQueueDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		results := make([]*models.MergeRequest, len(queue))
+		for i, request := range queue {
+			results[i] = mergeRequestModel(request)
+		}
+		return branches.NewListMergeQueueOK().WithPayload(&models.MergeRequestList{Results: results})
+	})
+}
+
+func (c *Controller) EnqueueMergeHandler() branches.EnqueueMergeHandler {
+	return branches.EnqueueMergeHandlerFunc(func(params branches.EnqueueMergeParams, user *models.User) middleware.Responder {
+		perms := []permissions.Permission{
+			{
+				Action:   permissions.CreateCommitAction,
+				Resource: permissions.BranchArn(params.Repository, params.Branch),
+			},
+		}
+		override := params.MergeRequest.OverrideGuardrails
+		if override {
+			perms = append(perms, permissions.Permission{
+				Action:   permissions.OverrideGuardrailsAction,
+				Resource: permissions.BranchArn(params.Repository, params.Branch),
+			})
+		}
+		deps, err := c.setupRequest(user, params.HTTPRequest, perms,
+			sandboxPermissions(user, params.Repository, params.Branch)...)
+		if err != nil {
+			return branches.NewEnqueueMergeUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("enqueue_merge")
+		userModel, err := deps.Auth.GetUser(user.ID)
+		if err != nil {
+			return branches.NewEnqueueMergeUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		request, err := deps.Cataloger.EnqueueMerge(deps.ctx, params.Repository, params.Branch,
+			swag.StringValue(params.MergeRequest.SourceRef), catalog.MergeQueueParams{
+				MergeParams: catalog.MergeParams{
+					Committer:          userModel.Username,
+					Message:            params.MergeRequest.Message,
+					Metadata:           params.MergeRequest.Metadata,
+					OverrideGuardrails: override,
+				},
+				MaxAttempts: int(params.MergeRequest.MaxAttempts),
+			})
+		switch {
+		case errors.Is(err, catalog.ErrInvalidValue):
+			return branches.NewEnqueueMergeBadRequest().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, db.ErrNotFound):
+			return branches.NewEnqueueMergeNotFound().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return branches.NewEnqueueMergeDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return branches.NewEnqueueMergeCreated().WithPayload(mergeRequestModel(request))
+	})
+}
+
+func (c *Controller) GetMergeRequestHandler() branches.GetMergeRequestHandler {
+	return branches.GetMergeRequestHandlerFunc(func(params branches.GetMergeRequestParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadRepositoryAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return branches.NewGetMergeRequestUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("get_merge_request")
+		request, err := deps.Cataloger.GetMergeRequest(deps.ctx, params.Repository, params.MergeRequestID)
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return branches.NewGetMergeRequestNotFound().WithPayload(responseError("merge request not found"))
+		case err != nil:
+			return branches.NewGetMergeRequestDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return branches.NewGetMergeRequestOK().WithPayload(mergeRequestModel(request))
+	})
+}
+
+func (c *Controller) CancelMergeRequestHandler() branches.CancelMergeRequestHandler {
+	return branches.CancelMergeRequestHandlerFunc(func(params branches.CancelMergeRequestParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadRepositoryAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return branches.NewCancelMergeRequestUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("cancel_merge_request")
+		request, err := deps.Cataloger.GetMergeRequest(deps.ctx, params.Repository, params.MergeRequestID)
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return branches.NewCancelMergeRequestNotFound().WithPayload(responseError("merge request not found"))
+		case err != nil:
+			return branches.NewCancelMergeRequestDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		// canceling a merge is writing to its destination branch
+		err = authorize(deps.Auth, user, []permissions.Permission{
+			{
+				Action:   permissions.CreateCommitAction,
+				Resource: permissions.BranchArn(params.Repository, request.DestinationBranch),
+			},
+		}, sandboxPermissions(user, params.Repository, request.DestinationBranch)...)
+		if err != nil {
+			return branches.NewCancelMergeRequestUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		err = deps.Cataloger.CancelMergeRequest(deps.ctx, params.Repository, params.MergeRequestID)
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return branches.NewCancelMergeRequestNotFound().WithPayload(responseError("merge request not found"))
+		case errors.Is(err, catalog.ErrMergeRequestNotQueued):
+			return branches.NewCancelMergeRequestConflict().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return branches.NewCancelMergeRequestDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return branches.NewCancelMergeRequestNoContent()
+	})
+}
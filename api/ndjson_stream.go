@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/treeverse/lakefs/errcode"
+	"github.com/treeverse/lakefs/logging"
+)
+
+const NDJSONMime = "application/x-ndjson"
+
+// acceptsNDJSON returns true if the client asked for the results of a listing as newline
+// delimited JSON
+func acceptsNDJSON(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaRange := range strings.Split(accept, ",") {
+			mediaType := strings.TrimSpace(strings.Split(mediaRange, ";")[0])
+			if strings.EqualFold(mediaType, NDJSONMime) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ndjsonStream writes records to the response as newline delimited JSON, flushing each record
+// so that clients receive it as soon as it is read.
+type ndjsonStream struct {
+	w           http.ResponseWriter
+	encoder     *json.Encoder
+	contentType string
+	started     bool
+}
+
+func newNDJSONStream(w http.ResponseWriter, contentType string) *ndjsonStream {
+	return &ndjsonStream{w: w, encoder: json.NewEncoder(w), contentType: contentType}
+}
+
+func (s *ndjsonStream) start() {
+	if s.started {
+		return
+	}
+	s.started = true
+	s.w.Header().Set("Content-Type", s.contentType)
+	s.w.WriteHeader(http.StatusOK)
+}
+
+func (s *ndjsonStream) Write(record interface{}) error {
+	s.start()
+	if err := s.encoder.Encode(record); err != nil {
+		return err
+	}
+	if f, ok := s.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// Fail reports err to the client.  Once records were sent the status can no longer change, the
+// response is aborted instead so that the client does not mistake it for a complete listing.
+func (s *ndjsonStream) Fail(err error, statusCode int) {
+	if s.started {
+		panic(http.ErrAbortHandler)
+	}
+	s.started = true
+	s.w.Header().Set("Content-Type", runtime.JSONMime)
+	s.w.WriteHeader(statusCode)
+	_ = json.NewEncoder(s.w).Encode(responseErrorFrom(err))
+}
+
+// ndjsonPageFunc returns the records following after and the offset to continue from, or an
+// empty offset after the last page.
+type ndjsonPageFunc func(after string) (records []interface{}, next string, err error)
+
+// streamNDJSON returns a responder streaming all records of a paginated listing, starting
+// after after, as newline delimited JSON.  Pages are read as the client consumes the response.
+func streamNDJSON(ctx context.Context, after string, fetch ndjsonPageFunc) middleware.Responder {
+	return middleware.ResponderFunc(func(w http.ResponseWriter, _ runtime.Producer) {
+		stream := newNDJSONStream(w, NDJSONMime)
+		for {
+			records, next, err := fetch(after)
+			if err != nil {
+				_, statusCode := errcode.Of(err)
+				if statusCode == http.StatusInternalServerError {
+					logging.FromContext(ctx).WithError(err).Error("Listing stream failed")
+				}
+				stream.Fail(err, statusCode)
+				return
+			}
+			for _, record := range records {
+				if err := stream.Write(record); err != nil {
+					logging.FromContext(ctx).WithError(err).Debug("Listing stream write failed")
+					return
+				}
+			}
+			if next == "" {
+				break
+			}
+			after = next
+		}
+		stream.start()
+	})
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/swag"
+	"github.com/treeverse/lakefs/api/gen/models"
+	notificationsop "github.com/treeverse/lakefs/api/gen/restapi/operations/notifications"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/errcode"
+	"github.com/treeverse/lakefs/notifications"
+	"github.com/treeverse/lakefs/permissions"
+)
+
+func notificationSinkModel(sink *notifications.Sink) *models.NotificationSink {
+	return &models.NotificationSink{
+		Name:         swag.String(sink.Name),
+		Type:         swag.String(sink.Type),
+		URL:          swag.String(sink.RedactedURL()),
+		Events:       sink.Events,
+		Branches:     sink.Branches,
+		CreationDate: swag.Int64(sink.CreationDate.Unix()),
+	}
+}
+
+func (c *Controller) ListNotificationSinksHandler() notificationsop.ListNotificationSinksHandler {
+	return notificationsop.ListNotificationSinksHandlerFunc(func(params notificationsop.ListNotificationSinksParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.GetNotificationSinkAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return notificationsop.NewListNotificationSinksUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("list_notification_sinks")
+		sinks, err := deps.Notifications.ListSinks(deps.ctx, params.Repository)
+		if err != nil {
+			return notificationsop.NewListNotificationSinksDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		results := make([]*models.NotificationSink, len(sinks))
+		for i, sink := range sinks {
+			results[i] = notificationSinkModel(sink)
+		}
+		return notificationsop.NewListNotificationSinksOK().WithPayload(&models.NotificationSinkList{Results: results})
+	})
+}
+
+func (c *Controller) GetNotificationSinkHandler() notificationsop.GetNotificationSinkHandler {
+	return notificationsop.GetNotificationSinkHandlerFunc(func(params notificationsop.GetNotificationSinkParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.GetNotificationSinkAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return notificationsop.NewGetNotificationSinkUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("get_notification_sink")
+		sink, err := deps.Notifications.GetSink(deps.ctx, params.Repository, params.Sink)
+		switch {
+		case errors.Is(err, notifications.ErrSinkNotFound):
+			return notificationsop.NewGetNotificationSinkNotFound().WithPayload(responseError("notification sink '%s' not found.", params.Sink))
+		case err != nil:
+			return notificationsop.NewGetNotificationSinkDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return notificationsop.NewGetNotificationSinkOK().WithPayload(notificationSinkModel(sink))
+	})
+}
+
+func (c *Controller) SetNotificationSinkHandler() notificationsop.SetNotificationSinkHandler {
+	return notificationsop.SetNotificationSinkHandlerFunc(func(params notificationsop.SetNotificationSinkParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.SetNotificationSinkAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return notificationsop.NewSetNotificationSinkUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("set_notification_sink")
+		_, err = deps.Cataloger.GetRepository(deps.ctx, params.Repository)
+		if errors.Is(err, db.ErrNotFound) {
+			return notificationsop.NewSetNotificationSinkNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository '%s' not found.", params.Repository))
+		}
+		if err != nil {
+			return notificationsop.NewSetNotificationSinkDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		sink := &notifications.Sink{
+			RepositoryID: params.Repository,
+			Name:         params.Sink,
+			Type:         swag.StringValue(params.NotificationSink.Type),
+			URL:          swag.StringValue(params.NotificationSink.URL),
+			Events:       params.NotificationSink.Events,
+			Branches:     params.NotificationSink.Branches,
+		}
+		err = deps.Notifications.SetSink(deps.ctx, sink)
+		if errors.Is(err, notifications.ErrInvalidSink) {
+			return notificationsop.NewSetNotificationSinkBadRequest().WithPayload(responseErrorFrom(err))
+		}
+		if err == nil {
+			sink, err = deps.Notifications.GetSink(deps.ctx, params.Repository, params.Sink)
+		}
+		if err != nil {
+			return notificationsop.NewSetNotificationSinkDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return notificationsop.NewSetNotificationSinkCreated().WithPayload(notificationSinkModel(sink))
+	})
+}
+
+func (c *Controller) DeleteNotificationSinkHandler() notificationsop.DeleteNotificationSinkHandler {
+	return notificationsop.DeleteNotificationSinkHandlerFunc(func(params notificationsop.DeleteNotificationSinkParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.SetNotificationSinkAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return notificationsop.NewDeleteNotificationSinkUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("delete_notification_sink")
+		err = deps.Notifications.DeleteSink(deps.ctx, params.Repository, params.Sink)
+		switch {
+		case errors.Is(err, notifications.ErrSinkNotFound):
+			return notificationsop.NewDeleteNotificationSinkNotFound().WithPayload(responseError("notification sink '%s' not found.", params.Sink))
+		case err != nil:
+			return notificationsop.NewDeleteNotificationSinkDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return notificationsop.NewDeleteNotificationSinkNoContent()
+	})
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/swag"
+	"github.com/treeverse/lakefs/api/gen/models"
+	"github.com/treeverse/lakefs/api/gen/restapi/operations/objects"
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/encryption"
+	"github.com/treeverse/lakefs/errcode"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/permissions"
+)
+
+// Reasons an object location cannot be read directly from the object store
+const (
+	LocationReasonLink      = "link"
+	LocationReasonEncrypted = "encrypted"
+	LocationReasonExpired   = "expired"
+	LocationReasonRedacted  = "redacted"
+)
+
+// objectLocations resolves the physical addresses of listed entries, presigning them if
+// presigner is set
+type objectLocations struct {
+	repo      *catalog.Repository
+	redacted  map[string]struct{}
+	presigner block.Presigner
+	expiry    time.Duration
+}
+
+// location returns the location of entry, or nil for entries that are not objects
+func (l *objectLocations) location(entry *catalog.DBEntry) (*models.ObjectLocation, error) {
+	if entry.CommonLevel || entry.DirectoryMarker {
+		return nil, nil
+	}
+	location := &models.ObjectLocation{
+		Path:      swag.String(entry.Path),
+		SizeBytes: swag.Int64(entry.Size),
+		Checksum:  swag.String(entry.Checksum),
+		Mtime:     swag.Int64(entry.CreationDate.Unix()),
+	}
+	_, redacted := l.redacted[entry.PhysicalAddress]
+	switch {
+	case entry.LinkTarget != "":
+		location.Reason = LocationReasonLink
+	case encryption.IsEncrypted(entry.Metadata):
+		// the object store holds the ciphertext, only lakeFS can decrypt it
+		location.Reason = LocationReasonEncrypted
+	case entry.Expired:
+		location.Reason = LocationReasonExpired
+	case redacted:
+		location.Reason = LocationReasonRedacted
+	}
+	location.DirectRead = swag.Bool(location.Reason == "")
+	if location.Reason != "" {
+		return location, nil
+	}
+	qk, err := block.ResolveNamespace(l.repo.StorageNamespace, entry.PhysicalAddress)
+	if err != nil {
+		return nil, err
+	}
+	location.PhysicalAddress = qk.Format()
+	if l.presigner != nil {
+		pointer := block.ObjectPointer{StorageNamespace: l.repo.StorageNamespace, Identifier: entry.PhysicalAddress}
+		location.URL, err = l.presigner.GetPresignedURL(pointer, l.expiry)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return location, nil
+}
+
+func (l *objectLocations) locations(entries []*catalog.DBEntry) ([]*models.ObjectLocation, error) {
+	results := make([]*models.ObjectLocation, 0, len(entries))
+	for _, entry := range entries {
+		location, err := l.location(entry)
+		if err != nil {
+			return nil, err
+		}
+		if location != nil {
+			results = append(results, location)
+		}
+	}
+	return results, nil
+}
+
+func (c *Controller) ObjectsListObjectLocationsHandler() objects.ListObjectLocationsHandler {
+	return objects.ListObjectLocationsHandlerFunc(func(params objects.ListObjectLocationsParams, user *models.User) middleware.Responder {
+		prefix := swag.StringValue(params.Prefix)
+		// physical addresses grant reading the data of every object listed
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ListObjectsAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+			{
+				Action:   permissions.ReadObjectAction,
+				Resource: permissions.ObjectArn(params.Repository, prefix),
+			},
+		})
+		if err != nil {
+			return objects.NewListObjectLocationsUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("list_object_locations")
+		cataloger := deps.Cataloger
+
+		locations := &objectLocations{expiry: DefaultDownloadExpiry}
+		if swag.BoolValue(params.Presign) {
+			presigner, ok := deps.BlockAdapter.(block.Presigner)
+			if !ok {
+				return objects.NewListObjectLocationsNotImplemented().
+					WithPayload(responseErrorCode(errcode.NotSupported, "block adapter does not support presigned URLs"))
+			}
+			locations.presigner = presigner
+		}
+		if params.ExpirySeconds != nil {
+			locations.expiry = time.Duration(*params.ExpirySeconds) * time.Second
+		}
+		locations.repo, err = cataloger.GetRepository(deps.ctx, params.Repository)
+		if errors.Is(err, db.ErrNotFound) {
+			return objects.NewListObjectLocationsNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository not found"))
+		}
+		if err != nil {
+			return objects.NewListObjectLocationsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		redactions, err := cataloger.ListRedactions(deps.ctx, params.Repository)
+		if err != nil {
+			return objects.NewListObjectLocationsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		locations.redacted = make(map[string]struct{}, len(redactions))
+		for _, redaction := range redactions {
+			locations.redacted[redaction.PhysicalAddress] = struct{}{}
+		}
+
+		if acceptsNDJSON(params.HTTPRequest) {
+			return streamNDJSON(deps.ctx, swag.StringValue(params.After), func(after string) ([]interface{}, string, error) {
+				entries, nextToken, err := cataloger.ListEntriesWithToken(deps.ctx, params.Repository, params.Ref,
+					prefix, after, "", MaxResultsPerPage)
+				if err != nil {
+					return nil, "", err
+				}
+				results, err := locations.locations(entries)
+				if err != nil {
+					return nil, "", err
+				}
+				records := make([]interface{}, len(results))
+				for i, location := range results {
+					records[i] = location
+				}
+				return records, nextToken, nil
+			})
+		}
+
+		after, amount := getPaginationParams(params.After, params.Amount)
+		entries, nextToken, err := cataloger.ListEntriesWithToken(deps.ctx, params.Repository, params.Ref,
+			prefix, after, "", amount)
+		if errors.Is(err, db.ErrNotFound) {
+			return objects.NewListObjectLocationsNotFound().WithPayload(responseError("could not find requested path"))
+		}
+		if errors.Is(err, graveler.ErrSnapshotExpired) || errors.Is(err, catalog.ErrInvalidContinuationToken) {
+			return objects.NewListObjectLocationsDefault(http.StatusConflict).WithPayload(responseErrorFrom(err))
+		}
+		if err != nil {
+			return objects.NewListObjectLocationsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		results, err := locations.locations(entries)
+		if err != nil {
+			return objects.NewListObjectLocationsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return objects.NewListObjectLocationsOK().WithPayload(&models.ObjectLocationList{
+			Pagination: createPaginator(nextToken, len(results)),
+			Results:    results,
+		})
+	})
+}
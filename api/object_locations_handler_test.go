@@ -0,0 +1,73 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-openapi/swag"
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/encryption"
+)
+
+type fakePresigner struct{}
+
+func (fakePresigner) GetPresignedRangeURL(obj block.ObjectPointer, startPosition, endPosition int64, expiry time.Duration) (string, error) {
+	return "", nil
+}
+
+func (fakePresigner) GetPresignedURL(obj block.ObjectPointer, expiry time.Duration) (string, error) {
+	return "https://presigned/" + obj.Identifier + "?expiry=" + expiry.String(), nil
+}
+
+func TestObjectLocations(t *testing.T) {
+	locations := &objectLocations{
+		repo:      &catalog.Repository{Name: "repo", StorageNamespace: "s3://bucket/repo"},
+		redacted:  map[string]struct{}{"redacted-address": {}},
+		presigner: fakePresigner{},
+		expiry:    time.Minute,
+	}
+	results, err := locations.locations([]*catalog.DBEntry{
+		{Path: "data/", CommonLevel: true},
+		{Path: "data/dir/", DirectoryMarker: true},
+		{Path: "data/object", PhysicalAddress: "address", Size: 10, Checksum: "abc"},
+		{Path: "data/link", LinkTarget: "data/object"},
+		{Path: "data/encrypted", PhysicalAddress: "encrypted-address", Metadata: catalog.Metadata{encryption.MetadataKeyID: "key"}},
+		{Path: "data/expired", PhysicalAddress: "expired-address", Expired: true},
+		{Path: "data/redacted", PhysicalAddress: "redacted-address"},
+	})
+	if err != nil {
+		t.Fatalf("locations: %s", err)
+	}
+	expectedReasons := map[string]string{
+		"data/object":    "",
+		"data/link":      LocationReasonLink,
+		"data/encrypted": LocationReasonEncrypted,
+		"data/expired":   LocationReasonExpired,
+		"data/redacted":  LocationReasonRedacted,
+	}
+	if len(results) != len(expectedReasons) {
+		t.Fatalf("got %d locations, expected %d", len(results), len(expectedReasons))
+	}
+	for _, location := range results {
+		path := swag.StringValue(location.Path)
+		reason, ok := expectedReasons[path]
+		if !ok {
+			t.Errorf("unexpected location of %s", path)
+			continue
+		}
+		if location.Reason != reason || swag.BoolValue(location.DirectRead) != (reason == "") {
+			t.Errorf("%s: got direct read %t reason %q, expected reason %q", path, swag.BoolValue(location.DirectRead), location.Reason, reason)
+		}
+		if reason != "" && (location.PhysicalAddress != "" || location.URL != "") {
+			t.Errorf("%s: got address %q and URL %q for object read through lakeFS", path, location.PhysicalAddress, location.URL)
+		}
+	}
+	object := results[0]
+	if object.PhysicalAddress != "s3://bucket/repo/address" {
+		t.Errorf("got physical address %s", object.PhysicalAddress)
+	}
+	if object.URL != "https://presigned/address?expiry=1m0s" {
+		t.Errorf("got URL %s", object.URL)
+	}
+}
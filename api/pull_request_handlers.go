@@ -0,0 +1,351 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/swag"
+	"github.com/treeverse/lakefs/api/gen/models"
+	"github.com/treeverse/lakefs/api/gen/restapi/operations/pulls"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/permissions"
+)
+
+func pullRequestModel(pr *catalog.PullRequest) *models.PullRequest {
+	approvals := make([]*models.PullRequestApproval, len(pr.Approvals))
+	for i, approval := range pr.Approvals {
+		approvals[i] = &models.PullRequestApproval{
+			Reviewer:     swag.String(approval.Reviewer),
+			CommitID:     swag.String(approval.CommitID),
+			CreationDate: swag.Int64(approval.CreationDate.Unix()),
+		}
+	}
+	return &models.PullRequest{
+		ID:                swag.Int64(pr.ID),
+		SourceRef:         swag.String(pr.SourceRef),
+		DestinationBranch: swag.String(pr.DestinationBranch),
+		Title:             swag.String(pr.Title),
+		Description:       pr.Description,
+		Author:            swag.String(pr.Author),
+		Reviewers:         pr.Reviewers,
+		Status:            swag.String(pr.Status),
+		MergeReference:    pr.MergeReference,
+		Approvals:         approvals,
+		CreationDate:      swag.Int64(pr.CreationDate.Unix()),
+		UpdateDate:        swag.Int64(pr.UpdateDate.Unix()),
+	}
+}
+
+func pullRequestCommentModel(comment *catalog.PullRequestComment) *models.PullRequestComment {
+	return &models.PullRequestComment{
+		ID:           swag.Int64(comment.ID),
+		Author:       swag.String(comment.Author),
+		Body:         swag.String(comment.Body),
+		Path:         comment.Path,
+		CreationDate: swag.Int64(comment.CreationDate.Unix()),
+	}
+}
+
+// authorizePullRequest gets pull request id and authorizes user to perform action on its
+// destination branch
+func authorizePullRequest(deps *Dependencies, user *models.User, repository string, id int64, action string) (*catalog.PullRequest, error) {
+	pr, err := deps.Cataloger.GetPullRequest(deps.ctx, repository, id)
+	if err != nil {
+		return nil, err
+	}
+	err = authorize(deps.Auth, user, []permissions.Permission{
+		{
+			Action:   action,
+			Resource: permissions.BranchArn(repository, pr.DestinationBranch),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
+
+func (c *Controller) ListPullRequestsHandler() pulls.ListPullRequestsHandler {
+	return pulls.ListPullRequestsHandlerFunc(func(params pulls.ListPullRequestsParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadRepositoryAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return pulls.NewListPullRequestsUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("list_pull_requests")
+		prs, err := deps.Cataloger.ListPullRequests(deps.ctx, params.Repository, swag.StringValue(params.Status))
+		if err != nil {
+			return pulls.NewListPullRequestsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		results := make([]*models.PullRequest, len(prs))
+		for i, pr := range prs {
+			results[i] = pullRequestModel(pr)
+		}
+		return pulls.NewListPullRequestsOK().WithPayload(&models.PullRequestList{Results: results})
+	})
+}
+
+func (c *Controller) OpenPullRequestHandler() pulls.OpenPullRequestHandler {
+	return pulls.OpenPullRequestHandlerFunc(func(params pulls.OpenPullRequestParams, user *models.User) middleware.Responder {
+		destinationBranch := swag.StringValue(params.PullRequest.DestinationBranch)
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.CreatePullRequestAction,
+				Resource: permissions.BranchArn(params.Repository, destinationBranch),
+			},
+		})
+		if err != nil {
+			return pulls.NewOpenPullRequestUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("open_pull_request")
+		userModel, err := deps.Auth.GetUser(user.ID)
+		if err != nil {
+			return pulls.NewOpenPullRequestUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		pr, err := deps.Cataloger.OpenPullRequest(deps.ctx, params.Repository, catalog.PullRequest{
+			SourceRef:         swag.StringValue(params.PullRequest.SourceRef),
+			DestinationBranch: destinationBranch,
+			Title:             swag.StringValue(params.PullRequest.Title),
+			Description:       params.PullRequest.Description,
+			Author:            userModel.Username,
+			Reviewers:         params.PullRequest.Reviewers,
+		})
+		switch {
+		case errors.Is(err, catalog.ErrInvalidValue):
+			return pulls.NewOpenPullRequestBadRequest().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, db.ErrNotFound):
+			return pulls.NewOpenPullRequestNotFound().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return pulls.NewOpenPullRequestDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return pulls.NewOpenPullRequestCreated().WithPayload(pullRequestModel(pr))
+	})
+}
+
+func (c *Controller) GetPullRequestHandler() pulls.GetPullRequestHandler {
+	return pulls.GetPullRequestHandlerFunc(func(params pulls.GetPullRequestParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadRepositoryAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return pulls.NewGetPullRequestUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("get_pull_request")
+		pr, err := deps.Cataloger.GetPullRequest(deps.ctx, params.Repository, params.PullRequestID)
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return pulls.NewGetPullRequestNotFound().WithPayload(responseError("pull request not found"))
+		case err != nil:
+			return pulls.NewGetPullRequestDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return pulls.NewGetPullRequestOK().WithPayload(pullRequestModel(pr))
+	})
+}
+
+func (c *Controller) ClosePullRequestHandler() pulls.ClosePullRequestHandler {
+	return pulls.ClosePullRequestHandlerFunc(func(params pulls.ClosePullRequestParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadRepositoryAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return pulls.NewClosePullRequestUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("close_pull_request")
+		_, err = authorizePullRequest(deps, user, params.Repository, params.PullRequestID, permissions.CreatePullRequestAction)
+		if err == nil {
+			err = deps.Cataloger.ClosePullRequest(deps.ctx, params.Repository, params.PullRequestID)
+		}
+		switch {
+		case errors.Is(err, ErrAuthorization):
+			return pulls.NewClosePullRequestUnauthorized().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, db.ErrNotFound):
+			return pulls.NewClosePullRequestNotFound().WithPayload(responseError("pull request not found"))
+		case errors.Is(err, catalog.ErrPullRequestNotOpen):
+			return pulls.NewClosePullRequestBadRequest().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return pulls.NewClosePullRequestDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return pulls.NewClosePullRequestNoContent()
+	})
+}
+
+func (c *Controller) ListPullRequestCommentsHandler() pulls.ListPullRequestCommentsHandler {
+	return pulls.ListPullRequestCommentsHandlerFunc(func(params pulls.ListPullRequestCommentsParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadRepositoryAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return pulls.NewListPullRequestCommentsUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("list_pull_request_comments")
+		comments, err := deps.Cataloger.ListPullRequestComments(deps.ctx, params.Repository, params.PullRequestID, swag.StringValue(params.Path))
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			return pulls.NewListPullRequestCommentsNotFound().WithPayload(responseError("pull request not found"))
+		case err != nil:
+			return pulls.NewListPullRequestCommentsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		results := make([]*models.PullRequestComment, len(comments))
+		for i, comment := range comments {
+			results[i] = pullRequestCommentModel(comment)
+		}
+		return pulls.NewListPullRequestCommentsOK().WithPayload(&models.PullRequestCommentList{Results: results})
+	})
+}
+
+func (c *Controller) CommentPullRequestHandler() pulls.CommentPullRequestHandler {
+	return pulls.CommentPullRequestHandlerFunc(func(params pulls.CommentPullRequestParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadRepositoryAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return pulls.NewCommentPullRequestUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("comment_pull_request")
+		userModel, err := deps.Auth.GetUser(user.ID)
+		if err != nil {
+			return pulls.NewCommentPullRequestUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		var comment *catalog.PullRequestComment
+		_, err = authorizePullRequest(deps, user, params.Repository, params.PullRequestID, permissions.CreatePullRequestAction)
+		if err == nil {
+			comment, err = deps.Cataloger.CommentPullRequest(deps.ctx, params.Repository, params.PullRequestID, catalog.PullRequestComment{
+				Author: userModel.Username,
+				Body:   swag.StringValue(params.Comment.Body),
+				Path:   params.Comment.Path,
+			})
+		}
+		switch {
+		case errors.Is(err, ErrAuthorization):
+			return pulls.NewCommentPullRequestUnauthorized().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, db.ErrNotFound):
+			return pulls.NewCommentPullRequestNotFound().WithPayload(responseError("pull request not found"))
+		case errors.Is(err, catalog.ErrInvalidValue) || errors.Is(err, catalog.ErrPullRequestNotOpen):
+			return pulls.NewCommentPullRequestBadRequest().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return pulls.NewCommentPullRequestDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return pulls.NewCommentPullRequestCreated().WithPayload(pullRequestCommentModel(comment))
+	})
+}
+
+func (c *Controller) ApprovePullRequestHandler() pulls.ApprovePullRequestHandler {
+	return pulls.ApprovePullRequestHandlerFunc(func(params pulls.ApprovePullRequestParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadRepositoryAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return pulls.NewApprovePullRequestUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("approve_pull_request")
+		userModel, err := deps.Auth.GetUser(user.ID)
+		if err != nil {
+			return pulls.NewApprovePullRequestUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		_, err = authorizePullRequest(deps, user, params.Repository, params.PullRequestID, permissions.ApprovePullRequestAction)
+		if err == nil {
+			err = deps.Cataloger.ApprovePullRequest(deps.ctx, params.Repository, params.PullRequestID, userModel.Username)
+		}
+		switch {
+		case errors.Is(err, ErrAuthorization):
+			return pulls.NewApprovePullRequestUnauthorized().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, db.ErrNotFound):
+			return pulls.NewApprovePullRequestNotFound().WithPayload(responseError("pull request not found"))
+		case errors.Is(err, catalog.ErrPullRequestNotOpen) || errors.Is(err, catalog.ErrInvalidValue):
+			return pulls.NewApprovePullRequestBadRequest().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return pulls.NewApprovePullRequestDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return pulls.NewApprovePullRequestNoContent()
+	})
+}
+
+func (c *Controller) MergePullRequestHandler() pulls.MergePullRequestHandler {
+	return pulls.MergePullRequestHandlerFunc(func(params pulls.MergePullRequestParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadRepositoryAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return pulls.NewMergePullRequestUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("merge_pull_request")
+		userModel, err := deps.Auth.GetUser(user.ID)
+		if err != nil {
+			return pulls.NewMergePullRequestUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		override := params.Merge != nil && params.Merge.OverrideGuardrails
+		pr, err := deps.Cataloger.GetPullRequest(deps.ctx, params.Repository, params.PullRequestID)
+		if err == nil {
+			// merging a pull request is merging its source into its destination branch
+			perms := []permissions.Permission{
+				{
+					Action:   permissions.CreateCommitAction,
+					Resource: permissions.BranchArn(params.Repository, pr.DestinationBranch),
+				},
+			}
+			if override {
+				perms = append(perms, permissions.Permission{
+					Action:   permissions.OverrideGuardrailsAction,
+					Resource: permissions.BranchArn(params.Repository, pr.DestinationBranch),
+				})
+			}
+			err = authorize(deps.Auth, user, perms, sandboxPermissions(user, params.Repository, pr.DestinationBranch)...)
+		}
+		var res *catalog.MergeResult
+		if err == nil {
+			mergeParams := catalog.PullRequestMergeParams{
+				MergeParams: catalog.MergeParams{
+					Committer:          userModel.Username,
+					OverrideGuardrails: override,
+				},
+				UserGroups: userGroupsFunc(deps),
+			}
+			if params.Merge != nil {
+				mergeParams.Message = params.Merge.Message
+				mergeParams.Metadata = params.Merge.Metadata
+			}
+			res, err = deps.Cataloger.MergePullRequest(deps.ctx, params.Repository, params.PullRequestID, mergeParams)
+		}
+		switch {
+		case err == nil:
+			return pulls.NewMergePullRequestOK().WithPayload(newMergeResultFromCatalog(res))
+		case errors.Is(err, ErrAuthorization):
+			return pulls.NewMergePullRequestUnauthorized().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, db.ErrNotFound):
+			return pulls.NewMergePullRequestNotFound().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, catalog.ErrPullRequestNotOpen):
+			return pulls.NewMergePullRequestBadRequest().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, catalog.ErrConflictFound) || errors.Is(err, graveler.ErrConflictFound):
+			return pulls.NewMergePullRequestConflict().WithPayload(newMergeResultFromCatalog(res))
+		case errors.Is(err, catalog.ErrMergeGuardrailsExceeded) || errors.Is(err, catalog.ErrInvalidOwners):
+			return pulls.NewMergePullRequestPreconditionFailed().WithPayload(responseErrorFrom(err))
+		default:
+			return pulls.NewMergePullRequestDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+	})
+}
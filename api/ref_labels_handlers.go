@@ -0,0 +1,118 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/swag"
+	"github.com/treeverse/lakefs/api/gen/models"
+	"github.com/treeverse/lakefs/api/gen/restapi/operations/labels"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/permissions"
+)
+
+func refLabelsModel(refLabels *catalog.RefLabels) *models.RefLabels {
+	return &models.RefLabels{
+		RefType: swag.String(refLabels.RefType),
+		RefID:   swag.String(refLabels.RefID),
+		Labels:  refLabels.Labels,
+	}
+}
+
+// parseLabels parses labels given as key=value
+func parseLabels(params []string) (map[string]string, error) {
+	res := make(map[string]string, len(params))
+	for _, param := range params {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("label '%s' not key=value: %w", param, catalog.ErrInvalidValue)
+		}
+		res[kv[0]] = kv[1]
+	}
+	return res, nil
+}
+
+func (c *Controller) FindRefsByLabelsHandler() labels.FindRefsByLabelsHandler {
+	return labels.FindRefsByLabelsHandlerFunc(func(params labels.FindRefsByLabelsParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadRepositoryAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return labels.NewFindRefsByLabelsUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("find_refs_by_labels")
+		query, err := parseLabels(params.Label)
+		if err != nil {
+			return labels.NewFindRefsByLabelsBadRequest().WithPayload(responseErrorFrom(err))
+		}
+		refs, err := deps.Cataloger.FindRefsByLabels(deps.ctx, params.Repository, query, swag.StringValue(params.Type))
+		if errors.Is(err, catalog.ErrInvalidValue) {
+			return labels.NewFindRefsByLabelsBadRequest().WithPayload(responseErrorFrom(err))
+		}
+		if err != nil {
+			return labels.NewFindRefsByLabelsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		payload := &models.RefLabelsList{Results: make([]*models.RefLabels, len(refs))}
+		for i, ref := range refs {
+			payload.Results[i] = refLabelsModel(ref)
+		}
+		return labels.NewFindRefsByLabelsOK().WithPayload(payload)
+	})
+}
+
+func (c *Controller) GetRefLabelsHandler() labels.GetRefLabelsHandler {
+	return labels.GetRefLabelsHandlerFunc(func(params labels.GetRefLabelsParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ReadRepositoryAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return labels.NewGetRefLabelsUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("get_ref_labels")
+		refLabels, err := deps.Cataloger.GetRefLabels(deps.ctx, params.Repository, params.RefType, params.RefID)
+		switch {
+		case errors.Is(err, catalog.ErrInvalidValue):
+			return labels.NewGetRefLabelsBadRequest().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, db.ErrNotFound):
+			return labels.NewGetRefLabelsNotFound().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return labels.NewGetRefLabelsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return labels.NewGetRefLabelsOK().WithPayload(refLabelsModel(refLabels))
+	})
+}
+
+func (c *Controller) SetRefLabelsHandler() labels.SetRefLabelsHandler {
+	return labels.SetRefLabelsHandlerFunc(func(params labels.SetRefLabelsParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.SetRefLabelsAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return labels.NewSetRefLabelsUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("set_ref_labels")
+		refLabels, err := deps.Cataloger.SetRefLabels(deps.ctx, params.Repository, params.RefType, params.RefID, params.Update.Set, params.Update.Unset)
+		switch {
+		case errors.Is(err, catalog.ErrInvalidValue):
+			return labels.NewSetRefLabelsBadRequest().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, db.ErrNotFound):
+			return labels.NewSetRefLabelsNotFound().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return labels.NewSetRefLabelsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return labels.NewSetRefLabelsOK().WithPayload(refLabelsModel(refLabels))
+	})
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/swag"
+	"github.com/treeverse/lakefs/api/gen/models"
+	"github.com/treeverse/lakefs/api/gen/restapi/operations/schemas"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/errcode"
+	"github.com/treeverse/lakefs/permissions"
+)
+
+func schemaModel(schema *catalog.Schema) *models.Schema {
+	return &models.Schema{
+		Prefix:        swag.String(schema.Prefix),
+		Format:        swag.String(schema.Format),
+		Definition:    swag.String(schema.Definition),
+		Compatibility: swag.String(schema.Compatibility),
+		CreationDate:  swag.Int64(schema.CreationDate.Unix()),
+	}
+}
+
+func (c *Controller) ListSchemasHandler() schemas.ListSchemasHandler {
+	return schemas.ListSchemasHandlerFunc(func(params schemas.ListSchemasParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.GetSchemaAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return schemas.NewListSchemasUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("list_schemas")
+		list, err := deps.Cataloger.ListSchemas(deps.ctx, params.Repository)
+		if err != nil {
+			return schemas.NewListSchemasDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		results := make([]*models.Schema, len(list))
+		for i, schema := range list {
+			results[i] = schemaModel(schema)
+		}
+		return schemas.NewListSchemasOK().WithPayload(&models.SchemaList{Results: results})
+	})
+}
+
+func (c *Controller) GetSchemaHandler() schemas.GetSchemaHandler {
+	return schemas.GetSchemaHandlerFunc(func(params schemas.GetSchemaParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.GetSchemaAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return schemas.NewGetSchemaUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("get_schema")
+		schema, err := deps.Cataloger.GetSchema(deps.ctx, params.Repository, params.Prefix)
+		switch {
+		case errors.Is(err, catalog.ErrSchemaNotFound):
+			return schemas.NewGetSchemaNotFound().WithPayload(responseError("schema of prefix '%s' not found.", params.Prefix))
+		case err != nil:
+			return schemas.NewGetSchemaDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return schemas.NewGetSchemaOK().WithPayload(schemaModel(schema))
+	})
+}
+
+func (c *Controller) SetSchemaHandler() schemas.SetSchemaHandler {
+	return schemas.SetSchemaHandlerFunc(func(params schemas.SetSchemaParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.SetSchemaAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return schemas.NewSetSchemaUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("set_schema")
+		err = deps.Cataloger.SetSchema(deps.ctx, params.Repository, catalog.Schema{
+			Prefix:        params.Prefix,
+			Format:        swag.StringValue(params.Schema.Format),
+			Definition:    swag.StringValue(params.Schema.Definition),
+			Compatibility: swag.StringValue(params.Schema.Compatibility),
+		})
+		switch {
+		case errors.Is(err, catalog.ErrInvalidValue) || errors.Is(err, catalog.ErrInvalidSchema):
+			return schemas.NewSetSchemaBadRequest().WithPayload(responseErrorFrom(err))
+		case errors.Is(err, db.ErrNotFound):
+			return schemas.NewSetSchemaNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository '%s' not found.", params.Repository))
+		case err != nil:
+			return schemas.NewSetSchemaDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		schema, err := deps.Cataloger.GetSchema(deps.ctx, params.Repository, params.Prefix)
+		if err != nil {
+			return schemas.NewSetSchemaDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return schemas.NewSetSchemaCreated().WithPayload(schemaModel(schema))
+	})
+}
+
+func (c *Controller) DeleteSchemaHandler() schemas.DeleteSchemaHandler {
+	return schemas.DeleteSchemaHandlerFunc(func(params schemas.DeleteSchemaParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.SetSchemaAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return schemas.NewDeleteSchemaUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("delete_schema")
+		err = deps.Cataloger.DeleteSchema(deps.ctx, params.Repository, params.Prefix)
+		switch {
+		case errors.Is(err, catalog.ErrSchemaNotFound):
+			return schemas.NewDeleteSchemaNotFound().WithPayload(responseError("schema of prefix '%s' not found.", params.Prefix))
+		case err != nil:
+			return schemas.NewDeleteSchemaDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return schemas.NewDeleteSchemaNoContent()
+	})
+}
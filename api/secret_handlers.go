@@ -0,0 +1,96 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/swag"
+	"github.com/treeverse/lakefs/api/gen/models"
+	secretsop "github.com/treeverse/lakefs/api/gen/restapi/operations/secrets"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/errcode"
+	"github.com/treeverse/lakefs/permissions"
+	"github.com/treeverse/lakefs/secrets"
+)
+
+func (c *Controller) ListSecretsHandler() secretsop.ListSecretsHandler {
+	return secretsop.ListSecretsHandlerFunc(func(params secretsop.ListSecretsParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.ListSecretsAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return secretsop.NewListSecretsUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("list_secrets")
+		list, err := deps.Secrets.ListSecrets(deps.ctx, params.Repository)
+		if err != nil {
+			return secretsop.NewListSecretsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		results := make([]*models.Secret, len(list))
+		for i, secret := range list {
+			results[i] = &models.Secret{
+				Name:         swag.String(secret.Name),
+				CreationDate: swag.Int64(secret.CreationDate.Unix()),
+				UpdateDate:   swag.Int64(secret.UpdateDate.Unix()),
+			}
+		}
+		return secretsop.NewListSecretsOK().WithPayload(&models.SecretList{Results: results})
+	})
+}
+
+func (c *Controller) SetSecretHandler() secretsop.SetSecretHandler {
+	return secretsop.SetSecretHandlerFunc(func(params secretsop.SetSecretParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.SetSecretAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return secretsop.NewSetSecretUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("set_secret")
+		_, err = deps.Cataloger.GetRepository(deps.ctx, params.Repository)
+		if errors.Is(err, db.ErrNotFound) {
+			return secretsop.NewSetSecretNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository '%s' not found.", params.Repository))
+		}
+		if err != nil {
+			return secretsop.NewSetSecretDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		err = deps.Secrets.SetSecret(deps.ctx, params.Repository, params.Name, swag.StringValue(params.Secret.Value))
+		if errors.Is(err, secrets.ErrInvalidSecret) {
+			return secretsop.NewSetSecretBadRequest().WithPayload(responseErrorFrom(err))
+		}
+		if err != nil {
+			return secretsop.NewSetSecretDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return secretsop.NewSetSecretNoContent()
+	})
+}
+
+func (c *Controller) DeleteSecretHandler() secretsop.DeleteSecretHandler {
+	return secretsop.DeleteSecretHandlerFunc(func(params secretsop.DeleteSecretParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.SetSecretAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return secretsop.NewDeleteSecretUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("delete_secret")
+		err = deps.Secrets.DeleteSecret(deps.ctx, params.Repository, params.Name)
+		switch {
+		case errors.Is(err, secrets.ErrSecretNotFound):
+			return secretsop.NewDeleteSecretNotFound().WithPayload(responseError("secret '%s' not found.", params.Name))
+		case err != nil:
+			return secretsop.NewDeleteSecretDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return secretsop.NewDeleteSecretNoContent()
+	})
+}
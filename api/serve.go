@@ -11,6 +11,7 @@ import (
 	"github.com/treeverse/lakefs/api/gen/restapi"
 	"github.com/treeverse/lakefs/api/gen/restapi/operations"
 	"github.com/treeverse/lakefs/httputil"
+	"github.com/treeverse/lakefs/lfs"
 
 	"github.com/treeverse/lakefs/logging"
 	_ "github.com/treeverse/lakefs/statik"
@@ -19,6 +20,8 @@ import (
 const (
 	RequestIDHeaderName = "X-Request-ID"
 	LoggerServiceName   = "rest_api"
+	// LFSLoggerServiceName is the service name of the logs of the git LFS API
+	LFSLoggerServiceName = "lfs"
 )
 
 func Serve(deps Dependencies) http.Handler {
@@ -31,6 +34,7 @@ func Serve(deps Dependencies) http.Handler {
 	}
 	api.BasicAuthAuth = NewBasicAuthHandler(deps.Auth)
 	api.JwtTokenAuth = NewJwtTokenAuthHandler(deps.Auth)
+	api.APIAuthorizer = NewImpersonationAuthorizer(deps.Auth)
 
 	// bind our handlers to the server
 	controller := NewController(deps)
@@ -44,9 +48,11 @@ func Serve(deps Dependencies) http.Handler {
 			logging.Fields{"service_name": LoggerServiceName},
 			promhttp.InstrumentHandlerCounter(requestCounter,
 				MetricsHandler(api.Context(),
-					NewCookieAPIHandler(handler))))
+					NewCookieAPIHandler(
+						NewErrorCodeHandler(
+							NewNetworkPolicyHandler(deps.Auth, handler))))))
 	})
-	uiHandler := NewUIHandler(deps.Auth)
+	uiHandler := NewUIHandler(deps.Auth, deps.ExternalAuth)
 
 	mux := http.NewServeMux()
 	mux.Handle("/_health", httputil.ServeHealth())
@@ -54,6 +60,14 @@ func Serve(deps Dependencies) http.Handler {
 	mux.Handle("/_pprof/", httputil.ServePPROF("/_pprof/"))
 	mux.Handle("/api/", apiHandler)
 	mux.Handle("/swagger.json", apiHandler)
+	mux.Handle(BrowsePathPrefix, httputil.LoggingMiddleware(
+		RequestIDHeaderName,
+		logging.Fields{"service_name": LoggerServiceName},
+		NewBrowseHandler(deps.Auth, controller.ObjectsGetObjectHandler())))
+	mux.Handle(lfs.PathPrefix, httputil.LoggingMiddleware(
+		RequestIDHeaderName,
+		logging.Fields{"service_name": LFSLoggerServiceName},
+		lfs.NewHandler(deps.Cataloger, deps.BlockAdapter, deps.Encryptor, deps.Auth)))
 	mux.Handle("/", uiHandler)
 	return mux
 }
@@ -1,6 +1,7 @@
 package api_test
 
 import (
+	"bytes"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -11,6 +12,7 @@ import (
 	"github.com/treeverse/lakefs/logging"
 
 	"github.com/go-openapi/swag"
+	"github.com/treeverse/lakefs/actions"
 	"github.com/treeverse/lakefs/api"
 	"github.com/treeverse/lakefs/api/gen/client"
 	"github.com/treeverse/lakefs/api/gen/client/setup"
@@ -25,12 +27,16 @@ import (
 	"github.com/treeverse/lakefs/config"
 	"github.com/treeverse/lakefs/db"
 	dbparams "github.com/treeverse/lakefs/db/params"
+	"github.com/treeverse/lakefs/encryption"
 	"github.com/treeverse/lakefs/stats"
 	"github.com/treeverse/lakefs/testutil"
 )
 
 const ServerTimeout = 30 * time.Second
 
+// encryptedRepository is the repository whose objects the test server encrypts
+const encryptedRepository = "encrypted"
+
 type dependencies struct {
 	blocks      block.Adapter
 	cataloger   catalog.Cataloger
@@ -65,6 +71,32 @@ func createDefaultAdminUser(t *testing.T, clt *client.Lakefs) *authmodel.Credent
 	}
 }
 
+// createUserWithStatements creates username with a policy of statements, returning the
+// credentials of the user
+func createUserWithStatements(t *testing.T, deps *dependencies, username string, statements authmodel.Statements) *authmodel.Credential {
+	t.Helper()
+	now := time.Now()
+	testutil.MustDo(t, "create user "+username, deps.authService.CreateUser(&authmodel.User{CreatedAt: now, Username: username}))
+	policy := &authmodel.Policy{CreatedAt: now, DisplayName: username + "Policy", Statement: statements}
+	testutil.MustDo(t, "write policy of "+username, deps.authService.WritePolicy(policy))
+	testutil.MustDo(t, "attach policy to "+username, deps.authService.AttachPolicyToUser(policy.DisplayName, username))
+	creds, err := deps.authService.CreateCredentials(username)
+	testutil.MustDo(t, "create credentials of "+username, err)
+	return creds
+}
+
+// createTenantAdminUser sets up tenant with its admin user, returning the credentials of the
+// admin
+func createTenantAdminUser(t *testing.T, deps *dependencies, tenant string) *authmodel.Credential {
+	t.Helper()
+	creds, err := auth.SetupTenant(deps.authService, &authmodel.Tenant{
+		CreatedAt:   time.Now(),
+		DisplayName: tenant,
+	}, auth.TenantPrefix(tenant)+"admin")
+	testutil.MustDo(t, "setup tenant "+tenant, err)
+	return creds
+}
+
 func setupHandler(t testing.TB, blockstoreType string, opts ...testutil.GetDBOption) (http.Handler, *dependencies) {
 	t.Helper()
 	conn, handlerDatabaseURI := testutil.GetDB(t, databaseURI, opts...)
@@ -97,13 +129,19 @@ func setupHandler(t testing.TB, blockstoreType string, opts ...testutil.GetDBOpt
 
 	collector := &nullCollector{}
 
+	keys, err := encryption.NewLocalKeyManager(map[string][]byte{"key": bytes.Repeat([]byte("k"), 32)})
+	testutil.MustDo(t, "build key manager", err)
+	encryptor := encryption.NewEncryptor(keys, []encryption.Rule{{Repository: encryptedRepository, KeyID: "key"}})
+
 	handler := api.Serve(api.Dependencies{
 		Cataloger:       cataloger,
+		Actions:         actions.NewStore(conn),
 		Auth:            authService,
 		BlockAdapter:    blockAdapter,
 		MetadataManager: meta,
 		Migrator:        migrator,
 		Collector:       collector,
+		Encryptor:       encryptor,
 		Logger:          logging.Default(),
 	})
 
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/swag"
+	"github.com/treeverse/lakefs/api/gen/models"
+	settingsop "github.com/treeverse/lakefs/api/gen/restapi/operations/settings"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/errcode"
+	"github.com/treeverse/lakefs/permissions"
+	"github.com/treeverse/lakefs/settings"
+)
+
+func (c *Controller) GetRepositorySettingsHandler() settingsop.GetRepositorySettingsHandler {
+	return settingsop.GetRepositorySettingsHandlerFunc(func(params settingsop.GetRepositorySettingsParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.GetSettingsAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return settingsop.NewGetRepositorySettingsUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("get_repository_settings")
+		repoSettings, err := deps.Settings.Get(deps.ctx, params.Repository)
+		if err != nil {
+			return settingsop.NewGetRepositorySettingsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		// read the overrides again, settings are cached and may not include recent changes
+		overrides, err := deps.Settings.ListOverrides(deps.ctx, params.Repository)
+		if err != nil {
+			return settingsop.NewGetRepositorySettingsDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		results := make([]*models.SettingOverride, len(overrides))
+		for i, override := range overrides {
+			results[i] = &models.SettingOverride{
+				Key:        swag.String(override.Key),
+				Value:      swag.String(override.Value),
+				UpdateDate: swag.Int64(override.UpdateDate.Unix()),
+			}
+		}
+		return settingsop.NewGetRepositorySettingsOK().WithPayload(&models.RepositorySettings{
+			StorageClass:         swag.String(repoSettings.StorageClass),
+			MaxObjectSize:        swag.Int64(repoSettings.MaxObjectSize),
+			NotificationsEnabled: swag.Bool(repoSettings.NotificationsEnabled),
+			Public:               repoSettings.Public,
+			Overrides:            results,
+		})
+	})
+}
+
+func (c *Controller) SetRepositorySettingHandler() settingsop.SetRepositorySettingHandler {
+	return settingsop.SetRepositorySettingHandlerFunc(func(params settingsop.SetRepositorySettingParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.SetSettingsAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return settingsop.NewSetRepositorySettingUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("set_repository_setting")
+		_, err = deps.Cataloger.GetRepository(deps.ctx, params.Repository)
+		if errors.Is(err, db.ErrNotFound) {
+			return settingsop.NewSetRepositorySettingNotFound().WithPayload(responseErrorCode(errcode.RepositoryNotFound, "repository '%s' not found.", params.Repository))
+		}
+		if err != nil {
+			return settingsop.NewSetRepositorySettingDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		value := swag.StringValue(params.Setting.Value)
+		if public, _ := strconv.ParseBool(value); params.Key == settings.KeyPublic && public {
+			instance, err := deps.InstanceSettings.Get(deps.ctx)
+			if err != nil {
+				return settingsop.NewSetRepositorySettingDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+			}
+			if !instance.PublicRepositoriesAllowed {
+				return settingsop.NewSetRepositorySettingBadRequest().WithPayload(responseErrorFrom(settings.ErrPublicNotAllowed))
+			}
+		}
+		err = deps.Settings.SetOverride(deps.ctx, params.Repository, params.Key, value)
+		switch {
+		case errors.Is(err, settings.ErrUnknownKey), errors.Is(err, settings.ErrInvalidValue):
+			return settingsop.NewSetRepositorySettingBadRequest().WithPayload(responseErrorFrom(err))
+		case err != nil:
+			return settingsop.NewSetRepositorySettingDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return settingsop.NewSetRepositorySettingNoContent()
+	})
+}
+
+func (c *Controller) DeleteRepositorySettingHandler() settingsop.DeleteRepositorySettingHandler {
+	return settingsop.DeleteRepositorySettingHandlerFunc(func(params settingsop.DeleteRepositorySettingParams, user *models.User) middleware.Responder {
+		deps, err := c.setupRequest(user, params.HTTPRequest, []permissions.Permission{
+			{
+				Action:   permissions.SetSettingsAction,
+				Resource: permissions.RepoArn(params.Repository),
+			},
+		})
+		if err != nil {
+			return settingsop.NewDeleteRepositorySettingUnauthorized().WithPayload(responseErrorFrom(err))
+		}
+		deps.LogAction("delete_repository_setting")
+		err = deps.Settings.DeleteOverride(deps.ctx, params.Repository, params.Key)
+		switch {
+		case errors.Is(err, settings.ErrOverrideNotFound):
+			return settingsop.NewDeleteRepositorySettingNotFound().WithPayload(responseError("setting '%s' of repository '%s' is not overridden.", params.Key, params.Repository))
+		case err != nil:
+			return settingsop.NewDeleteRepositorySettingDefault(http.StatusInternalServerError).WithPayload(responseErrorFrom(err))
+		}
+		return settingsop.NewDeleteRepositorySettingNoContent()
+	})
+}
@@ -4,7 +4,9 @@ import (
 	"strings"
 
 	"github.com/treeverse/lakefs/api/gen/models"
+	"github.com/treeverse/lakefs/block"
 	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/httputil"
 )
 
 func transformDifferenceTypeToString(d catalog.DifferenceType) string {
@@ -22,6 +24,29 @@ func transformDifferenceTypeToString(d catalog.DifferenceType) string {
 	}
 }
 
+func transformStringToDifferenceType(s string) catalog.DifferenceType {
+	switch s {
+	case models.DiffTypeAdded:
+		return catalog.DifferenceTypeAdded
+	case models.DiffTypeRemoved:
+		return catalog.DifferenceTypeRemoved
+	case models.DiffTypeChanged:
+		return catalog.DifferenceTypeChanged
+	case models.DiffTypeConflict:
+		return catalog.DifferenceTypeConflict
+	default:
+		return catalog.DifferenceTypeNone
+	}
+}
+
+func transformStringsToDifferenceTypes(types []string) []catalog.DifferenceType {
+	res := make([]catalog.DifferenceType, len(types))
+	for i, s := range types {
+		res[i] = transformStringToDifferenceType(s)
+	}
+	return res
+}
+
 func transformDifferenceToDiff(difference catalog.Difference) *models.Diff {
 	d := &models.Diff{
 		Path: difference.Path,
@@ -34,3 +59,55 @@ func transformDifferenceToDiff(difference catalog.Difference) *models.Diff {
 	}
 	return d
 }
+
+func transformCommitLogToCommit(commit *catalog.CommitLog) *models.Commit {
+	return &models.Commit{
+		Committer:      commit.Committer,
+		CommitterEmail: commit.CommitterEmail,
+		CreationDate:   commit.CreationDate.Unix(),
+		ID:             commit.Reference,
+		Message:        commit.Message,
+		Metadata:       commit.Metadata,
+		MetaRangeID:    commit.MetaRangeID,
+		Parents:        commit.Parents,
+		Author:         commit.Author,
+		AuthorEmail:    commit.AuthorEmail,
+		AuthorDate:     commit.AuthorDate.Unix(),
+	}
+}
+
+func transformEntryToObjectStats(repo *catalog.Repository, entry *catalog.DBEntry) (*models.ObjectStats, error) {
+	if entry.CommonLevel {
+		return &models.ObjectStats{
+			Path:     entry.Path,
+			PathType: models.ObjectStatsPathTypeCommonPrefix,
+		}, nil
+	}
+	if entry.LinkTarget != "" {
+		return &models.ObjectStats{
+			Mtime:          entry.CreationDate.Unix(),
+			Path:           entry.Path,
+			PathType:       models.ObjectStatsPathTypeLink,
+			LinkTarget:     entry.LinkTarget,
+			LinkRepository: entry.LinkRepository,
+			LinkRef:        entry.LinkRef,
+		}, nil
+	}
+	qk, err := block.ResolveNamespace(repo.StorageNamespace, entry.PhysicalAddress)
+	if err != nil {
+		return nil, err
+	}
+	var mtime int64
+	if !entry.CreationDate.IsZero() {
+		mtime = entry.CreationDate.Unix()
+	}
+	return &models.ObjectStats{
+		Checksum:        entry.Checksum,
+		Mtime:           mtime,
+		Path:            entry.Path,
+		PhysicalAddress: qk.Format(),
+		PathType:        objectPathType(entry),
+		SizeBytes:       entry.Size,
+		Metadata:        httputil.ObjectMetadata(entry.Metadata),
+	}, nil
+}
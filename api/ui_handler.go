@@ -11,9 +11,9 @@ import (
 	"github.com/treeverse/lakefs/statik"
 )
 
-func NewUIHandler(authService auth.Service) http.Handler {
+func NewUIHandler(authService auth.Service, externalAuth *auth.ExternalAuth) http.Handler {
 	mux := http.NewServeMux()
-	mux.Handle("/auth/login", NewLoginHandler(authService))
+	mux.Handle("/auth/login", NewLoginHandler(authService, externalAuth))
 	mux.Handle("/auth/logout", NewLogoutHandler())
 	staticFiles, _ := fs.NewWithNamespace(statik.Webui)
 	mux.Handle("/", NewHandlerWithDefault(staticFiles, http.FileServer(staticFiles), "/"))
@@ -0,0 +1,104 @@
+// Package archive writes files to tar and zip archives streamed to a writer
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	FormatTar = "tar"
+	FormatZip = "zip"
+
+	// fileMode is the mode of files written, archives hold read-only copies of objects
+	fileMode = 0444
+)
+
+var ErrUnknownFormat = errors.New("unknown archive format")
+
+// File is the header of a file written to an archive
+type File struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+// Writer writes files to an archive, Close writes the end of the archive
+type Writer interface {
+	// WriteFile writes file with the content read from r, which must hold file.Size bytes
+	WriteFile(file File, r io.Reader) error
+	Close() error
+}
+
+// NewWriter returns a Writer of an archive of format to w
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case FormatTar:
+		return &tarWriter{w: tar.NewWriter(w)}, nil
+	case FormatZip:
+		return &zipWriter{w: zip.NewWriter(w)}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+// ContentType returns the MIME type of archives of format
+func ContentType(format string) string {
+	switch format {
+	case FormatZip:
+		return "application/zip"
+	default:
+		return "application/x-tar"
+	}
+}
+
+type tarWriter struct {
+	w *tar.Writer
+}
+
+func (t *tarWriter) WriteFile(file File, r io.Reader) error {
+	err := t.w.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     file.Name,
+		Size:     file.Size,
+		Mode:     fileMode,
+		ModTime:  file.ModTime,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.CopyN(t.w, r, file.Size)
+	return err
+}
+
+func (t *tarWriter) Close() error {
+	return t.w.Close()
+}
+
+type zipWriter struct {
+	w *zip.Writer
+}
+
+func (z *zipWriter) WriteFile(file File, r io.Reader) error {
+	header := &zip.FileHeader{
+		Name: file.Name,
+		// objects are usually compressed already, store them as they are
+		Method:   zip.Store,
+		Modified: file.ModTime,
+	}
+	header.SetMode(fileMode)
+	fw, err := z.w.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.CopyN(fw, r, file.Size)
+	return err
+}
+
+func (z *zipWriter) Close() error {
+	return z.w.Close()
+}
@@ -0,0 +1,101 @@
+package archive_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/treeverse/lakefs/archive"
+)
+
+var testFiles = []struct {
+	name    string
+	content string
+}{
+	{name: "data/a.csv", content: "a,b\n1,2\n"},
+	{name: "data/empty", content: ""},
+	{name: "data/sub/b.json", content: `{"b":1}`},
+}
+
+func writeTestArchive(t *testing.T, format string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := archive.NewWriter(&buf, format)
+	if err != nil {
+		t.Fatalf("NewWriter(%s): %s", format, err)
+	}
+	for _, f := range testFiles {
+		file := archive.File{Name: f.name, Size: int64(len(f.content)), ModTime: time.Unix(1600000000, 0)}
+		if err := w.WriteFile(file, strings.NewReader(f.content)); err != nil {
+			t.Fatalf("WriteFile(%s): %s", f.name, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %s", err)
+	}
+	return buf.Bytes()
+}
+
+func TestWriter_Tar(t *testing.T) {
+	r := tar.NewReader(bytes.NewReader(writeTestArchive(t, archive.FormatTar)))
+	for _, f := range testFiles {
+		header, err := r.Next()
+		if err != nil {
+			t.Fatalf("read header of %s: %s", f.name, err)
+		}
+		if header.Name != f.name {
+			t.Errorf("got file %s, expected %s", header.Name, f.name)
+		}
+		content, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatalf("read %s: %s", f.name, err)
+		}
+		if string(content) != f.content {
+			t.Errorf("got content %q of %s, expected %q", content, f.name, f.content)
+		}
+	}
+	if _, err := r.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected end of archive, got %v", err)
+	}
+}
+
+func TestWriter_Zip(t *testing.T) {
+	data := writeTestArchive(t, archive.FormatZip)
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("open zip: %s", err)
+	}
+	if len(r.File) != len(testFiles) {
+		t.Fatalf("got %d files, expected %d", len(r.File), len(testFiles))
+	}
+	for i, f := range testFiles {
+		if r.File[i].Name != f.name {
+			t.Errorf("got file %s, expected %s", r.File[i].Name, f.name)
+		}
+		rc, err := r.File[i].Open()
+		if err != nil {
+			t.Fatalf("open %s: %s", f.name, err)
+		}
+		content, err := ioutil.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			t.Fatalf("read %s: %s", f.name, err)
+		}
+		if string(content) != f.content {
+			t.Errorf("got content %q of %s, expected %q", content, f.name, f.content)
+		}
+	}
+}
+
+func TestNewWriter_UnknownFormat(t *testing.T) {
+	_, err := archive.NewWriter(ioutil.Discard, "rar")
+	if !errors.Is(err, archive.ErrUnknownFormat) {
+		t.Errorf("expected ErrUnknownFormat, got %v", err)
+	}
+}
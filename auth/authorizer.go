@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/treeverse/lakefs/auth/model"
+	"github.com/treeverse/lakefs/permissions"
+)
+
+// AccessRequest is a single access decision asked of an Authorizer
+type AccessRequest struct {
+	Principal string   `json:"principal"`
+	Groups    []string `json:"groups"`
+	Action    string   `json:"action"`
+	Resource  string   `json:"resource"`
+	// Repository, Ref and Path are parsed from Resource when it names them.  Ref is the branch or
+	// tag of the resource, object resources hold a Path but no Ref.
+	Repository string `json:"repository,omitempty"`
+	Ref        string `json:"ref,omitempty"`
+	Path       string `json:"path,omitempty"`
+	// Deniable requests are not required to be allowed: a request denied by Authorize was denied
+	// explicitly by a policy
+	Deniable bool `json:"deniable"`
+}
+
+// Authorizer decides access requests in place of lakeFS policies, for organizations managing
+// access in a central policy engine
+type Authorizer interface {
+	Authorize(req *AccessRequest) (bool, error)
+}
+
+// NewAccessRequest returns the request of username, a member of groups, for perm
+func NewAccessRequest(username string, groups []string, perm permissions.Permission, deniable bool) *AccessRequest {
+	req := &AccessRequest{
+		Principal: username,
+		Groups:    groups,
+		Action:    perm.Action,
+		Resource:  perm.Resource,
+		Deniable:  deniable,
+	}
+	arn, err := ParseARN(perm.Resource)
+	if err != nil || arn.Service != "fs" {
+		return req
+	}
+	// repository/{repository}[/{type}/{id}]
+	const resourceParts = 4
+	parts := strings.SplitN(arn.ResourceID, "/", resourceParts)
+	if len(parts) < 2 || parts[0] != "repository" {
+		return req
+	}
+	req.Repository = parts[1]
+	if len(parts) < resourceParts {
+		return req
+	}
+	switch parts[2] {
+	case "branch", "tag":
+		req.Ref = parts[3]
+	case "object":
+		req.Path = parts[3]
+	}
+	return req
+}
+
+// AuthorizerService is a Service whose authorization decisions are delegated to an Authorizer.
+// Users of a tenant remain limited to its resources.
+type AuthorizerService struct {
+	Service
+	Authorizer Authorizer
+}
+
+func NewAuthorizerService(service Service, authorizer Authorizer) *AuthorizerService {
+	return &AuthorizerService{
+		Service:    service,
+		Authorizer: authorizer,
+	}
+}
+
+func (s *AuthorizerService) Authorize(req *AuthorizationRequest) (*AuthorizationResponse, error) {
+	user, err := s.GetUser(req.Username)
+	if err != nil {
+		return nil, err
+	}
+	for _, perm := range req.RequiredPermissions {
+		if !inTenantResource(user.Tenant, perm.Resource) {
+			return &AuthorizationResponse{Allowed: false, Error: ErrInsufficientPermissions}, nil
+		}
+	}
+	groups, err := s.userGroups(req.Username)
+	if err != nil {
+		return nil, err
+	}
+	check := func(perms []permissions.Permission, deniable bool) (bool, error) {
+		for _, perm := range perms {
+			allowed, err := s.Authorizer.Authorize(NewAccessRequest(req.Username, groups, perm, deniable))
+			if err != nil || !allowed {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+	allowed, err := check(req.RequiredPermissions, false)
+	if err == nil && allowed {
+		allowed, err = check(req.DeniablePermissions, true)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return &AuthorizationResponse{Allowed: false, Error: ErrInsufficientPermissions}, nil
+	}
+	return &AuthorizationResponse{Allowed: true}, nil
+}
+
+// Simulate returns the decision of the Authorizer on req, it is not explained by policies
+func (s *AuthorizerService) Simulate(req *AuthorizationRequest) (*Simulation, error) {
+	resp, err := s.Authorize(req)
+	if err != nil {
+		return nil, err
+	}
+	sim := &Simulation{Allowed: resp.Allowed, Reason: "denied by the external authorizer"}
+	if resp.Allowed {
+		sim.Reason = "allowed by the external authorizer"
+	}
+	return sim, nil
+}
+
+// userGroups returns the names of all groups of username
+func (s *AuthorizerService) userGroups(username string) ([]string, error) {
+	const pageSize = 1000
+	var (
+		groups []string
+		after  string
+	)
+	for {
+		page, paginator, err := s.ListUserGroups(username, &model.PaginationParams{After: after, Amount: pageSize})
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range page {
+			groups = append(groups, group.DisplayName)
+		}
+		if paginator.NextPageToken == "" {
+			return groups, nil
+		}
+		after = paginator.NextPageToken
+	}
+}
@@ -1,8 +1,18 @@
 package auth
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrInvalidArn              = errors.New("invalid ARN")
 	ErrInsufficientPermissions = errors.New("insufficient permissions")
+	ErrNotInTenant             = errors.New("name not prefixed by tenant")
+	ErrTenantQuotaExceeded     = errors.New("tenant quota exceeded")
+	ErrTenantNamesInUse        = errors.New("names prefixed by tenant in use")
+	ErrInvalidCredentials      = errors.New("invalid credentials")
+	ErrCredentialsExpired      = errors.New("credentials expired")
+	ErrAddressNotPermitted     = errors.New("source address not permitted by network policy")
+	ErrNotExternalUser         = fmt.Errorf("%w: user not mirrored from the external auth provider", ErrInvalidCredentials)
 )
@@ -0,0 +1,228 @@
+// Package ldap authenticates lakeFS users against an LDAP directory.
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/treeverse/lakefs/auth"
+	"github.com/treeverse/lakefs/auth/params"
+	"github.com/treeverse/lakefs/db"
+)
+
+const (
+	DefaultUserFilter         = "(uid=%s)"
+	DefaultGroupFilter        = "(member=%s)"
+	DefaultGroupNameAttribute = "cn"
+	DefaultPoolSize           = 4
+	DefaultTimeout            = 10 * time.Second
+)
+
+// noAttributesListOID requests entries with no attributes
+const noAttributesListOID = "1.1"
+
+var ErrAmbiguousUser = errors.New("several directory entries match the user")
+
+// Provider is an auth.AuthProvider backed by an LDAP directory.  It searches the directory as the
+// service account over a pool of connections, and verifies passwords by binding as the user.
+type Provider struct {
+	cfg  params.LDAP
+	idle chan *ldap.Conn
+}
+
+var _ auth.AuthProvider = (*Provider)(nil)
+
+func NewProvider(cfg params.LDAP) (*Provider, error) {
+	if cfg.UserFilter == "" {
+		cfg.UserFilter = DefaultUserFilter
+	}
+	if cfg.GroupFilter == "" {
+		cfg.GroupFilter = DefaultGroupFilter
+	}
+	if cfg.GroupNameAttribute == "" {
+		cfg.GroupNameAttribute = DefaultGroupNameAttribute
+	}
+	if cfg.PoolSize <= 0 {
+		cfg.PoolSize = DefaultPoolSize
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	// verify the filters before the first login
+	for _, filter := range []string{cfg.UserFilter, cfg.GroupFilter} {
+		if _, err := ldap.CompileFilter(fmt.Sprintf(filter, "x")); err != nil {
+			return nil, err
+		}
+	}
+	return &Provider{
+		cfg:  cfg,
+		idle: make(chan *ldap.Conn, cfg.PoolSize),
+	}, nil
+}
+
+// get returns an idle connection bound as the service account, opening one if none is idle
+func (p *Provider) get(ctx context.Context) (*ldap.Conn, error) {
+	var c *ldap.Conn
+	select {
+	case c = <-p.idle:
+	default:
+	}
+	if c == nil {
+		timeout, err := p.timeout(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c, err = ldap.DialURL(p.cfg.URL,
+			ldap.DialWithDialer(&net.Dialer{Timeout: timeout}),
+			ldap.DialWithTLSConfig(&tls.Config{MinVersion: tls.VersionTLS12}))
+		if err != nil {
+			return nil, err
+		}
+		if err := c.Bind(p.cfg.BindDN, p.cfg.BindPassword); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("bind %s: %w", p.cfg.BindDN, err)
+		}
+	}
+	timeout, err := p.timeout(ctx)
+	if err != nil {
+		p.put(c)
+		return nil, err
+	}
+	c.SetTimeout(timeout)
+	return c, nil
+}
+
+// timeout returns the configured timeout bounded by the context deadline
+func (p *Provider) timeout(ctx context.Context) (time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	timeout := p.cfg.Timeout
+	if deadline, ok := ctx.Deadline(); ok {
+		if untilDeadline := time.Until(deadline); untilDeadline < timeout {
+			timeout = untilDeadline
+		}
+	}
+	if timeout <= 0 {
+		return 0, context.DeadlineExceeded
+	}
+	return timeout, nil
+}
+
+// put returns c to the pool, closing it when the pool is full or c is closing
+func (p *Provider) put(c *ldap.Conn) {
+	if c.IsClosing() {
+		c.Close()
+		return
+	}
+	select {
+	case p.idle <- c:
+	default:
+		_ = c.Unbind()
+	}
+}
+
+// Close closes the idle connections
+func (p *Provider) Close() error {
+	for {
+		select {
+		case c := <-p.idle:
+			_ = c.Unbind()
+		default:
+			return nil
+		}
+	}
+}
+
+func search(c *ldap.Conn, baseDN, filter string, attributes []string, sizeLimit int) ([]*ldap.Entry, error) {
+	res, err := c.Search(ldap.NewSearchRequest(baseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		sizeLimit, 0, false, filter, attributes, nil))
+	if err != nil {
+		return nil, err
+	}
+	return res.Entries, nil
+}
+
+func (p *Provider) findUserDN(c *ldap.Conn, username string) (string, error) {
+	filter := fmt.Sprintf(p.cfg.UserFilter, ldap.EscapeFilter(username))
+	const sizeLimit = 2 // enough to detect ambiguous users
+	entries, err := search(c, p.cfg.UserBaseDN, filter, []string{noAttributesListOID}, sizeLimit)
+	switch {
+	case ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded):
+		return "", fmt.Errorf("%s: %w", username, ErrAmbiguousUser)
+	case err != nil:
+		return "", err
+	case len(entries) == 0:
+		return "", fmt.Errorf("directory user %s: %w", username, db.ErrNotFound)
+	case len(entries) > 1:
+		return "", fmt.Errorf("%s: %w", username, ErrAmbiguousUser)
+	}
+	return entries[0].DN, nil
+}
+
+func (p *Provider) Authenticate(ctx context.Context, username, password string) error {
+	// an empty password is an unauthenticated bind, which succeeds on many servers
+	if password == "" {
+		return auth.ErrInvalidCredentials
+	}
+	c, err := p.get(ctx)
+	if err != nil {
+		return err
+	}
+	defer p.put(c)
+
+	dn, err := p.findUserDN(c, username)
+	if errors.Is(err, db.ErrNotFound) {
+		return auth.ErrInvalidCredentials
+	}
+	if err != nil {
+		return err
+	}
+	bindErr := c.Bind(dn, password)
+	// restore the service account binding before returning the connection to the pool
+	if err := c.Bind(p.cfg.BindDN, p.cfg.BindPassword); err != nil {
+		c.Close()
+		return fmt.Errorf("bind %s: %w", p.cfg.BindDN, err)
+	}
+	if ldap.IsErrorWithCode(bindErr, ldap.LDAPResultInvalidCredentials) {
+		return auth.ErrInvalidCredentials
+	}
+	return bindErr
+}
+
+func (p *Provider) ListGroups(ctx context.Context, username string) ([]string, error) {
+	c, err := p.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer p.put(c)
+
+	dn, err := p.findUserDN(c, username)
+	if err != nil {
+		return nil, err
+	}
+	filter := fmt.Sprintf(p.cfg.GroupFilter, ldap.EscapeFilter(dn))
+	entries, err := search(c, p.cfg.GroupBaseDN, filter, []string{p.cfg.GroupNameAttribute}, 0)
+	if err != nil {
+		return nil, err
+	}
+	groups := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		groups = append(groups, groupName(entry, p.cfg.GroupNameAttribute))
+	}
+	return groups, nil
+}
+
+// groupName returns the nameAttribute of entry, matched case insensitively as attribute names
+// are, or the entry DN when it has no such attribute
+func groupName(entry *ldap.Entry, nameAttribute string) string {
+	if name := entry.GetEqualFoldAttributeValue(nameAttribute); name != "" {
+		return name
+	}
+	return entry.DN
+}
@@ -0,0 +1,221 @@
+package ldap
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/auth"
+	"github.com/treeverse/lakefs/auth/params"
+)
+
+// fakeDirectory is a directory server answering simple binds and searches with equality and
+// "and" filters
+type fakeDirectory struct {
+	entries   []*fakeEntry
+	passwords map[string]string
+	conns     int32
+}
+
+type fakeEntry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+func (d *fakeDirectory) serve(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("listen:", err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&d.conns, 1)
+			go d.handle(c)
+		}
+	}()
+	return "ldap://" + l.Addr().String()
+}
+
+func octetString(value string) *ber.Packet {
+	return ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "")
+}
+
+func ldapResult(tag ber.Tag, code uint16) *ber.Packet {
+	result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "")
+	result.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), ""))
+	result.AppendChild(octetString(""))
+	result.AppendChild(octetString(""))
+	return result
+}
+
+func (d *fakeDirectory) handle(c net.Conn) {
+	defer func() { _ = c.Close() }()
+	r := bufio.NewReader(c)
+	for {
+		msg, err := ber.ReadPacket(r)
+		if err != nil {
+			return
+		}
+		id, op := msg.Children[0].Value.(int64), msg.Children[1]
+		reply := func(resp *ber.Packet) {
+			envelope := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+			envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, ""))
+			envelope.AppendChild(resp)
+			_, _ = c.Write(envelope.Bytes())
+		}
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			code := uint16(ldap.LDAPResultInvalidCredentials)
+			if pw, ok := d.passwords[op.Children[1].Value.(string)]; ok && pw == op.Children[2].Data.String() {
+				code = ldap.LDAPResultSuccess
+			}
+			reply(ldapResult(ldap.ApplicationBindResponse, code))
+		case ldap.ApplicationSearchRequest:
+			base, filter := op.Children[0].Value.(string), op.Children[6]
+			for _, e := range d.entries {
+				if !strings.HasSuffix(e.DN, base) || !matches(e, filter) {
+					continue
+				}
+				attrs := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+				for name, values := range e.Attributes {
+					attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+					attr.AppendChild(octetString(name))
+					vals := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+					for _, v := range values {
+						vals.AppendChild(octetString(v))
+					}
+					attr.AppendChild(vals)
+					attrs.AppendChild(attr)
+				}
+				entry := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "")
+				entry.AppendChild(octetString(e.DN))
+				entry.AppendChild(attrs)
+				reply(entry)
+			}
+			reply(ldapResult(ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess))
+		case ldap.ApplicationUnbindRequest:
+			return
+		}
+	}
+}
+
+func matches(e *fakeEntry, filter *ber.Packet) bool {
+	switch filter.Tag {
+	case ldap.FilterAnd:
+		for _, child := range filter.Children {
+			if !matches(e, child) {
+				return false
+			}
+		}
+		return true
+	case ldap.FilterEqualityMatch:
+		for _, v := range e.Attributes[filter.Children[0].Value.(string)] {
+			if v == filter.Children[1].Value.(string) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func newTestProvider(t *testing.T) (*Provider, *fakeDirectory) {
+	t.Helper()
+	d := &fakeDirectory{
+		entries: []*fakeEntry{
+			{DN: "uid=jane,ou=people,dc=example", Attributes: map[string][]string{"uid": {"jane"}, "objectClass": {"person"}}},
+			{DN: "uid=joe,ou=people,dc=example", Attributes: map[string][]string{"uid": {"joe"}, "objectClass": {"person"}}},
+			{DN: "cn=data,ou=groups,dc=example", Attributes: map[string][]string{"cn": {"data"}, "member": {"uid=jane,ou=people,dc=example"}}},
+			{DN: "cn=ops,ou=groups,dc=example", Attributes: map[string][]string{"cn": {"ops"}, "member": {"uid=jane,ou=people,dc=example", "uid=joe,ou=people,dc=example"}}},
+		},
+		passwords: map[string]string{
+			"cn=lakefs,dc=example":          "service-secret",
+			"uid=jane,ou=people,dc=example": "jane-secret",
+		},
+	}
+	p, err := NewProvider(params.LDAP{
+		URL:          d.serve(t),
+		BindDN:       "cn=lakefs,dc=example",
+		BindPassword: "service-secret",
+		UserBaseDN:   "ou=people,dc=example",
+		UserFilter:   "(&(objectClass=person)(uid=%s))",
+		GroupBaseDN:  "ou=groups,dc=example",
+	})
+	if err != nil {
+		t.Fatal("NewProvider:", err)
+	}
+	t.Cleanup(func() { _ = p.Close() })
+	return p, d
+}
+
+func TestProvider_Authenticate(t *testing.T) {
+	p, d := newTestProvider(t)
+	ctx := context.Background()
+	cases := []struct {
+		name     string
+		username string
+		password string
+		err      error
+	}{
+		{name: "valid", username: "jane", password: "jane-secret"},
+		{name: "wrong_password", username: "jane", password: "joe-secret", err: auth.ErrInvalidCredentials},
+		{name: "empty_password", username: "jane", password: "", err: auth.ErrInvalidCredentials},
+		{name: "unknown_user", username: "john", password: "jane-secret", err: auth.ErrInvalidCredentials},
+		{name: "filter_injection", username: "*)(uid=jane", password: "jane-secret", err: auth.ErrInvalidCredentials},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := p.Authenticate(ctx, tc.username, tc.password)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("Authenticate(%s): expected %v, got %v", tc.username, tc.err, err)
+			}
+		})
+	}
+	if conns := atomic.LoadInt32(&d.conns); conns != 1 {
+		t.Errorf("expected requests to share a pooled connection, opened %d", conns)
+	}
+}
+
+func TestProvider_ListGroups(t *testing.T) {
+	p, _ := newTestProvider(t)
+	ctx := context.Background()
+	groups, err := p.ListGroups(ctx, "jane")
+	if err != nil {
+		t.Fatal("ListGroups:", err)
+	}
+	sort.Strings(groups)
+	if diff := deep.Equal(groups, []string{"data", "ops"}); diff != nil {
+		t.Error("ListGroups(jane):", diff)
+	}
+	groups, err = p.ListGroups(ctx, "joe")
+	if err != nil {
+		t.Fatal("ListGroups:", err)
+	}
+	if diff := deep.Equal(groups, []string{"ops"}); diff != nil {
+		t.Error("ListGroups(joe):", diff)
+	}
+}
+
+func TestNewProvider_InvalidFilter(t *testing.T) {
+	for _, cfg := range []params.LDAP{
+		{URL: "ldap://localhost", UserFilter: "(uid=%s"},
+		{URL: "ldap://localhost", GroupFilter: "member=%s"},
+	} {
+		if _, err := NewProvider(cfg); err == nil {
+			t.Errorf("NewProvider(user filter %q, group filter %q): expected an error", cfg.UserFilter, cfg.GroupFilter)
+		}
+	}
+}
@@ -1,9 +1,13 @@
 package model
 
 import (
+	"crypto/subtle"
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net"
+	"strings"
 	"time"
 
 	"github.com/treeverse/lakefs/api/gen/models"
@@ -29,6 +33,23 @@ type User struct {
 	ID        int       `db:"id"`
 	CreatedAt time.Time `db:"created_at"`
 	Username  string    `db:"display_name" json:"display_name"`
+	// Tenant is the name of the tenant of the user, empty for users of the installation
+	Tenant string `db:"tenant" json:"tenant,omitempty"`
+	// Source is the name of the external auth provider mirroring the user, empty for users
+	// managed by lakeFS
+	Source string `db:"source" json:"source,omitempty"`
+}
+
+// Tenant is an isolated team served by the installation.  Its repositories, users, groups and
+// policies are named with its name and a dash as prefix.
+type Tenant struct {
+	ID          int       `db:"id"`
+	CreatedAt   time.Time `db:"created_at"`
+	DisplayName string    `db:"display_name" json:"display_name"`
+	// MaxRepositories is the number of repositories the tenant may create, unlimited when 0
+	MaxRepositories int `db:"max_repositories" json:"max_repositories"`
+	// MaxUsers is the number of users the tenant may have, unlimited when 0
+	MaxUsers int `db:"max_users" json:"max_users"`
 }
 
 // SuperuserConfiguration requests a particular configuration for a superuser.
@@ -82,11 +103,126 @@ func (s *Statements) Scan(src interface{}) error {
 }
 
 type Credential struct {
-	AccessKeyID                   string    `db:"access_key_id"`
-	AccessSecretKey               string    `db:"-" json:"-"`
-	AccessSecretKeyEncryptedBytes []byte    `db:"access_secret_key" json:"-"`
-	IssuedDate                    time.Time `db:"issued_date"`
-	UserID                        int       `db:"user_id"`
+	AccessKeyID                   string     `db:"access_key_id"`
+	AccessSecretKey               string     `db:"-" json:"-"`
+	AccessSecretKeyEncryptedBytes []byte     `db:"access_secret_key" json:"-"`
+	IssuedDate                    time.Time  `db:"issued_date"`
+	UserID                        int        `db:"user_id"`
+	ExpiresAt                     *time.Time `db:"expires_at"`
+	LastUsedAt                    *time.Time `db:"last_used_at"`
+	// PreviousAccessSecretKey is the secret replaced by the last rotation, still valid until
+	// PreviousSecretExpiresAt
+	PreviousAccessSecretKey               string     `db:"-" json:"-"`
+	PreviousAccessSecretKeyEncryptedBytes []byte     `db:"previous_access_secret_key" json:"-"`
+	PreviousSecretExpiresAt               *time.Time `db:"previous_secret_expires_at"`
+	// NetworkPolicy restricts the addresses from which the credentials may be used
+	NetworkPolicy NetworkPolicy `db:"network_policy"`
+}
+
+// Expired returns true if the credentials can no longer be used at now
+func (c *Credential) Expired(now time.Time) bool {
+	return c.ExpiresAt != nil && !now.Before(*c.ExpiresAt)
+}
+
+// PreviousSecretValid returns true if the secret replaced by the last rotation is still valid
+// at now
+func (c *Credential) PreviousSecretValid(now time.Time) bool {
+	return c.PreviousAccessSecretKey != "" && c.PreviousSecretExpiresAt != nil && now.Before(*c.PreviousSecretExpiresAt)
+}
+
+// SecretMatches returns true if secret is the secret of the credentials, or the previous secret
+// within its grace period
+func (c *Credential) SecretMatches(secret string, now time.Time) bool {
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(c.AccessSecretKey)) == 1 {
+		return true
+	}
+	return c.PreviousSecretValid(now) &&
+		subtle.ConstantTimeCompare([]byte(secret), []byte(c.PreviousAccessSecretKey)) == 1
+}
+
+// NetworkPolicy restricts the source addresses of requests.  Addresses matching Deny are
+// rejected, and when Allow is not empty only addresses matching Allow are accepted.  Entries are
+// CIDR blocks or single addresses.
+type NetworkPolicy struct {
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+}
+
+var (
+	ErrInvalidNetworkPolicySrcFormat = errors.New("invalid network policy src format")
+	ErrInvalidNetworkAddress         = errors.New("invalid network address")
+)
+
+// parseNetwork parses a CIDR block, or a single address as a block of one address
+func parseNetwork(s string) (*net.IPNet, error) {
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("%s: %w", s, ErrInvalidNetworkAddress)
+		}
+		bits := 8 * net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 8*net.IPv4len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, ipNet, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s, ErrInvalidNetworkAddress)
+	}
+	return ipNet, nil
+}
+
+func anyNetworkContains(networks []string, ip net.IP) bool {
+	for _, n := range networks {
+		ipNet, err := parseNetwork(n)
+		if err == nil && ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate returns ErrInvalidNetworkAddress if an entry is not a CIDR block or an address
+func (p NetworkPolicy) Validate() error {
+	for _, n := range append(append([]string{}, p.Allow...), p.Deny...) {
+		if _, err := parseNetwork(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsEmpty returns true if the policy accepts every address
+func (p NetworkPolicy) IsEmpty() bool {
+	return len(p.Allow) == 0 && len(p.Deny) == 0
+}
+
+// Permits returns true if requests from ip are accepted.  A nil ip, an unknown source, is only
+// accepted by an empty policy.
+func (p NetworkPolicy) Permits(ip net.IP) bool {
+	if p.IsEmpty() {
+		return true
+	}
+	if ip == nil || anyNetworkContains(p.Deny, ip) {
+		return false
+	}
+	return len(p.Allow) == 0 || anyNetworkContains(p.Allow, ip)
+}
+
+func (p NetworkPolicy) Value() (driver.Value, error) {
+	return json.Marshal(p)
+}
+
+func (p *NetworkPolicy) Scan(src interface{}) error {
+	if src == nil {
+		return nil
+	}
+	data, ok := src.([]byte)
+	if !ok {
+		return ErrInvalidNetworkPolicySrcFormat
+	}
+	return json.Unmarshal(data, p)
 }
 
 // For JSON serialization:
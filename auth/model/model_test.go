@@ -0,0 +1,55 @@
+package model_test
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/treeverse/lakefs/auth/model"
+)
+
+func TestNetworkPolicy_Permits(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy model.NetworkPolicy
+		ip     string
+		want   bool
+	}{
+		{name: "empty", ip: "192.0.2.1", want: true},
+		{name: "empty unknown source", want: true},
+		{name: "allowed", policy: model.NetworkPolicy{Allow: []string{"10.0.0.0/8"}}, ip: "10.1.2.3", want: true},
+		{name: "not allowed", policy: model.NetworkPolicy{Allow: []string{"10.0.0.0/8"}}, ip: "192.0.2.1", want: false},
+		{name: "single address", policy: model.NetworkPolicy{Allow: []string{"192.0.2.1"}}, ip: "192.0.2.1", want: true},
+		{name: "single address other", policy: model.NetworkPolicy{Allow: []string{"192.0.2.1"}}, ip: "192.0.2.2", want: false},
+		{name: "denied", policy: model.NetworkPolicy{Deny: []string{"192.0.2.0/24"}}, ip: "192.0.2.1", want: false},
+		{name: "not denied", policy: model.NetworkPolicy{Deny: []string{"192.0.2.0/24"}}, ip: "198.51.100.1", want: true},
+		{name: "deny precedes allow", policy: model.NetworkPolicy{Allow: []string{"10.0.0.0/8"}, Deny: []string{"10.66.0.0/16"}}, ip: "10.66.1.1", want: false},
+		{name: "ipv4 mapped ipv6", policy: model.NetworkPolicy{Allow: []string{"10.0.0.0/8"}}, ip: "::ffff:10.1.2.3", want: true},
+		{name: "ipv6", policy: model.NetworkPolicy{Allow: []string{"2001:db8::/32"}}, ip: "2001:db8::1", want: true},
+		{name: "unknown source", policy: model.NetworkPolicy{Deny: []string{"192.0.2.0/24"}}, want: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var ip net.IP
+			if tc.ip != "" {
+				ip = net.ParseIP(tc.ip)
+			}
+			if got := tc.policy.Permits(ip); got != tc.want {
+				t.Errorf("Permits(%s) = %t, want %t", tc.ip, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNetworkPolicy_Validate(t *testing.T) {
+	valid := model.NetworkPolicy{Allow: []string{"10.0.0.0/8", "192.0.2.1", "2001:db8::/32"}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate(%v): %s", valid, err)
+	}
+	for _, network := range []string{"10.0.0.0/33", "10.0.0", "example.com", ""} {
+		policy := model.NetworkPolicy{Deny: []string{network}}
+		if err := policy.Validate(); !errors.Is(err, model.ErrInvalidNetworkAddress) {
+			t.Errorf("Validate(%s): expected %v, got %v", network, model.ErrInvalidNetworkAddress, err)
+		}
+	}
+}
@@ -12,6 +12,7 @@ var (
 	ErrValidationError = errors.New("validation error")
 
 	EntityIDRegexp = regexp.MustCompile(`^[\w+=.,@\-]{1,127}$`)
+	TenantIDRegexp = regexp.MustCompile(`^[a-z0-9]{2,32}$`)
 )
 
 func ValidateAuthEntityID(name string) error {
@@ -21,6 +22,15 @@ func ValidateAuthEntityID(name string) error {
 	return nil
 }
 
+// ValidateTenantID validates a tenant name.  Tenant names have no dashes, so the tenant of a
+// prefixed name is unambiguous.
+func ValidateTenantID(name string) error {
+	if !TenantIDRegexp.MatchString(name) {
+		return ErrValidationError
+	}
+	return nil
+}
+
 func ValidateActionName(name string) error {
 	return permissions.IsValidAction(name)
 }
@@ -0,0 +1,78 @@
+// Package opa delegates lakeFS access decisions to an Open Policy Agent server, typically a
+// sidecar, through its data API.
+package opa
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/treeverse/lakefs/auth"
+	"github.com/treeverse/lakefs/auth/params"
+)
+
+const DefaultTimeout = 5 * time.Second
+
+var (
+	ErrDecisionFailed  = errors.New("OPA decision failed")
+	ErrInvalidDecision = errors.New("OPA decision is not a boolean")
+)
+
+// Authorizer is an auth.Authorizer asking the rule at the configured URL.  The access request is
+// its input, and the request is allowed only if the rule is true; an undefined rule denies it.
+type Authorizer struct {
+	url    string
+	client *http.Client
+}
+
+var _ auth.Authorizer = (*Authorizer)(nil)
+
+func NewAuthorizer(cfg params.OPA) *Authorizer {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	return &Authorizer{
+		url:    cfg.URL,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+type decisionRequest struct {
+	Input *auth.AccessRequest `json:"input"`
+}
+
+type decisionResponse struct {
+	Result *json.RawMessage `json:"result"`
+}
+
+func (a *Authorizer) Authorize(req *auth.AccessRequest) (bool, error) {
+	body, err := json.Marshal(decisionRequest{Input: req})
+	if err != nil {
+		return false, err
+	}
+	resp, err := a.client.Post(a.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("%w: %s", ErrDecisionFailed, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("%w: status %s", ErrDecisionFailed, resp.Status)
+	}
+	var decision decisionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decision); err != nil {
+		return false, fmt.Errorf("%w: %s", ErrDecisionFailed, err)
+	}
+	if decision.Result == nil {
+		return false, nil
+	}
+	var allowed bool
+	if err := json.Unmarshal(*decision.Result, &allowed); err != nil {
+		return false, fmt.Errorf("%w: %s", ErrInvalidDecision, *decision.Result)
+	}
+	return allowed, nil
+}
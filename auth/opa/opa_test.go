@@ -0,0 +1,147 @@
+package opa_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/treeverse/lakefs/auth"
+	"github.com/treeverse/lakefs/auth/model"
+	"github.com/treeverse/lakefs/auth/opa"
+	"github.com/treeverse/lakefs/auth/params"
+	"github.com/treeverse/lakefs/permissions"
+)
+
+type fakeService struct {
+	auth.Service
+	groups map[string][]string
+}
+
+func (s *fakeService) GetUser(username string) (*model.User, error) {
+	return &model.User{Username: username}, nil
+}
+
+func (s *fakeService) ListUserGroups(username string, _ *model.PaginationParams) ([]*model.Group, *model.Paginator, error) {
+	var groups []*model.Group
+	for _, name := range s.groups[username] {
+		groups = append(groups, &model.Group{DisplayName: name})
+	}
+	return groups, &model.Paginator{Amount: len(groups)}, nil
+}
+
+// serveOPA serves a rule allowing data-eng members to read objects under public/ of any
+// repository, and allowing deniable requests except on branch main
+func serveOPA(t *testing.T) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var decision struct {
+			Input auth.AccessRequest `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&decision); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		in := decision.Input
+		if in.Action == "fs:Undefined" {
+			_, _ = w.Write([]byte(`{}`))
+			return
+		}
+		var allowed bool
+		if in.Deniable {
+			allowed = in.Ref != "main"
+		} else {
+			for _, group := range in.Groups {
+				if group == "data-eng" && in.Action == permissions.ReadObjectAction && in.Repository != "" && strings.HasPrefix(in.Path, "public/") {
+					allowed = true
+				}
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]bool{"result": allowed})
+	}))
+	t.Cleanup(server.Close)
+	return server.URL + "/v1/data/lakefs/allow"
+}
+
+func TestAuthorizerService_Authorize(t *testing.T) {
+	service := auth.NewAuthorizerService(
+		&fakeService{groups: map[string][]string{"alice": {"analysts", "data-eng"}}},
+		opa.NewAuthorizer(params.OPA{URL: serveOPA(t)}),
+	)
+	tests := []struct {
+		name     string
+		username string
+		required permissions.Permission
+		deniable []permissions.Permission
+		allowed  bool
+	}{
+		{
+			name:     "allowed",
+			username: "alice",
+			required: permissions.Permission{Action: permissions.ReadObjectAction, Resource: permissions.ObjectArn("repo", "public/a")},
+			allowed:  true,
+		},
+		{
+			name:     "other path",
+			username: "alice",
+			required: permissions.Permission{Action: permissions.ReadObjectAction, Resource: permissions.ObjectArn("repo", "private/a")},
+		},
+		{
+			name:     "not a member",
+			username: "bob",
+			required: permissions.Permission{Action: permissions.ReadObjectAction, Resource: permissions.ObjectArn("repo", "public/a")},
+		},
+		{
+			name:     "deniable allowed",
+			username: "alice",
+			required: permissions.Permission{Action: permissions.ReadObjectAction, Resource: permissions.ObjectArn("repo", "public/a")},
+			deniable: []permissions.Permission{{Action: permissions.WriteOutsideSandboxAction, Resource: permissions.BranchArn("repo", "dev")}},
+			allowed:  true,
+		},
+		{
+			name:     "deniable denied",
+			username: "alice",
+			required: permissions.Permission{Action: permissions.ReadObjectAction, Resource: permissions.ObjectArn("repo", "public/a")},
+			deniable: []permissions.Permission{{Action: permissions.WriteOutsideSandboxAction, Resource: permissions.BranchArn("repo", "main")}},
+		},
+		{
+			name:     "undefined",
+			username: "alice",
+			required: permissions.Permission{Action: "fs:Undefined", Resource: permissions.RepoArn("repo")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := service.Authorize(&auth.AuthorizationRequest{
+				Username:            tt.username,
+				RequiredPermissions: []permissions.Permission{tt.required},
+				DeniablePermissions: tt.deniable,
+			})
+			if err != nil {
+				t.Fatalf("Authorize() error = %v", err)
+			}
+			if resp.Allowed != tt.allowed {
+				t.Errorf("Authorize() allowed %t, expected %t", resp.Allowed, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestAuthorizer_Failure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"result": {"allow": true}}`))
+	}))
+	defer server.Close()
+	authorizer := opa.NewAuthorizer(params.OPA{URL: server.URL})
+	_, err := authorizer.Authorize(&auth.AccessRequest{Principal: "alice", Action: permissions.ReadRepositoryAction})
+	if !errors.Is(err, opa.ErrInvalidDecision) {
+		t.Errorf("Authorize() err=%v, expected %s", err, opa.ErrInvalidDecision)
+	}
+	server.Close()
+	_, err = authorizer.Authorize(&auth.AccessRequest{Principal: "alice", Action: permissions.ReadRepositoryAction})
+	if !errors.Is(err, opa.ErrDecisionFailed) {
+		t.Errorf("Authorize() err=%v, expected %s", err, opa.ErrDecisionFailed)
+	}
+}
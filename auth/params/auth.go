@@ -8,3 +8,33 @@ type ServiceCache struct {
 	TTL            time.Duration
 	EvictionJitter time.Duration
 }
+
+type LDAP struct {
+	// URL of the directory server, ldap://host[:port] or ldaps://host[:port]
+	URL string
+	// BindDN and BindPassword authenticate the service account searching the directory
+	BindDN       string
+	BindPassword string
+	// UserBaseDN is the subtree holding the users
+	UserBaseDN string
+	// UserFilter finds the entry of a user, "%s" is replaced by the escaped username
+	UserFilter string
+	// GroupBaseDN is the subtree holding the groups
+	GroupBaseDN string
+	// GroupFilter finds the groups of a user, "%s" is replaced by the escaped user DN
+	GroupFilter string
+	// GroupNameAttribute holds the group name returned by ListGroups
+	GroupNameAttribute string
+	// PoolSize is the number of idle connections kept open
+	PoolSize int
+	// Timeout bounds connecting and every request
+	Timeout time.Duration
+}
+
+type OPA struct {
+	// URL of the OPA rule deciding access requests, such as
+	// http://localhost:8181/v1/data/lakefs/allow
+	URL string
+	// Timeout bounds every decision request
+	Timeout time.Duration
+}
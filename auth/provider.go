@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/treeverse/lakefs/auth/model"
+	"github.com/treeverse/lakefs/db"
+)
+
+// AuthProvider authenticates users against an external identity store, such as an LDAP
+// directory
+type AuthProvider interface {
+	// Authenticate verifies password of username, returns ErrInvalidCredentials if it does not
+	// match
+	Authenticate(ctx context.Context, username, password string) error
+	// ListGroups returns the names of the provider groups of username
+	ListGroups(ctx context.Context, username string) ([]string, error)
+}
+
+// DefaultExternalAuthSource is the source of users mirrored by an ExternalAuth with no Source
+const DefaultExternalAuthSource = "external"
+
+// ExternalAuth mirrors the users of an AuthProvider as lakeFS users.  The policies attached
+// directly to mirrored users are managed by the provider groups: they are replaced on every login
+// by the policies mapped from the groups of the user.  Users managed by lakeFS, or mirrored from
+// another source, may not log in through the provider.
+type ExternalAuth struct {
+	Provider AuthProvider
+	// Source names the provider on the users it mirrors
+	Source string
+	// GroupPolicies maps provider group names to the names of the policies of their members
+	GroupPolicies map[string][]string
+}
+
+// Login authenticates username with the provider, creating the lakeFS user on its first login
+// and synchronizing its policies.  It returns unexpired credentials of the user whose network
+// policy permits sourceIP, creating them when the user has no unexpired credentials.  Credentials
+// are not created to get around the network policies of existing ones: ErrAddressNotPermitted is
+// returned when none permits sourceIP.
+func (e *ExternalAuth) Login(ctx context.Context, authService Service, username, password string, sourceIP net.IP) (*model.Credential, error) {
+	if err := e.Provider.Authenticate(ctx, username, password); err != nil {
+		return nil, err
+	}
+	groups, err := e.Provider.ListGroups(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("list groups of %s: %w", username, err)
+	}
+
+	user, err := authService.GetUser(username)
+	if errors.Is(err, db.ErrNotFound) {
+		user = &model.User{CreatedAt: time.Now(), Username: username, Source: e.source()}
+		err = authService.CreateUser(user)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("user %s: %w", username, err)
+	}
+	// the provider may not take over users it did not create, nor replace their policies
+	if user.Source != e.source() {
+		return nil, fmt.Errorf("user %s: %w", username, ErrNotExternalUser)
+	}
+	if err := e.syncPolicies(authService, username, groups); err != nil {
+		return nil, fmt.Errorf("policies of %s: %w", username, err)
+	}
+
+	creds, _, err := authService.ListUserCredentials(username, &model.PaginationParams{Amount: -1})
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	denied := false
+	for _, c := range creds {
+		if c.Expired(now) {
+			continue
+		}
+		if c.NetworkPolicy.Permits(sourceIP) {
+			return c, nil
+		}
+		denied = true
+	}
+	if denied {
+		return nil, fmt.Errorf("user %s: %w", username, ErrAddressNotPermitted)
+	}
+	return authService.CreateCredentials(username)
+}
+
+func (e *ExternalAuth) source() string {
+	if e.Source == "" {
+		return DefaultExternalAuthSource
+	}
+	return e.Source
+}
+
+// syncPolicies attaches to username the policies mapped from groups, detaching any other policy
+func (e *ExternalAuth) syncPolicies(authService Service, username string, groups []string) error {
+	wanted := make(map[string]struct{})
+	for _, group := range groups {
+		for _, policy := range e.GroupPolicies[group] {
+			wanted[policy] = struct{}{}
+		}
+	}
+	attached, _, err := authService.ListUserPolicies(username, &model.PaginationParams{Amount: -1})
+	if err != nil {
+		return err
+	}
+	for _, policy := range attached {
+		if _, ok := wanted[policy.DisplayName]; ok {
+			delete(wanted, policy.DisplayName)
+			continue
+		}
+		if err := authService.DetachPolicyFromUser(policy.DisplayName, username); err != nil {
+			return err
+		}
+	}
+	for policy := range wanted {
+		if err := authService.AttachPolicyToUser(policy, username); err != nil {
+			return err
+		}
+	}
+	return nil
+}
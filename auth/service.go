@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
@@ -21,6 +22,8 @@ import (
 type AuthorizationRequest struct {
 	Username            string
 	RequiredPermissions []permissions.Permission
+	// DeniablePermissions need not be allowed, but deny the request when a policy denies them
+	DeniablePermissions []permissions.Permission
 }
 
 type AuthorizationResponse struct {
@@ -28,6 +31,22 @@ type AuthorizationResponse struct {
 	Error   error
 }
 
+// PolicyMatch is a policy statement applying to a permission of a request
+type PolicyMatch struct {
+	Policy     string
+	Statement  model.Statement
+	Permission permissions.Permission
+}
+
+// Simulation explains an authorization decision
+type Simulation struct {
+	Allowed bool
+	Reason  string
+	// Matches are the statements applying to the permissions of the request, the decision is
+	// not necessarily based on policies
+	Matches []*PolicyMatch
+}
+
 type Service interface {
 	SecretStore() crypt.SecretStore
 
@@ -38,6 +57,12 @@ type Service interface {
 	GetUser(username string) (*model.User, error)
 	ListUsers(params *model.PaginationParams) ([]*model.User, *model.Paginator, error)
 
+	// tenants
+	CreateTenant(tenant *model.Tenant) error
+	DeleteTenant(tenantDisplayName string) error
+	GetTenant(tenantDisplayName string) (*model.Tenant, error)
+	ListTenants(params *model.PaginationParams) ([]*model.Tenant, *model.Paginator, error)
+
 	// groups
 	CreateGroup(group *model.Group) error
 	DeleteGroup(groupDisplayName string) error
@@ -58,7 +83,11 @@ type Service interface {
 
 	// credentials
 	CreateCredentials(username string) (*model.Credential, error)
+	CreateCredentialsWithExpiry(username string, expiresAt *time.Time) (*model.Credential, error)
 	AddCredentials(username, accessKeyID, secretAccessKey string) (*model.Credential, error)
+	RotateCredentials(username, accessKeyID string, gracePeriod time.Duration, expiresAt *time.Time) (*model.Credential, error)
+	MarkCredentialsUsed(accessKeyID string) error
+	SetCredentialsNetworkPolicy(username, accessKeyID string, policy model.NetworkPolicy) (*model.Credential, error)
 	DeleteCredentials(username, accessKeyID string) error
 	GetCredentialsForUser(username, accessKeyID string) (*model.Credential, error)
 	GetCredentials(accessKeyID string) (*model.Credential, error)
@@ -77,6 +106,8 @@ type Service interface {
 
 	// authorize user for an action
 	Authorize(req *AuthorizationRequest) (*AuthorizationResponse, error)
+	// Simulate explains the decision of Authorize on req
+	Simulate(req *AuthorizationRequest) (*Simulation, error)
 }
 
 var psql = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
@@ -169,10 +200,16 @@ func genAccessSecretKey() string {
 	return Base64StringGenerator(secretKeyLength)
 }
 
+// LastUsedGranularity is the precision of the last used time of credentials, bounding the
+// updates of a busy access key
+const LastUsedGranularity = time.Minute
+
 type DBAuthService struct {
 	db          db.Database
 	secretStore crypt.SecretStore
 	cache       Cache
+	// lastUsed holds the last used time recorded for each access key by this instance
+	lastUsed sync.Map
 }
 
 func NewDBAuthService(db db.Database, secretStore crypt.SecretStore, cacheConf params.ServiceCache) *DBAuthService {
@@ -219,7 +256,11 @@ func (s *DBAuthService) CreateUser(user *model.User) error {
 		if err := model.ValidateAuthEntityID(user.Username); err != nil {
 			return nil, err
 		}
-		err := tx.Get(user, `INSERT INTO auth_users (display_name, created_at) VALUES ($1, $2) RETURNING id`, user.Username, user.CreatedAt)
+		if err := checkTenantUser(tx, user); err != nil {
+			return nil, err
+		}
+		err := tx.Get(user, `INSERT INTO auth_users (display_name, created_at, tenant, source) VALUES ($1, $2, $3, $4) RETURNING id`,
+			user.Username, user.CreatedAt, user.Tenant, user.Source)
 		return nil, err
 	})
 	return err
@@ -640,12 +681,22 @@ func (s *DBAuthService) ListPolicies(params *model.PaginationParams) ([]*model.P
 }
 
 func (s *DBAuthService) CreateCredentials(username string) (*model.Credential, error) {
+	return s.CreateCredentialsWithExpiry(username, nil)
+}
+
+// CreateCredentialsWithExpiry creates credentials that can no longer be used from expiresAt, nil
+// for credentials that never expire
+func (s *DBAuthService) CreateCredentialsWithExpiry(username string, expiresAt *time.Time) (*model.Credential, error) {
 	accessKeyID := genAccessKeyID()
 	secretAccessKey := genAccessSecretKey()
-	return s.AddCredentials(username, accessKeyID, secretAccessKey)
+	return s.addCredentials(username, accessKeyID, secretAccessKey, expiresAt)
 }
 
 func (s *DBAuthService) AddCredentials(username, accessKeyID, secretAccessKey string) (*model.Credential, error) {
+	return s.addCredentials(username, accessKeyID, secretAccessKey, nil)
+}
+
+func (s *DBAuthService) addCredentials(username, accessKeyID, secretAccessKey string, expiresAt *time.Time) (*model.Credential, error) {
 	now := time.Now()
 	encryptedKey, err := s.encryptSecret(secretAccessKey)
 	if err != nil {
@@ -662,14 +713,16 @@ func (s *DBAuthService) AddCredentials(username, accessKeyID, secretAccessKey st
 			AccessSecretKeyEncryptedBytes: encryptedKey,
 			IssuedDate:                    now,
 			UserID:                        user.ID,
+			ExpiresAt:                     expiresAt,
 		}
 		_, err = tx.Exec(`
-			INSERT INTO auth_credentials (access_key_id, access_secret_key, issued_date, user_id)
-			VALUES ($1, $2, $3, $4)`,
+			INSERT INTO auth_credentials (access_key_id, access_secret_key, issued_date, user_id, expires_at)
+			VALUES ($1, $2, $3, $4, $5)`,
 			c.AccessKeyID,
 			encryptedKey,
 			c.IssuedDate,
 			c.UserID,
+			c.ExpiresAt,
 		)
 		return c, err
 	})
@@ -679,6 +732,83 @@ func (s *DBAuthService) AddCredentials(username, accessKeyID, secretAccessKey st
 	return credentials.(*model.Credential), err
 }
 
+// RotateCredentials replaces the secret of accessKeyID with a new secret.  The replaced secret
+// remains valid for gracePeriod, so that clients can switch to the new secret.  The rotated
+// credentials expire at expiresAt, nil for never.  Servers may accept the new secret only once
+// their cached credentials expire (auth.cache.ttl).
+func (s *DBAuthService) RotateCredentials(username, accessKeyID string, gracePeriod time.Duration, expiresAt *time.Time) (*model.Credential, error) {
+	secretAccessKey := genAccessSecretKey()
+	encryptedKey, err := s.encryptSecret(secretAccessKey)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	previousExpiresAt := now.Add(gracePeriod)
+	credentials, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		credentials, err := getCredentialsForUser(tx, username, accessKeyID)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(`
+			UPDATE auth_credentials
+			SET previous_access_secret_key = access_secret_key, previous_secret_expires_at = $2,
+				access_secret_key = $3, issued_date = $4, expires_at = $5
+			WHERE access_key_id = $1`,
+			accessKeyID, previousExpiresAt, encryptedKey, now, expiresAt)
+		if err != nil {
+			return nil, err
+		}
+		credentials.PreviousAccessSecretKeyEncryptedBytes = credentials.AccessSecretKeyEncryptedBytes
+		credentials.PreviousSecretExpiresAt = &previousExpiresAt
+		credentials.AccessSecretKey = secretAccessKey
+		credentials.AccessSecretKeyEncryptedBytes = encryptedKey
+		credentials.IssuedDate = now
+		credentials.ExpiresAt = expiresAt
+		return credentials, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return credentials.(*model.Credential), nil
+}
+
+// MarkCredentialsUsed records that accessKeyID was used now.  Updates closer than
+// LastUsedGranularity to the previous update by this instance are skipped.
+func (s *DBAuthService) MarkCredentialsUsed(accessKeyID string) error {
+	now := time.Now()
+	if last, ok := s.lastUsed.Load(accessKeyID); ok && now.Sub(last.(time.Time)) < LastUsedGranularity {
+		return nil
+	}
+	s.lastUsed.Store(accessKeyID, now)
+	_, err := s.db.Exec(`UPDATE auth_credentials SET last_used_at = $2 WHERE access_key_id = $1`, accessKeyID, now)
+	return err
+}
+
+// SetCredentialsNetworkPolicy replaces the network policy of accessKeyID.  Servers enforce the
+// new policy once their cached credentials expire (auth.cache.ttl).
+func (s *DBAuthService) SetCredentialsNetworkPolicy(username, accessKeyID string, policy model.NetworkPolicy) (*model.Credential, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", model.ErrValidationError, err)
+	}
+	credentials, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		credentials, err := getCredentialsForUser(tx, username, accessKeyID)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(`UPDATE auth_credentials SET network_policy = $2 WHERE access_key_id = $1`,
+			accessKeyID, policy)
+		if err != nil {
+			return nil, err
+		}
+		credentials.NetworkPolicy = policy
+		return credentials, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return credentials.(*model.Credential), nil
+}
+
 func (s *DBAuthService) DeleteCredentials(username, accessKeyID string) error {
 	_, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
 		return nil, deleteOrNotFound(tx, `
@@ -732,22 +862,26 @@ func (s *DBAuthService) DetachPolicyFromGroup(policyDisplayName, groupDisplayNam
 	return err
 }
 
+func getCredentialsForUser(tx db.Tx, username, accessKeyID string) (*model.Credential, error) {
+	if _, err := getUser(tx, username); err != nil {
+		return nil, err
+	}
+	credentials := &model.Credential{}
+	err := tx.Get(credentials, `
+		SELECT auth_credentials.*
+		FROM auth_credentials
+		INNER JOIN auth_users ON (auth_credentials.user_id = auth_users.id)
+		WHERE auth_credentials.access_key_id = $1
+			AND auth_users.display_name = $2`, accessKeyID, username)
+	if err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
 func (s *DBAuthService) GetCredentialsForUser(username, accessKeyID string) (*model.Credential, error) {
 	credentials, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
-		if _, err := getUser(tx, username); err != nil {
-			return nil, err
-		}
-		credentials := &model.Credential{}
-		err := tx.Get(credentials, `
-			SELECT auth_credentials.*
-			FROM auth_credentials
-			INNER JOIN auth_users ON (auth_credentials.user_id = auth_users.id)
-			WHERE auth_credentials.access_key_id = $1
-				AND auth_users.display_name = $2`, accessKeyID, username)
-		if err != nil {
-			return nil, err
-		}
-		return credentials, nil
+		return getCredentialsForUser(tx, username, accessKeyID)
 	})
 	if err != nil {
 		return nil, err
@@ -755,8 +889,10 @@ func (s *DBAuthService) GetCredentialsForUser(username, accessKeyID string) (*mo
 	return credentials.(*model.Credential), nil
 }
 
+// GetCredentials returns the credentials of accessKeyID with their secrets, or
+// ErrCredentialsExpired once they expired
 func (s *DBAuthService) GetCredentials(accessKeyID string) (*model.Credential, error) {
-	return s.cache.GetCredential(accessKeyID, func() (*model.Credential, error) {
+	credentials, err := s.cache.GetCredential(accessKeyID, func() (*model.Credential, error) {
 		credentials, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
 			credentials := &model.Credential{}
 			err := tx.Get(credentials, `
@@ -769,6 +905,13 @@ func (s *DBAuthService) GetCredentials(accessKeyID string) (*model.Credential, e
 				return nil, err
 			}
 			credentials.AccessSecretKey = key
+			if credentials.PreviousAccessSecretKeyEncryptedBytes != nil {
+				previousKey, err := s.decryptSecret(credentials.PreviousAccessSecretKeyEncryptedBytes)
+				if err != nil {
+					return nil, err
+				}
+				credentials.PreviousAccessSecretKey = previousKey
+			}
 			return credentials, nil
 		})
 		if err != nil {
@@ -776,13 +919,53 @@ func (s *DBAuthService) GetCredentials(accessKeyID string) (*model.Credential, e
 		}
 		return credentials.(*model.Credential), nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	if credentials.Expired(time.Now()) {
+		return nil, fmt.Errorf("%s: %w", accessKeyID, ErrCredentialsExpired)
+	}
+	return credentials, nil
 }
 
 func interpolateUser(resource string, username string) string {
 	return strings.ReplaceAll(resource, "${user}", username)
 }
 
+// anyDenied returns true if a statement of policies denies one of perms to username
+func anyDenied(policies []*model.Policy, username string, perms []permissions.Permission) bool {
+	for _, perm := range perms {
+		for _, policy := range policies {
+			for _, stmt := range policy.Statement {
+				if stmt.Effect != model.StatementEffectDeny || !ArnMatch(interpolateUser(stmt.Resource, username), perm.Resource) {
+					continue
+				}
+				for _, action := range stmt.Action {
+					if wildcard.Match(action, perm.Action) {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
 func (s *DBAuthService) Authorize(req *AuthorizationRequest) (*AuthorizationResponse, error) {
+	user, err := s.GetUser(req.Username)
+	if err != nil {
+		return nil, err
+	}
+	// users of a tenant are limited to its resources, whatever their policies allow
+	for _, perm := range req.RequiredPermissions {
+		if !inTenantPermission(user.Tenant, perm) {
+			return &AuthorizationResponse{
+				Allowed: false,
+				Error:   ErrInsufficientPermissions,
+			}, nil
+		}
+	}
+
 	policies, _, err := s.ListEffectivePolicies(req.Username, &model.PaginationParams{
 		After:  "", // all
 		Amount: -1, // all
@@ -818,7 +1001,7 @@ func (s *DBAuthService) Authorize(req *AuthorizationRequest) (*AuthorizationResp
 		}
 	}
 
-	if !allowed {
+	if !allowed || anyDenied(policies, req.Username, req.DeniablePermissions) {
 		return &AuthorizationResponse{
 			Allowed: false,
 			Error:   ErrInsufficientPermissions,
@@ -828,3 +1011,75 @@ func (s *DBAuthService) Authorize(req *AuthorizationRequest) (*AuthorizationResp
 	// we're allowed!
 	return &AuthorizationResponse{Allowed: true}, nil
 }
+
+// Simulate evaluates req as Authorize does, returning the statements applying to each of its
+// permissions.  A statement denying a permission denies the request, otherwise the request is
+// allowed if a statement allows one of its required permissions.
+func (s *DBAuthService) Simulate(req *AuthorizationRequest) (*Simulation, error) {
+	user, err := s.GetUser(req.Username)
+	if err != nil {
+		return nil, err
+	}
+	for _, perm := range req.RequiredPermissions {
+		if !inTenantPermission(user.Tenant, perm) {
+			return &Simulation{
+				Reason: fmt.Sprintf("%s is not a resource of tenant %s", perm.Resource, user.Tenant),
+			}, nil
+		}
+	}
+	policies, _, err := s.ListEffectivePolicies(req.Username, &model.PaginationParams{
+		After:  "", // all
+		Amount: -1, // all
+	})
+	if err != nil {
+		return nil, err
+	}
+	sim := &Simulation{}
+	var allowed, denied bool
+	match := func(perm permissions.Permission, deniable bool) {
+		for _, policy := range policies {
+			for _, stmt := range policy.Statement {
+				if !ArnMatch(interpolateUser(stmt.Resource, req.Username), perm.Resource) || !actionMatch(stmt.Action, perm.Action) {
+					continue
+				}
+				sim.Matches = append(sim.Matches, &PolicyMatch{
+					Policy:     policy.DisplayName,
+					Statement:  stmt,
+					Permission: perm,
+				})
+				switch {
+				case stmt.Effect == model.StatementEffectDeny:
+					denied = true
+				case !deniable:
+					allowed = true
+				}
+			}
+		}
+	}
+	for _, perm := range req.RequiredPermissions {
+		match(perm, false)
+	}
+	for _, perm := range req.DeniablePermissions {
+		match(perm, true)
+	}
+	switch {
+	case denied:
+		sim.Reason = "denied by a policy"
+	case !allowed:
+		sim.Reason = "no policy allows the request"
+	default:
+		sim.Allowed = true
+		sim.Reason = "allowed by a policy"
+	}
+	return sim, nil
+}
+
+// actionMatch returns true if one of the patterns of a statement matches action
+func actionMatch(patterns []string, action string) bool {
+	for _, pattern := range patterns {
+		if wildcard.Match(pattern, action) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,8 +1,10 @@
 package auth_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"reflect"
 	"sort"
@@ -470,6 +472,66 @@ func TestDBAuthService_Authorize(t *testing.T) {
 	}
 }
 
+func TestDBAuthService_Simulate(t *testing.T) {
+	s := setupService(t)
+	uid := userWithPolicies(t, s, []*model.Policy{
+		{
+			Statement: model.Statements{
+				{Effect: model.StatementEffectAllow, Action: []string{"fs:*"}, Resource: "*"},
+				{Effect: model.StatementEffectDeny, Action: []string{permissions.DeleteObjectAction}, Resource: permissions.ObjectArn("repo", "gold/*")},
+			},
+		},
+	})
+
+	cases := []struct {
+		name            string
+		permission      permissions.Permission
+		expectedAllowed bool
+		expectedMatches int
+	}{
+		{
+			name:            "allowed",
+			permission:      permissions.Permission{Action: permissions.DeleteObjectAction, Resource: permissions.ObjectArn("repo", "silver/a")},
+			expectedAllowed: true,
+			expectedMatches: 1,
+		},
+		{
+			name:            "denied",
+			permission:      permissions.Permission{Action: permissions.DeleteObjectAction, Resource: permissions.ObjectArn("repo", "gold/a")},
+			expectedAllowed: false,
+			expectedMatches: 2,
+		},
+		{
+			name:            "not allowed",
+			permission:      permissions.Permission{Action: permissions.ReadUserAction, Resource: permissions.UserArn("foobar")},
+			expectedAllowed: false,
+			expectedMatches: 0,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := &auth.AuthorizationRequest{
+				Username:            uid,
+				RequiredPermissions: []permissions.Permission{tc.permission},
+			}
+			sim, err := s.Simulate(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sim.Allowed != tc.expectedAllowed || len(sim.Matches) != tc.expectedMatches {
+				t.Fatalf("expected allowed %v with %d matches, got %+v", tc.expectedAllowed, tc.expectedMatches, sim)
+			}
+			response, err := s.Authorize(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if response.Allowed != sim.Allowed {
+				t.Errorf("Authorize allowed %v, Simulate allowed %v", response.Allowed, sim.Allowed)
+			}
+		})
+	}
+}
+
 func TestDBAuthService_ListUsers(t *testing.T) {
 	cases := []struct {
 		name      string
@@ -691,3 +753,354 @@ func benchmarkListEffectivePolicies(b *testing.B, s *auth.DBAuthService, userNam
 		}
 	}
 }
+
+func TestDBAuthService_Tenant(t *testing.T) {
+	s := setupService(t)
+	ts := time.Now()
+	creds, err := auth.SetupTenant(s, &model.Tenant{CreatedAt: ts, DisplayName: "acme", MaxUsers: 2}, "acme-admin")
+	if err != nil {
+		t.Fatal("SetupTenant:", err)
+	}
+	if creds.AccessKeyID == "" {
+		t.Fatal("SetupTenant returned no credentials")
+	}
+
+	if err := s.CreateUser(&model.User{CreatedAt: ts, Username: "jane", Tenant: "acme"}); !errors.Is(err, auth.ErrNotInTenant) {
+		t.Fatalf("CreateUser without tenant prefix: expected %v, got %v", auth.ErrNotInTenant, err)
+	}
+	if err := s.CreateUser(&model.User{CreatedAt: ts, Username: "acme-jane", Tenant: "acme"}); err != nil {
+		t.Fatal("CreateUser:", err)
+	}
+	if err := s.CreateUser(&model.User{CreatedAt: ts, Username: "acme-joe", Tenant: "acme"}); !errors.Is(err, auth.ErrTenantQuotaExceeded) {
+		t.Fatalf("CreateUser over quota: expected %v, got %v", auth.ErrTenantQuotaExceeded, err)
+	}
+	if err := s.CreateUser(&model.User{CreatedAt: ts, Username: "acme-joe"}); !errors.Is(err, auth.ErrNotInTenant) {
+		t.Fatalf("CreateUser outside tenant with tenant prefix: expected %v, got %v", auth.ErrNotInTenant, err)
+	}
+	if err := auth.CheckTenantName(s, "", "acme-repo"); !errors.Is(err, auth.ErrNotInTenant) {
+		t.Fatalf("CheckTenantName outside tenant with tenant prefix: expected %v, got %v", auth.ErrNotInTenant, err)
+	}
+	if err := auth.CheckTenantName(s, "", "other-repo"); err != nil {
+		t.Fatal("CheckTenantName outside tenant:", err)
+	}
+	if err := s.CreateUser(&model.User{CreatedAt: ts, Username: "other-joe"}); err != nil {
+		t.Fatal("CreateUser:", err)
+	}
+	if err := s.CreateTenant(&model.Tenant{CreatedAt: ts, DisplayName: "other"}); !errors.Is(err, auth.ErrTenantNamesInUse) {
+		t.Fatalf("CreateTenant with names in use: expected %v, got %v", auth.ErrTenantNamesInUse, err)
+	}
+
+	authorizeCases := []struct {
+		action   string
+		resource string
+		allowed  bool
+	}{
+		{action: permissions.ReadRepositoryAction, resource: permissions.RepoArn("acme-repo"), allowed: true},
+		{action: permissions.ReadRepositoryAction, resource: permissions.RepoArn("other-repo"), allowed: false},
+		{action: permissions.ReadUserAction, resource: permissions.UserArn("acme-jane"), allowed: true},
+		{action: permissions.ReadUserAction, resource: permissions.UserArn("jane"), allowed: false},
+		{action: permissions.ListRepositoriesAction, resource: permissions.All, allowed: true},
+		{action: permissions.WriteSettingsAction, resource: permissions.All, allowed: false},
+		{action: permissions.ReloadConfigAction, resource: permissions.All, allowed: false},
+	}
+	// a policy allowing everything, attached to the tenant admin, still grants nothing outside
+	// the tenant
+	err = s.WritePolicy(&model.Policy{
+		CreatedAt:   ts,
+		DisplayName: "acme-Everything",
+		Statement: model.Statements{
+			{Action: []string{"*"}, Resource: permissions.All, Effect: model.StatementEffectAllow},
+		},
+	})
+	if err != nil {
+		t.Fatal("WritePolicy:", err)
+	}
+	if err := s.AttachPolicyToUser("acme-Everything", "acme-admin"); err != nil {
+		t.Fatal("AttachPolicyToUser:", err)
+	}
+	for _, tc := range authorizeCases {
+		response, err := s.Authorize(&auth.AuthorizationRequest{
+			Username:            "acme-admin",
+			RequiredPermissions: []permissions.Permission{{Action: tc.action, Resource: tc.resource}},
+		})
+		if err != nil {
+			t.Fatalf("Authorize %s: %s", tc.resource, err)
+		}
+		if response.Allowed != tc.allowed {
+			t.Errorf("Authorize %s: expected allowed %v, got %v", tc.resource, tc.allowed, response.Allowed)
+		}
+	}
+
+	if err := s.DeleteTenant("acme"); err != nil {
+		t.Fatal("DeleteTenant:", err)
+	}
+	if _, err := s.GetUser("acme-admin"); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("GetUser after DeleteTenant: expected %v, got %v", db.ErrNotFound, err)
+	}
+	if _, err := s.GetGroup(auth.TenantAdminsGroup("acme")); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("GetGroup after DeleteTenant: expected %v, got %v", db.ErrNotFound, err)
+	}
+}
+
+type fakeAuthProvider struct {
+	passwords map[string]string
+	groups    map[string][]string
+}
+
+func (p *fakeAuthProvider) Authenticate(_ context.Context, username, password string) error {
+	if pw, ok := p.passwords[username]; !ok || pw != password {
+		return auth.ErrInvalidCredentials
+	}
+	return nil
+}
+
+func (p *fakeAuthProvider) ListGroups(_ context.Context, username string) ([]string, error) {
+	return p.groups[username], nil
+}
+
+func TestExternalAuth_Login(t *testing.T) {
+	s := setupService(t)
+	ctx := context.Background()
+	for _, name := range []string{"Readers", "Writers"} {
+		if err := s.WritePolicy(&model.Policy{CreatedAt: time.Now(), DisplayName: name, Statement: model.Statements{
+			{Action: []string{permissions.ReadRepositoryAction}, Resource: permissions.All, Effect: model.StatementEffectAllow},
+		}}); err != nil {
+			t.Fatal("WritePolicy:", err)
+		}
+	}
+	provider := &fakeAuthProvider{
+		passwords: map[string]string{"jane": "secret"},
+		groups:    map[string][]string{"jane": {"data"}},
+	}
+	externalAuth := &auth.ExternalAuth{
+		Provider:      provider,
+		GroupPolicies: map[string][]string{"data": {"Readers"}, "eng": {"Writers"}},
+	}
+
+	if _, err := externalAuth.Login(ctx, s, "jane", "wrong", nil); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Fatalf("Login with wrong password: expected %v, got %v", auth.ErrInvalidCredentials, err)
+	}
+	creds, err := externalAuth.Login(ctx, s, "jane", "secret", nil)
+	if err != nil {
+		t.Fatal("Login:", err)
+	}
+	checkPolicies := func(expected []string) {
+		t.Helper()
+		policies, _, err := s.ListUserPolicies("jane", &model.PaginationParams{Amount: -1})
+		if err != nil {
+			t.Fatal("ListUserPolicies:", err)
+		}
+		names := make([]string, len(policies))
+		for i, p := range policies {
+			names[i] = p.DisplayName
+		}
+		if diff := deep.Equal(names, expected); diff != nil {
+			t.Error("user policies:", diff)
+		}
+	}
+	checkPolicies([]string{"Readers"})
+
+	// groups changed in the provider
+	provider.groups["jane"] = []string{"eng"}
+	again, err := externalAuth.Login(ctx, s, "jane", "secret", nil)
+	if err != nil {
+		t.Fatal("second Login:", err)
+	}
+	if again.AccessKeyID != creds.AccessKeyID {
+		t.Errorf("second Login returned access key %s, expected %s", again.AccessKeyID, creds.AccessKeyID)
+	}
+	checkPolicies([]string{"Writers"})
+	user, err := s.GetUser("jane")
+	if err != nil {
+		t.Fatal("GetUser:", err)
+	}
+	if user.Source != auth.DefaultExternalAuthSource {
+		t.Errorf("mirrored user source %q, expected %q", user.Source, auth.DefaultExternalAuthSource)
+	}
+}
+
+func TestExternalAuth_LoginCredentials(t *testing.T) {
+	s := setupService(t)
+	ctx := context.Background()
+	externalAuth := &auth.ExternalAuth{
+		Provider: &fakeAuthProvider{passwords: map[string]string{"jane": "secret"}},
+	}
+	creds, err := externalAuth.Login(ctx, s, "jane", "secret", nil)
+	if err != nil {
+		t.Fatal("Login:", err)
+	}
+
+	// expired credentials are not returned, fresh credentials are created instead
+	if err := s.DeleteCredentials("jane", creds.AccessKeyID); err != nil {
+		t.Fatal("DeleteCredentials:", err)
+	}
+	expiresAt := time.Now().Add(-time.Hour)
+	expired, err := s.CreateCredentialsWithExpiry("jane", &expiresAt)
+	if err != nil {
+		t.Fatal("CreateCredentialsWithExpiry:", err)
+	}
+	fresh, err := externalAuth.Login(ctx, s, "jane", "secret", nil)
+	if err != nil {
+		t.Fatal("Login with expired credentials:", err)
+	}
+	if fresh.AccessKeyID == expired.AccessKeyID || fresh.Expired(time.Now()) {
+		t.Fatalf("Login returned credentials %s expiring at %v, expected fresh credentials", fresh.AccessKeyID, fresh.ExpiresAt)
+	}
+
+	// credentials whose network policy denies the source are not returned, nor replaced
+	if _, err := s.SetCredentialsNetworkPolicy("jane", fresh.AccessKeyID, model.NetworkPolicy{Allow: []string{"10.0.0.0/8"}}); err != nil {
+		t.Fatal("SetCredentialsNetworkPolicy:", err)
+	}
+	permitted, err := externalAuth.Login(ctx, s, "jane", "secret", net.ParseIP("10.1.2.3"))
+	if err != nil {
+		t.Fatal("Login from permitted address:", err)
+	}
+	if permitted.AccessKeyID != fresh.AccessKeyID {
+		t.Errorf("Login from permitted address returned access key %s, expected %s", permitted.AccessKeyID, fresh.AccessKeyID)
+	}
+	if _, err := externalAuth.Login(ctx, s, "jane", "secret", net.ParseIP("192.168.1.1")); !errors.Is(err, auth.ErrAddressNotPermitted) {
+		t.Fatalf("Login from denied address: expected %v, got %v", auth.ErrAddressNotPermitted, err)
+	}
+	creds2, _, err := s.ListUserCredentials("jane", &model.PaginationParams{Amount: -1})
+	if err != nil {
+		t.Fatal("ListUserCredentials:", err)
+	}
+	if len(creds2) != 2 {
+		t.Errorf("user has %d credentials, expected the expired and the restricted credentials", len(creds2))
+	}
+}
+
+func TestExternalAuth_LoginLocalUser(t *testing.T) {
+	s := setupService(t)
+	ctx := context.Background()
+	if err := s.CreateUser(&model.User{CreatedAt: time.Now(), Username: "admin"}); err != nil {
+		t.Fatal("CreateUser:", err)
+	}
+	if err := s.WritePolicy(&model.Policy{CreatedAt: time.Now(), DisplayName: "Admin", Statement: model.Statements{
+		{Action: []string{"*"}, Resource: permissions.All, Effect: model.StatementEffectAllow},
+	}}); err != nil {
+		t.Fatal("WritePolicy:", err)
+	}
+	if err := s.AttachPolicyToUser("Admin", "admin"); err != nil {
+		t.Fatal("AttachPolicyToUser:", err)
+	}
+	externalAuth := &auth.ExternalAuth{
+		Provider: &fakeAuthProvider{
+			passwords: map[string]string{"admin": "secret"},
+			groups:    map[string][]string{"admin": {"data"}},
+		},
+		GroupPolicies: map[string][]string{"data": {"Admin"}},
+	}
+
+	// the local user is neither taken over nor stripped of its policies
+	if _, err := externalAuth.Login(ctx, s, "admin", "secret", nil); !errors.Is(err, auth.ErrNotExternalUser) {
+		t.Fatalf("Login as local user: expected %v, got %v", auth.ErrNotExternalUser, err)
+	}
+	policies, _, err := s.ListUserPolicies("admin", &model.PaginationParams{Amount: -1})
+	if err != nil {
+		t.Fatal("ListUserPolicies:", err)
+	}
+	if len(policies) != 1 || policies[0].DisplayName != "Admin" {
+		t.Errorf("local user policies %v, expected [Admin]", policies)
+	}
+	creds, _, err := s.ListUserCredentials("admin", &model.PaginationParams{Amount: -1})
+	if err != nil {
+		t.Fatal("ListUserCredentials:", err)
+	}
+	if len(creds) != 0 {
+		t.Errorf("Login as local user created %d credentials", len(creds))
+	}
+}
+
+func TestDBAuthService_RotateCredentials(t *testing.T) {
+	s := setupService(t)
+	userName := userWithPolicies(t, s, nil)
+	creds, err := s.CreateCredentials(userName)
+	if err != nil {
+		t.Fatal("CreateCredentials:", err)
+	}
+	rotated, err := s.RotateCredentials(userName, creds.AccessKeyID, time.Hour, nil)
+	if err != nil {
+		t.Fatal("RotateCredentials:", err)
+	}
+	if rotated.AccessKeyID != creds.AccessKeyID || rotated.AccessSecretKey == creds.AccessSecretKey {
+		t.Fatalf("RotateCredentials returned %s with the same secret", rotated.AccessKeyID)
+	}
+	got, err := s.GetCredentials(creds.AccessKeyID)
+	if err != nil {
+		t.Fatal("GetCredentials:", err)
+	}
+	now := time.Now()
+	if !got.SecretMatches(rotated.AccessSecretKey, now) {
+		t.Error("new secret does not match")
+	}
+	if !got.SecretMatches(creds.AccessSecretKey, now) {
+		t.Error("replaced secret does not match within the grace period")
+	}
+	if got.SecretMatches(creds.AccessSecretKey, now.Add(2*time.Hour)) {
+		t.Error("replaced secret matches after the grace period")
+	}
+
+	if _, err := s.RotateCredentials(userName, "AKIANOSUCHKEY", time.Hour, nil); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("RotateCredentials of missing key: expected %v, got %v", db.ErrNotFound, err)
+	}
+}
+
+func TestDBAuthService_CredentialsExpiry(t *testing.T) {
+	s := setupService(t)
+	userName := userWithPolicies(t, s, nil)
+	past := time.Now().Add(-time.Minute)
+	expired, err := s.CreateCredentialsWithExpiry(userName, &past)
+	if err != nil {
+		t.Fatal("CreateCredentialsWithExpiry:", err)
+	}
+	if _, err := s.GetCredentials(expired.AccessKeyID); !errors.Is(err, auth.ErrCredentialsExpired) {
+		t.Errorf("GetCredentials of expired credentials: expected %v, got %v", auth.ErrCredentialsExpired, err)
+	}
+
+	future := time.Now().Add(time.Hour)
+	valid, err := s.CreateCredentialsWithExpiry(userName, &future)
+	if err != nil {
+		t.Fatal("CreateCredentialsWithExpiry:", err)
+	}
+	if err := s.MarkCredentialsUsed(valid.AccessKeyID); err != nil {
+		t.Fatal("MarkCredentialsUsed:", err)
+	}
+	got, err := s.GetCredentialsForUser(userName, valid.AccessKeyID)
+	if err != nil {
+		t.Fatal("GetCredentialsForUser:", err)
+	}
+	if got.LastUsedAt == nil {
+		t.Error("expected last used time after MarkCredentialsUsed")
+	}
+	if got.ExpiresAt == nil || !got.ExpiresAt.Equal(future.Truncate(time.Microsecond)) {
+		t.Errorf("expected expiry %s, got %v", future, got.ExpiresAt)
+	}
+}
+
+func TestDBAuthService_SetCredentialsNetworkPolicy(t *testing.T) {
+	s := setupService(t)
+	userName := userWithPolicies(t, s, nil)
+	creds, err := s.CreateCredentials(userName)
+	if err != nil {
+		t.Fatal("CreateCredentials:", err)
+	}
+	if _, err := s.SetCredentialsNetworkPolicy(userName, creds.AccessKeyID, model.NetworkPolicy{Allow: []string{"10.0.0.0/33"}}); !errors.Is(err, model.ErrValidationError) {
+		t.Errorf("SetCredentialsNetworkPolicy with invalid CIDR: expected %v, got %v", model.ErrValidationError, err)
+	}
+	if _, err := s.SetCredentialsNetworkPolicy(userName, "NOSUCHKEY", model.NetworkPolicy{}); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("SetCredentialsNetworkPolicy of missing key: expected %v, got %v", db.ErrNotFound, err)
+	}
+
+	policy := model.NetworkPolicy{Allow: []string{"10.0.0.0/8"}, Deny: []string{"10.66.0.0/16"}}
+	if _, err := s.SetCredentialsNetworkPolicy(userName, creds.AccessKeyID, policy); err != nil {
+		t.Fatal("SetCredentialsNetworkPolicy:", err)
+	}
+	got, err := s.GetCredentials(creds.AccessKeyID)
+	if err != nil {
+		t.Fatal("GetCredentials:", err)
+	}
+	if diff := deep.Equal(got.NetworkPolicy, policy); diff != nil {
+		t.Error("network policy:", diff)
+	}
+}
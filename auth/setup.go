@@ -47,6 +47,7 @@ func SetupBaseGroups(authService Service, ts time.Time) error {
 		{CreatedAt: ts, DisplayName: "SuperUsers"},
 		{CreatedAt: ts, DisplayName: "Developers"},
 		{CreatedAt: ts, DisplayName: "Viewers"},
+		{CreatedAt: ts, DisplayName: "Sandboxed"},
 	})
 	if err != nil {
 		return err
@@ -109,6 +110,32 @@ func SetupBaseGroups(authService Service, ts time.Time) error {
 				},
 			},
 		},
+		{
+			CreatedAt:   ts,
+			DisplayName: "FSReadAllWriteSandbox",
+			Statement: model.Statements{
+				{
+					Action: []string{
+						"fs:List*",
+						"fs:Read*",
+						permissions.CreateSandboxAction,
+						permissions.WriteObjectAction,
+						permissions.DeleteObjectAction,
+						permissions.CreateCommitAction,
+						permissions.RevertBranchAction,
+					},
+					Resource: permissions.All,
+					Effect:   model.StatementEffectAllow,
+				},
+				{
+					Action: []string{
+						permissions.WriteOutsideSandboxAction,
+					},
+					Resource: permissions.All,
+					Effect:   model.StatementEffectDeny,
+				},
+			},
+		},
 		{
 			CreatedAt:   ts,
 			DisplayName: "RepoManagementFullAccess",
@@ -198,6 +225,10 @@ func SetupBaseGroups(authService Service, ts time.Time) error {
 	if err != nil {
 		return err
 	}
+	err = attachPolicies(authService, "Sandboxed", []string{"FSReadAllWriteSandbox", "AuthManageOwnCredentials"})
+	if err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -0,0 +1,333 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/treeverse/lakefs/auth/model"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/permissions"
+)
+
+// TenantSeparator separates the tenant prefix of the names of tenant repositories, users,
+// groups and policies from the rest of the name
+const TenantSeparator = "-"
+
+// TenantPrefix returns the prefix of the names of the repositories, users, groups and policies
+// of tenant
+func TenantPrefix(tenant string) string {
+	return tenant + TenantSeparator
+}
+
+// InTenant returns true if name, of a repository, user, group or policy, belongs to tenant.
+// Every name belongs to the installation, the empty tenant.
+func InTenant(tenant, name string) bool {
+	return tenant == "" || strings.HasPrefix(name, TenantPrefix(tenant))
+}
+
+// NameTenant returns the tenant whose prefix starts name, or "" if name has no tenant prefix.
+// Tenant names have no separator, so it is the part of name before the first separator.  name
+// belongs to that tenant only if the tenant exists.
+func NameTenant(name string) string {
+	i := strings.Index(name, TenantSeparator)
+	if i <= 0 || model.ValidateTenantID(name[:i]) != nil {
+		return ""
+	}
+	return name[:i]
+}
+
+// checkInstallationName verifies that name, created outside any tenant, does not start with the
+// prefix of an existing tenant: users of that tenant would see and administer it.
+func checkInstallationName(tx db.Tx, name string) error {
+	nameTenant := NameTenant(name)
+	if nameTenant == "" {
+		return nil
+	}
+	var exists bool
+	if err := tx.Get(&exists, `SELECT EXISTS (SELECT 1 FROM auth_tenants WHERE display_name = $1)`, nameTenant); err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("%s prefixed by tenant %s: %w", name, nameTenant, ErrNotInTenant)
+	}
+	return nil
+}
+
+// CheckTenantName verifies that a user of tenant may create a repository, group or policy named
+// name.  Users of a tenant create names of their tenant, users of the installation may not create
+// names of an existing tenant.
+func CheckTenantName(authService Service, tenant, name string) error {
+	if tenant != "" {
+		if !InTenant(tenant, name) {
+			return fmt.Errorf("%s: %w", name, ErrNotInTenant)
+		}
+		return nil
+	}
+	nameTenant := NameTenant(name)
+	if nameTenant == "" {
+		return nil
+	}
+	_, err := authService.GetTenant(nameTenant)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%s prefixed by tenant %s: %w", name, nameTenant, ErrNotInTenant)
+}
+
+// tenantListActions are the actions users of a tenant may perform on the "*" resource:
+// listings are filtered by tenant
+var tenantListActions = []string{
+	permissions.ListRepositoriesAction,
+	permissions.ListUsersAction,
+	permissions.ListGroupsAction,
+	permissions.ListPoliciesAction,
+}
+
+func isTenantListAction(action string) bool {
+	for _, listAction := range tenantListActions {
+		if action == listAction {
+			return true
+		}
+	}
+	return false
+}
+
+// inTenantResource returns true if the resource ARN is within tenant.  Resources of the form
+// "service/name/..." are within the tenant when name is.
+func inTenantResource(tenant, resource string) bool {
+	if tenant == "" {
+		return true
+	}
+	arn, err := ParseARN(resource)
+	if err != nil {
+		return false
+	}
+	parts := strings.SplitN(arn.ResourceID, "/", 3) //nolint:gomnd
+	return len(parts) >= 2 && InTenant(tenant, parts[1])
+}
+
+// inTenantPermission returns true if users of tenant may be granted perm: its resource is
+// within tenant, or it lists the "*" resource
+func inTenantPermission(tenant string, perm permissions.Permission) bool {
+	if tenant != "" && perm.Resource == permissions.All {
+		return isTenantListAction(perm.Action)
+	}
+	return inTenantResource(tenant, perm.Resource)
+}
+
+// ValidateTenantStatements verifies statements allow only actions on resources of tenant, so
+// users of tenant cannot grant themselves permissions outside it.  Statements may allow only
+// listings on the "*" resource.  Deny statements are not limited.
+func ValidateTenantStatements(tenant string, statements model.Statements) error {
+	if tenant == "" {
+		return nil
+	}
+	for _, stmt := range statements {
+		if stmt.Effect == model.StatementEffectDeny {
+			continue
+		}
+		if stmt.Resource != permissions.All {
+			if !inTenantResource(tenant, stmt.Resource) {
+				return fmt.Errorf("resource %s: %w", stmt.Resource, ErrNotInTenant)
+			}
+			continue
+		}
+		for _, action := range stmt.Action {
+			if !isTenantListAction(action) {
+				return fmt.Errorf("action %s on resource %s: %w", action, stmt.Resource, ErrNotInTenant)
+			}
+		}
+	}
+	return nil
+}
+
+// TenantAdminsGroup returns the name of the group administering tenant
+func TenantAdminsGroup(tenant string) string {
+	return TenantPrefix(tenant) + "Admins"
+}
+
+// TenantAdminPolicy returns the name of the policy allowing all actions on the resources of
+// tenant
+func TenantAdminPolicy(tenant string) string {
+	return TenantPrefix(tenant) + "TenantAdmin"
+}
+
+func (s *DBAuthService) CreateTenant(tenant *model.Tenant) error {
+	_, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		if err := model.ValidateTenantID(tenant.DisplayName); err != nil {
+			return nil, err
+		}
+		// the tenant would take over the users, groups and policies already named by its prefix
+		var inUse bool
+		err := tx.Get(&inUse, `SELECT EXISTS (SELECT 1 FROM auth_users WHERE display_name LIKE $1)
+			OR EXISTS (SELECT 1 FROM auth_groups WHERE display_name LIKE $1)
+			OR EXISTS (SELECT 1 FROM auth_policies WHERE display_name LIKE $1)`,
+			TenantPrefix(tenant.DisplayName)+"%")
+		if err != nil {
+			return nil, err
+		}
+		if inUse {
+			return nil, fmt.Errorf("tenant %s: %w", tenant.DisplayName, ErrTenantNamesInUse)
+		}
+		return nil, tx.Get(tenant, `INSERT INTO auth_tenants (display_name, created_at, max_repositories, max_users)
+			VALUES ($1, $2, $3, $4) RETURNING id`,
+			tenant.DisplayName, tenant.CreatedAt, tenant.MaxRepositories, tenant.MaxUsers)
+	})
+	return err
+}
+
+func getTenant(tx db.Tx, tenantDisplayName string) (*model.Tenant, error) {
+	tenant := &model.Tenant{}
+	err := tx.Get(tenant, `SELECT * FROM auth_tenants WHERE display_name = $1`, tenantDisplayName)
+	if err != nil {
+		return nil, err
+	}
+	return tenant, nil
+}
+
+func (s *DBAuthService) GetTenant(tenantDisplayName string) (*model.Tenant, error) {
+	tenant, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		return getTenant(tx, tenantDisplayName)
+	}, db.ReadOnly())
+	if err != nil {
+		return nil, err
+	}
+	return tenant.(*model.Tenant), nil
+}
+
+func (s *DBAuthService) ListTenants(params *model.PaginationParams) ([]*model.Tenant, *model.Paginator, error) {
+	var tenant model.Tenant
+	slice, paginator, err := ListPaged(s.db, reflect.TypeOf(tenant), params, "display_name",
+		psql.Select("*").From("auth_tenants"))
+	if err != nil {
+		return nil, paginator, err
+	}
+	return slice.Interface().([]*model.Tenant), paginator, nil
+}
+
+// DeleteTenant deletes tenant with its users, groups and policies.  Repositories of the tenant
+// are not deleted.
+func (s *DBAuthService) DeleteTenant(tenantDisplayName string) error {
+	_, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		if err := deleteOrNotFound(tx, `DELETE FROM auth_tenants WHERE display_name = $1`, tenantDisplayName); err != nil {
+			return nil, err
+		}
+		prefix := TenantPrefix(tenantDisplayName) + "%"
+		if _, err := tx.Exec(`DELETE FROM auth_users WHERE tenant = $1`, tenantDisplayName); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`DELETE FROM auth_groups WHERE display_name LIKE $1`, prefix); err != nil {
+			return nil, err
+		}
+		_, err := tx.Exec(`DELETE FROM auth_policies WHERE display_name LIKE $1`, prefix)
+		return nil, err
+	})
+	return err
+}
+
+// checkTenantUser verifies user may be added to its tenant
+func checkTenantUser(tx db.Tx, user *model.User) error {
+	if user.Tenant == "" {
+		return checkInstallationName(tx, user.Username)
+	}
+	tenant, err := getTenant(tx, user.Tenant)
+	if err != nil {
+		return fmt.Errorf("tenant %s: %w", user.Tenant, err)
+	}
+	if !InTenant(tenant.DisplayName, user.Username) {
+		return fmt.Errorf("user %s: %w", user.Username, ErrNotInTenant)
+	}
+	if tenant.MaxUsers == 0 {
+		return nil
+	}
+	var users int
+	if err := tx.Get(&users, `SELECT COUNT(*) FROM auth_users WHERE tenant = $1`, tenant.DisplayName); err != nil {
+		return err
+	}
+	if users >= tenant.MaxUsers {
+		return fmt.Errorf("%d users: %w", tenant.MaxUsers, ErrTenantQuotaExceeded)
+	}
+	return nil
+}
+
+// SetupTenantAdmins creates the admins group of tenant, with a policy allowing all actions on the
+// resources of the tenant
+func SetupTenantAdmins(authService Service, tenant string, ts time.Time) error {
+	prefix := TenantPrefix(tenant)
+	err := createGroups(authService, []*model.Group{
+		{CreatedAt: ts, DisplayName: TenantAdminsGroup(tenant)},
+	})
+	if err != nil {
+		return err
+	}
+	err = createPolicies(authService, []*model.Policy{
+		{
+			CreatedAt:   ts,
+			DisplayName: TenantAdminPolicy(tenant),
+			Statement: model.Statements{
+				{
+					Action:   []string{"fs:*"},
+					Resource: permissions.RepoArn(prefix + "*"),
+					Effect:   model.StatementEffectAllow,
+				},
+				{
+					Action:   []string{"auth:*"},
+					Resource: permissions.UserArn(prefix + "*"),
+					Effect:   model.StatementEffectAllow,
+				},
+				{
+					Action:   []string{"auth:*"},
+					Resource: permissions.GroupArn(prefix + "*"),
+					Effect:   model.StatementEffectAllow,
+				},
+				{
+					Action:   []string{"auth:*"},
+					Resource: permissions.PolicyArn(prefix + "*"),
+					Effect:   model.StatementEffectAllow,
+				},
+				{
+					Action:   tenantListActions,
+					Resource: permissions.All,
+					Effect:   model.StatementEffectAllow,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return attachPolicies(authService, TenantAdminsGroup(tenant), []string{TenantAdminPolicy(tenant)})
+}
+
+// SetupTenant creates tenant with its admins group, and an admin user of the tenant with new
+// credentials
+func SetupTenant(authService Service, tenant *model.Tenant, adminUsername string) (*model.Credential, error) {
+	if err := authService.CreateTenant(tenant); err != nil {
+		return nil, fmt.Errorf("create tenant - %w", err)
+	}
+	if err := SetupTenantAdmins(authService, tenant.DisplayName, tenant.CreatedAt); err != nil {
+		return nil, fmt.Errorf("tenant admins - %w", err)
+	}
+	err := authService.CreateUser(&model.User{
+		CreatedAt: tenant.CreatedAt,
+		Username:  adminUsername,
+		Tenant:    tenant.DisplayName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create user - %w", err)
+	}
+	if err := authService.AddUserToGroup(adminUsername, TenantAdminsGroup(tenant.DisplayName)); err != nil {
+		return nil, fmt.Errorf("add user to group - %w", err)
+	}
+	creds, err := authService.CreateCredentials(adminUsername)
+	if err != nil {
+		return nil, fmt.Errorf("create credentials for %s: %w", adminUsername, err)
+	}
+	return creds, nil
+}
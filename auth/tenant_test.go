@@ -0,0 +1,106 @@
+package auth_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/treeverse/lakefs/auth"
+	"github.com/treeverse/lakefs/auth/model"
+	"github.com/treeverse/lakefs/permissions"
+)
+
+func TestInTenant(t *testing.T) {
+	cases := []struct {
+		Tenant string
+		Name   string
+		In     bool
+	}{
+		{Tenant: "", Name: "repo", In: true},
+		{Tenant: "acme", Name: "acme-repo", In: true},
+		{Tenant: "acme", Name: "acmerepo", In: false},
+		{Tenant: "acme", Name: "repo", In: false},
+		{Tenant: "acme", Name: "acme2-repo", In: false},
+	}
+	for _, c := range cases {
+		if got := auth.InTenant(c.Tenant, c.Name); got != c.In {
+			t.Errorf("InTenant(%s, %s) = %v, expected %v", c.Tenant, c.Name, got, c.In)
+		}
+	}
+}
+
+func TestNameTenant(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Tenant string
+	}{
+		{Name: "repo", Tenant: ""},
+		{Name: "acme-repo", Tenant: "acme"},
+		{Name: "acme-my-repo", Tenant: "acme"},
+		{Name: "-repo", Tenant: ""},
+		{Name: "Acme-repo", Tenant: ""},
+	}
+	for _, c := range cases {
+		if got := auth.NameTenant(c.Name); got != c.Tenant {
+			t.Errorf("NameTenant(%s) = %s, expected %s", c.Name, got, c.Tenant)
+		}
+	}
+}
+
+func TestValidateTenantStatements(t *testing.T) {
+	cases := []struct {
+		Name      string
+		Statement model.Statement
+		Valid     bool
+	}{
+		{
+			Name:      "tenant_repositories",
+			Statement: model.Statement{Action: []string{"fs:*"}, Resource: permissions.RepoArn("acme-*"), Effect: model.StatementEffectAllow},
+			Valid:     true,
+		},
+		{
+			Name:      "list_all",
+			Statement: model.Statement{Action: []string{permissions.ListRepositoriesAction}, Resource: permissions.All, Effect: model.StatementEffectAllow},
+			Valid:     true,
+		},
+		{
+			Name:      "deny_all",
+			Statement: model.Statement{Action: []string{"*"}, Resource: permissions.All, Effect: model.StatementEffectDeny},
+			Valid:     true,
+		},
+		{
+			Name:      "all_actions_on_all",
+			Statement: model.Statement{Action: []string{"*"}, Resource: permissions.All, Effect: model.StatementEffectAllow},
+			Valid:     false,
+		},
+		{
+			Name:      "write_settings",
+			Statement: model.Statement{Action: []string{permissions.WriteSettingsAction}, Resource: permissions.All, Effect: model.StatementEffectAllow},
+			Valid:     false,
+		},
+		{
+			Name:      "all_repositories",
+			Statement: model.Statement{Action: []string{"fs:*"}, Resource: permissions.RepoArn("*"), Effect: model.StatementEffectAllow},
+			Valid:     false,
+		},
+		{
+			Name:      "other_tenant_user",
+			Statement: model.Statement{Action: []string{"auth:*"}, Resource: permissions.UserArn("other-admin"), Effect: model.StatementEffectAllow},
+			Valid:     false,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			err := auth.ValidateTenantStatements("acme", model.Statements{c.Statement})
+			if c.Valid && err != nil {
+				t.Errorf("ValidateTenantStatements: %s, expected valid", err)
+			}
+			if !c.Valid && !errors.Is(err, auth.ErrNotInTenant) {
+				t.Errorf("ValidateTenantStatements: %v, expected %s", err, auth.ErrNotInTenant)
+			}
+		})
+	}
+	stmts := model.Statements{{Action: []string{"*"}, Resource: permissions.All, Effect: model.StatementEffectAllow}}
+	if err := auth.ValidateTenantStatements("", stmts); err != nil {
+		t.Errorf("ValidateTenantStatements of the installation: %s, expected valid", err)
+	}
+}
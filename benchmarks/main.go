@@ -221,7 +221,7 @@ func reader(ctx context.Context, ch chan string, repoName, _ string) int {
 
 			if err := retry.Do(func() error {
 				var b bytes.Buffer
-				_, err := client.Objects.GetObject(
+				_, _, err := client.Objects.GetObject(
 					objects.NewGetObjectParamsWithContext(ctx).
 						WithRepository(repoName).
 						WithRef(branchName).
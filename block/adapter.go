@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/aws/aws-sdk-go/service/s3"
 )
@@ -85,6 +86,17 @@ type Adapter interface {
 	BlockstoreType() string
 }
 
+// Presigner is implemented by adapters that can create URLs granting temporary access to read
+// objects directly from the object store.
+type Presigner interface {
+	// GetPresignedRangeURL returns a URL reading the bytes of obj between startPosition and
+	// endPosition, inclusive, valid for expiry.  Requests using the URL must send the header
+	// "Range: bytes=<startPosition>-<endPosition>".
+	GetPresignedRangeURL(obj ObjectPointer, startPosition, endPosition int64, expiry time.Duration) (string, error)
+	// GetPresignedURL returns a URL reading all of obj, valid for expiry
+	GetPresignedURL(obj ObjectPointer, expiry time.Duration) (string, error)
+}
+
 type UploadIDTranslator interface {
 	SetUploadID(uploadID string) string
 	TranslateUploadID(simulationID string) string
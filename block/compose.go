@@ -0,0 +1,91 @@
+package block
+
+import (
+	"encoding/hex"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// MinComposePartSize is the smallest size of the objects composed on the object store, all but
+// the last of them: S3 requires every part of a multipart upload but the last to hold at least
+// 5MiB.  Smaller objects are composed by copying their data through lakeFS.
+const MinComposePartSize = 5 * 1024 * 1024
+
+// MaxComposePartSize is the largest part copied by Compose, larger objects are copied as
+// several parts of equal size
+const MaxComposePartSize = 5 * 1024 * 1024 * 1024
+
+// ComposeSource is an object concatenated by Compose
+type ComposeSource struct {
+	Obj  ObjectPointer
+	Size int64
+}
+
+// Compose writes to destinationObj the concatenation of sources and returns its checksum.  The
+// sources are copied as parts of a multipart upload, so their data stays on the object store,
+// unless one of them is too small to be a part.
+func Compose(adapter Adapter, destinationObj ObjectPointer, sources []ComposeSource) (string, error) {
+	for i, source := range sources {
+		if i < len(sources)-1 && source.Size < MinComposePartSize {
+			return composeCopy(adapter, destinationObj, sources)
+		}
+	}
+	uploadID, err := adapter.CreateMultiPartUpload(destinationObj, nil, CreateMultiPartUploadOpts{})
+	if err != nil {
+		return "", err
+	}
+	completion := &MultipartUploadCompletion{}
+	for _, source := range sources {
+		parts := (source.Size + MaxComposePartSize - 1) / MaxComposePartSize
+		for i := int64(0); i < parts; i++ {
+			partNumber := int64(len(completion.Part) + 1)
+			var etag string
+			if parts == 1 {
+				etag, err = adapter.UploadCopyPart(source.Obj, destinationObj, uploadID, partNumber)
+			} else {
+				start, end := i*source.Size/parts, (i+1)*source.Size/parts-1
+				etag, err = adapter.UploadCopyPartRange(source.Obj, destinationObj, uploadID, partNumber, start, end)
+			}
+			if err != nil {
+				_ = adapter.AbortMultiPartUpload(destinationObj, uploadID)
+				return "", err
+			}
+			completion.Part = append(completion.Part, &s3.CompletedPart{
+				ETag:       aws.String(etag),
+				PartNumber: aws.Int64(partNumber),
+			})
+		}
+	}
+	etag, _, err := adapter.CompleteMultiPartUpload(destinationObj, uploadID, completion)
+	if err != nil {
+		return "", err
+	}
+	// the checksum of multipart uploads is their ETag without the parts count
+	checksum := strings.Trim(aws.StringValue(etag), "\"")
+	return strings.Split(checksum, "-")[0], nil
+}
+
+// composeCopy writes to destinationObj the data of sources read one after the other
+func composeCopy(adapter Adapter, destinationObj ObjectPointer, sources []ComposeSource) (string, error) {
+	readers := make([]io.Reader, 0, len(sources))
+	var size int64
+	for _, source := range sources {
+		reader, err := adapter.Get(source.Obj, source.Size)
+		if err != nil {
+			return "", err
+		}
+		defer func() {
+			_ = reader.Close()
+		}()
+		readers = append(readers, reader)
+		size += source.Size
+	}
+	hashReader := NewHashingReader(io.MultiReader(readers...), HashFunctionMD5)
+	if err := adapter.Put(destinationObj, size, hashReader, PutOpts{}); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hashReader.Md5.Sum(nil)), nil
+}
@@ -0,0 +1,55 @@
+package block_test
+
+import (
+	"bytes"
+	"crypto/md5" //nolint:gosec
+	"encoding/hex"
+	"io/ioutil"
+	"testing"
+
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/block/mem"
+	"github.com/treeverse/lakefs/testutil"
+)
+
+func TestCompose(t *testing.T) {
+	cases := []struct {
+		name      string
+		firstSize int
+		copied    bool
+	}{
+		{name: "small", firstSize: 100, copied: true},
+		{name: "large", firstSize: block.MinComposePartSize, copied: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			adapter := mem.New()
+			first := block.ObjectPointer{StorageNamespace: "mem://compose", Identifier: "first"}
+			second := block.ObjectPointer{StorageNamespace: "mem://compose", Identifier: "second"}
+			destination := block.ObjectPointer{StorageNamespace: "mem://compose", Identifier: "destination"}
+			firstData := bytes.Repeat([]byte("a"), tc.firstSize)
+			secondData := []byte("appended data")
+			testutil.MustDo(t, "Put first", adapter.Put(first, int64(len(firstData)), bytes.NewReader(firstData), block.PutOpts{}))
+			testutil.MustDo(t, "Put second", adapter.Put(second, int64(len(secondData)), bytes.NewReader(secondData), block.PutOpts{}))
+
+			checksum, err := block.Compose(adapter, destination, []block.ComposeSource{
+				{Obj: first, Size: int64(len(firstData))},
+				{Obj: second, Size: int64(len(secondData))},
+			})
+			testutil.MustDo(t, "Compose", err)
+
+			reader, err := adapter.Get(destination, 0)
+			testutil.MustDo(t, "Get destination", err)
+			data, err := ioutil.ReadAll(reader)
+			testutil.MustDo(t, "read destination", err)
+			expected := append(firstData, secondData...)
+			if !bytes.Equal(data, expected) {
+				t.Fatalf("got %d bytes composed, expected %d bytes", len(data), len(expected))
+			}
+			sum := md5.Sum(expected) //nolint:gosec
+			if copied := checksum == hex.EncodeToString(sum[:]); copied != tc.copied {
+				t.Errorf("got checksum %s, expected data copied: %t", checksum, tc.copied)
+			}
+		})
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/treeverse/lakefs/block/params"
 	s3a "github.com/treeverse/lakefs/block/s3"
 	"github.com/treeverse/lakefs/block/transient"
+	"github.com/treeverse/lakefs/faults"
 	"github.com/treeverse/lakefs/logging"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/option"
@@ -27,6 +28,18 @@ const googleAuthCloudPlatform = "https://www.googleapis.com/auth/cloud-platform"
 var ErrInvalidBlockStoreType = errors.New("invalid blockstore type")
 
 func BuildBlockAdapter(c params.AdapterConfig) (block.Adapter, error) {
+	adapter, err := buildBlockAdapter(c)
+	if err != nil {
+		return nil, err
+	}
+	if p := c.GetBlockAdapterFaultsParams(); p.Enabled() {
+		logging.Default().WithField("faults", p).Warn("Injecting faults into the blockstore adapter")
+		return block.NewFaultyAdapter(adapter, faults.NewInjector(p)), nil
+	}
+	return adapter, nil
+}
+
+func buildBlockAdapter(c params.AdapterConfig) (block.Adapter, error) {
 	blockstore := c.GetBlockstoreType()
 	logging.Default().
 		WithField("type", blockstore).
@@ -0,0 +1,212 @@
+package block
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/treeverse/lakefs/faults"
+	"github.com/treeverse/lakefs/logging"
+)
+
+// Operations of a block adapter faulted by NewFaultyAdapter
+const (
+	FaultOpPut                     = "put"
+	FaultOpGet                     = "get"
+	FaultOpWalk                    = "walk"
+	FaultOpExists                  = "exists"
+	FaultOpGetRange                = "get_range"
+	FaultOpGetProperties           = "get_properties"
+	FaultOpRemove                  = "remove"
+	FaultOpCopy                    = "copy"
+	FaultOpCreateMultiPartUpload   = "create_multipart_upload"
+	FaultOpUploadPart              = "upload_part"
+	FaultOpUploadCopyPart          = "upload_copy_part"
+	FaultOpAbortMultiPartUpload    = "abort_multipart_upload"
+	FaultOpCompleteMultiPartUpload = "complete_multipart_upload"
+	FaultOpGenerateInventory       = "generate_inventory"
+	FaultOpListVersions            = "list_versions"
+)
+
+// faultyAdapter is an Adapter that injects faults into the operations of another Adapter
+type faultyAdapter struct {
+	adapter  Adapter
+	injector *faults.Injector
+}
+
+// faultyVersionLister injects faults into listing versions of another VersionLister
+type faultyVersionLister struct {
+	lister   VersionLister
+	injector *faults.Injector
+}
+
+func (l *faultyVersionLister) ListVersions(ctx context.Context, bucket, prefix string, walkFn func(*ObjectVersion) error) error {
+	if err := l.injector.Fault(FaultOpListVersions); err != nil {
+		return err
+	}
+	return l.lister.ListVersions(ctx, bucket, prefix, walkFn)
+}
+
+// NewFaultyAdapter returns an Adapter that injects the faults of injector into the operations of
+// adapter.  Partial writes pass adapter part of the data and then fail its read.  The returned
+// Adapter is a Presigner or a VersionLister when adapter is one; presigning is never faulted.
+func NewFaultyAdapter(adapter Adapter, injector *faults.Injector) Adapter {
+	a := &faultyAdapter{adapter: adapter, injector: injector}
+	presigner, isPresigner := adapter.(Presigner)
+	lister, isLister := adapter.(VersionLister)
+	switch {
+	case isPresigner && isLister:
+		return &struct {
+			*faultyAdapter
+			Presigner
+			*faultyVersionLister
+		}{a, presigner, &faultyVersionLister{lister: lister, injector: injector}}
+	case isPresigner:
+		return &struct {
+			*faultyAdapter
+			Presigner
+		}{a, presigner}
+	case isLister:
+		return &struct {
+			*faultyAdapter
+			*faultyVersionLister
+		}{a, &faultyVersionLister{lister: lister, injector: injector}}
+	default:
+		return a
+	}
+}
+
+func (a *faultyAdapter) WithContext(ctx context.Context) Adapter {
+	return NewFaultyAdapter(a.adapter.WithContext(ctx), a.injector)
+}
+
+func (a *faultyAdapter) write(op string, sizeBytes int64, reader io.Reader, fn func(io.Reader) error) error {
+	if err := a.injector.Fault(op); err != nil {
+		return err
+	}
+	if !a.injector.PartialWrite(op) {
+		return fn(reader)
+	}
+	if err := fn(faults.NewPartialReader(reader, sizeBytes)); err != nil {
+		return err
+	}
+	return faults.ErrInjected
+}
+
+func (a *faultyAdapter) Put(obj ObjectPointer, sizeBytes int64, reader io.Reader, opts PutOpts) error {
+	return a.write(FaultOpPut, sizeBytes, reader, func(r io.Reader) error {
+		return a.adapter.Put(obj, sizeBytes, r, opts)
+	})
+}
+
+func (a *faultyAdapter) Get(obj ObjectPointer, expectedSize int64) (io.ReadCloser, error) {
+	if err := a.injector.Fault(FaultOpGet); err != nil {
+		return nil, err
+	}
+	return a.adapter.Get(obj, expectedSize)
+}
+
+func (a *faultyAdapter) Walk(walkOpt WalkOpts, walkFn WalkFunc) error {
+	if err := a.injector.Fault(FaultOpWalk); err != nil {
+		return err
+	}
+	return a.adapter.Walk(walkOpt, walkFn)
+}
+
+func (a *faultyAdapter) Exists(obj ObjectPointer) (bool, error) {
+	if err := a.injector.Fault(FaultOpExists); err != nil {
+		return false, err
+	}
+	return a.adapter.Exists(obj)
+}
+
+func (a *faultyAdapter) GetRange(obj ObjectPointer, startPosition int64, endPosition int64) (io.ReadCloser, error) {
+	if err := a.injector.Fault(FaultOpGetRange); err != nil {
+		return nil, err
+	}
+	return a.adapter.GetRange(obj, startPosition, endPosition)
+}
+
+func (a *faultyAdapter) GetProperties(obj ObjectPointer) (Properties, error) {
+	if err := a.injector.Fault(FaultOpGetProperties); err != nil {
+		return Properties{}, err
+	}
+	return a.adapter.GetProperties(obj)
+}
+
+func (a *faultyAdapter) Remove(obj ObjectPointer) error {
+	if err := a.injector.Fault(FaultOpRemove); err != nil {
+		return err
+	}
+	return a.adapter.Remove(obj)
+}
+
+func (a *faultyAdapter) Copy(sourceObj, destinationObj ObjectPointer) error {
+	if err := a.injector.Fault(FaultOpCopy); err != nil {
+		return err
+	}
+	return a.adapter.Copy(sourceObj, destinationObj)
+}
+
+func (a *faultyAdapter) CreateMultiPartUpload(obj ObjectPointer, r *http.Request, opts CreateMultiPartUploadOpts) (string, error) {
+	if err := a.injector.Fault(FaultOpCreateMultiPartUpload); err != nil {
+		return "", err
+	}
+	return a.adapter.CreateMultiPartUpload(obj, r, opts)
+}
+
+func (a *faultyAdapter) UploadPart(obj ObjectPointer, sizeBytes int64, reader io.Reader, uploadID string, partNumber int64) (string, error) {
+	var etag string
+	err := a.write(FaultOpUploadPart, sizeBytes, reader, func(r io.Reader) error {
+		var err error
+		etag, err = a.adapter.UploadPart(obj, sizeBytes, r, uploadID, partNumber)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return etag, nil
+}
+
+func (a *faultyAdapter) UploadCopyPart(sourceObj, destinationObj ObjectPointer, uploadID string, partNumber int64) (string, error) {
+	if err := a.injector.Fault(FaultOpUploadCopyPart); err != nil {
+		return "", err
+	}
+	return a.adapter.UploadCopyPart(sourceObj, destinationObj, uploadID, partNumber)
+}
+
+func (a *faultyAdapter) UploadCopyPartRange(sourceObj, destinationObj ObjectPointer, uploadID string, partNumber, startPosition, endPosition int64) (string, error) {
+	if err := a.injector.Fault(FaultOpUploadCopyPart); err != nil {
+		return "", err
+	}
+	return a.adapter.UploadCopyPartRange(sourceObj, destinationObj, uploadID, partNumber, startPosition, endPosition)
+}
+
+func (a *faultyAdapter) AbortMultiPartUpload(obj ObjectPointer, uploadID string) error {
+	if err := a.injector.Fault(FaultOpAbortMultiPartUpload); err != nil {
+		return err
+	}
+	return a.adapter.AbortMultiPartUpload(obj, uploadID)
+}
+
+func (a *faultyAdapter) CompleteMultiPartUpload(obj ObjectPointer, uploadID string, multipartList *MultipartUploadCompletion) (*string, int64, error) {
+	if err := a.injector.Fault(FaultOpCompleteMultiPartUpload); err != nil {
+		return nil, 0, err
+	}
+	return a.adapter.CompleteMultiPartUpload(obj, uploadID, multipartList)
+}
+
+func (a *faultyAdapter) GenerateInventory(ctx context.Context, logger logging.Logger, inventoryURL string, shouldSort bool, prefixes []string) (Inventory, error) {
+	if err := a.injector.Fault(FaultOpGenerateInventory); err != nil {
+		return nil, err
+	}
+	return a.adapter.GenerateInventory(ctx, logger, inventoryURL, shouldSort, prefixes)
+}
+
+func (a *faultyAdapter) ValidateConfiguration(storageNamespace string) error {
+	return a.adapter.ValidateConfiguration(storageNamespace)
+}
+
+func (a *faultyAdapter) BlockstoreType() string {
+	return a.adapter.BlockstoreType()
+}
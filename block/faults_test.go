@@ -0,0 +1,80 @@
+package block_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/block/mem"
+	s3a "github.com/treeverse/lakefs/block/s3"
+	"github.com/treeverse/lakefs/faults"
+)
+
+func TestFaultyAdapter(t *testing.T) {
+	data := []byte("some data to write")
+	obj := block.ObjectPointer{StorageNamespace: "mem://faults", Identifier: "obj"}
+
+	t.Run("errors", func(t *testing.T) {
+		adapter := mem.New()
+		faulty := block.NewFaultyAdapter(adapter, faults.NewInjector(faults.Params{ErrorRate: 1, Operations: []string{block.FaultOpPut}}))
+		err := faulty.Put(obj, int64(len(data)), bytes.NewReader(data), block.PutOpts{})
+		if !errors.Is(err, faults.ErrInjected) {
+			t.Fatalf("Put err=%v, expected %s", err, faults.ErrInjected)
+		}
+		if exists, err := faulty.Exists(obj); err != nil || exists {
+			t.Errorf("Exists() = %t, %v after failed put, expected not to exist", exists, err)
+		}
+	})
+
+	t.Run("partial writes", func(t *testing.T) {
+		adapter := mem.New()
+		faulty := block.NewFaultyAdapter(adapter, faults.NewInjector(faults.Params{PartialWriteRate: 1}))
+		err := faulty.Put(obj, int64(len(data)), bytes.NewReader(data), block.PutOpts{})
+		if !errors.Is(err, faults.ErrInjected) {
+			t.Fatalf("Put err=%v, expected %s", err, faults.ErrInjected)
+		}
+		if exists, _ := adapter.Exists(obj); exists {
+			t.Error("partial write created the object")
+		}
+	})
+
+	t.Run("no faults", func(t *testing.T) {
+		adapter := mem.New()
+		faulty := block.NewFaultyAdapter(adapter, faults.NewInjector(faults.Params{ErrorRate: 1, Operations: []string{block.FaultOpRemove}}))
+		if err := faulty.Put(obj, int64(len(data)), bytes.NewReader(data), block.PutOpts{}); err != nil {
+			t.Fatalf("Put: %s", err)
+		}
+		reader, err := faulty.WithContext(context.Background()).Get(obj, int64(len(data)))
+		if err != nil {
+			t.Fatalf("Get: %s", err)
+		}
+		read, err := ioutil.ReadAll(reader)
+		_ = reader.Close()
+		if err != nil || !bytes.Equal(read, data) {
+			t.Errorf("Get() read %q, %v, expected %q", read, err, data)
+		}
+		if err := faulty.Remove(obj); !errors.Is(err, faults.ErrInjected) {
+			t.Errorf("Remove err=%v, expected %s", err, faults.ErrInjected)
+		}
+		if _, ok := faulty.(block.Presigner); ok {
+			t.Error("faulty mem adapter is a presigner")
+		}
+	})
+
+	t.Run("optional interfaces", func(t *testing.T) {
+		sess := session.Must(session.NewSession(&aws.Config{Region: aws.String("us-east-1")}))
+		faulty := block.NewFaultyAdapter(s3a.NewAdapter(s3.New(sess)), faults.NewInjector(faults.Params{ErrorRate: 1}))
+		if _, ok := faulty.(block.Presigner); !ok {
+			t.Error("faulty s3 adapter is not a presigner")
+		}
+		if _, ok := faulty.WithContext(context.Background()).(block.VersionLister); !ok {
+			t.Error("faulty s3 adapter is not a version lister")
+		}
+	})
+}
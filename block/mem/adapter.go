@@ -44,7 +44,10 @@ func newMPU() *mpu {
 
 func (m *mpu) get() []byte {
 	buf := bytes.NewBuffer(nil)
-	keys := make([]int64, len(m.parts))
+	keys := make([]int64, 0, len(m.parts))
+	for part := range m.parts {
+		keys = append(keys, part)
+	}
 	sort.Slice(keys, func(i, j int) bool {
 		return keys[i] < keys[j]
 	})
@@ -178,16 +181,12 @@ func (a *Adapter) UploadCopyPart(sourceObj, destinationObj block.ObjectPointer,
 	if !ok {
 		return "", ErrMultiPartNotFound
 	}
-	entry, err := a.Get(sourceObj, 0)
-	if err != nil {
-		return "", err
-	}
-	data, err := ioutil.ReadAll(entry)
-	if err != nil {
-		return "", err
+	data, ok := a.data[getKey(sourceObj)]
+	if !ok {
+		return "", ErrNoDataForKey
 	}
 	h := sha256.New()
-	_, err = h.Write(data)
+	_, err := h.Write(data)
 	if err != nil {
 		return "", err
 	}
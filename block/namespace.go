@@ -21,10 +21,16 @@ var (
 	ErrInvalidNamespace = errors.New("invalid namespace")
 )
 
+// VersionIDParam is the query parameter of a fully qualified key that selects a version of the
+// object, such as "s3://bucket/key?versionId=3HL4kqtJlcpXroDTDmJ"
+const VersionIDParam = "versionId"
+
 type QualifiedKey struct {
 	StorageType      StorageType
 	StorageNamespace string
 	Key              string
+	// VersionID is the version of the object in a versioned bucket, empty for its current version
+	VersionID string
 }
 
 type QualifiedPrefix struct {
@@ -48,7 +54,11 @@ func (qk QualifiedKey) Format() string {
 		panic("unknown storage type")
 	}
 
-	return fmt.Sprintf("%s://%s", scheme, path.Join(qk.StorageNamespace, qk.Key))
+	formatted := fmt.Sprintf("%s://%s", scheme, path.Join(qk.StorageNamespace, qk.Key))
+	if qk.VersionID != "" {
+		formatted += "?" + url.Values{VersionIDParam: {qk.VersionID}}.Encode()
+	}
+	return formatted
 }
 
 func GetStorageType(namespaceURL *url.URL) (StorageType, error) {
@@ -130,5 +140,6 @@ func ResolveNamespace(defaultNamespace, key string) (QualifiedKey, error) {
 		StorageType:      storageType,
 		StorageNamespace: parsedKey.Host,
 		Key:              formatPathWithNamespace("", parsedKey.Path),
+		VersionID:        parsedKey.Query().Get(VersionIDParam),
 	}, nil
 }
@@ -60,6 +60,18 @@ func TestResolveNamespace(t *testing.T) {
 				Key:              "bar/baz",
 			},
 		},
+		{
+			Name:             "valid_fq_key_with_version",
+			DefaultNamespace: "mem://foo/",
+			Key:              "s3://example/bar/baz?versionId=3HL4kqtJ%2Blcp",
+			ExpectedErr:      nil,
+			Expected: block.QualifiedKey{
+				StorageType:      block.StorageTypeS3,
+				StorageNamespace: "example",
+				Key:              "bar/baz",
+				VersionID:        "3HL4kqtJ+lcp",
+			},
+		},
 		{
 			Name:             "invalid_namespace_wrong_scheme",
 			DefaultNamespace: "memzzzz://foo/",
@@ -129,6 +141,16 @@ func TestFormatQualifiedKey(t *testing.T) {
 			},
 			Expected: "s3://some-bucket/prefix/path/to/file",
 		},
+		{
+			Name: "path_with_version",
+			QualifiedKey: block.QualifiedKey{
+				StorageType:      block.StorageTypeS3,
+				StorageNamespace: "some-bucket",
+				Key:              "path/to/file",
+				VersionID:        "3HL4kqtJ+lcp",
+			},
+			Expected: "s3://some-bucket/path/to/file?versionId=3HL4kqtJ%2Blcp",
+		},
 	}
 
 	for _, cas := range cases {
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/treeverse/lakefs/faults"
 )
 
 // AdapterConfig configures a block adapter.
@@ -12,6 +13,7 @@ type AdapterConfig interface {
 	GetBlockAdapterLocalParams() (Local, error)
 	GetBlockAdapterS3Params() (S3, error)
 	GetBlockAdapterGSParams() (GS, error)
+	GetBlockAdapterFaultsParams() faults.Params
 }
 
 type Mem struct{}
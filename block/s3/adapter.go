@@ -7,6 +7,7 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -56,6 +57,23 @@ func resolveNamespacePrefix(opts block.WalkOpts) (block.QualifiedPrefix, error)
 	return qualifiedPrefix, nil
 }
 
+// versionID returns the version of qualifiedKey to read, nil for the current version
+func versionID(qualifiedKey block.QualifiedKey) *string {
+	if qualifiedKey.VersionID == "" {
+		return nil
+	}
+	return aws.String(qualifiedKey.VersionID)
+}
+
+// copySource returns the source of a copy of qualifiedKey, including its version
+func copySource(qualifiedKey block.QualifiedKey) string {
+	source := qualifiedKey.StorageNamespace + "/" + qualifiedKey.Key
+	if qualifiedKey.VersionID != "" {
+		source += "?" + url.Values{block.VersionIDParam: {qualifiedKey.VersionID}}.Encode()
+	}
+	return source
+}
+
 type Adapter struct {
 	s3                    s3iface.S3API
 	httpClient            *http.Client
@@ -256,8 +274,9 @@ func (a *Adapter) Get(obj block.ObjectPointer, _ int64) (io.ReadCloser, error) {
 	}
 	log := a.log().WithField("operation", "GetObject")
 	getObjectInput := s3.GetObjectInput{
-		Bucket: aws.String(qualifiedKey.StorageNamespace),
-		Key:    aws.String(qualifiedKey.Key),
+		Bucket:    aws.String(qualifiedKey.StorageNamespace),
+		Key:       aws.String(qualifiedKey.Key),
+		VersionId: versionID(qualifiedKey),
 	}
 	objectOutput, err := a.s3.GetObject(&getObjectInput)
 	if err != nil {
@@ -277,8 +296,9 @@ func (a *Adapter) Exists(obj block.ObjectPointer) (bool, error) {
 	}
 	log := a.log().WithField("operation", "HeadObject")
 	input := s3.HeadObjectInput{
-		Bucket: aws.String(qualifiedKey.StorageNamespace),
-		Key:    aws.String(qualifiedKey.Key),
+		Bucket:    aws.String(qualifiedKey.StorageNamespace),
+		Key:       aws.String(qualifiedKey.Key),
+		VersionId: versionID(qualifiedKey),
 	}
 	_, err = a.s3.HeadObject(&input)
 	if err != nil {
@@ -304,9 +324,10 @@ func (a *Adapter) GetRange(obj block.ObjectPointer, startPosition int64, endPosi
 	}
 	log := a.log().WithField("operation", "GetObjectRange")
 	getObjectInput := s3.GetObjectInput{
-		Bucket: aws.String(qualifiedKey.StorageNamespace),
-		Key:    aws.String(qualifiedKey.Key),
-		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", startPosition, endPosition)),
+		Bucket:    aws.String(qualifiedKey.StorageNamespace),
+		Key:       aws.String(qualifiedKey.Key),
+		VersionId: versionID(qualifiedKey),
+		Range:     aws.String(fmt.Sprintf("bytes=%d-%d", startPosition, endPosition)),
 	}
 	objectOutput, err := a.s3.GetObject(&getObjectInput)
 	if err != nil {
@@ -320,6 +341,34 @@ func (a *Adapter) GetRange(obj block.ObjectPointer, startPosition int64, endPosi
 	return objectOutput.Body, nil
 }
 
+func (a *Adapter) GetPresignedRangeURL(obj block.ObjectPointer, startPosition, endPosition int64, expiry time.Duration) (string, error) {
+	return a.presignGetObject(obj, aws.String(fmt.Sprintf("bytes=%d-%d", startPosition, endPosition)), expiry, "GetPresignedRangeURL")
+}
+
+func (a *Adapter) GetPresignedURL(obj block.ObjectPointer, expiry time.Duration) (string, error) {
+	return a.presignGetObject(obj, nil, expiry, "GetPresignedURL")
+}
+
+func (a *Adapter) presignGetObject(obj block.ObjectPointer, byteRange *string, expiry time.Duration, operation string) (string, error) {
+	qualifiedKey, err := resolveNamespace(obj)
+	if err != nil {
+		return "", err
+	}
+	req, _ := a.s3.GetObjectRequest(&s3.GetObjectInput{
+		Bucket:    aws.String(qualifiedKey.StorageNamespace),
+		Key:       aws.String(qualifiedKey.Key),
+		VersionId: versionID(qualifiedKey),
+		Range:     byteRange,
+	})
+	req.SetContext(a.ctx)
+	url, err := req.Presign(expiry)
+	if err != nil {
+		a.log().WithError(err).WithField("operation", operation).Error("failed to presign S3 object")
+		return "", err
+	}
+	return url, nil
+}
+
 func (a *Adapter) Walk(walkOpt block.WalkOpts, walkFn block.WalkFunc) error {
 	log := a.log().WithField("operation", "Walk")
 	var err error
@@ -371,8 +420,9 @@ func (a *Adapter) GetProperties(obj block.ObjectPointer) (block.Properties, erro
 		return block.Properties{}, err
 	}
 	headObjectParams := &s3.HeadObjectInput{
-		Bucket: aws.String(qualifiedKey.StorageNamespace),
-		Key:    aws.String(qualifiedKey.Key),
+		Bucket:    aws.String(qualifiedKey.StorageNamespace),
+		Key:       aws.String(qualifiedKey.Key),
+		VersionId: versionID(qualifiedKey),
 	}
 	s3Props, err := a.s3.HeadObject(headObjectParams)
 	if err != nil {
@@ -420,7 +470,7 @@ func (a *Adapter) copyPart(sourceObj, destinationObj block.ObjectPointer, upload
 		Key:        aws.String(qualifiedKey.Key),
 		PartNumber: aws.Int64(partNumber),
 		UploadId:   aws.String(uploadID),
-		CopySource: aws.String(fmt.Sprintf("%s/%s", srcKey.StorageNamespace, srcKey.Key)),
+		CopySource: aws.String(copySource(srcKey)),
 	}
 	if byteRange != nil {
 		uploadPartCopyObject.CopySourceRange = byteRange
@@ -473,7 +523,7 @@ func (a *Adapter) Copy(sourceObj, destinationObj block.ObjectPointer) error {
 	copyObjectParams := &s3.CopyObjectInput{
 		Bucket:     aws.String(qualifiedDestinationKey.StorageNamespace),
 		Key:        aws.String(qualifiedDestinationKey.Key),
-		CopySource: aws.String(qualifiedSourceKey.StorageNamespace + "/" + qualifiedSourceKey.Key),
+		CopySource: aws.String(copySource(qualifiedSourceKey)),
 	}
 	_, err = a.s3.CopyObject(copyObjectParams)
 	if err != nil {
@@ -0,0 +1,67 @@
+package s3
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/treeverse/lakefs/block"
+)
+
+func (a *Adapter) ListVersions(ctx context.Context, bucket, prefix string, walkFn func(*block.ObjectVersion) error) error {
+	return ListVersions(ctx, a.s3, bucket, prefix, walkFn)
+}
+
+// ListVersions lists the versions and delete markers of the objects under prefix of bucket
+func ListVersions(ctx context.Context, svc s3iface.S3API, bucket, prefix string, walkFn func(*block.ObjectVersion) error) error {
+	input := &s3.ListObjectVersionsInput{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	}
+	var walkErr error
+	err := svc.ListObjectVersionsPagesWithContext(ctx, input, func(page *s3.ListObjectVersionsOutput, _ bool) bool {
+		for _, v := range page.Versions {
+			walkErr = walkFn(&block.ObjectVersion{
+				Key:             aws.StringValue(v.Key),
+				VersionID:       aws.StringValue(v.VersionId),
+				Size:            aws.Int64Value(v.Size),
+				LastModified:    aws.TimeValue(v.LastModified),
+				Checksum:        strings.Trim(aws.StringValue(v.ETag), "\""),
+				PhysicalAddress: versionAddress(bucket, aws.StringValue(v.Key), aws.StringValue(v.VersionId)),
+			})
+			if walkErr != nil {
+				return false
+			}
+		}
+		for _, m := range page.DeleteMarkers {
+			walkErr = walkFn(&block.ObjectVersion{
+				Key:          aws.StringValue(m.Key),
+				VersionID:    aws.StringValue(m.VersionId),
+				LastModified: aws.TimeValue(m.LastModified),
+				DeleteMarker: true,
+			})
+			if walkErr != nil {
+				return false
+			}
+		}
+		return true
+	})
+	if walkErr != nil {
+		return walkErr
+	}
+	return err
+}
+
+// versionAddress returns the physical address of a version of key in bucket
+func versionAddress(bucket, key, versionID string) string {
+	u := url.URL{
+		Scheme:   "s3",
+		Host:     bucket,
+		Path:     "/" + key,
+		RawQuery: url.Values{block.VersionIDParam: {versionID}}.Encode(),
+	}
+	return u.String()
+}
@@ -0,0 +1,71 @@
+package s3_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	s3sdk "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/block/s3"
+)
+
+type mockVersionsClient struct {
+	s3iface.S3API
+	pages []*s3sdk.ListObjectVersionsOutput
+}
+
+func (m *mockVersionsClient) ListObjectVersionsPagesWithContext(_ aws.Context, _ *s3sdk.ListObjectVersionsInput, fn func(*s3sdk.ListObjectVersionsOutput, bool) bool, _ ...request.Option) error {
+	for i, page := range m.pages {
+		if !fn(page, i == len(m.pages)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func TestListVersions(t *testing.T) {
+	modified := time.Date(2021, 2, 1, 10, 0, 0, 0, time.UTC)
+	client := &mockVersionsClient{pages: []*s3sdk.ListObjectVersionsOutput{
+		{
+			Versions: []*s3sdk.ObjectVersion{
+				{Key: aws.String("data/a b"), VersionId: aws.String("v+2"), ETag: aws.String(`"e2"`), Size: aws.Int64(2), LastModified: aws.Time(modified.Add(time.Hour))},
+				{Key: aws.String("data/a b"), VersionId: aws.String("v1"), ETag: aws.String(`"e1"`), Size: aws.Int64(1), LastModified: aws.Time(modified)},
+			},
+		},
+		{
+			DeleteMarkers: []*s3sdk.DeleteMarkerEntry{
+				{Key: aws.String("data/c"), VersionId: aws.String("d1"), LastModified: aws.Time(modified)},
+			},
+		},
+	}}
+	var versions []*block.ObjectVersion
+	err := s3.ListVersions(context.Background(), client, "bucket", "data/", func(v *block.ObjectVersion) error {
+		versions = append(versions, v)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ListVersions() error = %s", err)
+	}
+	expected := []*block.ObjectVersion{
+		{Key: "data/a b", VersionID: "v+2", Size: 2, LastModified: modified.Add(time.Hour), Checksum: "e2", PhysicalAddress: "s3://bucket/data/a%20b?versionId=v%2B2"},
+		{Key: "data/a b", VersionID: "v1", Size: 1, LastModified: modified, Checksum: "e1", PhysicalAddress: "s3://bucket/data/a%20b?versionId=v1"},
+		{Key: "data/c", VersionID: "d1", LastModified: modified, DeleteMarker: true},
+	}
+	if diff := deep.Equal(versions, expected); diff != nil {
+		t.Fatalf("ListVersions() diff %s", diff)
+	}
+
+	// the address of a version resolves to the key and the version
+	qk, err := block.ResolveNamespace("s3://other", versions[0].PhysicalAddress)
+	if err != nil {
+		t.Fatalf("ResolveNamespace() error = %s", err)
+	}
+	if diff := deep.Equal(qk, block.QualifiedKey{StorageType: block.StorageTypeS3, StorageNamespace: "bucket", Key: "data/a b", VersionID: "v+2"}); diff != nil {
+		t.Errorf("ResolveNamespace() diff %s", diff)
+	}
+}
@@ -0,0 +1,25 @@
+package block
+
+import (
+	"context"
+	"time"
+)
+
+// ObjectVersion is a version of an object in a versioned bucket, or a delete marker of the object
+type ObjectVersion struct {
+	Key          string
+	VersionID    string
+	Size         int64
+	LastModified time.Time
+	Checksum     string
+	// DeleteMarker is set for versions that delete the object
+	DeleteMarker bool
+	// PhysicalAddress is the fully qualified address of this version of the object
+	PhysicalAddress string
+}
+
+type VersionLister interface {
+	// ListVersions calls walkFn with every version and delete marker of the objects under
+	// prefix in bucket.  Versions of the same key are listed newest first.
+	ListVersions(ctx context.Context, bucket, prefix string, walkFn func(*ObjectVersion) error) error
+}
@@ -45,6 +45,11 @@ func (c *GetSetCache) GetOrSet(k interface{}, setFn SetFn) (v interface{}, err e
 	})
 }
 
+// Remove drops the value of k, the next GetOrSet of k computes it again
+func (c *GetSetCache) Remove(k interface{}) {
+	c.lru.Remove(k)
+}
+
 func NewJitterFn(jitter time.Duration) JitterFn {
 	return func() time.Duration {
 		n := rand.Intn(int(jitter)) //nolint:gosec
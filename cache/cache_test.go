@@ -82,3 +82,26 @@ func TestCacheRace(t *testing.T) {
 	close(start)
 	wg.Wait()
 }
+
+func TestCacheRemove(t *testing.T) {
+	c := cache.NewCache(10, time.Hour*12, cache.NewJitterFn(time.Millisecond))
+	numCalls := 0
+	get := func() interface{} {
+		v, err := c.GetOrSet("k", func() (interface{}, error) {
+			numCalls++
+			return numCalls, nil
+		})
+		testutil.MustDo(t, "GetOrSet", err)
+		return v
+	}
+	if v := get(); v != 1 {
+		t.Errorf("first get %v, expected 1", v)
+	}
+	if v := get(); v != 1 {
+		t.Errorf("cached get %v, expected 1", v)
+	}
+	c.Remove("k")
+	if v := get(); v != 2 {
+		t.Errorf("get after Remove %v, expected 2", v)
+	}
+}
@@ -0,0 +1,266 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/logging"
+)
+
+const (
+	// AutoCommitCommitter is the committer of commits made by RunAutoCommitter
+	AutoCommitCommitter = "lakefs"
+	// DefaultAutoCommitMessageTemplate is the message of auto-commits of policies without a
+	// message template
+	DefaultAutoCommitMessageTemplate = "Auto-commit {{.Changes}} changes to {{.Branch}}"
+)
+
+// AutoCommitPolicy commits the uncommitted changes of a branch once enough of them accumulate,
+// or once enough time passed since the last commit of the branch.  A zero limit never commits.
+type AutoCommitPolicy struct {
+	// MaxStagedEntries commits once the branch has this many uncommitted changes
+	MaxStagedEntries int `db:"max_staged_entries"`
+	// IntervalSeconds commits uncommitted changes once this many seconds passed since the
+	// last commit of the branch
+	IntervalSeconds int `db:"interval_seconds"`
+	// MessageTemplate is a text/template of the commit message, executed on
+	// AutoCommitMessageData.  DefaultAutoCommitMessageTemplate is used when empty.
+	MessageTemplate string `db:"message_template"`
+}
+
+// AutoCommitMessageData is the data of the message template of an auto-commit
+type AutoCommitMessageData struct {
+	Repository string
+	Branch     string
+	// Changes is the number of uncommitted changes committed
+	Changes   int64
+	Writes    int64
+	Deletes   int64
+	SizeBytes int64
+	// Time is the time of the commit, in UTC
+	Time time.Time
+}
+
+// BranchAutoCommitPolicy is the auto-commit policy of a branch
+type BranchAutoCommitPolicy struct {
+	Repository string `db:"repository_id"`
+	Branch     string `db:"branch_id"`
+	AutoCommitPolicy
+}
+
+func validateAutoCommitPolicy(policy AutoCommitPolicy) error {
+	if err := Validate([]ValidateArg{
+		{"max_staged_entries", policy.MaxStagedEntries, ValidateNonNegativeInt},
+		{"interval_seconds", policy.IntervalSeconds, ValidateNonNegativeInt},
+	}); err != nil {
+		return err
+	}
+	if policy.MaxStagedEntries == 0 && policy.IntervalSeconds == 0 {
+		return fmt.Errorf("max_staged_entries or interval_seconds required: %w", ErrInvalidValue)
+	}
+	if _, err := policy.template(); err != nil {
+		return fmt.Errorf("message_template: %s: %w", err, ErrInvalidValue)
+	}
+	return nil
+}
+
+func (p *AutoCommitPolicy) template() (*template.Template, error) {
+	text := p.MessageTemplate
+	if text == "" {
+		text = DefaultAutoCommitMessageTemplate
+	}
+	return template.New("message").Option("missingkey=error").Parse(text)
+}
+
+// due returns true if a branch with stats uncommitted changes, last committed at lastCommit,
+// should be committed at now
+func (p *AutoCommitPolicy) due(stats graveler.StagingStats, lastCommit, now time.Time) bool {
+	changes := stats.Writes + stats.Deletes
+	if changes == 0 {
+		return false
+	}
+	if p.MaxStagedEntries > 0 && changes >= int64(p.MaxStagedEntries) {
+		return true
+	}
+	return p.IntervalSeconds > 0 && now.Sub(lastCommit) >= time.Duration(p.IntervalSeconds)*time.Second
+}
+
+// message returns the commit message for data
+func (p *AutoCommitPolicy) message(data AutoCommitMessageData) (string, error) {
+	tmpl, err := p.template()
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func (c *cataloger) SetAutoCommitPolicy(ctx context.Context, repository, branch string, policy AutoCommitPolicy) error {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+		{"branch", graveler.BranchID(branch), ValidateBranchID},
+	}); err != nil {
+		return err
+	}
+	if err := validateAutoCommitPolicy(policy); err != nil {
+		return err
+	}
+	if _, err := c.EntryCatalog.GetBranch(ctx, graveler.RepositoryID(repository), graveler.BranchID(branch)); err != nil {
+		return err
+	}
+	_, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		return tx.Exec(`INSERT INTO catalog_auto_commit_policies (repository_id, branch_id, max_staged_entries, interval_seconds, message_template, update_date)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (repository_id, branch_id) DO UPDATE SET max_staged_entries = EXCLUDED.max_staged_entries,
+				interval_seconds = EXCLUDED.interval_seconds, message_template = EXCLUDED.message_template,
+				update_date = EXCLUDED.update_date`,
+			repository, branch, policy.MaxStagedEntries, policy.IntervalSeconds, policy.MessageTemplate, time.Now().UTC())
+	}, db.WithContext(ctx))
+	return err
+}
+
+func (c *cataloger) GetAutoCommitPolicy(ctx context.Context, repository, branch string) (*AutoCommitPolicy, error) {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+		{"branch", graveler.BranchID(branch), ValidateBranchID},
+	}); err != nil {
+		return nil, err
+	}
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var policy AutoCommitPolicy
+		err := tx.Get(&policy, `SELECT max_staged_entries, interval_seconds, message_template
+			FROM catalog_auto_commit_policies WHERE repository_id = $1 AND branch_id = $2`,
+			repository, branch)
+		return &policy, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, ErrAutoCommitPolicyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res.(*AutoCommitPolicy), nil
+}
+
+func (c *cataloger) DeleteAutoCommitPolicy(ctx context.Context, repository, branch string) error {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+		{"branch", graveler.BranchID(branch), ValidateBranchID},
+	}); err != nil {
+		return err
+	}
+	_, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		res, err := tx.Exec(`DELETE FROM catalog_auto_commit_policies WHERE repository_id = $1 AND branch_id = $2`,
+			repository, branch)
+		if err != nil {
+			return nil, err
+		}
+		if res.RowsAffected() == 0 {
+			return nil, ErrAutoCommitPolicyNotFound
+		}
+		return nil, nil
+	}, db.WithContext(ctx))
+	return err
+}
+
+// CommitDueBranches commits the uncommitted changes of every branch whose auto-commit policy is
+// due.  Policies of deleted branches are deleted.
+func (c *cataloger) CommitDueBranches(ctx context.Context) (int, error) {
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var policies []*BranchAutoCommitPolicy
+		err := tx.Select(&policies, `SELECT repository_id, branch_id, max_staged_entries, interval_seconds, message_template
+			FROM catalog_auto_commit_policies ORDER BY repository_id, branch_id`)
+		return policies, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	committed := 0
+	for _, policy := range res.([]*BranchAutoCommitPolicy) {
+		log := c.log.WithFields(logging.Fields{
+			"repository": policy.Repository,
+			"branch":     policy.Branch,
+		})
+		ok, err := c.autoCommit(ctx, policy)
+		switch {
+		case errors.Is(err, graveler.ErrBranchNotFound) || errors.Is(err, graveler.ErrRepositoryNotFound):
+			if err := c.DeleteAutoCommitPolicy(ctx, policy.Repository, policy.Branch); err != nil {
+				log.WithError(err).Error("Failed to delete auto-commit policy of missing branch")
+			}
+		case err != nil:
+			log.WithError(err).Error("Failed to auto-commit")
+		case ok:
+			committed++
+		}
+	}
+	return committed, nil
+}
+
+// autoCommit commits the branch of policy if policy is due, and returns whether it committed
+func (c *cataloger) autoCommit(ctx context.Context, policy *BranchAutoCommitPolicy) (bool, error) {
+	repositoryID := graveler.RepositoryID(policy.Repository)
+	branchID := graveler.BranchID(policy.Branch)
+	stats, err := c.EntryCatalog.GetStagingStats(ctx, repositoryID, branchID)
+	if err != nil {
+		return false, err
+	}
+	if stats.Writes+stats.Deletes == 0 {
+		return false, nil
+	}
+	head, err := c.GetCommit(ctx, policy.Repository, policy.Branch)
+	if err != nil {
+		return false, err
+	}
+	now := time.Now().UTC()
+	if !policy.due(*stats, head.CreationDate, now) {
+		return false, nil
+	}
+	message, err := policy.message(AutoCommitMessageData{
+		Repository: policy.Repository,
+		Branch:     policy.Branch,
+		Changes:    stats.Writes + stats.Deletes,
+		Writes:     stats.Writes,
+		Deletes:    stats.Deletes,
+		SizeBytes:  stats.SizeBytes,
+		Time:       now,
+	})
+	if err != nil {
+		return false, fmt.Errorf("auto-commit message: %w", err)
+	}
+	_, err = c.Commit(ctx, policy.Repository, policy.Branch, message, AutoCommitCommitter, nil)
+	if errors.Is(err, graveler.ErrNoChanges) {
+		// committed meanwhile
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// RunAutoCommitter commits branches whose auto-commit policies are due each interval
+func RunAutoCommitter(ctx context.Context, c Cataloger, interval time.Duration) {
+	log := logging.FromContext(ctx).WithField("service", "auto_committer")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			committed, err := c.CommitDueBranches(ctx)
+			if err != nil {
+				log.WithError(err).Error("Failed to auto-commit branches")
+			}
+			if committed > 0 {
+				log.WithField("committed", committed).Info("Auto-committed branches")
+			}
+		}
+	}
+}
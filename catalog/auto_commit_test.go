@@ -0,0 +1,84 @@
+package catalog
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/treeverse/lakefs/graveler"
+)
+
+func TestAutoCommitPolicy_Due(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	policy := AutoCommitPolicy{MaxStagedEntries: 100, IntervalSeconds: 600}
+	tests := []struct {
+		name       string
+		policy     AutoCommitPolicy
+		stats      graveler.StagingStats
+		lastCommit time.Time
+		want       bool
+	}{
+		{name: "nothing staged", policy: policy, lastCommit: now.Add(-time.Hour)},
+		{name: "below limits", policy: policy, stats: graveler.StagingStats{Writes: 50, Deletes: 49}, lastCommit: now.Add(-time.Minute)},
+		{name: "entries", policy: policy, stats: graveler.StagingStats{Writes: 50, Deletes: 50}, lastCommit: now.Add(-time.Minute), want: true},
+		{name: "interval", policy: policy, stats: graveler.StagingStats{Deletes: 1}, lastCommit: now.Add(-10 * time.Minute), want: true},
+		{name: "entries only", policy: AutoCommitPolicy{MaxStagedEntries: 100}, stats: graveler.StagingStats{Writes: 1}, lastCommit: now.Add(-24 * time.Hour)},
+		{name: "interval only", policy: AutoCommitPolicy{IntervalSeconds: 600}, stats: graveler.StagingStats{Writes: 1000}, lastCommit: now.Add(-time.Minute)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.due(tt.stats, tt.lastCommit, now); got != tt.want {
+				t.Errorf("due() = %t, expected %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAutoCommitPolicy_Message(t *testing.T) {
+	data := AutoCommitMessageData{Repository: "repo", Branch: "ingest", Changes: 3, Writes: 2, Deletes: 1}
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{name: "default", want: "Auto-commit 3 changes to ingest"},
+		{name: "template", template: "{{.Repository}}/{{.Branch}}: +{{.Writes}} -{{.Deletes}}", want: "repo/ingest: +2 -1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := AutoCommitPolicy{IntervalSeconds: 60, MessageTemplate: tt.template}
+			got, err := policy.message(data)
+			if err != nil {
+				t.Fatalf("message() unexpected error: %s", err)
+			}
+			if got != tt.want {
+				t.Errorf("message() = %q, expected %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAutoCommitPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  AutoCommitPolicy
+		wantErr bool
+	}{
+		{name: "entries", policy: AutoCommitPolicy{MaxStagedEntries: 1000}},
+		{name: "interval", policy: AutoCommitPolicy{IntervalSeconds: 60, MessageTemplate: "ingest {{.Time}}"}},
+		{name: "never", policy: AutoCommitPolicy{}, wantErr: true},
+		{name: "negative", policy: AutoCommitPolicy{MaxStagedEntries: -1, IntervalSeconds: 60}, wantErr: true},
+		{name: "bad template", policy: AutoCommitPolicy{IntervalSeconds: 60, MessageTemplate: "{{.Branch"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAutoCommitPolicy(tt.policy)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("validateAutoCommitPolicy() error = %v, expected error %t", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidValue) {
+				t.Errorf("validateAutoCommitPolicy() error = %v, expected %s", err, ErrInvalidValue)
+			}
+		})
+	}
+}
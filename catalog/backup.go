@@ -0,0 +1,335 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/logging"
+)
+
+const (
+	// MetadataBackupPrefix is the prefix of metadata backups in the repository storage namespace
+	MetadataBackupPrefix = "_lakefs/backups/"
+	// metadataBackupLatest holds the ID of the latest metadata backup
+	metadataBackupLatest = MetadataBackupPrefix + "latest"
+
+	metadataBackupIDFormat = "20060102T150405.000000Z"
+)
+
+// MetadataBackup is a point-in-time backup of the commits, branches, tags and uncommitted
+// changes of a repository.  Each backup is complete: its dumps are MetaRanges in the storage
+// namespace, which share the ranges that did not change with earlier backups.
+type MetadataBackup struct {
+	ID                  string    `json:"id"`
+	Repository          string    `json:"repository"`
+	CreationDate        time.Time `json:"creation_date"`
+	CommitsMetaRangeID  string    `json:"commits_meta_range_id"`
+	BranchesMetaRangeID string    `json:"branches_meta_range_id"`
+	TagsMetaRangeID     string    `json:"tags_meta_range_id"`
+	// Staging maps each branch to the dump of its uncommitted changes
+	Staging map[string]string `json:"staging"`
+	// Base is the ID of the previous backup, empty for the first backup of the repository
+	Base string `json:"base,omitempty"`
+}
+
+// MetadataBackupDelta lists the changes a backup holds relative to its base
+type MetadataBackupDelta struct {
+	Base            string   `json:"base"`
+	AddedCommits    []string `json:"added_commits"`
+	RemovedCommits  []string `json:"removed_commits"`
+	ChangedBranches []string `json:"changed_branches"`
+	RemovedBranches []string `json:"removed_branches"`
+	ChangedTags     []string `json:"changed_tags"`
+	RemovedTags     []string `json:"removed_tags"`
+	ChangedStaging  []string `json:"changed_staging"`
+}
+
+func metadataBackupManifestPath(id string) string {
+	return MetadataBackupPrefix + id + "/manifest.json"
+}
+
+func metadataBackupDeltaPath(id string) string {
+	return MetadataBackupPrefix + id + "/delta.json"
+}
+
+// BackupMetadata writes a backup of the metadata of repository to its storage namespace, with a
+// delta file listing the changes since the previous backup
+func (c *cataloger) BackupMetadata(ctx context.Context, repository string) (*MetadataBackup, error) {
+	repositoryID := graveler.RepositoryID(repository)
+	repo, err := c.EntryCatalog.GetRepository(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	ns := repo.StorageNamespace.String()
+	base, err := c.latestMetadataBackup(ns)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	backup := &MetadataBackup{
+		ID:           now.Format(metadataBackupIDFormat),
+		Repository:   repository,
+		CreationDate: now,
+		Staging:      make(map[string]string),
+	}
+	if backup.CommitsMetaRangeID, err = c.DumpCommits(ctx, repository); err != nil {
+		return nil, fmt.Errorf("dump commits: %w", err)
+	}
+	if backup.BranchesMetaRangeID, err = c.DumpBranches(ctx, repository); err != nil {
+		return nil, fmt.Errorf("dump branches: %w", err)
+	}
+	if backup.TagsMetaRangeID, err = c.DumpTags(ctx, repository); err != nil {
+		return nil, fmt.Errorf("dump tags: %w", err)
+	}
+	branchesIt, err := c.EntryCatalog.ListBranches(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	var branchIDs []graveler.BranchID
+	for branchesIt.Next() {
+		branchIDs = append(branchIDs, branchesIt.Value().BranchID)
+	}
+	err = branchesIt.Err()
+	branchesIt.Close()
+	if err != nil {
+		return nil, err
+	}
+	for _, branchID := range branchIDs {
+		metaRangeID, err := c.EntryCatalog.DumpStaging(ctx, repositoryID, branchID)
+		if err != nil {
+			return nil, fmt.Errorf("dump staging of %s: %w", branchID, err)
+		}
+		backup.Staging[branchID.String()] = string(*metaRangeID)
+	}
+
+	delta := &MetadataBackupDelta{}
+	if base != nil {
+		backup.Base = base.ID
+		delta, err = c.metadataBackupDelta(ctx, repositoryID, base, backup)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := c.writeMetadataBackupObject(ns, metadataBackupDeltaPath(backup.ID), delta); err != nil {
+		return nil, err
+	}
+	if err := c.writeMetadataBackupObject(ns, metadataBackupManifestPath(backup.ID), backup); err != nil {
+		return nil, err
+	}
+	// written last, a failed backup leaves the previous backup as the latest
+	if err := c.writeMetadataBackupObject(ns, metadataBackupLatest, backup.ID); err != nil {
+		return nil, err
+	}
+	return backup, nil
+}
+
+func (c *cataloger) metadataBackupDelta(ctx context.Context, repositoryID graveler.RepositoryID, base, backup *MetadataBackup) (*MetadataBackupDelta, error) {
+	delta := &MetadataBackupDelta{Base: base.ID}
+	var err error
+	delta.AddedCommits, delta.RemovedCommits, _, err = c.diffDumps(ctx, repositoryID, base.CommitsMetaRangeID, backup.CommitsMetaRangeID)
+	if err != nil {
+		return nil, fmt.Errorf("diff commits: %w", err)
+	}
+	var added, changed []string
+	added, delta.RemovedBranches, changed, err = c.diffDumps(ctx, repositoryID, base.BranchesMetaRangeID, backup.BranchesMetaRangeID)
+	if err != nil {
+		return nil, fmt.Errorf("diff branches: %w", err)
+	}
+	delta.ChangedBranches = append(added, changed...)
+	added, delta.RemovedTags, changed, err = c.diffDumps(ctx, repositoryID, base.TagsMetaRangeID, backup.TagsMetaRangeID)
+	if err != nil {
+		return nil, fmt.Errorf("diff tags: %w", err)
+	}
+	delta.ChangedTags = append(added, changed...)
+	for branchID, metaRangeID := range backup.Staging {
+		if base.Staging[branchID] != metaRangeID {
+			delta.ChangedStaging = append(delta.ChangedStaging, branchID)
+		}
+	}
+	return delta, nil
+}
+
+// diffDumps returns the keys added, removed and changed between two dumps
+func (c *cataloger) diffDumps(ctx context.Context, repositoryID graveler.RepositoryID, left, right string) ([]string, []string, []string, error) {
+	if left == right {
+		return nil, nil, nil, nil
+	}
+	it, err := c.EntryCatalog.DiffDumps(ctx, repositoryID, graveler.MetaRangeID(left), graveler.MetaRangeID(right))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer it.Close()
+	var added, removed, changed []string
+	for it.Next() {
+		d := it.Value()
+		switch d.Type {
+		case graveler.DiffTypeAdded:
+			added = append(added, d.Key.String())
+		case graveler.DiffTypeRemoved:
+			removed = append(removed, d.Key.String())
+		default:
+			changed = append(changed, d.Key.String())
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, nil, nil, err
+	}
+	return added, removed, changed, nil
+}
+
+// GetMetadataBackup returns the metadata backup backupID of repository, or the latest backup
+// when backupID is empty
+func (c *cataloger) GetMetadataBackup(ctx context.Context, repository, backupID string) (*MetadataBackup, error) {
+	repo, err := c.EntryCatalog.GetRepository(ctx, graveler.RepositoryID(repository))
+	if err != nil {
+		return nil, err
+	}
+	ns := repo.StorageNamespace.String()
+	if backupID == "" {
+		backup, err := c.latestMetadataBackup(ns)
+		if err != nil {
+			return nil, err
+		}
+		if backup == nil {
+			return nil, ErrMetadataBackupNotFound
+		}
+		return backup, nil
+	}
+	var backup MetadataBackup
+	if err := c.readMetadataBackupObject(ns, metadataBackupManifestPath(backupID), &backup); err != nil {
+		return nil, err
+	}
+	return &backup, nil
+}
+
+// RestoreMetadataBackup loads the metadata backup backupID, or the latest backup when backupID
+// is empty, into repository.  Repository must be a bare repository created on the storage
+// namespace holding the backup.
+func (c *cataloger) RestoreMetadataBackup(ctx context.Context, repository, backupID string) (*MetadataBackup, error) {
+	backup, err := c.GetMetadataBackup(ctx, repository, backupID)
+	if err != nil {
+		return nil, err
+	}
+	repositoryID := graveler.RepositoryID(repository)
+	if err := c.LoadCommits(ctx, repository, backup.CommitsMetaRangeID); err != nil {
+		return nil, fmt.Errorf("load commits: %w", err)
+	}
+	if err := c.LoadBranches(ctx, repository, backup.BranchesMetaRangeID); err != nil {
+		return nil, fmt.Errorf("load branches: %w", err)
+	}
+	if err := c.LoadTags(ctx, repository, backup.TagsMetaRangeID); err != nil {
+		return nil, fmt.Errorf("load tags: %w", err)
+	}
+	for branchID, metaRangeID := range backup.Staging {
+		err := c.EntryCatalog.LoadStaging(ctx, repositoryID, graveler.BranchID(branchID), graveler.MetaRangeID(metaRangeID))
+		if err != nil {
+			return nil, fmt.Errorf("load staging of %s: %w", branchID, err)
+		}
+	}
+	return backup, nil
+}
+
+// latestMetadataBackup returns the latest metadata backup in storage namespace ns, or nil if
+// there is none
+func (c *cataloger) latestMetadataBackup(ns string) (*MetadataBackup, error) {
+	exists, err := c.EntryCatalog.BlockAdapter.Exists(block.ObjectPointer{StorageNamespace: ns, Identifier: metadataBackupLatest})
+	if err != nil || !exists {
+		return nil, err
+	}
+	var id string
+	if err := c.readMetadataBackupObject(ns, metadataBackupLatest, &id); err != nil {
+		return nil, err
+	}
+	var backup MetadataBackup
+	if err := c.readMetadataBackupObject(ns, metadataBackupManifestPath(id), &backup); err != nil {
+		return nil, err
+	}
+	return &backup, nil
+}
+
+func (c *cataloger) writeMetadataBackupObject(ns, identifier string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = c.EntryCatalog.BlockAdapter.Put(block.ObjectPointer{
+		StorageNamespace: ns,
+		Identifier:       identifier,
+	}, int64(len(data)), bytes.NewReader(data), block.PutOpts{})
+	if err != nil {
+		return fmt.Errorf("write %s: %w", identifier, err)
+	}
+	return nil
+}
+
+func (c *cataloger) readMetadataBackupObject(ns, identifier string, v interface{}) error {
+	return readJSONObject(c.EntryCatalog.BlockAdapter, ns, identifier, v)
+}
+
+// readJSONObject decodes the JSON object identifier in storage namespace ns into v
+func readJSONObject(adapter block.Adapter, ns, identifier string, v interface{}) error {
+	pointer := block.ObjectPointer{StorageNamespace: ns, Identifier: identifier}
+	exists, err := adapter.Exists(pointer)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrMetadataBackupNotFound
+	}
+	reader, err := adapter.Get(pointer, -1)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", identifier, err)
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", identifier, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("decode %s: %w", identifier, err)
+	}
+	return nil
+}
+
+// RunMetadataBackups backs up the metadata of every repository each interval.  Only
+// repositories for which owns returns true are backed up, all of them when owns is nil.
+func RunMetadataBackups(ctx context.Context, c Cataloger, interval time.Duration, owns func(repository string) bool) {
+	log := logging.FromContext(ctx).WithField("service", "metadata_backup")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			repos, _, err := c.ListRepositories(ctx, -1, "")
+			if err != nil {
+				log.WithError(err).Error("Failed to list repositories")
+				continue
+			}
+			for _, repo := range repos {
+				if owns != nil && !owns(repo.Name) {
+					continue
+				}
+				backup, err := c.BackupMetadata(ctx, repo.Name)
+				if err != nil {
+					log.WithError(err).WithField("repository", repo.Name).Error("Failed to back up metadata")
+					continue
+				}
+				log.WithFields(logging.Fields{
+					"repository": repo.Name,
+					"backup_id":  backup.ID,
+				}).Info("Backed up metadata")
+			}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/block/mem"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+func TestCataloger_BackupMetadata(t *testing.T) {
+	ctx := context.Background()
+	store := &FakeGraveler{
+		Repositories: map[graveler.RepositoryID]*graveler.Repository{
+			"repo": {StorageNamespace: "mem://backup", DefaultBranchID: "main"},
+		},
+		BranchIteratorFactory: NewFakeBranchIteratorFactory([]*graveler.BranchRecord{
+			{BranchID: "main", Branch: &graveler.Branch{CommitID: "c1"}},
+		}),
+		Dumps: map[string]graveler.MetaRangeID{
+			"commits":      "commits1",
+			"branches":     "branches1",
+			"tags":         "tags1",
+			"staging/main": "staging1",
+		},
+		DiffIteratorFactory: NewFakeDiffIteratorFactory([]*graveler.Diff{
+			{Key: graveler.Key("c2"), Type: graveler.DiffTypeAdded},
+		}),
+	}
+	c := &cataloger{EntryCatalog: &EntryCatalog{Store: store, BlockAdapter: mem.New()}}
+
+	if _, err := c.GetMetadataBackup(ctx, "repo", ""); !errors.Is(err, ErrMetadataBackupNotFound) {
+		t.Fatalf("GetMetadataBackup() before backup err=%v, expected %s", err, ErrMetadataBackupNotFound)
+	}
+	first, err := c.BackupMetadata(ctx, "repo")
+	if err != nil {
+		t.Fatalf("BackupMetadata: %s", err)
+	}
+	if first.Base != "" {
+		t.Errorf("first backup base=%s, expected none", first.Base)
+	}
+
+	store.Dumps["commits"] = "commits2"
+	second, err := c.BackupMetadata(ctx, "repo")
+	if err != nil {
+		t.Fatalf("second BackupMetadata: %s", err)
+	}
+	if second.Base != first.ID {
+		t.Errorf("second backup base=%s, expected %s", second.Base, first.ID)
+	}
+	var delta MetadataBackupDelta
+	if err := c.readMetadataBackupObject("mem://backup", metadataBackupDeltaPath(second.ID), &delta); err != nil {
+		t.Fatalf("read delta: %s", err)
+	}
+	if diff := deep.Equal(delta, MetadataBackupDelta{Base: first.ID, AddedCommits: []string{"c2"}}); diff != nil {
+		t.Error("delta diff found", diff)
+	}
+
+	latest, err := c.GetMetadataBackup(ctx, "repo", "")
+	if err != nil {
+		t.Fatalf("GetMetadataBackup: %s", err)
+	}
+	if latest.ID != second.ID {
+		t.Errorf("latest backup=%s, expected %s", latest.ID, second.ID)
+	}
+
+	if _, err := c.RestoreMetadataBackup(ctx, "repo", first.ID); err != nil {
+		t.Fatalf("RestoreMetadataBackup: %s", err)
+	}
+	expectedLoads := map[string]graveler.MetaRangeID{
+		"commits":      "commits1",
+		"branches":     "branches1",
+		"tags":         "tags1",
+		"staging/main": "staging1",
+	}
+	if diff := deep.Equal(store.Loads, expectedLoads); diff != nil {
+		t.Error("restore loads diff found", diff)
+	}
+}
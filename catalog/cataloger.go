@@ -3,16 +3,20 @@ package catalog
 import (
 	"context"
 	"io"
+	"time"
 )
 
 const (
 	DefaultPathDelimiter = "/"
+	// ListingSnapshotSweepInterval is the interval of dropping expired listing snapshots
+	ListingSnapshotSweepInterval = 10 * time.Minute
 )
 
 type DiffParams struct {
 	Limit            int
 	After            string
-	AdditionalFields []string // db fields names that will be load in additional to Path on Difference's Entry
+	AdditionalFields []string         // db fields names that will be load in additional to Path on Difference's Entry
+	Types            []DifferenceType // types of differences to return, all types when empty
 }
 
 type RevertParams struct {
@@ -21,6 +25,23 @@ type RevertParams struct {
 	Committer    string
 }
 
+type ReplacePrefixParams struct {
+	Prefix string
+	// SourceRef holds the entries replacing those under Prefix, the entries are dropped when empty
+	SourceRef string
+	Committer string
+	Message   string
+	Metadata  Metadata
+}
+
+type MergeParams struct {
+	Committer string
+	Message   string
+	Metadata  Metadata
+	// OverrideGuardrails merges even when the merge exceeds the guardrails of the destination branch
+	OverrideGuardrails bool
+}
+
 type ExpireResult struct {
 	Repository        string
 	Branch            string
@@ -43,6 +64,12 @@ type GetEntryParams struct {
 	// For entries to expired objects the Expired bit is set.  If true, GetEntry returns
 	// successfully for expired entries, otherwise it returns the entry with ErrExpired.
 	ReturnExpired bool
+	// If true, GetEntry returns link entries as is, otherwise links are followed and the
+	// entry they resolve to is returned.
+	NoFollowLinks bool
+	// AuthorizeLink is called before reading the path in repository a link resolves to.  Links to
+	// other repositories are followed only if it is set and returns no error.
+	AuthorizeLink func(repository, path string) error
 }
 
 type Cataloger interface {
@@ -53,9 +80,19 @@ type Cataloger interface {
 	// defaultBranchID will point to a non-existent branch on creation, it is up to the caller to eventually create it.
 	CreateBareRepository(ctx context.Context, repository string, storageNamespace string, defaultBranchID string) (*Repository, error)
 
+	// ForkRepository create a new repository with the commits, branches and tags of 'source'.
+	// The fork shares the storage namespace and objects of 'source', changes are staged on the fork only.
+	ForkRepository(ctx context.Context, source string, repository string) (*Repository, error)
+
 	// GetRepository get repository information
 	GetRepository(ctx context.Context, repository string) (*Repository, error)
 
+	// GetRangeSplitPolicy returns how trees of repository are split into ranges, zero fields
+	// are the configured defaults
+	GetRangeSplitPolicy(ctx context.Context, repository string) (*RangeSplitPolicy, error)
+	// SetRangeSplitPolicy sets how trees of repository written from now on are split into ranges
+	SetRangeSplitPolicy(ctx context.Context, repository string, policy RangeSplitPolicy) error
+
 	// DeleteRepository delete a repository
 	DeleteRepository(ctx context.Context, repository string) error
 
@@ -65,6 +102,14 @@ type Cataloger interface {
 
 	CreateBranch(ctx context.Context, repository, branch string, sourceRef string) (*CommitLog, error)
 	DeleteBranch(ctx context.Context, repository, branch string) error
+	// CreateEphemeralBranch creates a branch deleted after params.TTL, or optionally once it is merged
+	CreateEphemeralBranch(ctx context.Context, repository, branch string, sourceRef string, params EphemeralBranchParams) (*CommitLog, error)
+	GetEphemeralBranch(ctx context.Context, repository, branch string) (*EphemeralBranch, error)
+	// GetOrCreateSandbox returns the personal sandbox branch of username, creating it on first use
+	// and extending its lifetime to ttl from now
+	GetOrCreateSandbox(ctx context.Context, repository, username string, ttl time.Duration) (*Sandbox, error)
+	// DeleteExpiredBranches deletes ephemeral branches whose TTL passed, returns the number of branches deleted
+	DeleteExpiredBranches(ctx context.Context) (int, error)
 	ListBranches(ctx context.Context, repository string, prefix string, limit int, after string) ([]*Branch, bool, error)
 	BranchExists(ctx context.Context, repository string, branch string) (bool, error)
 	GetBranchReference(ctx context.Context, repository, branch string) (string, error)
@@ -74,31 +119,216 @@ type Cataloger interface {
 	DeleteTag(ctx context.Context, repository, tagID string) error
 	ListTags(ctx context.Context, repository string, limit int, after string) ([]*Tag, bool, error)
 	GetTag(ctx context.Context, repository, tagID string) (string, error)
+	// IsImmutableRef returns true if reference is a commit ID or a tag, whose objects never
+	// change.  Branches, commit ID prefixes and references with modifiers such as "~1" may
+	// resolve to other commits later.
+	IsImmutableRef(ctx context.Context, repository, reference string) (bool, error)
+
+	// CreateRepositorySnapshot atomically records the head commit of every branch under snapshotID
+	CreateRepositorySnapshot(ctx context.Context, repository, snapshotID string) (*RepositorySnapshot, error)
+	GetRepositorySnapshot(ctx context.Context, repository, snapshotID string) (*RepositorySnapshot, error)
+	ListRepositorySnapshots(ctx context.Context, repository string) ([]*RepositorySnapshot, error)
+	DeleteRepositorySnapshot(ctx context.Context, repository, snapshotID string) error
+	// RestoreRepositorySnapshot resets the branches recorded by snapshotID to their recorded heads,
+	// uncommitted changes on these branches are dropped
+	RestoreRepositorySnapshot(ctx context.Context, repository, snapshotID string) error
 
 	// GetEntry returns the current entry for path in repository branch reference.  Returns
 	// the entry with ExpiredError if it has expired from underlying storage.
 	GetEntry(ctx context.Context, repository, reference string, path string, params GetEntryParams) (*DBEntry, error)
+	// GetEntries returns the entries of paths in repository branch reference like GetEntry, in
+	// the order of paths and nil for paths that do not exist
+	GetEntries(ctx context.Context, repository, reference string, paths []string, params GetEntryParams) ([]*DBEntry, error)
 	CreateEntry(ctx context.Context, repository, branch string, entry DBEntry) error
+	// CreateEntryIf creates entry if condition holds for the current entry of its path, and
+	// returns graveler.ErrPreconditionFailed if it does not
+	CreateEntryIf(ctx context.Context, repository, branch string, entry DBEntry, condition EntryCondition) error
 	CreateEntries(ctx context.Context, repository, branch string, entries []DBEntry) error
+	// LinkEntryByDigest stages on path the entry holding the content described by params, if
+	// there is one at the source of params, and returns ErrDigestMismatch otherwise
+	LinkEntryByDigest(ctx context.Context, repository, branch, path string, params LinkByDigestParams) (*DBEntry, error)
 	DeleteEntry(ctx context.Context, repository, branch string, path string) error
 	ListEntries(ctx context.Context, repository, reference string, prefix, after string, delimiter string, limit int) ([]*DBEntry, bool, error)
+	// ListEntriesWithToken lists entries like ListEntries, using an opaque continuation token that keeps
+	// all pages of the listing on the state of the reference at the first page.  Returns the next
+	// continuation token, or an empty one when there are no more entries.
+	ListEntriesWithToken(ctx context.Context, repository, reference string, prefix, token string, delimiter string, limit int) ([]*DBEntry, string, error)
+	// DropExpiredListingSnapshots drops the staging copies pinned by expired continuation tokens of
+	// ListEntriesWithToken, returns the number of snapshots dropped
+	DropExpiredListingSnapshots(ctx context.Context) (int, error)
 	ResetEntry(ctx context.Context, repository, branch string, path string) error
+	// RestoreEntry stages on branch the entry of path as it is on fromReference, without
+	// copying its data
+	RestoreEntry(ctx context.Context, repository, branch string, path string, fromReference string) (*DBEntry, error)
 	ResetEntries(ctx context.Context, repository, branch string, prefix string) error
+	// GetStagingStats returns a summary of the uncommitted changes on branch, without listing them
+	GetStagingStats(ctx context.Context, repository, branch string) (*StagingStats, error)
 
 	Commit(ctx context.Context, repository, branch string, message string, committer string, metadata Metadata) (*CommitLog, error)
+	// CommitWithParams commits branch like Commit, with committer and author details
+	CommitWithParams(ctx context.Context, repository, branch string, params CommitParams) (*CommitLog, error)
+	// CommitPreview computes the result of committing branch without publishing it
+	CommitPreview(ctx context.Context, repository, branch string) (*CommitPreview, error)
 	GetCommit(ctx context.Context, repository, reference string) (*CommitLog, error)
-	ListCommits(ctx context.Context, repository, branch string, fromReference string, limit int) ([]*CommitLog, bool, error)
+	// ListCommits returns the log of branch, following only the first parent of merge commits
+	// when firstParent is set
+	ListCommits(ctx context.Context, repository, branch string, fromReference string, limit int, firstParent bool) ([]*CommitLog, bool, error)
+	// WalkCommitLog calls walkFn with each commit of the log of reference, newest first.  Commits
+	// are read as walkFn consumes them, the walk stops at the first error walkFn returns.
+	WalkCommitLog(ctx context.Context, repository, reference string, firstParent bool, walkFn func(*CommitLog) error) error
+	// GetEntryHistory returns the versions of path set by the commits of the first-parent log
+	// of branch, newest first
+	GetEntryHistory(ctx context.Context, repository, branch, path string, fromReference string, limit int) ([]*EntryVersion, bool, error)
+	// IsAncestor returns true if the commit of ancestorReference is reachable from the commit of reference
+	IsAncestor(ctx context.Context, repository, ancestorReference, reference string) (bool, error)
+	// CommitsBetween returns the commits reachable from toReference but not from fromReference, starting
+	// after the commit ID after, similar to 'git log fromReference..toReference'
+	CommitsBetween(ctx context.Context, repository, fromReference, toReference string, after string, limit int) ([]*CommitLog, bool, error)
+	// FindCommitsByMetadata returns up to limit commits in repository with metadata key set to value,
+	// newest first, starting after the commit ID after
+	FindCommitsByMetadata(ctx context.Context, repository string, key, value string, after string, limit int) ([]*CommitLog, bool, error)
+	// SetCommitStatus sets the status of a verification check of the commit of reference
+	SetCommitStatus(ctx context.Context, repository, reference string, status CommitStatus) error
+	// GetCommitVerification returns the statuses of the checks of the commit of reference and
+	// their combined state
+	GetCommitVerification(ctx context.Context, repository, reference string) (*CommitVerification, error)
+	// AnnotateCommits applies updates to the annotations of commits atomically, and returns the
+	// annotations of the commit of each update
+	AnnotateCommits(ctx context.Context, repository string, updates []CommitAnnotationUpdate, annotator string) ([]*CommitAnnotations, error)
+	// GetCommitAnnotations returns the annotations of the commit of reference
+	GetCommitAnnotations(ctx context.Context, repository, reference string) (*CommitAnnotations, error)
+
+	// SetRefLabels sets the labels in set and removes the labels in unset of the branch, tag or
+	// commit refID, by refType, and returns its labels
+	SetRefLabels(ctx context.Context, repository, refType, refID string, set map[string]string, unset []string) (*RefLabels, error)
+	// GetRefLabels returns the labels of the branch, tag or commit refID, by refType
+	GetRefLabels(ctx context.Context, repository, refType, refID string) (*RefLabels, error)
+	// FindRefsByLabels returns the refs of refType, or of every type if empty, that have all
+	// labels, ordered by type and ID
+	FindRefsByLabels(ctx context.Context, repository string, labels map[string]string, refType string) ([]*RefLabels, error)
 
 	// RollbackCommit sets the branch to point at the given commit, losing all later commits.
 	RollbackCommit(ctx context.Context, repository, branch string, reference string) error
 	// Revert creates a reverse patch to the given commit, and applies it as a new commit on the given branch.
 	Revert(ctx context.Context, repository, branch string, params RevertParams) error
+	// ReplacePrefix commits replacing the entries under params.Prefix on branch by those of
+	// params.SourceRef, or dropping them.  Ranges aligned with the prefix are swapped whole, so
+	// overwriting a partition costs in the number of its ranges rather than of its entries.
+	ReplacePrefix(ctx context.Context, repository, branch string, params ReplacePrefixParams) (*MergeResult, error)
+	// GenerateEntries commits params.Count synthesized entries on a clean branch, writing only
+	// their metadata, to quickly create large repositories for testing.
+	GenerateEntries(ctx context.Context, repository, branch string, params GenerateEntriesParams) (*MergeResult, error)
 
 	Diff(ctx context.Context, repository, leftReference string, rightReference string, params DiffParams) (Differences, bool, error)
 	Compare(ctx context.Context, repository, leftReference string, rightReference string, params DiffParams) (Differences, bool, error)
-	DiffUncommitted(ctx context.Context, repository, branch string, limit int, after string) (Differences, bool, error)
+	DiffUncommitted(ctx context.Context, repository, branch string, params DiffParams) (Differences, bool, error)
+	// ExportDiff writes the full diff between leftReference and rightReference to a Parquet or CSV
+	// file in the repository storage namespace
+	ExportDiff(ctx context.Context, repository, leftReference, rightReference, format string) (*DiffExport, error)
+	// DiffData compares the row count and schema of the Parquet and CSV objects of diffs, a
+	// two-dot diff between leftReference and rightReference
+	DiffData(ctx context.Context, repository, leftReference, rightReference string, diffs Differences) ([]*DataDifference, error)
+
+	// SetSchema registers the schema of the Parquet and Avro files under its prefix, checked on
+	// every commit adding or changing such files
+	SetSchema(ctx context.Context, repository string, schema Schema) error
+	GetSchema(ctx context.Context, repository, prefix string) (*Schema, error)
+	ListSchemas(ctx context.Context, repository string) ([]*Schema, error)
+	DeleteSchema(ctx context.Context, repository, prefix string) error
+
+	// Merge merges sourceRef into destinationBranch.  Unless params.OverrideGuardrails is set, it
+	// fails with ErrMergeGuardrailsExceeded when the merge exceeds the guardrails of destinationBranch.
+	Merge(ctx context.Context, repository, destinationBranch, sourceRef string, params MergeParams) (*MergeResult, error)
+	// MergePreview checks whether sourceRef can be merged into destinationBranch without performing the merge
+	MergePreview(ctx context.Context, repository, destinationBranch, sourceRef string) (*MergePreview, error)
+	// EnqueueMerge adds a request to merge sourceRef into destinationBranch to the merge queue of
+	// destinationBranch, merged by ProcessMergeQueues
+	EnqueueMerge(ctx context.Context, repository, destinationBranch, sourceRef string, params MergeQueueParams) (*MergeRequest, error)
+	GetMergeRequest(ctx context.Context, repository string, id int64) (*MergeRequest, error)
+	ListMergeQueue(ctx context.Context, repository, branch string) ([]*MergeRequest, error)
+	CancelMergeRequest(ctx context.Context, repository string, id int64) error
+	// ProcessMergeQueues merges queued merge requests, and returns the number merged
+	ProcessMergeQueues(ctx context.Context) (int, error)
 
-	Merge(ctx context.Context, repository, destinationBranch, sourceRef, committer, message string, metadata Metadata) (*MergeResult, error)
+	OpenPullRequest(ctx context.Context, repository string, pr PullRequest) (*PullRequest, error)
+	GetPullRequest(ctx context.Context, repository string, id int64) (*PullRequest, error)
+	ListPullRequests(ctx context.Context, repository, status string) ([]*PullRequest, error)
+	ClosePullRequest(ctx context.Context, repository string, id int64) error
+	// CommentPullRequest comments on the pull request, or on a path it changes
+	CommentPullRequest(ctx context.Context, repository string, id int64, comment PullRequestComment) (*PullRequestComment, error)
+	ListPullRequestComments(ctx context.Context, repository string, id int64, path string) ([]*PullRequestComment, error)
+	// ApprovePullRequest approves the current commit of the source of the pull request
+	ApprovePullRequest(ctx context.Context, repository string, id int64, reviewer string) error
+	// MergePullRequest merges the pull request, subject to the merge guardrails and owners of
+	// its destination branch
+	MergePullRequest(ctx context.Context, repository string, id int64, params PullRequestMergeParams) (*MergeResult, error)
+
+	// merge guardrails - limits on the changes a merge may make to a branch
+	SetMergeGuardrails(ctx context.Context, repository, branch string, guardrails MergeGuardrails) error
+	GetMergeGuardrails(ctx context.Context, repository, branch string) (*MergeGuardrails, error)
+	DeleteMergeGuardrails(ctx context.Context, repository, branch string) error
+
+	// auto-commit policies - periodic commits of the uncommitted changes of a branch
+	SetAutoCommitPolicy(ctx context.Context, repository, branch string, policy AutoCommitPolicy) error
+	GetAutoCommitPolicy(ctx context.Context, repository, branch string) (*AutoCommitPolicy, error)
+	DeleteAutoCommitPolicy(ctx context.Context, repository, branch string) error
+	// CommitDueBranches commits every branch whose auto-commit policy is due, returns the number
+	// of branches committed
+	CommitDueBranches(ctx context.Context) (int, error)
+
+	// path leases - exclusive writes of a user to the paths under a prefix of a branch
+	AcquirePathLease(ctx context.Context, repository, branch, prefix, owner string, ttl time.Duration) (*PathLease, error)
+	RenewPathLease(ctx context.Context, repository, branch, leaseID, owner string, ttl time.Duration) (*PathLease, error)
+	ReleasePathLease(ctx context.Context, repository, branch, leaseID, owner string) error
+	ListPathLeases(ctx context.Context, repository, branch string) ([]*PathLease, error)
+	// CheckPathLease fails with ErrPathLeased when path is leased on branch by a user other than user
+	CheckPathLease(ctx context.Context, repository, branch, path, user string) error
+
+	// dataset registry
+	CreateDataset(ctx context.Context, repository string, dataset Dataset) error
+	GetDataset(ctx context.Context, repository string, name string) (*Dataset, error)
+	ListDatasets(ctx context.Context, repository string) ([]*Dataset, error)
+	DeleteDataset(ctx context.Context, repository string, name string) error
+	DiffDataset(ctx context.Context, repository string, name string, leftReference string, rightReference string, params DiffParams) (Differences, bool, error)
+
+	// lineage - commits declare their inputs under LineageInputsMetadataKey
+	GetCommitInputs(ctx context.Context, repository string, reference string) ([]LineageRef, error)
+	ListCommitsByInput(ctx context.Context, repository string, reference string) ([]LineageRef, error)
+	// GetLineage traverses the lineage graph from reference, pruning the commits of repositories
+	// authorize rejects.  A nil authorize allows every repository.
+	GetLineage(ctx context.Context, repository string, reference string, downstream bool, maxDepth int, authorize func(repository string) error) ([]LineageEdge, error)
+	// IndexPendingLineage retries indexing the lineage inputs of commits which failed to index
+	// them when created, returns the number of commits indexed
+	IndexPendingLineage(ctx context.Context) (int, error)
+
+	// retention - commits not retained by the repository rules are removed by GarbageCollect
+	SetRetentionRules(ctx context.Context, repository string, rules RetentionRules) error
+	GetRetentionRules(ctx context.Context, repository string) (*RetentionRules, error)
+	DeleteRetentionRules(ctx context.Context, repository string) error
+	GarbageCollect(ctx context.Context, repository string, params GarbageCollectionParams) (*GarbageCollectionResult, error)
+
+	// redaction - the data of redacted objects is removed, entries pointing to them are kept.
+	// Redactions apply to every repository sharing the storage namespace, such as forks.
+	RedactEntry(ctx context.Context, repository, reference, path, reason, redactedBy string) (*Redaction, error)
+	GetRedaction(ctx context.Context, storageNamespace, physicalAddress string) (*Redaction, error)
+	ListRedactions(ctx context.Context, repository string) ([]*Redaction, error)
+
+	// PurgeHistory rewrites the history of repository without path, or all paths under it when
+	// prefix is set, and deletes the data stored for them
+	PurgeHistory(ctx context.Context, repository string, path string, prefix bool) (*PurgeHistoryResult, error)
+
+	// Scrub verifies the objects of the entries of reference against their size and ETag
+	Scrub(ctx context.Context, repository, reference string, params ScrubParams) (*ScrubReport, error)
+	// RepairDanglingEntries restores or removes the entries of branch whose objects are missing
+	RepairDanglingEntries(ctx context.Context, repository, branch string, params RepairParams) (*RepairResult, error)
+	// Fsck verifies the integrity of the branches, tags, commits and MetaRanges of repository
+	Fsck(ctx context.Context, repository string) (*FsckReport, error)
+	// UpgradeTrees commits the tree of every branch head of repository in the current tree
+	// format version, returns the upgrade commit ID of each upgraded branch
+	UpgradeTrees(ctx context.Context, repository, committer string) (map[string]string, error)
+	// FillGenerations computes the generations of the commits of repository added before
+	// generations were recorded, returns the number of commits updated
+	FillGenerations(ctx context.Context, repository string) (int, error)
 
 	// dump/load metadata
 	DumpCommits(ctx context.Context, repositoryID string) (string, error)
@@ -108,5 +338,15 @@ type Cataloger interface {
 	LoadBranches(ctx context.Context, repositoryID, branchesMetaRangeID string) error
 	LoadTags(ctx context.Context, repositoryID, tagsMetaRangeID string) error
 
+	// BackupMetadata writes a backup of the metadata of repository to its storage namespace
+	BackupMetadata(ctx context.Context, repository string) (*MetadataBackup, error)
+	// GetMetadataBackup returns a metadata backup of repository, the latest when backupID is empty
+	GetMetadataBackup(ctx context.Context, repository, backupID string) (*MetadataBackup, error)
+	// RestoreMetadataBackup loads a metadata backup into the bare repository
+	RestoreMetadataBackup(ctx context.Context, repository, backupID string) (*MetadataBackup, error)
+	// RecoverRefs rebuilds the refs of the bare repository from the latest metadata backup and
+	// the refs journal in its storage namespace
+	RecoverRefs(ctx context.Context, repository string) (*RefsRecovery, error)
+
 	io.Closer
 }
@@ -0,0 +1,175 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+const (
+	MaxCommitAnnotationUpdates     = 1000
+	MaxCommitAnnotationKeyLength   = 256
+	MaxCommitAnnotationValueLength = 4096
+)
+
+// CommitAnnotation is metadata attached to a commit after it was created, such as a quality
+// score or a label.  Unlike the metadata of the commit, annotations do not change its ID and
+// may be updated.
+type CommitAnnotation struct {
+	Key        string    `db:"key"`
+	Value      string    `db:"value"`
+	Annotator  string    `db:"annotator"`
+	UpdateDate time.Time `db:"update_date"`
+}
+
+// CommitAnnotations are the annotations of a commit, ordered by key
+type CommitAnnotations struct {
+	CommitID    string
+	Annotations []*CommitAnnotation
+}
+
+// CommitAnnotationUpdate sets the annotations in Set and removes the annotations in Unset of
+// the commit of Reference
+type CommitAnnotationUpdate struct {
+	Reference string
+	Set       map[string]string
+	Unset     []string
+}
+
+func validateCommitAnnotationKey(key string) error {
+	if key == "" || len(key) > MaxCommitAnnotationKeyLength {
+		return fmt.Errorf("annotation key '%s': %w", key, ErrInvalidValue)
+	}
+	return nil
+}
+
+func validateCommitAnnotationUpdates(updates []CommitAnnotationUpdate) error {
+	if len(updates) == 0 || len(updates) > MaxCommitAnnotationUpdates {
+		return fmt.Errorf("%d updates, expected 1 to %d: %w", len(updates), MaxCommitAnnotationUpdates, ErrInvalidValue)
+	}
+	for _, update := range updates {
+		if err := ValidateRef(graveler.Ref(update.Reference)); err != nil {
+			return fmt.Errorf("reference '%s': %w", update.Reference, ErrInvalidValue)
+		}
+		if len(update.Set) == 0 && len(update.Unset) == 0 {
+			return fmt.Errorf("update of '%s' changes no annotation: %w", update.Reference, ErrInvalidValue)
+		}
+		for key, value := range update.Set {
+			if err := validateCommitAnnotationKey(key); err != nil {
+				return err
+			}
+			if len(value) > MaxCommitAnnotationValueLength {
+				return fmt.Errorf("annotation '%s' value longer than %d: %w", key, MaxCommitAnnotationValueLength, ErrInvalidValue)
+			}
+		}
+		for _, key := range update.Unset {
+			if err := validateCommitAnnotationKey(key); err != nil {
+				return err
+			}
+			if _, ok := update.Set[key]; ok {
+				return fmt.Errorf("annotation '%s' both set and unset: %w", key, ErrInvalidValue)
+			}
+		}
+	}
+	return nil
+}
+
+// AnnotateCommits applies all updates to the annotations of their commits, or none of them,
+// and returns the annotations of the commit of each update after all were applied
+func (c *cataloger) AnnotateCommits(ctx context.Context, repository string, updates []CommitAnnotationUpdate, annotator string) ([]*CommitAnnotations, error) {
+	repositoryID := graveler.RepositoryID(repository)
+	if err := Validate([]ValidateArg{
+		{"repository", repositoryID, ValidateRepositoryID},
+	}); err != nil {
+		return nil, err
+	}
+	if err := validateCommitAnnotationUpdates(updates); err != nil {
+		return nil, err
+	}
+	commitIDs := make([]string, len(updates))
+	for i, update := range updates {
+		commitID, err := c.EntryCatalog.Dereference(ctx, repositoryID, graveler.Ref(update.Reference))
+		if err != nil {
+			return nil, fmt.Errorf("reference '%s': %w", update.Reference, err)
+		}
+		commitIDs[i] = commitID.String()
+	}
+	now := time.Now().UTC()
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		for i, update := range updates {
+			// keys are written in order, so concurrent batches do not deadlock
+			keys := make([]string, 0, len(update.Set))
+			for key := range update.Set {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				_, err := tx.Exec(`INSERT INTO catalog_commit_annotations (repository_id, commit_id, key, value, annotator, update_date)
+					VALUES ($1, $2, $3, $4, $5, $6)
+					ON CONFLICT (repository_id, commit_id, key) DO UPDATE SET value = EXCLUDED.value,
+						annotator = EXCLUDED.annotator, update_date = EXCLUDED.update_date`,
+					repository, commitIDs[i], key, update.Set[key], annotator, now)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if len(update.Unset) > 0 {
+				_, err := tx.Exec(`DELETE FROM catalog_commit_annotations WHERE repository_id = $1 AND commit_id = $2 AND key = ANY($3)`,
+					repository, commitIDs[i], update.Unset)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		results := make([]*CommitAnnotations, len(commitIDs))
+		for i, commitID := range commitIDs {
+			annotations, err := selectCommitAnnotations(tx, repository, commitID)
+			if err != nil {
+				return nil, err
+			}
+			results[i] = annotations
+		}
+		return results, nil
+	}, db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.([]*CommitAnnotations), nil
+}
+
+// GetCommitAnnotations returns the annotations of the commit of reference
+func (c *cataloger) GetCommitAnnotations(ctx context.Context, repository, reference string) (*CommitAnnotations, error) {
+	repositoryID := graveler.RepositoryID(repository)
+	if err := Validate([]ValidateArg{
+		{"repository", repositoryID, ValidateRepositoryID},
+		{"reference", graveler.Ref(reference), ValidateRef},
+	}); err != nil {
+		return nil, err
+	}
+	commitID, err := c.EntryCatalog.Dereference(ctx, repositoryID, graveler.Ref(reference))
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		return selectCommitAnnotations(tx, repository, commitID.String())
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.(*CommitAnnotations), nil
+}
+
+func selectCommitAnnotations(tx db.Tx, repository, commitID string) (*CommitAnnotations, error) {
+	annotations := make([]*CommitAnnotation, 0)
+	err := tx.Select(&annotations, `SELECT key, value, annotator, update_date
+		FROM catalog_commit_annotations WHERE repository_id = $1 AND commit_id = $2 ORDER BY key`,
+		repository, commitID)
+	if err != nil {
+		return nil, err
+	}
+	return &CommitAnnotations{CommitID: commitID, Annotations: annotations}, nil
+}
@@ -0,0 +1,37 @@
+package catalog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateCommitAnnotationUpdates(t *testing.T) {
+	tests := []struct {
+		name    string
+		updates []CommitAnnotationUpdate
+		wantErr bool
+	}{
+		{name: "valid", updates: []CommitAnnotationUpdate{
+			{Reference: "main", Set: map[string]string{"quality": "0.97"}},
+			{Reference: "a1b2c3", Set: map[string]string{"label": "golden"}, Unset: []string{"quality"}},
+		}},
+		{name: "unset only", updates: []CommitAnnotationUpdate{{Reference: "main", Unset: []string{"quality"}}}},
+		{name: "empty value", updates: []CommitAnnotationUpdate{{Reference: "main", Set: map[string]string{"label": ""}}}},
+		{name: "no updates", wantErr: true},
+		{name: "too many updates", updates: make([]CommitAnnotationUpdate, MaxCommitAnnotationUpdates+1), wantErr: true},
+		{name: "no reference", updates: []CommitAnnotationUpdate{{Set: map[string]string{"quality": "0.97"}}}, wantErr: true},
+		{name: "no change", updates: []CommitAnnotationUpdate{{Reference: "main"}}, wantErr: true},
+		{name: "empty key", updates: []CommitAnnotationUpdate{{Reference: "main", Set: map[string]string{"": "x"}}}, wantErr: true},
+		{name: "long key", updates: []CommitAnnotationUpdate{{Reference: "main", Unset: []string{strings.Repeat("k", MaxCommitAnnotationKeyLength+1)}}}, wantErr: true},
+		{name: "long value", updates: []CommitAnnotationUpdate{{Reference: "main", Set: map[string]string{"notes": strings.Repeat("v", MaxCommitAnnotationValueLength+1)}}}, wantErr: true},
+		{name: "set and unset", updates: []CommitAnnotationUpdate{{Reference: "main", Set: map[string]string{"label": "x"}, Unset: []string{"label"}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCommitAnnotationUpdates(tt.updates)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateCommitAnnotationUpdates() err=%v, expected error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
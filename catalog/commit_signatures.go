@@ -0,0 +1,91 @@
+package catalog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/treeverse/lakefs/graveler"
+)
+
+const (
+	MaxCommitSignatureNameLength  = 256
+	MaxCommitSignatureEmailLength = 256
+	// MaxCommitAuthorDateSkew is how far ahead of the server the clock of a client supplying an
+	// author date may be.  Author dates are recorded as given, the server dates the commit.
+	MaxCommitAuthorDateSkew = 5 * time.Minute
+)
+
+// CommitParams are the details of a new commit.  Author, AuthorEmail and AuthorDate are set when
+// the change was made by someone other than the committer or at another time, like git.
+type CommitParams struct {
+	Committer      string
+	CommitterEmail string
+	Message        string
+	Metadata       Metadata
+	Author         string
+	AuthorEmail    string
+	AuthorDate     time.Time
+	// AuthorizeLineageInput is called with the repository of each lineage input declared in
+	// Metadata before resolving it, nil allows every repository
+	AuthorizeLineageInput func(repository string) error
+}
+
+// validateSignatureField rejects values that cannot be written as part of a git signature
+func validateSignatureField(name, value string, maxLength int) error {
+	if len(value) > maxLength {
+		return fmt.Errorf("%s longer than %d: %w", name, maxLength, ErrInvalidValue)
+	}
+	if strings.ContainsAny(value, "<>\n\r\x00") {
+		return fmt.Errorf("%s '%s' contains a forbidden character: %w", name, value, ErrInvalidValue)
+	}
+	return nil
+}
+
+func validateSignatureEmail(name, value string) error {
+	if err := validateSignatureField(name, value, MaxCommitSignatureEmailLength); err != nil {
+		return err
+	}
+	if value != "" && !strings.Contains(value, "@") {
+		return fmt.Errorf("%s '%s' is not an address: %w", name, value, ErrInvalidValue)
+	}
+	return nil
+}
+
+func validateCommitParams(params CommitParams) error {
+	if err := validateSignatureField("committer", params.Committer, MaxCommitSignatureNameLength); err != nil {
+		return err
+	}
+	if err := validateSignatureEmail("committer email", params.CommitterEmail); err != nil {
+		return err
+	}
+	if err := validateSignatureField("author", params.Author, MaxCommitSignatureNameLength); err != nil {
+		return err
+	}
+	if err := validateSignatureEmail("author email", params.AuthorEmail); err != nil {
+		return err
+	}
+	// the change cannot be authored after it is committed, up to the skew of the client clock
+	if params.AuthorDate.After(time.Now().Add(MaxCommitAuthorDateSkew)) {
+		return fmt.Errorf("author date %s in the future: %w", params.AuthorDate, ErrInvalidValue)
+	}
+	return nil
+}
+
+// setCommitLogSignatures sets the committer email and the author of log from commit.  Commits
+// without an author were authored by their committer when they were created.
+func setCommitLogSignatures(log *CommitLog, commit *graveler.Commit) {
+	log.CommitterEmail = commit.CommitterEmail
+	log.Author = commit.Author
+	log.AuthorEmail = commit.AuthorEmail
+	log.AuthorDate = commit.AuthorDate
+	if log.Author == "" {
+		log.Author = commit.Committer
+		if log.AuthorEmail == "" {
+			log.AuthorEmail = commit.CommitterEmail
+		}
+	}
+	if log.AuthorDate.IsZero() {
+		log.AuthorDate = commit.CreationDate
+	}
+}
@@ -0,0 +1,77 @@
+package catalog
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+func TestValidateCommitParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  CommitParams
+		wantErr bool
+	}{
+		{name: "committer", params: CommitParams{Committer: "barak"}},
+		{name: "author", params: CommitParams{Committer: "ci", Author: "Barak", AuthorEmail: "barak@example.com", AuthorDate: time.Now().Add(-time.Hour)}},
+		{name: "committer email", params: CommitParams{Committer: "barak", CommitterEmail: "barak@example.com"}},
+		{name: "angle bracket in author", params: CommitParams{Committer: "ci", Author: "Barak <barak@example.com>"}, wantErr: true},
+		{name: "newline in author", params: CommitParams{Committer: "ci", Author: "Barak\nOz"}, wantErr: true},
+		{name: "long author", params: CommitParams{Committer: "ci", Author: strings.Repeat("a", MaxCommitSignatureNameLength+1)}, wantErr: true},
+		{name: "invalid author email", params: CommitParams{Committer: "ci", AuthorEmail: "barak"}, wantErr: true},
+		{name: "invalid committer email", params: CommitParams{Committer: "ci", CommitterEmail: "ci"}, wantErr: true},
+		{name: "skewed author date", params: CommitParams{Committer: "ci", AuthorDate: time.Now().Add(time.Minute)}},
+		{name: "future author date", params: CommitParams{Committer: "ci", AuthorDate: time.Now().Add(time.Hour)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCommitParams(tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateCommitParams() err=%v, expected error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSetCommitLogSignatures(t *testing.T) {
+	created := time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC)
+	authored := created.Add(-24 * time.Hour)
+	tests := []struct {
+		name   string
+		commit graveler.Commit
+		want   CommitLog
+	}{
+		{
+			name:   "committer is the author",
+			commit: graveler.Commit{Committer: "barak", CommitterEmail: "barak@example.com", CreationDate: created},
+			want:   CommitLog{CommitterEmail: "barak@example.com", Author: "barak", AuthorEmail: "barak@example.com", AuthorDate: created},
+		},
+		{
+			name:   "author",
+			commit: graveler.Commit{Committer: "ci", CommitterEmail: "ci@example.com", CreationDate: created, Author: "Oz", AuthorEmail: "oz@example.com", AuthorDate: authored},
+			want:   CommitLog{CommitterEmail: "ci@example.com", Author: "Oz", AuthorEmail: "oz@example.com", AuthorDate: authored},
+		},
+		{
+			name:   "author without email",
+			commit: graveler.Commit{Committer: "ci", CommitterEmail: "ci@example.com", CreationDate: created, Author: "Oz"},
+			want:   CommitLog{CommitterEmail: "ci@example.com", Author: "Oz", AuthorDate: created},
+		},
+		{
+			name:   "author date only",
+			commit: graveler.Commit{Committer: "barak", CreationDate: created, AuthorDate: authored},
+			want:   CommitLog{Author: "barak", AuthorDate: authored},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log CommitLog
+			setCommitLogSignatures(&log, &tt.commit)
+			if diff := deep.Equal(log, tt.want); diff != nil {
+				t.Errorf("setCommitLogSignatures() diff %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+const (
+	CommitStatusPending = "pending"
+	CommitStatusPassed  = "passed"
+	CommitStatusFailed  = "failed"
+)
+
+// CommitStatus is the state of a verification check of a commit, reported by hooks and by
+// external CI.  A commit has one status per check, set again as the check progresses.
+type CommitStatus struct {
+	// Context names the check
+	Context     string `db:"context"`
+	State       string `db:"state"`
+	Description string `db:"description"`
+	// TargetURL links to the details of the check
+	TargetURL  string    `db:"target_url"`
+	Creator    string    `db:"creator"`
+	UpdateDate time.Time `db:"update_date"`
+}
+
+// CommitVerification is the combined status of the checks of a commit: failed if a check
+// failed, passed if all checks passed, and pending otherwise, including before any check
+// reported.
+type CommitVerification struct {
+	CommitID string
+	State    string
+	Statuses []*CommitStatus
+}
+
+func validateCommitStatus(status CommitStatus) error {
+	if status.Context == "" {
+		return fmt.Errorf("context: %w", ErrInvalidValue)
+	}
+	switch status.State {
+	case CommitStatusPending, CommitStatusPassed, CommitStatusFailed:
+	default:
+		return fmt.Errorf("state %s: %w", status.State, ErrInvalidValue)
+	}
+	if status.TargetURL != "" {
+		u, err := url.Parse(status.TargetURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("target_url: %w", ErrInvalidValue)
+		}
+	}
+	return nil
+}
+
+// SetCommitStatus sets the status of a check of the commit of reference
+func (c *cataloger) SetCommitStatus(ctx context.Context, repository, reference string, status CommitStatus) error {
+	repositoryID := graveler.RepositoryID(repository)
+	if err := Validate([]ValidateArg{
+		{"repository", repositoryID, ValidateRepositoryID},
+		{"reference", graveler.Ref(reference), ValidateRef},
+	}); err != nil {
+		return err
+	}
+	if err := validateCommitStatus(status); err != nil {
+		return err
+	}
+	commitID, err := c.EntryCatalog.Dereference(ctx, repositoryID, graveler.Ref(reference))
+	if err != nil {
+		return err
+	}
+	_, err = c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		return tx.Exec(`INSERT INTO catalog_commit_statuses (repository_id, commit_id, context, state, description, target_url, creator, update_date)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			ON CONFLICT (repository_id, commit_id, context) DO UPDATE SET state = EXCLUDED.state,
+				description = EXCLUDED.description, target_url = EXCLUDED.target_url,
+				creator = EXCLUDED.creator, update_date = EXCLUDED.update_date`,
+			repository, commitID.String(), status.Context, status.State, status.Description, status.TargetURL, status.Creator, time.Now().UTC())
+	}, db.WithContext(ctx))
+	return err
+}
+
+// GetCommitVerification returns the statuses of the checks of the commit of reference
+func (c *cataloger) GetCommitVerification(ctx context.Context, repository, reference string) (*CommitVerification, error) {
+	repositoryID := graveler.RepositoryID(repository)
+	if err := Validate([]ValidateArg{
+		{"repository", repositoryID, ValidateRepositoryID},
+		{"reference", graveler.Ref(reference), ValidateRef},
+	}); err != nil {
+		return nil, err
+	}
+	commitID, err := c.EntryCatalog.Dereference(ctx, repositoryID, graveler.Ref(reference))
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := c.commitStatuses(ctx, repository, commitID)
+	if err != nil {
+		return nil, err
+	}
+	return &CommitVerification{
+		CommitID: commitID.String(),
+		State:    combinedCommitState(statuses),
+		Statuses: statuses,
+	}, nil
+}
+
+func combinedCommitState(statuses []*CommitStatus) string {
+	if len(statuses) == 0 {
+		return CommitStatusPending
+	}
+	state := CommitStatusPassed
+	for _, status := range statuses {
+		switch status.State {
+		case CommitStatusFailed:
+			return CommitStatusFailed
+		case CommitStatusPending:
+			state = CommitStatusPending
+		}
+	}
+	return state
+}
+
+// statusViolations lists the status checks required by the guardrails that did not pass
+func (g *MergeGuardrails) statusViolations(statuses []*CommitStatus) []string {
+	states := make(map[string]string, len(statuses))
+	for _, status := range statuses {
+		states[status.Context] = status.State
+	}
+	var violations []string
+	for _, check := range g.RequiredStatusChecks {
+		state, ok := states[check]
+		switch {
+		case !ok:
+			violations = append(violations, fmt.Sprintf("status check %s not reported", check))
+		case state != CommitStatusPassed:
+			violations = append(violations, fmt.Sprintf("status check %s is %s", check, state))
+		}
+	}
+	return violations
+}
+
+// commitStatuses returns the statuses reported on commitID, by context
+func (c *cataloger) commitStatuses(ctx context.Context, repository string, commitID graveler.CommitID) ([]*CommitStatus, error) {
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var statuses []*CommitStatus
+		err := tx.Select(&statuses, `SELECT context, state, description, target_url, creator, update_date
+			FROM catalog_commit_statuses WHERE repository_id = $1 AND commit_id = $2 ORDER BY context`,
+			repository, commitID.String())
+		return statuses, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.([]*CommitStatus), nil
+}
@@ -0,0 +1,75 @@
+package catalog
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestCombinedCommitState(t *testing.T) {
+	tests := []struct {
+		name   string
+		states []string
+		want   string
+	}{
+		{name: "none", want: CommitStatusPending},
+		{name: "passed", states: []string{CommitStatusPassed, CommitStatusPassed}, want: CommitStatusPassed},
+		{name: "pending", states: []string{CommitStatusPassed, CommitStatusPending}, want: CommitStatusPending},
+		{name: "failed", states: []string{CommitStatusPending, CommitStatusFailed, CommitStatusPassed}, want: CommitStatusFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statuses := make([]*CommitStatus, len(tt.states))
+			for i, state := range tt.states {
+				statuses[i] = &CommitStatus{Context: tt.name, State: state}
+			}
+			if got := combinedCommitState(statuses); got != tt.want {
+				t.Errorf("combinedCommitState() = %s, expected %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateCommitStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  CommitStatus
+		wantErr bool
+	}{
+		{name: "valid", status: CommitStatus{Context: "ci/tests", State: CommitStatusPassed, TargetURL: "https://ci.example.com/runs/1"}},
+		{name: "no context", status: CommitStatus{State: CommitStatusPassed}, wantErr: true},
+		{name: "unknown state", status: CommitStatus{Context: "ci/tests", State: "success"}, wantErr: true},
+		{name: "bad target url", status: CommitStatus{Context: "ci/tests", State: CommitStatusFailed, TargetURL: "ci.example.com"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCommitStatus(tt.status)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateCommitStatus() err=%v, expected error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMergeGuardrails_StatusViolations(t *testing.T) {
+	guardrails := MergeGuardrails{MaxChangedEntries: 10, RequiredStatusChecks: []string{"ci/tests", "quality", "review"}}
+	violations := guardrails.statusViolations([]*CommitStatus{
+		{Context: "ci/tests", State: CommitStatusPassed},
+		{Context: "quality", State: CommitStatusFailed},
+		{Context: "other", State: CommitStatusPending},
+	})
+	expected := []string{"status check quality is failed", "status check review not reported"}
+	if diff := deep.Equal(violations, expected); diff != nil {
+		t.Fatal("statusViolations() diff found", diff)
+	}
+
+	err := guardrails.check("main", 20, 0, 0, violations...)
+	var guardrailsErr *MergeGuardrailsError
+	if !errors.As(err, &guardrailsErr) {
+		t.Fatalf("check() error = %v, expected MergeGuardrailsError", err)
+	}
+	if len(guardrailsErr.Violations) != 3 {
+		t.Errorf("check() violations = %v, expected 3", guardrailsErr.Violations)
+	}
+}
@@ -0,0 +1,52 @@
+package catalog
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/treeverse/lakefs/graveler"
+)
+
+var ErrInvalidContinuationToken = errors.New("invalid continuation token")
+
+// listingContinuationToken is the opaque pagination token returned by ListEntriesWithToken.
+// It pins the snapshot the first page was read from, so later pages come from the same state.
+// The token is not signed, a staging area it pins is only listed for the repository and branch
+// it was copied from.  A read replica cannot pin snapshots, its tokens for a branch are Live and
+// later pages list the branch as it is when they are read.
+type listingContinuationToken struct {
+	Ref          string `json:"r"`
+	CommitID     string `json:"c,omitempty"`
+	StagingToken string `json:"s,omitempty"`
+	Live         bool   `json:"l,omitempty"`
+	After        string `json:"a"`
+}
+
+func (t *listingContinuationToken) snapshot() graveler.ListingSnapshot {
+	return graveler.ListingSnapshot{
+		CommitID:     graveler.CommitID(t.CommitID),
+		BranchID:     graveler.BranchID(t.Ref),
+		StagingToken: graveler.StagingToken(t.StagingToken),
+	}
+}
+
+func encodeContinuationToken(t *listingContinuationToken) (string, error) {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeContinuationToken(token string) (*listingContinuationToken, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidContinuationToken
+	}
+	var t listingContinuationToken
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, ErrInvalidContinuationToken
+	}
+	return &t, nil
+}
@@ -0,0 +1,402 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/encryption"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/xitongsys/parquet-go/parquet"
+)
+
+const (
+	TableFormatParquet = "parquet"
+	TableFormatCSV     = "csv"
+
+	// DataDiffLimitMax is the maximal number of differences whose data is compared at once
+	DataDiffLimitMax = 100
+	// DataDiffCSVSampleBytes is the size of the prefix of CSV objects read to find their columns
+	// and to estimate their row count
+	DataDiffCSVSampleBytes = 1024 * 1024
+
+	// parquetTailSize is the size of the footer length and magic ending Parquet files
+	parquetTailSize = 8
+	parquetMagic    = "PAR1"
+	// parquetMaxFooterSize bounds the footer read from objects that only look like Parquet files
+	parquetMaxFooterSize = 64 * 1024 * 1024
+)
+
+var ErrInvalidTable = errors.New("invalid table")
+
+// TableColumn is a column of a tabular object
+type TableColumn struct {
+	Name string
+	Type string
+}
+
+// TableStats describe the data of a tabular object
+type TableStats struct {
+	Format   string
+	RowCount int64
+	// RowCountEstimated is set when RowCount is extrapolated from a sample of the object
+	RowCountEstimated bool
+	Columns           []TableColumn
+}
+
+// DataDifference is a difference of a tabular object with the stats of its data on each side,
+// nil on the side missing the object
+type DataDifference struct {
+	Difference
+	Left  *TableStats
+	Right *TableStats
+	// AddedColumns, RemovedColumns and ChangedColumns are the schema delta from Left to Right
+	AddedColumns   []string
+	RemovedColumns []string
+	ChangedColumns []string
+	// Error is set if the data of a side could not be read, the difference is then reported
+	// without stats
+	Error string
+}
+
+// RowCountDelta returns the change of the number of rows from Left to Right
+func (d *DataDifference) RowCountDelta() int64 {
+	var delta int64
+	if d.Left != nil {
+		delta -= d.Left.RowCount
+	}
+	if d.Right != nil {
+		delta += d.Right.RowCount
+	}
+	return delta
+}
+
+// TableFormat returns the format of the tabular object at path by its extension, or an empty
+// string if it is not a tabular object
+func TableFormat(path string) string {
+	lower := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(lower, ".parquet"):
+		return TableFormatParquet
+	case strings.HasSuffix(lower, ".csv"):
+		return TableFormatCSV
+	default:
+		return ""
+	}
+}
+
+// DiffData compares the data of the tabular objects in diffs, differences between
+// leftReference and rightReference as returned by Diff.  Parquet objects are described by their
+// footer, CSV objects by a sample of their first rows.  Differences of other objects are
+// returned without stats.
+func (c *cataloger) DiffData(ctx context.Context, repository, leftReference, rightReference string, diffs Differences) ([]*DataDifference, error) {
+	repositoryID := graveler.RepositoryID(repository)
+	if err := Validate([]ValidateArg{
+		{"repository", repositoryID, ValidateRepositoryID},
+		{"left_reference", graveler.Ref(leftReference), ValidateRef},
+		{"right_reference", graveler.Ref(rightReference), ValidateRef},
+	}); err != nil {
+		return nil, err
+	}
+	if len(diffs) > DataDiffLimitMax {
+		return nil, fmt.Errorf("%w: %d differences above maximum (%d)", ErrInvalidValue, len(diffs), DataDiffLimitMax)
+	}
+	repo, err := c.EntryCatalog.GetRepository(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*DataDifference, len(diffs))
+	for i, diff := range diffs {
+		result := &DataDifference{Difference: diff}
+		results[i] = result
+		format := TableFormat(diff.Path)
+		if format == "" {
+			continue
+		}
+		// the entry of a difference is its left side for removals and its right side otherwise
+		var left, right *DBEntry
+		switch diff.Type {
+		case DifferenceTypeRemoved:
+			left = &results[i].DBEntry
+		case DifferenceTypeAdded:
+			right = &results[i].DBEntry
+		default:
+			right = &results[i].DBEntry
+			ent, err := c.EntryCatalog.GetEntry(ctx, repositoryID, graveler.Ref(leftReference), Path(diff.Path))
+			if err != nil && !errors.Is(err, graveler.ErrNotFound) {
+				return nil, err
+			}
+			if ent != nil {
+				leftEntry := newCatalogEntryFromEntry(false, diff.Path, ent)
+				left = &leftEntry
+			}
+		}
+		if err := c.fillDataDifference(repo.StorageNamespace.String(), format, left, right, result); err != nil {
+			result.Left, result.Right = nil, nil
+			result.Error = err.Error()
+		}
+	}
+	return results, nil
+}
+
+func (c *cataloger) fillDataDifference(storageNamespace, format string, left, right *DBEntry, result *DataDifference) error {
+	var err error
+	if left != nil {
+		if result.Left, err = c.readTableStats(storageNamespace, format, left); err != nil {
+			return fmt.Errorf("left: %w", err)
+		}
+	}
+	if right != nil {
+		if result.Right, err = c.readTableStats(storageNamespace, format, right); err != nil {
+			return fmt.Errorf("right: %w", err)
+		}
+	}
+	if result.Left != nil && result.Right != nil {
+		result.AddedColumns, result.RemovedColumns, result.ChangedColumns = columnsDelta(result.Left.Columns, result.Right.Columns)
+	}
+	return nil
+}
+
+func (c *cataloger) readTableStats(storageNamespace, format string, entry *DBEntry) (*TableStats, error) {
+	if encryption.IsEncrypted(entry.Metadata) {
+		return nil, fmt.Errorf("%w: encrypted object", ErrInvalidTable)
+	}
+	r := &tableReader{
+		adapter: c.EntryCatalog.BlockAdapter,
+		pointer: block.ObjectPointer{StorageNamespace: storageNamespace, Identifier: entry.PhysicalAddress},
+		size:    entry.Size,
+	}
+	switch format {
+	case TableFormatParquet:
+		return r.parquetStats()
+	case TableFormatCSV:
+		return r.csvStats()
+	default:
+		return nil, fmt.Errorf("%w: unknown format %s", ErrInvalidTable, format)
+	}
+}
+
+// columnsDelta returns the names of the columns added, removed and changed type from left to right
+func columnsDelta(left, right []TableColumn) (added, removed, changed []string) {
+	leftTypes := make(map[string]string, len(left))
+	for _, col := range left {
+		leftTypes[col.Name] = col.Type
+	}
+	rightTypes := make(map[string]string, len(right))
+	for _, col := range right {
+		rightTypes[col.Name] = col.Type
+		leftType, ok := leftTypes[col.Name]
+		switch {
+		case !ok:
+			added = append(added, col.Name)
+		case leftType != col.Type:
+			changed = append(changed, col.Name)
+		}
+	}
+	for _, col := range left {
+		if _, ok := rightTypes[col.Name]; !ok {
+			removed = append(removed, col.Name)
+		}
+	}
+	return added, removed, changed
+}
+
+// tableReader reads ranges of a tabular object through the block adapter
+type tableReader struct {
+	adapter block.Adapter
+	pointer block.ObjectPointer
+	size    int64
+}
+
+func (r *tableReader) readRange(start, length int64) ([]byte, error) {
+	rc, err := r.adapter.GetRange(r.pointer, start, start+length-1)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+	data, err := ioutil.ReadAll(io.LimitReader(rc, length))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) != length {
+		return nil, fmt.Errorf("%w: short read at %d", ErrInvalidTable, start)
+	}
+	return data, nil
+}
+
+// parquetFooter reads the file metadata from the footer of a Parquet object
+func (r *tableReader) parquetFooter() (*parquet.FileMetaData, error) {
+	if r.size < parquetTailSize+int64(len(parquetMagic)) {
+		return nil, fmt.Errorf("%w: too small for parquet", ErrInvalidTable)
+	}
+	tail, err := r.readRange(r.size-parquetTailSize, parquetTailSize)
+	if err != nil {
+		return nil, err
+	}
+	if string(tail[4:]) != parquetMagic {
+		return nil, fmt.Errorf("%w: missing parquet magic", ErrInvalidTable)
+	}
+	footerSize := int64(binary.LittleEndian.Uint32(tail[:4]))
+	if footerSize > parquetMaxFooterSize || footerSize > r.size-parquetTailSize {
+		return nil, fmt.Errorf("%w: parquet footer size %d", ErrInvalidTable, footerSize)
+	}
+	footer, err := r.readRange(r.size-parquetTailSize-footerSize, footerSize)
+	if err != nil {
+		return nil, err
+	}
+	metadata := parquet.NewFileMetaData()
+	protocol := thrift.NewTCompactProtocol(thrift.NewStreamTransportR(bytes.NewReader(footer)))
+	if err := metadata.Read(protocol); err != nil {
+		return nil, fmt.Errorf("%w: parquet footer: %s", ErrInvalidTable, err)
+	}
+	return metadata, nil
+}
+
+// parquetStats reads the row count and schema from the footer of a Parquet object
+func (r *tableReader) parquetStats() (*TableStats, error) {
+	metadata, err := r.parquetFooter()
+	if err != nil {
+		return nil, err
+	}
+	return &TableStats{
+		Format:   TableFormatParquet,
+		RowCount: metadata.GetNumRows(),
+		Columns:  parquetColumns(metadata.GetSchema()),
+	}, nil
+}
+
+// parquetColumns returns the leaf columns of a flattened Parquet schema, nested column names
+// joined by dots
+func parquetColumns(schema []*parquet.SchemaElement) []TableColumn {
+	var columns []TableColumn
+	var walk func(i int, prefix string) int
+	walk = func(i int, prefix string) int {
+		element := schema[i]
+		name := prefix + element.GetName()
+		i++
+		if element.GetNumChildren() == 0 {
+			columns = append(columns, TableColumn{Name: name, Type: parquetColumnType(element)})
+			return i
+		}
+		for child := int32(0); child < element.GetNumChildren() && i < len(schema); child++ {
+			i = walk(i, name+".")
+		}
+		return i
+	}
+	if len(schema) == 0 {
+		return columns
+	}
+	// the first element is the root of the schema
+	i := 1
+	for child := int32(0); child < schema[0].GetNumChildren() && i < len(schema); child++ {
+		i = walk(i, "")
+	}
+	return columns
+}
+
+func parquetColumnType(element *parquet.SchemaElement) string {
+	if element.IsSetConvertedType() {
+		return element.GetConvertedType().String()
+	}
+	return element.GetType().String()
+}
+
+// csvStats reads the header and a sample of the rows of a CSV object.  The row count is exact
+// if the sample holds the whole object, otherwise it is extrapolated from the sample.
+func (r *tableReader) csvStats() (*TableStats, error) {
+	stats := &TableStats{Format: TableFormatCSV}
+	if r.size == 0 {
+		return stats, nil
+	}
+	sampleSize := r.size
+	if sampleSize > DataDiffCSVSampleBytes {
+		sampleSize = DataDiffCSVSampleBytes
+		stats.RowCountEstimated = true
+	}
+	sample, err := r.readRange(0, sampleSize)
+	if err != nil {
+		return nil, err
+	}
+	if stats.RowCountEstimated {
+		// drop the last row, it may be cut by the end of the sample
+		if end := bytes.LastIndexByte(sample, '\n'); end >= 0 {
+			sample = sample[:end+1]
+		}
+	}
+	reader := csv.NewReader(bytes.NewReader(sample))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: csv header: %s", ErrInvalidTable, err)
+	}
+	columnValues := make([][]string, len(header))
+	var rows int64
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: csv row %d: %s", ErrInvalidTable, rows+1, err)
+		}
+		rows++
+		for i := range header {
+			if i < len(record) {
+				columnValues[i] = append(columnValues[i], record[i])
+			}
+		}
+	}
+	stats.Columns = make([]TableColumn, len(header))
+	for i, name := range header {
+		stats.Columns[i] = TableColumn{Name: name, Type: csvColumnType(columnValues[i])}
+	}
+	stats.RowCount = rows
+	if stats.RowCountEstimated {
+		stats.RowCount = rows * r.size / int64(len(sample))
+	}
+	return stats, nil
+}
+
+// csvColumnType infers the type of a CSV column from its sampled values, ignoring empty values
+func csvColumnType(values []string) string {
+	isInt, isFloat, isBool, seen := true, true, true, false
+	for _, v := range values {
+		if v == "" {
+			continue
+		}
+		seen = true
+		if _, err := strconv.ParseInt(v, 10, 64); err != nil {
+			isInt = false
+		}
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			isFloat = false
+		}
+		if _, err := strconv.ParseBool(v); err != nil {
+			isBool = false
+		}
+	}
+	switch {
+	case !seen:
+		return "string"
+	case isInt:
+		return "integer"
+	case isFloat:
+		return "float"
+	case isBool:
+		return "boolean"
+	default:
+		return "string"
+	}
+}
@@ -0,0 +1,190 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/block/mem"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/xitongsys/parquet-go-source/writerfile"
+	"github.com/xitongsys/parquet-go/writer"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type dataDiffRecordV1 struct {
+	ID   int64  `parquet:"name=id, type=INT64"`
+	Name string `parquet:"name=name, type=UTF8"`
+	Age  int32  `parquet:"name=age, type=INT32"`
+}
+
+type dataDiffRecordV2 struct {
+	ID    int64   `parquet:"name=id, type=INT64"`
+	Name  string  `parquet:"name=name, type=UTF8"`
+	Age   int64   `parquet:"name=age, type=INT64"`
+	Score float64 `parquet:"name=score, type=DOUBLE"`
+}
+
+func writeTestParquet(t *testing.T, obj interface{}, records ...interface{}) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := writer.NewParquetWriter(writerfile.NewWriterFile(&buf), obj, 1)
+	if err != nil {
+		t.Fatalf("parquet writer: %s", err)
+	}
+	for _, r := range records {
+		if err := w.Write(r); err != nil {
+			t.Fatalf("parquet write: %s", err)
+		}
+	}
+	if err := w.WriteStop(); err != nil {
+		t.Fatalf("parquet write stop: %s", err)
+	}
+	return buf.Bytes()
+}
+
+func TestCataloger_DiffData(t *testing.T) {
+	ctx := context.Background()
+	const storageNamespace = "mem://data-diff"
+	adapter := mem.New()
+	put := func(address string, data []byte) *Entry {
+		err := adapter.Put(block.ObjectPointer{StorageNamespace: storageNamespace, Identifier: address}, int64(len(data)), bytes.NewReader(data), block.PutOpts{})
+		if err != nil {
+			t.Fatalf("put %s: %s", address, err)
+		}
+		return &Entry{Address: address, Size: int64(len(data)), LastModified: timestamppb.New(time.Now())}
+	}
+	dbEntry := func(path string, ent *Entry) DBEntry {
+		return newCatalogEntryFromEntry(false, path, ent)
+	}
+
+	parquetV1 := put("parquet-v1", writeTestParquet(t, new(dataDiffRecordV1),
+		dataDiffRecordV1{ID: 1, Name: "a", Age: 10},
+		dataDiffRecordV1{ID: 2, Name: "b", Age: 20}))
+	parquetV2 := put("parquet-v2", writeTestParquet(t, new(dataDiffRecordV2),
+		dataDiffRecordV2{ID: 1, Name: "a", Age: 10, Score: 1.5},
+		dataDiffRecordV2{ID: 2, Name: "b", Age: 20, Score: 2.5},
+		dataDiffRecordV2{ID: 3, Name: "c", Age: 30, Score: 3.5}))
+	csvLeft := put("csv-left", []byte("id,name,active\n1,a,true\n2,b,false\n"))
+	csvRight := put("csv-right", []byte("id,name,score\n1,a,1.5\n"))
+	broken := put("broken", []byte("not parquet"))
+	text := put("text", []byte("text"))
+
+	c := &cataloger{
+		EntryCatalog: &EntryCatalog{
+			Store: &FakeGraveler{
+				KeyValue: map[string]*graveler.Value{
+					"repo/main/tables/users.parquet": MustEntryToValue(parquetV1),
+					"repo/main/tables/users.csv":     MustEntryToValue(csvLeft),
+				},
+				Repositories: map[graveler.RepositoryID]*graveler.Repository{
+					"repo": {StorageNamespace: storageNamespace, DefaultBranchID: "main"},
+				},
+			},
+			BlockAdapter: adapter,
+		},
+	}
+	diffs := Differences{
+		{Type: DifferenceTypeAdded, DBEntry: dbEntry("tables/broken.parquet", broken)},
+		{Type: DifferenceTypeAdded, DBEntry: dbEntry("tables/readme.txt", text)},
+		{Type: DifferenceTypeChanged, DBEntry: dbEntry("tables/users.csv", csvRight)},
+		{Type: DifferenceTypeChanged, DBEntry: dbEntry("tables/users.parquet", parquetV2)},
+		{Type: DifferenceTypeRemoved, DBEntry: dbEntry("tables/users_v0.parquet", parquetV1)},
+	}
+	results, err := c.DiffData(ctx, "repo", "main", "feature", diffs)
+	if err != nil {
+		t.Fatalf("DiffData: %s", err)
+	}
+	if len(results) != len(diffs) {
+		t.Fatalf("DiffData returned %d results, expected %d", len(results), len(diffs))
+	}
+
+	if results[0].Error == "" || !strings.Contains(results[0].Error, "parquet") || results[0].Right != nil {
+		t.Errorf("broken parquet result %+v, expected an error", results[0])
+	}
+	if results[1].Left != nil || results[1].Right != nil || results[1].Error != "" {
+		t.Errorf("text result %+v, expected no stats", results[1])
+	}
+
+	csvDiff := results[2]
+	expectedCSV := []*TableStats{
+		{Format: TableFormatCSV, RowCount: 2, Columns: []TableColumn{{"id", "integer"}, {"name", "string"}, {"active", "boolean"}}},
+		{Format: TableFormatCSV, RowCount: 1, Columns: []TableColumn{{"id", "integer"}, {"name", "string"}, {"score", "float"}}},
+	}
+	if diff := deep.Equal([]*TableStats{csvDiff.Left, csvDiff.Right}, expectedCSV); diff != nil {
+		t.Error("csv stats diff found", diff)
+	}
+	if diff := deep.Equal([][]string{csvDiff.AddedColumns, csvDiff.RemovedColumns}, [][]string{{"score"}, {"active"}}); diff != nil {
+		t.Error("csv columns diff found", diff)
+	}
+	if delta := csvDiff.RowCountDelta(); delta != -1 {
+		t.Errorf("csv row count delta %d, expected -1", delta)
+	}
+
+	parquetDiff := results[3]
+	if parquetDiff.Error != "" {
+		t.Fatalf("parquet diff error: %s", parquetDiff.Error)
+	}
+	if parquetDiff.Left.RowCount != 2 || parquetDiff.Right.RowCount != 3 || parquetDiff.RowCountDelta() != 1 {
+		t.Errorf("parquet row counts %d -> %d, expected 2 -> 3", parquetDiff.Left.RowCount, parquetDiff.Right.RowCount)
+	}
+	expectedColumns := []TableColumn{{"id", "INT64"}, {"name", "UTF8"}, {"age", "INT32"}}
+	if diff := deep.Equal(parquetDiff.Left.Columns, expectedColumns); diff != nil {
+		t.Error("parquet columns diff found", diff)
+	}
+	if diff := deep.Equal([][]string{parquetDiff.AddedColumns, parquetDiff.RemovedColumns, parquetDiff.ChangedColumns},
+		[][]string{{"score"}, nil, {"age"}}); diff != nil {
+		t.Error("parquet schema delta diff found", diff)
+	}
+
+	removed := results[4]
+	if removed.Left == nil || removed.Right != nil || removed.RowCountDelta() != -2 {
+		t.Errorf("removed parquet result %+v, expected left stats only", removed)
+	}
+}
+
+func TestCataloger_DiffData_CSVSample(t *testing.T) {
+	ctx := context.Background()
+	const storageNamespace = "mem://data-diff-sample"
+	adapter := mem.New()
+	var data bytes.Buffer
+	data.WriteString("id,value\n")
+	for data.Len() < 3*DataDiffCSVSampleBytes {
+		data.WriteString("12345,abcdefghij\n")
+	}
+	const address = "large"
+	err := adapter.Put(block.ObjectPointer{StorageNamespace: storageNamespace, Identifier: address}, int64(data.Len()), bytes.NewReader(data.Bytes()), block.PutOpts{})
+	if err != nil {
+		t.Fatalf("put: %s", err)
+	}
+	c := &cataloger{
+		EntryCatalog: &EntryCatalog{
+			Store: &FakeGraveler{
+				Repositories: map[graveler.RepositoryID]*graveler.Repository{
+					"repo": {StorageNamespace: storageNamespace, DefaultBranchID: "main"},
+				},
+			},
+			BlockAdapter: adapter,
+		},
+	}
+	ent := &Entry{Address: address, Size: int64(data.Len()), LastModified: timestamppb.New(time.Now())}
+	results, err := c.DiffData(ctx, "repo", "main", "feature", Differences{
+		{Type: DifferenceTypeAdded, DBEntry: newCatalogEntryFromEntry(false, "large.csv", ent)},
+	})
+	if err != nil {
+		t.Fatalf("DiffData: %s", err)
+	}
+	stats := results[0].Right
+	if stats == nil || !stats.RowCountEstimated {
+		t.Fatalf("large csv stats %+v, expected an estimated row count", stats)
+	}
+	const lineSize = int64(len("12345,abcdefghij\n"))
+	exact := int64(data.Len()) / lineSize
+	if stats.RowCount < exact-exact/100 || stats.RowCount > exact+exact/100 {
+		t.Errorf("estimated row count %d, expected about %d", stats.RowCount, exact)
+	}
+}
@@ -0,0 +1,146 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+// Dataset is a named logical unit of data in a repository, spanning one or more path prefixes
+type Dataset struct {
+	Name         string            `db:"name"`
+	Prefixes     []string          `db:"prefixes"`
+	SchemaHints  map[string]string `db:"schema_hints"`
+	CreationDate time.Time         `db:"creation_date"`
+}
+
+func datasetPrefixes(dataset *Dataset) []Path {
+	prefixes := make([]Path, len(dataset.Prefixes))
+	for i, p := range dataset.Prefixes {
+		prefixes[i] = Path(p)
+	}
+	return prefixes
+}
+
+func validateDataset(repository string, dataset Dataset) error {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+		{"name", dataset.Name, ValidateDatasetName},
+	}); err != nil {
+		return err
+	}
+	if len(dataset.Prefixes) == 0 {
+		return fmt.Errorf("argument prefixes: %w", ErrRequiredValue)
+	}
+	for _, p := range dataset.Prefixes {
+		// an empty prefix covers the entire repository
+		if len(p) > MaxPathLength {
+			return fmt.Errorf("argument prefixes: %w: %d is above maximum length (%d)", ErrInvalidValue, len(p), MaxPathLength)
+		}
+	}
+	return nil
+}
+
+func (c *cataloger) CreateDataset(ctx context.Context, repository string, dataset Dataset) error {
+	if err := validateDataset(repository, dataset); err != nil {
+		return err
+	}
+	if _, err := c.EntryCatalog.GetRepository(ctx, graveler.RepositoryID(repository)); err != nil {
+		return err
+	}
+	_, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		return tx.Exec(`INSERT INTO catalog_datasets (repository_id, name, prefixes, schema_hints, creation_date)
+			VALUES ($1, $2, $3, $4, $5)`,
+			repository, dataset.Name, dataset.Prefixes, dataset.SchemaHints, time.Now().UTC())
+	}, db.WithContext(ctx))
+	if errors.Is(err, db.ErrAlreadyExists) {
+		return ErrDatasetAlreadyExists
+	}
+	return err
+}
+
+func (c *cataloger) GetDataset(ctx context.Context, repository string, name string) (*Dataset, error) {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+		{"name", name, ValidateDatasetName},
+	}); err != nil {
+		return nil, err
+	}
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var dataset Dataset
+		err := tx.Get(&dataset, `SELECT name, prefixes, schema_hints, creation_date
+			FROM catalog_datasets WHERE repository_id = $1 AND name = $2`,
+			repository, name)
+		return &dataset, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, ErrDatasetNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res.(*Dataset), nil
+}
+
+func (c *cataloger) ListDatasets(ctx context.Context, repository string) ([]*Dataset, error) {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+	}); err != nil {
+		return nil, err
+	}
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var datasets []*Dataset
+		err := tx.Select(&datasets, `SELECT name, prefixes, schema_hints, creation_date
+			FROM catalog_datasets WHERE repository_id = $1 ORDER BY name`,
+			repository)
+		return datasets, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.([]*Dataset), nil
+}
+
+func (c *cataloger) DeleteDataset(ctx context.Context, repository string, name string) error {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+		{"name", name, ValidateDatasetName},
+	}); err != nil {
+		return err
+	}
+	_, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		res, err := tx.Exec(`DELETE FROM catalog_datasets WHERE repository_id = $1 AND name = $2`,
+			repository, name)
+		if err != nil {
+			return nil, err
+		}
+		if res.RowsAffected() == 0 {
+			return nil, ErrDatasetNotFound
+		}
+		return nil, nil
+	}, db.WithContext(ctx))
+	return err
+}
+
+// DiffDataset lists the differences between two references limited to the prefixes of the dataset
+func (c *cataloger) DiffDataset(ctx context.Context, repository string, name string, leftReference string, rightReference string, params DiffParams) (Differences, bool, error) {
+	dataset, err := c.GetDataset(ctx, repository, name)
+	if err != nil {
+		return nil, false, err
+	}
+	typeMask, err := diffTypeMask(params.Types)
+	if err != nil {
+		return nil, false, err
+	}
+	it, err := c.EntryCatalog.Diff(ctx, graveler.RepositoryID(repository), graveler.Ref(leftReference), graveler.Ref(rightReference), typeMask)
+	if err != nil {
+		return nil, false, err
+	}
+	it = NewPrefixesDiffIterator(it, datasetPrefixes(dataset))
+	defer it.Close()
+	return listDiffHelper(it, params.Limit, params.After)
+}
@@ -0,0 +1,28 @@
+package catalog
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateDataset(t *testing.T) {
+	tests := []struct {
+		name    string
+		dataset Dataset
+		wantErr error
+	}{
+		{name: "valid", dataset: Dataset{Name: "events", Prefixes: []string{"events/", "archive/events/"}}},
+		{name: "entire repository", dataset: Dataset{Name: "all", Prefixes: []string{""}}},
+		{name: "missing name", dataset: Dataset{Prefixes: []string{"events/"}}, wantErr: ErrRequiredValue},
+		{name: "invalid name", dataset: Dataset{Name: "a b", Prefixes: []string{"events/"}}, wantErr: ErrInvalidValue},
+		{name: "missing prefixes", dataset: Dataset{Name: "events"}, wantErr: ErrRequiredValue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDataset("repo1", tt.dataset)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("validateDataset() error = %v, expected %v", err, tt.wantErr)
+			}
+		})
+	}
+}
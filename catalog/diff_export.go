@@ -0,0 +1,255 @@
+package catalog
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/jobs"
+	"github.com/xitongsys/parquet-go-source/writerfile"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+const (
+	DiffExportFormatParquet = "parquet"
+	DiffExportFormatCSV     = "csv"
+
+	// DiffExportPrefix is the prefix of diff exports in the repository storage namespace
+	DiffExportPrefix = "_lakefs/diffs/"
+
+	diffExportParquetParallelism = 4
+)
+
+// DiffExportRecord is a single difference in a diff export.  Sizes and ETags of the side missing
+// the entry are null.
+type DiffExportRecord struct {
+	Path      string  `parquet:"name=path, type=UTF8"`
+	Type      string  `parquet:"name=type, type=UTF8"`
+	LeftSize  *int64  `parquet:"name=left_size, type=INT64, repetitiontype=OPTIONAL"`
+	RightSize *int64  `parquet:"name=right_size, type=INT64, repetitiontype=OPTIONAL"`
+	LeftETag  *string `parquet:"name=left_etag, type=UTF8, repetitiontype=OPTIONAL"`
+	RightETag *string `parquet:"name=right_etag, type=UTF8, repetitiontype=OPTIONAL"`
+}
+
+var diffExportCSVHeader = []string{"path", "type", "left_size", "right_size", "left_etag", "right_etag"}
+
+// DiffExport is a diff between two references written to the repository storage namespace
+type DiffExport struct {
+	ID             string
+	Format         string
+	LeftReference  string
+	RightReference string
+	// Location is the qualified address of the export file
+	Location     string
+	Count        int
+	CreationDate time.Time
+}
+
+type diffExportWriter interface {
+	Write(record *DiffExportRecord) error
+	Close() error
+}
+
+type csvDiffExportWriter struct {
+	w *csv.Writer
+}
+
+func newCSVDiffExportWriter(f *os.File) (*csvDiffExportWriter, error) {
+	w := csv.NewWriter(f)
+	if err := w.Write(diffExportCSVHeader); err != nil {
+		return nil, err
+	}
+	return &csvDiffExportWriter{w: w}, nil
+}
+
+func csvOptionalInt(v *int64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatInt(*v, 10)
+}
+
+func csvOptionalString(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
+
+func (c *csvDiffExportWriter) Write(record *DiffExportRecord) error {
+	return c.w.Write([]string{
+		record.Path,
+		record.Type,
+		csvOptionalInt(record.LeftSize),
+		csvOptionalInt(record.RightSize),
+		csvOptionalString(record.LeftETag),
+		csvOptionalString(record.RightETag),
+	})
+}
+
+func (c *csvDiffExportWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type parquetDiffExportWriter struct {
+	w *writer.ParquetWriter
+}
+
+func newParquetDiffExportWriter(f *os.File) (*parquetDiffExportWriter, error) {
+	w, err := writer.NewParquetWriter(writerfile.NewWriterFile(f), new(DiffExportRecord), diffExportParquetParallelism)
+	if err != nil {
+		return nil, err
+	}
+	return &parquetDiffExportWriter{w: w}, nil
+}
+
+func (p *parquetDiffExportWriter) Write(record *DiffExportRecord) error {
+	return p.w.Write(record)
+}
+
+func (p *parquetDiffExportWriter) Close() error {
+	return p.w.WriteStop()
+}
+
+func newDiffExportWriter(format string, f *os.File) (diffExportWriter, error) {
+	switch format {
+	case DiffExportFormatCSV:
+		return newCSVDiffExportWriter(f)
+	case DiffExportFormatParquet:
+		return newParquetDiffExportWriter(f)
+	default:
+		return nil, fmt.Errorf("format %s: %w", format, ErrInvalidValue)
+	}
+}
+
+func diffExportTypeName(typ graveler.DiffType) string {
+	switch typ {
+	case graveler.DiffTypeAdded:
+		return "added"
+	case graveler.DiffTypeRemoved:
+		return "removed"
+	case graveler.DiffTypeChanged:
+		return "changed"
+	default:
+		return "conflict"
+	}
+}
+
+// ExportDiff writes the full diff between leftReference and rightReference to a file in format
+// under DiffExportPrefix in the repository storage namespace
+func (c *cataloger) ExportDiff(ctx context.Context, repository, leftReference, rightReference, format string) (*DiffExport, error) {
+	repositoryID := graveler.RepositoryID(repository)
+	repo, err := c.EntryCatalog.GetRepository(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	f, err := ioutil.TempFile("", "diff-export-*."+format)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}()
+	w, err := newDiffExportWriter(format, f)
+	if err != nil {
+		return nil, err
+	}
+	count, err := c.writeDiffExport(ctx, repositoryID, graveler.Ref(leftReference), graveler.Ref(rightReference), w)
+	if err != nil {
+		return nil, err
+	}
+	jobs.Logf(ctx, "Wrote %d differences", count)
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("close export: %w", err)
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		return nil, err
+	}
+
+	export := &DiffExport{
+		ID:             uuid.New().String(),
+		Format:         format,
+		LeftReference:  leftReference,
+		RightReference: rightReference,
+		Count:          count,
+		CreationDate:   time.Now().UTC(),
+	}
+	identifier := DiffExportPrefix + export.ID + "." + format
+	err = c.EntryCatalog.BlockAdapter.Put(block.ObjectPointer{
+		StorageNamespace: repo.StorageNamespace.String(),
+		Identifier:       identifier,
+	}, stat.Size(), f, block.PutOpts{})
+	if err != nil {
+		return nil, fmt.Errorf("write %s: %w", identifier, err)
+	}
+	qk, err := block.ResolveNamespace(repo.StorageNamespace.String(), identifier)
+	if err != nil {
+		return nil, err
+	}
+	export.Location = qk.Format()
+	jobs.Logf(ctx, "Exported to %s", export.Location)
+	return export, nil
+}
+
+// writeDiffExport writes the differences between left and right to w and returns their count.
+// Left entries of changed paths are read from a listing of left that only moves forward, as
+// both iterate in path order.
+func (c *cataloger) writeDiffExport(ctx context.Context, repositoryID graveler.RepositoryID, left, right graveler.Ref, w diffExportWriter) (int, error) {
+	it, err := c.EntryCatalog.Diff(ctx, repositoryID, left, right, graveler.DiffTypeMaskAll)
+	if err != nil {
+		return 0, err
+	}
+	defer it.Close()
+	leftIt, err := c.EntryCatalog.ListEntries(ctx, repositoryID, left, "", "")
+	if err != nil {
+		return 0, err
+	}
+	defer leftIt.Close()
+
+	count := 0
+	for it.Next() {
+		v := it.Value()
+		record := &DiffExportRecord{
+			Path: v.Path.String(),
+			Type: diffExportTypeName(v.Type),
+		}
+		if v.Entry != nil {
+			size, etag := v.Entry.Size, v.Entry.ETag
+			if v.Type == graveler.DiffTypeRemoved {
+				record.LeftSize, record.LeftETag = &size, &etag
+			} else {
+				record.RightSize, record.RightETag = &size, &etag
+			}
+		}
+		if v.Type == graveler.DiffTypeChanged {
+			leftIt.SeekGE(v.Path)
+			if leftIt.Next() && leftIt.Value().Path == v.Path && leftIt.Value().Entry != nil {
+				size, etag := leftIt.Value().Size, leftIt.Value().ETag
+				record.LeftSize, record.LeftETag = &size, &etag
+			} else if err := leftIt.Err(); err != nil {
+				return 0, err
+			}
+		}
+		if err := w.Write(record); err != nil {
+			return 0, fmt.Errorf("write export: %w", err)
+		}
+		count++
+	}
+	if err := it.Err(); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,61 @@
+package catalog
+
+import (
+	"encoding/csv"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/go-openapi/swag"
+)
+
+func TestCSVDiffExportWriter(t *testing.T) {
+	f, err := ioutil.TempFile("", "diff-export-*.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}()
+	w, err := newDiffExportWriter(DiffExportFormatCSV, f)
+	if err != nil {
+		t.Fatalf("newDiffExportWriter: %s", err)
+	}
+	records := []*DiffExportRecord{
+		{Path: "a", Type: "added", RightSize: swag.Int64(10), RightETag: swag.String("etag1")},
+		{Path: "b", Type: "changed", LeftSize: swag.Int64(1), RightSize: swag.Int64(2), LeftETag: swag.String("etag2"), RightETag: swag.String("etag3")},
+		{Path: "c", Type: "removed", LeftSize: swag.Int64(0), LeftETag: swag.String("etag4")},
+	}
+	for _, record := range records {
+		if err := w.Write(record); err != nil {
+			t.Fatalf("Write(%+v): %s", record, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %s", err)
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("read CSV: %s", err)
+	}
+	expected := [][]string{
+		diffExportCSVHeader,
+		{"a", "added", "", "10", "", "etag1"},
+		{"b", "changed", "1", "2", "etag2", "etag3"},
+		{"c", "removed", "0", "", "etag4", ""},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("CSV rows = %v, expected %v", rows, expected)
+	}
+}
+
+func TestNewDiffExportWriter_UnknownFormat(t *testing.T) {
+	if _, err := newDiffExportWriter("json", nil); err == nil {
+		t.Error("newDiffExportWriter expected error on unknown format")
+	}
+}
@@ -0,0 +1,101 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/encryption"
+	"github.com/treeverse/lakefs/graveler"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// LinkByDigestParams describe the content a client is about to upload, and where an entry
+// holding the same content may already be
+type LinkByDigestParams struct {
+	// Checksum and Size are the ETag and size of the content
+	Checksum string
+	Size     int64
+	// SourceReference and SourcePath locate the entry that may hold the content, they default
+	// to the branch and path linked
+	SourceReference string
+	SourcePath      string
+	// EncryptionKeyID is the master key objects written to the path are encrypted with, empty
+	// if they are not encrypted.  Entries encrypted differently are not linked.
+	EncryptionKeyID string
+}
+
+// LinkEntryByDigest stages on path of branch the entry at the source of params if it holds the
+// content of params, so that clients skip uploading content lakeFS already has.  Entries are
+// linked by their physical address, which garbage collection keeps while any retained entry
+// points to it.  Returns ErrDigestMismatch if the content must be uploaded.
+func (c *cataloger) LinkEntryByDigest(ctx context.Context, repository, branch, path string, params LinkByDigestParams) (*DBEntry, error) {
+	repositoryID := graveler.RepositoryID(repository)
+	branchID := graveler.BranchID(branch)
+	sourceReference := params.SourceReference
+	if sourceReference == "" {
+		sourceReference = branch
+	}
+	sourcePath := params.SourcePath
+	if sourcePath == "" {
+		sourcePath = path
+	}
+	if err := Validate([]ValidateArg{
+		{"repository", repositoryID, ValidateRepositoryID},
+		{"branch", branchID, ValidateBranchID},
+		{"path", Path(path), ValidatePath},
+		{"source_reference", graveler.Ref(sourceReference), ValidateRef},
+		{"source_path", Path(sourcePath), ValidatePath},
+		{"checksum", params.Checksum, ValidateRequiredString},
+	}); err != nil {
+		return nil, err
+	}
+	repo, err := c.EntryCatalog.GetRepository(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	ent, err := c.EntryCatalog.GetEntry(ctx, repositoryID, graveler.Ref(sourceReference), Path(sourcePath))
+	if errors.Is(err, graveler.ErrNotFound) {
+		return nil, fmt.Errorf("%s not found: %w", sourcePath, ErrDigestMismatch)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !params.matches(ent) {
+		return nil, ErrDigestMismatch
+	}
+	// the data of redacted objects is gone
+	_, err = c.GetRedaction(ctx, repo.StorageNamespace.String(), ent.Address)
+	if err == nil {
+		return nil, fmt.Errorf("%s redacted: %w", sourcePath, ErrDigestMismatch)
+	}
+	if !errors.Is(err, db.ErrNotFound) {
+		return nil, err
+	}
+
+	if sourceReference != branch || sourcePath != path {
+		ent.LastModified = timestamppb.New(time.Now())
+		ctx = c.withRemovedObjectsCheck(ctx, repository, ent.Address)
+		err := c.writeLeasedPaths(ctx, repository, branch, []string{path}, false, func() error {
+			return c.EntryCatalog.SetEntry(ctx, repositoryID, branchID, Path(path), ent)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	catalogEntry := newCatalogEntryFromEntry(false, path, ent)
+	return &catalogEntry, nil
+}
+
+// matches returns true if ent holds the content of p and can be linked to its path
+func (p LinkByDigestParams) matches(ent *Entry) bool {
+	if ent.ETag != p.Checksum || ent.Size != p.Size {
+		return false
+	}
+	if ent.LinkTarget != "" || ent.DirectoryMarker {
+		return false
+	}
+	return ent.Metadata[encryption.MetadataKeyID] == p.EncryptionKeyID
+}
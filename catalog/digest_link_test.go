@@ -0,0 +1,45 @@
+package catalog
+
+import (
+	"testing"
+
+	"github.com/treeverse/lakefs/encryption"
+)
+
+func TestLinkByDigestParams_Matches(t *testing.T) {
+	params := LinkByDigestParams{Checksum: "etag1", Size: 10}
+	tests := []struct {
+		name     string
+		params   LinkByDigestParams
+		entry    *Entry
+		expected bool
+	}{
+		{name: "same", params: params, entry: &Entry{ETag: "etag1", Size: 10}, expected: true},
+		{name: "checksum differs", params: params, entry: &Entry{ETag: "etag2", Size: 10}},
+		{name: "size differs", params: params, entry: &Entry{ETag: "etag1", Size: 11}},
+		{name: "link", params: params, entry: &Entry{ETag: "etag1", Size: 10, LinkTarget: "other"}},
+		{
+			name:   "encrypted with another key",
+			params: params,
+			entry:  &Entry{ETag: "etag1", Size: 10, Metadata: map[string]string{encryption.MetadataKeyID: "key1"}},
+		},
+		{
+			name:     "encrypted with the key",
+			params:   LinkByDigestParams{Checksum: "etag1", Size: 10, EncryptionKeyID: "key1"},
+			entry:    &Entry{ETag: "etag1", Size: 10, Metadata: map[string]string{encryption.MetadataKeyID: "key1"}},
+			expected: true,
+		},
+		{
+			name:   "not encrypted with the key",
+			params: LinkByDigestParams{Checksum: "etag1", Size: 10, EncryptionKeyID: "key1"},
+			entry:  &Entry{ETag: "etag1", Size: 10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.params.matches(tt.entry); got != tt.expected {
+				t.Errorf("matches() = %t, expected %t", got, tt.expected)
+			}
+		})
+	}
+}
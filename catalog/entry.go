@@ -1,11 +1,92 @@
 package catalog
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/treeverse/lakefs/graveler"
 	"github.com/treeverse/lakefs/ident"
 	"google.golang.org/protobuf/proto"
 )
 
+const (
+	// DirectoryMarkerSuffix ends the path of every directory marker entry
+	DirectoryMarkerSuffix = "/"
+
+	directoryMarkerIdentity = "directory_marker"
+	linkTargetIdentity      = "link_target"
+
+	// MaxLinkDepth is the maximal number of links followed while resolving a single path
+	MaxLinkDepth = 16
+	// MaxDirectoryLinkDepth is the maximal number of path components of a directory link.  A
+	// lookup that misses checks a parent of the path for a link at each of these depths.
+	MaxDirectoryLinkDepth = 4
+
+	// MetadataKeyPartsCount is the metadata key of the number of parts of objects uploaded in
+	// multiple parts.  The checksum of such an object is the MD5 of the MD5s of its parts, and
+	// its ETag is the checksum followed by "-" and the number of parts.
+	MetadataKeyPartsCount = "lakefs-parts-count"
+)
+
+// IsDirectoryMarkerPath returns true if path can hold a directory marker - a zero-byte entry
+// representing an (optionally empty) directory, as created by Hadoop and Spark.
+func IsDirectoryMarkerPath(path string) bool {
+	return strings.HasSuffix(path, DirectoryMarkerSuffix)
+}
+
+// ValidateDirectoryMarker verifies that a directory marker entry is zero-byte and its path ends with DirectoryMarkerSuffix
+func ValidateDirectoryMarker(path Path, entry *Entry) error {
+	if entry == nil || !entry.DirectoryMarker {
+		return nil
+	}
+	if !IsDirectoryMarkerPath(path.String()) {
+		return fmt.Errorf("%w: path must end with '%s'", ErrInvalidDirectoryMarker, DirectoryMarkerSuffix)
+	}
+	if entry.Size != 0 {
+		return fmt.Errorf("%w: size must be zero", ErrInvalidDirectoryMarker)
+	}
+	return nil
+}
+
+// ValidateLink verifies that a link entry holds no data and points to a different path.  A
+// link to a directory (path ending with DirectoryMarkerSuffix) must point to a directory, and
+// be at most MaxDirectoryLinkDepth deep.
+func ValidateLink(path Path, entry *Entry) error {
+	if entry == nil || entry.LinkTarget == "" {
+		return nil
+	}
+	if err := ValidatePath(Path(entry.LinkTarget)); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidLinkTarget, err)
+	}
+	if entry.LinkRepository != "" {
+		// cross repository links are pinned to a commit and may only point to objects
+		if err := Validate([]ValidateArg{
+			{"linkRepository", graveler.RepositoryID(entry.LinkRepository), ValidateRepositoryID},
+			{"linkRef", graveler.CommitID(entry.LinkRef), ValidateCommitID},
+		}); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidLinkTarget, err)
+		}
+		if IsDirectoryMarkerPath(path.String()) || IsDirectoryMarkerPath(entry.LinkTarget) {
+			return fmt.Errorf("%w: cross repository link must point to an object", ErrInvalidLinkTarget)
+		}
+	} else if entry.LinkRef != "" {
+		return fmt.Errorf("%w: link ref requires link repository", ErrInvalidLinkTarget)
+	}
+	if entry.LinkRepository == "" && entry.LinkTarget == path.String() {
+		return fmt.Errorf("%w: link to itself", ErrInvalidLinkTarget)
+	}
+	if IsDirectoryMarkerPath(path.String()) != IsDirectoryMarkerPath(entry.LinkTarget) {
+		return fmt.Errorf("%w: directory link must point to a directory", ErrInvalidLinkTarget)
+	}
+	if IsDirectoryMarkerPath(path.String()) && strings.Count(path.String(), DirectoryMarkerSuffix) > MaxDirectoryLinkDepth {
+		return fmt.Errorf("%w: directory link deeper than %d", ErrInvalidLinkTarget, MaxDirectoryLinkDepth)
+	}
+	if entry.DirectoryMarker || entry.Address != "" || entry.Size != 0 {
+		return fmt.Errorf("%w: link entry cannot hold data", ErrInvalidLinkTarget)
+	}
+	return nil
+}
+
 func ValueToEntry(value *graveler.Value) (*Entry, error) {
 	if value == nil {
 		return nil, nil
@@ -25,14 +106,23 @@ func EntryToValue(entry *Entry) (*graveler.Value, error) {
 		return nil, err
 	}
 	// calculate entry identity
-	checksum := ident.NewAddressWriter().
+	w := ident.NewAddressWriter().
 		MarshalString(entry.Address).
 		MarshalInt64(entry.Size).
 		MarshalString(entry.ETag).
-		MarshalStringMap(entry.Metadata).
-		Identity()
+		MarshalStringMap(entry.Metadata)
+	if entry.DirectoryMarker {
+		// keep the identity of regular entries unchanged
+		w.MarshalString(directoryMarkerIdentity)
+	}
+	if entry.LinkTarget != "" {
+		w.MarshalString(linkTargetIdentity).
+			MarshalString(entry.LinkTarget).
+			MarshalString(entry.LinkRepository).
+			MarshalString(entry.LinkRef)
+	}
 	return &graveler.Value{
-		Identity: checksum,
+		Identity: w.Identity(),
 		Data:     data,
 	}, nil
 }
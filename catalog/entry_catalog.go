@@ -4,7 +4,9 @@ import (
 	"context"
 	"crypto"
 	_ "crypto/sha256"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/treeverse/lakefs/actions"
@@ -13,6 +15,7 @@ import (
 	"github.com/treeverse/lakefs/block"
 	"github.com/treeverse/lakefs/config"
 	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/events"
 	"github.com/treeverse/lakefs/graveler"
 	"github.com/treeverse/lakefs/graveler/committed"
 	"github.com/treeverse/lakefs/graveler/ref"
@@ -28,6 +31,51 @@ import (
 // function they will be unable to re-use any existing objects.
 const hashAlg = crypto.SHA256
 
+// commitReferences are the catalog records of commits, moved to the commits replacing them when
+// history is rewritten
+var commitReferences = []ref.CommitReference{
+	{Table: "catalog_commit_statuses", RepositoryColumn: "repository_id", CommitColumn: "commit_id"},
+	{Table: "catalog_commit_annotations", RepositoryColumn: "repository_id", CommitColumn: "commit_id"},
+	{Table: "catalog_lineage", RepositoryColumn: "repository_id", CommitColumn: "commit_id"},
+	{Table: "catalog_lineage", RepositoryColumn: "input_repository_id", CommitColumn: "input_commit_id"},
+}
+
+// repositoryTables are the records of repositories outside the refs, deleted together with their
+// repository
+var repositoryTables = []ref.RepositoryTable{
+	{Table: "catalog_datasets", RepositoryColumn: "repository_id"},
+	{Table: "catalog_lineage", RepositoryColumn: "repository_id"},
+	{Table: "catalog_schemas", RepositoryColumn: "repository_id"},
+	{Table: "catalog_ephemeral_branches", RepositoryColumn: "repository_id"},
+	{Table: "catalog_retention_rules", RepositoryColumn: "repository_id"},
+	{Table: "catalog_redactions", RepositoryColumn: "repository_id"},
+	{Table: "catalog_merge_guardrails", RepositoryColumn: "repository_id"},
+	{Table: "catalog_path_leases", RepositoryColumn: "repository_id"},
+	{Table: "catalog_auto_commit_policies", RepositoryColumn: "repository_id"},
+	{Table: "catalog_commit_statuses", RepositoryColumn: "repository_id"},
+	{Table: "catalog_commit_annotations", RepositoryColumn: "repository_id"},
+	{Table: "catalog_merge_queue", RepositoryColumn: "repository_id"},
+	// comments and approvals are deleted with their pull requests
+	{Table: "catalog_pull_requests", RepositoryColumn: "repository_id"},
+	{Table: "catalog_gc_removals", RepositoryColumn: "repository_id"},
+	{Table: "actions_definitions", RepositoryColumn: "repository_id"},
+	{Table: "repository_secrets", RepositoryColumn: "repository_id"},
+	// a repository created later with the same name must not send its events to these sinks
+	{Table: "notification_sinks", RepositoryColumn: "repository_id"},
+	{Table: "repository_settings", RepositoryColumn: "repository_id"},
+	{Table: "ingest_streams", RepositoryColumn: "repository_id"},
+	{Table: "jobs", RepositoryColumn: "repository_id"},
+	{Table: "usage_requests", RepositoryColumn: "repository_id"},
+}
+
+// branchTables are the records of branches outside the refs, deleted together with their branch
+var branchTables = []ref.BranchTable{
+	{Table: "catalog_ephemeral_branches", RepositoryColumn: "repository_id", BranchColumn: "branch_id"},
+	{Table: "catalog_merge_guardrails", RepositoryColumn: "repository_id", BranchColumn: "branch_id"},
+	{Table: "catalog_path_leases", RepositoryColumn: "repository_id", BranchColumn: "branch_id"},
+	{Table: "catalog_auto_commit_policies", RepositoryColumn: "repository_id", BranchColumn: "branch_id"},
+}
+
 type Path string
 
 type EntryRecord struct {
@@ -96,6 +144,8 @@ type Config struct {
 	Config *config.Config
 	DB     db.Database
 	LockDB db.Database
+	// Events receives the repository events of the cataloger, nil discards them
+	Events *events.Bus
 }
 
 func NewEntryCatalog(cfg Config) (*EntryCatalog, error) {
@@ -139,10 +189,25 @@ func NewEntryCatalog(cfg Config) (*EntryCatalog, error) {
 	if err != nil {
 		return nil, fmt.Errorf("create SSTable-based metarange manager: %w", err)
 	}
-	committedManager := committed.NewCommittedManager(sstableMetaRangeManager)
-
-	stagingManager := staging.NewManager(cfg.DB)
-	refManager := ref.NewPGRefManager(cfg.DB, ident.NewHexAddressProvider())
+	pgRefManager := ref.NewPGRefManager(cfg.DB, ident.NewHexAddressProvider())
+	var deltaResolutions committed.DeltaResolutions = pgRefManager
+	if cfg.Config.GetDatabaseParams().Replica {
+		deltaResolutions = replicaDeltaResolutions{DeltaResolutions: pgRefManager}
+	}
+	committedManager := committed.NewCommittedManager(sstableMetaRangeManager, *cfg.Config.GetCommittedDeltaParams(), deltaResolutions)
+	pgRefManager.SetCommitReferences(commitReferences...)
+	pgRefManager.SetRepositoryTables(repositoryTables...)
+	pgRefManager.SetBranchTables(branchTables...)
+	stagingManager := staging.NewManager(cfg.DB, staging.SpillParams{
+		Threshold:        cfg.Config.GetStagingSpillThreshold(),
+		StorageNamespace: pgRefManager.GetStagingTokenStorageNamespace,
+		RangeManager:     sstableManager,
+	})
+	stagingManager.SetValueSizeFunc(entryValueSize)
+	var refManager graveler.RefManager = pgRefManager
+	if cfg.Config.GetRefsJournalEnabled() {
+		refManager = graveler.NewJournalingRefManager(refManager, NewRefsJournal(tierFSParams.Adapter))
+	}
 	branchLocker := ref.NewBranchLocker(cfg.LockDB)
 	store := graveler.NewGraveler(branchLocker, committedManager, stagingManager, refManager)
 	entryCatalog := &EntryCatalog{
@@ -154,6 +219,16 @@ func NewEntryCatalog(cfg Config) (*EntryCatalog, error) {
 	return entryCatalog, nil
 }
 
+// replicaDeltaResolutions reads the delta resolutions recorded by the primary.  A read replica
+// cannot write to its metadata store, so resolutions it materializes are only cached in memory.
+type replicaDeltaResolutions struct {
+	committed.DeltaResolutions
+}
+
+func (replicaDeltaResolutions) SetDeltaResolution(_ context.Context, _ graveler.StorageNamespace, _, _ graveler.MetaRangeID) error {
+	return nil
+}
+
 func (e *EntryCatalog) AddCommitToBranchHead(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, commit graveler.Commit) (graveler.CommitID, error) {
 	if err := Validate([]ValidateArg{
 		{"repositoryID", repositoryID, ValidateRepositoryID},
@@ -182,6 +257,15 @@ func (e *EntryCatalog) GetRepository(ctx context.Context, repositoryID graveler.
 	return e.Store.GetRepository(ctx, repositoryID)
 }
 
+func (e *EntryCatalog) SetRangeSplitPolicy(ctx context.Context, repositoryID graveler.RepositoryID, policy *graveler.RangeSplitPolicy) error {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+	}); err != nil {
+		return err
+	}
+	return e.Store.SetRangeSplitPolicy(ctx, repositoryID, policy)
+}
+
 func (e *EntryCatalog) CreateRepository(ctx context.Context, repositoryID graveler.RepositoryID, storageNamespace graveler.StorageNamespace, branchID graveler.BranchID) (*graveler.Repository, error) {
 	if err := Validate([]ValidateArg{
 		{"repositoryID", repositoryID, ValidateRepositoryID},
@@ -202,6 +286,16 @@ func (e *EntryCatalog) CreateBareRepository(ctx context.Context, repositoryID gr
 	return e.Store.CreateBareRepository(ctx, repositoryID, storageNamespace, defaultBranchID)
 }
 
+func (e *EntryCatalog) ForkRepository(ctx context.Context, sourceID graveler.RepositoryID, repositoryID graveler.RepositoryID) (*graveler.Repository, error) {
+	if err := Validate([]ValidateArg{
+		{"sourceID", sourceID, ValidateRepositoryID},
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+	}); err != nil {
+		return nil, err
+	}
+	return e.Store.ForkRepository(ctx, sourceID, repositoryID)
+}
+
 func (e *EntryCatalog) ListRepositories(ctx context.Context) (graveler.RepositoryIterator, error) {
 	return e.Store.ListRepositories(ctx)
 }
@@ -291,14 +385,153 @@ func (e *EntryCatalog) ListTags(ctx context.Context, repositoryID graveler.Repos
 	return it, nil
 }
 
-func (e *EntryCatalog) Log(ctx context.Context, repositoryID graveler.RepositoryID, commitID graveler.CommitID) (graveler.CommitIterator, error) {
+func (e *EntryCatalog) FindCommitsByMetadata(ctx context.Context, repositoryID graveler.RepositoryID, key, value string, after graveler.CommitID, limit int) ([]*graveler.CommitRecord, bool, error) {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+		{"key", key, ValidateRequiredString},
+	}); err != nil {
+		return nil, false, err
+	}
+	return e.Store.FindCommitsByMetadata(ctx, repositoryID, key, value, after, limit)
+}
+
+func (e *EntryCatalog) SetRefLabels(ctx context.Context, repositoryID graveler.RepositoryID, refType graveler.LabeledRefType, refID string, set map[string]string, unset []string) (map[string]string, error) {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+		{"refID", refID, ValidateRequiredString},
+	}); err != nil {
+		return nil, err
+	}
+	return e.Store.SetRefLabels(ctx, repositoryID, refType, refID, set, unset)
+}
+
+func (e *EntryCatalog) GetRefLabels(ctx context.Context, repositoryID graveler.RepositoryID, refType graveler.LabeledRefType, refID string) (map[string]string, error) {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+		{"refID", refID, ValidateRequiredString},
+	}); err != nil {
+		return nil, err
+	}
+	return e.Store.GetRefLabels(ctx, repositoryID, refType, refID)
+}
+
+func (e *EntryCatalog) FindRefsByLabels(ctx context.Context, repositoryID graveler.RepositoryID, labels map[string]string, refType graveler.LabeledRefType) ([]*graveler.RefLabels, error) {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+	}); err != nil {
+		return nil, err
+	}
+	return e.Store.FindRefsByLabels(ctx, repositoryID, labels, refType)
+}
+
+func (e *EntryCatalog) ListCommits(ctx context.Context, repositoryID graveler.RepositoryID) (graveler.CommitIterator, error) {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+	}); err != nil {
+		return nil, err
+	}
+	return e.Store.ListCommits(ctx, repositoryID)
+}
+
+func (e *EntryCatalog) ExpiredCommits(ctx context.Context, repositoryID graveler.RepositoryID, rules graveler.RetentionRules, now time.Time, pinned []graveler.CommitID) (*graveler.ExpiredCommits, error) {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+	}); err != nil {
+		return nil, err
+	}
+	return e.Store.ExpiredCommits(ctx, repositoryID, rules, now, pinned)
+}
+
+func (e *EntryCatalog) DeleteBranchLogCommits(ctx context.Context, repositoryID graveler.RepositoryID, commitIDs []graveler.CommitID) error {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+	}); err != nil {
+		return err
+	}
+	return e.Store.DeleteBranchLogCommits(ctx, repositoryID, commitIDs)
+}
+
+func (e *EntryCatalog) PurgeHistory(ctx context.Context, repositoryID graveler.RepositoryID, path Path, prefix bool) (*graveler.PurgeResult, error) {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+		{"path", path, ValidatePath},
+	}); err != nil {
+		return nil, err
+	}
+	return e.Store.PurgeHistory(ctx, repositoryID, graveler.Key(path), prefix)
+}
+
+func (e *EntryCatalog) Fsck(ctx context.Context, repositoryID graveler.RepositoryID) (*graveler.FsckReport, error) {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+	}); err != nil {
+		return nil, err
+	}
+	return e.Store.Fsck(ctx, repositoryID)
+}
+
+func (e *EntryCatalog) UpgradeTrees(ctx context.Context, repositoryID graveler.RepositoryID, committer string) (map[graveler.BranchID]graveler.CommitID, error) {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+	}); err != nil {
+		return nil, err
+	}
+	return e.Store.UpgradeTrees(ctx, repositoryID, committer)
+}
+
+func (e *EntryCatalog) FillGenerations(ctx context.Context, repositoryID graveler.RepositoryID) (int, error) {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+	}); err != nil {
+		return 0, err
+	}
+	return e.Store.FillGenerations(ctx, repositoryID)
+}
+
+func (e *EntryCatalog) DeleteCommits(ctx context.Context, repositoryID graveler.RepositoryID, commitIDs []graveler.CommitID) error {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+	}); err != nil {
+		return err
+	}
+	for _, commitID := range commitIDs {
+		if err := ValidateCommitID(commitID); err != nil {
+			return fmt.Errorf("argument commitIDs: %w", err)
+		}
+	}
+	return e.Store.DeleteCommits(ctx, repositoryID, commitIDs)
+}
+
+func (e *EntryCatalog) Log(ctx context.Context, repositoryID graveler.RepositoryID, commitID graveler.CommitID, firstParent bool) (graveler.CommitIterator, error) {
 	if err := Validate([]ValidateArg{
 		{"repositoryID", repositoryID, ValidateRepositoryID},
 		{"commitID", commitID, ValidateCommitID},
 	}); err != nil {
 		return nil, err
 	}
-	return e.Store.Log(ctx, repositoryID, commitID)
+	return e.Store.Log(ctx, repositoryID, commitID, firstParent)
+}
+
+func (e *EntryCatalog) IsAncestor(ctx context.Context, repositoryID graveler.RepositoryID, ancestor, ref graveler.Ref) (bool, error) {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+		{"ancestor", ancestor, ValidateRef},
+		{"ref", ref, ValidateRef},
+	}); err != nil {
+		return false, err
+	}
+	return e.Store.IsAncestor(ctx, repositoryID, ancestor, ref)
+}
+
+func (e *EntryCatalog) CommitsBetween(ctx context.Context, repositoryID graveler.RepositoryID, from, to graveler.Ref) ([]*graveler.CommitRecord, error) {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+		{"from", from, ValidateRef},
+		{"to", to, ValidateRef},
+	}); err != nil {
+		return nil, err
+	}
+	return e.Store.CommitsBetween(ctx, repositoryID, from, to)
 }
 
 func (e *EntryCatalog) ListBranches(ctx context.Context, repositoryID graveler.RepositoryID) (graveler.BranchIterator, error) {
@@ -334,6 +567,65 @@ func (e *EntryCatalog) Commit(ctx context.Context, repositoryID graveler.Reposit
 	return e.Store.Commit(ctx, repositoryID, branchID, commitParams)
 }
 
+func (e *EntryCatalog) CommitPreview(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID) (graveler.MetaRangeID, graveler.DiffSummary, error) {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+		{"branchID", branchID, ValidateBranchID},
+	}); err != nil {
+		return "", graveler.DiffSummary{}, err
+	}
+	return e.Store.CommitPreview(ctx, repositoryID, branchID)
+}
+
+func (e *EntryCatalog) CreateRepositorySnapshot(ctx context.Context, repositoryID graveler.RepositoryID, snapshotID graveler.RepositorySnapshotID) (*graveler.RepositorySnapshot, error) {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+		{"snapshotID", snapshotID, ValidateRepositorySnapshotID},
+	}); err != nil {
+		return nil, err
+	}
+	return e.Store.CreateRepositorySnapshot(ctx, repositoryID, snapshotID)
+}
+
+func (e *EntryCatalog) GetRepositorySnapshot(ctx context.Context, repositoryID graveler.RepositoryID, snapshotID graveler.RepositorySnapshotID) (*graveler.RepositorySnapshot, error) {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+		{"snapshotID", snapshotID, ValidateRepositorySnapshotID},
+	}); err != nil {
+		return nil, err
+	}
+	return e.Store.GetRepositorySnapshot(ctx, repositoryID, snapshotID)
+}
+
+func (e *EntryCatalog) ListRepositorySnapshots(ctx context.Context, repositoryID graveler.RepositoryID) ([]*graveler.RepositorySnapshot, error) {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+	}); err != nil {
+		return nil, err
+	}
+	return e.Store.ListRepositorySnapshots(ctx, repositoryID)
+}
+
+func (e *EntryCatalog) DeleteRepositorySnapshot(ctx context.Context, repositoryID graveler.RepositoryID, snapshotID graveler.RepositorySnapshotID) error {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+		{"snapshotID", snapshotID, ValidateRepositorySnapshotID},
+	}); err != nil {
+		return err
+	}
+	return e.Store.DeleteRepositorySnapshot(ctx, repositoryID, snapshotID)
+}
+
+func (e *EntryCatalog) RestoreRepositorySnapshot(ctx context.Context, repositoryID graveler.RepositoryID, snapshotID graveler.RepositorySnapshotID) error {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+		{"snapshotID", snapshotID, ValidateRepositorySnapshotID},
+	}); err != nil {
+		return err
+	}
+	return e.Store.RestoreRepositorySnapshot(ctx, repositoryID, snapshotID)
+}
+
 func (e *EntryCatalog) GetCommit(ctx context.Context, repositoryID graveler.RepositoryID, commitID graveler.CommitID) (*graveler.Commit, error) {
 	if err := Validate([]ValidateArg{
 		{"repositoryID", repositoryID, ValidateRepositoryID},
@@ -387,6 +679,35 @@ func (e *EntryCatalog) ResetPrefix(ctx context.Context, repositoryID graveler.Re
 	return e.Store.ResetPrefix(ctx, repositoryID, branchID, keyPrefix)
 }
 
+func (e *EntryCatalog) LockBranch(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, fn func() error) error {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+		{"branchID", branchID, ValidateBranchID},
+	}); err != nil {
+		return err
+	}
+	return e.Store.LockBranch(ctx, repositoryID, branchID, fn)
+}
+
+func (e *EntryCatalog) GetStagingStats(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID) (*graveler.StagingStats, error) {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+		{"branchID", branchID, ValidateBranchID},
+	}); err != nil {
+		return nil, err
+	}
+	return e.Store.GetStagingStats(ctx, repositoryID, branchID)
+}
+
+// entryValueSize returns the size of the object of the entry in value, counted in staging stats
+func entryValueSize(value *graveler.Value) int64 {
+	entry, err := ValueToEntry(value)
+	if err != nil || entry == nil {
+		return 0
+	}
+	return entry.Size
+}
+
 func (e *EntryCatalog) Revert(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, ref graveler.Ref, parentNumber int, commitParams graveler.CommitParams) (graveler.CommitID, graveler.DiffSummary, error) {
 	if err := Validate([]ValidateArg{
 		{"repositoryID", repositoryID, ValidateRepositoryID},
@@ -401,6 +722,47 @@ func (e *EntryCatalog) Revert(ctx context.Context, repositoryID graveler.Reposit
 	return e.Store.Revert(ctx, repositoryID, branchID, ref, parentNumber, commitParams)
 }
 
+func (e *EntryCatalog) DropPrefix(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, prefix Path, commitParams graveler.CommitParams) (graveler.CommitID, graveler.DiffSummary, error) {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+		{"branchID", branchID, ValidateBranchID},
+		{"prefix", prefix, ValidatePath},
+		{"committer", commitParams.Committer, ValidateRequiredString},
+		{"message", commitParams.Message, ValidateRequiredString},
+	}); err != nil {
+		return "", graveler.DiffSummary{}, err
+	}
+	return e.Store.DropPrefix(ctx, repositoryID, branchID, graveler.Key(prefix), commitParams)
+}
+
+func (e *EntryCatalog) ReplacePrefixFromRef(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, prefix Path, source graveler.Ref, commitParams graveler.CommitParams) (graveler.CommitID, graveler.DiffSummary, error) {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+		{"branchID", branchID, ValidateBranchID},
+		{"prefix", prefix, ValidatePath},
+		{"source", source, ValidateRef},
+		{"committer", commitParams.Committer, ValidateRequiredString},
+		{"message", commitParams.Message, ValidateRequiredString},
+	}); err != nil {
+		return "", graveler.DiffSummary{}, err
+	}
+	return e.Store.ReplacePrefixFromRef(ctx, repositoryID, branchID, graveler.Key(prefix), source, commitParams)
+}
+
+// ApplyEntries commits the entries of it, sorted by path, written directly to the committed tree
+// of the branch without staging them
+func (e *EntryCatalog) ApplyEntries(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, it EntryIterator, commitParams graveler.CommitParams) (graveler.CommitID, graveler.DiffSummary, error) {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+		{"branchID", branchID, ValidateBranchID},
+		{"committer", commitParams.Committer, ValidateRequiredString},
+		{"message", commitParams.Message, ValidateRequiredString},
+	}); err != nil {
+		return "", graveler.DiffSummary{}, err
+	}
+	return e.Store.ApplyChanges(ctx, repositoryID, branchID, NewEntryToValueIterator(it), commitParams)
+}
+
 func (e *EntryCatalog) Merge(ctx context.Context, repositoryID graveler.RepositoryID, destination graveler.BranchID, source graveler.Ref, commitParams graveler.CommitParams) (graveler.CommitID, graveler.DiffSummary, error) {
 	if commitParams.Message == "" {
 		commitParams.Message = fmt.Sprintf("Merge '%s' into '%s'", source, destination)
@@ -417,21 +779,32 @@ func (e *EntryCatalog) Merge(ctx context.Context, repositoryID graveler.Reposito
 	return e.Store.Merge(ctx, repositoryID, destination, source, commitParams)
 }
 
-func (e *EntryCatalog) DiffUncommitted(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID) (EntryDiffIterator, error) {
+func (e *EntryCatalog) MergePreview(ctx context.Context, repositoryID graveler.RepositoryID, destination graveler.BranchID, source graveler.Ref) (*graveler.MergePreview, error) {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+		{"destination", destination, ValidateBranchID},
+		{"source", source, ValidateRef},
+	}); err != nil {
+		return nil, err
+	}
+	return e.Store.MergePreview(ctx, repositoryID, destination, source)
+}
+
+func (e *EntryCatalog) DiffUncommitted(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, typeMask graveler.DiffTypeMask) (EntryDiffIterator, error) {
 	if err := Validate([]ValidateArg{
 		{"repositoryID", repositoryID, ValidateRepositoryID},
 		{"branchID", branchID, ValidateBranchID},
 	}); err != nil {
 		return nil, err
 	}
-	iter, err := e.Store.DiffUncommitted(ctx, repositoryID, branchID)
+	iter, err := e.Store.DiffUncommitted(ctx, repositoryID, branchID, typeMask)
 	if err != nil {
 		return nil, err
 	}
 	return NewEntryDiffIterator(iter), nil
 }
 
-func (e *EntryCatalog) Diff(ctx context.Context, repositoryID graveler.RepositoryID, left, right graveler.Ref) (EntryDiffIterator, error) {
+func (e *EntryCatalog) Diff(ctx context.Context, repositoryID graveler.RepositoryID, left, right graveler.Ref, typeMask graveler.DiffTypeMask) (EntryDiffIterator, error) {
 	if err := Validate([]ValidateArg{
 		{"repositoryID", repositoryID, ValidateRepositoryID},
 		{"left", left, ValidateRef},
@@ -439,14 +812,14 @@ func (e *EntryCatalog) Diff(ctx context.Context, repositoryID graveler.Repositor
 	}); err != nil {
 		return nil, err
 	}
-	iter, err := e.Store.Diff(ctx, repositoryID, left, right)
+	iter, err := e.Store.Diff(ctx, repositoryID, left, right, typeMask)
 	if err != nil {
 		return nil, err
 	}
 	return NewEntryDiffIterator(iter), nil
 }
 
-func (e *EntryCatalog) Compare(ctx context.Context, repositoryID graveler.RepositoryID, from graveler.Ref, to graveler.Ref) (EntryDiffIterator, error) {
+func (e *EntryCatalog) Compare(ctx context.Context, repositoryID graveler.RepositoryID, from graveler.Ref, to graveler.Ref, typeMask graveler.DiffTypeMask) (EntryDiffIterator, error) {
 	if err := Validate([]ValidateArg{
 		{"repositoryID", repositoryID, ValidateRepositoryID},
 		{"from", from, ValidateRef},
@@ -454,7 +827,7 @@ func (e *EntryCatalog) Compare(ctx context.Context, repositoryID graveler.Reposi
 	}); err != nil {
 		return nil, err
 	}
-	iter, err := e.Store.Compare(ctx, repositoryID, from, to)
+	iter, err := e.Store.Compare(ctx, repositoryID, from, to, typeMask)
 	if err != nil {
 		return nil, err
 	}
@@ -476,20 +849,144 @@ func (e *EntryCatalog) GetEntry(ctx context.Context, repositoryID graveler.Repos
 	return ValueToEntry(val)
 }
 
+// GetEntries returns the entries of paths on ref, in the order of paths and nil for paths that
+// do not exist
+func (e *EntryCatalog) GetEntries(ctx context.Context, repositoryID graveler.RepositoryID, ref graveler.Ref, paths []Path) ([]*Entry, error) {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+		{"ref", ref, ValidateRef},
+	}); err != nil {
+		return nil, err
+	}
+	keys := make([]graveler.Key, len(paths))
+	for i, path := range paths {
+		if err := ValidatePath(path); err != nil {
+			return nil, fmt.Errorf("path %d: %w", i, err)
+		}
+		keys[i] = graveler.Key(path)
+	}
+	values, err := e.Store.GetMany(ctx, repositoryID, ref, keys)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*Entry, len(values))
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		if entries[i], err = ValueToEntry(value); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// ResolveEntry returns the entry at path, following link entries set on path or on any of its
+// parent directories within the same ref.  Returns the resolved path together with the entry.
+// Links to other repositories are not followed, the link entry itself is returned.
+// Returns ErrLinkLoop when resolving requires more than MaxLinkDepth links or loops.
+func (e *EntryCatalog) ResolveEntry(ctx context.Context, repositoryID graveler.RepositoryID, ref graveler.Ref, path Path) (Path, *Entry, error) {
+	visited := make(map[Path]struct{})
+	for depth := 0; depth <= MaxLinkDepth; depth++ {
+		if _, ok := visited[path]; ok {
+			break
+		}
+		visited[path] = struct{}{}
+		ent, err := e.GetEntry(ctx, repositoryID, ref, path)
+		if errors.Is(err, graveler.ErrNotFound) {
+			linkPath, link, linkErr := e.getParentLink(ctx, repositoryID, ref, path)
+			if linkErr != nil {
+				return "", nil, linkErr
+			}
+			if link == nil {
+				return "", nil, err
+			}
+			path = Path(link.LinkTarget) + path[len(linkPath):]
+			continue
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		if ent.LinkTarget == "" || ent.LinkRepository != "" {
+			return path, ent, nil
+		}
+		path = Path(ent.LinkTarget)
+	}
+	return "", nil, ErrLinkLoop
+}
+
+// getParentLink returns the top most directory link entry that is a parent of path, or nil if
+// there is none.  Directory links are at most MaxDirectoryLinkDepth deep, so it looks up at
+// most that many parents.
+func (e *EntryCatalog) getParentLink(ctx context.Context, repositoryID graveler.RepositoryID, ref graveler.Ref, path Path) (Path, *Entry, error) {
+	p := path.String()
+	depth := 0
+	for i := 0; i < len(p)-1 && depth < MaxDirectoryLinkDepth; i++ {
+		if !strings.HasPrefix(p[i:], DirectoryMarkerSuffix) {
+			continue
+		}
+		depth++
+		parent := Path(p[:i+len(DirectoryMarkerSuffix)])
+		ent, err := e.GetEntry(ctx, repositoryID, ref, parent)
+		if errors.Is(err, graveler.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		if ent.LinkTarget != "" && ent.LinkRepository == "" {
+			return parent, ent, nil
+		}
+	}
+	return "", nil, nil
+}
+
 func (e *EntryCatalog) SetEntry(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, path Path, entry *Entry) error {
+	key, value, err := entryKeyValue(repositoryID, branchID, path, entry)
+	if err != nil {
+		return err
+	}
+	return e.Store.Set(ctx, repositoryID, branchID, key, *value)
+}
+
+// SetEntryIf sets entry on path if condition holds for the current entry of path.  It returns
+// graveler.ErrPreconditionFailed if it does not.
+func (e *EntryCatalog) SetEntryIf(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, path Path, entry *Entry, condition EntryCondition) error {
+	key, value, err := entryKeyValue(repositoryID, branchID, path, entry)
+	if err != nil {
+		return err
+	}
+	return e.Store.SetIf(ctx, repositoryID, branchID, key, *value, func(current *graveler.Value) error {
+		var currentEntry *Entry
+		if current != nil {
+			var err error
+			if currentEntry, err = ValueToEntry(current); err != nil {
+				return err
+			}
+		}
+		return condition.check(currentEntry)
+	})
+}
+
+func entryKeyValue(repositoryID graveler.RepositoryID, branchID graveler.BranchID, path Path, entry *Entry) (graveler.Key, *graveler.Value, error) {
 	if err := Validate([]ValidateArg{
 		{"repositoryID", repositoryID, ValidateRepositoryID},
 		{"branchID", branchID, ValidateBranchID},
 		{"path", path, ValidatePath},
 	}); err != nil {
-		return err
+		return nil, nil, err
+	}
+	if err := ValidateDirectoryMarker(path, entry); err != nil {
+		return nil, nil, err
+	}
+	if err := ValidateLink(path, entry); err != nil {
+		return nil, nil, err
 	}
-	key := graveler.Key(path)
 	value, err := EntryToValue(entry)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	return e.Store.Set(ctx, repositoryID, branchID, key, *value)
+	return graveler.Key(path), value, nil
 }
 
 func (e *EntryCatalog) DeleteEntry(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, path Path) error {
@@ -521,6 +1018,49 @@ func (e *EntryCatalog) ListEntries(ctx context.Context, repositoryID graveler.Re
 	return NewEntryListingIterator(it, prefix, delimiter), nil
 }
 
+// Snapshot resolves ref into a listing snapshot, used to list entries consistently across pages
+func (e *EntryCatalog) Snapshot(ctx context.Context, repositoryID graveler.RepositoryID, ref graveler.Ref) (*graveler.ListingSnapshot, error) {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+		{"ref", ref, ValidateRef},
+	}); err != nil {
+		return nil, err
+	}
+	return e.Store.Snapshot(ctx, repositoryID, ref)
+}
+
+// PinSnapshot copies the staging area read by a live snapshot, so it can be listed again later
+func (e *EntryCatalog) PinSnapshot(ctx context.Context, repositoryID graveler.RepositoryID, snapshot *graveler.ListingSnapshot) error {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+	}); err != nil {
+		return err
+	}
+	return e.Store.PinSnapshot(ctx, repositoryID, snapshot)
+}
+
+// DropExpiredSnapshots drops the staging copies of expired listing snapshots
+func (e *EntryCatalog) DropExpiredSnapshots(ctx context.Context) (int, error) {
+	return e.Store.DropExpiredSnapshots(ctx)
+}
+
+// ListEntriesSnapshot lists entries as of snapshot, see ListEntries
+func (e *EntryCatalog) ListEntriesSnapshot(ctx context.Context, repositoryID graveler.RepositoryID, snapshot graveler.ListingSnapshot, prefix, delimiter Path) (EntryListingIterator, error) {
+	if err := Validate([]ValidateArg{
+		{"repositoryID", repositoryID, ValidateRepositoryID},
+		{"prefix", prefix, ValidatePathOptional},
+		{"delimiter", delimiter, ValidatePathOptional},
+	}); err != nil {
+		return nil, err
+	}
+	iter, err := e.Store.ListSnapshot(ctx, repositoryID, snapshot)
+	if err != nil {
+		return nil, err
+	}
+	it := NewValueToEntryIterator(iter)
+	return NewEntryListingIterator(it, prefix, delimiter), nil
+}
+
 func (e *EntryCatalog) DumpCommits(ctx context.Context, repositoryID graveler.RepositoryID) (*graveler.MetaRangeID, error) {
 	return e.Store.DumpCommits(ctx, repositoryID)
 }
@@ -545,6 +1085,22 @@ func (e *EntryCatalog) LoadTags(ctx context.Context, repositoryID graveler.Repos
 	return e.Store.LoadTags(ctx, repositoryID, metaRangeID)
 }
 
+func (e *EntryCatalog) DumpStaging(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID) (*graveler.MetaRangeID, error) {
+	return e.Store.DumpStaging(ctx, repositoryID, branchID)
+}
+
+func (e *EntryCatalog) LoadStaging(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, metaRangeID graveler.MetaRangeID) error {
+	return e.Store.LoadStaging(ctx, repositoryID, branchID, metaRangeID)
+}
+
+func (e *EntryCatalog) ReplayRefsJournal(ctx context.Context, repositoryID graveler.RepositoryID, entries []*graveler.RefsJournalEntry) error {
+	return e.Store.ReplayRefsJournal(ctx, repositoryID, entries)
+}
+
+func (e *EntryCatalog) DiffDumps(ctx context.Context, repositoryID graveler.RepositoryID, left, right graveler.MetaRangeID) (graveler.DiffIterator, error) {
+	return e.Store.DiffDumps(ctx, repositoryID, left, right)
+}
+
 func (e *EntryCatalog) preCommitHook(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, commit graveler.Commit) error {
 	_ = actions.Event{
 		EventType:     actions.EventTypePreCommit,
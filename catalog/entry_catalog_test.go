@@ -65,6 +65,119 @@ func TestEntryCatalog_SetEntry(t *testing.T) {
 	}
 }
 
+func TestEntryCatalog_SetEntryIf(t *testing.T) {
+	ctx := context.Background()
+	existing := &Entry{Address: "addr1", ETag: "etag1"}
+	entry := &Entry{Address: "addr2", ETag: "etag2"}
+	tests := []struct {
+		name      string
+		path      Path
+		condition EntryCondition
+		expectErr error
+	}{
+		{name: "no condition", path: "exists", condition: EntryCondition{}},
+		{name: "absent", path: "missing", condition: EntryCondition{IfAbsent: true}},
+		{name: "absent exists", path: "exists", condition: EntryCondition{IfAbsent: true}, expectErr: graveler.ErrPreconditionFailed},
+		{name: "etag matches", path: "exists", condition: EntryCondition{IfETagMatches: "etag1"}},
+		{name: "etag differs", path: "exists", condition: EntryCondition{IfETagMatches: "etag3"}, expectErr: graveler.ErrPreconditionFailed},
+		{name: "etag missing", path: "missing", condition: EntryCondition{IfETagMatches: "etag1"}, expectErr: graveler.ErrPreconditionFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gravelerMock := &FakeGraveler{KeyValue: map[string]*graveler.Value{"repo/master/exists": MustEntryToValue(existing)}}
+			cat := EntryCatalog{Store: gravelerMock}
+			err := cat.SetEntryIf(ctx, "repo", "master", tt.path, entry, tt.condition)
+			if !errors.Is(err, tt.expectErr) {
+				t.Fatalf("SetEntryIf() err = %v, expected %v", err, tt.expectErr)
+			}
+			got, err := cat.GetEntry(ctx, "repo", "master", tt.path)
+			expected := entry
+			if tt.expectErr != nil {
+				expected = nil
+				if tt.path == "exists" {
+					expected = existing
+				}
+			}
+			if expected == nil {
+				if !errors.Is(err, graveler.ErrNotFound) {
+					t.Fatalf("GetEntry() err = %v, expected not found", err)
+				}
+				return
+			}
+			testutil.MustDo(t, "get entry", err)
+			if diff := deep.Equal(expected, got); diff != nil {
+				t.Fatal("GetEntry() got entry with diff", diff)
+			}
+		})
+	}
+}
+
+func TestEntryCatalog_ResolveEntry(t *testing.T) {
+	data := &Entry{Address: "addr1"}
+	gravelerMock := &FakeGraveler{KeyValue: map[string]*graveler.Value{
+		"repo1/master/v1/data":        MustEntryToValue(data),
+		"repo1/master/latest/":        MustEntryToValue(&Entry{LinkTarget: "v1/"}),
+		"repo1/master/current":        MustEntryToValue(&Entry{LinkTarget: "latest/data"}),
+		"repo1/master/loop/a":         MustEntryToValue(&Entry{LinkTarget: "loop/b"}),
+		"repo1/master/loop/b":         MustEntryToValue(&Entry{LinkTarget: "loop/a"}),
+		"repo1/master/dangling/link1": MustEntryToValue(&Entry{LinkTarget: "missing"}),
+		"repo1/master/a/b/c/d/e/":     MustEntryToValue(&Entry{LinkTarget: "v1/"}),
+	}}
+	cat := EntryCatalog{Store: gravelerMock}
+	ctx := context.Background()
+	tests := []struct {
+		name         string
+		path         Path
+		expectedPath Path
+		expectedErr  error
+	}{
+		{name: "no link", path: "v1/data", expectedPath: "v1/data"},
+		{name: "directory link", path: "latest/data", expectedPath: "v1/data"},
+		{name: "link to link", path: "current", expectedPath: "v1/data"},
+		{name: "loop", path: "loop/a", expectedErr: ErrLinkLoop},
+		{name: "dangling", path: "dangling/link1", expectedErr: graveler.ErrNotFound},
+		{name: "not found", path: "latest/missing", expectedErr: graveler.ErrNotFound},
+		{name: "directory link too deep", path: "a/b/c/d/e/data", expectedErr: graveler.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPath, got, err := cat.ResolveEntry(ctx, "repo1", "master", tt.path)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("ResolveEntry() err = %v, expected %v", err, tt.expectedErr)
+			}
+			if err != nil {
+				return
+			}
+			if gotPath != tt.expectedPath {
+				t.Errorf("ResolveEntry() path = %s, expected %s", gotPath, tt.expectedPath)
+			}
+			if diff := deep.Equal(data, got); diff != nil {
+				t.Error("ResolveEntry() got entry with diff", diff)
+			}
+		})
+	}
+}
+
+func TestEntryCatalog_SetEntry_InvalidLink(t *testing.T) {
+	gravelerMock := &FakeGraveler{KeyValue: make(map[string]*graveler.Value)}
+	cat := EntryCatalog{Store: gravelerMock}
+	ctx := context.Background()
+	for _, entry := range []*Entry{
+		{LinkTarget: "path1"},
+		{LinkTarget: "dir/"},
+		{LinkTarget: "other", Address: "addr1"},
+	} {
+		err := cat.SetEntry(ctx, "repo", "ref", "path1", entry)
+		if !errors.Is(err, ErrInvalidLinkTarget) {
+			t.Errorf("SetEntry() link to %s err = %v, expected %v", entry.LinkTarget, err, ErrInvalidLinkTarget)
+		}
+	}
+	err := cat.SetEntry(ctx, "repo", "ref", "a/b/c/d/e/", &Entry{LinkTarget: "v1/"})
+	if !errors.Is(err, ErrInvalidLinkTarget) {
+		t.Errorf("SetEntry() directory link deeper than %d err = %v, expected %v", MaxDirectoryLinkDepth, err, ErrInvalidLinkTarget)
+	}
+}
+
 func TestEntryCatalog_ListEntries_NoDelimiter(t *testing.T) {
 	entriesData := []*Entry{{Address: "addr1", Size: 1}, nil, nil}
 	listingData := []*graveler.ValueRecord{
@@ -164,7 +277,7 @@ func TestEntryCatalog_Diff(t *testing.T) {
 	}
 	cat := EntryCatalog{Store: gravelerMock}
 	ctx := context.Background()
-	diffs, err := cat.Diff(ctx, "repo", "left", "right")
+	diffs, err := cat.Diff(ctx, "repo", "left", "right", graveler.DiffTypeMaskAll)
 	testutil.MustDo(t, "diff", err)
 	defer diffs.Close()
 
@@ -186,3 +299,33 @@ func TestEntryCatalog_Diff(t *testing.T) {
 		t.Fatalf("Diff() got %d diffs, expected %d", i, len(diffData))
 	}
 }
+
+func TestEntryCatalog_DiffTypeMask(t *testing.T) {
+	diffData := []*graveler.Diff{
+		{Type: graveler.DiffTypeAdded, Key: graveler.Key("file1"), Value: MustEntryToValue(&Entry{Address: "addr1"})},
+		{Type: graveler.DiffTypeRemoved, Key: graveler.Key("file2")},
+		{Type: graveler.DiffTypeAdded, Key: graveler.Key("file3"), Value: MustEntryToValue(&Entry{Address: "addr3"})},
+		{Type: graveler.DiffTypeRemoved, Key: graveler.Key("file4")},
+	}
+	gravelerMock := &FakeGraveler{
+		DiffIteratorFactory: NewFakeDiffIteratorFactory(diffData),
+	}
+	cat := EntryCatalog{Store: gravelerMock}
+	ctx := context.Background()
+	diffs, err := cat.Diff(ctx, "repo", "left", "right", graveler.NewDiffTypeMask(graveler.DiffTypeRemoved))
+	testutil.MustDo(t, "diff", err)
+	defer diffs.Close()
+
+	var paths []string
+	for diffs.Next() {
+		v := diffs.Value()
+		if v.Type != graveler.DiffTypeRemoved {
+			t.Errorf("Diff() path %s type %v, expected only removals", v.Path, v.Type)
+		}
+		paths = append(paths, v.Path.String())
+	}
+	testutil.MustDo(t, "diff iteration", diffs.Err())
+	if diff := deep.Equal(paths, []string{"file2", "file4"}); diff != nil {
+		t.Error("Diff() unexpected paths:", diff)
+	}
+}
@@ -0,0 +1,33 @@
+package catalog
+
+import (
+	"fmt"
+
+	"github.com/treeverse/lakefs/graveler"
+)
+
+// EntryCondition is a precondition of writing an entry, checked against the current entry of
+// its path.  The zero condition always holds.
+type EntryCondition struct {
+	// IfAbsent writes only if the path has no entry
+	IfAbsent bool
+	// IfETagMatches writes only if the current entry of the path has this ETag
+	IfETagMatches string
+}
+
+// check returns graveler.ErrPreconditionFailed unless the condition holds for current, the
+// current entry of the path or nil if it has none
+func (c EntryCondition) check(current *Entry) error {
+	if c.IfAbsent && current != nil {
+		return fmt.Errorf("entry exists: %w", graveler.ErrPreconditionFailed)
+	}
+	if c.IfETagMatches != "" {
+		if current == nil {
+			return fmt.Errorf("entry not found: %w", graveler.ErrPreconditionFailed)
+		}
+		if current.ETag != c.IfETagMatches {
+			return fmt.Errorf("entry ETag %s: %w", current.ETag, graveler.ErrPreconditionFailed)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/treeverse/lakefs/graveler"
+	"google.golang.org/protobuf/proto"
+)
+
+// EntryVersion is a version of the entry of a path: the commit that set it and the entry it set,
+// nil if the commit deleted the path
+type EntryVersion struct {
+	Commit *CommitLog
+	Entry  *DBEntry
+}
+
+// GetEntryHistory returns the versions of path on the first-parent log of branch, newest
+// first.  Uncommitted changes are not included.  Continue listing by passing the commit of the
+// last version returned as fromReference.
+func (c *cataloger) GetEntryHistory(ctx context.Context, repository, branch, path string, fromReference string, limit int) ([]*EntryVersion, bool, error) {
+	repositoryID := graveler.RepositoryID(repository)
+	p := Path(path)
+	if err := Validate([]ValidateArg{
+		{"repository", repositoryID, ValidateRepositoryID},
+		{"branch", graveler.BranchID(branch), ValidateBranchID},
+		{"path", p, ValidatePath},
+	}); err != nil {
+		return nil, false, err
+	}
+	branchCommitID, err := c.EntryCatalog.Dereference(ctx, repositoryID, graveler.Ref(branch))
+	if err != nil {
+		return nil, false, fmt.Errorf("branch ref: %w", err)
+	}
+	if branchCommitID == "" {
+		return make([]*EntryVersion, 0), false, nil
+	}
+	it, err := c.EntryCatalog.Log(ctx, repositoryID, branchCommitID, true)
+	if err != nil {
+		return nil, false, err
+	}
+	defer it.Close()
+	if fromReference != "" {
+		fromCommitID, err := c.EntryCatalog.Dereference(ctx, repositoryID, graveler.Ref(fromReference))
+		if err != nil {
+			return nil, false, fmt.Errorf("from ref: %w", err)
+		}
+		for it.Next() {
+			if it.Value().CommitID == fromCommitID {
+				break
+			}
+		}
+		if err := it.Err(); err != nil {
+			return nil, false, err
+		}
+	}
+
+	// scan runs of commits with the same entry, a run starts (oldest commit first) with the
+	// commit that set its entry
+	versions := make([]*EntryVersion, 0)
+	var (
+		run     *graveler.CommitRecord
+		current *Entry
+	)
+	for it.Next() {
+		record := it.Value()
+		ent := current
+		if run == nil || record.MetaRangeID != run.MetaRangeID {
+			ent, err = c.EntryCatalog.GetEntry(ctx, repositoryID, graveler.Ref(record.CommitID), p)
+			if errors.Is(err, graveler.ErrNotFound) {
+				ent = nil
+			} else if err != nil {
+				return nil, false, err
+			}
+		}
+		if run != nil && !entriesEqual(ent, current) {
+			versions = append(versions, newEntryVersion(run, p, current))
+			if len(versions) > limit {
+				break
+			}
+		}
+		run = record
+		current = ent
+	}
+	if err := it.Err(); err != nil {
+		return nil, false, err
+	}
+	if len(versions) <= limit && run != nil && current != nil {
+		// the oldest run, its entry was set by the first commit of the log that has it
+		versions = append(versions, newEntryVersion(run, p, current))
+	}
+	hasMore := false
+	if len(versions) > limit {
+		hasMore = true
+		versions = versions[:limit]
+	}
+	return versions, hasMore, nil
+}
+
+func newEntryVersion(record *graveler.CommitRecord, path Path, ent *Entry) *EntryVersion {
+	version := &EntryVersion{Commit: newCommitLogFromRecord(record)}
+	if ent != nil {
+		catalogEntry := newCatalogEntryFromEntry(false, path.String(), ent)
+		version.Entry = &catalogEntry
+	}
+	return version
+}
+
+func entriesEqual(a, b *Entry) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return proto.Equal(a, b)
+}
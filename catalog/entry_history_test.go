@@ -0,0 +1,69 @@
+package catalog
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/testutil"
+)
+
+func TestCataloger_GetEntryHistory(t *testing.T) {
+	ctx := context.Background()
+	v1 := &Entry{Address: "addr1", ETag: "etag1"}
+	v2 := &Entry{Address: "addr2", ETag: "etag2"}
+	// newest first: c5 sets v2, c4 deletes, c3 changes another path, c2 sets v1
+	store := &FakeGraveler{
+		KeyValue: map[string]*graveler.Value{
+			"repo/c2/file": MustEntryToValue(v1),
+			"repo/c3/file": MustEntryToValue(v1),
+			"repo/c5/file": MustEntryToValue(v2),
+		},
+		CommitLog: []*graveler.CommitRecord{
+			{CommitID: "c5", Commit: &graveler.Commit{MetaRangeID: "m5"}},
+			{CommitID: "c4", Commit: &graveler.Commit{MetaRangeID: "m4"}},
+			{CommitID: "c3", Commit: &graveler.Commit{MetaRangeID: "m3"}},
+			{CommitID: "c2", Commit: &graveler.Commit{MetaRangeID: "m2"}},
+			{CommitID: "c1", Commit: &graveler.Commit{MetaRangeID: "m1"}},
+		},
+	}
+	c := &cataloger{EntryCatalog: &EntryCatalog{Store: store}}
+
+	tests := []struct {
+		name            string
+		from            string
+		limit           int
+		expectedCommits []string
+		expectedETags   []string
+		expectedHasMore bool
+	}{
+		{name: "all", limit: 10, expectedCommits: []string{"c5", "c4", "c2"}, expectedETags: []string{"etag2", "", "etag1"}},
+		{name: "limit", limit: 2, expectedCommits: []string{"c5", "c4"}, expectedETags: []string{"etag2", ""}, expectedHasMore: true},
+		{name: "from", from: "c4", limit: 2, expectedCommits: []string{"c2"}, expectedETags: []string{"etag1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			versions, hasMore, err := c.GetEntryHistory(ctx, "repo", "master", "file", tt.from, tt.limit)
+			testutil.MustDo(t, "get entry history", err)
+			if hasMore != tt.expectedHasMore {
+				t.Errorf("GetEntryHistory() hasMore = %t, expected %t", hasMore, tt.expectedHasMore)
+			}
+			var commits, etags []string
+			for _, version := range versions {
+				commits = append(commits, version.Commit.Reference)
+				etag := ""
+				if version.Entry != nil {
+					etag = version.Entry.Checksum
+				}
+				etags = append(etags, etag)
+			}
+			if diff := deep.Equal(commits, tt.expectedCommits); diff != nil {
+				t.Errorf("GetEntryHistory() commits diff %s", diff)
+			}
+			if diff := deep.Equal(etags, tt.expectedETags); diff != nil {
+				t.Errorf("GetEntryHistory() etags diff %s", diff)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package catalog
 
 import (
+	"bytes"
+	"errors"
 	"testing"
 	"time"
 
@@ -33,3 +35,37 @@ func TestEntryToValueAndBack(t *testing.T) {
 		t.Fatal("Entry convert to value and back failed:", diff)
 	}
 }
+
+func TestEntryToValueDirectoryMarkerIdentity(t *testing.T) {
+	entry := &Entry{Address: "dir", LastModified: timestamppb.Now()}
+	val, err := EntryToValue(entry)
+	testutil.MustDo(t, "convert entry value", err)
+	entry.DirectoryMarker = true
+	markerVal, err := EntryToValue(entry)
+	testutil.MustDo(t, "convert directory marker value", err)
+	if bytes.Equal(val.Identity, markerVal.Identity) {
+		t.Error("EntryToValue() directory marker identity should differ from object identity")
+	}
+}
+
+func TestValidateDirectoryMarker(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    Path
+		entry   *Entry
+		wantErr error
+	}{
+		{name: "object", path: "a/b", entry: &Entry{Size: 10}},
+		{name: "marker", path: "a/b/", entry: &Entry{DirectoryMarker: true}},
+		{name: "marker without suffix", path: "a/b", entry: &Entry{DirectoryMarker: true}, wantErr: ErrInvalidDirectoryMarker},
+		{name: "marker with data", path: "a/b/", entry: &Entry{DirectoryMarker: true, Size: 1}, wantErr: ErrInvalidDirectoryMarker},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateDirectoryMarker(tt.path, tt.entry)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidateDirectoryMarker() error = %v, expected %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/logging"
+)
+
+// ephemeralBranchesReapBatch limits the number of expired branches deleted by a single reap
+const ephemeralBranchesReapBatch = 1000
+
+// EphemeralBranchParams controls the lifetime of a branch created by CreateEphemeralBranch
+type EphemeralBranchParams struct {
+	// TTL after which the branch is deleted by the reaper
+	TTL time.Duration
+	// DeleteAfterMerge deletes the branch once it is successfully merged into another branch
+	DeleteAfterMerge bool
+}
+
+// EphemeralBranch is the lifetime record of a branch created by CreateEphemeralBranch
+type EphemeralBranch struct {
+	Repository       string    `db:"repository_id"`
+	Branch           string    `db:"branch_id"`
+	ExpiresAt        time.Time `db:"expires_at"`
+	DeleteAfterMerge bool      `db:"delete_after_merge"`
+	CreationDate     time.Time `db:"creation_date"`
+}
+
+// CreateEphemeralBranch creates branch from sourceRef and records it for deletion once its TTL
+// expires, or optionally once it is merged.
+func (c *cataloger) CreateEphemeralBranch(ctx context.Context, repository string, branch string, sourceRef string, params EphemeralBranchParams) (*CommitLog, error) {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+		{"branch", graveler.BranchID(branch), ValidateBranchID},
+		{"ttl", params.TTL, ValidateEphemeralBranchTTL},
+	}); err != nil {
+		return nil, err
+	}
+	commitLog, err := c.CreateBranch(ctx, repository, branch, sourceRef)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	_, err = c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		return tx.Exec(`INSERT INTO catalog_ephemeral_branches (repository_id, branch_id, expires_at, delete_after_merge, creation_date)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (repository_id, branch_id) DO UPDATE SET expires_at = EXCLUDED.expires_at,
+				delete_after_merge = EXCLUDED.delete_after_merge, creation_date = EXCLUDED.creation_date`,
+			repository, branch, now.Add(params.TTL), params.DeleteAfterMerge, now)
+	}, db.WithContext(ctx))
+	if err != nil {
+		// do not leave behind a branch that nothing will clean up
+		if deleteErr := c.EntryCatalog.DeleteBranch(ctx, graveler.RepositoryID(repository), graveler.BranchID(branch)); deleteErr != nil {
+			c.log.WithError(deleteErr).WithFields(logging.Fields{
+				"repository": repository,
+				"branch":     branch,
+			}).Error("Failed to delete ephemeral branch after record failure")
+		}
+		return nil, err
+	}
+	return commitLog, nil
+}
+
+// GetEphemeralBranch returns the lifetime record of branch, or db.ErrNotFound if it isn't ephemeral
+func (c *cataloger) GetEphemeralBranch(ctx context.Context, repository string, branch string) (*EphemeralBranch, error) {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+		{"branch", graveler.BranchID(branch), ValidateBranchID},
+	}); err != nil {
+		return nil, err
+	}
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var b EphemeralBranch
+		err := tx.Get(&b, `SELECT repository_id, branch_id, expires_at, delete_after_merge, creation_date
+			FROM catalog_ephemeral_branches WHERE repository_id = $1 AND branch_id = $2`,
+			repository, branch)
+		return &b, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.(*EphemeralBranch), nil
+}
+
+func (c *cataloger) deleteEphemeralBranchRecord(ctx context.Context, repository string, branch string) error {
+	_, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		return tx.Exec(`DELETE FROM catalog_ephemeral_branches WHERE repository_id = $1 AND branch_id = $2`,
+			repository, branch)
+	}, db.WithContext(ctx))
+	return err
+}
+
+// deleteMergedEphemeralBranch deletes sourceRef after it was merged, if it is an ephemeral branch
+// marked for deletion after merge.  Failures are logged, the merge itself already succeeded.
+func (c *cataloger) deleteMergedEphemeralBranch(ctx context.Context, repository string, sourceRef string) {
+	log := c.log.WithFields(logging.Fields{
+		"repository": repository,
+		"branch":     sourceRef,
+	})
+	b, err := c.GetEphemeralBranch(ctx, repository, sourceRef)
+	if errors.Is(err, db.ErrNotFound) {
+		return
+	}
+	if err != nil {
+		log.WithError(err).Error("Ephemeral branch: get record after merge")
+		return
+	}
+	if !b.DeleteAfterMerge {
+		return
+	}
+	if err := c.DeleteBranch(ctx, repository, sourceRef); err != nil && !errors.Is(err, graveler.ErrNotFound) {
+		log.WithError(err).Error("Ephemeral branch: delete after merge")
+	}
+}
+
+// DeleteExpiredBranches deletes ephemeral branches whose TTL passed.  Returns the number of
+// branches deleted.
+func (c *cataloger) DeleteExpiredBranches(ctx context.Context) (int, error) {
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var branches []*EphemeralBranch
+		err := tx.Select(&branches, `SELECT repository_id, branch_id, expires_at, delete_after_merge, creation_date
+			FROM catalog_ephemeral_branches WHERE expires_at <= $1 ORDER BY expires_at LIMIT $2`,
+			time.Now().UTC(), ephemeralBranchesReapBatch)
+		return branches, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for _, b := range res.([]*EphemeralBranch) {
+		err := c.EntryCatalog.DeleteBranch(ctx, graveler.RepositoryID(b.Repository), graveler.BranchID(b.Branch))
+		switch {
+		case err == nil:
+			deleted++
+		case errors.Is(err, graveler.ErrNotFound):
+			// branch or repository already gone - just drop the record
+		default:
+			// keep the record for the next reap, don't let one branch block the rest
+			c.log.WithError(err).WithFields(logging.Fields{
+				"repository": b.Repository,
+				"branch":     b.Branch,
+			}).Error("Failed to delete expired branch")
+			continue
+		}
+		if err := c.deleteEphemeralBranchRecord(ctx, b.Repository, b.Branch); err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
+
+// RunEphemeralBranchReaper deletes expired ephemeral branches every interval until ctx is done
+func RunEphemeralBranchReaper(ctx context.Context, c Cataloger, interval time.Duration) {
+	log := logging.FromContext(ctx).WithField("service", "ephemeral_branch_reaper")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := c.DeleteExpiredBranches(ctx)
+			if err != nil {
+				log.WithError(err).Error("Failed to delete expired branches")
+			}
+			if deleted > 0 {
+				log.WithField("deleted", deleted).Info("Deleted expired branches")
+			}
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/testutil"
+)
+
+func TestCataloger_DeleteRepository_EphemeralBranches(t *testing.T) {
+	ctx := context.Background()
+	conn, _ := testutil.GetDB(t, databaseURI)
+	c := testGravelerCataloger(t, conn)
+	_, err := c.CreateRepository(ctx, "repo", "mem://ephemeral", "main")
+	testutil.MustDo(t, "create repository", err)
+	_, err = c.CreateEphemeralBranch(ctx, "repo", "temp", "main", EphemeralBranchParams{TTL: time.Minute})
+	testutil.MustDo(t, "create ephemeral branch", err)
+
+	testutil.MustDo(t, "delete repository", c.DeleteRepository(ctx, "repo"))
+	_, err = c.CreateRepository(ctx, "repo", "mem://ephemeral-recreated", "main")
+	testutil.MustDo(t, "recreate repository", err)
+	_, err = c.CreateBranch(ctx, "repo", "temp", "main")
+	testutil.MustDo(t, "create branch", err)
+	if _, err := c.GetEphemeralBranch(ctx, "repo", "temp"); !errors.Is(err, db.ErrNotFound) {
+		t.Fatalf("GetEphemeralBranch() of a branch of the recreated repository err = %v, expected %s", err, db.ErrNotFound)
+	}
+
+	// the reaper leaves the branch of the recreated repository
+	_, err = conn.Exec(`UPDATE catalog_ephemeral_branches SET expires_at = $1`, time.Now().Add(-time.Hour))
+	testutil.MustDo(t, "expire ephemeral branches", err)
+	deleted, err := c.DeleteExpiredBranches(ctx)
+	testutil.MustDo(t, "delete expired branches", err)
+	if deleted != 0 {
+		t.Errorf("DeleteExpiredBranches() deleted %d branches, expected 0", deleted)
+	}
+	if _, err := c.GetBranchReference(ctx, "repo", "temp"); err != nil {
+		t.Errorf("branch of the recreated repository: %s", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/graveler"
 )
 
 var (
@@ -13,8 +14,38 @@ var (
 	ErrFeatureNotSupported      = errors.New("feature not supported")
 	ErrBranchNotFound           = fmt.Errorf("branch %w", db.ErrNotFound)
 	ErrRepositoryNotFound       = fmt.Errorf("repository %w", db.ErrNotFound)
+	ErrDatasetNotFound          = fmt.Errorf("dataset %w", db.ErrNotFound)
+	ErrDatasetAlreadyExists     = fmt.Errorf("dataset %w", db.ErrAlreadyExists)
+	ErrInvalidLineageInputs     = errors.New("invalid lineage inputs")
+	ErrRetentionRulesNotFound   = fmt.Errorf("retention rules %w", db.ErrNotFound)
+	ErrEntryAlreadyRedacted     = fmt.Errorf("redaction %w", db.ErrAlreadyExists)
 	ErrInvalidValue             = errors.New("invalid value")
 	ErrNoDifferenceWasFound     = errors.New("no difference was found")
 	ErrConflictFound            = errors.New("conflict found")
 	ErrUnsupportedRelation      = errors.New("unsupported relation")
+	ErrInvalidDirectoryMarker   = errors.New("invalid directory marker")
+	ErrInvalidLinkTarget        = errors.New("invalid link target")
+	ErrLinkLoop                 = errors.New("too many levels of links")
+	ErrCrossRepositoryLink      = errors.New("cross repository link not allowed")
+	ErrMetadataBackupNotFound   = fmt.Errorf("metadata backup %w", db.ErrNotFound)
+	ErrMergeGuardrailsNotFound  = fmt.Errorf("merge guardrails %w", db.ErrNotFound)
+	ErrMergeGuardrailsExceeded  = errors.New("merge guardrails exceeded")
+	ErrPathLeaseNotFound        = fmt.Errorf("path lease %w", db.ErrNotFound)
+	ErrObjectNotFound           = fmt.Errorf("object %w", db.ErrNotFound)
+	ErrPathLeased               = errors.New("path leased")
+	ErrAutoCommitPolicyNotFound = fmt.Errorf("auto-commit policy %w", db.ErrNotFound)
+	ErrDigestMismatch           = errors.New("digest mismatch")
+	ErrSchemaNotFound           = fmt.Errorf("schema %w", db.ErrNotFound)
+	ErrInvalidSchema            = errors.New("invalid schema")
+	ErrMergeRequestNotFound     = fmt.Errorf("merge request %w", db.ErrNotFound)
+	ErrMergeRequestNotQueued    = errors.New("merge request not queued")
+	ErrPullRequestNotFound      = fmt.Errorf("pull request %w", db.ErrNotFound)
+	ErrPullRequestNotOpen       = errors.New("pull request not open")
+	ErrInvalidOwners            = errors.New("invalid owners file")
+	ErrNoRefsToRecover          = fmt.Errorf("metadata backup or refs journal %w", db.ErrNotFound)
+	// ErrGarbageCollectionInProgress fails writes staging objects of other refs while garbage
+	// collection removes objects
+	ErrGarbageCollectionInProgress = fmt.Errorf("%w: garbage collection in progress", graveler.ErrLockNotAcquired)
+	// ErrSchemaIncompatible fails commits as a built-in pre-commit hook
+	ErrSchemaIncompatible = fmt.Errorf("%w: schema incompatible", graveler.ErrAbortedByHook)
 )
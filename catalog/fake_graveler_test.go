@@ -4,20 +4,34 @@ import (
 	"bytes"
 	"context"
 	"strings"
+	"time"
 
 	"github.com/treeverse/lakefs/graveler"
 )
 
 type FakeGraveler struct {
 	KeyValue                  map[string]*graveler.Value
+	Repositories              map[graveler.RepositoryID]*graveler.Repository
 	Err                       error
 	ListIteratorFactory       func() graveler.ValueIterator
 	DiffIteratorFactory       func() graveler.DiffIterator
 	RepositoryIteratorFactory func() graveler.RepositoryIterator
 	BranchIteratorFactory     func() graveler.BranchIterator
 	TagIteratorFactory        func() graveler.TagIterator
-	preCommitHook             graveler.PreCommitFunc
-	preMergeHook              graveler.PreMergeFunc
+	// Dumps are the MetaRangeIDs returned by dumps, by "commits", "branches", "tags" or "staging/<branch>"
+	Dumps map[string]graveler.MetaRangeID
+	// Loads records the MetaRangeIDs loaded, keyed like Dumps
+	Loads map[string]graveler.MetaRangeID
+	// Replayed records the refs journal entries replayed
+	Replayed []*graveler.RefsJournalEntry
+	// CommitLog is returned by Log, newest first.  Branches dereference to its first commit.
+	CommitLog []*graveler.CommitRecord
+	// MergedSources records the sources merged, or a prefix of them replaced
+	MergedSources []graveler.Ref
+	// PinnedSnapshots counts the listing snapshots pinned
+	PinnedSnapshots int
+	preCommitHook   graveler.PreCommitFunc
+	preMergeHook    graveler.PreMergeFunc
 }
 
 func (g *FakeGraveler) CreateBareRepository(ctx context.Context, repositoryID graveler.RepositoryID, storageNamespace graveler.StorageNamespace, branchID graveler.BranchID) (*graveler.Repository, error) {
@@ -25,27 +39,59 @@ func (g *FakeGraveler) CreateBareRepository(ctx context.Context, repositoryID gr
 }
 
 func (g *FakeGraveler) LoadCommits(ctx context.Context, repositoryID graveler.RepositoryID, metaRangeID graveler.MetaRangeID) error {
-	panic("implement me")
+	g.recordLoad("commits", metaRangeID)
+	return nil
 }
 
 func (g *FakeGraveler) LoadBranches(ctx context.Context, repositoryID graveler.RepositoryID, metaRangeID graveler.MetaRangeID) error {
-	panic("implement me")
+	g.recordLoad("branches", metaRangeID)
+	return nil
 }
 
 func (g *FakeGraveler) LoadTags(ctx context.Context, repositoryID graveler.RepositoryID, metaRangeID graveler.MetaRangeID) error {
-	panic("implement me")
+	g.recordLoad("tags", metaRangeID)
+	return nil
 }
 
 func (g *FakeGraveler) DumpCommits(ctx context.Context, repositoryID graveler.RepositoryID) (*graveler.MetaRangeID, error) {
-	panic("implement me")
+	metaRangeID := g.Dumps["commits"]
+	return &metaRangeID, nil
 }
 
 func (g *FakeGraveler) DumpBranches(ctx context.Context, repositoryID graveler.RepositoryID) (*graveler.MetaRangeID, error) {
-	panic("implement me")
+	metaRangeID := g.Dumps["branches"]
+	return &metaRangeID, nil
 }
 
 func (g *FakeGraveler) DumpTags(ctx context.Context, repositoryID graveler.RepositoryID) (*graveler.MetaRangeID, error) {
-	panic("implement me")
+	metaRangeID := g.Dumps["tags"]
+	return &metaRangeID, nil
+}
+
+func (g *FakeGraveler) DumpStaging(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID) (*graveler.MetaRangeID, error) {
+	metaRangeID := g.Dumps["staging/"+branchID.String()]
+	return &metaRangeID, nil
+}
+
+func (g *FakeGraveler) DiffDumps(ctx context.Context, repositoryID graveler.RepositoryID, left, right graveler.MetaRangeID) (graveler.DiffIterator, error) {
+	return g.DiffIteratorFactory(), nil
+}
+
+func (g *FakeGraveler) LoadStaging(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, metaRangeID graveler.MetaRangeID) error {
+	g.recordLoad("staging/"+branchID.String(), metaRangeID)
+	return nil
+}
+
+func (g *FakeGraveler) ReplayRefsJournal(ctx context.Context, repositoryID graveler.RepositoryID, entries []*graveler.RefsJournalEntry) error {
+	g.Replayed = append(g.Replayed, entries...)
+	return nil
+}
+
+func (g *FakeGraveler) recordLoad(key string, metaRangeID graveler.MetaRangeID) {
+	if g.Loads == nil {
+		g.Loads = make(map[string]graveler.MetaRangeID)
+	}
+	g.Loads[key] = metaRangeID
 }
 
 func fakeGravelerBuildKey(repositoryID graveler.RepositoryID, ref graveler.Ref, key graveler.Key) string {
@@ -64,17 +110,53 @@ func (g *FakeGraveler) Get(_ context.Context, repositoryID graveler.RepositoryID
 	return v, nil
 }
 
-func (g *FakeGraveler) Set(_ context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, key graveler.Key, value graveler.Value) error {
+func (g *FakeGraveler) GetMany(_ context.Context, repositoryID graveler.RepositoryID, ref graveler.Ref, keys []graveler.Key) ([]*graveler.Value, error) {
+	if g.Err != nil {
+		return nil, g.Err
+	}
+	values := make([]*graveler.Value, len(keys))
+	for i, key := range keys {
+		values[i] = g.KeyValue[fakeGravelerBuildKey(repositoryID, ref, key)]
+	}
+	return values, nil
+}
+
+func (g *FakeGraveler) Set(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, key graveler.Key, value graveler.Value) error {
+	if g.Err != nil {
+		return g.Err
+	}
+	if err := graveler.CheckBranchWrite(ctx); err != nil {
+		return err
+	}
+	k := fakeGravelerBuildKey(repositoryID, graveler.Ref(branchID.String()), key)
+	g.KeyValue[k] = &value
+	return nil
+}
+
+func (g *FakeGraveler) SetIf(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, key graveler.Key, value graveler.Value, condition graveler.SetCondition) error {
 	if g.Err != nil {
 		return g.Err
 	}
+	if err := graveler.CheckBranchWrite(ctx); err != nil {
+		return err
+	}
 	k := fakeGravelerBuildKey(repositoryID, graveler.Ref(branchID.String()), key)
+	if err := condition(g.KeyValue[k]); err != nil {
+		return err
+	}
 	g.KeyValue[k] = &value
 	return nil
 }
 
 func (g *FakeGraveler) Delete(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, key graveler.Key) error {
-	panic("implement me")
+	if g.Err != nil {
+		return g.Err
+	}
+	if err := graveler.CheckBranchWrite(ctx); err != nil {
+		return err
+	}
+	delete(g.KeyValue, fakeGravelerBuildKey(repositoryID, graveler.Ref(branchID.String()), key))
+	return nil
 }
 
 func (g *FakeGraveler) List(_ context.Context, _ graveler.RepositoryID, _ graveler.Ref) (graveler.ValueIterator, error) {
@@ -84,14 +166,56 @@ func (g *FakeGraveler) List(_ context.Context, _ graveler.RepositoryID, _ gravel
 	return g.ListIteratorFactory(), nil
 }
 
+func (g *FakeGraveler) Snapshot(_ context.Context, _ graveler.RepositoryID, ref graveler.Ref) (*graveler.ListingSnapshot, error) {
+	if g.Err != nil {
+		return nil, g.Err
+	}
+	return &graveler.ListingSnapshot{CommitID: graveler.CommitID(ref), StagingToken: "staging", Live: true}, nil
+}
+
+func (g *FakeGraveler) PinSnapshot(_ context.Context, _ graveler.RepositoryID, snapshot *graveler.ListingSnapshot) error {
+	if g.Err != nil {
+		return g.Err
+	}
+	g.PinnedSnapshots++
+	snapshot.StagingToken = "staging-pinned"
+	snapshot.Live = false
+	return nil
+}
+
+func (g *FakeGraveler) DropExpiredSnapshots(_ context.Context) (int, error) {
+	if g.Err != nil {
+		return 0, g.Err
+	}
+	return 0, nil
+}
+
+func (g *FakeGraveler) ListSnapshot(_ context.Context, _ graveler.RepositoryID, _ graveler.ListingSnapshot) (graveler.ValueIterator, error) {
+	if g.Err != nil {
+		return nil, g.Err
+	}
+	return g.ListIteratorFactory(), nil
+}
+
 func (g *FakeGraveler) GetRepository(ctx context.Context, repositoryID graveler.RepositoryID) (*graveler.Repository, error) {
-	panic("implement me")
+	if g.Err != nil {
+		return nil, g.Err
+	}
+	repo, ok := g.Repositories[repositoryID]
+	if !ok {
+		return nil, graveler.ErrRepositoryNotFound
+	}
+	return repo, nil
 }
 
 func (g *FakeGraveler) CreateRepository(ctx context.Context, repositoryID graveler.RepositoryID, storageNamespace graveler.StorageNamespace, branchID graveler.BranchID) (*graveler.Repository, error) {
 	panic("implement me")
 }
 
+func (g *FakeGraveler) ForkRepository(ctx context.Context, sourceID graveler.RepositoryID, repositoryID graveler.RepositoryID) (*graveler.Repository, error) {
+	panic("implement me")
+}
+
 func (g *FakeGraveler) ListRepositories(ctx context.Context) (graveler.RepositoryIterator, error) {
 	if g.Err != nil {
 		return nil, g.Err
@@ -150,10 +274,113 @@ func (g *FakeGraveler) ListTags(ctx context.Context, repositoryID graveler.Repos
 	return g.TagIteratorFactory(), nil
 }
 
-func (g *FakeGraveler) Log(ctx context.Context, repositoryID graveler.RepositoryID, commitID graveler.CommitID) (graveler.CommitIterator, error) {
+func (g *FakeGraveler) IsAncestor(ctx context.Context, repositoryID graveler.RepositoryID, ancestor, ref graveler.Ref) (bool, error) {
+	panic("implement me")
+}
+
+func (g *FakeGraveler) CommitsBetween(ctx context.Context, repositoryID graveler.RepositoryID, from, to graveler.Ref) ([]*graveler.CommitRecord, error) {
+	panic("implement me")
+}
+
+func (g *FakeGraveler) FindCommitsByMetadata(ctx context.Context, repositoryID graveler.RepositoryID, key, value string, after graveler.CommitID, limit int) ([]*graveler.CommitRecord, bool, error) {
+	if g.Err != nil {
+		return nil, false, g.Err
+	}
+	var commits []*graveler.CommitRecord
+	found := after == ""
+	for _, commit := range g.CommitLog {
+		if !found {
+			found = commit.CommitID == after
+			continue
+		}
+		if commit.Metadata[key] != value {
+			continue
+		}
+		if len(commits) == limit {
+			return commits, true, nil
+		}
+		commits = append(commits, commit)
+	}
+	return commits, false, nil
+}
+
+func (g *FakeGraveler) SetRefLabels(ctx context.Context, repositoryID graveler.RepositoryID, refType graveler.LabeledRefType, refID string, set map[string]string, unset []string) (map[string]string, error) {
 	panic("implement me")
 }
 
+func (g *FakeGraveler) GetRefLabels(ctx context.Context, repositoryID graveler.RepositoryID, refType graveler.LabeledRefType, refID string) (map[string]string, error) {
+	panic("implement me")
+}
+
+func (g *FakeGraveler) FindRefsByLabels(ctx context.Context, repositoryID graveler.RepositoryID, labels map[string]string, refType graveler.LabeledRefType) ([]*graveler.RefLabels, error) {
+	panic("implement me")
+}
+
+func (g *FakeGraveler) CreateRepositorySnapshot(ctx context.Context, repositoryID graveler.RepositoryID, snapshotID graveler.RepositorySnapshotID) (*graveler.RepositorySnapshot, error) {
+	panic("implement me")
+}
+
+func (g *FakeGraveler) GetRepositorySnapshot(ctx context.Context, repositoryID graveler.RepositoryID, snapshotID graveler.RepositorySnapshotID) (*graveler.RepositorySnapshot, error) {
+	panic("implement me")
+}
+
+func (g *FakeGraveler) ListRepositorySnapshots(ctx context.Context, repositoryID graveler.RepositoryID) ([]*graveler.RepositorySnapshot, error) {
+	panic("implement me")
+}
+
+func (g *FakeGraveler) DeleteRepositorySnapshot(ctx context.Context, repositoryID graveler.RepositoryID, snapshotID graveler.RepositorySnapshotID) error {
+	panic("implement me")
+}
+
+func (g *FakeGraveler) RestoreRepositorySnapshot(ctx context.Context, repositoryID graveler.RepositoryID, snapshotID graveler.RepositorySnapshotID) error {
+	panic("implement me")
+}
+
+func (g *FakeGraveler) ListCommits(ctx context.Context, repositoryID graveler.RepositoryID) (graveler.CommitIterator, error) {
+	panic("implement me")
+}
+
+func (g *FakeGraveler) ExpiredCommits(ctx context.Context, repositoryID graveler.RepositoryID, rules graveler.RetentionRules, now time.Time, pinned []graveler.CommitID) (*graveler.ExpiredCommits, error) {
+	panic("implement me")
+}
+
+func (g *FakeGraveler) DeleteBranchLogCommits(ctx context.Context, repositoryID graveler.RepositoryID, commitIDs []graveler.CommitID) error {
+	panic("implement me")
+}
+
+func (g *FakeGraveler) DeleteCommits(ctx context.Context, repositoryID graveler.RepositoryID, commitIDs []graveler.CommitID) error {
+	panic("implement me")
+}
+
+func (g *FakeGraveler) Fsck(ctx context.Context, repositoryID graveler.RepositoryID) (*graveler.FsckReport, error) {
+	panic("implement me")
+}
+
+func (g *FakeGraveler) UpgradeTrees(ctx context.Context, repositoryID graveler.RepositoryID, committer string) (map[graveler.BranchID]graveler.CommitID, error) {
+	panic("implement me")
+}
+
+func (g *FakeGraveler) SetRangeSplitPolicy(ctx context.Context, repositoryID graveler.RepositoryID, policy *graveler.RangeSplitPolicy) error {
+	panic("implement me")
+}
+
+func (g *FakeGraveler) FillGenerations(ctx context.Context, repositoryID graveler.RepositoryID) (int, error) {
+	panic("implement me")
+}
+
+func (g *FakeGraveler) PurgeHistory(ctx context.Context, repositoryID graveler.RepositoryID, key graveler.Key, prefix bool) (*graveler.PurgeResult, error) {
+	panic("implement me")
+}
+
+func (g *FakeGraveler) Log(_ context.Context, _ graveler.RepositoryID, commitID graveler.CommitID, _ bool) (graveler.CommitIterator, error) {
+	for i, record := range g.CommitLog {
+		if record.CommitID == commitID {
+			return &fakeCommitIterator{records: g.CommitLog[i:]}, nil
+		}
+	}
+	return nil, graveler.ErrNotFound
+}
+
 func (g *FakeGraveler) ListBranches(_ context.Context, _ graveler.RepositoryID) (graveler.BranchIterator, error) {
 	if g.Err != nil {
 		return nil, g.Err
@@ -169,53 +396,153 @@ func (g *FakeGraveler) Commit(ctx context.Context, repositoryID graveler.Reposit
 	panic("implement me")
 }
 
-func (g *FakeGraveler) GetCommit(ctx context.Context, repositoryID graveler.RepositoryID, commitID graveler.CommitID) (*graveler.Commit, error) {
+func (g *FakeGraveler) CommitPreview(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID) (graveler.MetaRangeID, graveler.DiffSummary, error) {
 	panic("implement me")
 }
 
-func (g *FakeGraveler) Dereference(ctx context.Context, repositoryID graveler.RepositoryID, ref graveler.Ref) (graveler.CommitID, error) {
-	panic("implement me")
+func (g *FakeGraveler) GetCommit(_ context.Context, _ graveler.RepositoryID, commitID graveler.CommitID) (*graveler.Commit, error) {
+	if g.CommitLog == nil {
+		panic("implement me")
+	}
+	for _, record := range g.CommitLog {
+		if record.CommitID == commitID {
+			return record.Commit, nil
+		}
+	}
+	return nil, graveler.ErrCommitNotFound
 }
 
-func (g *FakeGraveler) Reset(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID) error {
-	panic("implement me")
+func (g *FakeGraveler) Dereference(_ context.Context, _ graveler.RepositoryID, ref graveler.Ref) (graveler.CommitID, error) {
+	if g.CommitLog == nil {
+		panic("implement me")
+	}
+	for _, record := range g.CommitLog {
+		if record.CommitID.String() == ref.String() {
+			return record.CommitID, nil
+		}
+	}
+	if len(g.CommitLog) == 0 {
+		return "", nil
+	}
+	return g.CommitLog[0].CommitID, nil
+}
+
+func (g *FakeGraveler) Reset(_ context.Context, _ graveler.RepositoryID, _ graveler.BranchID) error {
+	return g.Err
 }
 
 func (g *FakeGraveler) ResetKey(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, key graveler.Key) error {
-	panic("implement me")
+	return g.Delete(ctx, repositoryID, branchID, key)
 }
 
 func (g *FakeGraveler) ResetPrefix(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, key graveler.Key) error {
+	if g.Err != nil {
+		return g.Err
+	}
+	if err := graveler.CheckBranchWrite(ctx); err != nil {
+		return err
+	}
+	prefix := fakeGravelerBuildKey(repositoryID, graveler.Ref(branchID.String()), key)
+	for k := range g.KeyValue {
+		if strings.HasPrefix(k, prefix) {
+			delete(g.KeyValue, k)
+		}
+	}
+	return nil
+}
+
+func (g *FakeGraveler) LockBranch(_ context.Context, _ graveler.RepositoryID, _ graveler.BranchID, fn func() error) error {
+	if g.Err != nil {
+		return g.Err
+	}
+	return fn()
+}
+
+func (g *FakeGraveler) GetStagingStats(_ context.Context, _ graveler.RepositoryID, _ graveler.BranchID) (*graveler.StagingStats, error) {
 	panic("implement me")
 }
 
+func (g *FakeGraveler) DropPrefix(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, key graveler.Key, _ graveler.CommitParams) (graveler.CommitID, graveler.DiffSummary, error) {
+	if err := g.ResetPrefix(ctx, repositoryID, branchID, key); err != nil {
+		return "", graveler.DiffSummary{}, err
+	}
+	return "dropped", graveler.DiffSummary{Count: map[graveler.DiffType]int{}}, nil
+}
+
+func (g *FakeGraveler) ReplacePrefixFromRef(ctx context.Context, _ graveler.RepositoryID, _ graveler.BranchID, _ graveler.Key, source graveler.Ref, _ graveler.CommitParams) (graveler.CommitID, graveler.DiffSummary, error) {
+	if g.Err != nil {
+		return "", graveler.DiffSummary{}, g.Err
+	}
+	if err := graveler.CheckBranchWrite(ctx); err != nil {
+		return "", graveler.DiffSummary{}, err
+	}
+	if err := graveler.CheckPrefixChange(ctx, "base", "replaced"); err != nil {
+		return "", graveler.DiffSummary{}, err
+	}
+	g.MergedSources = append(g.MergedSources, source)
+	return "replaced", graveler.DiffSummary{Count: map[graveler.DiffType]int{}}, nil
+}
+
+func (g *FakeGraveler) ApplyChanges(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, changes graveler.ValueIterator, _ graveler.CommitParams) (graveler.CommitID, graveler.DiffSummary, error) {
+	if g.Err != nil {
+		return "", graveler.DiffSummary{}, g.Err
+	}
+	if err := graveler.CheckBranchWrite(ctx); err != nil {
+		return "", graveler.DiffSummary{}, err
+	}
+	if g.KeyValue == nil {
+		g.KeyValue = make(map[string]*graveler.Value)
+	}
+	added := 0
+	for changes.Next() {
+		record := changes.Value()
+		g.KeyValue[fakeGravelerBuildKey(repositoryID, graveler.Ref(branchID), record.Key)] = record.Value
+		added++
+	}
+	if err := changes.Err(); err != nil {
+		return "", graveler.DiffSummary{}, err
+	}
+	return "applied", graveler.DiffSummary{Count: map[graveler.DiffType]int{graveler.DiffTypeAdded: added}}, nil
+}
+
 func (g *FakeGraveler) Revert(_ context.Context, _ graveler.RepositoryID, _ graveler.BranchID, _ graveler.Ref, _ int, _ graveler.CommitParams) (graveler.CommitID, graveler.DiffSummary, error) {
-	panic("implement me")
+	if g.Err != nil {
+		return "", graveler.DiffSummary{}, g.Err
+	}
+	return "reverted", graveler.DiffSummary{Count: map[graveler.DiffType]int{}}, nil
+}
+
+func (g *FakeGraveler) Merge(_ context.Context, _ graveler.RepositoryID, _ graveler.BranchID, source graveler.Ref, _ graveler.CommitParams) (graveler.CommitID, graveler.DiffSummary, error) {
+	if g.Err != nil {
+		return "", graveler.DiffSummary{}, g.Err
+	}
+	g.MergedSources = append(g.MergedSources, source)
+	return "merged", graveler.DiffSummary{Count: map[graveler.DiffType]int{}}, nil
 }
 
-func (g *FakeGraveler) Merge(ctx context.Context, repositoryID graveler.RepositoryID, destination graveler.BranchID, source graveler.Ref, _ graveler.CommitParams) (graveler.CommitID, graveler.DiffSummary, error) {
+func (g *FakeGraveler) MergePreview(_ context.Context, _ graveler.RepositoryID, _ graveler.BranchID, _ graveler.Ref) (*graveler.MergePreview, error) {
 	panic("implement me")
 }
 
-func (g *FakeGraveler) DiffUncommitted(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID) (graveler.DiffIterator, error) {
+func (g *FakeGraveler) DiffUncommitted(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, typeMask graveler.DiffTypeMask) (graveler.DiffIterator, error) {
 	if g.Err != nil {
 		return nil, g.Err
 	}
-	return g.DiffIteratorFactory(), nil
+	return graveler.NewDiffTypeFilterIterator(g.DiffIteratorFactory(), typeMask), nil
 }
 
-func (g *FakeGraveler) Diff(_ context.Context, _ graveler.RepositoryID, _, _ graveler.Ref) (graveler.DiffIterator, error) {
+func (g *FakeGraveler) Diff(_ context.Context, _ graveler.RepositoryID, _, _ graveler.Ref, typeMask graveler.DiffTypeMask) (graveler.DiffIterator, error) {
 	if g.Err != nil {
 		return nil, g.Err
 	}
-	return g.DiffIteratorFactory(), nil
+	return graveler.NewDiffTypeFilterIterator(g.DiffIteratorFactory(), typeMask), nil
 }
 
-func (g *FakeGraveler) Compare(_ context.Context, _ graveler.RepositoryID, _, _ graveler.Ref) (graveler.DiffIterator, error) {
+func (g *FakeGraveler) Compare(_ context.Context, _ graveler.RepositoryID, _, _ graveler.Ref, typeMask graveler.DiffTypeMask) (graveler.DiffIterator, error) {
 	if g.Err != nil {
 		return nil, g.Err
 	}
-	return g.DiffIteratorFactory(), nil
+	return graveler.NewDiffTypeFilterIterator(g.DiffIteratorFactory(), typeMask), nil
 }
 
 func (g *FakeGraveler) PreCommitHook() graveler.PreCommitFunc {
@@ -317,8 +644,14 @@ func (m *FakeDiffIterator) Next() bool {
 	return m.Index < len(m.Data)
 }
 
-func (m *FakeDiffIterator) SeekGE(_ graveler.Key) {
-	panic("implement me")
+func (m *FakeDiffIterator) SeekGE(id graveler.Key) {
+	m.Index = len(m.Data)
+	for i, d := range m.Data {
+		if bytes.Compare(d.Key, id) >= 0 {
+			m.Index = i - 1
+			return
+		}
+	}
 }
 
 func (m *FakeDiffIterator) Value() *graveler.Diff {
@@ -455,3 +788,32 @@ func (m *FakeTagIterator) Err() error {
 }
 
 func (m *FakeTagIterator) Close() {}
+
+type fakeCommitIterator struct {
+	records []*graveler.CommitRecord
+	value   *graveler.CommitRecord
+}
+
+func (it *fakeCommitIterator) Next() bool {
+	if len(it.records) == 0 {
+		it.value = nil
+		return false
+	}
+	it.value = it.records[0]
+	it.records = it.records[1:]
+	return true
+}
+
+func (it *fakeCommitIterator) SeekGE(id graveler.CommitID) {
+	panic("implement me")
+}
+
+func (it *fakeCommitIterator) Value() *graveler.CommitRecord {
+	return it.value
+}
+
+func (it *fakeCommitIterator) Err() error {
+	return nil
+}
+
+func (it *fakeCommitIterator) Close() {}
@@ -0,0 +1,60 @@
+package catalog
+
+import (
+	"context"
+
+	"github.com/treeverse/lakefs/graveler"
+)
+
+type FsckFinding struct {
+	// Severity is "error" for inconsistencies that break reading or writing the repository,
+	// "warning" otherwise
+	Severity string `json:"severity"`
+	Check    string `json:"check"`
+	Object   string `json:"object"`
+	Message  string `json:"message"`
+}
+
+type FsckReport struct {
+	Repository string        `json:"repository"`
+	Branches   int           `json:"branches"`
+	Tags       int           `json:"tags"`
+	Commits    int           `json:"commits"`
+	MetaRanges int           `json:"metaranges"`
+	Findings   []FsckFinding `json:"findings"`
+}
+
+// HasErrors returns true if the report holds a finding of error severity
+func (r *FsckReport) HasErrors() bool {
+	for _, f := range r.Findings {
+		if f.Severity == string(graveler.FsckSeverityError) {
+			return true
+		}
+	}
+	return false
+}
+
+// Fsck cross-validates the branches, tags, commits and MetaRanges of repository
+func (c *cataloger) Fsck(ctx context.Context, repository string) (*FsckReport, error) {
+	res, err := c.EntryCatalog.Fsck(ctx, graveler.RepositoryID(repository))
+	if err != nil {
+		return nil, err
+	}
+	report := &FsckReport{
+		Repository: repository,
+		Branches:   res.Branches,
+		Tags:       res.Tags,
+		Commits:    res.Commits,
+		MetaRanges: res.MetaRanges,
+		Findings:   make([]FsckFinding, len(res.Findings)),
+	}
+	for i, f := range res.Findings {
+		report.Findings[i] = FsckFinding{
+			Severity: string(f.Severity),
+			Check:    f.Check,
+			Object:   f.Object,
+			Message:  f.Message,
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,191 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/treeverse/lakefs/graveler"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// MaxGeneratedEntries bounds the number of entries generated by a single GenerateEntries call
+const MaxGeneratedEntries = 10_000_000
+
+// generatedAddressPrefix starts the physical addresses of generated entries, which have no data
+const generatedAddressPrefix = "generated-"
+
+// IsGeneratedAddress returns true if address belongs to an entry created by GenerateEntries,
+// so its object never existed
+func IsGeneratedAddress(address string) bool {
+	return strings.HasPrefix(address, generatedAddressPrefix)
+}
+
+// GenerateEntriesParams describes the entries synthesized by GenerateEntries.  Entries are spread
+// evenly over FanOut^Depth directories under Prefix, e.g. Prefix/d0/d3/f00017 for Depth 2.
+type GenerateEntriesParams struct {
+	Prefix string
+	Count  int
+	Depth  int
+	FanOut int
+	// Size is the size of every generated entry, no data is written for it
+	Size      int64
+	Committer string
+	Message   string
+	Metadata  Metadata
+}
+
+func validateGenerateEntriesParams(params GenerateEntriesParams) error {
+	if err := Validate([]ValidateArg{
+		{"committer", params.Committer, ValidateRequiredString},
+		{"depth", params.Depth, ValidateNonNegativeInt},
+	}); err != nil {
+		return err
+	}
+	if params.Count <= 0 || params.Count > MaxGeneratedEntries {
+		return fmt.Errorf("count must be between 1 and %d: %w", MaxGeneratedEntries, ErrInvalidValue)
+	}
+	if params.FanOut < 1 {
+		return fmt.Errorf("fan_out must be positive: %w", ErrInvalidValue)
+	}
+	if params.Size < 0 {
+		return fmt.Errorf("size must not be negative: %w", ErrInvalidValue)
+	}
+	dirs := 1
+	for i := 0; i < params.Depth; i++ {
+		dirs *= params.FanOut
+		if dirs > MaxGeneratedEntries {
+			return fmt.Errorf("fan_out^depth above %d: %w", MaxGeneratedEntries, ErrInvalidValue)
+		}
+	}
+	return nil
+}
+
+// generatedEntryIterator iterates over the entries described by GenerateEntriesParams, sorted by
+// path.  Path components are zero-padded so that their order follows the entry index.
+type generatedEntryIterator struct {
+	params      GenerateEntriesParams
+	filesPerDir int
+	dirWidth    int
+	fileWidth   int
+	modified    *timestamppb.Timestamp
+	next        int
+	value       *EntryRecord
+}
+
+func newGeneratedEntryIterator(params GenerateEntriesParams, modified time.Time) *generatedEntryIterator {
+	dirs := 1
+	for i := 0; i < params.Depth; i++ {
+		dirs *= params.FanOut
+	}
+	filesPerDir := (params.Count + dirs - 1) / dirs
+	return &generatedEntryIterator{
+		params:      params,
+		filesPerDir: filesPerDir,
+		dirWidth:    len(strconv.Itoa(params.FanOut - 1)),
+		fileWidth:   len(strconv.Itoa(filesPerDir - 1)),
+		modified:    timestamppb.New(modified),
+	}
+}
+
+func (it *generatedEntryIterator) path(i int) Path {
+	dir := i / it.filesPerDir
+	components := make([]string, it.params.Depth)
+	for level := it.params.Depth - 1; level >= 0; level-- {
+		components[level] = fmt.Sprintf("d%0*d/", it.dirWidth, dir%it.params.FanOut)
+		dir /= it.params.FanOut
+	}
+	p := it.params.Prefix
+	for _, c := range components {
+		p += c
+	}
+	return Path(fmt.Sprintf("%sf%0*d", p, it.fileWidth, i%it.filesPerDir))
+}
+
+func (it *generatedEntryIterator) Next() bool {
+	if it.next >= it.params.Count {
+		it.value = nil
+		return false
+	}
+	it.value = &EntryRecord{
+		Path: it.path(it.next),
+		Entry: &Entry{
+			Address:      fmt.Sprintf("%s%032x", generatedAddressPrefix, it.next),
+			LastModified: it.modified,
+			Size:         it.params.Size,
+			ETag:         fmt.Sprintf("%032x", it.next),
+		},
+	}
+	it.next++
+	return true
+}
+
+func (it *generatedEntryIterator) SeekGE(id Path) {
+	it.value = nil
+	it.next = sort.Search(it.params.Count, func(i int) bool {
+		return it.path(i) >= id
+	})
+}
+
+func (it *generatedEntryIterator) Value() *EntryRecord {
+	return it.value
+}
+
+func (it *generatedEntryIterator) Err() error {
+	return nil
+}
+
+func (it *generatedEntryIterator) Close() {}
+
+// GenerateEntries commits on branch the entries described by params, without writing their data.
+// The default branch of the repository is refused, so generated entries stay off the branch
+// readers use.
+func (c *cataloger) GenerateEntries(ctx context.Context, repository string, branch string, params GenerateEntriesParams) (*MergeResult, error) {
+	if err := validateGenerateEntriesParams(params); err != nil {
+		return nil, err
+	}
+	repo, err := c.EntryCatalog.GetRepository(ctx, graveler.RepositoryID(repository))
+	if err != nil {
+		return nil, err
+	}
+	if repo.DefaultBranchID.String() == branch {
+		return nil, fmt.Errorf("generate on default branch %s: %w", branch, ErrInvalidValue)
+	}
+	message := params.Message
+	if message == "" {
+		message = fmt.Sprintf("Generate %d entries under %s", params.Count, params.Prefix)
+	}
+	it := newGeneratedEntryIterator(params, time.Now())
+	defer it.Close()
+	var (
+		commitID graveler.CommitID
+		summary  graveler.DiffSummary
+	)
+	err = c.writeLeasedPaths(ctx, repository, branch, []string{params.Prefix}, true, func() error {
+		var err error
+		commitID, summary, err = c.EntryCatalog.ApplyEntries(ctx, graveler.RepositoryID(repository), graveler.BranchID(branch), it, graveler.CommitParams{
+			Committer: params.Committer,
+			Message:   message,
+			Metadata:  graveler.Metadata(params.Metadata),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	count := make(map[DifferenceType]int)
+	for k, v := range summary.Count {
+		kk, err := catalogDiffType(k)
+		if err != nil {
+			return nil, err
+		}
+		count[kk] = v
+	}
+	return &MergeResult{
+		Summary:   count,
+		Reference: commitID.String(),
+	}, nil
+}
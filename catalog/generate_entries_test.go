@@ -0,0 +1,86 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+func TestGeneratedEntryIterator(t *testing.T) {
+	params := GenerateEntriesParams{Prefix: "gen/", Count: 25, Depth: 2, FanOut: 3, Size: 100}
+	it := newGeneratedEntryIterator(params, time.Now())
+	var paths []string
+	for it.Next() {
+		v := it.Value()
+		if v.Size != 100 {
+			t.Errorf("entry %s size %d, expected 100", v.Path, v.Size)
+		}
+		paths = append(paths, v.Path.String())
+	}
+	if len(paths) != 25 {
+		t.Fatalf("generated %d entries, expected 25", len(paths))
+	}
+	if !sort.StringsAreSorted(paths) {
+		t.Errorf("generated entries not sorted: %v", paths)
+	}
+	expected := []string{"gen/d0/d0/f0", "gen/d0/d0/f1", "gen/d0/d0/f2", "gen/d0/d1/f0"}
+	if diff := deep.Equal(paths[:4], expected); diff != nil {
+		t.Error("generated paths diff found", diff)
+	}
+	if last := paths[len(paths)-1]; last != "gen/d2/d2/f0" {
+		t.Errorf("last generated path %s, expected gen/d2/d2/f0", last)
+	}
+
+	it.SeekGE("gen/d1/")
+	if !it.Next() || it.Value().Path != "gen/d1/d0/f0" {
+		t.Errorf("SeekGE(gen/d1/) got %+v, expected gen/d1/d0/f0", it.Value())
+	}
+	it.SeekGE("gen/e")
+	if it.Next() {
+		t.Errorf("SeekGE past the last entry got %+v", it.Value())
+	}
+}
+
+func TestCataloger_GenerateEntries(t *testing.T) {
+	ctx := context.Background()
+	store := &FakeGraveler{
+		Repositories: map[graveler.RepositoryID]*graveler.Repository{
+			"repo": {StorageNamespace: "mem://generate", DefaultBranchID: "production"},
+		},
+	}
+	c := testCataloger(t, store)
+	res, err := c.GenerateEntries(ctx, "repo", "main", GenerateEntriesParams{Count: 1000, Depth: 1, FanOut: 10, Committer: "tester"})
+	if err != nil {
+		t.Fatalf("GenerateEntries: %s", err)
+	}
+	if res.Summary[DifferenceTypeAdded] != 1000 || len(store.KeyValue) != 1000 {
+		t.Errorf("GenerateEntries() summary %v applying %d entries, expected 1000", res.Summary, len(store.KeyValue))
+	}
+	if v, ok := store.KeyValue["repo/main/d9/f99"]; !ok {
+		t.Error("GenerateEntries() did not apply d9/f99")
+	} else if entry, err := ValueToEntry(v); err != nil || !IsGeneratedAddress(entry.Address) {
+		t.Errorf("GenerateEntries() applied d9/f99 with entry %+v (%v), expected a generated address", entry, err)
+	}
+	_, err = c.GenerateEntries(ctx, "repo", "production", GenerateEntriesParams{Count: 10, FanOut: 1, Committer: "tester"})
+	if !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("GenerateEntries() on the default branch err=%v, expected %s", err, ErrInvalidValue)
+	}
+
+	invalid := []GenerateEntriesParams{
+		{Count: 0, FanOut: 1, Committer: "tester"},
+		{Count: MaxGeneratedEntries + 1, FanOut: 1, Committer: "tester"},
+		{Count: 10, FanOut: 0, Committer: "tester"},
+		{Count: 10, Depth: 10, FanOut: 10, Committer: "tester"},
+		{Count: 10, FanOut: 1, Size: -1, Committer: "tester"},
+	}
+	for _, params := range invalid {
+		if _, err := c.GenerateEntries(ctx, "repo", "main", params); !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("GenerateEntries(%+v) err=%v, expected %s", params, err, ErrInvalidValue)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package catalog
+
+import (
+	"context"
+	"testing"
+
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/testutil"
+)
+
+// refsStore resolves references of a single repository
+type refsStore struct {
+	Store
+	resolve  map[graveler.Ref]graveler.CommitID
+	branches map[graveler.BranchID]graveler.CommitID
+	tags     map[graveler.TagID]graveler.CommitID
+}
+
+func (s *refsStore) Dereference(_ context.Context, _ graveler.RepositoryID, ref graveler.Ref) (graveler.CommitID, error) {
+	commitID, ok := s.resolve[ref]
+	if !ok {
+		return "", graveler.ErrNotFound
+	}
+	return commitID, nil
+}
+
+func (s *refsStore) GetBranch(_ context.Context, _ graveler.RepositoryID, branchID graveler.BranchID) (*graveler.Branch, error) {
+	commitID, ok := s.branches[branchID]
+	if !ok {
+		return nil, graveler.ErrNotFound
+	}
+	return &graveler.Branch{CommitID: commitID}, nil
+}
+
+func (s *refsStore) GetTag(_ context.Context, _ graveler.RepositoryID, tagID graveler.TagID) (*graveler.CommitID, error) {
+	commitID, ok := s.tags[tagID]
+	if !ok {
+		return nil, graveler.ErrNotFound
+	}
+	return &commitID, nil
+}
+
+func TestCataloger_IsImmutableRef(t *testing.T) {
+	const (
+		head   = graveler.CommitID("c2c2c2c2c2c2c2c2c2c2c2c2c2c2c2c2c2c2c2c2c2c2c2c2c2c2c2c2c2c2c2c2")
+		parent = graveler.CommitID("c1c1c1c1c1c1c1c1c1c1c1c1c1c1c1c1c1c1c1c1c1c1c1c1c1c1c1c1c1c1c1c1")
+	)
+	store := &refsStore{
+		resolve: map[graveler.Ref]graveler.CommitID{
+			graveler.Ref(head):   head,
+			graveler.Ref(parent): parent,
+			"c2c2c2":             head,
+			"main":               head,
+			"main~1":             parent,
+			"v1":                 parent,
+			"v1~1":               parent,
+			"shadowed":           head,
+			"c1c1":               parent,
+		},
+		branches: map[graveler.BranchID]graveler.CommitID{"main": head, "shadowed": head},
+		tags:     map[graveler.TagID]graveler.CommitID{"v1": parent, "shadowed": parent, "c1c1": head},
+	}
+	c := &cataloger{EntryCatalog: &EntryCatalog{Store: store}}
+	tests := []struct {
+		reference string
+		expected  bool
+	}{
+		{reference: head.String(), expected: true},
+		{reference: parent.String(), expected: true},
+		{reference: "c2c2c2", expected: false},
+		{reference: "main", expected: false},
+		{reference: "main~1", expected: false},
+		{reference: "v1", expected: true},
+		{reference: "v1~1", expected: false},
+		{reference: "shadowed", expected: false},
+		{reference: "c1c1", expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reference, func(t *testing.T) {
+			immutable, err := c.IsImmutableRef(context.Background(), "repo", tt.reference)
+			testutil.MustDo(t, "is immutable ref", err)
+			if immutable != tt.expected {
+				t.Errorf("IsImmutableRef(%s) = %t, expected %t", tt.reference, immutable, tt.expected)
+			}
+		})
+	}
+}
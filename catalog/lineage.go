@@ -0,0 +1,255 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/logging"
+)
+
+// LineageInputsMetadataKey is the commit metadata key holding the inputs a commit was derived
+// from: a JSON array of {"repository": ..., "reference": ...} objects.  References are pinned to
+// their commit ID when the commit is created.
+const LineageInputsMetadataKey = "::lakefs::lineage_inputs"
+
+// LineageMaxDepth is the maximal depth of lineage graph traversal
+const LineageMaxDepth = 100
+
+// LineageIndexRetryInterval is the interval between retries of indexing the lineage inputs of
+// commits which failed to index them when created
+const LineageIndexRetryInterval = time.Minute
+
+// LineageRef identifies a commit in a repository taking part in the lineage graph
+type LineageRef struct {
+	Repository string `json:"repository" db:"repository_id"`
+	Reference  string `json:"reference" db:"commit_id"`
+}
+
+// LineageEdge links a commit to an input it was derived from
+type LineageEdge struct {
+	Commit LineageRef
+	Input  LineageRef
+}
+
+// pendingLineage is a commit whose lineage inputs are not indexed yet
+type pendingLineage struct {
+	repository string
+	commitID   graveler.CommitID
+	inputs     []LineageRef
+}
+
+func parseLineageInputs(metadata Metadata) ([]LineageRef, error) {
+	value, ok := metadata[LineageInputsMetadataKey]
+	if !ok {
+		return nil, nil
+	}
+	var inputs []LineageRef
+	if err := json.Unmarshal([]byte(value), &inputs); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidLineageInputs, err)
+	}
+	for _, input := range inputs {
+		if err := Validate([]ValidateArg{
+			{"repository", graveler.RepositoryID(input.Repository), ValidateRepositoryID},
+			{"reference", graveler.Ref(input.Reference), ValidateRef},
+		}); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidLineageInputs, err)
+		}
+	}
+	return inputs, nil
+}
+
+// pinLineageInputs resolves the lineage inputs declared in metadata to commit IDs, after
+// authorizing the repository of each of them when authorize is set.  Returns the metadata to
+// commit, holding the pinned inputs, together with the pinned inputs.
+func (c *cataloger) pinLineageInputs(ctx context.Context, metadata Metadata, authorize func(repository string) error) (Metadata, []LineageRef, error) {
+	inputs, err := parseLineageInputs(metadata)
+	if err != nil || len(inputs) == 0 {
+		return metadata, nil, err
+	}
+	pinned := make([]LineageRef, len(inputs))
+	for i, input := range inputs {
+		if authorize != nil {
+			if err := authorize(input.Repository); err != nil {
+				return nil, nil, fmt.Errorf("lineage input %s@%s: %w", input.Repository, input.Reference, err)
+			}
+		}
+		commitID, err := c.EntryCatalog.Dereference(ctx, graveler.RepositoryID(input.Repository), graveler.Ref(input.Reference))
+		if err != nil {
+			return nil, nil, fmt.Errorf("lineage input %s@%s: %w", input.Repository, input.Reference, err)
+		}
+		pinned[i] = LineageRef{Repository: input.Repository, Reference: commitID.String()}
+	}
+	value, err := json.Marshal(pinned)
+	if err != nil {
+		return nil, nil, err
+	}
+	result := make(Metadata, len(metadata))
+	for k, v := range metadata {
+		result[k] = v
+	}
+	result[LineageInputsMetadataKey] = string(value)
+	return result, pinned, nil
+}
+
+func (c *cataloger) indexLineageInputs(ctx context.Context, repository string, commitID graveler.CommitID, inputs []LineageRef) error {
+	if len(inputs) == 0 {
+		return nil
+	}
+	_, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		for _, input := range inputs {
+			_, err := tx.Exec(`INSERT INTO catalog_lineage (repository_id, commit_id, input_repository_id, input_commit_id)
+				VALUES ($1, $2, $3, $4)
+				ON CONFLICT DO NOTHING`,
+				repository, commitID, input.Repository, input.Reference)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}, db.WithContext(ctx))
+	return err
+}
+
+// indexLineageInputsLater keeps the inputs of commitID to index by IndexPendingLineage.  The
+// commit holds its inputs in its metadata, only downstream lookups miss them meanwhile.
+func (c *cataloger) indexLineageInputsLater(repository string, commitID graveler.CommitID, inputs []LineageRef) {
+	c.lineageMu.Lock()
+	defer c.lineageMu.Unlock()
+	c.lineagePending = append(c.lineagePending, pendingLineage{repository: repository, commitID: commitID, inputs: inputs})
+}
+
+// IndexPendingLineage indexes the lineage inputs of the commits which failed to index them when
+// created, and returns the number of commits indexed
+func (c *cataloger) IndexPendingLineage(ctx context.Context) (int, error) {
+	c.lineageMu.Lock()
+	pending := c.lineagePending
+	c.lineagePending = nil
+	c.lineageMu.Unlock()
+	for i, p := range pending {
+		if err := c.indexLineageInputs(ctx, p.repository, p.commitID, p.inputs); err != nil {
+			c.lineageMu.Lock()
+			c.lineagePending = append(c.lineagePending, pending[i:]...)
+			c.lineageMu.Unlock()
+			return i, err
+		}
+	}
+	return len(pending), nil
+}
+
+// RunLineageIndexer retries indexing pending lineage inputs every interval until ctx is done
+func RunLineageIndexer(ctx context.Context, c Cataloger, interval time.Duration) {
+	log := logging.FromContext(ctx).WithField("service", "lineage_indexer")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			indexed, err := c.IndexPendingLineage(ctx)
+			if err != nil {
+				log.WithError(err).Error("Failed to index pending lineage inputs")
+			}
+			if indexed > 0 {
+				log.WithField("indexed", indexed).Info("Indexed pending lineage inputs")
+			}
+		}
+	}
+}
+
+// GetCommitInputs returns the inputs the commit at reference was declared to be derived from
+func (c *cataloger) GetCommitInputs(ctx context.Context, repository string, reference string) ([]LineageRef, error) {
+	commit, err := c.GetCommit(ctx, repository, reference)
+	if err != nil {
+		return nil, err
+	}
+	return parseLineageInputs(commit.Metadata)
+}
+
+// ListCommitsByInput returns the commits declaring the commit at reference as their input
+func (c *cataloger) ListCommitsByInput(ctx context.Context, repository string, reference string) ([]LineageRef, error) {
+	commitID, err := c.EntryCatalog.Dereference(ctx, graveler.RepositoryID(repository), graveler.Ref(reference))
+	if err != nil {
+		return nil, err
+	}
+	return c.listCommitsByInput(ctx, LineageRef{Repository: repository, Reference: commitID.String()})
+}
+
+func (c *cataloger) listCommitsByInput(ctx context.Context, input LineageRef) ([]LineageRef, error) {
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var refs []LineageRef
+		err := tx.Select(&refs, `SELECT repository_id, commit_id FROM catalog_lineage
+			WHERE input_repository_id = $1 AND input_commit_id = $2
+			ORDER BY repository_id, commit_id`,
+			input.Repository, input.Reference)
+		return refs, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.([]LineageRef), nil
+}
+
+// GetLineage traverses the lineage graph from the commit at reference up to maxDepth levels.
+// Upstream traversal follows the inputs of each commit, downstream traversal follows the
+// commits using each commit as input.  When authorize is set, commits of repositories it
+// rejects are pruned from the graph, together with their edges.
+func (c *cataloger) GetLineage(ctx context.Context, repository string, reference string, downstream bool, maxDepth int, authorize func(repository string) error) ([]LineageEdge, error) {
+	if maxDepth <= 0 || maxDepth > LineageMaxDepth {
+		maxDepth = LineageMaxDepth
+	}
+	commitID, err := c.EntryCatalog.Dereference(ctx, graveler.RepositoryID(repository), graveler.Ref(reference))
+	if err != nil {
+		return nil, err
+	}
+	authorized := map[string]bool{repository: true}
+	isAuthorized := func(repository string) bool {
+		if authorize == nil {
+			return true
+		}
+		allowed, ok := authorized[repository]
+		if !ok {
+			allowed = authorize(repository) == nil
+			authorized[repository] = allowed
+		}
+		return allowed
+	}
+	start := LineageRef{Repository: repository, Reference: commitID.String()}
+	visited := map[LineageRef]struct{}{start: {}}
+	current := []LineageRef{start}
+	var edges []LineageEdge
+	for depth := 0; depth < maxDepth && len(current) > 0; depth++ {
+		var next []LineageRef
+		for _, ref := range current {
+			var neighbours []LineageRef
+			if downstream {
+				neighbours, err = c.listCommitsByInput(ctx, ref)
+			} else {
+				neighbours, err = c.GetCommitInputs(ctx, ref.Repository, ref.Reference)
+			}
+			if err != nil {
+				return nil, err
+			}
+			for _, n := range neighbours {
+				if !isAuthorized(n.Repository) {
+					continue
+				}
+				if downstream {
+					edges = append(edges, LineageEdge{Commit: n, Input: ref})
+				} else {
+					edges = append(edges, LineageEdge{Commit: ref, Input: n})
+				}
+				if _, ok := visited[n]; !ok {
+					visited[n] = struct{}{}
+					next = append(next, n)
+				}
+			}
+		}
+		current = next
+	}
+	return edges, nil
+}
@@ -0,0 +1,115 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/testutil"
+)
+
+var errDenied = errors.New("denied")
+
+func TestParseLineageInputs(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata Metadata
+		expected []LineageRef
+		wantErr  error
+	}{
+		{name: "no inputs", metadata: Metadata{"key": "value"}},
+		{
+			name:     "inputs",
+			metadata: Metadata{LineageInputsMetadataKey: `[{"repository":"raw-data","reference":"master"},{"repository":"features","reference":"v1"}]`},
+			expected: []LineageRef{{Repository: "raw-data", Reference: "master"}, {Repository: "features", Reference: "v1"}},
+		},
+		{name: "not json", metadata: Metadata{LineageInputsMetadataKey: "raw-data@master"}, wantErr: ErrInvalidLineageInputs},
+		{name: "invalid repository", metadata: Metadata{LineageInputsMetadataKey: `[{"repository":"R","reference":"master"}]`}, wantErr: ErrInvalidLineageInputs},
+		{name: "missing reference", metadata: Metadata{LineageInputsMetadataKey: `[{"repository":"raw-data"}]`}, wantErr: ErrInvalidLineageInputs},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputs, err := parseLineageInputs(tt.metadata)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("parseLineageInputs() error = %v, expected %v", err, tt.wantErr)
+			}
+			if diff := deep.Equal(inputs, tt.expected); diff != nil {
+				t.Fatal("parseLineageInputs() diff found", diff)
+			}
+		})
+	}
+}
+
+func TestCataloger_LineageAuthorization(t *testing.T) {
+	ctx := context.Background()
+	conn, _ := testutil.GetDB(t, databaseURI)
+	c := testGravelerCataloger(t, conn)
+	commitIDs := make(map[string]string)
+	for _, repo := range []string{"raw", "derived"} {
+		_, err := c.CreateRepository(ctx, repo, "mem://lineage-"+repo, "main")
+		testutil.MustDo(t, "create repository "+repo, err)
+		err = c.CreateEntry(ctx, repo, "main", DBEntry{Path: "data", PhysicalAddress: repo, Size: 1, Checksum: repo})
+		testutil.MustDo(t, "create entry in "+repo, err)
+	}
+	raw, err := c.Commit(ctx, "raw", "main", "load raw", "tester", nil)
+	testutil.MustDo(t, "commit raw", err)
+	commitIDs["raw"] = raw.Reference
+
+	rejectRaw := func(repository string) error {
+		if repository == "raw" {
+			return errDenied
+		}
+		return nil
+	}
+	metadata := Metadata{LineageInputsMetadataKey: `[{"repository":"raw","reference":"main"}]`}
+	_, err = c.CommitWithParams(ctx, "derived", "main", CommitParams{
+		Committer:             "tester",
+		Message:               "derive",
+		Metadata:              metadata,
+		AuthorizeLineageInput: rejectRaw,
+	})
+	if !errors.Is(err, errDenied) {
+		t.Fatalf("CommitWithParams() with an unreadable input err = %v, expected %s", err, errDenied)
+	}
+	derived, err := c.CommitWithParams(ctx, "derived", "main", CommitParams{Committer: "tester", Message: "derive", Metadata: metadata})
+	testutil.MustDo(t, "commit derived", err)
+	commitIDs["derived"] = derived.Reference
+
+	edges, err := c.GetLineage(ctx, "derived", "main", false, 0, nil)
+	testutil.MustDo(t, "get lineage", err)
+	expected := []LineageEdge{{
+		Commit: LineageRef{Repository: "derived", Reference: commitIDs["derived"]},
+		Input:  LineageRef{Repository: "raw", Reference: commitIDs["raw"]},
+	}}
+	if diff := deep.Equal(edges, expected); diff != nil {
+		t.Error("GetLineage() diff found", diff)
+	}
+	edges, err = c.GetLineage(ctx, "derived", "main", false, 0, rejectRaw)
+	testutil.MustDo(t, "get lineage without raw", err)
+	if len(edges) != 0 {
+		t.Errorf("GetLineage() returned %v of an unreadable repository", edges)
+	}
+}
+
+func TestCataloger_IndexPendingLineage(t *testing.T) {
+	ctx := context.Background()
+	conn, _ := testutil.GetDB(t, databaseURI)
+	c := testGravelerCataloger(t, conn)
+	input := LineageRef{Repository: "raw", Reference: "c1"}
+	c.indexLineageInputsLater("derived", "c2", []LineageRef{input})
+
+	indexed, err := c.IndexPendingLineage(ctx)
+	testutil.MustDo(t, "index pending lineage", err)
+	if indexed != 1 {
+		t.Errorf("IndexPendingLineage() indexed %d, expected 1", indexed)
+	}
+	refs, err := c.listCommitsByInput(ctx, input)
+	testutil.MustDo(t, "list commits by input", err)
+	if diff := deep.Equal(refs, []LineageRef{{Repository: "derived", Reference: "c2"}}); diff != nil {
+		t.Error("listCommitsByInput() diff found", diff)
+	}
+	if indexed, err := c.IndexPendingLineage(ctx); err != nil || indexed != 0 {
+		t.Errorf("IndexPendingLineage() again indexed %d err %v, expected nothing pending", indexed, err)
+	}
+}
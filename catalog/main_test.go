@@ -0,0 +1,67 @@
+package catalog
+
+import (
+	"flag"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/sirupsen/logrus"
+	"github.com/treeverse/lakefs/block/mem"
+	"github.com/treeverse/lakefs/config"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/logging"
+	"github.com/treeverse/lakefs/testutil"
+)
+
+var databaseURI string
+
+// testCataloger returns a cataloger keeping its own records in a fresh database, over store
+func testCataloger(t testing.TB, store *FakeGraveler) *cataloger {
+	t.Helper()
+	conn, _ := testutil.GetDB(t, databaseURI)
+	return &cataloger{
+		EntryCatalog: &EntryCatalog{Store: store},
+		db:           conn,
+		log:          logging.Default(),
+		redactions:   newRedactionsCache(),
+	}
+}
+
+// testGravelerCataloger returns a cataloger over graveler and the mem block adapter, keeping all
+// records in conn
+func testGravelerCataloger(t testing.TB, conn db.Database) *cataloger {
+	t.Helper()
+	cfg := config.NewConfig()
+	cacheDir := t.TempDir()
+	cfg.Override(func(configurator config.Configurator) {
+		configurator.SetDefault(config.BlockstoreTypeKey, mem.BlockstoreType)
+		configurator.SetDefault(config.CommittedLocalCacheDirKey, cacheDir)
+	})
+	c, err := NewCataloger(Config{Config: cfg, DB: conn})
+	testutil.MustDo(t, "build cataloger", err)
+	t.Cleanup(func() {
+		_ = c.Close()
+	})
+	return c.(*cataloger)
+}
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if !testing.Verbose() {
+		// keep the log level calm
+		logrus.SetLevel(logrus.PanicLevel)
+	}
+
+	// postgres container
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		log.Fatalf("Could not connect to Docker: %s", err)
+	}
+	var closer func()
+	databaseURI, closer = testutil.GetDBInstance(pool)
+	code := m.Run()
+	closer() // cleanup
+	os.Exit(code)
+}
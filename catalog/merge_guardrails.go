@@ -0,0 +1,243 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+// MergeGuardrails limit the changes a single merge may make to a branch.  A zero limit is not
+// enforced.
+type MergeGuardrails struct {
+	// MaxChangedEntries limits the number of entries added, changed or deleted by a merge
+	MaxChangedEntries int `db:"max_changed_entries"`
+	// MaxDeletedEntries limits the number of entries deleted by a merge
+	MaxDeletedEntries int `db:"max_deleted_entries"`
+	// MaxBytes limits the total size of the entries added or changed by a merge
+	MaxBytes int64 `db:"max_bytes"`
+	// RequiredStatusChecks are the contexts of the commit statuses that must pass on the source
+	// of a merge
+	RequiredStatusChecks []string `db:"required_status_checks"`
+	// RequiredApprovals is the number of reviewers of an open pull request that must approve
+	// the source of a merge
+	RequiredApprovals int `db:"required_approvals"`
+}
+
+// MergeGuardrailsError describes the guardrails exceeded by a merge
+type MergeGuardrailsError struct {
+	Branch     string
+	Violations []string
+}
+
+func (e *MergeGuardrailsError) Error() string {
+	return fmt.Sprintf("merge into '%s' exceeds guardrails: %s", e.Branch, strings.Join(e.Violations, ", "))
+}
+
+func (e *MergeGuardrailsError) Unwrap() error {
+	return ErrMergeGuardrailsExceeded
+}
+
+func validateMergeGuardrails(guardrails MergeGuardrails) error {
+	if guardrails.MaxBytes < 0 {
+		return fmt.Errorf("max_bytes: %w", ErrInvalidValue)
+	}
+	for _, check := range guardrails.RequiredStatusChecks {
+		if check == "" {
+			return fmt.Errorf("required_status_checks: %w", ErrInvalidValue)
+		}
+	}
+	return Validate([]ValidateArg{
+		{"max_changed_entries", guardrails.MaxChangedEntries, ValidateNonNegativeInt},
+		{"max_deleted_entries", guardrails.MaxDeletedEntries, ValidateNonNegativeInt},
+		{"required_approvals", guardrails.RequiredApprovals, ValidateNonNegativeInt},
+	})
+}
+
+func (c *cataloger) SetMergeGuardrails(ctx context.Context, repository, branch string, guardrails MergeGuardrails) error {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+		{"branch", graveler.BranchID(branch), ValidateBranchID},
+	}); err != nil {
+		return err
+	}
+	if err := validateMergeGuardrails(guardrails); err != nil {
+		return err
+	}
+	if _, err := c.EntryCatalog.GetBranch(ctx, graveler.RepositoryID(repository), graveler.BranchID(branch)); err != nil {
+		return err
+	}
+	requiredStatusChecks := guardrails.RequiredStatusChecks
+	if requiredStatusChecks == nil {
+		requiredStatusChecks = []string{}
+	}
+	_, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		return tx.Exec(`INSERT INTO catalog_merge_guardrails (repository_id, branch_id, max_changed_entries, max_deleted_entries, max_bytes, required_status_checks, required_approvals, update_date)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			ON CONFLICT (repository_id, branch_id) DO UPDATE SET max_changed_entries = EXCLUDED.max_changed_entries,
+				max_deleted_entries = EXCLUDED.max_deleted_entries, max_bytes = EXCLUDED.max_bytes,
+				required_status_checks = EXCLUDED.required_status_checks, required_approvals = EXCLUDED.required_approvals,
+				update_date = EXCLUDED.update_date`,
+			repository, branch, guardrails.MaxChangedEntries, guardrails.MaxDeletedEntries, guardrails.MaxBytes, requiredStatusChecks,
+			guardrails.RequiredApprovals, time.Now().UTC())
+	}, db.WithContext(ctx))
+	return err
+}
+
+func (c *cataloger) GetMergeGuardrails(ctx context.Context, repository, branch string) (*MergeGuardrails, error) {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+		{"branch", graveler.BranchID(branch), ValidateBranchID},
+	}); err != nil {
+		return nil, err
+	}
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var guardrails MergeGuardrails
+		err := tx.Get(&guardrails, `SELECT max_changed_entries, max_deleted_entries, max_bytes, required_status_checks, required_approvals
+			FROM catalog_merge_guardrails WHERE repository_id = $1 AND branch_id = $2`,
+			repository, branch)
+		return &guardrails, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, ErrMergeGuardrailsNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res.(*MergeGuardrails), nil
+}
+
+func (c *cataloger) DeleteMergeGuardrails(ctx context.Context, repository, branch string) error {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+		{"branch", graveler.BranchID(branch), ValidateBranchID},
+	}); err != nil {
+		return err
+	}
+	_, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		res, err := tx.Exec(`DELETE FROM catalog_merge_guardrails WHERE repository_id = $1 AND branch_id = $2`,
+			repository, branch)
+		if err != nil {
+			return nil, err
+		}
+		if res.RowsAffected() == 0 {
+			return nil, ErrMergeGuardrailsNotFound
+		}
+		return nil, nil
+	}, db.WithContext(ctx))
+	return err
+}
+
+// checkMergeGuardrails verifies the changes of merging sourceCommitID, the commit of sourceRef,
+// into destinationBranch against the guardrails of destinationBranch
+func (c *cataloger) checkMergeGuardrails(ctx context.Context, repository, destinationBranch, sourceRef string, sourceCommitID graveler.CommitID) error {
+	guardrails, err := c.GetMergeGuardrails(ctx, repository, destinationBranch)
+	if errors.Is(err, ErrMergeGuardrailsNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	statusViolations, err := c.mergeSourceViolations(ctx, guardrails, repository, destinationBranch, sourceRef, sourceCommitID)
+	if err != nil {
+		return err
+	}
+	it, err := c.EntryCatalog.Compare(ctx, graveler.RepositoryID(repository), graveler.Ref(sourceCommitID), graveler.Ref(destinationBranch), graveler.DiffTypeMaskAll)
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	return guardrails.checkChanges(destinationBranch, it, statusViolations...)
+}
+
+// checkPrefixChangeGuardrails verifies replacing a prefix of destinationBranch with the entries
+// of sourceCommitID, the commit of sourceRef, against the guardrails of destinationBranch as a
+// merge of sourceRef.  The replacement changes the MetaRange baseID of the branch head to
+// changedID.
+func (c *cataloger) checkPrefixChangeGuardrails(ctx context.Context, repository, destinationBranch, sourceRef string, sourceCommitID graveler.CommitID, baseID, changedID graveler.MetaRangeID) error {
+	guardrails, err := c.GetMergeGuardrails(ctx, repository, destinationBranch)
+	if errors.Is(err, ErrMergeGuardrailsNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	statusViolations, err := c.mergeSourceViolations(ctx, guardrails, repository, destinationBranch, sourceRef, sourceCommitID)
+	if err != nil {
+		return err
+	}
+	diffs, err := c.EntryCatalog.DiffDumps(ctx, graveler.RepositoryID(repository), baseID, changedID)
+	if err != nil {
+		return err
+	}
+	it := NewEntryDiffIterator(diffs)
+	defer it.Close()
+	return guardrails.checkChanges(destinationBranch, it, statusViolations...)
+}
+
+// mergeSourceViolations returns the required status checks and approvals sourceCommitID, the
+// commit of sourceRef, misses to merge into destinationBranch
+func (c *cataloger) mergeSourceViolations(ctx context.Context, guardrails *MergeGuardrails, repository, destinationBranch, sourceRef string, sourceCommitID graveler.CommitID) ([]string, error) {
+	var violations []string
+	if len(guardrails.RequiredStatusChecks) > 0 {
+		statuses, err := c.commitStatuses(ctx, repository, sourceCommitID)
+		if err != nil {
+			return nil, err
+		}
+		violations = guardrails.statusViolations(statuses)
+	}
+	if guardrails.RequiredApprovals > 0 {
+		approvals, err := c.pullRequestApprovals(ctx, repository, destinationBranch, sourceRef, sourceCommitID)
+		if err != nil {
+			return nil, err
+		}
+		violations = append(violations, guardrails.approvalViolations(approvals)...)
+	}
+	return violations, nil
+}
+
+// checkChanges verifies the changes of it against the guardrails of branch
+func (g *MergeGuardrails) checkChanges(branch string, it EntryDiffIterator, statusViolations ...string) error {
+	var changed, deleted int
+	var bytes int64
+	for it.Next() {
+		v := it.Value()
+		switch v.Type {
+		case graveler.DiffTypeRemoved:
+			deleted++
+		case graveler.DiffTypeAdded, graveler.DiffTypeChanged:
+			if v.Entry != nil {
+				bytes += v.Entry.Size
+			}
+		default:
+			// conflicts fail the merge itself
+			continue
+		}
+		changed++
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	return g.check(branch, changed, deleted, bytes, statusViolations...)
+}
+
+func (g *MergeGuardrails) check(branch string, changed, deleted int, bytes int64, statusViolations ...string) error {
+	violations := statusViolations
+	if g.MaxChangedEntries > 0 && changed > g.MaxChangedEntries {
+		violations = append(violations, fmt.Sprintf("%d entries changed, limit is %d", changed, g.MaxChangedEntries))
+	}
+	if g.MaxDeletedEntries > 0 && deleted > g.MaxDeletedEntries {
+		violations = append(violations, fmt.Sprintf("%d entries deleted, limit is %d", deleted, g.MaxDeletedEntries))
+	}
+	if g.MaxBytes > 0 && bytes > g.MaxBytes {
+		violations = append(violations, fmt.Sprintf("%d bytes changed, limit is %d", bytes, g.MaxBytes))
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return &MergeGuardrailsError{Branch: branch, Violations: violations}
+}
@@ -0,0 +1,157 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+func TestMergeGuardrails_Check(t *testing.T) {
+	guardrails := MergeGuardrails{MaxChangedEntries: 10, MaxDeletedEntries: 2, MaxBytes: 1000}
+	tests := []struct {
+		name           string
+		guardrails     MergeGuardrails
+		changed        int
+		deleted        int
+		bytes          int64
+		wantViolations int
+	}{
+		{name: "within", guardrails: guardrails, changed: 10, deleted: 2, bytes: 1000},
+		{name: "changed", guardrails: guardrails, changed: 11, wantViolations: 1},
+		{name: "deleted", guardrails: guardrails, changed: 3, deleted: 3, wantViolations: 1},
+		{name: "bytes", guardrails: guardrails, changed: 1, bytes: 1001, wantViolations: 1},
+		{name: "all", guardrails: guardrails, changed: 20, deleted: 20, bytes: 5000, wantViolations: 3},
+		{name: "unlimited", changed: 1000, deleted: 1000, bytes: 1 << 40},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.guardrails.check("main", tt.changed, tt.deleted, tt.bytes)
+			if tt.wantViolations == 0 {
+				if err != nil {
+					t.Fatalf("check() unexpected error: %s", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrMergeGuardrailsExceeded) {
+				t.Fatalf("check() error = %v, expected %s", err, ErrMergeGuardrailsExceeded)
+			}
+			var guardrailsErr *MergeGuardrailsError
+			if !errors.As(err, &guardrailsErr) {
+				t.Fatalf("check() error = %v, expected MergeGuardrailsError", err)
+			}
+			if len(guardrailsErr.Violations) != tt.wantViolations {
+				t.Errorf("check() violations = %v, expected %d", guardrailsErr.Violations, tt.wantViolations)
+			}
+		})
+	}
+}
+
+func TestCataloger_Merge_Guardrails(t *testing.T) {
+	ctx := context.Background()
+	diffs := []*graveler.Diff{
+		{Type: graveler.DiffTypeRemoved, Key: graveler.Key("a")},
+		{Type: graveler.DiffTypeRemoved, Key: graveler.Key("b")},
+		{Type: graveler.DiffTypeAdded, Key: graveler.Key("c"), Value: MustEntryToValue(&Entry{Address: "c", Size: 100})},
+	}
+	tests := []struct {
+		name       string
+		guardrails MergeGuardrails
+		// status is reported on the source before merging, if set
+		status  string
+		wantErr error
+	}{
+		{name: "within", guardrails: MergeGuardrails{MaxChangedEntries: 3, MaxDeletedEntries: 2, MaxBytes: 100}},
+		{name: "deleted", guardrails: MergeGuardrails{MaxDeletedEntries: 1}, wantErr: ErrMergeGuardrailsExceeded},
+		{name: "bytes", guardrails: MergeGuardrails{MaxBytes: 99}, wantErr: ErrMergeGuardrailsExceeded},
+		{name: "status not reported", guardrails: MergeGuardrails{RequiredStatusChecks: []string{"ci"}}, wantErr: ErrMergeGuardrailsExceeded},
+		{name: "status failed", guardrails: MergeGuardrails{RequiredStatusChecks: []string{"ci"}}, status: CommitStatusFailed, wantErr: ErrMergeGuardrailsExceeded},
+		{name: "status passed", guardrails: MergeGuardrails{RequiredStatusChecks: []string{"ci"}}, status: CommitStatusPassed},
+		{name: "approvals", guardrails: MergeGuardrails{RequiredApprovals: 1}, wantErr: ErrMergeGuardrailsExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMergeTestStore("c1", diffs...)
+			c := testCataloger(t, store)
+			if err := c.SetMergeGuardrails(ctx, "repo", "main", tt.guardrails); err != nil {
+				t.Fatalf("SetMergeGuardrails() error = %s", err)
+			}
+			if tt.status != "" {
+				if err := c.SetCommitStatus(ctx, "repo", "feature", CommitStatus{Context: "ci", State: tt.status, Creator: "ci"}); err != nil {
+					t.Fatalf("SetCommitStatus() error = %s", err)
+				}
+			}
+			_, err := c.Merge(ctx, "repo", "main", "feature", MergeParams{Committer: "tester"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Merge() error = %v, expected %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if len(store.MergedSources) != 0 {
+					t.Errorf("Merge() exceeding guardrails merged %v", store.MergedSources)
+				}
+				// overriding guardrails merges anyway
+				_, err = c.Merge(ctx, "repo", "main", "feature", MergeParams{Committer: "tester", OverrideGuardrails: true})
+				if err != nil {
+					t.Fatalf("Merge() overriding guardrails error = %s", err)
+				}
+			}
+			if diff := deep.Equal(store.MergedSources, []graveler.Ref{"c1"}); diff != nil {
+				t.Error("merged sources diff found", diff)
+			}
+		})
+	}
+
+	// a status reported on an older commit of the source does not pass its new commit
+	store := newMergeTestStore("c1")
+	c := testCataloger(t, store)
+	if err := c.SetMergeGuardrails(ctx, "repo", "main", MergeGuardrails{RequiredStatusChecks: []string{"ci"}}); err != nil {
+		t.Fatalf("SetMergeGuardrails() error = %s", err)
+	}
+	if err := c.SetCommitStatus(ctx, "repo", "feature", CommitStatus{Context: "ci", State: CommitStatusPassed, Creator: "ci"}); err != nil {
+		t.Fatalf("SetCommitStatus() error = %s", err)
+	}
+	advance(store, "c2")
+	if _, err := c.Merge(ctx, "repo", "main", "feature", MergeParams{Committer: "tester"}); !errors.Is(err, ErrMergeGuardrailsExceeded) {
+		t.Errorf("Merge() of unchecked commit error = %v, expected %s", err, ErrMergeGuardrailsExceeded)
+	}
+}
+
+func TestCataloger_ReplacePrefix_Guardrails(t *testing.T) {
+	ctx := context.Background()
+	diffs := []*graveler.Diff{
+		{Type: graveler.DiffTypeRemoved, Key: graveler.Key("data/a")},
+		{Type: graveler.DiffTypeRemoved, Key: graveler.Key("data/b")},
+	}
+	store := newMergeTestStore("c1", diffs...)
+	c := testCataloger(t, store)
+	if err := c.SetMergeGuardrails(ctx, "repo", "main", MergeGuardrails{MaxDeletedEntries: 1}); err != nil {
+		t.Fatalf("SetMergeGuardrails() error = %s", err)
+	}
+
+	// replacing the entire branch is a merge
+	_, err := c.ReplacePrefix(ctx, "repo", "main", ReplacePrefixParams{Prefix: "", SourceRef: "feature", Committer: "tester"})
+	if !errors.Is(err, ErrInvalidValue) {
+		t.Fatalf("ReplacePrefix() of an empty prefix error = %v, expected %s", err, ErrInvalidValue)
+	}
+	_, err = c.ReplacePrefix(ctx, "repo", "main", ReplacePrefixParams{Prefix: "data/", SourceRef: "feature", Committer: "tester"})
+	if !errors.Is(err, ErrMergeGuardrailsExceeded) {
+		t.Fatalf("ReplacePrefix() exceeding guardrails error = %v, expected %s", err, ErrMergeGuardrailsExceeded)
+	}
+	if len(store.MergedSources) != 0 {
+		t.Errorf("ReplacePrefix() exceeding guardrails replaced from %v", store.MergedSources)
+	}
+
+	if err := c.SetMergeGuardrails(ctx, "repo", "main", MergeGuardrails{MaxDeletedEntries: 2}); err != nil {
+		t.Fatalf("SetMergeGuardrails() error = %s", err)
+	}
+	_, err = c.ReplacePrefix(ctx, "repo", "main", ReplacePrefixParams{Prefix: "data/", SourceRef: "feature", Committer: "tester"})
+	if err != nil {
+		t.Fatalf("ReplacePrefix() within guardrails error = %s", err)
+	}
+	// the checked commit is the one replaced from
+	if diff := deep.Equal(store.MergedSources, []graveler.Ref{"c1"}); diff != nil {
+		t.Error("replaced sources diff found", diff)
+	}
+}
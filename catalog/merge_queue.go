@@ -0,0 +1,321 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/logging"
+)
+
+const (
+	MergeRequestQueued  = "queued"
+	MergeRequestRunning = "running"
+	MergeRequestMerged  = "merged"
+	MergeRequestFailed  = "failed"
+
+	// DefaultMergeQueueMaxAttempts is the number of attempts of merge requests enqueued
+	// without a limit
+	DefaultMergeQueueMaxAttempts = 5
+	MergeQueueMaxAttemptsLimit   = 100
+
+	// mergeQueueStaleTimeout is the time after which a running merge request is assumed
+	// abandoned by a stopped server and is attempted again
+	mergeQueueStaleTimeout = 10 * time.Minute
+	mergeRequestCanceled   = "canceled"
+)
+
+// MergeQueueParams are the parameters of a merge request
+type MergeQueueParams struct {
+	MergeParams
+	// MaxAttempts limits the attempts to merge the request while the destination branch is
+	// busy, DefaultMergeQueueMaxAttempts when 0
+	MaxAttempts int
+}
+
+// MergeRequest is a merge waiting in the merge queue of its destination branch.  The requests
+// of a branch are merged one at a time in the order they were enqueued.  Each attempt merges the
+// source as it is at the time of the attempt into the current head of the destination.
+type MergeRequest struct {
+	ID                 int64     `db:"id"`
+	Repository         string    `db:"repository_id"`
+	DestinationBranch  string    `db:"destination_branch"`
+	SourceRef          string    `db:"source_ref"`
+	Committer          string    `db:"committer"`
+	Message            string    `db:"message"`
+	Metadata           Metadata  `db:"metadata"`
+	OverrideGuardrails bool      `db:"override_guardrails"`
+	MaxAttempts        int       `db:"max_attempts"`
+	State              string    `db:"state"`
+	Attempts           int       `db:"attempts"`
+	Error              string    `db:"error"`
+	Reference          string    `db:"reference"`
+	CreationDate       time.Time `db:"creation_date"`
+	UpdateDate         time.Time `db:"update_date"`
+}
+
+// mergeQueueBranch is a destination branch with queued merge requests
+type mergeQueueBranch struct {
+	Repository string `db:"repository_id"`
+	Branch     string `db:"destination_branch"`
+}
+
+const mergeRequestFields = `id, repository_id, destination_branch, source_ref, committer, message, metadata,
+	override_guardrails, max_attempts, state, attempts, error, reference, creation_date, update_date`
+
+// isRetryableMergeError returns true if a merge failed only because the destination branch was
+// busy, so a later attempt may succeed
+func isRetryableMergeError(err error) bool {
+	return errors.Is(err, graveler.ErrLockNotAcquired) || errors.Is(err, graveler.ErrDirtyBranch)
+}
+
+// attempted records the result of an attempt to merge the request
+func (r *MergeRequest) attempted(result *MergeResult, err error) {
+	r.Attempts++
+	switch {
+	case err == nil:
+		r.State = MergeRequestMerged
+		r.Error = ""
+		r.Reference = result.Reference
+	case isRetryableMergeError(err) && r.Attempts < r.MaxAttempts:
+		r.State = MergeRequestQueued
+		r.Error = err.Error()
+	default:
+		r.State = MergeRequestFailed
+		r.Error = err.Error()
+	}
+}
+
+// EnqueueMerge adds a request to merge sourceRef into destinationBranch to the merge queue of
+// the branch
+func (c *cataloger) EnqueueMerge(ctx context.Context, repository, destinationBranch, sourceRef string, params MergeQueueParams) (*MergeRequest, error) {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+		{"destination_branch", graveler.BranchID(destinationBranch), ValidateBranchID},
+		{"source_ref", graveler.Ref(sourceRef), ValidateRef},
+		{"max_attempts", params.MaxAttempts, ValidateNonNegativeInt},
+	}); err != nil {
+		return nil, err
+	}
+	if params.MaxAttempts > MergeQueueMaxAttemptsLimit {
+		return nil, fmt.Errorf("max_attempts above maximum (%d): %w", MergeQueueMaxAttemptsLimit, ErrInvalidValue)
+	}
+	maxAttempts := params.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = DefaultMergeQueueMaxAttempts
+	}
+	if _, err := c.EntryCatalog.GetBranch(ctx, graveler.RepositoryID(repository), graveler.BranchID(destinationBranch)); err != nil {
+		return nil, err
+	}
+	if _, err := c.EntryCatalog.Dereference(ctx, graveler.RepositoryID(repository), graveler.Ref(sourceRef)); err != nil {
+		return nil, err
+	}
+	metadata := params.Metadata
+	if metadata == nil {
+		metadata = Metadata{}
+	}
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var request MergeRequest
+		now := time.Now().UTC()
+		err := tx.Get(&request, `INSERT INTO catalog_merge_queue (repository_id, destination_branch, source_ref, committer, message, metadata,
+				override_guardrails, max_attempts, state, attempts, error, reference, creation_date, update_date)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, 0, '', '', $10, $10)
+			RETURNING `+mergeRequestFields,
+			repository, destinationBranch, sourceRef, params.Committer, params.Message, metadata,
+			params.OverrideGuardrails, maxAttempts, MergeRequestQueued, now)
+		return &request, err
+	}, db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.(*MergeRequest), nil
+}
+
+func (c *cataloger) GetMergeRequest(ctx context.Context, repository string, id int64) (*MergeRequest, error) {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+	}); err != nil {
+		return nil, err
+	}
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var request MergeRequest
+		err := tx.Get(&request, `SELECT `+mergeRequestFields+`
+			FROM catalog_merge_queue WHERE repository_id = $1 AND id = $2`,
+			repository, id)
+		return &request, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, ErrMergeRequestNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res.(*MergeRequest), nil
+}
+
+// ListMergeQueue returns the queued and running merge requests into branch, in merge order
+func (c *cataloger) ListMergeQueue(ctx context.Context, repository, branch string) ([]*MergeRequest, error) {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+		{"branch", graveler.BranchID(branch), ValidateBranchID},
+	}); err != nil {
+		return nil, err
+	}
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var requests []*MergeRequest
+		err := tx.Select(&requests, `SELECT `+mergeRequestFields+`
+			FROM catalog_merge_queue WHERE repository_id = $1 AND destination_branch = $2 AND state IN ($3, $4)
+			ORDER BY id`,
+			repository, branch, MergeRequestQueued, MergeRequestRunning)
+		return requests, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.([]*MergeRequest), nil
+}
+
+// CancelMergeRequest fails a queued merge request.  It fails with ErrMergeRequestNotQueued
+// if the request is already running or done.
+func (c *cataloger) CancelMergeRequest(ctx context.Context, repository string, id int64) error {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+	}); err != nil {
+		return err
+	}
+	_, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		res, err := tx.Exec(`UPDATE catalog_merge_queue SET state = $1, error = $2, update_date = $3
+			WHERE repository_id = $4 AND id = $5 AND state = $6`,
+			MergeRequestFailed, mergeRequestCanceled, time.Now().UTC(), repository, id, MergeRequestQueued)
+		if err != nil {
+			return nil, err
+		}
+		if res.RowsAffected() > 0 {
+			return nil, nil
+		}
+		var exists bool
+		err = tx.GetPrimitive(&exists, `SELECT EXISTS (SELECT 1 FROM catalog_merge_queue WHERE repository_id = $1 AND id = $2)`,
+			repository, id)
+		switch {
+		case err != nil:
+			return nil, err
+		case !exists:
+			return nil, ErrMergeRequestNotFound
+		default:
+			return nil, ErrMergeRequestNotQueued
+		}
+	}, db.WithContext(ctx))
+	return err
+}
+
+// ProcessMergeQueues merges the queued merge requests of every branch, one at a time per branch.
+// A branch stops processing its queue for this round when its first request must be retried.
+func (c *cataloger) ProcessMergeQueues(ctx context.Context) (int, error) {
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var branches []*mergeQueueBranch
+		err := tx.Select(&branches, `SELECT DISTINCT repository_id, destination_branch
+			FROM catalog_merge_queue WHERE state = $1 ORDER BY repository_id, destination_branch`,
+			MergeRequestQueued)
+		return branches, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	merged := 0
+	for _, b := range res.([]*mergeQueueBranch) {
+		n, err := c.processMergeQueue(ctx, b.Repository, b.Branch)
+		merged += n
+		if err != nil {
+			c.log.WithError(err).WithFields(logging.Fields{
+				"repository": b.Repository,
+				"branch":     b.Branch,
+			}).Error("Failed to process merge queue")
+		}
+	}
+	return merged, nil
+}
+
+func (c *cataloger) processMergeQueue(ctx context.Context, repository, branch string) (int, error) {
+	merged := 0
+	for {
+		request, err := c.claimMergeRequest(ctx, repository, branch)
+		if err != nil || request == nil {
+			return merged, err
+		}
+		result, err := c.Merge(ctx, request.Repository, request.DestinationBranch, request.SourceRef, MergeParams{
+			Committer:          request.Committer,
+			Message:            request.Message,
+			Metadata:           request.Metadata,
+			OverrideGuardrails: request.OverrideGuardrails,
+		})
+		request.attempted(result, err)
+		if err := c.updateMergeRequest(ctx, request); err != nil {
+			return merged, err
+		}
+		switch request.State {
+		case MergeRequestMerged:
+			merged++
+		case MergeRequestQueued:
+			// the branch is busy, retry on the next round
+			return merged, nil
+		}
+	}
+}
+
+// claimMergeRequest marks the first merge request in the queue of branch running and returns it,
+// or returns nil if the queue is empty or its first request is running
+func (c *cataloger) claimMergeRequest(ctx context.Context, repository, branch string) (*MergeRequest, error) {
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var request MergeRequest
+		now := time.Now().UTC()
+		err := tx.Get(&request, `UPDATE catalog_merge_queue SET state = $1, update_date = $2
+			WHERE id = (
+				SELECT id FROM catalog_merge_queue
+				WHERE repository_id = $3 AND destination_branch = $4 AND state IN ($5, $1)
+				ORDER BY id LIMIT 1)
+			AND (state = $5 OR update_date < $6)
+			RETURNING `+mergeRequestFields,
+			MergeRequestRunning, now, repository, branch, MergeRequestQueued, now.Add(-mergeQueueStaleTimeout))
+		return &request, err
+	}, db.WithContext(ctx))
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res.(*MergeRequest), nil
+}
+
+func (c *cataloger) updateMergeRequest(ctx context.Context, request *MergeRequest) error {
+	_, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		return tx.Exec(`UPDATE catalog_merge_queue SET state = $1, attempts = $2, error = $3, reference = $4, update_date = $5
+			WHERE id = $6`,
+			request.State, request.Attempts, request.Error, request.Reference, time.Now().UTC(), request.ID)
+	}, db.WithContext(ctx))
+	return err
+}
+
+// RunMergeQueue processes the merge queues of all branches each interval
+func RunMergeQueue(ctx context.Context, c Cataloger, interval time.Duration) {
+	log := logging.FromContext(ctx).WithField("service", "merge_queue")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			merged, err := c.ProcessMergeQueues(ctx)
+			if err != nil {
+				log.WithError(err).Error("Failed to process merge queues")
+			}
+			if merged > 0 {
+				log.WithField("merged", merged).Info("Merged queued merge requests")
+			}
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/treeverse/lakefs/graveler"
+)
+
+func TestMergeRequest_Attempted(t *testing.T) {
+	errBusy := fmt.Errorf("merge: %w", graveler.ErrLockNotAcquired)
+	tests := []struct {
+		name          string
+		attempts      int
+		result        *MergeResult
+		err           error
+		wantState     string
+		wantReference string
+	}{
+		{name: "merged", result: &MergeResult{Reference: "c1"}, wantState: MergeRequestMerged, wantReference: "c1"},
+		{name: "busy", err: errBusy, wantState: MergeRequestQueued},
+		{name: "dirty", err: graveler.ErrDirtyBranch, wantState: MergeRequestQueued},
+		{name: "busy last attempt", attempts: 2, err: errBusy, wantState: MergeRequestFailed},
+		{name: "conflict", err: graveler.ErrConflictFound, wantState: MergeRequestFailed},
+		{name: "other error", err: errors.New("failed"), wantState: MergeRequestFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := &MergeRequest{State: MergeRequestRunning, Attempts: tt.attempts, MaxAttempts: 3}
+			request.attempted(tt.result, tt.err)
+			if request.Attempts != tt.attempts+1 {
+				t.Errorf("attempted() attempts = %d, expected %d", request.Attempts, tt.attempts+1)
+			}
+			if request.State != tt.wantState {
+				t.Errorf("attempted() state = %s, expected %s", request.State, tt.wantState)
+			}
+			if request.Reference != tt.wantReference {
+				t.Errorf("attempted() reference = %s, expected %s", request.Reference, tt.wantReference)
+			}
+			if (tt.err == nil) != (request.Error == "") {
+				t.Errorf("attempted() error = '%s', with attempt error %v", request.Error, tt.err)
+			}
+		})
+	}
+}
@@ -29,16 +29,28 @@ type DBEntry struct {
 	Checksum        string    `db:"checksum"`
 	Metadata        Metadata  `db:"metadata"`
 	Expired         bool      `db:"is_expired"`
+	DirectoryMarker bool
+	// LinkTarget is set for entries linking to another path, within the same ref unless
+	// LinkRepository and LinkRef pin it to a commit in another repository
+	LinkTarget     string
+	LinkRepository string
+	LinkRef        string
 }
 
 type CommitLog struct {
-	Reference    string
-	Committer    string    `db:"committer"`
-	Message      string    `db:"message"`
-	CreationDate time.Time `db:"creation_date"`
-	Metadata     Metadata  `db:"metadata"`
-	MetaRangeID  string    `db:"meta_range_id"`
-	Parents      []string
+	Reference      string
+	Committer      string    `db:"committer"`
+	CommitterEmail string    `db:"committer_email"`
+	Message        string    `db:"message"`
+	CreationDate   time.Time `db:"creation_date"`
+	Metadata       Metadata  `db:"metadata"`
+	MetaRangeID    string    `db:"meta_range_id"`
+	Parents        []string
+	// Author, AuthorEmail and AuthorDate are the committer and the creation date unless the
+	// commit was made on behalf of someone else
+	Author      string    `db:"author"`
+	AuthorEmail string    `db:"author_email"`
+	AuthorDate  time.Time `db:"author_date"`
 }
 
 type MergeResult struct {
@@ -46,6 +58,32 @@ type MergeResult struct {
 	Reference string
 }
 
+// CommitPreview is the result of a dry-run commit: the MetaRange a commit would point to and
+// a summary of the staged changes it would include.
+type CommitPreview struct {
+	MetaRangeID string
+	Summary     map[DifferenceType]int
+}
+
+// MergePreview is the result of a dry-run merge: the conflicting paths and a summary of the changes
+// the merge would apply.  A merge succeeds only when there are no conflicts and the destination
+// branch has no uncommitted changes.
+type MergePreview struct {
+	Summary          map[DifferenceType]int
+	Conflicts        []string
+	DestinationDirty bool
+}
+
+// StagingStats summarizes the uncommitted changes of a branch
+type StagingStats struct {
+	// Writes is the number of uncommitted objects added or changed
+	Writes int64
+	// Deletes is the number of uncommitted object deletions
+	Deletes int64
+	// SizeBytes is the total size of the uncommitted objects added or changed
+	SizeBytes int64
+}
+
 type Branch struct {
 	Name      string `db:"name"`
 	Reference string
@@ -56,6 +94,14 @@ type Tag struct {
 	CommitID string
 }
 
+// RepositorySnapshot holds the head commit of every branch of a repository at CreationDate
+type RepositorySnapshot struct {
+	ID           string
+	CreationDate time.Time
+	// Branches maps each branch to its head commit
+	Branches map[string]string
+}
+
 func (j Metadata) Value() (driver.Value, error) {
 	if j == nil {
 		return json.Marshal(struct{}{})
@@ -0,0 +1,176 @@
+package catalog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+const (
+	// OwnersPath is the ownership file of a branch.  Each line maps a path prefix to the groups
+	// that must approve pull requests changing paths under it:
+	//
+	//   # comment
+	//   datasets/         data-eng
+	//   datasets/sales/   data-eng sales
+	//   *                 admins
+	//
+	// The longest prefix matching a path applies, "*" matches every path.
+	OwnersPath = "_lakefs/OWNERS"
+
+	// OwnersSizeLimit is the maximal size of an ownership file
+	OwnersSizeLimit = 1024 * 1024
+
+	ownersMatchAll = "*"
+)
+
+type ownersRule struct {
+	Prefix string
+	Groups []string
+}
+
+// ownersRules maps path prefixes to their owning groups
+type ownersRules []ownersRule
+
+// parseOwners parses an ownership file
+func parseOwners(data []byte) (ownersRules, error) {
+	var rules ownersRules
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		const minFields = 2
+		if len(fields) < minFields {
+			return nil, fmt.Errorf("%w: line %d: prefix without groups", ErrInvalidOwners, line)
+		}
+		prefix := fields[0]
+		if prefix == ownersMatchAll {
+			prefix = ""
+		}
+		rules = append(rules, ownersRule{Prefix: prefix, Groups: fields[1:]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidOwners, err)
+	}
+	return rules, nil
+}
+
+// match returns the rule of the longest prefix of path, or nil if no rule matches
+func (r ownersRules) match(path string) *ownersRule {
+	var matched *ownersRule
+	for i := range r {
+		rule := &r[i]
+		if strings.HasPrefix(path, rule.Prefix) && (matched == nil || len(rule.Prefix) >= len(matched.Prefix)) {
+			matched = rule
+		}
+	}
+	return matched
+}
+
+// addViolations adds the owning groups of path that no approver belongs to, to violations
+func (r ownersRules) addViolations(violations map[string]struct{}, path string, approverGroups map[string]struct{}) {
+	rule := r.match(path)
+	if rule == nil {
+		return
+	}
+	for _, group := range rule.Groups {
+		if _, ok := approverGroups[group]; ok {
+			continue
+		}
+		prefix := rule.Prefix
+		if prefix == "" {
+			prefix = ownersMatchAll
+		}
+		violations[fmt.Sprintf("changes under '%s' require approval of group %s", prefix, group)] = struct{}{}
+	}
+}
+
+// readOwners returns the ownership rules of ref, or nil if it has no ownership file
+func (c *cataloger) readOwners(ctx context.Context, repository, ref string) (ownersRules, error) {
+	repositoryID := graveler.RepositoryID(repository)
+	entry, err := c.EntryCatalog.GetEntry(ctx, repositoryID, graveler.Ref(ref), OwnersPath)
+	if errors.Is(err, graveler.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if entry.Size > OwnersSizeLimit {
+		return nil, fmt.Errorf("%w: %d bytes, limit is %d", ErrInvalidOwners, entry.Size, OwnersSizeLimit)
+	}
+	repo, err := c.EntryCatalog.GetRepository(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := c.EntryCatalog.BlockAdapter.Get(block.ObjectPointer{
+		StorageNamespace: repo.StorageNamespace.String(),
+		Identifier:       entry.Address,
+	}, entry.Size)
+	if err != nil {
+		return nil, fmt.Errorf("getting owners file: %w", err)
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("reading owners file: %w", err)
+	}
+	return parseOwners(data)
+}
+
+// checkPullRequestOwners verifies that a member of every group owning a path the pull request
+// changes approved commitID, its current source commit.  The ownership file of the destination
+// branch applies, so pull requests can not change the owners of their own changes.
+func (c *cataloger) checkPullRequestOwners(ctx context.Context, pr *PullRequest, commitID graveler.CommitID, userGroups func(username string) ([]string, error)) error {
+	rules, err := c.readOwners(ctx, pr.Repository, pr.DestinationBranch)
+	if err != nil || len(rules) == 0 {
+		return err
+	}
+	repositoryID := graveler.RepositoryID(pr.Repository)
+	approverGroups := make(map[string]struct{})
+	for _, approval := range pr.Approvals {
+		if approval.CommitID != commitID.String() || userGroups == nil {
+			continue
+		}
+		groups, err := userGroups(approval.Reviewer)
+		if err != nil {
+			return err
+		}
+		for _, group := range groups {
+			approverGroups[group] = struct{}{}
+		}
+	}
+	it, err := c.EntryCatalog.Compare(ctx, repositoryID, graveler.Ref(commitID), graveler.Ref(pr.DestinationBranch), graveler.DiffTypeMaskAll)
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	missing := make(map[string]struct{})
+	for it.Next() {
+		rules.addViolations(missing, it.Value().Path.String(), approverGroups)
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	violations := make([]string, 0, len(missing))
+	for violation := range missing {
+		violations = append(violations, violation)
+	}
+	sort.Strings(violations)
+	return &MergeGuardrailsError{Branch: pr.DestinationBranch, Violations: violations}
+}
@@ -0,0 +1,72 @@
+package catalog
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+const testOwners = `# owners
+datasets/          data-eng
+datasets/sales/    data-eng sales
+
+*                  admins
+`
+
+func TestParseOwners(t *testing.T) {
+	rules, err := parseOwners([]byte(testOwners))
+	if err != nil {
+		t.Fatalf("parseOwners() error = %v", err)
+	}
+	expected := ownersRules{
+		{Prefix: "datasets/", Groups: []string{"data-eng"}},
+		{Prefix: "datasets/sales/", Groups: []string{"data-eng", "sales"}},
+		{Prefix: "", Groups: []string{"admins"}},
+	}
+	if diff := deep.Equal(rules, expected); diff != nil {
+		t.Fatal("parseOwners() diff found", diff)
+	}
+
+	if _, err := parseOwners([]byte("datasets/ data-eng\nlogs/\n")); !errors.Is(err, ErrInvalidOwners) {
+		t.Errorf("parseOwners() of prefix without groups error = %v, expected %v", err, ErrInvalidOwners)
+	}
+}
+
+func TestOwnersRules_AddViolations(t *testing.T) {
+	rules, err := parseOwners([]byte(testOwners))
+	if err != nil {
+		t.Fatalf("parseOwners() error = %v", err)
+	}
+	tests := []struct {
+		name   string
+		paths  []string
+		groups []string
+		want   []string
+	}{
+		{name: "approved", paths: []string{"datasets/sales/2021.csv", "datasets/users.csv"}, groups: []string{"data-eng", "sales"}},
+		{name: "longest prefix", paths: []string{"datasets/sales/2021.csv"}, groups: []string{"data-eng"},
+			want: []string{"changes under 'datasets/sales/' require approval of group sales"}},
+		{name: "match all", paths: []string{"logs/1.log", "logs/2.log"},
+			want: []string{"changes under '*' require approval of group admins"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			approverGroups := make(map[string]struct{})
+			for _, group := range tt.groups {
+				approverGroups[group] = struct{}{}
+			}
+			missing := make(map[string]struct{})
+			for _, path := range tt.paths {
+				rules.addViolations(missing, path, approverGroups)
+			}
+			var violations []string
+			for violation := range missing {
+				violations = append(violations, violation)
+			}
+			if diff := deep.Equal(violations, tt.want); diff != nil {
+				t.Error("addViolations() diff found", diff)
+			}
+		})
+	}
+}
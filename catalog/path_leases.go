@@ -0,0 +1,293 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+const (
+	// DefaultPathLeaseTTL is the lifetime of a path lease that is not renewed
+	DefaultPathLeaseTTL = 10 * time.Minute
+	// PathLeaseMaxTTL is the longest lifetime of a path lease between renewals
+	PathLeaseMaxTTL = 24 * time.Hour
+)
+
+// PathLease reserves the paths under Prefix on a branch to its owner until it expires.  Writes,
+// merges, reverts and resets of other users changing these paths fail with ErrPathLeased.
+// Catalog writes are on behalf of the owner set on their context by WithPathLeaseOwner.  Leases
+// are checked before the write and not locked while it runs, so a write racing the acquisition
+// of a lease may still land under it: leases coordinate cooperating writers, they do not
+// guarantee exclusive access.
+type PathLease struct {
+	ID           string    `db:"id"`
+	Repository   string    `db:"repository_id"`
+	Branch       string    `db:"branch_id"`
+	Prefix       string    `db:"prefix"`
+	Owner        string    `db:"owner"`
+	ExpiresAt    time.Time `db:"expires_at"`
+	CreationDate time.Time `db:"creation_date"`
+}
+
+// PathLeasedError reports a write, or a lease, conflicting with the lease of another user
+type PathLeasedError struct {
+	Path  string
+	Lease PathLease
+}
+
+func (e *PathLeasedError) Error() string {
+	return fmt.Sprintf("'%s' is leased by '%s' under prefix '%s' until %s",
+		e.Path, e.Lease.Owner, e.Lease.Prefix, e.Lease.ExpiresAt.Format(time.RFC3339))
+}
+
+func (e *PathLeasedError) Unwrap() error {
+	return ErrPathLeased
+}
+
+type pathLeaseOwnerKey struct{}
+
+// WithPathLeaseOwner returns a context for catalog writes on behalf of owner: they pass the
+// leases of owner and fail with ErrPathLeased under the leases of other users.  Writes with no
+// owner on their context fail under any lease.
+func WithPathLeaseOwner(ctx context.Context, owner string) context.Context {
+	return context.WithValue(ctx, pathLeaseOwnerKey{}, owner)
+}
+
+func pathLeaseOwner(ctx context.Context) string {
+	owner, _ := ctx.Value(pathLeaseOwnerKey{}).(string)
+	return owner
+}
+
+func pathLeasesLockKey(repository, branch string) string {
+	return "path_leases:" + repository + "/" + branch
+}
+
+// lockPathLeases serializes the changes to the leases of branch until the end of tx
+func lockPathLeases(tx db.Tx, repository, branch string) error {
+	_, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext($1))`, pathLeasesLockKey(repository, branch))
+	return err
+}
+
+// pathLeaseConflict is a lease of another user on a written path
+type pathLeaseConflict struct {
+	PathLease
+	Path string `db:"path"`
+}
+
+// findPathLeaseConflict returns the error of a path of paths under the unexpired lease of a user
+// other than owner, nil if there is none.  When prefixes is set paths are written as prefixes,
+// so they also conflict with leases on the paths under them.
+func findPathLeaseConflict(tx db.Tx, repository, branch string, paths []string, prefixes bool, owner string) error {
+	var conflict pathLeaseConflict
+	err := tx.Get(&conflict, `SELECT l.id, l.repository_id, l.branch_id, l.prefix, l.owner, l.expires_at, l.creation_date, p.path
+		FROM catalog_path_leases l
+			JOIN unnest($3::text[]) AS p(path)
+				ON left(p.path, length(l.prefix)) = l.prefix OR ($6 AND left(l.prefix, length(p.path)) = p.path)
+		WHERE l.repository_id = $1 AND l.branch_id = $2 AND l.owner <> $4 AND l.expires_at > $5
+		LIMIT 1`,
+		repository, branch, paths, owner, time.Now().UTC(), prefixes)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return &PathLeasedError{Path: conflict.Path, Lease: conflict.PathLease}
+}
+
+// writeLeasedPaths runs write of paths on branch, or fails with ErrPathLeased if one of them is
+// under the lease of a user other than the owner of ctx.  Leases are checked by a single query
+// outside a transaction, so write does not hold a connection besides its own, and costs a single
+// index lookup on branches with no leases.  The check and the write are not atomic: a lease
+// acquired after the check does not stop write.
+func (c *cataloger) writeLeasedPaths(ctx context.Context, repository, branch string, paths []string, prefixes bool, write func() error) error {
+	if len(paths) > 0 {
+		if err := findPathLeaseConflict(c.db.WithContext(ctx), repository, branch, paths, prefixes, pathLeaseOwner(ctx)); err != nil {
+			return err
+		}
+	}
+	return write()
+}
+
+// checkLeasedChanges fails with ErrPathLeased if a path changed by the diff of changes is under
+// the unexpired lease of a user other than the owner of ctx.  The leases of branch are read
+// first, so changes are only diffed on branches leased by other users.  Like writeLeasedPaths,
+// a lease acquired after the check does not stop the change.
+func (c *cataloger) checkLeasedChanges(ctx context.Context, repository, branch string, changes func() (EntryDiffIterator, error)) error {
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var leases []*PathLease
+		err := tx.Select(&leases, `SELECT id, repository_id, branch_id, prefix, owner, expires_at, creation_date
+			FROM catalog_path_leases
+			WHERE repository_id = $1 AND branch_id = $2 AND owner <> $3 AND expires_at > $4`,
+			repository, branch, pathLeaseOwner(ctx), time.Now().UTC())
+		return leases, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	leases := res.([]*PathLease)
+	if len(leases) == 0 {
+		return nil
+	}
+	it, err := changes()
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	for it.Next() {
+		path := it.Value().Path.String()
+		for _, lease := range leases {
+			if strings.HasPrefix(path, lease.Prefix) {
+				return &PathLeasedError{Path: path, Lease: *lease}
+			}
+		}
+	}
+	return it.Err()
+}
+
+// AcquirePathLease leases prefix on branch to owner for ttl.  It fails with ErrPathLeased if
+// prefix overlaps the unexpired lease of another user.
+func (c *cataloger) AcquirePathLease(ctx context.Context, repository, branch, prefix, owner string, ttl time.Duration) (*PathLease, error) {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+		{"branch", graveler.BranchID(branch), ValidateBranchID},
+		{"owner", owner, ValidateRequiredString},
+		{"ttl", ttl, ValidatePathLeaseTTL},
+	}); err != nil {
+		return nil, err
+	}
+	if _, err := c.EntryCatalog.GetBranch(ctx, graveler.RepositoryID(repository), graveler.BranchID(branch)); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	lease := &PathLease{
+		ID:           uuid.New().String(),
+		Repository:   repository,
+		Branch:       branch,
+		Prefix:       prefix,
+		Owner:        owner,
+		ExpiresAt:    now.Add(ttl),
+		CreationDate: now,
+	}
+	_, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		if err := lockPathLeases(tx, repository, branch); err != nil {
+			return nil, err
+		}
+		_, err := tx.Exec(`DELETE FROM catalog_path_leases
+			WHERE repository_id = $1 AND branch_id = $2 AND expires_at <= $3`,
+			repository, branch, now)
+		if err != nil {
+			return nil, err
+		}
+		// prefixes overlap when one starts with the other
+		var conflict PathLease
+		err = tx.Get(&conflict, `SELECT id, repository_id, branch_id, prefix, owner, expires_at, creation_date
+			FROM catalog_path_leases
+			WHERE repository_id = $1 AND branch_id = $2 AND owner <> $4
+				AND (left($3, length(prefix)) = prefix OR left(prefix, length($3)) = $3)
+			LIMIT 1`,
+			repository, branch, prefix, owner)
+		if err == nil {
+			return nil, &PathLeasedError{Path: prefix, Lease: conflict}
+		}
+		if !errors.Is(err, db.ErrNotFound) {
+			return nil, err
+		}
+		return tx.Exec(`INSERT INTO catalog_path_leases (id, repository_id, branch_id, prefix, owner, expires_at, creation_date)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			lease.ID, lease.Repository, lease.Branch, lease.Prefix, lease.Owner, lease.ExpiresAt, lease.CreationDate)
+	}, db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+// RenewPathLease extends the unexpired lease leaseID of owner to ttl from now
+func (c *cataloger) RenewPathLease(ctx context.Context, repository, branch, leaseID, owner string, ttl time.Duration) (*PathLease, error) {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+		{"branch", graveler.BranchID(branch), ValidateBranchID},
+		{"ttl", ttl, ValidatePathLeaseTTL},
+	}); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var lease PathLease
+		err := tx.Get(&lease, `UPDATE catalog_path_leases SET expires_at = $5
+			WHERE repository_id = $1 AND branch_id = $2 AND id = $3 AND owner = $4 AND expires_at > $6
+			RETURNING id, repository_id, branch_id, prefix, owner, expires_at, creation_date`,
+			repository, branch, leaseID, owner, now.Add(ttl), now)
+		return &lease, err
+	}, db.WithContext(ctx))
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, ErrPathLeaseNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res.(*PathLease), nil
+}
+
+// ReleasePathLease deletes the lease leaseID of owner
+func (c *cataloger) ReleasePathLease(ctx context.Context, repository, branch, leaseID, owner string) error {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+		{"branch", graveler.BranchID(branch), ValidateBranchID},
+	}); err != nil {
+		return err
+	}
+	_, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		res, err := tx.Exec(`DELETE FROM catalog_path_leases
+			WHERE repository_id = $1 AND branch_id = $2 AND id = $3 AND owner = $4`,
+			repository, branch, leaseID, owner)
+		if err != nil {
+			return nil, err
+		}
+		if res.RowsAffected() == 0 {
+			return nil, ErrPathLeaseNotFound
+		}
+		return nil, nil
+	}, db.WithContext(ctx))
+	return err
+}
+
+// ListPathLeases returns the unexpired leases on branch ordered by prefix
+func (c *cataloger) ListPathLeases(ctx context.Context, repository, branch string) ([]*PathLease, error) {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+		{"branch", graveler.BranchID(branch), ValidateBranchID},
+	}); err != nil {
+		return nil, err
+	}
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var leases []*PathLease
+		err := tx.Select(&leases, `SELECT id, repository_id, branch_id, prefix, owner, expires_at, creation_date
+			FROM catalog_path_leases
+			WHERE repository_id = $1 AND branch_id = $2 AND expires_at > $3
+			ORDER BY prefix, id`,
+			repository, branch, time.Now().UTC())
+		return leases, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.([]*PathLease), nil
+}
+
+// CheckPathLease verifies that user may write path on branch: it fails with ErrPathLeased when
+// path is under the unexpired lease of another user.  Writes check leases themselves, this only
+// reports a conflict early.
+func (c *cataloger) CheckPathLease(ctx context.Context, repository, branch, path, user string) error {
+	_, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		return nil, findPathLeaseConflict(tx, repository, branch, []string{path}, false, user)
+	}, db.ReadOnly(), db.WithContext(ctx))
+	return err
+}
@@ -0,0 +1,232 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/block/mem"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+// testLeaseCataloger returns a cataloger with a main branch holding leased/a and free/a
+func testLeaseCataloger(t *testing.T) *cataloger {
+	const storageNamespace = "mem://leases"
+	store := newMergeTestStore("c1")
+	entry := &Entry{Address: "address", ETag: "etag", Size: 3}
+	store.KeyValue = map[string]*graveler.Value{
+		"repo/main/leased/a": MustEntryToValue(entry),
+		"repo/main/free/a":   MustEntryToValue(entry),
+	}
+	store.Repositories = map[graveler.RepositoryID]*graveler.Repository{
+		"repo": {StorageNamespace: storageNamespace, DefaultBranchID: "production"},
+	}
+	c := testCataloger(t, store)
+	adapter := mem.New()
+	err := adapter.Put(block.ObjectPointer{StorageNamespace: storageNamespace, Identifier: entry.Address}, entry.Size, strings.NewReader("abc"), block.PutOpts{})
+	if err != nil {
+		t.Fatalf("put object: %s", err)
+	}
+	c.EntryCatalog.BlockAdapter = adapter
+	return c
+}
+
+func TestCataloger_PathLeases_Writes(t *testing.T) {
+	ctx := context.Background()
+	writes := []struct {
+		name  string
+		write func(c *cataloger, ctx context.Context, dir string) error
+	}{
+		{"create entry", func(c *cataloger, ctx context.Context, dir string) error {
+			return c.CreateEntry(ctx, "repo", "main", DBEntry{Path: dir + "new", PhysicalAddress: "new"})
+		}},
+		{"create entry if", func(c *cataloger, ctx context.Context, dir string) error {
+			return c.CreateEntryIf(ctx, "repo", "main", DBEntry{Path: dir + "new", PhysicalAddress: "new"}, EntryCondition{})
+		}},
+		{"create entries", func(c *cataloger, ctx context.Context, dir string) error {
+			return c.CreateEntries(ctx, "repo", "main", []DBEntry{
+				{Path: "other/new", PhysicalAddress: "other"},
+				{Path: dir + "new", PhysicalAddress: "new"},
+			})
+		}},
+		{"delete entry", func(c *cataloger, ctx context.Context, dir string) error {
+			return c.DeleteEntry(ctx, "repo", "main", dir+"a")
+		}},
+		{"reset entry", func(c *cataloger, ctx context.Context, dir string) error {
+			return c.ResetEntry(ctx, "repo", "main", dir+"a")
+		}},
+		{"reset entries", func(c *cataloger, ctx context.Context, dir string) error {
+			return c.ResetEntries(ctx, "repo", "main", dir)
+		}},
+		{"restore entry", func(c *cataloger, ctx context.Context, dir string) error {
+			_, err := c.RestoreEntry(ctx, "repo", "main", dir+"a", "main")
+			return err
+		}},
+		{"link by digest", func(c *cataloger, ctx context.Context, dir string) error {
+			_, err := c.LinkEntryByDigest(ctx, "repo", "main", dir+"linked", LinkByDigestParams{
+				SourcePath: dir + "a",
+				Checksum:   "etag",
+				Size:       3,
+			})
+			return err
+		}},
+		{"drop prefix", func(c *cataloger, ctx context.Context, dir string) error {
+			_, err := c.ReplacePrefix(ctx, "repo", "main", ReplacePrefixParams{Prefix: dir, Committer: "committer"})
+			return err
+		}},
+		{"generate entries", func(c *cataloger, ctx context.Context, dir string) error {
+			_, err := c.GenerateEntries(ctx, "repo", "main", GenerateEntriesParams{
+				Prefix:    dir + "generated/",
+				Count:     2,
+				FanOut:    1,
+				Committer: "committer",
+			})
+			return err
+		}},
+	}
+	for _, tt := range writes {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testLeaseCataloger(t)
+			if _, err := c.AcquirePathLease(ctx, "repo", "main", "leased/", "alice", time.Minute); err != nil {
+				t.Fatalf("AcquirePathLease() error = %s", err)
+			}
+			bob := WithPathLeaseOwner(ctx, "bob")
+			if err := tt.write(c, bob, "leased/"); !errors.Is(err, ErrPathLeased) {
+				t.Errorf("write of another user under the lease error = %v, expected %s", err, ErrPathLeased)
+			}
+			if err := tt.write(c, ctx, "leased/"); !errors.Is(err, ErrPathLeased) {
+				t.Errorf("write with no owner under the lease error = %v, expected %s", err, ErrPathLeased)
+			}
+			if err := tt.write(c, bob, "free/"); err != nil {
+				t.Errorf("write of another user outside the lease error = %s", err)
+			}
+			if err := tt.write(c, WithPathLeaseOwner(ctx, "alice"), "leased/"); err != nil {
+				t.Errorf("write of the lease owner error = %s", err)
+			}
+		})
+	}
+}
+
+func TestCataloger_PathLeases_BranchChanges(t *testing.T) {
+	ctx := context.Background()
+	changes := []struct {
+		name   string
+		change func(c *cataloger, ctx context.Context) error
+	}{
+		{"merge", func(c *cataloger, ctx context.Context) error {
+			_, err := c.Merge(ctx, "repo", "main", "feature", MergeParams{Committer: "committer"})
+			return err
+		}},
+		{"revert", func(c *cataloger, ctx context.Context) error {
+			return c.Revert(ctx, "repo", "main", RevertParams{Reference: "c2", Committer: "committer"})
+		}},
+		{"reset branch", func(c *cataloger, ctx context.Context) error {
+			return c.ResetBranch(ctx, "repo", "main")
+		}},
+	}
+	for _, tt := range changes {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testLeaseCataloger(t)
+			store := c.EntryCatalog.Store.(*FakeGraveler)
+			store.CommitLog = []*graveler.CommitRecord{{CommitID: "c2", Commit: &graveler.Commit{Parents: graveler.CommitParents{"c1"}}}}
+			if _, err := c.AcquirePathLease(ctx, "repo", "main", "leased/", "alice", time.Minute); err != nil {
+				t.Fatalf("AcquirePathLease() error = %s", err)
+			}
+			bob := WithPathLeaseOwner(ctx, "bob")
+			setChanges := func(path string) {
+				store.DiffIteratorFactory = NewFakeDiffIteratorFactory([]*graveler.Diff{
+					{Type: graveler.DiffTypeChanged, Key: graveler.Key(path), Value: MustEntryToValue(&Entry{Address: "changed"})},
+				})
+			}
+			setChanges("leased/a")
+			if err := tt.change(c, bob); !errors.Is(err, ErrPathLeased) {
+				t.Errorf("change of another user under the lease error = %v, expected %s", err, ErrPathLeased)
+			}
+			if err := tt.change(c, WithPathLeaseOwner(ctx, "alice")); err != nil {
+				t.Errorf("change of the lease owner error = %s", err)
+			}
+			setChanges("free/a")
+			if err := tt.change(c, bob); err != nil {
+				t.Errorf("change of another user outside the lease error = %s", err)
+			}
+		})
+	}
+}
+
+func TestCataloger_PathLeases_PrefixWritesOverlap(t *testing.T) {
+	ctx := context.Background()
+	c := testLeaseCataloger(t)
+	if _, err := c.AcquirePathLease(ctx, "repo", "main", "leased/deep/", "alice", time.Minute); err != nil {
+		t.Fatalf("AcquirePathLease() error = %s", err)
+	}
+	// resetting a parent prefix would reset the leased paths too
+	err := c.ResetEntries(WithPathLeaseOwner(ctx, "bob"), "repo", "main", "leased/")
+	if !errors.Is(err, ErrPathLeased) {
+		t.Errorf("ResetEntries() of a prefix over the lease error = %v, expected %s", err, ErrPathLeased)
+	}
+	// a single path under the parent is outside the lease
+	err = c.DeleteEntry(WithPathLeaseOwner(ctx, "bob"), "repo", "main", "leased/a")
+	if err != nil {
+		t.Errorf("DeleteEntry() outside the leased prefix error = %s", err)
+	}
+}
+
+func TestCataloger_PathLeases_ExpiryAndTakeover(t *testing.T) {
+	ctx := context.Background()
+	alice := WithPathLeaseOwner(ctx, "alice")
+	bob := WithPathLeaseOwner(ctx, "bob")
+	c := testLeaseCataloger(t)
+	lease, err := c.AcquirePathLease(ctx, "repo", "main", "leased/", "alice", time.Minute)
+	if err != nil {
+		t.Fatalf("AcquirePathLease() error = %s", err)
+	}
+	if _, err := c.AcquirePathLease(ctx, "repo", "main", "leased/", "bob", time.Minute); !errors.Is(err, ErrPathLeased) {
+		t.Fatalf("AcquirePathLease() over an unexpired lease error = %v, expected %s", err, ErrPathLeased)
+	}
+
+	_, err = c.db.Exec(`UPDATE catalog_path_leases SET expires_at = $2 WHERE id = $1`, lease.ID, time.Now().Add(-time.Second))
+	if err != nil {
+		t.Fatalf("expire lease: %s", err)
+	}
+	if err := c.CreateEntry(bob, "repo", "main", DBEntry{Path: "leased/b", PhysicalAddress: "b"}); err != nil {
+		t.Errorf("CreateEntry() under an expired lease error = %s", err)
+	}
+	if _, err := c.RenewPathLease(ctx, "repo", "main", lease.ID, "alice", time.Minute); !errors.Is(err, ErrPathLeaseNotFound) {
+		t.Errorf("RenewPathLease() of an expired lease error = %v, expected %s", err, ErrPathLeaseNotFound)
+	}
+
+	if _, err := c.AcquirePathLease(ctx, "repo", "main", "leased/", "bob", time.Minute); err != nil {
+		t.Fatalf("AcquirePathLease() over an expired lease error = %s", err)
+	}
+	if err := c.CreateEntry(alice, "repo", "main", DBEntry{Path: "leased/c", PhysicalAddress: "c"}); !errors.Is(err, ErrPathLeased) {
+		t.Errorf("CreateEntry() of the previous owner error = %v, expected %s", err, ErrPathLeased)
+	}
+	if err := c.CreateEntry(bob, "repo", "main", DBEntry{Path: "leased/c", PhysicalAddress: "c"}); err != nil {
+		t.Errorf("CreateEntry() of the new owner error = %s", err)
+	}
+}
+
+func TestCataloger_PathLeases_AcquireDuringWrite(t *testing.T) {
+	ctx := context.Background()
+	c := testLeaseCataloger(t)
+	bob := WithPathLeaseOwner(ctx, "bob")
+	err := c.writeLeasedPaths(bob, "repo", "main", []string{"leased/a"}, false, func() error {
+		// writes are not serialized with leases, the lease applies from the next write
+		_, err := c.AcquirePathLease(ctx, "repo", "main", "leased/", "alice", time.Minute)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("write acquiring a lease error = %s", err)
+	}
+	written := false
+	err = c.writeLeasedPaths(bob, "repo", "main", []string{"leased/a"}, false, func() error {
+		written = true
+		return nil
+	})
+	if !errors.Is(err, ErrPathLeased) || written {
+		t.Errorf("write after the lease error = %v written = %t, expected %s", err, written, ErrPathLeased)
+	}
+}
@@ -0,0 +1,72 @@
+package catalog
+
+import (
+	"sort"
+	"strings"
+)
+
+// prefixesDiffIterator passes only differences with a path under one of the prefixes
+type prefixesDiffIterator struct {
+	it       EntryDiffIterator
+	prefixes []Path
+	value    *EntryDiff
+}
+
+// NewPrefixesDiffIterator returns an iterator over the differences of 'it' under any of 'prefixes'.
+// An empty prefix matches every path.
+func NewPrefixesDiffIterator(it EntryDiffIterator, prefixes []Path) EntryDiffIterator {
+	return &prefixesDiffIterator{
+		it:       it,
+		prefixes: normalizePrefixes(prefixes),
+	}
+}
+
+// normalizePrefixes returns the sorted prefixes, dropping prefixes covered by another prefix
+func normalizePrefixes(prefixes []Path) []Path {
+	sorted := make([]Path, len(prefixes))
+	copy(sorted, prefixes)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	result := make([]Path, 0, len(sorted))
+	for _, p := range sorted {
+		if len(result) > 0 && strings.HasPrefix(p.String(), result[len(result)-1].String()) {
+			continue
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+func (p *prefixesDiffIterator) Next() bool {
+	for p.it.Next() {
+		v := p.it.Value()
+		// prefixes do not overlap, the only candidate is the last prefix not greater than path
+		i := sort.Search(len(p.prefixes), func(i int) bool { return p.prefixes[i] > v.Path })
+		if i > 0 && strings.HasPrefix(v.Path.String(), p.prefixes[i-1].String()) {
+			p.value = v
+			return true
+		}
+		if i == len(p.prefixes) {
+			break
+		}
+		p.it.SeekGE(p.prefixes[i])
+	}
+	p.value = nil
+	return false
+}
+
+func (p *prefixesDiffIterator) SeekGE(id Path) {
+	p.value = nil
+	p.it.SeekGE(id)
+}
+
+func (p *prefixesDiffIterator) Value() *EntryDiff {
+	return p.value
+}
+
+func (p *prefixesDiffIterator) Err() error {
+	return p.it.Err()
+}
+
+func (p *prefixesDiffIterator) Close() {
+	p.it.Close()
+}
@@ -0,0 +1,50 @@
+package catalog_test
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/graveler/testutil"
+)
+
+func TestPrefixesDiffIterator(t *testing.T) {
+	keys := []string{"a/1", "a/2", "b/1", "c/1", "c/d/1", "e/1"}
+	tests := []struct {
+		name     string
+		prefixes []catalog.Path
+		expected []catalog.Path
+	}{
+		{name: "no prefixes"},
+		{name: "empty prefix", prefixes: []catalog.Path{""}, expected: []catalog.Path{"a/1", "a/2", "b/1", "c/1", "c/d/1", "e/1"}},
+		{name: "one prefix", prefixes: []catalog.Path{"c/"}, expected: []catalog.Path{"c/1", "c/d/1"}},
+		{name: "several prefixes", prefixes: []catalog.Path{"e/", "a/"}, expected: []catalog.Path{"a/1", "a/2", "e/1"}},
+		{name: "overlapping prefixes", prefixes: []catalog.Path{"c/d/", "c/"}, expected: []catalog.Path{"c/1", "c/d/1"}},
+		{name: "missing prefix", prefixes: []catalog.Path{"d/"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs := make([]graveler.Diff, len(keys))
+			for i, k := range keys {
+				diffs[i] = graveler.Diff{
+					Type:  graveler.DiffTypeAdded,
+					Key:   graveler.Key(k),
+					Value: catalog.MustEntryToValue(&catalog.Entry{Address: k}),
+				}
+			}
+			it := catalog.NewPrefixesDiffIterator(catalog.NewEntryDiffIterator(testutil.NewDiffIter(diffs)), tt.prefixes)
+			defer it.Close()
+			var paths []catalog.Path
+			for it.Next() {
+				paths = append(paths, it.Value().Path)
+			}
+			if err := it.Err(); err != nil {
+				t.Fatalf("iterate prefixes diff: %s", err)
+			}
+			if diff := deep.Equal(paths, tt.expected); diff != nil {
+				t.Fatal("NewPrefixesDiffIterator() diff found", diff)
+			}
+		})
+	}
+}
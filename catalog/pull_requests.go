@@ -0,0 +1,378 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+const (
+	PullRequestOpen   = "open"
+	PullRequestMerged = "merged"
+	PullRequestClosed = "closed"
+)
+
+// PullRequest proposes merging a source reference into a destination branch, for review before
+// the merge.  Merge guardrails of the destination branch requiring approvals fail merges of
+// sources that no open pull request approved.
+type PullRequest struct {
+	ID                int64    `db:"id"`
+	Repository        string   `db:"repository_id"`
+	SourceRef         string   `db:"source_ref"`
+	DestinationBranch string   `db:"destination_branch"`
+	Title             string   `db:"title"`
+	Description       string   `db:"description"`
+	Author            string   `db:"author"`
+	Reviewers         []string `db:"reviewers"`
+	Status            string   `db:"status"`
+	// MergeReference is the merge commit of a merged pull request
+	MergeReference string                 `db:"merge_reference"`
+	CreationDate   time.Time              `db:"creation_date"`
+	UpdateDate     time.Time              `db:"update_date"`
+	Approvals      []*PullRequestApproval `db:"-"`
+}
+
+// PullRequestApproval approves the commit the source of a pull request pointed to when it was
+// approved.  Approvals of older commits do not count once the source moves on.
+type PullRequestApproval struct {
+	Reviewer     string    `db:"reviewer"`
+	CommitID     string    `db:"commit_id"`
+	CreationDate time.Time `db:"creation_date"`
+}
+
+// PullRequestComment comments on a pull request, or on a path in its diff
+type PullRequestComment struct {
+	ID     int64  `db:"id"`
+	Author string `db:"author"`
+	Body   string `db:"body"`
+	// Path is a path or a prefix, such as a table or a partition, changed by the pull request.
+	// Empty for comments on the pull request itself.
+	Path         string    `db:"path"`
+	CreationDate time.Time `db:"creation_date"`
+}
+
+// PullRequestMergeParams are the parameters of merging a pull request
+type PullRequestMergeParams struct {
+	MergeParams
+	// UserGroups returns the groups of a user, checked against the owners of the paths the pull
+	// request changes.  Approvals do not count for any owners when nil.
+	UserGroups func(username string) ([]string, error)
+}
+
+const pullRequestFields = `id, repository_id, source_ref, destination_branch, title, description, author, reviewers,
+	status, merge_reference, creation_date, update_date`
+
+// OpenPullRequest opens a pull request proposing to merge pr.SourceRef into pr.DestinationBranch
+func (c *cataloger) OpenPullRequest(ctx context.Context, repository string, pr PullRequest) (*PullRequest, error) {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+		{"destination_branch", graveler.BranchID(pr.DestinationBranch), ValidateBranchID},
+		{"source_ref", graveler.Ref(pr.SourceRef), ValidateRef},
+		{"title", pr.Title, ValidateRequiredString},
+	}); err != nil {
+		return nil, err
+	}
+	for _, reviewer := range pr.Reviewers {
+		if reviewer == "" || reviewer == pr.Author {
+			return nil, fmt.Errorf("reviewers: %w", ErrInvalidValue)
+		}
+	}
+	if _, err := c.EntryCatalog.GetBranch(ctx, graveler.RepositoryID(repository), graveler.BranchID(pr.DestinationBranch)); err != nil {
+		return nil, err
+	}
+	if _, err := c.EntryCatalog.Dereference(ctx, graveler.RepositoryID(repository), graveler.Ref(pr.SourceRef)); err != nil {
+		return nil, err
+	}
+	reviewers := pr.Reviewers
+	if reviewers == nil {
+		reviewers = []string{}
+	}
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var opened PullRequest
+		err := tx.Get(&opened, `INSERT INTO catalog_pull_requests (repository_id, source_ref, destination_branch, title, description,
+				author, reviewers, status, merge_reference, creation_date, update_date)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, '', $9, $9)
+			RETURNING `+pullRequestFields,
+			repository, pr.SourceRef, pr.DestinationBranch, pr.Title, pr.Description,
+			pr.Author, reviewers, PullRequestOpen, time.Now().UTC())
+		return &opened, err
+	}, db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.(*PullRequest), nil
+}
+
+// GetPullRequest returns the pull request with its approvals
+func (c *cataloger) GetPullRequest(ctx context.Context, repository string, id int64) (*PullRequest, error) {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+	}); err != nil {
+		return nil, err
+	}
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var pr PullRequest
+		err := tx.Get(&pr, `SELECT `+pullRequestFields+`
+			FROM catalog_pull_requests WHERE repository_id = $1 AND id = $2`,
+			repository, id)
+		if err != nil {
+			return nil, err
+		}
+		err = tx.Select(&pr.Approvals, `SELECT reviewer, commit_id, creation_date
+			FROM catalog_pull_request_approvals WHERE pull_request_id = $1 ORDER BY reviewer`,
+			id)
+		return &pr, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, ErrPullRequestNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res.(*PullRequest), nil
+}
+
+// ListPullRequests returns the pull requests of repository, newest first.  An empty status
+// lists pull requests of every status.
+func (c *cataloger) ListPullRequests(ctx context.Context, repository, status string) ([]*PullRequest, error) {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+	}); err != nil {
+		return nil, err
+	}
+	switch status {
+	case "", PullRequestOpen, PullRequestMerged, PullRequestClosed:
+	default:
+		return nil, fmt.Errorf("status %s: %w", status, ErrInvalidValue)
+	}
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var prs []*PullRequest
+		err := tx.Select(&prs, `SELECT `+pullRequestFields+`
+			FROM catalog_pull_requests WHERE repository_id = $1 AND ($2 = '' OR status = $2)
+			ORDER BY id DESC`,
+			repository, status)
+		return prs, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.([]*PullRequest), nil
+}
+
+// ClosePullRequest closes an open pull request without merging it
+func (c *cataloger) ClosePullRequest(ctx context.Context, repository string, id int64) error {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+	}); err != nil {
+		return err
+	}
+	_, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		return nil, setPullRequestStatus(tx, repository, id, PullRequestClosed, "")
+	}, db.WithContext(ctx))
+	return err
+}
+
+// setPullRequestStatus moves an open pull request to status
+func setPullRequestStatus(tx db.Tx, repository string, id int64, status, mergeReference string) error {
+	res, err := tx.Exec(`UPDATE catalog_pull_requests SET status = $1, merge_reference = $2, update_date = $3
+		WHERE repository_id = $4 AND id = $5 AND status = $6`,
+		status, mergeReference, time.Now().UTC(), repository, id, PullRequestOpen)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() > 0 {
+		return nil
+	}
+	var exists bool
+	err = tx.GetPrimitive(&exists, `SELECT EXISTS (SELECT 1 FROM catalog_pull_requests WHERE repository_id = $1 AND id = $2)`,
+		repository, id)
+	switch {
+	case err != nil:
+		return err
+	case !exists:
+		return ErrPullRequestNotFound
+	default:
+		return ErrPullRequestNotOpen
+	}
+}
+
+// CommentPullRequest adds comment to the pull request.  Comments on a path are only added to
+// open pull requests changing the path.
+func (c *cataloger) CommentPullRequest(ctx context.Context, repository string, id int64, comment PullRequestComment) (*PullRequestComment, error) {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+		{"body", comment.Body, ValidateRequiredString},
+	}); err != nil {
+		return nil, err
+	}
+	pr, err := c.GetPullRequest(ctx, repository, id)
+	if err != nil {
+		return nil, err
+	}
+	if comment.Path != "" {
+		if pr.Status != PullRequestOpen {
+			return nil, ErrPullRequestNotOpen
+		}
+		changed, err := c.pullRequestChanges(ctx, pr, comment.Path)
+		if err != nil {
+			return nil, err
+		}
+		if !changed {
+			return nil, fmt.Errorf("path %s not changed by pull request: %w", comment.Path, ErrInvalidValue)
+		}
+	}
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var added PullRequestComment
+		err := tx.Get(&added, `INSERT INTO catalog_pull_request_comments (pull_request_id, author, body, path, creation_date)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id, author, body, path, creation_date`,
+			id, comment.Author, comment.Body, comment.Path, time.Now().UTC())
+		return &added, err
+	}, db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.(*PullRequestComment), nil
+}
+
+// ListPullRequestComments returns the comments on the pull request, oldest first.  A non-empty
+// path lists only the comments on path.
+func (c *cataloger) ListPullRequestComments(ctx context.Context, repository string, id int64, path string) ([]*PullRequestComment, error) {
+	if _, err := c.GetPullRequest(ctx, repository, id); err != nil {
+		return nil, err
+	}
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var comments []*PullRequestComment
+		err := tx.Select(&comments, `SELECT id, author, body, path, creation_date
+			FROM catalog_pull_request_comments WHERE pull_request_id = $1 AND ($2 = '' OR path = $2) ORDER BY id`,
+			id, path)
+		return comments, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.([]*PullRequestComment), nil
+}
+
+// ApprovePullRequest approves the current commit of the source of an open pull request.  Only
+// the reviewers of the pull request may approve it, authors may not approve their own pull
+// requests.
+func (c *cataloger) ApprovePullRequest(ctx context.Context, repository string, id int64, reviewer string) error {
+	pr, err := c.GetPullRequest(ctx, repository, id)
+	if err != nil {
+		return err
+	}
+	if pr.Status != PullRequestOpen {
+		return ErrPullRequestNotOpen
+	}
+	if err := pr.checkReviewer(reviewer); err != nil {
+		return err
+	}
+	commitID, err := c.EntryCatalog.Dereference(ctx, graveler.RepositoryID(repository), graveler.Ref(pr.SourceRef))
+	if err != nil {
+		return err
+	}
+	_, err = c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		return tx.Exec(`INSERT INTO catalog_pull_request_approvals (pull_request_id, reviewer, commit_id, creation_date)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (pull_request_id, reviewer) DO UPDATE SET commit_id = EXCLUDED.commit_id, creation_date = EXCLUDED.creation_date`,
+			id, reviewer, commitID.String(), time.Now().UTC())
+	}, db.WithContext(ctx))
+	return err
+}
+
+// MergePullRequest merges the source of an open pull request into its destination branch and
+// marks it merged.  Unless overriding guardrails, members of the owners of the changed paths must
+// have approved the pull request.
+func (c *cataloger) MergePullRequest(ctx context.Context, repository string, id int64, params PullRequestMergeParams) (*MergeResult, error) {
+	pr, err := c.GetPullRequest(ctx, repository, id)
+	if err != nil {
+		return nil, err
+	}
+	if pr.Status != PullRequestOpen {
+		return nil, ErrPullRequestNotOpen
+	}
+	if params.Message == "" {
+		params.Message = fmt.Sprintf("Merge pull request #%d: %s", pr.ID, pr.Title)
+	}
+	sourceCommitID, err := c.EntryCatalog.Dereference(ctx, graveler.RepositoryID(repository), graveler.Ref(pr.SourceRef))
+	if err != nil {
+		return nil, err
+	}
+	if !params.OverrideGuardrails {
+		if err := c.checkPullRequestOwners(ctx, pr, sourceCommitID, params.UserGroups); err != nil {
+			return nil, err
+		}
+	}
+	result, err := c.mergeCommit(ctx, repository, pr.DestinationBranch, pr.SourceRef, sourceCommitID, params.MergeParams)
+	if err != nil {
+		return result, err
+	}
+	_, err = c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		return nil, setPullRequestStatus(tx, repository, id, PullRequestMerged, result.Reference)
+	}, db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// pullRequestChanges returns true if the pull request changes path or a path under it
+func (c *cataloger) pullRequestChanges(ctx context.Context, pr *PullRequest, path string) (bool, error) {
+	it, err := c.EntryCatalog.Compare(ctx, graveler.RepositoryID(pr.Repository), graveler.Ref(pr.SourceRef), graveler.Ref(pr.DestinationBranch), graveler.DiffTypeMaskAll)
+	if err != nil {
+		return false, err
+	}
+	defer it.Close()
+	it.SeekGE(Path(path))
+	if !it.Next() {
+		return false, it.Err()
+	}
+	return strings.HasPrefix(it.Value().Path.String(), path), nil
+}
+
+// pullRequestApprovals returns the most approvals of commitID, the commit of sourceRef, by an open
+// pull request proposing to merge sourceRef into destinationBranch
+func (c *cataloger) pullRequestApprovals(ctx context.Context, repository, destinationBranch, sourceRef string, commitID graveler.CommitID) (int, error) {
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var approvals int
+		err := tx.GetPrimitive(&approvals, `SELECT COALESCE(MAX(approvals), 0) FROM (
+				SELECT COUNT(a.reviewer) AS approvals
+				FROM catalog_pull_requests p JOIN catalog_pull_request_approvals a ON a.pull_request_id = p.id
+				WHERE p.repository_id = $1 AND p.destination_branch = $2 AND p.source_ref = $3 AND p.status = $4
+					AND a.commit_id = $5
+				GROUP BY p.id) approved`,
+			repository, destinationBranch, sourceRef, PullRequestOpen, commitID.String())
+		return approvals, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	return res.(int), nil
+}
+
+// approvalViolations describes missing approvals required by the guardrails
+func (g *MergeGuardrails) approvalViolations(approvals int) []string {
+	if g.RequiredApprovals == 0 || approvals >= g.RequiredApprovals {
+		return nil
+	}
+	return []string{fmt.Sprintf("%d pull request approvals, %d required", approvals, g.RequiredApprovals)}
+}
+
+// checkReviewer verifies that reviewer may approve pr
+func (pr *PullRequest) checkReviewer(reviewer string) error {
+	if reviewer == pr.Author {
+		return fmt.Errorf("author approving own pull request: %w", ErrInvalidValue)
+	}
+	for _, r := range pr.Reviewers {
+		if r == reviewer {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not a reviewer of pull request #%d: %w", reviewer, pr.ID, ErrInvalidValue)
+}
@@ -0,0 +1,171 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+// newMergeTestStore returns a store with a main branch, whose other refs dereference to commitID
+func newMergeTestStore(commitID graveler.CommitID, diffs ...*graveler.Diff) *FakeGraveler {
+	return &FakeGraveler{
+		BranchIteratorFactory: NewFakeBranchIteratorFactory([]*graveler.BranchRecord{
+			{BranchID: "main", Branch: &graveler.Branch{CommitID: "base"}},
+		}),
+		DiffIteratorFactory: NewFakeDiffIteratorFactory(diffs),
+		CommitLog:           []*graveler.CommitRecord{{CommitID: commitID, Commit: &graveler.Commit{}}},
+	}
+}
+
+// advance moves the refs of store on to commitID
+func advance(store *FakeGraveler, commitID graveler.CommitID) {
+	store.CommitLog = append([]*graveler.CommitRecord{{CommitID: commitID, Commit: &graveler.Commit{}}}, store.CommitLog...)
+}
+
+func TestCataloger_ApprovePullRequest(t *testing.T) {
+	ctx := context.Background()
+	c := testCataloger(t, newMergeTestStore("c1"))
+	pr, err := c.OpenPullRequest(ctx, "repo", PullRequest{
+		SourceRef:         "feature",
+		DestinationBranch: "main",
+		Title:             "add feature",
+		Author:            "alice",
+		Reviewers:         []string{"bob"},
+	})
+	if err != nil {
+		t.Fatalf("OpenPullRequest() error = %s", err)
+	}
+
+	for _, reviewer := range []string{"alice", "carol"} {
+		if err := c.ApprovePullRequest(ctx, "repo", pr.ID, reviewer); !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("ApprovePullRequest() by %s error = %v, expected %s", reviewer, err, ErrInvalidValue)
+		}
+	}
+	if err := c.ApprovePullRequest(ctx, "repo", pr.ID, "bob"); err != nil {
+		t.Fatalf("ApprovePullRequest() by reviewer error = %s", err)
+	}
+	approved, err := c.GetPullRequest(ctx, "repo", pr.ID)
+	if err != nil {
+		t.Fatalf("GetPullRequest() error = %s", err)
+	}
+	if len(approved.Approvals) != 1 || approved.Approvals[0].Reviewer != "bob" || approved.Approvals[0].CommitID != "c1" {
+		t.Errorf("GetPullRequest() approvals = %+v, expected bob approving c1", approved.Approvals)
+	}
+
+	if err := c.ClosePullRequest(ctx, "repo", pr.ID); err != nil {
+		t.Fatalf("ClosePullRequest() error = %s", err)
+	}
+	if err := c.ApprovePullRequest(ctx, "repo", pr.ID, "bob"); !errors.Is(err, ErrPullRequestNotOpen) {
+		t.Errorf("ApprovePullRequest() of closed pull request error = %v, expected %s", err, ErrPullRequestNotOpen)
+	}
+}
+
+func TestCataloger_MergePullRequest_RequiredApprovals(t *testing.T) {
+	ctx := context.Background()
+	store := newMergeTestStore("c1")
+	c := testCataloger(t, store)
+	if err := c.SetMergeGuardrails(ctx, "repo", "main", MergeGuardrails{RequiredApprovals: 1}); err != nil {
+		t.Fatalf("SetMergeGuardrails() error = %s", err)
+	}
+	pr, err := c.OpenPullRequest(ctx, "repo", PullRequest{
+		SourceRef:         "feature",
+		DestinationBranch: "main",
+		Title:             "add feature",
+		Author:            "alice",
+		Reviewers:         []string{"bob"},
+	})
+	if err != nil {
+		t.Fatalf("OpenPullRequest() error = %s", err)
+	}
+	params := PullRequestMergeParams{MergeParams: MergeParams{Committer: "alice"}}
+	if _, err := c.MergePullRequest(ctx, "repo", pr.ID, params); !errors.Is(err, ErrMergeGuardrailsExceeded) {
+		t.Fatalf("MergePullRequest() without approval error = %v, expected %s", err, ErrMergeGuardrailsExceeded)
+	}
+
+	// approvals of a commit do not count once the source moves on
+	if err := c.ApprovePullRequest(ctx, "repo", pr.ID, "bob"); err != nil {
+		t.Fatalf("ApprovePullRequest() error = %s", err)
+	}
+	advance(store, "c2")
+	if _, err := c.MergePullRequest(ctx, "repo", pr.ID, params); !errors.Is(err, ErrMergeGuardrailsExceeded) {
+		t.Fatalf("MergePullRequest() of unapproved commit error = %v, expected %s", err, ErrMergeGuardrailsExceeded)
+	}
+
+	if err := c.ApprovePullRequest(ctx, "repo", pr.ID, "bob"); err != nil {
+		t.Fatalf("ApprovePullRequest() error = %s", err)
+	}
+	if _, err := c.MergePullRequest(ctx, "repo", pr.ID, params); err != nil {
+		t.Fatalf("MergePullRequest() error = %s", err)
+	}
+	// the approved commit is merged, rather than whatever the source points to at merge time
+	if diff := deep.Equal(store.MergedSources, []graveler.Ref{"c2"}); diff != nil {
+		t.Error("merged sources diff found", diff)
+	}
+	merged, err := c.GetPullRequest(ctx, "repo", pr.ID)
+	if err != nil {
+		t.Fatalf("GetPullRequest() error = %s", err)
+	}
+	if merged.Status != PullRequestMerged || merged.MergeReference != "merged" {
+		t.Errorf("merged pull request status %s reference %s", merged.Status, merged.MergeReference)
+	}
+}
+
+func TestMergeGuardrails_ApprovalViolations(t *testing.T) {
+	tests := []struct {
+		name      string
+		required  int
+		approvals int
+		want      []string
+	}{
+		{name: "not required", approvals: 0},
+		{name: "approved", required: 2, approvals: 2},
+		{name: "over approved", required: 1, approvals: 3},
+		{name: "missing", required: 2, approvals: 1, want: []string{"1 pull request approvals, 2 required"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guardrails := MergeGuardrails{RequiredApprovals: tt.required}
+			if diff := deep.Equal(guardrails.approvalViolations(tt.approvals), tt.want); diff != nil {
+				t.Error("approvalViolations() diff found", diff)
+			}
+		})
+	}
+}
+
+func TestCataloger_PullRequestChanges(t *testing.T) {
+	ctx := context.Background()
+	diffs := []*graveler.Diff{
+		{Type: graveler.DiffTypeChanged, Key: graveler.Key("tables/events/dt=2021-01-01/part-0.parquet")},
+		{Type: graveler.DiffTypeAdded, Key: graveler.Key("tables/users/part-0.parquet")},
+	}
+	c := &cataloger{
+		EntryCatalog: &EntryCatalog{
+			Store: &FakeGraveler{DiffIteratorFactory: NewFakeDiffIteratorFactory(diffs)},
+		},
+	}
+	pr := &PullRequest{Repository: "repo", SourceRef: "feature", DestinationBranch: "main"}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "tables/users/part-0.parquet", want: true},
+		{path: "tables/events/dt=2021-01-01/", want: true},
+		{path: "tables/", want: true},
+		{path: "tables/events/dt=2021-01-02/", want: false},
+		{path: "tables/users/part-1.parquet", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			changed, err := c.pullRequestChanges(ctx, pr, tt.path)
+			if err != nil {
+				t.Fatalf("pullRequestChanges() error = %v", err)
+			}
+			if changed != tt.want {
+				t.Errorf("pullRequestChanges() = %t, expected %t", changed, tt.want)
+			}
+		})
+	}
+}
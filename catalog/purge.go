@@ -0,0 +1,91 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/logging"
+)
+
+type PurgeHistoryResult struct {
+	// Commits maps each rewritten commit to the commit replacing it
+	Commits map[string]string
+	// RemovedObjects are the physical addresses of the purged objects that were deleted
+	RemovedObjects []string
+}
+
+// PurgeHistory removes path, or all paths under it when prefix is set, from every commit and
+// branch of repository, and deletes the objects it stored.  Commits are re-created with their
+// original metadata, the mapping from old to new commit IDs is returned.  Objects still
+// referenced by other paths of repository, such as copies and links by digest, or by other
+// repositories sharing the storage namespace are kept, and so are objects outside the repository
+// storage namespace.  The ranges of the original commits, which hold the purged paths and their
+// metadata but not their data, are left in place.
+func (c *cataloger) PurgeHistory(ctx context.Context, repository string, path string, prefix bool) (*PurgeHistoryResult, error) {
+	repositoryID := graveler.RepositoryID(repository)
+	repo, err := c.EntryCatalog.GetRepository(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.EntryCatalog.PurgeHistory(ctx, repositoryID, Path(path), prefix)
+	if err != nil {
+		return nil, err
+	}
+	result := &PurgeHistoryResult{
+		Commits: make(map[string]string, len(res.Commits)),
+	}
+	for oldID, newID := range res.Commits {
+		result.Commits[oldID.String()] = newID.String()
+	}
+
+	addresses := make(map[string]struct{})
+	for _, v := range res.Removed {
+		entry, err := ValueToEntry(v.Value)
+		if err != nil {
+			return nil, fmt.Errorf("path %s: %w", v.Key, err)
+		}
+		if !isCollectableAddress(entry.Address) {
+			continue
+		}
+		addresses[entry.Address] = struct{}{}
+	}
+	if len(addresses) > 0 {
+		// addresses are shared by copies and links by digest, and by forks sharing the storage
+		// namespace
+		shared, err := c.sharedRepositories(ctx, repositoryID, repo.StorageNamespace)
+		if err != nil {
+			return nil, err
+		}
+		live := make(map[string]struct{})
+		if err := c.collectSharedAddresses(ctx, append([]graveler.RepositoryID{repositoryID}, shared...), live); err != nil {
+			return nil, err
+		}
+		for address := range live {
+			delete(addresses, address)
+		}
+	}
+	for address := range addresses {
+		result.RemovedObjects = append(result.RemovedObjects, address)
+	}
+	sort.Strings(result.RemovedObjects)
+	for _, address := range result.RemovedObjects {
+		err := c.EntryCatalog.BlockAdapter.Remove(block.ObjectPointer{
+			StorageNamespace: repo.StorageNamespace.String(),
+			Identifier:       address,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("remove object %s: %w", address, err)
+		}
+	}
+	c.log.WithFields(logging.Fields{
+		"repository":      repository,
+		"path":            path,
+		"prefix":          prefix,
+		"rewritten":       len(result.Commits),
+		"removed_objects": len(result.RemovedObjects),
+	}).Info("Purged path from history")
+	return result, nil
+}
@@ -0,0 +1,70 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/testutil"
+)
+
+func TestCataloger_PurgeHistory(t *testing.T) {
+	ctx := context.Background()
+	conn, _ := testutil.GetDB(t, databaseURI)
+	c := testGravelerCataloger(t, conn)
+	const ns = "mem://purge"
+	_, err := c.CreateRepository(ctx, "repo", ns, "main")
+	testutil.MustDo(t, "create repository", err)
+	_, err = c.CreateRepository(ctx, "fork", ns, "main")
+	testutil.MustDo(t, "create fork", err)
+	write := func(repository, path, address string) {
+		data := []byte(address)
+		err := c.EntryCatalog.BlockAdapter.Put(block.ObjectPointer{StorageNamespace: ns, Identifier: address},
+			int64(len(data)), bytes.NewReader(data), block.PutOpts{})
+		testutil.MustDo(t, "put "+address, err)
+		err = c.CreateEntry(ctx, repository, "main", DBEntry{Path: path, PhysicalAddress: address, Size: int64(len(data)), Checksum: address})
+		testutil.MustDo(t, "create entry "+path, err)
+	}
+	// "copied" is shared by a copy on repo, "forked" by the fork
+	write("repo", "secret/only", "only")
+	write("repo", "secret/copied", "copied")
+	write("repo", "public/copy", "copied")
+	write("repo", "secret/forked", "forked")
+	write("fork", "data", "forked")
+	commit, err := c.Commit(ctx, "repo", "main", "add secrets", "tester", nil)
+	testutil.MustDo(t, "commit", err)
+	err = c.SetCommitStatus(ctx, "repo", commit.Reference, CommitStatus{
+		Context:    "ci",
+		State:      CommitStatusPassed,
+		Creator:    "tester",
+		UpdateDate: time.Now(),
+	})
+	testutil.MustDo(t, "set commit status", err)
+
+	res, err := c.PurgeHistory(ctx, "repo", "secret/", true)
+	testutil.MustDo(t, "purge history", err)
+	if diff := deep.Equal(res.RemovedObjects, []string{"only"}); diff != nil {
+		t.Errorf("removed objects diff %s", diff)
+	}
+	for address, expected := range map[string]bool{"only": false, "copied": true, "forked": true} {
+		exists, err := c.EntryCatalog.BlockAdapter.Exists(block.ObjectPointer{StorageNamespace: ns, Identifier: address})
+		testutil.MustDo(t, "exists "+address, err)
+		if exists != expected {
+			t.Errorf("object %s exists %v, expected %v", address, exists, expected)
+		}
+	}
+
+	// the status of the purged commit moves to the commit replacing it
+	newCommitID, ok := res.Commits[commit.Reference]
+	if !ok {
+		t.Fatalf("commit %s not rewritten", commit.Reference)
+	}
+	verification, err := c.GetCommitVerification(ctx, "repo", newCommitID)
+	testutil.MustDo(t, "get commit verification", err)
+	if verification.State != CommitStatusPassed || len(verification.Statuses) != 1 {
+		t.Errorf("rewritten commit verification %+v, expected the status of %s", verification, commit.Reference)
+	}
+}
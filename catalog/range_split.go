@@ -0,0 +1,64 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/treeverse/lakefs/graveler"
+)
+
+// RangeSplitPolicy tunes how the trees of a repository are split into ranges.  Zero fields keep
+// the configured defaults.
+type RangeSplitPolicy struct {
+	MinRangeSizeBytes uint64
+	MaxRangeSizeBytes uint64
+	MaxRangeEntries   int
+	// PrefixBoundaryDepth splits ranges only where the first PrefixBoundaryDepth path
+	// components of keys change, so that ranges align with partitions
+	PrefixBoundaryDepth int
+}
+
+func validateRangeSplitPolicy(policy RangeSplitPolicy) error {
+	if err := Validate([]ValidateArg{
+		{"max_range_entries", policy.MaxRangeEntries, ValidateNonNegativeInt},
+		{"prefix_boundary_depth", policy.PrefixBoundaryDepth, ValidateNonNegativeInt},
+	}); err != nil {
+		return err
+	}
+	if policy.MaxRangeSizeBytes > 0 && policy.MinRangeSizeBytes > policy.MaxRangeSizeBytes {
+		return fmt.Errorf("min_range_size_bytes above max_range_size_bytes: %w", ErrInvalidValue)
+	}
+	return nil
+}
+
+func (c *cataloger) GetRangeSplitPolicy(ctx context.Context, repository string) (*RangeSplitPolicy, error) {
+	repo, err := c.EntryCatalog.GetRepository(ctx, graveler.RepositoryID(repository))
+	if err != nil {
+		return nil, err
+	}
+	if repo.RangeSplit == nil {
+		return &RangeSplitPolicy{}, nil
+	}
+	return &RangeSplitPolicy{
+		MinRangeSizeBytes:   repo.RangeSplit.MinRangeSizeBytes,
+		MaxRangeSizeBytes:   repo.RangeSplit.MaxRangeSizeBytes,
+		MaxRangeEntries:     repo.RangeSplit.MaxRangeEntries,
+		PrefixBoundaryDepth: repo.RangeSplit.PrefixBoundaryDepth,
+	}, nil
+}
+
+func (c *cataloger) SetRangeSplitPolicy(ctx context.Context, repository string, policy RangeSplitPolicy) error {
+	if err := validateRangeSplitPolicy(policy); err != nil {
+		return err
+	}
+	var rangeSplit *graveler.RangeSplitPolicy
+	if policy != (RangeSplitPolicy{}) {
+		rangeSplit = &graveler.RangeSplitPolicy{
+			MinRangeSizeBytes:   policy.MinRangeSizeBytes,
+			MaxRangeSizeBytes:   policy.MaxRangeSizeBytes,
+			MaxRangeEntries:     policy.MaxRangeEntries,
+			PrefixBoundaryDepth: policy.PrefixBoundaryDepth,
+		}
+	}
+	return c.EntryCatalog.SetRangeSplitPolicy(ctx, graveler.RepositoryID(repository), rangeSplit)
+}
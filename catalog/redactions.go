@@ -0,0 +1,164 @@
+package catalog
+
+import (
+	"context"
+	"time"
+
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/cache"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/logging"
+)
+
+const (
+	// RedactionsCacheExpiry is the time the redactions of a storage namespace are cached, other
+	// lakeFS instances may take that long to refuse reads of an object redacted
+	RedactionsCacheExpiry = 10 * time.Second
+	RedactionsCacheSize   = 1024
+	RedactionsCacheJitter = 2 * time.Second
+)
+
+func newRedactionsCache() *cache.GetSetCache {
+	return cache.NewCache(RedactionsCacheSize, RedactionsCacheExpiry, cache.NewJitterFn(RedactionsCacheJitter))
+}
+
+// Redaction marks the data of an object as removed.  It applies to every entry, committed or
+// staged, pointing to the physical address in any repository using the storage namespace, so
+// commits and their IDs are left unchanged.
+type Redaction struct {
+	PhysicalAddress string    `db:"physical_address"`
+	Path            string    `db:"path"`
+	Reason          string    `db:"reason"`
+	RedactedBy      string    `db:"redacted_by"`
+	CreationDate    time.Time `db:"creation_date"`
+}
+
+// RedactEntry redacts the object path points to on reference, deleting its data.  Reading any
+// entry pointing to the same object returns its redaction reason instead of the data.
+func (c *cataloger) RedactEntry(ctx context.Context, repository, reference, path, reason, redactedBy string) (*Redaction, error) {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+		{"reference", graveler.Ref(reference), ValidateRef},
+		{"path", Path(path), ValidatePath},
+		{"reason", reason, ValidateRequiredString},
+	}); err != nil {
+		return nil, err
+	}
+	repositoryID := graveler.RepositoryID(repository)
+	repo, err := c.EntryCatalog.GetRepository(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := c.EntryCatalog.GetEntry(ctx, repositoryID, graveler.Ref(reference), Path(path))
+	if err != nil {
+		return nil, err
+	}
+	redaction := &Redaction{
+		PhysicalAddress: entry.Address,
+		Path:            path,
+		Reason:          reason,
+		RedactedBy:      redactedBy,
+		CreationDate:    time.Now().UTC(),
+	}
+	_, err = c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		res, err := tx.Exec(`INSERT INTO catalog_redactions (storage_namespace, physical_address, repository_id, path, reason, redacted_by, creation_date)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT DO NOTHING`,
+			repo.StorageNamespace, redaction.PhysicalAddress, repository, redaction.Path, redaction.Reason, redaction.RedactedBy, redaction.CreationDate)
+		if err != nil {
+			return nil, err
+		}
+		if res.RowsAffected() == 0 {
+			return nil, ErrEntryAlreadyRedacted
+		}
+		return nil, nil
+	}, db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	c.redactions.Remove(repo.StorageNamespace.String())
+	// objects outside the storage namespace are not managed by lakeFS
+	if block.IsResolvableKey(redaction.PhysicalAddress) {
+		err := c.EntryCatalog.BlockAdapter.Remove(block.ObjectPointer{
+			StorageNamespace: repo.StorageNamespace.String(),
+			Identifier:       redaction.PhysicalAddress,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	c.log.WithFields(logging.Fields{
+		"repository": repository,
+		"path":       path,
+		"address":    redaction.PhysicalAddress,
+	}).Info("Redacted entry")
+	return redaction, nil
+}
+
+// GetRedaction returns the redaction of the object at physicalAddress of storageNamespace, or
+// db.ErrNotFound.  It is called on every read of an object, so the redactions of the storage
+// namespace are read from a cache.
+func (c *cataloger) GetRedaction(ctx context.Context, storageNamespace, physicalAddress string) (*Redaction, error) {
+	res, err := c.redactions.GetOrSet(storageNamespace, func() (interface{}, error) {
+		redactions, err := c.listRedactions(ctx, storageNamespace)
+		if err != nil {
+			return nil, err
+		}
+		byAddress := make(map[string]*Redaction, len(redactions))
+		for _, redaction := range redactions {
+			byAddress[redaction.PhysicalAddress] = redaction
+		}
+		return byAddress, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	redaction, ok := res.(map[string]*Redaction)[physicalAddress]
+	if !ok {
+		return nil, db.ErrNotFound
+	}
+	return redaction, nil
+}
+
+// ListRedactions returns the redactions of the storage namespace of repository, including those
+// made in other repositories using it
+func (c *cataloger) ListRedactions(ctx context.Context, repository string) ([]*Redaction, error) {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+	}); err != nil {
+		return nil, err
+	}
+	repo, err := c.EntryCatalog.GetRepository(ctx, graveler.RepositoryID(repository))
+	if err != nil {
+		return nil, err
+	}
+	return c.listRedactions(ctx, repo.StorageNamespace.String())
+}
+
+func (c *cataloger) listRedactions(ctx context.Context, storageNamespace string) ([]*Redaction, error) {
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var redactions []*Redaction
+		err := tx.Select(&redactions, `SELECT physical_address, path, reason, redacted_by, creation_date
+			FROM catalog_redactions WHERE storage_namespace = $1
+			ORDER BY path, physical_address`,
+			storageNamespace)
+		return redactions, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.([]*Redaction), nil
+}
+
+// redactedAddresses adds the redacted physical addresses of repository to addresses
+func (c *cataloger) redactedAddresses(ctx context.Context, repository string, addresses map[string]struct{}) error {
+	redactions, err := c.ListRedactions(ctx, repository)
+	if err != nil {
+		return err
+	}
+	for _, redaction := range redactions {
+		addresses[redaction.PhysicalAddress] = struct{}{}
+	}
+	return nil
+}
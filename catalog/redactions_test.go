@@ -0,0 +1,73 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/block/mem"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/testutil"
+)
+
+func TestCataloger_Redactions(t *testing.T) {
+	ctx := context.Background()
+	const storageNamespace = "mem://redactions"
+	store := &FakeGraveler{
+		KeyValue: map[string]*graveler.Value{
+			"repo/main/file": MustEntryToValue(&Entry{Address: "addr1", ETag: "etag1", Size: 4}),
+			"fork/main/file": MustEntryToValue(&Entry{Address: "addr1", ETag: "etag1", Size: 4}),
+		},
+		Repositories: map[graveler.RepositoryID]*graveler.Repository{
+			"repo":  {StorageNamespace: storageNamespace, DefaultBranchID: "main"},
+			"fork":  {StorageNamespace: storageNamespace, DefaultBranchID: "main"},
+			"other": {StorageNamespace: "mem://other", DefaultBranchID: "main"},
+		},
+	}
+	c := testCataloger(t, store)
+	adapter := mem.New()
+	object := block.ObjectPointer{StorageNamespace: storageNamespace, Identifier: "addr1"}
+	testutil.MustDo(t, "put object", adapter.Put(object, 4, strings.NewReader("data"), block.PutOpts{}))
+	c.EntryCatalog.BlockAdapter = adapter
+
+	// caches that addr1 is not redacted
+	if _, err := c.GetRedaction(ctx, storageNamespace, "addr1"); !errors.Is(err, db.ErrNotFound) {
+		t.Fatalf("GetRedaction() before redacting err = %v, expected not found", err)
+	}
+	redaction, err := c.RedactEntry(ctx, "repo", "main", "file", "leaked credentials", "admin")
+	testutil.MustDo(t, "redact entry", err)
+	if exists, _ := adapter.Exists(object); exists {
+		t.Error("RedactEntry() left the object data")
+	}
+
+	got, err := c.GetRedaction(ctx, storageNamespace, "addr1")
+	if err != nil {
+		t.Fatalf("GetRedaction() after redacting err = %s", err)
+	}
+	if got.Reason != redaction.Reason || got.Path != "file" {
+		t.Errorf("GetRedaction() got %+v, expected %+v", got, redaction)
+	}
+	if _, err := c.GetRedaction(ctx, "mem://other", "addr1"); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("GetRedaction() of another storage namespace err = %v, expected not found", err)
+	}
+
+	// the fork shares the object, so it is redacted there too
+	if _, err := c.RedactEntry(ctx, "fork", "main", "file", "again", "admin"); !errors.Is(err, ErrEntryAlreadyRedacted) {
+		t.Errorf("RedactEntry() of the object in a fork err = %v, expected %s", err, ErrEntryAlreadyRedacted)
+	}
+	for _, repository := range []string{"repo", "fork"} {
+		redactions, err := c.ListRedactions(ctx, repository)
+		testutil.MustDo(t, "list redactions of "+repository, err)
+		if len(redactions) != 1 || redactions[0].PhysicalAddress != "addr1" {
+			t.Errorf("ListRedactions(%s) got %+v, expected the redaction of addr1", repository, redactions)
+		}
+	}
+	redactions, err := c.ListRedactions(ctx, "other")
+	testutil.MustDo(t, "list redactions of other", err)
+	if len(redactions) != 0 {
+		t.Errorf("ListRedactions(other) got %+v, expected none", redactions)
+	}
+}
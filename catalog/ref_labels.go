@@ -0,0 +1,163 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/treeverse/lakefs/graveler"
+)
+
+const (
+	RefTypeBranch = string(graveler.LabeledRefBranch)
+	RefTypeTag    = string(graveler.LabeledRefTag)
+	RefTypeCommit = string(graveler.LabeledRefCommit)
+
+	MaxRefLabelKeyLength   = 256
+	MaxRefLabelValueLength = 1024
+)
+
+// RefLabels are the free-form labels of a branch, tag or commit, such as stage=prod, used to
+// find refs
+type RefLabels struct {
+	RefType string
+	RefID   string
+	Labels  map[string]string
+}
+
+func validateRefLabelKey(key string) error {
+	if key == "" || len(key) > MaxRefLabelKeyLength {
+		return fmt.Errorf("label key '%s': %w", key, ErrInvalidValue)
+	}
+	return nil
+}
+
+func validateRefLabels(labels map[string]string) error {
+	for key, value := range labels {
+		if err := validateRefLabelKey(key); err != nil {
+			return err
+		}
+		if len(value) > MaxRefLabelValueLength {
+			return fmt.Errorf("label '%s' value longer than %d: %w", key, MaxRefLabelValueLength, ErrInvalidValue)
+		}
+	}
+	return nil
+}
+
+func validateRefLabelsUpdate(set map[string]string, unset []string) error {
+	if len(set) == 0 && len(unset) == 0 {
+		return fmt.Errorf("update changes no label: %w", ErrInvalidValue)
+	}
+	if err := validateRefLabels(set); err != nil {
+		return err
+	}
+	for _, key := range unset {
+		if err := validateRefLabelKey(key); err != nil {
+			return err
+		}
+		if _, ok := set[key]; ok {
+			return fmt.Errorf("label '%s' both set and unset: %w", key, ErrInvalidValue)
+		}
+	}
+	return nil
+}
+
+// labeledRef returns the ID labels of the ref of refType refID are stored under: commits are
+// labeled by their full ID
+func (c *cataloger) labeledRef(ctx context.Context, repositoryID graveler.RepositoryID, refType, refID string) (string, error) {
+	switch refType {
+	case RefTypeBranch:
+		if err := ValidateBranchID(graveler.BranchID(refID)); err != nil {
+			return "", fmt.Errorf("branch '%s': %w", refID, ErrInvalidValue)
+		}
+		return refID, nil
+	case RefTypeTag:
+		if err := ValidateTagID(graveler.TagID(refID)); err != nil {
+			return "", fmt.Errorf("tag '%s': %w", refID, ErrInvalidValue)
+		}
+		return refID, nil
+	case RefTypeCommit:
+		if err := ValidateRef(graveler.Ref(refID)); err != nil {
+			return "", fmt.Errorf("commit '%s': %w", refID, ErrInvalidValue)
+		}
+		commitID, err := c.EntryCatalog.Dereference(ctx, repositoryID, graveler.Ref(refID))
+		if err != nil {
+			return "", err
+		}
+		return commitID.String(), nil
+	default:
+		return "", fmt.Errorf("ref type '%s': %w", refType, ErrInvalidValue)
+	}
+}
+
+// SetRefLabels sets the labels in set and removes the labels in unset of the ref of refType
+// refID
+func (c *cataloger) SetRefLabels(ctx context.Context, repository, refType, refID string, set map[string]string, unset []string) (*RefLabels, error) {
+	repositoryID := graveler.RepositoryID(repository)
+	if err := Validate([]ValidateArg{
+		{"repository", repositoryID, ValidateRepositoryID},
+	}); err != nil {
+		return nil, err
+	}
+	if err := validateRefLabelsUpdate(set, unset); err != nil {
+		return nil, err
+	}
+	id, err := c.labeledRef(ctx, repositoryID, refType, refID)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := c.EntryCatalog.SetRefLabels(ctx, repositoryID, graveler.LabeledRefType(refType), id, set, unset)
+	if err != nil {
+		return nil, err
+	}
+	return &RefLabels{RefType: refType, RefID: id, Labels: labels}, nil
+}
+
+// GetRefLabels returns the labels of the ref of refType refID
+func (c *cataloger) GetRefLabels(ctx context.Context, repository, refType, refID string) (*RefLabels, error) {
+	repositoryID := graveler.RepositoryID(repository)
+	if err := Validate([]ValidateArg{
+		{"repository", repositoryID, ValidateRepositoryID},
+	}); err != nil {
+		return nil, err
+	}
+	id, err := c.labeledRef(ctx, repositoryID, refType, refID)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := c.EntryCatalog.GetRefLabels(ctx, repositoryID, graveler.LabeledRefType(refType), id)
+	if err != nil {
+		return nil, err
+	}
+	return &RefLabels{RefType: refType, RefID: id, Labels: labels}, nil
+}
+
+// FindRefsByLabels returns the refs of refType, or of every type if empty, that have all
+// labels
+func (c *cataloger) FindRefsByLabels(ctx context.Context, repository string, labels map[string]string, refType string) ([]*RefLabels, error) {
+	repositoryID := graveler.RepositoryID(repository)
+	if err := Validate([]ValidateArg{
+		{"repository", repositoryID, ValidateRepositoryID},
+	}); err != nil {
+		return nil, err
+	}
+	if len(labels) == 0 {
+		return nil, fmt.Errorf("no labels to find: %w", ErrInvalidValue)
+	}
+	if err := validateRefLabels(labels); err != nil {
+		return nil, err
+	}
+	switch refType {
+	case "", RefTypeBranch, RefTypeTag, RefTypeCommit:
+	default:
+		return nil, fmt.Errorf("ref type '%s': %w", refType, ErrInvalidValue)
+	}
+	refs, err := c.EntryCatalog.FindRefsByLabels(ctx, repositoryID, labels, graveler.LabeledRefType(refType))
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*RefLabels, len(refs))
+	for i, ref := range refs {
+		res[i] = &RefLabels{RefType: string(ref.Type), RefID: ref.ID, Labels: ref.Labels}
+	}
+	return res, nil
+}
@@ -0,0 +1,32 @@
+package catalog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateRefLabelsUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		set     map[string]string
+		unset   []string
+		wantErr bool
+	}{
+		{name: "set", set: map[string]string{"stage": "prod", "dataset": "clickstream"}},
+		{name: "unset", unset: []string{"stage"}},
+		{name: "set and unset others", set: map[string]string{"stage": "prod"}, unset: []string{"owner"}},
+		{name: "no change", wantErr: true},
+		{name: "empty key", set: map[string]string{"": "prod"}, wantErr: true},
+		{name: "long key", unset: []string{strings.Repeat("k", MaxRefLabelKeyLength+1)}, wantErr: true},
+		{name: "long value", set: map[string]string{"notes": strings.Repeat("v", MaxRefLabelValueLength+1)}, wantErr: true},
+		{name: "set and unset", set: map[string]string{"stage": "prod"}, unset: []string{"stage"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRefLabelsUpdate(tt.set, tt.unset)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateRefLabelsUpdate() err=%v, expected error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+// RefsJournalPrefix is the prefix of the refs journal in the repository storage namespace
+const RefsJournalPrefix = "_lakefs/journal/"
+
+// RefsRecovery reports the sources that rebuilt the refs of a repository
+type RefsRecovery struct {
+	Repository string `json:"repository"`
+	// Backup is the ID of the metadata backup loaded, empty when there was none
+	Backup string `json:"backup,omitempty"`
+	// JournalEntries is the number of changes replayed from the refs journal after the backup
+	JournalEntries int `json:"journal_entries"`
+}
+
+// blockRefsJournal writes each operation of the refs journal as an object named by its time,
+// so that listing the journal lists it in order
+type blockRefsJournal struct {
+	adapter block.Adapter
+}
+
+// NewRefsJournal returns a refs journal kept in the storage namespaces of repositories
+func NewRefsJournal(adapter block.Adapter) graveler.RefsJournal {
+	return &blockRefsJournal{adapter: adapter}
+}
+
+// refsJournalPath returns the path of an operation at time t.  The time is padded to sort
+// lexicographically, the UUID keeps operations written at the same time apart.
+func refsJournalPath(t time.Time) string {
+	return fmt.Sprintf("%s%020d-%s.json", RefsJournalPrefix, t.UnixNano(), uuid.New().String())
+}
+
+func (j *blockRefsJournal) Append(_ context.Context, storageNamespace graveler.StorageNamespace, entries []*graveler.RefsJournalEntry) error {
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	identifier := refsJournalPath(entries[0].Time)
+	err = j.adapter.Put(block.ObjectPointer{
+		StorageNamespace: storageNamespace.String(),
+		Identifier:       identifier,
+	}, int64(len(data)), bytes.NewReader(data), block.PutOpts{})
+	if err != nil {
+		return fmt.Errorf("write %s: %w", identifier, err)
+	}
+	return nil
+}
+
+// ReadRefsJournal returns the entries of the refs journal in storage namespace ns recorded at
+// or after since, in order.  It reads only the storage namespace, auditing changes to refs
+// needs no access to the database.
+func ReadRefsJournal(adapter block.Adapter, ns string, since time.Time) ([]*graveler.RefsJournalEntry, error) {
+	var names []string
+	err := adapter.Walk(block.WalkOpts{
+		StorageNamespace: ns,
+		Prefix:           RefsJournalPrefix,
+	}, func(id string) error {
+		// adapters report IDs of different forms, but all end in the object name
+		names = append(names, path.Base(id))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list refs journal: %w", err)
+	}
+	sort.Strings(names)
+	var entries []*graveler.RefsJournalEntry
+	for _, name := range names {
+		nanos, err := strconv.ParseInt(strings.SplitN(name, "-", 2)[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("refs journal object %s: %w", name, ErrInvalidValue)
+		}
+		if !since.IsZero() && nanos < since.UnixNano() {
+			continue
+		}
+		var operation []*graveler.RefsJournalEntry
+		if err := readJSONObject(adapter, ns, RefsJournalPrefix+name, &operation); err != nil {
+			return nil, err
+		}
+		entries = append(entries, operation...)
+	}
+	return entries, nil
+}
+
+// RecoverRefs rebuilds the commits, branches and tags of repository from its storage namespace:
+// the latest metadata backup, followed by the changes recorded in the refs journal since.
+// Repository must be a bare repository created on the storage namespace of the lost
+// repository.  Uncommitted changes are not recovered.
+func (c *cataloger) RecoverRefs(ctx context.Context, repository string) (*RefsRecovery, error) {
+	repositoryID := graveler.RepositoryID(repository)
+	repo, err := c.EntryCatalog.GetRepository(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	ns := repo.StorageNamespace.String()
+	recovery := &RefsRecovery{Repository: repository}
+	backup, err := c.latestMetadataBackup(ns)
+	if err != nil {
+		return nil, err
+	}
+	// changes recorded before the backup started are in the backup
+	var since time.Time
+	if backup != nil {
+		recovery.Backup = backup.ID
+		since = backup.CreationDate
+		if err := c.LoadCommits(ctx, repository, backup.CommitsMetaRangeID); err != nil {
+			return nil, fmt.Errorf("load commits: %w", err)
+		}
+		if err := c.LoadBranches(ctx, repository, backup.BranchesMetaRangeID); err != nil {
+			return nil, fmt.Errorf("load branches: %w", err)
+		}
+		if err := c.LoadTags(ctx, repository, backup.TagsMetaRangeID); err != nil {
+			return nil, fmt.Errorf("load tags: %w", err)
+		}
+	}
+	entries, err := ReadRefsJournal(c.EntryCatalog.BlockAdapter, ns, since)
+	if err != nil {
+		return nil, err
+	}
+	if backup == nil && len(entries) == 0 {
+		return nil, ErrNoRefsToRecover
+	}
+	if err := c.EntryCatalog.ReplayRefsJournal(ctx, repositoryID, entries); err != nil {
+		return nil, err
+	}
+	recovery.JournalEntries = len(entries)
+	return recovery, nil
+}
@@ -0,0 +1,118 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/block/mem"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+func TestCataloger_RecoverRefs(t *testing.T) {
+	ctx := context.Background()
+	store := &FakeGraveler{
+		Repositories: map[graveler.RepositoryID]*graveler.Repository{
+			"repo": {StorageNamespace: "mem://recover", DefaultBranchID: "main"},
+		},
+		BranchIteratorFactory: NewFakeBranchIteratorFactory(nil),
+		Dumps: map[string]graveler.MetaRangeID{
+			"commits":  "commits1",
+			"branches": "branches1",
+			"tags":     "tags1",
+		},
+	}
+	adapter := mem.New()
+	c := &cataloger{EntryCatalog: &EntryCatalog{Store: store, BlockAdapter: adapter}}
+	journal := NewRefsJournal(adapter)
+
+	if _, err := c.RecoverRefs(ctx, "repo"); !errors.Is(err, ErrNoRefsToRecover) {
+		t.Fatalf("RecoverRefs() of an empty namespace err=%v, expected %s", err, ErrNoRefsToRecover)
+	}
+
+	// journal entries recorded before the backup are in the backup
+	before := &graveler.RefsJournalEntry{Time: time.Now().Add(-time.Hour), Op: graveler.RefsJournalSetBranch, BranchID: "main", CommitID: "c1"}
+	if err := journal.Append(ctx, "mem://recover", []*graveler.RefsJournalEntry{before}); err != nil {
+		t.Fatalf("Append: %s", err)
+	}
+	backup, err := c.BackupMetadata(ctx, "repo")
+	if err != nil {
+		t.Fatalf("BackupMetadata: %s", err)
+	}
+	after := []*graveler.RefsJournalEntry{
+		{Time: time.Now().Add(time.Hour), Op: graveler.RefsJournalSetBranch, BranchID: "main", CommitID: "c2"},
+		{Time: time.Now().Add(time.Hour), Op: graveler.RefsJournalDeleteBranch, BranchID: "dev"},
+	}
+	later := &graveler.RefsJournalEntry{Time: time.Now().Add(2 * time.Hour), Op: graveler.RefsJournalCreateTag, TagID: "v1", CommitID: "c2"}
+	// appended out of order, replayed in order of time
+	if err := journal.Append(ctx, "mem://recover", []*graveler.RefsJournalEntry{later}); err != nil {
+		t.Fatalf("Append: %s", err)
+	}
+	if err := journal.Append(ctx, "mem://recover", after); err != nil {
+		t.Fatalf("Append: %s", err)
+	}
+
+	recovery, err := c.RecoverRefs(ctx, "repo")
+	if err != nil {
+		t.Fatalf("RecoverRefs: %s", err)
+	}
+	if diff := deep.Equal(recovery, &RefsRecovery{Repository: "repo", Backup: backup.ID, JournalEntries: 3}); diff != nil {
+		t.Error("recovery diff found", diff)
+	}
+	expectedLoads := map[string]graveler.MetaRangeID{
+		"commits":  "commits1",
+		"branches": "branches1",
+		"tags":     "tags1",
+	}
+	if diff := deep.Equal(store.Loads, expectedLoads); diff != nil {
+		t.Error("recovery loads diff found", diff)
+	}
+	var ops []string
+	for _, entry := range store.Replayed {
+		ops = append(ops, entry.Op+" "+entry.BranchID.String()+entry.TagID.String())
+	}
+	expectedOps := []string{"set_branch main", "delete_branch dev", "create_tag v1"}
+	if diff := deep.Equal(ops, expectedOps); diff != nil {
+		t.Error("replayed journal diff found", diff)
+	}
+}
+
+func TestReadRefsJournal(t *testing.T) {
+	ctx := context.Background()
+	adapter := mem.New()
+	journal := NewRefsJournal(adapter)
+	start := time.Now()
+	operations := [][]*graveler.RefsJournalEntry{
+		{{Time: start, Repository: "repo", Op: graveler.RefsJournalSetBranch, BranchID: "main", CommitID: "c1"}},
+		{
+			{Time: start.Add(time.Minute), Repository: "repo", Op: graveler.RefsJournalSetBranch, BranchID: "main", CommitID: "c2", PreviousCommitID: "c1"},
+			{Time: start.Add(time.Minute), Repository: "repo", Op: graveler.RefsJournalCreateTag, TagID: "v1", CommitID: "c2"},
+		},
+	}
+	for _, operation := range operations {
+		if err := journal.Append(ctx, "mem://audit", operation); err != nil {
+			t.Fatalf("Append: %s", err)
+		}
+	}
+
+	entries, err := ReadRefsJournal(adapter, "mem://audit", time.Time{})
+	if err != nil {
+		t.Fatalf("ReadRefsJournal: %s", err)
+	}
+	if len(entries) != 3 || entries[1].PreviousCommitID != "c1" || entries[2].TagID != "v1" {
+		t.Errorf("ReadRefsJournal() = %+v, expected all entries in order", entries)
+	}
+	entries, err = ReadRefsJournal(adapter, "mem://audit", start.Add(time.Second))
+	if err != nil {
+		t.Fatalf("ReadRefsJournal since: %s", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("ReadRefsJournal() since got %d entries, expected 2", len(entries))
+	}
+	entries, err = ReadRefsJournal(adapter, "mem://empty", time.Time{})
+	if err != nil || len(entries) != 0 {
+		t.Errorf("ReadRefsJournal() of an empty namespace = %v, %v, expected no entries", entries, err)
+	}
+}
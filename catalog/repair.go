@@ -0,0 +1,219 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/logging"
+)
+
+// RepairCommitMessage is the message of commits removing dangling entries
+const RepairCommitMessage = "Remove dangling entries"
+
+type RepairParams struct {
+	// Prefix limits the repair to the entries under it
+	Prefix string
+	// ReplicaNamespace, when set, is a storage namespace holding copies of the objects of the
+	// repository at the same addresses.  Missing objects found there are copied back.
+	ReplicaNamespace string
+	// Remove deletes the entries whose objects are missing (and were not restored) in a commit
+	// on the branch
+	Remove bool
+	// Committer of the repair commit
+	Committer string
+}
+
+type RepairResult struct {
+	// Dangling are the paths of the entries whose objects are missing
+	Dangling []string
+	// Restored are the paths of the entries whose objects were copied from the replica namespace
+	Restored []string
+	// Removed are the paths of the entries deleted by the repair commit
+	Removed []string
+	// CommitID of the repair commit, empty if no commit was made
+	CommitID string
+}
+
+// RepairDanglingEntries finds the entries of branch whose objects no longer exist in the
+// repository storage namespace.  Without parameters it only reports them.  Objects of dangling
+// entries are restored from params.ReplicaNamespace when set, and the remaining dangling entries
+// are deleted in a single commit when params.Remove is set.  Removal requires the branch to have
+// no uncommitted changes: the repair commit holds only the removals, applied to the committed
+// tree under the branch lock, and fails with ErrDirtyBranch if changes were staged meanwhile.
+// Entries linking to other repositories, and entries whose address is outside the storage
+// namespace, cannot be restored.  Entries of redacted objects are not dangling, their objects
+// were removed on purpose and are never restored.
+func (c *cataloger) RepairDanglingEntries(ctx context.Context, repository, branch string, params RepairParams) (*RepairResult, error) {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+		{"branch", graveler.BranchID(branch), ValidateBranchID},
+	}); err != nil {
+		return nil, err
+	}
+	repositoryID := graveler.RepositoryID(repository)
+	repo, err := c.EntryCatalog.GetRepository(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	if params.Remove {
+		// fail before scanning, the repair commit checks again under the branch lock
+		diffs, _, err := c.DiffUncommitted(ctx, repository, branch, DiffParams{Limit: 1})
+		if err != nil {
+			return nil, err
+		}
+		if len(diffs) > 0 {
+			return nil, graveler.ErrDirtyBranch
+		}
+	}
+
+	redacted := make(map[string]struct{})
+	if err := c.redactedAddresses(ctx, repository, redacted); err != nil {
+		return nil, err
+	}
+	dangling, err := c.findDanglingEntries(ctx, repositoryID, graveler.Ref(branch), repo.StorageNamespace.String(), params.Prefix, redacted)
+	if err != nil {
+		return nil, err
+	}
+	result := &RepairResult{Dangling: make([]string, 0, len(dangling))}
+	var remaining []string
+	for _, d := range dangling {
+		result.Dangling = append(result.Dangling, d.path)
+		if params.ReplicaNamespace != "" && block.IsResolvableKey(d.address) {
+			restored, err := c.restoreObject(params.ReplicaNamespace, repo.StorageNamespace.String(), d.address, d.size)
+			if err != nil {
+				return nil, fmt.Errorf("restore %s: %w", d.path, err)
+			}
+			if restored {
+				result.Restored = append(result.Restored, d.path)
+				continue
+			}
+		}
+		remaining = append(remaining, d.path)
+	}
+
+	if params.Remove && len(remaining) > 0 {
+		// dangling entries are listed sorted by path
+		it := &removedEntryIterator{paths: remaining, i: -1}
+		commitID, _, err := c.EntryCatalog.ApplyEntries(ctx, repositoryID, graveler.BranchID(branch), it, graveler.CommitParams{
+			Committer: params.Committer,
+			Message:   RepairCommitMessage,
+			Metadata: graveler.Metadata{
+				"removed_entries": fmt.Sprintf("%d", len(remaining)),
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		result.Removed = remaining
+		result.CommitID = commitID.String()
+	}
+	logging.FromContext(ctx).WithFields(logging.Fields{
+		"repository": repository,
+		"branch":     branch,
+		"dangling":   len(result.Dangling),
+		"restored":   len(result.Restored),
+		"removed":    len(result.Removed),
+	}).Info("Repaired dangling entries")
+	return result, nil
+}
+
+type danglingEntry struct {
+	path    string
+	address string
+	size    int64
+}
+
+// removedEntryIterator iterates over deletions of sorted paths
+type removedEntryIterator struct {
+	paths []string
+	i     int
+}
+
+func (it *removedEntryIterator) Next() bool {
+	if it.i+1 >= len(it.paths) {
+		it.i = len(it.paths)
+		return false
+	}
+	it.i++
+	return true
+}
+
+func (it *removedEntryIterator) SeekGE(id Path) {
+	it.i = sort.SearchStrings(it.paths, id.String()) - 1
+}
+
+func (it *removedEntryIterator) Value() *EntryRecord {
+	if it.i < 0 || it.i >= len(it.paths) {
+		return nil
+	}
+	return &EntryRecord{Path: Path(it.paths[it.i])}
+}
+
+func (it *removedEntryIterator) Err() error {
+	return nil
+}
+
+func (it *removedEntryIterator) Close() {}
+
+// findDanglingEntries returns the entries under prefix of ref whose objects do not exist,
+// skipping the entries of redacted addresses
+func (c *cataloger) findDanglingEntries(ctx context.Context, repositoryID graveler.RepositoryID, ref graveler.Ref, storageNamespace, prefix string, redacted map[string]struct{}) ([]danglingEntry, error) {
+	it, err := c.EntryCatalog.ListEntries(ctx, repositoryID, ref, Path(prefix), "")
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+	var dangling []danglingEntry
+	for it.Next() {
+		v := it.Value()
+		// generated entries never had data
+		if v.CommonPrefix || v.Entry == nil || v.LinkRepository != "" || IsGeneratedAddress(v.Address) {
+			continue
+		}
+		if _, ok := redacted[v.Address]; ok {
+			continue
+		}
+		exists, err := c.EntryCatalog.BlockAdapter.Exists(block.ObjectPointer{
+			StorageNamespace: storageNamespace,
+			Identifier:       v.Address,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("check %s: %w", v.Path, err)
+		}
+		if !exists {
+			dangling = append(dangling, danglingEntry{path: v.Path.String(), address: v.Address, size: v.Size})
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return dangling, nil
+}
+
+// restoreObject copies the object at address from the replica namespace, returns false if the
+// replica does not hold it
+func (c *cataloger) restoreObject(replicaNamespace, storageNamespace, address string, size int64) (bool, error) {
+	replica := block.ObjectPointer{StorageNamespace: replicaNamespace, Identifier: address}
+	exists, err := c.EntryCatalog.BlockAdapter.Exists(replica)
+	if err != nil || !exists {
+		return false, err
+	}
+	reader, err := c.EntryCatalog.BlockAdapter.Get(replica, size)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	err = c.EntryCatalog.BlockAdapter.Put(block.ObjectPointer{
+		StorageNamespace: storageNamespace,
+		Identifier:       address,
+	}, size, reader, block.PutOpts{})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
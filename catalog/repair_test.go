@@ -0,0 +1,94 @@
+package catalog
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/block/mem"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/testutil"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestCataloger_RepairDanglingEntries(t *testing.T) {
+	ctx := context.Background()
+	const (
+		storageNamespace = "mem://repair"
+		replicaNamespace = "mem://replica"
+	)
+	adapter := mem.New()
+	put := func(namespace, address, data string) {
+		err := adapter.Put(block.ObjectPointer{StorageNamespace: namespace, Identifier: address}, int64(len(data)), strings.NewReader(data), block.PutOpts{})
+		if err != nil {
+			t.Fatalf("put %s: %s", address, err)
+		}
+	}
+	put(storageNamespace, "present", "data")
+	put(replicaNamespace, "replicated", "data")
+	put(replicaNamespace, "redacted", "secret")
+
+	now := timestamppb.New(time.Now())
+	gravelerData := []*graveler.ValueRecord{
+		{Key: graveler.Key("file1"), Value: MustEntryToValue(&Entry{Address: "present", LastModified: now, Size: 4})},
+		{Key: graveler.Key("file2"), Value: MustEntryToValue(&Entry{Address: "replicated", LastModified: now, Size: 4})},
+		{Key: graveler.Key("file3"), Value: MustEntryToValue(&Entry{Address: "lost", LastModified: now, Size: 4})},
+		// generated entries never had data, they are not dangling
+		{Key: graveler.Key("file4"), Value: MustEntryToValue(&Entry{Address: generatedAddressPrefix + "0", LastModified: now, Size: 4})},
+		// redacted data is removed on purpose, it is never restored or removed
+		{Key: graveler.Key("file5"), Value: MustEntryToValue(&Entry{Address: "redacted", LastModified: now, Size: 6})},
+	}
+	store := &FakeGraveler{
+		ListIteratorFactory: NewFakeValueIteratorFactory(gravelerData),
+		DiffIteratorFactory: NewFakeDiffIteratorFactory(nil),
+		Repositories: map[graveler.RepositoryID]*graveler.Repository{
+			"repo": {StorageNamespace: storageNamespace, DefaultBranchID: "main"},
+		},
+	}
+	c := testCataloger(t, store)
+	c.EntryCatalog.BlockAdapter = adapter
+	_, err := c.db.Exec(`INSERT INTO catalog_redactions (storage_namespace, physical_address, repository_id, path, reason, redacted_by, creation_date)
+		VALUES ($1, 'redacted', 'repo', 'file5', 'leaked', 'admin', NOW())`, storageNamespace)
+	testutil.MustDo(t, "insert redaction", err)
+
+	res, err := c.RepairDanglingEntries(ctx, "repo", "main", RepairParams{})
+	if err != nil {
+		t.Fatalf("RepairDanglingEntries: %s", err)
+	}
+	if diff := deep.Equal(res, &RepairResult{Dangling: []string{"file2", "file3"}}); diff != nil {
+		t.Error("RepairDanglingEntries() report diff found", diff)
+	}
+
+	res, err = c.RepairDanglingEntries(ctx, "repo", "main", RepairParams{ReplicaNamespace: replicaNamespace})
+	if err != nil {
+		t.Fatalf("RepairDanglingEntries with replica: %s", err)
+	}
+	if diff := deep.Equal(res, &RepairResult{Dangling: []string{"file2", "file3"}, Restored: []string{"file2"}}); diff != nil {
+		t.Error("RepairDanglingEntries() restore diff found", diff)
+	}
+	exists, err := adapter.Exists(block.ObjectPointer{StorageNamespace: storageNamespace, Identifier: "replicated"})
+	if err != nil || !exists {
+		t.Errorf("restored object exists=%t err=%v, expected to exist", exists, err)
+	}
+	exists, err = adapter.Exists(block.ObjectPointer{StorageNamespace: storageNamespace, Identifier: "redacted"})
+	if err != nil || exists {
+		t.Errorf("redacted object exists=%t err=%v, expected not to be restored", exists, err)
+	}
+
+	res, err = c.RepairDanglingEntries(ctx, "repo", "main", RepairParams{Remove: true, Committer: "admin"})
+	if err != nil {
+		t.Fatalf("RepairDanglingEntries with remove: %s", err)
+	}
+	if diff := deep.Equal(res, &RepairResult{Dangling: []string{"file3"}, Removed: []string{"file3"}, CommitID: "applied"}); diff != nil {
+		t.Error("RepairDanglingEntries() remove diff found", diff)
+	}
+	if _, ok := store.KeyValue[fakeGravelerBuildKey("repo", "main", graveler.Key("file5"))]; ok {
+		t.Error("RepairDanglingEntries() removed the redacted entry file5")
+	}
+	if v, ok := store.KeyValue[fakeGravelerBuildKey("repo", "main", graveler.Key("file3"))]; !ok || v != nil {
+		t.Errorf("RepairDanglingEntries() applied %v to file3, expected a deletion", v)
+	}
+}
@@ -0,0 +1,117 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/logging"
+)
+
+// Repository event topics published on the events bus of the cataloger
+const (
+	// TopicCommit is published when a commit is created on a branch
+	TopicCommit = "catalog.commit"
+	// TopicMerge is published when a reference is merged into a branch
+	TopicMerge = "catalog.merge"
+	// TopicHookFailure is published when hooks abort a commit or a merge
+	TopicHookFailure = "catalog.hook_failure"
+	// TopicDatasetChange is published for each dataset a commit or a merge on a branch changed
+	TopicDatasetChange = "catalog.dataset_change"
+)
+
+// RepositoryEvent is the payload of the repository event topics
+type RepositoryEvent struct {
+	Repository string `json:"repository"`
+	Branch     string `json:"branch"`
+	// SourceRef is the reference merged, set on merges
+	SourceRef string `json:"source_ref,omitempty"`
+	// Reference is the commit created, empty when hooks aborted it
+	Reference string `json:"reference,omitempty"`
+	Committer string `json:"committer"`
+	Message   string `json:"message"`
+	// Error is the hook failure
+	Error string `json:"error,omitempty"`
+	// Dataset is the dataset changed, set on dataset changes
+	Dataset string `json:"dataset,omitempty"`
+}
+
+// publishCommitEvent publishes event on topic once the commit or merge it describes ends with
+// err, hook failures are published on TopicHookFailure instead
+func (c *cataloger) publishCommitEvent(topic string, event RepositoryEvent, err error) {
+	switch {
+	case err == nil:
+		c.events.Publish(topic, event)
+		c.publishDatasetChanges(event)
+	case errors.Is(err, graveler.ErrAbortedByHook):
+		event.Error = err.Error()
+		c.events.Publish(TopicHookFailure, event)
+	}
+}
+
+// publishDatasetChanges publishes an event on TopicDatasetChange for each dataset of the
+// repository of event that its commit changed over its first parent.  Datasets are diffed in
+// the background, failures are logged.
+func (c *cataloger) publishDatasetChanges(event RepositoryEvent) {
+	if !c.events.Subscribed(TopicDatasetChange) {
+		return
+	}
+	go func() {
+		ctx := context.Background()
+		log := c.log.WithFields(logging.Fields{
+			"repository": event.Repository,
+			"branch":     event.Branch,
+			"commit_id":  event.Reference,
+		})
+		datasets, err := c.ListDatasets(ctx, event.Repository)
+		if err != nil {
+			log.WithError(err).Error("Dataset changes: list datasets")
+			return
+		}
+		if len(datasets) == 0 {
+			return
+		}
+		repositoryID := graveler.RepositoryID(event.Repository)
+		commitID := graveler.CommitID(event.Reference)
+		commit, err := c.EntryCatalog.GetCommit(ctx, repositoryID, commitID)
+		if err != nil {
+			log.WithError(err).Error("Dataset changes: get commit")
+			return
+		}
+		if len(commit.Parents) == 0 {
+			return
+		}
+		changed, err := c.changedDatasets(ctx, repositoryID, datasets, commit.Parents[0], commitID)
+		if err != nil {
+			log.WithError(err).Error("Dataset changes: diff")
+			return
+		}
+		for _, name := range changed {
+			datasetEvent := event
+			datasetEvent.Dataset = name
+			c.events.Publish(TopicDatasetChange, datasetEvent)
+		}
+	}()
+}
+
+// changedDatasets returns the names of datasets with differences between parent and commitID
+func (c *cataloger) changedDatasets(ctx context.Context, repositoryID graveler.RepositoryID, datasets []*Dataset, parent, commitID graveler.CommitID) ([]string, error) {
+	var changed []string
+	for _, dataset := range datasets {
+		it, err := c.EntryCatalog.Diff(ctx, repositoryID, graveler.Ref(parent), graveler.Ref(commitID), graveler.DiffTypeMaskAll)
+		if err != nil {
+			return nil, err
+		}
+		it = NewPrefixesDiffIterator(it, datasetPrefixes(dataset))
+		hasDiff := it.Next()
+		err = it.Err()
+		it.Close()
+		if err != nil {
+			return nil, err
+		}
+		if hasDiff {
+			changed = append(changed, dataset.Name)
+		}
+	}
+	return changed, nil
+}
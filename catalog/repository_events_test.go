@@ -0,0 +1,72 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/events"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+func TestCataloger_PublishCommitEvent(t *testing.T) {
+	bus := events.NewBus()
+	received := make(chan events.Event, 10)
+	for _, topic := range []string{TopicCommit, TopicMerge, TopicHookFailure} {
+		cancel := bus.Subscribe(topic, func(e events.Event) { received <- e })
+		defer cancel()
+	}
+	c := &cataloger{events: bus}
+	event := RepositoryEvent{Repository: "repo", Branch: "main", Reference: "c1"}
+
+	c.publishCommitEvent(TopicCommit, event, nil)
+	c.publishCommitEvent(TopicMerge, event, errors.New("merge failed"))
+	c.publishCommitEvent(TopicMerge, RepositoryEvent{Repository: "repo", Branch: "main", SourceRef: "feature"},
+		fmt.Errorf("%w: check failed", graveler.ErrAbortedByHook))
+
+	// subscribers of different topics are called concurrently
+	expected := map[string]RepositoryEvent{
+		TopicCommit:      event,
+		TopicHookFailure: {Repository: "repo", Branch: "main", SourceRef: "feature", Error: "aborted by hook: check failed"},
+	}
+	for range expected {
+		select {
+		case e := <-received:
+			if exp, ok := expected[e.Topic]; !ok || e.Payload != exp {
+				t.Errorf("received %s %+v, expected %+v", e.Topic, e.Payload, exp)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for events")
+		}
+	}
+	select {
+	case e := <-received:
+		t.Errorf("unexpected event %s %+v", e.Topic, e.Payload)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestCataloger_ChangedDatasets(t *testing.T) {
+	gravelerMock := &FakeGraveler{
+		DiffIteratorFactory: NewFakeDiffIteratorFactory([]*graveler.Diff{
+			{Type: graveler.DiffTypeAdded, Key: graveler.Key("sales/2021/part-0001"), Value: MustEntryToValue(&Entry{Address: "a1"})},
+			{Type: graveler.DiffTypeRemoved, Key: graveler.Key("users/part-0001"), Value: MustEntryToValue(&Entry{Address: "a2"})},
+		}),
+	}
+	c := &cataloger{EntryCatalog: &EntryCatalog{Store: gravelerMock}}
+	datasets := []*Dataset{
+		{Name: "sales", Prefixes: []string{"sales/2020/", "sales/2021/"}},
+		{Name: "events", Prefixes: []string{"events/"}},
+		{Name: "people", Prefixes: []string{"employees/", "users/"}},
+	}
+	changed, err := c.changedDatasets(context.Background(), "repo", datasets, "c1", "c2")
+	if err != nil {
+		t.Fatalf("changedDatasets() error = %s", err)
+	}
+	if diff := deep.Equal(changed, []string{"sales", "people"}); diff != nil {
+		t.Error("changedDatasets() diff found", diff)
+	}
+}
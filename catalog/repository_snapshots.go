@@ -0,0 +1,55 @@
+package catalog
+
+import (
+	"context"
+
+	"github.com/treeverse/lakefs/graveler"
+)
+
+func newRepositorySnapshot(snapshot *graveler.RepositorySnapshot) *RepositorySnapshot {
+	branches := make(map[string]string, len(snapshot.Branches))
+	for branchID, commitID := range snapshot.Branches {
+		branches[branchID.String()] = commitID.String()
+	}
+	return &RepositorySnapshot{
+		ID:           snapshot.SnapshotID.String(),
+		CreationDate: snapshot.CreationDate,
+		Branches:     branches,
+	}
+}
+
+func (c *cataloger) CreateRepositorySnapshot(ctx context.Context, repository, snapshotID string) (*RepositorySnapshot, error) {
+	snapshot, err := c.EntryCatalog.CreateRepositorySnapshot(ctx, graveler.RepositoryID(repository), graveler.RepositorySnapshotID(snapshotID))
+	if err != nil {
+		return nil, err
+	}
+	return newRepositorySnapshot(snapshot), nil
+}
+
+func (c *cataloger) GetRepositorySnapshot(ctx context.Context, repository, snapshotID string) (*RepositorySnapshot, error) {
+	snapshot, err := c.EntryCatalog.GetRepositorySnapshot(ctx, graveler.RepositoryID(repository), graveler.RepositorySnapshotID(snapshotID))
+	if err != nil {
+		return nil, err
+	}
+	return newRepositorySnapshot(snapshot), nil
+}
+
+func (c *cataloger) ListRepositorySnapshots(ctx context.Context, repository string) ([]*RepositorySnapshot, error) {
+	snapshots, err := c.EntryCatalog.ListRepositorySnapshots(ctx, graveler.RepositoryID(repository))
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*RepositorySnapshot, len(snapshots))
+	for i, snapshot := range snapshots {
+		res[i] = newRepositorySnapshot(snapshot)
+	}
+	return res, nil
+}
+
+func (c *cataloger) DeleteRepositorySnapshot(ctx context.Context, repository, snapshotID string) error {
+	return c.EntryCatalog.DeleteRepositorySnapshot(ctx, graveler.RepositoryID(repository), graveler.RepositorySnapshotID(snapshotID))
+}
+
+func (c *cataloger) RestoreRepositorySnapshot(ctx context.Context, repository, snapshotID string) error {
+	return c.EntryCatalog.RestoreRepositorySnapshot(ctx, graveler.RepositoryID(repository), graveler.RepositorySnapshotID(snapshotID))
+}
@@ -0,0 +1,641 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/jobs"
+	"github.com/treeverse/lakefs/logging"
+)
+
+// RetentionRules define which commits of a repository are retained by garbage collection.
+// Branch heads are always retained, a commit is retained if any rule matches it.
+type RetentionRules struct {
+	// KeepLastCommits retains the last N commits reachable from each branch head
+	KeepLastCommits int `db:"keep_last_commits"`
+	// KeepNewerThanDays retains commits created in the last N days
+	KeepNewerThanDays int `db:"keep_newer_than_days"`
+	// KeepTagged retains commits pointed to by a tag
+	KeepTagged bool `db:"keep_tagged"`
+}
+
+type GarbageCollectionParams struct {
+	// DryRun reports what would be removed without removing it
+	DryRun bool
+}
+
+type GarbageCollectionResult struct {
+	// ExpiredCommits are the commits not retained by the retention rules
+	ExpiredCommits []string
+	// RemovedCommits are the expired commits not reachable from retained commits, their records
+	// are removed
+	RemovedCommits []string
+	// RemovedObjects are the physical addresses referenced only by expired commits
+	RemovedObjects []string
+}
+
+func validateRetentionRules(rules RetentionRules) error {
+	return Validate([]ValidateArg{
+		{"keep_last_commits", rules.KeepLastCommits, ValidateNonNegativeInt},
+		{"keep_newer_than_days", rules.KeepNewerThanDays, ValidateNonNegativeInt},
+	})
+}
+
+func (c *cataloger) SetRetentionRules(ctx context.Context, repository string, rules RetentionRules) error {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+	}); err != nil {
+		return err
+	}
+	if err := validateRetentionRules(rules); err != nil {
+		return err
+	}
+	if _, err := c.EntryCatalog.GetRepository(ctx, graveler.RepositoryID(repository)); err != nil {
+		return err
+	}
+	_, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		return tx.Exec(`INSERT INTO catalog_retention_rules (repository_id, keep_last_commits, keep_newer_than_days, keep_tagged, update_date)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (repository_id) DO UPDATE SET keep_last_commits = EXCLUDED.keep_last_commits,
+				keep_newer_than_days = EXCLUDED.keep_newer_than_days, keep_tagged = EXCLUDED.keep_tagged,
+				update_date = EXCLUDED.update_date`,
+			repository, rules.KeepLastCommits, rules.KeepNewerThanDays, rules.KeepTagged, time.Now().UTC())
+	}, db.WithContext(ctx))
+	return err
+}
+
+func (c *cataloger) GetRetentionRules(ctx context.Context, repository string) (*RetentionRules, error) {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+	}); err != nil {
+		return nil, err
+	}
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var rules RetentionRules
+		err := tx.Get(&rules, `SELECT keep_last_commits, keep_newer_than_days, keep_tagged
+			FROM catalog_retention_rules WHERE repository_id = $1`,
+			repository)
+		return &rules, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, ErrRetentionRulesNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res.(*RetentionRules), nil
+}
+
+func (c *cataloger) DeleteRetentionRules(ctx context.Context, repository string) error {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+	}); err != nil {
+		return err
+	}
+	_, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		res, err := tx.Exec(`DELETE FROM catalog_retention_rules WHERE repository_id = $1`, repository)
+		if err != nil {
+			return nil, err
+		}
+		if res.RowsAffected() == 0 {
+			return nil, ErrRetentionRulesNotFound
+		}
+		return nil, nil
+	}, db.WithContext(ctx))
+	return err
+}
+
+const (
+	// garbageCollectionBatchSize is the number of objects garbage collection removes between
+	// records of its progress
+	garbageCollectionBatchSize = 1000
+	// garbageCollectionDrainInterval is the wait between attempts to drain the writes to a
+	// branch being committed to
+	garbageCollectionDrainInterval = time.Second
+)
+
+// isCollectableAddress returns true if garbage collection may remove the object at address:
+// objects outside the storage namespace and generated objects are never removed
+func isCollectableAddress(address string) bool {
+	return address != "" && block.IsResolvableKey(address) && !IsGeneratedAddress(address)
+}
+
+// withRemovedObjectsCheck returns a context for branch writes of repository staging the
+// objects at addresses.  The writes fail with ErrObjectNotFound if garbage collection removed,
+// or is removing, one of the objects.
+func (c *cataloger) withRemovedObjectsCheck(ctx context.Context, repository string, addresses ...string) context.Context {
+	var collectable []string
+	for _, address := range addresses {
+		if isCollectableAddress(address) {
+			collectable = append(collectable, address)
+		}
+	}
+	if len(collectable) == 0 {
+		return ctx
+	}
+	return graveler.WithBranchWriteCheck(ctx, func() error {
+		var address string
+		err := c.db.WithContext(ctx).Get(&address, `SELECT coalesce(min(physical_address), '') FROM catalog_gc_removals
+			WHERE repository_id = $1 AND physical_address = ANY($2)`,
+			repository, collectable)
+		if err != nil {
+			return err
+		}
+		if address != "" {
+			return fmt.Errorf("%s removed by garbage collection: %w", address, ErrObjectNotFound)
+		}
+		return nil
+	})
+}
+
+// withGarbageCollectionCheck returns a context for branch writes of repository staging objects
+// of other refs.  The writes fail with ErrGarbageCollectionInProgress while garbage collection
+// removes objects of repository.
+func (c *cataloger) withGarbageCollectionCheck(ctx context.Context, repository string) context.Context {
+	return graveler.WithBranchWriteCheck(ctx, func() error {
+		var removing bool
+		err := c.db.WithContext(ctx).Get(&removing, `SELECT EXISTS (SELECT 1 FROM catalog_gc_removals
+			WHERE repository_id = $1 AND NOT removed)`,
+			repository)
+		if err != nil {
+			return err
+		}
+		if removing {
+			return ErrGarbageCollectionInProgress
+		}
+		return nil
+	})
+}
+
+// removedAddresses adds the physical addresses garbage collection removed from repository to
+// addresses
+func (c *cataloger) removedAddresses(ctx context.Context, repository string, addresses map[string]struct{}) error {
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var removed []string
+		err := tx.Select(&removed, `SELECT physical_address FROM catalog_gc_removals
+			WHERE repository_id = $1 AND removed`,
+			repository)
+		return removed, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	for _, address := range res.([]string) {
+		addresses[address] = struct{}{}
+	}
+	return nil
+}
+
+// markRemovals records that garbage collection is removing the objects at addresses from
+// repositories: entry writes staging them fail from now on
+func (c *cataloger) markRemovals(ctx context.Context, repositories []string, addresses []string) error {
+	now := time.Now().UTC()
+	for start := 0; start < len(addresses); start += garbageCollectionBatchSize {
+		end := start + garbageCollectionBatchSize
+		if end > len(addresses) {
+			end = len(addresses)
+		}
+		_, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+			return tx.Exec(`INSERT INTO catalog_gc_removals (repository_id, physical_address, creation_date)
+				SELECT r.repository_id, a.physical_address, $3
+				FROM unnest($1::text[]) AS r(repository_id) CROSS JOIN unnest($2::text[]) AS a(physical_address)
+				ON CONFLICT DO NOTHING`,
+				repositories, addresses[start:end], now)
+		}, db.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unmarkRemovals drops the records of objects at addresses garbage collection is no longer
+// removing from repositories
+func (c *cataloger) unmarkRemovals(ctx context.Context, repositories []string, addresses []string) error {
+	if len(addresses) == 0 {
+		return nil
+	}
+	_, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		return tx.Exec(`DELETE FROM catalog_gc_removals
+			WHERE repository_id = ANY($1) AND physical_address = ANY($2) AND NOT removed`,
+			repositories, addresses)
+	}, db.WithContext(ctx))
+	return err
+}
+
+// removeObjects removes the objects at addresses from storageNamespace in batches, recording
+// each batch as removed from repositories.  It returns the number of objects removed, also on
+// error.
+func (c *cataloger) removeObjects(ctx context.Context, storageNamespace string, repositories []string, addresses []string) (int, error) {
+	removed := 0
+	for removed < len(addresses) {
+		end := removed + garbageCollectionBatchSize
+		if end > len(addresses) {
+			end = len(addresses)
+		}
+		var err error
+		i := removed
+		for ; i < end; i++ {
+			if err = ctx.Err(); err != nil {
+				break
+			}
+			err = c.EntryCatalog.BlockAdapter.Remove(block.ObjectPointer{
+				StorageNamespace: storageNamespace,
+				Identifier:       addresses[i],
+			})
+			if err != nil {
+				err = fmt.Errorf("remove object %s: %w", addresses[i], err)
+				break
+			}
+		}
+		if i > removed {
+			_, recordErr := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+				return tx.Exec(`UPDATE catalog_gc_removals SET removed = true
+					WHERE repository_id = ANY($1) AND physical_address = ANY($2)`,
+					repositories, addresses[removed:i])
+			}, db.WithContext(context.Background()))
+			if recordErr != nil {
+				return removed, recordErr
+			}
+			removed = i
+		}
+		if err != nil {
+			return removed, err
+		}
+		jobs.SetProgress(ctx, float64(removed)*100/float64(len(addresses)))
+	}
+	return removed, nil
+}
+
+// drainBranchWrites waits for the writes in flight to branch to end, retrying while the
+// branch is committed to
+func (c *cataloger) drainBranchWrites(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID) error {
+	for {
+		err := c.EntryCatalog.LockBranch(ctx, repositoryID, branchID, func() error { return nil })
+		if !errors.Is(err, graveler.ErrAlreadyLocked) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(garbageCollectionDrainInterval):
+		}
+	}
+}
+
+// visitEntries calls visit with each entry of ref
+func (c *cataloger) visitEntries(ctx context.Context, repositoryID graveler.RepositoryID, ref graveler.Ref, visit func(*Entry)) error {
+	it, err := c.EntryCatalog.ListEntries(ctx, repositoryID, ref, "", "")
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	for it.Next() {
+		if v := it.Value(); v.Entry != nil {
+			visit(v.Entry)
+		}
+	}
+	return it.Err()
+}
+
+// collectAddresses adds the physical addresses of the entries in ref to addresses
+func (c *cataloger) collectAddresses(ctx context.Context, repositoryID graveler.RepositoryID, ref graveler.Ref, addresses map[string]struct{}) error {
+	return c.visitEntries(ctx, repositoryID, ref, func(ent *Entry) {
+		if ent.Address != "" {
+			addresses[ent.Address] = struct{}{}
+		}
+	})
+}
+
+// visitCommitsEntries calls visit with each entry of commitIDs, listing each MetaRange once
+func (c *cataloger) visitCommitsEntries(ctx context.Context, repositoryID graveler.RepositoryID, commitIDs []graveler.CommitID, visit func(*Entry)) error {
+	seen := make(map[graveler.MetaRangeID]struct{})
+	for _, commitID := range commitIDs {
+		commit, err := c.EntryCatalog.GetCommit(ctx, repositoryID, commitID)
+		if err != nil {
+			return err
+		}
+		if _, ok := seen[commit.MetaRangeID]; ok || commit.MetaRangeID == "" {
+			continue
+		}
+		seen[commit.MetaRangeID] = struct{}{}
+		if err := c.visitEntries(ctx, repositoryID, graveler.Ref(commitID), visit); err != nil {
+			return fmt.Errorf("commit %s: %w", commitID, err)
+		}
+	}
+	return nil
+}
+
+// collectCommitsAddresses adds the physical addresses of the entries of commitIDs to addresses,
+// listing each MetaRange once
+func (c *cataloger) collectCommitsAddresses(ctx context.Context, repositoryID graveler.RepositoryID, commitIDs []graveler.CommitID, addresses map[string]struct{}) error {
+	return c.visitCommitsEntries(ctx, repositoryID, commitIDs, func(ent *Entry) {
+		if ent.Address != "" {
+			addresses[ent.Address] = struct{}{}
+		}
+	})
+}
+
+// listCommitIDs returns the IDs of all commits of repositoryID
+func (c *cataloger) listCommitIDs(ctx context.Context, repositoryID graveler.RepositoryID) ([]graveler.CommitID, error) {
+	commitsIt, err := c.EntryCatalog.ListCommits(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer commitsIt.Close()
+	var commitIDs []graveler.CommitID
+	for commitsIt.Next() {
+		commitIDs = append(commitIDs, commitsIt.Value().CommitID)
+	}
+	return commitIDs, commitsIt.Err()
+}
+
+// sharedRepositories returns the other repositories using storageNamespace, such as forks of
+// repositoryID
+func (c *cataloger) sharedRepositories(ctx context.Context, repositoryID graveler.RepositoryID, storageNamespace graveler.StorageNamespace) ([]graveler.RepositoryID, error) {
+	reposIt, err := c.EntryCatalog.ListRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer reposIt.Close()
+	var shared []graveler.RepositoryID
+	for reposIt.Next() {
+		repo := reposIt.Value()
+		if repo.RepositoryID != repositoryID && repo.StorageNamespace == storageNamespace {
+			shared = append(shared, repo.RepositoryID)
+		}
+	}
+	return shared, reposIt.Err()
+}
+
+// collectSharedAddresses adds to addresses the physical addresses of all commits and branches of
+// the shared repositories
+func (c *cataloger) collectSharedAddresses(ctx context.Context, shared []graveler.RepositoryID, addresses map[string]struct{}) error {
+	for _, id := range shared {
+		commitIDs, err := c.listCommitIDs(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := c.collectCommitsAddresses(ctx, id, commitIDs, addresses); err != nil {
+			return fmt.Errorf("repository %s: %w", id, err)
+		}
+		if err := c.collectBranchesAddresses(ctx, id, addresses); err != nil {
+			return fmt.Errorf("repository %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// visitBranchesEntries calls visit with each entry of all branches, including staged entries
+func (c *cataloger) visitBranchesEntries(ctx context.Context, repositoryID graveler.RepositoryID, visit func(*Entry)) error {
+	branchesIt, err := c.EntryCatalog.ListBranches(ctx, repositoryID)
+	if err != nil {
+		return err
+	}
+	defer branchesIt.Close()
+	for branchesIt.Next() {
+		branchID := branchesIt.Value().BranchID
+		if err := c.visitEntries(ctx, repositoryID, graveler.Ref(branchID), visit); err != nil {
+			return fmt.Errorf("branch %s: %w", branchID, err)
+		}
+	}
+	return branchesIt.Err()
+}
+
+// collectBranchesAddresses adds the physical addresses of the entries of all branches, including
+// staged entries, to addresses
+func (c *cataloger) collectBranchesAddresses(ctx context.Context, repositoryID graveler.RepositoryID, addresses map[string]struct{}) error {
+	return c.visitBranchesEntries(ctx, repositoryID, func(ent *Entry) {
+		if ent.Address != "" {
+			addresses[ent.Address] = struct{}{}
+		}
+	})
+}
+
+// linkedCommits returns the commits of repositoryID that entries of other repositories link to,
+// on any of their commits or branches
+func (c *cataloger) linkedCommits(ctx context.Context, repositoryID graveler.RepositoryID) ([]graveler.CommitID, error) {
+	reposIt, err := c.EntryCatalog.ListRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var others []graveler.RepositoryID
+	for reposIt.Next() {
+		if id := reposIt.Value().RepositoryID; id != repositoryID {
+			others = append(others, id)
+		}
+	}
+	err = reposIt.Err()
+	reposIt.Close()
+	if err != nil {
+		return nil, err
+	}
+	linked := make(map[graveler.CommitID]struct{})
+	visit := func(ent *Entry) {
+		if ent.LinkRepository == repositoryID.String() && ent.LinkRef != "" {
+			linked[graveler.CommitID(ent.LinkRef)] = struct{}{}
+		}
+	}
+	for _, id := range others {
+		commitIDs, err := c.listCommitIDs(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.visitCommitsEntries(ctx, id, commitIDs, visit); err != nil {
+			return nil, fmt.Errorf("repository %s: %w", id, err)
+		}
+		if err := c.visitBranchesEntries(ctx, id, visit); err != nil {
+			return nil, fmt.Errorf("repository %s: %w", id, err)
+		}
+	}
+	res := make([]graveler.CommitID, 0, len(linked))
+	for commitID := range linked {
+		res = append(res, commitID)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	return res, nil
+}
+
+// collectDrainedBranchesAddresses adds the physical addresses of the entries of all branches to
+// addresses, like collectBranchesAddresses, listing each branch once the writes in flight to it
+// end
+func (c *cataloger) collectDrainedBranchesAddresses(ctx context.Context, repositoryID graveler.RepositoryID, addresses map[string]struct{}) error {
+	branchesIt, err := c.EntryCatalog.ListBranches(ctx, repositoryID)
+	if err != nil {
+		return err
+	}
+	var branchIDs []graveler.BranchID
+	for branchesIt.Next() {
+		branchIDs = append(branchIDs, branchesIt.Value().BranchID)
+	}
+	err = branchesIt.Err()
+	branchesIt.Close()
+	if err != nil {
+		return err
+	}
+	for _, branchID := range branchIDs {
+		if err := c.drainBranchWrites(ctx, repositoryID, branchID); err != nil {
+			return fmt.Errorf("branch %s: %w", branchID, err)
+		}
+		err := c.collectAddresses(ctx, repositoryID, graveler.Ref(branchID), addresses)
+		if errors.Is(err, graveler.ErrNotFound) {
+			// deleted since listed
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("branch %s: %w", branchID, err)
+		}
+	}
+	return nil
+}
+
+// GarbageCollect applies the retention rules of repository: objects referenced only by expired
+// commits are removed, and so are the records of expired commits that are no longer reachable
+// from retained commits or tags.  Commits other repositories link to are retained like branch
+// heads, and so are the commits of branch log entries within the retention rules; the log
+// entries of expired commits are dropped.  Objects staged on any branch are kept, and so are
+// objects outside the repository storage namespace and objects referenced by other repositories
+// sharing the storage namespace.  Entry writes staging an object being removed fail with
+// ErrObjectNotFound, other writes proceed while objects are removed.
+func (c *cataloger) GarbageCollect(ctx context.Context, repository string, params GarbageCollectionParams) (*GarbageCollectionResult, error) {
+	rules, err := c.GetRetentionRules(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	repositoryID := graveler.RepositoryID(repository)
+	repo, err := c.EntryCatalog.GetRepository(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	// links of other repositories read their target commits
+	linked, err := c.linkedCommits(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	expired, err := c.EntryCatalog.ExpiredCommits(ctx, repositoryID, graveler.RetentionRules{
+		KeepLastCommits:   rules.KeepLastCommits,
+		KeepNewerThanDays: rules.KeepNewerThanDays,
+		KeepTagged:        rules.KeepTagged,
+	}, time.Now(), linked)
+	if err != nil {
+		return nil, err
+	}
+	jobs.Logf(ctx, "Found %d expired commits, %d unreachable", len(expired.Expired), len(expired.Unreachable))
+	result := &GarbageCollectionResult{}
+	for _, commitID := range expired.Expired {
+		result.ExpiredCommits = append(result.ExpiredCommits, commitID.String())
+	}
+	for _, commitID := range expired.Unreachable {
+		result.RemovedCommits = append(result.RemovedCommits, commitID.String())
+	}
+	if len(expired.Expired) == 0 {
+		return result, nil
+	}
+
+	// addresses still in use: retained commits and branches, which include staged entries
+	active := make(map[string]struct{})
+	if err := c.collectCommitsAddresses(ctx, repositoryID, expired.Retained, active); err != nil {
+		return nil, err
+	}
+	if err := c.collectBranchesAddresses(ctx, repositoryID, active); err != nil {
+		return nil, err
+	}
+	// forks share the storage namespace, and so objects, of their source
+	shared, err := c.sharedRepositories(ctx, repositoryID, repo.StorageNamespace)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.collectSharedAddresses(ctx, shared, active); err != nil {
+		return nil, err
+	}
+	// redacted objects and objects removed by earlier runs were already removed
+	if err := c.redactedAddresses(ctx, repository, active); err != nil {
+		return nil, err
+	}
+	if err := c.removedAddresses(ctx, repository, active); err != nil {
+		return nil, err
+	}
+
+	candidates := make(map[string]struct{})
+	if err := c.collectCommitsAddresses(ctx, repositoryID, expired.Expired, candidates); err != nil {
+		return nil, err
+	}
+	for address := range candidates {
+		if _, ok := active[address]; ok || !isCollectableAddress(address) {
+			continue
+		}
+		result.RemovedObjects = append(result.RemovedObjects, address)
+	}
+	sort.Strings(result.RemovedObjects)
+	jobs.Logf(ctx, "Found %d objects to remove", len(result.RemovedObjects))
+	if params.DryRun {
+		return result, nil
+	}
+
+	// "branch@{n}" no longer reads expired commits once their objects are removed
+	if err := c.EntryCatalog.DeleteBranchLogCommits(ctx, repositoryID, expired.Expired); err != nil {
+		return nil, err
+	}
+	log := c.log.WithField("repository", repository)
+	repositories := []string{repository}
+	for _, id := range shared {
+		repositories = append(repositories, id.String())
+	}
+	// restore, link by digest and copy may have staged a candidate since the scan.  Fail new
+	// writes staging the candidates, wait for the writes in flight to each branch and keep the
+	// addresses now on the branches.
+	if err := c.markRemovals(ctx, repositories, result.RemovedObjects); err != nil {
+		return nil, err
+	}
+	removals := result.RemovedObjects
+	removed := 0
+	defer func() {
+		if removed == len(removals) {
+			return
+		}
+		// let entry writes stage the objects left again
+		if err := c.unmarkRemovals(context.Background(), repositories, removals[removed:]); err != nil {
+			log.WithError(err).Error("Failed to drop garbage collection removals")
+		}
+	}()
+	staged := make(map[string]struct{})
+	for _, id := range repositories {
+		if err := c.collectDrainedBranchesAddresses(ctx, graveler.RepositoryID(id), staged); err != nil {
+			return nil, fmt.Errorf("repository %s: %w", id, err)
+		}
+	}
+	unstaged := make([]string, 0, len(removals))
+	var kept []string
+	for _, address := range removals {
+		if _, ok := staged[address]; ok {
+			kept = append(kept, address)
+		} else {
+			unstaged = append(unstaged, address)
+		}
+	}
+	if err := c.unmarkRemovals(ctx, repositories, kept); err != nil {
+		return nil, err
+	}
+	removals = unstaged
+	removed, err = c.removeObjects(ctx, repo.StorageNamespace.String(), repositories, removals)
+	if err != nil {
+		return nil, err
+	}
+	result.RemovedObjects = removals
+	jobs.Logf(ctx, "Removed %d objects", len(result.RemovedObjects))
+	if err := c.EntryCatalog.DeleteCommits(ctx, repositoryID, expired.Unreachable); err != nil {
+		return nil, err
+	}
+	log.WithFields(logging.Fields{
+		"expired_commits": len(result.ExpiredCommits),
+		"removed_commits": len(result.RemovedCommits),
+		"removed_objects": len(result.RemovedObjects),
+	}).Info("Garbage collection done")
+	return result, nil
+}
@@ -0,0 +1,224 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/testutil"
+)
+
+const retentionTestNamespace = "mem://retention"
+
+// setupRetentionTest returns a cataloger with repository "repo" holding two commits on main:
+// the first stages "data" at address "old" and "live" at address "live", the second overwrites
+// "data" with address "new".  Only the second commit is retained.
+func setupRetentionTest(t *testing.T) (*cataloger, *CommitLog) {
+	t.Helper()
+	ctx := context.Background()
+	conn, _ := testutil.GetDB(t, databaseURI)
+	c := testGravelerCataloger(t, conn)
+	_, err := c.CreateRepository(ctx, "repo", retentionTestNamespace, "main")
+	testutil.MustDo(t, "create repository", err)
+	write := func(path, address string) {
+		data := []byte(address)
+		err := c.EntryCatalog.BlockAdapter.Put(block.ObjectPointer{StorageNamespace: retentionTestNamespace, Identifier: address},
+			int64(len(data)), bytes.NewReader(data), block.PutOpts{})
+		testutil.MustDo(t, "put "+address, err)
+		err = c.CreateEntry(ctx, "repo", "main", DBEntry{Path: path, PhysicalAddress: address, Size: int64(len(data)), Checksum: address})
+		testutil.MustDo(t, "create entry "+path, err)
+	}
+	write("data", "old")
+	write("live", "live")
+	first, err := c.Commit(ctx, "repo", "main", "first", "tester", nil)
+	testutil.MustDo(t, "first commit", err)
+	write("data", "new")
+	_, err = c.Commit(ctx, "repo", "main", "second", "tester", nil)
+	testutil.MustDo(t, "second commit", err)
+	err = c.SetRetentionRules(ctx, "repo", RetentionRules{KeepLastCommits: 1})
+	testutil.MustDo(t, "set retention rules", err)
+	return c, first
+}
+
+func (c *cataloger) testObjectExists(t *testing.T, address string) bool {
+	t.Helper()
+	exists, err := c.EntryCatalog.BlockAdapter.Exists(block.ObjectPointer{StorageNamespace: retentionTestNamespace, Identifier: address})
+	testutil.MustDo(t, "exists "+address, err)
+	return exists
+}
+
+func TestCataloger_GarbageCollect(t *testing.T) {
+	ctx := context.Background()
+	c, first := setupRetentionTest(t)
+
+	res, err := c.GarbageCollect(ctx, "repo", GarbageCollectionParams{DryRun: true})
+	testutil.MustDo(t, "dry run", err)
+	if diff := deep.Equal(res.RemovedObjects, []string{"old"}); diff != nil {
+		t.Errorf("dry run removed objects diff %s", diff)
+	}
+	if !c.testObjectExists(t, "old") {
+		t.Error("dry run removed object old")
+	}
+
+	res, err = c.GarbageCollect(ctx, "repo", GarbageCollectionParams{})
+	testutil.MustDo(t, "garbage collect", err)
+	if diff := deep.Equal(res.ExpiredCommits, []string{first.Reference}); diff != nil {
+		t.Errorf("expired commits diff %s", diff)
+	}
+	if diff := deep.Equal(res.RemovedObjects, []string{"old"}); diff != nil {
+		t.Errorf("removed objects diff %s", diff)
+	}
+	if c.testObjectExists(t, "old") {
+		t.Error("expired object old not removed")
+	}
+	for _, address := range []string{"live", "new"} {
+		if !c.testObjectExists(t, address) {
+			t.Errorf("live object %s removed", address)
+		}
+	}
+
+	// the removed object may not be staged again
+	_, err = c.RestoreEntry(ctx, "repo", "main", "data", first.Reference)
+	if !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("RestoreEntry of removed object: %v, expected %s", err, ErrObjectNotFound)
+	}
+	err = c.CreateEntry(ctx, "repo", "main", DBEntry{Path: "copy", PhysicalAddress: "old", Checksum: "old"})
+	if !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("CreateEntry of removed object: %v, expected %s", err, ErrObjectNotFound)
+	}
+
+	res, err = c.GarbageCollect(ctx, "repo", GarbageCollectionParams{})
+	testutil.MustDo(t, "garbage collect again", err)
+	if len(res.RemovedObjects) != 0 {
+		t.Errorf("garbage collection again removed %s", res.RemovedObjects)
+	}
+}
+
+func TestCataloger_GarbageCollect_KeepsStaged(t *testing.T) {
+	ctx := context.Background()
+	c, _ := setupRetentionTest(t)
+
+	err := c.CreateEntry(ctx, "repo", "main", DBEntry{Path: "copy", PhysicalAddress: "old", Checksum: "old"})
+	testutil.MustDo(t, "stage copy of old", err)
+
+	res, err := c.GarbageCollect(ctx, "repo", GarbageCollectionParams{})
+	testutil.MustDo(t, "garbage collect", err)
+	if len(res.RemovedObjects) != 0 {
+		t.Errorf("removed staged objects %s", res.RemovedObjects)
+	}
+	if !c.testObjectExists(t, "old") {
+		t.Error("staged object old removed")
+	}
+}
+
+func TestCataloger_GarbageCollect_RemovalInProgress(t *testing.T) {
+	ctx := context.Background()
+	c, first := setupRetentionTest(t)
+
+	// writes between marking the removals and removing the objects
+	testutil.MustDo(t, "mark removals", c.markRemovals(ctx, []string{"repo"}, []string{"old"}))
+	_, err := c.RestoreEntry(ctx, "repo", "main", "data", first.Reference)
+	if !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("RestoreEntry of object being removed: %v, expected %s", err, ErrObjectNotFound)
+	}
+	_, err = c.ReplacePrefix(ctx, "repo", "main", ReplacePrefixParams{Prefix: "data", SourceRef: first.Reference, Committer: "tester"})
+	if !errors.Is(err, ErrGarbageCollectionInProgress) {
+		t.Errorf("ReplacePrefix from expired commit: %v, expected %s", err, ErrGarbageCollectionInProgress)
+	}
+	err = c.CreateEntry(ctx, "repo", "main", DBEntry{Path: "other", PhysicalAddress: "other", Checksum: "other"})
+	testutil.MustDo(t, "create unrelated entry", err)
+
+	testutil.MustDo(t, "unmark removals", c.unmarkRemovals(ctx, []string{"repo"}, []string{"old"}))
+	_, err = c.RestoreEntry(ctx, "repo", "main", "data", first.Reference)
+	testutil.MustDo(t, "restore after removal dropped", err)
+}
+
+func TestCataloger_GarbageCollect_RacingWrite(t *testing.T) {
+	ctx := context.Background()
+	c, first := setupRetentionTest(t)
+
+	restored := make(chan error, 1)
+	go func() {
+		_, err := c.RestoreEntry(ctx, "repo", "main", "data", first.Reference)
+		restored <- err
+	}()
+	res, err := c.GarbageCollect(ctx, "repo", GarbageCollectionParams{})
+	testutil.MustDo(t, "garbage collect", err)
+	restoreErr := <-restored
+
+	// either the restore staged the object before garbage collection found it on main, or the
+	// object is removed and the restore failed
+	exists := c.testObjectExists(t, "old")
+	switch {
+	case restoreErr == nil:
+		if !exists || len(res.RemovedObjects) != 0 {
+			t.Errorf("restored object old removed (removed objects %s)", res.RemovedObjects)
+		}
+	case errors.Is(restoreErr, ErrObjectNotFound):
+		if exists {
+			t.Error("restore failed but object old not removed")
+		}
+	default:
+		t.Fatalf("RestoreEntry: %s", restoreErr)
+	}
+}
+
+func TestCataloger_GarbageCollect_KeepsLinked(t *testing.T) {
+	ctx := context.Background()
+	c, first := setupRetentionTest(t)
+
+	_, err := c.CreateRepository(ctx, "other", "mem://retention-other", "main")
+	testutil.MustDo(t, "create other repository", err)
+	err = c.CreateEntry(ctx, "other", "main", DBEntry{Path: "linked", LinkRepository: "repo", LinkRef: first.Reference, LinkTarget: "data"})
+	testutil.MustDo(t, "create cross repository link", err)
+
+	res, err := c.GarbageCollect(ctx, "repo", GarbageCollectionParams{})
+	testutil.MustDo(t, "garbage collect", err)
+	if len(res.ExpiredCommits) != 0 || len(res.RemovedObjects) != 0 {
+		t.Errorf("expired commits %s removed objects %s, expected the linked commit retained", res.ExpiredCommits, res.RemovedObjects)
+	}
+	if !c.testObjectExists(t, "old") {
+		t.Error("linked object old removed")
+	}
+	if _, err := c.GetCommit(ctx, "repo", first.Reference); err != nil {
+		t.Errorf("linked commit: %s", err)
+	}
+}
+
+func TestCataloger_GarbageCollect_BranchLog(t *testing.T) {
+	ctx := context.Background()
+	c, first := setupRetentionTest(t)
+	second, err := c.GetCommit(ctx, "repo", "main")
+	testutil.MustDo(t, "get second commit", err)
+
+	// the second commit is no longer reachable from main, only through its log
+	testutil.MustDo(t, "rollback", c.RollbackCommit(ctx, "repo", "main", first.Reference))
+	err = c.SetRetentionRules(ctx, "repo", RetentionRules{KeepLastCommits: 2})
+	testutil.MustDo(t, "set retention rules", err)
+	res, err := c.GarbageCollect(ctx, "repo", GarbageCollectionParams{})
+	testutil.MustDo(t, "garbage collect", err)
+	if len(res.ExpiredCommits) != 0 || len(res.RemovedObjects) != 0 {
+		t.Errorf("expired commits %s removed objects %s, expected the logged commit retained", res.ExpiredCommits, res.RemovedObjects)
+	}
+	logged, err := c.GetCommit(ctx, "repo", "main@{1}")
+	testutil.MustDo(t, "get main@{1}", err)
+	if logged.Reference != second.Reference {
+		t.Errorf("main@{1} is %s, expected %s", logged.Reference, second.Reference)
+	}
+
+	// once its log entry expires the commit is collected, and no longer read through the log
+	err = c.SetRetentionRules(ctx, "repo", RetentionRules{KeepLastCommits: 1})
+	testutil.MustDo(t, "set retention rules", err)
+	res, err = c.GarbageCollect(ctx, "repo", GarbageCollectionParams{})
+	testutil.MustDo(t, "garbage collect", err)
+	if diff := deep.Equal(res.RemovedObjects, []string{"new"}); diff != nil {
+		t.Errorf("removed objects diff %s", diff)
+	}
+	logged, err = c.GetCommit(ctx, "repo", "main@{1}")
+	if err == nil && logged.Reference == second.Reference {
+		t.Errorf("main@{1} is the expired commit %s", second.Reference)
+	}
+}
@@ -5,15 +5,28 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/cache"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/events"
 	"github.com/treeverse/lakefs/graveler"
 	"github.com/treeverse/lakefs/logging"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type cataloger struct {
-	EntryCatalog *EntryCatalog
-	log          logging.Logger
+	EntryCatalog   *EntryCatalog
+	db             db.Database
+	log            logging.Logger
+	events         *events.Bus
+	redactions     *cache.GetSetCache
+	lineageMu      sync.Mutex
+	lineagePending []pendingLineage
+	// replica is set when the metadata store is a read replica, which cannot pin listing snapshots
+	replica bool
 }
 
 const (
@@ -22,6 +35,8 @@ const (
 	ListTagsLimitMax         = 1000
 	DiffLimitMax             = 1000
 	ListEntriesLimitMax      = 10000
+	CommitsBetweenLimitMax   = 1000
+	FindCommitsLimitMax      = 1000
 )
 
 var ErrUnknownDiffType = errors.New("unknown graveler difference type")
@@ -33,7 +48,11 @@ func NewCataloger(cfg Config) (Cataloger, error) {
 	}
 	return &cataloger{
 		EntryCatalog: entryCatalog,
+		db:           cfg.DB,
 		log:          logging.Default(),
+		events:       cfg.Events,
+		redactions:   newRedactionsCache(),
+		replica:      cfg.Config.GetDatabaseParams().Replica,
 	}, nil
 }
 
@@ -73,6 +92,23 @@ func (c *cataloger) CreateBareRepository(ctx context.Context, repository string,
 	return catalogRepo, nil
 }
 
+// ForkRepository creates repository as a copy of the refs of source, sharing its storage namespace.
+// Branches of the fork start with an empty staging area.
+func (c *cataloger) ForkRepository(ctx context.Context, source string, repository string) (*Repository, error) {
+	repositoryID := graveler.RepositoryID(repository)
+	repo, err := c.EntryCatalog.ForkRepository(ctx, graveler.RepositoryID(source), repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	catalogRepo := &Repository{
+		Name:             repositoryID.String(),
+		StorageNamespace: repo.StorageNamespace.String(),
+		DefaultBranch:    repo.DefaultBranchID.String(),
+		CreationDate:     repo.CreationDate,
+	}
+	return catalogRepo, nil
+}
+
 // GetRepository get repository information
 func (c *cataloger) GetRepository(ctx context.Context, repository string) (*Repository, error) {
 	repositoryID := graveler.RepositoryID(repository)
@@ -89,10 +125,9 @@ func (c *cataloger) GetRepository(ctx context.Context, repository string) (*Repo
 	return catalogRepository, nil
 }
 
-// DeleteRepository delete a repository
+// DeleteRepository delete a repository, together with the records kept for it
 func (c *cataloger) DeleteRepository(ctx context.Context, repository string) error {
-	repositoryID := graveler.RepositoryID(repository)
-	return c.EntryCatalog.DeleteRepository(ctx, repositoryID)
+	return c.EntryCatalog.DeleteRepository(ctx, graveler.RepositoryID(repository))
 }
 
 // ListRepositories list repositories information, the bool returned is true when more repositories can be listed.
@@ -160,6 +195,7 @@ func (c *cataloger) CreateBranch(ctx context.Context, repository string, branch
 		Message:   commit.Message,
 		Metadata:  Metadata(commit.Metadata),
 	}
+	setCommitLogSignatures(catalogCommitLog, commit)
 	for _, parent := range commit.Parents {
 		catalogCommitLog.Parents = append(catalogCommitLog.Parents, string(parent))
 	}
@@ -246,6 +282,13 @@ func (c *cataloger) GetBranchReference(ctx context.Context, repository string, b
 func (c *cataloger) ResetBranch(ctx context.Context, repository string, branch string) error {
 	repositoryID := graveler.RepositoryID(repository)
 	branchID := graveler.BranchID(branch)
+	// reset discards the uncommitted changes of leased paths too
+	err := c.checkLeasedChanges(ctx, repository, branch, func() (EntryDiffIterator, error) {
+		return c.EntryCatalog.DiffUncommitted(ctx, repositoryID, branchID, graveler.DiffTypeMaskAll)
+	})
+	if err != nil {
+		return err
+	}
 	return c.EntryCatalog.Reset(ctx, repositoryID, branchID)
 }
 
@@ -318,13 +361,52 @@ func (c *cataloger) GetTag(ctx context.Context, repository string, tagID string)
 	return commit.String(), nil
 }
 
+func (c *cataloger) IsImmutableRef(ctx context.Context, repository string, reference string) (bool, error) {
+	repositoryID := graveler.RepositoryID(repository)
+	commitID, err := c.EntryCatalog.Dereference(ctx, repositoryID, graveler.Ref(reference))
+	if err != nil {
+		return false, err
+	}
+	if commitID.String() == reference {
+		return true, nil
+	}
+	// references resolve to branches before tags of the same name
+	if ValidateBranchID(graveler.BranchID(reference)) == nil {
+		_, err := c.EntryCatalog.GetBranch(ctx, repositoryID, graveler.BranchID(reference))
+		if err == nil {
+			return false, nil
+		}
+		if !errors.Is(err, graveler.ErrNotFound) {
+			return false, err
+		}
+	}
+	if ValidateTagID(graveler.TagID(reference)) != nil {
+		return false, nil
+	}
+	tagCommitID, err := c.EntryCatalog.GetTag(ctx, repositoryID, graveler.TagID(reference))
+	if errors.Is(err, graveler.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	// a tag name that is also a commit ID prefix resolves to the commit
+	return *tagCommitID == commitID, nil
+}
+
 // GetEntry returns the current entry for path in repository branch reference.  Returns
 // the entry with ExpiredError if it has expired from underlying storage.
-func (c *cataloger) GetEntry(ctx context.Context, repository string, reference string, path string, _ GetEntryParams) (*DBEntry, error) {
+func (c *cataloger) GetEntry(ctx context.Context, repository string, reference string, path string, params GetEntryParams) (*DBEntry, error) {
 	repositoryID := graveler.RepositoryID(repository)
 	ref := graveler.Ref(reference)
 	p := Path(path)
-	ent, err := c.EntryCatalog.GetEntry(ctx, repositoryID, ref, p)
+	var ent *Entry
+	var err error
+	if params.NoFollowLinks {
+		ent, err = c.EntryCatalog.GetEntry(ctx, repositoryID, ref, p)
+	} else {
+		ent, err = c.resolveEntry(ctx, repositoryID, ref, p, params.AuthorizeLink)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -332,13 +414,101 @@ func (c *cataloger) GetEntry(ctx context.Context, repository string, reference s
 	return &catalogEntry, nil
 }
 
+// GetEntries returns the entries of paths on reference like GetEntry, reading them together.
+// Entries are returned in the order of paths, nil for paths that do not exist.
+func (c *cataloger) GetEntries(ctx context.Context, repository string, reference string, paths []string, params GetEntryParams) ([]*DBEntry, error) {
+	repositoryID := graveler.RepositoryID(repository)
+	ref := graveler.Ref(reference)
+	entryPaths := make([]Path, len(paths))
+	for i, path := range paths {
+		entryPaths[i] = Path(path)
+	}
+	ents, err := c.EntryCatalog.GetEntries(ctx, repositoryID, ref, entryPaths)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*DBEntry, len(paths))
+	for i, ent := range ents {
+		if !params.NoFollowLinks && (ent == nil || ent.LinkTarget != "") {
+			// resolve links, including links of parent directories of missing paths, one by one
+			ent, err = c.resolveEntry(ctx, repositoryID, ref, entryPaths[i], params.AuthorizeLink)
+			if errors.Is(err, graveler.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", paths[i], err)
+			}
+		}
+		if ent == nil {
+			continue
+		}
+		catalogEntry := newCatalogEntryFromEntry(false, paths[i], ent)
+		entries[i] = &catalogEntry
+	}
+	return entries, nil
+}
+
+// resolveEntry resolves path, following links to other repositories after authorizing them.
+// Paths links resolve to within a repository are authorized too when authorizeLink is set, as
+// the reader of a link may not be allowed to read its target.  The address of an entry read from
+// another repository is qualified with that repository's storage namespace.
+func (c *cataloger) resolveEntry(ctx context.Context, repositoryID graveler.RepositoryID, ref graveler.Ref, path Path, authorizeLink func(repository, path string) error) (*Entry, error) {
+	resolved, ent, err := c.EntryCatalog.ResolveEntry(ctx, repositoryID, ref, path)
+	if err != nil {
+		return nil, err
+	}
+	if resolved != path && authorizeLink != nil {
+		if err := authorizeLink(repositoryID.String(), resolved.String()); err != nil {
+			return nil, err
+		}
+	}
+	for depth := 0; ent.LinkRepository != ""; depth++ {
+		if depth >= MaxLinkDepth {
+			return nil, ErrLinkLoop
+		}
+		if authorizeLink == nil {
+			return nil, ErrCrossRepositoryLink
+		}
+		if err := authorizeLink(ent.LinkRepository, ent.LinkTarget); err != nil {
+			return nil, err
+		}
+		linkRepositoryID := graveler.RepositoryID(ent.LinkRepository)
+		repo, err := c.EntryCatalog.GetRepository(ctx, linkRepositoryID)
+		if err != nil {
+			return nil, err
+		}
+		target := Path(ent.LinkTarget)
+		resolved, ent, err = c.EntryCatalog.ResolveEntry(ctx, linkRepositoryID, graveler.Ref(ent.LinkRef), target)
+		if err != nil {
+			return nil, err
+		}
+		if resolved != target {
+			if err := authorizeLink(linkRepositoryID.String(), resolved.String()); err != nil {
+				return nil, err
+			}
+		}
+		if ent.LinkRepository == "" {
+			qk, err := block.ResolveNamespace(repo.StorageNamespace.String(), ent.Address)
+			if err != nil {
+				return nil, err
+			}
+			ent.Address = qk.Format()
+		}
+	}
+	return ent, nil
+}
+
 func EntryFromCatalogEntry(entry DBEntry) *Entry {
 	return &Entry{
-		Address:      entry.PhysicalAddress,
-		Metadata:     entry.Metadata,
-		LastModified: timestamppb.New(entry.CreationDate),
-		ETag:         entry.Checksum,
-		Size:         entry.Size,
+		Address:         entry.PhysicalAddress,
+		Metadata:        entry.Metadata,
+		LastModified:    timestamppb.New(entry.CreationDate),
+		ETag:            entry.Checksum,
+		Size:            entry.Size,
+		DirectoryMarker: entry.DirectoryMarker,
+		LinkTarget:      entry.LinkTarget,
+		LinkRepository:  entry.LinkRepository,
+		LinkRef:         entry.LinkRef,
 	}
 }
 
@@ -346,26 +516,50 @@ func (c *cataloger) CreateEntry(ctx context.Context, repository string, branch s
 	repositoryID := graveler.RepositoryID(repository)
 	branchID := graveler.BranchID(branch)
 	ent := EntryFromCatalogEntry(entry)
-	return c.EntryCatalog.SetEntry(ctx, repositoryID, branchID, Path(entry.Path), ent)
+	ctx = c.withRemovedObjectsCheck(ctx, repository, entry.PhysicalAddress)
+	return c.writeLeasedPaths(ctx, repository, branch, []string{entry.Path}, false, func() error {
+		return c.EntryCatalog.SetEntry(ctx, repositoryID, branchID, Path(entry.Path), ent)
+	})
+}
+
+func (c *cataloger) CreateEntryIf(ctx context.Context, repository string, branch string, entry DBEntry, condition EntryCondition) error {
+	repositoryID := graveler.RepositoryID(repository)
+	branchID := graveler.BranchID(branch)
+	ent := EntryFromCatalogEntry(entry)
+	ctx = c.withRemovedObjectsCheck(ctx, repository, entry.PhysicalAddress)
+	return c.writeLeasedPaths(ctx, repository, branch, []string{entry.Path}, false, func() error {
+		return c.EntryCatalog.SetEntryIf(ctx, repositoryID, branchID, Path(entry.Path), ent, condition)
+	})
 }
 
 func (c *cataloger) CreateEntries(ctx context.Context, repository string, branch string, entries []DBEntry) error {
 	repositoryID := graveler.RepositoryID(repository)
 	branchID := graveler.BranchID(branch)
-	for _, entry := range entries {
-		ent := EntryFromCatalogEntry(entry)
-		if err := c.EntryCatalog.SetEntry(ctx, repositoryID, branchID, Path(entry.Path), ent); err != nil {
-			return err
+	paths := make([]string, len(entries))
+	addresses := make([]string, len(entries))
+	for i, entry := range entries {
+		paths[i] = entry.Path
+		addresses[i] = entry.PhysicalAddress
+	}
+	ctx = c.withRemovedObjectsCheck(ctx, repository, addresses...)
+	return c.writeLeasedPaths(ctx, repository, branch, paths, false, func() error {
+		for _, entry := range entries {
+			ent := EntryFromCatalogEntry(entry)
+			if err := c.EntryCatalog.SetEntry(ctx, repositoryID, branchID, Path(entry.Path), ent); err != nil {
+				return err
+			}
 		}
-	}
-	return nil
+		return nil
+	})
 }
 
 func (c *cataloger) DeleteEntry(ctx context.Context, repository string, branch string, path string) error {
 	repositoryID := graveler.RepositoryID(repository)
 	branchID := graveler.BranchID(branch)
 	p := Path(path)
-	return c.EntryCatalog.DeleteEntry(ctx, repositoryID, branchID, p)
+	return c.writeLeasedPaths(ctx, repository, branch, []string{path}, false, func() error {
+		return c.EntryCatalog.DeleteEntry(ctx, repositoryID, branchID, p)
+	})
 }
 
 func (c *cataloger) ListEntries(ctx context.Context, repository string, reference string, prefix string, after string, delimiter string, limit int) ([]*DBEntry, bool, error) {
@@ -380,6 +574,100 @@ func (c *cataloger) ListEntries(ctx context.Context, repository string, referenc
 	if err != nil {
 		return nil, false, err
 	}
+	defer it.Close()
+	return listEntriesHelper(it, afterPath, limit)
+}
+
+// ListEntriesWithToken lists entries like ListEntries, paginating with an opaque continuation token
+// instead of a path.  The token pins the commit (and a copy of the staging area) the first page
+// was read from, the staging area is copied only when the listing continues past the first page.
+// Pass an empty token to start listing, continue with the returned token until it is empty.
+// A token that cannot be decoded is treated as an 'after' path for compatibility.
+func (c *cataloger) ListEntriesWithToken(ctx context.Context, repository string, reference string, prefix string, token string, delimiter string, limit int) ([]*DBEntry, string, error) {
+	// normalize limit, an empty page cannot be continued
+	if limit <= 0 || limit > ListEntriesLimitMax {
+		limit = ListEntriesLimitMax
+	}
+	repositoryID := graveler.RepositoryID(repository)
+	var continuation *listingContinuationToken
+	if token != "" {
+		continuation, _ = decodeContinuationToken(token)
+		if continuation != nil && continuation.Ref != reference {
+			return nil, "", fmt.Errorf("%w: reference mismatch", ErrInvalidContinuationToken)
+		}
+	}
+	var snapshot *graveler.ListingSnapshot
+	after := token
+	if continuation != nil {
+		after = continuation.After
+	}
+	if continuation != nil && !continuation.Live {
+		s := continuation.snapshot()
+		snapshot = &s
+	} else {
+		var err error
+		snapshot, err = c.EntryCatalog.Snapshot(ctx, repositoryID, graveler.Ref(reference))
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	it, err := c.EntryCatalog.ListEntriesSnapshot(ctx, repositoryID, *snapshot, Path(prefix), Path(delimiter))
+	if err != nil {
+		return nil, "", err
+	}
+	defer it.Close()
+	entries, hasMore, err := listEntriesHelper(it, Path(after), limit)
+	if err != nil || !hasMore {
+		return entries, "", err
+	}
+	next := &listingContinuationToken{
+		Ref:   reference,
+		After: entries[len(entries)-1].Path,
+	}
+	if c.replica && snapshot.Live {
+		// a replica cannot copy the staging area, later pages list the branch as it is then
+		next.Live = true
+	} else {
+		// writes staged while the first page was read may show on later pages, past its last path
+		if err := c.EntryCatalog.PinSnapshot(ctx, repositoryID, snapshot); err != nil {
+			return nil, "", err
+		}
+		next.CommitID = snapshot.CommitID.String()
+		next.StagingToken = string(snapshot.StagingToken)
+	}
+	nextToken, err := encodeContinuationToken(next)
+	if err != nil {
+		return nil, "", err
+	}
+	return entries, nextToken, nil
+}
+
+func (c *cataloger) DropExpiredListingSnapshots(ctx context.Context) (int, error) {
+	return c.EntryCatalog.DropExpiredSnapshots(ctx)
+}
+
+// RunListingSnapshotSweeper drops expired listing snapshots every interval until ctx is done
+func RunListingSnapshotSweeper(ctx context.Context, c Cataloger, interval time.Duration) {
+	log := logging.FromContext(ctx).WithField("service", "listing_snapshot_sweeper")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			dropped, err := c.DropExpiredListingSnapshots(ctx)
+			if err != nil {
+				log.WithError(err).Error("Failed to drop expired listing snapshots")
+			}
+			if dropped > 0 {
+				log.WithField("dropped", dropped).Info("Dropped expired listing snapshots")
+			}
+		}
+	}
+}
+
+func listEntriesHelper(it EntryListingIterator, afterPath Path, limit int) ([]*DBEntry, bool, error) {
 	it.SeekGE(afterPath)
 	var entries []*DBEntry
 	for it.Next() {
@@ -409,27 +697,131 @@ func (c *cataloger) ResetEntry(ctx context.Context, repository string, branch st
 	repositoryID := graveler.RepositoryID(repository)
 	branchID := graveler.BranchID(branch)
 	entryPath := Path(path)
-	return c.EntryCatalog.ResetKey(ctx, repositoryID, branchID, entryPath)
+	return c.writeLeasedPaths(ctx, repository, branch, []string{path}, false, func() error {
+		return c.EntryCatalog.ResetKey(ctx, repositoryID, branchID, entryPath)
+	})
+}
+
+// RestoreEntry stages on branch the entry of path as it is on fromReference, pointing to the same
+// object so no data is copied.  It returns the entry staged, or ErrObjectNotFound if the object
+// was removed, e.g. by garbage collection or redaction.
+func (c *cataloger) RestoreEntry(ctx context.Context, repository string, branch string, path string, fromReference string) (*DBEntry, error) {
+	repositoryID := graveler.RepositoryID(repository)
+	branchID := graveler.BranchID(branch)
+	entryPath := Path(path)
+	if err := Validate([]ValidateArg{
+		{"repository", repositoryID, ValidateRepositoryID},
+		{"branch", branchID, ValidateBranchID},
+		{"path", entryPath, ValidatePath},
+		{"from", graveler.Ref(fromReference), ValidateRef},
+	}); err != nil {
+		return nil, err
+	}
+	repo, err := c.EntryCatalog.GetRepository(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	ent, err := c.EntryCatalog.GetEntry(ctx, repositoryID, graveler.Ref(fromReference), entryPath)
+	if err != nil {
+		return nil, err
+	}
+	if ent.Address != "" && ent.LinkRepository == "" {
+		exists, err := c.EntryCatalog.BlockAdapter.Exists(block.ObjectPointer{
+			StorageNamespace: repo.StorageNamespace.String(),
+			Identifier:       ent.Address,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrObjectNotFound
+		}
+		// garbage collection may be removing the object
+		ctx = c.withRemovedObjectsCheck(ctx, repository, ent.Address)
+	}
+	err = c.writeLeasedPaths(ctx, repository, branch, []string{path}, false, func() error {
+		return c.EntryCatalog.SetEntry(ctx, repositoryID, branchID, entryPath, ent)
+	})
+	if err != nil {
+		return nil, err
+	}
+	catalogEntry := newCatalogEntryFromEntry(false, path, ent)
+	return &catalogEntry, nil
 }
 
 func (c *cataloger) ResetEntries(ctx context.Context, repository string, branch string, prefix string) error {
 	repositoryID := graveler.RepositoryID(repository)
 	branchID := graveler.BranchID(branch)
 	prefixPath := Path(prefix)
-	return c.EntryCatalog.ResetPrefix(ctx, repositoryID, branchID, prefixPath)
+	return c.writeLeasedPaths(ctx, repository, branch, []string{prefix}, true, func() error {
+		return c.EntryCatalog.ResetPrefix(ctx, repositoryID, branchID, prefixPath)
+	})
+}
+
+func (c *cataloger) GetStagingStats(ctx context.Context, repository string, branch string) (*StagingStats, error) {
+	stats, err := c.EntryCatalog.GetStagingStats(ctx, graveler.RepositoryID(repository), graveler.BranchID(branch))
+	if err != nil {
+		return nil, err
+	}
+	return &StagingStats{
+		Writes:    stats.Writes,
+		Deletes:   stats.Deletes,
+		SizeBytes: stats.SizeBytes,
+	}, nil
 }
 
 func (c *cataloger) Commit(ctx context.Context, repository string, branch string, message string, committer string, metadata Metadata) (*CommitLog, error) {
-	repositoryID := graveler.RepositoryID(repository)
-	branchID := graveler.BranchID(branch)
-	commitID, err := c.EntryCatalog.Commit(ctx, repositoryID, branchID, graveler.CommitParams{
+	return c.CommitWithParams(ctx, repository, branch, CommitParams{
 		Committer: committer,
 		Message:   message,
-		Metadata:  map[string]string(metadata),
+		Metadata:  metadata,
 	})
+}
+
+func (c *cataloger) CommitWithParams(ctx context.Context, repository string, branch string, params CommitParams) (*CommitLog, error) {
+	if err := validateCommitParams(params); err != nil {
+		return nil, err
+	}
+	repositoryID := graveler.RepositoryID(repository)
+	branchID := graveler.BranchID(branch)
+	committer := params.Committer
+	message := params.Message
+	metadata, inputs, err := c.pinLineageInputs(ctx, params.Metadata, params.AuthorizeLineageInput)
+	if err != nil {
+		return nil, err
+	}
+	var commitID graveler.CommitID
+	err = c.checkSchemas(ctx, repository, branch)
+	if err == nil {
+		commitID, err = c.EntryCatalog.Commit(ctx, repositoryID, branchID, graveler.CommitParams{
+			Committer:      committer,
+			CommitterEmail: params.CommitterEmail,
+			Message:        message,
+			Metadata:       map[string]string(metadata),
+			Author:         params.Author,
+			AuthorEmail:    params.AuthorEmail,
+			AuthorDate:     params.AuthorDate,
+		})
+	}
+	c.publishCommitEvent(TopicCommit, RepositoryEvent{
+		Repository: repository,
+		Branch:     branch,
+		Reference:  commitID.String(),
+		Committer:  committer,
+		Message:    message,
+	}, err)
 	if err != nil {
 		return nil, err
 	}
+	// the commit holds its inputs in its metadata, the index only serves downstream lookups.
+	// The commit is already published, failing to index is retried later.
+	if err := c.indexLineageInputs(ctx, repository, commitID, inputs); err != nil {
+		c.log.WithError(err).WithFields(logging.Fields{
+			"repository": repository,
+			"commit_id":  commitID,
+		}).Error("Failed to index lineage inputs of commit, will retry")
+		c.indexLineageInputsLater(repository, commitID, inputs)
+	}
 	catalogCommitLog := &CommitLog{
 		Reference: commitID.String(),
 		Committer: committer,
@@ -445,9 +837,29 @@ func (c *cataloger) Commit(ctx context.Context, repository string, branch string
 		catalogCommitLog.Parents = append(catalogCommitLog.Parents, parent.String())
 	}
 	catalogCommitLog.CreationDate = commit.CreationDate.UTC()
+	setCommitLogSignatures(catalogCommitLog, commit)
 	return catalogCommitLog, nil
 }
 
+func (c *cataloger) CommitPreview(ctx context.Context, repository string, branch string) (*CommitPreview, error) {
+	metaRangeID, summary, err := c.EntryCatalog.CommitPreview(ctx, graveler.RepositoryID(repository), graveler.BranchID(branch))
+	if err != nil {
+		return nil, err
+	}
+	count := make(map[DifferenceType]int)
+	for k, v := range summary.Count {
+		kk, err := catalogDiffType(k)
+		if err != nil {
+			return nil, err
+		}
+		count[kk] = v
+	}
+	return &CommitPreview{
+		MetaRangeID: string(metaRangeID),
+		Summary:     count,
+	}, nil
+}
+
 func (c *cataloger) GetCommit(ctx context.Context, repository string, reference string) (*CommitLog, error) {
 	repositoryID := graveler.RepositoryID(repository)
 	ref := graveler.Ref(reference)
@@ -467,13 +879,84 @@ func (c *cataloger) GetCommit(ctx context.Context, repository string, reference
 		MetaRangeID:  string(commit.MetaRangeID),
 		Metadata:     Metadata(commit.Metadata),
 	}
+	setCommitLogSignatures(catalogCommitLog, commit)
 	for _, parent := range commit.Parents {
 		catalogCommitLog.Parents = append(catalogCommitLog.Parents, string(parent))
 	}
 	return catalogCommitLog, nil
 }
 
-func (c *cataloger) ListCommits(ctx context.Context, repository string, branch string, fromReference string, limit int) ([]*CommitLog, bool, error) {
+func (c *cataloger) FindCommitsByMetadata(ctx context.Context, repository string, key, value string, after string, limit int) ([]*CommitLog, bool, error) {
+	// normalize limit, an empty page cannot be continued
+	if limit <= 0 || limit > FindCommitsLimitMax {
+		limit = FindCommitsLimitMax
+	}
+	records, hasMore, err := c.EntryCatalog.FindCommitsByMetadata(ctx, graveler.RepositoryID(repository), key, value, graveler.CommitID(after), limit)
+	if err != nil {
+		return nil, false, err
+	}
+	commits := make([]*CommitLog, len(records))
+	for i, rec := range records {
+		commits[i] = newCommitLogFromRecord(rec)
+	}
+	return commits, hasMore, nil
+}
+
+func (c *cataloger) IsAncestor(ctx context.Context, repository, ancestorReference, reference string) (bool, error) {
+	return c.EntryCatalog.IsAncestor(ctx, graveler.RepositoryID(repository), graveler.Ref(ancestorReference), graveler.Ref(reference))
+}
+
+func (c *cataloger) FillGenerations(ctx context.Context, repository string) (int, error) {
+	return c.EntryCatalog.FillGenerations(ctx, graveler.RepositoryID(repository))
+}
+
+func (c *cataloger) CommitsBetween(ctx context.Context, repository, fromReference, toReference string, after string, limit int) ([]*CommitLog, bool, error) {
+	records, err := c.EntryCatalog.CommitsBetween(ctx, graveler.RepositoryID(repository), graveler.Ref(fromReference), graveler.Ref(toReference))
+	if err != nil {
+		return nil, false, err
+	}
+	// skip until 'after' if needed
+	if after != "" {
+		i := 0
+		for i < len(records) && records[i].CommitID.String() != after {
+			i++
+		}
+		if i < len(records) {
+			i++
+		}
+		records = records[i:]
+	}
+	if limit < 0 || limit > CommitsBetweenLimitMax {
+		limit = CommitsBetweenLimitMax
+	}
+	hasMore := len(records) > limit
+	if hasMore {
+		records = records[:limit]
+	}
+	commits := make([]*CommitLog, len(records))
+	for i, rec := range records {
+		commits[i] = newCommitLogFromRecord(rec)
+	}
+	return commits, hasMore, nil
+}
+
+func newCommitLogFromRecord(rec *graveler.CommitRecord) *CommitLog {
+	commit := &CommitLog{
+		Reference:    rec.CommitID.String(),
+		Committer:    rec.Committer,
+		Message:      rec.Message,
+		CreationDate: rec.CreationDate,
+		Metadata:     map[string]string(rec.Metadata),
+		MetaRangeID:  string(rec.MetaRangeID),
+	}
+	setCommitLogSignatures(commit, rec.Commit)
+	for _, parent := range rec.Parents {
+		commit.Parents = append(commit.Parents, parent.String())
+	}
+	return commit
+}
+
+func (c *cataloger) ListCommits(ctx context.Context, repository string, branch string, fromReference string, limit int, firstParent bool) ([]*CommitLog, bool, error) {
 	repositoryID := graveler.RepositoryID(repository)
 	branchCommitID, err := c.EntryCatalog.Dereference(ctx, repositoryID, graveler.Ref(branch))
 	if err != nil {
@@ -483,7 +966,7 @@ func (c *cataloger) ListCommits(ctx context.Context, repository string, branch s
 		// return empty log if there is no commit on branch yet
 		return make([]*CommitLog, 0), false, nil
 	}
-	it, err := c.EntryCatalog.Log(ctx, repositoryID, branchCommitID)
+	it, err := c.EntryCatalog.Log(ctx, repositoryID, branchCommitID, firstParent)
 	if err != nil {
 		return nil, false, err
 	}
@@ -516,6 +999,7 @@ func (c *cataloger) ListCommits(ctx context.Context, repository string, branch s
 			MetaRangeID:  string(v.MetaRangeID),
 			Parents:      make([]string, 0, len(v.Parents)),
 		}
+		setCommitLogSignatures(commit, v.Commit)
 		for _, parent := range v.Parents {
 			commit.Parents = append(commit.Parents, parent.String())
 		}
@@ -535,24 +1019,153 @@ func (c *cataloger) ListCommits(ctx context.Context, repository string, branch s
 	return commits, hasMore, nil
 }
 
+func (c *cataloger) WalkCommitLog(ctx context.Context, repository, reference string, firstParent bool, walkFn func(*CommitLog) error) error {
+	repositoryID := graveler.RepositoryID(repository)
+	commitID, err := c.EntryCatalog.Dereference(ctx, repositoryID, graveler.Ref(reference))
+	if err != nil {
+		return err
+	}
+	if commitID == "" {
+		return nil
+	}
+	it, err := c.EntryCatalog.Log(ctx, repositoryID, commitID, firstParent)
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	for it.Next() {
+		if err := walkFn(newCommitLogFromRecord(it.Value())); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
+
 func (c *cataloger) Revert(ctx context.Context, repository string, branch string, params RevertParams) error {
 	repositoryID := graveler.RepositoryID(repository)
 	branchID := graveler.BranchID(branch)
 	ref := graveler.Ref(params.Reference)
-	_, _, err := c.EntryCatalog.Revert(ctx, repositoryID, branchID, ref, params.ParentNumber, graveler.CommitParams{
+	err := c.checkLeasedChanges(ctx, repository, branch, func() (EntryDiffIterator, error) {
+		return c.revertedChanges(ctx, repositoryID, ref, params.ParentNumber)
+	})
+	if err != nil {
+		return err
+	}
+	_, _, err = c.EntryCatalog.Revert(ctx, repositoryID, branchID, ref, params.ParentNumber, graveler.CommitParams{
 		Committer: params.Committer,
 		Message:   fmt.Sprintf("Revert %s", params.Reference),
 	})
 	return err
 }
 
+// revertedChanges returns the diff of the commit ref points to over the parent parentNumber
+// reverts it to, the first parent if it is not set
+func (c *cataloger) revertedChanges(ctx context.Context, repositoryID graveler.RepositoryID, ref graveler.Ref, parentNumber int) (EntryDiffIterator, error) {
+	commitID, err := c.EntryCatalog.Dereference(ctx, repositoryID, ref)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := c.EntryCatalog.GetCommit(ctx, repositoryID, commitID)
+	if err != nil {
+		return nil, err
+	}
+	if len(commit.Parents) > 1 && parentNumber <= 0 {
+		return nil, graveler.ErrRevertMergeNoParent
+	}
+	parent := 0
+	if parentNumber > 0 {
+		parent = parentNumber - 1
+	}
+	if parent >= len(commit.Parents) {
+		return nil, fmt.Errorf("%w: parent %d", graveler.ErrRevertParentOutOfRange, parentNumber)
+	}
+	return c.EntryCatalog.Diff(ctx, repositoryID, graveler.Ref(commit.Parents[parent]), graveler.Ref(commitID), graveler.DiffTypeMaskAll)
+}
+
+func (c *cataloger) ReplacePrefix(ctx context.Context, repository string, branch string, params ReplacePrefixParams) (*MergeResult, error) {
+	repositoryID := graveler.RepositoryID(repository)
+	branchID := graveler.BranchID(branch)
+	// replacing the entire branch is a merge, and passes its guardrails through Merge
+	if params.Prefix == "" {
+		return nil, fmt.Errorf("prefix: %w", ErrInvalidValue)
+	}
+	message := params.Message
+	if message == "" {
+		if params.SourceRef == "" {
+			message = fmt.Sprintf("Drop %s", params.Prefix)
+		} else {
+			message = fmt.Sprintf("Replace %s from %s", params.Prefix, params.SourceRef)
+		}
+	}
+	commitParams := graveler.CommitParams{
+		Committer: params.Committer,
+		Message:   message,
+		Metadata:  graveler.Metadata(params.Metadata),
+	}
+	var (
+		commitID graveler.CommitID
+		summary  graveler.DiffSummary
+		err      error
+	)
+	var sourceCommitID graveler.CommitID
+	if params.SourceRef != "" {
+		// the source may hold objects garbage collection is removing
+		ctx = c.withGarbageCollectionCheck(ctx, repository)
+		sourceCommitID, err = c.EntryCatalog.Dereference(ctx, repositoryID, graveler.Ref(params.SourceRef))
+		if err != nil {
+			return nil, err
+		}
+		// entries of the source are merged into the branch, the guardrails of merges apply to
+		// the same commit that is replaced from
+		ctx = graveler.WithPrefixChangeCheck(ctx, func(ctx context.Context, baseID, changedID graveler.MetaRangeID) error {
+			return c.checkPrefixChangeGuardrails(ctx, repository, branch, params.SourceRef, sourceCommitID, baseID, changedID)
+		})
+	}
+	err = c.writeLeasedPaths(ctx, repository, branch, []string{params.Prefix}, true, func() error {
+		var err error
+		if params.SourceRef == "" {
+			commitID, summary, err = c.EntryCatalog.DropPrefix(ctx, repositoryID, branchID, Path(params.Prefix), commitParams)
+		} else {
+			commitID, summary, err = c.EntryCatalog.ReplacePrefixFromRef(ctx, repositoryID, branchID, Path(params.Prefix), graveler.Ref(sourceCommitID), commitParams)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.publishDatasetChanges(RepositoryEvent{
+		Repository: repository,
+		Branch:     branch,
+		SourceRef:  params.SourceRef,
+		Reference:  commitID.String(),
+		Committer:  params.Committer,
+		Message:    message,
+	})
+	count := make(map[DifferenceType]int)
+	for k, v := range summary.Count {
+		kk, err := catalogDiffType(k)
+		if err != nil {
+			return nil, err
+		}
+		count[kk] = v
+	}
+	return &MergeResult{
+		Summary:   count,
+		Reference: commitID.String(),
+	}, nil
+}
+
 func (c *cataloger) RollbackCommit(_ context.Context, _ string, _ string, _ string) error {
 	c.log.Debug("rollback to commit is not supported in rocks implementation")
 	return ErrFeatureNotSupported
 }
 
 func (c *cataloger) Diff(ctx context.Context, repository string, leftReference string, rightReference string, params DiffParams) (Differences, bool, error) {
-	it, err := c.EntryCatalog.Diff(ctx, graveler.RepositoryID(repository), graveler.Ref(leftReference), graveler.Ref(rightReference))
+	typeMask, err := diffTypeMask(params.Types)
+	if err != nil {
+		return nil, false, err
+	}
+	it, err := c.EntryCatalog.Diff(ctx, graveler.RepositoryID(repository), graveler.Ref(leftReference), graveler.Ref(rightReference), typeMask)
 	if err != nil {
 		return nil, false, err
 	}
@@ -561,7 +1174,11 @@ func (c *cataloger) Diff(ctx context.Context, repository string, leftReference s
 }
 
 func (c *cataloger) Compare(ctx context.Context, repository, leftReference string, rightReference string, params DiffParams) (Differences, bool, error) {
-	it, err := c.EntryCatalog.Compare(ctx, graveler.RepositoryID(repository), graveler.Ref(leftReference), graveler.Ref(rightReference))
+	typeMask, err := diffTypeMask(params.Types)
+	if err != nil {
+		return nil, false, err
+	}
+	it, err := c.EntryCatalog.Compare(ctx, graveler.RepositoryID(repository), graveler.Ref(leftReference), graveler.Ref(rightReference), typeMask)
 	if err != nil {
 		return nil, false, err
 	}
@@ -569,13 +1186,17 @@ func (c *cataloger) Compare(ctx context.Context, repository, leftReference strin
 	return listDiffHelper(it, params.Limit, params.After)
 }
 
-func (c *cataloger) DiffUncommitted(ctx context.Context, repository string, branch string, limit int, after string) (Differences, bool, error) {
-	it, err := c.EntryCatalog.DiffUncommitted(ctx, graveler.RepositoryID(repository), graveler.BranchID(branch))
+func (c *cataloger) DiffUncommitted(ctx context.Context, repository string, branch string, params DiffParams) (Differences, bool, error) {
+	typeMask, err := diffTypeMask(params.Types)
+	if err != nil {
+		return nil, false, err
+	}
+	it, err := c.EntryCatalog.DiffUncommitted(ctx, graveler.RepositoryID(repository), graveler.BranchID(branch), typeMask)
 	if err != nil {
 		return nil, false, err
 	}
 	defer it.Close()
-	return listDiffHelper(it, limit, after)
+	return listDiffHelper(it, params.Limit, params.After)
 }
 
 func listDiffHelper(it EntryDiffIterator, limit int, after string) (Differences, bool, error) {
@@ -612,16 +1233,45 @@ func listDiffHelper(it EntryDiffIterator, limit int, after string) (Differences,
 	return diffs, hasMore, nil
 }
 
-func (c *cataloger) Merge(ctx context.Context, repository string, destinationBranch string, sourceRef string, committer string, message string, metadata Metadata) (*MergeResult, error) {
+func (c *cataloger) Merge(ctx context.Context, repository string, destinationBranch string, sourceRef string, params MergeParams) (*MergeResult, error) {
+	sourceCommitID, err := c.EntryCatalog.Dereference(ctx, graveler.RepositoryID(repository), graveler.Ref(sourceRef))
+	if err != nil {
+		return nil, err
+	}
+	return c.mergeCommit(ctx, repository, destinationBranch, sourceRef, sourceCommitID, params)
+}
+
+// mergeCommit merges sourceCommitID, the commit sourceRef pointed to, into destinationBranch.  The
+// guardrails are checked against the same commit that is merged, so a source advancing in between
+// cannot merge changes that were not checked.
+func (c *cataloger) mergeCommit(ctx context.Context, repository string, destinationBranch string, sourceRef string, sourceCommitID graveler.CommitID, params MergeParams) (*MergeResult, error) {
 	repositoryID := graveler.RepositoryID(repository)
 	dest := graveler.BranchID(destinationBranch)
-	source := graveler.Ref(sourceRef)
-	meta := graveler.Metadata(metadata)
-	commitID, summary, err := c.EntryCatalog.Merge(ctx, repositoryID, dest, source, graveler.CommitParams{
-		Committer: committer,
-		Message:   message,
+	meta := graveler.Metadata(params.Metadata)
+	if !params.OverrideGuardrails {
+		if err := c.checkMergeGuardrails(ctx, repository, destinationBranch, sourceRef, sourceCommitID); err != nil {
+			return nil, err
+		}
+	}
+	err := c.checkLeasedChanges(ctx, repository, destinationBranch, func() (EntryDiffIterator, error) {
+		return c.EntryCatalog.Compare(ctx, repositoryID, graveler.Ref(sourceCommitID), graveler.Ref(destinationBranch), graveler.DiffTypeMaskAll)
+	})
+	if err != nil {
+		return nil, err
+	}
+	commitID, summary, err := c.EntryCatalog.Merge(ctx, repositoryID, dest, graveler.Ref(sourceCommitID), graveler.CommitParams{
+		Committer: params.Committer,
+		Message:   params.Message,
 		Metadata:  meta,
 	})
+	c.publishCommitEvent(TopicMerge, RepositoryEvent{
+		Repository: repository,
+		Branch:     destinationBranch,
+		SourceRef:  sourceRef,
+		Reference:  commitID.String(),
+		Committer:  params.Committer,
+		Message:    params.Message,
+	}, err)
 	if errors.Is(err, graveler.ErrConflictFound) {
 		// for compatibility with old cataloger
 		return &MergeResult{
@@ -631,6 +1281,7 @@ func (c *cataloger) Merge(ctx context.Context, repository string, destinationBra
 	if err != nil {
 		return nil, err
 	}
+	c.deleteMergedEphemeralBranch(ctx, repository, sourceRef)
 	count := make(map[DifferenceType]int)
 	for k, v := range summary.Count {
 		kk, err := catalogDiffType(k)
@@ -645,6 +1296,30 @@ func (c *cataloger) Merge(ctx context.Context, repository string, destinationBra
 	}, nil
 }
 
+func (c *cataloger) MergePreview(ctx context.Context, repository string, destinationBranch string, sourceRef string) (*MergePreview, error) {
+	preview, err := c.EntryCatalog.MergePreview(ctx, graveler.RepositoryID(repository), graveler.BranchID(destinationBranch), graveler.Ref(sourceRef))
+	if err != nil {
+		return nil, err
+	}
+	count := make(map[DifferenceType]int)
+	for k, v := range preview.Summary.Count {
+		kk, err := catalogDiffType(k)
+		if err != nil {
+			return nil, err
+		}
+		count[kk] = v
+	}
+	conflicts := make([]string, len(preview.Conflicts))
+	for i, key := range preview.Conflicts {
+		conflicts[i] = key.String()
+	}
+	return &MergePreview{
+		Summary:          count,
+		Conflicts:        conflicts,
+		DestinationDirty: preview.DestinationDirty,
+	}, nil
+}
+
 func (c *cataloger) DumpCommits(ctx context.Context, repositoryID string) (string, error) {
 	metaRangeID, err := c.EntryCatalog.DumpCommits(ctx, graveler.RepositoryID(repositoryID))
 	if err != nil {
@@ -697,6 +1372,10 @@ func newCatalogEntryFromEntry(commonPrefix bool, path string, ent *Entry) DBEntr
 		catEnt.Checksum = ent.ETag
 		catEnt.Metadata = ent.Metadata
 		catEnt.Expired = false
+		catEnt.DirectoryMarker = ent.DirectoryMarker
+		catEnt.LinkTarget = ent.LinkTarget
+		catEnt.LinkRepository = ent.LinkRepository
+		catEnt.LinkRef = ent.LinkRef
 	}
 	return catEnt
 }
@@ -716,6 +1395,26 @@ func catalogDiffType(typ graveler.DiffType) (DifferenceType, error) {
 	}
 }
 
+// diffTypeMask returns the mask selecting the graveler diff types matching types
+func diffTypeMask(types []DifferenceType) (graveler.DiffTypeMask, error) {
+	gravelerTypes := make([]graveler.DiffType, len(types))
+	for i, typ := range types {
+		switch typ {
+		case DifferenceTypeAdded:
+			gravelerTypes[i] = graveler.DiffTypeAdded
+		case DifferenceTypeRemoved:
+			gravelerTypes[i] = graveler.DiffTypeRemoved
+		case DifferenceTypeChanged:
+			gravelerTypes[i] = graveler.DiffTypeChanged
+		case DifferenceTypeConflict:
+			gravelerTypes[i] = graveler.DiffTypeConflict
+		default:
+			return graveler.DiffTypeMaskAll, fmt.Errorf("%d: %w", typ, ErrUnknownDiffType)
+		}
+	}
+	return graveler.NewDiffTypeMask(gravelerTypes...), nil
+}
+
 func newDifferenceFromEntryDiff(v *EntryDiff) (Difference, error) {
 	var (
 		diff Difference
@@ -2,10 +2,14 @@ package catalog
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/block/mem"
 	"github.com/treeverse/lakefs/graveler"
 	"github.com/treeverse/lakefs/testutil"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -475,3 +479,299 @@ func TestCataloger_ListEntries(t *testing.T) {
 		})
 	}
 }
+
+func TestCataloger_ListEntriesWithToken(t *testing.T) {
+	now := time.Now()
+	gravelerData := []*graveler.ValueRecord{
+		{Key: graveler.Key("file1"), Value: MustEntryToValue(&Entry{Address: "file1", LastModified: timestamppb.New(now), Size: 1, ETag: "01"})},
+		{Key: graveler.Key("file2"), Value: MustEntryToValue(&Entry{Address: "file2", LastModified: timestamppb.New(now), Size: 2, ETag: "02"})},
+		{Key: graveler.Key("file3"), Value: MustEntryToValue(&Entry{Address: "file3", LastModified: timestamppb.New(now), Size: 3, ETag: "03"})},
+	}
+	gravelerMock := &FakeGraveler{
+		ListIteratorFactory: NewFakeValueIteratorFactory(gravelerData),
+	}
+	c := &cataloger{
+		EntryCatalog: &EntryCatalog{
+			Store: gravelerMock,
+		},
+	}
+	ctx := context.Background()
+
+	var paths []string
+	token := ""
+	for pages := 0; ; pages++ {
+		if pages > len(gravelerData) {
+			t.Fatal("ListEntriesWithToken() did not end listing")
+		}
+		entries, nextToken, err := c.ListEntriesWithToken(ctx, "repo", "ref", "", token, "", 2)
+		if err != nil {
+			t.Fatalf("ListEntriesWithToken() error = %s", err)
+		}
+		for _, ent := range entries {
+			paths = append(paths, ent.Path)
+		}
+		if nextToken == "" {
+			break
+		}
+		continuation, err := decodeContinuationToken(nextToken)
+		if err != nil {
+			t.Fatalf("decode continuation token: %s", err)
+		}
+		if continuation.CommitID != "ref" || continuation.StagingToken != "staging-pinned" {
+			t.Errorf("continuation token commit = %s staging = %s, expected pinned snapshot 'ref' 'staging-pinned'",
+				continuation.CommitID, continuation.StagingToken)
+		}
+		token = nextToken
+	}
+	if diff := deep.Equal(paths, []string{"file1", "file2", "file3"}); diff != nil {
+		t.Error("ListEntriesWithToken() diff found", diff)
+	}
+	if gravelerMock.PinnedSnapshots != 1 {
+		t.Errorf("ListEntriesWithToken() pinned %d snapshots, expected 1", gravelerMock.PinnedSnapshots)
+	}
+
+	// a limit of 0 lists the default page, a single page pins no snapshot
+	entries, _, err := c.ListEntriesWithToken(ctx, "repo", "ref", "", "", "", 0)
+	if err != nil {
+		t.Fatalf("ListEntriesWithToken() with limit 0 error = %s", err)
+	}
+	if len(entries) != len(gravelerData) {
+		t.Errorf("ListEntriesWithToken() with limit 0 listed %d entries, expected %d", len(entries), len(gravelerData))
+	}
+	if gravelerMock.PinnedSnapshots != 1 {
+		t.Errorf("ListEntriesWithToken() of a single page pinned %d snapshots, expected 1", gravelerMock.PinnedSnapshots)
+	}
+
+	// token of another reference is rejected
+	_, nextToken, err := c.ListEntriesWithToken(ctx, "repo", "ref", "", "", "", 1)
+	if err != nil {
+		t.Fatalf("ListEntriesWithToken() error = %s", err)
+	}
+	_, _, err = c.ListEntriesWithToken(ctx, "repo", "other", "", nextToken, "", 1)
+	if !errors.Is(err, ErrInvalidContinuationToken) {
+		t.Errorf("ListEntriesWithToken() with token of another ref, error = %v, expected %s", err, ErrInvalidContinuationToken)
+	}
+}
+
+func TestCataloger_ListEntriesWithToken_Replica(t *testing.T) {
+	now := time.Now()
+	gravelerData := []*graveler.ValueRecord{
+		{Key: graveler.Key("file1"), Value: MustEntryToValue(&Entry{Address: "file1", LastModified: timestamppb.New(now), Size: 1, ETag: "01"})},
+		{Key: graveler.Key("file2"), Value: MustEntryToValue(&Entry{Address: "file2", LastModified: timestamppb.New(now), Size: 2, ETag: "02"})},
+		{Key: graveler.Key("file3"), Value: MustEntryToValue(&Entry{Address: "file3", LastModified: timestamppb.New(now), Size: 3, ETag: "03"})},
+	}
+	gravelerMock := &FakeGraveler{
+		ListIteratorFactory: NewFakeValueIteratorFactory(gravelerData),
+	}
+	c := &cataloger{
+		EntryCatalog: &EntryCatalog{
+			Store: gravelerMock,
+		},
+		replica: true,
+	}
+	ctx := context.Background()
+
+	var paths []string
+	token := ""
+	for pages := 0; ; pages++ {
+		if pages > len(gravelerData) {
+			t.Fatal("ListEntriesWithToken() did not end listing")
+		}
+		entries, nextToken, err := c.ListEntriesWithToken(ctx, "repo", "ref", "", token, "", 1)
+		if err != nil {
+			t.Fatalf("ListEntriesWithToken() error = %s", err)
+		}
+		for _, ent := range entries {
+			paths = append(paths, ent.Path)
+		}
+		if nextToken == "" {
+			break
+		}
+		continuation, err := decodeContinuationToken(nextToken)
+		if err != nil {
+			t.Fatalf("decode continuation token: %s", err)
+		}
+		if !continuation.Live || continuation.CommitID != "" || continuation.StagingToken != "" {
+			t.Errorf("continuation token live = %t commit = %s staging = %s, expected a live token",
+				continuation.Live, continuation.CommitID, continuation.StagingToken)
+		}
+		token = nextToken
+	}
+	if diff := deep.Equal(paths, []string{"file1", "file2", "file3"}); diff != nil {
+		t.Error("ListEntriesWithToken() diff found", diff)
+	}
+	if gravelerMock.PinnedSnapshots != 0 {
+		t.Errorf("ListEntriesWithToken() on a replica pinned %d snapshots, expected none", gravelerMock.PinnedSnapshots)
+	}
+}
+
+func TestCataloger_GetEntry_CrossRepositoryLink(t *testing.T) {
+	const commitID = "7f8e9d5a6b4c3d2e1f0a9b8c7d6e5f4a3b2c1d0e9f8a7b6c5d4e3f2a1b0c9d8e"
+	gravelerMock := &FakeGraveler{
+		KeyValue: map[string]*graveler.Value{
+			"repo1/master/shared":               MustEntryToValue(&Entry{LinkRepository: "repo2", LinkRef: commitID, LinkTarget: "data/file1"}),
+			"repo2/" + commitID + "/data/file1": MustEntryToValue(&Entry{Address: "file1", Size: 10}),
+		},
+		Repositories: map[graveler.RepositoryID]*graveler.Repository{
+			"repo2": {StorageNamespace: "s3://bucket/repo2"},
+		},
+	}
+	c := &cataloger{
+		EntryCatalog: &EntryCatalog{
+			Store: gravelerMock,
+		},
+	}
+	ctx := context.Background()
+
+	// not followed without authorization
+	_, err := c.GetEntry(ctx, "repo1", "master", "shared", GetEntryParams{})
+	if !errors.Is(err, ErrCrossRepositoryLink) {
+		t.Fatalf("GetEntry() err = %v, expected %s", err, ErrCrossRepositoryLink)
+	}
+
+	// denied
+	errDenied := errors.New("denied")
+	_, err = c.GetEntry(ctx, "repo1", "master", "shared", GetEntryParams{
+		AuthorizeLink: func(repository, path string) error { return errDenied },
+	})
+	if !errors.Is(err, errDenied) {
+		t.Fatalf("GetEntry() err = %v, expected %s", err, errDenied)
+	}
+
+	// followed
+	var authorized []string
+	ent, err := c.GetEntry(ctx, "repo1", "master", "shared", GetEntryParams{
+		AuthorizeLink: func(repository, path string) error {
+			authorized = append(authorized, repository+":"+path)
+			return nil
+		},
+	})
+	testutil.MustDo(t, "get linked entry", err)
+	if diff := deep.Equal(authorized, []string{"repo2:data/file1"}); diff != nil {
+		t.Error("GetEntry() authorized links diff", diff)
+	}
+	if ent.Path != "shared" || ent.Size != 10 || ent.PhysicalAddress != "s3://bucket/repo2/file1" {
+		t.Errorf("GetEntry() got %+v, expected linked entry from repo2", ent)
+	}
+}
+
+func TestCataloger_RestoreEntry(t *testing.T) {
+	ctx := context.Background()
+	const storageNamespace = "mem://restore"
+	previous := &Entry{Address: "addr1", ETag: "etag1", Size: 10}
+	gravelerMock := &FakeGraveler{
+		KeyValue: map[string]*graveler.Value{
+			"repo/c1/file":     MustEntryToValue(previous),
+			"repo/c1/removed":  MustEntryToValue(&Entry{Address: "addr3", ETag: "etag3", Size: 30}),
+			"repo/master/file": MustEntryToValue(&Entry{Address: "addr2", ETag: "etag2", Size: 20}),
+		},
+		Repositories: map[graveler.RepositoryID]*graveler.Repository{
+			"repo": {StorageNamespace: storageNamespace, DefaultBranchID: "master"},
+		},
+	}
+	c := testCataloger(t, gravelerMock)
+	adapter := mem.New()
+	err := adapter.Put(block.ObjectPointer{StorageNamespace: storageNamespace, Identifier: "addr1"}, 10, strings.NewReader("0123456789"), block.PutOpts{})
+	testutil.MustDo(t, "put object", err)
+	c.EntryCatalog.BlockAdapter = adapter
+
+	restored, err := c.RestoreEntry(ctx, "repo", "master", "file", "c1")
+	testutil.MustDo(t, "restore entry", err)
+	if restored.PhysicalAddress != "addr1" || restored.Checksum != "etag1" || restored.Size != 10 {
+		t.Errorf("RestoreEntry() got %+v, expected entry of c1", restored)
+	}
+	got, err := c.EntryCatalog.GetEntry(ctx, "repo", "master", "file")
+	testutil.MustDo(t, "get restored entry", err)
+	if diff := deep.Equal(previous, got); diff != nil {
+		t.Error("RestoreEntry() staged entry with diff", diff)
+	}
+
+	_, err = c.RestoreEntry(ctx, "repo", "master", "missing", "c1")
+	if !errors.Is(err, graveler.ErrNotFound) {
+		t.Errorf("RestoreEntry() of missing path err = %v, expected not found", err)
+	}
+	_, err = c.RestoreEntry(ctx, "repo", "master", "removed", "c1")
+	if !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("RestoreEntry() of removed object err = %v, expected %s", err, ErrObjectNotFound)
+	}
+	if _, err := c.EntryCatalog.GetEntry(ctx, "repo", "master", "removed"); !errors.Is(err, graveler.ErrNotFound) {
+		t.Errorf("RestoreEntry() of removed object staged it, get err = %v", err)
+	}
+}
+
+func TestCataloger_GetEntries(t *testing.T) {
+	ctx := context.Background()
+	gravelerMock := &FakeGraveler{KeyValue: map[string]*graveler.Value{
+		"repo/master/file1": MustEntryToValue(&Entry{Address: "addr1", ETag: "etag1"}),
+		"repo/master/file2": MustEntryToValue(&Entry{Address: "addr2", ETag: "etag2"}),
+		"repo/master/link":  MustEntryToValue(&Entry{LinkTarget: "file2"}),
+	}}
+	c := &cataloger{EntryCatalog: &EntryCatalog{Store: gravelerMock}}
+
+	paths := []string{"file2", "missing", "file1", "link"}
+	entries, err := c.GetEntries(ctx, "repo", "master", paths, GetEntryParams{})
+	testutil.MustDo(t, "get entries", err)
+	expectedAddresses := []string{"addr2", "", "addr1", "addr2"}
+	if len(entries) != len(paths) {
+		t.Fatalf("GetEntries() got %d entries, expected %d", len(entries), len(paths))
+	}
+	for i, entry := range entries {
+		address := ""
+		if entry != nil {
+			address = entry.PhysicalAddress
+			if entry.Path != paths[i] {
+				t.Errorf("GetEntries() entry %d path %s, expected %s", i, entry.Path, paths[i])
+			}
+		}
+		if address != expectedAddresses[i] {
+			t.Errorf("GetEntries() entry %d address %s, expected %s", i, address, expectedAddresses[i])
+		}
+	}
+
+	entries, err = c.GetEntries(ctx, "repo", "master", []string{"link"}, GetEntryParams{NoFollowLinks: true})
+	testutil.MustDo(t, "get entries without following links", err)
+	if entries[0] == nil || entries[0].LinkTarget != "file2" {
+		t.Errorf("GetEntries() without following links got %+v, expected the link entry", entries[0])
+	}
+}
+
+func TestCataloger_FindCommitsByMetadata(t *testing.T) {
+	metadata := graveler.Metadata{"airflow.dag_run_id": "run1"}
+	gravelerMock := &FakeGraveler{
+		CommitLog: []*graveler.CommitRecord{
+			{CommitID: "c3", Commit: &graveler.Commit{Message: "third", Metadata: metadata}},
+			{CommitID: "c2", Commit: &graveler.Commit{Message: "second", Metadata: graveler.Metadata{"airflow.dag_run_id": "run2"}}},
+			{CommitID: "c1", Commit: &graveler.Commit{Message: "first", Metadata: metadata}},
+		},
+	}
+	c := &cataloger{
+		EntryCatalog: &EntryCatalog{
+			Store: gravelerMock,
+		},
+	}
+	ctx := context.Background()
+
+	commits, hasMore, err := c.FindCommitsByMetadata(ctx, "repo", "airflow.dag_run_id", "run1", "", 1)
+	if err != nil {
+		t.Fatalf("FindCommitsByMetadata() error = %s", err)
+	}
+	if len(commits) != 1 || commits[0].Reference != "c3" || !hasMore {
+		t.Fatalf("FindCommitsByMetadata() with limit 1 got %+v (has more %t), expected c3 and more", commits, hasMore)
+	}
+
+	// a limit of 0 finds the default page
+	commits, hasMore, err = c.FindCommitsByMetadata(ctx, "repo", "airflow.dag_run_id", "run1", "", 0)
+	if err != nil {
+		t.Fatalf("FindCommitsByMetadata() with limit 0 error = %s", err)
+	}
+	var refs []string
+	for _, commit := range commits {
+		refs = append(refs, commit.Reference)
+	}
+	if diff := deep.Equal(refs, []string{"c3", "c1"}); diff != nil {
+		t.Error("FindCommitsByMetadata() with limit 0 diff found", diff)
+	}
+	if hasMore {
+		t.Error("FindCommitsByMetadata() with limit 0 has more, expected all commits")
+	}
+}
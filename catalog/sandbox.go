@@ -0,0 +1,94 @@
+package catalog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+const (
+	// SandboxBranchPrefix prefixes the names of the personal sandbox branches of users
+	SandboxBranchPrefix = "sandbox-"
+	// DefaultSandboxTTL is the lifetime of an unused sandbox
+	DefaultSandboxTTL = 24 * time.Hour
+)
+
+var reInvalidBranchChars = regexp.MustCompile(`[^-\w]`)
+
+// Sandbox is the personal branch of a user in a repository
+type Sandbox struct {
+	Repository string
+	Branch     string
+	Reference  string
+	ExpiresAt  time.Time
+	// Created is true when the sandbox was created by the request returning it
+	Created bool
+}
+
+// SandboxBranchID returns the name of the sandbox branch of username.  Characters not allowed
+// in branch names are replaced, and a digest of username is then appended to keep names of
+// different users distinct.
+func SandboxBranchID(username string) string {
+	name := reInvalidBranchChars.ReplaceAllString(username, "_")
+	if name == username {
+		return SandboxBranchPrefix + name
+	}
+	const digestLen = 4
+	digest := sha256.Sum256([]byte(username))
+	return SandboxBranchPrefix + name + "-" + hex.EncodeToString(digest[:digestLen])
+}
+
+// GetOrCreateSandbox returns the sandbox branch of username in repository, creating it from the
+// default branch on first use.  Sandboxes are ephemeral branches: each call extends the lifetime
+// of the sandbox to ttl from now, unused sandboxes are deleted by the ephemeral branch reaper.
+func (c *cataloger) GetOrCreateSandbox(ctx context.Context, repository, username string, ttl time.Duration) (*Sandbox, error) {
+	repo, err := c.GetRepository(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	branch := SandboxBranchID(username)
+	expiresAt := time.Now().UTC().Add(ttl)
+	sandbox := &Sandbox{Repository: repository, Branch: branch, ExpiresAt: expiresAt}
+	commitLog, err := c.CreateEphemeralBranch(ctx, repository, branch, repo.DefaultBranch, EphemeralBranchParams{TTL: ttl})
+	if err == nil {
+		sandbox.Reference = commitLog.Reference
+		sandbox.Created = true
+		return sandbox, nil
+	}
+	if !errors.Is(err, graveler.ErrBranchExists) {
+		return nil, err
+	}
+	sandbox.Reference, err = c.GetBranchReference(ctx, repository, branch)
+	if err != nil {
+		return nil, err
+	}
+	// extend the lifetime of the sandbox, a branch created with the same name by other means is
+	// not ephemeral and is left alone
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		_, err := tx.Exec(`UPDATE catalog_ephemeral_branches SET expires_at = GREATEST(expires_at, $3)
+			WHERE repository_id = $1 AND branch_id = $2`,
+			repository, branch, expiresAt)
+		if err != nil {
+			return nil, err
+		}
+		var current time.Time
+		err = tx.GetPrimitive(&current, `SELECT expires_at FROM catalog_ephemeral_branches
+			WHERE repository_id = $1 AND branch_id = $2`,
+			repository, branch)
+		if errors.Is(err, db.ErrNotFound) {
+			return time.Time{}, nil
+		}
+		return current, err
+	}, db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	sandbox.ExpiresAt = res.(time.Time)
+	return sandbox, nil
+}
@@ -0,0 +1,33 @@
+package catalog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/treeverse/lakefs/graveler"
+)
+
+func TestSandboxBranchID(t *testing.T) {
+	tests := []struct {
+		username string
+		want     string
+	}{
+		{username: "alice", want: "sandbox-alice"},
+		{username: "bob-1_x", want: "sandbox-bob-1_x"},
+		{username: "alice@example.com", want: "sandbox-alice_example_com-"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.username, func(t *testing.T) {
+			got := SandboxBranchID(tt.username)
+			if !strings.HasPrefix(got, tt.want) {
+				t.Errorf("SandboxBranchID(%s) = %s, expected prefix %s", tt.username, got, tt.want)
+			}
+			if err := ValidateBranchID(graveler.BranchID(got)); err != nil {
+				t.Errorf("SandboxBranchID(%s) = %s, invalid branch: %s", tt.username, got, err)
+			}
+		})
+	}
+	if SandboxBranchID("a.b") == SandboxBranchID("a_b") {
+		t.Error("SandboxBranchID expected different sandboxes for different users")
+	}
+}
@@ -0,0 +1,611 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/encryption"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/xitongsys/parquet-go/parquet"
+)
+
+const (
+	SchemaFormatAvro = "avro"
+	SchemaFormatJSON = "json"
+
+	// SchemaCompatibilityNone accepts any file schema
+	SchemaCompatibilityNone = "none"
+	// SchemaCompatibilityBackward accepts files whose schema reads data of the registered schema
+	SchemaCompatibilityBackward = "backward"
+	// SchemaCompatibilityForward accepts files whose data the registered schema reads
+	SchemaCompatibilityForward = "forward"
+	// SchemaCompatibilityFull accepts files both backward and forward compatible
+	SchemaCompatibilityFull = "full"
+
+	// maxSchemaViolations bounds the violations reported by a failed commit
+	maxSchemaViolations = 20
+
+	avroMagic           = "Obj\x01"
+	avroSchemaMetadata  = "avro.schema"
+	avroHeaderSizeLimit = 1024 * 1024
+)
+
+// Schema is the schema of the Parquet and Avro files under a prefix of a repository.  Commits
+// adding or changing files under the prefix fail unless the schema of every such file is
+// compatible with it.
+type Schema struct {
+	Prefix string `db:"prefix"`
+	// Format is the format of Definition, an Avro record schema or a JSON schema of an object
+	Format        string    `db:"format"`
+	Definition    string    `db:"definition"`
+	Compatibility string    `db:"compatibility"`
+	CreationDate  time.Time `db:"creation_date"`
+}
+
+// SchemaCompatibilityError describes the files of a commit incompatible with their schemas
+type SchemaCompatibilityError struct {
+	Branch     string
+	Violations []string
+}
+
+func (e *SchemaCompatibilityError) Error() string {
+	return fmt.Sprintf("commit to '%s' breaks registered schemas: %s", e.Branch, strings.Join(e.Violations, ", "))
+}
+
+func (e *SchemaCompatibilityError) Unwrap() error {
+	return ErrSchemaIncompatible
+}
+
+// schemaField is a top-level field of a schema, typed by the names of Avro types
+type schemaField struct {
+	Type     string
+	Optional bool
+}
+
+type schemaFields map[string]schemaField
+
+// schemaPromotions are the types read from data written with other types, by the rules of
+// Avro schema resolution
+var schemaPromotions = map[string][]string{
+	"long":   {"int"},
+	"float":  {"int", "long"},
+	"double": {"int", "long", "float"},
+	"string": {"bytes"},
+	"bytes":  {"string"},
+}
+
+func validateSchema(schema Schema) error {
+	if schema.Prefix == "" {
+		return fmt.Errorf("prefix: %w", ErrInvalidValue)
+	}
+	switch schema.Compatibility {
+	case SchemaCompatibilityNone, SchemaCompatibilityBackward, SchemaCompatibilityForward, SchemaCompatibilityFull:
+	default:
+		return fmt.Errorf("compatibility %s: %w", schema.Compatibility, ErrInvalidValue)
+	}
+	_, err := parseSchemaDefinition(schema.Format, []byte(schema.Definition))
+	return err
+}
+
+func (c *cataloger) SetSchema(ctx context.Context, repository string, schema Schema) error {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+	}); err != nil {
+		return err
+	}
+	if err := validateSchema(schema); err != nil {
+		return err
+	}
+	if _, err := c.EntryCatalog.GetRepository(ctx, graveler.RepositoryID(repository)); err != nil {
+		return err
+	}
+	_, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		return tx.Exec(`INSERT INTO catalog_schemas (repository_id, prefix, format, definition, compatibility, creation_date)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (repository_id, prefix) DO UPDATE SET format = EXCLUDED.format,
+				definition = EXCLUDED.definition, compatibility = EXCLUDED.compatibility,
+				creation_date = EXCLUDED.creation_date`,
+			repository, schema.Prefix, schema.Format, schema.Definition, schema.Compatibility, time.Now().UTC())
+	}, db.WithContext(ctx))
+	return err
+}
+
+func (c *cataloger) GetSchema(ctx context.Context, repository, prefix string) (*Schema, error) {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+	}); err != nil {
+		return nil, err
+	}
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var schema Schema
+		err := tx.Get(&schema, `SELECT prefix, format, definition, compatibility, creation_date
+			FROM catalog_schemas WHERE repository_id = $1 AND prefix = $2`,
+			repository, prefix)
+		return &schema, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, ErrSchemaNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res.(*Schema), nil
+}
+
+func (c *cataloger) ListSchemas(ctx context.Context, repository string) ([]*Schema, error) {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+	}); err != nil {
+		return nil, err
+	}
+	res, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var schemas []*Schema
+		err := tx.Select(&schemas, `SELECT prefix, format, definition, compatibility, creation_date
+			FROM catalog_schemas WHERE repository_id = $1 ORDER BY prefix`,
+			repository)
+		return schemas, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.([]*Schema), nil
+}
+
+func (c *cataloger) DeleteSchema(ctx context.Context, repository, prefix string) error {
+	if err := Validate([]ValidateArg{
+		{"repository", graveler.RepositoryID(repository), ValidateRepositoryID},
+	}); err != nil {
+		return err
+	}
+	_, err := c.db.Transact(func(tx db.Tx) (interface{}, error) {
+		res, err := tx.Exec(`DELETE FROM catalog_schemas WHERE repository_id = $1 AND prefix = $2`,
+			repository, prefix)
+		if err != nil {
+			return nil, err
+		}
+		if res.RowsAffected() == 0 {
+			return nil, ErrSchemaNotFound
+		}
+		return nil, nil
+	}, db.WithContext(ctx))
+	return err
+}
+
+// checkSchemas verifies the Parquet and Avro files added or changed by the uncommitted changes of
+// branch against the schemas registered for their prefixes
+func (c *cataloger) checkSchemas(ctx context.Context, repository, branch string) error {
+	schemas, err := c.ListSchemas(ctx, repository)
+	if err != nil {
+		return err
+	}
+	if len(schemas) == 0 {
+		return nil
+	}
+	violations, err := c.schemaViolations(ctx, graveler.RepositoryID(repository), graveler.BranchID(branch), schemas)
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return &SchemaCompatibilityError{Branch: branch, Violations: violations}
+	}
+	return nil
+}
+
+func (c *cataloger) schemaViolations(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, schemas []*Schema) ([]string, error) {
+	repo, err := c.EntryCatalog.GetRepository(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	it, err := c.EntryCatalog.DiffUncommitted(ctx, repositoryID, branchID, graveler.NewDiffTypeMask(graveler.DiffTypeAdded, graveler.DiffTypeChanged))
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+	var violations []string
+	for it.Next() && len(violations) < maxSchemaViolations {
+		v := it.Value()
+		path := v.Path.String()
+		format := schemaFileFormat(path)
+		schema := matchSchema(schemas, path)
+		if format == "" || schema == nil || schema.Compatibility == SchemaCompatibilityNone ||
+			v.Entry == nil || v.Entry.DirectoryMarker || v.Entry.LinkTarget != "" {
+			continue
+		}
+		registered, err := parseSchemaDefinition(schema.Format, []byte(schema.Definition))
+		if err != nil {
+			return nil, err
+		}
+		fileFields, err := c.readFileSchema(repo.StorageNamespace.String(), format, v.Entry)
+		if err != nil {
+			violations = append(violations, fmt.Sprintf("%s: %s", path, err))
+			continue
+		}
+		for _, violation := range schemaCompatibilityViolations(schema.Compatibility, registered, fileFields) {
+			violations = append(violations, fmt.Sprintf("%s: %s", path, violation))
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	if len(violations) > maxSchemaViolations {
+		violations = violations[:maxSchemaViolations]
+	}
+	return violations, nil
+}
+
+// matchSchema returns the schema of the longest prefix of path, nil if none matches
+func matchSchema(schemas []*Schema, path string) *Schema {
+	var match *Schema
+	for _, schema := range schemas {
+		if strings.HasPrefix(path, schema.Prefix) && (match == nil || len(schema.Prefix) > len(match.Prefix)) {
+			match = schema
+		}
+	}
+	return match
+}
+
+// schemaFileFormat returns the format of the data file at path by its extension, or an empty
+// string if its schema is not checked
+func schemaFileFormat(path string) string {
+	lower := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(lower, ".parquet"):
+		return TableFormatParquet
+	case strings.HasSuffix(lower, ".avro"):
+		return SchemaFormatAvro
+	default:
+		return ""
+	}
+}
+
+func (c *cataloger) readFileSchema(storageNamespace, format string, entry *Entry) (schemaFields, error) {
+	if encryption.IsEncrypted(entry.Metadata) {
+		return nil, fmt.Errorf("%w: encrypted object", ErrInvalidSchema)
+	}
+	r := &tableReader{
+		adapter: c.EntryCatalog.BlockAdapter,
+		pointer: block.ObjectPointer{StorageNamespace: storageNamespace, Identifier: entry.Address},
+		size:    entry.Size,
+	}
+	switch format {
+	case TableFormatParquet:
+		metadata, err := r.parquetFooter()
+		if err != nil {
+			return nil, err
+		}
+		return parquetSchemaFields(metadata.GetSchema()), nil
+	case SchemaFormatAvro:
+		size := r.size
+		if size > avroHeaderSizeLimit {
+			size = avroHeaderSizeLimit
+		}
+		header, err := r.readRange(0, size)
+		if err != nil {
+			return nil, err
+		}
+		definition, err := avroFileSchema(header)
+		if err != nil {
+			return nil, err
+		}
+		return parseAvroSchema(definition)
+	default:
+		return nil, fmt.Errorf("%w: unknown format %s", ErrInvalidSchema, format)
+	}
+}
+
+// schemaCompatibilityViolations lists the reasons the schema of a file is not compatible with
+// the registered schema at level compatibility
+func schemaCompatibilityViolations(compatibility string, registered, file schemaFields) []string {
+	switch compatibility {
+	case SchemaCompatibilityBackward:
+		return readViolations(file, registered)
+	case SchemaCompatibilityForward:
+		return readViolations(registered, file)
+	case SchemaCompatibilityFull:
+		return append(readViolations(file, registered), readViolations(registered, file)...)
+	default:
+		return nil
+	}
+}
+
+// readViolations lists the reasons data written with schema writer can not be read with schema
+// reader
+func readViolations(reader, writer schemaFields) []string {
+	names := make([]string, 0, len(reader))
+	for name := range reader {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var violations []string
+	for _, name := range names {
+		r := reader[name]
+		w, ok := writer[name]
+		switch {
+		case !ok && !r.Optional:
+			violations = append(violations, fmt.Sprintf("field %s required but missing", name))
+		case !ok:
+		case !canReadType(r.Type, w.Type):
+			violations = append(violations, fmt.Sprintf("field %s of type %s read as %s", name, w.Type, r.Type))
+		case w.Optional && !r.Optional:
+			violations = append(violations, fmt.Sprintf("field %s optional but read as required", name))
+		}
+	}
+	return violations
+}
+
+func canReadType(readerType, writerType string) bool {
+	if readerType == writerType {
+		return true
+	}
+	for _, t := range schemaPromotions[readerType] {
+		if t == writerType {
+			return true
+		}
+	}
+	return false
+}
+
+func parseSchemaDefinition(format string, definition []byte) (schemaFields, error) {
+	switch format {
+	case SchemaFormatAvro:
+		return parseAvroSchema(definition)
+	case SchemaFormatJSON:
+		return parseJSONSchema(definition)
+	default:
+		return nil, fmt.Errorf("%w: unknown format %s", ErrInvalidSchema, format)
+	}
+}
+
+// parseAvroSchema returns the fields of an Avro record schema
+func parseAvroSchema(definition []byte) (schemaFields, error) {
+	var record struct {
+		Type   string `json:"type"`
+		Fields []struct {
+			Name    string          `json:"name"`
+			Type    json.RawMessage `json:"type"`
+			Default json.RawMessage `json:"default"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal(definition, &record); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSchema, err)
+	}
+	if record.Type != "record" {
+		return nil, fmt.Errorf("%w: avro schema of type %s is not a record", ErrInvalidSchema, record.Type)
+	}
+	fields := make(schemaFields, len(record.Fields))
+	for _, f := range record.Fields {
+		typ, nullable, err := avroTypeName(f.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		fields[f.Name] = schemaField{Type: typ, Optional: nullable || f.Default != nil}
+	}
+	return fields, nil
+}
+
+// avroTypeName returns the name of an Avro type and whether it is a union with null
+func avroTypeName(raw json.RawMessage) (string, bool, error) {
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		return name, name == "null", nil
+	}
+	var union []json.RawMessage
+	if err := json.Unmarshal(raw, &union); err == nil {
+		var types []string
+		nullable := false
+		for _, u := range union {
+			t, _, err := avroTypeName(u)
+			if err != nil {
+				return "", false, err
+			}
+			if t == "null" {
+				nullable = true
+				continue
+			}
+			types = append(types, t)
+		}
+		return strings.Join(types, "|"), nullable, nil
+	}
+	var complexType struct {
+		Type json.RawMessage `json:"type"`
+	}
+	if err := json.Unmarshal(raw, &complexType); err == nil && complexType.Type != nil {
+		return avroTypeName(complexType.Type)
+	}
+	return "", false, fmt.Errorf("%w: avro type %s", ErrInvalidSchema, raw)
+}
+
+// jsonSchemaTypes are the Avro types of JSON schema types
+var jsonSchemaTypes = map[string]string{
+	"integer": "long",
+	"number":  "double",
+	"string":  "string",
+	"boolean": "boolean",
+	"object":  "record",
+	"array":   "array",
+	"null":    "null",
+}
+
+// parseJSONSchema returns the properties of a JSON schema of an object
+func parseJSONSchema(definition []byte) (schemaFields, error) {
+	var object struct {
+		Type       string `json:"type"`
+		Properties map[string]struct {
+			Type json.RawMessage `json:"type"`
+		} `json:"properties"`
+		Required []string `json:"required"`
+	}
+	if err := json.Unmarshal(definition, &object); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSchema, err)
+	}
+	if object.Type != "object" {
+		return nil, fmt.Errorf("%w: json schema of type %s is not an object", ErrInvalidSchema, object.Type)
+	}
+	fields := make(schemaFields, len(object.Properties))
+	for name, property := range object.Properties {
+		var names []string
+		if err := json.Unmarshal(property.Type, &names); err != nil {
+			var single string
+			if err := json.Unmarshal(property.Type, &single); err != nil {
+				return nil, fmt.Errorf("%w: property %s type %s", ErrInvalidSchema, name, property.Type)
+			}
+			names = []string{single}
+		}
+		field := schemaField{Optional: !contains(object.Required, name)}
+		var types []string
+		for _, n := range names {
+			t, ok := jsonSchemaTypes[n]
+			if !ok {
+				return nil, fmt.Errorf("%w: property %s type %s", ErrInvalidSchema, name, n)
+			}
+			if t == "null" {
+				field.Optional = true
+				continue
+			}
+			types = append(types, t)
+		}
+		field.Type = strings.Join(types, "|")
+		fields[name] = field
+	}
+	return fields, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// parquetSchemaFields returns the top-level columns of a flattened Parquet schema
+func parquetSchemaFields(schema []*parquet.SchemaElement) schemaFields {
+	fields := make(schemaFields)
+	if len(schema) == 0 {
+		return fields
+	}
+	// skip returns the index following the subtree of element i
+	var skip func(i int) int
+	skip = func(i int) int {
+		children := schema[i].GetNumChildren()
+		i++
+		for child := int32(0); child < children && i < len(schema); child++ {
+			i = skip(i)
+		}
+		return i
+	}
+	// the first element is the root of the schema
+	i := 1
+	for child := int32(0); child < schema[0].GetNumChildren() && i < len(schema); child++ {
+		element := schema[i]
+		fields[element.GetName()] = schemaField{
+			Type:     parquetAvroType(element),
+			Optional: element.GetRepetitionType() == parquet.FieldRepetitionType_OPTIONAL,
+		}
+		i = skip(i)
+	}
+	return fields
+}
+
+// parquetAvroType returns the name of the Avro type of a Parquet column
+func parquetAvroType(element *parquet.SchemaElement) string {
+	if element.GetNumChildren() > 0 {
+		switch element.GetConvertedType() {
+		case parquet.ConvertedType_LIST:
+			return "array"
+		case parquet.ConvertedType_MAP, parquet.ConvertedType_MAP_KEY_VALUE:
+			return "map"
+		default:
+			return "record"
+		}
+	}
+	if element.GetRepetitionType() == parquet.FieldRepetitionType_REPEATED {
+		return "array"
+	}
+	switch element.GetType() {
+	case parquet.Type_BOOLEAN:
+		return "boolean"
+	case parquet.Type_INT32:
+		return "int"
+	case parquet.Type_INT64:
+		return "long"
+	case parquet.Type_FLOAT:
+		return "float"
+	case parquet.Type_DOUBLE:
+		return "double"
+	case parquet.Type_BYTE_ARRAY:
+		if element.IsSetConvertedType() {
+			switch element.GetConvertedType() {
+			case parquet.ConvertedType_UTF8, parquet.ConvertedType_ENUM, parquet.ConvertedType_JSON:
+				return "string"
+			}
+		}
+		return "bytes"
+	default:
+		return "bytes"
+	}
+}
+
+// avroFileSchema returns the schema in the header of an Avro object container file
+func avroFileSchema(header []byte) ([]byte, error) {
+	if !bytes.HasPrefix(header, []byte(avroMagic)) {
+		return nil, fmt.Errorf("%w: missing avro magic", ErrInvalidSchema)
+	}
+	r := bytes.NewReader(header[len(avroMagic):])
+	for {
+		// the metadata map is encoded in blocks of entries ending with an empty block
+		count, err := binary.ReadVarint(r)
+		if err != nil {
+			return nil, fmt.Errorf("%w: avro header: %s", ErrInvalidSchema, err)
+		}
+		if count == 0 {
+			break
+		}
+		if count < 0 {
+			// a negative count is followed by the size of the block
+			count = -count
+			if _, err := binary.ReadVarint(r); err != nil {
+				return nil, fmt.Errorf("%w: avro header: %s", ErrInvalidSchema, err)
+			}
+		}
+		for ; count > 0; count-- {
+			key, err := readAvroBytes(r)
+			if err != nil {
+				return nil, err
+			}
+			value, err := readAvroBytes(r)
+			if err != nil {
+				return nil, err
+			}
+			if string(key) == avroSchemaMetadata {
+				return value, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: avro header missing schema", ErrInvalidSchema)
+}
+
+func readAvroBytes(r *bytes.Reader) ([]byte, error) {
+	length, err := binary.ReadVarint(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: avro header: %s", ErrInvalidSchema, err)
+	}
+	if length < 0 || length > int64(r.Len()) {
+		return nil, fmt.Errorf("%w: avro header length %d", ErrInvalidSchema, length)
+	}
+	b := make([]byte, length)
+	if _, err := r.Read(b); err != nil {
+		return nil, fmt.Errorf("%w: avro header: %s", ErrInvalidSchema, err)
+	}
+	return b, nil
+}
@@ -0,0 +1,181 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/block/mem"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+const testAvroUserSchema = `{"type": "record", "name": "user", "fields": [
+	{"name": "id", "type": "long"},
+	{"name": "name", "type": "string"},
+	{"name": "age", "type": ["null", "int"], "default": null}
+]}`
+
+func TestSchemaCompatibilityViolations(t *testing.T) {
+	registered, err := parseAvroSchema([]byte(testAvroUserSchema))
+	if err != nil {
+		t.Fatalf("parse registered schema: %s", err)
+	}
+	tests := []struct {
+		name          string
+		file          string
+		compatibility string
+		want          []string
+	}{
+		{
+			name:          "identical",
+			file:          testAvroUserSchema,
+			compatibility: SchemaCompatibilityFull,
+		},
+		{
+			name:          "added optional field",
+			file:          `{"type": "record", "fields": [{"name": "id", "type": "long"}, {"name": "name", "type": "string"}, {"name": "email", "type": ["null", "string"]}]}`,
+			compatibility: SchemaCompatibilityFull,
+		},
+		{
+			name:          "added required field backward",
+			file:          `{"type": "record", "fields": [{"name": "id", "type": "long"}, {"name": "name", "type": "string"}, {"name": "email", "type": "string"}]}`,
+			compatibility: SchemaCompatibilityBackward,
+			want:          []string{"field email required but missing"},
+		},
+		{
+			name:          "added required field forward",
+			file:          `{"type": "record", "fields": [{"name": "id", "type": "long"}, {"name": "name", "type": "string"}, {"name": "email", "type": "string"}]}`,
+			compatibility: SchemaCompatibilityForward,
+		},
+		{
+			name:          "removed required field forward",
+			file:          `{"type": "record", "fields": [{"name": "id", "type": "long"}]}`,
+			compatibility: SchemaCompatibilityForward,
+			want:          []string{"field name required but missing"},
+		},
+		{
+			name:          "promoted type backward",
+			file:          `{"type": "record", "fields": [{"name": "id", "type": "long"}, {"name": "name", "type": "string"}, {"name": "age", "type": ["null", "long"]}]}`,
+			compatibility: SchemaCompatibilityBackward,
+		},
+		{
+			name:          "promoted type full",
+			file:          `{"type": "record", "fields": [{"name": "id", "type": "long"}, {"name": "name", "type": "string"}, {"name": "age", "type": ["null", "long"]}]}`,
+			compatibility: SchemaCompatibilityFull,
+			want:          []string{"field age of type long read as int"},
+		},
+		{
+			name:          "changed type none",
+			file:          `{"type": "record", "fields": [{"name": "id", "type": "string"}]}`,
+			compatibility: SchemaCompatibilityNone,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := parseAvroSchema([]byte(tt.file))
+			if err != nil {
+				t.Fatalf("parse file schema: %s", err)
+			}
+			got := schemaCompatibilityViolations(tt.compatibility, registered, file)
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Error("violations diff found", diff)
+			}
+		})
+	}
+}
+
+func TestParseJSONSchema(t *testing.T) {
+	fields, err := parseJSONSchema([]byte(`{"type": "object", "required": ["id"], "properties": {
+		"id": {"type": "integer"}, "score": {"type": ["number", "null"]}, "name": {"type": "string"}}}`))
+	if err != nil {
+		t.Fatalf("parseJSONSchema: %s", err)
+	}
+	expected := schemaFields{
+		"id":    {Type: "long"},
+		"score": {Type: "double", Optional: true},
+		"name":  {Type: "string", Optional: true},
+	}
+	if diff := deep.Equal(fields, expected); diff != nil {
+		t.Error("fields diff found", diff)
+	}
+	if _, err := parseJSONSchema([]byte(`{"type": "array"}`)); err == nil {
+		t.Error("parseJSONSchema of an array succeeded, expected an error")
+	}
+}
+
+func writeTestAvroHeader(schema string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(avroMagic)
+	varint := make([]byte, binary.MaxVarintLen64)
+	writeLong := func(v int64) {
+		buf.Write(varint[:binary.PutVarint(varint, v)])
+	}
+	writeBytes := func(s string) {
+		writeLong(int64(len(s)))
+		buf.WriteString(s)
+	}
+	writeLong(2)
+	writeBytes("avro.codec")
+	writeBytes("null")
+	writeBytes(avroSchemaMetadata)
+	writeBytes(schema)
+	writeLong(0)
+	// sync marker
+	buf.WriteString(strings.Repeat("s", 16))
+	return buf.Bytes()
+}
+
+func TestCataloger_SchemaViolations(t *testing.T) {
+	ctx := context.Background()
+	const storageNamespace = "mem://schemas"
+	adapter := mem.New()
+	put := func(address string, data []byte) *Entry {
+		err := adapter.Put(block.ObjectPointer{StorageNamespace: storageNamespace, Identifier: address}, int64(len(data)), bytes.NewReader(data), block.PutOpts{})
+		if err != nil {
+			t.Fatalf("put %s: %s", address, err)
+		}
+		return &Entry{Address: address, Size: int64(len(data))}
+	}
+	parquetData := writeTestParquet(t, new(dataDiffRecordV1), dataDiffRecordV1{ID: 1, Name: "a", Age: 10})
+	diffs := []*graveler.Diff{
+		{Type: graveler.DiffTypeAdded, Key: graveler.Key("events/day=1/part.avro"), Value: MustEntryToValue(put("avro", writeTestAvroHeader(
+			`{"type": "record", "fields": [{"name": "id", "type": "long"}, {"name": "kind", "type": "string"}]}`)))},
+		{Type: graveler.DiffTypeAdded, Key: graveler.Key("other/part.parquet"), Value: MustEntryToValue(put("other", []byte("unchecked")))},
+		{Type: graveler.DiffTypeChanged, Key: graveler.Key("users/legacy/part.parquet"), Value: MustEntryToValue(put("broken", []byte("not parquet")))},
+		{Type: graveler.DiffTypeAdded, Key: graveler.Key("users/part.csv"), Value: MustEntryToValue(put("csv", []byte("id\n1\n")))},
+		{Type: graveler.DiffTypeAdded, Key: graveler.Key("users/part.parquet"), Value: MustEntryToValue(put("parquet", parquetData))},
+	}
+	c := &cataloger{
+		EntryCatalog: &EntryCatalog{
+			Store: &FakeGraveler{
+				DiffIteratorFactory: NewFakeDiffIteratorFactory(diffs),
+				Repositories: map[graveler.RepositoryID]*graveler.Repository{
+					"repo": {StorageNamespace: storageNamespace, DefaultBranchID: "main"},
+				},
+			},
+			BlockAdapter: adapter,
+		},
+	}
+	schemas := []*Schema{
+		{Prefix: "events/", Format: SchemaFormatJSON, Compatibility: SchemaCompatibilityBackward,
+			Definition: `{"type": "object", "required": ["id", "ts"], "properties": {"id": {"type": "integer"}, "ts": {"type": "integer"}}}`},
+		{Prefix: "users/", Format: SchemaFormatAvro, Compatibility: SchemaCompatibilityFull, Definition: testAvroUserSchema},
+		{Prefix: "users/legacy/", Format: SchemaFormatAvro, Compatibility: SchemaCompatibilityBackward, Definition: testAvroUserSchema},
+	}
+	violations, err := c.schemaViolations(ctx, "repo", "main", schemas)
+	if err != nil {
+		t.Fatalf("schemaViolations: %s", err)
+	}
+	expected := []string{
+		"events/day=1/part.avro: field kind required but missing",
+		"users/legacy/part.parquet: invalid table: too small for parquet",
+		"users/part.parquet: field age optional but read as required",
+	}
+	if diff := deep.Equal(violations, expected); diff != nil {
+		t.Error("violations diff found", diff)
+	}
+}
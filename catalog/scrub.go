@@ -0,0 +1,230 @@
+package catalog
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/encryption"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/logging"
+)
+
+type ScrubIssueType string
+
+const (
+	// ScrubIssueMissing is an object that does not exist or cannot be read
+	ScrubIssueMissing ScrubIssueType = "missing"
+	// ScrubIssueSizeMismatch is an object whose size differs from the size of its entry
+	ScrubIssueSizeMismatch ScrubIssueType = "size_mismatch"
+	// ScrubIssueChecksumMismatch is an object whose MD5 digest differs from the ETag of its entry
+	ScrubIssueChecksumMismatch ScrubIssueType = "checksum_mismatch"
+)
+
+var (
+	scrubEntriesChecked = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "scrub_entries_checked_total",
+			Help: "Entries whose objects were read by the scrubber",
+		}, []string{"repository"})
+	scrubIssues = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "scrub_issues_total",
+			Help: "Objects found by the scrubber not matching their entries, by issue type",
+		}, []string{"repository", "type"})
+)
+
+type ScrubParams struct {
+	// Prefix limits the scrub to the entries under it
+	Prefix string
+	// SampleRate is the fraction of entries checked, all entries are checked when it is 0 or above 1
+	SampleRate float64
+}
+
+type ScrubIssue struct {
+	Path            string         `json:"path"`
+	PhysicalAddress string         `json:"physical_address"`
+	Type            ScrubIssueType `json:"type"`
+	Expected        string         `json:"expected,omitempty"`
+	Actual          string         `json:"actual,omitempty"`
+}
+
+type ScrubReport struct {
+	Repository string       `json:"repository"`
+	Reference  string       `json:"reference"`
+	StartTime  time.Time    `json:"start_time"`
+	EndTime    time.Time    `json:"end_time"`
+	Scanned    int          `json:"scanned"`
+	Checked    int          `json:"checked"`
+	Issues     []ScrubIssue `json:"issues"`
+}
+
+// Scrub reads the objects of the entries of reference, or a sample of them, and reports the
+// objects that are missing or do not match the size and ETag of their entries.  The digest of
+// objects uploaded in parts, and of encrypted objects, is not verified, as their ETag is not the
+// MD5 digest of their data.  Entries linking to other repositories, and entries of redacted
+// objects, are skipped.
+func (c *cataloger) Scrub(ctx context.Context, repository, reference string, params ScrubParams) (*ScrubReport, error) {
+	repositoryID := graveler.RepositoryID(repository)
+	repo, err := c.EntryCatalog.GetRepository(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	// redacted objects were removed on purpose
+	redacted := make(map[string]struct{})
+	if err := c.redactedAddresses(ctx, repository, redacted); err != nil {
+		return nil, err
+	}
+	it, err := c.EntryCatalog.ListEntries(ctx, repositoryID, graveler.Ref(reference), Path(params.Prefix), "")
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	report := &ScrubReport{
+		Repository: repository,
+		Reference:  reference,
+		StartTime:  time.Now().UTC(),
+		Issues:     make([]ScrubIssue, 0),
+	}
+	sample := params.SampleRate > 0 && params.SampleRate < 1
+	for it.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		v := it.Value()
+		// generated entries have no data to check
+		if v.CommonPrefix || v.Entry == nil || v.LinkRepository != "" || IsGeneratedAddress(v.Address) {
+			continue
+		}
+		if _, ok := redacted[v.Address]; ok {
+			continue
+		}
+		report.Scanned++
+		if sample && rand.Float64() >= params.SampleRate { //nolint:gosec
+			continue
+		}
+		report.Checked++
+		scrubEntriesChecked.WithLabelValues(repository).Inc()
+		issue := c.scrubEntry(repo.StorageNamespace.String(), v.Path.String(), v.Entry)
+		if issue != nil {
+			scrubIssues.WithLabelValues(repository, string(issue.Type)).Inc()
+			report.Issues = append(report.Issues, *issue)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	report.EndTime = time.Now().UTC()
+	return report, nil
+}
+
+// scrubEntry reads the object of entry, returns the issue found or nil if it matches entry
+func (c *cataloger) scrubEntry(storageNamespace, path string, entry *Entry) *ScrubIssue {
+	issue := &ScrubIssue{
+		Path:            path,
+		PhysicalAddress: entry.Address,
+	}
+	reader, err := c.EntryCatalog.BlockAdapter.Get(block.ObjectPointer{
+		StorageNamespace: storageNamespace,
+		Identifier:       entry.Address,
+	}, entry.Size)
+	if err != nil {
+		issue.Type = ScrubIssueMissing
+		issue.Actual = err.Error()
+		return issue
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	digest := md5.New() //nolint:gosec
+	size, err := io.Copy(digest, reader)
+	if err != nil {
+		issue.Type = ScrubIssueMissing
+		issue.Actual = err.Error()
+		return issue
+	}
+	if size != entry.Size {
+		issue.Type = ScrubIssueSizeMismatch
+		issue.Expected = strconv.FormatInt(entry.Size, 10)
+		issue.Actual = strconv.FormatInt(size, 10)
+		return issue
+	}
+	if strings.Contains(entry.ETag, "-") || encryption.IsEncrypted(entry.Metadata) {
+		return nil
+	}
+	checksum := hex.EncodeToString(digest.Sum(nil))
+	if checksum != entry.ETag {
+		issue.Type = ScrubIssueChecksumMismatch
+		issue.Expected = entry.ETag
+		issue.Actual = checksum
+		return issue
+	}
+	return nil
+}
+
+// RunScrubber scrubs the default branch of every repository each interval, sampling sampleRate
+// of the entries.  Each report with issues is logged, and written as JSON to reportDir when set.
+// Only repositories for which owns returns true are scrubbed, all of them when owns is nil.
+func RunScrubber(ctx context.Context, c Cataloger, interval time.Duration, sampleRate float64, reportDir string, owns func(repository string) bool) {
+	log := logging.FromContext(ctx).WithField("service", "scrubber")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			repos, _, err := c.ListRepositories(ctx, -1, "")
+			if err != nil {
+				log.WithError(err).Error("Failed to list repositories")
+				continue
+			}
+			for _, repo := range repos {
+				if owns != nil && !owns(repo.Name) {
+					continue
+				}
+				report, err := c.Scrub(ctx, repo.Name, repo.DefaultBranch, ScrubParams{SampleRate: sampleRate})
+				if err != nil {
+					log.WithError(err).WithField("repository", repo.Name).Error("Failed to scrub repository")
+					continue
+				}
+				if len(report.Issues) == 0 {
+					continue
+				}
+				log.WithFields(logging.Fields{
+					"repository": repo.Name,
+					"checked":    report.Checked,
+					"issues":     len(report.Issues),
+				}).Warn("Scrub found objects not matching their entries")
+				if reportDir != "" {
+					if err := WriteScrubReport(reportDir, report); err != nil {
+						log.WithError(err).Error("Failed to write scrub report")
+					}
+				}
+			}
+		}
+	}
+}
+
+// WriteScrubReport writes report as JSON to a file in dir named by its repository and start time
+func WriteScrubReport(dir string, report *ScrubReport) error {
+	name := fmt.Sprintf("scrub-%s-%s.json", report.Repository, report.StartTime.Format("20060102T150405Z"))
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, name), data, 0600)
+}
@@ -0,0 +1,83 @@
+package catalog
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/block/mem"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/testutil"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestCataloger_Scrub(t *testing.T) {
+	ctx := context.Background()
+	const storageNamespace = "mem://scrub"
+	adapter := mem.New()
+	put := func(address, data string) string {
+		err := adapter.Put(block.ObjectPointer{StorageNamespace: storageNamespace, Identifier: address}, int64(len(data)), strings.NewReader(data), block.PutOpts{})
+		if err != nil {
+			t.Fatalf("put %s: %s", address, err)
+		}
+		checksum := md5.Sum([]byte(data)) //nolint:gosec
+		return hex.EncodeToString(checksum[:])
+	}
+	goodETag := put("good", "good data")
+	corruptETag := put("corrupt", "original")
+	put("corrupt", "modified")
+	put("truncated", "dat")
+	put("multipart", "parts")
+
+	now := timestamppb.New(time.Now())
+	gravelerData := []*graveler.ValueRecord{
+		{Key: graveler.Key("a/corrupt"), Value: MustEntryToValue(&Entry{Address: "corrupt", LastModified: now, Size: 8, ETag: corruptETag})},
+		{Key: graveler.Key("a/good"), Value: MustEntryToValue(&Entry{Address: "good", LastModified: now, Size: 9, ETag: goodETag})},
+		{Key: graveler.Key("a/link"), Value: MustEntryToValue(&Entry{LastModified: now, LinkRepository: "other", LinkRef: "main", LinkTarget: "file"})},
+		{Key: graveler.Key("a/missing"), Value: MustEntryToValue(&Entry{Address: "missing", LastModified: now, Size: 1, ETag: "01"})},
+		{Key: graveler.Key("a/multipart"), Value: MustEntryToValue(&Entry{Address: "multipart", LastModified: now, Size: 5, ETag: "0123-2"})},
+		{Key: graveler.Key("a/redacted"), Value: MustEntryToValue(&Entry{Address: "redacted", LastModified: now, Size: 1, ETag: "01"})},
+		{Key: graveler.Key("a/truncated"), Value: MustEntryToValue(&Entry{Address: "truncated", LastModified: now, Size: 4, ETag: "01"})},
+		{Key: graveler.Key("b/missing"), Value: MustEntryToValue(&Entry{Address: "missing", LastModified: now, Size: 1, ETag: "01"})},
+	}
+	c := testCataloger(t, &FakeGraveler{
+		ListIteratorFactory: NewFakeValueIteratorFactory(gravelerData),
+		Repositories: map[graveler.RepositoryID]*graveler.Repository{
+			"repo": {StorageNamespace: storageNamespace, DefaultBranchID: "main"},
+		},
+	})
+	c.EntryCatalog.BlockAdapter = adapter
+	// the data of redacted objects is removed on purpose
+	_, err := c.db.Exec(`INSERT INTO catalog_redactions (storage_namespace, physical_address, repository_id, path, reason, redacted_by, creation_date)
+		VALUES ($1, 'redacted', 'repo', 'a/redacted', 'leaked', 'admin', NOW())`, storageNamespace)
+	testutil.MustDo(t, "insert redaction", err)
+
+	report, err := c.Scrub(ctx, "repo", "main", ScrubParams{Prefix: "a/"})
+	if err != nil {
+		t.Fatalf("Scrub: %s", err)
+	}
+	if report.Scanned != 5 || report.Checked != 5 {
+		t.Errorf("Scrub scanned %d and checked %d entries, expected 5 and 5", report.Scanned, report.Checked)
+	}
+	for i := range report.Issues {
+		if report.Issues[i].Type == ScrubIssueMissing && report.Issues[i].Actual == "" {
+			t.Errorf("missing object issue %+v without error", report.Issues[i])
+		}
+		if report.Issues[i].Type == ScrubIssueMissing {
+			report.Issues[i].Actual = ""
+		}
+	}
+	expected := []ScrubIssue{
+		{Path: "a/corrupt", PhysicalAddress: "corrupt", Type: ScrubIssueChecksumMismatch, Expected: corruptETag, Actual: put("corrupt", "modified")},
+		{Path: "a/missing", PhysicalAddress: "missing", Type: ScrubIssueMissing},
+		{Path: "a/truncated", PhysicalAddress: "truncated", Type: ScrubIssueSizeMismatch, Expected: "4", Actual: "3"},
+	}
+	if diff := deep.Equal(report.Issues, expected); diff != nil {
+		t.Error("Scrub() issues diff found", diff)
+	}
+}
@@ -0,0 +1,62 @@
+package catalog
+
+import (
+	"context"
+	"time"
+
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/logging"
+)
+
+// TreeUpgradeCommitter is the committer of upgrade commits made by RunTreeUpgrader
+const TreeUpgradeCommitter = "lakefs"
+
+// UpgradeTrees commits the tree of every branch head of repository rewritten in the current tree
+// format version
+func (c *cataloger) UpgradeTrees(ctx context.Context, repository, committer string) (map[string]string, error) {
+	res, err := c.EntryCatalog.UpgradeTrees(ctx, graveler.RepositoryID(repository), committer)
+	if err != nil {
+		return nil, err
+	}
+	upgraded := make(map[string]string, len(res))
+	for branchID, commitID := range res {
+		upgraded[branchID.String()] = commitID.String()
+	}
+	return upgraded, nil
+}
+
+// RunTreeUpgrader upgrades the trees of the branches of every repository each interval.
+// Only repositories for which enabled returns true are upgraded, all of them when enabled is nil.
+func RunTreeUpgrader(ctx context.Context, c Cataloger, interval time.Duration, enabled func(repository string) bool) {
+	log := logging.FromContext(ctx).WithField("service", "tree_upgrader")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			repos, _, err := c.ListRepositories(ctx, -1, "")
+			if err != nil {
+				log.WithError(err).Error("Failed to list repositories")
+				continue
+			}
+			for _, repo := range repos {
+				if enabled != nil && !enabled(repo.Name) {
+					continue
+				}
+				upgraded, err := c.UpgradeTrees(ctx, repo.Name, TreeUpgradeCommitter)
+				if err != nil {
+					log.WithError(err).WithField("repository", repo.Name).Error("Failed to upgrade trees")
+					continue
+				}
+				if len(upgraded) > 0 {
+					log.WithFields(logging.Fields{
+						"repository": repo.Name,
+						"branches":   len(upgraded),
+					}).Info("Upgraded branch trees")
+				}
+			}
+		}
+	}
+}
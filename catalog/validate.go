@@ -5,19 +5,23 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/treeverse/lakefs/graveler"
 	"github.com/treeverse/lakefs/ident"
 )
 
 const (
-	MaxPathLength = 1024
+	MaxPathLength         = 1024
+	EphemeralBranchMaxTTL = 30 * 24 * time.Hour
 )
 
 var (
 	reValidRef          = regexp.MustCompile(`^[^\s]+$`)
 	reValidBranchID     = regexp.MustCompile(`^\w[-\w]*$`)
 	reValidRepositoryID = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{2,62}$`)
+	reValidDatasetName  = regexp.MustCompile(`^\w[-\w.]{0,127}$`)
+	reValidSnapshotID   = regexp.MustCompile(`^\w[-\w.]{0,127}$`)
 )
 
 var (
@@ -164,6 +168,34 @@ func ValidateRepositoryID(v interface{}) error {
 	return nil
 }
 
+func ValidateDatasetName(v interface{}) error {
+	s, ok := v.(string)
+	if !ok {
+		panic(ErrInvalidType)
+	}
+	if len(s) == 0 {
+		return ErrRequiredValue
+	}
+	if !reValidDatasetName.MatchString(s) {
+		return ErrInvalidValue
+	}
+	return nil
+}
+
+func ValidateRepositorySnapshotID(v interface{}) error {
+	s, ok := v.(graveler.RepositorySnapshotID)
+	if !ok {
+		panic(ErrInvalidType)
+	}
+	if len(s) == 0 {
+		return ErrRequiredValue
+	}
+	if !reValidSnapshotID.MatchString(s.String()) {
+		return ErrInvalidValue
+	}
+	return nil
+}
+
 func ValidatePath(v interface{}) error {
 	s, ok := v.(Path)
 	if !ok {
@@ -201,5 +233,33 @@ func ValidateNonNegativeInt(v interface{}) error {
 	return nil
 }
 
+func ValidateEphemeralBranchTTL(v interface{}) error {
+	ttl, ok := v.(time.Duration)
+	if !ok {
+		panic(ErrInvalidType)
+	}
+	if ttl <= 0 {
+		return ErrRequiredValue
+	}
+	if ttl > EphemeralBranchMaxTTL {
+		return fmt.Errorf("%w: %s is above maximum (%s)", ErrInvalidValue, ttl, EphemeralBranchMaxTTL)
+	}
+	return nil
+}
+
+func ValidatePathLeaseTTL(v interface{}) error {
+	ttl, ok := v.(time.Duration)
+	if !ok {
+		panic(ErrInvalidType)
+	}
+	if ttl <= 0 {
+		return ErrRequiredValue
+	}
+	if ttl > PathLeaseMaxTTL {
+		return fmt.Errorf("%w: %s is above maximum (%s)", ErrInvalidValue, ttl, PathLeaseMaxTTL)
+	}
+	return nil
+}
+
 var ValidatePathOptional = MakeValidateOptional(ValidatePath)
 var ValidateTagIDOptional = MakeValidateOptional(ValidateTagID)
@@ -3,6 +3,7 @@ package catalog
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/treeverse/lakefs/graveler"
 )
@@ -49,3 +50,68 @@ func TestValidateTagID_Type(t *testing.T) {
 	}()
 	_ = ValidateTagID("tag")
 }
+
+func TestValidateEphemeralBranchTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		ttl     time.Duration
+		wantErr error
+	}{
+		{name: "zero", ttl: 0, wantErr: ErrRequiredValue},
+		{name: "negative", ttl: -time.Minute, wantErr: ErrRequiredValue},
+		{name: "valid", ttl: time.Hour, wantErr: nil},
+		{name: "max", ttl: EphemeralBranchMaxTTL, wantErr: nil},
+		{name: "above max", ttl: EphemeralBranchMaxTTL + time.Second, wantErr: ErrInvalidValue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateEphemeralBranchTTL(tt.ttl)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateEphemeralBranchTTL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatePathLeaseTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		ttl     time.Duration
+		wantErr error
+	}{
+		{name: "zero", ttl: 0, wantErr: ErrRequiredValue},
+		{name: "negative", ttl: -time.Minute, wantErr: ErrRequiredValue},
+		{name: "default", ttl: DefaultPathLeaseTTL, wantErr: nil},
+		{name: "max", ttl: PathLeaseMaxTTL, wantErr: nil},
+		{name: "above max", ttl: PathLeaseMaxTTL + time.Second, wantErr: ErrInvalidValue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePathLeaseTTL(tt.ttl)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidatePathLeaseTTL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateRepositorySnapshotID(t *testing.T) {
+	tests := []struct {
+		name       string
+		snapshotID graveler.RepositorySnapshotID
+		wantErr    error
+	}{
+		{name: "empty", snapshotID: "", wantErr: ErrRequiredValue},
+		{name: "valid", snapshotID: "before-backfill.2021-01-20", wantErr: nil},
+		{name: "leading dash", snapshotID: "-snapshot", wantErr: ErrInvalidValue},
+		{name: "slash", snapshotID: "snap/shot", wantErr: ErrInvalidValue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRepositorySnapshotID(tt.snapshotID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateRepositorySnapshotID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/treeverse/lakefs/cmdutils"
+	"github.com/treeverse/lakefs/uri"
+)
+
+const isAncestorCmdArgs = 2
+
+var isAncestorCmd = &cobra.Command{
+	Use:   "is-ancestor <ancestor ref uri> <ref uri>",
+	Short: "check whether a reference is an ancestor of another reference",
+	Long:  "exits with status 0 if the commit of the first reference is reachable from the commit of the second reference, and with status 1 otherwise",
+	Args: cmdutils.ValidationChain(
+		cobra.ExactArgs(isAncestorCmdArgs),
+		cmdutils.FuncValidator(0, uri.ValidateRefURI),
+		cmdutils.FuncValidator(1, uri.ValidateRefURI),
+	),
+	Run: func(cmd *cobra.Command, args []string) {
+		ancestorURI := uri.Must(uri.Parse(args[0]))
+		refURI := uri.Must(uri.Parse(args[1]))
+		if ancestorURI.Repository != refURI.Repository {
+			Die("both references must belong to the same repository", 1)
+		}
+		client := getClient()
+		isAncestor, err := client.IsAncestor(context.Background(), refURI.Repository, ancestorURI.Ref, refURI.Ref)
+		if err != nil {
+			DieErr(err)
+		}
+		if !isAncestor {
+			Die(ancestorURI.Ref+" is not an ancestor of "+refURI.Ref, 1)
+		}
+		Fmt("%s is an ancestor of %s\n", ancestorURI.Ref, refURI.Ref)
+	},
+}
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(isAncestorCmd)
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/go-openapi/swag"
 	"github.com/spf13/cobra"
 	"github.com/treeverse/lakefs/api/gen/models"
 )
@@ -171,6 +172,33 @@ var authUsersPoliciesList = &cobra.Command{
 	},
 }
 
+var authUsersPoliciesSimulate = &cobra.Command{
+	Use:   "simulate",
+	Short: "explain whether the policies of a user allow an action on a resource",
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetString("id")
+		action, _ := cmd.Flags().GetString("action")
+		resource, _ := cmd.Flags().GetString("resource")
+		clt := getClient()
+
+		sim, err := clt.SimulateAuthorization(context.Background(), id, action, resource)
+		if err != nil {
+			DieErr(err)
+		}
+
+		decision := "denied"
+		if swag.BoolValue(sim.Allowed) {
+			decision = "allowed"
+		}
+		Fmt("%s is %s %s on %s: %s\n", id, decision, action, resource, swag.StringValue(sim.Reason))
+		rows := make([][]interface{}, len(sim.Matches))
+		for i, match := range sim.Matches {
+			rows[i] = []interface{}{swag.StringValue(match.Policy), swag.StringValue(match.Statement.Resource), swag.StringValue(match.Statement.Effect), strings.Join(match.Statement.Action, ", ")}
+		}
+		PrintTable(rows, []interface{}{"Policy ID", "Resource", "Effect", "Actions"}, nil, len(rows))
+	},
+}
+
 var authUsersPoliciesAttach = &cobra.Command{
 	Use:   "attach",
 	Short: "attach a policy to a user",
@@ -225,7 +253,12 @@ var authUsersCredentialsCreate = &cobra.Command{
 			id = user.ID
 		}
 
-		credentials, err := clt.CreateCredentials(context.Background(), id)
+		var expiresAt *time.Time
+		if expiresIn, _ := cmd.Flags().GetDuration("expires-in"); expiresIn > 0 {
+			t := time.Now().Add(expiresIn)
+			expiresAt = &t
+		}
+		credentials, err := clt.CreateCredentials(context.Background(), id, expiresAt)
 		if err != nil {
 			DieErr(err)
 		}
@@ -234,6 +267,68 @@ var authUsersCredentialsCreate = &cobra.Command{
 	},
 }
 
+var authUsersCredentialsRotate = &cobra.Command{
+	Use:   "rotate",
+	Short: "replace the secret of user credentials, the replaced secret remains valid for a grace period",
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetString("id")
+		accessKeyID, _ := cmd.Flags().GetString("access-key-id")
+		gracePeriod, _ := cmd.Flags().GetDuration("grace-period")
+		clt := getClient()
+
+		if id == "" {
+			user, err := clt.GetCurrentUser(context.Background())
+			if err != nil {
+				DieErr(err)
+			}
+			id = user.ID
+		}
+
+		var expiresAt *time.Time
+		if expiresIn, _ := cmd.Flags().GetDuration("expires-in"); expiresIn > 0 {
+			t := time.Now().Add(expiresIn)
+			expiresAt = &t
+		}
+		credentials, err := clt.RotateCredentials(context.Background(), id, accessKeyID, gracePeriod, expiresAt)
+		if err != nil {
+			DieErr(err)
+		}
+
+		Write(credentialsCreatedTemplate, credentials)
+	},
+}
+
+var authUsersCredentialsSetNetworkPolicy = &cobra.Command{
+	Use:   "set-network-policy",
+	Short: "replace the source addresses from which user credentials may be used",
+	Long:  "replace the source addresses from which user credentials may be used, with no --allow and --deny flags any address is accepted",
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetString("id")
+		accessKeyID, _ := cmd.Flags().GetString("access-key-id")
+		allow, _ := cmd.Flags().GetStringSlice("allow")
+		deny, _ := cmd.Flags().GetStringSlice("deny")
+		clt := getClient()
+
+		if id == "" {
+			user, err := clt.GetCurrentUser(context.Background())
+			if err != nil {
+				DieErr(err)
+			}
+			id = user.ID
+		}
+
+		credentials, err := clt.SetCredentialsNetworkPolicy(context.Background(), id, accessKeyID, &models.NetworkPolicy{
+			Allow: allow,
+			Deny:  deny,
+		})
+		if err != nil {
+			DieErr(err)
+		}
+
+		Fmt("Network policy of %s set: allow %v, deny %v\n", credentials.AccessKeyID, credentials.NetworkPolicy.Allow, credentials.NetworkPolicy.Deny)
+	},
+}
+
 var authUsersCredentialsDelete = &cobra.Command{
 	Use:   "delete",
 	Short: "delete user credentials",
@@ -283,15 +378,22 @@ var authUsersCredentialsList = &cobra.Command{
 
 		rows := make([][]interface{}, len(credentials))
 		for i, c := range credentials {
-
 			ts := time.Unix(c.CreationDate, 0).String()
-			rows[i] = []interface{}{c.AccessKeyID, ts}
+			rows[i] = []interface{}{c.AccessKeyID, ts, optionalUnixTime(c.ExpiresAt), optionalUnixTime(c.LastUsedAt)}
 		}
 
-		PrintTable(rows, []interface{}{"Access Key ID", "Issued Date"}, pagination, amount)
+		PrintTable(rows, []interface{}{"Access Key ID", "Issued Date", "Expires At", "Last Used"}, pagination, amount)
 	},
 }
 
+// optionalUnixTime formats the unix time sec, unset (0) times are empty
+func optionalUnixTime(sec int64) string {
+	if sec == 0 {
+		return ""
+	}
+	return time.Unix(sec, 0).String()
+}
+
 // groups
 var authGroups = &cobra.Command{
 	Use:   "groups",
@@ -641,10 +743,32 @@ func init() {
 	authUsersPolicies.AddCommand(authUsersPoliciesAttach)
 	authUsersPolicies.AddCommand(authUsersPoliciesDetach)
 
+	authUsersPoliciesSimulate.Flags().String("id", "", "user identifier")
+	_ = authUsersPoliciesSimulate.MarkFlagRequired("id")
+	authUsersPoliciesSimulate.Flags().String("action", "", "action to authorize, such as fs:ReadObject")
+	_ = authUsersPoliciesSimulate.MarkFlagRequired("action")
+	authUsersPoliciesSimulate.Flags().String("resource", "", "ARN of the resource, such as arn:lakefs:fs:::repository/example/object/a.parquet")
+	_ = authUsersPoliciesSimulate.MarkFlagRequired("resource")
+	authUsersPolicies.AddCommand(authUsersPoliciesSimulate)
+
 	authUsersCredentialsList.Flags().String("id", "", "user identifier (default: current user)")
 	addPaginationFlags(authUsersCredentialsList)
 
 	authUsersCredentialsCreate.Flags().String("id", "", "user identifier (default: current user)")
+	authUsersCredentialsCreate.Flags().Duration("expires-in", 0, "duration until the credentials expire (default: never)")
+
+	const defaultRotationGracePeriod = 24 * time.Hour
+	authUsersCredentialsRotate.Flags().String("id", "", "user identifier (default: current user)")
+	authUsersCredentialsRotate.Flags().String("access-key-id", "", "access key ID to rotate")
+	_ = authUsersCredentialsRotate.MarkFlagRequired("access-key-id")
+	authUsersCredentialsRotate.Flags().Duration("grace-period", defaultRotationGracePeriod, "duration during which the replaced secret remains valid")
+	authUsersCredentialsRotate.Flags().Duration("expires-in", 0, "duration until the rotated credentials expire (default: never)")
+
+	authUsersCredentialsSetNetworkPolicy.Flags().String("id", "", "user identifier (default: current user)")
+	authUsersCredentialsSetNetworkPolicy.Flags().String("access-key-id", "", "access key ID to restrict")
+	_ = authUsersCredentialsSetNetworkPolicy.MarkFlagRequired("access-key-id")
+	authUsersCredentialsSetNetworkPolicy.Flags().StringSlice("allow", nil, "CIDR blocks or addresses, when set only matching addresses are accepted")
+	authUsersCredentialsSetNetworkPolicy.Flags().StringSlice("deny", nil, "CIDR blocks or addresses that are rejected")
 
 	authUsersCredentialsDelete.Flags().String("id", "", "user identifier (default: current user)")
 	authUsersCredentialsDelete.Flags().String("access-key-id", "", "access key ID to delete")
@@ -653,6 +777,8 @@ func init() {
 	authUsersCredentials.AddCommand(authUsersCredentialsList)
 	authUsersCredentials.AddCommand(authUsersCredentialsCreate)
 	authUsersCredentials.AddCommand(authUsersCredentialsDelete)
+	authUsersCredentials.AddCommand(authUsersCredentialsRotate)
+	authUsersCredentials.AddCommand(authUsersCredentialsSetNetworkPolicy)
 
 	authUsers.AddCommand(authUsersCreate)
 	authUsers.AddCommand(authUsersDelete)
@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/go-openapi/swag"
 	"github.com/spf13/cobra"
@@ -103,6 +104,32 @@ var branchCreateCmd = &cobra.Command{
 	},
 }
 
+var branchSandboxCmd = &cobra.Command{
+	Use:   "sandbox <repository uri>",
+	Short: "show your personal sandbox branch, creating it from the default branch on first use",
+	Long:  "show your personal sandbox branch, creating it from the default branch on first use. Every use extends the lifetime of the sandbox, an unused sandbox is deleted once its TTL passes.",
+	Args: cmdutils.ValidationChain(
+		cobra.ExactArgs(1),
+		cmdutils.FuncValidator(0, uri.ValidateRepoURI),
+	),
+	Run: func(cmd *cobra.Command, args []string) {
+		u := uri.Must(uri.Parse(args[0]))
+		ttl, _ := cmd.Flags().GetDuration("ttl")
+		client := getClient()
+		sandbox, err := client.GetOrCreateSandbox(context.Background(), u.Repository, ttl)
+		if err != nil {
+			DieErr(err)
+		}
+		if swag.BoolValue(sandbox.Created) {
+			Fmt("created sandbox branch '%s'\n", swag.StringValue(sandbox.Branch))
+		}
+		Fmt("lakefs://%s@%s\n", u.Repository, swag.StringValue(sandbox.Branch))
+		if sandbox.ExpiresAt != 0 {
+			Fmt("expires if unused at %s\n", time.Unix(sandbox.ExpiresAt, 0))
+		}
+	},
+}
+
 var branchDeleteCmd = &cobra.Command{
 	Use:   "delete <branch uri>",
 	Short: "delete a branch in a repository, along with its uncommitted changes (CAREFUL)",
@@ -249,6 +276,7 @@ func init() {
 	branchCmd.AddCommand(branchShowCmd)
 	branchCmd.AddCommand(branchResetCmd)
 	branchCmd.AddCommand(branchRevertCmd)
+	branchCmd.AddCommand(branchSandboxCmd)
 
 	branchListCmd.Flags().Int("amount", -1, "how many results to return, or-1 for all results (used for pagination)")
 	branchListCmd.Flags().String("after", "", "show results after this value (used for pagination)")
@@ -256,6 +284,8 @@ func init() {
 	branchCreateCmd.Flags().StringP("source", "s", "", "source branch uri")
 	_ = branchCreateCmd.MarkFlagRequired("source")
 
+	branchSandboxCmd.Flags().Duration("ttl", 0, "lifetime of the sandbox when unused (default: 24h)")
+
 	branchResetCmd.Flags().String("commit", "", "commit ID to reset branch to")
 	branchResetCmd.Flags().String("prefix", "", "prefix of the objects to be reset")
 	branchResetCmd.Flags().String("object", "", "path to object to be reset")
@@ -30,6 +30,7 @@ var diffCmd = &cobra.Command{
 	),
 	Run: func(cmd *cobra.Command, args []string) {
 		client := getClient()
+		changeTypes, _ := cmd.Flags().GetStringSlice("change-type")
 
 		const diffWithOtherArgsCount = 2
 		if len(args) == diffWithOtherArgsCount {
@@ -41,10 +42,14 @@ var diffCmd = &cobra.Command{
 			if leftRefURI.Repository != rightRefURI.Repository {
 				Die("both references must belong to the same repository", 1)
 			}
-			printDiffRefs(client, leftRefURI.Repository, leftRefURI.Ref, rightRefURI.Ref)
+			if format, _ := cmd.Flags().GetString("export"); format != "" {
+				exportDiffRefs(client, leftRefURI.Repository, leftRefURI.Ref, rightRefURI.Ref, format)
+				return
+			}
+			printDiffRefs(client, leftRefURI.Repository, leftRefURI.Ref, rightRefURI.Ref, changeTypes)
 		} else {
 			branchURI := uri.Must(uri.Parse(args[0]))
-			printDiffBranch(client, branchURI.Repository, branchURI.Ref)
+			printDiffBranch(client, branchURI.Repository, branchURI.Ref, changeTypes)
 		}
 	},
 }
@@ -61,11 +66,11 @@ func (p *pageSize) Next() int {
 	return p.Value()
 }
 
-func printDiffBranch(client api.Client, repository string, branch string) {
+func printDiffBranch(client api.Client, repository string, branch string, changeTypes []string) {
 	var after string
 	pageSize := pageSize(minDiffPageSize)
 	for {
-		diff, pagination, err := client.DiffBranch(context.Background(), repository, branch, after, pageSize.Value())
+		diff, pagination, err := client.DiffBranch(context.Background(), repository, branch, after, pageSize.Value(), changeTypes)
 		if err != nil {
 			DieErr(err)
 		}
@@ -80,12 +85,12 @@ func printDiffBranch(client api.Client, repository string, branch string) {
 	}
 }
 
-func printDiffRefs(client api.Client, repository string, leftRef string, rightRef string) {
+func printDiffRefs(client api.Client, repository string, leftRef string, rightRef string, changeTypes []string) {
 	var after string
 	pageSize := pageSize(minDiffPageSize)
 	for {
 		diff, pagination, err := client.DiffRefs(context.Background(), repository, leftRef, rightRef,
-			after, pageSize.Value())
+			after, pageSize.Value(), changeTypes)
 		if err != nil {
 			DieErr(err)
 		}
@@ -100,6 +105,14 @@ func printDiffRefs(client api.Client, repository string, leftRef string, rightRe
 	}
 }
 
+func exportDiffRefs(client api.Client, repository, leftRef, rightRef, format string) {
+	export, err := client.ExportDiff(context.Background(), repository, leftRef, rightRef, format)
+	if err != nil {
+		DieErr(err)
+	}
+	Fmt("Exported %d differences to %s\n", swag.Int64Value(export.Count), swag.StringValue(export.Location))
+}
+
 func FmtDiff(diff *models.Diff, withDirection bool) {
 	var color text.Color
 	var action string
@@ -135,4 +148,6 @@ func FmtDiff(diff *models.Diff, withDirection bool) {
 //nolint:gochecknoinits
 func init() {
 	rootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().StringSlice("change-type", nil, "show only differences of these types (added, removed, changed, conflict)")
+	diffCmd.Flags().String("export", "", "write the full two-dot diff between the two references to a file in the repository storage namespace, in this format (parquet or csv)")
 }
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-openapi/swag"
+	"github.com/spf13/cobra"
+	"github.com/treeverse/lakefs/api/gen/models"
+	"github.com/treeverse/lakefs/cmdutils"
+	"github.com/treeverse/lakefs/uri"
+)
+
+const leaseIDArgs = 2
+
+const leaseTemplate = `Lease ID: {{index . 0|yellow}}
+Prefix: {{index . 1}}
+Owner: {{index . 2}}
+Expires At: {{index . 3}}
+`
+
+const leaseListTemplate = `{{.LeaseTable | table -}}`
+
+// leaseCmd represents the lease command
+var leaseCmd = &cobra.Command{
+	Use:   "lease",
+	Short: "lease prefixes of branches for exclusive writes",
+	Long:  "Lease prefixes of branches for exclusive writes: writes of other users to the paths under a leased prefix fail until the lease is released or expires.",
+}
+
+var leaseAcquireCmd = &cobra.Command{
+	Use:     "acquire <path uri>",
+	Short:   "lease the paths under a prefix of a branch",
+	Example: "lakectl lease acquire lakefs://<repository>@<branch>/<prefix>",
+	Args: cmdutils.ValidationChain(
+		cobra.ExactArgs(1),
+		cmdutils.FuncValidator(0, uri.ValidatePathURI),
+	),
+	Run: func(cmd *cobra.Command, args []string) {
+		u := uri.Must(uri.Parse(args[0]))
+		ttl, _ := cmd.Flags().GetDuration("ttl")
+		client := getClient()
+		lease, err := client.AcquirePathLease(context.Background(), u.Repository, u.Ref, u.Path, ttl)
+		if err != nil {
+			DieErr(err)
+		}
+		Write(leaseTemplate, leaseRow(lease))
+	},
+}
+
+var leaseRenewCmd = &cobra.Command{
+	Use:   "renew <branch uri> <lease id>",
+	Short: "extend the lifetime of a lease",
+	Args: cmdutils.ValidationChain(
+		cobra.ExactArgs(leaseIDArgs),
+		cmdutils.FuncValidator(0, uri.ValidateRefURI),
+	),
+	Run: func(cmd *cobra.Command, args []string) {
+		u := uri.Must(uri.Parse(args[0]))
+		ttl, _ := cmd.Flags().GetDuration("ttl")
+		client := getClient()
+		lease, err := client.RenewPathLease(context.Background(), u.Repository, u.Ref, args[1], ttl)
+		if err != nil {
+			DieErr(err)
+		}
+		Write(leaseTemplate, leaseRow(lease))
+	},
+}
+
+var leaseReleaseCmd = &cobra.Command{
+	Use:   "release <branch uri> <lease id>",
+	Short: "release a lease",
+	Args: cmdutils.ValidationChain(
+		cobra.ExactArgs(leaseIDArgs),
+		cmdutils.FuncValidator(0, uri.ValidateRefURI),
+	),
+	Run: func(cmd *cobra.Command, args []string) {
+		u := uri.Must(uri.Parse(args[0]))
+		client := getClient()
+		err := client.ReleasePathLease(context.Background(), u.Repository, u.Ref, args[1])
+		if err != nil {
+			DieErr(err)
+		}
+		Fmt("Lease released.\n")
+	},
+}
+
+var leaseListCmd = &cobra.Command{
+	Use:   "list <branch uri>",
+	Short: "list the leases of a branch",
+	Args: cmdutils.ValidationChain(
+		cobra.ExactArgs(1),
+		cmdutils.FuncValidator(0, uri.ValidateRefURI),
+	),
+	Run: func(cmd *cobra.Command, args []string) {
+		u := uri.Must(uri.Parse(args[0]))
+		client := getClient()
+		leases, err := client.ListPathLeases(context.Background(), u.Repository, u.Ref)
+		if err != nil {
+			DieErr(err)
+		}
+		rows := make([][]interface{}, len(leases))
+		for i, lease := range leases {
+			rows[i] = leaseRow(lease)
+		}
+		Write(leaseListTemplate, struct {
+			LeaseTable *Table
+		}{
+			LeaseTable: &Table{
+				Headers: []interface{}{"Lease ID", "Prefix", "Owner", "Expires At"},
+				Rows:    rows,
+			},
+		})
+	},
+}
+
+func leaseRow(lease *models.PathLease) []interface{} {
+	return []interface{}{
+		swag.StringValue(lease.ID),
+		swag.StringValue(lease.Prefix),
+		swag.StringValue(lease.Owner),
+		time.Unix(swag.Int64Value(lease.ExpiresAt), 0).String(),
+	}
+}
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(leaseCmd)
+	leaseCmd.AddCommand(leaseAcquireCmd, leaseRenewCmd, leaseReleaseCmd, leaseListCmd)
+	leaseAcquireCmd.Flags().Duration("ttl", 0, "lifetime of the lease unless renewed (default: 10m)")
+	leaseRenewCmd.Flags().Duration("ttl", 0, "lifetime of the lease from now (default: 10m)")
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"os"
 
 	"github.com/go-openapi/swag"
 	"github.com/spf13/cobra"
@@ -47,9 +48,40 @@ var logCmd = &cobra.Command{
 			DieErr(err)
 		}
 		showMetaRangeID, _ := cmd.Flags().GetBool("show-meta-range-id")
+		exclude, _ := cmd.Flags().GetString("exclude")
+		firstParent, _ := cmd.Flags().GetBool("first-parent")
+		stream, _ := cmd.Flags().GetBool("stream")
 		client := getClient()
 		branchURI := uri.Must(uri.Parse(args[0]))
-		commits, pagination, err := client.GetCommitLog(context.Background(), branchURI.Repository, branchURI.Ref, after, amount)
+		if stream {
+			if exclude != "" {
+				Die("--stream cannot be used with --exclude", 1)
+			}
+			err := client.StreamCommitLog(context.Background(), branchURI.Repository, branchURI.Ref, firstParent, os.Stdout)
+			if err != nil {
+				DieErr(err)
+			}
+			return
+		}
+		var (
+			commits    []*models.Commit
+			pagination *models.Pagination
+		)
+		if exclude != "" {
+			if firstParent {
+				Die("--first-parent cannot be used with --exclude", 1)
+			}
+			if err := uri.ValidateRefURI(exclude); err != nil {
+				DieErr(err)
+			}
+			excludeURI := uri.Must(uri.Parse(exclude))
+			if excludeURI.Repository != branchURI.Repository {
+				Die("both references must belong to the same repository", 1)
+			}
+			commits, pagination, err = client.CommitsBetween(context.Background(), branchURI.Repository, excludeURI.Ref, branchURI.Ref, after, amount)
+		} else {
+			commits, pagination, err = client.GetCommitLog(context.Background(), branchURI.Repository, branchURI.Ref, after, amount, firstParent)
+		}
 		ctx := struct {
 			Commits         []*models.Commit
 			Pagination      *Pagination
@@ -78,4 +110,7 @@ func init() {
 	logCmd.Flags().Int("amount", -1, "how many results to return, or-1 for all results (used for pagination)")
 	logCmd.Flags().String("after", "", "show results after this value (used for pagination)")
 	logCmd.Flags().Bool("show-meta-range-id", false, "also show meta range ID")
+	logCmd.Flags().String("exclude", "", "show only commits not reachable from this ref uri")
+	logCmd.Flags().Bool("first-parent", false, "follow only the first parent of merge commits")
+	logCmd.Flags().Bool("stream", false, "write the complete log to stdout as newline delimited JSON, ignoring --amount and --after")
 }
@@ -48,7 +48,10 @@ var mergeCmd = &cobra.Command{
 			Die("both references must belong to the same repository", 1)
 		}
 
-		result, err := client.Merge(context.Background(), destinationRef.Repository, destinationRef.Ref, sourceRef.Ref)
+		overrideGuardrails, _ := cmd.Flags().GetBool("override-guardrails")
+		result, err := client.Merge(context.Background(), destinationRef.Repository, destinationRef.Ref, sourceRef.Ref, &models.Merge{
+			OverrideGuardrails: overrideGuardrails,
+		})
 		if errors.Is(err, catalog.ErrConflictFound) {
 			_, _ = fmt.Printf("Conflicts: %d\n", result.Summary.Conflict)
 			return
@@ -70,4 +73,5 @@ var mergeCmd = &cobra.Command{
 //nolint:gochecknoinits
 func init() {
 	rootCmd.AddCommand(mergeCmd)
+	mergeCmd.Flags().Bool("override-guardrails", false, "merge even if the merge exceeds the guardrails of the destination branch")
 }
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/block/factory"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/cmdutils"
+	"github.com/treeverse/lakefs/config"
+	"github.com/treeverse/lakefs/loadtest"
+	"github.com/treeverse/lakefs/uri"
+)
+
+// defaultDurationSampleSize is the number of latencies kept for each call of a run limited only
+// by duration
+const defaultDurationSampleSize = 100000
+
+// mixCmd represents the mix command
+var mixCmd = &cobra.Command{
+	Use:   "mix <branch uri>",
+	Short: "Load test the catalog with a mix of entry, list, commit and merge calls",
+	Long: `Load test the catalog with a configurable mix of set entry, get entry, list, commit and merge
+calls from concurrent workers.  Each worker works on its own branch created from the given branch,
+and merges into it.  Prints the latency histogram of each call, and optionally exports the results
+as JSON to compare runs.`,
+	Args: cmdutils.ValidationChain(
+		cobra.ExactArgs(1),
+		cmdutils.FuncValidator(0, uri.ValidateRefURI),
+	),
+	Run: func(cmd *cobra.Command, args []string) {
+		u := uri.Must(uri.Parse(args[0]))
+		connectionString, _ := cmd.Flags().GetString("db")
+		requests, _ := cmd.Flags().GetInt("requests")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		sampleRatio, _ := cmd.Flags().GetFloat64("sample")
+		duration, _ := cmd.Flags().GetDuration("duration")
+		mixValue, _ := cmd.Flags().GetString("mix")
+		objectSize, _ := cmd.Flags().GetInt64("object-size")
+		writeData, _ := cmd.Flags().GetBool("write-data")
+		listAmount, _ := cmd.Flags().GetInt("list-amount")
+		keepBranches, _ := cmd.Flags().GetBool("keep-branches")
+		output, _ := cmd.Flags().GetString("output")
+
+		mix, err := loadtest.ParseMix(mixValue)
+		if err != nil {
+			fmt.Printf("Invalid mix: %s\n", err)
+			os.Exit(1)
+		}
+
+		ctx := context.Background()
+		database := connectToDB(connectionString)
+		defer database.Close()
+		lockDB := connectToDB(connectionString)
+		defer lockDB.Close()
+
+		conf := config.NewConfig()
+		c, err := catalog.NewCataloger(catalog.Config{
+			Config: conf,
+			DB:     database,
+			LockDB: lockDB,
+		})
+		if err != nil {
+			fmt.Printf("Cannot create cataloger: %s\n", err)
+			os.Exit(1)
+		}
+		defer func() { _ = c.Close() }()
+		var adapter block.Adapter
+		if writeData {
+			adapter, err = factory.BuildBlockAdapter(conf)
+			if err != nil {
+				fmt.Printf("Cannot create block adapter: %s\n", err)
+				os.Exit(1)
+			}
+		}
+
+		fmt.Printf("Concurrency: %d\n", concurrency)
+		fmt.Printf("Requests: %d per worker, duration: %s\n", requests, duration)
+		loader := &loadtest.CatalogLoader{
+			Cataloger: c,
+			Adapter:   adapter,
+			Config: loadtest.CatalogLoadConfig{
+				Repository:   u.Repository,
+				Branch:       u.Ref,
+				Mix:          mix,
+				ObjectSize:   objectSize,
+				Concurrency:  concurrency,
+				Requests:     requests,
+				Duration:     duration,
+				ListAmount:   listAmount,
+				SampleSize:   int(float64(requests*concurrency) * sampleRatio),
+				KeepBranches: keepBranches,
+			},
+		}
+		if duration > 0 && requests == 0 {
+			loader.Config.SampleSize = defaultDurationSampleSize
+		}
+		results, err := loader.Run(ctx)
+		if err != nil {
+			fmt.Printf("Load test failed: %s\n", err)
+			os.Exit(1)
+		}
+		if err := results.WriteText(os.Stdout); err != nil {
+			fmt.Printf("Failed to write results: %s\n", err)
+			os.Exit(1)
+		}
+		if output != "" {
+			f, err := os.Create(output)
+			if err != nil {
+				fmt.Printf("Failed to create results file: %s\n", err)
+				os.Exit(1)
+			}
+			err = results.WriteJSON(f)
+			_ = f.Close()
+			if err != nil {
+				fmt.Printf("Failed to export results: %s\n", err)
+				os.Exit(1)
+			}
+		}
+		if results.HasErrors() {
+			fmt.Println("\nSome requests FAILED!")
+			os.Exit(1)
+		}
+	},
+}
+
+//nolint:gochecknoinits
+func init() {
+	dbCmd.AddCommand(mixCmd)
+	mixCmd.Flags().String("mix", loadtest.DefaultMix, "Relative weight of each call: set_entry, get_entry, list, commit and merge")
+	mixCmd.Flags().Duration("duration", 0, "Stop after this duration, 0 to run all requests")
+	mixCmd.Flags().Int64("object-size", 0, "Size in bytes of the objects created")
+	mixCmd.Flags().Bool("write-data", false, "Write the data of created objects to the storage namespace")
+	mixCmd.Flags().Int("list-amount", 100, "Number of entries listed by each list call")
+	mixCmd.Flags().Bool("keep-branches", false, "Do not delete the branches of the workers at the end of the test")
+	mixCmd.Flags().String("output", "", "Export the results as JSON to this file")
+}
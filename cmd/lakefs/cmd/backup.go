@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+)
+
+// backupCmd backs up the metadata of repositories to their storage namespaces
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Back up the commits, branches, tags and uncommitted changes of repositories",
+	Run: func(cmd *cobra.Command, args []string) {
+		repository, _ := cmd.Flags().GetString("repository")
+
+		ctx := context.Background()
+		dbPool := db.BuildDatabaseConnection(cfg.GetDatabaseParams())
+		defer dbPool.Close()
+		cataloger, err := catalog.NewCataloger(catalog.Config{
+			Config: cfg,
+			DB:     dbPool,
+		})
+		if err != nil {
+			fmt.Printf("Failed to create cataloger: %s\n", err)
+			os.Exit(1)
+		}
+		defer func() { _ = cataloger.Close() }()
+
+		repositories := []string{repository}
+		if repository == "" {
+			repos, _, err := cataloger.ListRepositories(ctx, -1, "")
+			if err != nil {
+				fmt.Printf("Failed to list repositories: %s\n", err)
+				os.Exit(1)
+			}
+			repositories = make([]string, len(repos))
+			for i, repo := range repos {
+				repositories[i] = repo.Name
+			}
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		for _, name := range repositories {
+			backup, err := cataloger.BackupMetadata(ctx, name)
+			if err != nil {
+				fmt.Printf("Backup of %s failed: %s\n", name, err)
+				os.Exit(1)
+			}
+			if err := enc.Encode(backup); err != nil {
+				fmt.Printf("Failed to write backup: %s\n", err)
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+// backupRestoreCmd restores a metadata backup into a bare repository
+var backupRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore a metadata backup into a bare repository on the storage namespace of the backup",
+	Run: func(cmd *cobra.Command, args []string) {
+		repository, _ := cmd.Flags().GetString("repository")
+		backupID, _ := cmd.Flags().GetString("backup-id")
+
+		ctx := context.Background()
+		dbPool := db.BuildDatabaseConnection(cfg.GetDatabaseParams())
+		defer dbPool.Close()
+		cataloger, err := catalog.NewCataloger(catalog.Config{
+			Config: cfg,
+			DB:     dbPool,
+		})
+		if err != nil {
+			fmt.Printf("Failed to create cataloger: %s\n", err)
+			os.Exit(1)
+		}
+		defer func() { _ = cataloger.Close() }()
+
+		backup, err := cataloger.RestoreMetadataBackup(ctx, repository, backupID)
+		if err != nil {
+			fmt.Printf("Restore failed: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Restored backup %s of %s into %s\n", backup.ID, backup.Repository, repository)
+	},
+}
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(backupCmd)
+	backupCmd.AddCommand(backupRestoreCmd)
+	backupCmd.Flags().String("repository", "", "repository to back up (all repositories when empty)")
+	backupRestoreCmd.Flags().String("repository", "", "bare repository to restore into")
+	backupRestoreCmd.Flags().String("backup-id", "", "backup to restore (latest when empty)")
+	_ = backupRestoreCmd.MarkFlagRequired("repository")
+}
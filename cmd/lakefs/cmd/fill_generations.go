@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+)
+
+// fillGenerationsCmd computes the generations of commits added before generations were recorded
+var fillGenerationsCmd = &cobra.Command{
+	Use:   "fill-generations",
+	Short: "Compute the generation numbers of commits added before generations were recorded",
+	Long: `Compute the generation numbers of commits added before generations were recorded.
+Ancestry checks, merge base lookups and commit ranges skip most of the history of commits with known generations.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repository, _ := cmd.Flags().GetString("repository")
+
+		ctx := context.Background()
+		dbPool := db.BuildDatabaseConnection(cfg.GetDatabaseParams())
+		defer dbPool.Close()
+		cataloger, err := catalog.NewCataloger(catalog.Config{
+			Config: cfg,
+			DB:     dbPool,
+		})
+		if err != nil {
+			fmt.Printf("Failed to create cataloger: %s\n", err)
+			os.Exit(1)
+		}
+		defer func() { _ = cataloger.Close() }()
+
+		repositories := []string{repository}
+		if repository == "" {
+			repos, _, err := cataloger.ListRepositories(ctx, -1, "")
+			if err != nil {
+				fmt.Printf("Failed to list repositories: %s\n", err)
+				os.Exit(1)
+			}
+			repositories = make([]string, len(repos))
+			for i, repo := range repos {
+				repositories[i] = repo.Name
+			}
+		}
+
+		for _, name := range repositories {
+			filled, err := cataloger.FillGenerations(ctx, name)
+			if err != nil {
+				fmt.Printf("Filling generations of %s failed: %s\n", name, err)
+				os.Exit(1)
+			}
+			fmt.Printf("%s: filled generations of %d commits\n", name, filled)
+		}
+	},
+}
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(fillGenerationsCmd)
+	fillGenerationsCmd.Flags().String("repository", "", "repository to fill (all repositories when empty)")
+}
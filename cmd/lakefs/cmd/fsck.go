@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+)
+
+// fsckCmd verifies the integrity of repositories
+var fsckCmd = &cobra.Command{
+	Use:   "fsck",
+	Short: "Verify the integrity of the branches, tags, commits and metaranges of repositories",
+	Run: func(cmd *cobra.Command, args []string) {
+		repository, _ := cmd.Flags().GetString("repository")
+
+		ctx := context.Background()
+		dbPool := db.BuildDatabaseConnection(cfg.GetDatabaseParams())
+		defer dbPool.Close()
+		cataloger, err := catalog.NewCataloger(catalog.Config{
+			Config: cfg,
+			DB:     dbPool,
+		})
+		if err != nil {
+			fmt.Printf("Failed to create cataloger: %s\n", err)
+			os.Exit(1)
+		}
+		defer func() { _ = cataloger.Close() }()
+
+		repositories := []string{repository}
+		if repository == "" {
+			repos, _, err := cataloger.ListRepositories(ctx, -1, "")
+			if err != nil {
+				fmt.Printf("Failed to list repositories: %s\n", err)
+				os.Exit(1)
+			}
+			repositories = make([]string, len(repos))
+			for i, repo := range repos {
+				repositories[i] = repo.Name
+			}
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		hasErrors := false
+		for _, name := range repositories {
+			report, err := cataloger.Fsck(ctx, name)
+			if err != nil {
+				fmt.Printf("Fsck of %s failed: %s\n", name, err)
+				os.Exit(1)
+			}
+			if err := enc.Encode(report); err != nil {
+				fmt.Printf("Failed to write report: %s\n", err)
+				os.Exit(1)
+			}
+			hasErrors = hasErrors || report.HasErrors()
+		}
+		if hasErrors {
+			os.Exit(1)
+		}
+	},
+}
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(fsckCmd)
+	fsckCmd.Flags().String("repository", "", "repository to verify (all repositories when empty)")
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/gitexport"
+)
+
+// gitExportCmd writes the history of a ref to a git repository of pointer files
+var gitExportCmd = &cobra.Command{
+	Use:   "git-export",
+	Short: "Export the commit history of a ref to a bare git repository, objects are exported as pointer files",
+	Run: func(cmd *cobra.Command, args []string) {
+		repository, _ := cmd.Flags().GetString("repository")
+		ref, _ := cmd.Flags().GetString("ref")
+		path, _ := cmd.Flags().GetString("path")
+		branch, _ := cmd.Flags().GetString("branch")
+		if branch == "" {
+			branch = ref
+		}
+
+		ctx := context.Background()
+		dbPool := db.BuildDatabaseConnection(cfg.GetDatabaseParams())
+		defer dbPool.Close()
+		cataloger, err := catalog.NewCataloger(catalog.Config{
+			Config: cfg,
+			DB:     dbPool,
+		})
+		if err != nil {
+			fmt.Printf("Failed to create cataloger: %s\n", err)
+			os.Exit(1)
+		}
+		defer func() { _ = cataloger.Close() }()
+
+		repo, err := gitexport.InitRepository(path, branch)
+		if err != nil {
+			fmt.Printf("Failed to initialize git repository: %s\n", err)
+			os.Exit(1)
+		}
+		res, err := gitexport.NewExporter(cataloger, repo).Export(ctx, repository, ref, branch)
+		if err != nil {
+			fmt.Printf("Export of %s@%s failed: %s\n", repository, ref, err)
+			os.Exit(1)
+		}
+		for commitID, paths := range res.SkippedPaths {
+			for _, p := range paths {
+				fmt.Printf("Skipped %s in commit %s\n", p, commitID)
+			}
+		}
+		fmt.Printf("Exported %d commits, refs/heads/%s is %s\n", res.Commits, branch, res.Head)
+	},
+}
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(gitExportCmd)
+	gitExportCmd.Flags().String("repository", "", "repository to export")
+	gitExportCmd.Flags().String("ref", "", "branch, tag or commit whose history to export")
+	gitExportCmd.Flags().String("path", "", "directory of the bare git repository, created when missing")
+	gitExportCmd.Flags().String("branch", "", "git branch to point at the exported head (defaults to the ref)")
+	_ = gitExportCmd.MarkFlagRequired("repository")
+	_ = gitExportCmd.MarkFlagRequired("ref")
+	_ = gitExportCmd.MarkFlagRequired("path")
+}
@@ -203,7 +203,10 @@ func runImport(cmd *cobra.Command, args []string) (statusCode int) {
 	if withMerge {
 		fmt.Printf("Merging import changes into lakefs://%s@%s/\n", repoName, repo.DefaultBranch)
 		msg := fmt.Sprintf(onboard.CommitMsgTemplate, stats.CommitRef)
-		commitLog, err := cataloger.Merge(ctx, repoName, onboard.DefaultImportBranchName, repo.DefaultBranch, CommitterName, msg, nil)
+		commitLog, err := cataloger.Merge(ctx, repoName, onboard.DefaultImportBranchName, repo.DefaultBranch, catalog.MergeParams{
+			Committer: CommitterName,
+			Message:   msg,
+		})
 		if err != nil {
 			fmt.Printf("Merge failed: %s\n", err)
 			return 1
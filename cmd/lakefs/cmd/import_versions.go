@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/text"
+	"github.com/spf13/cobra"
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/block/factory"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/cmdutils"
+	"github.com/treeverse/lakefs/config"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/logging"
+	"github.com/treeverse/lakefs/onboard"
+	"github.com/treeverse/lakefs/uri"
+)
+
+const (
+	SourceFlagName        = "source"
+	SliceFlagName         = "slice"
+	VersionsSourceFormat  = "s3://example-bucket/prefix/"
+	DefaultSliceDuration  = 24 * time.Hour
+	ImportVersionsNumArgs = 1
+)
+
+var importVersionsCmd = &cobra.Command{
+	Use:   "import-versions <repository uri> --source <s3 uri of a versioned bucket prefix>",
+	Short: "Import the version history of objects in a versioned S3 bucket to a lakeFS repository",
+	Long: fmt.Sprintf("Import the version history of objects in a versioned S3 bucket without copying the data. "+
+		"Every time slice that changed objects becomes a commit on branch %s. "+
+		"Running it again imports the versions created since the last import", onboard.DefaultVersionsImportBranchName),
+	Args: cmdutils.ValidationChain(
+		cobra.ExactArgs(ImportVersionsNumArgs),
+		cmdutils.FuncValidator(0, uri.ValidateRepoURI),
+	),
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(runImportVersions(cmd, args))
+	},
+}
+
+func runImportVersions(cmd *cobra.Command, args []string) int {
+	flags := cmd.Flags()
+	source, _ := flags.GetString(SourceFlagName)
+	slice, _ := flags.GetDuration(SliceFlagName)
+
+	parsedSource, err := url.Parse(source)
+	if err != nil || parsedSource.Scheme != "s3" || parsedSource.Host == "" {
+		fmt.Printf("Invalid source. expected format: %s\n", VersionsSourceFormat)
+		return 1
+	}
+
+	ctx := context.Background()
+	conf := config.NewConfig()
+	err = db.ValidateSchemaUpToDate(conf.GetDatabaseParams())
+	if errors.Is(err, db.ErrSchemaNotCompatible) {
+		fmt.Println("Migration version mismatch, for more information see https://docs.lakefs.io/deploying/upgrade.html")
+		return 1
+	}
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		return 1
+	}
+	logger := logging.FromContext(ctx)
+	dbPool := db.BuildDatabaseConnection(cfg.GetDatabaseParams())
+	defer dbPool.Close()
+
+	catalogCfg := catalog.Config{
+		Config: cfg,
+		DB:     dbPool,
+	}
+	cataloger, err := catalog.NewCataloger(catalogCfg)
+	if err != nil {
+		fmt.Printf("Failed to create cataloger: %s\n", err)
+		return 1
+	}
+	defer func() { _ = cataloger.Close() }()
+
+	entryCataloger, err := catalog.NewEntryCatalog(catalogCfg)
+	if err != nil {
+		fmt.Printf("Failed to build entry catalog: %s\n", err)
+		return 1
+	}
+	blockStore, err := factory.BuildBlockAdapter(cfg)
+	if err != nil {
+		fmt.Printf("Failed to create block adapter: %s\n", err)
+		return 1
+	}
+	versionLister, ok := blockStore.(block.VersionLister)
+	if !ok || blockStore.BlockstoreType() != "s3" {
+		fmt.Printf("Configuration uses unsupported block adapter: %s. Only s3 is supported.\n", blockStore.BlockstoreType())
+		return 1
+	}
+
+	repoName := uri.Must(uri.Parse(args[0])).Repository
+	repo, err := getRepository(ctx, cataloger, repoName)
+	if err != nil {
+		fmt.Println("Error getting repository", err)
+		return 1
+	}
+
+	stats, err := onboard.ImportVersions(ctx, logger, &onboard.VersionsConfig{
+		CommitUsername:  CommitterName,
+		Bucket:          parsedSource.Host,
+		Prefix:          strings.TrimPrefix(parsedSource.Path, "/"),
+		RepositoryID:    graveler.RepositoryID(repoName),
+		DefaultBranchID: graveler.BranchID(repo.DefaultBranch),
+		SliceDuration:   slice,
+		VersionLister:   versionLister,
+		EntryCatalog:    entryCataloger,
+	})
+	if err != nil {
+		fmt.Printf("Import failed: %s\n", err)
+		return 1
+	}
+	fmt.Println(text.FgYellow.Sprint("Imported versions:"), stats.Versions)
+	fmt.Println(text.FgYellow.Sprint("Commits:"), stats.Commits)
+	fmt.Print(text.FgYellow.Sprint("Commit ref:"), stats.CommitRef)
+	fmt.Println()
+	fmt.Printf("To list the imported history, run:\n\t$ lakectl log lakefs://%s@%s\n", repoName, onboard.DefaultVersionsImportBranchName)
+	return 0
+}
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(importVersionsCmd)
+	importVersionsCmd.Flags().String(SourceFlagName, "", fmt.Sprintf("S3 uri of the versioned bucket prefix to import. Format: %s", VersionsSourceFormat))
+	_ = importVersionsCmd.MarkFlagRequired(SourceFlagName)
+	importVersionsCmd.Flags().Duration(SliceFlagName, DefaultSliceDuration, "Period of the history that each commit covers")
+}
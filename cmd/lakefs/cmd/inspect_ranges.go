@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"context"
+	"crypto"
+	_ "crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/treeverse/lakefs/block/factory"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/graveler/committed"
+	"github.com/treeverse/lakefs/graveler/sstable"
+)
+
+// rangeFileInfo is a line of inspect-ranges list output
+type rangeFileInfo struct {
+	ID            string            `json:"id"`
+	Type          string            `json:"type"`
+	FormatVersion int               `json:"format_version"`
+	SizeBytes     int64             `json:"size_bytes"`
+	Count         int               `json:"count"`
+	MinKey        string            `json:"min_key"`
+	MaxKey        string            `json:"max_key"`
+	Metadata      graveler.Metadata `json:"metadata,omitempty"`
+}
+
+// rangeFileRecord is a line of inspect-ranges dump output.  Records of MetaRanges hold a
+// Range, records of object Ranges hold an entry.
+type rangeFileRecord struct {
+	Key      string         `json:"key"`
+	Identity string         `json:"identity"`
+	DataSize int            `json:"data_size"`
+	Range    *rangeFileInfo `json:"range,omitempty"`
+	Entry    *catalog.Entry `json:"entry,omitempty"`
+	Problems []string       `json:"problems,omitempty"`
+}
+
+var inspectRangesCmd = &cobra.Command{
+	Use:   "inspect-ranges",
+	Short: "Inspect the range and metarange files of a storage namespace, without a running lakeFS",
+	Long: `Inspect the range and metarange files of a storage namespace, without a running lakeFS.
+Files are read directly from the block adapter of the configuration, for debugging and
+recovery.  The database is not used.`,
+}
+
+var inspectRangesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List range and metarange files with their boundaries and stats",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		in := newRangeInspector(cmd)
+		enc := json.NewEncoder(os.Stdout)
+		err := in.List(ctx, func(id committed.ID) error {
+			info, err := in.Stat(ctx, id)
+			if err != nil {
+				return err
+			}
+			return enc.Encode(&rangeFileInfo{
+				ID:            string(info.ID),
+				Type:          info.Type,
+				FormatVersion: info.FormatVersion,
+				SizeBytes:     info.SizeBytes,
+				Count:         info.Count,
+				MinKey:        string(info.MinKey),
+				MaxKey:        string(info.MaxKey),
+			})
+		})
+		if err != nil {
+			fmt.Printf("Failed to list: %s\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var inspectRangesDumpCmd = &cobra.Command{
+	Use:   "dump <id>",
+	Short: "Dump the metadata and records of a range or metarange file",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		amount, _ := cmd.Flags().GetInt("amount")
+		ctx := context.Background()
+		in := newRangeInspector(cmd)
+		f, err := in.Open(ctx, committed.ID(args[0]))
+		if err != nil {
+			fmt.Printf("Failed to open: %s\n", err)
+			os.Exit(1)
+		}
+		defer func() { _ = f.Close() }()
+		enc := json.NewEncoder(os.Stdout)
+		if err := enc.Encode(&rangeFileInfo{ID: string(f.ID), Type: f.Metadata[committed.MetadataTypeKey], SizeBytes: f.SizeBytes, Metadata: f.Metadata}); err != nil {
+			fmt.Printf("Failed to write: %s\n", err)
+			os.Exit(1)
+		}
+		iter, err := f.NewIterator()
+		if err != nil {
+			fmt.Printf("Failed to read: %s\n", err)
+			os.Exit(1)
+		}
+		defer iter.Close()
+		isMetaRange := f.Metadata[committed.MetadataTypeKey] == committed.MetadataMetarangesType
+		isObjects := f.Metadata[graveler.EntityTypeKey] == ""
+		for i := 0; iter.Next() && (amount <= 0 || i < amount); i++ {
+			if err := enc.Encode(dumpRangeRecord(iter.Value(), isMetaRange, isObjects)); err != nil {
+				fmt.Printf("Failed to write: %s\n", err)
+				os.Exit(1)
+			}
+		}
+		if err := iter.Err(); err != nil {
+			fmt.Printf("Failed to read: %s\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var inspectRangesValidateCmd = &cobra.Command{
+	Use:   "validate [<id>...]",
+	Short: "Validate range and metarange files, all metaranges of the namespace when no ID is given",
+	Long: `Validate range and metarange files, all metaranges of the namespace when no ID is given.
+A file is valid when its keys are sorted, its stats and ID match its records, and its format
+version is known.  A metarange is validated together with its ranges: they must exist, be
+ordered without overlaps, and match their records in the metarange.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		in := newRangeInspector(cmd)
+		ids := make([]committed.ID, len(args))
+		for i, arg := range args {
+			ids[i] = committed.ID(arg)
+		}
+		if len(ids) == 0 {
+			err := in.List(ctx, func(id committed.ID) error {
+				f, err := in.Open(ctx, id)
+				if err != nil {
+					return err
+				}
+				if f.Metadata[committed.MetadataTypeKey] == committed.MetadataMetarangesType {
+					ids = append(ids, id)
+				}
+				return f.Close()
+			})
+			if err != nil {
+				fmt.Printf("Failed to list: %s\n", err)
+				os.Exit(1)
+			}
+		}
+		hasProblems := false
+		for _, id := range ids {
+			problems, err := in.Validate(ctx, id)
+			if err != nil {
+				fmt.Printf("Validate %s failed: %s\n", id, err)
+				os.Exit(1)
+			}
+			for _, problem := range problems {
+				fmt.Printf("%s: %s\n", id, problem)
+			}
+			hasProblems = hasProblems || len(problems) > 0
+		}
+		fmt.Fprintf(os.Stderr, "Validated %d files\n", len(ids))
+		if hasProblems {
+			os.Exit(1)
+		}
+	},
+}
+
+func newRangeInspector(cmd *cobra.Command) *sstable.Inspector {
+	storageNamespace, _ := cmd.Flags().GetString("storage-namespace")
+	adapter, err := factory.BuildBlockAdapter(cfg)
+	if err != nil {
+		fmt.Printf("Failed to create block adapter: %s\n", err)
+		os.Exit(1)
+	}
+	tierFSParams, err := cfg.GetCommittedTierFSParams()
+	if err != nil {
+		fmt.Printf("Failed to get pyramid params: %s\n", err)
+		os.Exit(1)
+	}
+	return &sstable.Inspector{
+		Adapter:            adapter,
+		StorageNamespace:   storageNamespace,
+		BlockStoragePrefix: tierFSParams.BlockStoragePrefix,
+		// the hash the catalog uses to identify ranges and metaranges
+		Hash: crypto.SHA256,
+	}
+}
+
+func dumpRangeRecord(record *committed.Record, isMetaRange, isObjects bool) *rangeFileRecord {
+	ret := &rangeFileRecord{Key: string(record.Key)}
+	value, err := committed.UnmarshalValue(record.Value)
+	if err != nil {
+		ret.Problems = append(ret.Problems, err.Error())
+		return ret
+	}
+	ret.Identity = hex.EncodeToString(value.Identity)
+	ret.DataSize = len(value.Data)
+	switch {
+	case isMetaRange:
+		rng, err := committed.UnmarshalRange(value.Data)
+		if err != nil {
+			ret.Problems = append(ret.Problems, err.Error())
+			break
+		}
+		// a MetaRange identifies each Range by its ID
+		ret.Identity = string(value.Identity)
+		ret.Range = &rangeFileInfo{
+			ID:        string(value.Identity),
+			Type:      committed.MetadataRangesType,
+			SizeBytes: int64(rng.EstimatedSize),
+			Count:     int(rng.Count),
+			MinKey:    string(rng.MinKey),
+			MaxKey:    string(rng.MaxKey),
+		}
+	case isObjects:
+		entry, err := catalog.ValueToEntry(value)
+		if err != nil {
+			ret.Problems = append(ret.Problems, err.Error())
+			break
+		}
+		ret.Entry = entry
+	}
+	return ret
+}
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(inspectRangesCmd)
+	inspectRangesCmd.AddCommand(inspectRangesListCmd, inspectRangesDumpCmd, inspectRangesValidateCmd)
+	inspectRangesCmd.PersistentFlags().String("storage-namespace", "", "storage namespace of the repository, e.g. s3://bucket/repo")
+	_ = inspectRangesCmd.MarkPersistentFlagRequired("storage-namespace")
+	inspectRangesDumpCmd.Flags().Int("amount", 0, "maximal number of records to dump (all when 0)")
+}
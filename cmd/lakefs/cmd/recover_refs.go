@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+)
+
+// recoverRefsCmd rebuilds the refs of a repository from its storage namespace
+var recoverRefsCmd = &cobra.Command{
+	Use:   "recover-refs",
+	Short: "Rebuild the commits, branches and tags of a lost repository from its storage namespace",
+	Long: `Rebuild the commits, branches and tags of a lost repository from its storage namespace.
+Creates a bare repository on the storage namespace, loads the latest metadata backup found there,
+and replays the changes recorded in the refs journal since the backup.  Use it to recover
+repositories after losing the database.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repository, _ := cmd.Flags().GetString("repository")
+		storageNamespace, _ := cmd.Flags().GetString("storage-namespace")
+		defaultBranch, _ := cmd.Flags().GetString("default-branch")
+
+		ctx := context.Background()
+		dbPool := db.BuildDatabaseConnection(cfg.GetDatabaseParams())
+		defer dbPool.Close()
+		cataloger, err := catalog.NewCataloger(catalog.Config{
+			Config: cfg,
+			DB:     dbPool,
+		})
+		if err != nil {
+			fmt.Printf("Failed to create cataloger: %s\n", err)
+			os.Exit(1)
+		}
+		defer func() { _ = cataloger.Close() }()
+
+		if _, err := cataloger.CreateBareRepository(ctx, repository, storageNamespace, defaultBranch); err != nil {
+			fmt.Printf("Failed to create bare repository: %s\n", err)
+			os.Exit(1)
+		}
+		recovery, err := cataloger.RecoverRefs(ctx, repository)
+		if err != nil {
+			fmt.Printf("Recovery failed: %s\n", err)
+			os.Exit(1)
+		}
+		if err := json.NewEncoder(os.Stdout).Encode(recovery); err != nil {
+			fmt.Printf("Failed to write report: %s\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(recoverRefsCmd)
+	recoverRefsCmd.Flags().String("repository", "", "name of the repository to create")
+	recoverRefsCmd.Flags().String("storage-namespace", "", "storage namespace of the lost repository, e.g. s3://bucket/repo")
+	recoverRefsCmd.Flags().String("default-branch", "main", "default branch of the repository")
+	_ = recoverRefsCmd.MarkFlagRequired("repository")
+	_ = recoverRefsCmd.MarkFlagRequired("storage-namespace")
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/treeverse/lakefs/block/factory"
+	"github.com/treeverse/lakefs/catalog"
+)
+
+// refsJournalCmd prints the refs journal of a storage namespace
+var refsJournalCmd = &cobra.Command{
+	Use:   "refs-journal",
+	Short: "Print the changes to refs recorded in the storage namespace of a repository",
+	Long: `Print the changes to commits, branches and tags recorded in the refs journal of a storage
+namespace, one JSON entry per line, in order.  Reads only the storage namespace, so it works
+without access to the database.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		storageNamespace, _ := cmd.Flags().GetString("storage-namespace")
+		sinceValue, _ := cmd.Flags().GetString("since")
+		var since time.Time
+		if sinceValue != "" {
+			var err error
+			since, err = time.Parse(time.RFC3339, sinceValue)
+			if err != nil {
+				fmt.Printf("Invalid since time: %s\n", err)
+				os.Exit(1)
+			}
+		}
+
+		adapter, err := factory.BuildBlockAdapter(cfg)
+		if err != nil {
+			fmt.Printf("Failed to create block adapter: %s\n", err)
+			os.Exit(1)
+		}
+		entries, err := catalog.ReadRefsJournal(adapter, storageNamespace, since)
+		if err != nil {
+			fmt.Printf("Failed to read refs journal: %s\n", err)
+			os.Exit(1)
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		for _, entry := range entries {
+			if err := encoder.Encode(entry); err != nil {
+				fmt.Printf("Failed to write entry: %s\n", err)
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(refsJournalCmd)
+	refsJournalCmd.Flags().String("storage-namespace", "", "storage namespace of the repository, e.g. s3://bucket/repo")
+	refsJournalCmd.Flags().String("since", "", "print only changes recorded at or after this time (RFC3339)")
+	_ = refsJournalCmd.MarkFlagRequired("storage-namespace")
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+)
+
+// repairDanglingCmd finds entries whose objects are missing, restoring or removing them
+var repairDanglingCmd = &cobra.Command{
+	Use:   "repair-dangling",
+	Short: "Find entries of a branch whose objects are missing, optionally restoring or removing them",
+	Run: func(cmd *cobra.Command, args []string) {
+		repository, _ := cmd.Flags().GetString("repository")
+		branch, _ := cmd.Flags().GetString("branch")
+		prefix, _ := cmd.Flags().GetString("prefix")
+		replica, _ := cmd.Flags().GetString("restore-from")
+		remove, _ := cmd.Flags().GetBool("remove")
+		committer, _ := cmd.Flags().GetString("committer")
+
+		ctx := context.Background()
+		dbPool := db.BuildDatabaseConnection(cfg.GetDatabaseParams())
+		defer dbPool.Close()
+		cataloger, err := catalog.NewCataloger(catalog.Config{
+			Config: cfg,
+			DB:     dbPool,
+		})
+		if err != nil {
+			fmt.Printf("Failed to create cataloger: %s\n", err)
+			os.Exit(1)
+		}
+		defer func() { _ = cataloger.Close() }()
+
+		res, err := cataloger.RepairDanglingEntries(ctx, repository, branch, catalog.RepairParams{
+			Prefix:           prefix,
+			ReplicaNamespace: replica,
+			Remove:           remove,
+			Committer:        committer,
+		})
+		if err != nil {
+			fmt.Printf("Repair failed: %s\n", err)
+			os.Exit(1)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(res); err != nil {
+			fmt.Printf("Failed to write result: %s\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(repairDanglingCmd)
+	f := repairDanglingCmd.Flags()
+	f.String("repository", "", "repository to repair")
+	f.String("branch", "", "branch to repair")
+	f.String("prefix", "", "repair only the entries under this path prefix")
+	f.String("restore-from", "", "storage namespace holding replicas of the missing objects to copy back")
+	f.Bool("remove", false, "delete the entries whose objects are missing in a repair commit")
+	f.String("committer", "lakefs", "committer of the repair commit")
+
+	_ = repairDanglingCmd.MarkFlagRequired("repository")
+	_ = repairDanglingCmd.MarkFlagRequired("branch")
+}
@@ -9,6 +9,7 @@ import (
 	"net/url"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -16,24 +17,34 @@ import (
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/cobra"
+	"github.com/treeverse/lakefs/actions"
 	"github.com/treeverse/lakefs/api"
 	"github.com/treeverse/lakefs/auth"
 	"github.com/treeverse/lakefs/auth/crypt"
+	"github.com/treeverse/lakefs/auth/ldap"
+	"github.com/treeverse/lakefs/auth/opa"
 	"github.com/treeverse/lakefs/block/factory"
 	"github.com/treeverse/lakefs/catalog"
 	"github.com/treeverse/lakefs/config"
+	"github.com/treeverse/lakefs/coordination"
 	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/events"
 	"github.com/treeverse/lakefs/gateway"
 	"github.com/treeverse/lakefs/gateway/multiparts"
 	"github.com/treeverse/lakefs/gateway/simulator"
 	"github.com/treeverse/lakefs/httputil"
+	"github.com/treeverse/lakefs/ingest"
+	"github.com/treeverse/lakefs/jobs"
 	"github.com/treeverse/lakefs/logging"
+	"github.com/treeverse/lakefs/notifications"
+	"github.com/treeverse/lakefs/replica"
+	"github.com/treeverse/lakefs/secrets"
+	"github.com/treeverse/lakefs/settings"
 	"github.com/treeverse/lakefs/stats"
+	"github.com/treeverse/lakefs/usage"
 )
 
 const (
-	gracefulShutdownTimeout = 30 * time.Second
-
 	serviceAPIServer = "api"
 	serviceS3Gateway = "s3gateway"
 )
@@ -52,6 +63,10 @@ var runCmd = &cobra.Command{
 
 		// validate service names and turn on the right flags
 		dbParams := cfg.GetDatabaseParams()
+		replicaCfg, err := cfg.GetReplicaConfig()
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to read replica configuration")
+		}
 
 		if err := db.ValidateSchemaUpToDate(dbParams); errors.Is(err, db.ErrSchemaNotCompatible) {
 			logger.WithError(err).Fatal("Migration version mismatch, for more information see https://docs.lakefs.io/deploying/upgrade.html")
@@ -69,10 +84,12 @@ var runCmd = &cobra.Command{
 		registerPrometheusCollector(dbPool)
 		migrator := db.NewDatabaseMigrator(dbParams)
 
+		eventsBus := events.NewBus()
 		cataloger, err := catalog.NewCataloger(catalog.Config{
 			Config: cfg,
 			DB:     dbPool,
 			LockDB: lockdbPool,
+			Events: eventsBus,
 		})
 		if err != nil {
 			logger.WithError(err).Fatal("failed to create cataloger")
@@ -84,12 +101,32 @@ var runCmd = &cobra.Command{
 		if err != nil {
 			logger.WithError(err).Fatal("Failed to create block adapter")
 		}
+		encryptor, err := cfg.GetEncryptor()
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to create encryptor")
+		}
 
 		// init authentication
-		authService := auth.NewDBAuthService(
+		var authService auth.Service = auth.NewDBAuthService(
 			dbPool,
 			crypt.NewSecretStore(cfg.GetAuthEncryptionSecret()),
 			cfg.GetAuthCacheConfig())
+		if opaParams := cfg.GetAuthOPAConfig(); opaParams != nil {
+			authService = auth.NewAuthorizerService(authService, opa.NewAuthorizer(*opaParams))
+		}
+		var externalAuth *auth.ExternalAuth
+		if ldapParams := cfg.GetAuthLDAPConfig(); ldapParams != nil {
+			ldapProvider, err := ldap.NewProvider(*ldapParams)
+			if err != nil {
+				logger.WithError(err).Fatal("Failed to create LDAP auth provider")
+			}
+			defer func() { _ = ldapProvider.Close() }()
+			externalAuth = &auth.ExternalAuth{
+				Provider:      ldapProvider,
+				Source:        "ldap",
+				GroupPolicies: cfg.GetAuthLDAPGroupPolicies(),
+			}
+		}
 		authMetadataManager := auth.NewDBMetadataManager(config.Version, dbPool)
 		cloudMetadataProvider := stats.BuildMetadataProvider(logger, cfg)
 		metadata := stats.NewMetadata(logger, cfg.GetBlockstoreType(), authMetadataManager, cloudMetadataProvider)
@@ -105,15 +142,49 @@ var runCmd = &cobra.Command{
 			_ = cataloger.Close()
 		}()
 
+		secretsStore := secrets.NewStore(dbPool, authService.SecretStore())
+		settingsService := settings.NewService(settings.NewStore(dbPool), cfg.GetRepositorySettingsDefaults)
+		instanceSettings := settings.NewInstanceService(settings.NewInstanceStore(dbPool))
+		notificationsStore := notifications.NewStore(dbPool)
+		usageRecorder := usage.NewRecorder(dbPool)
+		if replicaCfg == nil {
+			// a replica cannot record the events of the reads it serves, writes are forwarded
+			// and publish their events on the primary
+			cancelNotifications := notifications.NewNotifier(notificationsStore, secretsStore, settingsService, cfg.GetNotificationsBaseURL()).Subscribe(eventsBus)
+			defer cancelNotifications()
+			cancelUsage := usageRecorder.Subscribe(eventsBus)
+			defer cancelUsage()
+		}
+		jobManager := jobs.NewManager(jobs.NewStore(dbPool), jobs.DefaultUpdateInterval)
+
 		// start API server
 		done := make(chan bool, 1)
 		quit := make(chan os.Signal, 1)
 		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+		reload := make(chan os.Signal, 1)
+		signal.Notify(reload, syscall.SIGHUP)
+		go func() {
+			for range reload {
+				if err := cfg.Reload(); err != nil {
+					logger.WithError(err).Error("Failed to reload configuration")
+				}
+			}
+		}()
 
 		apiHandler := api.Serve(api.Dependencies{
 			Cataloger:             cataloger,
+			Actions:               actions.NewStore(dbPool),
+			Notifications:         notificationsStore,
+			IngestStreams:         ingest.NewStore(dbPool),
+			Secrets:               secretsStore,
+			Jobs:                  jobManager,
+			Settings:              settingsService,
+			InstanceSettings:      instanceSettings,
+			Config:                cfg,
 			Auth:                  authService,
+			ExternalAuth:          externalAuth,
 			BlockAdapter:          blockStore,
+			Encryptor:             encryptor,
 			MetadataManager:       authMetadataManager,
 			CloudMetadataProvider: cloudMetadataProvider,
 			Migrator:              migrator,
@@ -138,22 +209,99 @@ var runCmd = &cobra.Command{
 			authService,
 			cfg.GetS3GatewayDomainName(),
 			bufferedCollector,
+			eventsBus,
+			encryptor,
+			settingsService,
+			cfg.GetCacheParams(),
 			s3FallbackURL,
 		)
 		ctx, cancelFn := context.WithCancel(context.Background())
 		go bufferedCollector.Run(ctx)
+		// instances sharing the database run each singleton worker on the holder of its lease,
+		// and split per-repository work between them
+		coordinator := coordination.NewCoordinator(coordination.NewStore(dbPool), coordination.NewInstanceID(), cfg.GetCoordinationLeaseDuration())
+		workers := newWorkerGroup(coordinator)
+		replicaSource := replica.NewDBSource(dbPool)
+		var replicaMonitor *replica.Monitor
+		if replicaCfg != nil {
+			// a replica runs no background work, it only reads the metadata store
+			replicaMonitor = replica.NewMonitor(replicaSource, replicaCfg.MaxStaleness)
+			workers.Go(func(ctx context.Context) {
+				replicaMonitor.Run(ctx, replicaCfg.CheckInterval)
+			})
+		} else {
+			workers.Go(coordinator.Run)
+			workers.Go(func(ctx context.Context) {
+				usageRecorder.Run(ctx, usage.DefaultFlushInterval)
+			})
+			workers.GoAsLeader("jobs_cleanup", func(ctx context.Context) {
+				jobManager.Run(ctx, jobs.DefaultCleanupInterval, jobs.DefaultRetention)
+			})
+			workers.GoAsLeader("ephemeral_branch_reaper", func(ctx context.Context) {
+				catalog.RunEphemeralBranchReaper(ctx, cataloger, cfg.GetEphemeralBranchesReapInterval())
+			})
+			workers.GoAsLeader("listing_snapshot_sweeper", func(ctx context.Context) {
+				catalog.RunListingSnapshotSweeper(ctx, cataloger, catalog.ListingSnapshotSweepInterval)
+			})
+			// commits failing to index their lineage keep it in the memory of this instance
+			workers.Go(func(ctx context.Context) {
+				catalog.RunLineageIndexer(ctx, cataloger, catalog.LineageIndexRetryInterval)
+			})
+			if scrubCfg := cfg.GetScrubConfig(); scrubCfg.Interval > 0 {
+				workers.Go(func(ctx context.Context) {
+					catalog.RunScrubber(ctx, cataloger, scrubCfg.Interval, scrubCfg.SampleRate, scrubCfg.ReportDir, coordinator.Owns)
+				})
+			}
+			if backupInterval := cfg.GetMetadataBackupInterval(); backupInterval > 0 {
+				workers.Go(func(ctx context.Context) {
+					catalog.RunMetadataBackups(ctx, cataloger, backupInterval, coordinator.Owns)
+				})
+			}
+			if upgradeInterval := cfg.GetCommittedUpgradeInterval(); upgradeInterval > 0 {
+				workers.GoAsLeader("tree_upgrader", func(ctx context.Context) {
+					catalog.RunTreeUpgrader(ctx, cataloger, upgradeInterval, func(repository string) bool {
+						enabled, err := instanceSettings.FeatureEnabled(ctx, settings.FeatureTreeUpgrade, repository)
+						if err != nil {
+							logger.WithError(err).WithField("repository", repository).Warn("Failed to read feature flag")
+						}
+						return enabled
+					})
+				})
+			}
+			if autoCommitInterval := cfg.GetAutoCommitCheckInterval(); autoCommitInterval > 0 {
+				workers.GoAsLeader("auto_committer", func(ctx context.Context) {
+					catalog.RunAutoCommitter(ctx, cataloger, autoCommitInterval)
+				})
+			}
+			if mergeQueueInterval := cfg.GetMergeQueueInterval(); mergeQueueInterval > 0 {
+				workers.GoAsLeader("merge_queue", func(ctx context.Context) {
+					catalog.RunMergeQueue(ctx, cataloger, mergeQueueInterval)
+				})
+			}
+		}
 
 		bufferedCollector.CollectEvent("global", "run")
 
+		trustedProxies, err := cfg.GetAuthTrustedProxies()
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to parse trusted proxies")
+		}
 		logging.Default().WithField("listen_address", cfg.GetListenAddress()).Info("starting HTTP server")
+		handler := httputil.SourceIPHandler(trustedProxies, httputil.HostMux(
+			httputil.HostHandler(apiHandler).Default(), // api as default handler
+			httputil.HostHandler(s3gatewayHandler, // s3 gateway for its bare domain and sub-domains of that
+				httputil.Exact(cfg.GetS3GatewayDomainName()),
+				httputil.SubdomainsOf(cfg.GetS3GatewayDomainName())),
+		))
+		if replicaMonitor != nil {
+			logger.WithField("primary_url", replicaCfg.PrimaryURL).Info("Serving reads from a replica")
+			handler = replica.Handler(replicaCfg.PrimaryURL, replicaMonitor, handler)
+		} else if cfg.GetReplicaIssueConsistencyTokens() {
+			handler = replica.TokenHandler(replicaSource.CurrentLSN, handler)
+		}
 		server := &http.Server{
-			Addr: cfg.GetListenAddress(),
-			Handler: httputil.HostMux(
-				httputil.HostHandler(apiHandler).Default(), // api as default handler
-				httputil.HostHandler(s3gatewayHandler, // s3 gateway for its bare domain and sub-domains of that
-					httputil.Exact(cfg.GetS3GatewayDomainName()),
-					httputil.SubdomainsOf(cfg.GetS3GatewayDomainName())),
-			),
+			Addr:    cfg.GetListenAddress(),
+			Handler: httputil.TrackInFlight(handler),
 		}
 
 		go func() {
@@ -163,7 +311,9 @@ var runCmd = &cobra.Command{
 			}
 		}()
 
-		go gracefulShutdown(quit, done, server)
+		// in-flight requests complete before background workers stop, and jobs are recorded
+		// last so they may still be submitted by draining requests
+		go gracefulShutdown(quit, done, cfg.GetShutdownDrainDelay(), cfg.GetShutdownTimeout(), server, workers, jobManager)
 
 		<-done
 		cancelFn()
@@ -205,27 +355,85 @@ func registerPrometheusCollector(db sqlstats.StatsGetter) {
 	}
 }
 
-func gracefulShutdown(quit <-chan os.Signal, done chan<- bool, servers ...Shutter) {
+// gracefulShutdown fails the health check on quit and keeps serving for drainDelay, then shuts
+// down servers in order, waiting up to timeout for all of them to finish
+func gracefulShutdown(quit <-chan os.Signal, done chan<- bool, drainDelay, timeout time.Duration, servers ...Shutter) {
 	logger := logging.Default()
 	logger.WithField("version", config.Version).Info("Up and running (^C to shutdown)...")
 
 	printWelcome(os.Stderr)
 
 	<-quit
-	logger.Warn("shutting down...")
-
-	ctx, cancel := context.WithTimeout(context.Background(), gracefulShutdownTimeout)
+	logger.WithFields(logging.Fields{
+		"drain_delay": drainDelay,
+		"timeout":     timeout,
+	}).Warn("shutting down...")
+	httputil.SetDraining()
+	time.Sleep(drainDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
+	drained := true
 	for i, server := range servers {
 		if err := server.Shutdown(ctx); err != nil {
+			drained = false
 			fmt.Printf("Error while shutting down service (%d): %s\n", i, err)
 		}
 	}
 	simulator.ShutdownRecorder()
+	logger.WithFields(logging.Fields{
+		"drained":            drained,
+		"in_flight_requests": httputil.InFlightRequests(),
+	}).Info("Shutdown complete")
 	close(done)
 }
 
+// workerGroup runs background workers until shut down
+type workerGroup struct {
+	ctx         context.Context
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+	coordinator *coordination.Coordinator
+}
+
+func newWorkerGroup(coordinator *coordination.Coordinator) *workerGroup {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &workerGroup{ctx: ctx, cancel: cancel, coordinator: coordinator}
+}
+
+// Go runs fn in the background with a context canceled on shutdown
+func (g *workerGroup) Go(fn func(ctx context.Context)) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		fn(g.ctx)
+	}()
+}
+
+// GoAsLeader runs fn in the background while this instance holds the lease name
+func (g *workerGroup) GoAsLeader(name string, fn func(ctx context.Context)) {
+	g.Go(func(ctx context.Context) {
+		g.coordinator.RunAsLeader(ctx, name, fn)
+	})
+}
+
+// Shutdown cancels the workers and waits until ctx is done for them to return
+func (g *workerGroup) Shutdown(ctx context.Context) error {
+	g.cancel()
+	stopped := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 //nolint:gochecknoinits
 func init() {
 	rootCmd.AddCommand(runCmd)
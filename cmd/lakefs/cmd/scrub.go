@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+)
+
+// scrubCmd verifies stored objects against the entries of a reference
+var scrubCmd = &cobra.Command{
+	Use:   "scrub",
+	Short: "Verify the objects of a reference against the size and ETag of their entries",
+	Run: func(cmd *cobra.Command, args []string) {
+		repository, _ := cmd.Flags().GetString("repository")
+		reference, _ := cmd.Flags().GetString("ref")
+		prefix, _ := cmd.Flags().GetString("prefix")
+		sampleRate, _ := cmd.Flags().GetFloat64("sample-rate")
+		reportPath, _ := cmd.Flags().GetString("report")
+
+		ctx := context.Background()
+		dbPool := db.BuildDatabaseConnection(cfg.GetDatabaseParams())
+		defer dbPool.Close()
+		cataloger, err := catalog.NewCataloger(catalog.Config{
+			Config: cfg,
+			DB:     dbPool,
+		})
+		if err != nil {
+			fmt.Printf("Failed to create cataloger: %s\n", err)
+			os.Exit(1)
+		}
+		defer func() { _ = cataloger.Close() }()
+
+		if reference == "" {
+			repo, err := cataloger.GetRepository(ctx, repository)
+			if err != nil {
+				fmt.Printf("Failed to get repository: %s\n", err)
+				os.Exit(1)
+			}
+			reference = repo.DefaultBranch
+		}
+		report, err := cataloger.Scrub(ctx, repository, reference, catalog.ScrubParams{
+			Prefix:     prefix,
+			SampleRate: sampleRate,
+		})
+		if err != nil {
+			fmt.Printf("Scrub failed: %s\n", err)
+			os.Exit(1)
+		}
+
+		out := os.Stdout
+		if reportPath != "" {
+			out, err = os.Create(reportPath)
+			if err != nil {
+				fmt.Printf("Failed to create report: %s\n", err)
+				os.Exit(1)
+			}
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			fmt.Printf("Failed to write report: %s\n", err)
+			os.Exit(1)
+		}
+		if reportPath != "" {
+			_ = out.Close()
+		}
+		fmt.Fprintf(os.Stderr, "Checked %d of %d objects, found %d issues\n", report.Checked, report.Scanned, len(report.Issues))
+		if len(report.Issues) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(scrubCmd)
+	f := scrubCmd.Flags()
+	f.String("repository", "", "repository to scrub")
+	f.String("ref", "", "reference to scrub (default branch when empty)")
+	f.String("prefix", "", "scrub only the objects under this path prefix")
+	f.Float64("sample-rate", 1, "fraction of the objects to verify")
+	f.String("report", "", "write the JSON report to this file instead of stdout")
+
+	_ = scrubCmd.MarkFlagRequired("repository")
+}
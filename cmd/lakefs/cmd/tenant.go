@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/treeverse/lakefs/auth"
+	"github.com/treeverse/lakefs/auth/crypt"
+	"github.com/treeverse/lakefs/auth/model"
+	"github.com/treeverse/lakefs/db"
+)
+
+// tenantCmd manages the tenants of the installation
+var tenantCmd = &cobra.Command{
+	Use:   "tenant",
+	Short: "Manage tenants, isolated groups of repositories, users, groups and policies",
+}
+
+func newTenantAuthService(dbPool db.Database) auth.Service {
+	return auth.NewDBAuthService(
+		dbPool,
+		crypt.NewSecretStore(cfg.GetAuthEncryptionSecret()),
+		cfg.GetAuthCacheConfig())
+}
+
+var tenantCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a tenant with an admin user",
+	Run: func(cmd *cobra.Command, args []string) {
+		name, _ := cmd.Flags().GetString("name")
+		maxRepositories, _ := cmd.Flags().GetInt("max-repositories")
+		maxUsers, _ := cmd.Flags().GetInt("max-users")
+		admin, _ := cmd.Flags().GetString("admin")
+
+		dbPool := db.BuildDatabaseConnection(cfg.GetDatabaseParams())
+		defer dbPool.Close()
+		authService := newTenantAuthService(dbPool)
+
+		adminUsername := auth.TenantPrefix(name) + admin
+		credentials, err := auth.SetupTenant(authService, &model.Tenant{
+			CreatedAt:       time.Now(),
+			DisplayName:     name,
+			MaxRepositories: maxRepositories,
+			MaxUsers:        maxUsers,
+		}, adminUsername)
+		if err != nil {
+			fmt.Printf("Failed to create tenant: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("admin: %s\ncredentials:\n  access_key_id: %s\n  secret_access_key: %s\n",
+			adminUsername, credentials.AccessKeyID, credentials.AccessSecretKey)
+	},
+}
+
+var tenantListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List tenants",
+	Run: func(cmd *cobra.Command, args []string) {
+		dbPool := db.BuildDatabaseConnection(cfg.GetDatabaseParams())
+		defer dbPool.Close()
+		authService := newTenantAuthService(dbPool)
+
+		tenants, _, err := authService.ListTenants(&model.PaginationParams{Amount: -1})
+		if err != nil {
+			fmt.Printf("Failed to list tenants: %s\n", err)
+			os.Exit(1)
+		}
+		for _, t := range tenants {
+			fmt.Printf("%s\tmax_repositories=%d\tmax_users=%d\n", t.DisplayName, t.MaxRepositories, t.MaxUsers)
+		}
+	},
+}
+
+var tenantDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a tenant with its users, groups and policies; its repositories are kept",
+	Run: func(cmd *cobra.Command, args []string) {
+		name, _ := cmd.Flags().GetString("name")
+
+		dbPool := db.BuildDatabaseConnection(cfg.GetDatabaseParams())
+		defer dbPool.Close()
+		authService := newTenantAuthService(dbPool)
+
+		if err := authService.DeleteTenant(name); err != nil {
+			fmt.Printf("Failed to delete tenant: %s\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(tenantCmd)
+	tenantCmd.AddCommand(tenantCreateCmd)
+	tenantCmd.AddCommand(tenantListCmd)
+	tenantCmd.AddCommand(tenantDeleteCmd)
+
+	f := tenantCreateCmd.Flags()
+	f.String("name", "", "tenant name, prefixing the names of its repositories, users, groups and policies")
+	f.Int("max-repositories", 0, "maximal number of repositories of the tenant, 0 for unlimited")
+	f.Int("max-users", 0, "maximal number of users of the tenant, 0 for unlimited")
+	f.String("admin", "admin", "name of the tenant admin user, without the tenant prefix")
+	_ = tenantCreateCmd.MarkFlagRequired("name")
+
+	tenantDeleteCmd.Flags().String("name", "", "tenant to delete")
+	_ = tenantDeleteCmd.MarkFlagRequired("name")
+}
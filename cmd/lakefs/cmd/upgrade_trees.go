@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+)
+
+// upgradeTreesCmd rewrites the trees of branch heads in the current tree format version
+var upgradeTreesCmd = &cobra.Command{
+	Use:   "upgrade-trees",
+	Short: "Rewrite the trees of branch heads written in an older tree format version",
+	Run: func(cmd *cobra.Command, args []string) {
+		repository, _ := cmd.Flags().GetString("repository")
+		committer, _ := cmd.Flags().GetString("committer")
+
+		ctx := context.Background()
+		dbPool := db.BuildDatabaseConnection(cfg.GetDatabaseParams())
+		defer dbPool.Close()
+		cataloger, err := catalog.NewCataloger(catalog.Config{
+			Config: cfg,
+			DB:     dbPool,
+		})
+		if err != nil {
+			fmt.Printf("Failed to create cataloger: %s\n", err)
+			os.Exit(1)
+		}
+		defer func() { _ = cataloger.Close() }()
+
+		repositories := []string{repository}
+		if repository == "" {
+			repos, _, err := cataloger.ListRepositories(ctx, -1, "")
+			if err != nil {
+				fmt.Printf("Failed to list repositories: %s\n", err)
+				os.Exit(1)
+			}
+			repositories = make([]string, len(repos))
+			for i, repo := range repos {
+				repositories[i] = repo.Name
+			}
+		}
+
+		for _, name := range repositories {
+			upgraded, err := cataloger.UpgradeTrees(ctx, name, committer)
+			if err != nil {
+				fmt.Printf("Upgrade of %s failed: %s\n", name, err)
+				os.Exit(1)
+			}
+			for branch, commitID := range upgraded {
+				fmt.Printf("%s: upgraded branch %s in commit %s\n", name, branch, commitID)
+			}
+		}
+	},
+}
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(upgradeTreesCmd)
+	upgradeTreesCmd.Flags().String("repository", "", "repository to upgrade (all repositories when empty)")
+	upgradeTreesCmd.Flags().String("committer", catalog.TreeUpgradeCommitter, "committer of upgrade commits")
+}
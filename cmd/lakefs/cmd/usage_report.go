@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/usage"
+)
+
+const usageReportDateLayout = "2006-01-02"
+
+// usageReportCmd attributes stored bytes and requests to branches and top-level prefixes
+var usageReportCmd = &cobra.Command{
+	Use:   "usage-report",
+	Short: "Write a CSV report of the objects stored and the requests served under the top-level prefixes of each branch",
+	Run: func(cmd *cobra.Command, args []string) {
+		repository, _ := cmd.Flags().GetString("repository")
+		sinceFlag, _ := cmd.Flags().GetString("since")
+		untilFlag, _ := cmd.Flags().GetString("until")
+		reportPath, _ := cmd.Flags().GetString("output")
+
+		until := time.Now()
+		if untilFlag != "" {
+			var err error
+			until, err = time.Parse(usageReportDateLayout, untilFlag)
+			if err != nil {
+				fmt.Printf("Invalid --until date: %s\n", err)
+				os.Exit(1)
+			}
+		}
+		const defaultReportDays = 30
+		since := until.AddDate(0, 0, -defaultReportDays)
+		if sinceFlag != "" {
+			var err error
+			since, err = time.Parse(usageReportDateLayout, sinceFlag)
+			if err != nil {
+				fmt.Printf("Invalid --since date: %s\n", err)
+				os.Exit(1)
+			}
+		}
+
+		ctx := context.Background()
+		dbPool := db.BuildDatabaseConnection(cfg.GetDatabaseParams())
+		defer dbPool.Close()
+		cataloger, err := catalog.NewCataloger(catalog.Config{
+			Config: cfg,
+			DB:     dbPool,
+		})
+		if err != nil {
+			fmt.Printf("Failed to create cataloger: %s\n", err)
+			os.Exit(1)
+		}
+		defer func() { _ = cataloger.Close() }()
+
+		rows, err := usage.Report(ctx, cataloger, dbPool, repository, since, until)
+		if err != nil {
+			fmt.Printf("Usage report failed: %s\n", err)
+			os.Exit(1)
+		}
+
+		out := os.Stdout
+		if reportPath != "" {
+			out, err = os.Create(reportPath)
+			if err != nil {
+				fmt.Printf("Failed to create report: %s\n", err)
+				os.Exit(1)
+			}
+		}
+		if err := usage.WriteCSV(out, rows); err != nil {
+			fmt.Printf("Failed to write report: %s\n", err)
+			os.Exit(1)
+		}
+		if reportPath != "" {
+			_ = out.Close()
+		}
+	},
+}
+
+//nolint:gochecknoinits
+func init() {
+	rootCmd.AddCommand(usageReportCmd)
+	f := usageReportCmd.Flags()
+	f.String("repository", "", "repository to report")
+	f.String("since", "", "first day of requests to report, YYYY-MM-DD (default 30 days before --until)")
+	f.String("until", "", "day after the last day of requests to report, YYYY-MM-DD (default now)")
+	f.String("output", "", "write the CSV report to this file instead of stdout")
+
+	_ = usageReportCmd.MarkFlagRequired("repository")
+}
@@ -1,9 +1,11 @@
 package config
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"math"
+	"net"
 	"net/url"
 	"strings"
 	"time"
@@ -11,6 +13,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
 	"github.com/aws/aws-sdk-go/service/sts"
 	_ "github.com/jackc/pgx/v4/stdlib"
 	"github.com/mitchellh/go-homedir"
@@ -20,9 +23,13 @@ import (
 	"github.com/treeverse/lakefs/block/factory"
 	blockparams "github.com/treeverse/lakefs/block/params"
 	dbparams "github.com/treeverse/lakefs/db/params"
+	"github.com/treeverse/lakefs/encryption"
+	"github.com/treeverse/lakefs/faults"
 	"github.com/treeverse/lakefs/graveler/committed"
+	"github.com/treeverse/lakefs/httputil"
 	"github.com/treeverse/lakefs/logging"
 	pyramidparams "github.com/treeverse/lakefs/pyramid/params"
+	"github.com/treeverse/lakefs/settings"
 )
 
 const (
@@ -42,6 +49,9 @@ const (
 	DefaultCommittedPermanentMinRangeSizeBytes      = 0
 	DefaultCommittedPermanentMaxRangeSizeBytes      = 20 * 1024 * 1024
 	DefaultCommittedPermanentRangeRaggednessEntries = 50_000
+	DefaultCommittedDeltaMaxChanges                 = 0
+	DefaultCommittedDeltaMaxDepth                   = 20
+	DefaultStagingSpillThreshold                    = 0
 
 	DefaultBlockStoreGSS3Endpoint = "https://storage.googleapis.com"
 
@@ -59,6 +69,27 @@ const (
 	DefaultStatsAddr          = "https://stats.treeverse.io"
 	DefaultStatsFlushInterval = time.Second * 30
 
+	DefaultEphemeralBranchesReapInterval = time.Minute
+	DefaultAutoCommitCheckInterval       = 30 * time.Second
+	DefaultMergeQueueInterval            = time.Second
+
+	DefaultShutdownTimeout = 30 * time.Second
+
+	DefaultCachingImmutableMaxAge = 365 * 24 * time.Hour
+
+	DefaultCoordinationLeaseDuration = 15 * time.Second
+
+	DefaultReplicaMaxStaleness  = 30 * time.Second
+	DefaultReplicaCheckInterval = 5 * time.Second
+
+	DefaultRepositoriesNotificationsEnabled = true
+
+	DefaultEncryptionKeyManager = "local"
+
+	DefaultScrubSampleRate = 0.01
+
+	DefaultRefsJournalEnabled = true
+
 	MetaStoreType          = "metastore.type"
 	MetaStoreHiveURI       = "metastore.hive.uri"
 	MetastoreGlueCatalogID = "metastore.glue.catalog_id"
@@ -67,6 +98,8 @@ const (
 var (
 	ErrMissingSecretKey  = errors.New("auth.encrypt.secret_key cannot be empty")
 	ErrInvalidProportion = errors.New("total proportion isn't 1.0")
+	ErrInvalidKeyManager = errors.New("invalid encryption key manager")
+	ErrInvalidPrimaryURL = errors.New("replica.primary_url must be an http or https URL")
 )
 
 type LogrusAWSAdapter struct {
@@ -98,6 +131,23 @@ const (
 	AuthCacheTTLKey     = "auth.cache.ttl"
 	AuthCacheJitterKey  = "auth.cache.jitter"
 
+	AuthLDAPURLKey                = "auth.ldap.url"
+	AuthLDAPBindDNKey             = "auth.ldap.bind_dn"
+	AuthLDAPBindPasswordKey       = "auth.ldap.bind_password"
+	AuthLDAPUserBaseDNKey         = "auth.ldap.user_base_dn"
+	AuthLDAPUserFilterKey         = "auth.ldap.user_filter"
+	AuthLDAPGroupBaseDNKey        = "auth.ldap.group_base_dn"
+	AuthLDAPGroupFilterKey        = "auth.ldap.group_filter"
+	AuthLDAPGroupNameAttributeKey = "auth.ldap.group_name_attribute"
+	AuthLDAPGroupPoliciesKey      = "auth.ldap.group_policies"
+	AuthLDAPPoolSizeKey           = "auth.ldap.pool_size"
+	AuthLDAPTimeoutKey            = "auth.ldap.timeout"
+
+	AuthOPAURLKey     = "auth.opa.url"
+	AuthOPATimeoutKey = "auth.opa.timeout"
+
+	AuthTrustedProxiesKey = "auth.trusted_proxies"
+
 	BlockstoreTypeKey                    = "blockstore.type"
 	BlockstoreLocalPathKey               = "blockstore.local.path"
 	BlockstoreS3RegionKey                = "blockstore.s3.region"
@@ -114,9 +164,18 @@ const (
 	CommittedPermanentStorageMinRangeSizeKey    = "committed.permanent.min_range_size_bytes"
 	CommittedPermanentStorageMaxRangeSizeKey    = "committed.permanent.max_range_size_bytes"
 	CommittedPermanentStorageRangeRaggednessKey = "committed.permanent.range_raggedness_entries"
+	CommittedPermanentStorageMaxRangeEntriesKey = "committed.permanent.max_range_entries"
+	CommittedPermanentStoragePrefixDepthKey     = "committed.permanent.prefix_boundary_depth"
 
 	CommittedPebbleSSTableCacheSizeBytesKey = "committed.sstable.memory.cache_size_bytes"
 
+	CommittedUpgradeIntervalKey = "committed.upgrade.interval"
+
+	CommittedDeltaMaxChangesKey = "committed.delta.max_changes"
+	CommittedDeltaMaxDepthKey   = "committed.delta.max_depth"
+
+	StagingSpillThresholdKey = "staging.spill.threshold"
+
 	GatewaysS3DomainNameKey = "gateways.s3.domain_name"
 	GatewaysS3RegionKey     = "gateways.s3.region"
 
@@ -125,6 +184,49 @@ const (
 	StatsEnabledKey       = "stats.enabled"
 	StatsAddressKey       = "stats.address"
 	StatsFlushIntervalKey = "stats.flush_interval"
+
+	EphemeralBranchesReapIntervalKey = "catalog.ephemeral_branches.reap_interval"
+
+	ScrubIntervalKey   = "catalog.scrub.interval"
+	ScrubSampleRateKey = "catalog.scrub.sample_rate"
+	ScrubReportDirKey  = "catalog.scrub.report_dir"
+
+	MetadataBackupIntervalKey = "catalog.backup.interval"
+
+	RefsJournalEnabledKey = "catalog.refs_journal.enabled"
+
+	AutoCommitCheckIntervalKey = "catalog.auto_commit.check_interval"
+
+	MergeQueueIntervalKey = "catalog.merge_queue.interval"
+
+	NotificationsBaseURLKey = "notifications.base_url"
+
+	CachingImmutableMaxAgeKey = "caching.immutable_max_age"
+	CachingPublicKey          = "caching.public"
+
+	ShutdownTimeoutKey    = "shutdown.timeout"
+	ShutdownDrainDelayKey = "shutdown.drain_delay"
+
+	CoordinationLeaseDurationKey = "coordination.lease_duration"
+
+	ReplicaPrimaryURLKey    = "replica.primary_url"
+	ReplicaMaxStalenessKey  = "replica.max_staleness"
+	ReplicaCheckIntervalKey = "replica.check_interval"
+
+	ReplicaIssueConsistencyTokensKey = "replica.issue_consistency_tokens"
+
+	RepositoriesDefaultStorageClassKey         = "repositories.defaults.storage_class"
+	RepositoriesDefaultMaxObjectSizeKey        = "repositories.defaults.max_object_size"
+	RepositoriesDefaultNotificationsEnabledKey = "repositories.defaults.notifications_enabled"
+
+	EncryptionRulesKey           = "encryption.rules"
+	EncryptionKeyManagerKey      = "encryption.key_manager"
+	EncryptionLocalMasterKeysKey = "encryption.local.master_keys"
+
+	// FaultsBlockstoreKey and FaultsDatabaseKey prefix the keys of faults injected into the
+	// blockstore and the database: error_rate, latency, partial_write_rate and operations
+	FaultsBlockstoreKey = "faults.blockstore"
+	FaultsDatabaseKey   = "faults.database"
 )
 
 func setDefaults() {
@@ -157,6 +259,10 @@ func setDefaults() {
 	viper.SetDefault(CommittedPermanentStorageMaxRangeSizeKey, DefaultCommittedPermanentMaxRangeSizeBytes)
 	viper.SetDefault(CommittedPermanentStorageRangeRaggednessKey, DefaultCommittedPermanentRangeRaggednessEntries)
 	viper.SetDefault(CommittedPebbleSSTableCacheSizeBytesKey, DefaultCommittedPebbleSSTableCacheSizeBytes)
+	viper.SetDefault(CommittedDeltaMaxChangesKey, DefaultCommittedDeltaMaxChanges)
+	viper.SetDefault(CommittedDeltaMaxDepthKey, DefaultCommittedDeltaMaxDepth)
+
+	viper.SetDefault(StagingSpillThresholdKey, DefaultStagingSpillThreshold)
 
 	viper.SetDefault(GatewaysS3DomainNameKey, DefaultS3GatewayDomainName)
 	viper.SetDefault(GatewaysS3RegionKey, DefaultS3GatewayRegion)
@@ -166,6 +272,26 @@ func setDefaults() {
 	viper.SetDefault(StatsEnabledKey, DefaultStatsEnabled)
 	viper.SetDefault(StatsAddressKey, DefaultStatsAddr)
 	viper.SetDefault(StatsFlushIntervalKey, DefaultStatsFlushInterval)
+
+	viper.SetDefault(EphemeralBranchesReapIntervalKey, DefaultEphemeralBranchesReapInterval)
+	viper.SetDefault(AutoCommitCheckIntervalKey, DefaultAutoCommitCheckInterval)
+	viper.SetDefault(MergeQueueIntervalKey, DefaultMergeQueueInterval)
+
+	viper.SetDefault(ScrubSampleRateKey, DefaultScrubSampleRate)
+	viper.SetDefault(RefsJournalEnabledKey, DefaultRefsJournalEnabled)
+
+	viper.SetDefault(ShutdownTimeoutKey, DefaultShutdownTimeout)
+
+	viper.SetDefault(CachingImmutableMaxAgeKey, DefaultCachingImmutableMaxAge)
+
+	viper.SetDefault(CoordinationLeaseDurationKey, DefaultCoordinationLeaseDuration)
+
+	viper.SetDefault(ReplicaMaxStalenessKey, DefaultReplicaMaxStaleness)
+	viper.SetDefault(ReplicaCheckIntervalKey, DefaultReplicaCheckInterval)
+
+	viper.SetDefault(RepositoriesDefaultNotificationsEnabledKey, DefaultRepositoriesNotificationsEnabled)
+
+	viper.SetDefault(EncryptionKeyManagerKey, DefaultEncryptionKeyManager)
 }
 
 type Configurator interface {
@@ -189,6 +315,18 @@ func (c *Config) GetDatabaseParams() dbparams.Database {
 		MaxOpenConnections:    viper.GetInt32("database.max_open_connections"),
 		MaxIdleConnections:    viper.GetInt32("database.max_idle_connections"),
 		ConnectionMaxLifetime: viper.GetDuration("database.connection_max_lifetime"),
+		Replica:               viper.GetString(ReplicaPrimaryURLKey) != "",
+		Faults:                getFaultsParams(FaultsDatabaseKey),
+	}
+}
+
+// getFaultsParams returns the faults configured under prefix
+func getFaultsParams(prefix string) faults.Params {
+	return faults.Params{
+		ErrorRate:        viper.GetFloat64(prefix + ".error_rate"),
+		Latency:          viper.GetDuration(prefix + ".latency"),
+		PartialWriteRate: viper.GetFloat64(prefix + ".partial_write_rate"),
+		Operations:       viper.GetStringSlice(prefix + ".operations"),
 	}
 }
 
@@ -317,6 +455,11 @@ func (c *Config) GetBlockAdapterGSParams() (blockparams.GS, error) {
 	}, nil
 }
 
+// GetBlockAdapterFaultsParams returns the faults injected into the blockstore, for testing
+func (c *Config) GetBlockAdapterFaultsParams() faults.Params {
+	return getFaultsParams(FaultsBlockstoreKey)
+}
+
 func (c *Config) GetAuthCacheConfig() authparams.ServiceCache {
 	return authparams.ServiceCache{
 		Enabled:        viper.GetBool(AuthCacheEnabledKey),
@@ -326,6 +469,58 @@ func (c *Config) GetAuthCacheConfig() authparams.ServiceCache {
 	}
 }
 
+// GetAuthLDAPConfig returns the LDAP directory authenticating users, nil when no directory
+// is configured
+func (c *Config) GetAuthLDAPConfig() *authparams.LDAP {
+	if !viper.IsSet(AuthLDAPURLKey) {
+		return nil
+	}
+	return &authparams.LDAP{
+		URL:                viper.GetString(AuthLDAPURLKey),
+		BindDN:             viper.GetString(AuthLDAPBindDNKey),
+		BindPassword:       viper.GetString(AuthLDAPBindPasswordKey),
+		UserBaseDN:         viper.GetString(AuthLDAPUserBaseDNKey),
+		UserFilter:         viper.GetString(AuthLDAPUserFilterKey),
+		GroupBaseDN:        viper.GetString(AuthLDAPGroupBaseDNKey),
+		GroupFilter:        viper.GetString(AuthLDAPGroupFilterKey),
+		GroupNameAttribute: viper.GetString(AuthLDAPGroupNameAttributeKey),
+		PoolSize:           viper.GetInt(AuthLDAPPoolSizeKey),
+		Timeout:            viper.GetDuration(AuthLDAPTimeoutKey),
+	}
+}
+
+// GetAuthLDAPGroupPolicies maps LDAP group names to the policies of their members
+func (c *Config) GetAuthLDAPGroupPolicies() map[string][]string {
+	return viper.GetStringMapStringSlice(AuthLDAPGroupPoliciesKey)
+}
+
+// GetAuthOPAConfig returns the OPA server deciding access requests in place of lakeFS policies,
+// nil when no server is configured
+func (c *Config) GetAuthOPAConfig() *authparams.OPA {
+	if !viper.IsSet(AuthOPAURLKey) {
+		return nil
+	}
+	return &authparams.OPA{
+		URL:     viper.GetString(AuthOPAURLKey),
+		Timeout: viper.GetDuration(AuthOPATimeoutKey),
+	}
+}
+
+// GetAuthTrustedProxies returns the networks of the proxies trusted to report the source
+// address of requests in X-Forwarded-For
+func (c *Config) GetAuthTrustedProxies() ([]*net.IPNet, error) {
+	cidrs := viper.GetStringSlice(AuthTrustedProxiesKey)
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", AuthTrustedProxiesKey, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
 func (c *Config) GetAuthEncryptionSecret() []byte {
 	secret := viper.GetString("auth.encrypt.secret_key")
 	if len(secret) == 0 {
@@ -362,6 +557,176 @@ func (c *Config) GetStatsFlushInterval() time.Duration {
 	return viper.GetDuration(StatsFlushIntervalKey)
 }
 
+func (c *Config) GetEphemeralBranchesReapInterval() time.Duration {
+	return viper.GetDuration(EphemeralBranchesReapIntervalKey)
+}
+
+// GetCommittedUpgradeInterval returns the interval between upgrades of branch trees to the current
+// tree format version, background upgrades are disabled when 0
+func (c *Config) GetCommittedUpgradeInterval() time.Duration {
+	return viper.GetDuration(CommittedUpgradeIntervalKey)
+}
+
+// GetMetadataBackupInterval returns the interval between metadata backups, backups are disabled when 0
+func (c *Config) GetMetadataBackupInterval() time.Duration {
+	return viper.GetDuration(MetadataBackupIntervalKey)
+}
+
+// GetRefsJournalEnabled returns true if changes to refs are recorded in the storage namespaces of
+// repositories, to rebuild their refs if the database is lost
+func (c *Config) GetRefsJournalEnabled() bool {
+	return viper.GetBool(RefsJournalEnabledKey)
+}
+
+// GetAutoCommitCheckInterval returns the interval between checks of branch auto-commit policies,
+// auto-commits are disabled when 0
+func (c *Config) GetAutoCommitCheckInterval() time.Duration {
+	return viper.GetDuration(AutoCommitCheckIntervalKey)
+}
+
+// GetMergeQueueInterval returns the interval between processing rounds of the branch merge
+// queues, queued merges are not processed when 0
+func (c *Config) GetMergeQueueInterval() time.Duration {
+	return viper.GetDuration(MergeQueueIntervalKey)
+}
+
+// GetShutdownTimeout returns the time to wait on shutdown for in-flight requests, background
+// workers and jobs to finish
+func (c *Config) GetShutdownTimeout() time.Duration {
+	return viper.GetDuration(ShutdownTimeoutKey)
+}
+
+// GetShutdownDrainDelay returns the time to keep serving requests on shutdown after the health
+// check starts failing, letting load balancers stop routing to this server
+func (c *Config) GetShutdownDrainDelay() time.Duration {
+	return viper.GetDuration(ShutdownDrainDelayKey)
+}
+
+// GetCoordinationLeaseDuration returns the time background work stays assigned to a lakeFS
+// instance that stopped renewing it
+func (c *Config) GetCoordinationLeaseDuration() time.Duration {
+	return viper.GetDuration(CoordinationLeaseDurationKey)
+}
+
+type ReplicaConfig struct {
+	// PrimaryURL is the lakeFS serving from the primary metadata store that requests other
+	// than reads are forwarded to
+	PrimaryURL    *url.URL
+	MaxStaleness  time.Duration
+	CheckInterval time.Duration
+}
+
+// GetReplicaConfig returns the configuration of read replica mode, or nil if this instance
+// serves from the primary metadata store
+func (c *Config) GetReplicaConfig() (*ReplicaConfig, error) {
+	primaryURL := viper.GetString(ReplicaPrimaryURLKey)
+	if primaryURL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(primaryURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%s: %w", primaryURL, ErrInvalidPrimaryURL)
+	}
+	return &ReplicaConfig{
+		PrimaryURL:    u,
+		MaxStaleness:  viper.GetDuration(ReplicaMaxStalenessKey),
+		CheckInterval: viper.GetDuration(ReplicaCheckIntervalKey),
+	}, nil
+}
+
+// GetReplicaIssueConsistencyTokens returns true if writes served from the primary metadata
+// store return consistency tokens for reads from replicas
+func (c *Config) GetReplicaIssueConsistencyTokens() bool {
+	return viper.GetBool(ReplicaIssueConsistencyTokensKey)
+}
+
+// GetRepositorySettingsDefaults returns the settings of repositories without overrides.  They are
+// read on every call, so they follow reloads of the configuration.
+func (c *Config) GetRepositorySettingsDefaults() settings.Settings {
+	return settings.Settings{
+		StorageClass:         viper.GetString(RepositoriesDefaultStorageClassKey),
+		MaxObjectSize:        viper.GetInt64(RepositoriesDefaultMaxObjectSizeKey),
+		NotificationsEnabled: viper.GetBool(RepositoriesDefaultNotificationsEnabledKey),
+	}
+}
+
+// GetNotificationsBaseURL returns the URL of the lakeFS UI linked from notifications, links are
+// omitted when empty
+func (c *Config) GetNotificationsBaseURL() string {
+	return viper.GetString(NotificationsBaseURLKey)
+}
+
+// GetCacheParams returns the Cache-Control parameters of responses reading objects
+func (c *Config) GetCacheParams() httputil.CacheParams {
+	return httputil.CacheParams{
+		ImmutableMaxAge: viper.GetDuration(CachingImmutableMaxAgeKey),
+		Public:          viper.GetBool(CachingPublicKey),
+	}
+}
+
+type encryptionRule struct {
+	Repository string `mapstructure:"repository"`
+	Prefix     string `mapstructure:"prefix"`
+	KeyID      string `mapstructure:"key_id"`
+}
+
+// GetEncryptor returns the encryptor of objects written under the configured encryption rules,
+// or nil if no rules are configured
+func (c *Config) GetEncryptor() (*encryption.Encryptor, error) {
+	var rules []encryptionRule
+	if err := viper.UnmarshalKey(EncryptionRulesKey, &rules); err != nil {
+		return nil, fmt.Errorf("%s: %w", EncryptionRulesKey, err)
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	var keys encryption.KeyManager
+	switch keyManager := viper.GetString(EncryptionKeyManagerKey); keyManager {
+	case "local":
+		masterKeys := make(map[string][]byte)
+		for keyID, value := range viper.GetStringMapString(EncryptionLocalMasterKeysKey) {
+			key, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", EncryptionLocalMasterKeysKey, keyID, err)
+			}
+			masterKeys[keyID] = key
+		}
+		local, err := encryption.NewLocalKeyManager(masterKeys)
+		if err != nil {
+			return nil, err
+		}
+		keys = local
+	case "kms":
+		sess, err := session.NewSession(c.GetAwsConfig())
+		if err != nil {
+			return nil, fmt.Errorf("get AWS session: %w", err)
+		}
+		keys = encryption.NewKMSKeyManager(kms.New(sess))
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidKeyManager, keyManager)
+	}
+	encryptionRules := make([]encryption.Rule, len(rules))
+	for i, rule := range rules {
+		encryptionRules[i] = encryption.Rule(rule)
+	}
+	return encryption.NewEncryptor(keys, encryptionRules), nil
+}
+
+type ScrubConfig struct {
+	// Interval between background scrubs, scrubbing is disabled when 0
+	Interval   time.Duration
+	SampleRate float64
+	ReportDir  string
+}
+
+func (c *Config) GetScrubConfig() ScrubConfig {
+	return ScrubConfig{
+		Interval:   viper.GetDuration(ScrubIntervalKey),
+		SampleRate: viper.GetFloat64(ScrubSampleRateKey),
+		ReportDir:  viper.GetString(ScrubReportDirKey),
+	}
+}
+
 const floatSumTolerance = 1e-6
 
 // GetCommittedTierFSParams returns parameters for building a tierFS.  Caller must separately
@@ -405,10 +770,23 @@ func (c *Config) GetCommittedParams() *committed.Params {
 		MinRangeSizeBytes:          viper.GetUint64(CommittedPermanentStorageMinRangeSizeKey),
 		MaxRangeSizeBytes:          viper.GetUint64(CommittedPermanentStorageMaxRangeSizeKey),
 		RangeSizeEntriesRaggedness: viper.GetFloat64(CommittedPermanentStorageRangeRaggednessKey),
+		MaxRangeEntries:            viper.GetInt(CommittedPermanentStorageMaxRangeEntriesKey),
+		PrefixBoundaryDepth:        viper.GetInt(CommittedPermanentStoragePrefixDepthKey),
 		MaxUploaders:               viper.GetInt(CommittedLocalCacheNumUploadersKey),
 	}
 }
 
+func (c *Config) GetCommittedDeltaParams() *committed.DeltaParams {
+	return &committed.DeltaParams{
+		MaxChanges: viper.GetInt(CommittedDeltaMaxChangesKey),
+		MaxDepth:   viper.GetInt(CommittedDeltaMaxDepthKey),
+	}
+}
+
+func (c *Config) GetStagingSpillThreshold() int {
+	return viper.GetInt(StagingSpillThresholdKey)
+}
+
 func GetMetastoreAwsConfig() *aws.Config {
 	cfg := &aws.Config{
 		Region: aws.String(viper.GetString("metastore.glue.region")),
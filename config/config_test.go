@@ -3,6 +3,7 @@ package config_test
 import (
 	"bufio"
 	"encoding/json"
+	"io/ioutil"
 	"os"
 	"testing"
 
@@ -137,3 +138,36 @@ func TestConfig_JSONLogger(t *testing.T) {
 	}
 
 }
+
+func TestConfig_Reload(t *testing.T) {
+	f, err := ioutil.TempFile("", "lakefs_reload_config_*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.Remove(f.Name())
+	}()
+	writeConfig := func(content string) {
+		if err := ioutil.WriteFile(f.Name(), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig("logging:\n  level: NONE\n")
+	c := newConfigFromFile(f.Name())
+	if defaults := c.GetRepositorySettingsDefaults(); defaults.StorageClass != "" || !defaults.NotificationsEnabled {
+		t.Fatalf("repository settings defaults %+v, expected no storage class and notifications enabled", defaults)
+	}
+
+	writeConfig("logging:\n  level: NONE\nrepositories:\n  defaults:\n    storage_class: STANDARD_IA\n    notifications_enabled: false\n")
+	if err := c.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if defaults := c.GetRepositorySettingsDefaults(); defaults.StorageClass != "STANDARD_IA" || defaults.NotificationsEnabled {
+		t.Errorf("repository settings defaults %+v after reload, expected STANDARD_IA and notifications disabled", defaults)
+	}
+
+	writeConfig("logging: [")
+	if err := c.Reload(); err == nil {
+		t.Error("Reload() of invalid config succeeded")
+	}
+}
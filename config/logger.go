@@ -84,7 +84,10 @@ func setupLogger() {
 		}()
 	}
 
-	// set level
+	setLogLevel()
+}
+
+func setLogLevel() {
 	switch strings.ToLower(viper.GetString(LoggingLevelKey)) {
 	case "trace":
 		log.SetLevel(log.TraceLevel)
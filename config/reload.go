@@ -0,0 +1,25 @@
+package config
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/spf13/viper"
+	"github.com/treeverse/lakefs/logging"
+)
+
+var reloadMu sync.Mutex
+
+// Reload reads the configuration file again.  Settings read on use, such as the logging level
+// and the defaults of repository settings, take effect immediately.  Settings read on startup,
+// such as the listen address or the block adapter, take effect on restart.
+func (c *Config) Reload() error {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	if err := viper.ReadInConfig(); err != nil {
+		return fmt.Errorf("reload config file %s: %w", viper.ConfigFileUsed(), err)
+	}
+	setLogLevel()
+	logging.Default().WithField("config_file", viper.ConfigFileUsed()).Info("Configuration reloaded")
+	return nil
+}
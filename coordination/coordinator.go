@@ -0,0 +1,187 @@
+// Package coordination lets lakeFS instances sharing a database share background work.
+// Workers that must run on a single instance run on the holder of a lease named after them,
+// and work on many repositories is partitioned between the live instances.
+package coordination
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/treeverse/lakefs/logging"
+)
+
+// DefaultLeaseDuration is the time a lease is held without renewal.  Leases and membership are
+// renewed every third of it, so a stopped instance is replaced within that time.
+const DefaultLeaseDuration = 15 * time.Second
+
+// Coordinator is the member of the instances sharing background work that runs in this process
+type Coordinator struct {
+	store         Store
+	id            string
+	leaseDuration time.Duration
+	log           logging.Logger
+
+	mu      sync.RWMutex
+	members []string
+}
+
+// NewInstanceID returns an ID naming this process among the instances, from its hostname
+func NewInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "lakefs"
+	}
+	return fmt.Sprintf("%s-%s", hostname, uuid.New().String()[:8])
+}
+
+func NewCoordinator(store Store, id string, leaseDuration time.Duration) *Coordinator {
+	if leaseDuration <= 0 {
+		leaseDuration = DefaultLeaseDuration
+	}
+	return &Coordinator{
+		store:         store,
+		id:            id,
+		leaseDuration: leaseDuration,
+		log:           logging.Default().WithFields(logging.Fields{"service_name": "coordination", "instance_id": id}),
+		members:       []string{id},
+	}
+}
+
+// ID returns the ID of this instance
+func (c *Coordinator) ID() string {
+	return c.id
+}
+
+func (c *Coordinator) renewInterval() time.Duration {
+	return c.leaseDuration / 3
+}
+
+// Run keeps this instance a member until ctx is done, refreshing the list of live members
+// partitioning work
+func (c *Coordinator) Run(ctx context.Context) {
+	c.heartbeat(ctx)
+	ticker := time.NewTicker(c.renewInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := c.store.Leave(context.Background(), c.id); err != nil {
+				c.log.WithError(err).Warn("Failed to leave")
+			}
+			return
+		case <-ticker.C:
+			c.heartbeat(ctx)
+		}
+	}
+}
+
+func (c *Coordinator) heartbeat(ctx context.Context) {
+	members, err := c.store.Heartbeat(ctx, c.id, c.leaseDuration)
+	if err != nil {
+		if ctx.Err() == nil {
+			c.log.WithError(err).Warn("Failed to record heartbeat")
+		}
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(members) != len(c.members) {
+		c.log.WithField("members", members).Info("Instances sharing background work changed")
+	}
+	c.members = members
+}
+
+// Members returns the IDs of the live instances, as of the last heartbeat
+func (c *Coordinator) Members() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]string(nil), c.members...)
+}
+
+// Owns returns true if the work on key, usually a repository, is assigned to this instance.
+// Every key is owned by exactly one live member, and only the keys of members joining or
+// leaving move.  Instances may briefly disagree while membership changes, so work must still
+// be safe to repeat.
+func (c *Coordinator) Owns(key string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return Owner(c.members, key) == c.id
+}
+
+// Owner returns the member of members assigned key, by rendezvous hashing
+func Owner(members []string, key string) string {
+	var (
+		owner string
+		best  uint64
+	)
+	for _, member := range members {
+		h := sha256.Sum256([]byte(member + "\x00" + key))
+		score := binary.BigEndian.Uint64(h[:8])
+		if owner == "" || score > best {
+			owner, best = member, score
+		}
+	}
+	return owner
+}
+
+// RunAsLeader runs fn only while this instance holds the lease name, until ctx is done.  The
+// context passed to fn is canceled when the lease is lost, and RunAsLeader waits for fn to
+// return before trying to take it again.
+func (c *Coordinator) RunAsLeader(ctx context.Context, name string, fn func(ctx context.Context)) {
+	log := c.log.WithField("lease", name)
+	var (
+		cancel  context.CancelFunc
+		stopped chan struct{}
+	)
+	stop := func() {
+		if cancel == nil {
+			return
+		}
+		cancel()
+		<-stopped
+		cancel = nil
+	}
+	ticker := time.NewTicker(c.renewInterval())
+	defer ticker.Stop()
+	for {
+		acquired, err := c.store.AcquireLease(ctx, name, c.id, c.leaseDuration)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			// unable to renew, so another instance may take over once the lease expires
+			log.WithError(err).Warn("Failed to acquire lease")
+			if cancel != nil {
+				log.Warn("Stopping as leader")
+				stop()
+			}
+		case acquired && cancel == nil:
+			log.Info("Running as leader")
+			var leaderCtx context.Context
+			leaderCtx, cancel = context.WithCancel(ctx)
+			stopped = make(chan struct{})
+			go func() {
+				defer close(stopped)
+				fn(leaderCtx)
+			}()
+		case !acquired && err == nil && cancel != nil:
+			log.Warn("Lease taken by another instance, stopping as leader")
+			stop()
+		}
+		select {
+		case <-ctx.Done():
+			if cancel != nil {
+				stop()
+				if err := c.store.ReleaseLease(context.Background(), name, c.id); err != nil {
+					log.WithError(err).Warn("Failed to release lease")
+				}
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package coordination
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+type lease struct {
+	holder  string
+	expires time.Time
+}
+
+type fakeStore struct {
+	mu      sync.Mutex
+	leases  map[string]lease
+	members map[string]time.Time
+	fail    bool
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{leases: make(map[string]lease), members: make(map[string]time.Time)}
+}
+
+func (s *fakeStore) AcquireLease(_ context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail {
+		return false, fmt.Errorf("database down")
+	}
+	now := time.Now()
+	if l, ok := s.leases[name]; ok && l.holder != holder && l.expires.After(now) {
+		return false, nil
+	}
+	s.leases[name] = lease{holder: holder, expires: now.Add(ttl)}
+	return true, nil
+}
+
+func (s *fakeStore) ReleaseLease(_ context.Context, name, holder string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.leases[name].holder == holder {
+		delete(s.leases, name)
+	}
+	return nil
+}
+
+func (s *fakeStore) Heartbeat(_ context.Context, id string, ttl time.Duration) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.members[id] = now
+	var members []string
+	for member, heartbeat := range s.members {
+		if heartbeat.After(now.Add(-ttl)) {
+			members = append(members, member)
+		}
+	}
+	sort.Strings(members)
+	return members, nil
+}
+
+func (s *fakeStore) Leave(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.members, id)
+	return nil
+}
+
+func (s *fakeStore) holder(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.leases[name].holder
+}
+
+// leaderRecorder records which instances are running as leader
+type leaderRecorder struct {
+	mu      sync.Mutex
+	running map[string]bool
+}
+
+func (r *leaderRecorder) fn(id string) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		r.mu.Lock()
+		r.running[id] = true
+		r.mu.Unlock()
+		<-ctx.Done()
+		r.mu.Lock()
+		delete(r.running, id)
+		r.mu.Unlock()
+	}
+}
+
+func (r *leaderRecorder) leaders() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var leaders []string
+	for id := range r.running {
+		leaders = append(leaders, id)
+	}
+	sort.Strings(leaders)
+	return leaders
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for i := 0; i < 300; i++ {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+func TestCoordinator_RunAsLeader(t *testing.T) {
+	store := newFakeStore()
+	recorder := &leaderRecorder{running: make(map[string]bool)}
+	const leaseDuration = 60 * time.Millisecond
+
+	ctxA, cancelA := context.WithCancel(context.Background())
+	doneA := make(chan struct{})
+	a := NewCoordinator(store, "a", leaseDuration)
+	go func() {
+		a.RunAsLeader(ctxA, "merge_queue", recorder.fn("a"))
+		close(doneA)
+	}()
+	waitFor(t, "a to lead", func() bool { return len(recorder.leaders()) == 1 })
+
+	ctxB, cancelB := context.WithCancel(context.Background())
+	defer cancelB()
+	b := NewCoordinator(store, "b", leaseDuration)
+	go b.RunAsLeader(ctxB, "merge_queue", recorder.fn("b"))
+	time.Sleep(3 * leaseDuration)
+	if leaders := recorder.leaders(); len(leaders) != 1 || leaders[0] != "a" {
+		t.Fatalf("leaders %v while a holds the lease, expected [a]", leaders)
+	}
+
+	// a stops and releases the lease, so b takes over
+	cancelA()
+	<-doneA
+	waitFor(t, "b to lead", func() bool {
+		leaders := recorder.leaders()
+		return len(leaders) == 1 && leaders[0] == "b"
+	})
+	if holder := store.holder("merge_queue"); holder != "b" {
+		t.Errorf("lease holder %s, expected b", holder)
+	}
+}
+
+func TestCoordinator_LeaseLost(t *testing.T) {
+	store := newFakeStore()
+	recorder := &leaderRecorder{running: make(map[string]bool)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a := NewCoordinator(store, "a", 60*time.Millisecond)
+	go a.RunAsLeader(ctx, "reaper", recorder.fn("a"))
+	waitFor(t, "a to lead", func() bool { return len(recorder.leaders()) == 1 })
+
+	// unable to renew, a stops before its lease expires
+	store.mu.Lock()
+	store.fail = true
+	store.mu.Unlock()
+	waitFor(t, "a to stop", func() bool { return len(recorder.leaders()) == 0 })
+
+	store.mu.Lock()
+	store.fail = false
+	store.mu.Unlock()
+	waitFor(t, "a to lead again", func() bool { return len(recorder.leaders()) == 1 })
+}
+
+func TestCoordinator_Owns(t *testing.T) {
+	store := newFakeStore()
+	coordinators := make([]*Coordinator, 3)
+	for i := range coordinators {
+		coordinators[i] = NewCoordinator(store, fmt.Sprintf("lakefs-%d", i), time.Minute)
+	}
+	for _, c := range coordinators {
+		c.heartbeat(context.Background())
+	}
+	// the first heartbeats did not see later members
+	for _, c := range coordinators {
+		c.heartbeat(context.Background())
+	}
+
+	owned := make(map[string]int)
+	for i := 0; i < 300; i++ {
+		key := fmt.Sprintf("repo-%d", i)
+		owners := 0
+		for _, c := range coordinators {
+			if c.Owns(key) {
+				owners++
+				owned[c.ID()]++
+			}
+		}
+		if owners != 1 {
+			t.Fatalf("%s owned by %d instances, expected 1", key, owners)
+		}
+	}
+	for _, c := range coordinators {
+		if owned[c.ID()] < 50 {
+			t.Errorf("%s owns %d of 300 keys, expected about 100", c.ID(), owned[c.ID()])
+		}
+	}
+
+	// keys of a leaving member move, the others stay
+	before := make(map[string]string)
+	for i := 0; i < 300; i++ {
+		key := fmt.Sprintf("repo-%d", i)
+		before[key] = Owner(coordinators[0].Members(), key)
+	}
+	if err := store.Leave(context.Background(), "lakefs-2"); err != nil {
+		t.Fatal(err)
+	}
+	coordinators[0].heartbeat(context.Background())
+	for key, owner := range before {
+		after := Owner(coordinators[0].Members(), key)
+		if owner != "lakefs-2" && after != owner {
+			t.Errorf("%s moved from %s to %s", key, owner, after)
+		}
+		if after == "lakefs-2" {
+			t.Errorf("%s still owned by lakefs-2", key)
+		}
+	}
+
+	// alone, an instance owns everything
+	alone := NewCoordinator(newFakeStore(), "solo", time.Minute)
+	if !alone.Owns("repo") {
+		t.Error("single instance does not own repo")
+	}
+}
@@ -0,0 +1,89 @@
+package coordination
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/treeverse/lakefs/db"
+)
+
+type Store interface {
+	// AcquireLease takes lease name for holder, or extends it if holder already has it, until
+	// ttl from now.  It returns false while another holder has an unexpired lease.
+	AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	// ReleaseLease gives up lease name if holder has it
+	ReleaseLease(ctx context.Context, name, holder string) error
+	// Heartbeat records member id alive, and returns the IDs of the members alive in the last
+	// ttl
+	Heartbeat(ctx context.Context, id string, ttl time.Duration) ([]string, error)
+	// Leave removes member id
+	Leave(ctx context.Context, id string) error
+}
+
+// Times are all taken from the database clock, so leases do not depend on the clocks of
+// lakeFS instances agreeing.
+type store struct {
+	db db.Database
+}
+
+func NewStore(adb db.Database) Store {
+	return &store{db: adb}
+}
+
+func (s *store) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	_, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var acquired string
+		return nil, tx.GetPrimitive(&acquired, `INSERT INTO coordination_leases (name, holder, expires_at)
+			VALUES ($1, $2, now() + make_interval(secs => $3))
+			ON CONFLICT (name) DO UPDATE SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at
+				WHERE coordination_leases.holder = EXCLUDED.holder OR coordination_leases.expires_at < now()
+			RETURNING holder`,
+			name, holder, ttl.Seconds())
+	}, db.WithContext(ctx))
+	if errors.Is(err, db.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *store) ReleaseLease(ctx context.Context, name, holder string) error {
+	_, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		return tx.Exec(`DELETE FROM coordination_leases WHERE name = $1 AND holder = $2`, name, holder)
+	}, db.WithContext(ctx))
+	return err
+}
+
+func (s *store) Heartbeat(ctx context.Context, id string, ttl time.Duration) ([]string, error) {
+	res, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		_, err := tx.Exec(`INSERT INTO coordination_members (id, heartbeat_at) VALUES ($1, now())
+			ON CONFLICT (id) DO UPDATE SET heartbeat_at = EXCLUDED.heartbeat_at`,
+			id)
+		if err != nil {
+			return nil, err
+		}
+		// members that stopped without leaving are forgotten
+		_, err = tx.Exec(`DELETE FROM coordination_members WHERE heartbeat_at < now() - make_interval(secs => $1)`,
+			ttl.Seconds())
+		if err != nil {
+			return nil, err
+		}
+		var members []string
+		err = tx.Select(&members, `SELECT id FROM coordination_members ORDER BY id`)
+		return members, err
+	}, db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.([]string), nil
+}
+
+func (s *store) Leave(ctx context.Context, id string) error {
+	_, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		return tx.Exec(`DELETE FROM coordination_members WHERE id = $1`, id)
+	}, db.WithContext(ctx))
+	return err
+}
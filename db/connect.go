@@ -7,6 +7,7 @@ import (
 
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/treeverse/lakefs/db/params"
+	"github.com/treeverse/lakefs/faults"
 
 	"github.com/treeverse/lakefs/logging"
 )
@@ -50,6 +51,7 @@ func ConnectDBPool(p params.Database) (*pgxpool.Pool, error) {
 		"max_open_conns":    p.MaxOpenConnections,
 		"max_idle_conns":    p.MaxIdleConnections,
 		"conn_max_lifetime": p.ConnectionMaxLifetime,
+		"replica":           p.Replica,
 	})
 	log.Info("connecting to the DB")
 	config, err := pgxpool.ParseConfig(p.ConnectionString)
@@ -80,7 +82,13 @@ func ConnectDB(p params.Database) (Database, error) {
 	if err != nil {
 		return nil, err
 	}
-	return NewPgxDatabase(pool), nil
+	database := NewPgxDatabase(pool)
+	database.replica = p.Replica
+	if p.Faults.Enabled() {
+		logging.Default().WithField("faults", p.Faults).Warn("Injecting faults into the database")
+		return NewFaultyDatabase(database, faults.NewInjector(p.Faults)), nil
+	}
+	return database, nil
 }
 
 func normalizeDBParams(p *params.Database) {
@@ -41,6 +41,9 @@ type QueryOptions struct {
 type PgxDatabase struct {
 	db           *pgxpool.Pool
 	queryOptions *QueryOptions
+	// replica runs serializable transactions as repeatable read, the strongest isolation a
+	// hot standby offers
+	replica bool
 }
 
 func NewPgxDatabase(db *pgxpool.Pool) *PgxDatabase {
@@ -63,7 +66,8 @@ func (d *PgxDatabase) getContext() context.Context {
 
 func (d *PgxDatabase) WithContext(ctx context.Context) Database {
 	return &PgxDatabase{
-		db: d.db,
+		db:      d.db,
+		replica: d.replica,
 		queryOptions: &QueryOptions{
 			logger: logging.Default().WithContext(ctx),
 			ctx:    ctx,
@@ -160,6 +164,9 @@ func (d *PgxDatabase) Transact(fn TxFunc, opts ...TxOpt) (interface{}, error) {
 	for _, opt := range opts {
 		opt(options)
 	}
+	if d.replica && options.isolationLevel == pgx.Serializable {
+		options.isolationLevel = pgx.RepeatableRead
+	}
 	var attempt int
 	var ret interface{}
 	var err error
@@ -11,4 +11,6 @@ var (
 	ErrNotFound      = fmt.Errorf("not found: %w", pgx.ErrNoRows)
 	ErrAlreadyExists = errors.New("already exists")
 	ErrSerialization = errors.New("serialization error")
+	// ErrReplicaNotStreaming is returned for the lag of a standby not streaming from its primary
+	ErrReplicaNotStreaming = errors.New("standby not streaming from its primary")
 )
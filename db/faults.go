@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/treeverse/lakefs/faults"
+)
+
+// Operations of a database faulted by NewFaultyDatabase
+const (
+	FaultOpQuery        = "query"
+	FaultOpSelect       = "select"
+	FaultOpGet          = "get"
+	FaultOpGetPrimitive = "get_primitive"
+	FaultOpExec         = "exec"
+	FaultOpTransact     = "transact"
+)
+
+// faultyTx injects faults into the statements of another Tx
+type faultyTx struct {
+	tx       Tx
+	injector *faults.Injector
+}
+
+func (t *faultyTx) Query(query string, args ...interface{}) (pgx.Rows, error) {
+	if err := t.injector.Fault(FaultOpQuery); err != nil {
+		return nil, err
+	}
+	return t.tx.Query(query, args...)
+}
+
+func (t *faultyTx) Select(dest interface{}, query string, args ...interface{}) error {
+	if err := t.injector.Fault(FaultOpSelect); err != nil {
+		return err
+	}
+	return t.tx.Select(dest, query, args...)
+}
+
+func (t *faultyTx) Get(dest interface{}, query string, args ...interface{}) error {
+	if err := t.injector.Fault(FaultOpGet); err != nil {
+		return err
+	}
+	return t.tx.Get(dest, query, args...)
+}
+
+func (t *faultyTx) GetPrimitive(dest interface{}, query string, args ...interface{}) error {
+	if err := t.injector.Fault(FaultOpGetPrimitive); err != nil {
+		return err
+	}
+	return t.tx.GetPrimitive(dest, query, args...)
+}
+
+func (t *faultyTx) Exec(query string, args ...interface{}) (pgconn.CommandTag, error) {
+	if err := t.injector.Fault(FaultOpExec); err != nil {
+		return nil, err
+	}
+	return t.tx.Exec(query, args...)
+}
+
+// faultyDatabase injects faults into the statements and transactions of another Database
+type faultyDatabase struct {
+	faultyTx
+	database Database
+}
+
+// NewFaultyDatabase returns a Database that injects the faults of injector into the statements
+// and transactions of database.  A partial write of a transaction fails it after all of its
+// statements ran, so that it rolls back.
+func NewFaultyDatabase(database Database, injector *faults.Injector) Database {
+	return &faultyDatabase{
+		faultyTx: faultyTx{tx: database, injector: injector},
+		database: database,
+	}
+}
+
+func (d *faultyDatabase) Transact(fn TxFunc, opts ...TxOpt) (interface{}, error) {
+	if err := d.injector.Fault(FaultOpTransact); err != nil {
+		return nil, err
+	}
+	return d.database.Transact(func(tx Tx) (interface{}, error) {
+		ret, err := fn(&faultyTx{tx: tx, injector: d.injector})
+		if err == nil && d.injector.PartialWrite(FaultOpTransact) {
+			return nil, faults.ErrInjected
+		}
+		return ret, err
+	}, opts...)
+}
+
+func (d *faultyDatabase) Close() {
+	d.database.Close()
+}
+
+func (d *faultyDatabase) Metadata() (map[string]string, error) {
+	return d.database.Metadata()
+}
+
+func (d *faultyDatabase) Stats() sql.DBStats {
+	return d.database.Stats()
+}
+
+func (d *faultyDatabase) WithContext(ctx context.Context) Database {
+	return NewFaultyDatabase(d.database.WithContext(ctx), d.injector)
+}
+
+func (d *faultyDatabase) Pool() *pgxpool.Pool {
+	return d.database.Pool()
+}
@@ -0,0 +1,57 @@
+package db_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/faults"
+)
+
+func TestFaultyDatabase(t *testing.T) {
+	d := getDB(t)
+	_, err := d.Exec(`CREATE TABLE faults_test (id INTEGER)`)
+	if err != nil {
+		t.Fatalf("create table: %s", err)
+	}
+	defer func() { _, _ = d.Exec(`DROP TABLE faults_test`) }()
+
+	t.Run("errors", func(t *testing.T) {
+		faulty := db.NewFaultyDatabase(d, faults.NewInjector(faults.Params{ErrorRate: 1, Operations: []string{db.FaultOpExec}}))
+		_, err := faulty.Transact(func(tx db.Tx) (interface{}, error) {
+			return tx.Exec(`INSERT INTO faults_test VALUES (1)`)
+		})
+		if !errors.Is(err, faults.ErrInjected) {
+			t.Fatalf("Transact err=%v, expected %s", err, faults.ErrInjected)
+		}
+		var count int
+		if err := faulty.GetPrimitive(&count, `SELECT COUNT(*) FROM faults_test`); err != nil {
+			t.Fatalf("count: %s", err)
+		}
+		if count != 0 {
+			t.Errorf("got %d rows after failed insert, expected none", count)
+		}
+	})
+
+	t.Run("partial writes roll back", func(t *testing.T) {
+		faulty := db.NewFaultyDatabase(d, faults.NewInjector(faults.Params{PartialWriteRate: 1}))
+		ran := false
+		_, err := faulty.Transact(func(tx db.Tx) (interface{}, error) {
+			ran = true
+			return tx.Exec(`INSERT INTO faults_test VALUES (2)`)
+		})
+		if !errors.Is(err, faults.ErrInjected) {
+			t.Fatalf("Transact err=%v, expected %s", err, faults.ErrInjected)
+		}
+		if !ran {
+			t.Error("partial write did not run the transaction")
+		}
+		var count int
+		if err := d.GetPrimitive(&count, `SELECT COUNT(*) FROM faults_test`); err != nil {
+			t.Fatalf("count: %s", err)
+		}
+		if count != 0 {
+			t.Errorf("got %d rows after rolled back insert, expected none", count)
+		}
+	})
+}
@@ -1,6 +1,10 @@
 package params
 
-import "time"
+import (
+	"time"
+
+	"github.com/treeverse/lakefs/faults"
+)
 
 type Database struct {
 	Driver                string
@@ -8,4 +12,8 @@ type Database struct {
 	MaxOpenConnections    int32
 	MaxIdleConnections    int32
 	ConnectionMaxLifetime time.Duration
+	// Replica connects to a hot standby, which cannot run serializable transactions
+	Replica bool
+	// Faults are injected into statements and transactions, for testing
+	Faults faults.Params
 }
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type replicationState struct {
+	InRecovery bool    `db:"in_recovery"`
+	Status     string  `db:"status"`
+	LagSeconds float64 `db:"lag_seconds"`
+}
+
+// ReplicationLag returns how far d lags behind its primary, 0 if it is not a standby.  The lag
+// is the time since the last transaction the standby replayed.  A standby that replayed all it
+// received lags no more than the time since it last heard from its primary, so an idle primary
+// does not make it stale.  Fails with ErrReplicaNotStreaming unless the WAL receiver of the
+// standby is streaming: a disconnected standby falls behind without replaying anything new.
+func ReplicationLag(ctx context.Context, d Database) (time.Duration, error) {
+	res, err := d.Transact(func(tx Tx) (interface{}, error) {
+		var state replicationState
+		err := tx.Get(&state, `
+			SELECT pg_is_in_recovery() AS in_recovery,
+				COALESCE(r.status, '') AS status,
+				COALESCE(EXTRACT(EPOCH FROM now() - CASE
+					WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn()
+						THEN GREATEST(pg_last_xact_replay_timestamp(), r.last_msg_receipt_time)
+					ELSE pg_last_xact_replay_timestamp()
+				END), 0)::float8 AS lag_seconds
+			FROM (SELECT 1) AS one
+				LEFT JOIN pg_stat_wal_receiver AS r ON true`)
+		return &state, err
+	}, ReadOnly(), WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	state := res.(*replicationState)
+	if !state.InRecovery {
+		return 0, nil
+	}
+	if state.Status != "streaming" {
+		return 0, fmt.Errorf("%w: WAL receiver status %q", ErrReplicaNotStreaming, state.Status)
+	}
+	return time.Duration(state.LagSeconds * float64(time.Second)), nil
+}
+
+// CurrentLSN returns the position in the write-ahead log of d after every transaction it
+// committed
+func CurrentLSN(ctx context.Context, d Database) (string, error) {
+	return queryLSN(ctx, d, `SELECT pg_current_wal_lsn()::text`)
+}
+
+// ReplayedLSN returns the position up to which the standby d replayed the write-ahead log of
+// its primary, or the current position if d is not a standby
+func ReplayedLSN(ctx context.Context, d Database) (string, error) {
+	return queryLSN(ctx, d, `SELECT COALESCE(pg_last_wal_replay_lsn(), pg_current_wal_lsn())::text`)
+}
+
+func queryLSN(ctx context.Context, d Database, query string) (string, error) {
+	res, err := d.Transact(func(tx Tx) (interface{}, error) {
+		var lsn string
+		err := tx.GetPrimitive(&lsn, query)
+		return lsn, err
+	}, ReadOnly(), WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	return res.(string), nil
+}
@@ -0,0 +1,35 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/treeverse/lakefs/db"
+)
+
+func TestReplicationLag(t *testing.T) {
+	d := getDB(t)
+	lag, err := db.ReplicationLag(context.Background(), d)
+	if err != nil {
+		t.Fatalf("ReplicationLag: %s", err)
+	}
+	if lag != 0 {
+		t.Errorf("got lag %s on a primary, expected 0", lag)
+	}
+}
+
+func TestLSN(t *testing.T) {
+	d := getDB(t)
+	ctx := context.Background()
+	current, err := db.CurrentLSN(ctx, d)
+	if err != nil {
+		t.Fatalf("CurrentLSN: %s", err)
+	}
+	replayed, err := db.ReplayedLSN(ctx, d)
+	if err != nil {
+		t.Fatalf("ReplayedLSN: %s", err)
+	}
+	if current == "" || replayed == "" {
+		t.Errorf("got current %q replayed %q, expected log positions", current, replayed)
+	}
+}
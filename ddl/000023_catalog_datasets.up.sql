@@ -0,0 +1,13 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS catalog_datasets
+(
+    repository_id text        NOT NULL,
+    name          text        NOT NULL,
+
+    prefixes      text[]      NOT NULL,
+    schema_hints  jsonb,
+    creation_date timestamptz NOT NULL,
+
+    PRIMARY KEY (repository_id, name)
+);
+COMMIT;
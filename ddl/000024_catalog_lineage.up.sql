@@ -0,0 +1,13 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS catalog_lineage
+(
+    repository_id       text NOT NULL,
+    commit_id           text NOT NULL,
+
+    input_repository_id text NOT NULL,
+    input_commit_id     text NOT NULL,
+
+    PRIMARY KEY (repository_id, commit_id, input_repository_id, input_commit_id)
+);
+CREATE INDEX IF NOT EXISTS catalog_lineage_input_idx ON catalog_lineage (input_repository_id, input_commit_id);
+COMMIT;
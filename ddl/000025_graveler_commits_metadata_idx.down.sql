@@ -0,0 +1,3 @@
+BEGIN;
+DROP INDEX IF EXISTS graveler_commits_metadata_idx;
+COMMIT;
@@ -0,0 +1,3 @@
+BEGIN;
+CREATE INDEX IF NOT EXISTS graveler_commits_metadata_idx ON graveler_commits USING gin (metadata jsonb_path_ops);
+COMMIT;
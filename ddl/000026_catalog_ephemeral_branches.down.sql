@@ -0,0 +1,3 @@
+BEGIN;
+DROP TABLE IF EXISTS catalog_ephemeral_branches;
+COMMIT;
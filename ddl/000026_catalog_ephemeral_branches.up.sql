@@ -0,0 +1,14 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS catalog_ephemeral_branches
+(
+    repository_id      text        NOT NULL,
+    branch_id          text        NOT NULL,
+
+    expires_at         timestamptz NOT NULL,
+    delete_after_merge boolean     NOT NULL DEFAULT false,
+    creation_date      timestamptz NOT NULL,
+
+    PRIMARY KEY (repository_id, branch_id)
+);
+CREATE INDEX IF NOT EXISTS catalog_ephemeral_branches_expires_at_idx ON catalog_ephemeral_branches (expires_at);
+COMMIT;
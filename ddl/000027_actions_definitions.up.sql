@@ -0,0 +1,14 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS actions_definitions
+(
+    repository_id text        NOT NULL,
+    name          text        NOT NULL,
+    version       integer     NOT NULL,
+
+    definition    text        NOT NULL,
+    enabled       boolean     NOT NULL,
+    creation_date timestamptz NOT NULL,
+
+    PRIMARY KEY (repository_id, name, version)
+);
+COMMIT;
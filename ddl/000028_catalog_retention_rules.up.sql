@@ -0,0 +1,11 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS catalog_retention_rules
+(
+    repository_id        text        NOT NULL PRIMARY KEY,
+
+    keep_last_commits    integer     NOT NULL,
+    keep_newer_than_days integer     NOT NULL,
+    keep_tagged          boolean     NOT NULL,
+    update_date          timestamptz NOT NULL
+);
+COMMIT;
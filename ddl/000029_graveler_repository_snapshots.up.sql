@@ -0,0 +1,11 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS graveler_repository_snapshots
+(
+    repository_id text        NOT NULL,
+    id            text        NOT NULL,
+    creation_date timestamptz NOT NULL,
+    branches      jsonb       NOT NULL,
+
+    PRIMARY KEY (repository_id, id)
+);
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS catalog_redactions
+(
+    repository_id    text        NOT NULL,
+    physical_address text        NOT NULL,
+
+    path             text        NOT NULL,
+    reason           text        NOT NULL,
+    redacted_by      text        NOT NULL,
+    creation_date    timestamptz NOT NULL,
+
+    PRIMARY KEY (repository_id, physical_address)
+);
+COMMIT;
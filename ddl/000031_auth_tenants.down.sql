@@ -0,0 +1,5 @@
+BEGIN;
+DROP INDEX IF EXISTS idx_auth_users_tenant;
+ALTER TABLE auth_users DROP COLUMN IF EXISTS tenant;
+DROP TABLE IF EXISTS auth_tenants;
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS auth_tenants (
+    id serial NOT NULL PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    display_name text NOT NULL,
+    max_repositories integer NOT NULL DEFAULT 0,
+    max_users integer NOT NULL DEFAULT 0,
+
+    CONSTRAINT auth_tenants_unique_display_name UNIQUE (display_name)
+);
+
+ALTER TABLE auth_users ADD COLUMN IF NOT EXISTS tenant text NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_auth_users_tenant ON auth_users (tenant); -- count users by tenant
+COMMIT;
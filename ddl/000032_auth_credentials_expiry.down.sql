@@ -0,0 +1,7 @@
+BEGIN;
+ALTER TABLE auth_credentials
+    DROP COLUMN IF EXISTS expires_at,
+    DROP COLUMN IF EXISTS last_used_at,
+    DROP COLUMN IF EXISTS previous_access_secret_key,
+    DROP COLUMN IF EXISTS previous_secret_expires_at;
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+ALTER TABLE auth_credentials
+    ADD COLUMN IF NOT EXISTS expires_at timestamptz,
+    ADD COLUMN IF NOT EXISTS last_used_at timestamptz,
+    ADD COLUMN IF NOT EXISTS previous_access_secret_key bytea,
+    ADD COLUMN IF NOT EXISTS previous_secret_expires_at timestamptz;
+COMMIT;
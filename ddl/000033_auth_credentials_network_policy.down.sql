@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE auth_credentials
+    DROP COLUMN IF EXISTS network_policy;
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE auth_credentials
+    ADD COLUMN IF NOT EXISTS network_policy jsonb NOT NULL DEFAULT '{}';
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS catalog_merge_guardrails
+(
+    repository_id       text        NOT NULL,
+    branch_id           text        NOT NULL,
+
+    max_changed_entries integer     NOT NULL,
+    max_deleted_entries integer     NOT NULL,
+    max_bytes           bigint      NOT NULL,
+    update_date         timestamptz NOT NULL,
+
+    PRIMARY KEY (repository_id, branch_id)
+);
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS catalog_path_leases
+(
+    id            text        NOT NULL PRIMARY KEY,
+    repository_id text        NOT NULL,
+    branch_id     text        NOT NULL,
+    prefix        text        NOT NULL,
+    owner         text        NOT NULL,
+    expires_at    timestamptz NOT NULL,
+    creation_date timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS catalog_path_leases_branch_idx
+    ON catalog_path_leases (repository_id, branch_id);
+COMMIT;
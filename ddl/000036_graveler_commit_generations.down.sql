@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE graveler_commits DROP COLUMN IF EXISTS generation;
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+-- generation is 1 for root commits and 1 + the highest generation of the parents otherwise,
+-- 0 until computed for commits added before generations were recorded
+ALTER TABLE graveler_commits ADD COLUMN IF NOT EXISTS generation bigint NOT NULL DEFAULT 0;
+COMMIT;
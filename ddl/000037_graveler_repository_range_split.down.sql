@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE graveler_repositories DROP COLUMN IF EXISTS range_split;
+COMMIT;
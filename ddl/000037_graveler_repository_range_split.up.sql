@@ -0,0 +1,4 @@
+BEGIN;
+-- range_split tunes the split of the trees of the repository into ranges, NULL for the defaults
+ALTER TABLE graveler_repositories ADD COLUMN IF NOT EXISTS range_split jsonb;
+COMMIT;
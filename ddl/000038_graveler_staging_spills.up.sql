@@ -0,0 +1,16 @@
+BEGIN;
+-- graveler_staging_spills holds the sorted chunks of staging areas spilled to object storage.
+-- Entries of later chunks (higher seq) replace those of earlier ones, entries still in
+-- graveler_staging_kv replace both.
+CREATE TABLE IF NOT EXISTS graveler_staging_spills
+(
+    staging_token varchar NOT NULL,
+    seq           bigserial NOT NULL,
+    range_id      varchar NOT NULL,
+    min_key       bytea   NOT NULL,
+    max_key       bytea   NOT NULL,
+    count         bigint  NOT NULL,
+
+    PRIMARY KEY (staging_token, seq)
+);
+COMMIT;
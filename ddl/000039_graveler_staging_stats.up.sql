@@ -0,0 +1,18 @@
+BEGIN;
+-- graveler_staging_stats summarizes the values of each staging area, updated as they are set and
+-- dropped
+CREATE TABLE IF NOT EXISTS graveler_staging_stats
+(
+    staging_token varchar PRIMARY KEY,
+    writes        bigint NOT NULL DEFAULT 0,
+    deletes       bigint NOT NULL DEFAULT 0,
+    size_bytes    bigint NOT NULL DEFAULT 0
+);
+
+-- sizes are only known to lakeFS, areas staged before this migration count no bytes
+INSERT INTO graveler_staging_stats (staging_token, writes, deletes)
+SELECT staging_token, count(identity), count(*) - count(identity)
+FROM graveler_staging_kv
+GROUP BY staging_token
+ON CONFLICT DO NOTHING;
+COMMIT;
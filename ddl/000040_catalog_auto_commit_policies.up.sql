@@ -0,0 +1,14 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS catalog_auto_commit_policies
+(
+    repository_id      text        NOT NULL,
+    branch_id          text        NOT NULL,
+
+    max_staged_entries integer     NOT NULL,
+    interval_seconds   integer     NOT NULL,
+    message_template   text        NOT NULL,
+    update_date        timestamptz NOT NULL,
+
+    PRIMARY KEY (repository_id, branch_id)
+);
+COMMIT;
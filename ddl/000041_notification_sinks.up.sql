@@ -0,0 +1,15 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS notification_sinks
+(
+    repository_id text        NOT NULL,
+    name          text        NOT NULL,
+
+    type          text        NOT NULL,
+    url           text        NOT NULL,
+    events        text[]      NOT NULL,
+    branches      text[]      NOT NULL,
+    creation_date timestamptz NOT NULL,
+
+    PRIMARY KEY (repository_id, name)
+);
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS catalog_schemas
+(
+    repository_id text        NOT NULL,
+    prefix        text        NOT NULL,
+
+    format        text        NOT NULL,
+    definition    text        NOT NULL,
+    compatibility text        NOT NULL,
+    creation_date timestamptz NOT NULL,
+
+    PRIMARY KEY (repository_id, prefix)
+);
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+ALTER TABLE catalog_merge_guardrails
+    DROP COLUMN IF EXISTS required_status_checks;
+
+DROP TABLE IF EXISTS catalog_commit_statuses;
+COMMIT;
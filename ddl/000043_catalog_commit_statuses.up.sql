@@ -0,0 +1,19 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS catalog_commit_statuses
+(
+    repository_id text        NOT NULL,
+    commit_id     text        NOT NULL,
+    context       text        NOT NULL,
+
+    state         text        NOT NULL,
+    description   text        NOT NULL,
+    target_url    text        NOT NULL,
+    creator       text        NOT NULL,
+    update_date   timestamptz NOT NULL,
+
+    PRIMARY KEY (repository_id, commit_id, context)
+);
+
+ALTER TABLE catalog_merge_guardrails
+    ADD COLUMN IF NOT EXISTS required_status_checks text[] NOT NULL DEFAULT '{}';
+COMMIT;
@@ -0,0 +1,24 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS catalog_merge_queue
+(
+    id                  bigserial   PRIMARY KEY,
+    repository_id       text        NOT NULL,
+    destination_branch  text        NOT NULL,
+    source_ref          text        NOT NULL,
+    committer           text        NOT NULL,
+    message             text        NOT NULL,
+    metadata            jsonb       NOT NULL,
+    override_guardrails boolean     NOT NULL,
+    max_attempts        integer     NOT NULL,
+
+    state               text        NOT NULL,
+    attempts            integer     NOT NULL,
+    error               text        NOT NULL,
+    reference           text        NOT NULL,
+    creation_date       timestamptz NOT NULL,
+    update_date         timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS catalog_merge_queue_branch_state_idx
+    ON catalog_merge_queue (repository_id, destination_branch, state, id);
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+ALTER TABLE catalog_merge_guardrails
+    DROP COLUMN IF EXISTS required_approvals;
+
+DROP TABLE IF EXISTS catalog_pull_request_approvals;
+DROP TABLE IF EXISTS catalog_pull_request_comments;
+DROP TABLE IF EXISTS catalog_pull_requests;
+COMMIT;
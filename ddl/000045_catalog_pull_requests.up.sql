@@ -0,0 +1,46 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS catalog_pull_requests
+(
+    id                 bigserial   PRIMARY KEY,
+    repository_id      text        NOT NULL,
+    source_ref         text        NOT NULL,
+    destination_branch text        NOT NULL,
+    title              text        NOT NULL,
+    description        text        NOT NULL,
+    author             text        NOT NULL,
+    reviewers          text[]      NOT NULL,
+
+    status             text        NOT NULL,
+    merge_reference    text        NOT NULL,
+    creation_date      timestamptz NOT NULL,
+    update_date        timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS catalog_pull_requests_branch_status_idx
+    ON catalog_pull_requests (repository_id, destination_branch, source_ref, status);
+
+CREATE TABLE IF NOT EXISTS catalog_pull_request_comments
+(
+    id              bigserial   PRIMARY KEY,
+    pull_request_id bigint      NOT NULL REFERENCES catalog_pull_requests (id) ON DELETE CASCADE,
+    author          text        NOT NULL,
+    body            text        NOT NULL,
+    creation_date   timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS catalog_pull_request_comments_pull_request_idx
+    ON catalog_pull_request_comments (pull_request_id, id);
+
+CREATE TABLE IF NOT EXISTS catalog_pull_request_approvals
+(
+    pull_request_id bigint      NOT NULL REFERENCES catalog_pull_requests (id) ON DELETE CASCADE,
+    reviewer        text        NOT NULL,
+    commit_id       text        NOT NULL,
+    creation_date   timestamptz NOT NULL,
+
+    PRIMARY KEY (pull_request_id, reviewer)
+);
+
+ALTER TABLE catalog_merge_guardrails
+    ADD COLUMN IF NOT EXISTS required_approvals integer NOT NULL DEFAULT 0;
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE catalog_pull_request_comments
+    DROP COLUMN IF EXISTS path;
+COMMIT;
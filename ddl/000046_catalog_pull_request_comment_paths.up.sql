@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE catalog_pull_request_comments
+    ADD COLUMN IF NOT EXISTS path text NOT NULL DEFAULT '';
+COMMIT;
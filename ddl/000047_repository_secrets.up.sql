@@ -0,0 +1,12 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS repository_secrets
+(
+    repository_id   text        NOT NULL,
+    name            text        NOT NULL,
+    encrypted_value bytea       NOT NULL,
+    creation_date   timestamptz NOT NULL,
+    update_date     timestamptz NOT NULL,
+
+    PRIMARY KEY (repository_id, name)
+);
+COMMIT;
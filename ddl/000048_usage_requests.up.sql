@@ -0,0 +1,15 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS usage_requests
+(
+    repository_id text   NOT NULL,
+    reference     text   NOT NULL,
+    prefix        text   NOT NULL,
+    day           date   NOT NULL,
+
+    requests      bigint NOT NULL,
+    bytes_in      bigint NOT NULL,
+    bytes_out     bigint NOT NULL,
+
+    PRIMARY KEY (repository_id, reference, prefix, day)
+);
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS jobs
+(
+    id               text                     NOT NULL PRIMARY KEY,
+    type             text                     NOT NULL,
+    repository_id    text                     NOT NULL,
+    created_by       text                     NOT NULL,
+    status           text                     NOT NULL,
+    progress         double precision         NOT NULL DEFAULT 0,
+    error            text                     NOT NULL DEFAULT '',
+    result           jsonb,
+    log              text[]                   NOT NULL DEFAULT '{}',
+    cancel_requested boolean                  NOT NULL DEFAULT false,
+    creation_date    timestamp with time zone NOT NULL,
+    update_date      timestamp with time zone NOT NULL
+);
+CREATE INDEX IF NOT EXISTS jobs_repository_id_creation_date_idx ON jobs (repository_id, creation_date);
+COMMIT;
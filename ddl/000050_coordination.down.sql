@@ -0,0 +1,4 @@
+BEGIN;
+DROP TABLE IF EXISTS coordination_members;
+DROP TABLE IF EXISTS coordination_leases;
+COMMIT;
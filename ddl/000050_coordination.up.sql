@@ -0,0 +1,14 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS coordination_leases
+(
+    name       text                     NOT NULL PRIMARY KEY,
+    holder     text                     NOT NULL,
+    expires_at timestamp with time zone NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS coordination_members
+(
+    id           text                     NOT NULL PRIMARY KEY,
+    heartbeat_at timestamp with time zone NOT NULL
+);
+COMMIT;
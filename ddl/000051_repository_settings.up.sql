@@ -0,0 +1,12 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS repository_settings
+(
+    repository_id text        NOT NULL,
+    key           text        NOT NULL,
+
+    value         text        NOT NULL,
+    update_date   timestamptz NOT NULL,
+
+    PRIMARY KEY (repository_id, key)
+);
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+DROP TABLE IF EXISTS instance_settings_audit;
+DROP TABLE IF EXISTS feature_flags;
+DROP TABLE IF EXISTS instance_settings;
+COMMIT;
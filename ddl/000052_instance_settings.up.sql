@@ -0,0 +1,28 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS instance_settings
+(
+    key         text        NOT NULL PRIMARY KEY,
+    value       text        NOT NULL,
+    updated_by  text        NOT NULL,
+    update_date timestamptz NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS feature_flags
+(
+    name         text        NOT NULL PRIMARY KEY,
+    enabled      boolean     NOT NULL,
+    repositories text[]      NOT NULL,
+    percentage   integer     NOT NULL,
+    updated_by   text        NOT NULL,
+    update_date  timestamptz NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS instance_settings_audit
+(
+    id          bigserial   NOT NULL PRIMARY KEY,
+    key         text        NOT NULL,
+    value       text        NOT NULL,
+    updated_by  text        NOT NULL,
+    update_date timestamptz NOT NULL
+);
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS ingest_streams
+(
+    repository_id         text        NOT NULL,
+    name                  text        NOT NULL,
+
+    branch_id             text        NOT NULL,
+    prefix                text        NOT NULL,
+    format                text        NOT NULL,
+    max_file_size_bytes   bigint      NOT NULL,
+    roll_interval_seconds integer     NOT NULL,
+    creation_date         timestamptz NOT NULL,
+    current_path          text        NOT NULL,
+    current_start_date    timestamptz NOT NULL,
+
+    PRIMARY KEY (repository_id, name)
+);
+COMMIT;
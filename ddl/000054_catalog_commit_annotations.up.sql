@@ -0,0 +1,14 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS catalog_commit_annotations
+(
+    repository_id text        NOT NULL,
+    commit_id     text        NOT NULL,
+    key           text        NOT NULL,
+
+    value         text        NOT NULL,
+    annotator     text        NOT NULL,
+    update_date   timestamptz NOT NULL,
+
+    PRIMARY KEY (repository_id, commit_id, key)
+);
+COMMIT;
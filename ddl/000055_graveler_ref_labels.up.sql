@@ -0,0 +1,14 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS graveler_ref_labels
+(
+    repository_id text  NOT NULL,
+    ref_type      text  NOT NULL,
+    ref_id        text  NOT NULL,
+
+    labels        jsonb NOT NULL,
+
+    PRIMARY KEY (repository_id, ref_type, ref_id)
+);
+
+CREATE INDEX IF NOT EXISTS graveler_ref_labels_labels_idx ON graveler_ref_labels USING gin (labels jsonb_path_ops);
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+ALTER TABLE graveler_commits
+    DROP COLUMN IF EXISTS committer_email,
+    DROP COLUMN IF EXISTS author,
+    DROP COLUMN IF EXISTS author_email,
+    DROP COLUMN IF EXISTS author_date;
+COMMIT;
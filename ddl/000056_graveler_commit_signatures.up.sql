@@ -0,0 +1,7 @@
+BEGIN;
+ALTER TABLE graveler_commits
+    ADD COLUMN committer_email text NOT NULL DEFAULT '',
+    ADD COLUMN author text NOT NULL DEFAULT '',
+    ADD COLUMN author_email text NOT NULL DEFAULT '',
+    ADD COLUMN author_date timestamptz;
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE gateway_multiparts
+    DROP COLUMN IF EXISTS metadata;
+COMMIT;
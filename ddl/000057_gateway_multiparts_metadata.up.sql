@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE gateway_multiparts
+    ADD COLUMN metadata jsonb;
+COMMIT;
@@ -0,0 +1,17 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS graveler_branch_log
+(
+    repository_id text        NOT NULL,
+    branch_id     text        NOT NULL,
+    id            bigserial   NOT NULL,
+
+    commit_id     text        NOT NULL,
+    creation_date timestamptz NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (repository_id, branch_id, id)
+);
+
+-- existing branches start their log at their current commit
+INSERT INTO graveler_branch_log (repository_id, branch_id, commit_id)
+SELECT repository_id, id, commit_id FROM graveler_branches;
+COMMIT;
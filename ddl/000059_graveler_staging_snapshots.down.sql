@@ -0,0 +1,5 @@
+BEGIN;
+DELETE FROM graveler_staging_kv WHERE staging_token IN (SELECT staging_token FROM graveler_staging_snapshots);
+DELETE FROM graveler_staging_spills WHERE staging_token IN (SELECT staging_token FROM graveler_staging_snapshots);
+DROP TABLE IF EXISTS graveler_staging_snapshots;
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+-- graveler_staging_snapshots records the copies of staging areas taken for paginated listings.
+-- A copy is held under its own staging token until it expires.
+CREATE TABLE IF NOT EXISTS graveler_staging_snapshots
+(
+    staging_token varchar PRIMARY KEY,
+    creation_date timestamptz NOT NULL DEFAULT NOW()
+);
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+ALTER TABLE catalog_redactions DROP CONSTRAINT IF EXISTS catalog_redactions_pkey;
+ALTER TABLE catalog_redactions ADD PRIMARY KEY (repository_id, physical_address);
+ALTER TABLE catalog_redactions DROP COLUMN IF EXISTS storage_namespace;
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+-- forks share the storage namespace, and so the objects, of their source repository: a redaction
+-- applies to every repository using the storage namespace
+ALTER TABLE catalog_redactions ADD COLUMN IF NOT EXISTS storage_namespace text;
+UPDATE catalog_redactions r SET storage_namespace = g.storage_namespace
+    FROM graveler_repositories g
+    WHERE g.id = r.repository_id;
+-- the storage namespace of deleted repositories is unknown
+DELETE FROM catalog_redactions WHERE storage_namespace IS NULL;
+-- keep the first redaction of objects redacted in both a fork and its source
+DELETE FROM catalog_redactions a
+    USING catalog_redactions b
+    WHERE a.storage_namespace = b.storage_namespace AND a.physical_address = b.physical_address
+      AND (a.creation_date, a.repository_id) > (b.creation_date, b.repository_id);
+ALTER TABLE catalog_redactions ALTER COLUMN storage_namespace SET NOT NULL;
+ALTER TABLE catalog_redactions DROP CONSTRAINT IF EXISTS catalog_redactions_pkey;
+ALTER TABLE catalog_redactions ADD PRIMARY KEY (storage_namespace, physical_address);
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+-- objects garbage collection removes, or is removing, from the storage namespace of a repository.
+-- Entry writes staging them fail, so garbage collection never waits for them.
+CREATE TABLE IF NOT EXISTS catalog_gc_removals (
+    repository_id text NOT NULL,
+    physical_address text NOT NULL,
+    -- false while the object is being removed
+    removed boolean NOT NULL DEFAULT false,
+    creation_date timestamptz NOT NULL,
+    PRIMARY KEY (repository_id, physical_address)
+);
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+-- name of the external auth provider mirroring the user, empty for users managed by lakeFS
+ALTER TABLE auth_users ADD COLUMN IF NOT EXISTS source text NOT NULL DEFAULT '';
+COMMIT;
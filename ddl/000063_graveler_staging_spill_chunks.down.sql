@@ -0,0 +1,5 @@
+BEGIN;
+DROP TABLE IF EXISTS graveler_staging_spill_drops;
+ALTER TABLE graveler_staging_spills DROP COLUMN IF EXISTS storage_namespace;
+DROP TABLE IF EXISTS graveler_staging_spill_chunks;
+COMMIT;
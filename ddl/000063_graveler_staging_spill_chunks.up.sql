@@ -0,0 +1,32 @@
+BEGIN;
+-- graveler_staging_spill_chunks holds every chunk spilled to object storage.  Staging areas
+-- and their snapshots share chunks, a chunk is removed once no row of graveler_staging_spills
+-- references it.
+CREATE TABLE IF NOT EXISTS graveler_staging_spill_chunks
+(
+    storage_namespace varchar NOT NULL,
+    range_id          varchar NOT NULL,
+
+    PRIMARY KEY (storage_namespace, range_id)
+);
+-- chunks are written to the storage namespace of the repository of the staging area
+ALTER TABLE graveler_staging_spills ADD COLUMN IF NOT EXISTS storage_namespace varchar NOT NULL DEFAULT '';
+ALTER TABLE graveler_staging_spills ALTER COLUMN storage_namespace DROP DEFAULT;
+INSERT INTO graveler_staging_spill_chunks (storage_namespace, range_id)
+SELECT DISTINCT storage_namespace, range_id FROM graveler_staging_spills;
+ALTER TABLE graveler_staging_spills ADD FOREIGN KEY (storage_namespace, range_id)
+    REFERENCES graveler_staging_spill_chunks (storage_namespace, range_id);
+
+-- graveler_staging_spill_drops holds the key ranges [from_key, to_key) dropped from a staging
+-- area.  A drop hides the entries of chunks spilled before it (lower seq), chunks are never
+-- rewritten.  A NULL to_key leaves the range unbounded.
+CREATE TABLE IF NOT EXISTS graveler_staging_spill_drops
+(
+    staging_token varchar NOT NULL,
+    seq           bigint  NOT NULL DEFAULT nextval(pg_get_serial_sequence('graveler_staging_spills', 'seq')),
+    from_key      bytea   NOT NULL,
+    to_key        bytea,
+
+    PRIMARY KEY (staging_token, seq)
+);
+COMMIT;
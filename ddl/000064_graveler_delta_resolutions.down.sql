@@ -0,0 +1,3 @@
+BEGIN;
+DROP TABLE IF EXISTS graveler_delta_resolutions;
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+-- graveler_delta_resolutions holds the full MetaRange materialized for each delta MetaRange,
+-- so a delta is materialized once rather than once per lakeFS process.
+CREATE TABLE IF NOT EXISTS graveler_delta_resolutions
+(
+    storage_namespace varchar NOT NULL,
+    delta_id          varchar NOT NULL,
+    meta_range_id     varchar NOT NULL,
+
+    PRIMARY KEY (storage_namespace, delta_id)
+);
+COMMIT;
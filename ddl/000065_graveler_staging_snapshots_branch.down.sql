@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE graveler_staging_snapshots DROP COLUMN IF EXISTS branch_id;
+ALTER TABLE graveler_staging_snapshots DROP COLUMN IF EXISTS repository_id;
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+-- a snapshot of a staging area is only listed for the branch it was copied from, listing
+-- continuation tokens are not trusted to name it
+ALTER TABLE graveler_staging_snapshots ADD COLUMN IF NOT EXISTS repository_id varchar NOT NULL DEFAULT '';
+ALTER TABLE graveler_staging_snapshots ADD COLUMN IF NOT EXISTS branch_id varchar NOT NULL DEFAULT '';
+ALTER TABLE graveler_staging_snapshots ALTER COLUMN repository_id DROP DEFAULT;
+ALTER TABLE graveler_staging_snapshots ALTER COLUMN branch_id DROP DEFAULT;
+COMMIT;
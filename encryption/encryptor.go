@@ -0,0 +1,190 @@
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// Entry metadata keys holding the envelope of an encrypted object
+const (
+	MetadataKeyID      = "lakefs-encryption-key-id"
+	MetadataWrappedKey = "lakefs-encryption-wrapped-key"
+	MetadataIV         = "lakefs-encryption-iv"
+)
+
+var (
+	ErrNotConfigured   = errors.New("encryption not configured")
+	ErrUnknownKey      = errors.New("unknown master key")
+	ErrInvalidKeySize  = errors.New("invalid key size")
+	ErrInvalidEnvelope = errors.New("invalid encryption envelope")
+)
+
+// Rule encrypts the objects of Repository, or of all repositories when empty, whose path starts
+// with Prefix using data keys wrapped by master key KeyID
+type Rule struct {
+	Repository string
+	Prefix     string
+	KeyID      string
+}
+
+func (r Rule) match(repository, path string) bool {
+	return (r.Repository == "" || r.Repository == repository) && strings.HasPrefix(path, r.Prefix)
+}
+
+// Encryptor encrypts objects written under the prefixes of its rules with a new data key per
+// object.  Objects are encrypted with AES-256-CTR, so encrypted objects keep their size and
+// ranges can be decrypted without reading the object from its start.  A nil *Encryptor encrypts
+// nothing.
+type Encryptor struct {
+	keys  KeyManager
+	rules []Rule
+}
+
+// NewEncryptor returns an Encryptor applying rules.  When more than one rule matches a path,
+// the rule with the longest prefix is used.
+func NewEncryptor(keys KeyManager, rules []Rule) *Encryptor {
+	sorted := make([]Rule, len(rules))
+	copy(sorted, rules)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if len(sorted[i].Prefix) != len(sorted[j].Prefix) {
+			return len(sorted[i].Prefix) > len(sorted[j].Prefix)
+		}
+		// a rule of a specific repository wins over a rule of all repositories
+		return sorted[i].Repository != "" && sorted[j].Repository == ""
+	})
+	return &Encryptor{keys: keys, rules: sorted}
+}
+
+// KeyID returns the master key of objects written to path in repository, if they are encrypted
+func (e *Encryptor) KeyID(repository, path string) (string, bool) {
+	if e == nil {
+		return "", false
+	}
+	for _, r := range e.rules {
+		if r.match(repository, path) {
+			return r.KeyID, true
+		}
+	}
+	return "", false
+}
+
+// Encrypt returns a reader of r encrypted, and the entry metadata required to decrypt it, if
+// objects written to path in repository are encrypted.  Otherwise returns r and nil metadata.
+func (e *Encryptor) Encrypt(ctx context.Context, repository, path string, r io.Reader) (io.Reader, map[string]string, error) {
+	keyID, ok := e.KeyID(repository, path)
+	if !ok {
+		return r, nil, nil
+	}
+	dataKey, wrapped, err := e.keys.GenerateDataKey(ctx, keyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	iv := make([]byte, aes.BlockSize)
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, nil, err
+	}
+	stream, err := newStream(dataKey, iv, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	metadata := map[string]string{
+		MetadataKeyID:      keyID,
+		MetadataWrappedKey: base64.StdEncoding.EncodeToString(wrapped),
+		MetadataIV:         base64.StdEncoding.EncodeToString(iv),
+	}
+	return &cipher.StreamReader{S: stream, R: r}, metadata, nil
+}
+
+// IsEncrypted returns true if metadata holds the envelope of an encrypted object
+func IsEncrypted(metadata map[string]string) bool {
+	_, ok := metadata[MetadataKeyID]
+	return ok
+}
+
+// Decrypt returns a reader decrypting r, the data of an encrypted object starting at offset.
+// metadata is the entry metadata returned by Encrypt when the object was written.
+func (e *Encryptor) Decrypt(ctx context.Context, metadata map[string]string, r io.Reader, offset int64) (io.Reader, error) {
+	if e == nil {
+		return nil, ErrNotConfigured
+	}
+	keyID := metadata[MetadataKeyID]
+	wrapped, err := base64.StdEncoding.DecodeString(metadata[MetadataWrappedKey])
+	if err != nil {
+		return nil, fmt.Errorf("%w: wrapped key: %s", ErrInvalidEnvelope, err)
+	}
+	iv, err := base64.StdEncoding.DecodeString(metadata[MetadataIV])
+	if err != nil {
+		return nil, fmt.Errorf("%w: iv: %s", ErrInvalidEnvelope, err)
+	}
+	if len(iv) != aes.BlockSize {
+		return nil, fmt.Errorf("%w: iv size %d", ErrInvalidEnvelope, len(iv))
+	}
+	dataKey, err := e.keys.DecryptDataKey(ctx, keyID, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := newStream(dataKey, iv, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &cipher.StreamReader{S: stream, R: r}, nil
+}
+
+// DecryptReadCloser is Decrypt of an io.ReadCloser, closing the returned reader closes r
+func (e *Encryptor) DecryptReadCloser(ctx context.Context, metadata map[string]string, r io.ReadCloser, offset int64) (io.ReadCloser, error) {
+	decrypted, err := e.Decrypt(ctx, metadata, r, offset)
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{Reader: decrypted, Closer: r}, nil
+}
+
+// newStream returns the AES-CTR key stream of key and iv positioned at offset
+func newStream(key, iv []byte, offset int64) (cipher.Stream, error) {
+	if len(key) != DataKeySize {
+		return nil, ErrInvalidKeySize
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	// the counter block is the IV incremented once for every block before offset
+	counter := new(big.Int).SetBytes(iv)
+	counter.Add(counter, big.NewInt(offset/aes.BlockSize))
+	counterBytes := counter.Bytes()
+	if len(counterBytes) > aes.BlockSize {
+		counterBytes = counterBytes[len(counterBytes)-aes.BlockSize:]
+	}
+	ctr := make([]byte, aes.BlockSize)
+	copy(ctr[aes.BlockSize-len(counterBytes):], counterBytes)
+	stream := cipher.NewCTR(block, ctr)
+	if skip := offset % aes.BlockSize; skip > 0 {
+		_, err = io.CopyN(ioutil.Discard, &cipher.StreamReader{S: stream, R: zeroReader{}}, skip)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return stream, nil
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
@@ -0,0 +1,126 @@
+package encryption_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/treeverse/lakefs/encryption"
+)
+
+func newTestEncryptor(t *testing.T, rules []encryption.Rule) *encryption.Encryptor {
+	t.Helper()
+	keys, err := encryption.NewLocalKeyManager(map[string][]byte{
+		"key1": bytes.Repeat([]byte{1}, encryption.DataKeySize),
+		"key2": bytes.Repeat([]byte{2}, encryption.DataKeySize),
+	})
+	if err != nil {
+		t.Fatalf("NewLocalKeyManager: %s", err)
+	}
+	return encryption.NewEncryptor(keys, rules)
+}
+
+func TestEncryptor_KeyID(t *testing.T) {
+	e := newTestEncryptor(t, []encryption.Rule{
+		{Prefix: "secret/", KeyID: "key1"},
+		{Repository: "repo1", Prefix: "secret/", KeyID: "key2"},
+		{Repository: "repo2", Prefix: "secret/pii/", KeyID: "key2"},
+	})
+	cases := []struct {
+		repository string
+		path       string
+		keyID      string
+		encrypted  bool
+	}{
+		{repository: "repo1", path: "public/file", encrypted: false},
+		{repository: "repo1", path: "secret/file", keyID: "key2", encrypted: true},
+		{repository: "repo2", path: "secret/file", keyID: "key1", encrypted: true},
+		{repository: "repo2", path: "secret/pii/file", keyID: "key2", encrypted: true},
+		{repository: "repo3", path: "secret/pii/file", keyID: "key1", encrypted: true},
+	}
+	for _, tt := range cases {
+		keyID, encrypted := e.KeyID(tt.repository, tt.path)
+		if keyID != tt.keyID || encrypted != tt.encrypted {
+			t.Errorf("KeyID(%s, %s) = (%s, %t), expected (%s, %t)", tt.repository, tt.path, keyID, encrypted, tt.keyID, tt.encrypted)
+		}
+	}
+
+	var nilEncryptor *encryption.Encryptor
+	if _, encrypted := nilEncryptor.KeyID("repo1", "secret/file"); encrypted {
+		t.Error("nil encryptor encrypts objects")
+	}
+}
+
+func TestEncryptor_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	e := newTestEncryptor(t, []encryption.Rule{{Prefix: "secret/", KeyID: "key1"}})
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	r, metadata, err := e.Encrypt(ctx, "repo1", "secret/file", bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Encrypt: %s", err)
+	}
+	if !encryption.IsEncrypted(metadata) {
+		t.Fatalf("metadata %v does not hold an envelope", metadata)
+	}
+	ciphertext, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read encrypted: %s", err)
+	}
+	if len(ciphertext) != len(data) {
+		t.Fatalf("encrypted size %d, expected %d", len(ciphertext), len(data))
+	}
+	if bytes.Equal(ciphertext, data) {
+		t.Fatal("encrypted data equals plaintext")
+	}
+
+	for _, offset := range []int64{0, 1, 15, 16, 17, 500, 999} {
+		r, err := e.Decrypt(ctx, metadata, bytes.NewReader(ciphertext[offset:]), offset)
+		if err != nil {
+			t.Fatalf("Decrypt at %d: %s", offset, err)
+		}
+		plaintext, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatalf("read decrypted at %d: %s", offset, err)
+		}
+		if !bytes.Equal(plaintext, data[offset:]) {
+			t.Errorf("decrypted data at offset %d differs from plaintext", offset)
+		}
+	}
+}
+
+func TestEncryptor_NotEncrypted(t *testing.T) {
+	e := newTestEncryptor(t, []encryption.Rule{{Prefix: "secret/", KeyID: "key1"}})
+	body := bytes.NewReader([]byte("data"))
+	r, metadata, err := e.Encrypt(context.Background(), "repo1", "public/file", body)
+	if err != nil {
+		t.Fatalf("Encrypt: %s", err)
+	}
+	if r != body || metadata != nil {
+		t.Errorf("Encrypt of unencrypted path returned (%v, %v), expected original reader and no metadata", r, metadata)
+	}
+}
+
+func TestEncryptor_DecryptWrongKey(t *testing.T) {
+	ctx := context.Background()
+	e := newTestEncryptor(t, []encryption.Rule{{Prefix: "", KeyID: "key1"}})
+	_, metadata, err := e.Encrypt(ctx, "repo1", "file", bytes.NewReader([]byte("data")))
+	if err != nil {
+		t.Fatalf("Encrypt: %s", err)
+	}
+	metadata[encryption.MetadataKeyID] = "key2"
+	_, err = e.Decrypt(ctx, metadata, bytes.NewReader(nil), 0)
+	if !errors.Is(err, encryption.ErrInvalidEnvelope) {
+		t.Errorf("Decrypt with wrong key: %v, expected %s", err, encryption.ErrInvalidEnvelope)
+	}
+	metadata[encryption.MetadataKeyID] = "missing"
+	_, err = e.Decrypt(ctx, metadata, bytes.NewReader(nil), 0)
+	if !errors.Is(err, encryption.ErrUnknownKey) {
+		t.Errorf("Decrypt with unknown key: %v, expected %s", err, encryption.ErrUnknownKey)
+	}
+}
@@ -0,0 +1,124 @@
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+)
+
+// DataKeySize is the size in bytes of the data keys encrypting objects (AES-256)
+const DataKeySize = 32
+
+// KeyManager generates data keys and unwraps them using the master keys it holds.  Master keys
+// never leave the KeyManager, only data keys wrapped by them are stored.
+type KeyManager interface {
+	// GenerateDataKey returns a new data key and the same key wrapped by master key keyID
+	GenerateDataKey(ctx context.Context, keyID string) (plaintext, wrapped []byte, err error)
+	// DecryptDataKey unwraps a data key wrapped by master key keyID
+	DecryptDataKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// LocalKeyManager wraps data keys with AES-256-GCM using master keys from the configuration
+type LocalKeyManager struct {
+	masterKeys map[string]cipher.AEAD
+}
+
+// NewLocalKeyManager returns a KeyManager using masterKeys, mapping key IDs to 32 byte keys
+func NewLocalKeyManager(masterKeys map[string][]byte) (*LocalKeyManager, error) {
+	m := &LocalKeyManager{masterKeys: make(map[string]cipher.AEAD, len(masterKeys))}
+	for keyID, key := range masterKeys {
+		if len(key) != DataKeySize {
+			return nil, fmt.Errorf("master key %s: %w", keyID, ErrInvalidKeySize)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("master key %s: %w", keyID, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("master key %s: %w", keyID, err)
+		}
+		m.masterKeys[keyID] = aead
+	}
+	return m, nil
+}
+
+func (m *LocalKeyManager) masterKey(keyID string) (cipher.AEAD, error) {
+	aead, ok := m.masterKeys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("master key %s: %w", keyID, ErrUnknownKey)
+	}
+	return aead, nil
+}
+
+func (m *LocalKeyManager) GenerateDataKey(_ context.Context, keyID string) ([]byte, []byte, error) {
+	aead, err := m.masterKey(keyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	plaintext := make([]byte, DataKeySize)
+	if _, err := io.ReadFull(rand.Reader, plaintext); err != nil {
+		return nil, nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, nil, err
+	}
+	// the key ID is authenticated so a wrapped key cannot be presented as wrapped by another key
+	wrapped := aead.Seal(nonce, nonce, plaintext, []byte(keyID))
+	return plaintext, wrapped, nil
+}
+
+func (m *LocalKeyManager) DecryptDataKey(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	aead, err := m.masterKey(keyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, ErrInvalidEnvelope
+	}
+	nonce, sealed := wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, []byte(keyID))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidEnvelope, err)
+	}
+	return plaintext, nil
+}
+
+// KMSKeyManager generates and unwraps data keys using AWS KMS, key IDs are KMS key IDs or ARNs
+type KMSKeyManager struct {
+	client kmsiface.KMSAPI
+}
+
+func NewKMSKeyManager(client kmsiface.KMSAPI) *KMSKeyManager {
+	return &KMSKeyManager{client: client}
+}
+
+func (m *KMSKeyManager) GenerateDataKey(ctx context.Context, keyID string) ([]byte, []byte, error) {
+	out, err := m.client.GenerateDataKeyWithContext(ctx, &kms.GenerateDataKeyInput{
+		KeyId:   aws.String(keyID),
+		KeySpec: aws.String(kms.DataKeySpecAes256),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("generate data key with %s: %w", keyID, err)
+	}
+	return out.Plaintext, out.CiphertextBlob, nil
+}
+
+func (m *KMSKeyManager) DecryptDataKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	out, err := m.client.DecryptWithContext(ctx, &kms.DecryptInput{
+		KeyId:          aws.String(keyID),
+		CiphertextBlob: wrapped,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("decrypt data key with %s: %w", keyID, err)
+	}
+	return out.Plaintext, nil
+}
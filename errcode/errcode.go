@@ -0,0 +1,161 @@
+// Package errcode maps errors returned by the catalog, graveler and auth services to stable,
+// machine-readable codes.  The codes are returned in REST API error bodies and select the S3
+// error returned by the gateway, so clients can handle errors without parsing messages.
+package errcode
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/treeverse/lakefs/auth"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/ingest"
+	"github.com/treeverse/lakefs/upload"
+)
+
+// Code is a stable identifier of an error condition.  Codes are part of the API: existing
+// codes must never be renamed or given a different meaning.
+type Code string
+
+const (
+	BadRequest         Code = "bad_request"
+	Unauthorized       Code = "unauthorized"
+	Forbidden          Code = "forbidden"
+	NotFound           Code = "not_found"
+	RepositoryNotFound Code = "repository_not_found"
+	BranchNotFound     Code = "branch_not_found"
+	CommitNotFound     Code = "commit_not_found"
+	TagNotFound        Code = "tag_not_found"
+	AmbiguousReference Code = "ambiguous_reference"
+	AlreadyExists      Code = "already_exists"
+	BranchExists       Code = "branch_exists"
+	TagExists          Code = "tag_exists"
+	InvalidValue       Code = "invalid_value"
+	NoChanges          Code = "no_changes"
+	NoMergeBase        Code = "no_merge_base"
+	Conflict           Code = "conflict"
+	MergeConflict      Code = "merge_conflict"
+	DirtyBranch        Code = "dirty_branch"
+	Locked             Code = "locked"
+	HistoryChanged     Code = "history_changed"
+	PreconditionFailed Code = "precondition_failed"
+	AbortedByHook      Code = "aborted_by_hook"
+	GuardrailsExceeded Code = "guardrails_exceeded"
+	PathLeased         Code = "path_leased"
+	DigestMismatch     Code = "digest_mismatch"
+	ListingExpired     Code = "listing_expired"
+	ObjectExpired      Code = "object_expired"
+	EntityTooLarge     Code = "entity_too_large"
+	QuotaExceeded      Code = "quota_exceeded"
+	TooManyRequests    Code = "too_many_requests"
+	NotSupported       Code = "not_supported"
+	InternalError      Code = "internal_error"
+	ServiceUnavailable Code = "service_unavailable"
+	CredentialsExpired Code = "credentials_expired"
+)
+
+type mapping struct {
+	err    error
+	code   Code
+	status int
+}
+
+// mappings is matched in order using errors.Is, so errors that wrap more generic errors
+// (e.g. graveler.ErrBranchNotFound wrapping db.ErrNotFound) must come first.
+var mappings = []mapping{
+	{err: graveler.ErrRepositoryNotFound, code: RepositoryNotFound, status: http.StatusNotFound},
+	{err: catalog.ErrRepositoryNotFound, code: RepositoryNotFound, status: http.StatusNotFound},
+	{err: graveler.ErrBranchNotFound, code: BranchNotFound, status: http.StatusNotFound},
+	{err: catalog.ErrBranchNotFound, code: BranchNotFound, status: http.StatusNotFound},
+	{err: graveler.ErrCommitNotFound, code: CommitNotFound, status: http.StatusNotFound},
+	{err: graveler.ErrTagNotFound, code: TagNotFound, status: http.StatusNotFound},
+	{err: graveler.ErrRefAmbiguous, code: AmbiguousReference, status: http.StatusBadRequest},
+	{err: db.ErrNotFound, code: NotFound, status: http.StatusNotFound},
+	{err: graveler.ErrBranchExists, code: BranchExists, status: http.StatusConflict},
+	{err: graveler.ErrTagAlreadyExists, code: TagExists, status: http.StatusConflict},
+	{err: graveler.ErrNotUnique, code: AlreadyExists, status: http.StatusConflict},
+	{err: graveler.ErrRepositorySnapshotExists, code: AlreadyExists, status: http.StatusConflict},
+	{err: db.ErrAlreadyExists, code: AlreadyExists, status: http.StatusConflict},
+	{err: graveler.ErrInvalidValue, code: InvalidValue, status: http.StatusBadRequest},
+	{err: catalog.ErrInvalidValue, code: InvalidValue, status: http.StatusBadRequest},
+	{err: catalog.ErrInvalidSchema, code: InvalidValue, status: http.StatusBadRequest},
+	{err: catalog.ErrInvalidOwners, code: InvalidValue, status: http.StatusBadRequest},
+	{err: catalog.ErrInvalidLinkTarget, code: InvalidValue, status: http.StatusBadRequest},
+	{err: catalog.ErrCrossRepositoryLink, code: InvalidValue, status: http.StatusBadRequest},
+	{err: catalog.ErrLinkLoop, code: InvalidValue, status: http.StatusBadRequest},
+	{err: ingest.ErrInvalidStream, code: InvalidValue, status: http.StatusBadRequest},
+	{err: ingest.ErrInvalidBatch, code: InvalidValue, status: http.StatusBadRequest},
+	{err: graveler.ErrNoChanges, code: NoChanges, status: http.StatusBadRequest},
+	{err: catalog.ErrNoDifferenceWasFound, code: NoChanges, status: http.StatusBadRequest},
+	{err: graveler.ErrNoMergeBase, code: NoMergeBase, status: http.StatusBadRequest},
+	{err: catalog.ErrUnsupportedRelation, code: NoMergeBase, status: http.StatusBadRequest},
+	{err: graveler.ErrConflictFound, code: MergeConflict, status: http.StatusConflict},
+	{err: catalog.ErrConflictFound, code: MergeConflict, status: http.StatusConflict},
+	{err: graveler.ErrDirtyBranch, code: DirtyBranch, status: http.StatusConflict},
+	{err: graveler.ErrLockNotAcquired, code: Locked, status: http.StatusConflict},
+	{err: graveler.ErrHistoryChanged, code: HistoryChanged, status: http.StatusConflict},
+	{err: db.ErrSerialization, code: Conflict, status: http.StatusConflict},
+	{err: graveler.ErrCommitNotHeadBranch, code: Conflict, status: http.StatusConflict},
+	{err: graveler.ErrPreconditionFailed, code: PreconditionFailed, status: http.StatusPreconditionFailed},
+	{err: graveler.ErrAbortedByHook, code: AbortedByHook, status: http.StatusPreconditionFailed},
+	{err: catalog.ErrMergeGuardrailsExceeded, code: GuardrailsExceeded, status: http.StatusPreconditionFailed},
+	{err: catalog.ErrPullRequestNotOpen, code: PreconditionFailed, status: http.StatusPreconditionFailed},
+	{err: catalog.ErrMergeRequestNotQueued, code: PreconditionFailed, status: http.StatusPreconditionFailed},
+	{err: catalog.ErrPathLeased, code: PathLeased, status: http.StatusConflict},
+	{err: catalog.ErrDigestMismatch, code: DigestMismatch, status: http.StatusBadRequest},
+	{err: graveler.ErrSnapshotExpired, code: ListingExpired, status: http.StatusGone},
+	{err: catalog.ErrExpired, code: ObjectExpired, status: http.StatusGone},
+	{err: upload.ErrAppendTooLarge, code: EntityTooLarge, status: http.StatusRequestEntityTooLarge},
+	{err: catalog.ErrFeatureNotSupported, code: NotSupported, status: http.StatusNotImplemented},
+	{err: auth.ErrInsufficientPermissions, code: Forbidden, status: http.StatusForbidden},
+	{err: auth.ErrAddressNotPermitted, code: Forbidden, status: http.StatusForbidden},
+	{err: auth.ErrNotInTenant, code: Forbidden, status: http.StatusForbidden},
+	{err: auth.ErrInvalidCredentials, code: Unauthorized, status: http.StatusUnauthorized},
+	{err: auth.ErrCredentialsExpired, code: CredentialsExpired, status: http.StatusUnauthorized},
+	{err: auth.ErrTenantQuotaExceeded, code: QuotaExceeded, status: http.StatusForbidden},
+	{err: auth.ErrTenantNamesInUse, code: AlreadyExists, status: http.StatusConflict},
+}
+
+// Of returns the code of err and the HTTP status it should be reported with.  Errors not
+// known to the mapping are internal errors.
+func Of(err error) (Code, int) {
+	for _, m := range mappings {
+		if errors.Is(err, m.err) {
+			return m.code, m.status
+		}
+	}
+	return InternalError, http.StatusInternalServerError
+}
+
+// FromStatus returns a generic code for an HTTP status, used for errors that were reported
+// without one.
+func FromStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return BadRequest
+	case http.StatusUnauthorized:
+		return Unauthorized
+	case http.StatusForbidden:
+		return Forbidden
+	case http.StatusNotFound:
+		return NotFound
+	case http.StatusConflict:
+		return Conflict
+	case http.StatusPreconditionFailed:
+		return PreconditionFailed
+	case http.StatusRequestEntityTooLarge:
+		return EntityTooLarge
+	case http.StatusTooManyRequests:
+		return TooManyRequests
+	case http.StatusNotImplemented:
+		return NotSupported
+	case http.StatusServiceUnavailable:
+		return ServiceUnavailable
+	}
+	if status >= http.StatusBadRequest && status < http.StatusInternalServerError {
+		return BadRequest
+	}
+	return InternalError
+}
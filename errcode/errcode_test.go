@@ -0,0 +1,56 @@
+package errcode_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/errcode"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+func TestOf(t *testing.T) {
+	cases := []struct {
+		name       string
+		err        error
+		wantCode   errcode.Code
+		wantStatus int
+	}{
+		{name: "branch not found", err: graveler.ErrBranchNotFound, wantCode: errcode.BranchNotFound, wantStatus: http.StatusNotFound},
+		{name: "wrapped repository not found", err: fmt.Errorf("get repo: %w", catalog.ErrRepositoryNotFound), wantCode: errcode.RepositoryNotFound, wantStatus: http.StatusNotFound},
+		{name: "generic not found", err: catalog.ErrSchemaNotFound, wantCode: errcode.NotFound, wantStatus: http.StatusNotFound},
+		{name: "invalid branch id", err: graveler.ErrInvalidBranchID, wantCode: errcode.InvalidValue, wantStatus: http.StatusBadRequest},
+		{name: "conflict", err: graveler.ErrConflictFound, wantCode: errcode.MergeConflict, wantStatus: http.StatusConflict},
+		{name: "schema hook", err: catalog.ErrSchemaIncompatible, wantCode: errcode.AbortedByHook, wantStatus: http.StatusPreconditionFailed},
+		{name: "already locked", err: graveler.ErrAlreadyLocked, wantCode: errcode.Locked, wantStatus: http.StatusConflict},
+		{name: "already exists", err: catalog.ErrDatasetAlreadyExists, wantCode: errcode.AlreadyExists, wantStatus: http.StatusConflict},
+		{name: "serialization", err: db.ErrSerialization, wantCode: errcode.Conflict, wantStatus: http.StatusConflict},
+		{name: "unknown", err: errors.New("disk on fire"), wantCode: errcode.InternalError, wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			code, status := errcode.Of(tt.err)
+			if code != tt.wantCode || status != tt.wantStatus {
+				t.Errorf("Of(%v) = %s, %d, want %s, %d", tt.err, code, status, tt.wantCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestFromStatus(t *testing.T) {
+	cases := map[int]errcode.Code{
+		http.StatusNotFound:            errcode.NotFound,
+		http.StatusUnauthorized:        errcode.Unauthorized,
+		http.StatusTeapot:              errcode.BadRequest,
+		http.StatusInternalServerError: errcode.InternalError,
+		http.StatusBadGateway:          errcode.InternalError,
+	}
+	for status, want := range cases {
+		if got := errcode.FromStatus(status); got != want {
+			t.Errorf("FromStatus(%d) = %s, want %s", status, got, want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/treeverse/lakefs/logging"
+)
+
+// DefaultSubscriberBufferSize is the number of events buffered for each subscriber before new
+// events are dropped
+const DefaultSubscriberBufferSize = 1024
+
+// Event is a structured notification published on a topic
+type Event struct {
+	Topic   string      `json:"topic"`
+	Time    time.Time   `json:"time"`
+	Payload interface{} `json:"payload"`
+}
+
+// HandlerFunc receives the events of a subscription, in publish order
+type HandlerFunc func(Event)
+
+type subscriber struct {
+	ch chan Event
+}
+
+// Bus delivers published events to the subscribers of their topic.  Each subscriber is called
+// from its own goroutine, a slow subscriber drops events rather than slowing down publishers.
+// A nil *Bus discards all events.
+type Bus struct {
+	bufferSize  int
+	mu          sync.RWMutex
+	subscribers map[string]map[*subscriber]struct{}
+	log         logging.Logger
+}
+
+func NewBus() *Bus {
+	return NewBusWithBufferSize(DefaultSubscriberBufferSize)
+}
+
+func NewBusWithBufferSize(bufferSize int) *Bus {
+	return &Bus{
+		bufferSize:  bufferSize,
+		subscribers: make(map[string]map[*subscriber]struct{}),
+		log:         logging.Default().WithField("service_name", "events_bus"),
+	}
+}
+
+// Subscribe calls fn for each event published on topic.  Call the returned function to cancel
+// the subscription, events already buffered are still delivered.
+func (b *Bus) Subscribe(topic string, fn HandlerFunc) func() {
+	sub := &subscriber{ch: make(chan Event, b.bufferSize)}
+	b.mu.Lock()
+	if b.subscribers[topic] == nil {
+		b.subscribers[topic] = make(map[*subscriber]struct{})
+	}
+	b.subscribers[topic][sub] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		for event := range sub.ch {
+			fn(event)
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers[topic], sub)
+			b.mu.Unlock()
+			close(sub.ch)
+		})
+	}
+}
+
+// Subscribed returns true if topic has subscribers, publishers may skip preparing events no
+// one receives
+func (b *Bus) Subscribed(topic string) bool {
+	if b == nil {
+		return false
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subscribers[topic]) > 0
+}
+
+// Publish sends payload to the subscribers of topic without blocking
+func (b *Bus) Publish(topic string, payload interface{}) {
+	if b == nil {
+		return
+	}
+	event := Event{
+		Topic:   topic,
+		Time:    time.Now(),
+		Payload: payload,
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subscribers[topic] {
+		select {
+		case sub.ch <- event:
+		default:
+			b.log.WithField("topic", topic).Warn("Subscriber buffer full, event dropped")
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package events_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/treeverse/lakefs/events"
+)
+
+func TestBus_PublishSubscribe(t *testing.T) {
+	bus := events.NewBus()
+	received := make(chan events.Event, 10)
+	cancel := bus.Subscribe("topic1", func(e events.Event) {
+		received <- e
+	})
+
+	bus.Publish("topic2", "other")
+	bus.Publish("topic1", "payload1")
+	select {
+	case e := <-received:
+		if e.Topic != "topic1" || e.Payload != "payload1" {
+			t.Fatalf("received event %+v, expected payload1 on topic1", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for event")
+	}
+
+	cancel()
+	bus.Publish("topic1", "payload2")
+	select {
+	case e := <-received:
+		t.Fatalf("received event %+v after cancel", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestBus_SlowSubscriberDropsEvents(t *testing.T) {
+	const bufferSize = 2
+	bus := events.NewBusWithBufferSize(bufferSize)
+	block := make(chan struct{})
+	received := make(chan events.Event, 10)
+	cancel := bus.Subscribe("topic1", func(e events.Event) {
+		<-block
+		received <- e
+	})
+	defer cancel()
+
+	// one event is taken by the blocked handler, the buffer holds two more
+	const published = 10
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < published; i++ {
+			bus.Publish("topic1", i)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publish blocked on slow subscriber")
+	}
+	close(block)
+	time.Sleep(50 * time.Millisecond)
+	if n := len(received); n == 0 || n > bufferSize+1 {
+		t.Fatalf("received %d events, expected between 1 and %d", n, bufferSize+1)
+	}
+}
+
+func TestBus_NilPublish(t *testing.T) {
+	var bus *events.Bus
+	bus.Publish("topic1", "payload")
+}
+
+func TestBus_Subscribed(t *testing.T) {
+	var nilBus *events.Bus
+	if nilBus.Subscribed("topic1") {
+		t.Error("nil bus subscribed to topic1")
+	}
+	bus := events.NewBus()
+	cancel := bus.Subscribe("topic1", func(events.Event) {})
+	if !bus.Subscribed("topic1") || bus.Subscribed("topic2") {
+		t.Errorf("subscribed topic1 = %t topic2 = %t, expected only topic1", bus.Subscribed("topic1"), bus.Subscribed("topic2"))
+	}
+	cancel()
+	if bus.Subscribed("topic1") {
+		t.Error("subscribed to topic1 after cancel")
+	}
+}
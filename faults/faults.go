@@ -0,0 +1,125 @@
+// Package faults injects errors, latency and partial writes into the operations of lakeFS
+// dependencies, to validate retries and rollbacks in staging deployments and integration tests.
+package faults
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ErrInjected is the error of every injected fault
+var ErrInjected = errors.New("injected fault")
+
+// Params configures the faults injected into the operations of a dependency
+type Params struct {
+	// ErrorRate is the probability of an operation to fail
+	ErrorRate float64
+	// Latency is the maximal latency added to an operation, each operation is delayed by a
+	// random duration up to it
+	Latency time.Duration
+	// PartialWriteRate is the probability of a write to fail after writing part of its data
+	PartialWriteRate float64
+	// Operations limits faults to these operations, all operations are faulted when empty
+	Operations []string
+}
+
+// Enabled returns true if p injects any fault
+func (p Params) Enabled() bool {
+	return p.ErrorRate > 0 || p.Latency > 0 || p.PartialWriteRate > 0
+}
+
+// Injector decides which operations to fault.  It is safe for concurrent use.
+type Injector struct {
+	params     Params
+	operations map[string]struct{}
+	mu         sync.Mutex
+	rand       *rand.Rand
+}
+
+// NewInjector returns an Injector of the faults configured by params
+func NewInjector(params Params) *Injector {
+	return NewInjectorWithSource(params, rand.NewSource(time.Now().UnixNano()))
+}
+
+// NewInjectorWithSource returns an Injector that draws faults from source, for repeatable runs
+func NewInjectorWithSource(params Params, source rand.Source) *Injector {
+	var operations map[string]struct{}
+	if len(params.Operations) > 0 {
+		operations = make(map[string]struct{}, len(params.Operations))
+		for _, op := range params.Operations {
+			operations[op] = struct{}{}
+		}
+	}
+	return &Injector{
+		params:     params,
+		operations: operations,
+		rand:       rand.New(source), //nolint:gosec
+	}
+}
+
+func (i *Injector) faulted(op string) bool {
+	if i.operations == nil {
+		return true
+	}
+	_, ok := i.operations[op]
+	return ok
+}
+
+func (i *Injector) float64() float64 {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.rand.Float64()
+}
+
+// Fault delays operation op by the configured latency, and returns ErrInjected if it should fail
+func (i *Injector) Fault(op string) error {
+	if !i.faulted(op) {
+		return nil
+	}
+	if i.params.Latency > 0 {
+		time.Sleep(time.Duration(i.float64() * float64(i.params.Latency)))
+	}
+	if i.params.ErrorRate > 0 && i.float64() < i.params.ErrorRate {
+		return ErrInjected
+	}
+	return nil
+}
+
+// PartialWrite returns true if a write of operation op should fail after writing part of its data
+func (i *Injector) PartialWrite(op string) bool {
+	if !i.faulted(op) {
+		return false
+	}
+	return i.params.PartialWriteRate > 0 && i.float64() < i.params.PartialWriteRate
+}
+
+// partialReader reads up to its limit and then fails
+type partialReader struct {
+	reader io.Reader
+	left   int64
+}
+
+// NewPartialReader returns a reader of half of the size bytes of reader, which then fails with
+// ErrInjected
+func NewPartialReader(reader io.Reader, size int64) io.Reader {
+	return &partialReader{reader: reader, left: size / 2}
+}
+
+func (r *partialReader) Read(p []byte) (int, error) {
+	if r.left <= 0 {
+		return 0, ErrInjected
+	}
+	if int64(len(p)) > r.left {
+		p = p[:r.left]
+	}
+	n, err := r.reader.Read(p)
+	r.left -= int64(n)
+	if errors.Is(err, io.EOF) {
+		// the writer must not complete the write
+		return n, ErrInjected
+	}
+	return n, err
+}
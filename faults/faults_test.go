@@ -0,0 +1,86 @@
+package faults_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/treeverse/lakefs/faults"
+)
+
+func TestInjector_Fault(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Params   faults.Params
+		Op       string
+		Expected error
+	}{
+		{Name: "no faults", Params: faults.Params{}, Op: "put"},
+		{Name: "always fail", Params: faults.Params{ErrorRate: 1}, Op: "put", Expected: faults.ErrInjected},
+		{Name: "faulted operation", Params: faults.Params{ErrorRate: 1, Operations: []string{"get", "put"}}, Op: "put", Expected: faults.ErrInjected},
+		{Name: "other operation", Params: faults.Params{ErrorRate: 1, Operations: []string{"get"}}, Op: "put"},
+	}
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			injector := faults.NewInjector(tt.Params)
+			for i := 0; i < 10; i++ {
+				if err := injector.Fault(tt.Op); !errors.Is(err, tt.Expected) {
+					t.Fatalf("Fault(%s) err=%v, expected %v", tt.Op, err, tt.Expected)
+				}
+			}
+		})
+	}
+}
+
+func TestInjector_ErrorRate(t *testing.T) {
+	const calls = 10000
+	injector := faults.NewInjectorWithSource(faults.Params{ErrorRate: 0.2}, rand.NewSource(1))
+	failed := 0
+	for i := 0; i < calls; i++ {
+		if injector.Fault("put") != nil {
+			failed++
+		}
+	}
+	if failed < calls/10 || failed > calls*3/10 {
+		t.Errorf("%d of %d calls failed with error rate 0.2", failed, calls)
+	}
+}
+
+func TestInjector_Latency(t *testing.T) {
+	const latency = 20 * time.Millisecond
+	injector := faults.NewInjector(faults.Params{Latency: latency, Operations: []string{"get"}})
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		if err := injector.Fault("get"); err != nil {
+			t.Fatalf("Fault with only latency: %s", err)
+		}
+	}
+	if took := time.Since(start); took > 10*latency {
+		t.Errorf("10 calls took %s, expected at most %s", took, 10*latency)
+	}
+	start = time.Now()
+	_ = injector.Fault("put")
+	if took := time.Since(start); took >= latency {
+		t.Errorf("call of an operation without faults took %s", took)
+	}
+}
+
+func TestPartialReader(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), 1000)
+	read, err := ioutil.ReadAll(faults.NewPartialReader(bytes.NewReader(data), int64(len(data))))
+	if !errors.Is(err, faults.ErrInjected) {
+		t.Errorf("read err=%v, expected %s", err, faults.ErrInjected)
+	}
+	if len(read) != len(data)/2 {
+		t.Errorf("read %d bytes, expected %d", len(read), len(data)/2)
+	}
+
+	// a reader shorter than its size fails instead of ending
+	read, err = ioutil.ReadAll(faults.NewPartialReader(bytes.NewReader(data[:100]), int64(len(data))))
+	if !errors.Is(err, faults.ErrInjected) || len(read) != 100 {
+		t.Errorf("read of short reader %d bytes err=%v, expected 100 bytes and %s", len(read), err, faults.ErrInjected)
+	}
+}
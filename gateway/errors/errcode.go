@@ -0,0 +1,41 @@
+package errors
+
+import "github.com/treeverse/lakefs/errcode"
+
+// errcodeToAPIErrorCode maps lakeFS error codes to the S3 error closest in meaning
+var errcodeToAPIErrorCode = map[errcode.Code]APIErrorCode{
+	errcode.BadRequest:         ErrBadRequest,
+	errcode.Unauthorized:       ErrAccessDenied,
+	errcode.Forbidden:          ErrAccessDenied,
+	errcode.CredentialsExpired: ErrAccessDenied,
+	errcode.QuotaExceeded:      ErrAccessDenied,
+	errcode.NotFound:           ErrNoSuchKey,
+	errcode.RepositoryNotFound: ErrNoSuchBucket,
+	errcode.BranchNotFound:     ErrNoSuchBucket,
+	errcode.CommitNotFound:     ErrNoSuchBucket,
+	errcode.TagNotFound:        ErrNoSuchBucket,
+	errcode.AmbiguousReference: ErrBadRequest,
+	errcode.InvalidValue:       ErrBadRequest,
+	errcode.PreconditionFailed: ErrPreconditionFailed,
+	errcode.AbortedByHook:      ErrPreconditionFailed,
+	errcode.GuardrailsExceeded: ErrPreconditionFailed,
+	errcode.PathLeased:         ERRLakeFSPathLeased,
+	errcode.DigestMismatch:     ErrBadDigest,
+	errcode.ObjectExpired:      ERRLakeFSObjectRedacted,
+	errcode.EntityTooLarge:     ErrEntityTooLarge,
+	errcode.Locked:             ErrSlowDown,
+	errcode.Conflict:           ErrSlowDown,
+	errcode.TooManyRequests:    ErrSlowDown,
+	errcode.ServiceUnavailable: ErrSlowDown,
+	errcode.NotSupported:       ERRLakeFSNotSupported,
+}
+
+// FromError returns the S3 error code to report for an error returned by the catalog, using
+// ErrInternalError for errors without a matching S3 error.
+func FromError(err error) APIErrorCode {
+	code, _ := errcode.Of(err)
+	if apiErrCode, ok := errcodeToAPIErrorCode[code]; ok {
+		return apiErrCode
+	}
+	return ErrInternalError
+}
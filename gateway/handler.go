@@ -2,6 +2,7 @@ package gateway
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	gohttputil "net/http/httputil"
 	"net/url"
@@ -12,6 +13,8 @@ import (
 	"github.com/treeverse/lakefs/auth/model"
 	"github.com/treeverse/lakefs/block"
 	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/encryption"
+	"github.com/treeverse/lakefs/events"
 	gatewayerrors "github.com/treeverse/lakefs/gateway/errors"
 	"github.com/treeverse/lakefs/gateway/multiparts"
 	"github.com/treeverse/lakefs/gateway/operations"
@@ -20,6 +23,7 @@ import (
 	"github.com/treeverse/lakefs/httputil"
 	"github.com/treeverse/lakefs/logging"
 	"github.com/treeverse/lakefs/permissions"
+	"github.com/treeverse/lakefs/settings"
 	"github.com/treeverse/lakefs/stats"
 )
 
@@ -58,6 +62,9 @@ type ServerContext struct {
 	blockStore        block.Adapter
 	authService       simulator.GatewayAuthService
 	stats             stats.Collector
+	events            *events.Bus
+	encryptor         *encryption.Encryptor
+	settings          *settings.Service
 }
 
 func (c *ServerContext) WithContext(ctx context.Context) *ServerContext {
@@ -70,6 +77,9 @@ func (c *ServerContext) WithContext(ctx context.Context) *ServerContext {
 		blockStore:        c.blockStore.WithContext(ctx),
 		authService:       c.authService,
 		stats:             c.stats,
+		events:            c.events,
+		encryptor:         c.encryptor,
+		settings:          c.settings,
 	}
 }
 
@@ -81,6 +91,10 @@ func NewHandler(
 	authService simulator.GatewayAuthService,
 	bareDomain string,
 	stats stats.Collector,
+	eventsBus *events.Bus,
+	encryptor *encryption.Encryptor,
+	settingsService *settings.Service,
+	cacheParams httputil.CacheParams,
 	fallbackURL *url.URL,
 ) http.Handler {
 	var fallbackHandler http.Handler
@@ -100,6 +114,9 @@ func NewHandler(
 		blockStore:        blockStore,
 		authService:       authService,
 		stats:             stats,
+		events:            eventsBus,
+		encryptor:         encryptor,
+		settings:          settingsService,
 	}
 
 	// setup routes
@@ -109,16 +126,22 @@ func NewHandler(
 		sc:                 sc,
 		ServerErrorHandler: nil,
 		operationHandlers: map[operations.OperationID]http.Handler{
-			operations.OperationIDDeleteObject:         PathOperationHandler(sc, &operations.DeleteObject{}),
-			operations.OperationIDDeleteObjects:        RepoOperationHandler(sc, &operations.DeleteObjects{}),
-			operations.OperationIDGetObject:            PathOperationHandler(sc, &operations.GetObject{}),
-			operations.OperationIDHeadBucket:           RepoOperationHandler(sc, &operations.HeadBucket{}),
-			operations.OperationIDHeadObject:           PathOperationHandler(sc, &operations.HeadObject{}),
-			operations.OperationIDListBuckets:          OperationHandler(sc, &operations.ListBuckets{}),
-			operations.OperationIDListObjects:          RepoOperationHandler(sc, &operations.ListObjects{}),
-			operations.OperationIDPostObject:           PathOperationHandler(sc, &operations.PostObject{}),
-			operations.OperationIDPutObject:            PathOperationHandler(sc, &operations.PutObject{}),
-			operations.OperationIDUnsupportedOperation: unsupportedOperationHandler(),
+			operations.OperationIDDeleteObject:           PathOperationHandler(sc, &operations.DeleteObject{}),
+			operations.OperationIDDeleteObjects:          RepoOperationHandler(sc, &operations.DeleteObjects{}),
+			operations.OperationIDGetBucketACL:           RepoOperationHandler(sc, &operations.GetBucketACL{}),
+			operations.OperationIDGetBucketLocation:      RepoOperationHandler(sc, &operations.GetBucketLocation{}),
+			operations.OperationIDGetBucketVersioning:    RepoOperationHandler(sc, &operations.GetBucketVersioning{}),
+			operations.OperationIDGetBucketNotConfigured: RepoOperationHandler(sc, &operations.GetBucketNotConfigured{}),
+			operations.OperationIDGetObject:              PathOperationHandler(sc, &operations.GetObject{Cache: cacheParams}),
+			operations.OperationIDGetObjectACL:           PathOperationHandler(sc, &operations.GetObjectACL{}),
+			operations.OperationIDHeadBucket:             RepoOperationHandler(sc, &operations.HeadBucket{}),
+			operations.OperationIDHeadBranch:             BranchOperationHandler(sc, &operations.HeadBranch{}),
+			operations.OperationIDHeadObject:             PathOperationHandler(sc, &operations.HeadObject{Cache: cacheParams}),
+			operations.OperationIDListBuckets:            OperationHandler(sc, &operations.ListBuckets{}),
+			operations.OperationIDListObjects:            RepoOperationHandler(sc, &operations.ListObjects{}),
+			operations.OperationIDPostObject:             PathOperationHandler(sc, &operations.PostObject{}),
+			operations.OperationIDPutObject:              PathOperationHandler(sc, &operations.PutObject{}),
+			operations.OperationIDUnsupportedOperation:   unsupportedOperationHandler(),
 		},
 	}
 	h = simulator.RegisterRecorder(httputil.LoggingMiddleware(
@@ -201,6 +224,37 @@ func RepoOperationHandler(sc *ServerContext, handler operations.RepoOperationHan
 	})
 }
 
+func BranchOperationHandler(sc *ServerContext, handler operations.BranchOperationHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		repo := ctx.Value(ContextKeyRepository).(*catalog.Repository)
+		refID := ctx.Value(ContextKeyRef).(string)
+		o := ctx.Value(ContextKeyOperation).(*operations.Operation)
+		perms, err := handler.RequiredPermissions(req, repo.Name, refID)
+		if err != nil {
+			_ = o.EncodeError(w, req, gatewayerrors.ErrAccessDenied.ToAPIErr())
+			return
+		}
+		authOp := authorize(w, req, sc.authService, perms)
+		if authOp == nil {
+			return
+		}
+		operation := &operations.RefOperation{
+			RepoOperation: &operations.RepoOperation{
+				AuthorizedOperation: authOp,
+				Repository:          repo,
+			},
+			Reference: refID,
+		}
+		ctx = catalog.WithPathLeaseOwner(ctx, authOp.Principal)
+		req = req.WithContext(logging.AddFields(ctx, logging.Fields{
+			"repository": repo.Name,
+			"ref":        refID,
+		}))
+		handler.Handle(w, req, operation)
+	})
+}
+
 func PathOperationHandler(sc *ServerContext, handler operations.PathOperationHandler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		ctx := req.Context()
@@ -213,10 +267,23 @@ func PathOperationHandler(sc *ServerContext, handler operations.PathOperationHan
 			_ = o.EncodeError(w, req, gatewayerrors.ErrAccessDenied.ToAPIErr())
 			return
 		}
-		authOp := authorize(w, req, sc.authService, perms)
+		authOp := authorize(w, req, sc.authService, perms, sandboxPermissions(req, repo.Name, refID, perms)...)
 		if authOp == nil {
 			return
 		}
+		// writes check the lease of path themselves, checking early rejects uploads before
+		// their data is written
+		if writesObjects(perms) {
+			err := sc.cataloger.CheckPathLease(ctx, repo.Name, refID, path, authOp.Principal)
+			if errors.Is(err, catalog.ErrPathLeased) {
+				_ = o.EncodeError(w, req, gatewayerrors.ERRLakeFSPathLeased.ToAPIErr())
+				return
+			}
+			if err != nil {
+				_ = o.EncodeError(w, req, gatewayerrors.ErrInternalError.ToAPIErr())
+				return
+			}
+		}
 		// run callback
 		operation := &operations.PathOperation{
 			RefOperation: &operations.RefOperation{
@@ -228,19 +295,52 @@ func PathOperationHandler(sc *ServerContext, handler operations.PathOperationHan
 			},
 			Path: path,
 		}
+		ctx = catalog.WithPathLeaseOwner(ctx, authOp.Principal)
 		req = req.WithContext(logging.AddFields(ctx, logging.Fields{
 			"repository": repo.Name,
 			"ref":        refID,
 			"path":       path,
 		}))
-		handler.Handle(w, req, operation)
+		if o.Events == nil {
+			handler.Handle(w, req, operation)
+			return
+		}
+		cw := operations.NewCountingResponseWriter(w)
+		handler.Handle(cw, req, operation)
+		operation.PublishRequest(req, cw)
 	})
 }
 
-func authorize(w http.ResponseWriter, req *http.Request, authService simulator.GatewayAuthService, perms []permissions.Permission) *operations.AuthorizedOperation {
+// sandboxPermissions returns the deniable permissions of writing to branch when perms write
+// objects, policies denying them confine the user to its sandbox branch
+func sandboxPermissions(req *http.Request, repository, branch string, perms []permissions.Permission) []permissions.Permission {
+	user := req.Context().Value(ContextKeyUser).(*model.User)
+	if !writesObjects(perms) || branch == catalog.SandboxBranchID(user.Username) {
+		return nil
+	}
+	return []permissions.Permission{
+		{
+			Action:   permissions.WriteOutsideSandboxAction,
+			Resource: permissions.BranchArn(repository, branch),
+		},
+	}
+}
+
+// writesObjects returns true if perms write or delete objects
+func writesObjects(perms []permissions.Permission) bool {
+	for _, perm := range perms {
+		if perm.Action == permissions.WriteObjectAction || perm.Action == permissions.DeleteObjectAction {
+			return true
+		}
+	}
+	return false
+}
+
+func authorize(w http.ResponseWriter, req *http.Request, authService simulator.GatewayAuthService, perms []permissions.Permission, deniable ...permissions.Permission) *operations.AuthorizedOperation {
 	ctx := req.Context()
 	o := ctx.Value(ContextKeyOperation).(*operations.Operation)
-	username := ctx.Value(ContextKeyUser).(*model.User).Username
+	user := ctx.Value(ContextKeyUser).(*model.User)
+	username := user.Username
 	authContext := ctx.Value(ContextKeyAuthContext).(sig.SigContext)
 
 	if len(perms) == 0 {
@@ -248,12 +348,14 @@ func authorize(w http.ResponseWriter, req *http.Request, authService simulator.G
 		return &operations.AuthorizedOperation{
 			Operation: o,
 			Principal: username,
+			Tenant:    user.Tenant,
 		}
 	}
 
 	authResp, err := authService.Authorize(&auth.AuthorizationRequest{
 		Username:            username,
 		RequiredPermissions: perms,
+		DeniablePermissions: deniable,
 	})
 	if err != nil {
 		o.Log(req).WithError(err).Error("failed to authorize")
@@ -268,6 +370,7 @@ func authorize(w http.ResponseWriter, req *http.Request, authService simulator.G
 	return &operations.AuthorizedOperation{
 		Operation: o,
 		Principal: username,
+		Tenant:    user.Tenant,
 	}
 }
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -39,7 +40,7 @@ func AuthenticationHandler(authService simulator.GatewayAuthService, bareDomain
 		creds, err := authService.GetCredentials(accessKeyID)
 		logger := o.Log(req).WithField("key", accessKeyID)
 		if err != nil {
-			if !errors.Is(err, db.ErrNotFound) {
+			if !errors.Is(err, db.ErrNotFound) && !errors.Is(err, auth.ErrCredentialsExpired) {
 				logger.WithError(err).Warn("error getting access key")
 				_ = o.EncodeError(w, req, gatewayerrors.ErrInternalError.ToAPIErr())
 			} else {
@@ -49,12 +50,26 @@ func AuthenticationHandler(authService simulator.GatewayAuthService, bareDomain
 			return
 		}
 		err = authenticator.Verify(creds, bareDomain)
+		if err != nil && creds.PreviousSecretValid(time.Now()) {
+			// the request may be signed with the secret replaced by a rotation
+			previous := *creds
+			previous.AccessSecretKey = creds.PreviousAccessSecretKey
+			err = authenticator.Verify(&previous, bareDomain)
+		}
 		logger = logger.WithField("authenticator", authenticator)
 		if err != nil {
 			logger.WithError(err).Warn("error verifying credentials for key")
 			_ = o.EncodeError(w, req, getAPIErrOrDefault(err, gatewayerrors.ErrAccessDenied))
 			return
 		}
+		if sourceIP := httputil.SourceIPFromRequest(req); !creds.NetworkPolicy.Permits(sourceIP) {
+			logger.WithField("source_ip", sourceIP.String()).Warn("source address not permitted for key")
+			_ = o.EncodeError(w, req, gatewayerrors.ErrAccessDenied.ToAPIErr())
+			return
+		}
+		if err := authService.MarkCredentialsUsed(accessKeyID); err != nil {
+			logger.WithError(err).Warn("failed to record credentials use")
+		}
 		user, err := authService.GetUserByID(creds.UserID)
 		if err != nil {
 			logger.WithError(err).Warn("could not get user for credentials key")
@@ -91,6 +106,9 @@ func EnrichWithOperation(sc *ServerContext, next http.Handler) http.Handler {
 			MultipartsTracker: sc.multipartsTracker,
 			BlockStore:        sc.blockStore,
 			Auth:              sc.authService,
+			Events:            sc.events,
+			Encryptor:         sc.encryptor,
+			Settings:          sc.settings,
 			Incr: func(action string) {
 				logging.FromContext(ctx).
 					WithField("action", action).
@@ -127,11 +145,8 @@ func EnrichWithRepositoryOrFallback(cataloger catalog.Cataloger, authService sim
 		}
 		repo, err := cataloger.GetRepository(ctx, repoID)
 		if errors.Is(err, db.ErrNotFound) {
-			authResp, authErr := authService.Authorize(&auth.AuthorizationRequest{
-				Username:            username,
-				RequiredPermissions: []permissions.Permission{{Action: permissions.ListRepositoriesAction, Resource: "*"}},
-			})
-			if authErr != nil || authResp.Error != nil || !authResp.Allowed {
+			// only users who could see the repository learn that it does not exist
+			if !mayReadRepository(authService, username, repoID) {
 				_ = o.EncodeError(w, req, gatewayerrors.ErrAccessDenied.ToAPIErr())
 				return
 			}
@@ -151,6 +166,23 @@ func EnrichWithRepositoryOrFallback(cataloger catalog.Cataloger, authService sim
 	})
 }
 
+// mayReadRepository returns true if username may list all repositories or read repository repoID
+func mayReadRepository(authService simulator.GatewayAuthService, username, repoID string) bool {
+	for _, perm := range []permissions.Permission{
+		{Action: permissions.ListRepositoriesAction, Resource: "*"},
+		{Action: permissions.ReadRepositoryAction, Resource: permissions.RepoArn(repoID)},
+	} {
+		authResp, err := authService.Authorize(&auth.AuthorizationRequest{
+			Username:            username,
+			RequiredPermissions: []permissions.Permission{perm},
+		})
+		if err == nil && authResp.Error == nil && authResp.Allowed {
+			return true
+		}
+	}
+	return false
+}
+
 func OperationLookupHandler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		ctx := req.Context()
@@ -170,11 +202,14 @@ func OperationLookupHandler(next http.Handler) http.Handler {
 			switch {
 			case ref != "" && pth != "":
 				req = req.WithContext(ctx)
-				operationID = pathBasedOperationID(req.Method)
+				operationID = pathBasedOperationID(req.Method, req.URL.Query())
 			case ref == "" && pth == "":
-				operationID = repositoryBasedOperationID(req.Method)
+				operationID = repositoryBasedOperationID(req.Method, req.URL.Query())
+			case req.Method == http.MethodHead:
+				// "repository/ref" used as a bucket name
+				operationID = operations.OperationIDHeadBranch
 			default:
-				w.WriteHeader(http.StatusNotFound)
+				operationID = operations.OperationIDOperationNotFound
 			}
 		}
 		o.OperationID = operationID
@@ -214,7 +249,10 @@ func Parts(host string, urlPath string, bareDomain string) (repo string, ref str
 	return repo, p[0], p[1]
 }
 
-func pathBasedOperationID(method string) operations.OperationID {
+func pathBasedOperationID(method string, query url.Values) operations.OperationID {
+	if _, found := query["acl"]; found {
+		return objectACLOperationID(method)
+	}
 	switch method {
 	case http.MethodDelete:
 		return operations.OperationIDDeleteObject
@@ -231,7 +269,20 @@ func pathBasedOperationID(method string) operations.OperationID {
 	}
 }
 
-func repositoryBasedOperationID(method string) operations.OperationID {
+// objectACLOperationID selects the operation on the ACL of an object.  ACLs are not enforced by
+// lakeFS, so they cannot be set other than by a canned ACL on upload.
+func objectACLOperationID(method string) operations.OperationID {
+	switch method {
+	case http.MethodGet:
+		return operations.OperationIDGetObjectACL
+	case http.MethodPut:
+		return operations.OperationIDUnsupportedOperation
+	default:
+		return operations.OperationIDOperationNotFound
+	}
+}
+
+func repositoryBasedOperationID(method string, query url.Values) operations.OperationID {
 	switch method {
 	case http.MethodDelete, http.MethodPut:
 		return operations.OperationIDUnsupportedOperation
@@ -240,8 +291,28 @@ func repositoryBasedOperationID(method string) operations.OperationID {
 	case http.MethodPost:
 		return operations.OperationIDDeleteObjects
 	case http.MethodGet:
-		return operations.OperationIDListObjects
+		return bucketGetOperationID(query)
 	default:
 		return operations.OperationIDOperationNotFound
 	}
 }
+
+// bucketGetOperationID selects the operation of a GET on a bucket subresource, listing objects if
+// there is none
+func bucketGetOperationID(query url.Values) operations.OperationID {
+	if _, found := query["location"]; found {
+		return operations.OperationIDGetBucketLocation
+	}
+	if _, found := query["versioning"]; found {
+		return operations.OperationIDGetBucketVersioning
+	}
+	if _, found := query["acl"]; found {
+		return operations.OperationIDGetBucketACL
+	}
+	for subresource := range operations.BucketNotConfiguredSubresources {
+		if _, found := query[subresource]; found {
+			return operations.OperationIDGetBucketNotConfigured
+		}
+	}
+	return operations.OperationIDListObjects
+}
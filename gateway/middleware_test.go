@@ -0,0 +1,47 @@
+package gateway_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/treeverse/lakefs/gateway"
+	"github.com/treeverse/lakefs/gateway/operations"
+)
+
+func TestOperationLookupHandler(t *testing.T) {
+	const bareDomain = "s3.example.com"
+	cases := []struct {
+		Name     string
+		Method   string
+		Target   string
+		Expected operations.OperationID
+	}{
+		{Name: "list_buckets", Method: http.MethodGet, Target: "/", Expected: operations.OperationIDListBuckets},
+		{Name: "head_bucket", Method: http.MethodHead, Target: "/repo", Expected: operations.OperationIDHeadBucket},
+		{Name: "head_bucket_trailing_slash", Method: http.MethodHead, Target: "/repo/", Expected: operations.OperationIDHeadBucket},
+		{Name: "head_branch", Method: http.MethodHead, Target: "/repo/main", Expected: operations.OperationIDHeadBranch},
+		{Name: "head_branch_trailing_slash", Method: http.MethodHead, Target: "/repo/main/", Expected: operations.OperationIDHeadBranch},
+		{Name: "get_branch", Method: http.MethodGet, Target: "/repo/main", Expected: operations.OperationIDOperationNotFound},
+		{Name: "head_object", Method: http.MethodHead, Target: "/repo/main/file", Expected: operations.OperationIDHeadObject},
+		{Name: "get_object_acl", Method: http.MethodGet, Target: "/repo/main/file?acl", Expected: operations.OperationIDGetObjectACL},
+		{Name: "put_object_acl", Method: http.MethodPut, Target: "/repo/main/file?acl", Expected: operations.OperationIDUnsupportedOperation},
+		{Name: "location", Method: http.MethodGet, Target: "/repo?location", Expected: operations.OperationIDGetBucketLocation},
+	}
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			var operationID operations.OperationID
+			next := http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+				operationID = req.Context().Value(gateway.ContextKeyOperation).(*operations.Operation).OperationID
+			})
+			h := gateway.EnrichWithParts(bareDomain, gateway.OperationLookupHandler(next))
+			req := httptest.NewRequest(tt.Method, "http://"+bareDomain+tt.Target, nil)
+			req = req.WithContext(context.WithValue(req.Context(), gateway.ContextKeyOperation, &operations.Operation{}))
+			h.ServeHTTP(httptest.NewRecorder(), req)
+			if operationID != tt.Expected {
+				t.Errorf("%s %s got operation %s, expected %s", tt.Method, tt.Target, operationID, tt.Expected)
+			}
+		})
+	}
+}
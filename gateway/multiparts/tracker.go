@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/treeverse/lakefs/catalog"
 	"github.com/treeverse/lakefs/db"
 )
 
@@ -14,10 +15,12 @@ type MultipartUpload struct {
 	Path            string    `db:"path"`
 	CreationDate    time.Time `db:"creation_date"`
 	PhysicalAddress string    `db:"physical_address"`
+	// Metadata is the metadata of the object created by completing the upload
+	Metadata catalog.Metadata `db:"metadata"`
 }
 
 type Tracker interface {
-	Create(ctx context.Context, uploadID, path, physicalAddress string, creationTime time.Time) error
+	Create(ctx context.Context, uploadID, path, physicalAddress string, creationTime time.Time, metadata catalog.Metadata) error
 	Get(ctx context.Context, uploadID string) (*MultipartUpload, error)
 	Delete(ctx context.Context, uploadID string) error
 }
@@ -37,14 +40,14 @@ func NewTracker(adb db.Database) Tracker {
 	}
 }
 
-func (m *tracker) Create(ctx context.Context, uploadID, path, physicalAddress string, creationTime time.Time) error {
+func (m *tracker) Create(ctx context.Context, uploadID, path, physicalAddress string, creationTime time.Time, metadata catalog.Metadata) error {
 	if uploadID == "" {
 		return ErrInvalidUploadID
 	}
 	_, err := m.db.Transact(func(tx db.Tx) (interface{}, error) {
-		_, err := tx.Exec(`INSERT INTO gateway_multiparts (upload_id,path,creation_date,physical_address)
-			VALUES ($1, $2, $3, $4)`,
-			uploadID, path, creationTime, physicalAddress)
+		_, err := tx.Exec(`INSERT INTO gateway_multiparts (upload_id,path,creation_date,physical_address,metadata)
+			VALUES ($1, $2, $3, $4, $5)`,
+			uploadID, path, creationTime, physicalAddress, metadata)
 		return nil, err
 	}, db.WithContext(ctx))
 	return err
@@ -57,7 +60,7 @@ func (m *tracker) Get(ctx context.Context, uploadID string) (*MultipartUpload, e
 	res, err := m.db.Transact(func(tx db.Tx) (interface{}, error) {
 		var m MultipartUpload
 		if err := tx.Get(&m, `
-			SELECT upload_id, path, creation_date, physical_address 
+			SELECT upload_id, path, creation_date, physical_address, metadata
 			FROM gateway_multiparts
 			WHERE upload_id = $1`,
 			uploadID); err != nil {
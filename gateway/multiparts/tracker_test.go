@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/treeverse/lakefs/catalog"
 	"github.com/treeverse/lakefs/testutil"
 )
 
@@ -21,7 +22,7 @@ func Tracker_Get(t *testing.T) {
 
 	creationTime := time.Now().Round(time.Second) // round in order to remove the monotonic clock
 	// setup test data
-	if err := tracker.Create(ctx, "upload1", "/path1", "/file1", creationTime); err != nil {
+	if err := tracker.Create(ctx, "upload1", "/path1", "/file1", creationTime, catalog.Metadata{"x-amz-meta-mtime": "1614556800"}); err != nil {
 		t.Fatal("create multipart upload for testing", err)
 	}
 
@@ -42,6 +43,7 @@ func Tracker_Get(t *testing.T) {
 				Path:            "/path1",
 				CreationDate:    creationTime,
 				PhysicalAddress: "/file1",
+				Metadata:        catalog.Metadata{"x-amz-meta-mtime": "1614556800"},
 			},
 			wantErr: false,
 		},
@@ -77,7 +79,7 @@ func Tracker_Delete(t *testing.T) {
 	c := testTracker(t)
 
 	// setup test data
-	if err := c.Create(ctx, "uploadX", "/pathX", "/fileX", time.Now()); err != nil {
+	if err := c.Create(ctx, "uploadX", "/pathX", "/fileX", time.Now(), nil); err != nil {
 		t.Fatal("create multipart upload for testing", err)
 	}
 
@@ -107,7 +109,7 @@ func Tracker_Create(t *testing.T) {
 	tracker := testTracker(t)
 
 	// setup test data
-	if err := tracker.Create(ctx, "uploadX", "/pathX", "/fileX", time.Now()); err != nil {
+	if err := tracker.Create(ctx, "uploadX", "/pathX", "/fileX", time.Now(), nil); err != nil {
 		t.Fatal("create multipart upload for testing", err)
 	}
 
@@ -150,7 +152,7 @@ func Tracker_Create(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tracker.Create(ctx, tt.args.uploadID, tt.args.path, tt.args.physicalAddress, tt.args.creationTime)
+			err := tracker.Create(ctx, tt.args.uploadID, tt.args.path, tt.args.physicalAddress, tt.args.creationTime, nil)
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("Create() error = %v, wantErr %v", err, tt.wantErr)
 			}
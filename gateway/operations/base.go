@@ -11,12 +11,15 @@ import (
 	"github.com/treeverse/lakefs/auth"
 	"github.com/treeverse/lakefs/block"
 	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/encryption"
+	"github.com/treeverse/lakefs/events"
 	"github.com/treeverse/lakefs/gateway/errors"
 	"github.com/treeverse/lakefs/gateway/multiparts"
 	"github.com/treeverse/lakefs/gateway/simulator"
 	"github.com/treeverse/lakefs/httputil"
 	"github.com/treeverse/lakefs/logging"
 	"github.com/treeverse/lakefs/permissions"
+	"github.com/treeverse/lakefs/settings"
 )
 
 const StorageClassHeader = "x-amz-storage-class"
@@ -24,15 +27,21 @@ const StorageClassHeader = "x-amz-storage-class"
 type OperationID string
 
 const (
-	OperationIDDeleteObject  OperationID = "delete_object"
-	OperationIDDeleteObjects OperationID = "delete_objects"
-	OperationIDGetObject     OperationID = "get_object"
-	OperationIDHeadBucket    OperationID = "head_bucket"
-	OperationIDHeadObject    OperationID = "head_object"
-	OperationIDListBuckets   OperationID = "list_buckets"
-	OperationIDListObjects   OperationID = "list_objects"
-	OperationIDPostObject    OperationID = "post_object"
-	OperationIDPutObject     OperationID = "put_object"
+	OperationIDDeleteObject           OperationID = "delete_object"
+	OperationIDDeleteObjects          OperationID = "delete_objects"
+	OperationIDGetBucketACL           OperationID = "get_bucket_acl"
+	OperationIDGetBucketLocation      OperationID = "get_bucket_location"
+	OperationIDGetBucketVersioning    OperationID = "get_bucket_versioning"
+	OperationIDGetBucketNotConfigured OperationID = "get_bucket_not_configured"
+	OperationIDGetObject              OperationID = "get_object"
+	OperationIDGetObjectACL           OperationID = "get_object_acl"
+	OperationIDHeadBucket             OperationID = "head_bucket"
+	OperationIDHeadBranch             OperationID = "head_branch"
+	OperationIDHeadObject             OperationID = "head_object"
+	OperationIDListBuckets            OperationID = "list_buckets"
+	OperationIDListObjects            OperationID = "list_objects"
+	OperationIDPostObject             OperationID = "post_object"
+	OperationIDPutObject              OperationID = "put_object"
 
 	OperationIDUnsupportedOperation OperationID = "unsupported"
 	OperationIDOperationNotFound    OperationID = "not_found"
@@ -48,6 +57,9 @@ type Operation struct {
 	MultipartsTracker multiparts.Tracker
 	BlockStore        block.Adapter
 	Auth              simulator.GatewayAuthService
+	Events            *events.Bus
+	Encryptor         *encryption.Encryptor
+	Settings          *settings.Service
 	Incr              ActionIncr
 }
 
@@ -59,6 +71,18 @@ func StorageClassFromHeader(header http.Header) *string {
 	return &storageClass
 }
 
+// repositorySettings returns the settings of the repository of o, or encodes an error and
+// returns false if they cannot be read
+func (o *RepoOperation) repositorySettings(w http.ResponseWriter, req *http.Request) (*settings.Settings, bool) {
+	s, err := o.Settings.Get(req.Context(), o.Repository.Name)
+	if err != nil {
+		o.Log(req).WithError(err).Error("could not read repository settings")
+		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrInternalError))
+		return nil, false
+	}
+	return s, true
+}
+
 func (o *Operation) Log(req *http.Request) logging.Logger {
 	return logging.FromContext(req.Context())
 }
@@ -152,6 +176,8 @@ func generateHostID() string {
 type AuthorizedOperation struct {
 	*Operation
 	Principal string
+	// Tenant of the principal, empty for users of the installation
+	Tenant string
 }
 
 type RepoOperation struct {
@@ -56,7 +56,7 @@ func (controller *DeleteObject) Handle(w http.ResponseWriter, req *http.Request,
 		lg.WithError(err).Debug("could not delete object, it doesn't exist")
 	case err != nil:
 		lg.WithError(err).Error("could not delete object")
-		_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.ErrInternalError))
+		_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.FromError(err)))
 		return
 	default:
 		lg.Debug("object set for deletion")
@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/treeverse/lakefs/auth"
+	"github.com/treeverse/lakefs/catalog"
 	"github.com/treeverse/lakefs/db"
 	gerrors "github.com/treeverse/lakefs/gateway/errors"
 	"github.com/treeverse/lakefs/gateway/path"
@@ -41,7 +42,7 @@ func (controller *DeleteObjects) Handle(w http.ResponseWriter, req *http.Request
 			continue
 		}
 		// authorize this object deletion
-		authResp, err := o.Auth.Authorize(&auth.AuthorizationRequest{
+		authReq := &auth.AuthorizationRequest{
 			Username: o.Principal,
 			RequiredPermissions: []permissions.Permission{
 				{
@@ -49,13 +50,23 @@ func (controller *DeleteObjects) Handle(w http.ResponseWriter, req *http.Request
 					Resource: permissions.ObjectArn(o.Repository.Name, resolvedPath.Path),
 				},
 			},
-		})
+		}
+		if resolvedPath.Ref != catalog.SandboxBranchID(o.Principal) {
+			authReq.DeniablePermissions = []permissions.Permission{
+				{
+					Action:   permissions.WriteOutsideSandboxAction,
+					Resource: permissions.BranchArn(o.Repository.Name, resolvedPath.Ref),
+				},
+			}
+		}
+		authResp, err := o.Auth.Authorize(authReq)
 		if err != nil || !authResp.Allowed {
 			errs = append(errs, serde.DeleteError{
 				Code:    "AccessDenied",
 				Key:     obj.Key,
 				Message: "Access Denied",
 			})
+			continue
 		}
 
 		lg := o.Log(req).WithField("key", obj.Key)
@@ -63,6 +74,13 @@ func (controller *DeleteObjects) Handle(w http.ResponseWriter, req *http.Request
 		switch {
 		case errors.Is(err, db.ErrNotFound):
 			lg.Debug("tried to delete a non-existent object")
+		case errors.Is(err, catalog.ErrPathLeased):
+			errs = append(errs, serde.DeleteError{
+				Code:    "PathLeased",
+				Key:     obj.Key,
+				Message: err.Error(),
+			})
+			continue
 		case err != nil:
 			lg.WithError(err).Error("failed deleting object")
 			errs = append(errs, serde.DeleteError{
@@ -0,0 +1,56 @@
+package operations
+
+import (
+	"net/http"
+
+	"github.com/treeverse/lakefs/gateway/serde"
+	"github.com/treeverse/lakefs/permissions"
+)
+
+// Permissions granted in ACLs
+const (
+	ACLPermissionFullControl = "FULL_CONTROL"
+	ACLPermissionRead        = "READ"
+)
+
+type GetBucketACL struct{}
+
+func (controller *GetBucketACL) RequiredPermissions(_ *http.Request, repoID string) ([]permissions.Permission, error) {
+	return []permissions.Permission{
+		{
+			Action:   permissions.ReadRepositoryAction,
+			Resource: permissions.RepoArn(repoID),
+		},
+	}, nil
+}
+
+// Handle reports the caller as owner with full control, access is managed by lakeFS policies
+func (controller *GetBucketACL) Handle(w http.ResponseWriter, req *http.Request, o *RepoOperation) {
+	o.Incr("get_bucket_acl")
+	o.EncodeResponse(w, req, accessControlPolicy(o.Principal, ACLPermissionFullControl), http.StatusOK)
+}
+
+// accessControlPolicy returns the ACL of a resource owned by principal, granting principal
+// permission on it
+func accessControlPolicy(principal, permission string) serde.AccessControlPolicy {
+	owner := serde.Owner{
+		DisplayName: principal,
+		ID:          principal,
+	}
+	return serde.AccessControlPolicy{
+		Owner: owner,
+		AccessControlList: serde.AccessControlList{
+			Grant: []serde.Grant{
+				{
+					Grantee: serde.Grantee{
+						XMLNS:       "http://www.w3.org/2001/XMLSchema-instance",
+						XMLXSI:      "CanonicalUser",
+						ID:          owner.ID,
+						DisplayName: owner.DisplayName,
+					},
+					Permission: permission,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,31 @@
+package operations
+
+import (
+	"net/http"
+
+	"github.com/treeverse/lakefs/gateway/serde"
+	"github.com/treeverse/lakefs/permissions"
+)
+
+// defaultRegion is reported by S3 as an empty location constraint
+const defaultRegion = "us-east-1"
+
+type GetBucketLocation struct{}
+
+func (controller *GetBucketLocation) RequiredPermissions(_ *http.Request, repoID string) ([]permissions.Permission, error) {
+	return []permissions.Permission{
+		{
+			Action:   permissions.ReadRepositoryAction,
+			Resource: permissions.RepoArn(repoID),
+		},
+	}, nil
+}
+
+func (controller *GetBucketLocation) Handle(w http.ResponseWriter, req *http.Request, o *RepoOperation) {
+	o.Incr("get_bucket_location")
+	location := o.Region
+	if location == defaultRegion {
+		location = ""
+	}
+	o.EncodeResponse(w, req, serde.LocationConstraint{Location: location}, http.StatusOK)
+}
@@ -0,0 +1,42 @@
+package operations
+
+import (
+	"net/http"
+
+	gatewayerrors "github.com/treeverse/lakefs/gateway/errors"
+	"github.com/treeverse/lakefs/permissions"
+)
+
+// BucketNotConfiguredSubresources maps bucket subresources lakeFS does not support to the error S3
+// returns for a bucket without that configuration
+var BucketNotConfiguredSubresources = map[string]gatewayerrors.APIErrorCode{
+	"policy":     gatewayerrors.ErrNoSuchBucketPolicy,
+	"lifecycle":  gatewayerrors.ErrNoSuchBucketLifecycle,
+	"tagging":    gatewayerrors.ErrNoSuchTagSet,
+	"cors":       gatewayerrors.ErrNoSuchCORSConfiguration,
+	"website":    gatewayerrors.ErrNoSuchWebsiteConfiguration,
+	"encryption": gatewayerrors.ErrNoSuchEncryptionConfiguration,
+}
+
+type GetBucketNotConfigured struct{}
+
+func (controller *GetBucketNotConfigured) RequiredPermissions(_ *http.Request, repoID string) ([]permissions.Permission, error) {
+	return []permissions.Permission{
+		{
+			Action:   permissions.ReadRepositoryAction,
+			Resource: permissions.RepoArn(repoID),
+		},
+	}, nil
+}
+
+func (controller *GetBucketNotConfigured) Handle(w http.ResponseWriter, req *http.Request, o *RepoOperation) {
+	o.Incr("get_bucket_not_configured")
+	query := req.URL.Query()
+	for subresource, code := range BucketNotConfiguredSubresources {
+		if _, found := query[subresource]; found {
+			_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(code))
+			return
+		}
+	}
+	_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.ErrBadRequest))
+}
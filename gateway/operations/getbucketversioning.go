@@ -0,0 +1,25 @@
+package operations
+
+import (
+	"net/http"
+
+	"github.com/treeverse/lakefs/gateway/serde"
+	"github.com/treeverse/lakefs/permissions"
+)
+
+type GetBucketVersioning struct{}
+
+func (controller *GetBucketVersioning) RequiredPermissions(_ *http.Request, repoID string) ([]permissions.Permission, error) {
+	return []permissions.Permission{
+		{
+			Action:   permissions.ReadRepositoryAction,
+			Resource: permissions.RepoArn(repoID),
+		},
+	}, nil
+}
+
+func (controller *GetBucketVersioning) Handle(w http.ResponseWriter, req *http.Request, o *RepoOperation) {
+	o.Incr("get_bucket_versioning")
+	// versions are kept by lakeFS commits, S3 object versioning was never enabled
+	o.EncodeXMLBytes(w, req, []byte(serde.VersioningResponse), http.StatusOK)
+}
@@ -10,14 +10,16 @@ import (
 	"github.com/treeverse/lakefs/block"
 	"github.com/treeverse/lakefs/catalog"
 	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/encryption"
 	gatewayerrors "github.com/treeverse/lakefs/gateway/errors"
-	ghttp "github.com/treeverse/lakefs/gateway/http"
 	"github.com/treeverse/lakefs/gateway/serde"
 	"github.com/treeverse/lakefs/httputil"
 	"github.com/treeverse/lakefs/permissions"
 )
 
-type GetObject struct{}
+type GetObject struct {
+	Cache httputil.CacheParams
+}
 
 func (controller *GetObject) RequiredPermissions(_ *http.Request, repoID, _, path string) ([]permissions.Permission, error) {
 	return []permissions.Permission{
@@ -42,7 +44,7 @@ func (controller *GetObject) Handle(w http.ResponseWriter, req *http.Request, o
 	}
 
 	beforeMeta := time.Now()
-	entry, err := o.Cataloger.GetEntry(req.Context(), o.Repository.Name, o.Reference, o.Path, catalog.GetEntryParams{})
+	entry, err := o.Cataloger.GetEntry(req.Context(), o.Repository.Name, o.Reference, o.Path, catalog.GetEntryParams{AuthorizeLink: o.authorizeLink})
 	metaTook := time.Since(beforeMeta)
 	o.Log(req).
 		WithField("took", metaTook).
@@ -54,27 +56,42 @@ func (controller *GetObject) Handle(w http.ResponseWriter, req *http.Request, o
 		_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.ErrNoSuchKey))
 		return
 	}
+	if errors.Is(err, ErrLinkAccessDenied) || errors.Is(err, catalog.ErrCrossRepositoryLink) {
+		_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.ErrAccessDenied))
+		return
+	}
 	if errors.Is(err, catalog.ErrExpired) {
 		_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.ErrNoSuchVersion))
+		return
 	}
 	if err != nil {
-		_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.ErrInternalError))
+		_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.FromError(err)))
+		return
+	}
+	if handleRedacted(w, req, o, entry) {
+		return
+	}
+	encrypted := encryption.IsEncrypted(entry.Metadata)
+	if encrypted && o.authorizeDecrypt() != nil {
+		_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.ErrAccessDenied))
 		return
 	}
 
-	o.SetHeader(w, "Last-Modified", httputil.HeaderTimestamp(entry.CreationDate))
-	o.SetHeader(w, "ETag", httputil.ETag(entry.Checksum))
+	if handleNotModified(w, req, o, controller.Cache, entry) {
+		return
+	}
+	setUserMetadataHeaders(w, entry.Metadata)
 	o.SetHeader(w, "Accept-Ranges", "bytes")
 	// TODO: the rest of https://docs.aws.amazon.com/en_pv/AmazonS3/latest/API/API_GetObject.html
 
 	// range query
-	var expected int64
+	var expected, offset int64
 	var data io.ReadCloser
-	var rng ghttp.Range
+	var rng httputil.Range
 	// range query
 	rangeSpec := req.Header.Get("Range")
 	if len(rangeSpec) > 0 {
-		rng, err = ghttp.ParseRange(rangeSpec, entry.Size)
+		rng, err = httputil.ParseRange(rangeSpec, entry.Size)
 		if err != nil {
 			o.Log(req).WithError(err).WithField("range", rangeSpec).Debug("invalid range spec")
 		}
@@ -85,6 +102,7 @@ func (controller *GetObject) Handle(w http.ResponseWriter, req *http.Request, o
 		data, err = o.BlockStore.Get(block.ObjectPointer{StorageNamespace: o.Repository.StorageNamespace, Identifier: entry.PhysicalAddress}, entry.Size)
 	} else {
 		expected = rng.EndOffset - rng.StartOffset + 1 // both range ends are inclusive
+		offset = rng.StartOffset
 		data, err = o.BlockStore.GetRange(block.ObjectPointer{StorageNamespace: o.Repository.StorageNamespace, Identifier: entry.PhysicalAddress}, rng.StartOffset, rng.EndOffset)
 		o.SetHeader(w, "Content-Range", fmt.Sprintf("bytes %d-%d/%d", rng.StartOffset, rng.EndOffset, entry.Size))
 	}
@@ -95,12 +113,60 @@ func (controller *GetObject) Handle(w http.ResponseWriter, req *http.Request, o
 	defer func() {
 		_ = data.Close()
 	}()
+	var body io.Reader = data
+	if encrypted {
+		body, err = o.Encryptor.Decrypt(req.Context(), entry.Metadata, data, offset)
+		if err != nil {
+			o.Log(req).WithError(err).Error("could not decrypt object")
+			_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.ErrInternalError))
+			return
+		}
+	}
 	o.SetHeader(w, "Content-Length", fmt.Sprintf("%d", expected))
-	// Delete the default content-type header so http.Server will detect it from contents
-	// TODO(ariels): After/if we add content-type support to adapter, use *that*.
-	o.DeleteHeader(w, "Content-Type")
-	_, err = io.Copy(w, data)
+	if !hasContentType(entry.Metadata) {
+		// Delete the default content-type header so http.Server will detect it from contents
+		o.DeleteHeader(w, "Content-Type")
+	}
+	_, err = io.Copy(w, body)
 	if err != nil {
 		o.Log(req).WithError(err).Error("could not write response body for object")
 	}
 }
+
+// handleNotModified sets the caching headers of a response reading entry, and writes a 304 Not
+// Modified response if the conditional headers of req show that the client holds entry.
+// Returns true if a response was written.
+func handleNotModified(w http.ResponseWriter, req *http.Request, o *PathOperation, cache httputil.CacheParams, entry *catalog.DBEntry) bool {
+	immutable, err := o.Cataloger.IsImmutableRef(req.Context(), o.Repository.Name, o.Reference)
+	if err != nil {
+		o.Log(req).WithError(err).Error("could not resolve reference")
+		_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.ErrInternalError))
+		return true
+	}
+	etag := entryETag(entry)
+	o.SetHeader(w, "Last-Modified", httputil.HeaderTimestamp(entry.CreationDate))
+	o.SetHeader(w, "ETag", etag)
+	o.SetHeader(w, "Cache-Control", cache.CacheControl(immutable))
+	if !httputil.NotModified(req, etag, entry.CreationDate) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// handleRedacted encodes an error response if the data of entry was redacted, returns true if
+// a response was written
+func handleRedacted(w http.ResponseWriter, req *http.Request, o *PathOperation, entry *catalog.DBEntry) bool {
+	redaction, err := o.Cataloger.GetRedaction(req.Context(), o.Repository.StorageNamespace, entry.PhysicalAddress)
+	if errors.Is(err, db.ErrNotFound) {
+		return false
+	}
+	if err != nil {
+		o.Log(req).WithError(err).Error("could not get object redaction")
+		_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.ErrInternalError))
+		return true
+	}
+	o.Log(req).WithField("reason", redaction.Reason).Debug("object redacted")
+	_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.ERRLakeFSObjectRedacted))
+	return true
+}
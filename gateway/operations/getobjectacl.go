@@ -0,0 +1,48 @@
+package operations
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+	gatewayerrors "github.com/treeverse/lakefs/gateway/errors"
+	"github.com/treeverse/lakefs/permissions"
+)
+
+type GetObjectACL struct{}
+
+func (controller *GetObjectACL) RequiredPermissions(_ *http.Request, repoID, _, path string) ([]permissions.Permission, error) {
+	return []permissions.Permission{
+		{
+			Action:   permissions.ReadObjectAction,
+			Resource: permissions.ObjectArn(repoID, path),
+		},
+	}, nil
+}
+
+// Handle reports the caller as owner of the object, with full control if lakeFS policies allow it
+// to write the object and read permission otherwise.  A canned ACL set on upload is not reported,
+// it is not enforced.
+func (controller *GetObjectACL) Handle(w http.ResponseWriter, req *http.Request, o *PathOperation) {
+	o.Incr("get_object_acl")
+	_, err := o.Cataloger.GetEntry(req.Context(), o.Repository.Name, o.Reference, o.Path, catalog.GetEntryParams{ReturnExpired: true, AuthorizeLink: o.authorizeLink})
+	if errors.Is(err, db.ErrNotFound) {
+		_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.ErrNoSuchKey))
+		return
+	}
+	if errors.Is(err, ErrLinkAccessDenied) || errors.Is(err, catalog.ErrCrossRepositoryLink) {
+		_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.ErrAccessDenied))
+		return
+	}
+	if err != nil {
+		o.Log(req).WithError(err).Error("could not get entry")
+		_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.FromError(err)))
+		return
+	}
+	permission := ACLPermissionRead
+	if o.mayWriteObject() {
+		permission = ACLPermissionFullControl
+	}
+	o.EncodeResponse(w, req, accessControlPolicy(o.Principal, permission), http.StatusOK)
+}
@@ -1,8 +1,11 @@
 package operations
 
 import (
+	"errors"
 	"net/http"
 
+	"github.com/treeverse/lakefs/db"
+	gatewayerrors "github.com/treeverse/lakefs/gateway/errors"
 	"github.com/treeverse/lakefs/permissions"
 )
 
@@ -19,5 +22,36 @@ func (controller *HeadBucket) RequiredPermissions(_ *http.Request, repoID string
 
 func (controller *HeadBucket) Handle(w http.ResponseWriter, _ *http.Request, o *RepoOperation) {
 	o.Incr("get_repo")
+	// SDKs locate the bucket by its region before using it
+	o.SetHeader(w, "X-Amz-Bucket-Region", o.Region)
+	w.WriteHeader(http.StatusOK)
+}
+
+// HeadBranch checks the existence of a ref, for clients configured with a bucket of the form
+// "repository/ref" that check it exists before using it
+type HeadBranch struct{}
+
+func (controller *HeadBranch) RequiredPermissions(_ *http.Request, repoID, branchID string) ([]permissions.Permission, error) {
+	return []permissions.Permission{
+		{
+			Action:   permissions.ReadBranchAction,
+			Resource: permissions.BranchArn(repoID, branchID),
+		},
+	}, nil
+}
+
+func (controller *HeadBranch) Handle(w http.ResponseWriter, req *http.Request, o *RefOperation) {
+	o.Incr("get_branch")
+	_, err := o.Cataloger.GetCommit(req.Context(), o.Repository.Name, o.Reference)
+	if errors.Is(err, db.ErrNotFound) {
+		_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.ErrNoSuchBucket))
+		return
+	}
+	if err != nil {
+		o.Log(req).WithError(err).Error("failed querying ref")
+		_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.FromError(err)))
+		return
+	}
+	o.SetHeader(w, "X-Amz-Bucket-Region", o.Region)
 	w.WriteHeader(http.StatusOK)
 }
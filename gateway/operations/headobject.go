@@ -12,7 +12,9 @@ import (
 	"github.com/treeverse/lakefs/permissions"
 )
 
-type HeadObject struct{}
+type HeadObject struct {
+	Cache httputil.CacheParams
+}
 
 func (controller *HeadObject) RequiredPermissions(_ *http.Request, repoID, _, path string) ([]permissions.Permission, error) {
 	return []permissions.Permission{
@@ -25,16 +27,20 @@ func (controller *HeadObject) RequiredPermissions(_ *http.Request, repoID, _, pa
 
 func (controller *HeadObject) Handle(w http.ResponseWriter, req *http.Request, o *PathOperation) {
 	o.Incr("stat_object")
-	entry, err := o.Cataloger.GetEntry(req.Context(), o.Repository.Name, o.Reference, o.Path, catalog.GetEntryParams{ReturnExpired: true})
+	entry, err := o.Cataloger.GetEntry(req.Context(), o.Repository.Name, o.Reference, o.Path, catalog.GetEntryParams{ReturnExpired: true, AuthorizeLink: o.authorizeLink})
 	if errors.Is(err, db.ErrNotFound) {
 		// TODO: create distinction between missing repo & missing key
 		o.Log(req).Debug("path not found")
 		_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.ErrNoSuchKey))
 		return
 	}
+	if errors.Is(err, ErrLinkAccessDenied) || errors.Is(err, catalog.ErrCrossRepositoryLink) {
+		_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.ErrAccessDenied))
+		return
+	}
 	if err != nil {
 		o.Log(req).WithError(err).Error("failed querying path")
-		_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.ErrInternalError))
+		_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.FromError(err)))
 		return
 	}
 	if entry.Expired {
@@ -42,13 +48,19 @@ func (controller *HeadObject) Handle(w http.ResponseWriter, req *http.Request, o
 		_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.ErrNoSuchVersion))
 		return
 	}
+	if handleRedacted(w, req, o, entry) {
+		return
+	}
+	if handleNotModified(w, req, o, controller.Cache, entry) {
+		return
+	}
+	setUserMetadataHeaders(w, entry.Metadata)
 
 	o.SetHeader(w, "Accept-Ranges", "bytes")
-	o.SetHeader(w, "Last-Modified", httputil.HeaderTimestamp(entry.CreationDate))
-	o.SetHeader(w, "ETag", httputil.ETag(entry.Checksum))
 	o.SetHeader(w, "Content-Length", fmt.Sprintf("%d", entry.Size))
 
-	// Delete the default content-type header so http.Server will detect it from contents
-	// TODO(ariels): After/if we add content-type support to adapter, use *that*.
-	o.DeleteHeader(w, "Content-Type")
+	if !hasContentType(entry.Metadata) {
+		// Delete the default content-type header so http.Server will detect it from contents
+		o.DeleteHeader(w, "Content-Type")
+	}
 }
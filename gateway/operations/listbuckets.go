@@ -3,6 +3,7 @@ package operations
 import (
 	"net/http"
 
+	"github.com/treeverse/lakefs/auth"
 	"github.com/treeverse/lakefs/permissions"
 
 	"github.com/treeverse/lakefs/gateway/errors"
@@ -24,17 +25,20 @@ func (controller *ListBuckets) Handle(w http.ResponseWriter, req *http.Request,
 	o.Incr("list_repos")
 	repos, _, err := o.Cataloger.ListRepositories(req.Context(), -1, "")
 	if err != nil {
-		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrInternalError))
+		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.FromError(err)))
 		return
 	}
 
 	// assemble response
-	buckets := make([]serde.Bucket, len(repos))
-	for i, repo := range repos {
-		buckets[i] = serde.Bucket{
+	buckets := make([]serde.Bucket, 0, len(repos))
+	for _, repo := range repos {
+		if !auth.InTenant(o.Tenant, repo.Name) {
+			continue
+		}
+		buckets = append(buckets, serde.Bucket{
 			CreationDate: serde.Timestamp(repo.CreationDate),
 			Name:         repo.Name,
-		}
+		})
 	}
 	// write response
 	o.EncodeResponse(w, req, serde.ListAllMyBucketsResult{
@@ -11,7 +11,6 @@ import (
 	gatewayerrors "github.com/treeverse/lakefs/gateway/errors"
 	"github.com/treeverse/lakefs/gateway/path"
 	"github.com/treeverse/lakefs/gateway/serde"
-	"github.com/treeverse/lakefs/httputil"
 	"github.com/treeverse/lakefs/logging"
 	"github.com/treeverse/lakefs/permissions"
 )
@@ -70,7 +69,7 @@ func (controller *ListObjects) serializeEntries(ref string, entries []*catalog.D
 			files = append(files, serde.Contents{
 				Key:          path.WithRef(entry.Path, ref),
 				LastModified: serde.Timestamp(entry.CreationDate),
-				ETag:         httputil.ETag(entry.Checksum),
+				ETag:         entryETag(entry),
 				Size:         entry.Size,
 				StorageClass: "STANDARD",
 			})
@@ -119,6 +118,7 @@ func (controller *ListObjects) ListV2(w http.ResponseWriter, req *http.Request,
 
 	var results []*catalog.DBEntry
 	var hasMore bool
+	var nextContinuationToken string
 	var ref string
 	// should we list branches?
 	prefix, err := path.ResolvePath(params.Get("prefix"))
@@ -139,7 +139,7 @@ func (controller *ListObjects) ListV2(w http.ResponseWriter, req *http.Request,
 		branches, hasMore, err := o.Cataloger.ListBranches(req.Context(), o.Repository.Name, branchPrefix, maxKeys, fromStr)
 		if err != nil {
 			o.Log(req).WithError(err).Error("could not list branches")
-			_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.ErrInternalError))
+			_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.FromError(err)))
 			return
 		}
 		// return branch response
@@ -168,28 +168,32 @@ func (controller *ListObjects) ListV2(w http.ResponseWriter, req *http.Request,
 	} else {
 		// list branches then.
 		ref = prefix.Ref
-		if len(fromStr) > 0 {
-			from, err = path.ResolvePath(fromStr)
+		// continuation token is opaque and pins the listed state, start-after is a path
+		token := continuationToken
+		if len(token) == 0 && len(startAfter) > 0 {
+			from, err = path.ResolvePath(startAfter)
 			if err != nil || !strings.EqualFold(from.Ref, prefix.Ref) {
 				o.Log(req).WithError(err).WithFields(logging.Fields{
 					"branch": prefix.Ref,
 					"path":   prefix.Path,
-					"from":   fromStr,
+					"from":   startAfter,
 				}).Error("invalid marker - doesnt start with branch name")
 				_ = o.EncodeError(w, req, gatewayerrors.Codes.ToAPIErr(gatewayerrors.ErrBadRequest))
 				return
 			}
+			token = from.Path
 		}
 
-		results, hasMore, err = o.Cataloger.ListEntries(
+		results, nextContinuationToken, err = o.Cataloger.ListEntriesWithToken(
 			req.Context(),
 			o.Repository.Name,
 			prefix.Ref,
 			prefix.Path,
-			from.Path,
+			token,
 			delimiter,
 			maxKeys,
 		)
+		hasMore = nextContinuationToken != ""
 		if errors.Is(err, catalog.ErrBranchNotFound) {
 			o.Log(req).WithError(err).WithFields(logging.Fields{
 				"ref":  prefix.Ref,
@@ -205,7 +209,7 @@ func (controller *ListObjects) ListV2(w http.ResponseWriter, req *http.Request,
 		}
 	}
 
-	dirs, files, lastKey := controller.serializeEntries(ref, results)
+	dirs, files, _ := controller.serializeEntries(ref, results)
 	resp := serde.ListObjectsV2Output{
 		Name:           o.Repository.Name,
 		Prefix:         params.Get("prefix"),
@@ -222,7 +226,7 @@ func (controller *ListObjects) ListV2(w http.ResponseWriter, req *http.Request,
 
 	if hasMore {
 		resp.IsTruncated = true
-		resp.NextContinuationToken = path.WithRef(lastKey, ref)
+		resp.NextContinuationToken = nextContinuationToken
 	}
 
 	o.EncodeResponse(w, req, resp, http.StatusOK)
@@ -367,15 +371,8 @@ func (controller *ListObjects) Handle(w http.ResponseWriter, req *http.Request,
 	// parse request parameters
 	// GET /example?list-type=2&prefix=master%2F&delimiter=%2F&encoding-type=url HTTP/1.1
 
-	// handle GET /?versioning
-	query := req.URL.Query()
-	if _, found := query["versioning"]; found {
-		o.EncodeXMLBytes(w, req, []byte(serde.VersioningResponse), http.StatusOK)
-		return
-	}
-
 	// handle ListObjects versions
-	listType := query.Get("list-type")
+	listType := req.URL.Query().Get("list-type")
 	switch listType {
 	case "", "1":
 		controller.ListV1(w, req, o)
@@ -0,0 +1,183 @@
+package operations
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/httputil"
+)
+
+const (
+	// UserMetadataHeaderPrefix starts the headers of the user metadata of objects.  The user
+	// metadata is kept in the metadata of the entry, under its lowercase header name.
+	UserMetadataHeaderPrefix = httputil.UserMetadataHeaderPrefix
+	// MaxUserMetadataSize is the maximal size of the names and values of the user metadata of
+	// an object, as in S3
+	MaxUserMetadataSize = httputil.MaxUserMetadataSize
+
+	MetadataDirectiveHeader  = "x-amz-metadata-directive"
+	MetadataDirectiveReplace = "REPLACE"
+
+	// CannedACLHeader sets the canned ACL of an uploaded object.  lakeFS does not enforce ACLs,
+	// access is managed by lakeFS policies, the canned ACL is only kept in the metadata of the
+	// entry under this key.
+	CannedACLHeader = "x-amz-acl"
+
+	// awsChunkedEncoding is the content encoding of the body of requests signed in chunks, it
+	// is not an encoding of the object
+	awsChunkedEncoding = "aws-chunked"
+)
+
+// cannedACLs are the canned ACLs accepted on upload
+var cannedACLs = map[string]struct{}{
+	"private":                   {},
+	"public-read":               {},
+	"public-read-write":         {},
+	"authenticated-read":        {},
+	"aws-exec-read":             {},
+	"bucket-owner-read":         {},
+	"bucket-owner-full-control": {},
+	"log-delivery-write":        {},
+}
+
+// cannedACLFromHeader returns the canned ACL set on header, empty if none is set.  It returns
+// false if the canned ACL is unknown.
+func cannedACLFromHeader(header http.Header) (string, bool) {
+	acl := header.Get(CannedACLHeader)
+	if acl == "" {
+		return "", true
+	}
+	_, ok := cannedACLs[acl]
+	return acl, ok
+}
+
+// userMetadataFromHeader returns the user metadata of the x-amz-meta-* headers of a request
+// together with its object headers such as Content-Type and its canned ACL, false if the user
+// metadata is larger than MaxUserMetadataSize
+func userMetadataFromHeader(header http.Header) (catalog.Metadata, bool) {
+	metadata := catalog.Metadata{}
+	size := 0
+	for name, values := range header {
+		key := strings.ToLower(name)
+		if !strings.HasPrefix(key, UserMetadataHeaderPrefix) || len(values) == 0 {
+			continue
+		}
+		value := strings.Join(values, ",")
+		metadata[key] = value
+		size += len(key) - len(UserMetadataHeaderPrefix) + len(value)
+	}
+	for k, v := range httputil.ObjectHeadersMetadata(header) {
+		metadata[k] = v
+	}
+	if acl := header.Get(CannedACLHeader); acl != "" {
+		metadata[CannedACLHeader] = acl
+	}
+	const contentEncodingKey = "content-encoding"
+	if encoding := objectContentEncoding(metadata[contentEncodingKey]); encoding != "" {
+		metadata[contentEncodingKey] = encoding
+	} else {
+		delete(metadata, contentEncodingKey)
+	}
+	return metadata, size <= MaxUserMetadataSize
+}
+
+// objectContentEncoding returns the content encoding of an object uploaded with Content-Encoding
+// contentEncoding, without the encoding of chunked signing
+func objectContentEncoding(contentEncoding string) string {
+	var encodings []string
+	for _, encoding := range strings.Split(contentEncoding, ",") {
+		encoding = strings.TrimSpace(encoding)
+		if encoding != "" && !strings.EqualFold(encoding, awsChunkedEncoding) {
+			encodings = append(encodings, encoding)
+		}
+	}
+	return strings.Join(encodings, ",")
+}
+
+// replaceUserMetadata returns metadata with its user metadata, object headers and canned ACL
+// replaced by those of userMetadata
+func replaceUserMetadata(metadata, userMetadata catalog.Metadata) catalog.Metadata {
+	res := catalog.Metadata{}
+	for k, v := range metadata {
+		if !httputil.IsObjectMetadataKey(k) && k != CannedACLHeader {
+			res[k] = v
+		}
+	}
+	for k, v := range userMetadata {
+		res[k] = v
+	}
+	return res
+}
+
+// replaceCannedACL returns metadata with its canned ACL replaced by acl, or removed if acl is
+// empty.  A copied object does not keep the canned ACL of its source, as in S3.
+func replaceCannedACL(metadata catalog.Metadata, acl string) catalog.Metadata {
+	res := catalog.Metadata{}
+	for k, v := range metadata {
+		if k != CannedACLHeader {
+			res[k] = v
+		}
+	}
+	if acl != "" {
+		res[CannedACLHeader] = acl
+	}
+	return res
+}
+
+// setUserMetadataHeaders sets the x-amz-meta-* headers of the user metadata of an object, and
+// the object headers set when it was uploaded
+func setUserMetadataHeaders(w http.ResponseWriter, metadata catalog.Metadata) {
+	for k, v := range metadata {
+		if strings.HasPrefix(k, UserMetadataHeaderPrefix) {
+			w.Header()[k] = []string{v}
+		}
+	}
+	for _, name := range httputil.ObjectHeaders {
+		if v := httputil.ObjectHeaderValue(metadata, name); v != "" {
+			w.Header().Set(name, v)
+		}
+	}
+}
+
+// hasContentType returns true if the Content-Type of an object was set when it was uploaded
+func hasContentType(metadata catalog.Metadata) bool {
+	return httputil.ObjectHeaderValue(metadata, "Content-Type") != ""
+}
+
+// completedUploadEntry returns the checksum and the metadata of the entry of a completed
+// multipart upload, from the ETag of the uploaded object and the metadata of the upload
+func completedUploadEntry(etag string, uploadMetadata catalog.Metadata) (string, catalog.Metadata) {
+	metadata := catalog.Metadata{}
+	for k, v := range uploadMetadata {
+		metadata[k] = v
+	}
+	etag = trimQuotes(etag)
+	checksum := checksumFromETag(etag)
+	if checksum != etag {
+		metadata[catalog.MetadataKeyPartsCount] = etag[len(checksum)+1:]
+	}
+	return checksum, metadata
+}
+
+// entryETag returns the quoted ETag of entry as S3 returns it.  The ETag of an object uploaded
+// in multiple parts ends with its number of parts, so that clients do not mistake it for the MD5
+// of the object.
+func entryETag(entry *catalog.DBEntry) string {
+	if parts := entry.Metadata[catalog.MetadataKeyPartsCount]; parts != "" {
+		return httputil.ETag(entry.Checksum + "-" + parts)
+	}
+	return httputil.ETag(entry.Checksum)
+}
+
+// checksumFromETag returns the checksum lakeFS keeps for the object of an ETag sent by a client
+func checksumFromETag(etag string) string {
+	etag = trimQuotes(etag)
+	if i := strings.LastIndexByte(etag, '-'); i >= 0 {
+		if _, err := strconv.Atoi(etag[i+1:]); err == nil {
+			return etag[:i]
+		}
+	}
+	return etag
+}
@@ -0,0 +1,220 @@
+package operations
+
+import (
+	"crypto/md5" //nolint:gosec
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/catalog"
+)
+
+var rcloneMD5ETag = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// rcloneMD5 returns the MD5 rclone's S3 backend derives for an object, as its Object.Hash does:
+// the ETag of the listing if it looks like an MD5, otherwise the base64 MD5 of the
+// X-Amz-Meta-Md5chksum header of a HEAD of the object, otherwise an unknown (empty) MD5
+func rcloneMD5(t *testing.T, listingETag string, headHeader http.Header) string {
+	t.Helper()
+	etag := strings.Trim(strings.ToLower(listingETag), `"`)
+	if rcloneMD5ETag.MatchString(etag) {
+		return etag
+	}
+	md5sum := headHeader.Get("X-Amz-Meta-Md5chksum")
+	if md5sum == "" {
+		return ""
+	}
+	md5sumBytes, err := base64.StdEncoding.DecodeString(md5sum)
+	if err != nil {
+		t.Fatalf("decode md5chksum %s: %s", md5sum, err)
+	}
+	return hex.EncodeToString(md5sumBytes)
+}
+
+// headHeader returns the headers HEAD sets for the metadata of entry
+func headHeader(entry *catalog.DBEntry) http.Header {
+	w := httptest.NewRecorder()
+	setUserMetadataHeaders(w, entry.Metadata)
+	// clients parse headers into their canonical form
+	header := http.Header{}
+	for k, v := range w.Header() {
+		header[http.CanonicalHeaderKey(k)] = v
+	}
+	return header
+}
+
+func TestRcloneChecksums(t *testing.T) {
+	data := []byte(strings.Repeat("lakeFS", 1000))
+	contentMD5 := md5.Sum(data)                          //nolint:gosec
+	part1 := md5.Sum(data[:3000])                        //nolint:gosec
+	part2 := md5.Sum(data[3000:])                        //nolint:gosec
+	md5OfParts := md5.Sum(append(part1[:], part2[:]...)) //nolint:gosec
+	multipartETag := `"` + hex.EncodeToString(md5OfParts[:]) + `-2"`
+
+	tests := []struct {
+		name string
+		// uploadHeader are the headers rclone sends when it creates the multipart upload
+		uploadHeader http.Header
+		expectedMD5  string
+	}{
+		{
+			name:         "with md5chksum",
+			uploadHeader: http.Header{"X-Amz-Meta-Md5chksum": {base64.StdEncoding.EncodeToString(contentMD5[:])}, "X-Amz-Meta-Mtime": {"1614556800.5"}},
+			expectedMD5:  hex.EncodeToString(contentMD5[:]),
+		},
+		{
+			// rclone skips comparing hashes it does not know, it must not get the MD5 of the parts
+			name:         "without md5chksum",
+			uploadHeader: http.Header{},
+			expectedMD5:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uploadMetadata, ok := userMetadataFromHeader(tt.uploadHeader)
+			if !ok {
+				t.Fatal("user metadata too large")
+			}
+			checksum, metadata := completedUploadEntry(multipartETag, uploadMetadata)
+			entry := &catalog.DBEntry{Checksum: checksum, Metadata: metadata, Size: int64(len(data))}
+			listingETag := entryETag(entry)
+			if listingETag != multipartETag {
+				t.Errorf("listing ETag %s, expected the ETag of the upload %s", listingETag, multipartETag)
+			}
+			if got := rcloneMD5(t, listingETag, headHeader(entry)); got != tt.expectedMD5 {
+				t.Errorf("rclone MD5 %s, expected %s", got, tt.expectedMD5)
+			}
+			if mtime := headHeader(entry).Get("X-Amz-Meta-Mtime"); mtime != tt.uploadHeader.Get("X-Amz-Meta-Mtime") {
+				t.Errorf("mtime metadata %s, expected %s", mtime, tt.uploadHeader.Get("X-Amz-Meta-Mtime"))
+			}
+		})
+	}
+
+	t.Run("single part", func(t *testing.T) {
+		entry := &catalog.DBEntry{Checksum: hex.EncodeToString(contentMD5[:]), Metadata: catalog.Metadata{}}
+		if got := rcloneMD5(t, entryETag(entry), headHeader(entry)); got != hex.EncodeToString(contentMD5[:]) {
+			t.Errorf("rclone MD5 %s, expected the MD5 of the content", got)
+		}
+	})
+}
+
+func TestUserMetadataFromHeader(t *testing.T) {
+	header := http.Header{
+		"X-Amz-Meta-Mtime": {"1614556800"},
+		"X-Amz-Meta-Tags":  {"a", "b"},
+		"Content-Type":     {"text/plain"},
+	}
+	metadata, ok := userMetadataFromHeader(header)
+	if !ok {
+		t.Fatal("userMetadataFromHeader() reported metadata too large")
+	}
+	expected := catalog.Metadata{"x-amz-meta-mtime": "1614556800", "x-amz-meta-tags": "a,b", "content-type": "text/plain"}
+	if diff := deep.Equal(metadata, expected); diff != nil {
+		t.Errorf("userMetadataFromHeader() diff %s", diff)
+	}
+
+	// the encoding of chunked signing is not kept
+	for contentEncoding, expected := range map[string]string{"aws-chunked": "", "aws-chunked,gzip": "gzip", "gzip": "gzip"} {
+		metadata, _ := userMetadataFromHeader(http.Header{"Content-Encoding": {contentEncoding}})
+		if got, ok := metadata["content-encoding"]; got != expected || ok != (expected != "") {
+			t.Errorf("userMetadataFromHeader() with Content-Encoding %s kept %s, expected %s", contentEncoding, got, expected)
+		}
+	}
+
+	header.Set("X-Amz-Meta-Large", strings.Repeat("x", MaxUserMetadataSize))
+	if _, ok := userMetadataFromHeader(header); ok {
+		t.Error("userMetadataFromHeader() accepted metadata larger than the maximum")
+	}
+}
+
+func TestReplaceUserMetadata(t *testing.T) {
+	metadata := catalog.Metadata{
+		"x-amz-meta-mtime":            "1",
+		"x-amz-meta-old":              "old",
+		catalog.MetadataKeyPartsCount: "3",
+	}
+	got := replaceUserMetadata(metadata, catalog.Metadata{"x-amz-meta-mtime": "2"})
+	expected := catalog.Metadata{"x-amz-meta-mtime": "2", catalog.MetadataKeyPartsCount: "3"}
+	if diff := deep.Equal(got, expected); diff != nil {
+		t.Errorf("replaceUserMetadata() diff %s", diff)
+	}
+	if metadata["x-amz-meta-mtime"] != "1" {
+		t.Error("replaceUserMetadata() changed its input")
+	}
+
+	metadata = catalog.Metadata{"content-type": "text/plain", "cache-control": "no-cache"}
+	got = replaceUserMetadata(metadata, catalog.Metadata{"content-type": "application/json"})
+	if diff := deep.Equal(got, catalog.Metadata{"content-type": "application/json"}); diff != nil {
+		t.Errorf("replaceUserMetadata() of object headers diff %s", diff)
+	}
+}
+
+func TestCannedACL(t *testing.T) {
+	for acl, expectedOK := range map[string]bool{"": true, "private": true, "bucket-owner-full-control": true, "public": false} {
+		got, ok := cannedACLFromHeader(http.Header{"X-Amz-Acl": {acl}})
+		if got != acl || ok != expectedOK {
+			t.Errorf("cannedACLFromHeader(%s) = %s, %t, expected %t", acl, got, ok, expectedOK)
+		}
+	}
+
+	metadata, _ := userMetadataFromHeader(http.Header{"X-Amz-Acl": {"private"}, "X-Amz-Meta-Owner": {"me"}})
+	expected := catalog.Metadata{CannedACLHeader: "private", "x-amz-meta-owner": "me"}
+	if diff := deep.Equal(metadata, expected); diff != nil {
+		t.Errorf("userMetadataFromHeader() with canned ACL diff %s", diff)
+	}
+	if got := headHeader(&catalog.DBEntry{Metadata: metadata}); got.Get(CannedACLHeader) != "" {
+		t.Errorf("canned ACL returned in headers of object %v", got)
+	}
+	if got := replaceUserMetadata(metadata, catalog.Metadata{}); len(got) != 0 {
+		t.Errorf("replaceUserMetadata() kept %v", got)
+	}
+
+	got := replaceCannedACL(metadata, "")
+	if diff := deep.Equal(got, catalog.Metadata{"x-amz-meta-owner": "me"}); diff != nil {
+		t.Errorf("replaceCannedACL() removing the canned ACL diff %s", diff)
+	}
+	got = replaceCannedACL(metadata, "public-read")
+	if got[CannedACLHeader] != "public-read" || metadata[CannedACLHeader] != "private" {
+		t.Errorf("replaceCannedACL() = %v from %v, expected public-read", got, metadata)
+	}
+}
+
+func TestSetUserMetadataHeaders(t *testing.T) {
+	header, _ := userMetadataFromHeader(http.Header{
+		"X-Amz-Meta-Owner":    {"me"},
+		"Content-Type":        {"application/json"},
+		"Content-Encoding":    {"gzip"},
+		"Cache-Control":       {"max-age=60"},
+		"Content-Disposition": {`attachment; filename="data.json"`},
+	})
+	got := headHeader(&catalog.DBEntry{Metadata: header})
+	expected := http.Header{
+		"X-Amz-Meta-Owner":    {"me"},
+		"Content-Type":        {"application/json"},
+		"Content-Encoding":    {"gzip"},
+		"Cache-Control":       {"max-age=60"},
+		"Content-Disposition": {`attachment; filename="data.json"`},
+	}
+	if diff := deep.Equal(got, expected); diff != nil {
+		t.Errorf("headers of object diff %s", diff)
+	}
+}
+
+func TestChecksumFromETag(t *testing.T) {
+	tests := map[string]string{
+		`"d41d8cd98f00b204e9800998ecf8427e"`:    "d41d8cd98f00b204e9800998ecf8427e",
+		`"d41d8cd98f00b204e9800998ecf8427e-12"`: "d41d8cd98f00b204e9800998ecf8427e",
+		"d41d8cd98f00b204e9800998ecf8427e-12":   "d41d8cd98f00b204e9800998ecf8427e",
+		"not-a-count":                           "not-a-count",
+	}
+	for etag, expected := range tests {
+		if got := checksumFromETag(etag); got != expected {
+			t.Errorf("checksumFromETag(%s) = %s, expected %s", etag, got, expected)
+		}
+	}
+}
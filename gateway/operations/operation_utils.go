@@ -1,26 +1,88 @@
 package operations
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
+	"github.com/treeverse/lakefs/auth"
 	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/graveler"
 	"github.com/treeverse/lakefs/logging"
+	"github.com/treeverse/lakefs/permissions"
 )
 
-func (o *PathOperation) finishUpload(req *http.Request, checksum, physicalAddress string, size int64) error {
+var (
+	ErrLinkAccessDenied    = errors.New("access denied to linked object")
+	ErrDecryptAccessDenied = errors.New("access denied to encrypted object")
+)
+
+// authorizeLink authorizes the principal to read an object a link points to
+func (o *AuthorizedOperation) authorizeLink(repository, path string) error {
+	authResp, err := o.Auth.Authorize(&auth.AuthorizationRequest{
+		Username: o.Principal,
+		RequiredPermissions: []permissions.Permission{
+			{
+				Action:   permissions.ReadObjectAction,
+				Resource: permissions.ObjectArn(repository, path),
+			},
+		},
+	})
+	if err != nil || !authResp.Allowed {
+		return ErrLinkAccessDenied
+	}
+	return nil
+}
+
+// authorizeDecrypt authorizes the principal to read the decrypted data of an encrypted object
+func (o *PathOperation) authorizeDecrypt() error {
+	authResp, err := o.Auth.Authorize(&auth.AuthorizationRequest{
+		Username: o.Principal,
+		RequiredPermissions: []permissions.Permission{
+			{
+				Action:   permissions.DecryptObjectAction,
+				Resource: permissions.ObjectArn(o.Repository.Name, o.Path),
+			},
+		},
+	})
+	if err != nil || !authResp.Allowed {
+		return ErrDecryptAccessDenied
+	}
+	return nil
+}
+
+// mayWriteObject returns true if the principal may write the object of o
+func (o *PathOperation) mayWriteObject() bool {
+	authResp, err := o.Auth.Authorize(&auth.AuthorizationRequest{
+		Username: o.Principal,
+		RequiredPermissions: []permissions.Permission{
+			{
+				Action:   permissions.WriteObjectAction,
+				Resource: permissions.ObjectArn(o.Repository.Name, o.Path),
+			},
+		},
+	})
+	return err == nil && authResp.Error == nil && authResp.Allowed
+}
+
+func (o *PathOperation) finishUpload(req *http.Request, checksum, physicalAddress string, size int64, metadata catalog.Metadata, condition catalog.EntryCondition) error {
 	// write metadata
 	writeTime := time.Now()
 	entry := catalog.DBEntry{
 		Path:            o.Path,
 		PhysicalAddress: physicalAddress,
 		Checksum:        checksum,
-		Metadata:        nil, // TODO: Read whatever metadata came from the request headers/params and add here
+		Metadata:        metadata,
 		Size:            size,
 		CreationDate:    writeTime,
+		DirectoryMarker: size == 0 && catalog.IsDirectoryMarkerPath(o.Path),
 	}
 
-	err := o.Cataloger.CreateEntry(req.Context(), o.Repository.Name, o.Reference, entry)
+	err := o.Cataloger.CreateEntryIf(req.Context(), o.Repository.Name, o.Reference, entry, condition)
+	if isPreconditionFailed(err) {
+		o.Log(req).WithError(err).Debug("upload precondition failed")
+		return err
+	}
 	if err != nil {
 		o.Log(req).WithError(err).Error("could not update metadata")
 		return err
@@ -31,3 +93,13 @@ func (o *PathOperation) finishUpload(req *http.Request, checksum, physicalAddres
 	}).Debug("metadata update complete")
 	return nil
 }
+
+// isPreconditionFailed returns true if err is a failed precondition of writing an entry
+func isPreconditionFailed(err error) bool {
+	return errors.Is(err, graveler.ErrPreconditionFailed)
+}
+
+// isPathLeased returns true if err is writing an entry under the lease of another user
+func isPathLeased(err error) bool {
+	return errors.Is(err, catalog.ErrPathLeased)
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/catalog"
 	"github.com/treeverse/lakefs/gateway/errors"
 	"github.com/treeverse/lakefs/gateway/path"
 	"github.com/treeverse/lakefs/gateway/serde"
@@ -37,9 +38,28 @@ func (controller *PostObject) RequiredPermissions(_ *http.Request, repoID, _, pa
 
 func (controller *PostObject) HandleCreateMultipartUpload(w http.ResponseWriter, req *http.Request, o *PathOperation) {
 	o.Incr("create_mpu")
+	if _, encrypted := o.Encryptor.KeyID(o.Repository.Name, o.Path); encrypted {
+		// parts are written directly to the block adapter and cannot be encrypted
+		o.Log(req).Debug("multipart upload to encrypted path")
+		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ERRLakeFSNotSupported))
+		return
+	}
+	if _, ok := cannedACLFromHeader(req.Header); !ok {
+		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrInvalidCannedACL))
+		return
+	}
+	userMetadata, ok := userMetadataFromHeader(req.Header)
+	if !ok {
+		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrMetadataTooLarge))
+		return
+	}
 	uuidBytes := [16]byte(uuid.New())
 	objName := hex.EncodeToString(uuidBytes[:])
-	storageClass := StorageClassFromHeader(req.Header)
+	repoSettings, ok := o.repositorySettings(w, req)
+	if !ok {
+		return
+	}
+	storageClass := repoSettings.StorageClassOr(StorageClassFromHeader(req.Header))
 	opts := block.CreateMultiPartUploadOpts{StorageClass: storageClass}
 	uploadID, err := o.BlockStore.CreateMultiPartUpload(block.ObjectPointer{StorageNamespace: o.Repository.StorageNamespace, Identifier: objName}, req, opts)
 	if err != nil {
@@ -47,7 +67,7 @@ func (controller *PostObject) HandleCreateMultipartUpload(w http.ResponseWriter,
 		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrInternalError))
 		return
 	}
-	err = o.MultipartsTracker.Create(req.Context(), uploadID, o.Path, objName, time.Now())
+	err = o.MultipartsTracker.Create(req.Context(), uploadID, o.Path, objName, time.Now(), userMetadata)
 	if err != nil {
 		o.Log(req).WithError(err).Error("could not write multipart upload to DB")
 		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrInternalError))
@@ -97,17 +117,30 @@ func (controller *PostObject) HandleCompleteMultipartUpload(w http.ResponseWrite
 		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrInternalError))
 		return
 	}
-	ch := trimQuotes(*etag)
-	checksum := strings.Split(ch, "-")[0]
-	err = o.finishUpload(req, checksum, objName, size)
+	repoSettings, ok := o.repositorySettings(w, req)
+	if !ok {
+		return
+	}
+	if repoSettings.TooLarge(size) {
+		// the completed object is left unreferenced in the storage namespace
+		o.Log(req).WithField("size", size).Debug("object larger than the repository maximum object size")
+		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrEntityTooLarge))
+		return
+	}
+	checksum, metadata := completedUploadEntry(*etag, multiPart.Metadata)
+	err = o.finishUpload(req, checksum, objName, size, metadata, catalog.EntryCondition{})
 	if err != nil {
-		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrInternalError))
+		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.FromError(err)))
 		return
 	}
 	err = o.MultipartsTracker.Delete(req.Context(), uploadID)
 	if err != nil {
 		o.Log(req).WithError(err).Warn("could not delete multipart record")
 	}
+	event := o.uploadEvent(uploadID, 0, -1)
+	event.BytesStaged = size
+	event.PartsCompleted = len(MultipartList.Part)
+	o.Events.Publish(TopicUploadCompleted, event)
 
 	scheme := httputil.RequestScheme(req)
 	location := fmt.Sprintf("%s://%s.%s/%s/%s", scheme, o.Repository, o.FQDN, o.Reference, o.Path)
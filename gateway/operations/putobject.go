@@ -8,10 +8,9 @@ import (
 	"strings"
 	"time"
 
-	ghttp "github.com/treeverse/lakefs/gateway/http"
-
 	"github.com/treeverse/lakefs/block"
 	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/encryption"
 	"github.com/treeverse/lakefs/gateway/errors"
 	"github.com/treeverse/lakefs/gateway/path"
 	"github.com/treeverse/lakefs/gateway/serde"
@@ -26,6 +25,12 @@ const (
 	CopySourceRangeHeader = "x-amz-copy-source-range"
 	QueryParamUploadID    = "uploadId"
 	QueryParamPartNumber  = "partNumber"
+	IfNoneMatchHeader     = "If-None-Match"
+	IfMatchHeader         = "If-Match"
+	// AppendHeader set to "true" on a PUT request appends its body to the object instead of
+	// replacing it
+	AppendHeader     = "X-Lakefs-Append"
+	ObjectSizeHeader = "X-Lakefs-Object-Size"
 )
 
 type PutObject struct{}
@@ -39,6 +44,25 @@ func (controller *PutObject) RequiredPermissions(_ *http.Request, repoID, _, pat
 	}, nil
 }
 
+// entryConditionFromHeader returns the precondition of writing the object of a PUT request.
+// Only "If-None-Match: *" and "If-Match" of a single ETag are supported.
+func entryConditionFromHeader(header http.Header) (catalog.EntryCondition, bool) {
+	var condition catalog.EntryCondition
+	if ifNoneMatch := header.Get(IfNoneMatchHeader); ifNoneMatch != "" {
+		if ifNoneMatch != "*" {
+			return condition, false
+		}
+		condition.IfAbsent = true
+	}
+	if ifMatch := header.Get(IfMatchHeader); ifMatch != "" {
+		if ifMatch == "*" || strings.Contains(ifMatch, ",") {
+			return condition, false
+		}
+		condition.IfETagMatches = checksumFromETag(ifMatch)
+	}
+	return condition, true
+}
+
 func extractEntryFromCopyReq(w http.ResponseWriter, req *http.Request, o *PathOperation, copySource string) *catalog.DBEntry {
 	copySourceDecoded, err := url.QueryUnescape(copySource)
 	if err != nil {
@@ -69,13 +93,42 @@ func extractEntryFromCopyReq(w http.ResponseWriter, req *http.Request, o *PathOp
 
 func handleCopy(w http.ResponseWriter, req *http.Request, o *PathOperation, copySource string) {
 	o.Incr("copy_object")
+	condition, ok := entryConditionFromHeader(req.Header)
+	if !ok {
+		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrNotImplemented))
+		return
+	}
+	acl, ok := cannedACLFromHeader(req.Header)
+	if !ok {
+		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrInvalidCannedACL))
+		return
+	}
 	ent := extractEntryFromCopyReq(w, req, o, copySource)
 	if ent == nil {
 		return // operation already failed
 	}
 	ent.CreationDate = time.Now()
 	ent.Path = o.Path
-	err := o.Cataloger.CreateEntry(req.Context(), o.Repository.Name, o.Reference, *ent)
+	if strings.EqualFold(req.Header.Get(MetadataDirectiveHeader), MetadataDirectiveReplace) {
+		userMetadata, ok := userMetadataFromHeader(req.Header)
+		if !ok {
+			_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrMetadataTooLarge))
+			return
+		}
+		ent.Metadata = replaceUserMetadata(ent.Metadata, userMetadata)
+	} else {
+		ent.Metadata = replaceCannedACL(ent.Metadata, acl)
+	}
+	err := o.Cataloger.CreateEntryIf(req.Context(), o.Repository.Name, o.Reference, *ent, condition)
+	if isPreconditionFailed(err) {
+		o.Log(req).WithError(err).Debug("copy destination precondition failed")
+		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrPreconditionFailed))
+		return
+	}
+	if isPathLeased(err) {
+		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ERRLakeFSPathLeased))
+		return
+	}
 	if err != nil {
 		o.Log(req).WithError(err).Error("could not write copy destination")
 		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrInvalidCopyDest))
@@ -84,7 +137,7 @@ func handleCopy(w http.ResponseWriter, req *http.Request, o *PathOperation, copy
 
 	o.EncodeResponse(w, req, &serde.CopyObjectResult{
 		LastModified: serde.Timestamp(ent.CreationDate),
-		ETag:         fmt.Sprintf("\"%s\"", ent.Checksum),
+		ETag:         entryETag(ent),
 	}, http.StatusOK)
 }
 
@@ -122,6 +175,12 @@ func handleUploadPart(w http.ResponseWriter, req *http.Request, o *PathOperation
 		if ent == nil {
 			return // operation already failed
 		}
+		if encryption.IsEncrypted(ent.Metadata) {
+			// the copied part would hold the encrypted data without its data key
+			o.Log(req).WithField("copy_source", ent.Path).Debug("copy part from encrypted object")
+			_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ERRLakeFSNotSupported))
+			return
+		}
 
 		var etag string
 		src := block.ObjectPointer{
@@ -136,7 +195,7 @@ func handleUploadPart(w http.ResponseWriter, req *http.Request, o *PathOperation
 
 		if rang := req.Header.Get(CopySourceRangeHeader); rang != "" {
 			// if this is a copy part with a byte range:
-			parsedRange, parseErr := ghttp.ParseRange(rang, ent.Size)
+			parsedRange, parseErr := httputil.ParseRange(rang, ent.Size)
 			if parseErr != nil {
 				// invalid range will silently fallback to copying the entire object. ¯\_(ツ)_/¯
 				etag, err = o.BlockStore.UploadCopyPart(src, dst, uploadID, partNumber)
@@ -162,13 +221,26 @@ func handleUploadPart(w http.ResponseWriter, req *http.Request, o *PathOperation
 	}
 
 	byteSize := req.ContentLength
+	repoSettings, ok := o.repositorySettings(w, req)
+	if !ok {
+		return
+	}
+	if repoSettings.TooLarge(byteSize) {
+		o.Log(req).WithField("size", byteSize).Debug("part larger than the repository maximum object size")
+		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrEntityTooLarge))
+		return
+	}
+	event := o.uploadEvent(uploadID, partNumber, byteSize)
+	body := newProgressReader(req.Body, o, event)
 	etag, err := o.BlockStore.UploadPart(block.ObjectPointer{StorageNamespace: o.Repository.StorageNamespace, Identifier: multiPart.PhysicalAddress},
-		byteSize, req.Body, uploadID, partNumber)
+		byteSize, body, uploadID, partNumber)
 	if err != nil {
 		o.Log(req).WithError(err).Error("part " + partNumberStr + " upload failed")
 		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrInternalError))
 		return
 	}
+	event.BytesStaged = byteSize
+	o.Events.Publish(TopicUploadPartCompleted, event)
 	o.SetHeader(w, "ETag", etag)
 	w.WriteHeader(http.StatusOK)
 }
@@ -209,15 +281,84 @@ func (controller *PutObject) Handle(w http.ResponseWriter, req *http.Request, o
 		return
 	}
 
+	if strings.EqualFold(req.Header.Get(AppendHeader), "true") {
+		handleAppend(w, req, o)
+		return
+	}
+
 	// handle the upload itself
 	handlePut(w, req, o)
 }
 
+func handleAppend(w http.ResponseWriter, req *http.Request, o *PathOperation) {
+	o.Incr("append_object")
+	if req.Header.Get(IfNoneMatchHeader) != "" || req.Header.Get(IfMatchHeader) != "" {
+		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrNotImplemented))
+		return
+	}
+	repoSettings, ok := o.repositorySettings(w, req)
+	if !ok {
+		return
+	}
+	if repoSettings.TooLarge(req.ContentLength) {
+		o.Log(req).WithField("size", req.ContentLength).Debug("appended data larger than the repository maximum object size")
+		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrEntityTooLarge))
+		return
+	}
+	event := o.uploadEvent("", 0, req.ContentLength)
+	body := newProgressReader(req.Body, o, event)
+	entry, err := upload.AppendBlob(req.Context(), o.Cataloger, o.BlockStore, o.Encryptor, upload.AppendParams{
+		Repository:    o.Repository,
+		Branch:        o.Reference,
+		Path:          o.Path,
+		Body:          body,
+		ContentLength: req.ContentLength,
+		MaxSize:       repoSettings.MaxObjectSize,
+	})
+	if err != nil {
+		o.Log(req).WithError(err).Debug("could not append to object")
+		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.FromError(err)))
+		return
+	}
+	event.BytesStaged = req.ContentLength
+	o.Events.Publish(TopicUploadCompleted, event)
+	o.SetHeader(w, "ETag", httputil.ETag(entry.Checksum))
+	o.SetHeader(w, ObjectSizeHeader, strconv.FormatInt(entry.Size, 10))
+	w.WriteHeader(http.StatusOK)
+}
+
 func handlePut(w http.ResponseWriter, req *http.Request, o *PathOperation) {
 	o.Incr("put_object")
-	storageClass := StorageClassFromHeader(req.Header)
+	// check the precondition is supported before uploading, it is only evaluated once the
+	// entry is written
+	condition, ok := entryConditionFromHeader(req.Header)
+	if !ok {
+		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrNotImplemented))
+		return
+	}
+	repoSettings, ok := o.repositorySettings(w, req)
+	if !ok {
+		return
+	}
+	if repoSettings.TooLarge(req.ContentLength) {
+		o.Log(req).WithField("size", req.ContentLength).Debug("object larger than the repository maximum object size")
+		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrEntityTooLarge))
+		return
+	}
+	if _, ok := cannedACLFromHeader(req.Header); !ok {
+		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrInvalidCannedACL))
+		return
+	}
+	userMetadata, ok := userMetadataFromHeader(req.Header)
+	if !ok {
+		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrMetadataTooLarge))
+		return
+	}
+	storageClass := repoSettings.StorageClassOr(StorageClassFromHeader(req.Header))
 	opts := block.PutOpts{StorageClass: storageClass}
-	blob, err := upload.WriteBlob(o.BlockStore, o.Repository.StorageNamespace, req.Body, req.ContentLength, opts)
+	event := o.uploadEvent("", 0, req.ContentLength)
+	body := newProgressReader(req.Body, o, event)
+	blob, err := upload.WriteEncryptedBlob(req.Context(), o.Encryptor, o.Repository.Name, o.Path, o.BlockStore, o.Repository.StorageNamespace, body, req.ContentLength, opts)
 	if err != nil {
 		o.Log(req).WithError(err).Error("could not write request body to block adapter")
 		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrInternalError))
@@ -225,11 +366,17 @@ func handlePut(w http.ResponseWriter, req *http.Request, o *PathOperation) {
 	}
 
 	// write metadata
-	err = o.finishUpload(req, blob.Checksum, blob.PhysicalAddress, blob.Size)
+	err = o.finishUpload(req, blob.Checksum, blob.PhysicalAddress, blob.Size, replaceUserMetadata(blob.Metadata, userMetadata), condition)
+	if isPreconditionFailed(err) {
+		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrPreconditionFailed))
+		return
+	}
 	if err != nil {
-		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.ErrInternalError))
+		_ = o.EncodeError(w, req, errors.Codes.ToAPIErr(errors.FromError(err)))
 		return
 	}
+	event.BytesStaged = blob.Size
+	o.Events.Publish(TopicUploadCompleted, event)
 	o.SetHeader(w, "ETag", httputil.ETag(blob.Checksum))
 	w.WriteHeader(http.StatusOK)
 }
@@ -0,0 +1,59 @@
+package operations
+
+import "net/http"
+
+// TopicObjectRequest is published when a request on an object path completes
+const TopicObjectRequest = "gateway.object_request"
+
+// ObjectRequestEvent is the payload of TopicObjectRequest
+type ObjectRequestEvent struct {
+	Repository string `json:"repository"`
+	Reference  string `json:"reference"`
+	Path       string `json:"path"`
+	Operation  string `json:"operation"`
+	Principal  string `json:"principal"`
+	StatusCode int    `json:"status_code"`
+	// BytesIn and BytesOut are the sizes of the request and response bodies
+	BytesIn  int64 `json:"bytes_in"`
+	BytesOut int64 `json:"bytes_out"`
+}
+
+// CountingResponseWriter records the status code and body size of a response
+type CountingResponseWriter struct {
+	http.ResponseWriter
+	StatusCode   int
+	BytesWritten int64
+}
+
+func NewCountingResponseWriter(w http.ResponseWriter) *CountingResponseWriter {
+	return &CountingResponseWriter{ResponseWriter: w, StatusCode: http.StatusOK}
+}
+
+func (w *CountingResponseWriter) WriteHeader(statusCode int) {
+	w.StatusCode = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *CountingResponseWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.BytesWritten += int64(n)
+	return n, err
+}
+
+// PublishRequest publishes the TopicObjectRequest event of o served through w
+func (o *PathOperation) PublishRequest(req *http.Request, w *CountingResponseWriter) {
+	var bytesIn int64
+	if req.ContentLength > 0 {
+		bytesIn = req.ContentLength
+	}
+	o.Events.Publish(TopicObjectRequest, ObjectRequestEvent{
+		Repository: o.Repository.Name,
+		Reference:  o.Reference,
+		Path:       o.Path,
+		Operation:  string(o.OperationID),
+		Principal:  o.Principal,
+		StatusCode: w.StatusCode,
+		BytesIn:    bytesIn,
+		BytesOut:   w.BytesWritten,
+	})
+}
@@ -0,0 +1,78 @@
+package operations
+
+import (
+	"io"
+)
+
+// Upload event topics published on the events bus
+const (
+	// TopicUploadProgress is published every UploadProgressInterval bytes staged by an upload
+	TopicUploadProgress = "gateway.upload.progress"
+	// TopicUploadPartCompleted is published when a part of a multipart upload is stored
+	TopicUploadPartCompleted = "gateway.upload.part_completed"
+	// TopicUploadCompleted is published when an object upload is staged on its branch
+	TopicUploadCompleted = "gateway.upload.completed"
+)
+
+// UploadProgressInterval is the number of bytes read between TopicUploadProgress events
+const UploadProgressInterval = 64 * 1024 * 1024
+
+// UploadEvent is the payload of the upload topics
+type UploadEvent struct {
+	Repository string `json:"repository"`
+	Reference  string `json:"reference"`
+	Path       string `json:"path"`
+	// UploadID and PartNumber are set for multipart uploads
+	UploadID   string `json:"upload_id,omitempty"`
+	PartNumber int64  `json:"part_number,omitempty"`
+	// ContentLength is the size of the request body, -1 if unknown
+	ContentLength int64 `json:"content_length"`
+	// BytesStaged is the number of bytes of this request stored so far, or of the whole object
+	// when the upload completes
+	BytesStaged int64 `json:"bytes_staged"`
+	// PartsCompleted is the number of parts of a completed multipart upload
+	PartsCompleted int `json:"parts_completed,omitempty"`
+}
+
+func (o *PathOperation) uploadEvent(uploadID string, partNumber int64, contentLength int64) UploadEvent {
+	return UploadEvent{
+		Repository:    o.Repository.Name,
+		Reference:     o.Reference,
+		Path:          o.Path,
+		UploadID:      uploadID,
+		PartNumber:    partNumber,
+		ContentLength: contentLength,
+	}
+}
+
+// progressReader publishes TopicUploadProgress events while reading an upload body
+type progressReader struct {
+	r          io.Reader
+	o          *PathOperation
+	event      UploadEvent
+	nextReport int64
+}
+
+func newProgressReader(r io.Reader, o *PathOperation, event UploadEvent) io.Reader {
+	if o.Events == nil {
+		return r
+	}
+	return &progressReader{
+		r:          r,
+		o:          o,
+		event:      event,
+		nextReport: UploadProgressInterval,
+	}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.event.BytesStaged += int64(n)
+	if p.event.BytesStaged >= p.nextReport {
+		p.o.Events.Publish(TopicUploadProgress, p.event)
+		for p.nextReport <= p.event.BytesStaged {
+			p.nextReport += UploadProgressInterval
+		}
+	}
+	return n, err
+}
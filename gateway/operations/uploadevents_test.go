@@ -0,0 +1,51 @@
+package operations
+
+import (
+	"bytes"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/events"
+)
+
+func TestProgressReader(t *testing.T) {
+	bus := events.NewBus()
+	var mu sync.Mutex
+	var staged []int64
+	cancel := bus.Subscribe(TopicUploadProgress, func(e events.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		staged = append(staged, e.Payload.(UploadEvent).BytesStaged)
+	})
+	defer cancel()
+
+	o := &PathOperation{
+		RefOperation: &RefOperation{
+			RepoOperation: &RepoOperation{
+				AuthorizedOperation: &AuthorizedOperation{Operation: &Operation{Events: bus}},
+				Repository:          &catalog.Repository{Name: "repo1"},
+			},
+			Reference: "master",
+		},
+		Path: "data/file1",
+	}
+	const size = 2*UploadProgressInterval + 100
+	r := newProgressReader(bytes.NewReader(make([]byte, size)), o, o.uploadEvent("", 0, size))
+	n, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(n) != size {
+		t.Fatalf("read %d bytes, expected %d", len(n), size)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(staged) != 2 || staged[0] < UploadProgressInterval || staged[1] < 2*UploadProgressInterval {
+		t.Fatalf("progress events at %v, expected one every %d bytes", staged, UploadProgressInterval)
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/treeverse/lakefs/gateway"
 	"github.com/treeverse/lakefs/gateway/multiparts"
 	"github.com/treeverse/lakefs/gateway/simulator"
+	"github.com/treeverse/lakefs/httputil"
 	"github.com/treeverse/lakefs/logging"
 	"github.com/treeverse/lakefs/stats"
 	"github.com/treeverse/lakefs/testutil"
@@ -146,6 +147,10 @@ func getBasicHandler(t *testing.T, authService *simulator.PlayBackMockConf) (htt
 		authService.BareDomain,
 		&mockCollector{},
 		nil,
+		nil,
+		nil,
+		httputil.CacheParams{},
+		nil,
 	)
 
 	return handler, &dependencies{
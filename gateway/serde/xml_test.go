@@ -45,3 +45,41 @@ func TestMarshal(t *testing.T) {
 		t.Fatalf("expected a buckets array")
 	}
 }
+
+func TestMarshalLocationConstraint(t *testing.T) {
+	data, err := xml.Marshal(serde.LocationConstraint{Location: "eu-west-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<LocationConstraint xmlns="http://s3.amazonaws.com/doc/2006-03-01/">eu-west-1</LocationConstraint>`
+	if string(data) != expected {
+		t.Fatalf("expected %s, got %s", expected, data)
+	}
+}
+
+func TestMarshalAccessControlPolicy(t *testing.T) {
+	response := serde.AccessControlPolicy{
+		Owner: serde.Owner{DisplayName: "user1", ID: "user1"},
+		AccessControlList: serde.AccessControlList{
+			Grant: []serde.Grant{
+				{
+					Grantee: serde.Grantee{
+						XMLNS:       "http://www.w3.org/2001/XMLSchema-instance",
+						XMLXSI:      "CanonicalUser",
+						ID:          "user1",
+						DisplayName: "user1",
+					},
+					Permission: "FULL_CONTROL",
+				},
+			},
+		},
+	}
+	data, err := xml.Marshal(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="CanonicalUser">`
+	if !strings.Contains(string(data), expected) {
+		t.Fatalf("expected grantee %s, got %s", expected, data)
+	}
+}
@@ -26,6 +26,7 @@ type PlayBackMockConf struct {
 // a limited service interface for the gateway, used by simulation playback
 type GatewayAuthService interface {
 	GetCredentials(accessKey string) (*model.Credential, error)
+	MarkCredentialsUsed(accessKey string) error
 	GetUserByID(userID int) (*model.User, error)
 	Authorize(req *auth.AuthorizationRequest) (*auth.AuthorizationResponse, error)
 }
@@ -149,6 +150,10 @@ func (m *PlayBackMockConf) GetCredentials(accessKey string) (*model.Credential,
 	return aCred, nil
 }
 
+func (m *PlayBackMockConf) MarkCredentialsUsed(_ string) error {
+	return nil
+}
+
 func (m *PlayBackMockConf) GetUserByID(userID int) (*model.User, error) {
 	return &model.User{
 		CreatedAt: time.Now(),
@@ -0,0 +1,47 @@
+package gitexport
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Signature is the author or the committer of a git commit
+type Signature struct {
+	Name  string
+	Email string
+	When  time.Time
+}
+
+func (s Signature) String() string {
+	// git signatures cannot hold angle brackets or newlines in names and addresses
+	clean := strings.NewReplacer("<", "", ">", "", "\n", " ", "\r", " ")
+	return fmt.Sprintf("%s <%s> %d +0000", clean.Replace(s.Name), clean.Replace(s.Email), s.When.Unix())
+}
+
+// Commit is a git commit
+type Commit struct {
+	Tree      Hash
+	Parents   []Hash
+	Author    Signature
+	Committer Signature
+	Message   string
+}
+
+// WriteCommit stores commit and returns its hash
+func (r *Repository) WriteCommit(commit *Commit) (Hash, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "tree %s\n", commit.Tree)
+	for _, parent := range commit.Parents {
+		fmt.Fprintf(&buf, "parent %s\n", parent)
+	}
+	fmt.Fprintf(&buf, "author %s\n", commit.Author)
+	fmt.Fprintf(&buf, "committer %s\n", commit.Committer)
+	buf.WriteByte('\n')
+	buf.WriteString(commit.Message)
+	if !strings.HasSuffix(commit.Message, "\n") {
+		buf.WriteByte('\n')
+	}
+	return r.writeObject("commit", buf.Bytes())
+}
@@ -0,0 +1,213 @@
+package gitexport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/treeverse/lakefs/catalog"
+)
+
+var (
+	ErrNoCommits     = errors.New("ref has no commits")
+	ErrIncompleteLog = errors.New("commit log misses a parent")
+)
+
+// Catalog is the history read by the exporter, implemented by catalog.Cataloger
+type Catalog interface {
+	WalkCommitLog(ctx context.Context, repository, reference string, firstParent bool, walkFn func(*catalog.CommitLog) error) error
+	ListEntries(ctx context.Context, repository, reference string, prefix, after string, delimiter string, limit int) ([]*catalog.DBEntry, bool, error)
+}
+
+// Result summarizes an export
+type Result struct {
+	// Head is the git commit of the exported ref
+	Head Hash
+	// Commits is the number of exported commits
+	Commits int
+	// SkippedPaths are the paths git cannot hold, by lakeFS commit
+	SkippedPaths map[string][]string
+}
+
+// Exporter exports the history of lakeFS refs to a git repository
+type Exporter struct {
+	catalog Catalog
+	repo    *Repository
+	// commits maps exported lakeFS commit IDs to their git commits
+	commits map[string]Hash
+	// trees maps the MetaRanges of exported commits to their git trees
+	trees map[string]Hash
+}
+
+func NewExporter(c Catalog, repo *Repository) *Exporter {
+	return &Exporter{
+		catalog: c,
+		repo:    repo,
+		commits: make(map[string]Hash),
+		trees:   make(map[string]Hash),
+	}
+}
+
+// PointerFile returns the content of the git file of entry
+func PointerFile(entry *catalog.DBEntry) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "address %s\n", entry.PhysicalAddress)
+	fmt.Fprintf(&b, "etag %s\n", entry.Checksum)
+	fmt.Fprintf(&b, "size %d\n", entry.Size)
+	if entry.LinkTarget != "" {
+		fmt.Fprintf(&b, "link %s\n", entry.LinkTarget)
+	}
+	return []byte(b.String())
+}
+
+// Export exports the history of reference in repository, and points the git branch at it
+func (e *Exporter) Export(ctx context.Context, repository, reference, branch string) (*Result, error) {
+	var log []*catalog.CommitLog
+	err := e.catalog.WalkCommitLog(ctx, repository, reference, false, func(commit *catalog.CommitLog) error {
+		log = append(log, commit)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk commit log: %w", err)
+	}
+	if len(log) == 0 {
+		return nil, fmt.Errorf("ref %s: %w", reference, ErrNoCommits)
+	}
+	byID := make(map[string]*catalog.CommitLog, len(log))
+	for _, commit := range log {
+		byID[commit.Reference] = commit
+	}
+
+	res := &Result{SkippedPaths: make(map[string][]string)}
+	// export parents before their children, without recursing over long histories
+	stack := []string{log[0].Reference}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		if _, ok := e.commits[id]; ok {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		commit, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("commit %s of %s: %w", id, reference, ErrIncompleteLog)
+		}
+		pending := false
+		for _, parent := range commit.Parents {
+			if _, ok := e.commits[parent]; !ok {
+				stack = append(stack, parent)
+				pending = true
+			}
+		}
+		if pending {
+			continue
+		}
+		stack = stack[:len(stack)-1]
+		h, skipped, err := e.exportCommit(ctx, repository, commit)
+		if err != nil {
+			return nil, fmt.Errorf("export commit %s: %w", id, err)
+		}
+		e.commits[id] = h
+		res.Commits++
+		if len(skipped) > 0 {
+			res.SkippedPaths[id] = skipped
+		}
+	}
+
+	res.Head = e.commits[log[0].Reference]
+	if err := e.repo.SetRef("refs/heads/"+branch, res.Head); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (e *Exporter) exportCommit(ctx context.Context, repository string, commit *catalog.CommitLog) (Hash, []string, error) {
+	tree, skipped, err := e.exportTree(ctx, repository, commit)
+	if err != nil {
+		return Hash{}, nil, err
+	}
+	parents := make([]Hash, len(commit.Parents))
+	for i, parent := range commit.Parents {
+		parents[i] = e.commits[parent]
+	}
+	h, err := e.repo.WriteCommit(&Commit{
+		Tree:    tree,
+		Parents: parents,
+		Author: Signature{
+			Name:  commit.Author,
+			Email: commit.AuthorEmail,
+			When:  commit.AuthorDate,
+		},
+		Committer: Signature{
+			Name:  commit.Committer,
+			Email: commit.CommitterEmail,
+			When:  commit.CreationDate,
+		},
+		Message: commitMessage(commit),
+	})
+	return h, skipped, err
+}
+
+// commitMessage is the message of the lakeFS commit, with its ID and metadata as trailers
+func commitMessage(commit *catalog.CommitLog) string {
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(commit.Message, "\n"))
+	b.WriteString("\n\n")
+	fmt.Fprintf(&b, "Lakefs-Commit-Id: %s\n", commit.Reference)
+	keys := make([]string, 0, len(commit.Metadata))
+	for k := range commit.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	oneLine := strings.NewReplacer("\n", " ", "\r", " ")
+	for _, k := range keys {
+		fmt.Fprintf(&b, "Lakefs-Metadata: %s=%s\n", oneLine.Replace(k), oneLine.Replace(commit.Metadata[k]))
+	}
+	return b.String()
+}
+
+// exportTree writes the tree of the entries of commit, commits with the same MetaRange share it
+func (e *Exporter) exportTree(ctx context.Context, repository string, commit *catalog.CommitLog) (Hash, []string, error) {
+	if h, ok := e.trees[commit.MetaRangeID]; ok && commit.MetaRangeID != "" {
+		return h, nil, nil
+	}
+	tree := NewTree()
+	var skipped []string
+	after := ""
+	for {
+		entries, hasMore, err := e.catalog.ListEntries(ctx, repository, commit.Reference, "", after, "", catalog.ListEntriesLimitMax)
+		if err != nil {
+			return Hash{}, nil, fmt.Errorf("list entries: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.DirectoryMarker {
+				continue
+			}
+			pointer := PointerFile(entry)
+			err := tree.Add(entry.Path, BlobHash(pointer))
+			if errors.Is(err, ErrInvalidPath) || errors.Is(err, ErrPathConflict) {
+				skipped = append(skipped, entry.Path)
+				continue
+			}
+			if err != nil {
+				return Hash{}, nil, err
+			}
+			if _, err := e.repo.WriteBlob(pointer); err != nil {
+				return Hash{}, nil, err
+			}
+		}
+		if !hasMore || len(entries) == 0 {
+			break
+		}
+		after = entries[len(entries)-1].Path
+	}
+	h, err := tree.Write(e.repo)
+	if err != nil {
+		return Hash{}, nil, err
+	}
+	if commit.MetaRangeID != "" {
+		e.trees[commit.MetaRangeID] = h
+	}
+	return h, skipped, nil
+}
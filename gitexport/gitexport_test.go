@@ -0,0 +1,217 @@
+package gitexport_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/gitexport"
+)
+
+type fakeCatalog struct {
+	// log of the ref, newest first
+	log     []*catalog.CommitLog
+	entries map[string][]*catalog.DBEntry
+}
+
+func (f *fakeCatalog) WalkCommitLog(_ context.Context, _, _ string, _ bool, walkFn func(*catalog.CommitLog) error) error {
+	for _, commit := range f.log {
+		if err := walkFn(commit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeCatalog) ListEntries(_ context.Context, _, reference string, _, after string, _ string, limit int) ([]*catalog.DBEntry, bool, error) {
+	var res []*catalog.DBEntry
+	for _, entry := range f.entries[reference] {
+		if entry.Path > after {
+			res = append(res, entry)
+		}
+	}
+	if len(res) > limit {
+		return res[:limit], true, nil
+	}
+	return res, false, nil
+}
+
+// readObject returns the content of the loose object h of the repository at dir
+func readObject(t *testing.T, dir string, h gitexport.Hash) string {
+	t.Helper()
+	id := h.String()
+	compressed, err := ioutil.ReadFile(filepath.Join(dir, "objects", id[:2], id[2:]))
+	if err != nil {
+		t.Fatalf("read object %s: %s", id, err)
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("decompress object %s: %s", id, err)
+	}
+	data, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("decompress object %s: %s", id, err)
+	}
+	return string(data)
+}
+
+func TestRepository_WriteBlob(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := gitexport.InitRepository(dir, "main")
+	if err != nil {
+		t.Fatalf("InitRepository() error = %s", err)
+	}
+	h, err := repo.WriteBlob([]byte("hello\n"))
+	if err != nil {
+		t.Fatalf("WriteBlob() error = %s", err)
+	}
+	// as computed by "git hash-object"
+	const expected = "ce013625030ba8dba906f756967f9e9ca394464a"
+	if h.String() != expected {
+		t.Errorf("WriteBlob() = %s, expected %s", h, expected)
+	}
+	if content := readObject(t, dir, h); content != "blob 6\x00hello\n" {
+		t.Errorf("blob object %q", content)
+	}
+	head, err := ioutil.ReadFile(filepath.Join(dir, "HEAD"))
+	if err != nil || string(head) != "ref: refs/heads/main\n" {
+		t.Errorf("HEAD %q, error %v", head, err)
+	}
+	if err := repo.SetRef("refs/heads/../../escape", h); !errors.Is(err, gitexport.ErrInvalidRefName) {
+		t.Errorf("SetRef() of escaping ref error = %v, expected %s", err, gitexport.ErrInvalidRefName)
+	}
+	if _, err := gitexport.InitRepository(t.TempDir(), "a/../b"); !errors.Is(err, gitexport.ErrInvalidRefName) {
+		t.Errorf("InitRepository() with invalid branch error = %v, expected %s", err, gitexport.ErrInvalidRefName)
+	}
+}
+
+func TestTree(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := gitexport.InitRepository(dir, "main")
+	if err != nil {
+		t.Fatalf("InitRepository() error = %s", err)
+	}
+	empty, err := gitexport.NewTree().Write(repo)
+	if err != nil {
+		t.Fatalf("Write() of empty tree error = %s", err)
+	}
+	// the well known empty tree of git
+	if empty.String() != "4b825dc642cb6eb9a060e54bf8d69288fbee4904" {
+		t.Errorf("empty tree %s", empty)
+	}
+
+	blob, err := repo.WriteBlob([]byte("data"))
+	if err != nil {
+		t.Fatalf("WriteBlob() error = %s", err)
+	}
+	tree := gitexport.NewTree()
+	for _, path := range []string{"a", "a.b", "b/c"} {
+		if err := tree.Add(path, blob); err != nil {
+			t.Fatalf("Add(%s) error = %s", path, err)
+		}
+	}
+	for path, expectedErr := range map[string]error{
+		"a/b":      gitexport.ErrPathConflict,
+		"b":        gitexport.ErrPathConflict,
+		"x//y":     gitexport.ErrInvalidPath,
+		"dir/":     gitexport.ErrInvalidPath,
+		".git/x":   gitexport.ErrInvalidPath,
+		"x/../y":   gitexport.ErrInvalidPath,
+		"x/.GIT/y": gitexport.ErrInvalidPath,
+	} {
+		if err := tree.Add(path, blob); !errors.Is(err, expectedErr) {
+			t.Errorf("Add(%s) error = %v, expected %s", path, err, expectedErr)
+		}
+	}
+	root, err := tree.Write(repo)
+	if err != nil {
+		t.Fatalf("Write() error = %s", err)
+	}
+	content := readObject(t, dir, root)
+	// git orders "a.b" before the directory "b", and the directory after files named like it
+	var names []string
+	for _, entry := range strings.Split(content[strings.IndexByte(content, 0)+1:], "\x00")[:3] {
+		names = append(names, entry[strings.LastIndexByte(entry, ' ')+1:])
+	}
+	if diff := deep.Equal(names, []string{"a", "a.b", "b"}); diff != nil {
+		t.Errorf("tree entries diff %s", diff)
+	}
+}
+
+func TestExporter_Export(t *testing.T) {
+	created := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	commit := func(id, metaRangeID, message string, at time.Duration, parents ...string) *catalog.CommitLog {
+		return &catalog.CommitLog{
+			Reference:    id,
+			Committer:    "barak",
+			Message:      message,
+			CreationDate: created.Add(at),
+			MetaRangeID:  metaRangeID,
+			Parents:      parents,
+			Author:       "barak",
+			AuthorEmail:  "barak@example.com",
+			AuthorDate:   created.Add(at),
+			Metadata:     catalog.Metadata{"job": "etl"},
+		}
+	}
+	entry := func(path, address string) *catalog.DBEntry {
+		return &catalog.DBEntry{Path: path, PhysicalAddress: address, Checksum: "etag-" + address, Size: 10}
+	}
+	fake := &fakeCatalog{
+		log: []*catalog.CommitLog{
+			commit("c4", "r3", "merge", 3*time.Hour, "c2", "c3"),
+			commit("c3", "r2", "on branch", 2*time.Hour, "c1"),
+			commit("c2", "r1", "add", time.Hour, "c1"),
+			commit("c1", "", "Repository created", 0),
+		},
+		entries: map[string][]*catalog.DBEntry{
+			"c2": {entry("data/a", "addr1"), entry("data/b", "addr2")},
+			"c3": {entry("data/a", "addr1"), entry("data/bad//path", "addr3")},
+			"c4": {entry("data/a", "addr1"), entry("data/b", "addr2"), {Path: "dir/", DirectoryMarker: true}},
+		},
+	}
+	dir := t.TempDir()
+	repo, err := gitexport.InitRepository(dir, "main")
+	if err != nil {
+		t.Fatalf("InitRepository() error = %s", err)
+	}
+	res, err := gitexport.NewExporter(fake, repo).Export(context.Background(), "repo", "main", "main")
+	if err != nil {
+		t.Fatalf("Export() error = %s", err)
+	}
+	if res.Commits != 4 {
+		t.Errorf("Export() exported %d commits, expected 4", res.Commits)
+	}
+	if diff := deep.Equal(res.SkippedPaths, map[string][]string{"c3": {"data/bad//path"}}); diff != nil {
+		t.Errorf("Export() skipped paths diff %s", diff)
+	}
+	ref, err := ioutil.ReadFile(filepath.Join(dir, "refs", "heads", "main"))
+	if err != nil || string(ref) != res.Head.String()+"\n" {
+		t.Errorf("refs/heads/main %q, error %v, expected %s", ref, err, res.Head)
+	}
+	head := readObject(t, dir, res.Head)
+	if strings.Count(head, "\nparent ") != 2 {
+		t.Errorf("merge commit does not have 2 parents: %q", head)
+	}
+	for _, expected := range []string{
+		"\nauthor barak <barak@example.com> 1614610800 +0000\n",
+		"\ncommitter barak <> 1614610800 +0000\n",
+		"\n\nmerge\n\nLakefs-Commit-Id: c4\nLakefs-Metadata: job=etl\n",
+	} {
+		if !strings.Contains(head, expected) {
+			t.Errorf("merge commit %q does not contain %q", head, expected)
+		}
+	}
+	pointer := string(gitexport.PointerFile(entry("data/a", "addr1")))
+	if pointer != "address addr1\netag etag-addr1\nsize 10\n" {
+		t.Errorf("PointerFile() = %q", pointer)
+	}
+}
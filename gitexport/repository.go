@@ -0,0 +1,132 @@
+// Package gitexport exports the history of a lakeFS ref to a git repository of pointer files,
+// so git tooling such as log, blame and bisect can be used on the history of the data.  The
+// data itself is not copied: every object is a small text file with its address, ETag and size.
+package gitexport
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1" //nolint:gosec
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Hash is the ID of a git object
+type Hash [sha1.Size]byte
+
+func (h Hash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+// Repository is a bare git repository on the local filesystem.  Objects are written loose,
+// "git gc" packs them.
+type Repository struct {
+	dir string
+}
+
+const bareConfig = `[core]
+	repositoryformatversion = 0
+	filemode = true
+	bare = true
+`
+
+// InitRepository opens the bare git repository at dir, creating it if it does not exist.  HEAD
+// of a new repository names branch.
+func InitRepository(dir, branch string) (*Repository, error) {
+	if !validPath(branch) {
+		return nil, fmt.Errorf("branch %s: %w", branch, ErrInvalidRefName)
+	}
+	r := &Repository{dir: dir}
+	if _, err := os.Stat(filepath.Join(dir, "HEAD")); err == nil {
+		return r, nil
+	}
+	for _, sub := range []string{"objects/info", "objects/pack", "refs/heads", "refs/tags"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil { //nolint:gosec
+			return nil, fmt.Errorf("create %s: %w", sub, err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "config"), []byte(bareConfig), 0644); err != nil { //nolint:gosec
+		return nil, fmt.Errorf("write config: %w", err)
+	}
+	head := "ref: refs/heads/" + branch + "\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "HEAD"), []byte(head), 0644); err != nil { //nolint:gosec
+		return nil, fmt.Errorf("write HEAD: %w", err)
+	}
+	return r, nil
+}
+
+// encodeObject returns the hash and the uncompressed form of an object of type typ
+func encodeObject(typ string, content []byte) (Hash, []byte) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %d\x00", typ, len(content))
+	buf.Write(content)
+	return Hash(sha1.Sum(buf.Bytes())), buf.Bytes() //nolint:gosec
+}
+
+// BlobHash returns the hash of a file with data, without storing it
+func BlobHash(data []byte) Hash {
+	h, _ := encodeObject("blob", data)
+	return h
+}
+
+// writeObject stores an object of type typ unless it exists, and returns its hash
+func (r *Repository) writeObject(typ string, content []byte) (Hash, error) {
+	h, object := encodeObject(typ, content)
+	id := h.String()
+	path := filepath.Join(r.dir, "objects", id[:2], id[2:])
+	if _, err := os.Stat(path); err == nil {
+		return h, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil { //nolint:gosec
+		return h, fmt.Errorf("create object directory: %w", err)
+	}
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(object); err != nil {
+		return h, fmt.Errorf("compress object %s: %w", id, err)
+	}
+	if err := zw.Close(); err != nil {
+		return h, fmt.Errorf("compress object %s: %w", id, err)
+	}
+	// objects appear whole, or not at all
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "tmp_obj_")
+	if err != nil {
+		return h, fmt.Errorf("write object %s: %w", id, err)
+	}
+	_, err = tmp.Write(compressed.Bytes())
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return h, fmt.Errorf("write object %s: %w", id, err)
+	}
+	return h, nil
+}
+
+// WriteBlob stores a file with data and returns its hash
+func (r *Repository) WriteBlob(data []byte) (Hash, error) {
+	return r.writeObject("blob", data)
+}
+
+// SetRef points the ref name, such as "refs/heads/main", at h
+func (r *Repository) SetRef(name string, h Hash) error {
+	if !strings.HasPrefix(name, "refs/") || !validPath(name) {
+		return fmt.Errorf("%s: %w", name, ErrInvalidRefName)
+	}
+	path := filepath.Join(r.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil { //nolint:gosec
+		return fmt.Errorf("create ref directory: %w", err)
+	}
+	if err := ioutil.WriteFile(path, []byte(h.String()+"\n"), 0644); err != nil { //nolint:gosec
+		return fmt.Errorf("write ref %s: %w", name, err)
+	}
+	return nil
+}
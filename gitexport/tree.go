@@ -0,0 +1,118 @@
+package gitexport
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrInvalidPath    = errors.New("path cannot be a git path")
+	ErrPathConflict   = errors.New("path is both a file and a directory")
+	ErrInvalidRefName = errors.New("invalid ref name")
+	forbiddenSegments = map[string]struct{}{"": {}, ".": {}, "..": {}, ".git": {}}
+)
+
+const (
+	modeFile      = "100644"
+	modeDirectory = "40000"
+)
+
+type treeEntry struct {
+	blob     *Hash
+	children map[string]*treeEntry
+}
+
+// Tree collects the files of a commit, and writes them as git trees
+type Tree struct {
+	root treeEntry
+}
+
+func NewTree() *Tree {
+	return &Tree{root: treeEntry{children: make(map[string]*treeEntry)}}
+}
+
+// validPath reports whether every segment of the slash separated path is a valid git name
+func validPath(path string) bool {
+	for _, segment := range strings.Split(path, "/") {
+		if _, ok := forbiddenSegments[strings.ToLower(segment)]; ok || strings.ContainsRune(segment, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// Add adds the file of blob at path.  It returns ErrInvalidPath for paths git cannot hold, such
+// as paths with empty segments or a ".git" segment, and ErrPathConflict if path is also a
+// directory of another path.
+func (t *Tree) Add(path string, blob Hash) error {
+	if !validPath(path) {
+		return fmt.Errorf("%s: %w", path, ErrInvalidPath)
+	}
+	segments := strings.Split(path, "/")
+	dir := &t.root
+	for _, segment := range segments[:len(segments)-1] {
+		child, ok := dir.children[segment]
+		if !ok {
+			child = &treeEntry{children: make(map[string]*treeEntry)}
+			dir.children[segment] = child
+		} else if child.blob != nil {
+			return fmt.Errorf("%s: %w", path, ErrPathConflict)
+		}
+		dir = child
+	}
+	name := segments[len(segments)-1]
+	if existing, ok := dir.children[name]; ok && existing.children != nil {
+		return fmt.Errorf("%s: %w", path, ErrPathConflict)
+	}
+	dir.children[name] = &treeEntry{blob: &blob}
+	return nil
+}
+
+// Write writes the trees of t to r and returns the hash of the root tree
+func (t *Tree) Write(r *Repository) (Hash, error) {
+	return writeTree(r, t.root.children)
+}
+
+// gitTreeName is the name by which git orders tree entries, directories sort as if their name
+// ended with a slash
+func gitTreeName(name string, entry *treeEntry) string {
+	if entry.blob == nil {
+		return name + "/"
+	}
+	return name
+}
+
+func writeTree(r *Repository, children map[string]*treeEntry) (Hash, error) {
+	names := make([]string, 0, len(children))
+	for name := range children {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return gitTreeName(names[i], children[names[i]]) < gitTreeName(names[j], children[names[j]])
+	})
+	var buf bytes.Buffer
+	for _, name := range names {
+		entry := children[name]
+		mode := modeFile
+		var h Hash
+		if entry.blob != nil {
+			h = *entry.blob
+		} else {
+			var err error
+			h, err = writeTree(r, entry.children)
+			if err != nil {
+				return Hash{}, err
+			}
+			mode = modeDirectory
+		}
+		buf.WriteString(mode)
+		buf.WriteByte(' ')
+		buf.WriteString(name)
+		buf.WriteByte(0)
+		buf.Write(h[:])
+	}
+	return r.writeObject("tree", buf.Bytes())
+}
@@ -0,0 +1,86 @@
+package graveler
+
+import (
+	"context"
+)
+
+// stagingDumpIterator writes the staged values of a branch as MetaRange values.  A staged
+// deletion has no value, it is written as a value with no identity.
+type stagingDumpIterator struct {
+	ValueIterator
+	value *ValueRecord
+}
+
+func (it *stagingDumpIterator) Next() bool {
+	if !it.ValueIterator.Next() {
+		return false
+	}
+	v := it.ValueIterator.Value()
+	it.value = v
+	if v.Value == nil {
+		it.value = &ValueRecord{Key: v.Key, Value: &Value{}}
+	}
+	return true
+}
+
+func (it *stagingDumpIterator) Value() *ValueRecord {
+	return it.value
+}
+
+// DumpStaging dumps the uncommitted changes of branchID, staged deletions included
+func (g *Graveler) DumpStaging(ctx context.Context, repositoryID RepositoryID, branchID BranchID) (*MetaRangeID, error) {
+	repo, err := g.GetRepository(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	branch, err := g.GetBranch(ctx, repositoryID, branchID)
+	if err != nil {
+		return nil, err
+	}
+	it, err := g.StagingManager.List(ctx, branch.StagingToken)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+	return g.CommittedManager.WriteMetaRange(WithRangeSplitPolicy(ctx, repo.RangeSplit), repo.StorageNamespace, &stagingDumpIterator{ValueIterator: it}, Metadata{
+		EntityTypeKey: EntityTypeStaging,
+	})
+}
+
+// LoadStaging stages the uncommitted changes dumped by DumpStaging on branchID
+func (g *Graveler) LoadStaging(ctx context.Context, repositoryID RepositoryID, branchID BranchID, metaRangeID MetaRangeID) error {
+	repo, err := g.GetRepository(ctx, repositoryID)
+	if err != nil {
+		return err
+	}
+	branch, err := g.GetBranch(ctx, repositoryID, branchID)
+	if err != nil {
+		return err
+	}
+	it, err := g.CommittedManager.List(ctx, repo.StorageNamespace, metaRangeID)
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	for it.Next() {
+		v := it.Value()
+		value := v.Value
+		if len(value.Identity) == 0 {
+			value = nil
+		}
+		if err := g.StagingManager.Set(ctx, branch.StagingToken, v.Key.Copy(), value); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
+
+// DiffDumps returns the differences between two dumps of the same type, keyed by commit,
+// branch or tag ID, or by path for dumps of staging
+func (g *Graveler) DiffDumps(ctx context.Context, repositoryID RepositoryID, left, right MetaRangeID) (DiffIterator, error) {
+	repo, err := g.GetRepository(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	return g.CommittedManager.Diff(ctx, repo.StorageNamespace, left, right)
+}
@@ -0,0 +1,103 @@
+package graveler
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/treeverse/lakefs/ident"
+)
+
+// Commit IDs are content addresses: the hex encoded SHA-256 of the canonical form of the commit.
+// The canonical form is a sequence of typed values, each starting with a type byte:
+//
+//	string:        0x01, the length as int64, the bytes of the string as given (no normalization)
+//	int64:         0x02, 0x08, 8 bytes big endian two's complement
+//	string slice:  0x03, the number of items as int64, each item as string
+//	string map:    0x04, the number of entries as int64, each key then its value as string,
+//	               ordered by the bytes of the key
+//	embedded hash: 0x05, then as bytes (0x00, the length as int64, the bytes) the SHA-256 of
+//	               the canonical form of the embedded value
+//
+// A commit is the values, in this order:
+//
+//	string        the version, "commit:v1" or "commit:v2"
+//	string        Committer
+//	string        Message
+//	string        MetaRangeID
+//	int64         CreationDate as seconds since the Unix epoch, UTC
+//	string map    Metadata, an absent map is empty
+//	embedded hash Parents as a string slice, in order
+//
+// followed by these values for version "commit:v2" only:
+//
+//	string        CommitterEmail
+//	string        Author
+//	string        AuthorEmail
+//	int64         AuthorDate as seconds since the Unix epoch, 0 when absent
+//
+// Version "commit:v1" is used when CommitterEmail, Author, AuthorEmail and AuthorDate are all
+// empty, so that commits created before they existed keep their IDs.  Generation is derived
+// from the parents and is not part of the identity.  New fields must only be added under a new
+// version, existing IDs never change.
+const (
+	CommitIdentityV1 = "commit:v1"
+	CommitIdentityV2 = "commit:v2"
+)
+
+// hasSignatures returns true if the commit has committer or author details beyond the committer
+// name, commits without them keep their v1 identity
+func (c Commit) hasSignatures() bool {
+	return c.CommitterEmail != "" || c.Author != "" || c.AuthorEmail != "" || !c.AuthorDate.IsZero()
+}
+
+// IdentityVersion returns the version of the canonical form of the commit
+func (c Commit) IdentityVersion() string {
+	if c.hasSignatures() {
+		return CommitIdentityV2
+	}
+	return CommitIdentityV1
+}
+
+func (c Commit) marshal(b *ident.AddressWriter) *ident.AddressWriter {
+	version := c.IdentityVersion()
+	b.MarshalString(version)
+	b.MarshalString(c.Committer)
+	b.MarshalString(c.Message)
+	b.MarshalString(string(c.MetaRangeID))
+	b.MarshalInt64(c.CreationDate.Unix())
+	b.MarshalStringMap(c.Metadata)
+	b.MarshalIdentifiable(c.Parents)
+	if version == CommitIdentityV2 {
+		b.MarshalString(c.CommitterEmail)
+		b.MarshalString(c.Author)
+		b.MarshalString(c.AuthorEmail)
+		var authorDate int64
+		if !c.AuthorDate.IsZero() {
+			authorDate = c.AuthorDate.Unix()
+		}
+		b.MarshalInt64(authorDate)
+	}
+	return b
+}
+
+func (c Commit) Identity() []byte {
+	return c.marshal(ident.NewAddressWriter()).Identity()
+}
+
+// CanonicalForm returns the serialization of the commit hashed into its ID
+func (c Commit) CanonicalForm() []byte {
+	return c.marshal(ident.NewRecordingAddressWriter()).Serialized()
+}
+
+// ID returns the content address of the commit, the same on every installation and version
+func (c Commit) ID() CommitID {
+	return CommitID(hex.EncodeToString(c.Identity()))
+}
+
+// VerifyCommitID returns ErrInvalidCommitID if commitID is not the ID of commit
+func VerifyCommitID(commitID CommitID, commit Commit) error {
+	if expected := commit.ID(); commitID != expected {
+		return fmt.Errorf("commit %s has content of %s: %w", commitID, expected, ErrInvalidCommitID)
+	}
+	return nil
+}
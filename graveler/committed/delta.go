@@ -0,0 +1,314 @@
+package committed
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/logging"
+)
+
+const (
+	// MetadataDeltaBaseKey is the metadata key of a delta MetaRange holding the ID of the
+	// MetaRange its changes apply to.  MetaRanges without it hold all of their values.
+	MetadataDeltaBaseKey = "delta_base"
+	// MetadataDeltaDepthKey is the metadata key of a delta MetaRange holding the number of
+	// deltas down to the nearest full MetaRange, including itself.
+	MetadataDeltaDepthKey = "delta_depth"
+
+	DeltaResolveCacheSize   = 10000
+	DeltaResolveCacheExpiry = time.Hour
+	DeltaResolveCacheJitter = time.Minute
+)
+
+var ErrBadDeltaMetadata = errors.New("bad delta metarange metadata")
+
+// DeltaParams configure delta MetaRanges.  Apply writes a small set of changes as a delta: a
+// MetaRange holding only the changes and the ID of the MetaRange they change.  The full
+// MetaRange of a delta is materialized when it is first read, ranges are not rewritten for
+// commits that are never read in full.
+type DeltaParams struct {
+	// MaxChanges is the largest number of changes written as a delta.  Deltas are disabled
+	// when 0.
+	MaxChanges int
+	// MaxDepth is the longest chain of deltas over a full MetaRange, applying changes over a
+	// delta at this depth first materializes it.
+	MaxDepth int
+}
+
+// deltaTombstone marks a deleted key inside a delta, values of full MetaRanges always have an
+// identity
+var deltaTombstone = graveler.Value{Identity: []byte{}, Data: []byte{}}
+
+func isDeltaTombstone(v *graveler.Value) bool {
+	return v != nil && len(v.Identity) == 0
+}
+
+// DeltaResolutions persists the full MetaRange materialized for each delta MetaRange
+type DeltaResolutions interface {
+	// GetDeltaResolution returns the full MetaRange of deltaID, or graveler.ErrNotFound if it
+	// was never materialized
+	GetDeltaResolution(ctx context.Context, ns graveler.StorageNamespace, deltaID graveler.MetaRangeID) (graveler.MetaRangeID, error)
+	// SetDeltaResolution records metaRangeID as the full MetaRange of deltaID
+	SetDeltaResolution(ctx context.Context, ns graveler.StorageNamespace, deltaID, metaRangeID graveler.MetaRangeID) error
+}
+
+type deltaInfo struct {
+	base  graveler.MetaRangeID
+	depth int
+}
+
+type resolveKey struct {
+	ns graveler.StorageNamespace
+	id graveler.MetaRangeID
+}
+
+// getDeltaInfo returns the base and depth of the delta MetaRange id, or nil if id is a full
+// MetaRange
+func (c *committedManager) getDeltaInfo(ctx context.Context, ns graveler.StorageNamespace, id graveler.MetaRangeID) (*deltaInfo, error) {
+	if id == "" {
+		return nil, nil
+	}
+	metadata, err := c.metaRangeManager.GetMetadata(ctx, ns, id)
+	if err != nil {
+		return nil, err
+	}
+	base, ok := metadata[MetadataDeltaBaseKey]
+	if !ok {
+		return nil, nil
+	}
+	depth, err := strconv.Atoi(metadata[MetadataDeltaDepthKey])
+	if err != nil || depth < 1 {
+		return nil, fmt.Errorf("metarange %s depth %s: %w", id, metadata[MetadataDeltaDepthKey], ErrBadDeltaMetadata)
+	}
+	return &deltaInfo{base: graveler.MetaRangeID(base), depth: depth}, nil
+}
+
+// resolve returns the ID of the full MetaRange of id, materializing it when id is a delta
+func (c *committedManager) resolve(ctx context.Context, ns graveler.StorageNamespace, id graveler.MetaRangeID) (graveler.MetaRangeID, error) {
+	if id == "" {
+		return id, nil
+	}
+	// MetaRanges are immutable and content addressed, a delta always resolves to the same ID
+	res, err := c.resolved.GetOrSet(resolveKey{ns: ns, id: id}, func() (interface{}, error) {
+		info, err := c.getDeltaInfo(ctx, ns, id)
+		if err != nil || info == nil {
+			return id, err
+		}
+		if c.resolutions != nil {
+			resolvedID, err := c.resolutions.GetDeltaResolution(ctx, ns, id)
+			if err == nil {
+				return resolvedID, nil
+			}
+			if !errors.Is(err, graveler.ErrNotFound) {
+				return nil, fmt.Errorf("get delta resolution ns=%s id=%s: %w", ns, id, err)
+			}
+		}
+		base, err := c.resolve(ctx, ns, info.base)
+		if err != nil {
+			return nil, err
+		}
+		resolvedID, err := c.materialize(ctx, ns, id, base)
+		if err != nil {
+			return nil, err
+		}
+		if c.resolutions != nil {
+			// the delta is resolved regardless, other processes will materialize it again
+			if err := c.resolutions.SetDeltaResolution(ctx, ns, id, resolvedID); err != nil {
+				c.logger.WithError(err).WithFields(logging.Fields{
+					"storage_namespace": ns,
+					"meta_range_id":     id,
+					"resolved_id":       resolvedID,
+				}).Warn("Failed to record delta resolution")
+			}
+		}
+		return resolvedID, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return res.(graveler.MetaRangeID), nil
+}
+
+// materialize writes the full MetaRange of the changes of deltaID applied to the full MetaRange
+// baseID
+func (c *committedManager) materialize(ctx context.Context, ns graveler.StorageNamespace, deltaID, baseID graveler.MetaRangeID) (graveler.MetaRangeID, error) {
+	writer := c.metaRangeManager.NewWriter(ctx, ns, nil)
+	defer func() {
+		if err := writer.Abort(); err != nil {
+			c.logger.WithError(err).Error("Abort failed after materializing delta")
+		}
+	}()
+	baseIt, err := c.metaRangeManager.NewMetaRangeIterator(ctx, ns, baseID)
+	if err != nil {
+		return "", fmt.Errorf("get metarange ns=%s id=%s: %w", ns, baseID, err)
+	}
+	defer baseIt.Close()
+	deltaIt, err := c.metaRangeManager.NewMetaRangeIterator(ctx, ns, deltaID)
+	if err != nil {
+		return "", fmt.Errorf("get delta metarange ns=%s id=%s: %w", ns, deltaID, err)
+	}
+	changes := &deltaChangesIterator{ValueIterator: NewValueIterator(deltaIt)}
+	defer changes.Close()
+	if _, err := Apply(ctx, writer, baseIt, changes, &ApplyOptions{AllowEmpty: true}); err != nil {
+		return "", fmt.Errorf("materialize delta ns=%s id=%s: %w", ns, deltaID, err)
+	}
+	newID, err := writer.Close()
+	if err != nil {
+		return "", fmt.Errorf("close writer ns=%s id=%s: %w", ns, deltaID, err)
+	}
+	return *newID, nil
+}
+
+// applyDelta writes changes to rangeID as a delta MetaRange
+func (c *committedManager) applyDelta(ctx context.Context, ns graveler.StorageNamespace, rangeID graveler.MetaRangeID, changes []*graveler.ValueRecord) (graveler.MetaRangeID, graveler.DiffSummary, error) {
+	summary := graveler.DiffSummary{Count: make(map[graveler.DiffType]int)}
+	info, err := c.getDeltaInfo(ctx, ns, rangeID)
+	if err != nil {
+		return "", summary, err
+	}
+	depth := 0
+	if info != nil {
+		depth = info.depth
+	}
+	if depth >= c.deltaParams.MaxDepth {
+		if rangeID, err = c.resolve(ctx, ns, rangeID); err != nil {
+			return "", summary, err
+		}
+		depth = 0
+	}
+	records := make([]graveler.ValueRecord, 0, len(changes))
+	for _, change := range changes {
+		existing, err := c.Get(ctx, ns, rangeID, change.Key)
+		if err != nil && !errors.Is(err, graveler.ErrNotFound) {
+			return "", summary, err
+		}
+		exists := err == nil
+		record := graveler.ValueRecord{Key: change.Key, Value: change.Value}
+		switch {
+		case change.IsTombstone() && !exists:
+			// deletion of a key that is not there
+			continue
+		case !change.IsTombstone() && exists && bytes.Equal(existing.Identity, change.Value.Identity):
+			// same value as already there
+			continue
+		case change.IsTombstone():
+			record.Value = &deltaTombstone
+			incrementDiffSummary(&summary, graveler.DiffTypeRemoved)
+		case exists:
+			incrementDiffSummary(&summary, graveler.DiffTypeChanged)
+		default:
+			incrementDiffSummary(&summary, graveler.DiffTypeAdded)
+		}
+		records = append(records, record)
+	}
+	if len(records) == 0 {
+		return "", summary, graveler.ErrNoChanges
+	}
+
+	writer := c.metaRangeManager.NewWriter(ctx, ns, graveler.Metadata{
+		MetadataDeltaBaseKey:  string(rangeID),
+		MetadataDeltaDepthKey: strconv.Itoa(depth + 1),
+	})
+	defer func() {
+		if err := writer.Abort(); err != nil {
+			c.logger.WithError(err).Error("Abort failed after writing delta")
+		}
+	}()
+	for _, record := range records {
+		if err := writer.WriteRecord(record); err != nil {
+			return "", summary, fmt.Errorf("write delta record: %w", err)
+		}
+	}
+	newID, err := writer.Close()
+	if err != nil {
+		return "", summary, fmt.Errorf("close delta writer ns=%s base=%s: %w", ns, rangeID, err)
+	}
+	return *newID, summary, nil
+}
+
+// readChanges reads up to limit changes from it.  It returns the changes and true if it read all
+// changes of it.
+func readChanges(it graveler.ValueIterator, limit int) ([]*graveler.ValueRecord, bool, error) {
+	var changes []*graveler.ValueRecord
+	for len(changes) < limit {
+		if !it.Next() {
+			return changes, true, it.Err()
+		}
+		// iterators may reuse the memory of a record once they move on
+		record := it.Value()
+		change := &graveler.ValueRecord{Key: record.Key.Copy()}
+		if record.Value != nil {
+			change.Value = &graveler.Value{
+				Identity: append([]byte(nil), record.Value.Identity...),
+				Data:     append([]byte(nil), record.Value.Data...),
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes, false, nil
+}
+
+// deltaChangesIterator reads the values of a delta MetaRange as changes, translating the
+// tombstones of the delta to deletions
+type deltaChangesIterator struct {
+	graveler.ValueIterator
+}
+
+func (it *deltaChangesIterator) Value() *graveler.ValueRecord {
+	record := it.ValueIterator.Value()
+	if record == nil || !isDeltaTombstone(record.Value) {
+		return record
+	}
+	return &graveler.ValueRecord{Key: record.Key}
+}
+
+// prefixedValueIterator iterates over prefix and then over the rest of it.  All keys of prefix
+// come before the keys remaining in it.
+type prefixedValueIterator struct {
+	prefix []*graveler.ValueRecord
+	it     graveler.ValueIterator
+	value  *graveler.ValueRecord
+}
+
+func newPrefixedValueIterator(prefix []*graveler.ValueRecord, it graveler.ValueIterator) *prefixedValueIterator {
+	return &prefixedValueIterator{prefix: prefix, it: it}
+}
+
+func (p *prefixedValueIterator) Next() bool {
+	if len(p.prefix) > 0 {
+		p.value = p.prefix[0]
+		p.prefix = p.prefix[1:]
+		return true
+	}
+	if !p.it.Next() {
+		p.value = nil
+		return false
+	}
+	p.value = p.it.Value()
+	return true
+}
+
+func (p *prefixedValueIterator) SeekGE(id graveler.Key) {
+	for len(p.prefix) > 0 && bytes.Compare(p.prefix[0].Key, id) < 0 {
+		p.prefix = p.prefix[1:]
+	}
+	p.value = nil
+	p.it.SeekGE(id)
+}
+
+func (p *prefixedValueIterator) Value() *graveler.ValueRecord {
+	return p.value
+}
+
+func (p *prefixedValueIterator) Err() error {
+	return p.it.Err()
+}
+
+func (p *prefixedValueIterator) Close() {
+	p.it.Close()
+}
@@ -0,0 +1,93 @@
+package committed_test
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/graveler/committed"
+	"github.com/treeverse/lakefs/graveler/testutil"
+)
+
+func makeValueRecords(keys ...string) []graveler.ValueRecord {
+	records := make([]graveler.ValueRecord, 0, len(keys))
+	for _, key := range keys {
+		records = append(records, graveler.ValueRecord{
+			Key:   graveler.Key(key),
+			Value: &graveler.Value{Identity: []byte("id-" + key), Data: []byte("data-" + key)},
+		})
+	}
+	return records
+}
+
+func readKeys(t *testing.T, it graveler.ValueIterator) []string {
+	t.Helper()
+	var keys []string
+	for it.Next() {
+		keys = append(keys, string(it.Value().Key))
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("iterate: %s", err)
+	}
+	return keys
+}
+
+func TestReadChanges(t *testing.T) {
+	cases := []struct {
+		Name             string
+		Keys             []string
+		Limit            int
+		ExpectedKeys     []string
+		ExpectedComplete bool
+	}{
+		{Name: "empty", Keys: nil, Limit: 3, ExpectedKeys: nil, ExpectedComplete: true},
+		{Name: "under_limit", Keys: []string{"a", "b"}, Limit: 3, ExpectedKeys: []string{"a", "b"}, ExpectedComplete: true},
+		{Name: "at_limit", Keys: []string{"a", "b", "c"}, Limit: 3, ExpectedKeys: []string{"a", "b", "c"}, ExpectedComplete: false},
+		{Name: "over_limit", Keys: []string{"a", "b", "c", "d"}, Limit: 3, ExpectedKeys: []string{"a", "b", "c"}, ExpectedComplete: false},
+	}
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			it := testutil.NewValueIteratorFake(makeValueRecords(tt.Keys...))
+			defer it.Close()
+			changes, complete, err := committed.ReadChanges(it, tt.Limit)
+			if err != nil {
+				t.Fatalf("ReadChanges: %s", err)
+			}
+			var keys []string
+			for _, change := range changes {
+				keys = append(keys, string(change.Key))
+			}
+			if diff := deep.Equal(keys, tt.ExpectedKeys); diff != nil {
+				t.Errorf("ReadChanges keys diff: %s", diff)
+			}
+			if complete != tt.ExpectedComplete {
+				t.Errorf("ReadChanges complete = %t, expected %t", complete, tt.ExpectedComplete)
+			}
+		})
+	}
+}
+
+func TestPrefixedValueIterator(t *testing.T) {
+	records := makeValueRecords("a", "b", "c", "d", "e")
+	it := testutil.NewValueIteratorFake(records)
+	prefix, complete, err := committed.ReadChanges(it, 2)
+	if err != nil || complete {
+		t.Fatalf("ReadChanges = complete %t, err %v", complete, err)
+	}
+	prefixed := committed.NewPrefixedValueIterator(prefix, it)
+	defer prefixed.Close()
+	if diff := deep.Equal(readKeys(t, prefixed), []string{"a", "b", "c", "d", "e"}); diff != nil {
+		t.Errorf("iterate prefixed diff: %s", diff)
+	}
+
+	it = testutil.NewValueIteratorFake(records)
+	prefix, _, err = committed.ReadChanges(it, 2)
+	if err != nil {
+		t.Fatalf("ReadChanges: %s", err)
+	}
+	prefixed = committed.NewPrefixedValueIterator(prefix, it)
+	prefixed.SeekGE(graveler.Key("d"))
+	if diff := deep.Equal(readKeys(t, prefixed), []string{"d", "e"}); diff != nil {
+		t.Errorf("iterate prefixed after SeekGE diff: %s", diff)
+	}
+}
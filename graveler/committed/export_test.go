@@ -0,0 +1,7 @@
+package committed
+
+var (
+	ReadChanges              = readChanges
+	NewPrefixedValueIterator = newPrefixedValueIterator
+	KeyPrefix                = keyPrefix
+)
@@ -0,0 +1,135 @@
+package committed
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/treeverse/lakefs/graveler"
+)
+
+const (
+	// MetadataFormatVersionKey is the metadata key holding the format version of Ranges and
+	// MetaRanges.  Trees written before versioning have no format version, and are version 0.
+	MetadataFormatVersionKey = "format_version"
+
+	// CurrentFormatVersion is the format version of newly written Ranges and MetaRanges.  A
+	// MetaRange of this version holds only Ranges of this version.
+	CurrentFormatVersion = 1
+)
+
+var (
+	ErrUnknownFormatVersion = errors.New("unknown tree format version")
+	ErrFormatMigrationOrder = errors.New("format migration does not follow the previous migration")
+)
+
+// FormatMigration upgrades a record from format version FromVersion to FromVersion+1
+type FormatMigration struct {
+	FromVersion int
+	Migrate     func(record *graveler.ValueRecord) (*graveler.ValueRecord, error)
+}
+
+// formatMigrations are ordered by FromVersion, formatMigrations[i] upgrades version i
+var formatMigrations = []FormatMigration{
+	// version 1 introduced the format version itself, records are unchanged
+	{FromVersion: 0, Migrate: func(record *graveler.ValueRecord) (*graveler.ValueRecord, error) { return record, nil }},
+}
+
+// RegisterFormatMigration adds the migration to the next format version.  It must be called
+// (from an init function) together with raising CurrentFormatVersion.
+func RegisterFormatMigration(m FormatMigration) error {
+	if m.FromVersion != len(formatMigrations) {
+		return fmt.Errorf("migration from version %d after %d migrations: %w", m.FromVersion, len(formatMigrations), ErrFormatMigrationOrder)
+	}
+	formatMigrations = append(formatMigrations, m)
+	return nil
+}
+
+// ParseFormatVersion returns the format version recorded in metadata of a Range or MetaRange
+func ParseFormatVersion(metadata graveler.Metadata) (int, error) {
+	v, ok := metadata[MetadataFormatVersionKey]
+	if !ok {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(v)
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("%s: %w", v, ErrUnknownFormatVersion)
+	}
+	if version > CurrentFormatVersion {
+		return 0, fmt.Errorf("version %d newer than %d: %w", version, CurrentFormatVersion, ErrUnknownFormatVersion)
+	}
+	return version, nil
+}
+
+// UpgradeRecord applies the migrations upgrading record from format version to
+// CurrentFormatVersion
+func UpgradeRecord(version int, record *graveler.ValueRecord) (*graveler.ValueRecord, error) {
+	for ; version < CurrentFormatVersion; version++ {
+		if version >= len(formatMigrations) {
+			return nil, fmt.Errorf("no migration from version %d: %w", version, ErrUnknownFormatVersion)
+		}
+		var err error
+		record, err = formatMigrations[version].Migrate(record)
+		if err != nil {
+			return nil, fmt.Errorf("migrate from version %d: %w", version, err)
+		}
+	}
+	return record, nil
+}
+
+// upgradingIterator reads a MetaRange of an old format version as a MetaRange of the current
+// version
+type upgradingIterator struct {
+	Iterator
+	version int
+	value   *graveler.ValueRecord
+	rng     *Range
+	err     error
+}
+
+func newUpgradingIterator(it Iterator, version int) Iterator {
+	return &upgradingIterator{Iterator: it, version: version}
+}
+
+func (it *upgradingIterator) load() bool {
+	value, rng := it.Iterator.Value()
+	if rng != nil {
+		r := *rng
+		r.FormatVersion = it.version
+		rng = &r
+	}
+	it.rng = rng
+	it.value = value
+	if value != nil {
+		it.value, it.err = UpgradeRecord(it.version, value)
+		if it.err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (it *upgradingIterator) Next() bool {
+	if it.err != nil || !it.Iterator.Next() {
+		return false
+	}
+	return it.load()
+}
+
+func (it *upgradingIterator) NextRange() bool {
+	if it.err != nil || !it.Iterator.NextRange() {
+		return false
+	}
+	return it.load()
+}
+
+func (it *upgradingIterator) Value() (*graveler.ValueRecord, *Range) {
+	return it.value, it.rng
+}
+
+func (it *upgradingIterator) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.Iterator.Err()
+}
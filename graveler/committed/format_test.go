@@ -0,0 +1,59 @@
+package committed_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/graveler/committed"
+)
+
+func TestParseFormatVersion(t *testing.T) {
+	cases := []struct {
+		name     string
+		metadata graveler.Metadata
+		version  int
+		err      error
+	}{
+		{name: "unversioned", metadata: graveler.Metadata{committed.MetadataTypeKey: committed.MetadataMetarangesType}, version: 0},
+		{name: "current", metadata: graveler.Metadata{committed.MetadataFormatVersionKey: strconv.Itoa(committed.CurrentFormatVersion)}, version: committed.CurrentFormatVersion},
+		{name: "newer", metadata: graveler.Metadata{committed.MetadataFormatVersionKey: strconv.Itoa(committed.CurrentFormatVersion + 1)}, err: committed.ErrUnknownFormatVersion},
+		{name: "garbage", metadata: graveler.Metadata{committed.MetadataFormatVersionKey: "one"}, err: committed.ErrUnknownFormatVersion},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := committed.ParseFormatVersion(tt.metadata)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseFormatVersion() err=%v, expected %v", err, tt.err)
+			}
+			if version != tt.version {
+				t.Errorf("ParseFormatVersion()=%d, expected %d", version, tt.version)
+			}
+		})
+	}
+}
+
+func TestUpgradeRecord(t *testing.T) {
+	record := &graveler.ValueRecord{Key: graveler.Key("key"), Value: &graveler.Value{Identity: []byte("id"), Data: []byte("data")}}
+	for version := 0; version <= committed.CurrentFormatVersion; version++ {
+		upgraded, err := committed.UpgradeRecord(version, record)
+		if err != nil {
+			t.Fatalf("UpgradeRecord(%d): %s", version, err)
+		}
+		if diff := deep.Equal(upgraded, record); diff != nil {
+			t.Errorf("UpgradeRecord(%d) diff found %s", version, diff)
+		}
+	}
+}
+
+func TestRegisterFormatMigration_Order(t *testing.T) {
+	err := committed.RegisterFormatMigration(committed.FormatMigration{
+		FromVersion: 0,
+		Migrate:     func(record *graveler.ValueRecord) (*graveler.ValueRecord, error) { return record, nil },
+	})
+	if !errors.Is(err, committed.ErrFormatMigrationOrder) {
+		t.Fatalf("RegisterFormatMigration() of registered version err=%v, expected %s", err, committed.ErrFormatMigrationOrder)
+	}
+}
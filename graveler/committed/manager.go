@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/treeverse/lakefs/cache"
 	"github.com/treeverse/lakefs/logging"
 
 	"github.com/treeverse/lakefs/graveler"
@@ -13,18 +14,56 @@ import (
 
 type committedManager struct {
 	metaRangeManager MetaRangeManager
-	logger           logging.Logger
+	deltaParams      DeltaParams
+	// resolved caches the full MetaRange of every MetaRange read
+	resolved cache.Cache
+	// resolutions persists the full MetaRange of every materialized delta, may be nil
+	resolutions DeltaResolutions
+	logger      logging.Logger
 }
 
-func NewCommittedManager(m MetaRangeManager) graveler.CommittedManager {
-	return &committedManager{metaRangeManager: m, logger: logging.Default()}
+// NewCommittedManager returns a CommittedManager over m.  resolutions persists materialized
+// deltas across processes, deltas are only cached in memory when it is nil.
+func NewCommittedManager(m MetaRangeManager, deltaParams DeltaParams, resolutions DeltaResolutions) graveler.CommittedManager {
+	return &committedManager{
+		metaRangeManager: m,
+		deltaParams:      deltaParams,
+		resolutions:      resolutions,
+		resolved:         cache.NewCache(DeltaResolveCacheSize, DeltaResolveCacheExpiry, cache.NewJitterFn(DeltaResolveCacheJitter)),
+		logger:           logging.Default(),
+	}
 }
 
 func (c *committedManager) Exists(ctx context.Context, ns graveler.StorageNamespace, id graveler.MetaRangeID) (bool, error) {
 	return c.metaRangeManager.Exists(ctx, ns, id)
 }
 
+// Get looks up key in the changes of each delta down to the full MetaRange, without
+// materializing deltas
 func (c *committedManager) Get(ctx context.Context, ns graveler.StorageNamespace, rangeID graveler.MetaRangeID, key graveler.Key) (*graveler.Value, error) {
+	for {
+		info, err := c.getDeltaInfo(ctx, ns, rangeID)
+		if err != nil {
+			return nil, err
+		}
+		value, err := c.getFromMetaRange(ctx, ns, rangeID, key)
+		if info == nil {
+			return value, err
+		}
+		if err == nil {
+			if isDeltaTombstone(value) {
+				return nil, graveler.ErrNotFound
+			}
+			return value, nil
+		}
+		if !errors.Is(err, graveler.ErrNotFound) {
+			return nil, err
+		}
+		rangeID = info.base
+	}
+}
+
+func (c *committedManager) getFromMetaRange(ctx context.Context, ns graveler.StorageNamespace, rangeID graveler.MetaRangeID, key graveler.Key) (*graveler.Value, error) {
 	it, err := c.metaRangeManager.NewMetaRangeIterator(ctx, ns, rangeID)
 	if err != nil {
 		return nil, err
@@ -49,6 +88,10 @@ func (c *committedManager) Get(ctx context.Context, ns graveler.StorageNamespace
 }
 
 func (c *committedManager) List(ctx context.Context, ns graveler.StorageNamespace, rangeID graveler.MetaRangeID) (graveler.ValueIterator, error) {
+	rangeID, err := c.resolve(ctx, ns, rangeID)
+	if err != nil {
+		return nil, err
+	}
 	it, err := c.metaRangeManager.NewMetaRangeIterator(ctx, ns, rangeID)
 	if err != nil {
 		return nil, err
@@ -81,6 +124,14 @@ func (c *committedManager) WriteMetaRange(ctx context.Context, ns graveler.Stora
 }
 
 func (c *committedManager) Diff(ctx context.Context, ns graveler.StorageNamespace, left, right graveler.MetaRangeID) (graveler.DiffIterator, error) {
+	left, err := c.resolve(ctx, ns, left)
+	if err != nil {
+		return nil, err
+	}
+	right, err = c.resolve(ctx, ns, right)
+	if err != nil {
+		return nil, err
+	}
 	leftIt, err := c.metaRangeManager.NewMetaRangeIterator(ctx, ns, left)
 	if err != nil {
 		return nil, err
@@ -98,6 +149,10 @@ func (c *committedManager) Merge(ctx context.Context, ns graveler.StorageNamespa
 		return "", graveler.DiffSummary{}, fmt.Errorf("diff: %w", err)
 	}
 	defer diffIt.Close()
+	base, err = c.resolve(ctx, ns, base)
+	if err != nil {
+		return "", graveler.DiffSummary{}, err
+	}
 	baseIt, err := c.metaRangeManager.NewMetaRangeIterator(ctx, ns, base)
 	if err != nil {
 		return "", graveler.DiffSummary{}, fmt.Errorf("get base iterator: %w", err)
@@ -109,6 +164,20 @@ func (c *committedManager) Merge(ctx context.Context, ns graveler.StorageNamespa
 }
 
 func (c *committedManager) Apply(ctx context.Context, ns graveler.StorageNamespace, rangeID graveler.MetaRangeID, diffs graveler.ValueIterator) (graveler.MetaRangeID, graveler.DiffSummary, error) {
+	if c.deltaParams.MaxChanges > 0 {
+		changes, complete, err := readChanges(diffs, c.deltaParams.MaxChanges+1)
+		if err != nil {
+			return "", graveler.DiffSummary{}, err
+		}
+		if complete {
+			return c.applyDelta(ctx, ns, rangeID, changes)
+		}
+		diffs = newPrefixedValueIterator(changes, diffs)
+	}
+	rangeID, err := c.resolve(ctx, ns, rangeID)
+	if err != nil {
+		return "", graveler.DiffSummary{}, err
+	}
 	mwWriter := c.metaRangeManager.NewWriter(ctx, ns, nil)
 	defer func() {
 		err := mwWriter.Abort()
@@ -135,14 +204,117 @@ func (c *committedManager) Apply(ctx context.Context, ns graveler.StorageNamespa
 	return *newID, summary, err
 }
 
+func (c *committedManager) DropPrefix(ctx context.Context, ns graveler.StorageNamespace, rangeID graveler.MetaRangeID, prefix graveler.Key) (graveler.MetaRangeID, graveler.DiffSummary, error) {
+	return c.replacePrefix(ctx, ns, rangeID, nil, prefix)
+}
+
+func (c *committedManager) ReplacePrefix(ctx context.Context, ns graveler.StorageNamespace, destination, source graveler.MetaRangeID, prefix graveler.Key) (graveler.MetaRangeID, graveler.DiffSummary, error) {
+	return c.replacePrefix(ctx, ns, destination, &source, prefix)
+}
+
+// replacePrefix replaces the values under prefix in destination by those in source, or drops
+// them if source is nil
+func (c *committedManager) replacePrefix(ctx context.Context, ns graveler.StorageNamespace, destination graveler.MetaRangeID, source *graveler.MetaRangeID, prefix graveler.Key) (graveler.MetaRangeID, graveler.DiffSummary, error) {
+	destination, err := c.resolve(ctx, ns, destination)
+	if err != nil {
+		return "", graveler.DiffSummary{}, err
+	}
+	destIt, err := c.metaRangeManager.NewMetaRangeIterator(ctx, ns, destination)
+	if err != nil {
+		return "", graveler.DiffSummary{}, fmt.Errorf("get metarange ns=%s id=%s: %w", ns, destination, err)
+	}
+	defer destIt.Close()
+	var sourceIt Iterator
+	if source != nil {
+		sourceID, err := c.resolve(ctx, ns, *source)
+		if err != nil {
+			return "", graveler.DiffSummary{}, err
+		}
+		sourceIt, err = c.metaRangeManager.NewMetaRangeIterator(ctx, ns, sourceID)
+		if err != nil {
+			return "", graveler.DiffSummary{}, fmt.Errorf("get metarange ns=%s id=%s: %w", ns, sourceID, err)
+		}
+		defer sourceIt.Close()
+	}
+	mwWriter := c.metaRangeManager.NewWriter(ctx, ns, nil)
+	defer func() {
+		err := mwWriter.Abort()
+		if err != nil {
+			c.logger.WithError(err).Error("Abort failed after ReplacePrefix")
+		}
+	}()
+	summary, err := ReplacePrefix(ctx, mwWriter, destIt, sourceIt, prefix)
+	if err != nil {
+		if !errors.Is(err, graveler.ErrUserVisible) {
+			err = fmt.Errorf("replace prefix ns=%s id=%s: %w", ns, destination, err)
+		}
+		return "", graveler.DiffSummary{}, err
+	}
+	newID, err := mwWriter.Close()
+	if newID == nil {
+		return "", graveler.DiffSummary{}, fmt.Errorf("close writer ns=%s id=%s: %w", ns, destination, err)
+	}
+	if err == nil && *newID == destination {
+		// source holds the same Ranges under prefix
+		return "", graveler.DiffSummary{}, graveler.ErrNoChanges
+	}
+	return *newID, summary, err
+}
+
 func (c *committedManager) Compare(ctx context.Context, ns graveler.StorageNamespace, destination, source, base graveler.MetaRangeID) (graveler.DiffIterator, error) {
 	diffIt, err := c.Diff(ctx, ns, destination, source)
 	if err != nil {
 		return nil, fmt.Errorf("diff: %w", err)
 	}
+	base, err = c.resolve(ctx, ns, base)
+	if err != nil {
+		return nil, err
+	}
 	baseIt, err := c.metaRangeManager.NewMetaRangeIterator(ctx, ns, base)
 	if err != nil {
 		return nil, fmt.Errorf("get base iterator: %w", err)
 	}
 	return NewCompareIterator(diffIt, baseIt), nil
 }
+
+// Upgrade rewrites the MetaRange with id in the current format version and returns the ID of
+// the rewritten MetaRange, or id if it is already in the current format version.
+func (c *committedManager) Upgrade(ctx context.Context, ns graveler.StorageNamespace, id graveler.MetaRangeID) (graveler.MetaRangeID, error) {
+	// a delta is upgraded by materializing it in the current format version
+	info, err := c.getDeltaInfo(ctx, ns, id)
+	if err != nil {
+		return "", err
+	}
+	if info != nil {
+		return c.resolve(ctx, ns, id)
+	}
+	version, err := c.metaRangeManager.GetFormatVersion(ctx, ns, id)
+	if err != nil {
+		return "", err
+	}
+	if version == CurrentFormatVersion {
+		return id, nil
+	}
+	writer := c.metaRangeManager.NewWriter(ctx, ns, nil)
+	defer func() {
+		if err := writer.Abort(); err != nil {
+			c.logger.WithError(err).Error("Abort failed after Upgrade")
+		}
+	}()
+	metaRangeIterator, err := c.metaRangeManager.NewMetaRangeIterator(ctx, ns, id)
+	if err != nil {
+		return "", fmt.Errorf("get metarange ns=%s id=%s: %w", ns, id, err)
+	}
+	defer metaRangeIterator.Close()
+	// applying no changes copies all ranges, and the writer rewrites ranges of older versions
+	noChanges := NewValueIterator(NewEmptyIterator())
+	defer noChanges.Close()
+	if _, err := Apply(ctx, writer, metaRangeIterator, noChanges, &ApplyOptions{AllowEmpty: true}); err != nil {
+		return "", fmt.Errorf("upgrade ns=%s id=%s from version %d: %w", ns, id, version, err)
+	}
+	newID, err := writer.Close()
+	if err != nil {
+		return "", fmt.Errorf("close writer ns=%s id=%s: %w", ns, id, err)
+	}
+	return *newID, nil
+}
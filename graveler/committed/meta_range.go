@@ -32,10 +32,17 @@ type MetaRangeManager interface {
 	// MetaRange with id.
 	GetValue(ctx context.Context, ns graveler.StorageNamespace, id graveler.MetaRangeID, key graveler.Key) (*graveler.ValueRecord, error)
 
+	// GetFormatVersion returns the format version of the MetaRange with id.
+	GetFormatVersion(ctx context.Context, ns graveler.StorageNamespace, id graveler.MetaRangeID) (int, error)
+
+	// GetMetadata returns the metadata written with the MetaRange with id.
+	GetMetadata(ctx context.Context, ns graveler.StorageNamespace, id graveler.MetaRangeID) (graveler.Metadata, error)
+
 	// NewRangeWriter returns a writer that is used for creating new MetaRanges
 	NewWriter(ctx context.Context, ns graveler.StorageNamespace, metadata graveler.Metadata) MetaRangeWriter
 
-	// NewMetaRangeIterator returns an Iterator over the MetaRange with id.
+	// NewMetaRangeIterator returns an Iterator over the MetaRange with id, upgrading values
+	// of older format versions to the current format version.
 	NewMetaRangeIterator(ctx context.Context, ns graveler.StorageNamespace, metaRangeID graveler.MetaRangeID) (Iterator, error)
 }
 
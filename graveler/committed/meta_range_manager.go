@@ -20,10 +20,37 @@ type Params struct {
 	// the expected number of records after MinRangeSizeBytes at which to split the range
 	// -- ranges are split at the first key with hash divisible by this raggedness.
 	RangeSizeEntriesRaggedness float64
+	// MaxRangeEntries is the largest number of records in a range partition, unlimited when
+	// 0.
+	MaxRangeEntries int
+	// PrefixBoundaryDepth, when positive, splits range partitions past MinRangeSizeBytes
+	// where the first PrefixBoundaryDepth path components of keys change, instead of by
+	// RangeSizeEntriesRaggedness.  Ranges of partitioned data then align with partitions.
+	PrefixBoundaryDepth int
 	// MaxUploaders is the maximal number of uploaders to use in a single metarange writer.
 	MaxUploaders int
 }
 
+// WithRangeSplitPolicy returns params with the fields set by policy replaced
+func (p Params) WithRangeSplitPolicy(policy *graveler.RangeSplitPolicy) Params {
+	if policy == nil {
+		return p
+	}
+	if policy.MinRangeSizeBytes > 0 {
+		p.MinRangeSizeBytes = policy.MinRangeSizeBytes
+	}
+	if policy.MaxRangeSizeBytes > 0 {
+		p.MaxRangeSizeBytes = policy.MaxRangeSizeBytes
+	}
+	if policy.MaxRangeEntries > 0 {
+		p.MaxRangeEntries = policy.MaxRangeEntries
+	}
+	if policy.PrefixBoundaryDepth > 0 {
+		p.PrefixBoundaryDepth = policy.PrefixBoundaryDepth
+	}
+	return p
+}
+
 type metaRangeManager struct {
 	params       Params
 	metaManager  RangeManager // For metaranges
@@ -75,23 +102,60 @@ func (m *metaRangeManager) GetValue(ctx context.Context, ns graveler.StorageName
 	if err != nil {
 		return nil, err
 	}
-	return &graveler.ValueRecord{
+	version, err := m.GetFormatVersion(ctx, ns, id)
+	if err != nil {
+		return nil, err
+	}
+	return UpgradeRecord(version, &graveler.ValueRecord{
 		Key:   key,
 		Value: value,
-	}, nil
+	})
+}
+
+// GetFormatVersion returns the format version of the MetaRange with id
+func (m *metaRangeManager) GetFormatVersion(ctx context.Context, ns graveler.StorageNamespace, id graveler.MetaRangeID) (int, error) {
+	if id == "" {
+		return CurrentFormatVersion, nil
+	}
+	metadata, err := m.metaManager.GetMetadata(ctx, Namespace(ns), ID(id))
+	if err != nil {
+		return 0, fmt.Errorf("get metarange %s metadata: %w", id, err)
+	}
+	version, err := ParseFormatVersion(metadata)
+	if err != nil {
+		return 0, fmt.Errorf("metarange %s: %w", id, err)
+	}
+	return version, nil
+}
+
+// GetMetadata returns the metadata written with the MetaRange with id
+func (m *metaRangeManager) GetMetadata(ctx context.Context, ns graveler.StorageNamespace, id graveler.MetaRangeID) (graveler.Metadata, error) {
+	if id == "" {
+		return graveler.Metadata{}, nil
+	}
+	metadata, err := m.metaManager.GetMetadata(ctx, Namespace(ns), ID(id))
+	if err != nil {
+		return nil, fmt.Errorf("get metarange %s metadata: %w", id, err)
+	}
+	return metadata, nil
 }
 
 func (m *metaRangeManager) NewWriter(ctx context.Context, ns graveler.StorageNamespace, metadata graveler.Metadata) MetaRangeWriter {
-	return NewGeneralMetaRangeWriter(ctx, m.rangeManager, m.metaManager, &m.params, Namespace(ns), metadata)
+	params := m.params.WithRangeSplitPolicy(graveler.RangeSplitPolicyFromContext(ctx))
+	return NewGeneralMetaRangeWriter(ctx, m.rangeManager, m.metaManager, &params, Namespace(ns), metadata)
 }
 
 func (m *metaRangeManager) NewMetaRangeIterator(ctx context.Context, ns graveler.StorageNamespace, id graveler.MetaRangeID) (Iterator, error) {
 	if id == "" {
 		return NewEmptyIterator(), nil
 	}
+	version, err := m.GetFormatVersion(ctx, ns, id)
+	if err != nil {
+		return nil, err
+	}
 	rangesIt, err := m.metaManager.NewRangeIterator(ctx, Namespace(ns), ID(id))
 	if err != nil {
 		return nil, fmt.Errorf("manage metarange %s: %w", id, err)
 	}
-	return NewIterator(ctx, m.rangeManager, Namespace(ns), rangesIt), nil
+	return newUpgradingIterator(NewIterator(ctx, m.rangeManager, Namespace(ns), rangesIt), version), nil
 }
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"hash/fnv"
 	"sort"
+	"strconv"
 
 	"github.com/treeverse/lakefs/graveler"
 	"github.com/treeverse/lakefs/logging"
@@ -20,6 +21,7 @@ type GeneralMetaRangeWriter struct {
 	metaRangeManager RangeManager
 	rangeManager     RangeManager
 	rangeWriter      RangeWriter // writer for the current range
+	rangeEntries     int         // number of records written to the current range
 	lastKey          Key
 	batchWriteCloser BatchWriterCloser
 	ranges           []Range
@@ -29,6 +31,9 @@ const (
 	MetadataTypeKey        = "type"
 	MetadataRangesType     = "ranges"
 	MetadataMetarangesType = "metaranges"
+
+	// PathDelimiter separates the path components of keys for PrefixBoundaryDepth
+	PathDelimiter = '/'
 )
 
 var (
@@ -58,12 +63,18 @@ func (w *GeneralMetaRangeWriter) WriteRecord(record graveler.ValueRecord) error
 	}
 
 	var err error
+	if w.rangeWriter != nil && w.shouldBreakBeforeKey(record.Key) {
+		if err := w.closeCurrentRange(); err != nil {
+			return err
+		}
+	}
 	if w.rangeWriter == nil {
 		w.rangeWriter, err = w.rangeManager.GetWriter(w.ctx, w.namespace, w.metadata)
 		if err != nil {
 			return fmt.Errorf("get range writer: %w", err)
 		}
 		w.rangeWriter.SetMetadata(MetadataTypeKey, MetadataRangesType)
+		w.rangeWriter.SetMetadata(MetadataFormatVersionKey, strconv.Itoa(CurrentFormatVersion))
 	}
 
 	v, err := MarshalValue(record.Value)
@@ -74,6 +85,7 @@ func (w *GeneralMetaRangeWriter) WriteRecord(record graveler.ValueRecord) error
 	if err != nil {
 		return fmt.Errorf("write record to range: %w", err)
 	}
+	w.rangeEntries++
 	w.lastKey = Key(record.Key.Copy())
 	if w.shouldBreakAtKey(record.Key) {
 		return w.closeCurrentRange()
@@ -89,6 +101,7 @@ func (w *GeneralMetaRangeWriter) closeCurrentRange() error {
 		return fmt.Errorf("write range: %w", err)
 	}
 	w.rangeWriter = nil
+	w.rangeEntries = 0
 	return nil
 }
 
@@ -110,10 +123,15 @@ func (w *GeneralMetaRangeWriter) getBatchedRanges() ([]Range, error) {
 	return ranges, nil
 }
 
+// WriteRange adds rng to the MetaRange.  A Range of an older format version is rewritten in the
+// current format version, so MetaRanges hold only Ranges of their format version.
 func (w *GeneralMetaRangeWriter) WriteRange(rng Range) error {
 	if w.lastKey != nil && bytes.Compare(rng.MinKey, w.lastKey) <= 0 {
 		return ErrUnsortedKeys
 	}
+	if rng.FormatVersion < CurrentFormatVersion {
+		return w.upgradeRange(rng)
+	}
 	if err := w.closeCurrentRange(); err != nil {
 		return err
 	}
@@ -123,6 +141,26 @@ func (w *GeneralMetaRangeWriter) WriteRange(rng Range) error {
 	return nil
 }
 
+// upgradeRange writes the records of rng upgraded to the current format version
+func (w *GeneralMetaRangeWriter) upgradeRange(rng Range) error {
+	rangeIt, err := w.rangeManager.NewRangeIterator(w.ctx, w.namespace, rng.ID)
+	if err != nil {
+		return fmt.Errorf("open range %s: %w", rng.ID, err)
+	}
+	it := NewUnmarshalIterator(rangeIt)
+	defer it.Close()
+	for it.Next() {
+		record, err := UpgradeRecord(rng.FormatVersion, it.Value())
+		if err != nil {
+			return fmt.Errorf("upgrade range %s: %w", rng.ID, err)
+		}
+		if err := w.WriteRecord(*record); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
+
 func (w *GeneralMetaRangeWriter) Close() (*graveler.MetaRangeID, error) {
 	if err := w.closeCurrentRange(); err != nil {
 		return nil, err
@@ -139,15 +177,40 @@ func (w *GeneralMetaRangeWriter) Close() (*graveler.MetaRangeID, error) {
 	return w.writeRangesToMetaRange()
 }
 
+// shouldBreakBeforeKey returns true if should break range before the given key, at a prefix
+// boundary
+func (w *GeneralMetaRangeWriter) shouldBreakBeforeKey(key graveler.Key) bool {
+	if w.params.PrefixBoundaryDepth <= 0 || w.rangeWriter.GetApproximateSize() < w.params.MinRangeSizeBytes {
+		return false
+	}
+	return !bytes.Equal(keyPrefix(key, w.params.PrefixBoundaryDepth), keyPrefix(graveler.Key(w.lastKey), w.params.PrefixBoundaryDepth))
+}
+
+// keyPrefix returns the first depth path components of key, or its parent path if it has fewer
+func keyPrefix(key graveler.Key, depth int) []byte {
+	end := 0
+	for i := 0; i < depth; i++ {
+		next := bytes.IndexByte(key[end:], PathDelimiter)
+		if next < 0 {
+			break
+		}
+		end += next + 1
+	}
+	return key[:end]
+}
+
 // shouldBreakAtKey returns true if should break range after the given key
 func (w *GeneralMetaRangeWriter) shouldBreakAtKey(key graveler.Key) bool {
 	approximateSize := w.rangeWriter.GetApproximateSize()
-	if approximateSize < w.params.MinRangeSizeBytes {
-		return false
-	}
 	if approximateSize >= w.params.MaxRangeSizeBytes {
 		return true
 	}
+	if w.params.MaxRangeEntries > 0 && w.rangeEntries >= w.params.MaxRangeEntries {
+		return true
+	}
+	if approximateSize < w.params.MinRangeSizeBytes || w.params.PrefixBoundaryDepth > 0 {
+		return false
+	}
 
 	h := fnv.New64a()
 	// FNV always reads all bytes and never fails; ignore its return values
@@ -167,8 +230,9 @@ func (w *GeneralMetaRangeWriter) writeRangesToMetaRange() (*graveler.MetaRangeID
 	for k, v := range w.metadata {
 		metaRangeWriter.SetMetadata(k, v)
 	}
-	// set type
+	// set type and format version
 	metaRangeWriter.SetMetadata(MetadataTypeKey, MetadataMetarangesType)
+	metaRangeWriter.SetMetadata(MetadataFormatVersionKey, strconv.Itoa(CurrentFormatVersion))
 
 	defer func() {
 		if abortErr := metaRangeWriter.Abort(); abortErr != nil {
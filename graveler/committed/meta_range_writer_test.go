@@ -102,8 +102,8 @@ func TestWriter_OverlappingRanges(t *testing.T) {
 
 	rangeManager := mock.NewMockRangeManager(ctrl)
 	namespace := committed.Namespace("ns")
-	rng := committed.Range{MinKey: committed.Key("a"), MaxKey: committed.Key("g")}
-	rng2 := committed.Range{MinKey: committed.Key("c"), MaxKey: committed.Key("l")}
+	rng := committed.Range{MinKey: committed.Key("a"), MaxKey: committed.Key("g"), FormatVersion: committed.CurrentFormatVersion}
+	rng2 := committed.Range{MinKey: committed.Key("c"), MaxKey: committed.Key("l"), FormatVersion: committed.CurrentFormatVersion}
 	w := committed.NewGeneralMetaRangeWriter(ctx, rangeManager, rangeManager, &params, namespace, nil)
 	err := w.WriteRange(rng)
 	if err != nil {
@@ -133,7 +133,7 @@ func TestWriter_RecordRangeAndClose(t *testing.T) {
 
 	namespace := committed.Namespace("ns")
 	record := graveler.ValueRecord{Key: nil, Value: &graveler.Value{}}
-	rng := committed.Range{ID: "rng2-id", MinKey: committed.Key("a"), MaxKey: committed.Key("g"), Count: 4}
+	rng := committed.Range{ID: "rng2-id", MinKey: committed.Key("a"), MaxKey: committed.Key("g"), Count: 4, FormatVersion: committed.CurrentFormatVersion}
 
 	// get writer - once for record writer, once for range writer
 	rangeManager.EXPECT().GetWriter(gomock.Any(), gomock.Any(), gomock.Any()).Return(fakeWriter, nil)
@@ -0,0 +1,173 @@
+package committed
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/logging"
+)
+
+// prefixPosition is the position of a key or a Range relative to the keys starting with a prefix
+type prefixPosition int
+
+const (
+	beforePrefix prefixPosition = iota
+	insidePrefix
+	afterPrefix
+	// acrossPrefix is a Range holding keys both inside and outside the prefix
+	acrossPrefix
+)
+
+type prefixBounds struct {
+	prefix graveler.Key
+	// upper is the exclusive upper bound of keys starting with prefix, nil if there is none
+	upper graveler.Key
+}
+
+func newPrefixBounds(prefix graveler.Key) prefixBounds {
+	return prefixBounds{prefix: prefix, upper: graveler.UpperBoundForPrefix(prefix)}
+}
+
+func (b prefixBounds) position(key []byte) prefixPosition {
+	if bytes.Compare(key, b.prefix) < 0 {
+		return beforePrefix
+	}
+	if b.upper == nil || bytes.Compare(key, b.upper) < 0 {
+		return insidePrefix
+	}
+	return afterPrefix
+}
+
+func (b prefixBounds) rangePosition(rng *Range) prefixPosition {
+	minPosition := b.position(rng.MinKey)
+	if minPosition != b.position(rng.MaxKey) {
+		return acrossPrefix
+	}
+	return minPosition
+}
+
+// ReplacePrefix writes to writer the records of dest that do not start with prefix followed by
+// the records of source that do, dropping prefix when source is nil.  Ranges entirely inside or
+// outside prefix are copied or skipped whole, so when Range boundaries align with prefix its cost
+// is in the number of Ranges rather than of records.  The summary counts the records removed
+// from dest and added from source, using the counts recorded in whole Ranges.  It returns
+// ErrNoChanges if dest and source hold no records under prefix.
+func ReplacePrefix(ctx context.Context, writer MetaRangeWriter, dest, source Iterator, prefix graveler.Key) (graveler.DiffSummary, error) {
+	logger := logging.FromContext(ctx)
+	summary := graveler.DiffSummary{Count: make(map[graveler.DiffType]int)}
+	bounds := newPrefixBounds(prefix)
+	changed := false
+	sourceDone := source == nil
+	writeSource := func() error {
+		if sourceDone {
+			return nil
+		}
+		sourceDone = true
+		added, copied, err := copyPrefix(writer, source, bounds)
+		addIntoDiffSummary(&summary, graveler.DiffTypeAdded, added)
+		changed = changed || copied
+		return err
+	}
+
+	for have := dest.Next(); have; {
+		record, rng := dest.Value()
+		if record == nil {
+			var err error
+			switch bounds.rangePosition(rng) {
+			case beforePrefix:
+				err = writer.WriteRange(*rng)
+			case insidePrefix:
+				if logger.IsTracing() {
+					logger.WithFields(logging.Fields{
+						"from": string(rng.MinKey),
+						"to":   string(rng.MaxKey),
+						"ID":   rng.ID,
+					}).Trace("drop entire range inside prefix")
+				}
+				addIntoDiffSummary(&summary, graveler.DiffTypeRemoved, int(rng.Count))
+				changed = true
+			case afterPrefix:
+				if err = writeSource(); err == nil {
+					err = writer.WriteRange(*rng)
+				}
+			default:
+				// range crosses a bound of prefix, scan its records
+				have = dest.Next()
+				continue
+			}
+			if err != nil {
+				return summary, fmt.Errorf("range %s: %w", rng.ID, err)
+			}
+			have = dest.NextRange()
+			continue
+		}
+		var err error
+		switch bounds.position(record.Key) {
+		case beforePrefix:
+			err = writer.WriteRecord(*record)
+		case insidePrefix:
+			incrementDiffSummary(&summary, graveler.DiffTypeRemoved)
+			changed = true
+		case afterPrefix:
+			if err = writeSource(); err == nil {
+				err = writer.WriteRecord(*record)
+			}
+		}
+		if err != nil {
+			return summary, fmt.Errorf("write record: %w", err)
+		}
+		have = dest.Next()
+	}
+	if err := dest.Err(); err != nil {
+		return summary, err
+	}
+	if err := writeSource(); err != nil {
+		return summary, err
+	}
+	if !changed {
+		return summary, graveler.ErrNoChanges
+	}
+	return summary, nil
+}
+
+// copyPrefix writes the records of source starting with the prefix of bounds to writer.  It
+// returns the number of records written and whether any were written.
+func copyPrefix(writer MetaRangeWriter, source Iterator, bounds prefixBounds) (int, bool, error) {
+	added := 0
+	copied := false
+	for have := source.Next(); have; {
+		record, rng := source.Value()
+		if record == nil {
+			switch bounds.rangePosition(rng) {
+			case beforePrefix:
+				have = source.NextRange()
+			case insidePrefix:
+				if err := writer.WriteRange(*rng); err != nil {
+					return added, copied, fmt.Errorf("copy source range %s: %w", rng.ID, err)
+				}
+				added += int(rng.Count)
+				copied = true
+				have = source.NextRange()
+			case afterPrefix:
+				return added, copied, source.Err()
+			default:
+				have = source.Next()
+			}
+			continue
+		}
+		switch bounds.position(record.Key) {
+		case insidePrefix:
+			if err := writer.WriteRecord(*record); err != nil {
+				return added, copied, fmt.Errorf("write source record: %w", err)
+			}
+			added++
+			copied = true
+		case afterPrefix:
+			return added, copied, source.Err()
+		}
+		have = source.Next()
+	}
+	return added, copied, source.Err()
+}
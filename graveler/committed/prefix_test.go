@@ -0,0 +1,86 @@
+package committed_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/graveler/committed"
+	"github.com/treeverse/lakefs/graveler/testutil"
+)
+
+// recordingWriter records what is written to it as range IDs or record keys
+type recordingWriter struct {
+	written []string
+}
+
+func (w *recordingWriter) WriteRecord(record graveler.ValueRecord) error {
+	w.written = append(w.written, "record:"+string(record.Key))
+	return nil
+}
+
+func (w *recordingWriter) WriteRange(rng committed.Range) error {
+	w.written = append(w.written, "range:"+string(rng.ID))
+	return nil
+}
+
+func (w *recordingWriter) Close() (*graveler.MetaRangeID, error) {
+	return nil, nil
+}
+
+func (w *recordingWriter) Abort() error {
+	return nil
+}
+
+func newDestIterator() *testutil.FakeIterator {
+	dest := testutil.NewFakeIterator()
+	dest.
+		AddRange(&committed.Range{ID: "before", MinKey: committed.Key("a/1"), MaxKey: committed.Key("a/9"), Count: 2}).
+		AddValueRecords(makeV("a/1", "dest:a/1"), makeV("a/9", "dest:a/9")).
+		AddRange(&committed.Range{ID: "inside", MinKey: committed.Key("b/1"), MaxKey: committed.Key("b/5"), Count: 2}).
+		AddValueRecords(makeV("b/1", "dest:b/1"), makeV("b/5", "dest:b/5")).
+		AddRange(&committed.Range{ID: "across", MinKey: committed.Key("b/7"), MaxKey: committed.Key("c/2"), Count: 2}).
+		AddValueRecords(makeV("b/7", "dest:b/7"), makeV("c/2", "dest:c/2")).
+		AddRange(&committed.Range{ID: "after", MinKey: committed.Key("d/1"), MaxKey: committed.Key("d/2"), Count: 2}).
+		AddValueRecords(makeV("d/1", "dest:d/1"), makeV("d/2", "dest:d/2"))
+	return dest
+}
+
+func TestReplacePrefix(t *testing.T) {
+	ctx := context.Background()
+	t.Run("drop", func(t *testing.T) {
+		writer := &recordingWriter{}
+		summary, err := committed.ReplacePrefix(ctx, writer, newDestIterator(), nil, graveler.Key("b/"))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"range:before", "record:c/2", "range:after"}, writer.written)
+		assert.Equal(t, 3, summary.Count[graveler.DiffTypeRemoved])
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		source := testutil.NewFakeIterator()
+		source.
+			AddRange(&committed.Range{ID: "source-before", MinKey: committed.Key("a/5"), MaxKey: committed.Key("a/6"), Count: 1}).
+			AddValueRecords(makeV("a/5", "source:a/5")).
+			AddRange(&committed.Range{ID: "source-inside", MinKey: committed.Key("b/2"), MaxKey: committed.Key("b/3"), Count: 2}).
+			AddValueRecords(makeV("b/2", "source:b/2"), makeV("b/3", "source:b/3")).
+			AddRange(&committed.Range{ID: "source-across", MinKey: committed.Key("b/8"), MaxKey: committed.Key("e/1"), Count: 2}).
+			AddValueRecords(makeV("b/8", "source:b/8"), makeV("e/1", "source:e/1"))
+		writer := &recordingWriter{}
+		summary, err := committed.ReplacePrefix(ctx, writer, newDestIterator(), source, graveler.Key("b/"))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"range:before", "range:source-inside", "record:b/8", "record:c/2", "range:after"}, writer.written)
+		assert.Equal(t, 3, summary.Count[graveler.DiffTypeRemoved])
+		assert.Equal(t, 3, summary.Count[graveler.DiffTypeAdded])
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		writer := &recordingWriter{}
+		_, err := committed.ReplacePrefix(ctx, writer, newDestIterator(), nil, graveler.Key("x/"))
+		if !errors.Is(err, graveler.ErrNoChanges) {
+			t.Errorf("ReplacePrefix of missing prefix returned %v, expected %s", err, graveler.ErrNoChanges)
+		}
+		assert.Equal(t, []string{"range:before", "range:inside", "range:across", "range:after"}, writer.written)
+	})
+}
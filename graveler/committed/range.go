@@ -9,6 +9,8 @@ type Range struct {
 	MaxKey        Key
 	EstimatedSize uint64 // EstimatedSize estimated Range size in bytes
 	Count         int64
+	// FormatVersion of the Range, that of the MetaRange holding it.  It is not serialized.
+	FormatVersion int
 }
 
 func MarshalRange(r Range) ([]byte, error) {
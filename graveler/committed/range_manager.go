@@ -57,6 +57,9 @@ type RangeManager interface {
 	// NewRangeIterator returns an iterator over values in the Range with ID.
 	NewRangeIterator(ctx context.Context, ns Namespace, pid ID) (ValueIterator, error)
 
+	// GetMetadata returns the metadata written with the Range referenced by id.
+	GetMetadata(ctx context.Context, ns Namespace, id ID) (graveler.Metadata, error)
+
 	// GetWriter returns a new Range writer instance
 	GetWriter(ctx context.Context, ns Namespace, metadata graveler.Metadata) (RangeWriter, error)
 }
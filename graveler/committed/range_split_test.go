@@ -0,0 +1,59 @@
+package committed_test
+
+import (
+	"testing"
+
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/graveler/committed"
+)
+
+func TestKeyPrefix(t *testing.T) {
+	cases := []struct {
+		Key      string
+		Depth    int
+		Expected string
+	}{
+		{Key: "table/date=2021-01-01/part-0", Depth: 1, Expected: "table/"},
+		{Key: "table/date=2021-01-01/part-0", Depth: 2, Expected: "table/date=2021-01-01/"},
+		{Key: "table/date=2021-01-01/part-0", Depth: 3, Expected: "table/date=2021-01-01/"},
+		{Key: "table/_SUCCESS", Depth: 2, Expected: "table/"},
+		{Key: "README", Depth: 1, Expected: ""},
+		{Key: "", Depth: 1, Expected: ""},
+	}
+	for _, tt := range cases {
+		t.Run(tt.Key, func(t *testing.T) {
+			got := string(committed.KeyPrefix(graveler.Key(tt.Key), tt.Depth))
+			if got != tt.Expected {
+				t.Errorf("KeyPrefix(%s, %d) = %s, expected %s", tt.Key, tt.Depth, got, tt.Expected)
+			}
+		})
+	}
+}
+
+func TestParams_WithRangeSplitPolicy(t *testing.T) {
+	defaults := committed.Params{
+		MinRangeSizeBytes:          0,
+		MaxRangeSizeBytes:          50_000,
+		RangeSizeEntriesRaggedness: 100,
+		MaxUploaders:               3,
+	}
+	if got := defaults.WithRangeSplitPolicy(nil); got != defaults {
+		t.Errorf("WithRangeSplitPolicy(nil) = %+v, expected %+v", got, defaults)
+	}
+	got := defaults.WithRangeSplitPolicy(&graveler.RangeSplitPolicy{
+		MinRangeSizeBytes:   1_000,
+		MaxRangeEntries:     500,
+		PrefixBoundaryDepth: 2,
+	})
+	expected := committed.Params{
+		MinRangeSizeBytes:          1_000,
+		MaxRangeSizeBytes:          50_000,
+		RangeSizeEntriesRaggedness: 100,
+		MaxRangeEntries:            500,
+		PrefixBoundaryDepth:        2,
+		MaxUploaders:               3,
+	}
+	if got != expected {
+		t.Errorf("WithRangeSplitPolicy() = %+v, expected %+v", got, expected)
+	}
+}
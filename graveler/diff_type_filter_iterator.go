@@ -0,0 +1,42 @@
+package graveler
+
+// DiffTypeFilterIterator skips the changes of an underlying diff iterator whose types are not
+// selected by a mask
+type DiffTypeFilterIterator struct {
+	it   DiffIterator
+	mask DiffTypeMask
+}
+
+// NewDiffTypeFilterIterator returns an iterator over the changes of it selected by mask.  It
+// returns it unchanged when mask selects every type of change.
+func NewDiffTypeFilterIterator(it DiffIterator, mask DiffTypeMask) DiffIterator {
+	if mask == DiffTypeMaskAll {
+		return it
+	}
+	return &DiffTypeFilterIterator{it: it, mask: mask}
+}
+
+func (d *DiffTypeFilterIterator) Next() bool {
+	for d.it.Next() {
+		if d.mask.Has(d.it.Value().Type) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *DiffTypeFilterIterator) SeekGE(id Key) {
+	d.it.SeekGE(id)
+}
+
+func (d *DiffTypeFilterIterator) Value() *Diff {
+	return d.it.Value()
+}
+
+func (d *DiffTypeFilterIterator) Err() error {
+	return d.it.Err()
+}
+
+func (d *DiffTypeFilterIterator) Close() {
+	d.it.Close()
+}
@@ -0,0 +1,48 @@
+package graveler_test
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/graveler/testutil"
+)
+
+func TestDiffTypeFilterIterator(t *testing.T) {
+	diffs := []graveler.Diff{
+		{Key: graveler.Key("a"), Type: graveler.DiffTypeAdded},
+		{Key: graveler.Key("b"), Type: graveler.DiffTypeRemoved},
+		{Key: graveler.Key("c"), Type: graveler.DiffTypeChanged},
+		{Key: graveler.Key("d"), Type: graveler.DiffTypeAdded},
+		{Key: graveler.Key("e"), Type: graveler.DiffTypeConflict},
+		{Key: graveler.Key("f"), Type: graveler.DiffTypeRemoved},
+	}
+	tests := []struct {
+		name         string
+		mask         graveler.DiffTypeMask
+		expectedKeys []string
+	}{
+		{name: "all", mask: graveler.DiffTypeMaskAll, expectedKeys: []string{"a", "b", "c", "d", "e", "f"}},
+		{name: "added", mask: graveler.NewDiffTypeMask(graveler.DiffTypeAdded), expectedKeys: []string{"a", "d"}},
+		{name: "removed", mask: graveler.NewDiffTypeMask(graveler.DiffTypeRemoved), expectedKeys: []string{"b", "f"}},
+		{name: "conflict", mask: graveler.NewDiffTypeMask(graveler.DiffTypeConflict), expectedKeys: []string{"e"}},
+		{name: "removed or changed", mask: graveler.NewDiffTypeMask(graveler.DiffTypeRemoved, graveler.DiffTypeChanged), expectedKeys: []string{"b", "c", "f"}},
+		{name: "empty mask", mask: graveler.NewDiffTypeMask(), expectedKeys: []string{"a", "b", "c", "d", "e", "f"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			it := graveler.NewDiffTypeFilterIterator(testutil.NewDiffIter(diffs), tt.mask)
+			defer it.Close()
+			var keys []string
+			for it.Next() {
+				keys = append(keys, string(it.Value().Key))
+			}
+			if err := it.Err(); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if diff := deep.Equal(keys, tt.expectedKeys); diff != nil {
+				t.Error("unexpected keys:", diff)
+			}
+		})
+	}
+}
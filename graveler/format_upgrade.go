@@ -0,0 +1,93 @@
+package graveler
+
+import (
+	"context"
+	"fmt"
+)
+
+// UpgradeTreesCommitMessage is the message of commits upgrading the tree format of a branch
+const UpgradeTreesCommitMessage = "Upgrade tree format"
+
+// UpgradeTrees rewrites the MetaRange of the head commit of every branch of the repository in
+// the current tree format version.  Each upgraded branch gets a commit holding the rewritten
+// MetaRange, with the same contents; uncommitted changes stay staged.  Older commits keep their
+// trees, which are upgraded when read.  It returns the upgrade commit of each upgraded branch.
+func (g *Graveler) UpgradeTrees(ctx context.Context, repositoryID RepositoryID, committer string) (map[BranchID]CommitID, error) {
+	branchesIt, err := g.RefManager.ListBranches(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	var branchIDs []BranchID
+	for branchesIt.Next() {
+		branchIDs = append(branchIDs, branchesIt.Value().BranchID)
+	}
+	err = branchesIt.Err()
+	branchesIt.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	upgraded := make(map[BranchID]CommitID)
+	for _, branchID := range branchIDs {
+		commitID, err := g.upgradeBranchTree(ctx, repositoryID, branchID, committer)
+		if err != nil {
+			return nil, fmt.Errorf("upgrade branch %s: %w", branchID, err)
+		}
+		if commitID != "" {
+			upgraded[branchID] = commitID
+		}
+	}
+	return upgraded, nil
+}
+
+// upgradeBranchTree commits the upgraded MetaRange of the branch head, returns an empty
+// CommitID if the branch needs no upgrade
+func (g *Graveler) upgradeBranchTree(ctx context.Context, repositoryID RepositoryID, branchID BranchID, committer string) (CommitID, error) {
+	res, err := g.branchLocker.MetadataUpdater(ctx, repositoryID, branchID, func() (interface{}, error) {
+		repo, err := g.RefManager.GetRepository(ctx, repositoryID)
+		if err != nil {
+			return nil, err
+		}
+		branch, err := g.RefManager.GetBranch(ctx, repositoryID, branchID)
+		if err != nil {
+			return nil, err
+		}
+		if branch.CommitID == "" {
+			return CommitID(""), nil
+		}
+		head, err := g.RefManager.GetCommit(ctx, repositoryID, branch.CommitID)
+		if err != nil {
+			return nil, err
+		}
+		metaRangeID, err := g.CommittedManager.Upgrade(WithRangeSplitPolicy(ctx, repo.RangeSplit), repo.StorageNamespace, head.MetaRangeID)
+		if err != nil {
+			return nil, err
+		}
+		if metaRangeID == head.MetaRangeID {
+			return CommitID(""), nil
+		}
+		commitID, err := g.RefManager.AddCommit(ctx, repositoryID, Commit{
+			Committer:    committer,
+			Message:      UpgradeTreesCommitMessage,
+			MetaRangeID:  metaRangeID,
+			CreationDate: commitCreationDate(head),
+			Parents:      CommitParents{branch.CommitID},
+			Metadata:     Metadata{"upgraded_meta_range_id": string(head.MetaRangeID)},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("add commit: %w", err)
+		}
+		err = g.RefManager.SetBranch(ctx, repositoryID, branchID, Branch{
+			CommitID:     commitID,
+			StagingToken: branch.StagingToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("set branch commit %s: %w", commitID, err)
+		}
+		return commitID, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return res.(CommitID), nil
+}
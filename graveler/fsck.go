@@ -0,0 +1,237 @@
+package graveler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+)
+
+type FsckSeverity string
+
+const (
+	// FsckSeverityError is an inconsistency that breaks reading or writing the repository
+	FsckSeverityError FsckSeverity = "error"
+	// FsckSeverityWarning is an inconsistency that does not affect reading or writing the repository
+	FsckSeverityWarning FsckSeverity = "warning"
+)
+
+// Checks reported by Fsck
+const (
+	FsckCheckDefaultBranch   = "default_branch"
+	FsckCheckBranchCommit    = "branch_commit"
+	FsckCheckTagCommit       = "tag_commit"
+	FsckCheckCommitParent    = "commit_parent"
+	FsckCheckCommitCycle     = "commit_cycle"
+	FsckCheckMetaRange       = "metarange"
+	FsckCheckMetaRangeOrder  = "metarange_order"
+	FsckCheckUnreachedCommit = "unreachable_commit"
+)
+
+// FsckFinding is an inconsistency found by Fsck in Object, a branch, tag, commit or MetaRange
+type FsckFinding struct {
+	Severity FsckSeverity `json:"severity"`
+	Check    string       `json:"check"`
+	Object   string       `json:"object"`
+	Message  string       `json:"message"`
+}
+
+type FsckReport struct {
+	Branches   int           `json:"branches"`
+	Tags       int           `json:"tags"`
+	Commits    int           `json:"commits"`
+	MetaRanges int           `json:"metaranges"`
+	Findings   []FsckFinding `json:"findings"`
+}
+
+// HasErrors returns true if the report holds a finding of error severity
+func (r *FsckReport) HasErrors() bool {
+	for _, f := range r.Findings {
+		if f.Severity == FsckSeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *FsckReport) add(severity FsckSeverity, check, object, format string, args ...interface{}) {
+	r.Findings = append(r.Findings, FsckFinding{
+		Severity: severity,
+		Check:    check,
+		Object:   object,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Fsck verifies the integrity of the repository: the default branch exists, branches and tags
+// point to existing commits, commit parents exist and do not form a cycle, and the MetaRange of
+// every commit is readable with its keys in ascending order.  Commits not reachable from any
+// branch or tag are reported as warnings.  Errors reading the repository records themselves are
+// returned rather than reported.
+func (g *Graveler) Fsck(ctx context.Context, repositoryID RepositoryID) (*FsckReport, error) {
+	repo, err := g.RefManager.GetRepository(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	report := &FsckReport{Findings: make([]FsckFinding, 0)}
+
+	commitsIt, err := g.RefManager.ListCommits(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	commits := make(map[CommitID]*Commit)
+	for commitsIt.Next() {
+		rec := commitsIt.Value()
+		commits[rec.CommitID] = rec.Commit
+	}
+	err = commitsIt.Err()
+	commitsIt.Close()
+	if err != nil {
+		return nil, err
+	}
+	report.Commits = len(commits)
+
+	// refs
+	var heads []CommitID
+	branchesIt, err := g.RefManager.ListBranches(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	defaultBranchFound := false
+	for branchesIt.Next() {
+		b := branchesIt.Value()
+		report.Branches++
+		if b.BranchID == repo.DefaultBranchID {
+			defaultBranchFound = true
+		}
+		if _, ok := commits[b.CommitID]; !ok {
+			report.add(FsckSeverityError, FsckCheckBranchCommit, b.BranchID.String(), "branch commit %s not found", b.CommitID)
+			continue
+		}
+		heads = append(heads, b.CommitID)
+	}
+	err = branchesIt.Err()
+	branchesIt.Close()
+	if err != nil {
+		return nil, err
+	}
+	if !defaultBranchFound {
+		report.add(FsckSeverityError, FsckCheckDefaultBranch, repo.DefaultBranchID.String(), "default branch not found")
+	}
+	tagsIt, err := g.RefManager.ListTags(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	for tagsIt.Next() {
+		t := tagsIt.Value()
+		report.Tags++
+		if _, ok := commits[t.CommitID]; !ok {
+			report.add(FsckSeverityError, FsckCheckTagCommit, t.TagID.String(), "tag commit %s not found", t.CommitID)
+			continue
+		}
+		heads = append(heads, t.CommitID)
+	}
+	err = tagsIt.Err()
+	tagsIt.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	// commits, ordered for a stable report
+	ids := make([]CommitID, 0, len(commits))
+	for id := range commits {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		for _, parent := range commits[id].Parents {
+			if _, ok := commits[parent]; !ok {
+				report.add(FsckSeverityError, FsckCheckCommitParent, id.String(), "parent commit %s not found", parent)
+			}
+		}
+	}
+	fsckCycles(report, ids, commits)
+
+	reached := make(map[CommitID]struct{}, len(commits))
+	for len(heads) > 0 {
+		id := heads[len(heads)-1]
+		heads = heads[:len(heads)-1]
+		if _, ok := reached[id]; ok {
+			continue
+		}
+		reached[id] = struct{}{}
+		if commit, ok := commits[id]; ok {
+			heads = append(heads, commit.Parents...)
+		}
+	}
+	for _, id := range ids {
+		if _, ok := reached[id]; !ok {
+			report.add(FsckSeverityWarning, FsckCheckUnreachedCommit, id.String(), "commit not reachable from any branch or tag")
+		}
+	}
+
+	// metaranges, each read once
+	checked := make(map[MetaRangeID]struct{})
+	for _, id := range ids {
+		metaRangeID := commits[id].MetaRangeID
+		if metaRangeID == "" {
+			continue
+		}
+		if _, ok := checked[metaRangeID]; ok {
+			continue
+		}
+		checked[metaRangeID] = struct{}{}
+		if err := g.fsckMetaRange(ctx, report, repo.StorageNamespace, metaRangeID); err != nil {
+			report.add(FsckSeverityError, FsckCheckMetaRange, string(metaRangeID), "metarange of commit %s not readable: %s", id, err)
+		}
+	}
+	report.MetaRanges = len(checked)
+	return report, nil
+}
+
+// fsckMetaRange reads all values of metaRangeID, reporting keys not in ascending order
+func (g *Graveler) fsckMetaRange(ctx context.Context, report *FsckReport, ns StorageNamespace, metaRangeID MetaRangeID) error {
+	it, err := g.CommittedManager.List(ctx, ns, metaRangeID)
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	var last Key
+	for it.Next() {
+		key := it.Value().Key
+		if last != nil && bytes.Compare(last, key) >= 0 {
+			report.add(FsckSeverityError, FsckCheckMetaRangeOrder, string(metaRangeID), "key %s follows key %s", key, last)
+		}
+		last = key.Copy()
+	}
+	return it.Err()
+}
+
+// fsckCycles reports each commit closing a cycle of parent links
+func fsckCycles(report *FsckReport, ids []CommitID, commits map[CommitID]*Commit) {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[CommitID]int, len(commits))
+	var visit func(id CommitID)
+	visit = func(id CommitID) {
+		state[id] = visiting
+		if commit, ok := commits[id]; ok {
+			for _, parent := range commit.Parents {
+				switch state[parent] {
+				case visiting:
+					report.add(FsckSeverityError, FsckCheckCommitCycle, id.String(), "parent commit %s is also a descendant", parent)
+				case 0:
+					visit(parent)
+				}
+			}
+		}
+		state[id] = done
+	}
+	for _, id := range ids {
+		if state[id] == 0 {
+			visit(id)
+		}
+	}
+}
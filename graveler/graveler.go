@@ -27,6 +27,26 @@ const (
 	DiffTypeConflict
 )
 
+// DiffTypeMask selects the types of changes returned by a diff
+type DiffTypeMask uint8
+
+// DiffTypeMaskAll selects every type of change
+const DiffTypeMaskAll DiffTypeMask = 0
+
+// NewDiffTypeMask returns a mask selecting types.  It selects every type of change when types is empty.
+func NewDiffTypeMask(types ...DiffType) DiffTypeMask {
+	var m DiffTypeMask
+	for _, t := range types {
+		m |= 1 << t
+	}
+	return m
+}
+
+// Has returns true if m selects changes of type t
+func (m DiffTypeMask) Has(t DiffType) bool {
+	return m == DiffTypeMaskAll || m&(1<<t) != 0
+}
+
 type DiffSummary struct {
 	Count map[DiffType]int
 }
@@ -63,6 +83,9 @@ type Ref string
 // TagID represents a named tag pointing at a commit
 type TagID string
 
+// RepositorySnapshotID identifies a recording of the heads of all branches of a repository
+type RepositorySnapshotID string
+
 type CommitParents []CommitID
 
 // BranchID is an identifier for a branch
@@ -85,6 +108,8 @@ type Repository struct {
 	StorageNamespace StorageNamespace `db:"storage_namespace"`
 	CreationDate     time.Time        `db:"creation_date"`
 	DefaultBranchID  BranchID         `db:"default_branch"`
+	// RangeSplit tunes the split of trees of the repository into ranges, or nil for the defaults
+	RangeSplit *RangeSplitPolicy `db:"range_split"`
 }
 
 type RepositoryRecord struct {
@@ -108,6 +133,16 @@ func (v *ValueRecord) IsTombstone() bool {
 	return v.Value == nil
 }
 
+// StagingStats summarizes the uncommitted changes of a staging area
+type StagingStats struct {
+	// Writes is the number of staged values, each adding or changing a key
+	Writes int64 `db:"writes"`
+	// Deletes is the number of staged tombstones
+	Deletes int64 `db:"deletes"`
+	// SizeBytes is the total size of the staged values
+	SizeBytes int64 `db:"size_bytes"`
+}
+
 func (cp CommitParents) Identity() []byte {
 	commits := make([]string, len(cp))
 	for i, v := range cp {
@@ -140,24 +175,21 @@ const FirstCommitMsg = "Repository created"
 
 // Commit represents commit metadata (author, time, MetaRangeID)
 type Commit struct {
-	Committer    string        `db:"committer"`
-	Message      string        `db:"message"`
-	MetaRangeID  MetaRangeID   `db:"meta_range_id"`
-	CreationDate time.Time     `db:"creation_date"`
-	Parents      CommitParents `db:"parents"`
-	Metadata     Metadata      `db:"metadata"`
-}
-
-func (c Commit) Identity() []byte {
-	b := ident.NewAddressWriter()
-	b.MarshalString("commit:v1")
-	b.MarshalString(c.Committer)
-	b.MarshalString(c.Message)
-	b.MarshalString(string(c.MetaRangeID))
-	b.MarshalInt64(c.CreationDate.Unix())
-	b.MarshalStringMap(c.Metadata)
-	b.MarshalIdentifiable(c.Parents)
-	return b.Identity()
+	Committer      string        `db:"committer"`
+	CommitterEmail string        `db:"committer_email"`
+	Message        string        `db:"message"`
+	MetaRangeID    MetaRangeID   `db:"meta_range_id"`
+	CreationDate   time.Time     `db:"creation_date"`
+	Parents        CommitParents `db:"parents"`
+	Metadata       Metadata      `db:"metadata"`
+	// Author, AuthorEmail and AuthorDate identify who made the change when it differs from the
+	// committer, the committer made the change on CreationDate when they are empty.
+	Author      string    `db:"author"`
+	AuthorEmail string    `db:"author_email"`
+	AuthorDate  time.Time `db:"author_date"`
+	// Generation is 1 for root commits and 1 + the highest generation of the parents otherwise,
+	// 0 when unknown.  It is computed when the commit is stored and is not part of its identity.
+	Generation int64 `db:"generation"`
 }
 
 // CommitRecord holds CommitID with the associated Commit data
@@ -178,6 +210,49 @@ type BranchRecord struct {
 	*Branch
 }
 
+// ListingSnapshot pins the state a listing of a reference reads from, so a paginated listing can
+// be continued from the same commit and staging area it started with.
+// StagingToken is set only when the reference is a branch with staged changes at the time of the
+// snapshot.  While Live it is the staging area of the branch itself, PinSnapshot replaces it with a
+// copy of those changes that later writes to the branch do not modify.
+// BranchID is the branch the staging area belongs to, a copy is only listed for that branch.
+type ListingSnapshot struct {
+	CommitID     CommitID
+	BranchID     BranchID
+	StagingToken StagingToken
+	Live         bool
+}
+
+// RepositorySnapshot is the head commit of every branch of a repository at CreationDate
+type RepositorySnapshot struct {
+	SnapshotID   RepositorySnapshotID
+	CreationDate time.Time
+	Branches     map[BranchID]CommitID
+}
+
+// BranchLogRecord is an entry of the log of the commits a branch pointed to
+type BranchLogRecord struct {
+	BranchID     BranchID  `db:"branch_id"`
+	CommitID     CommitID  `db:"commit_id"`
+	CreationDate time.Time `db:"creation_date"`
+}
+
+// LabeledRefType is the type of the refs labels are attached to
+type LabeledRefType string
+
+const (
+	LabeledRefBranch LabeledRefType = "branch"
+	LabeledRefTag    LabeledRefType = "tag"
+	LabeledRefCommit LabeledRefType = "commit"
+)
+
+// RefLabels are the free-form labels of a branch, tag or commit, indexed for search
+type RefLabels struct {
+	Type   LabeledRefType
+	ID     string
+	Labels map[string]string
+}
+
 // TagRecord holds TagID with the associated Tag data
 type TagRecord struct {
 	TagID    TagID
@@ -202,11 +277,25 @@ func (d *Diff) Copy() *Diff {
 }
 
 type CommitParams struct {
-	Committer string
-	Message   string
-	Metadata  Metadata
+	Committer      string
+	CommitterEmail string
+	Message        string
+	Metadata       Metadata
+	// Author, AuthorEmail and AuthorDate are set when the change was made by someone other
+	// than the committer, or at another time
+	Author      string
+	AuthorEmail string
+	AuthorDate  time.Time
 }
 
+// SetCondition checks the current value of a key before a conditional set replaces it, value is nil
+// if the key does not exist.  It returns an error (usually ErrPreconditionFailed) to fail the set.
+type SetCondition func(value *Value) error
+
+// StagedCondition checks the value staged on a key before a conditional set replaces it.  staged
+// is false if nothing is staged on the key, value is nil if a tombstone is staged.
+type StagedCondition func(value *Value, staged bool) error
+
 type PreCommitFunc func(ctx context.Context, repositoryID RepositoryID, branch BranchID, commit Commit) error
 type PreMergeFunc func(ctx context.Context, repositoryID RepositoryID, destination BranchID, source Ref, commit Commit) error
 
@@ -215,26 +304,57 @@ type KeyValueStore interface {
 	// returns error if value does not exist
 	Get(ctx context.Context, repositoryID RepositoryID, ref Ref, key Key) (*Value, error)
 
+	// GetMany returns the values of keys from repository / reference, resolving reference once.
+	// Values are returned in the order of keys, nil for keys that do not exist
+	GetMany(ctx context.Context, repositoryID RepositoryID, ref Ref, keys []Key) ([]*Value, error)
+
 	// Set stores value on repository / branch by key. nil value is a valid value for tombstone
 	Set(ctx context.Context, repositoryID RepositoryID, branchID BranchID, key Key, value Value) error
 
+	// SetIf stores value on repository / branch by key if condition accepts the current value of key.
+	// Returns the error of condition if it rejects the current value
+	SetIf(ctx context.Context, repositoryID RepositoryID, branchID BranchID, key Key, value Value, condition SetCondition) error
+
 	// Delete value from repository / branch branch by key
 	Delete(ctx context.Context, repositoryID RepositoryID, branchID BranchID, key Key) error
 
 	// List lists values on repository / ref
 	List(ctx context.Context, repositoryID RepositoryID, ref Ref) (ValueIterator, error)
+
+	// Snapshot resolves ref into the commit and staging area a consistent listing should read from.
+	// The staging area is not copied, the snapshot must be pinned before it is listed again later.
+	Snapshot(ctx context.Context, repositoryID RepositoryID, ref Ref) (*ListingSnapshot, error)
+
+	// PinSnapshot copies the staging area of a live snapshot, so later writes to its branch do not
+	// change what the snapshot lists
+	PinSnapshot(ctx context.Context, repositoryID RepositoryID, snapshot *ListingSnapshot) error
+
+	// DropExpiredSnapshots drops the copies of staging areas pinned by expired snapshots
+	DropExpiredSnapshots(ctx context.Context) (int, error)
+
+	// ListSnapshot lists values on repository as of snapshot.
+	// Returns ErrSnapshotExpired if the staging area pinned by the snapshot is no longer available.
+	ListSnapshot(ctx context.Context, repositoryID RepositoryID, snapshot ListingSnapshot) (ValueIterator, error)
 }
 
 type VersionController interface {
 	// GetRepository returns the Repository metadata object for the given RepositoryID
 	GetRepository(ctx context.Context, repositoryID RepositoryID) (*Repository, error)
 
+	// SetRangeSplitPolicy sets how trees of the repository written from now on are split into
+	// ranges, nil restores the defaults
+	SetRangeSplitPolicy(ctx context.Context, repositoryID RepositoryID, policy *RangeSplitPolicy) error
+
 	// CreateRepository stores a new Repository under RepositoryID with the given Branch as default branch
 	CreateRepository(ctx context.Context, repositoryID RepositoryID, storageNamespace StorageNamespace, branchID BranchID) (*Repository, error)
 
 	// CreateBareRepository stores a new Repository under RepositoryID with no initial branch or commit
 	CreateBareRepository(ctx context.Context, repositoryID RepositoryID, storageNamespace StorageNamespace, defaultBranchID BranchID) (*Repository, error)
 
+	// ForkRepository creates a new Repository under RepositoryID with the commits, branches and tags
+	// of sourceID.  The fork shares the storage namespace, and so the data, of the source repository.
+	ForkRepository(ctx context.Context, sourceID RepositoryID, repositoryID RepositoryID) (*Repository, error)
+
 	// ListRepositories returns iterator to scan repositories
 	ListRepositories(ctx context.Context) (RepositoryIterator, error)
 
@@ -262,8 +382,76 @@ type VersionController interface {
 	// ListTags lists tags on a repository
 	ListTags(ctx context.Context, repositoryID RepositoryID) (TagIterator, error)
 
-	// Log returns an iterator starting at commit ID up to repository root
-	Log(ctx context.Context, repositoryID RepositoryID, commitID CommitID) (CommitIterator, error)
+	// CreateRepositorySnapshot atomically records the head commit of every branch of the repository
+	CreateRepositorySnapshot(ctx context.Context, repositoryID RepositoryID, snapshotID RepositorySnapshotID) (*RepositorySnapshot, error)
+
+	// GetRepositorySnapshot returns the branch heads recorded by the snapshot
+	GetRepositorySnapshot(ctx context.Context, repositoryID RepositoryID, snapshotID RepositorySnapshotID) (*RepositorySnapshot, error)
+
+	// ListRepositorySnapshots lists the snapshots of the repository, ordered by their ID
+	ListRepositorySnapshots(ctx context.Context, repositoryID RepositoryID) ([]*RepositorySnapshot, error)
+
+	// DeleteRepositorySnapshot removes the snapshot, branches are not changed
+	DeleteRepositorySnapshot(ctx context.Context, repositoryID RepositoryID, snapshotID RepositorySnapshotID) error
+
+	// RestoreRepositorySnapshot resets every branch recorded by the snapshot to its recorded head commit,
+	// dropping uncommitted changes.  Branches deleted after the snapshot was taken are created again,
+	// branches created after it are left untouched.
+	RestoreRepositorySnapshot(ctx context.Context, repositoryID RepositoryID, snapshotID RepositorySnapshotID) error
+
+	// Log returns an iterator starting at commit ID up to repository root, following only the
+	// first parent of merge commits when firstParent is set
+	Log(ctx context.Context, repositoryID RepositoryID, commitID CommitID, firstParent bool) (CommitIterator, error)
+
+	// IsAncestor returns true if the commit of 'ancestor' is reachable from the commit of 'ref'
+	IsAncestor(ctx context.Context, repositoryID RepositoryID, ancestor, ref Ref) (bool, error)
+
+	// CommitsBetween returns the commits reachable from 'to' but not from 'from', similar to 'git log from..to'
+	CommitsBetween(ctx context.Context, repositoryID RepositoryID, from, to Ref) ([]*CommitRecord, error)
+
+	// FindCommitsByMetadata returns up to limit commits with metadata 'key' set to 'value', newest
+	// first, starting after the commit 'after'.  Also returns whether more commits match.
+	FindCommitsByMetadata(ctx context.Context, repositoryID RepositoryID, key, value string, after CommitID, limit int) ([]*CommitRecord, bool, error)
+
+	// SetRefLabels sets the labels in set and removes the labels in unset of the ref of
+	// refType refID, and returns its labels
+	SetRefLabels(ctx context.Context, repositoryID RepositoryID, refType LabeledRefType, refID string, set map[string]string, unset []string) (map[string]string, error)
+
+	// GetRefLabels returns the labels of the ref of refType refID, empty if it has none
+	GetRefLabels(ctx context.Context, repositoryID RepositoryID, refType LabeledRefType, refID string) (map[string]string, error)
+
+	// FindRefsByLabels returns the refs of refType, or of every type if empty, that have all
+	// labels, ordered by type and ID
+	FindRefsByLabels(ctx context.Context, repositoryID RepositoryID, labels map[string]string, refType LabeledRefType) ([]*RefLabels, error)
+
+	// ListCommits returns an iterator over all commits of the repository, ordered by their commit ID
+	ListCommits(ctx context.Context, repositoryID RepositoryID) (CommitIterator, error)
+
+	// ExpiredCommits applies retention rules to the commits of the repository as of 'now'.
+	// pinned commits are retained like branch heads.
+	ExpiredCommits(ctx context.Context, repositoryID RepositoryID, rules RetentionRules, now time.Time, pinned []CommitID) (*ExpiredCommits, error)
+
+	// DeleteBranchLogCommits removes commitIDs from the logs of the branches of the repository,
+	// used to expire the log entries of expired commits
+	DeleteBranchLogCommits(ctx context.Context, repositoryID RepositoryID, commitIDs []CommitID) error
+
+	// DeleteCommits removes commit records, used to remove unreachable expired commits
+	DeleteCommits(ctx context.Context, repositoryID RepositoryID, commitIDs []CommitID) error
+
+	// PurgeHistory rewrites the history of the repository without 'key', or without all keys starting
+	// with 'key' when 'prefix' is set, and returns the replaced commits and the values removed
+	PurgeHistory(ctx context.Context, repositoryID RepositoryID, key Key, prefix bool) (*PurgeResult, error)
+
+	// Fsck verifies the integrity of the refs, commits and MetaRanges of the repository
+	Fsck(ctx context.Context, repositoryID RepositoryID) (*FsckReport, error)
+
+	// UpgradeTrees commits the tree of every branch head rewritten in the current tree format
+	// version, and returns the upgrade commit of each upgraded branch
+	UpgradeTrees(ctx context.Context, repositoryID RepositoryID, committer string) (map[BranchID]CommitID, error)
+
+	// FillGenerations computes the generations of commits added before generations were
+	// recorded, and returns the number of commits updated
+	FillGenerations(ctx context.Context, repositoryID RepositoryID) (int, error)
 
 	// ListBranches lists branches on repositories
 	ListBranches(ctx context.Context, repositoryID RepositoryID) (BranchIterator, error)
@@ -275,6 +463,10 @@ type VersionController interface {
 	//   ErrNothingToCommit in case there is no data in stage
 	Commit(ctx context.Context, repositoryID RepositoryID, branchID BranchID, commitParams CommitParams) (CommitID, error)
 
+	// CommitPreview applies the staged data on the branch to its head commit and returns the
+	// resulting MetaRangeID and a summary of the changes, without adding a commit or updating the branch
+	CommitPreview(ctx context.Context, repositoryID RepositoryID, branchID BranchID) (MetaRangeID, DiffSummary, error)
+
 	// WriteMetaRange accepts a ValueIterator and writes the entire iterator to a new MetaRange
 	// and returns the result ID.
 	WriteMetaRange(ctx context.Context, repositoryID RepositoryID, it ValueIterator) (*MetaRangeID, error)
@@ -303,22 +495,48 @@ type VersionController interface {
 	// Reset throws all staged data starting with the given prefix on the repository / branch
 	ResetPrefix(ctx context.Context, repositoryID RepositoryID, branchID BranchID, key Key) error
 
+	// LockBranch runs fn once the writes in flight to the repository / branch end.  Writes to
+	// the branch fail with ErrAlreadyLocked until fn returns.
+	LockBranch(ctx context.Context, repositoryID RepositoryID, branchID BranchID, fn func() error) error
+
+	// GetStagingStats returns a summary of the uncommitted changes on the repository / branch
+	GetStagingStats(ctx context.Context, repositoryID RepositoryID, branchID BranchID) (*StagingStats, error)
+
 	// Revert creates a reverse patch to the commit given as 'ref', and applies it as a new commit on the given branch.
 	Revert(ctx context.Context, repositoryID RepositoryID, branchID BranchID, ref Ref, parentNumber int, commitParams CommitParams) (CommitID, DiffSummary, error)
 
+	// DropPrefix commits the removal of all keys starting with prefix from the branch, dropping
+	// whole ranges under prefix.  The branch must have no uncommitted changes.
+	DropPrefix(ctx context.Context, repositoryID RepositoryID, branchID BranchID, prefix Key, commitParams CommitParams) (CommitID, DiffSummary, error)
+
+	// ReplacePrefixFromRef commits replacing all keys starting with prefix on the branch by the
+	// keys starting with prefix in 'source', swapping whole ranges under prefix.  The branch must
+	// have no uncommitted changes.
+	ReplacePrefixFromRef(ctx context.Context, repositoryID RepositoryID, branchID BranchID, prefix Key, source Ref, commitParams CommitParams) (CommitID, DiffSummary, error)
+
+	// ApplyChanges commits changes, sorted by key, applied directly to the committed tree of the
+	// branch without staging them.  Values set to nil delete their keys.  The branch must have
+	// no uncommitted changes.
+	ApplyChanges(ctx context.Context, repositoryID RepositoryID, branchID BranchID, changes ValueIterator, commitParams CommitParams) (CommitID, DiffSummary, error)
+
 	// Merge merges 'source' into 'destination' and returns the commit id for the created merge commit, and a summary of results.
 	Merge(ctx context.Context, repositoryID RepositoryID, destination BranchID, source Ref, commitParams CommitParams) (CommitID, DiffSummary, error)
 
-	// DiffUncommitted returns iterator to scan the changes made on the branch
-	DiffUncommitted(ctx context.Context, repositoryID RepositoryID, branchID BranchID) (DiffIterator, error)
+	// MergePreview runs the merge of 'source' into 'destination' without writing a tree or a commit, and returns
+	// the conflicts found and a summary of the changes the merge would apply.
+	MergePreview(ctx context.Context, repositoryID RepositoryID, destination BranchID, source Ref) (*MergePreview, error)
 
-	// Diff returns the changes between 'left' and 'right' ref.
+	// DiffUncommitted returns iterator to scan the changes made on the branch, of the types selected by typeMask
+	DiffUncommitted(ctx context.Context, repositoryID RepositoryID, branchID BranchID, typeMask DiffTypeMask) (DiffIterator, error)
+
+	// Diff returns the changes between 'left' and 'right' ref, of the types selected by typeMask.
 	// This is similar to a two-dot (left..right) diff in git.
-	Diff(ctx context.Context, repositoryID RepositoryID, left, right Ref) (DiffIterator, error)
+	Diff(ctx context.Context, repositoryID RepositoryID, left, right Ref, typeMask DiffTypeMask) (DiffIterator, error)
 
-	// Compare returns the difference between the commit where 'to' was last synced into 'from', and the most recent commit of `from`.
+	// Compare returns the difference between the commit where 'to' was last synced into 'from', and the most recent commit of `from`,
+	// of the types selected by typeMask.
 	// This is similar to a three-dot (from...to) diff in git.
-	Compare(ctx context.Context, repositoryID RepositoryID, from, to Ref) (DiffIterator, error)
+	Compare(ctx context.Context, repositoryID RepositoryID, from, to Ref, typeMask DiffTypeMask) (DiffIterator, error)
 
 	// PreCommitHook get current pre-commit hook function
 	PreCommitHook() PreCommitFunc
@@ -342,6 +560,12 @@ type Dumper interface {
 
 	// DumpTags iterates through all tags and dumps them in Graveler format
 	DumpTags(ctx context.Context, repositoryID RepositoryID) (*MetaRangeID, error)
+
+	// DumpStaging dumps the uncommitted changes of a branch in Graveler format
+	DumpStaging(ctx context.Context, repositoryID RepositoryID, branchID BranchID) (*MetaRangeID, error)
+
+	// DiffDumps returns the differences between two dumps of the same type
+	DiffDumps(ctx context.Context, repositoryID RepositoryID, left, right MetaRangeID) (DiffIterator, error)
 }
 
 type Loader interface {
@@ -353,6 +577,12 @@ type Loader interface {
 
 	// DumpTags iterates through all tags in Graveler format and loads them into repositoryID
 	LoadTags(ctx context.Context, repositoryID RepositoryID, metaRangeID MetaRangeID) error
+
+	// LoadStaging stages the uncommitted changes dumped in Graveler format on branchID
+	LoadStaging(ctx context.Context, repositoryID RepositoryID, branchID BranchID, metaRangeID MetaRangeID) error
+
+	// ReplayRefsJournal applies the changes to refs recorded in a refs journal to repositoryID
+	ReplayRefsJournal(ctx context.Context, repositoryID RepositoryID, entries []*RefsJournalEntry) error
 }
 
 // Internal structures used by Graveler
@@ -428,6 +658,9 @@ type RefManager interface {
 	// GetRepository returns the Repository metadata object for the given RepositoryID
 	GetRepository(ctx context.Context, repositoryID RepositoryID) (*Repository, error)
 
+	// SetRangeSplitPolicy stores the range split policy of the repository, nil for the defaults
+	SetRangeSplitPolicy(ctx context.Context, repositoryID RepositoryID, policy *RangeSplitPolicy) error
+
 	// CreateRepository stores a new Repository under RepositoryID with the given Branch as default branch
 	CreateRepository(ctx context.Context, repositoryID RepositoryID, repository Repository, token StagingToken) error
 
@@ -467,6 +700,26 @@ type RefManager interface {
 	// ListTags lists tags
 	ListTags(ctx context.Context, repositoryID RepositoryID) (TagIterator, error)
 
+	// CreateRepositorySnapshot records the current commit of all branches under snapshotID in a
+	// single operation
+	CreateRepositorySnapshot(ctx context.Context, repositoryID RepositoryID, snapshotID RepositorySnapshotID, creationDate time.Time) error
+
+	// GetRepositorySnapshot returns the snapshot stored under snapshotID
+	GetRepositorySnapshot(ctx context.Context, repositoryID RepositoryID, snapshotID RepositorySnapshotID) (*RepositorySnapshot, error)
+
+	// ListRepositorySnapshots returns all snapshots of the repository, ordered by their ID
+	ListRepositorySnapshots(ctx context.Context, repositoryID RepositoryID) ([]*RepositorySnapshot, error)
+
+	// DeleteRepositorySnapshot deletes the snapshot stored under snapshotID
+	DeleteRepositorySnapshot(ctx context.Context, repositoryID RepositoryID, snapshotID RepositorySnapshotID) error
+
+	// ListBranchLog returns the logs of all branches of the repository, ordered by branch and
+	// newest entry first
+	ListBranchLog(ctx context.Context, repositoryID RepositoryID) ([]*BranchLogRecord, error)
+
+	// DeleteBranchLogCommits removes the entries of commitIDs from the logs of all branches
+	DeleteBranchLogCommits(ctx context.Context, repositoryID RepositoryID, commitIDs []CommitID) error
+
 	// GetCommit returns the Commit metadata object for the given CommitID.
 	GetCommit(ctx context.Context, repositoryID RepositoryID, commitID CommitID) (*Commit, error)
 
@@ -478,11 +731,50 @@ type RefManager interface {
 	// and internally: https://github.com/treeverse/lakeFS/blob/09954804baeb36ada74fa17d8fdc13a38552394e/index/dag/commits.go
 	FindMergeBase(ctx context.Context, repositoryID RepositoryID, commitIDs ...CommitID) (*Commit, error)
 
-	// Log returns an iterator starting at commit ID up to repository root
-	Log(ctx context.Context, repositoryID RepositoryID, commitID CommitID) (CommitIterator, error)
+	// Log returns an iterator starting at commit ID up to repository root, following only the
+	// first parent of merge commits when firstParent is set
+	Log(ctx context.Context, repositoryID RepositoryID, commitID CommitID, firstParent bool) (CommitIterator, error)
+
+	// IsAncestor returns true if ancestor is reachable from commitID, a commit is its own ancestor
+	IsAncestor(ctx context.Context, repositoryID RepositoryID, ancestor, commitID CommitID) (bool, error)
+
+	// CommitsBetween returns the commits reachable from 'to' but not from 'from'
+	CommitsBetween(ctx context.Context, repositoryID RepositoryID, from, to CommitID) ([]*CommitRecord, error)
+
+	// FillGenerations computes the unknown generations of the commits of the repository, and
+	// returns the number of commits updated
+	FillGenerations(ctx context.Context, repositoryID RepositoryID) (int, error)
+
+	// FindCommitsByMetadata returns up to limit commits with metadata 'key' set to 'value', newest
+	// first, starting after the commit 'after'.  Also returns whether more commits match.
+	FindCommitsByMetadata(ctx context.Context, repositoryID RepositoryID, key, value string, after CommitID, limit int) ([]*CommitRecord, bool, error)
+
+	// SetRefLabels sets the labels in set and removes the labels in unset of the ref of
+	// refType refID, and returns its labels
+	SetRefLabels(ctx context.Context, repositoryID RepositoryID, refType LabeledRefType, refID string, set map[string]string, unset []string) (map[string]string, error)
+
+	// GetRefLabels returns the labels of the ref of refType refID, empty if it has none
+	GetRefLabels(ctx context.Context, repositoryID RepositoryID, refType LabeledRefType, refID string) (map[string]string, error)
+
+	// FindRefsByLabels returns the refs of refType, or of every type if empty, that have all
+	// labels, ordered by type and ID
+	FindRefsByLabels(ctx context.Context, repositoryID RepositoryID, labels map[string]string, refType LabeledRefType) ([]*RefLabels, error)
 
 	// ListCommits returns an iterator over all known commits, ordered by their commit ID
 	ListCommits(ctx context.Context, repositoryID RepositoryID) (CommitIterator, error)
+
+	// ForkRepository stores a new Repository under RepositoryID with copies of the commits, tags and
+	// branches of sourceID.  Branches are created with new staging tokens from newStagingToken.
+	ForkRepository(ctx context.Context, sourceID RepositoryID, repositoryID RepositoryID, repository Repository, newStagingToken func(BranchID) StagingToken) error
+
+	// DeleteCommits removes the commit records of commitIDs
+	DeleteCommits(ctx context.Context, repositoryID RepositoryID, commitIDs []CommitID) error
+
+	// ReplaceCommits atomically moves branches, tags, repository snapshots and other records of
+	// commits from each commit in replacements to its replacement and removes the replaced
+	// commits.  Returns ErrHistoryChanged
+	// if another commit has a replaced commit as parent.
+	ReplaceCommits(ctx context.Context, repositoryID RepositoryID, replacements map[CommitID]CommitID) error
 }
 
 // CommittedManager reads and applies committed snapshots
@@ -517,6 +809,19 @@ type CommittedManager interface {
 	// A change is either an entity to write/overwrite, or a tombstone to mark a deletion
 	// it returns a new MetaRangeID that is expected to be immediately addressable
 	Apply(ctx context.Context, ns StorageNamespace, rangeID MetaRangeID, iterator ValueIterator) (MetaRangeID, DiffSummary, error)
+
+	// DropPrefix returns the ID of the MetaRange holding the values of rangeID with keys not
+	// starting with prefix.  Ranges entirely under prefix are dropped without reading them.
+	DropPrefix(ctx context.Context, ns StorageNamespace, rangeID MetaRangeID, prefix Key) (MetaRangeID, DiffSummary, error)
+
+	// ReplacePrefix returns the ID of the MetaRange holding the values of destination with keys
+	// not starting with prefix, and the values of source with keys starting with prefix.
+	// Ranges entirely under prefix are swapped without reading them.
+	ReplacePrefix(ctx context.Context, ns StorageNamespace, destination, source MetaRangeID, prefix Key) (MetaRangeID, DiffSummary, error)
+
+	// Upgrade rewrites the MetaRange with rangeID in the current tree format version and
+	// returns the ID of the rewritten MetaRange, or rangeID if it needs no upgrade.
+	Upgrade(ctx context.Context, ns StorageNamespace, rangeID MetaRangeID) (MetaRangeID, error)
 }
 
 // StagingManager manages entries in a staging area, denoted by a staging token
@@ -525,9 +830,17 @@ type StagingManager interface {
 	// Returns ErrNotFound if no value found on key.
 	Get(ctx context.Context, st StagingToken, key Key) (*Value, error)
 
+	// GetMany returns the values staged on keys under the given staging token, by key.  Keys
+	// staged as tombstones map to nil, keys not staged are missing.
+	GetMany(ctx context.Context, st StagingToken, keys []Key) (map[string]*Value, error)
+
 	// Set writes a (possibly nil) value under the given staging token and key.
 	Set(ctx context.Context, st StagingToken, key Key, value *Value) error
 
+	// SetIf writes a (possibly nil) value under the given staging token and key if condition
+	// accepts what is staged on key, atomically with respect to other writes of key.
+	SetIf(ctx context.Context, st StagingToken, key Key, value *Value, condition StagedCondition) error
+
 	// List returns a ValueIterator for the given staging token
 	List(ctx context.Context, st StagingToken) (ValueIterator, error)
 
@@ -539,6 +852,21 @@ type StagingManager interface {
 
 	// DropByPrefix drops all keys starting with the given prefix, from the given staging area
 	DropByPrefix(ctx context.Context, st StagingToken, prefix Key) error
+
+	// Stats returns a summary of the values of the given staging area, maintained as they
+	// are set and dropped
+	Stats(ctx context.Context, st StagingToken) (*StagingStats, error)
+
+	// Snapshot copies the given staging area of branchID under a new staging token, not modified
+	// by later writes, and returns that token.  Snapshots expire after a while.
+	Snapshot(ctx context.Context, repositoryID RepositoryID, branchID BranchID, st StagingToken) (StagingToken, error)
+
+	// ListSnapshot returns a ValueIterator for the given snapshot of branchID from key from.
+	// Returns ErrSnapshotExpired if the snapshot expired or was taken of another branch.
+	ListSnapshot(ctx context.Context, repositoryID RepositoryID, branchID BranchID, st StagingToken, from Key) (ValueIterator, error)
+
+	// DropExpiredSnapshots drops expired snapshots and returns the number dropped
+	DropExpiredSnapshots(ctx context.Context) (int, error)
 }
 
 // BranchLockerFunc
@@ -554,6 +882,57 @@ type BranchLocker interface {
 	MetadataUpdater(ctx context.Context, repositoryID RepositoryID, branchID BranchID, lockeFn BranchLockerFunc) (interface{}, error)
 }
 
+type branchWriteCheckKey struct{}
+
+// WithBranchWriteCheck returns a context whose branch writes call check once they hold the
+// branch lock, before changing the branch, and fail with its error.  A check passing for a
+// write holds until the write ends, as changes made under LockBranch wait for it.  Checks
+// already set on ctx run first.
+func WithBranchWriteCheck(ctx context.Context, check func() error) context.Context {
+	if prev, ok := ctx.Value(branchWriteCheckKey{}).(func() error); ok {
+		next := check
+		check = func() error {
+			if err := prev(); err != nil {
+				return err
+			}
+			return next()
+		}
+	}
+	return context.WithValue(ctx, branchWriteCheckKey{}, check)
+}
+
+// CheckBranchWrite calls the check set on ctx by WithBranchWriteCheck, if any.  BranchLocker
+// implementations call it once they lock the branch.
+func CheckBranchWrite(ctx context.Context) error {
+	check, _ := ctx.Value(branchWriteCheckKey{}).(func() error)
+	if check == nil {
+		return nil
+	}
+	return check()
+}
+
+type prefixChangeCheckKey struct{}
+
+// PrefixChangeCheckFunc checks a change of the MetaRange baseID of the head of a branch to the
+// MetaRange changedID
+type PrefixChangeCheckFunc func(ctx context.Context, baseID, changedID MetaRangeID) error
+
+// WithPrefixChangeCheck returns a context whose prefix changes (DropPrefix, ReplacePrefixFromRef
+// and ApplyChanges) call check with the MetaRange of the branch head and the MetaRange they are
+// about to commit, under the branch lock, and fail with its error.
+func WithPrefixChangeCheck(ctx context.Context, check PrefixChangeCheckFunc) context.Context {
+	return context.WithValue(ctx, prefixChangeCheckKey{}, check)
+}
+
+// CheckPrefixChange calls the check set on ctx by WithPrefixChangeCheck, if any
+func CheckPrefixChange(ctx context.Context, baseID, changedID MetaRangeID) error {
+	check, _ := ctx.Value(prefixChangeCheckKey{}).(PrefixChangeCheckFunc)
+	if check == nil {
+		return nil
+	}
+	return check(ctx, baseID, changedID)
+}
+
 func (id RepositoryID) String() string {
 	return string(id)
 }
@@ -592,6 +971,10 @@ func (id CommitID) Ref() Ref {
 	return Ref(id)
 }
 
+func (id RepositorySnapshotID) String() string {
+	return string(id)
+}
+
 func (id TagID) String() string {
 	return string(id)
 }
@@ -620,6 +1003,10 @@ func (g *Graveler) GetRepository(ctx context.Context, repositoryID RepositoryID)
 	return g.RefManager.GetRepository(ctx, repositoryID)
 }
 
+func (g *Graveler) SetRangeSplitPolicy(ctx context.Context, repositoryID RepositoryID, policy *RangeSplitPolicy) error {
+	return g.RefManager.SetRangeSplitPolicy(ctx, repositoryID, policy)
+}
+
 func (g *Graveler) CreateRepository(ctx context.Context, repositoryID RepositoryID, storageNamespace StorageNamespace, branchID BranchID) (*Repository, error) {
 	repo := Repository{
 		StorageNamespace: storageNamespace,
@@ -647,6 +1034,26 @@ func (g *Graveler) CreateBareRepository(ctx context.Context, repositoryID Reposi
 	return &repo, nil
 }
 
+func (g *Graveler) ForkRepository(ctx context.Context, sourceID RepositoryID, repositoryID RepositoryID) (*Repository, error) {
+	source, err := g.RefManager.GetRepository(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	repo := Repository{
+		StorageNamespace: source.StorageNamespace,
+		CreationDate:     time.Now(),
+		DefaultBranchID:  source.DefaultBranchID,
+		RangeSplit:       source.RangeSplit,
+	}
+	err = g.RefManager.ForkRepository(ctx, sourceID, repositoryID, repo, func(branchID BranchID) StagingToken {
+		return generateStagingToken(repositoryID, branchID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &repo, nil
+}
+
 func (g *Graveler) ListRepositories(ctx context.Context) (RepositoryIterator, error) {
 	return g.RefManager.ListRepositories(ctx)
 }
@@ -656,7 +1063,7 @@ func (g *Graveler) WriteMetaRange(ctx context.Context, repositoryID RepositoryID
 	if err != nil {
 		return nil, err
 	}
-	return g.CommittedManager.WriteMetaRange(ctx, repo.StorageNamespace, it, nil)
+	return g.CommittedManager.WriteMetaRange(WithRangeSplitPolicy(ctx, repo.RangeSplit), repo.StorageNamespace, it, nil)
 }
 
 func (g *Graveler) DeleteRepository(ctx context.Context, repositoryID RepositoryID) error {
@@ -771,8 +1178,56 @@ func (g *Graveler) Dereference(ctx context.Context, repositoryID RepositoryID, r
 	return reference.CommitID(), nil
 }
 
-func (g *Graveler) Log(ctx context.Context, repositoryID RepositoryID, commitID CommitID) (CommitIterator, error) {
-	return g.RefManager.Log(ctx, repositoryID, commitID)
+func (g *Graveler) Log(ctx context.Context, repositoryID RepositoryID, commitID CommitID, firstParent bool) (CommitIterator, error) {
+	return g.RefManager.Log(ctx, repositoryID, commitID, firstParent)
+}
+
+func (g *Graveler) IsAncestor(ctx context.Context, repositoryID RepositoryID, ancestor, ref Ref) (bool, error) {
+	ancestorID, err := g.Dereference(ctx, repositoryID, ancestor)
+	if err != nil {
+		return false, err
+	}
+	commitID, err := g.Dereference(ctx, repositoryID, ref)
+	if err != nil {
+		return false, err
+	}
+	return g.RefManager.IsAncestor(ctx, repositoryID, ancestorID, commitID)
+}
+
+func (g *Graveler) CommitsBetween(ctx context.Context, repositoryID RepositoryID, from, to Ref) ([]*CommitRecord, error) {
+	fromID, err := g.Dereference(ctx, repositoryID, from)
+	if err != nil {
+		return nil, err
+	}
+	toID, err := g.Dereference(ctx, repositoryID, to)
+	if err != nil {
+		return nil, err
+	}
+	return g.RefManager.CommitsBetween(ctx, repositoryID, fromID, toID)
+}
+
+func (g *Graveler) FindCommitsByMetadata(ctx context.Context, repositoryID RepositoryID, key, value string, after CommitID, limit int) ([]*CommitRecord, bool, error) {
+	return g.RefManager.FindCommitsByMetadata(ctx, repositoryID, key, value, after, limit)
+}
+
+func (g *Graveler) SetRefLabels(ctx context.Context, repositoryID RepositoryID, refType LabeledRefType, refID string, set map[string]string, unset []string) (map[string]string, error) {
+	return g.RefManager.SetRefLabels(ctx, repositoryID, refType, refID, set, unset)
+}
+
+func (g *Graveler) GetRefLabels(ctx context.Context, repositoryID RepositoryID, refType LabeledRefType, refID string) (map[string]string, error) {
+	return g.RefManager.GetRefLabels(ctx, repositoryID, refType, refID)
+}
+
+func (g *Graveler) FindRefsByLabels(ctx context.Context, repositoryID RepositoryID, labels map[string]string, refType LabeledRefType) ([]*RefLabels, error) {
+	return g.RefManager.FindRefsByLabels(ctx, repositoryID, labels, refType)
+}
+
+func (g *Graveler) FillGenerations(ctx context.Context, repositoryID RepositoryID) (int, error) {
+	return g.RefManager.FillGenerations(ctx, repositoryID)
+}
+
+func (g *Graveler) ListCommits(ctx context.Context, repositoryID RepositoryID) (CommitIterator, error) {
+	return g.RefManager.ListCommits(ctx, repositoryID)
 }
 
 func (g *Graveler) ListBranches(ctx context.Context, repositoryID RepositoryID) (BranchIterator, error) {
@@ -826,6 +1281,48 @@ func (g *Graveler) Get(ctx context.Context, repositoryID RepositoryID, ref Ref,
 	return g.CommittedManager.Get(ctx, repo.StorageNamespace, commit.MetaRangeID, key)
 }
 
+func (g *Graveler) GetMany(ctx context.Context, repositoryID RepositoryID, ref Ref, keys []Key) ([]*Value, error) {
+	repo, err := g.RefManager.GetRepository(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	reference, err := g.RefManager.RevParse(ctx, repositoryID, ref)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]*Value, len(keys))
+	var staged map[string]*Value
+	if reference.Type() == ReferenceTypeBranch {
+		staged, err = g.StagingManager.GetMany(ctx, reference.Branch().StagingToken, keys)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var commit *Commit
+	for i, key := range keys {
+		if value, ok := staged[string(key)]; ok {
+			// nil for a tombstone
+			values[i] = value
+			continue
+		}
+		if commit == nil {
+			commit, err = g.RefManager.GetCommit(ctx, repositoryID, reference.CommitID())
+			if err != nil {
+				return nil, err
+			}
+		}
+		value, err := g.CommittedManager.Get(ctx, repo.StorageNamespace, commit.MetaRangeID, key)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
 func (g *Graveler) Set(ctx context.Context, repositoryID RepositoryID, branchID BranchID, key Key, value Value) error {
 	_, err := g.branchLocker.Writer(ctx, repositoryID, branchID, func() (interface{}, error) {
 		branch, err := g.GetBranch(ctx, repositoryID, branchID)
@@ -838,6 +1335,43 @@ func (g *Graveler) Set(ctx context.Context, repositoryID RepositoryID, branchID
 	return err
 }
 
+func (g *Graveler) SetIf(ctx context.Context, repositoryID RepositoryID, branchID BranchID, key Key, value Value, condition SetCondition) error {
+	_, err := g.branchLocker.Writer(ctx, repositoryID, branchID, func() (interface{}, error) {
+		repo, err := g.RefManager.GetRepository(ctx, repositoryID)
+		if err != nil {
+			return nil, err
+		}
+		branch, err := g.GetBranch(ctx, repositoryID, branchID)
+		if err != nil {
+			return nil, err
+		}
+		err = g.StagingManager.SetIf(ctx, branch.StagingToken, key, &value, func(current *Value, staged bool) error {
+			if staged {
+				return condition(current)
+			}
+			// nothing staged, the committed value is current.  It cannot change while we
+			// hold the writer lock.
+			if branch.CommitID == "" {
+				return condition(nil)
+			}
+			commit, err := g.RefManager.GetCommit(ctx, repositoryID, branch.CommitID)
+			if err != nil {
+				return err
+			}
+			current, err = g.CommittedManager.Get(ctx, repo.StorageNamespace, commit.MetaRangeID, key)
+			if errors.Is(err, ErrNotFound) {
+				return condition(nil)
+			}
+			if err != nil {
+				return err
+			}
+			return condition(current)
+		})
+		return nil, err
+	})
+	return err
+}
+
 // checkStaged returns true if key is staged on manager at token.  It treats staging manager
 // errors by returning "not a tombstone", and is unsafe to use if that matters!
 func isStagedTombstone(ctx context.Context, manager StagingManager, token StagingToken, key Key) bool {
@@ -896,15 +1430,102 @@ func (g *Graveler) Delete(ctx context.Context, repositoryID RepositoryID, branch
 }
 
 func (g *Graveler) List(ctx context.Context, repositoryID RepositoryID, ref Ref) (ValueIterator, error) {
-	repo, err := g.RefManager.GetRepository(ctx, repositoryID)
+	reference, err := g.RefManager.RevParse(ctx, repositoryID, ref)
 	if err != nil {
 		return nil, err
 	}
+	var stagingToken StagingToken
+	if reference.Type() == ReferenceTypeBranch {
+		stagingToken = reference.Branch().StagingToken
+	}
+	return g.listCommitAndStaging(ctx, repositoryID, reference.CommitID(), stagingToken)
+}
+
+func (g *Graveler) Snapshot(ctx context.Context, repositoryID RepositoryID, ref Ref) (*ListingSnapshot, error) {
 	reference, err := g.RefManager.RevParse(ctx, repositoryID, ref)
 	if err != nil {
 		return nil, err
 	}
-	commitID := reference.CommitID()
+	snapshot := &ListingSnapshot{
+		CommitID: reference.CommitID(),
+	}
+	if reference.Type() != ReferenceTypeBranch {
+		return snapshot, nil
+	}
+	branch := reference.Branch()
+	empty, err := g.stagingEmpty(ctx, &branch)
+	if err != nil {
+		return nil, err
+	}
+	if !empty {
+		snapshot.BranchID = BranchID(ref)
+		snapshot.StagingToken = branch.StagingToken
+		snapshot.Live = true
+	}
+	return snapshot, nil
+}
+
+func (g *Graveler) PinSnapshot(ctx context.Context, repositoryID RepositoryID, snapshot *ListingSnapshot) error {
+	if !snapshot.Live {
+		return nil
+	}
+	// the staging area of the branch is modified in place, later pages list a copy of it
+	stagingToken, err := g.StagingManager.Snapshot(ctx, repositoryID, snapshot.BranchID, snapshot.StagingToken)
+	if err != nil {
+		return err
+	}
+	snapshot.StagingToken = stagingToken
+	snapshot.Live = false
+	return nil
+}
+
+func (g *Graveler) DropExpiredSnapshots(ctx context.Context) (int, error) {
+	return g.StagingManager.DropExpiredSnapshots(ctx)
+}
+
+func (g *Graveler) ListSnapshot(ctx context.Context, repositoryID RepositoryID, snapshot ListingSnapshot) (ValueIterator, error) {
+	if snapshot.Live {
+		return g.listCommitAndStaging(ctx, repositoryID, snapshot.CommitID, snapshot.StagingToken)
+	}
+	var stagingList ValueIterator
+	if snapshot.StagingToken != "" {
+		var err error
+		stagingList, err = g.StagingManager.ListSnapshot(ctx, repositoryID, snapshot.BranchID, snapshot.StagingToken, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	it, err := g.listCommitAndStagingIterator(ctx, repositoryID, snapshot.CommitID, stagingList)
+	if err != nil && stagingList != nil {
+		stagingList.Close()
+	}
+	return it, err
+}
+
+// listCommitAndStaging lists the values of commitID, overridden by the values staged under stagingToken
+// (if not empty)
+func (g *Graveler) listCommitAndStaging(ctx context.Context, repositoryID RepositoryID, commitID CommitID, stagingToken StagingToken) (ValueIterator, error) {
+	if stagingToken == "" {
+		return g.listCommitAndStagingIterator(ctx, repositoryID, commitID, nil)
+	}
+	stagingList, err := g.StagingManager.List(ctx, stagingToken)
+	if err != nil {
+		return nil, err
+	}
+	it, err := g.listCommitAndStagingIterator(ctx, repositoryID, commitID, stagingList)
+	if err != nil {
+		stagingList.Close()
+	}
+	return it, err
+}
+
+// listCommitAndStagingIterator lists the values of commitID, overridden by the values of
+// stagingList (if not nil)
+func (g *Graveler) listCommitAndStagingIterator(ctx context.Context, repositoryID RepositoryID, commitID CommitID, stagingList ValueIterator) (ValueIterator, error) {
+	repo, err := g.RefManager.GetRepository(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
 	var metaRangeID MetaRangeID
 	if commitID != "" {
 		commit, err := g.RefManager.GetCommit(ctx, repositoryID, commitID)
@@ -918,11 +1539,7 @@ func (g *Graveler) List(ctx context.Context, repositoryID RepositoryID, ref Ref)
 	if err != nil {
 		return nil, err
 	}
-	if reference.Type() == ReferenceTypeBranch {
-		stagingList, err := g.StagingManager.List(ctx, reference.Branch().StagingToken)
-		if err != nil {
-			return nil, err
-		}
+	if stagingList != nil {
 		listing = NewCombinedIterator(stagingList, listing)
 	}
 	return listing, nil
@@ -939,12 +1556,24 @@ func (g *Graveler) Commit(ctx context.Context, repositoryID RepositoryID, branch
 			return "", fmt.Errorf("get branch: %w", err)
 		}
 
+		var branchCommit *Commit
+		if branch.CommitID != "" {
+			branchCommit, err = g.RefManager.GetCommit(ctx, repositoryID, branch.CommitID)
+			if err != nil {
+				return "", fmt.Errorf("get commit: %w", err)
+			}
+		}
+
 		// fill commit information - use for pre-commit and after adding the commit information used by commit
 		commit := Commit{
-			Committer:    params.Committer,
-			Message:      params.Message,
-			CreationDate: time.Now(),
-			Metadata:     params.Metadata,
+			Committer:      params.Committer,
+			CommitterEmail: params.CommitterEmail,
+			Message:        params.Message,
+			CreationDate:   commitCreationDate(branchCommit),
+			Metadata:       params.Metadata,
+			Author:         params.Author,
+			AuthorEmail:    params.AuthorEmail,
+			AuthorDate:     params.AuthorDate,
 		}
 		if branch.CommitID != "" {
 			commit.Parents = CommitParents{branch.CommitID}
@@ -956,12 +1585,8 @@ func (g *Graveler) Commit(ctx context.Context, repositoryID RepositoryID, branch
 		}
 
 		var branchMetaRangeID MetaRangeID
-		if branch.CommitID != "" {
-			commit, err := g.RefManager.GetCommit(ctx, repositoryID, branch.CommitID)
-			if err != nil {
-				return "", fmt.Errorf("get commit: %w", err)
-			}
-			branchMetaRangeID = commit.MetaRangeID
+		if branchCommit != nil {
+			branchMetaRangeID = branchCommit.MetaRangeID
 		}
 		changes, err := g.StagingManager.List(ctx, branch.StagingToken)
 		if err != nil {
@@ -969,7 +1594,7 @@ func (g *Graveler) Commit(ctx context.Context, repositoryID RepositoryID, branch
 		}
 		defer changes.Close()
 
-		commit.MetaRangeID, _, err = g.CommittedManager.Apply(ctx, repo.StorageNamespace, branchMetaRangeID, changes)
+		commit.MetaRangeID, _, err = g.CommittedManager.Apply(WithRangeSplitPolicy(ctx, repo.RangeSplit), repo.StorageNamespace, branchMetaRangeID, changes)
 		if err != nil {
 			return "", fmt.Errorf("commit: %w", err)
 		}
@@ -1004,25 +1629,57 @@ func (g *Graveler) Commit(ctx context.Context, repositoryID RepositoryID, branch
 	return res.(CommitID), nil
 }
 
+func (g *Graveler) CommitPreview(ctx context.Context, repositoryID RepositoryID, branchID BranchID) (MetaRangeID, DiffSummary, error) {
+	repo, err := g.RefManager.GetRepository(ctx, repositoryID)
+	if err != nil {
+		return "", DiffSummary{}, fmt.Errorf("get repository: %w", err)
+	}
+	branch, err := g.RefManager.GetBranch(ctx, repositoryID, branchID)
+	if err != nil {
+		return "", DiffSummary{}, fmt.Errorf("get branch: %w", err)
+	}
+	var branchMetaRangeID MetaRangeID
+	if branch.CommitID != "" {
+		commit, err := g.RefManager.GetCommit(ctx, repositoryID, branch.CommitID)
+		if err != nil {
+			return "", DiffSummary{}, fmt.Errorf("get commit: %w", err)
+		}
+		branchMetaRangeID = commit.MetaRangeID
+	}
+	changes, err := g.StagingManager.List(ctx, branch.StagingToken)
+	if err != nil {
+		return "", DiffSummary{}, fmt.Errorf("staging list: %w", err)
+	}
+	defer changes.Close()
+
+	// the written ranges are not referenced by any commit and will be collected as garbage
+	metaRangeID, summary, err := g.CommittedManager.Apply(WithRangeSplitPolicy(ctx, repo.RangeSplit), repo.StorageNamespace, branchMetaRangeID, changes)
+	if err != nil {
+		return "", DiffSummary{}, fmt.Errorf("apply: %w", err)
+	}
+	return metaRangeID, summary, nil
+}
+
 func newStagingToken(repositoryID RepositoryID, branchID BranchID) StagingToken {
 	v := strings.Join([]string{repositoryID.String(), branchID.String(), uuid.New().String()}, "-")
 	return StagingToken(v)
 }
 
-func (g *Graveler) validateCommitParent(ctx context.Context, repositoryID RepositoryID, commit Commit) (CommitID, error) {
+// validateCommitParent returns the parent of commit, or nil if it has none
+func (g *Graveler) validateCommitParent(ctx context.Context, repositoryID RepositoryID, commit Commit) (*Commit, error) {
 	if len(commit.Parents) > 1 {
-		return "", ErrMultipleParents
+		return nil, ErrMultipleParents
 	}
 	if len(commit.Parents) == 0 {
-		return "", nil
+		return nil, nil
 	}
 
 	parentCommitID := commit.Parents[0]
-	_, err := g.RefManager.GetCommit(ctx, repositoryID, parentCommitID)
+	parent, err := g.RefManager.GetCommit(ctx, repositoryID, parentCommitID)
 	if err != nil {
-		return "", fmt.Errorf("get parent commit %s: %w", parentCommitID, err)
+		return nil, fmt.Errorf("get parent commit %s: %w", parentCommitID, err)
 	}
-	return parentCommitID, nil
+	return parent, nil
 }
 
 func (g *Graveler) isCommitExist(ctx context.Context, repositoryID RepositoryID, commitID CommitID) (bool, error) {
@@ -1041,10 +1698,14 @@ func (g *Graveler) AddCommitToBranchHead(ctx context.Context, repositoryID Repos
 	res, err := g.branchLocker.MetadataUpdater(ctx, repositoryID, branchID, func() (interface{}, error) {
 		// parentCommitID should always match the HEAD of the branch.
 		// Empty parentCommitID matches first commit of the branch.
-		parentCommitID, err := g.validateCommitParent(ctx, repositoryID, commit)
+		parent, err := g.validateCommitParent(ctx, repositoryID, commit)
 		if err != nil {
 			return nil, err
 		}
+		var parentCommitID CommitID
+		if parent != nil {
+			parentCommitID = commit.Parents[0]
+		}
 
 		branch, err := g.RefManager.GetBranch(ctx, repositoryID, branchID)
 		if err != nil {
@@ -1053,9 +1714,10 @@ func (g *Graveler) AddCommitToBranchHead(ctx context.Context, repositoryID Repos
 		if branch.CommitID != parentCommitID {
 			return nil, ErrCommitNotHeadBranch
 		}
+		commit.CreationDate = commitCreationDateAfter(commit.CreationDate, parent)
 
 		// check if commit already exists.
-		commitID := CommitID(ident.NewHexAddressProvider().ContentAddress(commit))
+		commitID := commit.ID()
 		if exists, err := g.isCommitExist(ctx, repositoryID, commitID); err != nil {
 			return nil, err
 		} else if exists {
@@ -1083,13 +1745,14 @@ func (g *Graveler) AddCommit(ctx context.Context, repositoryID RepositoryID, com
 	if len(commit.Parents) == 0 {
 		return "", ErrAddCommitNoParent
 	}
-	_, err := g.validateCommitParent(ctx, repositoryID, commit)
+	parent, err := g.validateCommitParent(ctx, repositoryID, commit)
 	if err != nil {
 		return "", err
 	}
+	commit.CreationDate = commitCreationDateAfter(commit.CreationDate, parent)
 
 	// check if commit already exists.
-	commitID := CommitID(ident.NewHexAddressProvider().ContentAddress(commit))
+	commitID := commit.ID()
 	if exists, err := g.isCommitExist(ctx, repositoryID, commitID); err != nil {
 		return "", err
 	} else if exists {
@@ -1104,6 +1767,24 @@ func (g *Graveler) AddCommit(ctx context.Context, repositoryID RepositoryID, com
 	return commitID, nil
 }
 
+// commitCreationDate returns the creation date of a new commit on top of parents: the current
+// time, unless a parent was dated later by a server with a skewed clock.  Creation dates never
+// decrease along the history, so ordering the log and retention by date follow the ancestry.
+func commitCreationDate(parents ...*Commit) time.Time {
+	return commitCreationDateAfter(time.Now(), parents...)
+}
+
+// commitCreationDateAfter returns creationDate, or the latest creation date of parents if it is
+// later.  Commits added with their own creation date are clamped the same way.
+func commitCreationDateAfter(creationDate time.Time, parents ...*Commit) time.Time {
+	for _, parent := range parents {
+		if parent != nil && parent.CreationDate.After(creationDate) {
+			creationDate = parent.CreationDate
+		}
+	}
+	return creationDate
+}
+
 // addCommitNoLock lower API used to add commit into a repository. It will verify that the commit meta-range is accessible but will not lock any metadata update.
 func (g *Graveler) addCommitNoLock(ctx context.Context, repositoryID RepositoryID, commit Commit) (CommitID, error) {
 	repo, err := g.RefManager.GetRepository(ctx, repositoryID)
@@ -1176,11 +1857,36 @@ func (g *Graveler) ResetPrefix(ctx context.Context, repositoryID RepositoryID, b
 	return err
 }
 
+func (g *Graveler) LockBranch(ctx context.Context, repositoryID RepositoryID, branchID BranchID, fn func() error) error {
+	_, err := g.branchLocker.MetadataUpdater(ctx, repositoryID, branchID, func() (interface{}, error) {
+		return nil, fn()
+	})
+	return err
+}
+
+func (g *Graveler) GetStagingStats(ctx context.Context, repositoryID RepositoryID, branchID BranchID) (*StagingStats, error) {
+	branch, err := g.RefManager.GetBranch(ctx, repositoryID, branchID)
+	if err != nil {
+		return nil, err
+	}
+	return g.StagingManager.Stats(ctx, branch.StagingToken)
+}
+
 type CommitIDAndSummary struct {
 	ID      CommitID
 	Summary DiffSummary
 }
 
+// MergePreview is the result of a dry-run merge
+type MergePreview struct {
+	// Summary counts the changes the merge would apply to the destination
+	Summary DiffSummary
+	// Conflicts are the keys changed on both sides since the merge base
+	Conflicts []Key
+	// DestinationDirty is set when the destination has uncommitted changes and cannot be merged into
+	DestinationDirty bool
+}
+
 // Revert creates a reverse patch to the commit given as 'ref', and applies it as a new commit on the given branch.
 // This is implemented by merging the parent of 'ref' into the branch, with 'ref' as the merge base.
 // Example: consider the following tree: C1 -> C2 -> C3, with the branch pointing at C3.
@@ -1230,7 +1936,7 @@ func (g *Graveler) Revert(ctx context.Context, repositoryID RepositoryID, branch
 			return "", fmt.Errorf("get commit from ref %s: %w", branch.CommitID, err)
 		}
 		// merge from the parent to the top of the branch, with the given ref as the merge base:
-		metaRangeID, summary, err := g.CommittedManager.Merge(ctx, repo.StorageNamespace, branchCommit.MetaRangeID, parentMetaRangeID, commitRecord.MetaRangeID)
+		metaRangeID, summary, err := g.CommittedManager.Merge(WithRangeSplitPolicy(ctx, repo.RangeSplit), repo.StorageNamespace, branchCommit.MetaRangeID, parentMetaRangeID, commitRecord.MetaRangeID)
 		if err != nil {
 			if !errors.Is(err, ErrUserVisible) {
 				err = fmt.Errorf("merge: %w", err)
@@ -1241,7 +1947,7 @@ func (g *Graveler) Revert(ctx context.Context, repositoryID RepositoryID, branch
 			Committer:    commitParams.Committer,
 			Message:      commitParams.Message,
 			MetaRangeID:  metaRangeID,
-			CreationDate: time.Now(),
+			CreationDate: commitCreationDate(branchCommit.Commit),
 			Parents:      []CommitID{branch.CommitID},
 			Metadata:     commitParams.Metadata,
 		}
@@ -1265,6 +1971,93 @@ func (g *Graveler) Revert(ctx context.Context, repositoryID RepositoryID, branch
 	return c.ID, c.Summary, nil
 }
 
+func (g *Graveler) DropPrefix(ctx context.Context, repositoryID RepositoryID, branchID BranchID, prefix Key, commitParams CommitParams) (CommitID, DiffSummary, error) {
+	return g.commitPrefixChange(ctx, repositoryID, branchID, "", commitParams, func(ctx context.Context, repo *Repository, metaRangeID MetaRangeID) (MetaRangeID, DiffSummary, error) {
+		return g.CommittedManager.DropPrefix(ctx, repo.StorageNamespace, metaRangeID, prefix)
+	})
+}
+
+func (g *Graveler) ReplacePrefixFromRef(ctx context.Context, repositoryID RepositoryID, branchID BranchID, prefix Key, source Ref, commitParams CommitParams) (CommitID, DiffSummary, error) {
+	sourceCommit, err := g.getCommitRecordFromRef(ctx, repositoryID, source)
+	if err != nil {
+		return "", DiffSummary{}, fmt.Errorf("get commit from ref %s: %w", source, err)
+	}
+	return g.commitPrefixChange(ctx, repositoryID, branchID, sourceCommit.CommitID.Ref(), commitParams, func(ctx context.Context, repo *Repository, metaRangeID MetaRangeID) (MetaRangeID, DiffSummary, error) {
+		return g.CommittedManager.ReplacePrefix(ctx, repo.StorageNamespace, metaRangeID, sourceCommit.MetaRangeID, prefix)
+	})
+}
+
+func (g *Graveler) ApplyChanges(ctx context.Context, repositoryID RepositoryID, branchID BranchID, changes ValueIterator, commitParams CommitParams) (CommitID, DiffSummary, error) {
+	return g.commitPrefixChange(ctx, repositoryID, branchID, "", commitParams, func(ctx context.Context, repo *Repository, metaRangeID MetaRangeID) (MetaRangeID, DiffSummary, error) {
+		return g.CommittedManager.Apply(ctx, repo.StorageNamespace, metaRangeID, changes)
+	})
+}
+
+// commitPrefixChange commits to the clean branch the MetaRange change returns for the MetaRange
+// of its head.  The commit passes the pre-merge hook when it takes entries from source and the
+// pre-commit hook otherwise, as well as the check set by WithPrefixChangeCheck.
+func (g *Graveler) commitPrefixChange(ctx context.Context, repositoryID RepositoryID, branchID BranchID, source Ref, commitParams CommitParams, change func(context.Context, *Repository, MetaRangeID) (MetaRangeID, DiffSummary, error)) (CommitID, DiffSummary, error) {
+	res, err := g.branchLocker.MetadataUpdater(ctx, repositoryID, branchID, func() (interface{}, error) {
+		repo, err := g.RefManager.GetRepository(ctx, repositoryID)
+		if err != nil {
+			return nil, fmt.Errorf("get repo %s: %w", repositoryID, err)
+		}
+		branch, err := g.RefManager.GetBranch(ctx, repositoryID, branchID)
+		if err != nil {
+			return nil, fmt.Errorf("get branch %s: %w", branchID, err)
+		}
+		if empty, err := g.stagingEmpty(ctx, branch); err != nil {
+			return nil, err
+		} else if !empty {
+			return nil, ErrDirtyBranch
+		}
+		branchCommit, err := g.RefManager.GetCommit(ctx, repositoryID, branch.CommitID)
+		if err != nil {
+			return nil, fmt.Errorf("get commit %s: %w", branch.CommitID, err)
+		}
+		metaRangeID, summary, err := change(WithRangeSplitPolicy(ctx, repo.RangeSplit), repo, branchCommit.MetaRangeID)
+		if err != nil {
+			return nil, err
+		}
+		if err := CheckPrefixChange(ctx, branchCommit.MetaRangeID, metaRangeID); err != nil {
+			return nil, err
+		}
+		commit := Commit{
+			Committer:    commitParams.Committer,
+			Message:      commitParams.Message,
+			MetaRangeID:  metaRangeID,
+			CreationDate: commitCreationDate(branchCommit),
+			Parents:      []CommitID{branch.CommitID},
+			Metadata:     commitParams.Metadata,
+		}
+		if source == "" {
+			err = g.callPreCommitHooks(ctx, repositoryID, branchID, commit)
+		} else {
+			err = g.callPreMergeHook(ctx, repositoryID, branchID, source, commit)
+		}
+		if err != nil {
+			return nil, err
+		}
+		commitID, err := g.RefManager.AddCommit(ctx, repositoryID, commit)
+		if err != nil {
+			return nil, fmt.Errorf("add commit: %w", err)
+		}
+		err = g.RefManager.SetBranch(ctx, repositoryID, branchID, Branch{
+			CommitID:     commitID,
+			StagingToken: branch.StagingToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("set branch: %w", err)
+		}
+		return &CommitIDAndSummary{commitID, summary}, nil
+	})
+	if err != nil {
+		return "", DiffSummary{}, err
+	}
+	c := res.(*CommitIDAndSummary)
+	return c.ID, c.Summary, nil
+}
+
 func (g *Graveler) Merge(ctx context.Context, repositoryID RepositoryID, destination BranchID, source Ref, commitParams CommitParams) (CommitID, DiffSummary, error) {
 	res, err := g.branchLocker.MetadataUpdater(ctx, repositoryID, destination, func() (interface{}, error) {
 		repo, err := g.RefManager.GetRepository(ctx, repositoryID)
@@ -1286,7 +2079,15 @@ func (g *Graveler) Merge(ctx context.Context, repositoryID RepositoryID, destina
 		if err != nil {
 			return "", err
 		}
-		metaRangeID, summary, err := g.CommittedManager.Merge(ctx, repo.StorageNamespace, toCommit.MetaRangeID, fromCommit.MetaRangeID, baseCommit.MetaRangeID)
+		// nothing to merge when the source was already merged into the destination
+		merged, err := g.RefManager.IsAncestor(ctx, repositoryID, fromCommit.CommitID, toCommit.CommitID)
+		if err != nil {
+			return "", fmt.Errorf("check source ancestry: %w", err)
+		}
+		if merged {
+			return "", ErrNoChanges
+		}
+		metaRangeID, summary, err := g.CommittedManager.Merge(WithRangeSplitPolicy(ctx, repo.RangeSplit), repo.StorageNamespace, toCommit.MetaRangeID, fromCommit.MetaRangeID, baseCommit.MetaRangeID)
 		if err != nil {
 			if !errors.Is(err, ErrUserVisible) {
 				err = fmt.Errorf("merge in CommitManager: %w", err)
@@ -1296,7 +2097,7 @@ func (g *Graveler) Merge(ctx context.Context, repositoryID RepositoryID, destina
 		commit := Commit{
 			Committer:    commitParams.Committer,
 			Message:      commitParams.Message,
-			CreationDate: time.Now(),
+			CreationDate: commitCreationDate(fromCommit.Commit, toCommit.Commit),
 			MetaRangeID:  metaRangeID,
 			Parents:      []CommitID{fromCommit.CommitID, toCommit.CommitID},
 			Metadata:     commitParams.Metadata,
@@ -1323,7 +2124,39 @@ func (g *Graveler) Merge(ctx context.Context, repositoryID RepositoryID, destina
 	return c.ID, c.Summary, nil
 }
 
-func (g *Graveler) DiffUncommitted(ctx context.Context, repositoryID RepositoryID, branchID BranchID) (DiffIterator, error) {
+func (g *Graveler) MergePreview(ctx context.Context, repositoryID RepositoryID, destination BranchID, source Ref) (*MergePreview, error) {
+	branch, err := g.GetBranch(ctx, repositoryID, destination)
+	if err != nil {
+		return nil, fmt.Errorf("get branch: %w", err)
+	}
+	empty, err := g.stagingEmpty(ctx, branch)
+	if err != nil {
+		return nil, fmt.Errorf("check if staging empty: %w", err)
+	}
+	it, err := g.Compare(ctx, repositoryID, source, Ref(destination), DiffTypeMaskAll)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+	preview := &MergePreview{
+		Summary:          DiffSummary{Count: make(map[DiffType]int)},
+		DestinationDirty: !empty,
+	}
+	for it.Next() {
+		diff := it.Value()
+		if diff.Type == DiffTypeConflict {
+			preview.Conflicts = append(preview.Conflicts, diff.Key.Copy())
+			continue
+		}
+		preview.Summary.Count[diff.Type]++
+	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("compare: %w", err)
+	}
+	return preview, nil
+}
+
+func (g *Graveler) DiffUncommitted(ctx context.Context, repositoryID RepositoryID, branchID BranchID, typeMask DiffTypeMask) (DiffIterator, error) {
 	repo, err := g.RefManager.GetRepository(ctx, repositoryID)
 	if err != nil {
 		return nil, err
@@ -1345,7 +2178,7 @@ func (g *Graveler) DiffUncommitted(ctx context.Context, repositoryID RepositoryI
 	if err != nil {
 		return nil, err
 	}
-	return NewUncommittedDiffIterator(ctx, g.CommittedManager, valueIterator, repo.StorageNamespace, metaRangeID), nil
+	return NewDiffTypeFilterIterator(NewUncommittedDiffIterator(ctx, g.CommittedManager, valueIterator, repo.StorageNamespace, metaRangeID), typeMask), nil
 }
 
 func (g *Graveler) getCommitRecordFromRef(ctx context.Context, repositoryID RepositoryID, ref Ref) (*CommitRecord, error) {
@@ -1363,7 +2196,7 @@ func (g *Graveler) getCommitRecordFromRef(ctx context.Context, repositoryID Repo
 	}, nil
 }
 
-func (g *Graveler) Diff(ctx context.Context, repositoryID RepositoryID, left, right Ref) (DiffIterator, error) {
+func (g *Graveler) Diff(ctx context.Context, repositoryID RepositoryID, left, right Ref, typeMask DiffTypeMask) (DiffIterator, error) {
 	repo, err := g.RefManager.GetRepository(ctx, repositoryID)
 	if err != nil {
 		return nil, err
@@ -1377,10 +2210,14 @@ func (g *Graveler) Diff(ctx context.Context, repositoryID RepositoryID, left, ri
 		return nil, err
 	}
 
-	return g.CommittedManager.Diff(ctx, repo.StorageNamespace, leftCommit.MetaRangeID, rightCommit.MetaRangeID)
+	it, err := g.CommittedManager.Diff(ctx, repo.StorageNamespace, leftCommit.MetaRangeID, rightCommit.MetaRangeID)
+	if err != nil {
+		return nil, err
+	}
+	return NewDiffTypeFilterIterator(it, typeMask), nil
 }
 
-func (g *Graveler) Compare(ctx context.Context, repositoryID RepositoryID, from, to Ref) (DiffIterator, error) {
+func (g *Graveler) Compare(ctx context.Context, repositoryID RepositoryID, from, to Ref, typeMask DiffTypeMask) (DiffIterator, error) {
 	repo, err := g.RefManager.GetRepository(ctx, repositoryID)
 	if err != nil {
 		return nil, err
@@ -1389,7 +2226,11 @@ func (g *Graveler) Compare(ctx context.Context, repositoryID RepositoryID, from,
 	if err != nil {
 		return nil, err
 	}
-	return g.CommittedManager.Compare(ctx, repo.StorageNamespace, toCommit.MetaRangeID, fromCommit.MetaRangeID, baseCommit.MetaRangeID)
+	it, err := g.CommittedManager.Compare(ctx, repo.StorageNamespace, toCommit.MetaRangeID, fromCommit.MetaRangeID, baseCommit.MetaRangeID)
+	if err != nil {
+		return nil, err
+	}
+	return NewDiffTypeFilterIterator(it, typeMask), nil
 }
 
 func (g *Graveler) PreCommitHook() PreCommitFunc {
@@ -1448,26 +2289,36 @@ func (g *Graveler) LoadCommits(ctx context.Context, repositoryID RepositoryID, m
 		for i, p := range commit.GetParents() {
 			parents[i] = CommitID(p)
 		}
-		commitID, err := g.RefManager.AddCommit(ctx, repositoryID, Commit{
-			Committer:    commit.GetCommitter(),
-			Message:      commit.GetMessage(),
-			MetaRangeID:  MetaRangeID(commit.GetMetaRangeId()),
-			CreationDate: commit.GetCreationDate().AsTime(),
-			Parents:      parents,
-			Metadata:     commit.GetMetadata(),
-		})
-		if err != nil {
-			return err
+		var authorDate time.Time
+		if commit.GetAuthorDate() != nil {
+			authorDate = commit.GetAuthorDate().AsTime()
+		}
+		loaded := Commit{
+			Committer:      commit.GetCommitter(),
+			CommitterEmail: commit.GetCommitterEmail(),
+			Message:        commit.GetMessage(),
+			MetaRangeID:    MetaRangeID(commit.GetMetaRangeId()),
+			CreationDate:   commit.GetCreationDate().AsTime(),
+			Parents:        parents,
+			Metadata:       commit.GetMetadata(),
+			Author:         commit.GetAuthor(),
+			AuthorEmail:    commit.GetAuthorEmail(),
+			AuthorDate:     authorDate,
 		}
 		// integrity check that we get for free!
-		if commitID != CommitID(commit.Id) {
-			return fmt.Errorf("commit ID does not match for %s: %w", commitID, ErrInvalidCommitID)
+		if err := VerifyCommitID(CommitID(commit.Id), loaded); err != nil {
+			return err
+		}
+		if _, err := g.RefManager.AddCommit(ctx, repositoryID, loaded); err != nil {
+			return err
 		}
 	}
 	if iter.Err() != nil {
 		return iter.Err()
 	}
-	return nil
+	// commits are loaded in ID order, children may be added before their parents
+	_, err = g.RefManager.FillGenerations(ctx, repositoryID)
+	return err
 }
 
 func (g *Graveler) LoadBranches(ctx context.Context, repositoryID RepositoryID, metaRangeID MetaRangeID) error {
@@ -1753,14 +2604,22 @@ func (c *commitValueIterator) setValue() bool {
 		return false
 	}
 	commit := c.src.Value()
+	var authorDate *timestamppb.Timestamp
+	if !commit.AuthorDate.IsZero() {
+		authorDate = timestamppb.New(commit.AuthorDate)
+	}
 	data, err := proto.Marshal(&CommitData{
-		Id:           string(commit.CommitID),
-		Committer:    commit.Committer,
-		Message:      commit.Message,
-		CreationDate: timestamppb.New(commit.CreationDate),
-		MetaRangeId:  string(commit.MetaRangeID),
-		Metadata:     commit.Metadata,
-		Parents:      commit.Parents.AsStringSlice(),
+		Id:             string(commit.CommitID),
+		Committer:      commit.Committer,
+		CommitterEmail: commit.CommitterEmail,
+		Message:        commit.Message,
+		CreationDate:   timestamppb.New(commit.CreationDate),
+		MetaRangeId:    string(commit.MetaRangeID),
+		Metadata:       commit.Metadata,
+		Parents:        commit.Parents.AsStringSlice(),
+		Author:         commit.Author,
+		AuthorEmail:    commit.AuthorEmail,
+		AuthorDate:     authorDate,
 	})
 	if err != nil {
 		c.err = err
@@ -3,13 +3,16 @@ package graveler_test
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/go-test/deep"
 	"github.com/treeverse/lakefs/graveler"
 	"github.com/treeverse/lakefs/graveler/ref"
 	"github.com/treeverse/lakefs/graveler/testutil"
+	"github.com/treeverse/lakefs/ident"
 	tu "github.com/treeverse/lakefs/testutil"
 )
 
@@ -213,7 +216,7 @@ func TestGraveler_DiffUncommitted(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx := context.Background()
-			diff, err := tt.r.DiffUncommitted(ctx, "repo", "branch")
+			diff, err := tt.r.DiffUncommitted(ctx, "repo", "branch", graveler.DiffTypeMaskAll)
 			if err != tt.expectedErr {
 				t.Fatalf("wrong error, expected:%s got:%s", tt.expectedErr, err)
 			}
@@ -285,6 +288,31 @@ func TestGraveler_UpdateBranch(t *testing.T) {
 	}
 }
 
+func TestGraveler_RestoreRepositorySnapshot(t *testing.T) {
+	conn, _ := tu.GetDB(t, databaseURI)
+	branchLocker := ref.NewBranchLocker(conn)
+	stagingManager := &testutil.StagingFake{}
+	gravel := graveler.NewGraveler(branchLocker, nil, stagingManager, &testutil.RefsFake{
+		Branch: &graveler.Branch{CommitID: "c2", StagingToken: "token1"},
+		RepositorySnapshot: &graveler.RepositorySnapshot{
+			SnapshotID: "snapshot1",
+			Branches:   map[graveler.BranchID]graveler.CommitID{"master": "c1"},
+		},
+	})
+	if err := gravel.RestoreRepositorySnapshot(context.Background(), "repo1", "snapshot1"); err != nil {
+		t.Fatalf("RestoreRepositorySnapshot() unexpected error: %s", err)
+	}
+	if !stagingManager.DropCalled {
+		t.Error("RestoreRepositorySnapshot() expected staging to be dropped")
+	}
+
+	gravel = graveler.NewGraveler(branchLocker, nil, &testutil.StagingFake{}, &testutil.RefsFake{})
+	err := gravel.RestoreRepositorySnapshot(context.Background(), "repo1", "snapshot2")
+	if !errors.Is(err, graveler.ErrRepositorySnapshotNotFound) {
+		t.Fatalf("RestoreRepositorySnapshot() err=%v, expected %s", err, graveler.ErrRepositorySnapshotNotFound)
+	}
+}
+
 func TestGraveler_Commit(t *testing.T) {
 	conn, _ := tu.GetDB(t, databaseURI)
 	branchLocker := ref.NewBranchLocker(conn)
@@ -457,6 +485,246 @@ func TestGraveler_Commit(t *testing.T) {
 		})
 	}
 }
+
+func TestGraveler_CommitPreview(t *testing.T) {
+	conn, _ := tu.GetDB(t, databaseURI)
+	branchLocker := ref.NewBranchLocker(conn)
+	commitID := graveler.CommitID("commitID")
+	branchRangeID := graveler.MetaRangeID("branchRangeID")
+	previewRangeID := graveler.MetaRangeID("previewRangeID")
+	summary := graveler.DiffSummary{Count: map[graveler.DiffType]int{graveler.DiffTypeAdded: 1}}
+	values := testutil.NewValueIteratorFake([]graveler.ValueRecord{{Key: graveler.Key("foo"), Value: &graveler.Value{}}})
+
+	committedManager := &testutil.CommittedFake{MetaRangeID: previewRangeID, DiffSummary: summary}
+	stagingManager := &testutil.StagingFake{ValueIterator: values}
+	refManager := &testutil.RefsFake{
+		Branch:  &graveler.Branch{CommitID: commitID},
+		Commits: map[graveler.CommitID]*graveler.Commit{commitID: {MetaRangeID: branchRangeID}},
+	}
+	g := graveler.NewGraveler(branchLocker, committedManager, stagingManager, refManager)
+
+	gotRangeID, gotSummary, err := g.CommitPreview(context.Background(), "repo", "branch")
+	if err != nil {
+		t.Fatalf("CommitPreview() error = %s", err)
+	}
+	if gotRangeID != previewRangeID {
+		t.Errorf("CommitPreview() metarange = %s, want %s", gotRangeID, previewRangeID)
+	}
+	if diff := deep.Equal(gotSummary, summary); diff != nil {
+		t.Errorf("CommitPreview() summary diff %s", diff)
+	}
+	if committedManager.AppliedData.MetaRangeID != branchRangeID {
+		t.Errorf("applied on metarange %s, want %s", committedManager.AppliedData.MetaRangeID, branchRangeID)
+	}
+	if refManager.AddedCommit.MetaRangeID != "" {
+		t.Errorf("CommitPreview() added a commit: %+v", refManager.AddedCommit)
+	}
+	if stagingManager.DropCalled {
+		t.Error("CommitPreview() dropped staging")
+	}
+}
+
+func TestGraveler_MergePreview(t *testing.T) {
+	conn, _ := tu.GetDB(t, databaseURI)
+	branchLocker := ref.NewBranchLocker(conn)
+	commitID := graveler.CommitID("commitID")
+	diffs := testutil.NewDiffIter([]graveler.Diff{
+		{Key: graveler.Key("added"), Type: graveler.DiffTypeAdded},
+		{Key: graveler.Key("changed"), Type: graveler.DiffTypeChanged},
+		{Key: graveler.Key("conflict"), Type: graveler.DiffTypeConflict},
+		{Key: graveler.Key("removed1"), Type: graveler.DiffTypeRemoved},
+		{Key: graveler.Key("removed2"), Type: graveler.DiffTypeRemoved},
+	})
+	committedManager := &testutil.CommittedFake{DiffIterator: diffs}
+	stagingManager := &testutil.StagingFake{ValueIterator: testutil.NewValueIteratorFake(nil)}
+	refManager := &testutil.RefsFake{
+		CommitID: commitID,
+		Branch:   &graveler.Branch{CommitID: commitID},
+		Commits:  map[graveler.CommitID]*graveler.Commit{commitID: {}},
+	}
+	g := graveler.NewGraveler(branchLocker, committedManager, stagingManager, refManager)
+
+	preview, err := g.MergePreview(context.Background(), "repo", "branch", "source")
+	if err != nil {
+		t.Fatalf("MergePreview() error = %s", err)
+	}
+	expected := &graveler.MergePreview{
+		Summary: graveler.DiffSummary{Count: map[graveler.DiffType]int{
+			graveler.DiffTypeAdded:   1,
+			graveler.DiffTypeChanged: 1,
+			graveler.DiffTypeRemoved: 2,
+		}},
+		Conflicts: []graveler.Key{graveler.Key("conflict")},
+	}
+	if diff := deep.Equal(preview, expected); diff != nil {
+		t.Errorf("MergePreview() diff %s", diff)
+	}
+	if refManager.AddedCommit.MetaRangeID != "" {
+		t.Errorf("MergePreview() added a commit: %+v", refManager.AddedCommit)
+	}
+}
+
+func TestGraveler_PurgeHistory(t *testing.T) {
+	conn, _ := tu.GetDB(t, databaseURI)
+	branchLocker := ref.NewBranchLocker(conn)
+	const newCommitID = graveler.CommitID("newCommitID")
+	const newRangeID = graveler.MetaRangeID("newRangeID")
+	value := func(key, identity string) graveler.ValueRecord {
+		return graveler.ValueRecord{Key: graveler.Key(key), Value: &graveler.Value{Identity: []byte(identity), Data: []byte(identity)}}
+	}
+	// c1 holds the purged key, c2 does not but has c1 as parent
+	committedManager := &testutil.CommittedFake{
+		MetaRangeID: newRangeID,
+		ValuesByMetaRange: map[graveler.MetaRangeID][]graveler.ValueRecord{
+			"range1": {value("a", "a1"), value("secret/x", "x1")},
+			"range2": {value("a", "a1"), value("b", "b1")},
+		},
+	}
+	stagingManager := &testutil.StagingFake{ValueIterator: testutil.NewValueIteratorFake([]graveler.ValueRecord{value("secret/y", "y1")})}
+	refManager := &testutil.RefsFake{
+		CommitID: newCommitID,
+		Branch:   &graveler.Branch{CommitID: "c2", StagingToken: "token"},
+		ListBranchesRes: testutil.NewBranchIteratorFake([]*graveler.BranchRecord{
+			{BranchID: "master", Branch: &graveler.Branch{CommitID: "c2", StagingToken: "token"}},
+		}),
+		Commits: map[graveler.CommitID]*graveler.Commit{
+			"c1": {Message: "first", MetaRangeID: "range1"},
+			"c2": {Message: "second", MetaRangeID: "range2", Parents: graveler.CommitParents{"c1"}},
+		},
+	}
+	g := graveler.NewGraveler(branchLocker, committedManager, stagingManager, refManager)
+
+	res, err := g.PurgeHistory(context.Background(), "repo", graveler.Key("secret/"), true)
+	if err != nil {
+		t.Fatalf("PurgeHistory() error = %s", err)
+	}
+	expected := &graveler.PurgeResult{
+		Commits: map[graveler.CommitID]graveler.CommitID{"c1": newCommitID, "c2": newCommitID},
+		Removed: []*graveler.ValueRecord{
+			{Key: graveler.Key("secret/x"), Value: &graveler.Value{Identity: []byte("x1"), Data: []byte("x1")}},
+			{Key: graveler.Key("secret/y"), Value: &graveler.Value{Identity: []byte("y1"), Data: []byte("y1")}},
+		},
+	}
+	if diff := deep.Equal(res, expected); diff != nil {
+		t.Errorf("PurgeHistory() diff %s", diff)
+	}
+	if diff := deep.Equal(committedManager.WrittenValues, []graveler.ValueRecord{value("a", "a1")}); diff != nil {
+		t.Errorf("PurgeHistory() written metarange diff %s", diff)
+	}
+	// c2 keeps its metarange and moves to the new parent
+	if refManager.AddedCommit.Message != "second" || refManager.AddedCommit.MetaRangeID != "range2" ||
+		deep.Equal(refManager.AddedCommit.Parents, graveler.CommitParents{newCommitID}) != nil {
+		t.Errorf("PurgeHistory() last added commit %+v", refManager.AddedCommit)
+	}
+	if diff := deep.Equal(refManager.Replacements, expected.Commits); diff != nil {
+		t.Errorf("PurgeHistory() replacements diff %s", diff)
+	}
+}
+
+func TestGraveler_Fsck(t *testing.T) {
+	conn, _ := tu.GetDB(t, databaseURI)
+	branchLocker := ref.NewBranchLocker(conn)
+	value := func(key string) graveler.ValueRecord {
+		return graveler.ValueRecord{Key: graveler.Key(key), Value: &graveler.Value{Identity: []byte(key), Data: []byte(key)}}
+	}
+	committedManager := &testutil.CommittedFake{
+		ValuesByMetaRange: map[graveler.MetaRangeID][]graveler.ValueRecord{
+			"range1": {value("a"), value("b")},
+			"range2": {value("b"), value("a")},
+		},
+	}
+	refManager := &testutil.RefsFake{
+		Repository: &graveler.Repository{DefaultBranchID: "master"},
+		ListBranchesRes: testutil.NewBranchIteratorFake([]*graveler.BranchRecord{
+			{BranchID: "lost", Branch: &graveler.Branch{CommitID: "missing"}},
+			{BranchID: "master", Branch: &graveler.Branch{CommitID: "c2"}},
+		}),
+		ListTagsRes: testutil.NewTagIteratorFake([]*graveler.TagRecord{
+			{TagID: "v1", CommitID: "c1"},
+		}),
+		Commits: map[graveler.CommitID]*graveler.Commit{
+			"c1": {MetaRangeID: "range1"},
+			"c2": {MetaRangeID: "range2", Parents: graveler.CommitParents{"c1", "c0"}},
+			"c3": {MetaRangeID: "range1", Parents: graveler.CommitParents{"c4"}},
+			"c4": {MetaRangeID: "range1", Parents: graveler.CommitParents{"c3"}},
+		},
+	}
+	g := graveler.NewGraveler(branchLocker, committedManager, &testutil.StagingFake{}, refManager)
+
+	report, err := g.Fsck(context.Background(), "repo")
+	if err != nil {
+		t.Fatalf("Fsck() error = %s", err)
+	}
+	checks := make([]string, len(report.Findings))
+	for i, f := range report.Findings {
+		checks[i] = string(f.Severity) + " " + f.Check + " " + f.Object
+	}
+	expected := []string{
+		"error branch_commit lost",
+		"error commit_parent c2",
+		"error commit_cycle c4",
+		"warning unreachable_commit c3",
+		"warning unreachable_commit c4",
+		"error metarange_order range2",
+	}
+	if diff := deep.Equal(checks, expected); diff != nil {
+		t.Errorf("Fsck() findings diff %s", diff)
+	}
+	if report.Branches != 2 || report.Tags != 1 || report.Commits != 4 || report.MetaRanges != 2 {
+		t.Errorf("Fsck() counted %+v", report)
+	}
+	if !report.HasErrors() {
+		t.Error("Fsck() report has no errors")
+	}
+}
+
+func TestGraveler_UpgradeTrees(t *testing.T) {
+	conn, _ := tu.GetDB(t, databaseURI)
+	branchLocker := ref.NewBranchLocker(conn)
+	ctx := context.Background()
+	newRefManager := func() *testutil.RefsFake {
+		return &testutil.RefsFake{
+			ListBranchesRes: testutil.NewBranchIteratorFake([]*graveler.BranchRecord{
+				{BranchID: "master", Branch: &graveler.Branch{CommitID: "c1"}},
+			}),
+			Branch:   &graveler.Branch{CommitID: "c1", StagingToken: "token"},
+			Commits:  map[graveler.CommitID]*graveler.Commit{"c1": {MetaRangeID: "old"}},
+			CommitID: "c2",
+		}
+	}
+
+	refManager := newRefManager()
+	committedManager := &testutil.CommittedFake{UpgradedMetaRanges: map[graveler.MetaRangeID]graveler.MetaRangeID{"old": "new"}}
+	g := graveler.NewGraveler(branchLocker, committedManager, &testutil.StagingFake{}, refManager)
+	upgraded, err := g.UpgradeTrees(ctx, "repo", "upgrader")
+	if err != nil {
+		t.Fatalf("UpgradeTrees() error = %s", err)
+	}
+	if diff := deep.Equal(upgraded, map[graveler.BranchID]graveler.CommitID{"master": "c2"}); diff != nil {
+		t.Errorf("UpgradeTrees() diff %s", diff)
+	}
+	expectedCommit := testutil.AddedCommitData{
+		Committer:   "upgrader",
+		Message:     graveler.UpgradeTreesCommitMessage,
+		MetaRangeID: "new",
+		Parents:     graveler.CommitParents{"c1"},
+		Metadata:    graveler.Metadata{"upgraded_meta_range_id": "old"},
+	}
+	if diff := deep.Equal(refManager.AddedCommit, expectedCommit); diff != nil {
+		t.Errorf("UpgradeTrees() added commit diff %s", diff)
+	}
+
+	refManager = newRefManager()
+	g = graveler.NewGraveler(branchLocker, &testutil.CommittedFake{}, &testutil.StagingFake{}, refManager)
+	upgraded, err = g.UpgradeTrees(ctx, "repo", "upgrader")
+	if err != nil {
+		t.Fatalf("UpgradeTrees() of current trees error = %s", err)
+	}
+	if len(upgraded) != 0 || refManager.AddedCommit.MetaRangeID != "" {
+		t.Errorf("UpgradeTrees() of current trees upgraded %v", upgraded)
+	}
+}
+
 func TestGraveler_PreCommitHook(t *testing.T) {
 	// prepare graveler
 	conn, _ := tu.GetDB(t, databaseURI)
@@ -657,6 +925,66 @@ func TestGraveler_PreMergeHook(t *testing.T) {
 	}
 }
 
+func TestGraveler_PrefixChangeHooks(t *testing.T) {
+	conn, _ := tu.GetDB(t, databaseURI)
+	branchLocker := ref.NewBranchLocker(conn)
+	const expectedRangeID = graveler.MetaRangeID("expectedRangeID")
+	const expectedCommitID = graveler.CommitID("expectedCommitId")
+	committedManager := &testutil.CommittedFake{MetaRangeID: expectedRangeID}
+	stagingManager := &testutil.StagingFake{ValueIterator: testutil.NewValueIteratorFake(nil)}
+	refManager := &testutil.RefsFake{
+		CommitID: expectedCommitID,
+		Branch:   &graveler.Branch{CommitID: expectedCommitID},
+		Commits:  map[graveler.CommitID]*graveler.Commit{expectedCommitID: {MetaRangeID: expectedRangeID}},
+	}
+	errSomethingBad := errors.New("something bad")
+	commitParams := graveler.CommitParams{Committer: "committer", Message: "message"}
+	ctx := context.Background()
+	g := graveler.NewGraveler(branchLocker, committedManager, stagingManager, refManager)
+	var preCommitCalls, preMergeCalls int
+	var mergeSource graveler.Ref
+	g.SetPreCommitHook(func(context.Context, graveler.RepositoryID, graveler.BranchID, graveler.Commit) error {
+		preCommitCalls++
+		return errSomethingBad
+	})
+	g.SetPreMergeHook(func(_ context.Context, _ graveler.RepositoryID, _ graveler.BranchID, source graveler.Ref, _ graveler.Commit) error {
+		preMergeCalls++
+		mergeSource = source
+		return errSomethingBad
+	})
+
+	if _, _, err := g.DropPrefix(ctx, "repoID", "branchID", graveler.Key("prefix/"), commitParams); !errors.Is(err, errSomethingBad) {
+		t.Errorf("DropPrefix err=%v, expected=%v", err, errSomethingBad)
+	}
+	if _, _, err := g.ApplyChanges(ctx, "repoID", "branchID", testutil.NewValueIteratorFake(nil), commitParams); !errors.Is(err, errSomethingBad) {
+		t.Errorf("ApplyChanges err=%v, expected=%v", err, errSomethingBad)
+	}
+	if preCommitCalls != 2 {
+		t.Errorf("pre-commit hook called %d times, expected 2", preCommitCalls)
+	}
+	if _, _, err := g.ReplacePrefixFromRef(ctx, "repoID", "branchID", graveler.Key("prefix/"), "source", commitParams); !errors.Is(err, errSomethingBad) {
+		t.Errorf("ReplacePrefixFromRef err=%v, expected=%v", err, errSomethingBad)
+	}
+	if preMergeCalls != 1 || mergeSource != expectedCommitID.Ref() {
+		t.Errorf("pre-merge hook called %d times with source %s, expected once with %s", preMergeCalls, mergeSource, expectedCommitID)
+	}
+
+	// the prefix change check runs before the hooks
+	errCheck := errors.New("check failed")
+	checkCtx := graveler.WithPrefixChangeCheck(ctx, func(_ context.Context, baseID, changedID graveler.MetaRangeID) error {
+		if baseID != expectedRangeID || changedID != expectedRangeID {
+			t.Errorf("prefix change check of %s to %s, expected %s to %s", baseID, changedID, expectedRangeID, expectedRangeID)
+		}
+		return errCheck
+	})
+	if _, _, err := g.DropPrefix(checkCtx, "repoID", "branchID", graveler.Key("prefix/"), commitParams); !errors.Is(err, errCheck) {
+		t.Errorf("DropPrefix with failing check err=%v, expected=%v", err, errCheck)
+	}
+	if preCommitCalls != 2 {
+		t.Errorf("pre-commit hook called %d times after a failed check, expected 2", preCommitCalls)
+	}
+}
+
 func TestGraveler_AddCommitToBranchHead(t *testing.T) {
 	conn, _ := tu.GetDB(t, databaseURI)
 	branchLocker := ref.NewBranchLocker(conn)
@@ -1119,3 +1447,200 @@ func TestGraveler_Delete(t *testing.T) {
 		})
 	}
 }
+
+func TestCommitIdentity(t *testing.T) {
+	addressProvider := ident.NewHexAddressProvider()
+	commit := graveler.Commit{
+		Committer:    "barak",
+		Message:      "initial",
+		MetaRangeID:  "range",
+		CreationDate: time.Unix(1612345678, 0),
+		Metadata:     graveler.Metadata{"k": "v"},
+	}
+	// commits without author details keep their IDs
+	const expectedID = "afc8e952b1e3b40ee270f07b2a282005930c3d1f41f2ecbff6fabfa7b7225d58"
+	if id := addressProvider.ContentAddress(commit); id != expectedID {
+		t.Fatalf("commit ID %s, expected %s", id, expectedID)
+	}
+	seen := map[string]string{expectedID: "committer"}
+	authored := []struct {
+		name   string
+		modify func(c *graveler.Commit)
+	}{
+		{name: "committer email", modify: func(c *graveler.Commit) { c.CommitterEmail = "barak@example.com" }},
+		{name: "author", modify: func(c *graveler.Commit) { c.Author = "oz" }},
+		{name: "author email", modify: func(c *graveler.Commit) { c.AuthorEmail = "oz@example.com" }},
+		{name: "author date", modify: func(c *graveler.Commit) { c.AuthorDate = time.Unix(1612300000, 0) }},
+	}
+	for _, tt := range authored {
+		c := commit
+		tt.modify(&c)
+		id := addressProvider.ContentAddress(c)
+		if other, ok := seen[id]; ok {
+			t.Errorf("commit with %s has the same ID as commit with %s", tt.name, other)
+		}
+		seen[id] = tt.name
+	}
+}
+
+func TestGraveler_CommitCreationDate(t *testing.T) {
+	conn, _ := tu.GetDB(t, databaseURI)
+	branchLocker := ref.NewBranchLocker(conn)
+	ctx := context.Background()
+	const parentCommitID = graveler.CommitID("parent")
+	for _, tt := range []struct {
+		name       string
+		parentDate time.Time
+	}{
+		{name: "parent in the past", parentDate: time.Now().Add(-time.Hour)},
+		// the parent was committed by a server with a clock ahead of this one
+		{name: "parent in the future", parentDate: time.Now().Add(time.Hour)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			refManager := &testutil.RefsFake{
+				CommitID: "child",
+				Branch:   &graveler.Branch{CommitID: parentCommitID},
+				Commits:  map[graveler.CommitID]*graveler.Commit{parentCommitID: {MetaRangeID: "range", CreationDate: tt.parentDate}},
+			}
+			g := graveler.NewGraveler(branchLocker, &testutil.CommittedFake{MetaRangeID: "range"},
+				&testutil.StagingFake{ValueIterator: testutil.NewValueIteratorFake(nil)}, refManager)
+			var commitDate time.Time
+			g.SetPreCommitHook(func(_ context.Context, _ graveler.RepositoryID, _ graveler.BranchID, commit graveler.Commit) error {
+				commitDate = commit.CreationDate
+				return nil
+			})
+			before := time.Now()
+			_, err := g.Commit(ctx, "repo", "branch", graveler.CommitParams{Committer: "committer", Message: "message"})
+			if err != nil {
+				t.Fatalf("Commit() error = %s", err)
+			}
+			if commitDate.Before(tt.parentDate) {
+				t.Errorf("commit created at %s, before its parent at %s", commitDate, tt.parentDate)
+			}
+			if commitDate.Before(before) {
+				t.Errorf("commit created at %s, before committing at %s", commitDate, before)
+			}
+		})
+	}
+}
+
+func TestGraveler_AddCommitCreationDate(t *testing.T) {
+	conn, _ := tu.GetDB(t, databaseURI)
+	branchLocker := ref.NewBranchLocker(conn)
+	ctx := context.Background()
+	const parentCommitID = graveler.CommitID("parent")
+	// the parent was committed by a server with a clock ahead of the importing one
+	parentDate := time.Now().Add(time.Hour)
+	for _, tt := range []struct {
+		name string
+		add  func(g *graveler.Graveler, commit graveler.Commit) (graveler.CommitID, error)
+	}{
+		{
+			name: "add to branch head",
+			add: func(g *graveler.Graveler, commit graveler.Commit) (graveler.CommitID, error) {
+				return g.AddCommitToBranchHead(ctx, "repo", "branch", commit)
+			},
+		},
+		{
+			name: "add",
+			add: func(g *graveler.Graveler, commit graveler.Commit) (graveler.CommitID, error) {
+				return g.AddCommit(ctx, "repo", commit)
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			refManager := &testutil.RefsFake{
+				CommitID: "child",
+				Branch:   &graveler.Branch{CommitID: parentCommitID},
+				Commits:  map[graveler.CommitID]*graveler.Commit{parentCommitID: {MetaRangeID: "range", CreationDate: parentDate}},
+			}
+			g := graveler.NewGraveler(branchLocker, &testutil.CommittedFake{MetaRangeID: "range"},
+				&testutil.StagingFake{ValueIterator: testutil.NewValueIteratorFake(nil)}, refManager)
+			_, err := tt.add(g, graveler.Commit{
+				Committer:    "committer",
+				Message:      "import",
+				MetaRangeID:  "range",
+				CreationDate: time.Now(),
+				Parents:      graveler.CommitParents{parentCommitID},
+			})
+			if err != nil {
+				t.Fatalf("add commit error = %s", err)
+			}
+			if len(refManager.AddedCommits) != 1 {
+				t.Fatalf("added %d commits, expected 1", len(refManager.AddedCommits))
+			}
+			if commitDate := refManager.AddedCommits[0].CreationDate; commitDate.Before(parentDate) {
+				t.Errorf("commit created at %s, before its parent at %s", commitDate, parentDate)
+			}
+		})
+	}
+}
+
+func TestCommitCanonicalForm(t *testing.T) {
+	// golden values, commit IDs must be the same on every version and installation
+	tests := []struct {
+		name            string
+		commit          graveler.Commit
+		expectedID      graveler.CommitID
+		expectedForm    string
+		expectedVersion string
+	}{
+		{
+			name:            "minimal",
+			commit:          graveler.Commit{Message: "m", CreationDate: time.Unix(1, 0)},
+			expectedID:      "62c7f29b31f41cd10c094ae4710c35278b26e5d853a6f2c81351f509416a1636",
+			expectedForm:    "0102080000000000000009636f6d6d69743a7631010208000000000000000001020800000000000000016d0102080000000000000000020800000000000000010402080000000000000000050002080000000000000020c05d0ae027f29a4a5776bafad29a3b776473f1da64ad40219803783b75286a6d",
+			expectedVersion: graveler.CommitIdentityV1,
+		},
+		{
+			name: "committer",
+			commit: graveler.Commit{
+				Committer:    "barak",
+				Message:      "initial",
+				MetaRangeID:  "range",
+				CreationDate: time.Unix(1612345678, 0),
+				Metadata:     graveler.Metadata{"k": "v"},
+			},
+			expectedID:      "afc8e952b1e3b40ee270f07b2a282005930c3d1f41f2ecbff6fabfa7b7225d58",
+			expectedVersion: graveler.CommitIdentityV1,
+		},
+		{
+			name: "author",
+			commit: graveler.Commit{
+				Committer:    "barak",
+				Message:      "initial",
+				MetaRangeID:  "range",
+				CreationDate: time.Unix(1612345678, 0),
+				Metadata:     graveler.Metadata{"k": "v"},
+				Author:       "oz",
+				AuthorEmail:  "oz@example.com",
+				AuthorDate:   time.Unix(1612300000, 0),
+			},
+			expectedID:      "108ced2d4b09c1e0547f5052324843a1f3278fb81e30b7aa44704f978b066591",
+			expectedVersion: graveler.CommitIdentityV2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if id := tt.commit.ID(); id != tt.expectedID {
+				t.Errorf("ID() = %s, expected %s", id, tt.expectedID)
+			}
+			if version := tt.commit.IdentityVersion(); version != tt.expectedVersion {
+				t.Errorf("IdentityVersion() = %s, expected %s", version, tt.expectedVersion)
+			}
+			if tt.expectedForm != "" {
+				if form := hex.EncodeToString(tt.commit.CanonicalForm()); form != tt.expectedForm {
+					t.Errorf("CanonicalForm() = %s, expected %s", form, tt.expectedForm)
+				}
+			}
+			if err := graveler.VerifyCommitID(tt.expectedID, tt.commit); err != nil {
+				t.Errorf("VerifyCommitID() error = %s", err)
+			}
+			changed := tt.commit
+			changed.Message += "!"
+			if err := graveler.VerifyCommitID(tt.expectedID, changed); !errors.Is(err, graveler.ErrInvalidCommitID) {
+				t.Errorf("VerifyCommitID() of a changed commit error = %v, expected %s", err, graveler.ErrInvalidCommitID)
+			}
+		})
+	}
+}
@@ -0,0 +1,358 @@
+package graveler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/treeverse/lakefs/logging"
+)
+
+// Operations recorded in the refs journal
+const (
+	RefsJournalCreateRepository = "create_repository"
+	RefsJournalAddCommit        = "add_commit"
+	RefsJournalSetBranch        = "set_branch"
+	RefsJournalDeleteBranch     = "delete_branch"
+	RefsJournalCreateTag        = "create_tag"
+	RefsJournalDeleteTag        = "delete_tag"
+	RefsJournalReplaceCommits   = "replace_commits"
+	RefsJournalDeleteCommits    = "delete_commits"
+	RefsJournalExpireBranchLog  = "expire_branch_log"
+)
+
+// RefsJournalEntry records a change to the commits, branches or tags of a repository.  Replaying
+// the entries of a repository in order on its latest metadata backup rebuilds its refs.
+type RefsJournalEntry struct {
+	Time       time.Time    `json:"time"`
+	Repository RepositoryID `json:"repository"`
+	Op         string       `json:"op"`
+	CommitID   CommitID     `json:"commit_id,omitempty"`
+	// PreviousCommitID is the commit of a set or deleted branch before the change, for audit.
+	// It is empty for new branches.
+	PreviousCommitID CommitID `json:"previous_commit_id,omitempty"`
+	// Commit is the added commit, replayed under CommitID
+	Commit       *Commit               `json:"commit,omitempty"`
+	BranchID     BranchID              `json:"branch_id,omitempty"`
+	TagID        TagID                 `json:"tag_id,omitempty"`
+	Replacements map[CommitID]CommitID `json:"replacements,omitempty"`
+	CommitIDs    []CommitID            `json:"commit_ids,omitempty"`
+}
+
+// RefsJournal durably records the changes to refs of repositories, outside of the refs
+// database, in their storage namespaces
+type RefsJournal interface {
+	// Append records entries of a single operation on a repository in storageNamespace
+	Append(ctx context.Context, storageNamespace StorageNamespace, entries []*RefsJournalEntry) error
+}
+
+// previousBranchRefManager is implemented by RefManagers that return the commit of a branch
+// before setting or deleting it, read in the same transaction as the change
+type previousBranchRefManager interface {
+	SetBranchReturningPrevious(ctx context.Context, repositoryID RepositoryID, branchID BranchID, branch Branch) (CommitID, error)
+	DeleteBranchReturningPrevious(ctx context.Context, repositoryID RepositoryID, branchID BranchID) (CommitID, error)
+}
+
+// journalingRefManager is a RefManager that records its changes to refs in a RefsJournal after
+// making them
+type journalingRefManager struct {
+	RefManager
+	journal RefsJournal
+	log     logging.Logger
+}
+
+// NewJournalingRefManager returns a RefManager that records the changes of refManager in
+// journal.  Recording is best-effort: the journal lives in the object store, outside the
+// transaction of the change, so a change that fails to record is still made and reported
+// successful, and the failure is logged as a gap in the journal.
+func NewJournalingRefManager(refManager RefManager, journal RefsJournal) RefManager {
+	return &journalingRefManager{
+		RefManager: refManager,
+		journal:    journal,
+		log:        logging.Default().WithField("service_name", "graveler_refs_journal"),
+	}
+}
+
+func (m *journalingRefManager) record(ctx context.Context, repositoryID RepositoryID, storageNamespace StorageNamespace, entries ...*RefsJournalEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	if err := m.append(ctx, repositoryID, storageNamespace, entries); err != nil {
+		m.log.WithContext(ctx).WithError(err).WithFields(logging.Fields{
+			"repository": repositoryID,
+			"op":         entries[0].Op,
+			"entries":    len(entries),
+		}).Error("Refs journal missing a change")
+	}
+}
+
+func (m *journalingRefManager) append(ctx context.Context, repositoryID RepositoryID, storageNamespace StorageNamespace, entries []*RefsJournalEntry) error {
+	if storageNamespace == "" {
+		repo, err := m.RefManager.GetRepository(ctx, repositoryID)
+		if err != nil {
+			return fmt.Errorf("get storage namespace of refs journal: %w", err)
+		}
+		storageNamespace = repo.StorageNamespace
+	}
+	now := time.Now()
+	for _, entry := range entries {
+		entry.Time = now
+		entry.Repository = repositoryID
+	}
+	if err := m.journal.Append(ctx, storageNamespace, entries); err != nil {
+		return fmt.Errorf("record %s in refs journal: %w", entries[0].Op, err)
+	}
+	return nil
+}
+
+// branchCommitID returns the commit of branchID, or an empty commit ID when it does not exist.
+// It is read before the change, so it is only used when the RefManager cannot return it.
+func (m *journalingRefManager) branchCommitID(ctx context.Context, repositoryID RepositoryID, branchID BranchID) (CommitID, error) {
+	branch, err := m.RefManager.GetBranch(ctx, repositoryID, branchID)
+	if errors.Is(err, ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if branch == nil {
+		return "", nil
+	}
+	return branch.CommitID, nil
+}
+
+// setBranch sets branchID and returns its commit before the change
+func (m *journalingRefManager) setBranch(ctx context.Context, repositoryID RepositoryID, branchID BranchID, branch Branch) (CommitID, error) {
+	if refs, ok := m.RefManager.(previousBranchRefManager); ok {
+		return refs.SetBranchReturningPrevious(ctx, repositoryID, branchID, branch)
+	}
+	previousCommitID, err := m.branchCommitID(ctx, repositoryID, branchID)
+	if err != nil {
+		return "", err
+	}
+	return previousCommitID, m.RefManager.SetBranch(ctx, repositoryID, branchID, branch)
+}
+
+// deleteBranch deletes branchID and returns its commit before the change
+func (m *journalingRefManager) deleteBranch(ctx context.Context, repositoryID RepositoryID, branchID BranchID) (CommitID, error) {
+	if refs, ok := m.RefManager.(previousBranchRefManager); ok {
+		return refs.DeleteBranchReturningPrevious(ctx, repositoryID, branchID)
+	}
+	previousCommitID, err := m.branchCommitID(ctx, repositoryID, branchID)
+	if err != nil {
+		return "", err
+	}
+	return previousCommitID, m.RefManager.DeleteBranch(ctx, repositoryID, branchID)
+}
+
+func (m *journalingRefManager) CreateBareRepository(ctx context.Context, repositoryID RepositoryID, repository Repository) error {
+	if err := m.RefManager.CreateBareRepository(ctx, repositoryID, repository); err != nil {
+		return err
+	}
+	m.record(ctx, repositoryID, repository.StorageNamespace,
+		&RefsJournalEntry{Op: RefsJournalCreateRepository, BranchID: repository.DefaultBranchID})
+	return nil
+}
+
+func (m *journalingRefManager) CreateRepository(ctx context.Context, repositoryID RepositoryID, repository Repository, token StagingToken) error {
+	if err := m.RefManager.CreateRepository(ctx, repositoryID, repository, token); err != nil {
+		return err
+	}
+	branch, err := m.RefManager.GetBranch(ctx, repositoryID, repository.DefaultBranchID)
+	if err != nil {
+		return err
+	}
+	commit, err := m.RefManager.GetCommit(ctx, repositoryID, branch.CommitID)
+	if err != nil {
+		return err
+	}
+	m.record(ctx, repositoryID, repository.StorageNamespace,
+		&RefsJournalEntry{Op: RefsJournalCreateRepository, BranchID: repository.DefaultBranchID},
+		&RefsJournalEntry{Op: RefsJournalAddCommit, CommitID: branch.CommitID, Commit: commit},
+		&RefsJournalEntry{Op: RefsJournalSetBranch, BranchID: repository.DefaultBranchID, CommitID: branch.CommitID})
+	return nil
+}
+
+func (m *journalingRefManager) ForkRepository(ctx context.Context, sourceID RepositoryID, repositoryID RepositoryID, repository Repository, newStagingToken func(BranchID) StagingToken) error {
+	if err := m.RefManager.ForkRepository(ctx, sourceID, repositoryID, repository, newStagingToken); err != nil {
+		return err
+	}
+	// the fork starts the journal of its storage namespace with all of its refs
+	entries := []*RefsJournalEntry{{Op: RefsJournalCreateRepository, BranchID: repository.DefaultBranchID}}
+	commits, err := m.RefManager.ListCommits(ctx, repositoryID)
+	if err != nil {
+		return err
+	}
+	for commits.Next() {
+		c := commits.Value()
+		entries = append(entries, &RefsJournalEntry{Op: RefsJournalAddCommit, CommitID: c.CommitID, Commit: c.Commit})
+	}
+	err = commits.Err()
+	commits.Close()
+	if err != nil {
+		return err
+	}
+	branches, err := m.RefManager.ListBranches(ctx, repositoryID)
+	if err != nil {
+		return err
+	}
+	for branches.Next() {
+		b := branches.Value()
+		entries = append(entries, &RefsJournalEntry{Op: RefsJournalSetBranch, BranchID: b.BranchID, CommitID: b.CommitID})
+	}
+	err = branches.Err()
+	branches.Close()
+	if err != nil {
+		return err
+	}
+	tags, err := m.RefManager.ListTags(ctx, repositoryID)
+	if err != nil {
+		return err
+	}
+	for tags.Next() {
+		t := tags.Value()
+		entries = append(entries, &RefsJournalEntry{Op: RefsJournalCreateTag, TagID: t.TagID, CommitID: t.CommitID})
+	}
+	err = tags.Err()
+	tags.Close()
+	if err != nil {
+		return err
+	}
+	m.record(ctx, repositoryID, repository.StorageNamespace, entries...)
+	return nil
+}
+
+func (m *journalingRefManager) AddCommit(ctx context.Context, repositoryID RepositoryID, commit Commit) (CommitID, error) {
+	commitID, err := m.RefManager.AddCommit(ctx, repositoryID, commit)
+	if err != nil {
+		return "", err
+	}
+	m.record(ctx, repositoryID, "", &RefsJournalEntry{Op: RefsJournalAddCommit, CommitID: commitID, Commit: &commit})
+	return commitID, nil
+}
+
+func (m *journalingRefManager) SetBranch(ctx context.Context, repositoryID RepositoryID, branchID BranchID, branch Branch) error {
+	previousCommitID, err := m.setBranch(ctx, repositoryID, branchID, branch)
+	if err != nil {
+		return err
+	}
+	m.record(ctx, repositoryID, "", &RefsJournalEntry{Op: RefsJournalSetBranch, BranchID: branchID, CommitID: branch.CommitID, PreviousCommitID: previousCommitID})
+	return nil
+}
+
+func (m *journalingRefManager) DeleteBranch(ctx context.Context, repositoryID RepositoryID, branchID BranchID) error {
+	previousCommitID, err := m.deleteBranch(ctx, repositoryID, branchID)
+	if err != nil {
+		return err
+	}
+	m.record(ctx, repositoryID, "", &RefsJournalEntry{Op: RefsJournalDeleteBranch, BranchID: branchID, PreviousCommitID: previousCommitID})
+	return nil
+}
+
+func (m *journalingRefManager) CreateTag(ctx context.Context, repositoryID RepositoryID, tagID TagID, commitID CommitID) error {
+	if err := m.RefManager.CreateTag(ctx, repositoryID, tagID, commitID); err != nil {
+		return err
+	}
+	m.record(ctx, repositoryID, "", &RefsJournalEntry{Op: RefsJournalCreateTag, TagID: tagID, CommitID: commitID})
+	return nil
+}
+
+func (m *journalingRefManager) DeleteTag(ctx context.Context, repositoryID RepositoryID, tagID TagID) error {
+	if err := m.RefManager.DeleteTag(ctx, repositoryID, tagID); err != nil {
+		return err
+	}
+	m.record(ctx, repositoryID, "", &RefsJournalEntry{Op: RefsJournalDeleteTag, TagID: tagID})
+	return nil
+}
+
+func (m *journalingRefManager) ReplaceCommits(ctx context.Context, repositoryID RepositoryID, replacements map[CommitID]CommitID) error {
+	if err := m.RefManager.ReplaceCommits(ctx, repositoryID, replacements); err != nil {
+		return err
+	}
+	m.record(ctx, repositoryID, "", &RefsJournalEntry{Op: RefsJournalReplaceCommits, Replacements: replacements})
+	return nil
+}
+
+func (m *journalingRefManager) DeleteCommits(ctx context.Context, repositoryID RepositoryID, commitIDs []CommitID) error {
+	if err := m.RefManager.DeleteCommits(ctx, repositoryID, commitIDs); err != nil {
+		return err
+	}
+	m.record(ctx, repositoryID, "", &RefsJournalEntry{Op: RefsJournalDeleteCommits, CommitIDs: commitIDs})
+	return nil
+}
+
+func (m *journalingRefManager) DeleteBranchLogCommits(ctx context.Context, repositoryID RepositoryID, commitIDs []CommitID) error {
+	if err := m.RefManager.DeleteBranchLogCommits(ctx, repositoryID, commitIDs); err != nil {
+		return err
+	}
+	m.record(ctx, repositoryID, "", &RefsJournalEntry{Op: RefsJournalExpireBranchLog, CommitIDs: commitIDs})
+	return nil
+}
+
+// ReplayRefsJournal applies entries of the refs journal to repositoryID, in order.  Entries
+// that were already applied are skipped: branches and tags deleted again, tags and commits
+// created again.
+func (g *Graveler) ReplayRefsJournal(ctx context.Context, repositoryID RepositoryID, entries []*RefsJournalEntry) error {
+	for _, entry := range entries {
+		if err := g.replayRefsJournalEntry(ctx, repositoryID, entry); err != nil {
+			return fmt.Errorf("replay %s of %s: %w", entry.Op, entry.Time, err)
+		}
+	}
+	// commits of the journal may be added before their parents from the backup
+	_, err := g.RefManager.FillGenerations(ctx, repositoryID)
+	return err
+}
+
+func (g *Graveler) replayRefsJournalEntry(ctx context.Context, repositoryID RepositoryID, entry *RefsJournalEntry) error {
+	switch entry.Op {
+	case RefsJournalCreateRepository:
+		// the journal is replayed onto an existing repository
+		return nil
+	case RefsJournalAddCommit:
+		if entry.Commit == nil {
+			return fmt.Errorf("commit %s: %w", entry.CommitID, ErrInvalidValue)
+		}
+		if err := VerifyCommitID(entry.CommitID, *entry.Commit); err != nil {
+			return err
+		}
+		_, err := g.RefManager.AddCommit(ctx, repositoryID, *entry.Commit)
+		return err
+	case RefsJournalSetBranch:
+		// keep the staging token of an existing branch, its uncommitted changes were lost
+		stagingToken := generateStagingToken(repositoryID, entry.BranchID)
+		branch, err := g.RefManager.GetBranch(ctx, repositoryID, entry.BranchID)
+		if err == nil {
+			stagingToken = branch.StagingToken
+		} else if !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		return g.RefManager.SetBranch(ctx, repositoryID, entry.BranchID, Branch{CommitID: entry.CommitID, StagingToken: stagingToken})
+	case RefsJournalDeleteBranch:
+		err := g.RefManager.DeleteBranch(ctx, repositoryID, entry.BranchID)
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	case RefsJournalCreateTag:
+		err := g.RefManager.CreateTag(ctx, repositoryID, entry.TagID, entry.CommitID)
+		if errors.Is(err, ErrTagAlreadyExists) {
+			return nil
+		}
+		return err
+	case RefsJournalDeleteTag:
+		err := g.RefManager.DeleteTag(ctx, repositoryID, entry.TagID)
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	case RefsJournalReplaceCommits:
+		return g.RefManager.ReplaceCommits(ctx, repositoryID, entry.Replacements)
+	case RefsJournalDeleteCommits:
+		return g.RefManager.DeleteCommits(ctx, repositoryID, entry.CommitIDs)
+	case RefsJournalExpireBranchLog:
+		return g.RefManager.DeleteBranchLogCommits(ctx, repositoryID, entry.CommitIDs)
+	default:
+		return fmt.Errorf("%s: %w", entry.Op, ErrUnknownRefsJournalOp)
+	}
+}
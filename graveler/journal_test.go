@@ -0,0 +1,142 @@
+package graveler_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/graveler/testutil"
+)
+
+type recordingJournal struct {
+	appended map[graveler.StorageNamespace][][]*graveler.RefsJournalEntry
+	err      error
+}
+
+func (j *recordingJournal) Append(_ context.Context, storageNamespace graveler.StorageNamespace, entries []*graveler.RefsJournalEntry) error {
+	if j.err != nil {
+		return j.err
+	}
+	if j.appended == nil {
+		j.appended = make(map[graveler.StorageNamespace][][]*graveler.RefsJournalEntry)
+	}
+	j.appended[storageNamespace] = append(j.appended[storageNamespace], entries)
+	return nil
+}
+
+func TestJournalingRefManager(t *testing.T) {
+	ctx := context.Background()
+	commit := graveler.Commit{Committer: "dev", Message: "message", MetaRangeID: "mr1", CreationDate: time.Unix(1600000000, 0).UTC()}
+	refs := &testutil.RefsFake{
+		Repository: &graveler.Repository{StorageNamespace: "mem://journal"},
+		CommitID:   commit.ID(),
+		Branch:     &graveler.Branch{CommitID: "c0", StagingToken: "token"},
+	}
+	journal := &recordingJournal{}
+	m := graveler.NewJournalingRefManager(refs, journal)
+
+	commitID, err := m.AddCommit(ctx, "repo", commit)
+	if err != nil {
+		t.Fatalf("AddCommit: %s", err)
+	}
+	if err := m.SetBranch(ctx, "repo", "main", graveler.Branch{CommitID: commitID, StagingToken: "token"}); err != nil {
+		t.Fatalf("SetBranch: %s", err)
+	}
+	if err := m.DeleteTag(ctx, "repo", "v1"); err != nil {
+		t.Fatalf("DeleteTag: %s", err)
+	}
+	// changes that fail are not recorded
+	refs.CommitErr = errors.New("failed")
+	if _, err := m.AddCommit(ctx, "repo", graveler.Commit{Message: "failed"}); err == nil {
+		t.Fatal("AddCommit succeeded with failing refs")
+	}
+
+	operations := journal.appended["mem://journal"]
+	var entries []*graveler.RefsJournalEntry
+	for _, operation := range operations {
+		for _, entry := range operation {
+			if entry.Time.IsZero() {
+				t.Errorf("entry %+v recorded with no time", entry)
+			}
+			entry.Time = time.Time{}
+			entries = append(entries, entry)
+		}
+	}
+	expected := []*graveler.RefsJournalEntry{
+		{Repository: "repo", Op: graveler.RefsJournalAddCommit, CommitID: commit.ID(), Commit: &commit},
+		{Repository: "repo", Op: graveler.RefsJournalSetBranch, BranchID: "main", CommitID: commit.ID(), PreviousCommitID: "c0"},
+		{Repository: "repo", Op: graveler.RefsJournalDeleteTag, TagID: "v1"},
+	}
+	if diff := deep.Equal(entries, expected); diff != nil {
+		t.Error("journal entries diff found", diff)
+	}
+}
+
+func TestJournalingRefManager_FailedRecord(t *testing.T) {
+	ctx := context.Background()
+	errJournal := errors.New("journal failed")
+	refs := &testutil.RefsFake{
+		Repository: &graveler.Repository{StorageNamespace: "mem://journal"},
+		Err:        graveler.ErrNotFound,
+	}
+	m := graveler.NewJournalingRefManager(refs, &recordingJournal{err: errJournal})
+
+	// the changes were made, so they succeed even though the journal misses them
+	if err := m.SetBranch(ctx, "repo", "main", graveler.Branch{CommitID: "c1", StagingToken: "token"}); err != nil {
+		t.Errorf("SetBranch with failing journal: %s", err)
+	}
+	if err := m.CreateTag(ctx, "repo", "v1", "c1"); err != nil {
+		t.Errorf("CreateTag with failing journal: %s", err)
+	}
+}
+
+func TestJournalingRefManager_CreateBareRepository(t *testing.T) {
+	ctx := context.Background()
+	journal := &recordingJournal{}
+	m := graveler.NewJournalingRefManager(&testutil.RefsFake{}, journal)
+
+	err := m.CreateBareRepository(ctx, "repo", graveler.Repository{StorageNamespace: "mem://bare", DefaultBranchID: "main"})
+	if err != nil {
+		t.Fatalf("CreateBareRepository: %s", err)
+	}
+	operations := journal.appended["mem://bare"]
+	if len(operations) != 1 || len(operations[0]) != 1 {
+		t.Fatalf("journal of a bare repository %v, expected a single entry", operations)
+	}
+	entry := operations[0][0]
+	if entry.Op != graveler.RefsJournalCreateRepository || entry.Repository != "repo" || entry.BranchID != "main" {
+		t.Errorf("journal entry %+v, expected the creation of repo with default branch main", entry)
+	}
+}
+
+func TestGraveler_ReplayRefsJournal(t *testing.T) {
+	ctx := context.Background()
+	commit := graveler.Commit{Committer: "dev", Message: "message", MetaRangeID: "mr1", CreationDate: time.Unix(1600000000, 0).UTC()}
+	refs := &testutil.RefsFake{Branch: &graveler.Branch{StagingToken: "token"}}
+	g := graveler.NewGraveler(nil, nil, nil, refs)
+
+	err := g.ReplayRefsJournal(ctx, "repo", []*graveler.RefsJournalEntry{
+		{Op: graveler.RefsJournalAddCommit, CommitID: commit.ID(), Commit: &commit},
+		{Op: graveler.RefsJournalSetBranch, BranchID: "main", CommitID: commit.ID()},
+	})
+	if err != nil {
+		t.Fatalf("ReplayRefsJournal: %s", err)
+	}
+	if refs.AddedCommit.Message != commit.Message || refs.AddedCommit.MetaRangeID != commit.MetaRangeID {
+		t.Errorf("replay added commit %+v, expected %+v", refs.AddedCommit, commit)
+	}
+
+	err = g.ReplayRefsJournal(ctx, "repo", []*graveler.RefsJournalEntry{
+		{Op: graveler.RefsJournalAddCommit, CommitID: "not-its-id", Commit: &commit},
+	})
+	if !errors.Is(err, graveler.ErrInvalidCommitID) {
+		t.Errorf("replay of a commit under another ID err=%v, expected %s", err, graveler.ErrInvalidCommitID)
+	}
+	err = g.ReplayRefsJournal(ctx, "repo", []*graveler.RefsJournalEntry{{Op: "rename_branch"}})
+	if !errors.Is(err, graveler.ErrUnknownRefsJournalOp) {
+		t.Errorf("replay of an unknown operation err=%v, expected %s", err, graveler.ErrUnknownRefsJournalOp)
+	}
+}
@@ -0,0 +1,260 @@
+package graveler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+)
+
+// PurgeResult is the result of removing keys from the entire history of a repository
+type PurgeResult struct {
+	// Commits maps each rewritten commit to the commit replacing it
+	Commits map[CommitID]CommitID
+	// Removed are the values removed from commits and staging areas, each key and identity once
+	Removed []*ValueRecord
+}
+
+// purgeMatcher matches the keys removed by a purge: either a single key or all keys under a prefix
+type purgeMatcher struct {
+	key    Key
+	prefix bool
+}
+
+func (m purgeMatcher) match(key Key) bool {
+	if m.prefix {
+		return bytes.HasPrefix(key, m.key)
+	}
+	return bytes.Equal(key, m.key)
+}
+
+// purgeIterator skips the values matched by a purge, collecting them
+type purgeIterator struct {
+	ValueIterator
+	matcher purgeMatcher
+	removed func(*ValueRecord)
+}
+
+func (it *purgeIterator) Next() bool {
+	for it.ValueIterator.Next() {
+		v := it.ValueIterator.Value()
+		if !it.matcher.match(v.Key) {
+			return true
+		}
+		it.removed(v)
+	}
+	return false
+}
+
+// commitsParentsFirst orders the commits so that every commit appears after its parents
+func commitsParentsFirst(commits map[CommitID]*Commit) []CommitID {
+	ids := make([]CommitID, 0, len(commits))
+	for id := range commits {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	res := make([]CommitID, 0, len(commits))
+	visited := make(map[CommitID]struct{}, len(commits))
+	var visit func(id CommitID)
+	visit = func(id CommitID) {
+		if _, ok := visited[id]; ok {
+			return
+		}
+		visited[id] = struct{}{}
+		commit, ok := commits[id]
+		if !ok {
+			return
+		}
+		for _, parent := range commit.Parents {
+			visit(parent)
+		}
+		res = append(res, id)
+	}
+	for _, id := range ids {
+		visit(id)
+	}
+	return res
+}
+
+// PurgeHistory removes key, or all keys starting with key when prefix is set, from every commit
+// and staging area of the repository.  Commits are re-created with their original metadata on top
+// of the rewritten MetaRanges, and branches, tags and repository snapshots are moved to the
+// re-created commits before the original commits are deleted.  Returns ErrHistoryChanged if
+// commits were added on top of rewritten commits while purging.
+func (g *Graveler) PurgeHistory(ctx context.Context, repositoryID RepositoryID, key Key, prefix bool) (*PurgeResult, error) {
+	repo, err := g.RefManager.GetRepository(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	matcher := purgeMatcher{key: key, prefix: prefix}
+	removed := make(map[string]*ValueRecord)
+	collect := func(v *ValueRecord) {
+		if v.Value == nil {
+			return
+		}
+		id := string(v.Key) + "\x00" + string(v.Identity)
+		if _, ok := removed[id]; !ok {
+			removed[id] = &ValueRecord{
+				Key: v.Key.Copy(),
+				Value: &Value{
+					Identity: append([]byte(nil), v.Identity...),
+					Data:     append([]byte(nil), v.Data...),
+				},
+			}
+		}
+	}
+
+	if err := g.purgeStaging(ctx, repositoryID, matcher, collect); err != nil {
+		return nil, err
+	}
+
+	commitsIt, err := g.RefManager.ListCommits(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	commits := make(map[CommitID]*Commit)
+	for commitsIt.Next() {
+		rec := commitsIt.Value()
+		commits[rec.CommitID] = rec.Commit
+	}
+	err = commitsIt.Err()
+	commitsIt.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	metaRanges := make(map[MetaRangeID]MetaRangeID)
+	replaced := make(map[CommitID]CommitID)
+	for _, commitID := range commitsParentsFirst(commits) {
+		commit := commits[commitID]
+		metaRangeID, ok := metaRanges[commit.MetaRangeID]
+		if !ok {
+			metaRangeID, err = g.purgeMetaRange(WithRangeSplitPolicy(ctx, repo.RangeSplit), repo.StorageNamespace, commit.MetaRangeID, matcher, collect)
+			if err != nil {
+				return nil, fmt.Errorf("commit %s: %w", commitID, err)
+			}
+			metaRanges[commit.MetaRangeID] = metaRangeID
+		}
+		changed := metaRangeID != commit.MetaRangeID
+		parents := make(CommitParents, len(commit.Parents))
+		for i, parent := range commit.Parents {
+			parents[i] = parent
+			if newParent, ok := replaced[parent]; ok {
+				parents[i] = newParent
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		newCommit := *commit
+		newCommit.MetaRangeID = metaRangeID
+		newCommit.Parents = parents
+		newCommitID, err := g.RefManager.AddCommit(ctx, repositoryID, newCommit)
+		if err != nil {
+			return nil, fmt.Errorf("add commit replacing %s: %w", commitID, err)
+		}
+		replaced[commitID] = newCommitID
+	}
+
+	if err := g.RefManager.ReplaceCommits(ctx, repositoryID, replaced); err != nil {
+		return nil, err
+	}
+
+	result := &PurgeResult{
+		Commits: replaced,
+		Removed: make([]*ValueRecord, 0, len(removed)),
+	}
+	for _, v := range removed {
+		result.Removed = append(result.Removed, v)
+	}
+	sort.Slice(result.Removed, func(i, j int) bool {
+		if c := bytes.Compare(result.Removed[i].Key, result.Removed[j].Key); c != 0 {
+			return c < 0
+		}
+		return bytes.Compare(result.Removed[i].Identity, result.Removed[j].Identity) < 0
+	})
+	return result, nil
+}
+
+// purgeMetaRange returns the ID of metaRangeID without the values matched, writing a new
+// MetaRange only when a value matches
+func (g *Graveler) purgeMetaRange(ctx context.Context, ns StorageNamespace, metaRangeID MetaRangeID, matcher purgeMatcher, collect func(*ValueRecord)) (MetaRangeID, error) {
+	if metaRangeID == "" {
+		return "", nil
+	}
+	it, err := g.CommittedManager.List(ctx, ns, metaRangeID)
+	if err != nil {
+		return "", err
+	}
+	it.SeekGE(matcher.key)
+	found := it.Next() && matcher.match(it.Value().Key)
+	err = it.Err()
+	it.Close()
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return metaRangeID, nil
+	}
+
+	it, err = g.CommittedManager.List(ctx, ns, metaRangeID)
+	if err != nil {
+		return "", err
+	}
+	defer it.Close()
+	newMetaRangeID, err := g.CommittedManager.WriteMetaRange(ctx, ns, &purgeIterator{
+		ValueIterator: it,
+		matcher:       matcher,
+		removed:       collect,
+	}, nil)
+	if err != nil {
+		return "", fmt.Errorf("write metarange: %w", err)
+	}
+	return *newMetaRangeID, nil
+}
+
+// purgeStaging drops the values matched from the staging areas of all branches
+func (g *Graveler) purgeStaging(ctx context.Context, repositoryID RepositoryID, matcher purgeMatcher, collect func(*ValueRecord)) error {
+	branchesIt, err := g.RefManager.ListBranches(ctx, repositoryID)
+	if err != nil {
+		return err
+	}
+	var branchIDs []BranchID
+	for branchesIt.Next() {
+		branchIDs = append(branchIDs, branchesIt.Value().BranchID)
+	}
+	err = branchesIt.Err()
+	branchesIt.Close()
+	if err != nil {
+		return err
+	}
+	for _, branchID := range branchIDs {
+		_, err := g.branchLocker.Writer(ctx, repositoryID, branchID, func() (interface{}, error) {
+			branch, err := g.RefManager.GetBranch(ctx, repositoryID, branchID)
+			if err != nil {
+				return nil, err
+			}
+			it, err := g.StagingManager.List(ctx, branch.StagingToken)
+			if err != nil {
+				return nil, err
+			}
+			defer it.Close()
+			it.SeekGE(matcher.key)
+			for it.Next() && matcher.match(it.Value().Key) {
+				collect(it.Value())
+			}
+			if err := it.Err(); err != nil {
+				return nil, err
+			}
+			if matcher.prefix {
+				return nil, g.StagingManager.DropByPrefix(ctx, branch.StagingToken, matcher.key)
+			}
+			return nil, g.StagingManager.DropKey(ctx, branch.StagingToken, matcher.key)
+		})
+		if err != nil {
+			return fmt.Errorf("branch %s: %w", branchID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package graveler
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// RangeSplitPolicy tunes how the trees of a repository are split into ranges.  Zero fields keep
+// the configured defaults.
+type RangeSplitPolicy struct {
+	// MinRangeSizeBytes is the size a range reaches before it may be split
+	MinRangeSizeBytes uint64 `json:"min_range_size_bytes,omitempty"`
+	// MaxRangeSizeBytes is the size at which a range is always split
+	MaxRangeSizeBytes uint64 `json:"max_range_size_bytes,omitempty"`
+	// MaxRangeEntries is the number of entries at which a range is always split
+	MaxRangeEntries int `json:"max_range_entries,omitempty"`
+	// PrefixBoundaryDepth splits ranges past MinRangeSizeBytes only where the first
+	// PrefixBoundaryDepth path components of keys change, aligning ranges with partitions
+	PrefixBoundaryDepth int `json:"prefix_boundary_depth,omitempty"`
+}
+
+func (p RangeSplitPolicy) Value() (driver.Value, error) {
+	return json.Marshal(p)
+}
+
+func (p *RangeSplitPolicy) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(data, p)
+	case string:
+		return json.Unmarshal([]byte(data), p)
+	default:
+		return fmt.Errorf("range split policy type %T: %w", src, ErrInvalidValue)
+	}
+}
+
+type rangeSplitPolicyKey struct{}
+
+// WithRangeSplitPolicy returns a context carrying policy to the CommittedManager for the trees
+// written with it
+func WithRangeSplitPolicy(ctx context.Context, policy *RangeSplitPolicy) context.Context {
+	if policy == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, rangeSplitPolicyKey{}, policy)
+}
+
+// RangeSplitPolicyFromContext returns the policy set by WithRangeSplitPolicy, or nil
+func RangeSplitPolicyFromContext(ctx context.Context) *RangeSplitPolicy {
+	policy, _ := ctx.Value(rangeSplitPolicyKey{}).(*RangeSplitPolicy)
+	return policy
+}
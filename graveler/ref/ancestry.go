@@ -0,0 +1,123 @@
+package ref
+
+import (
+	"context"
+
+	"github.com/treeverse/lakefs/graveler"
+)
+
+// walkDecision tells walkCommitIDs how to continue after visiting a commit
+type walkDecision int
+
+const (
+	// walkParents continues the walk to the parents of the commit
+	walkParents walkDecision = iota
+	// walkSkipParents continues the walk without the parents of the commit
+	walkSkipParents
+	// walkStop ends the walk
+	walkStop
+)
+
+// IsAncestor returns true if ancestor is reachable from commitID by following parents.  A commit
+// is its own ancestor.  Commits with a known generation not above the generation of ancestor
+// cannot descend from it, so the walk does not continue to their parents.
+func IsAncestor(ctx context.Context, getter CommitGetter, repositoryID graveler.RepositoryID, ancestor, commitID graveler.CommitID) (bool, error) {
+	ancestorCommit, err := getter.GetCommit(ctx, repositoryID, ancestor)
+	if err != nil {
+		return false, err
+	}
+	found := false
+	err = walkCommitIDs(ctx, getter, repositoryID, commitID, func(id graveler.CommitID, commit *graveler.Commit) walkDecision {
+		if id == ancestor {
+			found = true
+			return walkStop
+		}
+		if ancestorCommit.Generation > 0 && commit.Generation > 0 && commit.Generation <= ancestorCommit.Generation {
+			return walkSkipParents
+		}
+		return walkParents
+	})
+	return found, err
+}
+
+// CommitsBetween returns the commits reachable from to but not from from.  It is similar to 'git
+// log from..to'.  When the generations of both commits are known the commits are ordered by
+// decreasing generation and only the history down to the merge base of both is read, otherwise
+// they are in breadth first order starting at to.
+func CommitsBetween(ctx context.Context, getter CommitGetter, repositoryID graveler.RepositoryID, from, to graveler.CommitID) ([]*graveler.CommitRecord, error) {
+	fromCommit, err := getter.GetCommit(ctx, repositoryID, from)
+	if err != nil {
+		return nil, err
+	}
+	toCommit, err := getter.GetCommit(ctx, repositoryID, to)
+	if err != nil {
+		return nil, err
+	}
+	if fromCommit.Generation > 0 && toCommit.Generation > 0 {
+		return commitsBetweenByGeneration(ctx, getter, repositoryID,
+			&graveler.CommitRecord{CommitID: from, Commit: fromCommit},
+			&graveler.CommitRecord{CommitID: to, Commit: toCommit})
+	}
+	excluded := make(map[graveler.CommitID]struct{})
+	err = walkCommitIDs(ctx, getter, repositoryID, from, func(id graveler.CommitID, _ *graveler.Commit) walkDecision {
+		excluded[id] = struct{}{}
+		return walkParents
+	})
+	if err != nil {
+		return nil, err
+	}
+	var commits []*graveler.CommitRecord
+	err = walkCommitIDs(ctx, getter, repositoryID, to, func(id graveler.CommitID, commit *graveler.Commit) walkDecision {
+		if _, ok := excluded[id]; ok {
+			return walkSkipParents
+		}
+		commits = append(commits, &graveler.CommitRecord{CommitID: id, Commit: commit})
+		return walkParents
+	})
+	if err != nil {
+		return nil, err
+	}
+	return commits, nil
+}
+
+func commitsBetweenByGeneration(ctx context.Context, getter CommitGetter, repositoryID graveler.RepositoryID, from, to *graveler.CommitRecord) ([]*graveler.CommitRecord, error) {
+	var commits []*graveler.CommitRecord
+	notFromLeft := func(flags paintFlags) bool { return flags&paintLeft == 0 }
+	err := paintWalk(ctx, getter, repositoryID, from, to, notFromLeft, func(id graveler.CommitID, commit *graveler.Commit, flags paintFlags) walkDecision {
+		if flags == paintRight {
+			commits = append(commits, &graveler.CommitRecord{CommitID: id, Commit: commit})
+		}
+		return walkParents
+	})
+	if err != nil {
+		return nil, err
+	}
+	return commits, nil
+}
+
+// walkCommitIDs walks the history of startID breadth first, calling visit once for each commit
+func walkCommitIDs(ctx context.Context, getter CommitGetter, repositoryID graveler.RepositoryID, startID graveler.CommitID, visit func(graveler.CommitID, *graveler.Commit) walkDecision) error {
+	queue := []graveler.CommitID{startID}
+	discovered := map[graveler.CommitID]struct{}{startID: {}}
+	for len(queue) > 0 {
+		commitID := queue[0]
+		queue = queue[1:]
+		commit, err := getter.GetCommit(ctx, repositoryID, commitID)
+		if err != nil {
+			return err
+		}
+		switch visit(commitID, commit) {
+		case walkStop:
+			return nil
+		case walkSkipParents:
+			continue
+		}
+		for _, parent := range commit.Parents {
+			if _, ok := discovered[parent]; !ok {
+				discovered[parent] = struct{}{}
+				queue = append(queue, parent)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package ref_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/graveler/ref"
+)
+
+// newAncestryReader returns commits c0..c6 where main is c0-c1-c3-c5, branch is c1-c2-c4 and c6
+// merges c4 into c5
+func newAncestryReader() *MockCommitGetter {
+	c0 := &graveler.Commit{Message: "0", Parents: []graveler.CommitID{}}
+	c1 := &graveler.Commit{Message: "1", Parents: []graveler.CommitID{caddr(c0)}}
+	c2 := &graveler.Commit{Message: "2", Parents: []graveler.CommitID{caddr(c1)}}
+	c3 := &graveler.Commit{Message: "3", Parents: []graveler.CommitID{caddr(c1)}}
+	c4 := &graveler.Commit{Message: "4", Parents: []graveler.CommitID{caddr(c2)}}
+	c5 := &graveler.Commit{Message: "5", Parents: []graveler.CommitID{caddr(c3)}}
+	c6 := &graveler.Commit{Message: "6", Parents: []graveler.CommitID{caddr(c5), caddr(c4)}}
+	return newReader(map[graveler.CommitID]*graveler.Commit{
+		"c0": c0, "c1": c1, "c2": c2, "c3": c3, "c4": c4, "c5": c5, "c6": c6,
+	})
+}
+
+func TestIsAncestor(t *testing.T) {
+	cases := []struct {
+		Ancestor string
+		Commit   string
+		Expected bool
+	}{
+		{Ancestor: "c0", Commit: "c6", Expected: true},
+		{Ancestor: "c4", Commit: "c6", Expected: true},
+		{Ancestor: "c3", Commit: "c5", Expected: true},
+		{Ancestor: "c5", Commit: "c5", Expected: true},
+		{Ancestor: "c6", Commit: "c5", Expected: false},
+		{Ancestor: "c2", Commit: "c5", Expected: false},
+		{Ancestor: "c3", Commit: "c4", Expected: false},
+	}
+	for _, cas := range cases {
+		t.Run(cas.Ancestor+"_"+cas.Commit, func(t *testing.T) {
+			getter := newAncestryReader()
+			got, err := ref.IsAncestor(context.Background(), getter, "",
+				caddr(getter.kv[graveler.CommitID(cas.Ancestor)]), caddr(getter.kv[graveler.CommitID(cas.Commit)]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != cas.Expected {
+				t.Errorf("IsAncestor(%s, %s) = %t, expected %t", cas.Ancestor, cas.Commit, got, cas.Expected)
+			}
+		})
+	}
+}
+
+func TestCommitsBetween(t *testing.T) {
+	cases := []struct {
+		From     string
+		To       string
+		Expected []string
+	}{
+		{From: "c5", To: "c6", Expected: []string{"c6", "c4", "c2"}},
+		{From: "c4", To: "c6", Expected: []string{"c6", "c5", "c3"}},
+		{From: "c2", To: "c5", Expected: []string{"c5", "c3"}},
+		{From: "c6", To: "c5", Expected: nil},
+		{From: "c5", To: "c5", Expected: nil},
+	}
+	for _, cas := range cases {
+		t.Run(cas.From+"_"+cas.To, func(t *testing.T) {
+			getter := newAncestryReader()
+			names := make(map[graveler.CommitID]string)
+			for name, commit := range getter.kv {
+				names[caddr(commit)] = string(name)
+			}
+			commits, err := ref.CommitsBetween(context.Background(), getter, "",
+				caddr(getter.kv[graveler.CommitID(cas.From)]), caddr(getter.kv[graveler.CommitID(cas.To)]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, commit := range commits {
+				got = append(got, names[commit.CommitID])
+			}
+			if diff := deep.Equal(got, cas.Expected); diff != nil {
+				t.Errorf("CommitsBetween(%s, %s) = %v: %s", cas.From, cas.To, got, diff)
+			}
+		})
+	}
+}
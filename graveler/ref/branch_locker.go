@@ -34,6 +34,9 @@ func (l *BranchLocker) Writer(ctx context.Context, repositoryID graveler.Reposit
 		if !locked {
 			return nil, fmt.Errorf("%w (%d)", graveler.ErrAlreadyLocked, writerLockKey)
 		}
+		if err := graveler.CheckBranchWrite(ctx); err != nil {
+			return nil, err
+		}
 		return lockedFn()
 	}, db.WithContext(ctx), db.WithIsolationLevel(pgx.ReadCommitted))
 }
@@ -58,6 +61,9 @@ func (l *BranchLocker) MetadataUpdater(ctx context.Context, repositoryID gravele
 		if err != nil {
 			return nil, fmt.Errorf("%w (%d): %s", graveler.ErrLockNotAcquired, writerLockKey, err)
 		}
+		if err := graveler.CheckBranchWrite(ctx); err != nil {
+			return nil, err
+		}
 		return lockedFn()
 	}, db.WithContext(ctx), db.WithIsolationLevel(pgx.ReadCommitted))
 }
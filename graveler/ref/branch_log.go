@@ -0,0 +1,76 @@
+package ref
+
+import (
+	"context"
+	"errors"
+
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+// logBranchCommit appends commitID to the log of branchID, unless it is already the commit of
+// the branch.  It must run before the branch is set to commitID.
+func logBranchCommit(tx db.Tx, repositoryID graveler.RepositoryID, branchID graveler.BranchID, commitID graveler.CommitID) error {
+	_, err := tx.Exec(`
+		INSERT INTO graveler_branch_log (repository_id, branch_id, commit_id)
+		SELECT $1, $2, $3
+		WHERE NOT EXISTS (SELECT 1 FROM graveler_branches WHERE repository_id = $1 AND id = $2 AND commit_id = $3)`,
+		repositoryID, branchID, commitID)
+	return err
+}
+
+// GetBranchLogCommit returns the commit of branchID n updates before its latest one, as the
+// reflog of git does for "branch@{n}"
+func (m *Manager) GetBranchLogCommit(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, n int) (*graveler.CommitID, error) {
+	commitID, err := m.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var commitID graveler.CommitID
+		err := tx.Get(&commitID, `
+			SELECT commit_id FROM graveler_branch_log
+			WHERE repository_id = $1 AND branch_id = $2
+			ORDER BY id DESC
+			OFFSET $3 LIMIT 1`,
+			repositoryID, branchID, n)
+		if err != nil {
+			return nil, err
+		}
+		return &commitID, nil
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, graveler.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return commitID.(*graveler.CommitID), nil
+}
+
+func (m *Manager) ListBranchLog(ctx context.Context, repositoryID graveler.RepositoryID) ([]*graveler.BranchLogRecord, error) {
+	records, err := m.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var records []*graveler.BranchLogRecord
+		err := tx.Select(&records, `
+			SELECT branch_id, commit_id, creation_date FROM graveler_branch_log
+			WHERE repository_id = $1
+			ORDER BY branch_id, id DESC`,
+			repositoryID)
+		return records, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return records.([]*graveler.BranchLogRecord), nil
+}
+
+func (m *Manager) DeleteBranchLogCommits(ctx context.Context, repositoryID graveler.RepositoryID, commitIDs []graveler.CommitID) error {
+	if len(commitIDs) == 0 {
+		return nil
+	}
+	ids := make([]string, len(commitIDs))
+	for i, id := range commitIDs {
+		ids[i] = id.String()
+	}
+	_, err := m.db.Transact(func(tx db.Tx) (interface{}, error) {
+		return tx.Exec(`DELETE FROM graveler_branch_log WHERE repository_id = $1 AND commit_id = ANY($2)`,
+			repositoryID, ids)
+	}, db.WithContext(ctx))
+	return err
+}
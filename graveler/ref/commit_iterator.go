@@ -13,6 +13,7 @@ type CommitIterator struct {
 	ctx          context.Context
 	repositoryID graveler.RepositoryID
 	start        graveler.CommitID
+	firstParent  bool
 	value        *graveler.CommitRecord
 	queue        commitsPriorityQueue
 	visit        map[graveler.CommitID]struct{}
@@ -58,12 +59,15 @@ func (c *commitsPriorityQueue) Pop() interface{} {
 	return item
 }
 
-func NewCommitIterator(ctx context.Context, db db.Database, repositoryID graveler.RepositoryID, start graveler.CommitID) *CommitIterator {
+// NewCommitIterator returns an iterator over the history of start, newest commits first.  When
+// firstParent is set only the first parent of each commit is followed, like 'git log --first-parent'.
+func NewCommitIterator(ctx context.Context, db db.Database, repositoryID graveler.RepositoryID, start graveler.CommitID, firstParent bool) *CommitIterator {
 	return &CommitIterator{
 		db:           db,
 		ctx:          ctx,
 		repositoryID: repositoryID,
 		start:        start,
+		firstParent:  firstParent,
 		queue:        make(commitsPriorityQueue, 0),
 		visit:        make(map[graveler.CommitID]struct{}),
 	}
@@ -72,7 +76,8 @@ func NewCommitIterator(ctx context.Context, db db.Database, repositoryID gravele
 func (ci *CommitIterator) getCommitRecord(commitID graveler.CommitID) (*graveler.CommitRecord, error) {
 	var rec commitRecord
 	err := ci.db.WithContext(ci.ctx).
-		Get(&rec, `SELECT id, committer, message, creation_date, parents, meta_range_id, metadata
+		Get(&rec, `SELECT id, committer, committer_email, message, creation_date, parents, meta_range_id, metadata,
+				author, author_email, author_date
 			FROM graveler_commits
 			WHERE repository_id = $1 AND id = $2`,
 			ci.repositoryID, commitID)
@@ -109,7 +114,11 @@ func (ci *CommitIterator) Next() bool {
 	// as long as we have something in the queue we will
 	// set it as the current value and push the current commit's parents to the queue
 	ci.value = heap.Pop(&ci.queue).(*graveler.CommitRecord)
-	for _, p := range ci.value.Parents {
+	parents := ci.value.Parents
+	if ci.firstParent && len(parents) > 1 {
+		parents = parents[:1]
+	}
+	for _, p := range parents {
 		rec, err := ci.getCommitRecord(p)
 		if err != nil {
 			ci.value = nil
@@ -66,7 +66,8 @@ func (iter *OrderedCommitIterator) maybeFetch() {
 
 	var buf []*commitRecord
 	err := iter.db.WithContext(iter.ctx).Select(&buf, `
-			SELECT id, committer, message, creation_date, meta_range_id, parents, metadata
+			SELECT id, committer, committer_email, message, creation_date, meta_range_id, parents, metadata,
+				author, author_email, author_date
 			FROM graveler_commits
 			WHERE repository_id = $1
 			AND id `+offsetCondition+` $2
@@ -7,13 +7,18 @@ import (
 )
 
 type commitRecord struct {
-	CommitID     string            `db:"id"`
-	Committer    string            `db:"committer"`
-	Message      string            `db:"message"`
-	RangeID      string            `db:"meta_range_id"`
-	CreationDate time.Time         `db:"creation_date"`
-	Parents      []string          `db:"parents"`
-	Metadata     map[string]string `db:"metadata"`
+	CommitID       string            `db:"id"`
+	Committer      string            `db:"committer"`
+	CommitterEmail string            `db:"committer_email"`
+	Message        string            `db:"message"`
+	RangeID        string            `db:"meta_range_id"`
+	CreationDate   time.Time         `db:"creation_date"`
+	Parents        []string          `db:"parents"`
+	Metadata       map[string]string `db:"metadata"`
+	Generation     int64             `db:"generation"`
+	Author         string            `db:"author"`
+	AuthorEmail    string            `db:"author_email"`
+	AuthorDate     *time.Time        `db:"author_date"`
 }
 
 func (c *commitRecord) toGravelerCommit() *graveler.Commit {
@@ -21,13 +26,22 @@ func (c *commitRecord) toGravelerCommit() *graveler.Commit {
 	for i := range c.Parents {
 		parents[i] = graveler.CommitID(c.Parents[i])
 	}
+	var authorDate time.Time
+	if c.AuthorDate != nil {
+		authorDate = *c.AuthorDate
+	}
 	return &graveler.Commit{
-		Committer:    c.Committer,
-		Message:      c.Message,
-		MetaRangeID:  graveler.MetaRangeID(c.RangeID),
-		CreationDate: c.CreationDate,
-		Parents:      parents,
-		Metadata:     c.Metadata,
+		Committer:      c.Committer,
+		CommitterEmail: c.CommitterEmail,
+		Message:        c.Message,
+		MetaRangeID:    graveler.MetaRangeID(c.RangeID),
+		CreationDate:   c.CreationDate,
+		Parents:        parents,
+		Metadata:       c.Metadata,
+		Generation:     c.Generation,
+		Author:         c.Author,
+		AuthorEmail:    c.AuthorEmail,
+		AuthorDate:     authorDate,
 	}
 }
 
@@ -0,0 +1,37 @@
+package ref
+
+import (
+	"context"
+	"errors"
+
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+// GetDeltaResolution returns the full MetaRange materialized for the delta MetaRange deltaID
+func (m *Manager) GetDeltaResolution(ctx context.Context, ns graveler.StorageNamespace, deltaID graveler.MetaRangeID) (graveler.MetaRangeID, error) {
+	res, err := m.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var metaRangeID graveler.MetaRangeID
+		err := tx.Get(&metaRangeID, `SELECT meta_range_id FROM graveler_delta_resolutions
+			WHERE storage_namespace = $1 AND delta_id = $2`, ns, deltaID)
+		return metaRangeID, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if errors.Is(err, db.ErrNotFound) {
+		return "", graveler.ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return res.(graveler.MetaRangeID), nil
+}
+
+// SetDeltaResolution records metaRangeID as the full MetaRange of the delta MetaRange deltaID.
+// MetaRanges are content addressed, so every process materializes a delta to the same ID.
+func (m *Manager) SetDeltaResolution(ctx context.Context, ns graveler.StorageNamespace, deltaID, metaRangeID graveler.MetaRangeID) error {
+	_, err := m.db.Transact(func(tx db.Tx) (interface{}, error) {
+		return tx.Exec(`INSERT INTO graveler_delta_resolutions (storage_namespace, delta_id, meta_range_id)
+			VALUES ($1, $2, $3)
+			ON CONFLICT DO NOTHING`, ns, deltaID, metaRangeID)
+	}, db.WithContext(ctx))
+	return err
+}
@@ -0,0 +1,33 @@
+package ref_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/testutil"
+)
+
+func TestManager_DeltaResolution(t *testing.T) {
+	r := testRefManager(t)
+	ctx := context.Background()
+
+	_, err := r.GetDeltaResolution(ctx, "s3://ns1", "delta1")
+	if !errors.Is(err, graveler.ErrNotFound) {
+		t.Fatalf("GetDeltaResolution() before set err=%v, expected %s", err, graveler.ErrNotFound)
+	}
+	testutil.Must(t, r.SetDeltaResolution(ctx, "s3://ns1", "delta1", "full1"))
+	// setting again keeps the first resolution, every process materializes the same ID
+	testutil.Must(t, r.SetDeltaResolution(ctx, "s3://ns1", "delta1", "full1"))
+
+	metaRangeID, err := r.GetDeltaResolution(ctx, "s3://ns1", "delta1")
+	testutil.MustDo(t, "get delta resolution", err)
+	if metaRangeID != "full1" {
+		t.Errorf("GetDeltaResolution() = %s, expected full1", metaRangeID)
+	}
+	_, err = r.GetDeltaResolution(ctx, "s3://ns2", "delta1")
+	if !errors.Is(err, graveler.ErrNotFound) {
+		t.Errorf("GetDeltaResolution() other namespace err=%v, expected %s", err, graveler.ErrNotFound)
+	}
+}
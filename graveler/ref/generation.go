@@ -0,0 +1,214 @@
+package ref
+
+import (
+	"container/heap"
+	"context"
+
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/ident"
+)
+
+// paintFlags mark the side of a generation walk a commit is reachable from
+type paintFlags uint8
+
+const (
+	paintLeft paintFlags = 1 << iota
+	paintRight
+	paintBoth = paintLeft | paintRight
+)
+
+type generationItem struct {
+	id     graveler.CommitID
+	commit *graveler.Commit
+}
+
+// generationQueue pops commits with the highest generation first, parents always have a lower
+// generation than their children
+type generationQueue []generationItem
+
+func (q generationQueue) Len() int {
+	return len(q)
+}
+
+func (q generationQueue) Less(i, j int) bool {
+	if q[i].commit.Generation == q[j].commit.Generation {
+		return q[i].id > q[j].id
+	}
+	return q[i].commit.Generation > q[j].commit.Generation
+}
+
+func (q generationQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+}
+
+func (q *generationQueue) Push(x interface{}) {
+	*q = append(*q, x.(generationItem))
+}
+
+func (q *generationQueue) Pop() interface{} {
+	old := *q
+	n := len(old) - 1
+	item := old[n]
+	*q = old[:n]
+	return item
+}
+
+// paintWalk walks the histories of left and right in decreasing generation order, painting
+// commits reachable from left with paintLeft and commits reachable from right with paintRight.
+// visit is called once for every commit after all of its descendants in the walk, with its
+// final paint.  The walk ends when visit returns walkStop, or when relevant is set and returns
+// false for the paint of every queued commit.  The generations of left and right must be known.
+func paintWalk(ctx context.Context, getter CommitGetter, repositoryID graveler.RepositoryID, left, right *graveler.CommitRecord, relevant func(paintFlags) bool, visit func(graveler.CommitID, *graveler.Commit, paintFlags) walkDecision) error {
+	var queue generationQueue
+	paint := make(map[graveler.CommitID]paintFlags)
+	queued := make(map[graveler.CommitID]struct{})
+	pendingRelevant := 0
+	isRelevant := func(flags paintFlags) bool {
+		return relevant == nil || relevant(flags)
+	}
+	enqueue := func(id graveler.CommitID, commit *graveler.Commit, flags paintFlags) {
+		old, seen := paint[id]
+		paint[id] = old | flags
+		_, inQueue := queued[id]
+		switch {
+		case !seen:
+			queued[id] = struct{}{}
+			heap.Push(&queue, generationItem{id: id, commit: commit})
+			if isRelevant(old | flags) {
+				pendingRelevant++
+			}
+		case inQueue && isRelevant(old) && !isRelevant(old|flags):
+			pendingRelevant--
+		case inQueue && !isRelevant(old) && isRelevant(old|flags):
+			pendingRelevant++
+		}
+	}
+	enqueue(left.CommitID, left.Commit, paintLeft)
+	enqueue(right.CommitID, right.Commit, paintRight)
+
+	for queue.Len() > 0 && pendingRelevant > 0 {
+		item := heap.Pop(&queue).(generationItem)
+		delete(queued, item.id)
+		flags := paint[item.id]
+		if isRelevant(flags) {
+			pendingRelevant--
+		}
+		switch visit(item.id, item.commit, flags) {
+		case walkStop:
+			return nil
+		case walkSkipParents:
+			continue
+		}
+		for _, parentID := range item.commit.Parents {
+			if _, seen := paint[parentID]; seen {
+				enqueue(parentID, nil, flags)
+				continue
+			}
+			parent, err := getter.GetCommit(ctx, repositoryID, parentID)
+			if err != nil {
+				return err
+			}
+			enqueue(parentID, parent, flags)
+		}
+	}
+	return nil
+}
+
+// FindMergeBase returns the best common ancestor of left and right, the one with the highest
+// generation, or nil when they have no common ancestor.  Without known generations it falls back
+// to FindLowestCommonAncestor.
+func FindMergeBase(ctx context.Context, getter CommitGetter, addressProvider ident.AddressProvider, repositoryID graveler.RepositoryID, left, right graveler.CommitID) (*graveler.Commit, error) {
+	leftCommit, err := getter.GetCommit(ctx, repositoryID, left)
+	if err != nil {
+		return nil, err
+	}
+	rightCommit, err := getter.GetCommit(ctx, repositoryID, right)
+	if err != nil {
+		return nil, err
+	}
+	if leftCommit.Generation == 0 || rightCommit.Generation == 0 {
+		return FindLowestCommonAncestor(ctx, getter, addressProvider, repositoryID, left, right)
+	}
+	return mergeBaseByGeneration(ctx, getter, repositoryID,
+		&graveler.CommitRecord{CommitID: left, Commit: leftCommit},
+		&graveler.CommitRecord{CommitID: right, Commit: rightCommit})
+}
+
+// mergeBaseByGeneration returns the common ancestor of left and right with the highest
+// generation, or nil if they have no common ancestor
+func mergeBaseByGeneration(ctx context.Context, getter CommitGetter, repositoryID graveler.RepositoryID, left, right *graveler.CommitRecord) (*graveler.Commit, error) {
+	var base *graveler.Commit
+	err := paintWalk(ctx, getter, repositoryID, left, right, nil, func(_ graveler.CommitID, commit *graveler.Commit, flags paintFlags) walkDecision {
+		if flags == paintBoth {
+			base = commit
+			return walkStop
+		}
+		return walkParents
+	})
+	return base, err
+}
+
+// ComputeGenerations returns the generation of every commit of parents, a map of commit IDs to
+// their parents.  Generations already known are taken from known.  Commits with a parent
+// missing from parents keep an unknown generation of 0, and so do their descendants.
+func ComputeGenerations(parents map[graveler.CommitID]graveler.CommitParents, known map[graveler.CommitID]int64) map[graveler.CommitID]int64 {
+	generations := make(map[graveler.CommitID]int64, len(parents))
+	for id, generation := range known {
+		if generation > 0 {
+			generations[id] = generation
+		}
+	}
+	// iterative depth first walk, a commit is resolved after all of its parents
+	type frame struct {
+		id   graveler.CommitID
+		next int
+	}
+	resolved := make(map[graveler.CommitID]struct{}, len(parents))
+	for start := range parents {
+		if _, ok := resolved[start]; ok {
+			continue
+		}
+		stack := []frame{{id: start}}
+		for len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			if _, ok := generations[top.id]; ok {
+				resolved[top.id] = struct{}{}
+				stack = stack[:len(stack)-1]
+				continue
+			}
+			commitParents := parents[top.id]
+			if top.next < len(commitParents) {
+				parentID := commitParents[top.next]
+				top.next++
+				if _, ok := resolved[parentID]; !ok {
+					if _, exists := parents[parentID]; exists {
+						stack = append(stack, frame{id: parentID})
+					}
+				}
+				continue
+			}
+			var generation int64 = 1
+			for _, parentID := range commitParents {
+				parentGeneration := generations[parentID]
+				if parentGeneration == 0 {
+					generation = 0
+					break
+				}
+				if parentGeneration >= generation {
+					generation = parentGeneration + 1
+				}
+			}
+			if generation > 0 {
+				generations[top.id] = generation
+			}
+			resolved[top.id] = struct{}{}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	for id := range parents {
+		if _, ok := generations[id]; !ok {
+			generations[id] = 0
+		}
+	}
+	return generations
+}
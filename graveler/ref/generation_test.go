@@ -0,0 +1,156 @@
+package ref_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/graveler/ref"
+	"github.com/treeverse/lakefs/ident"
+)
+
+// newGenerationReader returns the commits of newAncestryReader with their generations, and an
+// unrelated root commit r0
+func newGenerationReader() *MockCommitGetter {
+	getter := newAncestryReader()
+	generations := map[graveler.CommitID]int64{"c0": 1, "c1": 2, "c2": 3, "c3": 3, "c4": 4, "c5": 4, "c6": 5}
+	for name, generation := range generations {
+		getter.kv[name].Generation = generation
+	}
+	getter.kv["r0"] = &graveler.Commit{Message: "r0", Parents: []graveler.CommitID{}, Generation: 1}
+	return getter
+}
+
+func TestIsAncestor_Generations(t *testing.T) {
+	cases := []struct {
+		Ancestor        string
+		Commit          string
+		Expected        bool
+		NoVisitExpected []string
+	}{
+		{Ancestor: "c0", Commit: "c6", Expected: true},
+		{Ancestor: "c4", Commit: "c6", Expected: true},
+		{Ancestor: "c5", Commit: "c5", Expected: true},
+		{Ancestor: "c6", Commit: "c2", Expected: false, NoVisitExpected: []string{"c1", "c0"}},
+		{Ancestor: "c2", Commit: "c5", Expected: false, NoVisitExpected: []string{"c1", "c0"}},
+		{Ancestor: "c3", Commit: "c4", Expected: false, NoVisitExpected: []string{"c1", "c0"}},
+	}
+	for _, cas := range cases {
+		t.Run(cas.Ancestor+"_"+cas.Commit, func(t *testing.T) {
+			getter := newGenerationReader()
+			got, err := ref.IsAncestor(context.Background(), getter, "",
+				caddr(getter.kv[graveler.CommitID(cas.Ancestor)]), caddr(getter.kv[graveler.CommitID(cas.Commit)]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != cas.Expected {
+				t.Errorf("IsAncestor(%s, %s) = %t, expected %t", cas.Ancestor, cas.Commit, got, cas.Expected)
+			}
+			for _, name := range cas.NoVisitExpected {
+				if _, ok := getter.visited[caddr(getter.kv[graveler.CommitID(name)])]; ok {
+					t.Errorf("IsAncestor(%s, %s) visited %s", cas.Ancestor, cas.Commit, name)
+				}
+			}
+		})
+	}
+}
+
+func TestCommitsBetween_Generations(t *testing.T) {
+	cases := []struct {
+		From            string
+		To              string
+		Expected        []string
+		NoVisitExpected []string
+	}{
+		{From: "c5", To: "c6", Expected: []string{"c6", "c4", "c2"}, NoVisitExpected: []string{"c0"}},
+		{From: "c4", To: "c6", Expected: []string{"c6", "c5", "c3"}, NoVisitExpected: []string{"c0"}},
+		{From: "c2", To: "c5", Expected: []string{"c5", "c3"}, NoVisitExpected: []string{"c0"}},
+		{From: "c6", To: "c5", Expected: nil, NoVisitExpected: []string{"c3", "c2", "c1", "c0"}},
+		{From: "c5", To: "c5", Expected: nil, NoVisitExpected: []string{"c3", "c1", "c0"}},
+		{From: "r0", To: "c2", Expected: []string{"c2", "c1", "c0"}},
+	}
+	for _, cas := range cases {
+		t.Run(cas.From+"_"+cas.To, func(t *testing.T) {
+			getter := newGenerationReader()
+			names := make(map[graveler.CommitID]string)
+			for name, commit := range getter.kv {
+				names[caddr(commit)] = string(name)
+			}
+			commits, err := ref.CommitsBetween(context.Background(), getter, "",
+				caddr(getter.kv[graveler.CommitID(cas.From)]), caddr(getter.kv[graveler.CommitID(cas.To)]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, commit := range commits {
+				got = append(got, names[commit.CommitID])
+			}
+			if diff := deep.Equal(got, cas.Expected); diff != nil {
+				t.Errorf("CommitsBetween(%s, %s) = %v: %s", cas.From, cas.To, got, diff)
+			}
+			for _, name := range cas.NoVisitExpected {
+				if _, ok := getter.visited[caddr(getter.kv[graveler.CommitID(name)])]; ok {
+					t.Errorf("CommitsBetween(%s, %s) visited %s", cas.From, cas.To, name)
+				}
+			}
+		})
+	}
+}
+
+func TestFindMergeBase_Generations(t *testing.T) {
+	cases := []struct {
+		Left            string
+		Right           string
+		Expected        string
+		NoVisitExpected []string
+	}{
+		{Left: "c4", Right: "c5", Expected: "c1", NoVisitExpected: []string{"c0"}},
+		{Left: "c6", Right: "c2", Expected: "c2", NoVisitExpected: []string{"c0"}},
+		{Left: "c3", Right: "c4", Expected: "c1", NoVisitExpected: []string{"c0"}},
+		{Left: "c5", Right: "c5", Expected: "c5", NoVisitExpected: []string{"c3"}},
+		{Left: "r0", Right: "c6", Expected: ""},
+	}
+	for _, cas := range cases {
+		t.Run(cas.Left+"_"+cas.Right, func(t *testing.T) {
+			getter := newGenerationReader()
+			names := make(map[graveler.CommitID]string)
+			for name, commit := range getter.kv {
+				names[caddr(commit)] = string(name)
+			}
+			base, err := ref.FindMergeBase(context.Background(), getter, ident.NewHexAddressProvider(), "",
+				caddr(getter.kv[graveler.CommitID(cas.Left)]), caddr(getter.kv[graveler.CommitID(cas.Right)]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := names[caddr(base)]; got != cas.Expected {
+				t.Errorf("FindMergeBase(%s, %s) = %s, expected %s", cas.Left, cas.Right, got, cas.Expected)
+			}
+			for _, name := range cas.NoVisitExpected {
+				if _, ok := getter.visited[caddr(getter.kv[graveler.CommitID(name)])]; ok {
+					t.Errorf("FindMergeBase(%s, %s) visited %s", cas.Left, cas.Right, name)
+				}
+			}
+		})
+	}
+}
+
+func TestComputeGenerations(t *testing.T) {
+	parents := map[graveler.CommitID]graveler.CommitParents{
+		"c0": {},
+		"c1": {"c0"},
+		"c2": {"c1"},
+		"c3": {"c1"},
+		"c4": {"c2"},
+		"c5": {"c3", "c4"},
+		"d1": {"missing"},
+		"d2": {"d1", "c0"},
+	}
+	known := map[graveler.CommitID]int64{"c1": 2}
+	expected := map[graveler.CommitID]int64{
+		"c0": 1, "c1": 2, "c2": 3, "c3": 3, "c4": 4, "c5": 5, "d1": 0, "d2": 0,
+	}
+	if diff := deep.Equal(ref.ComputeGenerations(parents, known), expected); diff != nil {
+		t.Errorf("ComputeGenerations() diff %s", diff)
+	}
+}
@@ -0,0 +1,128 @@
+package ref
+
+import (
+	"context"
+	"errors"
+
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+type refLabelsRecord struct {
+	Type   string            `db:"ref_type"`
+	ID     string            `db:"ref_id"`
+	Labels map[string]string `db:"labels"`
+}
+
+func (m *Manager) SetRefLabels(ctx context.Context, repositoryID graveler.RepositoryID, refType graveler.LabeledRefType, refID string, set map[string]string, unset []string) (map[string]string, error) {
+	if set == nil {
+		set = map[string]string{}
+	}
+	if unset == nil {
+		unset = []string{}
+	}
+	res, err := m.db.Transact(func(tx db.Tx) (interface{}, error) {
+		if err := checkLabeledRefExists(tx, repositoryID, refType, refID); err != nil {
+			return nil, err
+		}
+		var labels map[string]string
+		err := tx.GetPrimitive(&labels, `
+			INSERT INTO graveler_ref_labels (repository_id, ref_type, ref_id, labels)
+			VALUES ($1, $2, $3, $4::jsonb - $5::text[])
+			ON CONFLICT (repository_id, ref_type, ref_id)
+			DO UPDATE SET labels = (graveler_ref_labels.labels || $4::jsonb) - $5::text[]
+			RETURNING labels`,
+			repositoryID, refType, refID, set, unset)
+		if err != nil {
+			return nil, err
+		}
+		if len(labels) == 0 {
+			// refs without labels have no record
+			if err := deleteRefLabels(tx, repositoryID, refType, refID); err != nil {
+				return nil, err
+			}
+			labels = map[string]string{}
+		}
+		return labels, nil
+	}, db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.(map[string]string), nil
+}
+
+func (m *Manager) GetRefLabels(ctx context.Context, repositoryID graveler.RepositoryID, refType graveler.LabeledRefType, refID string) (map[string]string, error) {
+	res, err := m.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var labels map[string]string
+		err := tx.GetPrimitive(&labels, `SELECT labels FROM graveler_ref_labels WHERE repository_id = $1 AND ref_type = $2 AND ref_id = $3`,
+			repositoryID, refType, refID)
+		if errors.Is(err, db.ErrNotFound) {
+			return map[string]string{}, nil
+		}
+		return labels, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.(map[string]string), nil
+}
+
+func (m *Manager) FindRefsByLabels(ctx context.Context, repositoryID graveler.RepositoryID, labels map[string]string, refType graveler.LabeledRefType) ([]*graveler.RefLabels, error) {
+	res, err := m.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var recs []*refLabelsRecord
+		// containment lookup uses the GIN index over labels
+		err := tx.Select(&recs, `
+			SELECT ref_type, ref_id, labels FROM graveler_ref_labels
+			WHERE repository_id = $1 AND labels @> $2::jsonb AND ($3 = '' OR ref_type = $3)
+			ORDER BY ref_type, ref_id`,
+			repositoryID, labels, string(refType))
+		if err != nil {
+			return nil, err
+		}
+		refs := make([]*graveler.RefLabels, len(recs))
+		for i, rec := range recs {
+			refs[i] = &graveler.RefLabels{
+				Type:   graveler.LabeledRefType(rec.Type),
+				ID:     rec.ID,
+				Labels: rec.Labels,
+			}
+		}
+		return refs, nil
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.([]*graveler.RefLabels), nil
+}
+
+func checkLabeledRefExists(tx db.Tx, repositoryID graveler.RepositoryID, refType graveler.LabeledRefType, refID string) error {
+	var (
+		query       string
+		errNotFound error
+	)
+	switch refType {
+	case graveler.LabeledRefBranch:
+		query, errNotFound = `SELECT EXISTS (SELECT 1 FROM graveler_branches WHERE repository_id = $1 AND id = $2)`, graveler.ErrBranchNotFound
+	case graveler.LabeledRefTag:
+		query, errNotFound = `SELECT EXISTS (SELECT 1 FROM graveler_tags WHERE repository_id = $1 AND id = $2)`, graveler.ErrTagNotFound
+	case graveler.LabeledRefCommit:
+		query, errNotFound = `SELECT EXISTS (SELECT 1 FROM graveler_commits WHERE repository_id = $1 AND id = $2)`, graveler.ErrCommitNotFound
+	default:
+		return graveler.ErrInvalidValue
+	}
+	var exists bool
+	if err := tx.GetPrimitive(&exists, query, repositoryID, refID); err != nil {
+		return err
+	}
+	if !exists {
+		return errNotFound
+	}
+	return nil
+}
+
+// deleteRefLabels deletes the labels of the refs of refType refIDs, when they are deleted
+func deleteRefLabels(tx db.Tx, repositoryID graveler.RepositoryID, refType graveler.LabeledRefType, refIDs ...string) error {
+	_, err := tx.Exec(`DELETE FROM graveler_ref_labels WHERE repository_id = $1 AND ref_type = $2 AND ref_id = ANY($3)`,
+		repositoryID, refType, refIDs)
+	return err
+}
@@ -0,0 +1,75 @@
+package ref_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/testutil"
+)
+
+func TestManager_RefLabels(t *testing.T) {
+	r := testRefManager(t)
+	ctx := context.Background()
+	testutil.Must(t, r.CreateRepository(ctx, "repo1", graveler.Repository{
+		StorageNamespace: "s3://",
+		CreationDate:     time.Now(),
+		DefaultBranchID:  "master",
+	}, ""))
+	commitID, err := r.AddCommit(ctx, "repo1", graveler.Commit{
+		Committer:    "user1",
+		Message:      "load",
+		MetaRangeID:  "deadbeef123",
+		CreationDate: time.Now(),
+	})
+	testutil.MustDo(t, "add commit", err)
+	testutil.Must(t, r.SetBranch(ctx, "repo1", "prod", graveler.Branch{CommitID: commitID, StagingToken: "st1"}))
+	testutil.Must(t, r.CreateTag(ctx, "repo1", "v1", commitID))
+
+	labels, err := r.SetRefLabels(ctx, "repo1", graveler.LabeledRefBranch, "prod", map[string]string{"stage": "prod", "dataset": "clickstream"}, nil)
+	testutil.MustDo(t, "label branch", err)
+	if diff := deep.Equal(labels, map[string]string{"stage": "prod", "dataset": "clickstream"}); diff != nil {
+		t.Fatal("SetRefLabels() diff found", diff)
+	}
+	_, err = r.SetRefLabels(ctx, "repo1", graveler.LabeledRefTag, "v1", map[string]string{"dataset": "clickstream"}, nil)
+	testutil.MustDo(t, "label tag", err)
+	_, err = r.SetRefLabels(ctx, "repo1", graveler.LabeledRefCommit, commitID.String(), map[string]string{"dataset": "clickstream", "quality": "low"}, nil)
+	testutil.MustDo(t, "label commit", err)
+	labels, err = r.SetRefLabels(ctx, "repo1", graveler.LabeledRefCommit, commitID.String(), map[string]string{"quality": "high"}, []string{"dataset"})
+	testutil.MustDo(t, "update commit labels", err)
+	if diff := deep.Equal(labels, map[string]string{"quality": "high"}); diff != nil {
+		t.Fatal("SetRefLabels() update diff found", diff)
+	}
+
+	_, err = r.SetRefLabels(ctx, "repo1", graveler.LabeledRefBranch, "missing", map[string]string{"stage": "dev"}, nil)
+	if !errors.Is(err, graveler.ErrBranchNotFound) {
+		t.Fatalf("SetRefLabels() on missing branch err=%v, expected %s", err, graveler.ErrBranchNotFound)
+	}
+
+	refs, err := r.FindRefsByLabels(ctx, "repo1", map[string]string{"dataset": "clickstream"}, "")
+	testutil.MustDo(t, "find refs", err)
+	var found []string
+	for _, ref := range refs {
+		found = append(found, string(ref.Type)+":"+ref.ID)
+	}
+	if diff := deep.Equal(found, []string{"branch:prod", "tag:v1"}); diff != nil {
+		t.Fatal("FindRefsByLabels() diff found", diff)
+	}
+	refs, err = r.FindRefsByLabels(ctx, "repo1", map[string]string{"dataset": "clickstream", "stage": "prod"}, graveler.LabeledRefTag)
+	testutil.MustDo(t, "find tags", err)
+	if len(refs) != 0 {
+		t.Fatalf("FindRefsByLabels() got %d tags, expected none", len(refs))
+	}
+
+	// labels are removed with their refs
+	testutil.Must(t, r.DeleteBranch(ctx, "repo1", "prod"))
+	testutil.Must(t, r.SetBranch(ctx, "repo1", "prod", graveler.Branch{CommitID: commitID, StagingToken: "st2"}))
+	labels, err = r.GetRefLabels(ctx, "repo1", graveler.LabeledRefBranch, "prod")
+	testutil.MustDo(t, "get labels of recreated branch", err)
+	if len(labels) != 0 {
+		t.Fatalf("GetRefLabels() got %v on recreated branch, expected none", labels)
+	}
+}
@@ -3,6 +3,7 @@ package ref
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/treeverse/lakefs/db"
@@ -14,19 +15,69 @@ import (
 const IteratorPrefetchSize = 1000
 
 type Manager struct {
-	db              db.Database
-	addressProvider ident.AddressProvider
+	db               db.Database
+	addressProvider  ident.AddressProvider
+	mergeBaseCache   *mergeBaseCache
+	commitReferences []CommitReference
+	repositoryTables []RepositoryTable
+	branchTables     []BranchTable
+}
+
+// CommitReference is a pair of columns of a table outside the refs holding IDs of commits of
+// repositories, such as the statuses of commits.  Table and column names are trusted
+// identifiers.
+type CommitReference struct {
+	Table            string
+	RepositoryColumn string
+	CommitColumn     string
+}
+
+// RepositoryTable is a table outside the refs holding records of repositories by their ID, such
+// as the settings of repositories.  Table and column names are trusted identifiers.
+type RepositoryTable struct {
+	Table            string
+	RepositoryColumn string
+}
+
+// BranchTable is a table outside the refs holding records of branches by their repository and
+// branch IDs, such as the policies of branches.  Table and column names are trusted identifiers.
+type BranchTable struct {
+	Table            string
+	RepositoryColumn string
+	BranchColumn     string
 }
 
 func NewPGRefManager(db db.Database, addressProvider ident.AddressProvider) *Manager {
-	return &Manager{db: db, addressProvider: addressProvider}
+	return &Manager{
+		db:              db,
+		addressProvider: addressProvider,
+		mergeBaseCache:  newMergeBaseCache(MergeBaseCacheSize, MergeBaseCacheExpiry, MergeBaseCacheJitter),
+	}
+}
+
+// SetCommitReferences sets the columns ReplaceCommits moves from each replaced commit to the
+// commit replacing it, together with the refs
+func (m *Manager) SetCommitReferences(refs ...CommitReference) {
+	m.commitReferences = refs
+}
+
+// SetRepositoryTables sets the tables DeleteRepository deletes the records of a repository from,
+// together with its refs.  A repository created later with the same ID starts without them.
+func (m *Manager) SetRepositoryTables(tables ...RepositoryTable) {
+	m.repositoryTables = tables
+}
+
+// SetBranchTables sets the tables DeleteBranch deletes the records of a branch from, together
+// with its ref.  A branch created later with the same ID starts without them.
+func (m *Manager) SetBranchTables(tables ...BranchTable) {
+	m.branchTables = tables
 }
 
 func (m *Manager) GetRepository(ctx context.Context, repositoryID graveler.RepositoryID) (*graveler.Repository, error) {
 	repository, err := m.db.Transact(func(tx db.Tx) (interface{}, error) {
 		repository := &graveler.Repository{}
 		err := tx.Get(repository,
-			`SELECT storage_namespace, creation_date, default_branch FROM graveler_repositories WHERE id = $1`,
+			`SELECT storage_namespace, creation_date, default_branch, range_split FROM graveler_repositories WHERE id = $1`,
 			repositoryID)
 		if err != nil {
 			return nil, err
@@ -44,14 +95,28 @@ func (m *Manager) GetRepository(ctx context.Context, repositoryID graveler.Repos
 
 func createBareRepository(tx db.Tx, repositoryID graveler.RepositoryID, repository graveler.Repository) error {
 	_, err := tx.Exec(
-		`INSERT INTO graveler_repositories (id, storage_namespace, creation_date, default_branch) VALUES ($1, $2, $3, $4)`,
-		repositoryID, repository.StorageNamespace, repository.CreationDate, repository.DefaultBranchID)
+		`INSERT INTO graveler_repositories (id, storage_namespace, creation_date, default_branch, range_split) VALUES ($1, $2, $3, $4, $5)`,
+		repositoryID, repository.StorageNamespace, repository.CreationDate, repository.DefaultBranchID, repository.RangeSplit)
 	if errors.Is(err, db.ErrAlreadyExists) {
 		return graveler.ErrNotUnique
 	}
 	return nil
 }
 
+func (m *Manager) SetRangeSplitPolicy(ctx context.Context, repositoryID graveler.RepositoryID, policy *graveler.RangeSplitPolicy) error {
+	_, err := m.db.Transact(func(tx db.Tx) (interface{}, error) {
+		res, err := tx.Exec(`UPDATE graveler_repositories SET range_split = $2 WHERE id = $1`, repositoryID, policy)
+		if err != nil {
+			return nil, err
+		}
+		if res.RowsAffected() == 0 {
+			return nil, graveler.ErrRepositoryNotFound
+		}
+		return nil, nil
+	}, db.WithContext(ctx))
+	return err
+}
+
 func (m *Manager) CreateRepository(ctx context.Context, repositoryID graveler.RepositoryID, repository graveler.Repository, token graveler.StagingToken) error {
 	firstCommit := graveler.Commit{
 		Message:      graveler.FirstCommitMsg,
@@ -67,6 +132,9 @@ func (m *Manager) CreateRepository(ctx context.Context, repositoryID graveler.Re
 		}
 
 		// Create the default branch with its staging token
+		if err := logBranchCommit(tx, repositoryID, repository.DefaultBranchID, graveler.CommitID(commitID)); err != nil {
+			return nil, err
+		}
 		_, err = tx.Exec(`
 				INSERT INTO graveler_branches (repository_id, id, staging_token, commit_id)
 				VALUES ($1, $2, $3, $4)`,
@@ -88,6 +156,72 @@ func (m *Manager) CreateBareRepository(ctx context.Context, repositoryID gravele
 	return err
 }
 
+func (m *Manager) ForkRepository(ctx context.Context, sourceID graveler.RepositoryID, repositoryID graveler.RepositoryID, repository graveler.Repository, newStagingToken func(graveler.BranchID) graveler.StagingToken) error {
+	_, err := m.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var exists bool
+		err := tx.Get(&exists, `SELECT EXISTS(SELECT 1 FROM graveler_repositories WHERE id = $1)`, sourceID)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, graveler.ErrRepositoryNotFound
+		}
+		if err := createBareRepository(tx, repositoryID, repository); err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(`
+				INSERT INTO graveler_commits (repository_id, id, committer, committer_email, message, creation_date, parents, meta_range_id, metadata, generation, author, author_email, author_date)
+				SELECT $1, id, committer, committer_email, message, creation_date, parents, meta_range_id, metadata, generation, author, author_email, author_date
+				FROM graveler_commits WHERE repository_id = $2`,
+			repositoryID, sourceID)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(`
+				INSERT INTO graveler_tags (repository_id, id, commit_id)
+				SELECT $1, id, commit_id
+				FROM graveler_tags WHERE repository_id = $2`,
+			repositoryID, sourceID)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(`
+				INSERT INTO graveler_ref_labels (repository_id, ref_type, ref_id, labels)
+				SELECT $1, ref_type, ref_id, labels
+				FROM graveler_ref_labels WHERE repository_id = $2`,
+			repositoryID, sourceID)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(`
+				INSERT INTO graveler_branch_log (repository_id, branch_id, commit_id, creation_date)
+				SELECT $1, branch_id, commit_id, creation_date
+				FROM graveler_branch_log WHERE repository_id = $2
+				ORDER BY id`,
+			repositoryID, sourceID)
+		if err != nil {
+			return nil, err
+		}
+		// branches start from the same commits, with their own empty staging
+		var branches []*branchRecord
+		err = tx.Select(&branches, `SELECT id, commit_id, staging_token FROM graveler_branches WHERE repository_id = $1`, sourceID)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range branches {
+			_, err := tx.Exec(`
+				INSERT INTO graveler_branches (repository_id, id, staging_token, commit_id)
+				VALUES ($1, $2, $3, $4)`,
+				repositoryID, b.BranchID, newStagingToken(b.BranchID), b.CommitID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}, db.WithContext(ctx))
+	return err
+}
+
 func (m *Manager) ListRepositories(ctx context.Context) (graveler.RepositoryIterator, error) {
 	return NewRepositoryIterator(ctx, m.db, IteratorPrefetchSize), nil
 }
@@ -110,9 +244,28 @@ func (m *Manager) DeleteRepository(ctx context.Context, repositoryID graveler.Re
 		if err != nil {
 			return nil, err
 		}
+		_, err = tx.Exec(`DELETE FROM graveler_repository_snapshots WHERE repository_id = $1`, repositoryID)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(`DELETE FROM graveler_ref_labels WHERE repository_id = $1`, repositoryID)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(`DELETE FROM graveler_branch_log WHERE repository_id = $1`, repositoryID)
+		if err != nil {
+			return nil, err
+		}
+		for _, table := range m.repositoryTables {
+			_, err = tx.Exec(fmt.Sprintf(`DELETE FROM %s WHERE %s = $1`, table.Table, table.RepositoryColumn), repositoryID)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", table.Table, err)
+			}
+		}
 		_, err = tx.Exec(`DELETE FROM graveler_repositories WHERE id = $1`, repositoryID)
 		return nil, err
 	}, db.WithContext(ctx))
+	m.mergeBaseCache.Invalidate(repositoryID)
 	if errors.Is(err, db.ErrNotFound) {
 		return graveler.ErrRepositoryNotFound
 	}
@@ -145,36 +298,93 @@ func (m *Manager) GetBranch(ctx context.Context, repositoryID graveler.Repositor
 	return branch.(*graveler.Branch), nil
 }
 
+// GetStagingTokenStorageNamespace returns the storage namespace of the repository whose branch
+// stages on st, or graveler.ErrBranchNotFound if no branch stages on st
+func (m *Manager) GetStagingTokenStorageNamespace(ctx context.Context, st graveler.StagingToken) (graveler.StorageNamespace, error) {
+	res, err := m.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var storageNamespace graveler.StorageNamespace
+		err := tx.Get(&storageNamespace, `SELECT r.storage_namespace
+			FROM graveler_branches b JOIN graveler_repositories r ON r.id = b.repository_id
+			WHERE b.staging_token = $1`, st)
+		return storageNamespace, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if errors.Is(err, db.ErrNotFound) {
+		return "", graveler.ErrBranchNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return res.(graveler.StorageNamespace), nil
+}
+
 func (m *Manager) SetBranch(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, branch graveler.Branch) error {
-	_, err := m.db.Transact(func(tx db.Tx) (interface{}, error) {
-		_, err := tx.Exec(`
+	_, err := m.SetBranchReturningPrevious(ctx, repositoryID, branchID, branch)
+	return err
+}
+
+// SetBranchReturningPrevious is SetBranch returning the commit of the branch before the change,
+// read in the same transaction.  The commit is empty for a new branch.
+func (m *Manager) SetBranchReturningPrevious(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, branch graveler.Branch) (graveler.CommitID, error) {
+	res, err := m.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var previous graveler.CommitID
+		err := tx.Get(&previous, `SELECT commit_id FROM graveler_branches WHERE repository_id = $1 AND id = $2 FOR UPDATE`,
+			repositoryID, branchID)
+		if err != nil && !errors.Is(err, db.ErrNotFound) {
+			return nil, err
+		}
+		if err := logBranchCommit(tx, repositoryID, branchID, branch.CommitID); err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(`
 			INSERT INTO graveler_branches (repository_id, id, staging_token, commit_id)
 			VALUES ($1, $2, $3, $4)
 				ON CONFLICT (repository_id, id)
 				DO UPDATE SET staging_token = $3, commit_id = $4`,
 			repositoryID, branchID, branch.StagingToken, branch.CommitID)
-		return nil, err
+		return previous, err
 	}, db.WithContext(ctx))
-	return err
+	if err != nil {
+		return "", err
+	}
+	return res.(graveler.CommitID), nil
 }
 
 func (m *Manager) DeleteBranch(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID) error {
-	_, err := m.db.Transact(func(tx db.Tx) (interface{}, error) {
-		r, err := tx.Exec(
-			`DELETE FROM graveler_branches WHERE repository_id = $1 AND id = $2`,
+	_, err := m.DeleteBranchReturningPrevious(ctx, repositoryID, branchID)
+	return err
+}
+
+// DeleteBranchReturningPrevious is DeleteBranch returning the commit of the deleted branch
+func (m *Manager) DeleteBranchReturningPrevious(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID) (graveler.CommitID, error) {
+	res, err := m.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var previous graveler.CommitID
+		err := tx.Get(&previous,
+			`DELETE FROM graveler_branches WHERE repository_id = $1 AND id = $2 RETURNING commit_id`,
 			repositoryID, branchID)
 		if err != nil {
 			return nil, err
 		}
-		if r.RowsAffected() == 0 {
-			return nil, graveler.ErrNotFound
+		_, err = tx.Exec(`DELETE FROM graveler_branch_log WHERE repository_id = $1 AND branch_id = $2`,
+			repositoryID, branchID)
+		if err != nil {
+			return nil, err
 		}
-		return nil, nil
+		for _, table := range m.branchTables {
+			_, err = tx.Exec(fmt.Sprintf(`DELETE FROM %s WHERE %s = $1 AND %s = $2`, table.Table, table.RepositoryColumn, table.BranchColumn),
+				repositoryID, branchID)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", table.Table, err)
+			}
+		}
+		return previous, deleteRefLabels(tx, repositoryID, graveler.LabeledRefBranch, branchID.String())
 	}, db.WithContext(ctx))
 	if errors.Is(err, db.ErrNotFound) {
-		return graveler.ErrBranchNotFound
+		return "", graveler.ErrBranchNotFound
 	}
-	return err
+	if err != nil {
+		return "", err
+	}
+	return res.(graveler.CommitID), nil
 }
 
 func (m *Manager) ListBranches(ctx context.Context, repositoryID graveler.RepositoryID) (graveler.BranchIterator, error) {
@@ -227,7 +437,7 @@ func (m *Manager) DeleteTag(ctx context.Context, repositoryID graveler.Repositor
 		if r.RowsAffected() == 0 {
 			return nil, graveler.ErrNotFound
 		}
-		return nil, nil
+		return nil, deleteRefLabels(tx, repositoryID, graveler.LabeledRefTag, tagID.String())
 	}, db.WithContext(ctx))
 	if errors.Is(err, db.ErrNotFound) {
 		return graveler.ErrTagNotFound
@@ -239,13 +449,90 @@ func (m *Manager) ListTags(ctx context.Context, repositoryID graveler.Repository
 	return NewTagIterator(ctx, m.db, repositoryID, IteratorPrefetchSize), nil
 }
 
+func (m *Manager) CreateRepositorySnapshot(ctx context.Context, repositoryID graveler.RepositoryID, snapshotID graveler.RepositorySnapshotID, creationDate time.Time) error {
+	_, err := m.db.Transact(func(tx db.Tx) (interface{}, error) {
+		// a single statement reads all branch heads at once
+		res, err := tx.Exec(`INSERT INTO graveler_repository_snapshots (repository_id, id, creation_date, branches)
+			SELECT $1, $2, $3, COALESCE(jsonb_object_agg(id, commit_id), '{}'::jsonb)
+			FROM graveler_branches WHERE repository_id = $1
+			ON CONFLICT DO NOTHING`,
+			repositoryID, snapshotID, creationDate)
+		if err != nil {
+			return nil, err
+		}
+		if res.RowsAffected() == 0 {
+			return nil, graveler.ErrRepositorySnapshotExists
+		}
+		return nil, nil
+	}, db.WithContext(ctx))
+	return err
+}
+
+func (m *Manager) GetRepositorySnapshot(ctx context.Context, repositoryID graveler.RepositoryID, snapshotID graveler.RepositorySnapshotID) (*graveler.RepositorySnapshot, error) {
+	snapshot, err := m.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var rec repositorySnapshotRecord
+		err := tx.Get(&rec, `SELECT id, creation_date, branches FROM graveler_repository_snapshots
+			WHERE repository_id = $1 AND id = $2`,
+			repositoryID, snapshotID)
+		if err != nil {
+			return nil, err
+		}
+		return rec.toGravelerRepositorySnapshot(), nil
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, graveler.ErrRepositorySnapshotNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.(*graveler.RepositorySnapshot), nil
+}
+
+func (m *Manager) ListRepositorySnapshots(ctx context.Context, repositoryID graveler.RepositoryID) ([]*graveler.RepositorySnapshot, error) {
+	snapshots, err := m.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var recs []*repositorySnapshotRecord
+		err := tx.Select(&recs, `SELECT id, creation_date, branches FROM graveler_repository_snapshots
+			WHERE repository_id = $1
+			ORDER BY id`,
+			repositoryID)
+		if err != nil {
+			return nil, err
+		}
+		snapshots := make([]*graveler.RepositorySnapshot, len(recs))
+		for i, rec := range recs {
+			snapshots[i] = rec.toGravelerRepositorySnapshot()
+		}
+		return snapshots, nil
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return snapshots.([]*graveler.RepositorySnapshot), nil
+}
+
+func (m *Manager) DeleteRepositorySnapshot(ctx context.Context, repositoryID graveler.RepositoryID, snapshotID graveler.RepositorySnapshotID) error {
+	_, err := m.db.Transact(func(tx db.Tx) (interface{}, error) {
+		res, err := tx.Exec(`DELETE FROM graveler_repository_snapshots WHERE repository_id = $1 AND id = $2`,
+			repositoryID, snapshotID)
+		if err != nil {
+			return nil, err
+		}
+		if res.RowsAffected() == 0 {
+			return nil, graveler.ErrRepositorySnapshotNotFound
+		}
+		return nil, nil
+	}, db.WithContext(ctx))
+	return err
+}
+
 func (m *Manager) GetCommitByPrefix(ctx context.Context, repositoryID graveler.RepositoryID, prefix graveler.CommitID) (*graveler.Commit, error) {
 	commit, err := m.db.Transact(func(tx db.Tx) (interface{}, error) {
 		records := make([]*commitRecord, 0)
 		// LIMIT 2 is used to test if a truncated commit ID resolves to *one* commit.
 		// if we get 2 results that start with the truncated ID, that's enough to determine this prefix is not unique
 		err := tx.Select(&records, `
-					SELECT id, committer, message, creation_date, parents, meta_range_id, metadata
+					SELECT id, committer, committer_email, message, creation_date, parents, meta_range_id, metadata, generation,
+						author, author_email, author_date
 					FROM graveler_commits
 					WHERE repository_id = $1 AND id LIKE $2 || '%'
 					LIMIT 2`,
@@ -277,7 +564,8 @@ func (m *Manager) GetCommit(ctx context.Context, repositoryID graveler.Repositor
 	commit, err := m.db.Transact(func(tx db.Tx) (interface{}, error) {
 		var rec commitRecord
 		err := tx.Get(&rec, `
-					SELECT committer, message, creation_date, parents, meta_range_id, metadata
+					SELECT committer, committer_email, message, creation_date, parents, meta_range_id, metadata, generation,
+						author, author_email, author_date
 					FROM graveler_commits WHERE repository_id = $1 AND id = $2`,
 			repositoryID, commitID)
 		if err != nil {
@@ -294,6 +582,175 @@ func (m *Manager) GetCommit(ctx context.Context, repositoryID graveler.Repositor
 	return commit.(*graveler.Commit), nil
 }
 
+func (m *Manager) FindCommitsByMetadata(ctx context.Context, repositoryID graveler.RepositoryID, key, value string, after graveler.CommitID, limit int) ([]*graveler.CommitRecord, bool, error) {
+	var hasMore bool
+	commits, err := m.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var recs []*commitRecord
+		// containment lookup uses the GIN index over metadata, paging continues from the
+		// position of 'after' in the (creation_date DESC, id) order
+		err := tx.Select(&recs, `
+					SELECT c.id, c.committer, c.committer_email, c.message, c.creation_date, c.parents, c.meta_range_id,
+						c.metadata, c.generation, c.author, c.author_email, c.author_date
+					FROM graveler_commits c
+					WHERE c.repository_id = $1 AND c.metadata @> jsonb_build_object($2::text, $3::text)
+						AND ($4 = '' OR EXISTS (
+							SELECT 1 FROM graveler_commits a
+							WHERE a.repository_id = $1 AND a.id = $4
+								AND (c.creation_date < a.creation_date OR (c.creation_date = a.creation_date AND c.id > a.id))))
+					ORDER BY c.creation_date DESC, c.id
+					LIMIT $5`,
+			repositoryID, key, value, after, limit+1)
+		if err != nil {
+			return nil, err
+		}
+		hasMore = len(recs) > limit
+		if hasMore {
+			recs = recs[:limit]
+		}
+		commits := make([]*graveler.CommitRecord, len(recs))
+		for i, rec := range recs {
+			commits[i] = rec.toGravelerCommitRecord()
+		}
+		return commits, nil
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return nil, false, err
+	}
+	return commits.([]*graveler.CommitRecord), hasMore, nil
+}
+
+func (m *Manager) FillGenerations(ctx context.Context, repositoryID graveler.RepositoryID) (int, error) {
+	res, err := m.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var records []*commitRecord
+		err := tx.Select(&records, `SELECT id, parents, generation FROM graveler_commits WHERE repository_id = $1`, repositoryID)
+		if err != nil {
+			return 0, err
+		}
+		parents := make(map[graveler.CommitID]graveler.CommitParents, len(records))
+		known := make(map[graveler.CommitID]int64, len(records))
+		for _, rec := range records {
+			commit := rec.toGravelerCommitRecord()
+			parents[commit.CommitID] = commit.Parents
+			known[commit.CommitID] = commit.Generation
+		}
+		var ids []string
+		var generations []int64
+		for id, generation := range ComputeGenerations(parents, known) {
+			if generation > 0 && known[id] == 0 {
+				ids = append(ids, id.String())
+				generations = append(generations, generation)
+			}
+		}
+		if len(ids) == 0 {
+			return 0, nil
+		}
+		_, err = tx.Exec(`UPDATE graveler_commits c SET generation = g.generation
+			FROM unnest($2::text[], $3::bigint[]) AS g(id, generation)
+			WHERE c.repository_id = $1 AND c.id = g.id`,
+			repositoryID, ids, generations)
+		return len(ids), err
+	}, db.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	return res.(int), nil
+}
+
+func (m *Manager) DeleteCommits(ctx context.Context, repositoryID graveler.RepositoryID, commitIDs []graveler.CommitID) error {
+	if len(commitIDs) == 0 {
+		return nil
+	}
+	ids := make([]string, len(commitIDs))
+	for i, id := range commitIDs {
+		ids[i] = id.String()
+	}
+	_, err := m.db.Transact(func(tx db.Tx) (interface{}, error) {
+		_, err := tx.Exec(`DELETE FROM graveler_commits WHERE repository_id = $1 AND id = ANY($2)`,
+			repositoryID, ids)
+		if err != nil {
+			return nil, err
+		}
+		return nil, deleteRefLabels(tx, repositoryID, graveler.LabeledRefCommit, ids...)
+	}, db.WithContext(ctx))
+	m.mergeBaseCache.Invalidate(repositoryID)
+	return err
+}
+
+func (m *Manager) ReplaceCommits(ctx context.Context, repositoryID graveler.RepositoryID, replacements map[graveler.CommitID]graveler.CommitID) error {
+	if len(replacements) == 0 {
+		return nil
+	}
+	oldIDs := make([]string, 0, len(replacements))
+	newIDs := make([]string, 0, len(replacements))
+	for oldID, newID := range replacements {
+		oldIDs = append(oldIDs, oldID.String())
+		newIDs = append(newIDs, newID.String())
+	}
+	_, err := m.db.Transact(func(tx db.Tx) (interface{}, error) {
+		// a commit added on top of a replaced commit would lose its history
+		var changed bool
+		err := tx.GetPrimitive(&changed, `SELECT EXISTS (SELECT 1 FROM graveler_commits
+			WHERE repository_id = $1 AND parents && $2::text[] AND id <> ALL($2) AND id <> ALL($3))`,
+			repositoryID, oldIDs, newIDs)
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			return nil, graveler.ErrHistoryChanged
+		}
+		_, err = tx.Exec(`UPDATE graveler_branches b SET commit_id = r.new_id
+			FROM unnest($2::text[], $3::text[]) AS r(old_id, new_id)
+			WHERE b.repository_id = $1 AND b.commit_id = r.old_id`,
+			repositoryID, oldIDs, newIDs)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(`UPDATE graveler_branch_log l SET commit_id = r.new_id
+			FROM unnest($2::text[], $3::text[]) AS r(old_id, new_id)
+			WHERE l.repository_id = $1 AND l.commit_id = r.old_id`,
+			repositoryID, oldIDs, newIDs)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(`UPDATE graveler_tags t SET commit_id = r.new_id
+			FROM unnest($2::text[], $3::text[]) AS r(old_id, new_id)
+			WHERE t.repository_id = $1 AND t.commit_id = r.old_id`,
+			repositoryID, oldIDs, newIDs)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(`UPDATE graveler_repository_snapshots s SET branches = (
+				SELECT jsonb_object_agg(b.key, COALESCE(r.new_id, b.value))
+				FROM jsonb_each_text(s.branches) AS b
+				LEFT JOIN unnest($2::text[], $3::text[]) AS r(old_id, new_id) ON b.value = r.old_id)
+			WHERE s.repository_id = $1 AND s.branches <> '{}'::jsonb`,
+			repositoryID, oldIDs, newIDs)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(`UPDATE graveler_ref_labels l SET ref_id = r.new_id
+			FROM unnest($2::text[], $3::text[]) AS r(old_id, new_id)
+			WHERE l.repository_id = $1 AND l.ref_type = $4 AND l.ref_id = r.old_id`,
+			repositoryID, oldIDs, newIDs, graveler.LabeledRefCommit)
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range m.commitReferences {
+			_, err = tx.Exec(fmt.Sprintf(`UPDATE %[1]s t SET %[3]s = r.new_id
+				FROM unnest($2::text[], $3::text[]) AS r(old_id, new_id)
+				WHERE t.%[2]s = $1 AND t.%[3]s = r.old_id`, ref.Table, ref.RepositoryColumn, ref.CommitColumn),
+				repositoryID, oldIDs, newIDs)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", ref.Table, err)
+			}
+		}
+		return tx.Exec(`DELETE FROM graveler_commits WHERE repository_id = $1 AND id = ANY($2)`,
+			repositoryID, oldIDs)
+	}, db.WithContext(ctx))
+	m.mergeBaseCache.Invalidate(repositoryID)
+	return err
+}
+
 func (m *Manager) AddCommit(ctx context.Context, repositoryID graveler.RepositoryID, commit graveler.Commit) (graveler.CommitID, error) {
 	commitID := m.addressProvider.ContentAddress(commit)
 	_, err := m.db.Transact(func(tx db.Tx) (interface{}, error) {
@@ -313,14 +770,27 @@ func (m *Manager) addCommit(tx db.Tx, repositoryID graveler.RepositoryID, commit
 	}
 
 	// commits are written based on their content hash, if we insert the same ID again,
-	// it will necessarily have the same attributes as the existing one, so no need to overwrite it
+	// it will necessarily have the same attributes as the existing one, so no need to overwrite it.
+	// the generation is unknown while the generation of a parent is unknown.
+	// the author date is kept NULL when the commit has none
+	var authorDate *time.Time
+	if !commit.AuthorDate.IsZero() {
+		d := commit.AuthorDate.UTC()
+		authorDate = &d
+	}
 	_, err := tx.Exec(`
 				INSERT INTO graveler_commits 
-				(repository_id, id, committer, message, creation_date, parents, meta_range_id, metadata)
-				VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+				(repository_id, id, committer, message, creation_date, parents, meta_range_id, metadata, generation,
+				 committer_email, author, author_email, author_date)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, (
+					SELECT CASE WHEN COUNT(*) FILTER (WHERE generation > 0) = COALESCE(cardinality($6::text[]), 0)
+						THEN COALESCE(MAX(generation), 0) + 1 ELSE 0 END
+					FROM graveler_commits WHERE repository_id = $1 AND id = ANY($6)),
+					$9, $10, $11, $12)
 				ON CONFLICT DO NOTHING`,
 		repositoryID, commitID, commit.Committer, commit.Message,
-		commit.CreationDate.UTC(), parents, commit.MetaRangeID, commit.Metadata)
+		commit.CreationDate.UTC(), parents, commit.MetaRangeID, commit.Metadata,
+		commit.CommitterEmail, commit.Author, commit.AuthorEmail, authorDate)
 
 	return err
 }
@@ -330,11 +800,21 @@ func (m *Manager) FindMergeBase(ctx context.Context, repositoryID graveler.Repos
 	if len(commitIDs) != allowedCommitsToCompare {
 		return nil, graveler.ErrInvalidMergeBase
 	}
-	return FindLowestCommonAncestor(ctx, m, m.addressProvider, repositoryID, commitIDs[0], commitIDs[1])
+	return m.mergeBaseCache.GetOrSet(repositoryID, commitIDs[0], commitIDs[1], func() (*graveler.Commit, error) {
+		return FindMergeBase(ctx, m, m.addressProvider, repositoryID, commitIDs[0], commitIDs[1])
+	})
+}
+
+func (m *Manager) Log(ctx context.Context, repositoryID graveler.RepositoryID, from graveler.CommitID, firstParent bool) (graveler.CommitIterator, error) {
+	return NewCommitIterator(ctx, m.db, repositoryID, from, firstParent), nil
+}
+
+func (m *Manager) IsAncestor(ctx context.Context, repositoryID graveler.RepositoryID, ancestor, commitID graveler.CommitID) (bool, error) {
+	return IsAncestor(ctx, m, repositoryID, ancestor, commitID)
 }
 
-func (m *Manager) Log(ctx context.Context, repositoryID graveler.RepositoryID, from graveler.CommitID) (graveler.CommitIterator, error) {
-	return NewCommitIterator(ctx, m.db, repositoryID, from), nil
+func (m *Manager) CommitsBetween(ctx context.Context, repositoryID graveler.RepositoryID, from, to graveler.CommitID) ([]*graveler.CommitRecord, error) {
+	return CommitsBetween(ctx, m, repositoryID, from, to)
 }
 
 func (m *Manager) ListCommits(ctx context.Context, repositoryID graveler.RepositoryID) (graveler.CommitIterator, error) {
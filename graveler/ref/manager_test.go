@@ -12,7 +12,9 @@ import (
 
 	"github.com/go-test/deep"
 	"github.com/stretchr/testify/assert"
+	"github.com/treeverse/lakefs/db"
 	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/graveler/ref"
 	"github.com/treeverse/lakefs/ident"
 	"github.com/treeverse/lakefs/testutil"
 )
@@ -152,6 +154,81 @@ func TestManager_DeleteRepository(t *testing.T) {
 	})
 }
 
+func TestManager_DeleteRepository_RepositoryTables(t *testing.T) {
+	r, conn := testRefManagerWithDB(t)
+	r.SetRepositoryTables(
+		ref.RepositoryTable{Table: "repository_secrets", RepositoryColumn: "repository_id"},
+		ref.RepositoryTable{Table: "repository_settings", RepositoryColumn: "repository_id"},
+		ref.RepositoryTable{Table: "notification_sinks", RepositoryColumn: "repository_id"},
+	)
+	ctx := context.Background()
+	for _, repositoryID := range []graveler.RepositoryID{"repo1", "repo2"} {
+		testutil.Must(t, r.CreateRepository(ctx, repositoryID, graveler.Repository{
+			StorageNamespace: "s3://foo",
+			CreationDate:     time.Now(),
+			DefaultBranchID:  "main",
+		}, ""))
+		_, err := conn.Exec(`INSERT INTO repository_secrets (repository_id, name, encrypted_value, creation_date, update_date)
+			VALUES ($1, 'token', 'secret', NOW(), NOW())`, repositoryID)
+		testutil.MustDo(t, "insert secret", err)
+		_, err = conn.Exec(`INSERT INTO repository_settings (repository_id, key, value, update_date)
+			VALUES ($1, 'key', 'value', NOW())`, repositoryID)
+		testutil.MustDo(t, "insert setting", err)
+		_, err = conn.Exec(`INSERT INTO notification_sinks (repository_id, name, type, url, events, branches, creation_date)
+			VALUES ($1, 'hook', 'webhook', 'https://example.com/hook', '{}', '{}', NOW())`, repositoryID)
+		testutil.MustDo(t, "insert notification sink", err)
+	}
+
+	testutil.Must(t, r.DeleteRepository(ctx, "repo1"))
+	for _, table := range []string{"repository_secrets", "repository_settings", "notification_sinks"} {
+		var repositories []string
+		_, err := conn.Transact(func(tx db.Tx) (interface{}, error) {
+			return nil, tx.Select(&repositories, `SELECT repository_id FROM `+table+` ORDER BY repository_id`)
+		})
+		testutil.MustDo(t, "select "+table, err)
+		if diff := deep.Equal(repositories, []string{"repo2"}); diff != nil {
+			t.Errorf("%s after DeleteRepository() diff found: %s", table, diff)
+		}
+	}
+}
+
+func TestManager_DeleteBranch_BranchTables(t *testing.T) {
+	r, conn := testRefManagerWithDB(t)
+	r.SetBranchTables(
+		ref.BranchTable{Table: "catalog_path_leases", RepositoryColumn: "repository_id", BranchColumn: "branch_id"},
+		ref.BranchTable{Table: "catalog_auto_commit_policies", RepositoryColumn: "repository_id", BranchColumn: "branch_id"},
+	)
+	ctx := context.Background()
+	testutil.Must(t, r.CreateRepository(ctx, "repo1", graveler.Repository{
+		StorageNamespace: "s3://foo",
+		CreationDate:     time.Now(),
+		DefaultBranchID:  "main",
+	}, ""))
+	main, err := r.GetBranch(ctx, "repo1", "main")
+	testutil.MustDo(t, "get main", err)
+	for _, branchID := range []graveler.BranchID{"branch1", "branch2"} {
+		testutil.Must(t, r.SetBranch(ctx, "repo1", branchID, graveler.Branch{CommitID: main.CommitID, StagingToken: graveler.StagingToken("st-" + branchID)}))
+		_, err := conn.Exec(`INSERT INTO catalog_path_leases (id, repository_id, branch_id, prefix, owner, expires_at, creation_date)
+			VALUES ($1, 'repo1', $2, 'data/', 'owner', NOW() + INTERVAL '1 hour', NOW())`, "lease-"+branchID, branchID)
+		testutil.MustDo(t, "insert path lease", err)
+		_, err = conn.Exec(`INSERT INTO catalog_auto_commit_policies (repository_id, branch_id, max_staged_entries, interval_seconds, message_template, update_date)
+			VALUES ('repo1', $1, 100, 60, 'auto commit', NOW())`, branchID)
+		testutil.MustDo(t, "insert auto commit policy", err)
+	}
+
+	testutil.Must(t, r.DeleteBranch(ctx, "repo1", "branch1"))
+	for _, table := range []string{"catalog_path_leases", "catalog_auto_commit_policies"} {
+		var branches []string
+		_, err := conn.Transact(func(tx db.Tx) (interface{}, error) {
+			return nil, tx.Select(&branches, `SELECT branch_id FROM `+table+` ORDER BY branch_id`)
+		})
+		testutil.MustDo(t, "select "+table, err)
+		if diff := deep.Equal(branches, []string{"branch2"}); diff != nil {
+			t.Errorf("%s after DeleteBranch() diff found: %s", table, diff)
+		}
+	}
+}
+
 func TestManager_GetBranch(t *testing.T) {
 	r := testRefManager(t)
 	t.Run("get_branch_exists", func(t *testing.T) {
@@ -235,6 +312,35 @@ func TestManager_DeleteBranch(t *testing.T) {
 	}
 }
 
+func TestManager_BranchReturningPrevious(t *testing.T) {
+	r := testRefManager(t)
+	ctx := context.Background()
+	testutil.Must(t, r.CreateRepository(ctx, "repo1", graveler.Repository{
+		StorageNamespace: "s3://",
+		CreationDate:     time.Now(),
+		DefaultBranchID:  "master",
+	}, ""))
+
+	previous, err := r.SetBranchReturningPrevious(ctx, "repo1", "branch2", graveler.Branch{CommitID: "c1"})
+	testutil.MustDo(t, "set new branch", err)
+	if previous != "" {
+		t.Errorf("previous commit of a new branch %s, expected none", previous)
+	}
+	previous, err = r.SetBranchReturningPrevious(ctx, "repo1", "branch2", graveler.Branch{CommitID: "c2"})
+	testutil.MustDo(t, "set branch", err)
+	if previous != "c1" {
+		t.Errorf("previous commit %s, expected c1", previous)
+	}
+	previous, err = r.DeleteBranchReturningPrevious(ctx, "repo1", "branch2")
+	testutil.MustDo(t, "delete branch", err)
+	if previous != "c2" {
+		t.Errorf("previous commit of deleted branch %s, expected c2", previous)
+	}
+	if _, err := r.DeleteBranchReturningPrevious(ctx, "repo1", "branch2"); !errors.Is(err, graveler.ErrBranchNotFound) {
+		t.Errorf("delete of a missing branch err=%v, expected %s", err, graveler.ErrBranchNotFound)
+	}
+}
+
 func TestManager_ListBranches(t *testing.T) {
 	r := testRefManager(t)
 	testutil.Must(t, r.CreateRepository(context.Background(), "repo1", graveler.Repository{
@@ -440,6 +546,245 @@ func TestManager_AddCommit(t *testing.T) {
 	}
 }
 
+func TestManager_FindCommitsByMetadata(t *testing.T) {
+	r := testRefManager(t)
+	ctx := context.Background()
+	testutil.Must(t, r.CreateRepository(ctx, "repo1", graveler.Repository{
+		StorageNamespace: "s3://",
+		CreationDate:     time.Now(),
+		DefaultBranchID:  "master",
+	}, ""))
+
+	ts := time.Now()
+	runIDs := []string{"run1", "run2", "run1"}
+	var run1Commits []graveler.CommitID
+	for i, runID := range runIDs {
+		cid, err := r.AddCommit(ctx, "repo1", graveler.Commit{
+			Committer:    "user1",
+			Message:      fmt.Sprintf("message%d", i),
+			MetaRangeID:  "deadbeef123",
+			CreationDate: ts.Add(time.Duration(i) * time.Minute),
+			Metadata:     graveler.Metadata{"airflow.dag_run_id": runID},
+		})
+		testutil.MustDo(t, "add commit", err)
+		if runID == "run1" {
+			run1Commits = append([]graveler.CommitID{cid}, run1Commits...)
+		}
+	}
+
+	commits, hasMore, err := r.FindCommitsByMetadata(ctx, "repo1", "airflow.dag_run_id", "run1", "", 10)
+	testutil.MustDo(t, "find commits by metadata", err)
+	var got []graveler.CommitID
+	for _, c := range commits {
+		got = append(got, c.CommitID)
+	}
+	if diff := deep.Equal(got, run1Commits); diff != nil {
+		t.Fatal("FindCommitsByMetadata() diff found", diff)
+	}
+	if hasMore {
+		t.Error("FindCommitsByMetadata() has more, expected all commits")
+	}
+
+	// page through the commits one at a time
+	got = nil
+	after := graveler.CommitID("")
+	for {
+		commits, hasMore, err = r.FindCommitsByMetadata(ctx, "repo1", "airflow.dag_run_id", "run1", after, 1)
+		testutil.MustDo(t, "find commits by metadata page", err)
+		if len(commits) != 1 {
+			t.Fatalf("FindCommitsByMetadata() page got %d commits, expected 1", len(commits))
+		}
+		got = append(got, commits[0].CommitID)
+		if !hasMore {
+			break
+		}
+		after = commits[0].CommitID
+	}
+	if diff := deep.Equal(got, run1Commits); diff != nil {
+		t.Fatal("FindCommitsByMetadata() paged diff found", diff)
+	}
+
+	commits, _, err = r.FindCommitsByMetadata(ctx, "repo1", "airflow.dag_run_id", "run3", "", 10)
+	testutil.MustDo(t, "find commits by missing metadata", err)
+	if len(commits) != 0 {
+		t.Fatalf("FindCommitsByMetadata() got %d commits, expected none", len(commits))
+	}
+}
+
+func TestManager_ForkRepository(t *testing.T) {
+	r := testRefManager(t)
+	ctx := context.Background()
+	testutil.Must(t, r.CreateRepository(ctx, "repo1", graveler.Repository{
+		StorageNamespace: "s3://",
+		CreationDate:     time.Now(),
+		DefaultBranchID:  "master",
+	}, "token1"))
+	commitID, err := r.AddCommit(ctx, "repo1", graveler.Commit{
+		Committer:    "user1",
+		Message:      "message1",
+		MetaRangeID:  "deadbeef123",
+		CreationDate: time.Now(),
+	})
+	testutil.MustDo(t, "add commit", err)
+	testutil.Must(t, r.SetBranch(ctx, "repo1", "master", graveler.Branch{CommitID: commitID, StagingToken: "token1"}))
+	testutil.Must(t, r.CreateTag(ctx, "repo1", "v1", commitID))
+
+	fork := graveler.Repository{
+		StorageNamespace: "s3://",
+		CreationDate:     time.Now(),
+		DefaultBranchID:  "master",
+	}
+	err = r.ForkRepository(ctx, "repo1", "repo2", fork, func(branchID graveler.BranchID) graveler.StagingToken {
+		return graveler.StagingToken("fork-" + branchID)
+	})
+	testutil.MustDo(t, "fork repository", err)
+
+	branch, err := r.GetBranch(ctx, "repo2", "master")
+	testutil.MustDo(t, "get fork branch", err)
+	if diff := deep.Equal(branch, &graveler.Branch{CommitID: commitID, StagingToken: "fork-master"}); diff != nil {
+		t.Fatal("fork branch diff found", diff)
+	}
+	commit, err := r.GetCommit(ctx, "repo2", commitID)
+	testutil.MustDo(t, "get fork commit", err)
+	if commit.MetaRangeID != "deadbeef123" {
+		t.Fatalf("fork commit meta range %s, expected deadbeef123", commit.MetaRangeID)
+	}
+	tagCommitID, err := r.GetTag(ctx, "repo2", "v1")
+	testutil.MustDo(t, "get fork tag", err)
+	if *tagCommitID != commitID {
+		t.Fatalf("fork tag points to %s, expected %s", *tagCommitID, commitID)
+	}
+
+	err = r.ForkRepository(ctx, "repo1", "repo2", fork, func(branchID graveler.BranchID) graveler.StagingToken { return "" })
+	if !errors.Is(err, graveler.ErrNotUnique) {
+		t.Fatalf("fork to existing repository err=%v, expected %s", err, graveler.ErrNotUnique)
+	}
+	err = r.ForkRepository(ctx, "repo3", "repo4", fork, func(branchID graveler.BranchID) graveler.StagingToken { return "" })
+	if !errors.Is(err, graveler.ErrRepositoryNotFound) {
+		t.Fatalf("fork missing repository err=%v, expected %s", err, graveler.ErrRepositoryNotFound)
+	}
+}
+
+func TestManager_RepositorySnapshots(t *testing.T) {
+	r := testRefManager(t)
+	ctx := context.Background()
+	testutil.Must(t, r.CreateRepository(ctx, "repo1", graveler.Repository{
+		StorageNamespace: "s3://",
+		CreationDate:     time.Now(),
+		DefaultBranchID:  "master",
+	}, ""))
+	testutil.Must(t, r.SetBranch(ctx, "repo1", "master", graveler.Branch{CommitID: "c1", StagingToken: "token1"}))
+	testutil.Must(t, r.SetBranch(ctx, "repo1", "feature", graveler.Branch{CommitID: "c2", StagingToken: "token2"}))
+
+	creationDate := time.Now().UTC().Truncate(time.Second)
+	testutil.Must(t, r.CreateRepositorySnapshot(ctx, "repo1", "snapshot1", creationDate))
+	err := r.CreateRepositorySnapshot(ctx, "repo1", "snapshot1", creationDate)
+	if !errors.Is(err, graveler.ErrRepositorySnapshotExists) {
+		t.Fatalf("CreateRepositorySnapshot() err=%v, expected %s", err, graveler.ErrRepositorySnapshotExists)
+	}
+
+	// moving a branch does not change the snapshot
+	testutil.Must(t, r.SetBranch(ctx, "repo1", "master", graveler.Branch{CommitID: "c3", StagingToken: "token1"}))
+	snapshot, err := r.GetRepositorySnapshot(ctx, "repo1", "snapshot1")
+	testutil.MustDo(t, "get snapshot", err)
+	expected := &graveler.RepositorySnapshot{
+		SnapshotID:   "snapshot1",
+		CreationDate: creationDate,
+		Branches:     map[graveler.BranchID]graveler.CommitID{"master": "c1", "feature": "c2"},
+	}
+	if diff := deep.Equal(snapshot, expected); diff != nil {
+		t.Fatal("GetRepositorySnapshot() diff found", diff)
+	}
+
+	snapshots, err := r.ListRepositorySnapshots(ctx, "repo1")
+	testutil.MustDo(t, "list snapshots", err)
+	if len(snapshots) != 1 || snapshots[0].SnapshotID != "snapshot1" {
+		t.Fatalf("ListRepositorySnapshots() got %v, expected snapshot1", snapshots)
+	}
+
+	testutil.Must(t, r.DeleteRepositorySnapshot(ctx, "repo1", "snapshot1"))
+	_, err = r.GetRepositorySnapshot(ctx, "repo1", "snapshot1")
+	if !errors.Is(err, graveler.ErrRepositorySnapshotNotFound) {
+		t.Fatalf("GetRepositorySnapshot() after delete err=%v, expected %s", err, graveler.ErrRepositorySnapshotNotFound)
+	}
+}
+
+func TestManager_ReplaceCommits(t *testing.T) {
+	r := testRefManager(t)
+	ctx := context.Background()
+	testutil.Must(t, r.CreateRepository(ctx, "repo1", graveler.Repository{
+		StorageNamespace: "s3://",
+		CreationDate:     time.Now(),
+		DefaultBranchID:  "master",
+	}, ""))
+	ts := time.Now().UTC().Truncate(time.Second)
+	c1, err := r.AddCommit(ctx, "repo1", graveler.Commit{Message: "c1", MetaRangeID: "range1", CreationDate: ts})
+	testutil.MustDo(t, "add c1", err)
+	c2, err := r.AddCommit(ctx, "repo1", graveler.Commit{Message: "c2", MetaRangeID: "range2", CreationDate: ts})
+	testutil.MustDo(t, "add c2", err)
+	testutil.Must(t, r.SetBranch(ctx, "repo1", "master", graveler.Branch{CommitID: c1, StagingToken: "token1"}))
+	testutil.Must(t, r.CreateTag(ctx, "repo1", "v1", c1))
+	testutil.Must(t, r.CreateRepositorySnapshot(ctx, "repo1", "snapshot1", ts))
+
+	testutil.Must(t, r.ReplaceCommits(ctx, "repo1", map[graveler.CommitID]graveler.CommitID{c1: c2}))
+	branch, err := r.GetBranch(ctx, "repo1", "master")
+	testutil.MustDo(t, "get branch", err)
+	if branch.CommitID != c2 {
+		t.Errorf("branch commit = %s, expected %s", branch.CommitID, c2)
+	}
+	tagCommitID, err := r.GetTag(ctx, "repo1", "v1")
+	testutil.MustDo(t, "get tag", err)
+	if *tagCommitID != c2 {
+		t.Errorf("tag commit = %s, expected %s", *tagCommitID, c2)
+	}
+	snapshot, err := r.GetRepositorySnapshot(ctx, "repo1", "snapshot1")
+	testutil.MustDo(t, "get snapshot", err)
+	if snapshot.Branches["master"] != c2 {
+		t.Errorf("snapshot commit = %s, expected %s", snapshot.Branches["master"], c2)
+	}
+	if _, err := r.GetCommit(ctx, "repo1", c1); !errors.Is(err, graveler.ErrCommitNotFound) {
+		t.Errorf("GetCommit() of replaced commit err=%v, expected %s", err, graveler.ErrCommitNotFound)
+	}
+
+	// a commit on top of a replaced commit fails the replace
+	c3, err := r.AddCommit(ctx, "repo1", graveler.Commit{Message: "c3", MetaRangeID: "range3", CreationDate: ts, Parents: graveler.CommitParents{c2}})
+	testutil.MustDo(t, "add c3", err)
+	err = r.ReplaceCommits(ctx, "repo1", map[graveler.CommitID]graveler.CommitID{c2: "c4"})
+	if !errors.Is(err, graveler.ErrHistoryChanged) {
+		t.Errorf("ReplaceCommits() err=%v, expected %s", err, graveler.ErrHistoryChanged)
+	}
+	if _, err := r.GetCommit(ctx, "repo1", c3); err != nil {
+		t.Errorf("GetCommit() after failed replace err=%v", err)
+	}
+}
+
+func TestManager_ReplaceCommitsReferences(t *testing.T) {
+	r, conn := testRefManagerWithDB(t)
+	r.SetCommitReferences(ref.CommitReference{Table: "catalog_commit_annotations", RepositoryColumn: "repository_id", CommitColumn: "commit_id"})
+	ctx := context.Background()
+	testutil.Must(t, r.CreateRepository(ctx, "repo1", graveler.Repository{
+		StorageNamespace: "s3://",
+		CreationDate:     time.Now(),
+		DefaultBranchID:  "master",
+	}, ""))
+	ts := time.Now().UTC().Truncate(time.Second)
+	c1, err := r.AddCommit(ctx, "repo1", graveler.Commit{Message: "c1", MetaRangeID: "range1", CreationDate: ts})
+	testutil.MustDo(t, "add c1", err)
+	c2, err := r.AddCommit(ctx, "repo1", graveler.Commit{Message: "c2", MetaRangeID: "range2", CreationDate: ts})
+	testutil.MustDo(t, "add c2", err)
+	_, err = conn.Exec(`INSERT INTO catalog_commit_annotations (repository_id, commit_id, key, value, annotator, update_date)
+		VALUES ('repo1', $1, 'k', 'v', 'tester', now())`, c1)
+	testutil.MustDo(t, "annotate c1", err)
+
+	testutil.Must(t, r.ReplaceCommits(ctx, "repo1", map[graveler.CommitID]graveler.CommitID{c1: c2}))
+	var commitIDs []string
+	err = conn.Select(&commitIDs, `SELECT commit_id FROM catalog_commit_annotations WHERE repository_id = 'repo1'`)
+	testutil.MustDo(t, "select annotations", err)
+	if diff := deep.Equal(commitIDs, []string{c2.String()}); diff != nil {
+		t.Errorf("annotated commits diff %s", diff)
+	}
+}
+
 func TestManager_Log(t *testing.T) {
 	r := testRefManager(t)
 	testutil.Must(t, r.CreateRepository(context.Background(), "repo1", graveler.Repository{
@@ -470,7 +815,7 @@ func TestManager_Log(t *testing.T) {
 		ts = ts.Add(time.Second)
 	}
 
-	iter, err := r.Log(context.Background(), "repo1", previous)
+	iter, err := r.Log(context.Background(), "repo1", previous, false)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -558,7 +903,7 @@ func TestManager_LogGraph(t *testing.T) {
 	}
 
 	// iterate over the commits
-	it, err := r.Log(ctx, "repo1", c8)
+	it, err := r.Log(ctx, "repo1", c8, false)
 	if err != nil {
 		t.Fatal("Error during create Log iterator", err)
 	}
@@ -592,6 +937,26 @@ func TestManager_LogGraph(t *testing.T) {
 	if diff := deep.Equal(commitsAfterSeek, expectedAfterSeek); diff != nil {
 		t.Fatal("Found diff between expected commits (after seek):", diff)
 	}
+
+	// first parent only
+	firstParentIt, err := r.Log(ctx, "repo1", c8, true)
+	if err != nil {
+		t.Fatal("Error during create first parent Log iterator", err)
+	}
+	defer firstParentIt.Close()
+	expectedFirstParent := []string{
+		"c8", "c6", "c5", "c3", "c1",
+	}
+	var firstParentCommits []string
+	for firstParentIt.Next() {
+		firstParentCommits = append(firstParentCommits, firstParentIt.Value().Message)
+	}
+	if err := firstParentIt.Err(); err != nil {
+		t.Fatal("Iteration ended with error", err)
+	}
+	if diff := deep.Equal(firstParentCommits, expectedFirstParent); diff != nil {
+		t.Fatal("Found diff between expected first parent commits:", diff)
+	}
 }
 
 type fakeAddressProvider struct {
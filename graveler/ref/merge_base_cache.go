@@ -0,0 +1,64 @@
+package ref
+
+import (
+	"sync"
+	"time"
+
+	"github.com/treeverse/lakefs/cache"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+const (
+	MergeBaseCacheSize   = 10000
+	MergeBaseCacheExpiry = time.Hour
+	MergeBaseCacheJitter = time.Minute
+)
+
+type mergeBaseKey struct {
+	repositoryID graveler.RepositoryID
+	epoch        uint64
+	left         graveler.CommitID
+	right        graveler.CommitID
+}
+
+// mergeBaseCache caches merge-bases of pairs of commits.  Commits are immutable, so an entry is
+// keyed by the commits and a branch that moves looks up a new entry.  Only rewriting history can
+// change a merge-base, it starts a new epoch of the repository that leaves all its entries
+// unreachable.  Epochs are kept in memory, entries expire to limit the lifetime of merge-bases
+// made stale by history rewritten through another lakeFS instance.
+type mergeBaseCache struct {
+	cache  cache.Cache
+	mu     sync.Mutex
+	epochs map[graveler.RepositoryID]uint64
+}
+
+func newMergeBaseCache(size int, expiry, jitter time.Duration) *mergeBaseCache {
+	return &mergeBaseCache{
+		cache:  cache.NewCache(size, expiry, cache.NewJitterFn(jitter)),
+		epochs: make(map[graveler.RepositoryID]uint64),
+	}
+}
+
+func (c *mergeBaseCache) epoch(repositoryID graveler.RepositoryID) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.epochs[repositoryID]
+}
+
+// GetOrSet returns the merge-base of left and right, calling setFn to find it on a cache miss
+func (c *mergeBaseCache) GetOrSet(repositoryID graveler.RepositoryID, left, right graveler.CommitID, setFn func() (*graveler.Commit, error)) (*graveler.Commit, error) {
+	k := mergeBaseKey{repositoryID: repositoryID, epoch: c.epoch(repositoryID), left: left, right: right}
+	v, err := c.cache.GetOrSet(k, func() (interface{}, error) { return setFn() })
+	if err != nil {
+		return nil, err
+	}
+	commit, _ := v.(*graveler.Commit)
+	return commit, nil
+}
+
+// Invalidate drops the cached merge-bases of repositoryID
+func (c *mergeBaseCache) Invalidate(repositoryID graveler.RepositoryID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.epochs[repositoryID]++
+}
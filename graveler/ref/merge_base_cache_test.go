@@ -0,0 +1,67 @@
+package ref
+
+import (
+	"testing"
+	"time"
+
+	"github.com/treeverse/lakefs/graveler"
+)
+
+func TestMergeBaseCache(t *testing.T) {
+	c := newMergeBaseCache(100, time.Hour, time.Millisecond)
+	calls := 0
+	base := &graveler.Commit{Message: "base"}
+	find := func() (*graveler.Commit, error) {
+		calls++
+		return base, nil
+	}
+	get := func(repositoryID graveler.RepositoryID, left, right graveler.CommitID) *graveler.Commit {
+		t.Helper()
+		commit, err := c.GetOrSet(repositoryID, left, right, find)
+		if err != nil {
+			t.Fatalf("GetOrSet(%s, %s, %s): %s", repositoryID, left, right, err)
+		}
+		return commit
+	}
+
+	if got := get("repo", "c1", "c2"); got != base {
+		t.Fatalf("GetOrSet() = %v, expected %v", got, base)
+	}
+	get("repo", "c1", "c2")
+	if calls != 1 {
+		t.Fatalf("merge-base computed %d times, expected once", calls)
+	}
+	// a moved branch looks up a new pair
+	get("repo", "c1", "c3")
+	if calls != 2 {
+		t.Fatalf("merge-base computed %d times after branch moved, expected 2", calls)
+	}
+	// other repositories are not affected by invalidation
+	get("other", "c1", "c2")
+	c.Invalidate("repo")
+	get("repo", "c1", "c2")
+	get("other", "c1", "c2")
+	if calls != 4 {
+		t.Fatalf("merge-base computed %d times after invalidation, expected 4", calls)
+	}
+}
+
+func TestMergeBaseCache_NoMergeBase(t *testing.T) {
+	c := newMergeBaseCache(100, time.Hour, time.Millisecond)
+	calls := 0
+	for i := 0; i < 2; i++ {
+		commit, err := c.GetOrSet("repo", "c1", "c2", func() (*graveler.Commit, error) {
+			calls++
+			return nil, nil
+		})
+		if err != nil {
+			t.Fatalf("GetOrSet(): %s", err)
+		}
+		if commit != nil {
+			t.Fatalf("GetOrSet() = %v, expected no merge-base", commit)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("merge-base computed %d times, expected once", calls)
+	}
+}
@@ -61,7 +61,7 @@ func (ri *RepositoryIterator) maybeFetch() {
 		offsetCondition = iteratorOffsetCondition(false)
 	}
 	ri.err = ri.db.WithContext(ri.ctx).Select(&ri.buf, `
-			SELECT id, storage_namespace, creation_date, default_branch
+			SELECT id, storage_namespace, creation_date, default_branch, range_split
 			FROM graveler_repositories
 			WHERE id `+offsetCondition+` $1
 			ORDER BY id ASC
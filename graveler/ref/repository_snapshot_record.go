@@ -0,0 +1,25 @@
+package ref
+
+import (
+	"time"
+
+	"github.com/treeverse/lakefs/graveler"
+)
+
+type repositorySnapshotRecord struct {
+	SnapshotID   string            `db:"id"`
+	CreationDate time.Time         `db:"creation_date"`
+	Branches     map[string]string `db:"branches"`
+}
+
+func (r *repositorySnapshotRecord) toGravelerRepositorySnapshot() *graveler.RepositorySnapshot {
+	branches := make(map[graveler.BranchID]graveler.CommitID, len(r.Branches))
+	for branchID, commitID := range r.Branches {
+		branches[graveler.BranchID(branchID)] = graveler.CommitID(commitID)
+	}
+	return &graveler.RepositorySnapshot{
+		SnapshotID:   graveler.RepositorySnapshotID(r.SnapshotID),
+		CreationDate: r.CreationDate,
+		Branches:     branches,
+	}
+}
@@ -3,13 +3,17 @@ package ref
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/treeverse/lakefs/graveler"
 	"github.com/treeverse/lakefs/ident"
 )
 
 type Store interface {
+	GetRepository(ctx context.Context, repositoryID graveler.RepositoryID) (*graveler.Repository, error)
 	GetBranch(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID) (*graveler.Branch, error)
+	// GetBranchLogCommit returns the commit of branchID n updates before its latest one
+	GetBranchLogCommit(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, n int) (*graveler.CommitID, error)
 	GetTag(ctx context.Context, repositoryID graveler.RepositoryID, tagID graveler.TagID) (*graveler.CommitID, error)
 	GetCommitByPrefix(ctx context.Context, repositoryID graveler.RepositoryID, prefix graveler.CommitID) (*graveler.Commit, error)
 	GetCommit(ctx context.Context, repositoryID graveler.RepositoryID, prefix graveler.CommitID) (*graveler.Commit, error)
@@ -35,9 +39,9 @@ func (r reference) CommitID() graveler.CommitID {
 	return *r.commitID
 }
 
-// revResolve return the first resolve of 'rev' - by hash, branch or tag
+// revResolve return the first resolve of 'rev' - by hash, branch, tag or HEAD
 func revResolve(ctx context.Context, store Store, addressProvider ident.AddressProvider, repositoryID graveler.RepositoryID, rev string) (graveler.Reference, error) {
-	resolvers := []revResolverFunc{revResolveAHash, revResolveBranch, revResolveTag}
+	resolvers := []revResolverFunc{revResolveAHash, revResolveBranch, revResolveTag, revResolveHead}
 	for _, resolveHelper := range resolvers {
 		r, err := resolveHelper(ctx, store, addressProvider, repositoryID, rev)
 		if err != nil {
@@ -52,8 +56,8 @@ func revResolve(ctx context.Context, store Store, addressProvider ident.AddressP
 
 func ResolveRef(ctx context.Context, store Store, addressProvider ident.AddressProvider, repositoryID graveler.RepositoryID, ref graveler.Ref) (graveler.Reference, error) {
 	// first we need to parse-rev to get a list references
-	// valid revs: branch, tag, commit ID, commit ID prefix (as long as unambiguous)
-	// valid modifiers: ~N
+	// valid revs: branch, tag, commit ID, commit ID prefix (as long as unambiguous), HEAD
+	// valid modifiers: @{N} of branches, ~N, ^N, ^{commit}
 	parsed, err := RevParse(ref)
 	if err != nil {
 		return nil, err
@@ -69,9 +73,28 @@ func ResolveRef(ctx context.Context, store Store, addressProvider ident.AddressP
 	}
 	baseCommit := rr.CommitID()
 
-	for _, mod := range parsed.Modifiers {
+	for i, mod := range parsed.Modifiers {
 		// lastly, apply modifier
 		switch mod.Type {
+		case RevModTypeAt:
+			if i > 0 || rr.Type() != graveler.ReferenceTypeBranch {
+				return nil, fmt.Errorf("@{%d} of %s, not a branch: %w", mod.Value, parsed.BaseRev, graveler.ErrInvalidRef)
+			}
+			if mod.Value == 0 {
+				continue // @{0} = the branch itself
+			}
+			branchID := graveler.BranchID(parsed.BaseRev)
+			if parsed.BaseRev == HeadRev {
+				branchID, err = headBranchID(ctx, store, repositoryID, parsed.BaseRev)
+				if err != nil {
+					return nil, err
+				}
+			}
+			commitID, err := store.GetBranchLogCommit(ctx, repositoryID, branchID, mod.Value)
+			if err != nil {
+				return nil, err
+			}
+			baseCommit = *commitID
 		case RevModTypeTilde:
 			// skip mod.ValueNumeric iterations
 			for i := 0; i < mod.Value; i++ {
@@ -144,6 +167,35 @@ func revResolveBranch(ctx context.Context, store Store, _ ident.AddressProvider,
 	}, nil
 }
 
+// headBranchID returns the branch of HEAD: a branch named HEAD if there is one, otherwise the
+// default branch of the repository
+func headBranchID(ctx context.Context, store Store, repositoryID graveler.RepositoryID, rev string) (graveler.BranchID, error) {
+	_, err := store.GetBranch(ctx, repositoryID, graveler.BranchID(rev))
+	if err == nil {
+		return graveler.BranchID(rev), nil
+	}
+	if !errors.Is(err, graveler.ErrNotFound) {
+		return "", err
+	}
+	repo, err := store.GetRepository(ctx, repositoryID)
+	if err != nil {
+		return "", err
+	}
+	return repo.DefaultBranchID, nil
+}
+
+// revResolveHead resolves HEAD to the default branch of the repository
+func revResolveHead(ctx context.Context, store Store, addressProvider ident.AddressProvider, repositoryID graveler.RepositoryID, rev string) (graveler.Reference, error) {
+	if rev != HeadRev {
+		return nil, nil
+	}
+	branchID, err := headBranchID(ctx, store, repositoryID, rev)
+	if err != nil {
+		return nil, err
+	}
+	return revResolveBranch(ctx, store, addressProvider, repositoryID, string(branchID))
+}
+
 func revResolveTag(ctx context.Context, store Store, _ ident.AddressProvider, repositoryID graveler.RepositoryID, rev string) (graveler.Reference, error) {
 	commitID, err := store.GetTag(ctx, repositoryID, graveler.TagID(rev))
 	if errors.Is(err, graveler.ErrNotFound) {
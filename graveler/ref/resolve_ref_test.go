@@ -44,7 +44,7 @@ func TestResolveRef_Dereference(t *testing.T) {
 		ts.Add(time.Minute)
 	}
 
-	iter, err := r.Log(ctx, "repo1", previous)
+	iter, err := r.Log(ctx, "repo1", previous, false)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -187,6 +187,82 @@ func TestResolveRef_Dereference(t *testing.T) {
 	}
 }
 
+func TestResolveRef_Expressions(t *testing.T) {
+	r := testRefManager(t)
+	ctx := context.Background()
+	testutil.Must(t, r.CreateRepository(ctx, "repo1", graveler.Repository{
+		StorageNamespace: "s3://",
+		CreationDate:     time.Now(),
+		DefaultBranchID:  "master",
+	}, ""))
+	master, err := r.GetBranch(ctx, "repo1", "master")
+	testutil.MustDo(t, "get master", err)
+	c0 := master.CommitID
+
+	ts, _ := time.Parse(time.RFC3339, "2020-12-01T15:00:00Z")
+	addCommit := func(message string, parents ...graveler.CommitID) graveler.CommitID {
+		cid, err := r.AddCommit(ctx, "repo1", graveler.Commit{
+			Message:      message,
+			Committer:    "tester",
+			MetaRangeID:  "deadbeef1",
+			CreationDate: ts,
+			Parents:      parents,
+		})
+		testutil.MustDo(t, "add commit", err)
+		return cid
+	}
+	c1 := addCommit("c1", c0)
+	c2 := addCommit("c2", c1)
+	testutil.Must(t, r.SetBranch(ctx, "repo1", "master", graveler.Branch{CommitID: c1}))
+	testutil.Must(t, r.SetBranch(ctx, "repo1", "master", graveler.Branch{CommitID: c2}))
+	// only changes of the commit are logged
+	testutil.Must(t, r.SetBranch(ctx, "repo1", "master", graveler.Branch{CommitID: c2, StagingToken: "token"}))
+	testutil.Must(t, r.CreateTag(ctx, "repo1", "v1.0", c1))
+
+	// a deleted branch loses its log
+	testutil.Must(t, r.SetBranch(ctx, "repo1", "recreated", graveler.Branch{CommitID: c1}))
+	testutil.Must(t, r.DeleteBranch(ctx, "repo1", "recreated"))
+	testutil.Must(t, r.SetBranch(ctx, "repo1", "recreated", graveler.Branch{CommitID: c2}))
+
+	table := []struct {
+		Ref         graveler.Ref
+		Expected    graveler.CommitID
+		ExpectedErr error
+	}{
+		{Ref: "master@{0}", Expected: c2},
+		{Ref: "master@{1}", Expected: c1},
+		{Ref: "master@{2}", Expected: c0},
+		{Ref: "master@{3}", ExpectedErr: graveler.ErrNotFound},
+		{Ref: "master@{1}~1", Expected: c0},
+		{Ref: "HEAD", Expected: c2},
+		{Ref: "HEAD^", Expected: c1},
+		{Ref: "HEAD@{1}", Expected: c1},
+		{Ref: "@{2}", Expected: c0},
+		{Ref: "v1.0^{commit}", Expected: c1},
+		{Ref: "master~1^{}", Expected: c1},
+		{Ref: "recreated@{1}", ExpectedErr: graveler.ErrNotFound},
+		{Ref: "v1.0@{1}", ExpectedErr: graveler.ErrInvalidRef},
+		{Ref: "master^{tree}", ExpectedErr: graveler.ErrInvalidRef},
+	}
+	for _, tt := range table {
+		t.Run(string(tt.Ref), func(t *testing.T) {
+			reference, err := r.RevParse(ctx, "repo1", tt.Ref)
+			if tt.ExpectedErr != nil {
+				if !errors.Is(err, tt.ExpectedErr) {
+					t.Fatalf("RevParse(%s) err=%v, expected %s", tt.Ref, err, tt.ExpectedErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RevParse(%s): %s", tt.Ref, err)
+			}
+			if reference.CommitID() != tt.Expected {
+				t.Errorf("RevParse(%s) = %s, expected %s", tt.Ref, reference.CommitID(), tt.Expected)
+			}
+		})
+	}
+}
+
 func TestResolveRef_SameDate(t *testing.T) {
 	r := testRefManager(t)
 	ctx := context.Background()
@@ -218,7 +294,7 @@ func TestResolveRef_SameDate(t *testing.T) {
 	c4 := addCommit("c4", c3)
 	c5 := addCommit("c5", c4, c2)
 
-	it, err := r.Log(ctx, "repo1", c5)
+	it, err := r.Log(ctx, "repo1", c5, false)
 	testutil.MustDo(t, "Log request", err)
 	var commitIDs []graveler.CommitID
 	for it.Next() {
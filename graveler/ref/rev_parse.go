@@ -11,13 +11,20 @@ import (
 var (
 	hashRegexp      = regexp.MustCompile("^[a-fA-F0-9]{1,64}$")
 	modifiersRegexp = regexp.MustCompile("(^|[~^])[^^~]*")
+	branchLogRegexp = regexp.MustCompile(`^(.*)@\{([0-9]+)\}$`)
 )
 
+// HeadRev is the base rev of the default branch of the repository, as checked out in git
+const HeadRev = "HEAD"
+
 type RevModType uint8
 
 const (
 	RevModTypeTilde RevModType = iota
 	RevModTypeCaret
+	// RevModTypeAt selects a previous commit of a branch, Value updates before its latest.  It
+	// is only the first modifier of a rev.
+	RevModTypeAt
 )
 
 func isAHash(part string) bool {
@@ -35,6 +42,10 @@ type RevModifier struct {
 }
 
 func parseMod(buf string) (RevModifier, error) {
+	if buf == "^{}" || buf == "^{commit}" {
+		// peeling a ref to its commit, every ref is already a commit
+		return RevModifier{Type: RevModTypeCaret, Value: 0}, nil
+	}
 	amount := 1
 	var err error
 	if len(buf) > 1 {
@@ -66,6 +77,18 @@ func RevParse(r graveler.Ref) (ParsedRev, error) {
 	}
 	baseRev := parts[0]
 	mods := make([]RevModifier, 0, len(parts)-1)
+	if match := branchLogRegexp.FindStringSubmatch(baseRev); match != nil {
+		n, err := strconv.Atoi(match[2])
+		if err != nil {
+			return ParsedRev{}, fmt.Errorf("could not parse modifier %s: %w", baseRev, graveler.ErrInvalidRef)
+		}
+		baseRev = match[1]
+		if baseRev == "" {
+			// "@{n}" is of the default branch, as in git
+			baseRev = HeadRev
+		}
+		mods = append(mods, RevModifier{Type: RevModTypeAt, Value: n})
+	}
 	for _, part := range parts[1:] {
 		mod, err := parseMod(part)
 		if err != nil {
@@ -91,6 +91,54 @@ func TestRevParse(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name:  "branch_log",
+			Input: "master@{2}~1",
+			Expected: ref.ParsedRev{
+				BaseRev: "master",
+				Modifiers: []ref.RevModifier{
+					{
+						Type:  ref.RevModTypeAt,
+						Value: 2,
+					},
+					{
+						Type:  ref.RevModTypeTilde,
+						Value: 1,
+					},
+				},
+			},
+		},
+		{
+			Name:  "head_log",
+			Input: "@{1}",
+			Expected: ref.ParsedRev{
+				BaseRev: ref.HeadRev,
+				Modifiers: []ref.RevModifier{
+					{
+						Type:  ref.RevModTypeAt,
+						Value: 1,
+					},
+				},
+			},
+		},
+		{
+			Name:  "peel_commit",
+			Input: "v1.0^{commit}",
+			Expected: ref.ParsedRev{
+				BaseRev: "v1.0",
+				Modifiers: []ref.RevModifier{
+					{
+						Type:  ref.RevModTypeCaret,
+						Value: 0,
+					},
+				},
+			},
+		},
+		{
+			Name:        "peel_tree",
+			Input:       "master^{tree}",
+			ExpectedErr: graveler.ErrInvalidRef,
+		},
 		{
 			Name:        "no_base",
 			Input:       "^^^3",
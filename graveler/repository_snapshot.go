@@ -0,0 +1,76 @@
+package graveler
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+func (g *Graveler) CreateRepositorySnapshot(ctx context.Context, repositoryID RepositoryID, snapshotID RepositorySnapshotID) (*RepositorySnapshot, error) {
+	if _, err := g.RefManager.GetRepository(ctx, repositoryID); err != nil {
+		return nil, err
+	}
+	if err := g.RefManager.CreateRepositorySnapshot(ctx, repositoryID, snapshotID, time.Now()); err != nil {
+		return nil, err
+	}
+	return g.RefManager.GetRepositorySnapshot(ctx, repositoryID, snapshotID)
+}
+
+func (g *Graveler) GetRepositorySnapshot(ctx context.Context, repositoryID RepositoryID, snapshotID RepositorySnapshotID) (*RepositorySnapshot, error) {
+	return g.RefManager.GetRepositorySnapshot(ctx, repositoryID, snapshotID)
+}
+
+func (g *Graveler) ListRepositorySnapshots(ctx context.Context, repositoryID RepositoryID) ([]*RepositorySnapshot, error) {
+	return g.RefManager.ListRepositorySnapshots(ctx, repositoryID)
+}
+
+func (g *Graveler) DeleteRepositorySnapshot(ctx context.Context, repositoryID RepositoryID, snapshotID RepositorySnapshotID) error {
+	return g.RefManager.DeleteRepositorySnapshot(ctx, repositoryID, snapshotID)
+}
+
+func (g *Graveler) RestoreRepositorySnapshot(ctx context.Context, repositoryID RepositoryID, snapshotID RepositorySnapshotID) error {
+	snapshot, err := g.RefManager.GetRepositorySnapshot(ctx, repositoryID, snapshotID)
+	if err != nil {
+		return err
+	}
+	branchIDs := make([]BranchID, 0, len(snapshot.Branches))
+	for branchID := range snapshot.Branches {
+		branchIDs = append(branchIDs, branchID)
+	}
+	sort.Slice(branchIDs, func(i, j int) bool { return branchIDs[i] < branchIDs[j] })
+	for _, branchID := range branchIDs {
+		if err := g.restoreBranch(ctx, repositoryID, branchID, snapshot.Branches[branchID]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreBranch points branchID to commitID and drops its staged changes, creating the branch if it
+// no longer exists
+func (g *Graveler) restoreBranch(ctx context.Context, repositoryID RepositoryID, branchID BranchID, commitID CommitID) error {
+	_, err := g.branchLocker.Writer(ctx, repositoryID, branchID, func() (interface{}, error) {
+		branch, err := g.RefManager.GetBranch(ctx, repositoryID, branchID)
+		if errors.Is(err, ErrBranchNotFound) {
+			return nil, g.RefManager.SetBranch(ctx, repositoryID, branchID, Branch{
+				CommitID:     commitID,
+				StagingToken: generateStagingToken(repositoryID, branchID),
+			})
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := g.StagingManager.Drop(ctx, branch.StagingToken); err != nil {
+			return nil, err
+		}
+		if branch.CommitID == commitID {
+			return nil, nil
+		}
+		return nil, g.RefManager.SetBranch(ctx, repositoryID, branchID, Branch{
+			CommitID:     commitID,
+			StagingToken: branch.StagingToken,
+		})
+	})
+	return err
+}
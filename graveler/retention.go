@@ -0,0 +1,252 @@
+package graveler
+
+import (
+	"container/heap"
+	"context"
+	"sort"
+	"time"
+)
+
+// RetentionRules define which commits of a repository are retained.  Branch heads are always
+// retained, a commit is retained if any rule matches it.
+type RetentionRules struct {
+	// KeepLastCommits retains the last N commits reachable from each branch head
+	KeepLastCommits int `json:"keep_last_commits"`
+	// KeepNewerThanDays retains commits created in the last N days
+	KeepNewerThanDays int `json:"keep_newer_than_days"`
+	// KeepTagged retains commits pointed to by a tag
+	KeepTagged bool `json:"keep_tagged"`
+}
+
+// ExpiredCommits is the result of applying RetentionRules to the commits of a repository
+type ExpiredCommits struct {
+	// Retained commits matched by the retention rules
+	Retained []CommitID
+	// Expired commits not matched by the retention rules, their data can be removed unless it is
+	// also referenced by a retained commit or staged on a branch
+	Expired []CommitID
+	// Unreachable expired commits that are not ancestors of any retained commit, their records
+	// can be removed without breaking history
+	Unreachable []CommitID
+}
+
+// commitsByDate is a max heap of commits ordered by creation date, newest first
+type commitsByDate struct {
+	ids     []CommitID
+	commits map[CommitID]*Commit
+}
+
+func (h *commitsByDate) Len() int { return len(h.ids) }
+
+func (h *commitsByDate) Less(i, j int) bool {
+	ci, cj := h.commits[h.ids[i]], h.commits[h.ids[j]]
+	if ci.CreationDate.Equal(cj.CreationDate) {
+		return h.ids[i] > h.ids[j]
+	}
+	return ci.CreationDate.After(cj.CreationDate)
+}
+
+func (h *commitsByDate) Swap(i, j int) { h.ids[i], h.ids[j] = h.ids[j], h.ids[i] }
+
+func (h *commitsByDate) Push(x interface{}) { h.ids = append(h.ids, x.(CommitID)) }
+
+func (h *commitsByDate) Pop() interface{} {
+	n := len(h.ids)
+	id := h.ids[n-1]
+	h.ids = h.ids[:n-1]
+	return id
+}
+
+// lastCommits returns the newest n commits reachable from head
+func lastCommits(commits map[CommitID]*Commit, head CommitID, n int) []CommitID {
+	if _, ok := commits[head]; !ok || n <= 0 {
+		return nil
+	}
+	h := &commitsByDate{ids: []CommitID{head}, commits: commits}
+	seen := map[CommitID]struct{}{head: {}}
+	var res []CommitID
+	for h.Len() > 0 && len(res) < n {
+		id := heap.Pop(h).(CommitID)
+		res = append(res, id)
+		for _, parent := range commits[id].Parents {
+			if _, ok := seen[parent]; ok {
+				continue
+			}
+			seen[parent] = struct{}{}
+			if _, ok := commits[parent]; ok {
+				heap.Push(h, parent)
+			}
+		}
+	}
+	return res
+}
+
+// ancestors returns the commits reachable from ids, including ids
+func ancestors(commits map[CommitID]*Commit, ids []CommitID) map[CommitID]struct{} {
+	res := make(map[CommitID]struct{})
+	queue := append([]CommitID(nil), ids...)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if _, ok := res[id]; ok {
+			continue
+		}
+		commit, ok := commits[id]
+		if !ok {
+			continue
+		}
+		res[id] = struct{}{}
+		queue = append(queue, commit.Parents...)
+	}
+	return res
+}
+
+func sortedCommitIDs(set map[CommitID]struct{}) []CommitID {
+	res := make([]CommitID, 0, len(set))
+	for id := range set {
+		res = append(res, id)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	return res
+}
+
+// FindExpiredCommits applies rules to commits as of now.  branchHeads are the commits branches
+// point to, taggedCommits the commits tags point to.
+func FindExpiredCommits(commits map[CommitID]*Commit, branchHeads []CommitID, taggedCommits []CommitID, rules RetentionRules, now time.Time) *ExpiredCommits {
+	retained := make(map[CommitID]struct{})
+	retain := func(id CommitID) {
+		if _, ok := commits[id]; ok {
+			retained[id] = struct{}{}
+		}
+	}
+	for _, head := range branchHeads {
+		retain(head)
+		for _, id := range lastCommits(commits, head, rules.KeepLastCommits) {
+			retain(id)
+		}
+	}
+	if rules.KeepTagged {
+		for _, id := range taggedCommits {
+			retain(id)
+		}
+	}
+	if rules.KeepNewerThanDays > 0 {
+		threshold := now.AddDate(0, 0, -rules.KeepNewerThanDays)
+		for id, commit := range commits {
+			if commit.CreationDate.After(threshold) {
+				retain(id)
+			}
+		}
+	}
+
+	expired := make(map[CommitID]struct{})
+	for id := range commits {
+		if _, ok := retained[id]; !ok {
+			expired[id] = struct{}{}
+		}
+	}
+	retainedIDs := sortedCommitIDs(retained)
+	// tags keep their commit records even when not retained
+	reachable := ancestors(commits, append(append([]CommitID(nil), retainedIDs...), taggedCommits...))
+	unreachable := make(map[CommitID]struct{})
+	for id := range expired {
+		if _, ok := reachable[id]; !ok {
+			unreachable[id] = struct{}{}
+		}
+	}
+	return &ExpiredCommits{
+		Retained:    retainedIDs,
+		Expired:     sortedCommitIDs(expired),
+		Unreachable: sortedCommitIDs(unreachable),
+	}
+}
+
+// RetainedBranchLogCommits applies rules to the entries of branch logs as of now, ordered by
+// branch and newest entry first.  It returns the commits of the last KeepLastCommits entries of
+// each log, and of the entries of the last KeepNewerThanDays days.  Other entries expire with
+// their commits, as "git reflog expire" expires entries of the reflog.
+func RetainedBranchLogCommits(log []*BranchLogRecord, rules RetentionRules, now time.Time) []CommitID {
+	threshold := now.AddDate(0, 0, -rules.KeepNewerThanDays)
+	var res []CommitID
+	var branchID BranchID
+	n := 0
+	for _, rec := range log {
+		if rec.BranchID != branchID {
+			branchID = rec.BranchID
+			n = 0
+		}
+		if n < rules.KeepLastCommits || (rules.KeepNewerThanDays > 0 && rec.CreationDate.After(threshold)) {
+			res = append(res, rec.CommitID)
+		}
+		n++
+	}
+	return res
+}
+
+func (g *Graveler) ExpiredCommits(ctx context.Context, repositoryID RepositoryID, rules RetentionRules, now time.Time, pinned []CommitID) (*ExpiredCommits, error) {
+	commitsIt, err := g.RefManager.ListCommits(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer commitsIt.Close()
+	commits := make(map[CommitID]*Commit)
+	for commitsIt.Next() {
+		rec := commitsIt.Value()
+		commits[rec.CommitID] = rec.Commit
+	}
+	if err := commitsIt.Err(); err != nil {
+		return nil, err
+	}
+
+	branchesIt, err := g.RefManager.ListBranches(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer branchesIt.Close()
+	var branchHeads []CommitID
+	for branchesIt.Next() {
+		branchHeads = append(branchHeads, branchesIt.Value().CommitID)
+	}
+	if err := branchesIt.Err(); err != nil {
+		return nil, err
+	}
+	// snapshots can be restored, so their commits are retained like branch heads
+	snapshots, err := g.RefManager.ListRepositorySnapshots(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	for _, snapshot := range snapshots {
+		for _, commitID := range snapshot.Branches {
+			branchHeads = append(branchHeads, commitID)
+		}
+	}
+	// earlier heads of branches are read as "branch@{n}" until their log entries expire
+	log, err := g.RefManager.ListBranchLog(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	branchHeads = append(branchHeads, RetainedBranchLogCommits(log, rules, now)...)
+	branchHeads = append(branchHeads, pinned...)
+
+	tagsIt, err := g.RefManager.ListTags(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer tagsIt.Close()
+	var taggedCommits []CommitID
+	for tagsIt.Next() {
+		taggedCommits = append(taggedCommits, tagsIt.Value().CommitID)
+	}
+	if err := tagsIt.Err(); err != nil {
+		return nil, err
+	}
+	return FindExpiredCommits(commits, branchHeads, taggedCommits, rules, now), nil
+}
+
+func (g *Graveler) DeleteBranchLogCommits(ctx context.Context, repositoryID RepositoryID, commitIDs []CommitID) error {
+	return g.RefManager.DeleteBranchLogCommits(ctx, repositoryID, commitIDs)
+}
+
+func (g *Graveler) DeleteCommits(ctx context.Context, repositoryID RepositoryID, commitIDs []CommitID) error {
+	return g.RefManager.DeleteCommits(ctx, repositoryID, commitIDs)
+}
@@ -0,0 +1,106 @@
+package graveler_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+func TestFindExpiredCommits(t *testing.T) {
+	now := time.Date(2021, 1, 20, 0, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time { return now.AddDate(0, 0, -d) }
+	// main: c1 <- c2 <- c3 <- c5 (merge of c4)
+	// feature: c2 <- c4
+	// dangling: c6
+	commits := map[graveler.CommitID]*graveler.Commit{
+		"c1": {CreationDate: day(10)},
+		"c2": {CreationDate: day(9), Parents: graveler.CommitParents{"c1"}},
+		"c3": {CreationDate: day(8), Parents: graveler.CommitParents{"c2"}},
+		"c4": {CreationDate: day(7), Parents: graveler.CommitParents{"c2"}},
+		"c5": {CreationDate: day(1), Parents: graveler.CommitParents{"c3", "c4"}},
+		"c6": {CreationDate: day(5), Parents: graveler.CommitParents{"c1"}},
+	}
+	heads := []graveler.CommitID{"c5", "c4"}
+	tagged := []graveler.CommitID{"c1"}
+
+	tests := []struct {
+		name  string
+		rules graveler.RetentionRules
+		want  *graveler.ExpiredCommits
+	}{
+		{
+			name:  "heads only",
+			rules: graveler.RetentionRules{},
+			want: &graveler.ExpiredCommits{
+				Retained:    []graveler.CommitID{"c4", "c5"},
+				Expired:     []graveler.CommitID{"c1", "c2", "c3", "c6"},
+				Unreachable: []graveler.CommitID{"c6"},
+			},
+		},
+		{
+			name:  "keep last",
+			rules: graveler.RetentionRules{KeepLastCommits: 2},
+			want: &graveler.ExpiredCommits{
+				Retained:    []graveler.CommitID{"c2", "c4", "c5"},
+				Expired:     []graveler.CommitID{"c1", "c3", "c6"},
+				Unreachable: []graveler.CommitID{"c6"},
+			},
+		},
+		{
+			name:  "keep newer",
+			rules: graveler.RetentionRules{KeepNewerThanDays: 6},
+			want: &graveler.ExpiredCommits{
+				Retained:    []graveler.CommitID{"c4", "c5", "c6"},
+				Expired:     []graveler.CommitID{"c1", "c2", "c3"},
+				Unreachable: []graveler.CommitID{},
+			},
+		},
+		{
+			name:  "keep tagged",
+			rules: graveler.RetentionRules{KeepTagged: true},
+			want: &graveler.ExpiredCommits{
+				Retained:    []graveler.CommitID{"c1", "c4", "c5"},
+				Expired:     []graveler.CommitID{"c2", "c3", "c6"},
+				Unreachable: []graveler.CommitID{"c6"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := graveler.FindExpiredCommits(commits, heads, tagged, tt.rules, now)
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Errorf("FindExpiredCommits() diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestRetainedBranchLogCommits(t *testing.T) {
+	now := time.Date(2021, 1, 20, 0, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time { return now.AddDate(0, 0, -d) }
+	log := []*graveler.BranchLogRecord{
+		{BranchID: "feature", CommitID: "c4", CreationDate: day(7)},
+		{BranchID: "main", CommitID: "c5", CreationDate: day(1)},
+		{BranchID: "main", CommitID: "c6", CreationDate: day(5)},
+		{BranchID: "main", CommitID: "c3", CreationDate: day(8)},
+	}
+	tests := []struct {
+		name  string
+		rules graveler.RetentionRules
+		want  []graveler.CommitID
+	}{
+		{name: "none", rules: graveler.RetentionRules{}, want: nil},
+		{name: "keep last", rules: graveler.RetentionRules{KeepLastCommits: 2}, want: []graveler.CommitID{"c4", "c5", "c6"}},
+		{name: "keep newer", rules: graveler.RetentionRules{KeepNewerThanDays: 6}, want: []graveler.CommitID{"c5", "c6"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := graveler.RetainedBranchLogCommits(log, tt.rules, now)
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Errorf("RetainedBranchLogCommits() diff: %s", diff)
+			}
+		})
+	}
+}
@@ -7,10 +7,11 @@ import (
 )
 
 const (
-	EntityTypeKey    = "entity"
-	EntityTypeCommit = "commit"
-	EntityTypeBranch = "branch"
-	EntityTypeTag    = "tag"
+	EntityTypeKey     = "entity"
+	EntityTypeCommit  = "commit"
+	EntityTypeBranch  = "branch"
+	EntityTypeTag     = "tag"
+	EntityTypeStaging = "staging"
 
 	EntitySchemaKey    = "schema_name"
 	EntitySchemaCommit = "io.treeverse.lakefs.graveler.CommitData"
@@ -0,0 +1,331 @@
+package sstable
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+
+	"github.com/cockroachdb/pebble/sstable"
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/graveler/committed"
+	"github.com/treeverse/lakefs/ident"
+)
+
+var (
+	ErrUnknownFileType    = errors.New("unknown range file type")
+	ErrUnsortedKeys       = errors.New("keys not sorted")
+	ErrStatsMismatch      = errors.New("recorded stats do not match records")
+	ErrIDMismatch         = errors.New("ID does not match contents")
+	ErrRangeMismatch      = errors.New("range does not match its metarange record")
+	ErrOverlappingRanges  = errors.New("overlapping ranges")
+	ErrMissingRange       = errors.New("range missing")
+	ErrBadMetaRangeRecord = errors.New("bad metarange record")
+)
+
+// Inspector reads Range and MetaRange files directly from the block storage of a storage
+// namespace.  It needs no database and no running lakeFS, and it bypasses the local cache of
+// the pyramid.  Use it for debugging and recovery.
+type Inspector struct {
+	Adapter          block.Adapter
+	StorageNamespace string
+	// BlockStoragePrefix is the prefix under which the pyramid stores Range and MetaRange
+	// files in the storage namespace
+	BlockStoragePrefix string
+	// Hash is the hash used to write the files, verified against their IDs
+	Hash crypto.Hash
+}
+
+// FileInfo describes a Range or MetaRange file, from its metadata and its records
+type FileInfo struct {
+	ID committed.ID
+	// Type is "ranges" or "metaranges"
+	Type          string
+	FormatVersion int
+	Metadata      graveler.Metadata
+	SizeBytes     int64
+	Count         int
+	MinKey        committed.Key
+	MaxKey        committed.Key
+}
+
+// InspectedFile is an open Range or MetaRange file
+type InspectedFile struct {
+	ID        committed.ID
+	Metadata  graveler.Metadata
+	SizeBytes int64
+	reader    *sstable.Reader
+	file      *os.File
+}
+
+// List calls cb with the ID of every Range and MetaRange file in the storage namespace, in
+// order
+func (in *Inspector) List(_ context.Context, cb func(id committed.ID) error) error {
+	var ids []string
+	err := in.Adapter.Walk(block.WalkOpts{
+		StorageNamespace: in.StorageNamespace,
+		Prefix:           in.BlockStoragePrefix + "/",
+	}, func(id string) error {
+		// adapters report IDs of different forms, but all end in the file name
+		ids = append(ids, path.Base(id))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("walk %s: %w", in.StorageNamespace, err)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if err := cb(committed.ID(id)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Open copies the file id to a local temporary file and opens it
+func (in *Inspector) Open(_ context.Context, id committed.ID) (*InspectedFile, error) {
+	r, err := in.Adapter.Get(block.ObjectPointer{
+		StorageNamespace: in.StorageNamespace,
+		Identifier:       path.Join(in.BlockStoragePrefix, string(id)),
+	}, 0)
+	if err != nil {
+		return nil, fmt.Errorf("get %s: %w", id, err)
+	}
+	defer func() { _ = r.Close() }()
+	file, err := ioutil.TempFile("", "lakefs-inspect-")
+	if err != nil {
+		return nil, err
+	}
+	size, err := io.Copy(file, r)
+	if err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return nil, fmt.Errorf("copy %s: %w", id, err)
+	}
+	// the reader takes ownership of file, and closes it
+	reader, err := sstable.NewReader(file, sstable.ReaderOptions{})
+	if err != nil {
+		_ = os.Remove(file.Name())
+		return nil, fmt.Errorf("open %s: %w", id, err)
+	}
+	metadata := make(graveler.Metadata, len(reader.Properties.UserProperties))
+	for k, v := range reader.Properties.UserProperties {
+		metadata[k] = v
+	}
+	return &InspectedFile{
+		ID:        id,
+		Metadata:  metadata,
+		SizeBytes: size,
+		reader:    reader,
+		file:      file,
+	}, nil
+}
+
+// NewIterator returns an iterator over the records of the file
+func (f *InspectedFile) NewIterator() (committed.ValueIterator, error) {
+	iter, err := f.reader.NewIter(nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("iterate %s: %w", f.ID, err)
+	}
+	return NewIterator(iter, func() error { return nil }), nil
+}
+
+// Close closes the file and removes its local copy
+func (f *InspectedFile) Close() error {
+	err := f.reader.Close()
+	if removeErr := os.Remove(f.file.Name()); err == nil {
+		err = removeErr
+	}
+	return err
+}
+
+// Stat returns the description of the file id.  It reads all records of the file.
+func (in *Inspector) Stat(ctx context.Context, id committed.ID) (*FileInfo, error) {
+	f, err := in.Open(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	info, _, err := in.scan(f, nil)
+	return info, err
+}
+
+// scan reads all records of f into a FileInfo, calling cb with each one.  It returns the
+// problems of the file: records out of order, stats or an ID that do not match the records.
+func (in *Inspector) scan(f *InspectedFile, cb func(record *committed.Record) error) (*FileInfo, []error, error) {
+	info := &FileInfo{
+		ID:        f.ID,
+		Type:      f.Metadata[committed.MetadataTypeKey],
+		Metadata:  f.Metadata,
+		SizeBytes: f.SizeBytes,
+	}
+	var problems []error
+	version, err := committed.ParseFormatVersion(f.Metadata)
+	if err != nil {
+		problems = append(problems, err)
+	}
+	info.FormatVersion = version
+	if info.Type != committed.MetadataRangesType && info.Type != committed.MetadataMetarangesType {
+		problems = append(problems, fmt.Errorf("%s: %w", info.Type, ErrUnknownFileType))
+	}
+
+	// the writer hashes records and then the metadata set before closing
+	h := in.Hash.New()
+	iter, err := f.NewIterator()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer iter.Close()
+	for iter.Next() {
+		record := iter.Value()
+		if info.Count > 0 && bytes.Compare(record.Key, info.MaxKey) <= 0 {
+			problems = append(problems, fmt.Errorf("key %q after %q: %w", record.Key, info.MaxKey, ErrUnsortedKeys))
+		}
+		if info.Count == 0 {
+			info.MinKey = append(committed.Key(nil), record.Key...)
+		}
+		info.MaxKey = append(info.MaxKey[:0], record.Key...)
+		info.Count++
+		for _, buf := range [][]byte{record.Key, record.Value} {
+			_, _ = h.Write([]byte(strconv.Itoa(len(buf))))
+			_, _ = h.Write(buf)
+			_, _ = h.Write([]byte("|"))
+		}
+		if cb != nil {
+			if err := cb(record); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, nil, fmt.Errorf("read %s: %w", f.ID, err)
+	}
+
+	stats := map[string]string{
+		MetadataFirstKey:      string(info.MinKey),
+		MetadataLastKey:       string(info.MaxKey),
+		MetadataNumRecordsKey: strconv.Itoa(info.Count),
+	}
+	hashed := make(map[string]string, len(f.Metadata))
+	for k, v := range f.Metadata {
+		if expected, ok := stats[k]; ok && v != expected {
+			problems = append(problems, fmt.Errorf("%s %q, records give %q: %w", k, v, expected, ErrStatsMismatch))
+		}
+		if _, ok := stats[k]; !ok && k != MetadataEstimatedSizeKey {
+			hashed[k] = v
+		}
+	}
+	ident.MarshalStringMap(h, hashed)
+	if hashID := hex.EncodeToString(h.Sum(nil)); hashID != string(f.ID) {
+		problems = append(problems, fmt.Errorf("contents hash to %s: %w", hashID, ErrIDMismatch))
+	}
+	return info, problems, nil
+}
+
+// Validate checks the file id and returns the problems it finds.  A MetaRange is checked
+// together with all of its Ranges: they must exist, be ordered without overlaps, and match
+// their records in the MetaRange.
+func (in *Inspector) Validate(ctx context.Context, id committed.ID) ([]error, error) {
+	f, err := in.Open(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var (
+		ranges   []committed.Range
+		problems []error
+	)
+	isMetaRange := f.Metadata[committed.MetadataTypeKey] == committed.MetadataMetarangesType
+	info, fileProblems, err := in.scan(f, func(record *committed.Record) error {
+		if !isMetaRange {
+			if _, err := committed.UnmarshalValue(record.Value); err != nil {
+				problems = append(problems, fmt.Errorf("key %q: %w", record.Key, err))
+			}
+			return nil
+		}
+		rng, err := unmarshalRangeRecord(record)
+		if err != nil {
+			problems = append(problems, err)
+			return nil
+		}
+		ranges = append(ranges, rng)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	problems = append(fileProblems, problems...)
+	for i, rng := range ranges {
+		if i > 0 && bytes.Compare(ranges[i-1].MaxKey, rng.MinKey) >= 0 {
+			problems = append(problems, fmt.Errorf("range %s from %q, previous range %s to %q: %w",
+				rng.ID, rng.MinKey, ranges[i-1].ID, ranges[i-1].MaxKey, ErrOverlappingRanges))
+		}
+		rangeProblems, err := in.validateRange(ctx, rng, info.FormatVersion)
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, rangeProblems...)
+	}
+	return problems, nil
+}
+
+func unmarshalRangeRecord(record *committed.Record) (committed.Range, error) {
+	value, err := committed.UnmarshalValue(record.Value)
+	if err != nil {
+		return committed.Range{}, fmt.Errorf("key %q: %w", record.Key, err)
+	}
+	rng, err := committed.UnmarshalRange(value.Data)
+	if err != nil {
+		return committed.Range{}, fmt.Errorf("key %q: %w", record.Key, err)
+	}
+	rng.ID = committed.ID(value.Identity)
+	if !bytes.Equal(rng.MaxKey, record.Key) || bytes.Compare(rng.MinKey, rng.MaxKey) > 0 {
+		return committed.Range{}, fmt.Errorf("key %q of range %s from %q to %q: %w",
+			record.Key, rng.ID, rng.MinKey, rng.MaxKey, ErrBadMetaRangeRecord)
+	}
+	return rng, nil
+}
+
+// validateRange checks the Range file of rng, a record of a MetaRange of format version
+func (in *Inspector) validateRange(ctx context.Context, rng committed.Range, version int) ([]error, error) {
+	var problems []error
+	f, err := in.Open(ctx, rng.ID)
+	if err != nil {
+		return []error{fmt.Errorf("range %s: %s: %w", rng.ID, err, ErrMissingRange)}, nil
+	}
+	defer func() { _ = f.Close() }()
+	info, fileProblems, err := in.scan(f, func(record *committed.Record) error {
+		if _, err := committed.UnmarshalValue(record.Value); err != nil {
+			problems = append(problems, fmt.Errorf("key %q: %w", record.Key, err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	problems = append(fileProblems, problems...)
+	if info.Type != committed.MetadataRangesType {
+		problems = append(problems, fmt.Errorf("type %s: %w", info.Type, ErrRangeMismatch))
+	}
+	if info.FormatVersion != version {
+		problems = append(problems, fmt.Errorf("format version %d in metarange of version %d: %w", info.FormatVersion, version, ErrRangeMismatch))
+	}
+	if !bytes.Equal(info.MinKey, rng.MinKey) || !bytes.Equal(info.MaxKey, rng.MaxKey) || int64(info.Count) != rng.Count {
+		problems = append(problems, fmt.Errorf("%d records from %q to %q, recorded %d from %q to %q: %w",
+			info.Count, info.MinKey, info.MaxKey, rng.Count, rng.MinKey, rng.MaxKey, ErrRangeMismatch))
+	}
+	for i, problem := range problems {
+		problems[i] = fmt.Errorf("range %s: %w", rng.ID, problem)
+	}
+	return problems, nil
+}
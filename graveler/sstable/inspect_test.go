@@ -0,0 +1,166 @@
+package sstable_test
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/block/mem"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/graveler/committed"
+	"github.com/treeverse/lakefs/graveler/sstable"
+	"github.com/treeverse/lakefs/logging"
+	"github.com/treeverse/lakefs/pyramid"
+	"github.com/treeverse/lakefs/pyramid/params"
+)
+
+const (
+	inspectNamespace = "mem://inspect"
+	inspectPrefix    = "_lakefs"
+)
+
+// writeMetaRange writes a MetaRange of records keys through the pyramid to adapter, with ranges
+// of at most maxRangeEntries records
+func writeMetaRange(t *testing.T, adapter block.Adapter, keys []string, maxRangeEntries int) graveler.MetaRangeID {
+	t.Helper()
+	ctx := context.Background()
+	baseDir, err := ioutil.TempDir("", "inspect-test")
+	if err != nil {
+		t.Fatalf("create base dir: %s", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(baseDir) })
+	newFS := func(name string) pyramid.FS {
+		fs, err := pyramid.NewFS(&params.InstanceParams{
+			FSName:              name,
+			DiskAllocProportion: 0.5,
+			SharedParams: params.SharedParams{
+				Adapter:            adapter,
+				Logger:             logging.Dummy(),
+				BlockStoragePrefix: inspectPrefix,
+				Local: params.LocalDiskParams{
+					BaseDir:             baseDir,
+					TotalAllocatedBytes: 1 << 20,
+				},
+			},
+		})
+		if err != nil {
+			t.Fatalf("create %s FS: %s", name, err)
+		}
+		return fs
+	}
+	cache := pebble.NewCache(0)
+	defer cache.Unref()
+	rangeManager := sstable.NewPebbleSSTableRangeManager(cache, newFS("range"), crypto.SHA256)
+	metaManager := sstable.NewPebbleSSTableRangeManager(cache, newFS("meta-range"), crypto.SHA256)
+	w := committed.NewGeneralMetaRangeWriter(ctx, rangeManager, metaManager, &committed.Params{
+		MinRangeSizeBytes: 1 << 20,
+		MaxRangeSizeBytes: 1 << 20,
+		MaxRangeEntries:   maxRangeEntries,
+		MaxUploaders:      1,
+	}, inspectNamespace, nil)
+	for _, key := range keys {
+		err := w.WriteRecord(graveler.ValueRecord{
+			Key:   graveler.Key(key),
+			Value: &graveler.Value{Identity: []byte("id-" + key), Data: []byte("data-" + key)},
+		})
+		if err != nil {
+			t.Fatalf("write record %s: %s", key, err)
+		}
+	}
+	id, err := w.Close()
+	if err != nil {
+		t.Fatalf("close metarange writer: %s", err)
+	}
+	return *id
+}
+
+func TestInspector(t *testing.T) {
+	ctx := context.Background()
+	adapter := mem.New()
+	keys := []string{"a", "b", "c", "d", "e"}
+	metaRangeID := writeMetaRange(t, adapter, keys, 2)
+	in := &sstable.Inspector{
+		Adapter:            adapter,
+		StorageNamespace:   inspectNamespace,
+		BlockStoragePrefix: inspectPrefix,
+		Hash:               crypto.SHA256,
+	}
+
+	var ranges []committed.ID
+	err := in.List(ctx, func(id committed.ID) error {
+		info, err := in.Stat(ctx, id)
+		if err != nil {
+			return err
+		}
+		if info.Type == committed.MetadataRangesType {
+			ranges = append(ranges, id)
+		} else if id != committed.ID(metaRangeID) {
+			return fmt.Errorf("listed %s of type %s", id, info.Type)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("List() error = %s", err)
+	}
+	if len(ranges) != 3 {
+		t.Fatalf("listed ranges %v, expected 3 ranges of 5 records", ranges)
+	}
+
+	info, err := in.Stat(ctx, committed.ID(metaRangeID))
+	if err != nil {
+		t.Fatalf("Stat(%s) error = %s", metaRangeID, err)
+	}
+	if info.Type != committed.MetadataMetarangesType || info.Count != 3 || string(info.MinKey) != "b" || string(info.MaxKey) != "e" ||
+		info.FormatVersion != committed.CurrentFormatVersion {
+		t.Errorf("Stat(%s) = %+v, expected a metarange of 3 ranges up to b, d and e", metaRangeID, info)
+	}
+
+	problems, err := in.Validate(ctx, committed.ID(metaRangeID))
+	if err != nil {
+		t.Fatalf("Validate(%s) error = %s", metaRangeID, err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("Validate(%s) of a written metarange = %v", metaRangeID, problems)
+	}
+
+	// replace the contents of one range with those of another, and remove a third
+	pointer := func(id committed.ID) block.ObjectPointer {
+		return block.ObjectPointer{StorageNamespace: inspectNamespace, Identifier: inspectPrefix + "/" + string(id)}
+	}
+	r, err := adapter.Get(pointer(ranges[0]), 0)
+	if err != nil {
+		t.Fatalf("get range %s: %s", ranges[0], err)
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read range %s: %s", ranges[0], err)
+	}
+	if err := adapter.Put(pointer(ranges[1]), int64(len(data)), bytes.NewReader(data), block.PutOpts{}); err != nil {
+		t.Fatalf("overwrite range %s: %s", ranges[1], err)
+	}
+	if err := adapter.Remove(pointer(ranges[2])); err != nil {
+		t.Fatalf("remove range %s: %s", ranges[2], err)
+	}
+	problems, err = in.Validate(ctx, committed.ID(metaRangeID))
+	if err != nil {
+		t.Fatalf("Validate(%s) error = %s", metaRangeID, err)
+	}
+	found := map[error]bool{}
+	for _, problem := range problems {
+		for _, expected := range []error{sstable.ErrIDMismatch, sstable.ErrRangeMismatch, sstable.ErrMissingRange} {
+			if errors.Is(problem, expected) {
+				found[expected] = true
+			}
+		}
+	}
+	if len(found) != 3 {
+		t.Errorf("Validate(%s) of a corrupted metarange = %v, expected an ID mismatch, a range mismatch and a missing range", metaRangeID, problems)
+	}
+}
@@ -165,11 +165,31 @@ func (m *RangeManager) NewRangeIterator(ctx context.Context, ns committed.Namesp
 	return NewIterator(iter, reader.Close), nil
 }
 
+// GetMetadata returns the user properties of the SSTable referenced by id
+func (m *RangeManager) GetMetadata(ctx context.Context, ns committed.Namespace, id committed.ID) (graveler.Metadata, error) {
+	reader, err := m.newReader(ctx, ns, id)
+	if err != nil {
+		return nil, err
+	}
+	defer m.execAndLog(ctx, reader.Close, "close reader")
+	metadata := make(graveler.Metadata, len(reader.Properties.UserProperties))
+	for k, v := range reader.Properties.UserProperties {
+		metadata[k] = v
+	}
+	return metadata, nil
+}
+
 // GetWriter returns a new SSTable writer instance
 func (m *RangeManager) GetWriter(ctx context.Context, ns committed.Namespace, metadata graveler.Metadata) (committed.RangeWriter, error) {
 	return NewDiskWriter(ctx, m.fs, ns, m.hash.New(), metadata)
 }
 
+// Remove deletes the SSTable referenced by id.  Ranges of metaranges are never removed, it is
+// only used for staging chunks.
+func (m *RangeManager) Remove(ctx context.Context, ns committed.Namespace, id committed.ID) error {
+	return m.fs.Remove(ctx, string(ns), string(id))
+}
+
 func (m *RangeManager) execAndLog(ctx context.Context, f func() error, msg string) {
 	if err := f(); err != nil {
 		logging.FromContext(ctx).WithError(err).Error(msg)
@@ -0,0 +1,3 @@
+package staging
+
+var NewLayeredIterator = newLayeredIterator
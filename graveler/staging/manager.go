@@ -3,6 +3,7 @@ package staging
 import (
 	"context"
 	"errors"
+	"sync"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/treeverse/lakefs/db"
@@ -11,14 +12,19 @@ import (
 )
 
 type Manager struct {
-	db  db.Database
-	log logging.Logger
+	db           db.Database
+	log          logging.Logger
+	spill        SpillParams
+	spillCounter spillCounter
+	spilling     sync.Map
+	valueSize    ValueSizeFunc
 }
 
-func NewManager(db db.Database) *Manager {
+func NewManager(db db.Database, spill SpillParams) *Manager {
 	return &Manager{
-		db:  db,
-		log: logging.Default().WithField("service_name", "postgres_staging_manager"),
+		db:    db,
+		log:   logging.Default().WithField("service_name", "postgres_staging_manager"),
+		spill: spill,
 	}
 }
 
@@ -29,6 +35,9 @@ func (p *Manager) Get(ctx context.Context, st graveler.StagingToken, key gravele
 		return value, err
 	}, p.txOpts(ctx, db.ReadOnly())...)
 	if errors.Is(err, db.ErrNotFound) {
+		if p.spill.enabled() {
+			return p.getSpilled(ctx, st, key)
+		}
 		return nil, graveler.ErrNotFound
 	}
 	if err != nil {
@@ -41,45 +50,194 @@ func (p *Manager) Get(ctx context.Context, st graveler.StagingToken, key gravele
 	return value, nil
 }
 
+func (p *Manager) GetMany(ctx context.Context, st graveler.StagingToken, keys []graveler.Key) (map[string]*graveler.Value, error) {
+	rawKeys := make([][]byte, len(keys))
+	for i, key := range keys {
+		rawKeys[i] = key
+	}
+	res, err := p.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var records []*graveler.ValueRecord
+		err := tx.Select(&records, "SELECT key, identity, data FROM graveler_staging_kv WHERE staging_token=$1 AND key = ANY($2)", st, rawKeys)
+		return records, err
+	}, p.txOpts(ctx, db.ReadOnly())...)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]*graveler.Value, len(keys))
+	for _, record := range res.([]*graveler.ValueRecord) {
+		values[string(record.Key)] = stagedValue(record.Value)
+	}
+	if !p.spill.enabled() {
+		return values, nil
+	}
+	for _, key := range keys {
+		if _, ok := values[string(key)]; ok {
+			continue
+		}
+		value, err := p.getSpilled(ctx, st, key)
+		if errors.Is(err, graveler.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		values[string(key)] = value
+	}
+	return values, nil
+}
+
 func (p *Manager) Set(ctx context.Context, st graveler.StagingToken, key graveler.Key, value *graveler.Value) error {
+	return p.set(ctx, st, key, value, nil)
+}
+
+func (p *Manager) SetIf(ctx context.Context, st graveler.StagingToken, key graveler.Key, value *graveler.Value, condition graveler.StagedCondition) error {
+	return p.set(ctx, st, key, value, condition)
+}
+
+// set writes value on key, if condition is not nil only once it accepts the value staged on key
+func (p *Manager) set(ctx context.Context, st graveler.StagingToken, key graveler.Key, value *graveler.Value, condition graveler.StagedCondition) error {
 	if value == nil {
 		value = new(graveler.Value)
 	} else if value.Identity == nil {
 		return graveler.ErrInvalidValue
 	}
 	_, err := p.db.Transact(func(tx db.Tx) (interface{}, error) {
-		return tx.Exec(`INSERT INTO graveler_staging_kv (staging_token, key, identity, data)
+		if condition != nil {
+			// serialize conditional writes of key, including ones of keys that are not
+			// staged or only spilled and so have no row to lock
+			if _, err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext($1))", "staging_set:"+string(st)+"/"+string(key)); err != nil {
+				return nil, err
+			}
+		}
+		delta := p.statsOf(stagedValue(value))
+		previous, err := p.lockStaged(ctx, tx, st, key)
+		staged := err == nil
+		if staged {
+			delta = subStats(delta, p.statsOf(previous))
+		} else if !errors.Is(err, graveler.ErrNotFound) {
+			return nil, err
+		}
+		if condition != nil {
+			if err := condition(previous, staged); err != nil {
+				return nil, err
+			}
+			if !staged {
+				// fail if an unconditional write staged key since we checked
+				res, err := tx.Exec(`INSERT INTO graveler_staging_kv (staging_token, key, identity, data)
+								VALUES ($1, $2, $3, $4)
+								ON CONFLICT (staging_token, key) DO NOTHING`,
+					st, key, value.Identity, value.Data)
+				if err != nil {
+					return nil, err
+				}
+				if res.RowsAffected() == 0 {
+					return nil, graveler.ErrPreconditionFailed
+				}
+				return nil, updateStats(tx, st, delta)
+			}
+		}
+		_, err = tx.Exec(`INSERT INTO graveler_staging_kv (staging_token, key, identity, data)
 								VALUES ($1, $2, $3, $4)
 								ON CONFLICT (staging_token, key) DO UPDATE
 									SET (staging_token, key, identity, data) =
 											(excluded.staging_token, excluded.key, excluded.identity, excluded.data)`,
 			st, key, value.Identity, value.Data)
+		if err != nil {
+			return nil, err
+		}
+		return nil, updateStats(tx, st, delta)
 	}, p.txOpts(ctx)...)
-	return err
+	if err != nil {
+		return err
+	}
+	p.maybeSpill(st)
+	return nil
 }
 
 func (p *Manager) DropKey(ctx context.Context, st graveler.StagingToken, key graveler.Key) error {
 	_, err := p.db.Transact(func(tx db.Tx) (interface{}, error) {
-		return tx.Exec("DELETE FROM graveler_staging_kv WHERE staging_token=$1 AND key=$2", st, key)
+		if p.spill.enabled() {
+			// before locking the row, like Spill
+			if err := lockSpills(tx, st); err != nil {
+				return nil, err
+			}
+		}
+		previous, err := p.lockStaged(ctx, tx, st, key)
+		if errors.Is(err, graveler.ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if p.spill.enabled() {
+			// the successor of key is key followed by a zero byte
+			to := append(append(graveler.Key{}, key...), 0)
+			if err := p.dropSpilled(ctx, tx, st, key, to); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := tx.Exec("DELETE FROM graveler_staging_kv WHERE staging_token=$1 AND key=$2", st, key); err != nil {
+			return nil, err
+		}
+		return nil, updateStats(tx, st, subStats(graveler.StagingStats{}, p.statsOf(previous)))
 	}, p.txOpts(ctx)...)
 	return err
 }
 
 func (p *Manager) List(ctx context.Context, st graveler.StagingToken) (graveler.ValueIterator, error) {
+	if p.spill.enabled() {
+		return p.listWithSpills(ctx, st)
+	}
 	return NewStagingIterator(ctx, p.db, p.log, st), nil
 }
 
 func (p *Manager) Drop(ctx context.Context, st graveler.StagingToken) error {
 	_, err := p.db.Transact(func(tx db.Tx) (interface{}, error) {
+		if p.spill.enabled() {
+			if err := lockSpills(tx, st); err != nil {
+				return nil, err
+			}
+		}
+		// spilled chunks may be shared with snapshots of st, they are removed by
+		// DropExpiredSnapshots once no longer referenced
+		if _, err := tx.Exec("DELETE FROM graveler_staging_spills WHERE staging_token=$1", st); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec("DELETE FROM graveler_staging_spill_drops WHERE staging_token=$1", st); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec("DELETE FROM graveler_staging_stats WHERE staging_token=$1", st); err != nil {
+			return nil, err
+		}
 		return tx.Exec("DELETE FROM graveler_staging_kv WHERE staging_token=$1", st)
 	}, p.txOpts(ctx)...)
+	if err == nil {
+		p.spillCounter.forget(st)
+	}
 	return err
 }
 
 func (p *Manager) DropByPrefix(ctx context.Context, st graveler.StagingToken, prefix graveler.Key) error {
 	upperBound := graveler.UpperBoundForPrefix(prefix)
-	builder := sq.Delete("graveler_staging_kv").Where(sq.Eq{"staging_token": st}).Where("key >= ?::bytea", prefix)
+	var removed graveler.StagingStats
+	if p.spill.enabled() {
+		// the values dropped may come from any spilled chunk, sum them before they are dropped
+		var err error
+		if removed, err = p.prefixStats(ctx, st, prefix); err != nil {
+			return err
+		}
+	}
+	builder := sq.Delete("graveler_staging_kv").Where(sq.Eq{"staging_token": st}).Where("key >= ?::bytea", prefix).
+		Suffix("RETURNING identity, data")
 	_, err := p.db.Transact(func(tx db.Tx) (interface{}, error) {
+		if p.spill.enabled() {
+			if err := lockSpills(tx, st); err != nil {
+				return nil, err
+			}
+			if err := p.dropSpilled(ctx, tx, st, prefix, upperBound); err != nil {
+				return nil, err
+			}
+		}
 		if upperBound != nil {
 			builder = builder.Where("key < ?::bytea", upperBound)
 		}
@@ -87,7 +245,26 @@ func (p *Manager) DropByPrefix(ctx context.Context, st graveler.StagingToken, pr
 		if err != nil {
 			return nil, err
 		}
-		return tx.Exec(query, args...)
+		rows, err := tx.Query(query, args...)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		var deleted graveler.StagingStats
+		for rows.Next() {
+			var value graveler.Value
+			if err := rows.Scan(&value.Identity, &value.Data); err != nil {
+				return nil, err
+			}
+			deleted = addStats(deleted, p.statsOf(stagedValue(&value)))
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		if !p.spill.enabled() {
+			removed = deleted
+		}
+		return nil, updateStats(tx, st, subStats(graveler.StagingStats{}, removed))
 	}, p.txOpts(ctx)...)
 	return err
 }
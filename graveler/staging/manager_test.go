@@ -3,19 +3,29 @@ package staging_test
 import (
 	"bytes"
 	"context"
+	"crypto"
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/cockroachdb/pebble"
+	"github.com/treeverse/lakefs/block/mem"
+	"github.com/treeverse/lakefs/db"
 	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/graveler/committed"
+	"github.com/treeverse/lakefs/graveler/sstable"
 	"github.com/treeverse/lakefs/graveler/staging"
+	"github.com/treeverse/lakefs/logging"
+	"github.com/treeverse/lakefs/pyramid"
+	"github.com/treeverse/lakefs/pyramid/params"
 	"github.com/treeverse/lakefs/testutil"
 )
 
 func newTestStagingManager(t *testing.T) (context.Context, graveler.StagingManager) {
 	t.Helper()
 	conn, _ := testutil.GetDB(t, databaseURI)
-	return context.Background(), staging.NewManager(conn)
+	return context.Background(), staging.NewManager(conn, staging.SpillParams{})
 }
 
 func TestSetGet(t *testing.T) {
@@ -34,6 +44,24 @@ func TestSetGet(t *testing.T) {
 	}
 }
 
+func TestGetMany(t *testing.T) {
+	ctx, s := newTestStagingManager(t)
+	testutil.Must(t, s.Set(ctx, "t1", []byte("a"), newTestValue("identity1", "value1")))
+	testutil.Must(t, s.Set(ctx, "t1", []byte("b"), nil))
+	testutil.Must(t, s.Set(ctx, "t2", []byte("c"), newTestValue("identity2", "value2")))
+	values, err := s.GetMany(ctx, "t1", []graveler.Key{[]byte("a"), []byte("b"), []byte("c")})
+	testutil.Must(t, err)
+	if len(values) != 2 {
+		t.Fatalf("expected 2 staged values, got %d: %v", len(values), values)
+	}
+	if v := values["a"]; v == nil || string(v.Identity) != "identity1" {
+		t.Errorf("got wrong value for a. expected identity=%s, got=%v", "identity1", v)
+	}
+	if v, ok := values["b"]; !ok || v != nil {
+		t.Errorf("expected tombstone for b, got %v (staged %t)", v, ok)
+	}
+}
+
 func TestMultiToken(t *testing.T) {
 	ctx, s := newTestStagingManager(t)
 	_, err := s.Get(ctx, "t1", []byte("a/b/c/"))
@@ -408,9 +436,271 @@ func TestDeleteAndTombstone(t *testing.T) {
 	}
 }
 
+func TestStats(t *testing.T) {
+	conn, _ := testutil.GetDB(t, databaseURI)
+	s := staging.NewManager(conn, staging.SpillParams{})
+	s.SetValueSizeFunc(func(value *graveler.Value) int64 {
+		return int64(len(value.Data))
+	})
+	ctx := context.Background()
+	expectStats := func(expected graveler.StagingStats) {
+		t.Helper()
+		stats, err := s.Stats(ctx, "t1")
+		testutil.Must(t, err)
+		if *stats != expected {
+			t.Errorf("got stats %+v, expected %+v", *stats, expected)
+		}
+	}
+
+	expectStats(graveler.StagingStats{})
+	testutil.Must(t, s.Set(ctx, "t1", []byte("a/1"), newTestValue("identity1", "12345")))
+	testutil.Must(t, s.Set(ctx, "t1", []byte("a/2"), newTestValue("identity2", "123")))
+	testutil.Must(t, s.Set(ctx, "t1", []byte("b/1"), nil))
+	expectStats(graveler.StagingStats{Writes: 2, Deletes: 1, SizeBytes: 8})
+
+	// overwriting a value replaces its size, overwriting a tombstone replaces the deletion
+	testutil.Must(t, s.Set(ctx, "t1", []byte("a/1"), newTestValue("identity3", "1")))
+	testutil.Must(t, s.Set(ctx, "t1", []byte("b/1"), newTestValue("identity4", "12")))
+	expectStats(graveler.StagingStats{Writes: 3, SizeBytes: 6})
+
+	testutil.Must(t, s.DropKey(ctx, "t1", []byte("b/1")))
+	expectStats(graveler.StagingStats{Writes: 2, SizeBytes: 4})
+
+	testutil.Must(t, s.DropByPrefix(ctx, "t1", []byte("a/")))
+	expectStats(graveler.StagingStats{})
+
+	testutil.Must(t, s.Set(ctx, "t1", []byte("c"), nil))
+	testutil.Must(t, s.Drop(ctx, "t1"))
+	expectStats(graveler.StagingStats{})
+}
+
 func newTestValue(identity, data string) *graveler.Value {
 	return &graveler.Value{
 		Identity: []byte(identity),
 		Data:     []byte(data),
 	}
 }
+
+func TestSnapshot(t *testing.T) {
+	ctx, s := newTestStagingManager(t)
+	testutil.Must(t, s.Set(ctx, "t1", []byte("a"), newTestValue("identity1", "value1")))
+	testutil.Must(t, s.Set(ctx, "t1", []byte("b"), newTestValue("identity2", "value2")))
+	snapshot, err := s.Snapshot(ctx, "repo1", "main", "t1")
+	testutil.MustDo(t, "snapshot", err)
+
+	// writes after the snapshot are not listed from it
+	testutil.Must(t, s.Set(ctx, "t1", []byte("a"), newTestValue("identity3", "value3")))
+	testutil.Must(t, s.Set(ctx, "t1", []byte("c"), newTestValue("identity4", "value4")))
+	testutil.Must(t, s.DropKey(ctx, "t1", []byte("b")))
+
+	it, err := s.ListSnapshot(ctx, "repo1", "main", snapshot, nil)
+	testutil.MustDo(t, "list snapshot", err)
+	defer it.Close()
+	var identities []string
+	for it.Next() {
+		identities = append(identities, string(it.Value().Identity))
+	}
+	testutil.MustDo(t, "iterate snapshot", it.Err())
+	if len(identities) != 2 || identities[0] != "identity1" || identities[1] != "identity2" {
+		t.Errorf("snapshot listed %v, expected [identity1 identity2]", identities)
+	}
+
+	if _, err := s.ListSnapshot(ctx, "repo1", "main", "t1", nil); !errors.Is(err, graveler.ErrSnapshotExpired) {
+		t.Errorf("ListSnapshot() of a staging token err=%v, expected %s", err, graveler.ErrSnapshotExpired)
+	}
+	// a snapshot is only listed for the branch it was taken of
+	if _, err := s.ListSnapshot(ctx, "repo2", "main", snapshot, nil); !errors.Is(err, graveler.ErrSnapshotExpired) {
+		t.Errorf("ListSnapshot() for another repository err=%v, expected %s", err, graveler.ErrSnapshotExpired)
+	}
+	if _, err := s.ListSnapshot(ctx, "repo1", "dev", snapshot, nil); !errors.Is(err, graveler.ErrSnapshotExpired) {
+		t.Errorf("ListSnapshot() for another branch err=%v, expected %s", err, graveler.ErrSnapshotExpired)
+	}
+}
+
+func TestDropExpiredSnapshots(t *testing.T) {
+	conn, _ := testutil.GetDB(t, databaseURI)
+	ctx := context.Background()
+	s := staging.NewManager(conn, staging.SpillParams{})
+	testutil.Must(t, s.Set(ctx, "t1", []byte("a"), newTestValue("identity1", "value1")))
+	expired, err := s.Snapshot(ctx, "repo1", "main", "t1")
+	testutil.MustDo(t, "snapshot", err)
+	live, err := s.Snapshot(ctx, "repo1", "main", "t1")
+	testutil.MustDo(t, "snapshot", err)
+	_, err = conn.Exec("UPDATE graveler_staging_snapshots SET creation_date=$2 WHERE staging_token=$1",
+		expired, time.Now().Add(-2*staging.SnapshotTTL))
+	testutil.MustDo(t, "expire snapshot", err)
+
+	dropped, err := s.DropExpiredSnapshots(ctx)
+	testutil.MustDo(t, "drop expired snapshots", err)
+	if dropped != 1 {
+		t.Errorf("DropExpiredSnapshots() dropped %d, expected 1", dropped)
+	}
+	if _, err := s.ListSnapshot(ctx, "repo1", "main", expired, nil); !errors.Is(err, graveler.ErrSnapshotExpired) {
+		t.Errorf("ListSnapshot() of a dropped snapshot err=%v, expected %s", err, graveler.ErrSnapshotExpired)
+	}
+	it, err := s.ListSnapshot(ctx, "repo1", "main", live, nil)
+	testutil.MustDo(t, "list live snapshot", err)
+	it.Close()
+}
+
+func newTestSpillRangeManager(t *testing.T) staging.SpillRangeManager {
+	t.Helper()
+	fs, err := pyramid.NewFS(&params.InstanceParams{
+		FSName:              "range",
+		DiskAllocProportion: 1,
+		SharedParams: params.SharedParams{
+			Adapter:            mem.New(),
+			Logger:             logging.Dummy(),
+			BlockStoragePrefix: "_lakefs",
+			Local: params.LocalDiskParams{
+				BaseDir:             t.TempDir(),
+				TotalAllocatedBytes: 1 << 20,
+			},
+		},
+	})
+	testutil.MustDo(t, "create FS", err)
+	cache := pebble.NewCache(0)
+	t.Cleanup(cache.Unref)
+	return sstable.NewPebbleSSTableRangeManager(cache, fs, crypto.SHA256)
+}
+
+func TestSpillDropAndRemoveChunks(t *testing.T) {
+	conn, _ := testutil.GetDB(t, databaseURI)
+	ctx := context.Background()
+	const storageNamespace = "mem://spill"
+	rangeManager := newTestSpillRangeManager(t)
+	s := staging.NewManager(conn, staging.SpillParams{
+		Threshold: 1000,
+		StorageNamespace: func(context.Context, graveler.StagingToken) (graveler.StorageNamespace, error) {
+			return storageNamespace, nil
+		},
+		RangeManager: rangeManager,
+	})
+	for _, key := range []string{"a", "b", "c"} {
+		testutil.Must(t, s.Set(ctx, "t1", []byte(key), newTestValue("identity-"+key, "value-"+key)))
+	}
+	testutil.MustDo(t, "spill", s.Spill(ctx, "t1"))
+	var rangeID string
+	_, err := conn.Transact(func(tx db.Tx) (interface{}, error) {
+		return nil, tx.Get(&rangeID, "SELECT range_id FROM graveler_staging_spill_chunks WHERE storage_namespace=$1", storageNamespace)
+	})
+	testutil.MustDo(t, "get spilled chunk", err)
+
+	snapshot, err := s.Snapshot(ctx, "repo1", "main", "t1")
+	testutil.MustDo(t, "snapshot", err)
+	testutil.Must(t, s.DropKey(ctx, "t1", []byte("b")))
+	if _, err := s.Get(ctx, "t1", []byte("b")); !errors.Is(err, graveler.ErrNotFound) {
+		t.Errorf("Get() of a dropped spilled key err=%v, expected %s", err, graveler.ErrNotFound)
+	}
+	listKeys := func(it graveler.ValueIterator, err error) string {
+		t.Helper()
+		testutil.MustDo(t, "list", err)
+		defer it.Close()
+		var keys []string
+		for it.Next() {
+			keys = append(keys, string(it.Value().Key))
+		}
+		testutil.MustDo(t, "iterate", it.Err())
+		return fmt.Sprint(keys)
+	}
+	if keys := listKeys(s.List(ctx, "t1")); keys != "[a c]" {
+		t.Errorf("List() after dropping b got %s, expected [a c]", keys)
+	}
+	// the snapshot shares the chunk and is not changed by the drop
+	if keys := listKeys(s.ListSnapshot(ctx, "repo1", "main", snapshot, nil)); keys != "[a b c]" {
+		t.Errorf("ListSnapshot() got %s, expected [a b c]", keys)
+	}
+
+	testutil.Must(t, s.Drop(ctx, "t1"))
+	_, err = s.DropExpiredSnapshots(ctx)
+	testutil.MustDo(t, "drop expired snapshots", err)
+	if exists, err := rangeManager.Exists(ctx, storageNamespace, committed.ID(rangeID)); err != nil || !exists {
+		t.Errorf("chunk shared with a snapshot exists=%t err=%v, expected to exist", exists, err)
+	}
+
+	_, err = conn.Exec("UPDATE graveler_staging_snapshots SET creation_date=$2 WHERE staging_token=$1",
+		snapshot, time.Now().Add(-2*staging.SnapshotTTL))
+	testutil.MustDo(t, "expire snapshot", err)
+	_, err = s.DropExpiredSnapshots(ctx)
+	testutil.MustDo(t, "drop expired snapshots", err)
+	if exists, err := rangeManager.Exists(ctx, storageNamespace, committed.ID(rangeID)); err != nil || exists {
+		t.Errorf("unreferenced chunk exists=%t err=%v, expected to be removed", exists, err)
+	}
+}
+
+// closeHookRangeManager runs beforeClose before closing the chunks it writes
+type closeHookRangeManager struct {
+	staging.SpillRangeManager
+	beforeClose func()
+}
+
+func (m *closeHookRangeManager) GetWriter(ctx context.Context, ns committed.Namespace, metadata graveler.Metadata) (committed.RangeWriter, error) {
+	writer, err := m.SpillRangeManager.GetWriter(ctx, ns, metadata)
+	if err != nil {
+		return nil, err
+	}
+	return &closeHookRangeWriter{RangeWriter: writer, beforeClose: m.beforeClose}, nil
+}
+
+type closeHookRangeWriter struct {
+	committed.RangeWriter
+	beforeClose func()
+}
+
+func (w *closeHookRangeWriter) Close() (*committed.WriteResult, error) {
+	w.beforeClose()
+	return w.RangeWriter.Close()
+}
+
+func TestSpillConcurrentChanges(t *testing.T) {
+	conn, _ := testutil.GetDB(t, databaseURI)
+	ctx := context.Background()
+	rangeManager := &closeHookRangeManager{SpillRangeManager: newTestSpillRangeManager(t)}
+	s := staging.NewManager(conn, staging.SpillParams{
+		Threshold: 1000,
+		StorageNamespace: func(context.Context, graveler.StagingToken) (graveler.StorageNamespace, error) {
+			return "mem://spill", nil
+		},
+		RangeManager: rangeManager,
+	})
+	for _, st := range []graveler.StagingToken{"t1", "t2"} {
+		for _, key := range []string{"a", "b", "c"} {
+			testutil.Must(t, s.Set(ctx, st, []byte(key), newTestValue("identity-"+key, "value-"+key)))
+		}
+	}
+
+	// a key staged over while the chunk is written keeps its new value
+	rangeManager.beforeClose = func() {
+		testutil.Must(t, s.Set(ctx, "t1", []byte("b"), newTestValue("identity-b2", "value-b2")))
+	}
+	testutil.MustDo(t, "spill", s.Spill(ctx, "t1"))
+	for key, expected := range map[string]string{"a": "value-a", "b": "value-b2", "c": "value-c"} {
+		value, err := s.Get(ctx, "t1", []byte(key))
+		testutil.MustDo(t, "get "+key, err)
+		if string(value.Data) != expected {
+			t.Errorf("Get(%s) after spill got %s, expected %s", key, value.Data, expected)
+		}
+	}
+	var staged int
+	_, err := conn.Transact(func(tx db.Tx) (interface{}, error) {
+		return nil, tx.Get(&staged, "SELECT count(*) FROM graveler_staging_kv WHERE staging_token=$1", "t1")
+	})
+	testutil.MustDo(t, "count staged rows", err)
+	if staged != 1 {
+		t.Errorf("staged rows after spill got %d, expected 1", staged)
+	}
+
+	// a key dropped while the chunk is written fails the spill rather than come back
+	rangeManager.beforeClose = func() {
+		testutil.Must(t, s.DropKey(ctx, "t2", []byte("b")))
+	}
+	if err := s.Spill(ctx, "t2"); !errors.Is(err, staging.ErrSpillKeysDropped) {
+		t.Fatalf("Spill() with a dropped key err=%v, expected %s", err, staging.ErrSpillKeysDropped)
+	}
+	if _, err := s.Get(ctx, "t2", []byte("b")); !errors.Is(err, graveler.ErrNotFound) {
+		t.Errorf("Get() of a key dropped while spilling err=%v, expected %s", err, graveler.ErrNotFound)
+	}
+	if _, err := s.Get(ctx, "t2", []byte("a")); err != nil {
+		t.Errorf("Get() after failed spill: %s", err)
+	}
+}
@@ -0,0 +1,104 @@
+package staging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+// SnapshotTTL is the time a snapshot of a staging area can be listed after it is taken
+const SnapshotTTL = time.Hour
+
+// Snapshot copies the values staged on st, the staging area of branchID, under a new staging
+// token, which is not changed by later writes to st.  Spilled chunks are shared with st, they are
+// never modified in place.  Snapshots older than SnapshotTTL are dropped by DropExpiredSnapshots.
+func (p *Manager) Snapshot(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, st graveler.StagingToken) (graveler.StagingToken, error) {
+	snapshot := graveler.StagingToken(fmt.Sprintf("%s@%s", st, uuid.New().String()))
+	_, err := p.db.Transact(func(tx db.Tx) (interface{}, error) {
+		if _, err := tx.Exec("INSERT INTO graveler_staging_snapshots (staging_token, repository_id, branch_id) VALUES ($1, $2, $3)",
+			snapshot, repositoryID, branchID); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`INSERT INTO graveler_staging_kv (staging_token, key, identity, data)
+			SELECT $2, key, identity, data FROM graveler_staging_kv WHERE staging_token=$1`, st, snapshot); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`INSERT INTO graveler_staging_spills (staging_token, seq, storage_namespace, range_id, min_key, max_key, count)
+			SELECT $2, seq, storage_namespace, range_id, min_key, max_key, count FROM graveler_staging_spills WHERE staging_token=$1`, st, snapshot); err != nil {
+			return nil, err
+		}
+		return tx.Exec(`INSERT INTO graveler_staging_spill_drops (staging_token, seq, from_key, to_key)
+			SELECT $2, seq, from_key, to_key FROM graveler_staging_spill_drops WHERE staging_token=$1`, st, snapshot)
+	}, p.txOpts(ctx)...)
+	if err != nil {
+		return "", err
+	}
+	return snapshot, nil
+}
+
+// ListSnapshot lists the values of snapshot of branchID from key from.  It returns
+// graveler.ErrSnapshotExpired if snapshot was dropped or was taken of another branch, a snapshot
+// is never listed for a branch it was not taken of.
+func (p *Manager) ListSnapshot(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, snapshot graveler.StagingToken, from graveler.Key) (graveler.ValueIterator, error) {
+	_, err := p.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var creationDate time.Time
+		return nil, tx.Get(&creationDate, `SELECT creation_date FROM graveler_staging_snapshots
+			WHERE staging_token=$1 AND repository_id=$2 AND branch_id=$3 AND creation_date >= $4`,
+			snapshot, repositoryID, branchID, time.Now().Add(-SnapshotTTL))
+	}, p.txOpts(ctx, db.ReadOnly())...)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, graveler.ErrSnapshotExpired
+	}
+	if err != nil {
+		return nil, err
+	}
+	it, err := p.List(ctx, snapshot)
+	if err != nil {
+		return nil, err
+	}
+	it.SeekGE(from)
+	return it, nil
+}
+
+// DropExpiredSnapshots drops the snapshots older than SnapshotTTL and returns the number dropped.
+// It then removes the spilled chunks that neither a staging area nor a snapshot references.
+func (p *Manager) DropExpiredSnapshots(ctx context.Context) (int, error) {
+	res, err := p.db.Transact(func(tx db.Tx) (interface{}, error) {
+		return dropExpiredSnapshots(tx)
+	}, p.txOpts(ctx)...)
+	if err != nil {
+		return 0, err
+	}
+	removed, err := p.removeUnreferencedSpills(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("remove unreferenced spills: %w", err)
+	}
+	if removed > 0 {
+		p.log.WithField("removed", removed).Info("Removed unreferenced spilled chunks")
+	}
+	return res.(int), nil
+}
+
+func dropExpiredSnapshots(tx db.Tx) (int, error) {
+	const expired = "SELECT staging_token FROM graveler_staging_snapshots WHERE creation_date < $1"
+	before := time.Now().Add(-SnapshotTTL)
+	if _, err := tx.Exec("DELETE FROM graveler_staging_kv WHERE staging_token IN ("+expired+")", before); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("DELETE FROM graveler_staging_spills WHERE staging_token IN ("+expired+")", before); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("DELETE FROM graveler_staging_spill_drops WHERE staging_token IN ("+expired+")", before); err != nil {
+		return 0, err
+	}
+	res, err := tx.Exec("DELETE FROM graveler_staging_snapshots WHERE creation_date < $1", before)
+	if err != nil {
+		return 0, err
+	}
+	return int(res.RowsAffected()), nil
+}
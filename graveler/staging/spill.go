@@ -0,0 +1,620 @@
+package staging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/graveler/committed"
+	"github.com/treeverse/lakefs/logging"
+)
+
+// spillChecksPerThreshold is the number of times the size of a staging area is checked while
+// it grows by the spill threshold
+const spillChecksPerThreshold = 10
+
+// spillIDMetadataKey is the metadata of a chunk making its range ID unique, so chunks are
+// never shared with committed ranges or with other spills
+const spillIDMetadataKey = "staging_spill_id"
+
+var (
+	ErrSpillDisabled    = errors.New("staging spill disabled")
+	ErrSpillKeysDropped = errors.New("staged keys dropped while spilling")
+)
+
+// SpillParams configure spilling of large staging areas to object storage.  Once a staging
+// area holds Threshold entries in the database they are moved into a sorted chunk in the
+// storage namespace of its repository.  Chunks are merged with the entries remaining in the
+// database on read.
+type SpillParams struct {
+	// Threshold is the number of entries of a staging area in the database that triggers a
+	// spill.  Spilling is disabled when 0.
+	Threshold int
+	// StorageNamespace returns the storage namespace of the repository of a staging token
+	StorageNamespace func(ctx context.Context, st graveler.StagingToken) (graveler.StorageNamespace, error)
+	// RangeManager writes, reads and removes spilled chunks
+	RangeManager SpillRangeManager
+}
+
+// SpillRangeManager is a committed.RangeManager that also removes the chunks it wrote
+type SpillRangeManager interface {
+	committed.RangeManager
+	// Remove deletes the Range referenced by id
+	Remove(ctx context.Context, ns committed.Namespace, id committed.ID) error
+}
+
+func (p SpillParams) enabled() bool {
+	return p.Threshold > 0 && p.StorageNamespace != nil && p.RangeManager != nil
+}
+
+// spill is a sorted chunk of a staging area on object storage
+type spill struct {
+	Seq              int64               `db:"seq"`
+	StorageNamespace committed.Namespace `db:"storage_namespace"`
+	RangeID          string              `db:"range_id"`
+	MinKey           graveler.Key        `db:"min_key"`
+	MaxKey           graveler.Key        `db:"max_key"`
+	Count            int64               `db:"count"`
+}
+
+// spillDrop is a range of keys [FromKey, ToKey) dropped from the chunks of a staging area
+// spilled before it.  A nil ToKey leaves the range unbounded.
+type spillDrop struct {
+	Seq     int64        `db:"seq"`
+	FromKey graveler.Key `db:"from_key"`
+	ToKey   graveler.Key `db:"to_key"`
+}
+
+// spillChunk is a chunk written to object storage, shared by a staging area and its snapshots
+type spillChunk struct {
+	StorageNamespace committed.Namespace `db:"storage_namespace"`
+	RangeID          string              `db:"range_id"`
+}
+
+// spillCounter counts the Sets of each staging token since its size was last checked
+type spillCounter struct {
+	counts sync.Map
+}
+
+// add counts a Set to st and returns true when it is time to check the size of st
+func (c *spillCounter) add(st graveler.StagingToken, every int64) bool {
+	count, _ := c.counts.LoadOrStore(st, new(int64))
+	if atomic.AddInt64(count.(*int64), 1) < every {
+		return false
+	}
+	atomic.StoreInt64(count.(*int64), 0)
+	return true
+}
+
+func (c *spillCounter) forget(st graveler.StagingToken) {
+	c.counts.Delete(st)
+}
+
+// maybeSpill spills st in the background if it holds at least the spill threshold of entries
+// in the database.  It only queries the database once every few calls, and runs a single spill
+// of st at a time.
+func (p *Manager) maybeSpill(st graveler.StagingToken) {
+	if !p.spill.enabled() {
+		return
+	}
+	every := int64(p.spill.Threshold / spillChecksPerThreshold)
+	if every < 1 {
+		every = 1
+	}
+	if !p.spillCounter.add(st, every) {
+		return
+	}
+	if _, running := p.spilling.LoadOrStore(st, struct{}{}); running {
+		return
+	}
+	go func() {
+		defer p.spilling.Delete(st)
+		// the Set that triggered the spill already returned
+		ctx := context.Background()
+		log := p.log.WithField("staging_token", st)
+		res, err := p.db.Transact(func(tx db.Tx) (interface{}, error) {
+			var count int
+			err := tx.Get(&count, `SELECT count(*) FROM (
+					SELECT 1 FROM graveler_staging_kv WHERE staging_token=$1 LIMIT $2) AS entries`,
+				st, p.spill.Threshold)
+			return count, err
+		}, p.txOpts(ctx, db.ReadOnly())...)
+		if err != nil {
+			log.WithError(err).Error("Failed to count staging entries")
+			return
+		}
+		if res.(int) < p.spill.Threshold {
+			return
+		}
+		// the values are already staged, failing to spill them only leaves them in the database
+		if err := p.Spill(ctx, st); errors.Is(err, graveler.ErrBranchNotFound) {
+			log.WithError(err).Debug("Skipped spilling staging area of no branch")
+		} else if errors.Is(err, ErrSpillKeysDropped) {
+			// spilled again on a later check
+			log.WithError(err).Debug("Skipped spilling staging area changed meanwhile")
+		} else if err != nil {
+			log.WithError(err).Error("Failed to spill staging area")
+		}
+	}()
+}
+
+// Spill moves all entries of st in the database to a new sorted chunk in the storage namespace
+// of its repository.  The chunk is written without locking the entries, entries staged over
+// meanwhile stay in the database and hide the values spilled for them.
+func (p *Manager) Spill(ctx context.Context, st graveler.StagingToken) error {
+	if !p.spill.enabled() {
+		return fmt.Errorf("spill staging token %s: %w", st, ErrSpillDisabled)
+	}
+	storageNamespace, err := p.spill.StorageNamespace(ctx, st)
+	if err != nil {
+		return fmt.Errorf("storage namespace of staging token %s: %w", st, err)
+	}
+	ns := committed.Namespace(storageNamespace)
+	written, keys, err := p.writeSpill(ctx, st, ns)
+	if err != nil || written == nil {
+		return err
+	}
+	_, err = p.db.Transact(func(tx db.Tx) (interface{}, error) {
+		if err := lockSpills(tx, st); err != nil {
+			return nil, err
+		}
+		for i := 0; i < len(keys); i += batchSize {
+			j := i + batchSize
+			if j > len(keys) {
+				j = len(keys)
+			}
+			if err := deleteSpilledRows(tx, st, keys[i:j]); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := tx.Exec("INSERT INTO graveler_staging_spill_chunks (storage_namespace, range_id) VALUES ($1, $2)",
+			ns, written.RangeID); err != nil {
+			return nil, err
+		}
+		return tx.Exec(`INSERT INTO graveler_staging_spills (staging_token, storage_namespace, range_id, min_key, max_key, count)
+								VALUES ($1, $2, $3, $4, $5, $6)`,
+			st, ns, written.RangeID, []byte(written.First), []byte(written.Last), written.Count)
+	}, p.txOpts(ctx)...)
+	if err != nil {
+		// no row references the chunk
+		if err := p.spill.RangeManager.Remove(ctx, ns, written.RangeID); err != nil {
+			p.log.WithError(err).WithField("range_id", written.RangeID).Error("Failed to remove chunk of failed spill")
+		}
+	}
+	return err
+}
+
+// spilledKey is a key written to a chunk with the identity of its value
+type spilledKey struct {
+	key      graveler.Key
+	identity []byte
+}
+
+// writeSpill writes the entries of st in the database to a new chunk and returns it with the
+// keys written, or a nil chunk if st has no entries in the database.  Entries are read in
+// short transactions, Sets are not blocked while the chunk is written.
+func (p *Manager) writeSpill(ctx context.Context, st graveler.StagingToken, ns committed.Namespace) (*committed.WriteResult, []spilledKey, error) {
+	writer, err := p.spill.RangeManager.GetWriter(ctx, ns, graveler.Metadata{spillIDMetadataKey: uuid.New().String()})
+	if err != nil {
+		return nil, nil, fmt.Errorf("get spill writer: %w", err)
+	}
+	closed := false
+	defer func() {
+		if closed {
+			return
+		}
+		if err := writer.Abort(); err != nil {
+			p.log.WithError(err).Error("Abort failed after spilling staging area")
+		}
+	}()
+	query := "SELECT key, identity, data FROM graveler_staging_kv WHERE staging_token=$1 AND key >= $2 ORDER BY key LIMIT $3"
+	from := graveler.Key{}
+	var keys []spilledKey
+	for {
+		res, err := p.db.Transact(func(tx db.Tx) (interface{}, error) {
+			var records []*graveler.ValueRecord
+			err := tx.Select(&records, query, st, from, batchSize)
+			return records, err
+		}, p.txOpts(ctx, db.ReadOnly())...)
+		if err != nil {
+			return nil, nil, err
+		}
+		records := res.([]*graveler.ValueRecord)
+		if len(records) == 0 {
+			break
+		}
+		for _, record := range records {
+			value := spilledValue(record.Value)
+			if err := writer.WriteRecord(committed.Record{
+				Key:   committed.Key(record.Key),
+				Value: committed.MustMarshalValue(value),
+			}); err != nil {
+				return nil, nil, fmt.Errorf("write spilled record: %w", err)
+			}
+			keys = append(keys, spilledKey{key: record.Key, identity: value.Identity})
+		}
+		from = records[len(records)-1].Key
+		query = "SELECT key, identity, data FROM graveler_staging_kv WHERE staging_token=$1 AND key > $2 ORDER BY key LIMIT $3"
+	}
+	if len(keys) == 0 {
+		return nil, nil, nil
+	}
+	closed = true
+	written, err := writer.Close()
+	if err != nil {
+		return nil, nil, fmt.Errorf("close spill writer: %w", err)
+	}
+	return written, keys, nil
+}
+
+// deleteSpilledRows deletes the rows of keys spilled to a chunk, unless they were staged over
+// since.  It fails if any of them was dropped since: the chunk would bring it back.
+func deleteSpilledRows(tx db.Tx, st graveler.StagingToken, keys []spilledKey) error {
+	rawKeys := make([][]byte, len(keys))
+	for i, k := range keys {
+		rawKeys[i] = k.key
+	}
+	var rows []*graveler.ValueRecord
+	if err := tx.Select(&rows, "SELECT key, identity FROM graveler_staging_kv WHERE staging_token=$1 AND key = ANY($2) FOR UPDATE", st, rawKeys); err != nil {
+		return err
+	}
+	if len(rows) < len(keys) {
+		return fmt.Errorf("spill staging token %s: %w", st, ErrSpillKeysDropped)
+	}
+	identities := make(map[string][]byte, len(rows))
+	for _, row := range rows {
+		identities[string(row.Key)] = row.Identity
+	}
+	unchanged := make([][]byte, 0, len(keys))
+	for _, k := range keys {
+		if bytes.Equal(identities[string(k.key)], k.identity) {
+			unchanged = append(unchanged, k.key)
+		}
+	}
+	_, err := tx.Exec("DELETE FROM graveler_staging_kv WHERE staging_token=$1 AND key = ANY($2)", st, unchanged)
+	return err
+}
+
+// lockSpills serializes adding a chunk to st with dropping keys or all of st, so a chunk is
+// never added after a drop it misses
+func lockSpills(tx db.Tx, st graveler.StagingToken) error {
+	_, err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext($1))", "staging_spill:"+string(st))
+	return err
+}
+
+// spilledValue returns the value stored in a chunk for value.  Chunks hold tombstones as values
+// with an empty identity.
+func spilledValue(value *graveler.Value) *graveler.Value {
+	if value == nil || value.Identity == nil {
+		return &graveler.Value{}
+	}
+	return value
+}
+
+// listSpills returns the chunks of st holding keys in [from, to], newest first.  A nil to
+// leaves the range unbounded.
+func (p *Manager) listSpills(ctx context.Context, tx db.Tx, st graveler.StagingToken, from, to graveler.Key) ([]*spill, error) {
+	builder := sq.Select("seq", "storage_namespace", "range_id", "min_key", "max_key", "count").
+		From("graveler_staging_spills").
+		Where(sq.Eq{"staging_token": st}).
+		Where("max_key >= ?::bytea", from).
+		OrderBy("seq DESC")
+	if to != nil {
+		builder = builder.Where("min_key <= ?::bytea", to)
+	}
+	query, args, err := builder.PlaceholderFormat(sq.Dollar).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	var spills []*spill
+	if err := tx.Select(&spills, query, args...); err != nil {
+		return nil, fmt.Errorf("list spills of staging token %s: %w", st, err)
+	}
+	return spills, nil
+}
+
+// getSpilled returns the value of key in the newest chunk of st holding it, unless key was
+// dropped after that chunk was spilled
+func (p *Manager) getSpilled(ctx context.Context, st graveler.StagingToken, key graveler.Key) (*graveler.Value, error) {
+	var droppedSeq int64
+	res, err := p.db.Transact(func(tx db.Tx) (interface{}, error) {
+		err := tx.Get(&droppedSeq, `SELECT COALESCE(MAX(seq), 0) FROM graveler_staging_spill_drops
+			WHERE staging_token=$1 AND from_key <= $2 AND (to_key IS NULL OR to_key > $2)`, st, key)
+		if err != nil {
+			return nil, err
+		}
+		return p.listSpills(ctx, tx, st, key, key)
+	}, p.txOpts(ctx, db.ReadOnly())...)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range res.([]*spill) {
+		if s.Seq < droppedSeq {
+			break
+		}
+		value, err := p.getFromSpill(ctx, s, key)
+		if errors.Is(err, graveler.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get from spill %s: %w", s.RangeID, err)
+		}
+		if value == nil || len(value.Identity) == 0 {
+			// tombstone
+			return nil, nil
+		}
+		return value, nil
+	}
+	return nil, graveler.ErrNotFound
+}
+
+func (p *Manager) getFromSpill(ctx context.Context, s *spill, key graveler.Key) (*graveler.Value, error) {
+	rangeIt, err := p.spill.RangeManager.NewRangeIterator(ctx, s.StorageNamespace, committed.ID(s.RangeID))
+	if err != nil {
+		return nil, err
+	}
+	it := committed.NewUnmarshalIterator(rangeIt)
+	defer it.Close()
+	it.SeekGE(key)
+	if !it.Next() {
+		if err := it.Err(); err != nil {
+			return nil, err
+		}
+		return nil, graveler.ErrNotFound
+	}
+	record := it.Value()
+	if !bytes.Equal(record.Key, key) {
+		return nil, graveler.ErrNotFound
+	}
+	return record.Value, nil
+}
+
+// listWithSpills returns an iterator over the entries of st in the database merged with its
+// spilled chunks
+func (p *Manager) listWithSpills(ctx context.Context, st graveler.StagingToken) (graveler.ValueIterator, error) {
+	var drops []*spillDrop
+	res, err := p.db.Transact(func(tx db.Tx) (interface{}, error) {
+		if err := tx.Select(&drops, "SELECT seq, from_key, to_key FROM graveler_staging_spill_drops WHERE staging_token=$1", st); err != nil {
+			return nil, err
+		}
+		return p.listSpills(ctx, tx, st, graveler.Key{}, nil)
+	}, p.txOpts(ctx, db.ReadOnly())...)
+	if err != nil {
+		return nil, err
+	}
+	spills := res.([]*spill)
+	layers := []graveler.ValueIterator{NewStagingIterator(ctx, p.db, p.log, st)}
+	for _, s := range spills {
+		it, err := p.spill.RangeManager.NewRangeIterator(ctx, s.StorageNamespace, committed.ID(s.RangeID))
+		if err != nil {
+			for _, layer := range layers {
+				layer.Close()
+			}
+			return nil, fmt.Errorf("iterate spill %s: %w", s.RangeID, err)
+		}
+		var layer graveler.ValueIterator = &spilledIterator{UnmarshalIterator: committed.NewUnmarshalIterator(it)}
+		if dropped := droppedRanges(drops, s.Seq); len(dropped) > 0 {
+			layer = &droppedKeysIterator{ValueIterator: layer, dropped: dropped}
+		}
+		layers = append(layers, layer)
+	}
+	if len(layers) == 1 {
+		return layers[0], nil
+	}
+	return newLayeredIterator(layers), nil
+}
+
+// dropSpilled hides the keys in [from, to) in the chunks of st spilled so far.  A nil to leaves
+// the range unbounded.  Chunks are shared with snapshots of st, so they are never rewritten.
+// Callers hold lockSpills of st.
+func (p *Manager) dropSpilled(ctx context.Context, tx db.Tx, st graveler.StagingToken, from, to graveler.Key) error {
+	spills, err := p.listSpills(ctx, tx, st, from, to)
+	if err != nil {
+		return err
+	}
+	if len(spills) == 0 {
+		return nil
+	}
+	_, err = tx.Exec("INSERT INTO graveler_staging_spill_drops (staging_token, from_key, to_key) VALUES ($1, $2, $3)",
+		st, []byte(from), []byte(to))
+	return err
+}
+
+// removeUnreferencedSpills removes the chunks no staging area or snapshot references any more
+// and returns the number removed
+func (p *Manager) removeUnreferencedSpills(ctx context.Context) (int, error) {
+	if p.spill.RangeManager == nil {
+		return 0, nil
+	}
+	// a chunk referenced concurrently (e.g. by a new snapshot) fails the delete on its foreign
+	// key, it is retried on the next call
+	res, err := p.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var chunks []*spillChunk
+		err := tx.Select(&chunks, `DELETE FROM graveler_staging_spill_chunks c
+			WHERE NOT EXISTS (SELECT 1 FROM graveler_staging_spills s
+				WHERE s.storage_namespace = c.storage_namespace AND s.range_id = c.range_id)
+			RETURNING storage_namespace, range_id`)
+		return chunks, err
+	}, p.txOpts(ctx)...)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, chunk := range res.([]*spillChunk) {
+		if chunk.StorageNamespace == "" {
+			// spilled before the storage namespace of chunks was recorded
+			continue
+		}
+		if err := p.spill.RangeManager.Remove(ctx, chunk.StorageNamespace, committed.ID(chunk.RangeID)); err != nil {
+			p.log.WithError(err).WithFields(logging.Fields{
+				"storage_namespace": chunk.StorageNamespace,
+				"range_id":          chunk.RangeID,
+			}).Error("Failed to remove unreferenced spilled chunk")
+			continue
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// keyRange is a range of keys [from, to), a nil to leaves it unbounded
+type keyRange struct {
+	from graveler.Key
+	to   graveler.Key
+}
+
+// droppedRanges returns the disjoint ranges of the drops after the chunk spilled at seq, sorted
+func droppedRanges(drops []*spillDrop, seq int64) []keyRange {
+	var ranges []keyRange
+	for _, drop := range drops {
+		if drop.Seq > seq {
+			ranges = append(ranges, keyRange{from: drop.FromKey, to: drop.ToKey})
+		}
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return bytes.Compare(ranges[i].from, ranges[j].from) < 0
+	})
+	merged := ranges[:0]
+	for _, r := range ranges {
+		if len(merged) > 0 {
+			last := &merged[len(merged)-1]
+			if last.to == nil || bytes.Compare(r.from, last.to) <= 0 {
+				if last.to != nil && (r.to == nil || bytes.Compare(r.to, last.to) > 0) {
+					last.to = r.to
+				}
+				continue
+			}
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// droppedKeysIterator skips the keys of a chunk dropped after it was spilled
+type droppedKeysIterator struct {
+	graveler.ValueIterator
+	dropped []keyRange
+}
+
+func (it *droppedKeysIterator) Next() bool {
+	for it.ValueIterator.Next() {
+		if !it.isDropped(it.ValueIterator.Value().Key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (it *droppedKeysIterator) isDropped(key graveler.Key) bool {
+	i := sort.Search(len(it.dropped), func(i int) bool {
+		return bytes.Compare(it.dropped[i].from, key) > 0
+	}) - 1
+	return i >= 0 && (it.dropped[i].to == nil || bytes.Compare(key, it.dropped[i].to) < 0)
+}
+
+// spilledIterator iterates over a spilled chunk, translating its tombstones
+type spilledIterator struct {
+	*committed.UnmarshalIterator
+}
+
+func (it *spilledIterator) Value() *graveler.ValueRecord {
+	record := it.UnmarshalIterator.Value()
+	if record == nil || record.Value == nil || len(record.Identity) > 0 {
+		return record
+	}
+	return &graveler.ValueRecord{Key: record.Key}
+}
+
+// layeredIterator merges iterators over layers of a staging area.  Keys found in several layers
+// take their value from the first of them.  Unlike graveler.CombinedIterator it returns
+// tombstones.
+type layeredIterator struct {
+	layers  []graveler.ValueIterator
+	has     []bool
+	advance []bool
+	value   *graveler.ValueRecord
+	err     error
+}
+
+func newLayeredIterator(layers []graveler.ValueIterator) *layeredIterator {
+	it := &layeredIterator{
+		layers:  layers,
+		has:     make([]bool, len(layers)),
+		advance: make([]bool, len(layers)),
+	}
+	it.reset()
+	return it
+}
+
+func (it *layeredIterator) reset() {
+	for i := range it.advance {
+		it.advance[i] = true
+	}
+	it.value = nil
+}
+
+func (it *layeredIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	for i, layer := range it.layers {
+		if !it.advance[i] {
+			continue
+		}
+		it.has[i] = layer.Next()
+		it.advance[i] = false
+		if err := layer.Err(); err != nil {
+			it.err = err
+			it.value = nil
+			return false
+		}
+	}
+	it.value = nil
+	for i, layer := range it.layers {
+		if !it.has[i] {
+			continue
+		}
+		if v := layer.Value(); it.value == nil || bytes.Compare(v.Key, it.value.Key) < 0 {
+			it.value = v
+		}
+	}
+	if it.value == nil {
+		return false
+	}
+	for i, layer := range it.layers {
+		if it.has[i] && bytes.Equal(layer.Value().Key, it.value.Key) {
+			it.advance[i] = true
+		}
+	}
+	return true
+}
+
+func (it *layeredIterator) SeekGE(id graveler.Key) {
+	for _, layer := range it.layers {
+		layer.SeekGE(id)
+	}
+	it.err = nil
+	it.reset()
+}
+
+func (it *layeredIterator) Value() *graveler.ValueRecord {
+	return it.value
+}
+
+func (it *layeredIterator) Err() error {
+	return it.err
+}
+
+func (it *layeredIterator) Close() {
+	for _, layer := range it.layers {
+		layer.Close()
+	}
+}
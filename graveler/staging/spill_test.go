@@ -0,0 +1,56 @@
+package staging_test
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/graveler/staging"
+	"github.com/treeverse/lakefs/graveler/testutil"
+)
+
+func TestLayeredIterator(t *testing.T) {
+	layers := []graveler.ValueIterator{
+		testutil.NewValueIteratorFake([]graveler.ValueRecord{
+			{Key: graveler.Key("b"), Value: newTestValue("top:b", "")},
+			{Key: graveler.Key("d")},
+		}),
+		testutil.NewValueIteratorFake([]graveler.ValueRecord{
+			{Key: graveler.Key("a"), Value: newTestValue("middle:a", "")},
+			{Key: graveler.Key("b"), Value: newTestValue("middle:b", "")},
+			{Key: graveler.Key("c")},
+		}),
+		testutil.NewValueIteratorFake([]graveler.ValueRecord{
+			{Key: graveler.Key("c"), Value: newTestValue("bottom:c", "")},
+			{Key: graveler.Key("d"), Value: newTestValue("bottom:d", "")},
+			{Key: graveler.Key("e"), Value: newTestValue("bottom:e", "")},
+		}),
+	}
+	it := staging.NewLayeredIterator(layers)
+	defer it.Close()
+
+	expected := []string{"a=middle:a", "b=top:b", "c=<tombstone>", "d=<tombstone>", "e=bottom:e"}
+	var got []string
+	for it.Next() {
+		record := it.Value()
+		identity := "<tombstone>"
+		if record.Value != nil {
+			identity = string(record.Identity)
+		}
+		got = append(got, string(record.Key)+"="+identity)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("iterate: %s", err)
+	}
+	if diffs := deep.Equal(got, expected); diffs != nil {
+		t.Errorf("unexpected records: %s", diffs)
+	}
+
+	it.SeekGE(graveler.Key("c"))
+	if !it.Next() {
+		t.Fatalf("no record after SeekGE: %v", it.Err())
+	}
+	if key := string(it.Value().Key); key != "c" {
+		t.Errorf("got key %s after SeekGE(c), expected c", key)
+	}
+}
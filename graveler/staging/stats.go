@@ -0,0 +1,123 @@
+package staging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+// ValueSizeFunc returns the size counted in staging stats for value
+type ValueSizeFunc func(value *graveler.Value) int64
+
+// SetValueSizeFunc sets the size counted in staging stats for values.  Values count no bytes
+// until it is set.
+func (p *Manager) SetValueSizeFunc(f ValueSizeFunc) {
+	p.valueSize = f
+}
+
+func (p *Manager) Stats(ctx context.Context, st graveler.StagingToken) (*graveler.StagingStats, error) {
+	res, err := p.db.Transact(func(tx db.Tx) (interface{}, error) {
+		stats := &graveler.StagingStats{}
+		err := tx.Get(stats, "SELECT writes, deletes, size_bytes FROM graveler_staging_stats WHERE staging_token=$1", st)
+		if errors.Is(err, db.ErrNotFound) {
+			// nothing staged yet
+			return stats, nil
+		}
+		return stats, err
+	}, p.txOpts(ctx, db.ReadOnly())...)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*graveler.StagingStats), nil
+}
+
+// statsOf returns the stats of a single staged value, nil for a tombstone
+func (p *Manager) statsOf(value *graveler.Value) graveler.StagingStats {
+	if value == nil {
+		return graveler.StagingStats{Deletes: 1}
+	}
+	stats := graveler.StagingStats{Writes: 1}
+	if p.valueSize != nil {
+		stats.SizeBytes = p.valueSize(value)
+	}
+	return stats
+}
+
+func addStats(a, b graveler.StagingStats) graveler.StagingStats {
+	return graveler.StagingStats{
+		Writes:    a.Writes + b.Writes,
+		Deletes:   a.Deletes + b.Deletes,
+		SizeBytes: a.SizeBytes + b.SizeBytes,
+	}
+}
+
+func subStats(a, b graveler.StagingStats) graveler.StagingStats {
+	return addStats(a, graveler.StagingStats{
+		Writes:    -b.Writes,
+		Deletes:   -b.Deletes,
+		SizeBytes: -b.SizeBytes,
+	})
+}
+
+// updateStats adds delta to the stats of st
+func updateStats(tx db.Tx, st graveler.StagingToken, delta graveler.StagingStats) error {
+	if delta == (graveler.StagingStats{}) {
+		return nil
+	}
+	_, err := tx.Exec(`INSERT INTO graveler_staging_stats (staging_token, writes, deletes, size_bytes)
+							VALUES ($1, $2, $3, $4)
+							ON CONFLICT (staging_token) DO UPDATE
+								SET (writes, deletes, size_bytes) =
+										(graveler_staging_stats.writes + excluded.writes,
+										 graveler_staging_stats.deletes + excluded.deletes,
+										 graveler_staging_stats.size_bytes + excluded.size_bytes)`,
+		st, delta.Writes, delta.Deletes, delta.SizeBytes)
+	return err
+}
+
+// stagedValue returns the value staged as value, nil for a tombstone
+func stagedValue(value *graveler.Value) *graveler.Value {
+	if value == nil || value.Identity == nil {
+		return nil
+	}
+	return value
+}
+
+// lockStaged returns the value staged for key, locking its row in the database until tx ends.
+// It returns (nil, nil) for a staged tombstone and ErrNotFound if nothing is staged for key.
+func (p *Manager) lockStaged(ctx context.Context, tx db.Tx, st graveler.StagingToken, key graveler.Key) (*graveler.Value, error) {
+	value := &graveler.Value{}
+	err := tx.Get(value, "SELECT identity, data FROM graveler_staging_kv WHERE staging_token=$1 AND key=$2 FOR UPDATE", st, key)
+	if errors.Is(err, db.ErrNotFound) {
+		if p.spill.enabled() {
+			return p.getSpilled(ctx, st, key)
+		}
+		return nil, graveler.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return stagedValue(value), nil
+}
+
+// prefixStats returns the stats of the values staged for keys starting with prefix
+func (p *Manager) prefixStats(ctx context.Context, st graveler.StagingToken, prefix graveler.Key) (graveler.StagingStats, error) {
+	var stats graveler.StagingStats
+	it, err := p.List(ctx, st)
+	if err != nil {
+		return stats, err
+	}
+	defer it.Close()
+	it.SeekGE(prefix)
+	for it.Next() {
+		record := it.Value()
+		if !bytes.HasPrefix(record.Key, prefix) {
+			break
+		}
+		stats = addStats(stats, p.statsOf(record.Value))
+	}
+	return stats, it.Err()
+}
@@ -3,8 +3,10 @@ package testutil
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/treeverse/lakefs/graveler"
 	"github.com/treeverse/lakefs/graveler/committed"
@@ -25,6 +27,11 @@ type CommittedFake struct {
 	MetaRangeID   graveler.MetaRangeID
 	DiffSummary   graveler.DiffSummary
 	AppliedData   AppliedData
+	// ValuesByMetaRange, when set, holds the values List returns for each MetaRange
+	ValuesByMetaRange map[graveler.MetaRangeID][]graveler.ValueRecord
+	WrittenValues     []graveler.ValueRecord
+	// UpgradedMetaRanges, when set, maps MetaRanges to the IDs Upgrade returns for them
+	UpgradedMetaRanges map[graveler.MetaRangeID]graveler.MetaRangeID
 }
 
 type MetaRangeFake struct {
@@ -53,10 +60,13 @@ func (c *CommittedFake) Get(_ context.Context, _ graveler.StorageNamespace, _ gr
 	return c.ValuesByKey[string(key)], nil
 }
 
-func (c *CommittedFake) List(context.Context, graveler.StorageNamespace, graveler.MetaRangeID) (graveler.ValueIterator, error) {
+func (c *CommittedFake) List(_ context.Context, _ graveler.StorageNamespace, metaRangeID graveler.MetaRangeID) (graveler.ValueIterator, error) {
 	if c.Err != nil {
 		return nil, c.Err
 	}
+	if c.ValuesByMetaRange != nil {
+		return NewValueIteratorFake(c.ValuesByMetaRange[metaRangeID]), nil
+	}
 	return c.ValueIterator, nil
 }
 
@@ -90,10 +100,37 @@ func (c *CommittedFake) Apply(_ context.Context, _ graveler.StorageNamespace, me
 	return c.MetaRangeID, c.DiffSummary, nil
 }
 
+func (c *CommittedFake) DropPrefix(_ context.Context, _ graveler.StorageNamespace, _ graveler.MetaRangeID, _ graveler.Key) (graveler.MetaRangeID, graveler.DiffSummary, error) {
+	if c.Err != nil {
+		return "", graveler.DiffSummary{}, c.Err
+	}
+	return c.MetaRangeID, c.DiffSummary, nil
+}
+
+func (c *CommittedFake) ReplacePrefix(_ context.Context, _ graveler.StorageNamespace, _, _ graveler.MetaRangeID, _ graveler.Key) (graveler.MetaRangeID, graveler.DiffSummary, error) {
+	if c.Err != nil {
+		return "", graveler.DiffSummary{}, c.Err
+	}
+	return c.MetaRangeID, c.DiffSummary, nil
+}
+
+func (c *CommittedFake) Upgrade(_ context.Context, _ graveler.StorageNamespace, metaRangeID graveler.MetaRangeID) (graveler.MetaRangeID, error) {
+	if c.Err != nil {
+		return "", c.Err
+	}
+	if upgraded, ok := c.UpgradedMetaRanges[metaRangeID]; ok {
+		return upgraded, nil
+	}
+	return metaRangeID, nil
+}
+
 func (c *CommittedFake) WriteMetaRange(ctx context.Context, ns graveler.StorageNamespace, it graveler.ValueIterator, metadata graveler.Metadata) (*graveler.MetaRangeID, error) {
 	if c.Err != nil {
 		return nil, c.Err
 	}
+	for it.Next() {
+		c.WrittenValues = append(c.WrittenValues, *it.Value())
+	}
 	return &c.MetaRangeID, nil
 }
 
@@ -107,6 +144,7 @@ type StagingFake struct {
 	LastRemovedKey     graveler.Key
 	DropCalled         bool
 	SetErr             error
+	StagingStats       graveler.StagingStats
 }
 
 func (s *StagingFake) DropByPrefix(context.Context, graveler.StagingToken, graveler.Key) error {
@@ -128,6 +166,21 @@ func (s *StagingFake) Get(context.Context, graveler.StagingToken, graveler.Key)
 	return s.Value, nil
 }
 
+func (s *StagingFake) GetMany(ctx context.Context, st graveler.StagingToken, keys []graveler.Key) (map[string]*graveler.Value, error) {
+	values := make(map[string]*graveler.Value)
+	for _, key := range keys {
+		value, err := s.Get(ctx, st, key)
+		if errors.Is(err, graveler.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		values[string(key)] = value
+	}
+	return values, nil
+}
+
 func (s *StagingFake) Set(_ context.Context, _ graveler.StagingToken, key graveler.Key, value *graveler.Value) error {
 	if s.SetErr != nil {
 		return s.SetErr
@@ -139,6 +192,21 @@ func (s *StagingFake) Set(_ context.Context, _ graveler.StagingToken, key gravel
 	return nil
 }
 
+func (s *StagingFake) SetIf(ctx context.Context, st graveler.StagingToken, key graveler.Key, value *graveler.Value, condition graveler.StagedCondition) error {
+	if s.SetErr != nil {
+		return s.SetErr
+	}
+	current, err := s.Get(ctx, st, key)
+	staged := err == nil
+	if err != nil && !errors.Is(err, graveler.ErrNotFound) {
+		return err
+	}
+	if err := condition(current, staged); err != nil {
+		return err
+	}
+	return s.Set(ctx, st, key, value)
+}
+
 func (s *StagingFake) DropKey(_ context.Context, _ graveler.StagingToken, key graveler.Key) error {
 	if s.Err != nil {
 		return s.Err
@@ -154,20 +222,35 @@ func (s *StagingFake) List(context.Context, graveler.StagingToken) (graveler.Val
 	return s.ValueIterator, nil
 }
 
-func (s *StagingFake) Snapshot(context.Context, graveler.StagingToken) (graveler.StagingToken, error) {
+func (s *StagingFake) Stats(context.Context, graveler.StagingToken) (*graveler.StagingStats, error) {
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	stats := s.StagingStats
+	return &stats, nil
+}
+
+func (s *StagingFake) Snapshot(context.Context, graveler.RepositoryID, graveler.BranchID, graveler.StagingToken) (graveler.StagingToken, error) {
 	if s.Err != nil {
 		return "", s.Err
 	}
 	return s.stagingToken, nil
 }
 
-func (s *StagingFake) ListSnapshot(context.Context, graveler.StagingToken, graveler.Key) (graveler.ValueIterator, error) {
+func (s *StagingFake) ListSnapshot(context.Context, graveler.RepositoryID, graveler.BranchID, graveler.StagingToken, graveler.Key) (graveler.ValueIterator, error) {
 	if s.Err != nil {
 		return nil, s.Err
 	}
 	return s.ValueIterator, nil
 }
 
+func (s *StagingFake) DropExpiredSnapshots(context.Context) (int, error) {
+	if s.Err != nil {
+		return 0, s.Err
+	}
+	return 0, nil
+}
+
 type AddedCommitData struct {
 	Committer   string
 	Message     string
@@ -177,6 +260,7 @@ type AddedCommitData struct {
 }
 
 type RefsFake struct {
+	Repository          *graveler.Repository
 	ListRepositoriesRes graveler.RepositoryIterator
 	ListBranchesRes     graveler.BranchIterator
 	ListTagsRes         graveler.TagIterator
@@ -187,16 +271,46 @@ type RefsFake struct {
 	Err                 error
 	CommitErr           error
 	AddedCommit         AddedCommitData
-	CommitID            graveler.CommitID
-	Commits             map[graveler.CommitID]*graveler.Commit
+	// AddedCommits records the commits added, in order
+	AddedCommits       []graveler.Commit
+	CommitID           graveler.CommitID
+	Commits            map[graveler.CommitID]*graveler.Commit
+	RepositorySnapshot *graveler.RepositorySnapshot
+	BranchLog          []*graveler.BranchLogRecord
+	Replacements       map[graveler.CommitID]graveler.CommitID
+	IsAncestorRes      bool
 }
 
-func (m *RefsFake) CreateBareRepository(ctx context.Context, repositoryID graveler.RepositoryID, repository graveler.Repository) error {
-	panic("implement me")
+func (m *RefsFake) CreateBareRepository(context.Context, graveler.RepositoryID, graveler.Repository) error {
+	return nil
+}
+
+func (m *RefsFake) ForkRepository(context.Context, graveler.RepositoryID, graveler.RepositoryID, graveler.Repository, func(graveler.BranchID) graveler.StagingToken) error {
+	return nil
 }
 
 func (m *RefsFake) ListCommits(ctx context.Context, repositoryID graveler.RepositoryID) (graveler.CommitIterator, error) {
-	return nil, nil
+	records := make([]*graveler.CommitRecord, 0, len(m.Commits))
+	for id, commit := range m.Commits {
+		records = append(records, &graveler.CommitRecord{CommitID: id, Commit: commit})
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].CommitID < records[j].CommitID })
+	return &commitIteratorFake{records: records, current: -1}, nil
+}
+
+func (m *RefsFake) DeleteCommits(_ context.Context, _ graveler.RepositoryID, commitIDs []graveler.CommitID) error {
+	for _, id := range commitIDs {
+		delete(m.Commits, id)
+	}
+	return nil
+}
+
+func (m *RefsFake) ReplaceCommits(_ context.Context, _ graveler.RepositoryID, replacements map[graveler.CommitID]graveler.CommitID) error {
+	m.Replacements = replacements
+	for id := range replacements {
+		delete(m.Commits, id)
+	}
+	return nil
 }
 
 func (m *RefsFake) RevParse(context.Context, graveler.RepositoryID, graveler.Ref) (graveler.Reference, error) {
@@ -209,6 +323,9 @@ func (m *RefsFake) RevParse(context.Context, graveler.RepositoryID, graveler.Ref
 }
 
 func (m *RefsFake) GetRepository(context.Context, graveler.RepositoryID) (*graveler.Repository, error) {
+	if m.Repository != nil {
+		return m.Repository, nil
+	}
 	return &graveler.Repository{}, nil
 }
 
@@ -256,6 +373,39 @@ func (m *RefsFake) ListTags(context.Context, graveler.RepositoryID) (graveler.Ta
 	return m.ListTagsRes, nil
 }
 
+func (m *RefsFake) CreateRepositorySnapshot(context.Context, graveler.RepositoryID, graveler.RepositorySnapshotID, time.Time) error {
+	return m.Err
+}
+
+func (m *RefsFake) GetRepositorySnapshot(context.Context, graveler.RepositoryID, graveler.RepositorySnapshotID) (*graveler.RepositorySnapshot, error) {
+	if m.RepositorySnapshot == nil {
+		return nil, graveler.ErrRepositorySnapshotNotFound
+	}
+	return m.RepositorySnapshot, nil
+}
+
+func (m *RefsFake) ListRepositorySnapshots(context.Context, graveler.RepositoryID) ([]*graveler.RepositorySnapshot, error) {
+	if m.RepositorySnapshot == nil {
+		return nil, nil
+	}
+	return []*graveler.RepositorySnapshot{m.RepositorySnapshot}, nil
+}
+
+func (m *RefsFake) DeleteRepositorySnapshot(context.Context, graveler.RepositoryID, graveler.RepositorySnapshotID) error {
+	return m.Err
+}
+
+func (m *RefsFake) ListBranchLog(context.Context, graveler.RepositoryID) ([]*graveler.BranchLogRecord, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	return m.BranchLog, nil
+}
+
+func (m *RefsFake) DeleteBranchLogCommits(context.Context, graveler.RepositoryID, []graveler.CommitID) error {
+	return m.Err
+}
+
 func (m *RefsFake) GetCommit(_ context.Context, _ graveler.RepositoryID, id graveler.CommitID) (*graveler.Commit, error) {
 	if val, ok := m.Commits[id]; ok {
 		return val, nil
@@ -275,6 +425,7 @@ func (m *RefsFake) AddCommit(_ context.Context, _ graveler.RepositoryID, commit
 		Parents:     commit.Parents,
 		Metadata:    commit.Metadata,
 	}
+	m.AddedCommits = append(m.AddedCommits, commit)
 	return m.CommitID, nil
 }
 
@@ -282,10 +433,68 @@ func (m *RefsFake) FindMergeBase(context.Context, graveler.RepositoryID, ...grav
 	return &graveler.Commit{}, nil
 }
 
-func (m *RefsFake) Log(context.Context, graveler.RepositoryID, graveler.CommitID) (graveler.CommitIterator, error) {
+func (m *RefsFake) Log(context.Context, graveler.RepositoryID, graveler.CommitID, bool) (graveler.CommitIterator, error) {
 	return m.CommitIter, nil
 }
 
+func (m *RefsFake) IsAncestor(context.Context, graveler.RepositoryID, graveler.CommitID, graveler.CommitID) (bool, error) {
+	return m.IsAncestorRes, m.Err
+}
+
+func (m *RefsFake) CommitsBetween(context.Context, graveler.RepositoryID, graveler.CommitID, graveler.CommitID) ([]*graveler.CommitRecord, error) {
+	panic("implement me")
+}
+
+func (m *RefsFake) SetRangeSplitPolicy(context.Context, graveler.RepositoryID, *graveler.RangeSplitPolicy) error {
+	return m.Err
+}
+
+func (m *RefsFake) FillGenerations(context.Context, graveler.RepositoryID) (int, error) {
+	return 0, m.Err
+}
+
+func (m *RefsFake) FindCommitsByMetadata(_ context.Context, _ graveler.RepositoryID, key, value string, after graveler.CommitID, limit int) ([]*graveler.CommitRecord, bool, error) {
+	var commits []*graveler.CommitRecord
+	for id, commit := range m.Commits {
+		if v, ok := commit.Metadata[key]; ok && v == value {
+			commits = append(commits, &graveler.CommitRecord{CommitID: id, Commit: commit})
+		}
+	}
+	sort.Slice(commits, func(i, j int) bool {
+		if !commits[i].CreationDate.Equal(commits[j].CreationDate) {
+			return commits[i].CreationDate.After(commits[j].CreationDate)
+		}
+		return commits[i].CommitID < commits[j].CommitID
+	})
+	if after != "" {
+		i := 0
+		for i < len(commits) && commits[i].CommitID != after {
+			i++
+		}
+		if i < len(commits) {
+			i++
+		}
+		commits = commits[i:]
+	}
+	hasMore := len(commits) > limit
+	if hasMore {
+		commits = commits[:limit]
+	}
+	return commits, hasMore, nil
+}
+
+func (m *RefsFake) SetRefLabels(context.Context, graveler.RepositoryID, graveler.LabeledRefType, string, map[string]string, []string) (map[string]string, error) {
+	panic("implement me")
+}
+
+func (m *RefsFake) GetRefLabels(context.Context, graveler.RepositoryID, graveler.LabeledRefType, string) (map[string]string, error) {
+	panic("implement me")
+}
+
+func (m *RefsFake) FindRefsByLabels(context.Context, graveler.RepositoryID, map[string]string, graveler.LabeledRefType) ([]*graveler.RefLabels, error) {
+	panic("implement me")
+}
+
 type diffIter struct {
 	current int
 	records []graveler.Diff
@@ -537,3 +746,86 @@ func (i *FakeIterator) Err() error {
 func (i *FakeIterator) Close() {
 	i.closed = true
 }
+
+type commitIteratorFake struct {
+	current int
+	records []*graveler.CommitRecord
+}
+
+func (c *commitIteratorFake) Next() bool {
+	c.current++
+	return c.current < len(c.records)
+}
+
+func (c *commitIteratorFake) SeekGE(id graveler.CommitID) {
+	c.current = sort.Search(len(c.records), func(i int) bool { return c.records[i].CommitID >= id }) - 1
+}
+
+func (c *commitIteratorFake) Value() *graveler.CommitRecord {
+	if c.current < 0 || c.current >= len(c.records) {
+		return nil
+	}
+	return c.records[c.current]
+}
+
+func (c *commitIteratorFake) Err() error { return nil }
+
+func (c *commitIteratorFake) Close() {}
+
+type branchIteratorFake struct {
+	current int
+	records []*graveler.BranchRecord
+}
+
+func NewBranchIteratorFake(records []*graveler.BranchRecord) graveler.BranchIterator {
+	return &branchIteratorFake{records: records, current: -1}
+}
+
+func (b *branchIteratorFake) Next() bool {
+	b.current++
+	return b.current < len(b.records)
+}
+
+func (b *branchIteratorFake) SeekGE(id graveler.BranchID) {
+	b.current = sort.Search(len(b.records), func(i int) bool { return b.records[i].BranchID >= id }) - 1
+}
+
+func (b *branchIteratorFake) Value() *graveler.BranchRecord {
+	if b.current < 0 || b.current >= len(b.records) {
+		return nil
+	}
+	return b.records[b.current]
+}
+
+func (b *branchIteratorFake) Err() error { return nil }
+
+func (b *branchIteratorFake) Close() {}
+
+type tagIteratorFake struct {
+	current int
+	records []*graveler.TagRecord
+}
+
+func NewTagIteratorFake(records []*graveler.TagRecord) graveler.TagIterator {
+	return &tagIteratorFake{records: records, current: -1}
+}
+
+func (t *tagIteratorFake) Next() bool {
+	t.current++
+	return t.current < len(t.records)
+}
+
+func (t *tagIteratorFake) SeekGE(id graveler.TagID) {
+	t.current = sort.Search(len(t.records), func(i int) bool { return t.records[i].TagID >= id }) - 1
+}
+
+func (t *tagIteratorFake) Value() *graveler.TagRecord {
+	if t.current < 0 || t.current >= len(t.records) {
+		return nil
+	}
+	return t.records[t.current]
+}
+
+func (t *tagIteratorFake) Err() error { return nil }
+
+func (t *tagIteratorFake) Close() {}
@@ -0,0 +1,69 @@
+package httputil
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CacheParams configures the Cache-Control header of responses reading objects
+type CacheParams struct {
+	// ImmutableMaxAge is how long caches may store objects read from immutable refs, such as
+	// commit IDs and tags, without revalidating them.  Zero makes caches revalidate them too.
+	ImmutableMaxAge time.Duration
+	// Public allows shared caches, such as CDNs and proxies, to store responses to
+	// authenticated requests.  Otherwise only the cache of the client stores them.
+	Public bool
+}
+
+// CacheControl returns the Cache-Control header of a response reading an object.  Objects read
+// from immutable refs never change and are cached for ImmutableMaxAge, objects read from other
+// refs may be cached but are revalidated on every read.
+func (p CacheParams) CacheControl(immutable bool) string {
+	visibility := "private"
+	if p.Public {
+		visibility = "public"
+	}
+	if immutable && p.ImmutableMaxAge > 0 {
+		return fmt.Sprintf("%s, max-age=%d, immutable", visibility, int64(p.ImmutableMaxAge/time.Second))
+	}
+	return visibility + ", no-cache"
+}
+
+// NotModified returns true if the conditional headers of r show that the client holds the
+// object with etag, last modified at lastModified, so a 304 Not Modified response is returned.
+// If-None-Match is evaluated instead of If-Modified-Since when both are sent (RFC 7232).
+func NotModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return etagListMatches(ifNoneMatch, etag)
+	}
+	ifModifiedSince := r.Header.Get("If-Modified-Since")
+	if ifModifiedSince == "" || lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+	// header timestamps have a resolution of seconds
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// etagListMatches returns true if the list of entity tags of an If-None-Match header holds
+// etag, compared weakly, or is "*"
+func etagListMatches(list, etag string) bool {
+	if strings.TrimSpace(list) == "*" {
+		return true
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(list, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package httputil_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/treeverse/lakefs/httputil"
+)
+
+func TestCacheParams_CacheControl(t *testing.T) {
+	cases := []struct {
+		name      string
+		params    httputil.CacheParams
+		immutable bool
+		expected  string
+	}{
+		{name: "mutable", params: httputil.CacheParams{ImmutableMaxAge: time.Hour}, expected: "private, no-cache"},
+		{name: "immutable", params: httputil.CacheParams{ImmutableMaxAge: time.Hour}, immutable: true, expected: "private, max-age=3600, immutable"},
+		{name: "public", params: httputil.CacheParams{ImmutableMaxAge: time.Hour, Public: true}, immutable: true, expected: "public, max-age=3600, immutable"},
+		{name: "no max age", params: httputil.CacheParams{}, immutable: true, expected: "private, no-cache"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.params.CacheControl(tc.immutable); got != tc.expected {
+				t.Errorf("got Cache-Control %q, expected %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	lastModified := time.Date(2021, 4, 12, 10, 30, 15, 500, time.UTC)
+	etag := httputil.ETag("abc")
+	cases := []struct {
+		name     string
+		method   string
+		headers  map[string]string
+		expected bool
+	}{
+		{name: "unconditional", expected: false},
+		{name: "etag match", headers: map[string]string{"If-None-Match": `"abc"`}, expected: true},
+		{name: "etag list", headers: map[string]string{"If-None-Match": `"xyz", W/"abc"`}, expected: true},
+		{name: "etag mismatch", headers: map[string]string{"If-None-Match": `"xyz"`}, expected: false},
+		{name: "any etag", headers: map[string]string{"If-None-Match": "*"}, expected: true},
+		{name: "not modified since", headers: map[string]string{"If-Modified-Since": httputil.HeaderTimestamp(lastModified)}, expected: true},
+		{name: "modified since", headers: map[string]string{"If-Modified-Since": httputil.HeaderTimestamp(lastModified.Add(-time.Minute))}, expected: false},
+		{name: "invalid date", headers: map[string]string{"If-Modified-Since": "yesterday"}, expected: false},
+		{
+			name:     "etag precedes date",
+			headers:  map[string]string{"If-None-Match": `"xyz"`, "If-Modified-Since": httputil.HeaderTimestamp(lastModified)},
+			expected: false,
+		},
+		{name: "unsafe method", method: http.MethodPut, headers: map[string]string{"If-None-Match": "*"}, expected: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			r, err := http.NewRequest(method, "http://lakefs/object", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range tc.headers {
+				r.Header.Set(k, v)
+			}
+			if got := httputil.NotModified(r, etag, lastModified); got != tc.expected {
+				t.Errorf("got not modified %t, expected %t", got, tc.expected)
+			}
+		})
+	}
+}
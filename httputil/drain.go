@@ -0,0 +1,37 @@
+package httputil
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+var (
+	draining int32
+	inFlight int64
+)
+
+// SetDraining marks the server as shutting down, the health endpoint then fails so load
+// balancers stop routing new requests to it
+func SetDraining() {
+	atomic.StoreInt32(&draining, 1)
+}
+
+// IsDraining returns true once SetDraining was called
+func IsDraining() bool {
+	return atomic.LoadInt32(&draining) == 1
+}
+
+// InFlightRequests returns the number of requests served by handlers wrapped with
+// TrackInFlight that did not complete yet
+func InFlightRequests() int64 {
+	return atomic.LoadInt64(&inFlight)
+}
+
+// TrackInFlight counts the requests being served by next
+func TrackInFlight(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&inFlight, 1)
+		defer atomic.AddInt64(&inFlight, -1)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,46 @@
+package httputil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTrackInFlight(t *testing.T) {
+	served := make(chan struct{})
+	release := make(chan struct{})
+	handler := TrackInFlight(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(served)
+		<-release
+	}))
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/repo/main/file", nil))
+		close(done)
+	}()
+	<-served
+	if n := InFlightRequests(); n != 1 {
+		t.Errorf("InFlightRequests() = %d while serving, expected 1", n)
+	}
+	close(release)
+	<-done
+	if n := InFlightRequests(); n != 0 {
+		t.Errorf("InFlightRequests() = %d after serving, expected 0", n)
+	}
+}
+
+func TestServeHealth_Draining(t *testing.T) {
+	defer func() { draining = 0 }()
+	health := ServeHealth()
+	w := httptest.NewRecorder()
+	health.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/_health", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("health status %d before draining, expected %d", w.Code, http.StatusOK)
+	}
+	SetDraining()
+	w = httptest.NewRecorder()
+	health.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/_health", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("health status %d while draining, expected %d", w.Code, http.StatusServiceUnavailable)
+	}
+}
@@ -15,6 +15,11 @@ func SetHealthHandlerInfo(info string) {
 
 func ServeHealth() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if IsDraining() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = io.WriteString(w, "draining")
+			return
+		}
 		_, _ = io.WriteString(w, "alive!")
 		if healthInfo != "" {
 			_, _ = io.WriteString(w, " "+healthInfo)
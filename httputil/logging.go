@@ -36,6 +36,13 @@ func (w *ResponseRecordingWriter) WriteHeader(statusCode int) {
 	w.Writer.WriteHeader(statusCode)
 }
 
+// Flush sends buffered data to the client, streaming responses depend on it
+func (w *ResponseRecordingWriter) Flush() {
+	if f, ok := w.Writer.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 func RequestID(r *http.Request) (*http.Request, string) {
 	ctx := r.Context()
 	resp := ctx.Value(RequestIDContextKey)
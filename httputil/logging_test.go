@@ -0,0 +1,32 @@
+package httputil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseWriters_Flush(t *testing.T) {
+	cases := []struct {
+		name   string
+		writer func(http.ResponseWriter) http.ResponseWriter
+	}{
+		{name: "recording", writer: func(w http.ResponseWriter) http.ResponseWriter { return &ResponseRecordingWriter{Writer: w} }},
+		{name: "metric", writer: func(w http.ResponseWriter) http.ResponseWriter { return NewMetricResponseWriter(w) }},
+		{name: "tracing", writer: func(w http.ResponseWriter) http.ResponseWriter { return newResponseTracingWriter(w, 0) }},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			w := tt.writer(rec)
+			flusher, ok := w.(http.Flusher)
+			if !ok {
+				t.Fatalf("%T is not an http.Flusher", w)
+			}
+			flusher.Flush()
+			if !rec.Flushed {
+				t.Error("Flush() did not flush the underlying writer")
+			}
+		})
+	}
+}
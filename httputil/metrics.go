@@ -15,3 +15,10 @@ func (mrw *MetricResponseWriter) WriteHeader(code int) {
 	mrw.StatusCode = code
 	mrw.ResponseWriter.WriteHeader(code)
 }
+
+// Flush sends buffered data to the client, streaming responses depend on it
+func (mrw *MetricResponseWriter) Flush() {
+	if f, ok := mrw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
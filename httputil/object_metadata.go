@@ -0,0 +1,65 @@
+package httputil
+
+import (
+	"net/http"
+	"strings"
+)
+
+// UserMetadataHeaderPrefix starts the headers of the user metadata of objects.  The user metadata
+// is kept in the metadata of the object, under its lowercase header name.
+const UserMetadataHeaderPrefix = "x-amz-meta-"
+
+// MaxUserMetadataSize is the maximal size of the names and values of the user metadata of an
+// object, as in S3
+const MaxUserMetadataSize = 2 * 1024
+
+// ObjectHeaders are the standard headers of an object set at upload, kept in the metadata of the
+// object under their lowercase names and returned when reading it
+var ObjectHeaders = []string{"Cache-Control", "Content-Disposition", "Content-Encoding", "Content-Type"}
+
+// ObjectHeadersMetadata returns the metadata of the object headers set on header
+func ObjectHeadersMetadata(header http.Header) map[string]string {
+	metadata := make(map[string]string)
+	for _, name := range ObjectHeaders {
+		if value := header.Get(name); value != "" {
+			metadata[strings.ToLower(name)] = value
+		}
+	}
+	return metadata
+}
+
+// ObjectHeaderValue returns the value of the object header name kept in metadata, empty if
+// it was not set
+func ObjectHeaderValue(metadata map[string]string, name string) string {
+	return metadata[strings.ToLower(name)]
+}
+
+// IsObjectMetadataKey returns true if key keeps user metadata or an object header of an object,
+// rather than metadata internal to lakeFS
+func IsObjectMetadataKey(key string) bool {
+	if strings.HasPrefix(key, UserMetadataHeaderPrefix) {
+		return true
+	}
+	for _, name := range ObjectHeaders {
+		if key == strings.ToLower(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ObjectMetadata returns the user metadata and the object headers kept in the metadata of an
+// object, nil if it has none
+func ObjectMetadata(metadata map[string]string) map[string]string {
+	var res map[string]string
+	for k, v := range metadata {
+		if !IsObjectMetadataKey(k) {
+			continue
+		}
+		if res == nil {
+			res = make(map[string]string)
+		}
+		res[k] = v
+	}
+	return res
+}
@@ -0,0 +1,38 @@
+package httputil_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/httputil"
+)
+
+func TestObjectHeadersMetadata(t *testing.T) {
+	header := http.Header{
+		"Content-Type":  {"text/csv"},
+		"Cache-Control": {"no-store"},
+		"Accept":        {"*/*"},
+	}
+	expected := map[string]string{"content-type": "text/csv", "cache-control": "no-store"}
+	if diff := deep.Equal(httputil.ObjectHeadersMetadata(header), expected); diff != nil {
+		t.Error("ObjectHeadersMetadata() diff found", diff)
+	}
+}
+
+func TestObjectMetadata(t *testing.T) {
+	metadata := map[string]string{
+		"content-type":             "text/csv",
+		"x-amz-meta-owner":         "me",
+		"lakefs-encryption-key-id": "key",
+		"lakefs-parts-count":       "3",
+		"content-encoding":         "gzip",
+	}
+	expected := map[string]string{"content-type": "text/csv", "x-amz-meta-owner": "me", "content-encoding": "gzip"}
+	if diff := deep.Equal(httputil.ObjectMetadata(metadata), expected); diff != nil {
+		t.Error("ObjectMetadata() diff found", diff)
+	}
+	if got := httputil.ObjectMetadata(map[string]string{"lakefs-parts-count": "3"}); got != nil {
+		t.Errorf("ObjectMetadata() of internal metadata = %v, expected nil", got)
+	}
+}
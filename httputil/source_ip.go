@@ -0,0 +1,63 @@
+package httputil
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const SourceIPContextKey contextKey = "source_ip"
+
+// SourceIP returns the address of the client sending r.  When r arrives from one of
+// trustedProxies, the client is the last address of X-Forwarded-For that does not belong to a
+// trusted proxy.  It returns nil if the address cannot be parsed.
+func SourceIP(r *http.Request, trustedProxies []*net.IPNet) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !anyContains(trustedProxies, ip) {
+		return ip
+	}
+	// walk the forwarding chain from the closest hop, each trusted proxy vouches for its client
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			return ip
+		}
+		ip = hop
+		if !anyContains(trustedProxies, ip) {
+			break
+		}
+	}
+	return ip
+}
+
+func anyContains(networks []*net.IPNet, ip net.IP) bool {
+	for _, n := range networks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// SourceIPHandler resolves the source address of requests once, see SourceIPFromRequest
+func SourceIPHandler(trustedProxies []*net.IPNet, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), SourceIPContextKey, SourceIP(r, trustedProxies))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// SourceIPFromRequest returns the source address resolved by SourceIPHandler, or the remote
+// address of r when it did not pass through SourceIPHandler
+func SourceIPFromRequest(r *http.Request) net.IP {
+	if ip, ok := r.Context().Value(SourceIPContextKey).(net.IP); ok {
+		return ip
+	}
+	return SourceIP(r, nil)
+}
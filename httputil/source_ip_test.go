@@ -0,0 +1,51 @@
+package httputil
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSourceIP(t *testing.T) {
+	_, proxies, _ := net.ParseCIDR("10.0.0.0/8")
+	trusted := []*net.IPNet{proxies}
+	tests := []struct {
+		name          string
+		remoteAddr    string
+		forwardedFor  []string
+		trusted       []*net.IPNet
+		want          string
+		wantUnknownIP bool
+	}{
+		{name: "direct", remoteAddr: "192.0.2.1:1234", want: "192.0.2.1"},
+		{name: "untrusted forwarder", remoteAddr: "192.0.2.1:1234", forwardedFor: []string{"198.51.100.1"}, trusted: trusted, want: "192.0.2.1"},
+		{name: "no trusted proxies", remoteAddr: "10.0.0.1:1234", forwardedFor: []string{"198.51.100.1"}, want: "10.0.0.1"},
+		{name: "trusted proxy", remoteAddr: "10.0.0.1:1234", forwardedFor: []string{"198.51.100.1"}, trusted: trusted, want: "198.51.100.1"},
+		{name: "proxy chain", remoteAddr: "10.0.0.1:1234", forwardedFor: []string{"203.0.113.7, 198.51.100.1, 10.0.0.2"}, trusted: trusted, want: "198.51.100.1"},
+		{name: "several headers", remoteAddr: "10.0.0.1:1234", forwardedFor: []string{"203.0.113.7", "198.51.100.1"}, trusted: trusted, want: "198.51.100.1"},
+		{name: "spoofed header", remoteAddr: "10.0.0.1:1234", forwardedFor: []string{"garbage, 198.51.100.1"}, trusted: trusted, want: "198.51.100.1"},
+		{name: "only proxies", remoteAddr: "10.0.0.1:1234", forwardedFor: []string{"10.0.0.3"}, trusted: trusted, want: "10.0.0.3"},
+		{name: "ipv6", remoteAddr: "[2001:db8::1]:1234", want: "2001:db8::1"},
+		{name: "unparsable", remoteAddr: "pipe", wantUnknownIP: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.forwardedFor {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			got := SourceIP(r, tt.trusted)
+			if tt.wantUnknownIP {
+				if got != nil {
+					t.Errorf("SourceIP() = %s, expected unknown address", got)
+				}
+				return
+			}
+			if !got.Equal(net.ParseIP(tt.want)) {
+				t.Errorf("SourceIP() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -75,6 +75,12 @@ func (w *responseTracingWriter) WriteHeader(statusCode int) {
 	w.Writer.WriteHeader(statusCode)
 }
 
+func (w *responseTracingWriter) Flush() {
+	if f, ok := w.Writer.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 type requestBodyTracer struct {
 	body         io.ReadCloser
 	bodyRecorder *CappedBuffer
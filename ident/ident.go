@@ -1,6 +1,7 @@
 package ident
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/binary"
 	"encoding/hex"
@@ -109,6 +110,32 @@ func NewAddressWriter() *AddressWriter {
 	return &AddressWriter{Hash: sha256.New()}
 }
 
+// recordingHash is a hash that keeps the bytes written to it
+type recordingHash struct {
+	hash.Hash
+	written bytes.Buffer
+}
+
+func (h *recordingHash) Write(p []byte) (int, error) {
+	_, _ = h.written.Write(p)
+	return h.Hash.Write(p)
+}
+
+// NewRecordingAddressWriter returns an AddressWriter that keeps the serialization it hashes,
+// for inspecting the canonical form of an entity
+func NewRecordingAddressWriter() *AddressWriter {
+	return &AddressWriter{Hash: &recordingHash{Hash: sha256.New()}}
+}
+
+// Serialized returns the bytes hashed so far by a writer of NewRecordingAddressWriter, nil for
+// other writers
+func (b *AddressWriter) Serialized() []byte {
+	if h, ok := b.Hash.(*recordingHash); ok {
+		return h.written.Bytes()
+	}
+	return nil
+}
+
 func (b *AddressWriter) MarshalBytes(v []byte) *AddressWriter {
 	MarshalBytes(b, v)
 	return b
@@ -0,0 +1,130 @@
+package ingest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const avroSyncSize = 16
+
+var (
+	ErrInvalidBatch = errors.New("invalid batch")
+	avroMagic       = []byte("Obj\x01")
+)
+
+// parseBatch validates a batch of records in format and returns the number of records it holds
+// and its data as written to a file
+func parseBatch(format Format, data []byte) (int64, []byte, error) {
+	switch format {
+	case FormatNDJSON:
+		return parseNDJSON(data)
+	case FormatAvro:
+		records, err := countAvroRecords(data)
+		return records, data, err
+	default:
+		return 0, nil, fmt.Errorf("format '%s' unknown: %w", format, ErrInvalidBatch)
+	}
+}
+
+// parseNDJSON validates every non-empty line of data is a JSON value.  The data returned ends
+// with a newline, so that the next batch appended starts on a line of its own.
+func parseNDJSON(data []byte) (int64, []byte, error) {
+	var records int64
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if !json.Valid(line) {
+			return 0, nil, fmt.Errorf("line %d is not JSON: %w", i+1, ErrInvalidBatch)
+		}
+		records++
+	}
+	if records == 0 {
+		return 0, nil, fmt.Errorf("no records: %w", ErrInvalidBatch)
+	}
+	if data[len(data)-1] != '\n' {
+		data = append(data, '\n')
+	}
+	return records, data, nil
+}
+
+// countAvroRecords validates data is an Avro object container file and returns the number of
+// records in its blocks
+func countAvroRecords(data []byte) (int64, error) {
+	if !bytes.HasPrefix(data, avroMagic) {
+		return 0, fmt.Errorf("not an Avro object container file: %w", ErrInvalidBatch)
+	}
+	r := bytes.NewReader(data[len(avroMagic):])
+	// the file metadata is a map of blocks of key, value pairs ending with an empty block
+	for {
+		count, err := readAvroLong(r)
+		if err != nil {
+			return 0, err
+		}
+		if count == 0 {
+			break
+		}
+		if count < 0 {
+			// a negative count is followed by the size of the block
+			count = -count
+			if _, err := readAvroLong(r); err != nil {
+				return 0, err
+			}
+		}
+		for i := int64(0); i < 2*count; i++ {
+			if err := skipAvroBytes(r); err != nil {
+				return 0, err
+			}
+		}
+	}
+	sync := make([]byte, avroSyncSize)
+	if _, err := io.ReadFull(r, sync); err != nil {
+		return 0, fmt.Errorf("header sync marker: %w", ErrInvalidBatch)
+	}
+	var records int64
+	blockSync := make([]byte, avroSyncSize)
+	for r.Len() > 0 {
+		count, err := readAvroLong(r)
+		if err != nil {
+			return 0, err
+		}
+		if count < 0 {
+			return 0, fmt.Errorf("negative block count: %w", ErrInvalidBatch)
+		}
+		if err := skipAvroBytes(r); err != nil {
+			return 0, err
+		}
+		if _, err := io.ReadFull(r, blockSync); err != nil || !bytes.Equal(blockSync, sync) {
+			return 0, fmt.Errorf("block sync marker mismatch: %w", ErrInvalidBatch)
+		}
+		records += count
+	}
+	return records, nil
+}
+
+// readAvroLong reads a zig-zag encoded variable length long
+func readAvroLong(r *bytes.Reader) (int64, error) {
+	n, err := binary.ReadVarint(r)
+	if err != nil {
+		return 0, fmt.Errorf("truncated Avro data: %w", ErrInvalidBatch)
+	}
+	return n, nil
+}
+
+// skipAvroBytes skips a long length followed by that many bytes
+func skipAvroBytes(r *bytes.Reader) error {
+	n, err := readAvroLong(r)
+	if err != nil {
+		return err
+	}
+	if n < 0 || n > int64(r.Len()) {
+		return fmt.Errorf("truncated Avro data: %w", ErrInvalidBatch)
+	}
+	_, err = r.Seek(n, io.SeekCurrent)
+	return err
+}
@@ -0,0 +1,101 @@
+package ingest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+func TestParseNDJSON(t *testing.T) {
+	cases := []struct {
+		name     string
+		data     string
+		records  int64
+		expected string
+		err      error
+	}{
+		{name: "records", data: "{\"a\":1}\n{\"a\":2}\n", records: 2, expected: "{\"a\":1}\n{\"a\":2}\n"},
+		{name: "missing final newline", data: "{\"a\":1}\n{\"a\":2}", records: 2, expected: "{\"a\":1}\n{\"a\":2}\n"},
+		{name: "blank lines", data: "{\"a\":1}\n\r\n  \n[1,2]\n", records: 2, expected: "{\"a\":1}\n\r\n  \n[1,2]\n"},
+		{name: "invalid line", data: "{\"a\":1}\n{\"a\":\n", err: ErrInvalidBatch},
+		{name: "empty", data: "\n\n", err: ErrInvalidBatch},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			records, data, err := parseBatch(FormatNDJSON, []byte(tc.data))
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("got error %v, expected %v", err, tc.err)
+			}
+			if err != nil {
+				return
+			}
+			if records != tc.records {
+				t.Errorf("got %d records, expected %d", records, tc.records)
+			}
+			if string(data) != tc.expected {
+				t.Errorf("got data %q, expected %q", data, tc.expected)
+			}
+		})
+	}
+}
+
+func avroLong(n int64) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	return buf[:binary.PutVarint(buf, n)]
+}
+
+func avroBytes(b []byte) []byte {
+	return append(avroLong(int64(len(b))), b...)
+}
+
+// avroFile returns an Avro object container file with blocks of the record counts of blocks
+func avroFile(sync []byte, blocks ...int64) []byte {
+	var buf bytes.Buffer
+	buf.Write(avroMagic)
+	buf.Write(avroLong(1))
+	buf.Write(avroBytes([]byte("avro.schema")))
+	buf.Write(avroBytes([]byte(`"long"`)))
+	buf.Write(avroLong(0))
+	buf.Write(sync)
+	for _, count := range blocks {
+		buf.Write(avroLong(count))
+		data := make([]byte, 0)
+		for i := int64(0); i < count; i++ {
+			data = append(data, avroLong(i)...)
+		}
+		buf.Write(avroBytes(data))
+		buf.Write(sync)
+	}
+	return buf.Bytes()
+}
+
+func TestCountAvroRecords(t *testing.T) {
+	sync := []byte("0123456789abcdef")
+	valid := avroFile(sync, 3, 5)
+	corrupt := append([]byte{}, valid...)
+	copy(corrupt[len(corrupt)-avroSyncSize:], "fedcba9876543210")
+	cases := []struct {
+		name    string
+		data    []byte
+		records int64
+		err     error
+	}{
+		{name: "blocks", data: valid, records: 8},
+		{name: "no blocks", data: avroFile(sync), records: 0},
+		{name: "not avro", data: []byte("{\"a\":1}\n"), err: ErrInvalidBatch},
+		{name: "truncated", data: valid[:len(valid)-5], err: ErrInvalidBatch},
+		{name: "sync mismatch", data: corrupt, err: ErrInvalidBatch},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			records, _, err := parseBatch(FormatAvro, tc.data)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("got error %v, expected %v", err, tc.err)
+			}
+			if records != tc.records {
+				t.Errorf("got %d records, expected %d", records, tc.records)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/encryption"
+	"github.com/treeverse/lakefs/upload"
+)
+
+const (
+	// MaxBatchSize is the size in bytes of the largest batch of records ingested at once
+	MaxBatchSize = 64 * 1024 * 1024
+	// maxRollAttempts is the number of times a batch tries to start a file while other
+	// batches start files of the same stream
+	maxRollAttempts = 5
+)
+
+var (
+	ErrBatchTooLarge = fmt.Errorf("batch larger than %d bytes: %w", MaxBatchSize, ErrInvalidBatch)
+	ErrRollConflict  = errors.New("stream files rolled concurrently")
+)
+
+// Result describes the ingestion of a batch
+type Result struct {
+	// Path is the file the batch was written to
+	Path string
+	// Records is the number of records in the batch
+	Records int64
+	// SizeBytes is the size of the file after the batch was written
+	SizeBytes int64
+	// Rolled is true if the file was closed after the batch, the next batch starts a new file
+	Rolled bool
+}
+
+// Ingester writes batches of records to the files of streams
+type Ingester struct {
+	store     Store
+	cataloger catalog.Cataloger
+	adapter   block.Adapter
+	encryptor *encryption.Encryptor
+}
+
+func NewIngester(store Store, cataloger catalog.Cataloger, adapter block.Adapter, encryptor *encryption.Encryptor) *Ingester {
+	return &Ingester{
+		store:     store,
+		cataloger: cataloger,
+		adapter:   adapter,
+		encryptor: encryptor,
+	}
+}
+
+// Ingest writes the batch of records read from body to the current file of stream, on
+// repository.  Files are rolled before they exceed maxObjectSize, zero for no limit.
+func (i *Ingester) Ingest(ctx context.Context, repository *catalog.Repository, stream *Stream, body io.Reader, maxObjectSize int64) (*Result, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(body, MaxBatchSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxBatchSize {
+		return nil, ErrBatchTooLarge
+	}
+	records, data, err := parseBatch(Format(stream.Format), data)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := i.currentFile(ctx, stream, false)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := i.append(ctx, repository, stream, path, data, maxObjectSize)
+	if errors.Is(err, upload.ErrAppendTooLarge) {
+		// the batch does not fit in the current file, start a new one
+		if path, err = i.currentFile(ctx, stream, true); err != nil {
+			return nil, err
+		}
+		entry, err = i.append(ctx, repository, stream, path, data, maxObjectSize)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		Path:      path,
+		Records:   records,
+		SizeBytes: entry.Size,
+	}
+	if Format(stream.Format) == FormatAvro || entry.Size >= stream.MaxFileSizeBytes {
+		// another batch may have started a new file meanwhile, leaving this file closed already
+		if _, err := i.store.RollFile(ctx, stream.RepositoryID, stream.Name, path, "", time.Now()); err != nil {
+			return nil, err
+		}
+		result.Rolled = true
+	}
+	return result, nil
+}
+
+func (i *Ingester) append(ctx context.Context, repository *catalog.Repository, stream *Stream, path string, data []byte, maxObjectSize int64) (*catalog.DBEntry, error) {
+	return upload.AppendBlob(ctx, i.cataloger, i.adapter, i.encryptor, upload.AppendParams{
+		Repository:    repository,
+		Branch:        stream.Branch,
+		Path:          path,
+		Body:          bytes.NewReader(data),
+		ContentLength: int64(len(data)),
+		MaxSize:       maxObjectSize,
+	})
+}
+
+// currentFile returns the file the next batch of stream is written to, starting a new file if
+// the current file is due to roll or if force is set
+func (i *Ingester) currentFile(ctx context.Context, stream *Stream, force bool) (string, error) {
+	current := stream
+	for attempt := 0; attempt < maxRollAttempts; attempt++ {
+		now := time.Now()
+		if !force && !current.rollDue(now) {
+			return current.CurrentPath, nil
+		}
+		path := stream.filePath(now)
+		ok, err := i.store.RollFile(ctx, stream.RepositoryID, stream.Name, current.CurrentPath, path, now)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return path, nil
+		}
+		// another batch rolled the file first, use the file it started
+		force = false
+		if current, err = i.store.GetStream(ctx, stream.RepositoryID, stream.Name); err != nil {
+			return "", err
+		}
+	}
+	return "", ErrRollConflict
+}
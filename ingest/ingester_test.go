@@ -0,0 +1,230 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/block/mem"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+type fakeStore struct {
+	Store
+	stream Stream
+}
+
+func (s *fakeStore) GetStream(_ context.Context, _, _ string) (*Stream, error) {
+	stream := s.stream
+	return &stream, nil
+}
+
+func (s *fakeStore) RollFile(_ context.Context, _, _, previousPath, path string, startDate time.Time) (bool, error) {
+	if s.stream.CurrentPath != previousPath {
+		return false, nil
+	}
+	s.stream.CurrentPath = path
+	s.stream.CurrentStartDate = startDate
+	return true, nil
+}
+
+type fakeCataloger struct {
+	catalog.Cataloger
+	entries map[string]catalog.DBEntry
+}
+
+func (c *fakeCataloger) GetEntry(_ context.Context, _, _, path string, _ catalog.GetEntryParams) (*catalog.DBEntry, error) {
+	entry, ok := c.entries[path]
+	if !ok {
+		return nil, db.ErrNotFound
+	}
+	return &entry, nil
+}
+
+func (c *fakeCataloger) CreateEntryIf(_ context.Context, _, _ string, entry catalog.DBEntry, condition catalog.EntryCondition) error {
+	current, ok := c.entries[entry.Path]
+	if condition.IfAbsent && ok {
+		return fmt.Errorf("entry exists: %w", graveler.ErrPreconditionFailed)
+	}
+	if condition.IfETagMatches != "" && (!ok || current.Checksum != condition.IfETagMatches) {
+		return fmt.Errorf("entry ETag: %w", graveler.ErrPreconditionFailed)
+	}
+	c.entries[entry.Path] = entry
+	return nil
+}
+
+var testRepository = &catalog.Repository{Name: "repo", StorageNamespace: "mem://repo"}
+
+type ingestTest struct {
+	store     *fakeStore
+	cataloger *fakeCataloger
+	adapter   block.Adapter
+	ingester  *Ingester
+}
+
+func newIngestTest(stream Stream) *ingestTest {
+	store := &fakeStore{stream: stream}
+	cataloger := &fakeCataloger{entries: make(map[string]catalog.DBEntry)}
+	adapter := mem.New()
+	return &ingestTest{
+		store:     store,
+		cataloger: cataloger,
+		adapter:   adapter,
+		ingester:  NewIngester(store, cataloger, adapter, nil),
+	}
+}
+
+func (it *ingestTest) ingest(t *testing.T, data string, maxObjectSize int64) *Result {
+	t.Helper()
+	stream, _ := it.store.GetStream(context.Background(), "", "")
+	result, err := it.ingester.Ingest(context.Background(), testRepository, stream, strings.NewReader(data), maxObjectSize)
+	if err != nil {
+		t.Fatalf("ingest %q: %s", data, err)
+	}
+	return result
+}
+
+func (it *ingestTest) read(t *testing.T, path string) string {
+	t.Helper()
+	entry, ok := it.cataloger.entries[path]
+	if !ok {
+		t.Fatalf("missing entry %s", path)
+	}
+	reader, err := it.adapter.Get(block.ObjectPointer{StorageNamespace: testRepository.StorageNamespace, Identifier: entry.PhysicalAddress}, entry.Size)
+	if err != nil {
+		t.Fatalf("read %s: %s", path, err)
+	}
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("read %s: %s", path, err)
+	}
+	return string(data)
+}
+
+func TestIngester_NDJSON(t *testing.T) {
+	it := newIngestTest(Stream{
+		Name:                "events",
+		Branch:              "main",
+		Prefix:              "events/",
+		Format:              string(FormatNDJSON),
+		MaxFileSizeBytes:    24,
+		RollIntervalSeconds: DefaultRollIntervalSeconds,
+	})
+
+	first := it.ingest(t, "{\"a\":1}\n", 0)
+	if !strings.HasPrefix(first.Path, "events/dt=") || !strings.HasSuffix(first.Path, ".ndjson") {
+		t.Errorf("got file %s", first.Path)
+	}
+	if first.Records != 1 || first.Rolled {
+		t.Errorf("got first result %+v", first)
+	}
+	second := it.ingest(t, "{\"a\":2}\n{\"a\":3}", 0)
+	if second.Path != first.Path {
+		t.Errorf("got file %s after %s, expected the same file", second.Path, first.Path)
+	}
+	if second.Records != 2 || second.SizeBytes != 24 || !second.Rolled {
+		t.Errorf("got second result %+v", second)
+	}
+	if data := it.read(t, first.Path); data != "{\"a\":1}\n{\"a\":2}\n{\"a\":3}\n" {
+		t.Errorf("got file %q", data)
+	}
+
+	third := it.ingest(t, "{\"a\":4}\n", 0)
+	if third.Path == first.Path {
+		t.Errorf("got file %s after roll, expected a new file", third.Path)
+	}
+}
+
+func TestIngester_MaxObjectSize(t *testing.T) {
+	it := newIngestTest(Stream{
+		Name:                "events",
+		Branch:              "main",
+		Format:              string(FormatNDJSON),
+		MaxFileSizeBytes:    DefaultMaxFileSizeBytes,
+		RollIntervalSeconds: DefaultRollIntervalSeconds,
+	})
+	first := it.ingest(t, "{\"a\":1}\n", 12)
+	second := it.ingest(t, "{\"a\":2}\n", 12)
+	if second.Path == first.Path {
+		t.Errorf("got file %s for batch exceeding the object size, expected a new file", second.Path)
+	}
+	if data := it.read(t, second.Path); data != "{\"a\":2}\n" {
+		t.Errorf("got file %q", data)
+	}
+}
+
+func TestIngester_Avro(t *testing.T) {
+	it := newIngestTest(Stream{
+		Name:                "events",
+		Branch:              "main",
+		Prefix:              "avro/",
+		Format:              string(FormatAvro),
+		MaxFileSizeBytes:    DefaultMaxFileSizeBytes,
+		RollIntervalSeconds: DefaultRollIntervalSeconds,
+	})
+	batch := string(avroFile([]byte("0123456789abcdef"), 2))
+	first := it.ingest(t, batch, 0)
+	second := it.ingest(t, batch, 0)
+	if !first.Rolled || !second.Rolled || first.Path == second.Path {
+		t.Errorf("got results %+v and %+v, expected a file per batch", first, second)
+	}
+	if first.Records != 2 {
+		t.Errorf("got %d records", first.Records)
+	}
+	if data := it.read(t, second.Path); data != batch {
+		t.Errorf("got file %q", data)
+	}
+}
+
+func TestIngester_InvalidBatch(t *testing.T) {
+	it := newIngestTest(Stream{
+		Name:                "events",
+		Branch:              "main",
+		Format:              string(FormatNDJSON),
+		MaxFileSizeBytes:    DefaultMaxFileSizeBytes,
+		RollIntervalSeconds: DefaultRollIntervalSeconds,
+	})
+	_, err := it.ingester.Ingest(context.Background(), testRepository, &it.store.stream, strings.NewReader("not json\n"), 0)
+	if !errors.Is(err, ErrInvalidBatch) {
+		t.Errorf("got error %v, expected invalid batch", err)
+	}
+	if len(it.cataloger.entries) != 0 {
+		t.Errorf("got %d entries after invalid batch", len(it.cataloger.entries))
+	}
+}
+
+func TestStream_Validate(t *testing.T) {
+	valid := Stream{
+		Name:                "events",
+		Branch:              "main",
+		Format:              string(FormatNDJSON),
+		MaxFileSizeBytes:    DefaultMaxFileSizeBytes,
+		RollIntervalSeconds: DefaultRollIntervalSeconds,
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("valid stream: %s", err)
+	}
+	cases := map[string]func(s *Stream){
+		"no name":      func(s *Stream) { s.Name = "" },
+		"bad branch":   func(s *Stream) { s.Branch = "a b" },
+		"bad format":   func(s *Stream) { s.Format = "csv" },
+		"no file size": func(s *Stream) { s.MaxFileSizeBytes = 0 },
+		"no roll time": func(s *Stream) { s.RollIntervalSeconds = 0 },
+	}
+	for name, change := range cases {
+		t.Run(name, func(t *testing.T) {
+			stream := valid
+			change(&stream)
+			if err := stream.Validate(); !errors.Is(err, ErrInvalidStream) {
+				t.Errorf("got error %v, expected invalid stream", err)
+			}
+		})
+	}
+}
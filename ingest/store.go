@@ -0,0 +1,123 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/treeverse/lakefs/db"
+)
+
+// Store manages the ingest streams of repositories and their current files
+type Store interface {
+	// SetStream creates or replaces the stream of the repository with the same name.  The
+	// next batch of a replaced stream starts a new file.
+	SetStream(ctx context.Context, stream *Stream) error
+	// GetStream returns the stream name of repositoryID
+	GetStream(ctx context.Context, repositoryID, name string) (*Stream, error)
+	// ListStreams returns the streams of repositoryID, ordered by name
+	ListStreams(ctx context.Context, repositoryID string) ([]*Stream, error)
+	// DeleteStream deletes the stream name of repositoryID
+	DeleteStream(ctx context.Context, repositoryID, name string) error
+	// RollFile makes path, started at startDate, the current file of the stream if its
+	// current file is still previousPath, and returns false otherwise.  An empty path closes
+	// the current file.
+	RollFile(ctx context.Context, repositoryID, name, previousPath, path string, startDate time.Time) (bool, error)
+}
+
+type store struct {
+	db db.Database
+}
+
+var ErrStreamNotFound = fmt.Errorf("ingest stream %w", db.ErrNotFound)
+
+func NewStore(adb db.Database) Store {
+	return &store{
+		db: adb,
+	}
+}
+
+const streamFields = `repository_id, name, branch_id, prefix, format, max_file_size_bytes, roll_interval_seconds,
+	creation_date, current_path, current_start_date`
+
+func (s *store) SetStream(ctx context.Context, stream *Stream) error {
+	if err := stream.Validate(); err != nil {
+		return err
+	}
+	_, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		return tx.Exec(`INSERT INTO ingest_streams (`+streamFields+`)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, '', $8)
+			ON CONFLICT (repository_id, name) DO UPDATE SET branch_id = EXCLUDED.branch_id, prefix = EXCLUDED.prefix,
+				format = EXCLUDED.format, max_file_size_bytes = EXCLUDED.max_file_size_bytes,
+				roll_interval_seconds = EXCLUDED.roll_interval_seconds, current_path = ''`,
+			stream.RepositoryID, stream.Name, stream.Branch, stream.Prefix, stream.Format, stream.MaxFileSizeBytes,
+			stream.RollIntervalSeconds, time.Now().UTC())
+	}, db.WithContext(ctx))
+	return err
+}
+
+func (s *store) GetStream(ctx context.Context, repositoryID, name string) (*Stream, error) {
+	res, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var stream Stream
+		err := tx.Get(&stream, `SELECT `+streamFields+`
+			FROM ingest_streams
+			WHERE repository_id = $1 AND name = $2`,
+			repositoryID, name)
+		return &stream, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, ErrStreamNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res.(*Stream), nil
+}
+
+func (s *store) ListStreams(ctx context.Context, repositoryID string) ([]*Stream, error) {
+	res, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var streams []*Stream
+		err := tx.Select(&streams, `SELECT `+streamFields+`
+			FROM ingest_streams
+			WHERE repository_id = $1
+			ORDER BY name`,
+			repositoryID)
+		return streams, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.([]*Stream), nil
+}
+
+func (s *store) DeleteStream(ctx context.Context, repositoryID, name string) error {
+	_, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		res, err := tx.Exec(`DELETE FROM ingest_streams WHERE repository_id = $1 AND name = $2`,
+			repositoryID, name)
+		if err != nil {
+			return nil, err
+		}
+		if res.RowsAffected() == 0 {
+			return nil, ErrStreamNotFound
+		}
+		return nil, nil
+	}, db.WithContext(ctx))
+	return err
+}
+
+func (s *store) RollFile(ctx context.Context, repositoryID, name, previousPath, path string, startDate time.Time) (bool, error) {
+	res, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		res, err := tx.Exec(`UPDATE ingest_streams SET current_path = $4, current_start_date = $5
+			WHERE repository_id = $1 AND name = $2 AND current_path = $3`,
+			repositoryID, name, previousPath, path, startDate.UTC())
+		if err != nil {
+			return nil, err
+		}
+		return res.RowsAffected() == 1, nil
+	}, db.WithContext(ctx))
+	if err != nil {
+		return false, err
+	}
+	return res.(bool), nil
+}
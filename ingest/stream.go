@@ -0,0 +1,85 @@
+// Package ingest lands streams of records on branches.  Batches of records posted to a stream
+// are appended to files under the prefix of the stream, in the staging area of its branch,
+// rolling to a new file once the current file is large or old enough.
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+// Format is the encoding of the records of a stream
+type Format string
+
+const (
+	// FormatNDJSON batches are newline delimited JSON records, appended to the current file
+	FormatNDJSON Format = "ndjson"
+	// FormatAvro batches are Avro object container files, each written to a file of its own
+	FormatAvro Format = "avro"
+)
+
+const (
+	DefaultMaxFileSizeBytes    = 128 * 1024 * 1024
+	DefaultRollIntervalSeconds = 300
+)
+
+var ErrInvalidStream = errors.New("invalid ingest stream")
+
+// Stream writes the records ingested to files under Prefix on Branch
+type Stream struct {
+	RepositoryID string `db:"repository_id"`
+	Name         string `db:"name"`
+	Branch       string `db:"branch_id"`
+	Prefix       string `db:"prefix"`
+	Format       string `db:"format"`
+	// MaxFileSizeBytes rolls the current file once it holds this many bytes
+	MaxFileSizeBytes int64 `db:"max_file_size_bytes"`
+	// RollIntervalSeconds rolls the current file once this many seconds passed since it was
+	// started
+	RollIntervalSeconds int       `db:"roll_interval_seconds"`
+	CreationDate        time.Time `db:"creation_date"`
+	// CurrentPath is the file batches are appended to, empty if the next batch starts a file
+	CurrentPath      string    `db:"current_path"`
+	CurrentStartDate time.Time `db:"current_start_date"`
+}
+
+// Validate returns ErrInvalidStream if the stream cannot be used
+func (s *Stream) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("missing name: %w", ErrInvalidStream)
+	}
+	if err := catalog.ValidateBranchID(graveler.BranchID(s.Branch)); err != nil {
+		return fmt.Errorf("branch '%s': %w", s.Branch, ErrInvalidStream)
+	}
+	switch Format(s.Format) {
+	case FormatNDJSON, FormatAvro:
+	default:
+		return fmt.Errorf("format '%s' unknown: %w", s.Format, ErrInvalidStream)
+	}
+	if s.MaxFileSizeBytes <= 0 {
+		return fmt.Errorf("max_file_size_bytes must be positive: %w", ErrInvalidStream)
+	}
+	if s.RollIntervalSeconds <= 0 {
+		return fmt.Errorf("roll_interval_seconds must be positive: %w", ErrInvalidStream)
+	}
+	return nil
+}
+
+// rollDue returns true if the next batch ingested at now starts a new file
+func (s *Stream) rollDue(now time.Time) bool {
+	return Format(s.Format) == FormatAvro ||
+		s.CurrentPath == "" ||
+		now.Sub(s.CurrentStartDate) >= time.Duration(s.RollIntervalSeconds)*time.Second
+}
+
+// filePath returns the path of a new file started at startDate, partitioned by date
+func (s *Stream) filePath(startDate time.Time) string {
+	uid := uuid.New()
+	startDate = startDate.UTC()
+	return fmt.Sprintf("%sdt=%s/part-%s-%x.%s", s.Prefix, startDate.Format("2006-01-02"), startDate.Format("150405"), uid[:4], s.Format)
+}
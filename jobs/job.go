@@ -0,0 +1,127 @@
+// Package jobs runs long operations, such as garbage collection and exports, in the
+// background.  Submitted jobs are recorded in the database with their status, progress and
+// log, so operators can poll and cancel them from any lakeFS instance.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/treeverse/lakefs/db"
+)
+
+const (
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusCanceled  = "canceled"
+)
+
+const (
+	TypeGarbageCollection = "garbage_collection"
+	TypeExportDiff        = "export_diff"
+)
+
+// MaxLogLines is the number of log lines retained for a job, older lines are dropped
+const MaxLogLines = 1000
+
+var (
+	ErrJobNotFound = fmt.Errorf("job %w", db.ErrNotFound)
+	ErrJobFinished = errors.New("job already finished")
+)
+
+type Job struct {
+	ID              string          `db:"id"`
+	Type            string          `db:"type"`
+	Repository      string          `db:"repository_id"`
+	CreatedBy       string          `db:"created_by"`
+	Status          string          `db:"status"`
+	Progress        float64         `db:"progress"`
+	Error           string          `db:"error"`
+	Result          json.RawMessage `db:"result"`
+	Log             []string        `db:"log"`
+	CancelRequested bool            `db:"cancel_requested"`
+	CreationDate    time.Time       `db:"creation_date"`
+	UpdateDate      time.Time       `db:"update_date"`
+}
+
+// Finished returns true if the job is no longer running
+func (j *Job) Finished() bool {
+	return j.Status != StatusRunning
+}
+
+// reporter collects the progress and log of a running job until they are written to the
+// store
+type reporter struct {
+	mu       sync.Mutex
+	progress float64
+	log      []string
+	dirty    bool
+}
+
+func (r *reporter) setProgress(percent float64) {
+	if percent < 0 {
+		percent = 0
+	} else if percent > 100 {
+		percent = 100
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if percent != r.progress {
+		r.progress = percent
+		r.dirty = true
+	}
+}
+
+func (r *reporter) logf(format string, args ...interface{}) {
+	line := time.Now().UTC().Format(time.RFC3339) + " " + fmt.Sprintf(format, args...)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.log = append(r.log, line)
+	if len(r.log) > MaxLogLines {
+		r.log = append([]string(nil), r.log[len(r.log)-MaxLogLines:]...)
+	}
+	r.dirty = true
+}
+
+// snapshot copies the progress and log into job, returning true if they changed since the
+// last snapshot
+func (r *reporter) snapshot(job *Job) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job.Progress = r.progress
+	job.Log = append([]string(nil), r.log...)
+	dirty := r.dirty
+	r.dirty = false
+	return dirty
+}
+
+type contextKey struct{}
+
+func withReporter(ctx context.Context, r *reporter) context.Context {
+	return context.WithValue(ctx, contextKey{}, r)
+}
+
+func reporterFromContext(ctx context.Context) *reporter {
+	r, _ := ctx.Value(contextKey{}).(*reporter)
+	return r
+}
+
+// SetProgress reports the percentage of the job running with ctx that is done.  It does
+// nothing outside of a job.
+func SetProgress(ctx context.Context, percent float64) {
+	if r := reporterFromContext(ctx); r != nil {
+		r.setProgress(percent)
+	}
+}
+
+// Logf adds a line to the log of the job running with ctx.  It does nothing outside of a job.
+func Logf(ctx context.Context, format string, args ...interface{}) {
+	if r := reporterFromContext(ctx); r != nil {
+		r.logf(format, args...)
+	}
+}
@@ -0,0 +1,223 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/treeverse/lakefs/logging"
+)
+
+const (
+	// DefaultUpdateInterval is the interval at which the progress and log of running jobs are
+	// written, and cancel requests from other instances are checked
+	DefaultUpdateInterval = 2 * time.Second
+	// DefaultRetention is the time finished jobs are kept
+	DefaultRetention = 7 * 24 * time.Hour
+	// DefaultCleanupInterval is the interval at which expired jobs are deleted
+	DefaultCleanupInterval = time.Hour
+)
+
+var errShutdown = errors.New("lakeFS shutting down")
+
+// RunFunc runs a job until ctx is done, returning its result.  It reports progress and log
+// lines with SetProgress and Logf on ctx.
+type RunFunc func(ctx context.Context) (interface{}, error)
+
+// Manager runs submitted jobs in the background and records them in the store
+type Manager struct {
+	store          Store
+	updateInterval time.Duration
+	log            logging.Logger
+
+	mu       sync.Mutex
+	running  map[string]context.CancelFunc
+	shutdown bool
+	wg       sync.WaitGroup
+}
+
+func NewManager(store Store, updateInterval time.Duration) *Manager {
+	if updateInterval <= 0 {
+		updateInterval = DefaultUpdateInterval
+	}
+	return &Manager{
+		store:          store,
+		updateInterval: updateInterval,
+		log:            logging.Default().WithField("service_name", "jobs"),
+		running:        make(map[string]context.CancelFunc),
+	}
+}
+
+// Submit records a new job of jobType on repository and starts running it in the background.
+// The job does not use ctx once submitted.
+func (m *Manager) Submit(ctx context.Context, jobType, repository, createdBy string, run RunFunc) (*Job, error) {
+	now := time.Now().UTC()
+	job := &Job{
+		ID:           uuid.New().String(),
+		Type:         jobType,
+		Repository:   repository,
+		CreatedBy:    createdBy,
+		Status:       StatusRunning,
+		CreationDate: now,
+		UpdateDate:   now,
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.shutdown {
+		return nil, errShutdown
+	}
+	if err := m.store.CreateJob(ctx, job); err != nil {
+		return nil, err
+	}
+	jobCtx, cancel := context.WithCancel(context.Background())
+	m.running[job.ID] = cancel
+	m.wg.Add(1)
+	go m.run(jobCtx, cancel, *job, run)
+	return job, nil
+}
+
+func (m *Manager) run(ctx context.Context, cancel context.CancelFunc, job Job, run RunFunc) {
+	defer m.wg.Done()
+	defer cancel()
+	log := m.log.WithFields(logging.Fields{
+		"job_id":     job.ID,
+		"job_type":   job.Type,
+		"repository": job.Repository,
+	})
+	r := &reporter{}
+	done := make(chan struct{})
+	go m.update(ctx, cancel, job.ID, r, done, log)
+
+	log.Info("Job started")
+	result, err := run(withReporter(ctx, r))
+	close(done)
+
+	m.mu.Lock()
+	delete(m.running, job.ID)
+	shutdown := m.shutdown
+	m.mu.Unlock()
+
+	switch {
+	case err == nil:
+		job.Status = StatusCompleted
+		r.setProgress(100)
+		if result != nil {
+			job.Result, err = json.Marshal(result)
+			if err != nil {
+				job.Status = StatusFailed
+				job.Error = err.Error()
+			}
+		}
+	case ctx.Err() != nil && shutdown:
+		job.Status = StatusFailed
+		job.Error = errShutdown.Error()
+		r.logf("Interrupted by shutdown, submit the job again to rerun it")
+	case ctx.Err() != nil:
+		job.Status = StatusCanceled
+		job.Error = err.Error()
+	default:
+		job.Status = StatusFailed
+		job.Error = err.Error()
+	}
+	r.snapshot(&job)
+	job.UpdateDate = time.Now().UTC()
+	if err := m.store.FinishJob(context.Background(), &job); err != nil {
+		log.WithError(err).Error("Failed to record finished job")
+		return
+	}
+	log.WithField("status", job.Status).Info("Job finished")
+}
+
+// update writes the progress and log of job id every interval until done, canceling the job
+// when requested from another instance
+func (m *Manager) update(ctx context.Context, cancel context.CancelFunc, id string, r *reporter, done <-chan struct{}, log logging.Logger) {
+	ticker := time.NewTicker(m.updateInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			update := Job{ID: id}
+			r.snapshot(&update)
+			cancelRequested, err := m.store.UpdateJob(ctx, &update)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.WithError(err).Warn("Failed to update job progress")
+				}
+				continue
+			}
+			if cancelRequested {
+				cancel()
+			}
+		}
+	}
+}
+
+func (m *Manager) Get(ctx context.Context, id string) (*Job, error) {
+	return m.store.GetJob(ctx, id)
+}
+
+func (m *Manager) List(ctx context.Context, repository string, amount int, after string) ([]*Job, bool, error) {
+	return m.store.ListJobs(ctx, repository, amount, after)
+}
+
+// Cancel requests job id to stop.  A job running on this instance is stopped right away, one
+// running on another instance stops when that instance next updates it.
+func (m *Manager) Cancel(ctx context.Context, id string) error {
+	if err := m.store.RequestCancel(ctx, id); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	cancel := m.running[id]
+	m.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	return nil
+}
+
+// Run deletes the jobs finished more than retention ago every interval until ctx is done
+func (m *Manager) Run(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := m.store.DeleteFinishedJobs(ctx, time.Now().Add(-retention))
+			if err != nil {
+				m.log.WithError(err).Error("Failed to delete expired jobs")
+			} else if n > 0 {
+				m.log.WithField("deleted", n).Debug("Deleted expired jobs")
+			}
+		}
+	}
+}
+
+// Shutdown stops accepting jobs, stops the jobs running on this instance and waits until ctx
+// is done for them to be recorded as failed, with their progress and log at the time they
+// stopped
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	m.shutdown = true
+	for _, cancel := range m.running {
+		cancel()
+	}
+	m.mu.Unlock()
+	stopped := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
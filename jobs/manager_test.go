@@ -0,0 +1,224 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeStore struct {
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{jobs: make(map[string]*Job)}
+}
+
+func (s *fakeStore) CreateJob(_ context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j := *job
+	s.jobs[job.ID] = &j
+	return nil
+}
+
+func (s *fakeStore) GetJob(_ context.Context, id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	j := *job
+	return &j, nil
+}
+
+func (s *fakeStore) ListJobs(context.Context, string, int, string) ([]*Job, bool, error) {
+	return nil, false, nil
+}
+
+func (s *fakeStore) UpdateJob(_ context.Context, job *Job) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[job.ID]
+	if !ok {
+		return false, ErrJobNotFound
+	}
+	j.Progress = job.Progress
+	j.Log = job.Log
+	return j.CancelRequested, nil
+}
+
+func (s *fakeStore) FinishJob(_ context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j := *job
+	s.jobs[job.ID] = &j
+	return nil
+}
+
+func (s *fakeStore) RequestCancel(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id]
+	if !ok {
+		return ErrJobNotFound
+	}
+	if j.Finished() {
+		return ErrJobFinished
+	}
+	j.CancelRequested = true
+	return nil
+}
+
+func (s *fakeStore) DeleteFinishedJobs(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+// waitFinished polls id until it finishes
+func waitFinished(t *testing.T, m *Manager, id string) *Job {
+	t.Helper()
+	for i := 0; i < 500; i++ {
+		job, err := m.Get(context.Background(), id)
+		if err != nil {
+			t.Fatalf("Get(%s) error = %v", id, err)
+		}
+		if job.Finished() {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return nil
+}
+
+func TestManager_Completed(t *testing.T) {
+	m := NewManager(newFakeStore(), 10*time.Millisecond)
+	job, err := m.Submit(context.Background(), TypeGarbageCollection, "repo", "alice", func(ctx context.Context) (interface{}, error) {
+		Logf(ctx, "removing %d objects", 2)
+		SetProgress(ctx, 50)
+		return map[string]int{"removed": 2}, nil
+	})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if job.Status != StatusRunning {
+		t.Errorf("submitted job status %s, expected %s", job.Status, StatusRunning)
+	}
+	job = waitFinished(t, m, job.ID)
+	if job.Status != StatusCompleted || job.Progress != 100 || job.Error != "" {
+		t.Errorf("job status %s progress %f error %q, expected completed at 100", job.Status, job.Progress, job.Error)
+	}
+	var result map[string]int
+	if err := json.Unmarshal(job.Result, &result); err != nil || result["removed"] != 2 {
+		t.Errorf("job result %s, expected removed 2", job.Result)
+	}
+	if len(job.Log) != 1 || len(job.Log[0]) == 0 {
+		t.Errorf("job log %v, expected one line", job.Log)
+	}
+}
+
+func TestManager_Failed(t *testing.T) {
+	m := NewManager(newFakeStore(), 10*time.Millisecond)
+	job, err := m.Submit(context.Background(), TypeExportDiff, "repo", "alice", func(ctx context.Context) (interface{}, error) {
+		return nil, errors.New("no space left")
+	})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	job = waitFinished(t, m, job.ID)
+	if job.Status != StatusFailed || job.Error != "no space left" {
+		t.Errorf("job status %s error %q, expected failed with no space left", job.Status, job.Error)
+	}
+	if err := m.Cancel(context.Background(), job.ID); !errors.Is(err, ErrJobFinished) {
+		t.Errorf("Cancel() finished job err=%v, expected %s", err, ErrJobFinished)
+	}
+}
+
+func TestManager_Cancel(t *testing.T) {
+	store := newFakeStore()
+	m := NewManager(store, 10*time.Millisecond)
+	run := func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	job, err := m.Submit(context.Background(), TypeGarbageCollection, "repo", "alice", run)
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if err := m.Cancel(context.Background(), job.ID); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	if job = waitFinished(t, m, job.ID); job.Status != StatusCanceled {
+		t.Errorf("job status %s, expected %s", job.Status, StatusCanceled)
+	}
+
+	// cancel requested by another instance is picked up on update
+	job, err = m.Submit(context.Background(), TypeGarbageCollection, "repo", "alice", run)
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if err := store.RequestCancel(context.Background(), job.ID); err != nil {
+		t.Fatalf("RequestCancel() error = %v", err)
+	}
+	if job = waitFinished(t, m, job.ID); job.Status != StatusCanceled {
+		t.Errorf("job status %s, expected %s", job.Status, StatusCanceled)
+	}
+
+	if err := m.Cancel(context.Background(), "missing"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Cancel() missing job err=%v, expected %s", err, ErrJobNotFound)
+	}
+}
+
+func TestManager_Shutdown(t *testing.T) {
+	m := NewManager(newFakeStore(), 10*time.Millisecond)
+	job, err := m.Submit(context.Background(), TypeGarbageCollection, "repo", "alice", func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if job = waitFinished(t, m, job.ID); job.Status != StatusFailed || len(job.Log) != 1 {
+		t.Errorf("job status %s log %v, expected %s with interruption logged", job.Status, job.Log, StatusFailed)
+	}
+	if _, err := m.Submit(context.Background(), TypeGarbageCollection, "repo", "alice", nil); err == nil {
+		t.Error("Submit() after shutdown succeeded")
+	}
+}
+
+func TestReporter_Log(t *testing.T) {
+	r := &reporter{}
+	ctx := withReporter(context.Background(), r)
+	for i := 0; i < MaxLogLines+5; i++ {
+		Logf(ctx, "line %d", i)
+	}
+	SetProgress(ctx, 150)
+	var job Job
+	if !r.snapshot(&job) {
+		t.Error("snapshot() not dirty after updates")
+	}
+	if r.snapshot(&job) {
+		t.Error("snapshot() dirty without updates")
+	}
+	if len(job.Log) != MaxLogLines {
+		t.Fatalf("log has %d lines, expected %d", len(job.Log), MaxLogLines)
+	}
+	if last := job.Log[len(job.Log)-1]; len(last) < 9 || last[len(last)-9:] != fmt.Sprintf("line %d", MaxLogLines+4) {
+		t.Errorf("last log line %q, expected line %d", last, MaxLogLines+4)
+	}
+	if job.Progress != 100 {
+		t.Errorf("progress %f, expected 100", job.Progress)
+	}
+	// outside of a job reporting does nothing
+	SetProgress(context.Background(), 10)
+	Logf(context.Background(), "ignored")
+}
@@ -0,0 +1,171 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/treeverse/lakefs/db"
+)
+
+type Store interface {
+	CreateJob(ctx context.Context, job *Job) error
+	GetJob(ctx context.Context, id string) (*Job, error)
+	// ListJobs lists the jobs of repository, newest first, starting after the job with ID after
+	ListJobs(ctx context.Context, repository string, amount int, after string) ([]*Job, bool, error)
+	// UpdateJob writes the progress and log of a running job, returning true if it was
+	// requested to cancel
+	UpdateJob(ctx context.Context, job *Job) (bool, error)
+	// FinishJob writes the final status, error, result, progress and log of a job
+	FinishJob(ctx context.Context, job *Job) error
+	// RequestCancel marks a running job to be canceled by the instance running it
+	RequestCancel(ctx context.Context, id string) error
+	// DeleteFinishedJobs deletes the jobs that finished before t
+	DeleteFinishedJobs(ctx context.Context, t time.Time) (int64, error)
+}
+
+const jobFields = `id, type, repository_id, created_by, status, progress, error, result, log, cancel_requested, creation_date, update_date`
+
+type store struct {
+	db db.Database
+}
+
+func NewStore(adb db.Database) Store {
+	return &store{db: adb}
+}
+
+func (s *store) CreateJob(ctx context.Context, job *Job) error {
+	log := job.Log
+	if log == nil {
+		log = []string{}
+	}
+	_, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		return tx.Exec(`INSERT INTO jobs (`+jobFields+`)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+			job.ID, job.Type, job.Repository, job.CreatedBy, job.Status, job.Progress, job.Error,
+			nullJSON(job.Result), log, job.CancelRequested, job.CreationDate, job.UpdateDate)
+	}, db.WithContext(ctx))
+	return err
+}
+
+func (s *store) GetJob(ctx context.Context, id string) (*Job, error) {
+	res, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var job Job
+		err := tx.Get(&job, `SELECT `+jobFields+` FROM jobs WHERE id = $1`, id)
+		return &job, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res.(*Job), nil
+}
+
+func (s *store) ListJobs(ctx context.Context, repository string, amount int, after string) ([]*Job, bool, error) {
+	type result struct {
+		jobs    []*Job
+		hasMore bool
+	}
+	res, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var jobs []*Job
+		var err error
+		if after == "" {
+			err = tx.Select(&jobs, `SELECT `+jobFields+`
+				FROM jobs
+				WHERE repository_id = $1
+				ORDER BY creation_date DESC, id DESC
+				LIMIT $2`,
+				repository, amount+1)
+		} else {
+			err = tx.Select(&jobs, `SELECT `+jobFields+`
+				FROM jobs
+				WHERE repository_id = $1 AND (creation_date, id) < (SELECT creation_date, id FROM jobs WHERE id = $2)
+				ORDER BY creation_date DESC, id DESC
+				LIMIT $3`,
+				repository, after, amount+1)
+		}
+		if err != nil {
+			return nil, err
+		}
+		hasMore := len(jobs) > amount
+		if hasMore {
+			jobs = jobs[:amount]
+		}
+		return &result{jobs: jobs, hasMore: hasMore}, nil
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return nil, false, err
+	}
+	r := res.(*result)
+	return r.jobs, r.hasMore, nil
+}
+
+func (s *store) UpdateJob(ctx context.Context, job *Job) (bool, error) {
+	res, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var cancelRequested bool
+		err := tx.GetPrimitive(&cancelRequested, `UPDATE jobs SET progress = $2, log = $3, update_date = $4
+			WHERE id = $1
+			RETURNING cancel_requested`,
+			job.ID, job.Progress, job.Log, time.Now().UTC())
+		return cancelRequested, err
+	}, db.WithContext(ctx))
+	if errors.Is(err, db.ErrNotFound) {
+		return false, ErrJobNotFound
+	}
+	if err != nil {
+		return false, err
+	}
+	return res.(bool), nil
+}
+
+func (s *store) FinishJob(ctx context.Context, job *Job) error {
+	_, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		return tx.Exec(`UPDATE jobs SET status = $2, progress = $3, error = $4, result = $5, log = $6, update_date = $7
+			WHERE id = $1`,
+			job.ID, job.Status, job.Progress, job.Error, nullJSON(job.Result), job.Log, job.UpdateDate)
+	}, db.WithContext(ctx))
+	return err
+}
+
+func (s *store) RequestCancel(ctx context.Context, id string) error {
+	_, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var status string
+		err := tx.GetPrimitive(&status, `SELECT status FROM jobs WHERE id = $1 FOR UPDATE`, id)
+		if errors.Is(err, db.ErrNotFound) {
+			return nil, ErrJobNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		if status != StatusRunning {
+			return nil, ErrJobFinished
+		}
+		return tx.Exec(`UPDATE jobs SET cancel_requested = true, update_date = $2 WHERE id = $1`,
+			id, time.Now().UTC())
+	}, db.WithContext(ctx))
+	return err
+}
+
+func (s *store) DeleteFinishedJobs(ctx context.Context, t time.Time) (int64, error) {
+	res, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		res, err := tx.Exec(`DELETE FROM jobs WHERE status <> $1 AND update_date < $2`, StatusRunning, t)
+		if err != nil {
+			return nil, err
+		}
+		return res.RowsAffected(), nil
+	}, db.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	return res.(int64), nil
+}
+
+// nullJSON returns nil for an empty result, stored as NULL
+func nullJSON(b []byte) interface{} {
+	if len(b) == 0 {
+		return nil
+	}
+	return string(b)
+}
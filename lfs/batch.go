@@ -0,0 +1,116 @@
+package lfs
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// MediaType is the content type of the requests and responses of the batch API
+	MediaType = "application/vnd.git-lfs+json"
+
+	OperationDownload = "download"
+	OperationUpload   = "upload"
+
+	// TransferBasic is the only transfer adapter served, objects are transferred by a single
+	// GET or PUT of their data
+	TransferBasic = "basic"
+
+	// ObjectsPrefix is the prefix of the paths of LFS objects on their branch
+	ObjectsPrefix = "lfs/objects/"
+
+	// ActionExpiresIn is the time the hrefs of the actions of a batch response are valid for
+	ActionExpiresIn = time.Hour
+
+	oidLength = 64
+)
+
+var (
+	ErrInvalidOID       = errors.New("invalid object ID")
+	ErrInvalidSize      = errors.New("invalid object size")
+	ErrInvalidOperation = errors.New("invalid operation")
+	ErrSizeMismatch     = errors.New("object size mismatch")
+	ErrOIDMismatch      = errors.New("object ID does not match the object data")
+)
+
+// BatchRequest is the request of the batch API
+type BatchRequest struct {
+	Operation string          `json:"operation"`
+	Transfers []string        `json:"transfers,omitempty"`
+	Ref       *BatchRef       `json:"ref,omitempty"`
+	Objects   []*BatchPointer `json:"objects"`
+}
+
+type BatchRef struct {
+	Name string `json:"name"`
+}
+
+// BatchPointer identifies an LFS object by the SHA-256 of its data and its size
+type BatchPointer struct {
+	OID  string `json:"oid"`
+	Size int64  `json:"size"`
+}
+
+// BatchResponse is the response of the batch API
+type BatchResponse struct {
+	Transfer string         `json:"transfer,omitempty"`
+	Objects  []*BatchObject `json:"objects"`
+}
+
+// BatchObject is the result of the batch API for an object.  An object with neither actions nor
+// an error needs no transfer.
+type BatchObject struct {
+	OID           string             `json:"oid"`
+	Size          int64              `json:"size"`
+	Authenticated bool               `json:"authenticated,omitempty"`
+	Actions       map[string]*Action `json:"actions,omitempty"`
+	Error         *ObjectError       `json:"error,omitempty"`
+}
+
+// Action is how a client transfers an object
+type Action struct {
+	Href      string            `json:"href"`
+	Header    map[string]string `json:"header,omitempty"`
+	ExpiresIn int               `json:"expires_in,omitempty"`
+}
+
+type ObjectError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// ErrorResponse is the body of the responses of failed requests
+type ErrorResponse struct {
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Validate returns an error if the pointer cannot identify an LFS object
+func (p *BatchPointer) Validate() error {
+	if err := ValidateOID(p.OID); err != nil {
+		return err
+	}
+	if p.Size < 0 {
+		return ErrInvalidSize
+	}
+	return nil
+}
+
+// ValidateOID returns an error unless oid is a lowercase hex SHA-256
+func ValidateOID(oid string) error {
+	if len(oid) != oidLength {
+		return ErrInvalidOID
+	}
+	for _, c := range oid {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return ErrInvalidOID
+		}
+	}
+	return nil
+}
+
+// ObjectPath returns the path of the LFS object oid on its branch, laid out as in the objects
+// directory of git LFS
+func ObjectPath(oid string) string {
+	return ObjectsPrefix + oid[0:2] + "/" + oid[2:4] + "/" + oid
+}
@@ -0,0 +1,425 @@
+package lfs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/treeverse/lakefs/auth"
+	"github.com/treeverse/lakefs/auth/model"
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/encryption"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/httputil"
+	"github.com/treeverse/lakefs/logging"
+	"github.com/treeverse/lakefs/permissions"
+	"github.com/treeverse/lakefs/upload"
+)
+
+const (
+	// PathPrefix starts the URLs of the LFS API.  The LFS URL of a ref is
+	// <lakeFS endpoint>/lfs/<repository>/<ref>, it serves the batch API at objects/batch and
+	// transfers objects at objects/<oid>.
+	PathPrefix = "/lfs/"
+
+	// AuthenticateHeader asks LFS clients for credentials
+	AuthenticateHeader = "LFS-Authenticate"
+	authenticateRealm  = `Basic realm="lakeFS"`
+)
+
+var (
+	ErrAuthenticationRequired = errors.New("authentication required")
+	ErrAuthenticationFailed   = errors.New("error authenticating request")
+	ErrAccessDenied           = errors.New("access denied")
+	ErrObjectNotFound         = errors.New("object not found")
+)
+
+// AuthService is the part of the auth service that authenticates and authorizes LFS requests
+type AuthService interface {
+	GetCredentials(accessKey string) (*model.Credential, error)
+	MarkCredentialsUsed(accessKey string) error
+	GetUserByID(userID int) (*model.User, error)
+	Authorize(req *auth.AuthorizationRequest) (*auth.AuthorizationResponse, error)
+}
+
+// Handler serves the git LFS batch API and its basic transfers for the refs of repositories.
+// LFS objects are kept on the ref under ObjectPath of their ID, so they are versioned with the
+// rest of the branch: uploads are uncommitted changes of the branch, and downloads from a
+// commit or a tag return the objects committed there.  Objects under encrypted prefixes are
+// encrypted by Encryptor like objects uploaded through the API.
+type Handler struct {
+	Cataloger    catalog.Cataloger
+	BlockAdapter block.Adapter
+	Encryptor    *encryption.Encryptor
+	Auth         AuthService
+}
+
+func NewHandler(cataloger catalog.Cataloger, adapter block.Adapter, encryptor *encryption.Encryptor, authService AuthService) http.Handler {
+	return &Handler{
+		Cataloger:    cataloger,
+		BlockAdapter: adapter,
+		Encryptor:    encryptor,
+		Auth:         authService,
+	}
+}
+
+// request is a request authenticated for a ref of a repository
+type request struct {
+	*http.Request
+	username   string
+	repository *catalog.Repository
+	ref        string
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r, _ = httputil.RequestID(r)
+	// <repository>/<ref>/objects/<batch or oid>
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, PathPrefix), "/")
+	const pathParts = 4
+	if len(parts) != pathParts || parts[0] == "" || parts[1] == "" || parts[2] != "objects" {
+		writeError(w, r, http.StatusNotFound, "not found")
+		return
+	}
+	username, err := h.authenticate(r)
+	if errors.Is(err, auth.ErrAddressNotPermitted) {
+		writeError(w, r, http.StatusForbidden, err.Error())
+		return
+	}
+	if err != nil {
+		w.Header().Set(AuthenticateHeader, authenticateRealm)
+		writeError(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+	repository, err := h.Cataloger.GetRepository(r.Context(), parts[0])
+	if errors.Is(err, db.ErrNotFound) {
+		writeError(w, r, http.StatusNotFound, "repository not found")
+		return
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).WithField("repository", parts[0]).Error("get repository")
+		writeError(w, r, http.StatusInternalServerError, "get repository")
+		return
+	}
+	r = r.WithContext(catalog.WithPathLeaseOwner(r.Context(), username))
+	req := &request{Request: r, username: username, repository: repository, ref: parts[1]}
+
+	switch {
+	case parts[3] == "batch" && r.Method == http.MethodPost:
+		h.serveBatch(w, req)
+	case parts[3] != "batch" && r.Method == http.MethodGet:
+		h.serveDownload(w, req, parts[3])
+	case parts[3] != "batch" && r.Method == http.MethodPut:
+		h.serveUpload(w, req, parts[3])
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// authenticate returns the username of the lakeFS access key pair of the basic authentication
+// of r
+func (h *Handler) authenticate(r *http.Request) (string, error) {
+	accessKey, secretKey, ok := r.BasicAuth()
+	if !ok {
+		return "", ErrAuthenticationRequired
+	}
+	credentials, err := h.Auth.GetCredentials(accessKey)
+	if err != nil || !credentials.SecretMatches(secretKey, time.Now()) {
+		return "", ErrAuthenticationFailed
+	}
+	if !credentials.NetworkPolicy.Permits(httputil.SourceIPFromRequest(r)) {
+		return "", auth.ErrAddressNotPermitted
+	}
+	if err := h.Auth.MarkCredentialsUsed(accessKey); err != nil {
+		logging.FromContext(r.Context()).WithError(err).WithField("access_key", accessKey).Warn("Failed to record credentials use")
+	}
+	user, err := h.Auth.GetUserByID(credentials.UserID)
+	if err != nil {
+		return "", ErrAuthenticationFailed
+	}
+	return user.Username, nil
+}
+
+// authorize returns ErrAccessDenied unless the user of req may perform the operation on the LFS
+// object oid
+func (h *Handler) authorize(req *request, operation, oid string) error {
+	action := permissions.ReadObjectAction
+	var deniable []permissions.Permission
+	if operation == OperationUpload {
+		action = permissions.WriteObjectAction
+		deniable = sandboxPermissions(req)
+	}
+	authResp, err := h.Auth.Authorize(&auth.AuthorizationRequest{
+		Username: req.username,
+		RequiredPermissions: []permissions.Permission{
+			{
+				Action:   action,
+				Resource: permissions.ObjectArn(req.repository.Name, ObjectPath(oid)),
+			},
+		},
+		DeniablePermissions: deniable,
+	})
+	if err != nil {
+		return err
+	}
+	if authResp.Error != nil || !authResp.Allowed {
+		return ErrAccessDenied
+	}
+	return nil
+}
+
+// sandboxPermissions returns the deniable permissions of uploading to the ref of req, policies
+// denying them confine the user to its sandbox branch
+func sandboxPermissions(req *request) []permissions.Permission {
+	if req.ref == catalog.SandboxBranchID(req.username) {
+		return nil
+	}
+	return []permissions.Permission{
+		{
+			Action:   permissions.WriteOutsideSandboxAction,
+			Resource: permissions.BranchArn(req.repository.Name, req.ref),
+		},
+	}
+}
+
+// getObject returns the entry of the LFS object of pointer on the ref of req, ErrObjectNotFound
+// if the ref holds no such object
+func (h *Handler) getObject(ctx context.Context, req *request, pointer *BatchPointer) (*catalog.DBEntry, error) {
+	entry, err := h.Cataloger.GetEntry(ctx, req.repository.Name, req.ref, ObjectPath(pointer.OID), catalog.GetEntryParams{NoFollowLinks: true})
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if entry.LinkTarget != "" || (pointer.Size >= 0 && entry.Size != pointer.Size) {
+		return nil, ErrObjectNotFound
+	}
+	return entry, nil
+}
+
+func (h *Handler) serveBatch(w http.ResponseWriter, req *request) {
+	var batch BatchRequest
+	if err := json.NewDecoder(req.Body).Decode(&batch); err != nil {
+		writeError(w, req.Request, http.StatusUnprocessableEntity, fmt.Sprintf("decode batch request: %s", err))
+		return
+	}
+	if batch.Operation != OperationDownload && batch.Operation != OperationUpload {
+		writeError(w, req.Request, http.StatusUnprocessableEntity, fmt.Sprintf("%s: %s", ErrInvalidOperation, batch.Operation))
+		return
+	}
+	if len(batch.Transfers) > 0 && !containsString(batch.Transfers, TransferBasic) {
+		writeError(w, req.Request, http.StatusUnprocessableEntity, "only the basic transfer adapter is supported")
+		return
+	}
+	resp := &BatchResponse{
+		Transfer: TransferBasic,
+		Objects:  make([]*BatchObject, 0, len(batch.Objects)),
+	}
+	for _, pointer := range batch.Objects {
+		resp.Objects = append(resp.Objects, h.batchObject(req, batch.Operation, pointer))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// batchObject returns the action that performs operation on the object of pointer, no action
+// when an upload finds the object already on the ref
+func (h *Handler) batchObject(req *request, operation string, pointer *BatchPointer) *BatchObject {
+	obj := &BatchObject{OID: pointer.OID, Size: pointer.Size}
+	if err := pointer.Validate(); err != nil {
+		obj.Error = &ObjectError{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+		return obj
+	}
+	if err := h.authorize(req, operation, pointer.OID); err != nil {
+		obj.Error = &ObjectError{Code: http.StatusForbidden, Message: err.Error()}
+		return obj
+	}
+	_, err := h.getObject(req.Context(), req, pointer)
+	switch {
+	case errors.Is(err, ErrObjectNotFound) && operation == OperationDownload:
+		obj.Error = &ObjectError{Code: http.StatusNotFound, Message: err.Error()}
+	case err != nil && !errors.Is(err, ErrObjectNotFound):
+		logging.FromContext(req.Context()).WithError(err).WithField("oid", pointer.OID).Error("get LFS object")
+		obj.Error = &ObjectError{Code: http.StatusInternalServerError, Message: "get object"}
+	case err == nil && operation == OperationUpload:
+		// the ref already holds the object
+	default:
+		obj.Authenticated = true
+		obj.Actions = map[string]*Action{
+			operation: {
+				Href:      objectURL(req, pointer.OID),
+				Header:    actionHeader(req),
+				ExpiresIn: int(ActionExpiresIn / time.Second),
+			},
+		}
+	}
+	return obj
+}
+
+func (h *Handler) serveDownload(w http.ResponseWriter, req *request, oid string) {
+	if err := ValidateOID(oid); err != nil {
+		writeError(w, req.Request, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if err := h.authorize(req, OperationDownload, oid); err != nil {
+		writeError(w, req.Request, http.StatusForbidden, err.Error())
+		return
+	}
+	entry, err := h.getObject(req.Context(), req, &BatchPointer{OID: oid, Size: -1})
+	if errors.Is(err, ErrObjectNotFound) {
+		writeError(w, req.Request, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		logging.FromContext(req.Context()).WithError(err).WithField("oid", oid).Error("get LFS object")
+		writeError(w, req.Request, http.StatusInternalServerError, "get object")
+		return
+	}
+	_, err = h.Cataloger.GetRedaction(req.Context(), req.repository.StorageNamespace, entry.PhysicalAddress)
+	if err == nil {
+		writeError(w, req.Request, http.StatusGone, "object redacted")
+		return
+	}
+	if !errors.Is(err, db.ErrNotFound) {
+		logging.FromContext(req.Context()).WithError(err).WithField("oid", oid).Error("get LFS object redaction")
+		writeError(w, req.Request, http.StatusInternalServerError, "get object")
+		return
+	}
+	reader, err := h.BlockAdapter.Get(block.ObjectPointer{StorageNamespace: req.repository.StorageNamespace, Identifier: entry.PhysicalAddress}, entry.Size)
+	if err == nil && encryption.IsEncrypted(entry.Metadata) {
+		reader, err = h.Encryptor.DecryptReadCloser(req.Context(), entry.Metadata, reader, 0)
+	}
+	if err != nil {
+		logging.FromContext(req.Context()).WithError(err).WithField("oid", oid).Error("read LFS object")
+		writeError(w, req.Request, http.StatusInternalServerError, "read object")
+		return
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.FormatInt(entry.Size, 10))
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, reader); err != nil {
+		logging.FromContext(req.Context()).WithError(err).WithField("oid", oid).Warn("copy LFS object")
+	}
+}
+
+func (h *Handler) serveUpload(w http.ResponseWriter, req *request, oid string) {
+	if err := ValidateOID(oid); err != nil {
+		writeError(w, req.Request, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if err := h.authorize(req, OperationUpload, oid); err != nil {
+		writeError(w, req.Request, http.StatusForbidden, err.Error())
+		return
+	}
+	if req.ContentLength < 0 {
+		writeError(w, req.Request, http.StatusLengthRequired, "content length required")
+		return
+	}
+	path := ObjectPath(oid)
+	// creating the entry checks the lease too, checking early rejects the upload before its
+	// data is written
+	err := h.Cataloger.CheckPathLease(req.Context(), req.repository.Name, req.ref, path, req.username)
+	if errors.Is(err, catalog.ErrPathLeased) {
+		writeError(w, req.Request, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		logging.FromContext(req.Context()).WithError(err).WithField("oid", oid).Error("check LFS object lease")
+		writeError(w, req.Request, http.StatusInternalServerError, "write object")
+		return
+	}
+	hash := sha256.New()
+	blob, err := upload.WriteEncryptedBlob(req.Context(), h.Encryptor, req.repository.Name, path, h.BlockAdapter, req.repository.StorageNamespace, io.TeeReader(req.Body, hash), req.ContentLength, block.PutOpts{})
+	if err != nil {
+		logging.FromContext(req.Context()).WithError(err).WithField("oid", oid).Error("write LFS object")
+		writeError(w, req.Request, http.StatusInternalServerError, "write object")
+		return
+	}
+	pointer := block.ObjectPointer{StorageNamespace: req.repository.StorageNamespace, Identifier: blob.PhysicalAddress}
+	if hex.EncodeToString(hash.Sum(nil)) != oid {
+		_ = h.BlockAdapter.Remove(pointer)
+		writeError(w, req.Request, http.StatusUnprocessableEntity, ErrOIDMismatch.Error())
+		return
+	}
+	err = h.Cataloger.CreateEntry(req.Context(), req.repository.Name, req.ref, catalog.DBEntry{
+		Path:            path,
+		PhysicalAddress: blob.PhysicalAddress,
+		CreationDate:    time.Now(),
+		Size:            blob.Size,
+		Checksum:        blob.Checksum,
+		Metadata:        blob.Metadata,
+	})
+	if err != nil {
+		_ = h.BlockAdapter.Remove(pointer)
+		if errors.Is(err, db.ErrNotFound) || errors.Is(err, graveler.ErrNotFound) {
+			writeError(w, req.Request, http.StatusNotFound, "branch not found")
+			return
+		}
+		if errors.Is(err, catalog.ErrPathLeased) {
+			writeError(w, req.Request, http.StatusConflict, err.Error())
+			return
+		}
+		logging.FromContext(req.Context()).WithError(err).WithField("oid", oid).Error("create LFS object entry")
+		writeError(w, req.Request, http.StatusInternalServerError, "create object")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// objectURL returns the URL transferring the object oid on the ref of req
+func objectURL(req *request, oid string) string {
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	} else if proto := req.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	u := url.URL{
+		Scheme: scheme,
+		Host:   req.Host,
+		Path:   PathPrefix + req.repository.Name + "/" + req.ref + "/objects/" + oid,
+	}
+	return u.String()
+}
+
+// actionHeader returns the headers of the transfers of the actions for req: its credentials
+func actionHeader(req *request) map[string]string {
+	authorization := req.Header.Get("Authorization")
+	if authorization == "" {
+		return nil
+	}
+	return map[string]string{"Authorization": authorization}
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", MediaType)
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, code int, message string) {
+	_, requestID := httputil.RequestID(r)
+	writeJSON(w, code, &ErrorResponse{Message: message, RequestID: requestID})
+}
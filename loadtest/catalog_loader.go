@@ -0,0 +1,379 @@
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jamiealquiza/tachymeter"
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+// Operations generated by the CatalogLoader
+const (
+	OpSetEntry = "set_entry"
+	OpGetEntry = "get_entry"
+	OpList     = "list"
+	OpCommit   = "commit"
+	OpMerge    = "merge"
+)
+
+var catalogOps = []string{OpSetEntry, OpGetEntry, OpList, OpCommit, OpMerge}
+
+// DefaultMix is the operations mix of a CatalogLoader with no mix configured
+const DefaultMix = "set_entry=60,get_entry=25,list=10,commit=4,merge=1"
+
+const (
+	defaultListAmount = 100
+	loadCommitter     = "loadtest"
+)
+
+var (
+	ErrInvalidMix         = errors.New("invalid operations mix")
+	ErrInvalidConcurrency = errors.New("concurrency must be at least 1")
+	ErrNoLimit            = errors.New("requests or duration must be set")
+)
+
+// CatalogLoadConfig configures a run of a CatalogLoader
+type CatalogLoadConfig struct {
+	Repository string `json:"repository"`
+	// Branch is the branch merged into.  Each worker works on its own branch created from it.
+	Branch string `json:"branch"`
+	// Mix is the relative weight of each operation, as parsed by ParseMix
+	Mix map[string]int `json:"mix"`
+	// ObjectSize is the size of objects created by set_entry.  Their data is written to the
+	// storage namespace only when the loader has a block adapter.
+	ObjectSize  int64 `json:"object_size"`
+	Concurrency int   `json:"concurrency"`
+	// Requests is the number of operations of each worker, unlimited when 0
+	Requests int `json:"requests"`
+	// Duration is the duration of the run, unlimited when 0
+	Duration   time.Duration `json:"duration"`
+	ListAmount int           `json:"list_amount"`
+	// SampleSize is the number of latencies kept for each operation
+	SampleSize int `json:"sample_size"`
+	// KeepBranches keeps the branches of workers after the run
+	KeepBranches bool `json:"keep_branches"`
+}
+
+// CatalogLoader generates a configurable mix of catalog operations from concurrent workers and
+// measures their latency
+type CatalogLoader struct {
+	Cataloger catalog.Cataloger
+	// Adapter writes the data of created objects when set
+	Adapter block.Adapter
+	Config  CatalogLoadConfig
+}
+
+// OpResult holds the measurements of a single operation
+type OpResult struct {
+	Op      string              `json:"op"`
+	Count   int64               `json:"count"`
+	Errors  int64               `json:"errors"`
+	Metrics *tachymeter.Metrics `json:"metrics"`
+}
+
+// CatalogLoadResults holds the measurements of a run of a CatalogLoader
+type CatalogLoadResults struct {
+	Config   CatalogLoadConfig `json:"config"`
+	WallTime time.Duration     `json:"wall_time"`
+	Ops      []*OpResult       `json:"ops"`
+}
+
+// ParseMix parses a mix of operations of the form "set_entry=60,get_entry=30,commit=10"
+func ParseMix(s string) (map[string]int, error) {
+	mix := make(map[string]int)
+	total := 0
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("%s: %w", part, ErrInvalidMix)
+		}
+		op := strings.TrimSpace(kv[0])
+		switch op {
+		case OpSetEntry, OpGetEntry, OpList, OpCommit, OpMerge:
+		default:
+			return nil, fmt.Errorf("unknown operation %s: %w", op, ErrInvalidMix)
+		}
+		weight, err := strconv.Atoi(strings.TrimSpace(kv[1]))
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("weight of %s: %w", op, ErrInvalidMix)
+		}
+		mix[op] += weight
+		total += weight
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("no operations: %w", ErrInvalidMix)
+	}
+	return mix, nil
+}
+
+type opMeter struct {
+	// mu guards meter: workers overwrite the same samples once it wraps around
+	mu     sync.Mutex
+	meter  *tachymeter.Tachymeter
+	count  int64
+	errors int64
+}
+
+func (m *opMeter) add(took time.Duration, err error) {
+	m.mu.Lock()
+	m.meter.AddTime(took)
+	m.mu.Unlock()
+	atomic.AddInt64(&m.count, 1)
+	if err != nil {
+		atomic.AddInt64(&m.errors, 1)
+	}
+}
+
+// weightedOps picks operations at random by their weight in a mix
+type weightedOps struct {
+	ops     []string
+	weights []int
+	total   int
+}
+
+func newWeightedOps(mix map[string]int) *weightedOps {
+	w := &weightedOps{}
+	for op := range mix {
+		w.ops = append(w.ops, op)
+	}
+	sort.Strings(w.ops)
+	for _, op := range w.ops {
+		w.total += mix[op]
+		w.weights = append(w.weights, w.total)
+	}
+	return w
+}
+
+func (w *weightedOps) pick(r *rand.Rand) string {
+	n := r.Intn(w.total)
+	for i, weight := range w.weights {
+		if n < weight {
+			return w.ops[i]
+		}
+	}
+	return w.ops[len(w.ops)-1]
+}
+
+// Run runs the configured load until every worker completed its requests or the duration passed
+func (l *CatalogLoader) Run(ctx context.Context) (*CatalogLoadResults, error) {
+	cfg := l.Config
+	if cfg.Concurrency < 1 {
+		return nil, ErrInvalidConcurrency
+	}
+	if cfg.Requests <= 0 && cfg.Duration <= 0 {
+		return nil, ErrNoLimit
+	}
+	if cfg.Mix == nil {
+		mix, err := ParseMix(DefaultMix)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Mix = mix
+	}
+	if cfg.ListAmount <= 0 {
+		cfg.ListAmount = defaultListAmount
+	}
+	if cfg.SampleSize <= 0 {
+		cfg.SampleSize = 1
+	}
+	ops := newWeightedOps(cfg.Mix)
+	repo, err := l.Cataloger.GetRepository(ctx, cfg.Repository)
+	if err != nil {
+		return nil, fmt.Errorf("get repository: %w", err)
+	}
+
+	runID := uuid.New().String()[:8]
+	workers := make([]*catalogLoadWorker, cfg.Concurrency)
+	for i := range workers {
+		branch := fmt.Sprintf("%s-load-%s-%02d", cfg.Branch, runID, i)
+		if _, err := l.Cataloger.CreateBranch(ctx, cfg.Repository, branch, cfg.Branch); err != nil {
+			return nil, fmt.Errorf("create branch %s: %w", branch, err)
+		}
+		workers[i] = &catalogLoadWorker{
+			loader:           l,
+			cfg:              &cfg,
+			storageNamespace: repo.StorageNamespace,
+			branch:           branch,
+			rand:             rand.New(rand.NewSource(time.Now().UnixNano() + int64(i))), //nolint:gosec
+		}
+	}
+	if !cfg.KeepBranches {
+		defer func() {
+			for _, w := range workers {
+				_ = l.Cataloger.DeleteBranch(ctx, cfg.Repository, w.branch)
+			}
+		}()
+	}
+
+	meters := make(map[string]*opMeter)
+	for _, op := range catalogOps {
+		meters[op] = &opMeter{meter: tachymeter.New(&tachymeter.Config{Size: cfg.SampleSize})}
+	}
+	runCtx := ctx
+	if cfg.Duration > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, cfg.Duration)
+		defer cancel()
+	}
+	var wg sync.WaitGroup
+	wg.Add(len(workers))
+	start := time.Now()
+	for _, w := range workers {
+		go func(w *catalogLoadWorker) {
+			defer wg.Done()
+			for i := 0; cfg.Requests <= 0 || i < cfg.Requests; i++ {
+				if runCtx.Err() != nil {
+					return
+				}
+				op := ops.pick(w.rand)
+				if op == OpGetEntry && len(w.paths) == 0 {
+					// nothing to read yet
+					op = OpSetEntry
+				}
+				opStart := time.Now()
+				err := w.do(ctx, op)
+				meters[op].add(time.Since(opStart), err)
+			}
+		}(w)
+	}
+	wg.Wait()
+	wallTime := time.Since(start)
+
+	results := &CatalogLoadResults{Config: cfg, WallTime: wallTime}
+	for _, op := range catalogOps {
+		m := meters[op]
+		if m.count == 0 {
+			continue
+		}
+		m.meter.SetWallTime(wallTime)
+		results.Ops = append(results.Ops, &OpResult{
+			Op:      op,
+			Count:   m.count,
+			Errors:  m.errors,
+			Metrics: m.meter.Calc(),
+		})
+	}
+	return results, nil
+}
+
+// HasErrors returns true if any operation of the run failed
+func (r *CatalogLoadResults) HasErrors() bool {
+	for _, op := range r.Ops {
+		if op.Errors > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteJSON exports the results as JSON
+func (r *CatalogLoadResults) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteText writes the results with a latency histogram of each operation
+func (r *CatalogLoadResults) WriteText(w io.Writer) error {
+	const histogramWidth = 50
+	for _, op := range r.Ops {
+		_, err := fmt.Fprintf(w, "Results for operation: %s (%d operations, %d errors)\n%s\n%s\n",
+			op.Op, op.Count, op.Errors, op.Metrics, op.Metrics.Histogram.String(histogramWidth))
+		if err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "Wall time: %s\n", r.WallTime)
+	return err
+}
+
+type catalogLoadWorker struct {
+	loader           *CatalogLoader
+	cfg              *CatalogLoadConfig
+	storageNamespace string
+	branch           string
+	rand             *rand.Rand
+	data             []byte
+	paths            []string
+}
+
+func (w *catalogLoadWorker) do(ctx context.Context, op string) error {
+	c := w.loader.Cataloger
+	switch op {
+	case OpSetEntry:
+		return w.setEntry(ctx)
+	case OpGetEntry:
+		p := w.paths[w.rand.Intn(len(w.paths))]
+		_, err := c.GetEntry(ctx, w.cfg.Repository, w.branch, p, catalog.GetEntryParams{})
+		return err
+	case OpList:
+		_, _, err := c.ListEntries(ctx, w.cfg.Repository, w.branch, "", "", "", w.cfg.ListAmount)
+		return err
+	case OpCommit:
+		_, err := c.Commit(ctx, w.cfg.Repository, w.branch, "load test commit", loadCommitter, nil)
+		if errors.Is(err, graveler.ErrNoChanges) {
+			return nil
+		}
+		return err
+	case OpMerge:
+		_, err := c.Merge(ctx, w.cfg.Repository, w.cfg.Branch, w.branch, catalog.MergeParams{
+			Committer: loadCommitter,
+			Message:   "load test merge",
+		})
+		if errors.Is(err, graveler.ErrNoChanges) {
+			return nil
+		}
+		return err
+	default:
+		return fmt.Errorf("%s: %w", op, ErrInvalidMix)
+	}
+}
+
+func (w *catalogLoadWorker) setEntry(ctx context.Context) error {
+	address := strings.ReplaceAll(uuid.New().String(), "-", "")
+	if w.loader.Adapter != nil && w.cfg.ObjectSize > 0 {
+		if w.data == nil {
+			w.data = make([]byte, w.cfg.ObjectSize)
+			_, _ = w.rand.Read(w.data)
+		}
+		err := w.loader.Adapter.Put(block.ObjectPointer{
+			StorageNamespace: w.storageNamespace,
+			Identifier:       address,
+		}, w.cfg.ObjectSize, bytes.NewReader(w.data), block.PutOpts{})
+		if err != nil {
+			return err
+		}
+	}
+	p := randomFilepath(address)
+	err := w.loader.Cataloger.CreateEntry(ctx, w.cfg.Repository, w.branch, catalog.DBEntry{
+		Path:            p,
+		PhysicalAddress: address,
+		CreationDate:    time.Now(),
+		Size:            w.cfg.ObjectSize,
+		Checksum:        address,
+	})
+	if err != nil {
+		return err
+	}
+	w.paths = append(w.paths, p)
+	return nil
+}
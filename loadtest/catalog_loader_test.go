@@ -0,0 +1,213 @@
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/block/mem"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+// fakeCataloger implements the calls of a CatalogLoader, and counts them
+type fakeCataloger struct {
+	catalog.Cataloger
+	mu       sync.Mutex
+	branches map[string]bool
+	entries  map[string]bool
+	calls    map[string]int
+}
+
+func (c *fakeCataloger) call(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.calls == nil {
+		c.calls = make(map[string]int)
+	}
+	c.calls[name]++
+}
+
+func (c *fakeCataloger) GetRepository(_ context.Context, repository string) (*catalog.Repository, error) {
+	return &catalog.Repository{Name: repository, StorageNamespace: "mem://load", DefaultBranch: "main"}, nil
+}
+
+func (c *fakeCataloger) CreateBranch(_ context.Context, _, branch string, _ string) (*catalog.CommitLog, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.branches == nil {
+		c.branches = make(map[string]bool)
+	}
+	c.branches[branch] = true
+	return &catalog.CommitLog{}, nil
+}
+
+func (c *fakeCataloger) DeleteBranch(_ context.Context, _, branch string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.branches, branch)
+	return nil
+}
+
+func (c *fakeCataloger) CreateEntry(_ context.Context, _, branch string, entry catalog.DBEntry) error {
+	c.call(OpSetEntry)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]bool)
+	}
+	c.entries[branch+"/"+entry.Path] = true
+	return nil
+}
+
+func (c *fakeCataloger) GetEntry(_ context.Context, _, reference string, path string, _ catalog.GetEntryParams) (*catalog.DBEntry, error) {
+	c.call(OpGetEntry)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.entries[reference+"/"+path] {
+		return nil, graveler.ErrNotFound
+	}
+	return &catalog.DBEntry{Path: path}, nil
+}
+
+func (c *fakeCataloger) ListEntries(context.Context, string, string, string, string, string, int) ([]*catalog.DBEntry, bool, error) {
+	c.call(OpList)
+	return nil, false, nil
+}
+
+func (c *fakeCataloger) Commit(context.Context, string, string, string, string, catalog.Metadata) (*catalog.CommitLog, error) {
+	c.call(OpCommit)
+	return nil, graveler.ErrNoChanges
+}
+
+func (c *fakeCataloger) Merge(context.Context, string, string, string, catalog.MergeParams) (*catalog.MergeResult, error) {
+	c.call(OpMerge)
+	return nil, errors.New("merge failed")
+}
+
+func TestParseMix(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Mix      string
+		Expected map[string]int
+	}{
+		{Name: "default", Mix: DefaultMix, Expected: map[string]int{OpSetEntry: 60, OpGetEntry: 25, OpList: 10, OpCommit: 4, OpMerge: 1}},
+		{Name: "spaces", Mix: " set_entry = 3 , list=1,", Expected: map[string]int{OpSetEntry: 3, OpList: 1}},
+		{Name: "repeated", Mix: "commit=1,commit=2", Expected: map[string]int{OpCommit: 3}},
+		{Name: "unknown", Mix: "set_entry=1,delete=1"},
+		{Name: "no weight", Mix: "set_entry"},
+		{Name: "negative", Mix: "set_entry=-1,list=2"},
+		{Name: "empty", Mix: "set_entry=0"},
+	}
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			mix, err := ParseMix(tt.Mix)
+			if tt.Expected == nil {
+				if !errors.Is(err, ErrInvalidMix) {
+					t.Fatalf("ParseMix(%s) err=%v, expected %s", tt.Mix, err, ErrInvalidMix)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMix(%s): %s", tt.Mix, err)
+			}
+			if diff := deep.Equal(mix, tt.Expected); diff != nil {
+				t.Error("mix diff found", diff)
+			}
+		})
+	}
+}
+
+func TestCatalogLoader_Run(t *testing.T) {
+	ctx := context.Background()
+	cataloger := &fakeCataloger{}
+	adapter := mem.New()
+	loader := &CatalogLoader{
+		Cataloger: cataloger,
+		Adapter:   adapter,
+		Config: CatalogLoadConfig{
+			Repository:  "repo",
+			Branch:      "main",
+			Mix:         map[string]int{OpSetEntry: 1, OpGetEntry: 1, OpList: 1, OpCommit: 1},
+			ObjectSize:  16,
+			Concurrency: 4,
+			Requests:    50,
+			SampleSize:  200,
+		},
+	}
+	results, err := loader.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run: %s", err)
+	}
+	var total int64
+	for _, op := range results.Ops {
+		total += op.Count
+		if int64(cataloger.calls[op.Op]) != op.Count {
+			t.Errorf("%s measured %d times, called %d times", op.Op, op.Count, cataloger.calls[op.Op])
+		}
+		if op.Errors != 0 {
+			t.Errorf("%s has %d errors, expected none", op.Op, op.Errors)
+		}
+		if op.Metrics == nil || op.Metrics.Count != int(op.Count) {
+			t.Errorf("%s metrics %+v, expected %d events", op.Op, op.Metrics, op.Count)
+		}
+	}
+	if total != 200 {
+		t.Errorf("run measured %d operations, expected 200", total)
+	}
+	if cataloger.calls[OpMerge] != 0 {
+		t.Errorf("merge called %d times, not in mix", cataloger.calls[OpMerge])
+	}
+	if len(cataloger.branches) != 0 {
+		t.Errorf("branches of workers left after run: %v", cataloger.branches)
+	}
+	if results.HasErrors() {
+		t.Error("results have errors")
+	}
+	var buf bytes.Buffer
+	if err := results.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON: %s", err)
+	}
+	var exported map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &exported); err != nil {
+		t.Fatalf("decode exported results: %s", err)
+	}
+	if _, ok := exported["ops"]; !ok {
+		t.Errorf("exported results %s have no ops", buf.String())
+	}
+}
+
+func TestCatalogLoader_RunErrors(t *testing.T) {
+	ctx := context.Background()
+	cataloger := &fakeCataloger{}
+	loader := &CatalogLoader{
+		Cataloger: cataloger,
+		Config: CatalogLoadConfig{
+			Repository:   "repo",
+			Branch:       "main",
+			Mix:          map[string]int{OpMerge: 1},
+			Concurrency:  2,
+			Requests:     5,
+			KeepBranches: true,
+		},
+	}
+	results, err := loader.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run: %s", err)
+	}
+	if !results.HasErrors() || len(results.Ops) != 1 || results.Ops[0].Errors != 10 {
+		t.Errorf("Run() with failing merges = %+v, expected 10 merge errors", results.Ops)
+	}
+	if len(cataloger.branches) != 2 {
+		t.Errorf("kept %d branches of workers, expected 2", len(cataloger.branches))
+	}
+
+	loader.Config.Requests = 0
+	if _, err := loader.Run(ctx); !errors.Is(err, ErrNoLimit) {
+		t.Errorf("Run() with no limit err=%v, expected %s", err, ErrNoLimit)
+	}
+}
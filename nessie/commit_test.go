@@ -25,7 +25,7 @@ func TestCommitSingle(t *testing.T) {
 	require.NoError(t, err, "failed to commit changes")
 
 	var b bytes.Buffer
-	_, err = client.Objects.GetObject(objects.NewGetObjectParamsWithContext(ctx).WithRepository(repo).WithRef(masterBranch).WithPath(objPath), nil, &b)
+	_, _, err = client.Objects.GetObject(objects.NewGetObjectParamsWithContext(ctx).WithRepository(repo).WithRef(masterBranch).WithPath(objPath), nil, &b)
 	require.NoError(t, err, "failed to get object")
 
 	require.Equal(t, objContent, b.String(), fmt.Sprintf("path: %s, expected: %s, actual:%s", objPath, objContent, b.String()))
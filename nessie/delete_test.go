@@ -17,7 +17,7 @@ import (
 
 func found(ctx context.Context, repo, ref, path string) (bool, error) {
 	var b bytes.Buffer
-	res, err := client.Objects.GetObject(objects.NewGetObjectParamsWithContext(ctx).WithRepository(repo).WithRef(masterBranch).WithPath(path), nil, &b)
+	res, _, err := client.Objects.GetObject(objects.NewGetObjectParamsWithContext(ctx).WithRepository(repo).WithRef(masterBranch).WithPath(path), nil, &b)
 
 	if res != nil {
 		return true, nil
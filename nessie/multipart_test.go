@@ -48,7 +48,7 @@ func TestMultipartUpload(t *testing.T) {
 	logger.WithField("key", completeResponse.Key).Info("Completed multipart request successfully")
 
 	var b bytes.Buffer
-	_, err = client.Objects.GetObject(
+	_, _, err = client.Objects.GetObject(
 		objects.NewGetObjectParamsWithContext(ctx).
 			WithRepository(repo).
 			WithRef(masterBranch).
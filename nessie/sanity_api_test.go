@@ -34,7 +34,7 @@ func TestSanityAPI(t *testing.T) {
 	log.Debug("verify upload content")
 	for i, p := range paths {
 		var buf bytes.Buffer
-		_, err := client.Objects.GetObject(objects.NewGetObjectParamsWithContext(ctx).
+		_, _, err := client.Objects.GetObject(objects.NewGetObjectParamsWithContext(ctx).
 			WithRepository(repo).
 			WithRef(masterBranch).
 			WithPath(p), nil, &buf)
@@ -0,0 +1,144 @@
+package notifications
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/treeverse/lakefs/catalog"
+)
+
+// shortCommitIDLength is the length of commit IDs shown in messages
+const shortCommitIDLength = 10
+
+// linker formats links to the lakeFS UI in the markup of a chat service, text is not linked
+// when the UI base URL is unknown
+type linker struct {
+	baseURL string
+	// link formats text linked to u
+	link func(text, u string) string
+	// escape escapes plain text
+	escape func(text string) string
+}
+
+func (l linker) to(text, path string, query url.Values) string {
+	text = l.escape(text)
+	if l.baseURL == "" {
+		return text
+	}
+	u := strings.TrimSuffix(l.baseURL, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return l.link(text, u)
+}
+
+func (l linker) repository(repository string) string {
+	return l.to(repository, "/repositories/"+url.PathEscape(repository)+"/tree", nil)
+}
+
+func (l linker) branch(repository, branch string) string {
+	return l.to(branch, "/repositories/"+url.PathEscape(repository)+"/tree", url.Values{"branch": {branch}})
+}
+
+func (l linker) commit(repository, commitID string) string {
+	text := commitID
+	if len(text) > shortCommitIDLength {
+		text = text[:shortCommitIDLength]
+	}
+	return l.to(text, "/repositories/"+url.PathEscape(repository)+"/tree", url.Values{"commit": {commitID}})
+}
+
+// summary returns the message of event of eventType formatted by l
+func (l linker) summary(eventType EventType, event catalog.RepositoryEvent) string {
+	repository := l.repository(event.Repository)
+	branch := l.branch(event.Repository, event.Branch)
+	committer := l.escape(event.Committer)
+	switch eventType {
+	case EventTypeCommit:
+		return fmt.Sprintf("%s committed %s to %s in %s: %s",
+			committer, l.commit(event.Repository, event.Reference), branch, repository, l.escape(event.Message))
+	case EventTypeMerge:
+		return fmt.Sprintf("%s merged %s into %s in %s as %s",
+			committer, l.escape(event.SourceRef), branch, repository, l.commit(event.Repository, event.Reference))
+	case EventTypeHookFailure:
+		operation := "commit"
+		if event.SourceRef != "" {
+			operation = "merge of " + l.escape(event.SourceRef)
+		}
+		return fmt.Sprintf("Hooks failed the %s by %s to %s in %s: %s",
+			operation, committer, branch, repository, l.escape(event.Error))
+	case EventTypeDatasetChange:
+		return fmt.Sprintf("%s changed dataset %s on %s in %s with %s",
+			committer, l.escape(event.Dataset), branch, repository, l.commit(event.Repository, event.Reference))
+	default:
+		return ""
+	}
+}
+
+// slackMessage is posted to Slack incoming webhooks
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func newSlackMessage(baseURL string, eventType EventType, event catalog.RepositoryEvent) interface{} {
+	l := linker{
+		baseURL: baseURL,
+		link: func(text, u string) string {
+			return "<" + u + "|" + text + ">"
+		},
+		escape: slackEscaper.Replace,
+	}
+	return &slackMessage{Text: l.summary(eventType, event)}
+}
+
+// teamsMessage is the message card posted to Microsoft Teams incoming webhooks
+type teamsMessage struct {
+	Type       string `json:"@type"`
+	Context    string `json:"@context"`
+	Summary    string `json:"summary"`
+	ThemeColor string `json:"themeColor"`
+	Text       string `json:"text"`
+}
+
+const (
+	teamsColorSuccess = "2EB67D"
+	teamsColorFailure = "E01E5A"
+)
+
+var teamsEscaper = strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, "*", `\*`, "_", `\_`, "<", "&lt;", ">", "&gt;")
+
+func newTeamsMessage(baseURL string, eventType EventType, event catalog.RepositoryEvent) interface{} {
+	l := linker{
+		baseURL: baseURL,
+		link: func(text, u string) string {
+			return "[" + text + "](" + u + ")"
+		},
+		escape: teamsEscaper.Replace,
+	}
+	color := teamsColorSuccess
+	if eventType == EventTypeHookFailure {
+		color = teamsColorFailure
+	}
+	return &teamsMessage{
+		Type:       "MessageCard",
+		Context:    "https://schema.org/extensions",
+		Summary:    fmt.Sprintf("lakeFS %s on %s/%s", eventType, event.Repository, event.Branch),
+		ThemeColor: color,
+		Text:       l.summary(eventType, event),
+	}
+}
+
+// newMessage returns the message of event to post to a sink of sinkType
+func newMessage(sinkType SinkType, baseURL string, eventType EventType, event catalog.RepositoryEvent) (interface{}, error) {
+	switch sinkType {
+	case SinkTypeSlack:
+		return newSlackMessage(baseURL, eventType, event), nil
+	case SinkTypeTeams:
+		return newTeamsMessage(baseURL, eventType, event), nil
+	default:
+		return nil, fmt.Errorf("type '%s' unknown: %w", sinkType, ErrInvalidSink)
+	}
+}
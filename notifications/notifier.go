@@ -0,0 +1,131 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/events"
+	"github.com/treeverse/lakefs/logging"
+	"github.com/treeverse/lakefs/secrets"
+	"github.com/treeverse/lakefs/settings"
+)
+
+// DefaultPostTimeout limits posting a message to a sink
+const DefaultPostTimeout = 10 * time.Second
+
+var ErrPostFailed = errors.New("notification post failed")
+
+// Notifier posts the repository events published on an events bus to the matching sinks of
+// their repository.  Delivery is best effort, failures are logged and not retried.
+type Notifier struct {
+	store    Store
+	secrets  secrets.Store
+	settings *settings.Service
+	baseURL  string
+	client   *http.Client
+	log      logging.Logger
+}
+
+// NewNotifier returns a Notifier of the sinks of store, linking to the lakeFS UI at baseURL if
+// it is not empty.  Secrets referenced by sink URLs are read from secretStore, and
+// repositories with notifications disabled in settingsService are skipped.
+func NewNotifier(store Store, secretStore secrets.Store, settingsService *settings.Service, baseURL string) *Notifier {
+	return &Notifier{
+		store:    store,
+		secrets:  secretStore,
+		settings: settingsService,
+		baseURL:  baseURL,
+		client:   &http.Client{Timeout: DefaultPostTimeout},
+		log:      logging.Default().WithField("service_name", "notifications"),
+	}
+}
+
+// Subscribe posts the repository events published on bus until the returned function is called
+func (n *Notifier) Subscribe(bus *events.Bus) func() {
+	cancels := make([]func(), 0, len(topicEventTypes))
+	for topic := range topicEventTypes {
+		cancels = append(cancels, bus.Subscribe(topic, n.handle))
+	}
+	return func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}
+}
+
+func (n *Notifier) handle(e events.Event) {
+	event, ok := e.Payload.(catalog.RepositoryEvent)
+	if !ok {
+		return
+	}
+	n.Notify(context.Background(), topicEventTypes[e.Topic], event)
+}
+
+// Notify posts event to the sinks of its repository matching eventType and its branch
+func (n *Notifier) Notify(ctx context.Context, eventType EventType, event catalog.RepositoryEvent) {
+	log := n.log.WithFields(logging.Fields{
+		"repository": event.Repository,
+		"branch":     event.Branch,
+		"event":      eventType,
+	})
+	repoSettings, err := n.settings.Get(ctx, event.Repository)
+	if err != nil {
+		log.WithError(err).Error("Failed to read repository settings")
+		return
+	}
+	if !repoSettings.NotificationsEnabled {
+		return
+	}
+	sinks, err := n.store.ListSinks(ctx, event.Repository)
+	if err != nil {
+		log.WithError(err).Error("Failed to list notification sinks")
+		return
+	}
+	for _, sink := range sinks {
+		if !sink.Match(eventType, event.Branch) {
+			continue
+		}
+		if err := n.post(ctx, sink, eventType, event); err != nil {
+			log.WithError(err).WithField("sink", sink.Name).Warn("Failed to post notification")
+		}
+	}
+}
+
+func (n *Notifier) post(ctx context.Context, sink *Sink, eventType EventType, event catalog.RepositoryEvent) error {
+	msg, err := newMessage(SinkType(sink.Type), n.baseURL, eventType, event)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	var lookup secrets.LookupFunc
+	if n.secrets != nil {
+		lookup = secrets.RepositoryLookup(ctx, n.secrets, sink.RepositoryID)
+	}
+	sinkURL, err := secrets.Expand(sink.URL, lookup)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sinkURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%w (status code: %d)", ErrPostFailed, resp.StatusCode)
+	}
+	return nil
+}
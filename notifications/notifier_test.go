@@ -0,0 +1,182 @@
+package notifications_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/notifications"
+	"github.com/treeverse/lakefs/secrets"
+	"github.com/treeverse/lakefs/settings"
+)
+
+type fakeStore struct {
+	notifications.Store
+	sinks []*notifications.Sink
+}
+
+func (s *fakeStore) ListSinks(_ context.Context, repositoryID string) ([]*notifications.Sink, error) {
+	var sinks []*notifications.Sink
+	for _, sink := range s.sinks {
+		if sink.RepositoryID == repositoryID {
+			sinks = append(sinks, sink)
+		}
+	}
+	return sinks, nil
+}
+
+type fakeSettingsStore struct {
+	settings.Store
+	disabled string
+}
+
+func (s *fakeSettingsStore) ListOverrides(_ context.Context, repositoryID string) ([]*settings.Override, error) {
+	if repositoryID != s.disabled {
+		return nil, nil
+	}
+	return []*settings.Override{{RepositoryID: repositoryID, Key: settings.KeyNotificationsEnabled, Value: "false"}}, nil
+}
+
+type fakeSecrets struct {
+	secrets.Store
+	values map[string]string
+}
+
+func (s *fakeSecrets) GetValue(_ context.Context, repositoryID, name string) (string, error) {
+	value, ok := s.values[repositoryID+"/"+name]
+	if !ok {
+		return "", secrets.ErrSecretNotFound
+	}
+	return value, nil
+}
+
+type postedMessage struct {
+	Path string
+	Body map[string]interface{}
+}
+
+func TestNotifier_Notify(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		posted []postedMessage
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode posted message: %s", err)
+		}
+		mu.Lock()
+		posted = append(posted, postedMessage{Path: r.URL.Path, Body: body})
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	store := &fakeStore{sinks: []*notifications.Sink{
+		{RepositoryID: "repo", Name: "all", Type: "slack", URL: server.URL + "/slack"},
+		{RepositoryID: "repo", Name: "failures", Type: "teams", URL: server.URL + "/teams", Events: []string{"hook_failure"}},
+		{RepositoryID: "repo", Name: "main", Type: "slack", URL: server.URL + "/main", Branches: []string{"main"}},
+		{RepositoryID: "repo", Name: "secret", Type: "slack", URL: server.URL + "/${secrets.HOOK_PATH}", Events: []string{"hook_failure"}},
+		{RepositoryID: "other", Name: "other", Type: "slack", URL: server.URL + "/other"},
+		{RepositoryID: "muted", Name: "muted", Type: "slack", URL: server.URL + "/muted"},
+	}}
+	secretStore := &fakeSecrets{values: map[string]string{"repo/HOOK_PATH": "hidden"}}
+	settingsService := settings.NewService(&fakeSettingsStore{disabled: "muted"}, func() settings.Settings {
+		return settings.Settings{NotificationsEnabled: true}
+	})
+	n := notifications.NewNotifier(store, secretStore, settingsService, "https://lakefs.example.com")
+	ctx := context.Background()
+
+	n.Notify(ctx, notifications.EventTypeCommit, catalog.RepositoryEvent{
+		Repository: "repo",
+		Branch:     "feature",
+		Reference:  "0123456789abcdef",
+		Committer:  "alice",
+		Message:    "add <data>",
+	})
+	if len(posted) != 1 || posted[0].Path != "/slack" {
+		t.Fatalf("commit posted %+v, expected only to /slack", posted)
+	}
+	const expectedText = "alice committed <https://lakefs.example.com/repositories/repo/tree?commit=0123456789abcdef|0123456789> to " +
+		"<https://lakefs.example.com/repositories/repo/tree?branch=feature|feature> in " +
+		"<https://lakefs.example.com/repositories/repo/tree|repo>: add &lt;data&gt;"
+	if text := posted[0].Body["text"]; text != expectedText {
+		t.Errorf("commit text %q, expected %q", text, expectedText)
+	}
+
+	posted = nil
+	n.Notify(ctx, notifications.EventTypeHookFailure, catalog.RepositoryEvent{
+		Repository: "repo",
+		Branch:     "main",
+		Committer:  "bob",
+		Error:      "aborted by hook: check failed",
+	})
+	paths := make(map[string]map[string]interface{})
+	for _, p := range posted {
+		paths[p.Path] = p.Body
+	}
+	if len(paths) != 4 || paths["/slack"] == nil || paths["/teams"] == nil || paths["/main"] == nil || paths["/hidden"] == nil {
+		t.Fatalf("hook failure posted %+v, expected to /slack, /teams, /main and /hidden", posted)
+	}
+	teams := paths["/teams"]
+	if teams["@type"] != "MessageCard" {
+		t.Errorf("teams message type %v, expected MessageCard", teams["@type"])
+	}
+	if text, _ := teams["text"].(string); !strings.Contains(text, "[main](https://lakefs.example.com/repositories/repo/tree?branch=main)") {
+		t.Errorf("teams text %q, expected a link to the branch", text)
+	}
+
+	posted = nil
+	n.Notify(ctx, notifications.EventTypeDatasetChange, catalog.RepositoryEvent{
+		Repository: "repo",
+		Branch:     "feature",
+		Reference:  "0123456789abcdef",
+		Committer:  "alice",
+		Dataset:    "sales",
+	})
+	if len(posted) != 1 || posted[0].Path != "/slack" {
+		t.Fatalf("dataset change posted %+v, expected only to /slack", posted)
+	}
+	const expectedDatasetText = "alice changed dataset sales on " +
+		"<https://lakefs.example.com/repositories/repo/tree?branch=feature|feature> in " +
+		"<https://lakefs.example.com/repositories/repo/tree|repo> with " +
+		"<https://lakefs.example.com/repositories/repo/tree?commit=0123456789abcdef|0123456789>"
+	if text := posted[0].Body["text"]; text != expectedDatasetText {
+		t.Errorf("dataset change text %q, expected %q", text, expectedDatasetText)
+	}
+
+	posted = nil
+	n.Notify(ctx, notifications.EventTypeCommit, catalog.RepositoryEvent{Repository: "muted", Branch: "main"})
+	if len(posted) != 0 {
+		t.Errorf("commit posted %+v with notifications disabled, expected nothing", posted)
+	}
+}
+
+func TestSink_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		sink    notifications.Sink
+		wantErr bool
+	}{
+		{name: "valid", sink: notifications.Sink{Name: "s", Type: "teams", URL: "https://example.com/hook", Events: []string{"merge"}, Branches: []string{"release-*"}}},
+		{name: "no name", sink: notifications.Sink{Type: "slack", URL: "https://example.com/hook"}, wantErr: true},
+		{name: "unknown type", sink: notifications.Sink{Name: "s", Type: "irc", URL: "https://example.com/hook"}, wantErr: true},
+		{name: "secret path", sink: notifications.Sink{Name: "s", Type: "slack", URL: "https://example.com/${secrets.HOOK}"}},
+		{name: "secret host", sink: notifications.Sink{Name: "s", Type: "slack", URL: "https://${secrets.HOST}/hook"}, wantErr: true},
+		{name: "bad url", sink: notifications.Sink{Name: "s", Type: "slack", URL: "example.com/hook"}, wantErr: true},
+		{name: "unknown event", sink: notifications.Sink{Name: "s", Type: "slack", URL: "https://example.com/hook", Events: []string{"push"}}, wantErr: true},
+		{name: "bad pattern", sink: notifications.Sink{Name: "s", Type: "slack", URL: "https://example.com/hook", Branches: []string{"["}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.sink.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() err=%v, expected error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+// Package notifications posts repository events to chat services configured per repository
+package notifications
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/secrets"
+)
+
+// SinkType is the chat service messages of a sink are formatted for
+type SinkType string
+
+const (
+	SinkTypeSlack SinkType = "slack"
+	SinkTypeTeams SinkType = "teams"
+)
+
+// EventType names the repository events a sink can filter on
+type EventType string
+
+const (
+	EventTypeCommit      EventType = "commit"
+	EventTypeMerge       EventType = "merge"
+	EventTypeHookFailure EventType = "hook_failure"
+	// EventTypeDatasetChange is a commit or merge changing a dataset of the repository
+	EventTypeDatasetChange EventType = "dataset_change"
+)
+
+// topicEventTypes maps the catalog event topics to the event types of sinks
+var topicEventTypes = map[string]EventType{
+	catalog.TopicCommit:        EventTypeCommit,
+	catalog.TopicMerge:         EventTypeMerge,
+	catalog.TopicHookFailure:   EventTypeHookFailure,
+	catalog.TopicDatasetChange: EventTypeDatasetChange,
+}
+
+var eventTypes = map[EventType]struct{}{
+	EventTypeCommit:        {},
+	EventTypeMerge:         {},
+	EventTypeHookFailure:   {},
+	EventTypeDatasetChange: {},
+}
+
+var ErrInvalidSink = errors.New("invalid notification sink")
+
+// Sink posts the events of a repository to an incoming webhook of a chat service
+type Sink struct {
+	RepositoryID string `db:"repository_id"`
+	Name         string `db:"name"`
+	Type         string `db:"type"`
+	// URL is the incoming webhook the messages are posted to.  Its path may reference repository
+	// secrets, such as "https://hooks.slack.com/services/${secrets.SLACK_WEBHOOK}".
+	URL string `db:"url"`
+	// Events are the event types posted, all if empty
+	Events []string `db:"events"`
+	// Branches are glob patterns of the branches whose events are posted, all if empty
+	Branches     []string  `db:"branches"`
+	CreationDate time.Time `db:"creation_date"`
+}
+
+// Validate returns ErrInvalidSink if the sink cannot be used
+func (s *Sink) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("missing name: %w", ErrInvalidSink)
+	}
+	switch SinkType(s.Type) {
+	case SinkTypeSlack, SinkTypeTeams:
+	default:
+		return fmt.Errorf("type '%s' unknown: %w", s.Type, ErrInvalidSink)
+	}
+	// secrets are only known when posting, they may not be the host
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || secrets.HasReferences(u.Host) {
+		return fmt.Errorf("url '%s': %w", s.URL, ErrInvalidSink)
+	}
+	for _, e := range s.Events {
+		if _, ok := eventTypes[EventType(e)]; !ok {
+			return fmt.Errorf("event '%s' unknown: %w", e, ErrInvalidSink)
+		}
+	}
+	for _, b := range s.Branches {
+		if _, err := path.Match(b, ""); err != nil {
+			return fmt.Errorf("branch pattern '%s': %w", b, ErrInvalidSink)
+		}
+	}
+	return nil
+}
+
+// Match returns true if events of eventType on branch are posted to the sink
+func (s *Sink) Match(eventType EventType, branch string) bool {
+	if len(s.Events) > 0 && !contains(s.Events, string(eventType)) {
+		return false
+	}
+	if len(s.Branches) == 0 {
+		return true
+	}
+	for _, b := range s.Branches {
+		if matched, _ := path.Match(b, branch); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactedURL returns the URL of the sink without its path, which holds the secret of most
+// incoming webhooks
+func (s *Sink) RedactedURL() string {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host + "/..."
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/treeverse/lakefs/db"
+)
+
+// Store manages the notification sinks of repositories
+type Store interface {
+	// SetSink creates or replaces the sink of the repository with the same name
+	SetSink(ctx context.Context, sink *Sink) error
+	// GetSink returns the sink name of repositoryID
+	GetSink(ctx context.Context, repositoryID, name string) (*Sink, error)
+	// ListSinks returns the sinks of repositoryID, ordered by name
+	ListSinks(ctx context.Context, repositoryID string) ([]*Sink, error)
+	// DeleteSink deletes the sink name of repositoryID
+	DeleteSink(ctx context.Context, repositoryID, name string) error
+}
+
+type store struct {
+	db db.Database
+}
+
+var ErrSinkNotFound = fmt.Errorf("notification sink %w", db.ErrNotFound)
+
+func NewStore(adb db.Database) Store {
+	return &store{
+		db: adb,
+	}
+}
+
+const sinkFields = `repository_id, name, type, url, events, branches, creation_date`
+
+func (s *store) SetSink(ctx context.Context, sink *Sink) error {
+	if err := sink.Validate(); err != nil {
+		return err
+	}
+	events := sink.Events
+	if events == nil {
+		events = []string{}
+	}
+	branches := sink.Branches
+	if branches == nil {
+		branches = []string{}
+	}
+	_, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		return tx.Exec(`INSERT INTO notification_sinks (`+sinkFields+`)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (repository_id, name) DO UPDATE SET type = EXCLUDED.type, url = EXCLUDED.url,
+				events = EXCLUDED.events, branches = EXCLUDED.branches`,
+			sink.RepositoryID, sink.Name, sink.Type, sink.URL, events, branches, time.Now().UTC())
+	}, db.WithContext(ctx))
+	return err
+}
+
+func (s *store) GetSink(ctx context.Context, repositoryID, name string) (*Sink, error) {
+	res, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var sink Sink
+		err := tx.Get(&sink, `SELECT `+sinkFields+`
+			FROM notification_sinks
+			WHERE repository_id = $1 AND name = $2`,
+			repositoryID, name)
+		return &sink, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, ErrSinkNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res.(*Sink), nil
+}
+
+func (s *store) ListSinks(ctx context.Context, repositoryID string) ([]*Sink, error) {
+	res, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var sinks []*Sink
+		err := tx.Select(&sinks, `SELECT `+sinkFields+`
+			FROM notification_sinks
+			WHERE repository_id = $1
+			ORDER BY name`,
+			repositoryID)
+		return sinks, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.([]*Sink), nil
+}
+
+func (s *store) DeleteSink(ctx context.Context, repositoryID, name string) error {
+	_, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		res, err := tx.Exec(`DELETE FROM notification_sinks WHERE repository_id = $1 AND name = $2`,
+			repositoryID, name)
+		if err != nil {
+			return nil, err
+		}
+		if res.RowsAffected() == 0 {
+			return nil, ErrSinkNotFound
+		}
+		return nil, nil
+	}, db.WithContext(ctx))
+	return err
+}
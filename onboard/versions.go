@@ -0,0 +1,290 @@
+package onboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/logging"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	VersionsCommitMsgTemplate       = "Import versions of %s until %s"
+	DefaultVersionsImportBranchName = "import-from-versions"
+
+	// VersionsSourceMetadataKey and VersionsUntilMetadataKey are the commit metadata of an
+	// imported time slice: its source, and the modification time of its last version
+	VersionsSourceMetadataKey = "versions_source"
+	VersionsUntilMetadataKey  = "versions_until"
+	VersionsCountMetadataKey  = "versions"
+)
+
+var ErrInvalidSliceDuration = errors.New("slice duration must be positive")
+
+// versionsCataloger is a facet for EntryCatalog for the versions import
+type versionsCataloger interface {
+	WriteMetaRange(ctx context.Context, repositoryID graveler.RepositoryID, it catalog.EntryIterator) (*graveler.MetaRangeID, error)
+	AddCommitToBranchHead(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, commit graveler.Commit) (graveler.CommitID, error)
+	ListEntries(ctx context.Context, repositoryID graveler.RepositoryID, ref graveler.Ref, prefix, delimiter catalog.Path) (catalog.EntryListingIterator, error)
+	GetBranch(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID) (*graveler.Branch, error)
+	CreateBranch(ctx context.Context, repositoryID graveler.RepositoryID, branchID graveler.BranchID, ref graveler.Ref) (*graveler.Branch, error)
+	GetCommit(ctx context.Context, repositoryID graveler.RepositoryID, commitID graveler.CommitID) (*graveler.Commit, error)
+}
+
+type VersionsConfig struct {
+	CommitUsername  string
+	Bucket          string
+	Prefix          string
+	RepositoryID    graveler.RepositoryID
+	DefaultBranchID graveler.BranchID
+	// SliceDuration is the period of the history that each commit covers
+	SliceDuration time.Duration
+	VersionLister block.VersionLister
+	EntryCatalog  versionsCataloger
+}
+
+type VersionsStats struct {
+	Versions  int
+	Commits   int
+	CommitRef string
+}
+
+// ImportVersions replays the version history of the objects under the prefix of a versioned
+// bucket on the versions import branch, one commit per time slice that changed objects.  The
+// commit of a slice holds the state of the objects at the end of the slice, its author date is
+// the modification time of its last version.  Paths outside the prefix are kept.  An import
+// continues from the last slice imported from the same source.
+func ImportVersions(ctx context.Context, logger logging.Logger, config *VersionsConfig) (*VersionsStats, error) {
+	if config.SliceDuration <= 0 {
+		return nil, ErrInvalidSliceDuration
+	}
+	source := "s3://" + config.Bucket + "/" + config.Prefix
+	branch, err := versionsBranch(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	since, err := importedUntil(ctx, config, branch.CommitID, source)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []*block.ObjectVersion
+	err = config.VersionLister.ListVersions(ctx, config.Bucket, config.Prefix, func(v *block.ObjectVersion) error {
+		if v.LastModified.After(since) {
+			versions = append(versions, v)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list versions of %s: %w", source, err)
+	}
+	// versions of a key are listed newest first, reversing before the stable sort applies
+	// versions with the same modification time in the order they were written
+	for i, j := 0, len(versions)-1; i < j; i, j = i+1, j-1 {
+		versions[i], versions[j] = versions[j], versions[i]
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].LastModified.Before(versions[j].LastModified)
+	})
+	logger.WithFields(logging.Fields{"source": source, "versions": len(versions), "since": since}).Info("importing versions")
+
+	stats := &VersionsStats{Versions: len(versions), CommitRef: string(branch.CommitID)}
+	parentID := branch.CommitID
+	for start := 0; start < len(versions); {
+		sliceEnd := versions[start].LastModified.Truncate(config.SliceDuration).Add(config.SliceDuration)
+		end := start
+		for end < len(versions) && versions[end].LastModified.Before(sliceEnd) {
+			end++
+		}
+		parentID, err = commitVersionsSlice(ctx, config, source, parentID, versions[start:end])
+		if err != nil {
+			return nil, err
+		}
+		stats.Commits++
+		stats.CommitRef = string(parentID)
+		start = end
+	}
+	return stats, nil
+}
+
+func versionsBranch(ctx context.Context, config *VersionsConfig) (*graveler.Branch, error) {
+	branch, err := config.EntryCatalog.GetBranch(ctx, config.RepositoryID, DefaultVersionsImportBranchName)
+	if !errors.Is(err, graveler.ErrBranchNotFound) {
+		return branch, err
+	}
+	// first import, let's create the branch
+	branch, err = config.EntryCatalog.CreateBranch(ctx, config.RepositoryID, DefaultVersionsImportBranchName, graveler.Ref(config.DefaultBranchID))
+	if err != nil {
+		return nil, fmt.Errorf("creating branch %s: %w", DefaultVersionsImportBranchName, err)
+	}
+	return branch, nil
+}
+
+// importedUntil returns the modification time of the last version imported from source by the
+// head commit, zero if it is not an import of source
+func importedUntil(ctx context.Context, config *VersionsConfig, commitID graveler.CommitID, source string) (time.Time, error) {
+	if commitID == "" {
+		return time.Time{}, nil
+	}
+	commit, err := config.EntryCatalog.GetCommit(ctx, config.RepositoryID, commitID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("get commit %s: %w", commitID, err)
+	}
+	if commit.Metadata[VersionsSourceMetadataKey] != source {
+		return time.Time{}, nil
+	}
+	until, err := time.Parse(time.RFC3339Nano, commit.Metadata[VersionsUntilMetadataKey])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("commit %s %s: %w", commitID, VersionsUntilMetadataKey, err)
+	}
+	return until, nil
+}
+
+// commitVersionsSlice commits the changes of the versions of a slice on top of parentID
+func commitVersionsSlice(ctx context.Context, config *VersionsConfig, source string, parentID graveler.CommitID, versions []*block.ObjectVersion) (graveler.CommitID, error) {
+	latest := make(map[string]*block.ObjectVersion)
+	for _, v := range versions {
+		latest[v.Key] = v
+	}
+	changes := make([]*catalog.EntryRecord, 0, len(latest))
+	for key, v := range latest {
+		record := &catalog.EntryRecord{Path: catalog.Path(key)}
+		if !v.DeleteMarker {
+			record.Entry = &catalog.Entry{
+				Address:      v.PhysicalAddress,
+				LastModified: timestamppb.New(v.LastModified),
+				Size:         v.Size,
+				ETag:         v.Checksum,
+			}
+		}
+		changes = append(changes, record)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+
+	var base catalog.EntryIterator
+	if parentID != "" {
+		listIt, err := config.EntryCatalog.ListEntries(ctx, config.RepositoryID, graveler.Ref(parentID), "", "")
+		if err != nil {
+			return "", fmt.Errorf("listing commit: %w", err)
+		}
+		defer listIt.Close()
+		base = &listToEntryIterator{EntryListingIterator: listIt}
+	}
+	metaRangeID, err := config.EntryCatalog.WriteMetaRange(ctx, config.RepositoryID, newChangesIterator(base, changes))
+	if err != nil {
+		return "", fmt.Errorf("write meta range: %w", err)
+	}
+
+	until := versions[len(versions)-1].LastModified
+	commit := graveler.Commit{
+		Committer:    config.CommitUsername,
+		Message:      fmt.Sprintf(VersionsCommitMsgTemplate, source, until.UTC().Format(time.RFC3339)),
+		MetaRangeID:  *metaRangeID,
+		CreationDate: time.Now(),
+		Author:       config.CommitUsername,
+		AuthorDate:   until,
+		Metadata: graveler.Metadata{
+			VersionsSourceMetadataKey: source,
+			VersionsUntilMetadataKey:  until.UTC().Format(time.RFC3339Nano),
+			VersionsCountMetadataKey:  strconv.Itoa(len(versions)),
+		},
+	}
+	if parentID != "" {
+		commit.Parents = graveler.CommitParents{parentID}
+	}
+	commitID, err := config.EntryCatalog.AddCommitToBranchHead(ctx, config.RepositoryID, DefaultVersionsImportBranchName, commit)
+	if err != nil {
+		return "", fmt.Errorf("creating commit from existing metarange %s: %w", *metaRangeID, err)
+	}
+	return commitID, nil
+}
+
+// changesIterator applies changes sorted by path to the entries of base.  A change without an
+// entry deletes its path.
+type changesIterator struct {
+	base    catalog.EntryIterator
+	changes []*catalog.EntryRecord
+
+	baseValue *catalog.EntryRecord
+	value     *catalog.EntryRecord
+	err       error
+	started   bool
+}
+
+func newChangesIterator(base catalog.EntryIterator, changes []*catalog.EntryRecord) catalog.EntryIterator {
+	return &changesIterator{base: base, changes: changes}
+}
+
+func (ci *changesIterator) advanceBase() {
+	ci.baseValue = nil
+	if ci.base == nil {
+		return
+	}
+	if ci.base.Next() {
+		ci.baseValue = ci.base.Value()
+		return
+	}
+	ci.err = ci.base.Err()
+}
+
+func (ci *changesIterator) Next() bool {
+	if !ci.started {
+		ci.started = true
+		ci.advanceBase()
+	}
+	for ci.err == nil {
+		switch {
+		case ci.baseValue == nil && len(ci.changes) == 0:
+			ci.value = nil
+			return false
+
+		case len(ci.changes) == 0 || (ci.baseValue != nil && ci.baseValue.Path < ci.changes[0].Path):
+			ci.value = ci.baseValue
+			ci.advanceBase()
+			return ci.err == nil
+
+		default:
+			change := ci.changes[0]
+			ci.changes = ci.changes[1:]
+			if ci.baseValue != nil && ci.baseValue.Path == change.Path {
+				ci.advanceBase()
+			}
+			if change.Entry != nil {
+				ci.value = change
+				return true
+			}
+		}
+	}
+	ci.value = nil
+	return false
+}
+
+func (ci *changesIterator) SeekGE(_ catalog.Path) {
+	ci.err = ErrNotSeekable
+}
+
+func (ci *changesIterator) Value() *catalog.EntryRecord {
+	if ci.err != nil {
+		return nil
+	}
+	return ci.value
+}
+
+func (ci *changesIterator) Err() error {
+	return ci.err
+}
+
+func (ci *changesIterator) Close() {
+	if ci.base != nil {
+		ci.base.Close()
+	}
+}
@@ -0,0 +1,189 @@
+package onboard_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/catalog/testutils"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/logging"
+	"github.com/treeverse/lakefs/onboard"
+)
+
+type fakeVersionLister []*block.ObjectVersion
+
+func (f fakeVersionLister) ListVersions(_ context.Context, _, _ string, walkFn func(*block.ObjectVersion) error) error {
+	for _, v := range f {
+		if err := walkFn(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fakeVersionsCataloger keeps the entries of every commit in memory
+type fakeVersionsCataloger struct {
+	branches   map[graveler.BranchID]graveler.CommitID
+	commits    map[graveler.CommitID]*graveler.Commit
+	metaRanges map[graveler.MetaRangeID][]*catalog.EntryRecord
+}
+
+func newFakeVersionsCataloger(defaultEntries []*catalog.EntryRecord) *fakeVersionsCataloger {
+	f := &fakeVersionsCataloger{
+		branches:   map[graveler.BranchID]graveler.CommitID{"main": "c0"},
+		commits:    map[graveler.CommitID]*graveler.Commit{"c0": {MetaRangeID: "m0"}},
+		metaRanges: map[graveler.MetaRangeID][]*catalog.EntryRecord{"m0": defaultEntries},
+	}
+	return f
+}
+
+func (f *fakeVersionsCataloger) WriteMetaRange(_ context.Context, _ graveler.RepositoryID, it catalog.EntryIterator) (*graveler.MetaRangeID, error) {
+	var records []*catalog.EntryRecord
+	for it.Next() {
+		records = append(records, it.Value())
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	id := graveler.MetaRangeID("m" + strconv.Itoa(len(f.metaRanges)))
+	f.metaRanges[id] = records
+	return &id, nil
+}
+
+func (f *fakeVersionsCataloger) AddCommitToBranchHead(_ context.Context, _ graveler.RepositoryID, branchID graveler.BranchID, commit graveler.Commit) (graveler.CommitID, error) {
+	if len(commit.Parents) != 1 || commit.Parents[0] != f.branches[branchID] {
+		return "", graveler.ErrCommitNotHeadBranch
+	}
+	id := graveler.CommitID("c" + strconv.Itoa(len(f.commits)))
+	f.commits[id] = &commit
+	f.branches[branchID] = id
+	return id, nil
+}
+
+func (f *fakeVersionsCataloger) ListEntries(_ context.Context, _ graveler.RepositoryID, ref graveler.Ref, _, _ catalog.Path) (catalog.EntryListingIterator, error) {
+	commit, ok := f.commits[graveler.CommitID(ref)]
+	if !ok {
+		return nil, graveler.ErrNotFound
+	}
+	return catalog.NewEntryListingIterator(testutils.NewFakeEntryIterator(f.metaRanges[commit.MetaRangeID]), "", ""), nil
+}
+
+func (f *fakeVersionsCataloger) GetBranch(_ context.Context, _ graveler.RepositoryID, branchID graveler.BranchID) (*graveler.Branch, error) {
+	commitID, ok := f.branches[branchID]
+	if !ok {
+		return nil, graveler.ErrBranchNotFound
+	}
+	return &graveler.Branch{CommitID: commitID}, nil
+}
+
+func (f *fakeVersionsCataloger) CreateBranch(_ context.Context, _ graveler.RepositoryID, branchID graveler.BranchID, ref graveler.Ref) (*graveler.Branch, error) {
+	f.branches[branchID] = f.branches[graveler.BranchID(ref)]
+	return &graveler.Branch{CommitID: f.branches[branchID]}, nil
+}
+
+func (f *fakeVersionsCataloger) GetCommit(_ context.Context, _ graveler.RepositoryID, commitID graveler.CommitID) (*graveler.Commit, error) {
+	commit, ok := f.commits[commitID]
+	if !ok {
+		return nil, graveler.ErrNotFound
+	}
+	return commit, nil
+}
+
+// headAddresses returns the address of every path at the head of the versions import branch
+func (f *fakeVersionsCataloger) headAddresses() map[string]string {
+	commit := f.commits[f.branches[onboard.DefaultVersionsImportBranchName]]
+	res := make(map[string]string)
+	for _, record := range f.metaRanges[commit.MetaRangeID] {
+		res[record.Path.String()] = record.Address
+	}
+	return res
+}
+
+func TestImportVersions(t *testing.T) {
+	start := time.Date(2021, 1, 10, 0, 0, 0, 0, time.UTC)
+	version := func(key, id string, at time.Duration) *block.ObjectVersion {
+		return &block.ObjectVersion{Key: key, VersionID: id, LastModified: start.Add(at), PhysicalAddress: key + "@" + id}
+	}
+	deleted := func(key string, at time.Duration) *block.ObjectVersion {
+		return &block.ObjectVersion{Key: key, LastModified: start.Add(at), DeleteMarker: true}
+	}
+	// listed like S3 lists them: by key, newest first
+	lister := fakeVersionLister{
+		version("data/a", "a3", 50*time.Hour),
+		version("data/a", "a2", 2*time.Hour),
+		version("data/a", "a1", time.Hour),
+		deleted("data/b", 26*time.Hour),
+		version("data/b", "b1", time.Hour),
+		version("data/c", "c1", 25*time.Hour),
+	}
+	cataloger := newFakeVersionsCataloger([]*catalog.EntryRecord{
+		{Path: "data/b", Entry: &catalog.Entry{Address: "old-b"}},
+		{Path: "other", Entry: &catalog.Entry{Address: "other"}},
+	})
+	config := &onboard.VersionsConfig{
+		CommitUsername:  "lakefs",
+		Bucket:          "bucket",
+		Prefix:          "data/",
+		RepositoryID:    "repo",
+		DefaultBranchID: "main",
+		SliceDuration:   24 * time.Hour,
+		VersionLister:   lister,
+		EntryCatalog:    cataloger,
+	}
+	stats, err := onboard.ImportVersions(context.Background(), logging.Default(), config)
+	if err != nil {
+		t.Fatalf("ImportVersions() error = %s", err)
+	}
+	if diff := deep.Equal(stats, &onboard.VersionsStats{Versions: 6, Commits: 3, CommitRef: "c3"}); diff != nil {
+		t.Fatalf("ImportVersions() stats diff %s", diff)
+	}
+
+	expectedStates := []map[string]string{
+		{"data/a": "data/a@a2", "data/b": "data/b@b1", "other": "other"},
+		{"data/a": "data/a@a2", "data/c": "data/c@c1", "other": "other"},
+		{"data/a": "data/a@a3", "data/c": "data/c@c1", "other": "other"},
+	}
+	for i, expected := range expectedStates {
+		commit := cataloger.commits[graveler.CommitID("c"+strconv.Itoa(i+1))]
+		state := make(map[string]string)
+		for _, record := range cataloger.metaRanges[commit.MetaRangeID] {
+			state[record.Path.String()] = record.Address
+		}
+		if diff := deep.Equal(state, expected); diff != nil {
+			t.Errorf("commit %d state diff %s", i+1, diff)
+		}
+		if commit.Metadata[onboard.VersionsSourceMetadataKey] != "s3://bucket/data/" {
+			t.Errorf("commit %d source %s", i+1, commit.Metadata[onboard.VersionsSourceMetadataKey])
+		}
+	}
+	if until := cataloger.commits["c2"].AuthorDate; !until.Equal(start.Add(26 * time.Hour)) {
+		t.Errorf("second commit author date %s, expected the time of its last version", until)
+	}
+
+	// a second import continues after the imported versions
+	config.VersionLister = append(fakeVersionLister{version("data/d", "d1", 100*time.Hour)}, lister...)
+	stats, err = onboard.ImportVersions(context.Background(), logging.Default(), config)
+	if err != nil {
+		t.Fatalf("second ImportVersions() error = %s", err)
+	}
+	if diff := deep.Equal(stats, &onboard.VersionsStats{Versions: 1, Commits: 1, CommitRef: "c4"}); diff != nil {
+		t.Fatalf("second ImportVersions() stats diff %s", diff)
+	}
+	expected := map[string]string{"data/a": "data/a@a3", "data/c": "data/c@c1", "data/d": "data/d@d1", "other": "other"}
+	if diff := deep.Equal(cataloger.headAddresses(), expected); diff != nil {
+		t.Errorf("head state after second import diff %s", diff)
+	}
+}
+
+func TestImportVersions_InvalidSliceDuration(t *testing.T) {
+	_, err := onboard.ImportVersions(context.Background(), logging.Default(), &onboard.VersionsConfig{})
+	if !errors.Is(err, onboard.ErrInvalidSliceDuration) {
+		t.Errorf("ImportVersions() error = %v, expected %s", err, onboard.ErrInvalidSliceDuration)
+	}
+}
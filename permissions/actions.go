@@ -18,6 +18,7 @@ const (
 	ListRepositoriesAction = "fs:ListRepositories"
 	ReadObjectAction       = "fs:ReadObject"
 	WriteObjectAction      = "fs:WriteObject"
+	DecryptObjectAction    = "fs:DecryptObject"
 	DeleteObjectAction     = "fs:DeleteObject"
 	ListObjectsAction      = "fs:ListObjects"
 	CreateCommitAction     = "fs:CreateCommit"
@@ -32,6 +33,53 @@ const (
 	DeleteTagAction        = "fs:DeleteTag"
 	ReadTagAction          = "fs:ReadTag"
 	ListTagsAction         = "fs:ListTags"
+	ReadActionAction       = "fs:ReadAction"
+	WriteActionAction      = "fs:WriteAction"
+	DeleteActionAction     = "fs:DeleteAction"
+	ListActionsAction      = "fs:ListActions"
+	RunActionAction        = "fs:RunAction"
+	CreateSnapshotAction   = "fs:CreateSnapshot"
+	ReadSnapshotAction     = "fs:ReadSnapshot"
+	ListSnapshotsAction    = "fs:ListSnapshots"
+	DeleteSnapshotAction   = "fs:DeleteSnapshot"
+	RestoreSnapshotAction  = "fs:RestoreSnapshot"
+	CreateDatasetAction    = "fs:CreateDataset"
+	ReadDatasetAction      = "fs:ReadDataset"
+	ListDatasetsAction     = "fs:ListDatasets"
+	DeleteDatasetAction    = "fs:DeleteDataset"
+	RedactObjectAction     = "fs:RedactObject"
+	ListRedactionsAction   = "fs:ListRedactions"
+	CreateSandboxAction    = "fs:CreateSandbox"
+	CreatePathLeaseAction  = "fs:CreatePathLease"
+	// WriteOutsideSandboxAction is never required.  It is checked on writes to branches other than
+	// the sandbox of the user, so policies denying it confine users to their sandboxes.
+	WriteOutsideSandboxAction = "fs:WriteOutsideSandbox"
+	GetMergeGuardrailsAction  = "fs:GetMergeGuardrails"
+	SetMergeGuardrailsAction  = "fs:SetMergeGuardrails"
+	OverrideGuardrailsAction  = "fs:OverrideMergeGuardrails"
+	GetRangeSplitPolicyAction = "fs:GetRangeSplitPolicy"
+	SetRangeSplitPolicyAction = "fs:SetRangeSplitPolicy"
+	GetAutoCommitPolicyAction = "fs:GetAutoCommitPolicy"
+	SetAutoCommitPolicyAction = "fs:SetAutoCommitPolicy"
+	GetNotificationSinkAction = "fs:GetNotificationSink"
+	SetNotificationSinkAction = "fs:SetNotificationSink"
+	GetIngestStreamAction     = "fs:GetIngestStream"
+	SetIngestStreamAction     = "fs:SetIngestStream"
+	IngestRecordsAction       = "fs:IngestRecords"
+	GetSchemaAction           = "fs:GetSchema"
+	SetSchemaAction           = "fs:SetSchema"
+	SetCommitStatusAction     = "fs:SetCommitStatus"
+	AnnotateCommitAction      = "fs:AnnotateCommit"
+	SetCommitAuthorAction     = "fs:SetCommitAuthor"
+	SetRefLabelsAction        = "fs:SetRefLabels"
+	CreatePullRequestAction   = "fs:CreatePullRequest"
+	ApprovePullRequestAction  = "fs:ApprovePullRequest"
+	ListSecretsAction         = "fs:ListSecrets"
+	SetSecretAction           = "fs:SetSecret"
+	CancelJobAction           = "fs:CancelJob"
+	GetSettingsAction         = "fs:GetSettings"
+	SetSettingsAction         = "fs:SetSettings"
+	GenerateEntriesAction     = "fs:GenerateEntries"
 
 	ReadUserAction          = "auth:ReadUser"
 	CreateUserAction        = "auth:CreateUser"
@@ -54,7 +102,18 @@ const (
 	CreateCredentialsAction = "auth:CreateCredentials"
 	DeleteCredentialsAction = "auth:DeleteCredentials"
 	ListCredentialsAction   = "auth:ListCredentials"
+	RotateCredentialsAction = "auth:RotateCredentials"
+	SetNetworkPolicyAction  = "auth:SetNetworkPolicy"
 	ReadConfigAction        = "auth:ReadConfig"
+	ReloadConfigAction      = "auth:ReloadConfig"
+	ReadSettingsAction      = "auth:ReadSettings"
+	WriteSettingsAction     = "auth:WriteSettings"
+	ImpersonateUserAction   = "auth:ImpersonateUser"
+
+	GetRetentionRulesAction    = "retention:GetRetentionRules"
+	SetRetentionRulesAction    = "retention:SetRetentionRules"
+	RunGarbageCollectionAction = "retention:RunGarbageCollection"
+	PurgeHistoryAction         = "retention:PurgeHistory"
 )
 
 var serviceSet = map[string]struct{}{
@@ -1,5 +1,7 @@
 package permissions
 
+import "strings"
+
 const (
 	fSArnPrefix   = "arn:lakefs:fs:::"
 	authArnPrefix = "arn:lakefs:auth:::"
@@ -28,6 +30,32 @@ func TagArn(repoID, tagID string) string {
 	return fSArnPrefix + "repository/" + repoID + "/tag/" + tagID
 }
 
+// ArnRepository returns the repository of resource, the ARN of a repository or of a resource
+// in it
+func ArnRepository(resource string) (string, bool) {
+	const repoArnPrefix = fSArnPrefix + "repository/"
+	if !strings.HasPrefix(resource, repoArnPrefix) {
+		return "", false
+	}
+	repoID := strings.SplitN(strings.TrimPrefix(resource, repoArnPrefix), "/", 2)[0]
+	if repoID == "" || strings.Contains(repoID, All) {
+		return "", false
+	}
+	return repoID, true
+}
+
+func ActionArn(repoID, name string) string {
+	return fSArnPrefix + "repository/" + repoID + "/action/" + name
+}
+
+func SnapshotArn(repoID, snapshotID string) string {
+	return fSArnPrefix + "repository/" + repoID + "/snapshot/" + snapshotID
+}
+
+func DatasetArn(repoID, name string) string {
+	return fSArnPrefix + "repository/" + repoID + "/dataset/" + name
+}
+
 func UserArn(userID string) string {
 	return authArnPrefix + "user/" + userID
 }
@@ -22,6 +22,10 @@ type FS interface {
 
 	// Exists returns true if filename currently exists on block storage.
 	Exists(ctx context.Context, namespace, filename string) (bool, error)
+
+	// Remove deletes filename from the block storage.  A local copy is left to the eviction
+	// policy, callers must not open filename again.
+	Remove(ctx context.Context, namespace, filename string) error
 }
 
 // File is pyramid abstraction for an os.File
@@ -242,6 +242,16 @@ func (tfs *TierFS) Exists(ctx context.Context, namespace, filename string) (bool
 	return tfs.adapter.WithContext(ctx).Exists(tfs.objPointer(namespace, filename))
 }
 
+func (tfs *TierFS) Remove(ctx context.Context, namespace, filename string) error {
+	if err := validateFilename(filename); err != nil {
+		return err
+	}
+	if err := tfs.adapter.WithContext(ctx).Remove(tfs.objPointer(namespace, filename)); err != nil {
+		return fmt.Errorf("adapter remove %s: %w", filename, err)
+	}
+	return nil
+}
+
 // openFile converts an os.File to pyramid.ROFile and updates the eviction control.
 func (tfs *TierFS) openFile(ctx context.Context, fileRef localFileRef, fh *os.File) (*ROFile, error) {
 	stat, err := fh.Stat()
@@ -0,0 +1,39 @@
+package replica
+
+import (
+	"context"
+	"time"
+
+	"github.com/treeverse/lakefs/db"
+)
+
+// DBSource reports the replication state of a PostgreSQL metadata store
+type DBSource struct {
+	db db.Database
+}
+
+func NewDBSource(database db.Database) *DBSource {
+	return &DBSource{db: database}
+}
+
+func (s *DBSource) Lag(ctx context.Context) (time.Duration, error) {
+	return db.ReplicationLag(ctx, s.db)
+}
+
+func (s *DBSource) ReplayedLSN(ctx context.Context) (LSN, error) {
+	lsn, err := db.ReplayedLSN(ctx, s.db)
+	if err != nil {
+		return 0, err
+	}
+	return ParseLSN(lsn)
+}
+
+// CurrentLSN returns the position of the log of the primary, reads passing it as their
+// consistency token observe every write committed before
+func (s *DBSource) CurrentLSN(ctx context.Context) (LSN, error) {
+	lsn, err := db.CurrentLSN(ctx, s.db)
+	if err != nil {
+		return 0, err
+	}
+	return ParseLSN(lsn)
+}
@@ -0,0 +1,77 @@
+package replica
+
+import (
+	"net/http"
+	gohttputil "net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// LagHeader reports the replication lag in milliseconds of the metadata a read served by a
+	// replica observed
+	LagHeader = "X-Lakefs-Replica-Lag-Ms"
+	// ForwardedHeader marks requests forwarded by a replica, a replica receiving one is
+	// forwarding to itself or to another replica
+	ForwardedHeader = "X-Lakefs-Forwarded-By-Replica"
+)
+
+// localPaths are read from the replica regardless of its lag, they report on the instance
+var localPaths = []string{"/_health", "/metrics", "/_pprof/"}
+
+// Handler serves reads with next while monitor finds the replica fresh, and forwards all
+// other requests to the lakeFS at primaryURL.  Reads passing a consistency token the replica
+// did not replay yet are forwarded too.  Forwarded requests keep their Host header, so
+// S3 gateway requests remain valid for the same gateway domain on the primary.
+func Handler(primaryURL *url.URL, monitor *Monitor, next http.Handler) http.Handler {
+	proxy := gohttputil.NewSingleHostReverseProxy(primaryURL)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		read := isRead(r)
+		if read && isLocalPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		lag, fresh := monitor.Fresh()
+		if fresh && read && observesToken(r, monitor) {
+			w.Header().Set(LagHeader, strconv.FormatInt(lag.Milliseconds(), 10))
+			next.ServeHTTP(w, r)
+			return
+		}
+		if r.Header.Get(ForwardedHeader) != "" {
+			http.Error(w, "request forwarded to a replica, replica.primary_url must name the primary", http.StatusLoopDetected)
+			return
+		}
+		r.Header.Set(ForwardedHeader, "true")
+		proxy.ServeHTTP(w, r)
+	})
+}
+
+// observesToken returns true if reads from the replica observe the write that returned the
+// consistency token of r, or if r has none
+func observesToken(r *http.Request, monitor *Monitor) bool {
+	token := r.Header.Get(ConsistencyTokenHeader)
+	if token == "" {
+		return true
+	}
+	lsn, err := ParseLSN(token)
+	return err == nil && monitor.Replayed(r.Context(), lsn)
+}
+
+func isRead(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+func isLocalPath(path string) bool {
+	for _, p := range localPaths {
+		if path == p || strings.HasSuffix(p, "/") && strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+// Package replica lets lakeFS instances serve read-only traffic from a replica of the metadata
+// store.  Reads are served locally while the replica lags its primary by no more than a
+// bounded staleness, all other requests are forwarded to a lakeFS instance on the primary.
+package replica
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/treeverse/lakefs/logging"
+)
+
+const (
+	DefaultMaxStaleness  = 30 * time.Second
+	DefaultCheckInterval = 5 * time.Second
+)
+
+// Source reports the replication state of the replica
+type Source interface {
+	// Lag returns how far the replica lags behind its primary
+	Lag(ctx context.Context) (time.Duration, error)
+	// ReplayedLSN returns the position up to which the replica replayed the log of its primary
+	ReplayedLSN(ctx context.Context) (LSN, error)
+}
+
+// Monitor tracks the replication lag of the replica
+type Monitor struct {
+	source       Source
+	maxStaleness time.Duration
+	log          logging.Logger
+
+	mu       sync.RWMutex
+	lag      time.Duration
+	checked  bool
+	replayed LSN
+}
+
+func NewMonitor(source Source, maxStaleness time.Duration) *Monitor {
+	if maxStaleness <= 0 {
+		maxStaleness = DefaultMaxStaleness
+	}
+	return &Monitor{
+		source:       source,
+		maxStaleness: maxStaleness,
+		log:          logging.Default().WithField("service_name", "replica"),
+	}
+}
+
+// Run checks the lag every interval until ctx is done
+func (m *Monitor) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultCheckInterval
+	}
+	m.Check(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Check(ctx)
+		}
+	}
+}
+
+// Check measures the lag once.  The replica is stale until a check succeeds again after a
+// failed check.
+func (m *Monitor) Check(ctx context.Context) {
+	lag, err := m.source.Lag(ctx)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	wasFresh := m.freshLocked()
+	m.lag, m.checked = lag, err == nil
+	if err != nil {
+		if ctx.Err() == nil {
+			m.log.WithError(err).Warn("Failed to measure replication lag")
+		}
+		return
+	}
+	if fresh := m.freshLocked(); fresh != wasFresh {
+		m.log.WithFields(logging.Fields{"lag": lag, "max_staleness": m.maxStaleness, "fresh": fresh}).
+			Info("Replica freshness changed")
+	}
+}
+
+// Fresh returns the last lag measured, and whether reads may be served from the replica
+func (m *Monitor) Fresh() (time.Duration, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.lag, m.freshLocked()
+}
+
+func (m *Monitor) freshLocked() bool {
+	return m.checked && m.lag <= m.maxStaleness
+}
+
+// Replayed returns true if the replica replayed the log of its primary up to lsn, so reads
+// from it observe every write the primary committed before lsn
+func (m *Monitor) Replayed(ctx context.Context, lsn LSN) bool {
+	m.mu.RLock()
+	replayed := m.replayed
+	m.mu.RUnlock()
+	if lsn <= replayed {
+		return true
+	}
+	replayed, err := m.source.ReplayedLSN(ctx)
+	if err != nil {
+		m.log.WithError(err).Warn("Failed to read replayed log position")
+		return false
+	}
+	m.mu.Lock()
+	if replayed > m.replayed {
+		m.replayed = replayed
+	}
+	m.mu.Unlock()
+	return lsn <= replayed
+}
@@ -0,0 +1,219 @@
+package replica_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/treeverse/lakefs/replica"
+)
+
+var errLag = errors.New("lag unknown")
+
+type fakeSource struct {
+	lag      time.Duration
+	err      error
+	replayed replica.LSN
+	calls    int
+}
+
+func (s *fakeSource) Lag(context.Context) (time.Duration, error) {
+	return s.lag, s.err
+}
+
+func (s *fakeSource) ReplayedLSN(context.Context) (replica.LSN, error) {
+	s.calls++
+	return s.replayed, s.err
+}
+
+func lagMonitor(lag time.Duration, err error) *replica.Monitor {
+	m := replica.NewMonitor(&fakeSource{lag: lag, err: err, replayed: 0x100}, 10*time.Second)
+	m.Check(context.Background())
+	return m
+}
+
+func TestMonitor_Fresh(t *testing.T) {
+	cases := []struct {
+		name      string
+		lag       time.Duration
+		err       error
+		wantFresh bool
+	}{
+		{name: "caught up", lag: 0, wantFresh: true},
+		{name: "within staleness", lag: 10 * time.Second, wantFresh: true},
+		{name: "stale", lag: 11 * time.Second, wantFresh: false},
+		{name: "unknown", err: errLag, wantFresh: false},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, fresh := lagMonitor(tt.lag, tt.err).Fresh(); fresh != tt.wantFresh {
+				t.Errorf("got fresh %t, expected %t", fresh, tt.wantFresh)
+			}
+		})
+	}
+
+	t.Run("unchecked", func(t *testing.T) {
+		m := replica.NewMonitor(&fakeSource{}, time.Second)
+		if _, fresh := m.Fresh(); fresh {
+			t.Error("got fresh before any check")
+		}
+	})
+}
+
+func TestHandler(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("primary " + r.Header.Get(replica.ForwardedHeader)))
+	}))
+	defer primary.Close()
+	primaryURL, err := url.Parse(primary.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	local := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("replica"))
+	})
+
+	cases := []struct {
+		name      string
+		method    string
+		path      string
+		header    string
+		token     string
+		lag       time.Duration
+		err       error
+		wantCode  int
+		wantBody  string
+		wantLagMs string
+	}{
+		{name: "read", method: http.MethodGet, path: "/api/v1/repositories", lag: 1500 * time.Millisecond, wantCode: http.StatusOK, wantBody: "replica", wantLagMs: "1500"},
+		{name: "head", method: http.MethodHead, path: "/repo/main/file", wantCode: http.StatusOK, wantLagMs: "0"},
+		{name: "write", method: http.MethodPost, path: "/api/v1/repositories", wantCode: http.StatusOK, wantBody: "primary true"},
+		{name: "delete", method: http.MethodDelete, path: "/repo/main/file", wantCode: http.StatusOK, wantBody: "primary true"},
+		{name: "stale read", method: http.MethodGet, path: "/api/v1/repositories", lag: time.Minute, wantCode: http.StatusOK, wantBody: "primary true"},
+		{name: "unknown lag read", method: http.MethodGet, path: "/api/v1/repositories", err: errLag, wantCode: http.StatusOK, wantBody: "primary true"},
+		{name: "stale health", method: http.MethodGet, path: "/_health", lag: time.Minute, wantCode: http.StatusOK, wantBody: "replica"},
+		{name: "loop", method: http.MethodPut, path: "/repo/main/file", header: "true", wantCode: http.StatusLoopDetected},
+		{name: "replayed token", method: http.MethodGet, path: "/api/v1/repositories", token: "0/100", wantCode: http.StatusOK, wantBody: "replica", wantLagMs: "0"},
+		{name: "token ahead", method: http.MethodGet, path: "/api/v1/repositories", token: "0/101", wantCode: http.StatusOK, wantBody: "primary true"},
+		{name: "invalid token", method: http.MethodGet, path: "/api/v1/repositories", token: "latest", wantCode: http.StatusOK, wantBody: "primary true"},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			h := replica.Handler(primaryURL, lagMonitor(tt.lag, tt.err), local)
+			r := httptest.NewRequest(tt.method, tt.path, strings.NewReader("data"))
+			if tt.header != "" {
+				r.Header.Set(replica.ForwardedHeader, tt.header)
+			}
+			if tt.token != "" {
+				r.Header.Set(replica.ConsistencyTokenHeader, tt.token)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.wantCode {
+				t.Fatalf("got status %d, expected %d", w.Code, tt.wantCode)
+			}
+			body, _ := ioutil.ReadAll(w.Body)
+			if tt.wantBody != "" && tt.method != http.MethodHead && string(body) != tt.wantBody {
+				t.Errorf("got body %q, expected %q", body, tt.wantBody)
+			}
+			if lagMs := w.Header().Get(replica.LagHeader); lagMs != tt.wantLagMs {
+				t.Errorf("got lag header %q, expected %q", lagMs, tt.wantLagMs)
+			}
+		})
+	}
+}
+
+func TestMonitor_Replayed(t *testing.T) {
+	source := &fakeSource{replayed: 0x200}
+	m := replica.NewMonitor(source, time.Second)
+	ctx := context.Background()
+	if !m.Replayed(ctx, 0x200) {
+		t.Error("expected 0/200 replayed")
+	}
+	if !m.Replayed(ctx, 0x100) {
+		t.Error("expected 0/100 replayed")
+	}
+	if source.calls != 1 {
+		t.Errorf("read replayed position %d times, expected once", source.calls)
+	}
+	if m.Replayed(ctx, 0x201) {
+		t.Error("expected 0/201 not replayed")
+	}
+	source.replayed = 0x300
+	if !m.Replayed(ctx, 0x201) {
+		t.Error("expected 0/201 replayed after replica caught up")
+	}
+}
+
+func TestParseLSN(t *testing.T) {
+	cases := []struct {
+		s       string
+		want    replica.LSN
+		wantErr bool
+	}{
+		{s: "0/0", want: 0},
+		{s: "16/B374D848", want: 0x16B374D848},
+		{s: "FFFFFFFF/FFFFFFFF", want: 0xFFFFFFFFFFFFFFFF},
+		{s: "", wantErr: true},
+		{s: "16", wantErr: true},
+		{s: "16/B374D848/1", wantErr: true},
+		{s: "x/1", wantErr: true},
+		{s: "100000000/0", wantErr: true},
+	}
+	for _, tt := range cases {
+		t.Run(tt.s, func(t *testing.T) {
+			lsn, err := replica.ParseLSN(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got err %v, expected error %t", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, replica.ErrInvalidLSN) {
+					t.Errorf("got err %v, expected ErrInvalidLSN", err)
+				}
+				return
+			}
+			if lsn != tt.want {
+				t.Errorf("got %d, expected %d", lsn, tt.want)
+			}
+			if lsn.String() != tt.s {
+				t.Errorf("got string %s, expected %s", lsn, tt.s)
+			}
+		})
+	}
+}
+
+func TestTokenHandler(t *testing.T) {
+	currentLSN := func(context.Context) (replica.LSN, error) { return 0x16B374D848, nil }
+	cases := []struct {
+		name      string
+		method    string
+		status    int
+		wantToken string
+	}{
+		{name: "write", method: http.MethodPut, status: http.StatusCreated, wantToken: "16/B374D848"},
+		{name: "implicit status", method: http.MethodPost, wantToken: "16/B374D848"},
+		{name: "failed write", method: http.MethodDelete, status: http.StatusConflict},
+		{name: "read", method: http.MethodGet, status: http.StatusOK},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			h := replica.TokenHandler(currentLSN, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				_, _ = w.Write([]byte("done"))
+			}))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(tt.method, "/api/v1/repositories/repo/branches", nil))
+			if token := w.Header().Get(replica.ConsistencyTokenHeader); token != tt.wantToken {
+				t.Errorf("got token %q, expected %q", token, tt.wantToken)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package replica
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/treeverse/lakefs/logging"
+)
+
+// ConsistencyTokenHeader carries the consistency token returned by writes.  Reads passing it
+// observe the write, whichever instance or replica serves them.
+const ConsistencyTokenHeader = "X-Lakefs-Consistency-Token"
+
+var ErrInvalidLSN = errors.New("invalid log sequence number")
+
+// LSN is a position in the write-ahead log of the metadata store
+type LSN uint64
+
+// ParseLSN parses the textual form of a PostgreSQL log sequence number, e.g. "16/B374D848"
+func ParseLSN(s string) (LSN, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("%s: %w", s, ErrInvalidLSN)
+	}
+	hi, err := strconv.ParseUint(parts[0], 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", s, ErrInvalidLSN)
+	}
+	lo, err := strconv.ParseUint(parts[1], 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", s, ErrInvalidLSN)
+	}
+	return LSN(hi<<32 | lo), nil
+}
+
+func (l LSN) String() string {
+	return fmt.Sprintf("%X/%X", uint64(l)>>32, uint64(l)&0xFFFFFFFF)
+}
+
+// LSNFunc returns a position in the write-ahead log of the metadata store
+type LSNFunc func(ctx context.Context) (LSN, error)
+
+// TokenHandler returns the position of the log of the primary after successful writes served
+// by next as their consistency token
+func TokenHandler(currentLSN LSNFunc, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isRead(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(&tokenResponseWriter{ResponseWriter: w, r: r, currentLSN: currentLSN}, r)
+	})
+}
+
+// tokenResponseWriter sets the consistency token before the response status is written, after
+// the write it responds to committed
+type tokenResponseWriter struct {
+	http.ResponseWriter
+	r           *http.Request
+	currentLSN  LSNFunc
+	wroteHeader bool
+}
+
+func (w *tokenResponseWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if code < http.StatusBadRequest {
+			w.setToken()
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *tokenResponseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush sends buffered data to the client, streaming responses depend on it
+func (w *tokenResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *tokenResponseWriter) setToken() {
+	lsn, err := w.currentLSN(w.r.Context())
+	if err != nil {
+		logging.FromContext(w.r.Context()).WithError(err).Warn("Failed to read log position for consistency token")
+		return
+	}
+	w.Header().Set(ConsistencyTokenHeader, lsn.String())
+}
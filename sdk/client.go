@@ -0,0 +1,149 @@
+// Package sdk is a Go client library for lakeFS.  It wraps the generated API client with
+// helpers for iterating over paginated listings, transferring large objects through the
+// lakeFS S3 gateway using multipart uploads and parallel ranged downloads, and retrying
+// requests that failed on transient errors.
+//
+// The package follows semantic versioning independently of lakeFS releases, see Version.
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/treeverse/lakefs/api"
+)
+
+// Version is the version of the SDK.  Breaking changes to exported identifiers of this
+// package increment its major version.
+const Version = "1.0.0"
+
+const (
+	DefaultMaxRetries  = 3
+	DefaultRetryDelay  = 200 * time.Millisecond
+	DefaultPageSize    = 1000
+	DefaultPartSize    = 16 * 1024 * 1024
+	DefaultConcurrency = 5
+	DefaultRegion      = "us-east-1"
+
+	userAgent = "lakefs-go-sdk/" + Version
+)
+
+var ErrMissingEndpoint = errors.New("missing lakeFS endpoint")
+
+type Config struct {
+	// Endpoint is the URL of the lakeFS server, e.g. "https://lakefs.example.com"
+	Endpoint        string
+	AccessKeyID     string
+	SecretAccessKey string
+	// S3Endpoint is the URL of the lakeFS S3 gateway.  Uploads and downloads go through the
+	// API in a single request when it is empty.
+	S3Endpoint string
+	// Region is the region reported to the S3 gateway
+	Region string
+	// MaxRetries is the number of times idempotent requests are retried on transient errors,
+	// or a negative value to disable retries
+	MaxRetries int
+	RetryDelay time.Duration
+	// PageSize is the number of results fetched per request by iterators
+	PageSize int
+	// PartSize is the size of the parts of multipart uploads and of ranged downloads
+	PartSize int64
+	// Concurrency is the number of parts transferred in parallel
+	Concurrency int
+	// HTTPClient is used for all requests
+	HTTPClient *http.Client
+}
+
+// Client is a lakeFS client, offering all API operations of api.Client along with helpers.
+type Client struct {
+	api.Client
+	pageSize   int
+	uploader   *s3manager.Uploader
+	downloader *s3manager.Downloader
+}
+
+// userAgentTransport sets the User-Agent of requests
+type userAgentTransport struct {
+	next http.RoundTripper
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", userAgent)
+	return t.next.RoundTrip(req)
+}
+
+func NewClient(cfg Config) (*Client, error) {
+	if cfg.Endpoint == "" {
+		return nil, ErrMissingEndpoint
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	} else if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.RetryDelay == 0 {
+		cfg.RetryDelay = DefaultRetryDelay
+	}
+	if cfg.PartSize == 0 {
+		cfg.PartSize = DefaultPartSize
+	}
+	if cfg.Concurrency == 0 {
+		cfg.Concurrency = DefaultConcurrency
+	}
+	if cfg.Region == "" {
+		cfg.Region = DefaultRegion
+	}
+
+	var baseClient http.Client
+	if cfg.HTTPClient != nil {
+		baseClient = *cfg.HTTPClient
+	}
+	transport := baseClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	httpClient := baseClient
+	httpClient.Transport = &userAgentTransport{
+		next: newRetryTransport(transport, cfg.MaxRetries, cfg.RetryDelay),
+	}
+
+	apiClient, err := api.NewClient(cfg.Endpoint, cfg.AccessKeyID, cfg.SecretAccessKey, api.WithHTTPClient(&httpClient))
+	if err != nil {
+		return nil, fmt.Errorf("api client: %w", err)
+	}
+	client := &Client{
+		Client:   apiClient,
+		pageSize: cfg.PageSize,
+	}
+	if cfg.S3Endpoint != "" {
+		s3Client := baseClient
+		s3Client.Transport = &userAgentTransport{next: transport}
+		sess, err := session.NewSession(&aws.Config{
+			Endpoint:         aws.String(cfg.S3Endpoint),
+			Region:           aws.String(cfg.Region),
+			Credentials:      credentials.NewStaticCredentials(cfg.AccessKeyID, cfg.SecretAccessKey, ""),
+			S3ForcePathStyle: aws.Bool(true),
+			MaxRetries:       aws.Int(cfg.MaxRetries),
+			HTTPClient:       &s3Client,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("s3 session: %w", err)
+		}
+		client.uploader = s3manager.NewUploader(sess, func(u *s3manager.Uploader) {
+			u.PartSize = cfg.PartSize
+			u.Concurrency = cfg.Concurrency
+		})
+		client.downloader = s3manager.NewDownloader(sess, func(d *s3manager.Downloader) {
+			d.PartSize = cfg.PartSize
+			d.Concurrency = cfg.Concurrency
+		})
+	}
+	return client, nil
+}
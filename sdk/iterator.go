@@ -0,0 +1,186 @@
+package sdk
+
+import (
+	"context"
+
+	"github.com/go-openapi/swag"
+	"github.com/treeverse/lakefs/api/gen/models"
+)
+
+// pageFunc fetches the page of results following after, along with its pagination
+type pageFunc func(ctx context.Context, after string, amount int) (results []interface{}, pagination *models.Pagination, err error)
+
+// pager iterates over the results of a paginated API call, fetching pages as needed
+type pager struct {
+	ctx    context.Context
+	fetch  pageFunc
+	amount int
+	after  string
+	page   []interface{}
+	value  interface{}
+	done   bool
+	err    error
+}
+
+func newPager(ctx context.Context, amount int, fetch pageFunc) *pager {
+	if amount <= 0 {
+		amount = DefaultPageSize
+	}
+	return &pager{ctx: ctx, fetch: fetch, amount: amount}
+}
+
+func (p *pager) next() bool {
+	for len(p.page) == 0 {
+		if p.done || p.err != nil {
+			p.value = nil
+			return false
+		}
+		results, pagination, err := p.fetch(p.ctx, p.after, p.amount)
+		if err != nil {
+			p.err = err
+			p.value = nil
+			return false
+		}
+		p.page = results
+		if pagination == nil || !swag.BoolValue(pagination.HasMore) {
+			p.done = true
+		} else {
+			p.after = pagination.NextOffset
+		}
+	}
+	p.value = p.page[0]
+	p.page = p.page[1:]
+	return true
+}
+
+// RepositoryIterator iterates over repositories
+type RepositoryIterator struct{ p *pager }
+
+func (it *RepositoryIterator) Next() bool { return it.p.next() }
+
+func (it *RepositoryIterator) Value() *models.Repository {
+	v, _ := it.p.value.(*models.Repository)
+	return v
+}
+
+func (it *RepositoryIterator) Err() error { return it.p.err }
+
+// RefIterator iterates over branches or tags
+type RefIterator struct{ p *pager }
+
+func (it *RefIterator) Next() bool { return it.p.next() }
+
+func (it *RefIterator) Value() *models.Ref {
+	v, _ := it.p.value.(*models.Ref)
+	return v
+}
+
+func (it *RefIterator) Err() error { return it.p.err }
+
+// CommitIterator iterates over commits
+type CommitIterator struct{ p *pager }
+
+func (it *CommitIterator) Next() bool { return it.p.next() }
+
+func (it *CommitIterator) Value() *models.Commit {
+	v, _ := it.p.value.(*models.Commit)
+	return v
+}
+
+func (it *CommitIterator) Err() error { return it.p.err }
+
+// ObjectIterator iterates over objects
+type ObjectIterator struct{ p *pager }
+
+func (it *ObjectIterator) Next() bool { return it.p.next() }
+
+func (it *ObjectIterator) Value() *models.ObjectStats {
+	v, _ := it.p.value.(*models.ObjectStats)
+	return v
+}
+
+func (it *ObjectIterator) Err() error { return it.p.err }
+
+// DiffIterator iterates over differences between references
+type DiffIterator struct{ p *pager }
+
+func (it *DiffIterator) Next() bool { return it.p.next() }
+
+func (it *DiffIterator) Value() *models.Diff {
+	v, _ := it.p.value.(*models.Diff)
+	return v
+}
+
+func (it *DiffIterator) Err() error { return it.p.err }
+
+// IterateRepositories returns an iterator over all repositories
+func (c *Client) IterateRepositories(ctx context.Context) *RepositoryIterator {
+	return &RepositoryIterator{p: newPager(ctx, c.pageSize, func(ctx context.Context, after string, amount int) ([]interface{}, *models.Pagination, error) {
+		repos, pagination, err := c.ListRepositories(ctx, after, amount)
+		results := make([]interface{}, len(repos))
+		for i, repo := range repos {
+			results[i] = repo
+		}
+		return results, pagination, err
+	})}
+}
+
+// IterateBranches returns an iterator over the branches of repository
+func (c *Client) IterateBranches(ctx context.Context, repository string) *RefIterator {
+	return &RefIterator{p: newPager(ctx, c.pageSize, func(ctx context.Context, after string, amount int) ([]interface{}, *models.Pagination, error) {
+		refs, pagination, err := c.ListBranches(ctx, repository, after, amount)
+		return refResults(refs), pagination, err
+	})}
+}
+
+// IterateTags returns an iterator over the tags of repository
+func (c *Client) IterateTags(ctx context.Context, repository string) *RefIterator {
+	return &RefIterator{p: newPager(ctx, c.pageSize, func(ctx context.Context, after string, amount int) ([]interface{}, *models.Pagination, error) {
+		refs, pagination, err := c.ListTags(ctx, repository, after, amount)
+		return refResults(refs), pagination, err
+	})}
+}
+
+func refResults(refs []*models.Ref) []interface{} {
+	results := make([]interface{}, len(refs))
+	for i, ref := range refs {
+		results[i] = ref
+	}
+	return results
+}
+
+// IterateCommits returns an iterator over the commit log of branch, newest first
+func (c *Client) IterateCommits(ctx context.Context, repository, branch string, firstParent bool) *CommitIterator {
+	return &CommitIterator{p: newPager(ctx, c.pageSize, func(ctx context.Context, after string, amount int) ([]interface{}, *models.Pagination, error) {
+		commits, pagination, err := c.GetCommitLog(ctx, repository, branch, after, amount, firstParent)
+		results := make([]interface{}, len(commits))
+		for i, commit := range commits {
+			results[i] = commit
+		}
+		return results, pagination, err
+	})}
+}
+
+// IterateObjects returns an iterator over the objects under prefix in ref
+func (c *Client) IterateObjects(ctx context.Context, repository, ref, prefix string) *ObjectIterator {
+	return &ObjectIterator{p: newPager(ctx, c.pageSize, func(ctx context.Context, after string, amount int) ([]interface{}, *models.Pagination, error) {
+		objects, pagination, err := c.ListObjects(ctx, repository, ref, prefix, after, amount)
+		results := make([]interface{}, len(objects))
+		for i, object := range objects {
+			results[i] = object
+		}
+		return results, pagination, err
+	})}
+}
+
+// IterateDiff returns an iterator over the differences between leftRef and rightRef
+func (c *Client) IterateDiff(ctx context.Context, repository, leftRef, rightRef string) *DiffIterator {
+	return &DiffIterator{p: newPager(ctx, c.pageSize, func(ctx context.Context, after string, amount int) ([]interface{}, *models.Pagination, error) {
+		diffs, pagination, err := c.DiffRefs(ctx, repository, leftRef, rightRef, after, amount, nil)
+		results := make([]interface{}, len(diffs))
+		for i, diff := range diffs {
+			results[i] = diff
+		}
+		return results, pagination, err
+	})}
+}
@@ -0,0 +1,81 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/go-openapi/swag"
+	"github.com/treeverse/lakefs/api/gen/models"
+)
+
+var errFetch = errors.New("fetch failed")
+
+// fakePages returns a pageFunc serving values 0..total-1, failing at failAt if it is not
+// negative
+func fakePages(total, failAt int, calls *int) pageFunc {
+	return func(_ context.Context, after string, amount int) ([]interface{}, *models.Pagination, error) {
+		*calls++
+		start := 0
+		if after != "" {
+			n, _ := strconv.Atoi(after)
+			start = n + 1
+		}
+		var results []interface{}
+		for i := start; i < total && len(results) < amount; i++ {
+			if i == failAt {
+				return nil, nil, errFetch
+			}
+			results = append(results, i)
+		}
+		last := start + len(results) - 1
+		return results, &models.Pagination{
+			HasMore:    swag.Bool(last < total-1),
+			NextOffset: strconv.Itoa(last),
+		}, nil
+	}
+}
+
+func TestPager(t *testing.T) {
+	cases := []struct {
+		name      string
+		total     int
+		amount    int
+		failAt    int
+		wantCount int
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "empty", total: 0, amount: 10, failAt: -1, wantCount: 0, wantCalls: 1},
+		{name: "single page", total: 5, amount: 10, failAt: -1, wantCount: 5, wantCalls: 1},
+		{name: "multiple pages", total: 25, amount: 10, failAt: -1, wantCount: 25, wantCalls: 3},
+		{name: "exact pages", total: 20, amount: 10, failAt: -1, wantCount: 20, wantCalls: 2},
+		{name: "error", total: 25, amount: 10, failAt: 15, wantCount: 10, wantCalls: 2, wantErr: errFetch},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			p := newPager(context.Background(), tt.amount, fakePages(tt.total, tt.failAt, &calls))
+			count := 0
+			for p.next() {
+				if p.value.(int) != count {
+					t.Fatalf("value = %v, want %d", p.value, count)
+				}
+				count++
+			}
+			if !errors.Is(p.err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", p.err, tt.wantErr)
+			}
+			if count != tt.wantCount {
+				t.Errorf("count = %d, want %d", count, tt.wantCount)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if p.next() {
+				t.Error("next returned true after the iterator was exhausted")
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package sdk
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryTransport(t *testing.T) {
+	cases := []struct {
+		name         string
+		method       string
+		failures     int32
+		failStatus   int
+		wantStatus   int
+		wantAttempts int32
+	}{
+		{name: "success", method: http.MethodGet, failures: 0, failStatus: http.StatusServiceUnavailable, wantStatus: http.StatusOK, wantAttempts: 1},
+		{name: "retried", method: http.MethodGet, failures: 2, failStatus: http.StatusServiceUnavailable, wantStatus: http.StatusOK, wantAttempts: 3},
+		{name: "exhausted", method: http.MethodGet, failures: 5, failStatus: http.StatusBadGateway, wantStatus: http.StatusBadGateway, wantAttempts: 4},
+		{name: "not retryable status", method: http.MethodGet, failures: 1, failStatus: http.StatusInternalServerError, wantStatus: http.StatusInternalServerError, wantAttempts: 1},
+		{name: "not idempotent", method: http.MethodPost, failures: 1, failStatus: http.StatusServiceUnavailable, wantStatus: http.StatusServiceUnavailable, wantAttempts: 1},
+		{name: "put with body", method: http.MethodPut, failures: 1, failStatus: http.StatusTooManyRequests, wantStatus: http.StatusOK, wantAttempts: 2},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&attempts, 1)
+				if r.Method == http.MethodPut {
+					body, _ := ioutil.ReadAll(r.Body)
+					if string(body) != "data" {
+						t.Errorf("attempt %d got body %q", n, body)
+					}
+				}
+				if n <= tt.failures {
+					w.WriteHeader(tt.failStatus)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			client := &http.Client{Transport: newRetryTransport(nil, 3, time.Millisecond)}
+			req, err := http.NewRequest(tt.method, server.URL, strings.NewReader("data"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %s", err)
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := atomic.LoadInt32(&attempts); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/treeverse/lakefs/api/gen/models"
+)
+
+// Upload writes the contents of r to path on branch.  When an S3 gateway endpoint is
+// configured, large objects are uploaded in parts, in parallel.
+func (c *Client) Upload(ctx context.Context, repository, branch, path string, r io.Reader) (*models.ObjectStats, error) {
+	if c.uploader == nil {
+		return c.UploadObject(ctx, repository, branch, path, r)
+	}
+	_, err := c.uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket: aws.String(repository),
+		Key:    aws.String(branch + "/" + path),
+		Body:   r,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("upload %s: %w", path, err)
+	}
+	return c.StatObject(ctx, repository, branch, path)
+}
+
+// offsetWriter writes sequentially to an io.WriterAt
+type offsetWriter struct {
+	w      io.WriterAt
+	offset int64
+}
+
+func (o *offsetWriter) Write(p []byte) (int, error) {
+	n, err := o.w.WriteAt(p, o.offset)
+	o.offset += int64(n)
+	return n, err
+}
+
+// Download writes the contents of path in ref to w, returning the number of bytes written.
+// When an S3 gateway endpoint is configured, large objects are downloaded in ranges, in
+// parallel.
+func (c *Client) Download(ctx context.Context, repository, ref, path string, w io.WriterAt) (int64, error) {
+	if c.downloader == nil {
+		ow := &offsetWriter{w: w}
+		if _, err := c.GetObject(ctx, repository, ref, path, ow); err != nil {
+			return ow.offset, err
+		}
+		return ow.offset, nil
+	}
+	n, err := c.downloader.DownloadWithContext(ctx, w, &s3.GetObjectInput{
+		Bucket: aws.String(repository),
+		Key:    aws.String(ref + "/" + path),
+	})
+	if err != nil {
+		return n, fmt.Errorf("download %s: %w", path, err)
+	}
+	return n, nil
+}
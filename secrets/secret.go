@@ -0,0 +1,66 @@
+// Package secrets stores encrypted secrets of repositories, referenced by hook and notification
+// configurations instead of embedding tokens in them
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Secret describes a secret of a repository.  Its value is never returned once set.
+type Secret struct {
+	RepositoryID string    `db:"repository_id"`
+	Name         string    `db:"name"`
+	CreationDate time.Time `db:"creation_date"`
+	UpdateDate   time.Time `db:"update_date"`
+}
+
+// LookupFunc returns the value of the secret name
+type LookupFunc func(name string) (string, error)
+
+var (
+	reName      = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,127}$`)
+	reReference = regexp.MustCompile(`\$\{secrets\.([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+	ErrInvalidSecret  = errors.New("invalid secret")
+	ErrNoSecretLookup = errors.New("secret references not supported")
+)
+
+// ValidateName returns ErrInvalidSecret unless name is letters, digits and underscores, not
+// starting with a digit
+func ValidateName(name string) error {
+	if !reName.MatchString(name) {
+		return fmt.Errorf("name '%s': %w", name, ErrInvalidSecret)
+	}
+	return nil
+}
+
+// HasReferences returns true if s references secrets
+func HasReferences(s string) bool {
+	return reReference.MatchString(s)
+}
+
+// Expand replaces the references to secrets in s, written ${secrets.NAME}, with their values
+func Expand(s string, lookup LookupFunc) (string, error) {
+	if !HasReferences(s) {
+		return s, nil
+	}
+	if lookup == nil {
+		return "", ErrNoSecretLookup
+	}
+	var lookupErr error
+	expanded := reReference.ReplaceAllStringFunc(s, func(reference string) string {
+		name := reReference.FindStringSubmatch(reference)[1]
+		value, err := lookup(name)
+		if err != nil && lookupErr == nil {
+			lookupErr = fmt.Errorf("secret %s: %w", name, err)
+		}
+		return value
+	})
+	if lookupErr != nil {
+		return "", lookupErr
+	}
+	return expanded, nil
+}
@@ -0,0 +1,59 @@
+package secrets_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/treeverse/lakefs/secrets"
+)
+
+func TestExpand(t *testing.T) {
+	values := map[string]string{"TOKEN": "t0k3n", "HOOK_PATH": "services/T0/B0"}
+	lookup := func(name string) (string, error) {
+		value, ok := values[name]
+		if !ok {
+			return "", secrets.ErrSecretNotFound
+		}
+		return value, nil
+	}
+	tests := []struct {
+		name    string
+		value   string
+		lookup  secrets.LookupFunc
+		want    string
+		wantErr error
+	}{
+		{name: "no references", value: "https://example.com/hook", want: "https://example.com/hook"},
+		{name: "no references without lookup", value: "Bearer token", want: "Bearer token"},
+		{name: "reference", value: "Bearer ${secrets.TOKEN}", lookup: lookup, want: "Bearer t0k3n"},
+		{name: "references", value: "https://hooks.example.com/${secrets.HOOK_PATH}?t=${secrets.TOKEN}", lookup: lookup,
+			want: "https://hooks.example.com/services/T0/B0?t=t0k3n"},
+		{name: "not a reference", value: "${TOKEN} $secrets.TOKEN", lookup: lookup, want: "${TOKEN} $secrets.TOKEN"},
+		{name: "missing secret", value: "${secrets.OTHER}", lookup: lookup, wantErr: secrets.ErrSecretNotFound},
+		{name: "reference without lookup", value: "${secrets.TOKEN}", wantErr: secrets.ErrNoSecretLookup},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := secrets.Expand(tt.value, tt.lookup)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expand() error = %v, expected %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Expand() = '%s', expected '%s'", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	for _, name := range []string{"TOKEN", "slack_webhook", "_x1"} {
+		if err := secrets.ValidateName(name); err != nil {
+			t.Errorf("ValidateName(%s) error = %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "1TOKEN", "my-token", "a.b"} {
+		if err := secrets.ValidateName(name); !errors.Is(err, secrets.ErrInvalidSecret) {
+			t.Errorf("ValidateName(%s) error = %v, expected %v", name, err, secrets.ErrInvalidSecret)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/treeverse/lakefs/auth/crypt"
+	"github.com/treeverse/lakefs/db"
+)
+
+// Store manages the secrets of repositories, encrypting their values
+type Store interface {
+	// SetSecret creates or replaces the secret name of repositoryID
+	SetSecret(ctx context.Context, repositoryID, name, value string) error
+	// ListSecrets returns the secrets of repositoryID, ordered by name
+	ListSecrets(ctx context.Context, repositoryID string) ([]*Secret, error)
+	// DeleteSecret deletes the secret name of repositoryID
+	DeleteSecret(ctx context.Context, repositoryID, name string) error
+	// GetValue returns the decrypted value of the secret name of repositoryID
+	GetValue(ctx context.Context, repositoryID, name string) (string, error)
+}
+
+type store struct {
+	db     db.Database
+	crypts crypt.SecretStore
+}
+
+var ErrSecretNotFound = fmt.Errorf("secret %w", db.ErrNotFound)
+
+func NewStore(adb db.Database, crypts crypt.SecretStore) Store {
+	return &store{
+		db:     adb,
+		crypts: crypts,
+	}
+}
+
+// RepositoryLookup returns a LookupFunc of the secrets of repositoryID in store
+func RepositoryLookup(ctx context.Context, store Store, repositoryID string) LookupFunc {
+	return func(name string) (string, error) {
+		return store.GetValue(ctx, repositoryID, name)
+	}
+}
+
+func (s *store) SetSecret(ctx context.Context, repositoryID, name, value string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	encrypted, err := s.crypts.Encrypt([]byte(value))
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		now := time.Now().UTC()
+		return tx.Exec(`INSERT INTO repository_secrets (repository_id, name, encrypted_value, creation_date, update_date)
+			VALUES ($1, $2, $3, $4, $4)
+			ON CONFLICT (repository_id, name) DO UPDATE SET encrypted_value = EXCLUDED.encrypted_value,
+				update_date = EXCLUDED.update_date`,
+			repositoryID, name, encrypted, now)
+	}, db.WithContext(ctx))
+	return err
+}
+
+func (s *store) ListSecrets(ctx context.Context, repositoryID string) ([]*Secret, error) {
+	res, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var list []*Secret
+		err := tx.Select(&list, `SELECT repository_id, name, creation_date, update_date
+			FROM repository_secrets
+			WHERE repository_id = $1
+			ORDER BY name`,
+			repositoryID)
+		return list, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.([]*Secret), nil
+}
+
+func (s *store) DeleteSecret(ctx context.Context, repositoryID, name string) error {
+	_, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		res, err := tx.Exec(`DELETE FROM repository_secrets WHERE repository_id = $1 AND name = $2`,
+			repositoryID, name)
+		if err != nil {
+			return nil, err
+		}
+		if res.RowsAffected() == 0 {
+			return nil, ErrSecretNotFound
+		}
+		return nil, nil
+	}, db.WithContext(ctx))
+	return err
+}
+
+func (s *store) GetValue(ctx context.Context, repositoryID, name string) (string, error) {
+	res, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var encrypted []byte
+		err := tx.GetPrimitive(&encrypted, `SELECT encrypted_value FROM repository_secrets
+			WHERE repository_id = $1 AND name = $2`,
+			repositoryID, name)
+		return encrypted, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if errors.Is(err, db.ErrNotFound) {
+		return "", ErrSecretNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	value, err := s.crypts.Decrypt(res.([]byte))
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
@@ -0,0 +1,71 @@
+package settings
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+const (
+	// FeatureTreeUpgrade enables background upgrades of branch trees to the current tree format
+	FeatureTreeUpgrade = "tree_upgrade"
+	// FeatureGenerateEntries enables committing generated entries with no data, for load tests
+	FeatureGenerateEntries = "generate_entries"
+)
+
+// Features are the names of the subsystems enabled by feature flags, each with whether it is
+// enabled on repositories while its flag is not set
+var Features = map[string]bool{
+	FeatureTreeUpgrade:     true,
+	FeatureGenerateEntries: false,
+}
+
+const maxPercentage = 100
+
+// FeatureFlag enables a subsystem on all repositories, on a list of repositories or on a
+// percentage of the repositories, so it can be rolled out gradually
+type FeatureFlag struct {
+	Name string `db:"name"`
+	// Enabled enables the feature on all repositories
+	Enabled bool `db:"enabled"`
+	// Repositories the feature is enabled on
+	Repositories []string `db:"repositories"`
+	// Percentage of the repositories the feature is enabled on, chosen by a hash of the
+	// repository name.  Raising it keeps the feature enabled where it already was.
+	Percentage int       `db:"percentage"`
+	UpdatedBy  string    `db:"updated_by"`
+	UpdateDate time.Time `db:"update_date"`
+}
+
+// defaultFeatureFlag returns the flag of feature name while it is not set
+func defaultFeatureFlag(name string) *FeatureFlag {
+	return &FeatureFlag{Name: name, Enabled: Features[name]}
+}
+
+func (f *FeatureFlag) Validate() error {
+	if _, ok := Features[f.Name]; !ok {
+		return fmt.Errorf("%s: %w", f.Name, ErrUnknownFeature)
+	}
+	if f.Percentage < 0 || f.Percentage > maxPercentage {
+		return fmt.Errorf("percentage %d: %w", f.Percentage, ErrInvalidFeatureFlag)
+	}
+	return nil
+}
+
+// EnabledFor returns true if the feature is enabled on repository
+func (f *FeatureFlag) EnabledFor(repository string) bool {
+	if f.Enabled {
+		return true
+	}
+	for _, r := range f.Repositories {
+		if r == repository {
+			return true
+		}
+	}
+	if f.Percentage <= 0 {
+		return false
+	}
+	h := sha256.Sum256([]byte(f.Name + "\x00" + repository))
+	return int(binary.BigEndian.Uint64(h[:8])%maxPercentage) < f.Percentage
+}
@@ -0,0 +1,99 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Keys of the instance settings
+const (
+	KeyPublicRepositoriesAllowed = "public_repositories_allowed"
+	KeyMaxRepositories           = "max_repositories"
+	KeyDefaultRetentionDays      = "default_retention_days"
+)
+
+var (
+	ErrMaxRepositories    = errors.New("maximum number of repositories reached")
+	ErrPublicNotAllowed   = errors.New("public repositories are not allowed")
+	ErrUnknownFeature     = errors.New("unknown feature")
+	ErrInvalidFeatureFlag = errors.New("invalid feature flag")
+)
+
+// InstanceSettings apply to all the repositories of the installation
+type InstanceSettings struct {
+	// PublicRepositoriesAllowed is true to let repositories set public, readable by every user
+	PublicRepositoriesAllowed bool `json:"public_repositories_allowed"`
+	// MaxRepositories is the number of repositories that may be created, unlimited when 0
+	MaxRepositories int `json:"max_repositories"`
+	// DefaultRetentionDays are the days of commits kept by the retention rules of new
+	// repositories, which have no retention rules when 0
+	DefaultRetentionDays int `json:"default_retention_days"`
+}
+
+// InstanceSetting is the value set for an instance setting
+type InstanceSetting struct {
+	Key        string    `db:"key"`
+	Value      string    `db:"value"`
+	UpdatedBy  string    `db:"updated_by"`
+	UpdateDate time.Time `db:"update_date"`
+}
+
+// AuditEntry records a change of an instance setting or a feature flag
+type AuditEntry struct {
+	ID         int64     `db:"id"`
+	Key        string    `db:"key"`
+	Value      string    `db:"value"`
+	UpdatedBy  string    `db:"updated_by"`
+	UpdateDate time.Time `db:"update_date"`
+}
+
+// Validate returns an error if Key is not an instance setting or Value cannot be applied to it
+func (s *InstanceSetting) Validate() error {
+	var settings InstanceSettings
+	return settings.apply(s.Key, s.Value)
+}
+
+func (s *InstanceSettings) apply(key, value string) error {
+	switch key {
+	case KeyPublicRepositoriesAllowed:
+		allowed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s %q: %w", key, value, ErrInvalidValue)
+		}
+		s.PublicRepositoriesAllowed = allowed
+	case KeyMaxRepositories, KeyDefaultRetentionDays:
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("%s %q: %w", key, value, ErrInvalidValue)
+		}
+		if key == KeyMaxRepositories {
+			s.MaxRepositories = n
+		} else {
+			s.DefaultRetentionDays = n
+		}
+	default:
+		return fmt.Errorf("%s: %w", key, ErrUnknownKey)
+	}
+	return nil
+}
+
+// ApplyInstanceSettings returns the defaults with the values of stored applied.  Values that
+// are no longer valid, e.g. of settings since removed, are skipped.
+func ApplyInstanceSettings(stored []*InstanceSetting) *InstanceSettings {
+	var settings InstanceSettings
+	for _, setting := range stored {
+		_ = settings.apply(setting.Key, setting.Value)
+	}
+	return &settings
+}
+
+// CheckRepositoryCount returns ErrMaxRepositories if count repositories already reach the
+// maximum number of repositories
+func (s *InstanceSettings) CheckRepositoryCount(count int) error {
+	if s.MaxRepositories > 0 && count >= s.MaxRepositories {
+		return fmt.Errorf("%d repositories: %w", s.MaxRepositories, ErrMaxRepositories)
+	}
+	return nil
+}
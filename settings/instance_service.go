@@ -0,0 +1,115 @@
+package settings
+
+import (
+	"context"
+	"sort"
+
+	"github.com/treeverse/lakefs/cache"
+)
+
+const (
+	instanceSettingsCacheKey = "instance_settings"
+	featureFlagsCacheKey     = "feature_flags"
+)
+
+// InstanceService resolves the instance settings and feature flags
+type InstanceService struct {
+	store InstanceStore
+	cache cache.Cache
+}
+
+func NewInstanceService(store InstanceStore) *InstanceService {
+	return &InstanceService{
+		store: store,
+		cache: cache.NewCache(DefaultCacheSize, DefaultCacheExpiry, cache.NewJitterFn(DefaultCacheJitter)),
+	}
+}
+
+// Get returns the instance settings.  A nil InstanceService returns the defaults.
+func (s *InstanceService) Get(ctx context.Context) (*InstanceSettings, error) {
+	if s == nil {
+		return &InstanceSettings{}, nil
+	}
+	res, err := s.cache.GetOrSet(instanceSettingsCacheKey, func() (interface{}, error) {
+		return s.store.ListInstanceSettings(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ApplyInstanceSettings(res.([]*InstanceSetting)), nil
+}
+
+// ListInstanceSettings returns the instance settings that were set, read without caching
+func (s *InstanceService) ListInstanceSettings(ctx context.Context) ([]*InstanceSetting, error) {
+	return s.store.ListInstanceSettings(ctx)
+}
+
+// Set sets the instance setting key to value on behalf of updatedBy
+func (s *InstanceService) Set(ctx context.Context, key, value, updatedBy string) error {
+	return s.store.SetInstanceSetting(ctx, &InstanceSetting{Key: key, Value: value, UpdatedBy: updatedBy})
+}
+
+// FeatureFlags returns the flags of all the features, ordered by name, with the default of the
+// features whose flag is not set
+func (s *InstanceService) FeatureFlags(ctx context.Context) ([]*FeatureFlag, error) {
+	flags, err := s.store.ListFeatureFlags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return withDefaultFlags(flags), nil
+}
+
+func withDefaultFlags(flags []*FeatureFlag) []*FeatureFlag {
+	set := make(map[string]bool, len(flags))
+	results := make([]*FeatureFlag, 0, len(Features))
+	for _, flag := range flags {
+		// flags of removed features are left in the database
+		if _, ok := Features[flag.Name]; ok {
+			set[flag.Name] = true
+			results = append(results, flag)
+		}
+	}
+	for name := range Features {
+		if !set[name] {
+			results = append(results, defaultFeatureFlag(name))
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results
+}
+
+// FeatureEnabled returns true if feature name is enabled on repository.  A nil InstanceService
+// returns the default of the feature.
+func (s *InstanceService) FeatureEnabled(ctx context.Context, name, repository string) (bool, error) {
+	if s == nil {
+		return defaultFeatureFlag(name).EnabledFor(repository), nil
+	}
+	res, err := s.cache.GetOrSet(featureFlagsCacheKey, func() (interface{}, error) {
+		return s.store.ListFeatureFlags(ctx)
+	})
+	if err != nil {
+		return false, err
+	}
+	for _, flag := range res.([]*FeatureFlag) {
+		if flag.Name == name {
+			return flag.EnabledFor(repository), nil
+		}
+	}
+	return defaultFeatureFlag(name).EnabledFor(repository), nil
+}
+
+// SetFeatureFlag creates or replaces the flag of a feature
+func (s *InstanceService) SetFeatureFlag(ctx context.Context, flag *FeatureFlag) error {
+	return s.store.SetFeatureFlag(ctx, flag)
+}
+
+// DeleteFeatureFlag returns the flag of feature name to its default on behalf of deletedBy
+func (s *InstanceService) DeleteFeatureFlag(ctx context.Context, name, deletedBy string) error {
+	return s.store.DeleteFeatureFlag(ctx, name, deletedBy)
+}
+
+// ListAudit returns up to amount changes of instance settings and feature flags, newest first,
+// older than the change with ID after when it is not 0
+func (s *InstanceService) ListAudit(ctx context.Context, amount int, after int64) ([]*AuditEntry, bool, error) {
+	return s.store.ListAudit(ctx, amount, after)
+}
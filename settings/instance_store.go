@@ -0,0 +1,162 @@
+package settings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/treeverse/lakefs/db"
+)
+
+// auditFeaturePrefix prefixes the names of feature flags in the audit log
+const auditFeaturePrefix = "feature:"
+
+var ErrFeatureFlagNotFound = fmt.Errorf("feature flag %w", db.ErrNotFound)
+
+// InstanceStore manages the instance settings and feature flags, recording every change in an
+// audit log
+type InstanceStore interface {
+	// ListInstanceSettings returns the instance settings that were set, ordered by key
+	ListInstanceSettings(ctx context.Context) ([]*InstanceSetting, error)
+	// SetInstanceSetting creates or replaces the value of the setting with the same key
+	SetInstanceSetting(ctx context.Context, setting *InstanceSetting) error
+	// ListFeatureFlags returns the feature flags that were set, ordered by name
+	ListFeatureFlags(ctx context.Context) ([]*FeatureFlag, error)
+	// SetFeatureFlag creates or replaces the feature flag with the same name
+	SetFeatureFlag(ctx context.Context, flag *FeatureFlag) error
+	// DeleteFeatureFlag returns feature flag name to its default
+	DeleteFeatureFlag(ctx context.Context, name, deletedBy string) error
+	// ListAudit returns up to amount changes, newest first, older than the change with ID
+	// after when it is not 0.  It also returns true if there are more changes.
+	ListAudit(ctx context.Context, amount int, after int64) ([]*AuditEntry, bool, error)
+}
+
+type instanceStore struct {
+	db db.Database
+}
+
+func NewInstanceStore(adb db.Database) InstanceStore {
+	return &instanceStore{
+		db: adb,
+	}
+}
+
+func audit(tx db.Tx, key, value, updatedBy string, updateDate time.Time) error {
+	_, err := tx.Exec(`INSERT INTO instance_settings_audit (key, value, updated_by, update_date)
+		VALUES ($1, $2, $3, $4)`,
+		key, value, updatedBy, updateDate)
+	return err
+}
+
+func (s *instanceStore) ListInstanceSettings(ctx context.Context) ([]*InstanceSetting, error) {
+	res, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var settings []*InstanceSetting
+		err := tx.Select(&settings, `SELECT key, value, updated_by, update_date FROM instance_settings ORDER BY key`)
+		return settings, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.([]*InstanceSetting), nil
+}
+
+func (s *instanceStore) SetInstanceSetting(ctx context.Context, setting *InstanceSetting) error {
+	if err := setting.Validate(); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	_, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		_, err := tx.Exec(`INSERT INTO instance_settings (key, value, updated_by, update_date)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, updated_by = EXCLUDED.updated_by,
+				update_date = EXCLUDED.update_date`,
+			setting.Key, setting.Value, setting.UpdatedBy, now)
+		if err != nil {
+			return nil, err
+		}
+		return nil, audit(tx, setting.Key, setting.Value, setting.UpdatedBy, now)
+	}, db.WithContext(ctx))
+	return err
+}
+
+func (s *instanceStore) ListFeatureFlags(ctx context.Context) ([]*FeatureFlag, error) {
+	res, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var flags []*FeatureFlag
+		err := tx.Select(&flags, `SELECT name, enabled, repositories, percentage, updated_by, update_date
+			FROM feature_flags ORDER BY name`)
+		return flags, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.([]*FeatureFlag), nil
+}
+
+func (s *instanceStore) SetFeatureFlag(ctx context.Context, flag *FeatureFlag) error {
+	if err := flag.Validate(); err != nil {
+		return err
+	}
+	repositories := flag.Repositories
+	if repositories == nil {
+		repositories = []string{}
+	}
+	value, err := json.Marshal(struct {
+		Enabled      bool     `json:"enabled"`
+		Repositories []string `json:"repositories"`
+		Percentage   int      `json:"percentage"`
+	}{flag.Enabled, repositories, flag.Percentage})
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	_, err = s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		_, err := tx.Exec(`INSERT INTO feature_flags (name, enabled, repositories, percentage, updated_by, update_date)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (name) DO UPDATE SET enabled = EXCLUDED.enabled, repositories = EXCLUDED.repositories,
+				percentage = EXCLUDED.percentage, updated_by = EXCLUDED.updated_by, update_date = EXCLUDED.update_date`,
+			flag.Name, flag.Enabled, repositories, flag.Percentage, flag.UpdatedBy, now)
+		if err != nil {
+			return nil, err
+		}
+		return nil, audit(tx, auditFeaturePrefix+flag.Name, string(value), flag.UpdatedBy, now)
+	}, db.WithContext(ctx))
+	return err
+}
+
+func (s *instanceStore) DeleteFeatureFlag(ctx context.Context, name, deletedBy string) error {
+	_, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		res, err := tx.Exec(`DELETE FROM feature_flags WHERE name = $1`, name)
+		if err != nil {
+			return nil, err
+		}
+		if res.RowsAffected() == 0 {
+			return nil, ErrFeatureFlagNotFound
+		}
+		// an empty value records the return to the default
+		return nil, audit(tx, auditFeaturePrefix+name, "", deletedBy, time.Now().UTC())
+	}, db.WithContext(ctx))
+	return err
+}
+
+func (s *instanceStore) ListAudit(ctx context.Context, amount int, after int64) ([]*AuditEntry, bool, error) {
+	res, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var entries []*AuditEntry
+		err := tx.Select(&entries, `SELECT id, key, value, updated_by, update_date
+			FROM instance_settings_audit
+			WHERE $1 = 0 OR id < $1
+			ORDER BY id DESC
+			LIMIT $2`,
+			after, amount+1)
+		return entries, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return nil, false, err
+	}
+	entries := res.([]*AuditEntry)
+	hasMore := len(entries) > amount
+	if hasMore {
+		entries = entries[:amount]
+	}
+	return entries, hasMore, nil
+}
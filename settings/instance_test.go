@@ -0,0 +1,131 @@
+package settings_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/treeverse/lakefs/settings"
+)
+
+type fakeInstanceStore struct {
+	settings.InstanceStore
+	settings []*settings.InstanceSetting
+	flags    []*settings.FeatureFlag
+}
+
+func (s *fakeInstanceStore) ListInstanceSettings(context.Context) ([]*settings.InstanceSetting, error) {
+	return s.settings, nil
+}
+
+func (s *fakeInstanceStore) SetInstanceSetting(_ context.Context, setting *settings.InstanceSetting) error {
+	if err := setting.Validate(); err != nil {
+		return err
+	}
+	s.settings = append(s.settings, setting)
+	return nil
+}
+
+func (s *fakeInstanceStore) ListFeatureFlags(context.Context) ([]*settings.FeatureFlag, error) {
+	return s.flags, nil
+}
+
+func TestInstanceService_Get(t *testing.T) {
+	svc := settings.NewInstanceService(&fakeInstanceStore{})
+	ctx := context.Background()
+	if err := svc.Set(ctx, settings.KeyMaxRepositories, "2", "admin"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := svc.Set(ctx, settings.KeyPublicRepositoriesAllowed, "true", "admin"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := svc.Set(ctx, settings.KeyDefaultRetentionDays, "-3", "admin"); !errors.Is(err, settings.ErrInvalidValue) {
+		t.Errorf("Set() negative retention err=%v, expected %s", err, settings.ErrInvalidValue)
+	}
+	if err := svc.Set(ctx, "max_branches", "3", "admin"); !errors.Is(err, settings.ErrUnknownKey) {
+		t.Errorf("Set() unknown key err=%v, expected %s", err, settings.ErrUnknownKey)
+	}
+	s, err := svc.Get(ctx)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	expected := settings.InstanceSettings{PublicRepositoriesAllowed: true, MaxRepositories: 2}
+	if *s != expected {
+		t.Errorf("Get() = %+v, expected %+v", *s, expected)
+	}
+	if err := s.CheckRepositoryCount(1); err != nil {
+		t.Errorf("CheckRepositoryCount(1) error = %v", err)
+	}
+	if err := s.CheckRepositoryCount(2); !errors.Is(err, settings.ErrMaxRepositories) {
+		t.Errorf("CheckRepositoryCount(2) err=%v, expected %s", err, settings.ErrMaxRepositories)
+	}
+
+	var unset *settings.InstanceService
+	if s, err := unset.Get(ctx); err != nil || *s != (settings.InstanceSettings{}) {
+		t.Errorf("Get() of nil service = %+v, %v, expected defaults", s, err)
+	}
+}
+
+func TestInstanceService_FeatureEnabled(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeInstanceStore{}
+	svc := settings.NewInstanceService(store)
+	if enabled, err := svc.FeatureEnabled(ctx, settings.FeatureTreeUpgrade, "repo"); err != nil || !enabled {
+		t.Errorf("FeatureEnabled() unset = %t, %v, expected the default enabled", enabled, err)
+	}
+	if enabled, err := svc.FeatureEnabled(ctx, settings.FeatureGenerateEntries, "repo"); err != nil || enabled {
+		t.Errorf("FeatureEnabled(%s) unset = %t, %v, expected the default disabled", settings.FeatureGenerateEntries, enabled, err)
+	}
+	flags, err := svc.FeatureFlags(ctx)
+	if err != nil || len(flags) != len(settings.Features) {
+		t.Fatalf("FeatureFlags() = %v, %v, expected a flag per feature", flags, err)
+	}
+
+	store = &fakeInstanceStore{flags: []*settings.FeatureFlag{
+		{Name: settings.FeatureTreeUpgrade, Repositories: []string{"canary"}},
+	}}
+	svc = settings.NewInstanceService(store)
+	for repository, expected := range map[string]bool{"canary": true, "repo": false} {
+		if enabled, err := svc.FeatureEnabled(ctx, settings.FeatureTreeUpgrade, repository); err != nil || enabled != expected {
+			t.Errorf("FeatureEnabled(%s) = %t, %v, expected %t", repository, enabled, err, expected)
+		}
+	}
+}
+
+func TestFeatureFlag_Percentage(t *testing.T) {
+	const repositories = 1000
+	enabledAt := func(percentage int) map[string]bool {
+		flag := &settings.FeatureFlag{Name: settings.FeatureTreeUpgrade, Percentage: percentage}
+		enabled := make(map[string]bool)
+		for i := 0; i < repositories; i++ {
+			repository := fmt.Sprintf("repo-%d", i)
+			if flag.EnabledFor(repository) {
+				enabled[repository] = true
+			}
+		}
+		return enabled
+	}
+	ten, fifty := enabledAt(10), enabledAt(50)
+	if len(ten) < 50 || len(ten) > 150 {
+		t.Errorf("10%% enabled on %d of %d repositories", len(ten), repositories)
+	}
+	for repository := range ten {
+		if !fifty[repository] {
+			t.Errorf("%s enabled at 10%% but not at 50%%", repository)
+		}
+	}
+	if len(enabledAt(0)) != 0 || len(enabledAt(100)) != repositories {
+		t.Error("0% and 100% do not enable none and all of the repositories")
+	}
+
+	invalid := []*settings.FeatureFlag{
+		{Name: "time_travel"},
+		{Name: settings.FeatureTreeUpgrade, Percentage: 101},
+	}
+	for _, flag := range invalid {
+		if err := flag.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded", flag)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package settings
+
+import (
+	"context"
+	"time"
+
+	"github.com/treeverse/lakefs/cache"
+	"github.com/treeverse/lakefs/logging"
+)
+
+const (
+	// DefaultCacheExpiry is the time overrides are cached, changes to overrides may take that
+	// long to apply
+	DefaultCacheExpiry = 10 * time.Second
+	DefaultCacheSize   = 1024
+	DefaultCacheJitter = 2 * time.Second
+)
+
+// DefaultsFunc returns the settings of repositories without overrides.  It is called on every
+// lookup, so defaults read from the configuration follow its reloads.
+type DefaultsFunc func() Settings
+
+// Service resolves the settings of repositories
+type Service struct {
+	store    Store
+	defaults DefaultsFunc
+	cache    cache.Cache
+	log      logging.Logger
+}
+
+func NewService(store Store, defaults DefaultsFunc) *Service {
+	return &Service{
+		store:    store,
+		defaults: defaults,
+		cache:    cache.NewCache(DefaultCacheSize, DefaultCacheExpiry, cache.NewJitterFn(DefaultCacheJitter)),
+		log:      logging.Default().WithField("service_name", "settings"),
+	}
+}
+
+// Get returns the settings of repository, its overrides applied to the defaults.  A nil Service
+// returns settings that change nothing.
+func (s *Service) Get(ctx context.Context, repository string) (*Settings, error) {
+	if s == nil {
+		return &Settings{NotificationsEnabled: true}, nil
+	}
+	res, err := s.cache.GetOrSet(repository, func() (interface{}, error) {
+		return s.store.ListOverrides(ctx, repository)
+	})
+	if err != nil {
+		return nil, err
+	}
+	settings := s.defaults()
+	for _, override := range res.([]*Override) {
+		// overrides are validated when set, but may be left over from a setting since removed
+		if err := settings.apply(override.Key, override.Value); err != nil {
+			s.log.WithError(err).WithField("repository", repository).Warn("Ignoring invalid setting override")
+		}
+	}
+	return &settings, nil
+}
+
+// Defaults returns the settings of repositories without overrides
+func (s *Service) Defaults() Settings {
+	return s.defaults()
+}
+
+// ListOverrides returns the overrides of repository, ordered by key
+func (s *Service) ListOverrides(ctx context.Context, repository string) ([]*Override, error) {
+	return s.store.ListOverrides(ctx, repository)
+}
+
+// SetOverride sets the value of key for repository
+func (s *Service) SetOverride(ctx context.Context, repository, key, value string) error {
+	return s.store.SetOverride(ctx, &Override{RepositoryID: repository, Key: key, Value: value})
+}
+
+// DeleteOverride returns the setting key of repository to its default
+func (s *Service) DeleteOverride(ctx context.Context, repository, key string) error {
+	return s.store.DeleteOverride(ctx, repository, key)
+}
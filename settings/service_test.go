@@ -0,0 +1,100 @@
+package settings_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/treeverse/lakefs/settings"
+)
+
+type fakeStore struct {
+	overrides map[string][]*settings.Override
+}
+
+func (s *fakeStore) ListOverrides(_ context.Context, repositoryID string) ([]*settings.Override, error) {
+	return s.overrides[repositoryID], nil
+}
+
+func (s *fakeStore) SetOverride(_ context.Context, override *settings.Override) error {
+	if err := override.Validate(); err != nil {
+		return err
+	}
+	s.overrides[override.RepositoryID] = append(s.overrides[override.RepositoryID], override)
+	return nil
+}
+
+func (s *fakeStore) DeleteOverride(context.Context, string, string) error {
+	return settings.ErrOverrideNotFound
+}
+
+func TestService_Get(t *testing.T) {
+	store := &fakeStore{overrides: map[string][]*settings.Override{
+		"stale": {{RepositoryID: "stale", Key: "removed_setting", Value: "1"}},
+	}}
+	defaults := settings.Settings{StorageClass: "STANDARD", NotificationsEnabled: true}
+	svc := settings.NewService(store, func() settings.Settings { return defaults })
+	ctx := context.Background()
+
+	if err := svc.SetOverride(ctx, "archive", settings.KeyStorageClass, "GLACIER"); err != nil {
+		t.Fatalf("SetOverride() error = %v", err)
+	}
+	if err := svc.SetOverride(ctx, "archive", settings.KeyNotificationsEnabled, "false"); err != nil {
+		t.Fatalf("SetOverride() error = %v", err)
+	}
+	if err := svc.SetOverride(ctx, "archive", settings.KeyMaxObjectSize, "1024"); err != nil {
+		t.Fatalf("SetOverride() error = %v", err)
+	}
+	if err := svc.SetOverride(ctx, "archive", settings.KeyMaxObjectSize, "-1"); !errors.Is(err, settings.ErrInvalidValue) {
+		t.Errorf("SetOverride() negative size err=%v, expected %s", err, settings.ErrInvalidValue)
+	}
+	if err := svc.SetOverride(ctx, "archive", "hooks", "true"); !errors.Is(err, settings.ErrUnknownKey) {
+		t.Errorf("SetOverride() unknown key err=%v, expected %s", err, settings.ErrUnknownKey)
+	}
+
+	tests := []struct {
+		repository string
+		expected   settings.Settings
+	}{
+		{repository: "plain", expected: defaults},
+		{repository: "stale", expected: defaults},
+		{repository: "archive", expected: settings.Settings{StorageClass: "GLACIER", MaxObjectSize: 1024}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.repository, func(t *testing.T) {
+			s, err := svc.Get(ctx, tt.repository)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if *s != tt.expected {
+				t.Errorf("Get() = %+v, expected %+v", *s, tt.expected)
+			}
+		})
+	}
+
+	// defaults are read on every lookup
+	defaults.StorageClass = "STANDARD_IA"
+	if s, err := svc.Get(ctx, "plain"); err != nil || s.StorageClass != "STANDARD_IA" {
+		t.Errorf("Get() after defaults changed = %+v, %v, expected storage class STANDARD_IA", s, err)
+	}
+}
+
+func TestSettings_Apply(t *testing.T) {
+	s := settings.Settings{StorageClass: "GLACIER", MaxObjectSize: 10}
+	requested := "STANDARD"
+	if got := s.StorageClassOr(&requested); got == nil || *got != "STANDARD" {
+		t.Errorf("StorageClassOr(STANDARD) = %v, expected the requested class", got)
+	}
+	if got := s.StorageClassOr(nil); got == nil || *got != "GLACIER" {
+		t.Errorf("StorageClassOr(nil) = %v, expected the default class", got)
+	}
+	if got := (&settings.Settings{}).StorageClassOr(nil); got != nil {
+		t.Errorf("StorageClassOr(nil) without default = %v, expected nil", *got)
+	}
+	if s.TooLarge(10) || !s.TooLarge(11) {
+		t.Error("TooLarge() does not limit objects to 10 bytes")
+	}
+	if (&settings.Settings{}).TooLarge(1 << 40) {
+		t.Error("TooLarge() limits objects without a maximum size")
+	}
+}
@@ -0,0 +1,98 @@
+// Package settings resolves the settings applied to a repository: the defaults read from the
+// configuration, overridden per repository by values stored in the database.
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/treeverse/lakefs/db"
+)
+
+// Keys of the settings a repository may override
+const (
+	KeyStorageClass         = "storage_class"
+	KeyMaxObjectSize        = "max_object_size"
+	KeyNotificationsEnabled = "notifications_enabled"
+	KeyPublic               = "public"
+)
+
+var (
+	ErrUnknownKey       = errors.New("unknown setting")
+	ErrInvalidValue     = errors.New("invalid setting value")
+	ErrOverrideNotFound = fmt.Errorf("setting override %w", db.ErrNotFound)
+)
+
+// Settings apply to the objects and events of a repository
+type Settings struct {
+	// StorageClass is the storage class of objects uploaded without one, the underlying
+	// storage decides when empty
+	StorageClass string `json:"storage_class"`
+	// MaxObjectSize is the size in bytes of the largest object that may be uploaded, unlimited
+	// when 0
+	MaxObjectSize int64 `json:"max_object_size"`
+	// NotificationsEnabled is false to stop posting notifications of repository events
+	NotificationsEnabled bool `json:"notifications_enabled"`
+	// Public is true to let every user read the repository, while the instance settings allow
+	// public repositories
+	Public bool `json:"public"`
+}
+
+// Override replaces the value of a setting for a single repository
+type Override struct {
+	RepositoryID string    `db:"repository_id"`
+	Key          string    `db:"key"`
+	Value        string    `db:"value"`
+	UpdateDate   time.Time `db:"update_date"`
+}
+
+// Validate returns an error if Key is not a known setting or Value cannot be applied to it
+func (o *Override) Validate() error {
+	var s Settings
+	return s.apply(o.Key, o.Value)
+}
+
+func (s *Settings) apply(key, value string) error {
+	switch key {
+	case KeyStorageClass:
+		s.StorageClass = value
+	case KeyMaxObjectSize:
+		size, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || size < 0 {
+			return fmt.Errorf("%s %q: %w", key, value, ErrInvalidValue)
+		}
+		s.MaxObjectSize = size
+	case KeyNotificationsEnabled:
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s %q: %w", key, value, ErrInvalidValue)
+		}
+		s.NotificationsEnabled = enabled
+	case KeyPublic:
+		public, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s %q: %w", key, value, ErrInvalidValue)
+		}
+		s.Public = public
+	default:
+		return fmt.Errorf("%s: %w", key, ErrUnknownKey)
+	}
+	return nil
+}
+
+// StorageClassOr returns storageClass if it is set, otherwise the default storage class of the
+// repository, or nil if it has none
+func (s *Settings) StorageClassOr(storageClass *string) *string {
+	if storageClass != nil || s.StorageClass == "" {
+		return storageClass
+	}
+	defaultClass := s.StorageClass
+	return &defaultClass
+}
+
+// TooLarge returns true if an object of size bytes may not be uploaded
+func (s *Settings) TooLarge(size int64) bool {
+	return s.MaxObjectSize > 0 && size > s.MaxObjectSize
+}
@@ -0,0 +1,72 @@
+package settings
+
+import (
+	"context"
+	"time"
+
+	"github.com/treeverse/lakefs/db"
+)
+
+// Store manages the setting overrides of repositories
+type Store interface {
+	// ListOverrides returns the overrides of repositoryID, ordered by key
+	ListOverrides(ctx context.Context, repositoryID string) ([]*Override, error)
+	// SetOverride creates or replaces the override of the same repository and key
+	SetOverride(ctx context.Context, override *Override) error
+	// DeleteOverride deletes the override of key in repositoryID
+	DeleteOverride(ctx context.Context, repositoryID, key string) error
+}
+
+type store struct {
+	db db.Database
+}
+
+func NewStore(adb db.Database) Store {
+	return &store{
+		db: adb,
+	}
+}
+
+func (s *store) ListOverrides(ctx context.Context, repositoryID string) ([]*Override, error) {
+	res, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		var overrides []*Override
+		err := tx.Select(&overrides, `SELECT repository_id, key, value, update_date
+			FROM repository_settings
+			WHERE repository_id = $1
+			ORDER BY key`,
+			repositoryID)
+		return overrides, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.([]*Override), nil
+}
+
+func (s *store) SetOverride(ctx context.Context, override *Override) error {
+	if err := override.Validate(); err != nil {
+		return err
+	}
+	_, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		return tx.Exec(`INSERT INTO repository_settings (repository_id, key, value, update_date)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (repository_id, key) DO UPDATE SET value = EXCLUDED.value, update_date = EXCLUDED.update_date`,
+			override.RepositoryID, override.Key, override.Value, time.Now().UTC())
+	}, db.WithContext(ctx))
+	return err
+}
+
+func (s *store) DeleteOverride(ctx context.Context, repositoryID, key string) error {
+	_, err := s.db.Transact(func(tx db.Tx) (interface{}, error) {
+		res, err := tx.Exec(`DELETE FROM repository_settings WHERE repository_id = $1 AND key = $2`,
+			repositoryID, key)
+		if err != nil {
+			return nil, err
+		}
+		if res.RowsAffected() == 0 {
+			return nil, ErrOverrideNotFound
+		}
+		return nil, nil
+	}, db.WithContext(ctx))
+	return err
+}
@@ -0,0 +1,121 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/encryption"
+	"github.com/treeverse/lakefs/graveler"
+)
+
+// MaxAppendAttempts is the number of times AppendBlob composes an object whose entry changes
+// while appending to it
+const MaxAppendAttempts = 5
+
+var (
+	ErrAppendNotSupported = fmt.Errorf("append: %w", catalog.ErrFeatureNotSupported)
+	ErrAppendTooLarge     = errors.New("appended object too large")
+)
+
+type AppendParams struct {
+	Repository    *catalog.Repository
+	Branch        string
+	Path          string
+	Body          io.Reader
+	ContentLength int64
+	// MaxSize is the maximum size of the appended object, zero for no limit
+	MaxSize int64
+}
+
+// AppendBlob appends the body of params to the object of its path, creating the object if the
+// path has no entry, and returns the entry written.  The body is written as a blob of its own
+// and then composed with the current object on the object store.  The entry is written only
+// if it did not change since it was read, otherwise the object is composed again.
+func AppendBlob(ctx context.Context, cataloger catalog.Cataloger, adapter block.Adapter, encryptor *encryption.Encryptor, params AppendParams) (*catalog.DBEntry, error) {
+	repo := params.Repository
+	if _, ok := encryptor.KeyID(repo.Name, params.Path); ok {
+		// composing encrypted objects would need the data keys of both
+		return nil, fmt.Errorf("%w: path %s is encrypted", ErrAppendNotSupported, params.Path)
+	}
+	blob, err := WriteBlob(adapter, repo.StorageNamespace, params.Body, params.ContentLength, block.PutOpts{})
+	if err != nil {
+		return nil, err
+	}
+	for attempt := 0; attempt < MaxAppendAttempts; attempt++ {
+		entry, err := appendEntry(ctx, cataloger, adapter, params, blob)
+		if errors.Is(err, graveler.ErrPreconditionFailed) {
+			continue
+		}
+		if err != nil || entry.PhysicalAddress != blob.PhysicalAddress {
+			// the blob was not written as the object
+			_ = adapter.Remove(block.ObjectPointer{StorageNamespace: repo.StorageNamespace, Identifier: blob.PhysicalAddress})
+		}
+		return entry, err
+	}
+	_ = adapter.Remove(block.ObjectPointer{StorageNamespace: repo.StorageNamespace, Identifier: blob.PhysicalAddress})
+	return nil, fmt.Errorf("%s changed during %d attempts to append: %w", params.Path, MaxAppendAttempts, graveler.ErrPreconditionFailed)
+}
+
+// appendEntry writes the entry of the current object of the path of params followed by blob,
+// if the current entry does not change meanwhile
+func appendEntry(ctx context.Context, cataloger catalog.Cataloger, adapter block.Adapter, params AppendParams, blob *Blob) (*catalog.DBEntry, error) {
+	repo := params.Repository
+	current, err := cataloger.GetEntry(ctx, repo.Name, params.Branch, params.Path, catalog.GetEntryParams{NoFollowLinks: true})
+	if errors.Is(err, db.ErrNotFound) {
+		current = nil
+	} else if err != nil {
+		return nil, err
+	}
+	entry := catalog.DBEntry{
+		Path:            params.Path,
+		PhysicalAddress: blob.PhysicalAddress,
+		CreationDate:    time.Now(),
+		Size:            blob.Size,
+		Checksum:        blob.Checksum,
+	}
+	condition := catalog.EntryCondition{IfAbsent: true}
+	if current != nil {
+		if current.LinkTarget != "" {
+			return nil, fmt.Errorf("%w: path %s is a link", ErrAppendNotSupported, params.Path)
+		}
+		if encryption.IsEncrypted(current.Metadata) {
+			return nil, fmt.Errorf("%w: path %s is encrypted", ErrAppendNotSupported, params.Path)
+		}
+		entry.Size += current.Size
+		// the parts of the current object are not the parts of the appended object
+		entry.Metadata = make(catalog.Metadata, len(current.Metadata))
+		for k, v := range current.Metadata {
+			if k != catalog.MetadataKeyPartsCount {
+				entry.Metadata[k] = v
+			}
+		}
+		condition = catalog.EntryCondition{IfETagMatches: current.Checksum}
+	}
+	if params.MaxSize > 0 && entry.Size > params.MaxSize {
+		return nil, fmt.Errorf("%w: %d bytes", ErrAppendTooLarge, entry.Size)
+	}
+	if current != nil {
+		entry.PhysicalAddress = newPhysicalAddress()
+		entry.Checksum, err = block.Compose(adapter, block.ObjectPointer{StorageNamespace: repo.StorageNamespace, Identifier: entry.PhysicalAddress}, []block.ComposeSource{
+			{Obj: block.ObjectPointer{StorageNamespace: repo.StorageNamespace, Identifier: current.PhysicalAddress}, Size: current.Size},
+			{Obj: block.ObjectPointer{StorageNamespace: repo.StorageNamespace, Identifier: blob.PhysicalAddress}, Size: blob.Size},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = cataloger.CreateEntryIf(ctx, repo.Name, params.Branch, entry, condition)
+	if err != nil {
+		if entry.PhysicalAddress != blob.PhysicalAddress {
+			_ = adapter.Remove(block.ObjectPointer{StorageNamespace: repo.StorageNamespace, Identifier: entry.PhysicalAddress})
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
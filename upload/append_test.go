@@ -0,0 +1,136 @@
+package upload_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/block/mem"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/encryption"
+	"github.com/treeverse/lakefs/graveler"
+	"github.com/treeverse/lakefs/upload"
+)
+
+const appendPath = "logs/events.log"
+
+type fakeCataloger struct {
+	catalog.Cataloger
+	entry *catalog.DBEntry
+	// conflicts is the number of writes that fail their condition, as if the entry changed
+	conflicts int
+}
+
+func (c *fakeCataloger) GetEntry(_ context.Context, _, _, path string, _ catalog.GetEntryParams) (*catalog.DBEntry, error) {
+	if c.entry == nil || c.entry.Path != path {
+		return nil, db.ErrNotFound
+	}
+	entry := *c.entry
+	return &entry, nil
+}
+
+func (c *fakeCataloger) CreateEntryIf(_ context.Context, _, _ string, entry catalog.DBEntry, condition catalog.EntryCondition) error {
+	if c.conflicts > 0 {
+		c.conflicts--
+		return fmt.Errorf("entry changed: %w", graveler.ErrPreconditionFailed)
+	}
+	if condition.IfAbsent && c.entry != nil {
+		return fmt.Errorf("entry exists: %w", graveler.ErrPreconditionFailed)
+	}
+	if condition.IfETagMatches != "" && (c.entry == nil || c.entry.Checksum != condition.IfETagMatches) {
+		return fmt.Errorf("entry ETag: %w", graveler.ErrPreconditionFailed)
+	}
+	c.entry = &entry
+	return nil
+}
+
+func appendString(t *testing.T, cataloger catalog.Cataloger, adapter block.Adapter, encryptor *encryption.Encryptor, data string, maxSize int64) (*catalog.DBEntry, error) {
+	t.Helper()
+	return upload.AppendBlob(context.Background(), cataloger, adapter, encryptor, upload.AppendParams{
+		Repository:    &catalog.Repository{Name: "repo", StorageNamespace: "mem://repo"},
+		Branch:        "main",
+		Path:          appendPath,
+		Body:          strings.NewReader(data),
+		ContentLength: int64(len(data)),
+		MaxSize:       maxSize,
+	})
+}
+
+func readEntry(t *testing.T, adapter block.Adapter, entry *catalog.DBEntry) string {
+	t.Helper()
+	reader, err := adapter.Get(block.ObjectPointer{StorageNamespace: "mem://repo", Identifier: entry.PhysicalAddress}, entry.Size)
+	if err != nil {
+		t.Fatalf("read %s: %s", entry.PhysicalAddress, err)
+	}
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("read %s: %s", entry.PhysicalAddress, err)
+	}
+	return string(data)
+}
+
+func TestAppendBlob(t *testing.T) {
+	adapter := mem.New()
+	cataloger := &fakeCataloger{}
+
+	entry, err := appendString(t, cataloger, adapter, nil, "first line\n", 0)
+	if err != nil {
+		t.Fatalf("append to missing object: %s", err)
+	}
+	if data := readEntry(t, adapter, entry); data != "first line\n" {
+		t.Errorf("got object %q after first append", data)
+	}
+
+	cataloger.conflicts = 2
+	entry, err = appendString(t, cataloger, adapter, nil, "second line\n", 0)
+	if err != nil {
+		t.Fatalf("append to existing object: %s", err)
+	}
+	if data := readEntry(t, adapter, entry); data != "first line\nsecond line\n" {
+		t.Errorf("got object %q after second append", data)
+	}
+	if entry.Size != int64(len("first line\nsecond line\n")) {
+		t.Errorf("got entry size %d", entry.Size)
+	}
+	if cataloger.entry.PhysicalAddress != entry.PhysicalAddress {
+		t.Errorf("got entry address %s, expected %s", cataloger.entry.PhysicalAddress, entry.PhysicalAddress)
+	}
+}
+
+func TestAppendBlob_Conflicts(t *testing.T) {
+	cataloger := &fakeCataloger{conflicts: upload.MaxAppendAttempts}
+	_, err := appendString(t, cataloger, mem.New(), nil, "line\n", 0)
+	if !errors.Is(err, graveler.ErrPreconditionFailed) {
+		t.Errorf("got error %v, expected precondition failed", err)
+	}
+}
+
+func TestAppendBlob_TooLarge(t *testing.T) {
+	adapter := mem.New()
+	cataloger := &fakeCataloger{}
+	if _, err := appendString(t, cataloger, adapter, nil, "12345", 8); err != nil {
+		t.Fatalf("append to missing object: %s", err)
+	}
+	_, err := appendString(t, cataloger, adapter, nil, "6789", 8)
+	if !errors.Is(err, upload.ErrAppendTooLarge) {
+		t.Errorf("got error %v, expected too large", err)
+	}
+}
+
+func TestAppendBlob_Encrypted(t *testing.T) {
+	keys, err := encryption.NewLocalKeyManager(map[string][]byte{"key": bytes.Repeat([]byte("k"), 32)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryptor := encryption.NewEncryptor(keys, []encryption.Rule{{Prefix: "logs/", KeyID: "key"}})
+	_, err = appendString(t, &fakeCataloger{}, mem.New(), encryptor, "line\n", 0)
+	if !errors.Is(err, catalog.ErrFeatureNotSupported) {
+		t.Errorf("got error %v, expected not supported", err)
+	}
+}
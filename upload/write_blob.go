@@ -1,28 +1,51 @@
 package upload
 
 import (
+	"context"
 	"encoding/hex"
 	"io"
 
 	"github.com/google/uuid"
 	"github.com/treeverse/lakefs/block"
+	"github.com/treeverse/lakefs/encryption"
 )
 
 type Blob struct {
 	PhysicalAddress string
 	Checksum        string
 	Size            int64
+	// Metadata is the entry metadata required to read the blob, set when it is encrypted
+	Metadata map[string]string
 }
 
 func WriteBlob(adapter block.Adapter, bucketName string, body io.Reader, contentLength int64, opts block.PutOpts) (*Blob, error) {
 	// handle the upload itself
 	hashReader := block.NewHashingReader(body, block.HashFunctionMD5, block.HashFunctionSHA256)
+	return writeBlob(adapter, bucketName, hashReader, hashReader, contentLength, opts, nil)
+}
+
+// WriteEncryptedBlob is WriteBlob encrypting body if encryptor encrypts the objects written to
+// path in repository.  The checksum and size of the blob are those of the unencrypted body.
+func WriteEncryptedBlob(ctx context.Context, encryptor *encryption.Encryptor, repository, path string, adapter block.Adapter, bucketName string, body io.Reader, contentLength int64, opts block.PutOpts) (*Blob, error) {
+	hashReader := block.NewHashingReader(body, block.HashFunctionMD5, block.HashFunctionSHA256)
+	data, metadata, err := encryptor.Encrypt(ctx, repository, path, hashReader)
+	if err != nil {
+		return nil, err
+	}
+	return writeBlob(adapter, bucketName, hashReader, data, contentLength, opts, metadata)
+}
+
+func newPhysicalAddress() string {
 	uid := uuid.New()
-	address := hex.EncodeToString(uid[:])
+	return hex.EncodeToString(uid[:])
+}
+
+func writeBlob(adapter block.Adapter, bucketName string, hashReader *block.HashingReader, data io.Reader, contentLength int64, opts block.PutOpts, metadata map[string]string) (*Blob, error) {
+	address := newPhysicalAddress()
 	err := adapter.Put(block.ObjectPointer{
 		StorageNamespace: bucketName,
 		Identifier:       address,
-	}, contentLength, hashReader, opts)
+	}, contentLength, data, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -31,5 +54,6 @@ func WriteBlob(adapter block.Adapter, bucketName string, body io.Reader, content
 		PhysicalAddress: address,
 		Checksum:        checksum,
 		Size:            hashReader.CopiedSize,
+		Metadata:        metadata,
 	}, nil
 }
@@ -0,0 +1,146 @@
+// Package usage attributes stored bytes and request traffic to the branches and top-level
+// prefixes of repositories, for charging back storage and traffic.
+package usage
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/treeverse/lakefs/db"
+	"github.com/treeverse/lakefs/events"
+	"github.com/treeverse/lakefs/gateway/operations"
+	"github.com/treeverse/lakefs/logging"
+)
+
+// DefaultFlushInterval is the interval at which recorded requests are written to the database
+const DefaultFlushInterval = time.Minute
+
+// TopPrefix returns the top-level prefix of path, including its delimiter, or "" for paths at
+// the root
+func TopPrefix(path string) string {
+	i := strings.Index(path, "/")
+	if i < 0 {
+		return ""
+	}
+	return path[:i+1]
+}
+
+type requestKey struct {
+	Repository string
+	Reference  string
+	Prefix     string
+	Day        time.Time
+}
+
+type requestCounts struct {
+	Requests int64
+	BytesIn  int64
+	BytesOut int64
+}
+
+// Recorder counts the S3 gateway requests on object paths by repository, reference, top-level
+// prefix and day, writing the counts to the database every flush
+type Recorder struct {
+	db      db.Database
+	mu      sync.Mutex
+	pending map[requestKey]*requestCounts
+	log     logging.Logger
+}
+
+func NewRecorder(adb db.Database) *Recorder {
+	return &Recorder{
+		db:      adb,
+		pending: make(map[requestKey]*requestCounts),
+		log:     logging.Default().WithField("service_name", "usage_recorder"),
+	}
+}
+
+// Subscribe records the requests published on bus until the returned function is called
+func (r *Recorder) Subscribe(bus *events.Bus) func() {
+	return bus.Subscribe(operations.TopicObjectRequest, r.handle)
+}
+
+func (r *Recorder) handle(e events.Event) {
+	req, ok := e.Payload.(operations.ObjectRequestEvent)
+	if !ok {
+		return
+	}
+	key := requestKey{
+		Repository: req.Repository,
+		Reference:  req.Reference,
+		Prefix:     TopPrefix(req.Path),
+		Day:        e.Time.UTC().Truncate(24 * time.Hour),
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counts := r.pending[key]
+	if counts == nil {
+		counts = &requestCounts{}
+		r.pending[key] = counts
+	}
+	counts.Requests++
+	counts.BytesIn += req.BytesIn
+	counts.BytesOut += req.BytesOut
+}
+
+// Run flushes the recorded requests every interval until ctx is done, then flushes them once
+// more
+func (r *Recorder) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := r.Flush(context.Background()); err != nil {
+				r.log.WithError(err).Error("Failed to flush request usage")
+			}
+			return
+		case <-ticker.C:
+			if err := r.Flush(ctx); err != nil {
+				r.log.WithError(err).Error("Failed to flush request usage")
+			}
+		}
+	}
+}
+
+// Flush adds the requests recorded since the last flush to the database.  Requests that fail
+// to be written are kept for the next flush.
+func (r *Recorder) Flush(ctx context.Context) error {
+	r.mu.Lock()
+	pending := r.pending
+	r.pending = make(map[requestKey]*requestCounts)
+	r.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+	_, err := r.db.Transact(func(tx db.Tx) (interface{}, error) {
+		for key, counts := range pending {
+			_, err := tx.Exec(`INSERT INTO usage_requests (repository_id, reference, prefix, day, requests, bytes_in, bytes_out)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)
+				ON CONFLICT (repository_id, reference, prefix, day) DO UPDATE SET
+					requests = usage_requests.requests + EXCLUDED.requests,
+					bytes_in = usage_requests.bytes_in + EXCLUDED.bytes_in,
+					bytes_out = usage_requests.bytes_out + EXCLUDED.bytes_out`,
+				key.Repository, key.Reference, key.Prefix, key.Day, counts.Requests, counts.BytesIn, counts.BytesOut)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}, db.WithContext(ctx))
+	if err != nil {
+		r.mu.Lock()
+		for key, counts := range pending {
+			if c := r.pending[key]; c != nil {
+				counts.Requests += c.Requests
+				counts.BytesIn += c.BytesIn
+				counts.BytesOut += c.BytesOut
+			}
+			r.pending[key] = counts
+		}
+		r.mu.Unlock()
+	}
+	return err
+}
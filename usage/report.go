@@ -0,0 +1,166 @@
+package usage
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/db"
+)
+
+const listPageSize = 1000
+
+// Row is the usage attributed to a top-level prefix of a reference.  Stored objects are those of
+// the branch head, objects shared by several branches are counted in each.  Requests are the S3
+// gateway requests on paths under the prefix during the report period.
+type Row struct {
+	Repository string
+	Reference  string
+	Prefix     string
+	Objects    int64
+	Bytes      int64
+	Requests   int64
+	BytesIn    int64
+	BytesOut   int64
+}
+
+type rowKey struct {
+	Reference string
+	Prefix    string
+}
+
+// Report attributes the objects stored on the branches of repository, and the requests
+// recorded from since until until, to references and top-level prefixes
+func Report(ctx context.Context, cataloger catalog.Cataloger, adb db.Database, repository string, since, until time.Time) ([]*Row, error) {
+	rows := make(map[rowKey]*Row)
+	row := func(reference, prefix string) *Row {
+		key := rowKey{Reference: reference, Prefix: prefix}
+		r := rows[key]
+		if r == nil {
+			r = &Row{Repository: repository, Reference: reference, Prefix: prefix}
+			rows[key] = r
+		}
+		return r
+	}
+	if err := addStorage(ctx, cataloger, repository, row); err != nil {
+		return nil, err
+	}
+	requests, err := listRequests(ctx, adb, repository, since, until)
+	if err != nil {
+		return nil, err
+	}
+	for _, req := range requests {
+		r := row(req.Reference, req.Prefix)
+		r.Requests = req.Requests
+		r.BytesIn = req.BytesIn
+		r.BytesOut = req.BytesOut
+	}
+
+	result := make([]*Row, 0, len(rows))
+	for _, r := range rows {
+		result = append(result, r)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Reference != result[j].Reference {
+			return result[i].Reference < result[j].Reference
+		}
+		return result[i].Prefix < result[j].Prefix
+	})
+	return result, nil
+}
+
+// addStorage adds the objects of every branch of repository to their rows
+func addStorage(ctx context.Context, cataloger catalog.Cataloger, repository string, row func(reference, prefix string) *Row) error {
+	var afterBranch string
+	for {
+		branches, hasMore, err := cataloger.ListBranches(ctx, repository, "", listPageSize, afterBranch)
+		if err != nil {
+			return err
+		}
+		for _, branch := range branches {
+			if err := addBranchStorage(ctx, cataloger, repository, branch.Name, row); err != nil {
+				return err
+			}
+		}
+		if !hasMore || len(branches) == 0 {
+			return nil
+		}
+		afterBranch = branches[len(branches)-1].Name
+	}
+}
+
+func addBranchStorage(ctx context.Context, cataloger catalog.Cataloger, repository, branch string, row func(reference, prefix string) *Row) error {
+	var after string
+	for {
+		entries, hasMore, err := cataloger.ListEntries(ctx, repository, branch, "", after, "", listPageSize)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if entry.Expired {
+				continue
+			}
+			r := row(branch, TopPrefix(entry.Path))
+			r.Objects++
+			r.Bytes += entry.Size
+		}
+		if !hasMore || len(entries) == 0 {
+			return nil
+		}
+		after = entries[len(entries)-1].Path
+	}
+}
+
+type requestRow struct {
+	Reference string `db:"reference"`
+	Prefix    string `db:"prefix"`
+	Requests  int64  `db:"requests"`
+	BytesIn   int64  `db:"bytes_in"`
+	BytesOut  int64  `db:"bytes_out"`
+}
+
+func listRequests(ctx context.Context, adb db.Database, repository string, since, until time.Time) ([]*requestRow, error) {
+	res, err := adb.Transact(func(tx db.Tx) (interface{}, error) {
+		var requests []*requestRow
+		err := tx.Select(&requests, `SELECT reference, prefix, SUM(requests)::bigint AS requests,
+				SUM(bytes_in)::bigint AS bytes_in, SUM(bytes_out)::bigint AS bytes_out
+			FROM usage_requests
+			WHERE repository_id = $1 AND day >= $2 AND day < $3
+			GROUP BY reference, prefix`,
+			repository, since.UTC().Truncate(24*time.Hour), until.UTC())
+		return requests, err
+	}, db.ReadOnly(), db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return res.([]*requestRow), nil
+}
+
+// WriteCSV writes rows as CSV with a header line
+func WriteCSV(w io.Writer, rows []*Row) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"repository", "reference", "prefix", "objects", "bytes", "requests", "bytes_in", "bytes_out"}); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		record := []string{
+			r.Repository,
+			r.Reference,
+			r.Prefix,
+			strconv.FormatInt(r.Objects, 10),
+			strconv.FormatInt(r.Bytes, 10),
+			strconv.FormatInt(r.Requests, 10),
+			strconv.FormatInt(r.BytesIn, 10),
+			strconv.FormatInt(r.BytesOut, 10),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,145 @@
+package usage
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/treeverse/lakefs/catalog"
+	"github.com/treeverse/lakefs/events"
+	"github.com/treeverse/lakefs/gateway/operations"
+)
+
+type fakeCataloger struct {
+	catalog.Cataloger
+	branches map[string][]*catalog.DBEntry
+}
+
+func (c *fakeCataloger) ListBranches(_ context.Context, _ string, _ string, limit int, after string) ([]*catalog.Branch, bool, error) {
+	var names []string
+	for name := range c.branches {
+		if name > after {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	hasMore := len(names) > limit
+	if hasMore {
+		names = names[:limit]
+	}
+	branches := make([]*catalog.Branch, len(names))
+	for i, name := range names {
+		branches[i] = &catalog.Branch{Name: name}
+	}
+	return branches, hasMore, nil
+}
+
+func (c *fakeCataloger) ListEntries(_ context.Context, _, reference string, _, after string, _ string, limit int) ([]*catalog.DBEntry, bool, error) {
+	var entries []*catalog.DBEntry
+	for _, entry := range c.branches[reference] {
+		if entry.Path > after {
+			entries = append(entries, entry)
+		}
+	}
+	hasMore := len(entries) > limit
+	if hasMore {
+		entries = entries[:limit]
+	}
+	return entries, hasMore, nil
+}
+
+func TestTopPrefix(t *testing.T) {
+	cases := map[string]string{
+		"file":          "",
+		"dir/file":      "dir/",
+		"dir/sub/file":  "dir/",
+		"/leading":      "/",
+		"dir/":          "dir/",
+		"":              "",
+		"a.b/c.parquet": "a.b/",
+	}
+	for path, expected := range cases {
+		if prefix := TopPrefix(path); prefix != expected {
+			t.Errorf("TopPrefix(%q) = %q, expected %q", path, prefix, expected)
+		}
+	}
+}
+
+func TestAddStorage(t *testing.T) {
+	cataloger := &fakeCataloger{branches: map[string][]*catalog.DBEntry{
+		"main": {
+			{Path: "README", Size: 10},
+			{Path: "raw/a", Size: 100},
+			{Path: "raw/b/c", Size: 200},
+			{Path: "tables/t", Size: 1000},
+			{Path: "tables/x", Size: 5000, Expired: true},
+		},
+		"dev": {
+			{Path: "raw/a", Size: 100},
+		},
+	}}
+	rows := make(map[rowKey]*Row)
+	row := func(reference, prefix string) *Row {
+		key := rowKey{Reference: reference, Prefix: prefix}
+		if rows[key] == nil {
+			rows[key] = &Row{Repository: "repo", Reference: reference, Prefix: prefix}
+		}
+		return rows[key]
+	}
+	if err := addStorage(context.Background(), cataloger, "repo", row); err != nil {
+		t.Fatalf("addStorage: %s", err)
+	}
+	expected := map[rowKey]*Row{
+		{Reference: "main", Prefix: ""}:        {Repository: "repo", Reference: "main", Prefix: "", Objects: 1, Bytes: 10},
+		{Reference: "main", Prefix: "raw/"}:    {Repository: "repo", Reference: "main", Prefix: "raw/", Objects: 2, Bytes: 300},
+		{Reference: "main", Prefix: "tables/"}: {Repository: "repo", Reference: "main", Prefix: "tables/", Objects: 1, Bytes: 1000},
+		{Reference: "dev", Prefix: "raw/"}:     {Repository: "repo", Reference: "dev", Prefix: "raw/", Objects: 1, Bytes: 100},
+	}
+	if diff := deep.Equal(rows, expected); diff != nil {
+		t.Errorf("addStorage rows differ: %s", diff)
+	}
+}
+
+func TestRecorder_Handle(t *testing.T) {
+	r := NewRecorder(nil)
+	day := time.Date(2021, 3, 4, 15, 0, 0, 0, time.UTC)
+	for _, req := range []operations.ObjectRequestEvent{
+		{Repository: "repo", Reference: "main", Path: "raw/a", BytesIn: 10},
+		{Repository: "repo", Reference: "main", Path: "raw/b/c", BytesOut: 20},
+		{Repository: "repo", Reference: "dev", Path: "raw/a", BytesOut: 5},
+	} {
+		r.handle(events.Event{Topic: operations.TopicObjectRequest, Time: day, Payload: req})
+	}
+	midnight := time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)
+	expected := map[requestKey]*requestCounts{
+		{Repository: "repo", Reference: "main", Prefix: "raw/", Day: midnight}: {Requests: 2, BytesIn: 10, BytesOut: 20},
+		{Repository: "repo", Reference: "dev", Prefix: "raw/", Day: midnight}:  {Requests: 1, BytesOut: 5},
+	}
+	if diff := deep.Equal(r.pending, expected); diff != nil {
+		t.Errorf("pending requests differ: %s", diff)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteCSV(&buf, []*Row{
+		{Repository: "repo", Reference: "main", Prefix: "raw/", Objects: 2, Bytes: 300, Requests: 7, BytesIn: 10, BytesOut: 20},
+		{Repository: "repo", Reference: "main", Prefix: "a,b/"},
+	})
+	if err != nil {
+		t.Fatalf("WriteCSV: %s", err)
+	}
+	expected := strings.Join([]string{
+		"repository,reference,prefix,objects,bytes,requests,bytes_in,bytes_out",
+		"repo,main,raw/,2,300,7,10,20",
+		`repo,main,"a,b/",0,0,0,0,0`,
+		"",
+	}, "\n")
+	if buf.String() != expected {
+		t.Errorf("WriteCSV wrote\n%s\nexpected\n%s", buf.String(), expected)
+	}
+}